@@ -0,0 +1,145 @@
+package saucer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// archiveEpoch is the default timestamp of archive entries. It is the
+// earliest time representable in zip archives.
+var archiveEpoch = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveOptions is the configuration assembled from ArchiveOptions.
+type archiveOptions struct {
+	prefix  string
+	modTime time.Time
+	skip    []string
+}
+
+// ArchiveOption configures WriteTar and WriteZip.
+type ArchiveOption func(*archiveOptions)
+
+// WithPrefix places every entry below the slash-separated directory prefix.
+func WithPrefix(prefix string) ArchiveOption {
+	return func(o *archiveOptions) { o.prefix = prefix }
+}
+
+// WithModTime sets the timestamp of every entry, 1980-01-01 UTC by default.
+// It is truncated to whole seconds.
+func WithModTime(t time.Time) ArchiveOption {
+	return func(o *archiveOptions) { o.modTime = t }
+}
+
+// WithSkip omits entries matching the path.Match patterns, like
+// ExtractOptions.Skip.
+func WithSkip(patterns ...string) ArchiveOption {
+	return func(o *archiveOptions) { o.skip = append(o.skip, patterns...) }
+}
+
+// WriteTar streams the tree of SourceFS to w as an uncompressed tar
+// archive. Entries are sorted and carry fixed timestamps, modes and owners,
+// so the output is byte-identical for identical sources and options.
+func WriteTar(w io.Writer, opts ...ArchiveOption) error {
+	return WriteTarFS(w, source, opts...)
+}
+
+// WriteTarFS is like WriteTar but archives src.
+func WriteTarFS(w io.Writer, src fs.FS, opts ...ArchiveOption) error {
+	tw := tar.NewWriter(w)
+
+	err := walkArchive(src, opts, func(name string, data []byte, dir bool, mtime time.Time) error {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: mtime, Typeflag: tar.TypeReg}
+		if dir {
+			hdr.Name, hdr.Mode, hdr.Typeflag = name+"/", 0o755, tar.TypeDir
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		_, err := tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// WriteZip streams the tree of SourceFS to w as a deflated zip archive
+// with the same guarantees as WriteTar.
+func WriteZip(w io.Writer, opts ...ArchiveOption) error {
+	return WriteZipFS(w, source, opts...)
+}
+
+// WriteZipFS is like WriteZip but archives src.
+func WriteZipFS(w io.Writer, src fs.FS, opts ...ArchiveOption) error {
+	zw := zip.NewWriter(w)
+
+	err := walkArchive(src, opts, func(name string, data []byte, dir bool, mtime time.Time) error {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: mtime}
+		hdr.SetMode(0o644)
+
+		if dir {
+			hdr.Name, hdr.Method = name+"/", zip.Store
+			hdr.SetMode(fs.ModeDir | 0o755)
+		}
+
+		f, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// walkArchive calls add for every entry of src in lexical order.
+func walkArchive(src fs.FS, opts []ArchiveOption, add func(name string, data []byte, dir bool, mtime time.Time) error) error {
+	o := archiveOptions{modTime: archiveEpoch}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := checkPatterns(o.skip); err != nil {
+		return err
+	}
+
+	mtime := o.modTime.UTC().Truncate(time.Second)
+
+	return fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return err
+		}
+
+		if skipped(o.skip, name) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		target := path.Join(o.prefix, name)
+		if d.IsDir() {
+			return add(target, nil, true, mtime)
+		}
+
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+
+		return add(target, data, false, mtime)
+	})
+}
@@ -0,0 +1,163 @@
+package saucer
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// BazelOptions configures GenerateBazel.
+type BazelOptions struct {
+	// Source is the tree to generate for, SourceFS if nil.
+	Source fs.FS
+	// Serializer is the built-in serializer, SerializerGlaze if empty.
+	Serializer string
+	// Deps are the labels of the C++ dependencies fetched by CPM in the CMake
+	// build, "@lockpp", "@coco" and so on by default.
+	Deps []string
+	// BackendDeps lists further labels by backend ("qt6", "webkitgtk",
+	// "webview2" or "wkwebview"), e.g. the system libraries of WebKitGTK,
+	// Qt with the moc output of the headers declaring Q_OBJECT, or the
+	// WebView2 SDK.
+	BackendDeps map[string][]string
+}
+
+// bazelOS maps the platforms a backend is the default for, as in
+// CMakeLists.txt, to it.
+var bazelOS = []struct{ constraint, backend string }{
+	{"@platforms//os:windows", backendWebView2},
+	{"@platforms//os:macos", backendWKWebView},
+	{"//conditions:default", backendWebKitGTK},
+}
+
+// GenerateBazel extracts the tree of opts.Source to dir and writes a
+// BUILD.bazel building it without CMake: a cc_library "saucer_<backend>" per
+// backend and the alias "saucer" selecting the default backend of the target
+// platform. The file lists are derived from the tree, so regenerating after
+// an update of the module picks up new upstream files.
+func GenerateBazel(dir string, opts BazelOptions) error {
+	return generate(opts.Source, dir, opts.Serializer, func(l *layout, serializer string) map[string]string {
+		return map[string]string{"BUILD.bazel": opts.render(l, serializer)}
+	})
+}
+
+// render returns the BUILD.bazel of l.
+func (o *BazelOptions) render(l *layout, serializer string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Code generated by saucer.GenerateBazel from saucer %s (%s). DO NOT EDIT.\n\n", version, upstreamTag)
+	b.WriteString("load(\"@rules_cc//cc:defs.bzl\", \"cc_library\", \"objc_library\")\n\n")
+	b.WriteString("package(default_visibility = [\"//visibility:public\"])\n\n")
+
+	deps := o.Deps
+	if deps == nil {
+		for _, dep := range cppDeps {
+			if dep.serializer == "" || dep.serializer == serializer {
+				deps = append(deps, "@"+dep.name)
+			}
+		}
+	}
+
+	b.WriteString("SAUCER_HDRS = " + starlarkList(0, append(slices.Clone(l.headers), "include/saucer/config.hpp")) + "\n\n")
+	b.WriteString("SAUCER_PRIVATE_HDRS = " + starlarkList(0, l.private) + "\n\n")
+	b.WriteString("SAUCER_SRCS = " + starlarkList(0, append(slices.Clone(l.sources[""]), l.sources[serializer]...)) + "\n\n")
+	b.WriteString("SAUCER_INCLUDES = " + starlarkList(0, []string{"include", "include/saucer", "private", "private/saucer"}) + "\n\n")
+	b.WriteString("SAUCER_DEPS = " + starlarkList(0, deps) + "\n\n")
+	b.WriteString(`SAUCER_COPTS = select({
+    "@rules_cc//cc/compiler:msvc-cl": ["/std:c++latest", "/Zc:preprocessor", "/utf-8", "/wd5030"],
+    "@rules_cc//cc/compiler:gcc": ["-std=c++23", "-Wno-attributes=sc::"],
+    "//conditions:default": ["-std=c++23", "-Wno-unknown-attributes"],
+})
+
+`)
+
+	backends := l.backends()
+	for _, backend := range backends {
+		defines := append([]string{"SAUCER_WEBKIT_PRIVATE"}, backendDefines[backend]...)
+
+		srcs := "SAUCER_SRCS + SAUCER_PRIVATE_HDRS"
+		var extra []string
+
+		switch backend {
+		case backendWKWebView:
+			// The Objective-C++ sources need an objc_library
+			objc := "saucer_wkwebview_objc"
+			fmt.Fprintf(&b, "objc_library(\n    name = %q,\n", objc)
+			fmt.Fprintf(&b, "    srcs = SAUCER_PRIVATE_HDRS + %s,\n", starlarkList(1, l.sources[backend]))
+			b.WriteString("    hdrs = SAUCER_HDRS,\n    copts = [\"-std=c++23\"],\n")
+			fmt.Fprintf(&b, "    defines = %s,\n", starlarkList(1, defines))
+			b.WriteString("    includes = SAUCER_INCLUDES,\n")
+			fmt.Fprintf(&b, "    deps = SAUCER_DEPS + %s,\n)\n\n", starlarkList(1, o.BackendDeps[backend]))
+
+			extra = append(extra, ":"+objc)
+		default:
+			srcs += " + " + starlarkList(1, l.sources[backend])
+		}
+
+		fmt.Fprintf(&b, "cc_library(\n    name = %q,\n", "saucer_"+backend)
+		fmt.Fprintf(&b, "    srcs = %s,\n", srcs)
+		b.WriteString("    hdrs = SAUCER_HDRS,\n    copts = SAUCER_COPTS,\n")
+		fmt.Fprintf(&b, "    defines = %s,\n", starlarkList(1, defines))
+		if backend == backendWebView2 {
+			b.WriteString("    local_defines = [\"NOMINMAX\"],\n")
+		}
+		b.WriteString("    includes = SAUCER_INCLUDES,\n")
+		if link := bazelLinkopts(backend); link != "" {
+			fmt.Fprintf(&b, "    linkopts = %s,\n", link)
+		}
+		fmt.Fprintf(&b, "    deps = SAUCER_DEPS + %s,\n)\n\n", starlarkList(1, append(extra, o.BackendDeps[backend]...)))
+	}
+
+	var choices []string
+	for _, p := range bazelOS {
+		if slices.Contains(backends, p.backend) {
+			choices = append(choices, fmt.Sprintf("        %q: \":saucer_%s\",\n", p.constraint, p.backend))
+		}
+	}
+	if len(choices) > 0 {
+		b.WriteString("alias(\n    name = \"saucer\",\n    actual = select({\n" + strings.Join(choices, "") + "    }),\n)\n")
+	}
+
+	return b.String()
+}
+
+// bazelLinkopts returns the linkopts of the system libraries of backend.
+func bazelLinkopts(backend string) string {
+	switch backend {
+	case backendWebView2:
+		libs := []string{"CoreMessaging", "RuntimeObject", "Wininet", "Shlwapi", "gdiplus", "ole32"}
+
+		msvc, gnu := make([]string, len(libs)), make([]string, len(libs))
+		for i, lib := range libs {
+			msvc[i], gnu[i] = lib+".lib", "-l"+lib
+		}
+
+		return "select({\n        \"@rules_cc//cc/compiler:msvc-cl\": " + starlarkList(2, msvc) +
+			",\n        \"//conditions:default\": " + starlarkList(2, gnu) + ",\n    })"
+	case backendWKWebView:
+		return starlarkList(1, []string{"-framework", "Cocoa", "-framework", "WebKit", "-framework", "CoreImage"})
+	}
+	return ""
+}
+
+// starlarkList formats items as a Starlark list nested depth levels deep,
+// one item per line if there are several.
+func starlarkList(depth int, items []string) string {
+	switch len(items) {
+	case 0:
+		return "[]"
+	case 1:
+		return fmt.Sprintf("[%q]", items[0])
+	}
+
+	indent := strings.Repeat("    ", depth)
+
+	var b strings.Builder
+	b.WriteString("[\n")
+	for _, item := range items {
+		fmt.Fprintf(&b, "%s    %q,\n", indent, item)
+	}
+	b.WriteString(indent + "]")
+	return b.String()
+}
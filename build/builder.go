@@ -0,0 +1,357 @@
+// Package build drives the native CMake build of the embedded saucer sources.
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aperturerobotics/saucer"
+)
+
+// Artifacts describes the output of a successful build.
+type Artifacts struct {
+	// Library is the path to the saucer static library.
+	Library string `json:"library"`
+	// IncludeDirs are the include directories needed to compile against saucer.
+	IncludeDirs []string `json:"includeDirs"`
+}
+
+// Builder extracts the saucer sources and builds them with CMake.
+//
+// Builds are cached by a key derived from the source manifest, the toolchain
+// versions and the configuration, so repeated builds skip the compile when
+// nothing changed.
+type Builder struct {
+	cfg     Config
+	release saucer.Release
+
+	mu    sync.Mutex
+	stats CacheStats
+}
+
+// NewBuilder constructs a Builder from cfg changed by opts, filling in
+// defaults for unset fields.
+func NewBuilder(cfg Config, opts ...Option) *Builder {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	release, err := saucer.LookupRelease(cfg.Version)
+	if err != nil {
+		// The tree of a release that is not embedded, e.g. a checkout, or
+		// building fails with err reading the sources
+		version := strings.TrimPrefix(cfg.Version, "v")
+		release = saucer.Release{Version: version, Tag: "v" + version}
+		if cfg.Source == nil {
+			cfg.Source = missingSource{err}
+		}
+	}
+	if cfg.Source == nil {
+		cfg.Source = release.Source
+	}
+	cfg.Version, release.Source = release.Version, cfg.Source
+
+	if cfg.CMake == "" {
+		cfg.CMake = "cmake"
+	}
+	if p, ok := profiles[cfg.Profile]; ok {
+		cfg.BuildType = p.buildType
+	}
+	if cfg.BuildType == "" {
+		cfg.BuildType = Release
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = BackendDefault
+	}
+	if cfg.Target == (Target{}) {
+		cfg.Target = HostTarget()
+	}
+	return &Builder{cfg: cfg, release: release}
+}
+
+// missingSource is the source tree of a release that is not embedded.
+type missingSource struct{ err error }
+
+func (m missingSource) Open(string) (fs.File, error) {
+	return nil, m.err
+}
+
+// Release returns the saucer release the builder builds.
+func (b *Builder) Release() saucer.Release {
+	return b.release
+}
+
+// SourceDir returns the directory the sources are extracted to.
+func (b *Builder) SourceDir() string {
+	return filepath.Join(b.cfg.Dir, "src")
+}
+
+// BuildDir returns the CMake binary directory.
+func (b *Builder) BuildDir() string {
+	return filepath.Join(b.cfg.Dir, "build")
+}
+
+// Build returns the cached artifacts for the configuration or extracts the
+// sources, configures and builds them.
+func (b *Builder) Build(ctx context.Context) (*Artifacts, error) {
+	if b.cfg.Dir == "" {
+		return nil, errors.New("build: config dir is required")
+	}
+
+	if err := b.cfg.checkProfile(); err != nil {
+		return nil, err
+	}
+	if err := b.cfg.checkSerializer(); err != nil {
+		return nil, err
+	}
+
+	art, err := b.build(ctx)
+	if err != nil || b.cfg.CgoFile == "" {
+		return art, err
+	}
+
+	if err := WriteCgoFlags(b.cfg.CgoFile, b.CgoFlags(art)); err != nil {
+		return nil, fmt.Errorf("build: write cgo flags: %w", err)
+	}
+	return art, nil
+}
+
+// build returns the cached artifacts or compiles them.
+func (b *Builder) build(ctx context.Context) (*Artifacts, error) {
+	cache, err := b.cacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	if cache == "" {
+		return b.compile(ctx)
+	}
+
+	key, err := b.cacheKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := filepath.Join(cache, key)
+	art, hit := lookupCache(entry)
+
+	b.mu.Lock()
+	b.stats.Dir, b.stats.Key = cache, key
+	if hit {
+		b.stats.Hits++
+	} else {
+		b.stats.Misses++
+	}
+	b.mu.Unlock()
+
+	if hit {
+		return art, nil
+	}
+
+	if art, err = b.compile(ctx); err != nil {
+		return nil, err
+	}
+
+	if art, err = storeCache(entry, art); err != nil {
+		return nil, fmt.Errorf("build: cache: %w", err)
+	}
+	return art, nil
+}
+
+// compile extracts the sources, configures and builds them.
+func (b *Builder) compile(ctx context.Context) (*Artifacts, error) {
+	if err := b.configure(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := b.cmake(ctx, "build", b.cfg.buildArgs(b.BuildDir())...); err != nil {
+		return nil, err
+	}
+
+	return b.artifacts()
+}
+
+// Stages of configuring a build, the Stage of a ConfigureError.
+const (
+	StageProfile      = "profile"
+	StageToolchain    = "toolchain"
+	StageExtract      = "extract"
+	StageDependencies = "dependencies"
+	StageCMake        = "cmake"
+)
+
+// ConfigureError is returned by Build and ExportCompileCommands when
+// configuring the build tree failed, before anything was compiled. A failure
+// of cmake wraps a *CompileError if its output holds an error.
+type ConfigureError struct {
+	// Stage is the stage that failed, StageCMake for cmake itself.
+	Stage string
+	// Err is the error of the stage.
+	Err error
+}
+
+func (e *ConfigureError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigureError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a *ConfigureError of the same stage, or of any
+// stage if its Stage is empty, e.g. errors.Is(err, &ConfigureError{Stage:
+// StageToolchain}).
+func (e *ConfigureError) Is(target error) bool {
+	t, ok := target.(*ConfigureError)
+	return ok && (t.Stage == "" || t.Stage == e.Stage)
+}
+
+// configure extracts the sources and configures the build tree, extra being
+// passed to cmake after the arguments of the configuration. It returns a
+// *ConfigureError.
+func (b *Builder) configure(ctx context.Context, extra ...string) error {
+	fail := func(stage string, err error) error {
+		return &ConfigureError{Stage: stage, Err: err}
+	}
+
+	if err := b.cfg.checkProfile(); err != nil {
+		return fail(StageProfile, err)
+	}
+
+	if err := b.CheckToolchain(); err != nil {
+		return fail(StageToolchain, err)
+	}
+
+	src := b.SourceDir()
+
+	if err := saucer.ExtractFS(b.cfg.Source, src, saucer.ExtractOptions{OnlyIfChanged: true}); err != nil {
+		return fail(StageExtract, fmt.Errorf("build: extract sources: %w", err))
+	}
+
+	toolchain, err := b.toolchainArgs()
+	if err != nil {
+		return fail(StageToolchain, err)
+	}
+
+	launcher, err := b.cfg.launcherArgs()
+	if err != nil {
+		return fail(StageToolchain, err)
+	}
+
+	deps, err := b.depsArgs()
+	if err != nil {
+		return fail(StageDependencies, err)
+	}
+
+	packages, err := b.packageManagerArgs()
+	if err != nil {
+		return fail(StageDependencies, err)
+	}
+
+	args := append(b.cfg.configureArgs(src, b.BuildDir()), toolchain...)
+	args = append(args, launcher...)
+	args = append(args, deps...)
+	args = append(args, packages...)
+	if err := b.cmake(ctx, "configure", append(args, extra...)...); err != nil {
+		return fail(StageCMake, err)
+	}
+	return nil
+}
+
+// ExportCompileCommands writes the compile_commands.json of the build to
+// path, so clangd and IDEs understand the extracted sources, e.g. while
+// writing patches against them. An empty path writes it next to the sources
+// in SourceDir, where clangd finds it.
+//
+// It extracts the sources and configures the build tree but does not
+// compile. The file is only generated by the Makefile and Ninja generators.
+func (b *Builder) ExportCompileCommands(ctx context.Context, path string) error {
+	if b.cfg.Dir == "" {
+		return errors.New("build: config dir is required")
+	}
+
+	if err := b.configure(ctx, "-DCMAKE_EXPORT_COMPILE_COMMANDS=ON"); err != nil {
+		return err
+	}
+
+	generated := filepath.Join(b.BuildDir(), "compile_commands.json")
+	if _, err := os.Stat(generated); err != nil {
+		return fmt.Errorf("build: compile_commands.json not generated, use a Makefile or Ninja generator: %w", err)
+	}
+
+	if path == "" {
+		path = filepath.Join(b.SourceDir(), "compile_commands.json")
+	}
+
+	if err := copyFile(path, generated); err != nil {
+		return fmt.Errorf("build: export compile commands: %w", err)
+	}
+	return nil
+}
+
+// cmake runs cmake with args, streaming its output to Config.Log and
+// Config.Progress. A failure returns a *CompileError if the output holds a
+// compiler error, otherwise the tail of the output is included in the error.
+func (b *Builder) cmake(ctx context.Context, step string, args ...string) error {
+	if step == "configure" && b.cfg.Progress != nil {
+		b.cfg.Progress(Progress{Phase: PhaseConfigure, Percent: -1})
+	}
+
+	out := &output{step: step, log: b.cfg.Log, progress: b.cfg.Progress}
+
+	cmd := exec.CommandContext(ctx, b.cfg.CMake, args...)
+	cmd.Stdout, cmd.Stderr = out, out
+
+	if err := cmd.Run(); err != nil {
+		return out.result(err)
+	}
+	return nil
+}
+
+// artifacts locates the build outputs.
+func (b *Builder) artifacts() (*Artifacts, error) {
+	build := b.BuildDir()
+
+	candidates := []string{
+		filepath.Join(build, "libsaucer.a"),
+		filepath.Join(build, "saucer.lib"),
+		filepath.Join(build, string(b.cfg.BuildType), "libsaucer.a"),
+		filepath.Join(build, string(b.cfg.BuildType), "saucer.lib"),
+	}
+
+	rtn := &Artifacts{}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			rtn.Library = candidate
+			break
+		}
+	}
+
+	if rtn.Library == "" {
+		return nil, fmt.Errorf("build: static library not found in %s", build)
+	}
+
+	deps, err := filepath.Glob(filepath.Join(build, "_deps", "*-src", "include"))
+	if err != nil {
+		return nil, err
+	}
+
+	rtn.IncludeDirs = append([]string{filepath.Join(b.SourceDir(), "include")}, deps...)
+	return rtn, nil
+}
+
+// Clean removes the working directory with the extracted sources and the
+// build tree.
+func (b *Builder) Clean() error {
+	if b.cfg.Dir == "" {
+		return errors.New("build: config dir is required")
+	}
+	return os.RemoveAll(b.cfg.Dir)
+}
@@ -0,0 +1,226 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aperturerobotics/saucer"
+)
+
+// CacheStats reports how the build cache was used by a Builder.
+type CacheStats struct {
+	// Dir is the cache directory, empty when caching is disabled.
+	Dir string
+	// Key identifies the cache entry of the last build.
+	Key string
+	// Hits counts builds served from the cache.
+	Hits int
+	// Misses counts builds that had to compile.
+	Misses int
+}
+
+// CacheStats returns the cache statistics of the builds run so far.
+func (b *Builder) CacheStats() CacheStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stats
+}
+
+// cacheDir returns the cache root, or an empty string if caching is disabled.
+func (b *Builder) cacheDir() (string, error) {
+	if b.cfg.NoCache {
+		return "", nil
+	}
+	if b.cfg.CacheDir != "" {
+		return b.cfg.CacheDir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("build: cache dir: %w", err)
+	}
+	return filepath.Join(dir, "saucer"), nil
+}
+
+// cacheKey derives the cache key from the source manifest, the toolchain
+// versions and every setting that affects the produced library.
+func (b *Builder) cacheKey(ctx context.Context) (string, error) {
+	manifest, err := saucer.ManifestFS(b.cfg.Source)
+	if err != nil {
+		return "", fmt.Errorf("build: hash sources: %w", err)
+	}
+
+	h := sha256.New()
+
+	fmt.Fprintf(h, "sources %s\n", manifest.Hash())
+	fmt.Fprintf(h, "target %s\n", b.cfg.Target)
+	fmt.Fprintf(h, "cmake %s\n", toolVersion(ctx, b.cfg.CMake))
+	fmt.Fprintf(h, "compiler %s\n", toolVersion(ctx, b.cfg.compiler()))
+
+	for _, env := range []string{"CXXFLAGS", "CFLAGS", "LDFLAGS"} {
+		fmt.Fprintf(h, "%s %s\n", env, os.Getenv(env))
+	}
+
+	for _, arg := range b.cfg.configureArgs("", "") {
+		fmt.Fprintf(h, "arg %s\n", arg)
+	}
+
+	tc, err := b.cfg.toolchain()
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(h, "toolchain %s\n%s", tc.file, tc.generated)
+	if b.cfg.ToolchainFile != "" {
+		data, err := os.ReadFile(b.cfg.ToolchainFile)
+		if err != nil {
+			return "", fmt.Errorf("build: toolchain file: %w", err)
+		}
+		fmt.Fprintf(h, "%s\n", data)
+	}
+	for _, define := range tc.defines {
+		fmt.Fprintf(h, "arg %s\n", define)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// compiler returns the C++ compiler CMake is going to use.
+func (c *Config) compiler() string {
+	if cxx := c.Defines["CMAKE_CXX_COMPILER"]; cxx != "" {
+		return cxx
+	}
+	if c.Zig {
+		return "zig"
+	}
+	if target, ok := crossTargets[c.Target]; ok && c.cross() && c.ToolchainFile == "" {
+		return target.prefix + target.cxx
+	}
+	if cxx := os.Getenv("CXX"); cxx != "" {
+		return cxx
+	}
+	return "c++"
+}
+
+// toolVersion returns the first line printed by tool --version.
+func toolVersion(ctx context.Context, tool string) string {
+	out, err := exec.CommandContext(ctx, tool, "--version").Output()
+	if err != nil {
+		return "unknown"
+	}
+
+	line, _, _ := strings.Cut(string(bytes.TrimSpace(out)), "\n")
+	return line
+}
+
+// LoadArtifacts returns the artifacts stored in dir using the layout of the
+// build cache: the static library in dir/lib and one directory per include
+// root in dir/include.
+func LoadArtifacts(dir string) (*Artifacts, error) {
+	art, ok := lookupCache(dir)
+	if !ok {
+		return nil, fmt.Errorf("build: no complete artifacts in %s", dir)
+	}
+	return art, nil
+}
+
+// lookupCache returns the artifacts stored in entry, if complete.
+func lookupCache(entry string) (*Artifacts, bool) {
+	var library string
+	for _, name := range []string{"libsaucer.a", "saucer.lib"} {
+		if _, err := os.Stat(filepath.Join(entry, "lib", name)); err == nil {
+			library = filepath.Join(entry, "lib", name)
+			break
+		}
+	}
+	if library == "" {
+		return nil, false
+	}
+
+	includes, _ := filepath.Glob(filepath.Join(entry, "include", "*"))
+	if len(includes) == 0 {
+		return nil, false
+	}
+
+	return &Artifacts{Library: library, IncludeDirs: includes}, true
+}
+
+// storeCache copies art into entry and returns the cached artifacts. The
+// entry is populated in a temporary directory and renamed into place so
+// concurrent builds never observe a partial entry.
+func storeCache(entry string, art *Artifacts) (*Artifacts, error) {
+	if err := os.MkdirAll(filepath.Dir(entry), 0o755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(entry), ".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := copyFile(filepath.Join(tmp, "lib", filepath.Base(art.Library)), art.Library); err != nil {
+		return nil, err
+	}
+
+	for _, dir := range art.IncludeDirs {
+		if err := os.CopyFS(filepath.Join(tmp, "include", includeName(dir)), os.DirFS(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.Rename(tmp, entry); err != nil {
+		// Another build may have stored the same entry first.
+		if rtn, ok := lookupCache(entry); ok {
+			return rtn, nil
+		}
+		return nil, err
+	}
+
+	rtn, ok := lookupCache(entry)
+	if !ok {
+		return nil, errors.New("incomplete cache entry")
+	}
+	return rtn, nil
+}
+
+// includeName names the cached copy of an include directory after its
+// project, e.g. "lockpp-src" for _deps/lockpp-src/include.
+func includeName(dir string) string {
+	parent := filepath.Base(filepath.Dir(dir))
+	if parent == "src" {
+		return "saucer"
+	}
+	return parent
+}
+
+// copyFile copies src to dst, creating the parent directory.
+func copyFile(dst, src string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
+
+// CleanCache removes every cached build.
+func (b *Builder) CleanCache() error {
+	dir, err := b.cacheDir()
+	if err != nil || dir == "" {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,68 @@
+package build
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// CompilerCache selects the compiler cache wrapping the C and C++ compilers.
+type CompilerCache string
+
+const (
+	// CompilerCacheNone compiles without a compiler cache.
+	CompilerCacheNone CompilerCache = ""
+	// CompilerCacheAuto uses sccache or ccache if one is installed.
+	CompilerCacheAuto CompilerCache = "auto"
+	// CompilerCacheCCache uses ccache.
+	CompilerCacheCCache CompilerCache = "ccache"
+	// CompilerCacheSCCache uses sccache.
+	CompilerCacheSCCache CompilerCache = "sccache"
+)
+
+// LookupCompilerCache returns the path of the executable of cache, the first
+// one installed for CompilerCacheAuto. It returns an empty path without error
+// for CompilerCacheNone and for CompilerCacheAuto if none is installed.
+func LookupCompilerCache(cache CompilerCache) (string, error) {
+	switch cache {
+	case CompilerCacheNone:
+		return "", nil
+	case CompilerCacheAuto:
+		for _, name := range []CompilerCache{CompilerCacheSCCache, CompilerCacheCCache} {
+			if path, err := exec.LookPath(string(name)); err == nil {
+				return path, nil
+			}
+		}
+		return "", nil
+	case CompilerCacheCCache, CompilerCacheSCCache:
+		path, err := exec.LookPath(string(cache))
+		if err != nil {
+			return "", fmt.Errorf("build: compiler cache %s not found", cache)
+		}
+		return path, nil
+	default:
+		return "", fmt.Errorf("build: unknown compiler cache %q", cache)
+	}
+}
+
+// launcherArgs returns the CMake arguments running the compilers through the
+// configured compiler cache. Without one they clear the launcher a previous
+// configure of the build tree might have set.
+func (c *Config) launcherArgs() ([]string, error) {
+	path, err := LookupCompilerCache(c.CompilerCache)
+	if err != nil {
+		return nil, err
+	}
+
+	path = filepath.ToSlash(path)
+	return []string{"-DCMAKE_C_COMPILER_LAUNCHER=" + path, "-DCMAKE_CXX_COMPILER_LAUNCHER=" + path}, nil
+}
+
+// buildArgs returns the arguments for the cmake build step.
+func (c *Config) buildArgs(build string) []string {
+	args := []string{"--build", build, "--config", string(c.BuildType)}
+	if c.Jobs > 0 {
+		args = append(args, "--parallel", fmt.Sprint(c.Jobs))
+	}
+	return args
+}
@@ -0,0 +1,188 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CgoFlags are the cgo directives needed to link a Go package against a
+// saucer build.
+type CgoFlags struct {
+	// GOOS is the operating system the flags apply to.
+	GOOS string `json:"goos"`
+	// GOARCH is the architecture the flags apply to, any if empty.
+	GOARCH string `json:"goarch,omitempty"`
+	// CXXFlags are passed to the C++ compiler.
+	CXXFlags []string `json:"cxxFlags"`
+	// LDFlags are passed to the linker.
+	LDFlags []string `json:"ldFlags"`
+	// PkgConfig lists pkg-config packages providing further flags.
+	PkgConfig []string `json:"pkgConfig,omitempty"`
+}
+
+// resolve returns the backend CMake selects for goos.
+func (b Backend) resolve(goos string) Backend {
+	if b != BackendDefault {
+		return b
+	}
+
+	switch goos {
+	case "windows":
+		return BackendWebView2
+	case "darwin":
+		return BackendWebKit
+	default:
+		return BackendWebKitGtk
+	}
+}
+
+// CgoFlags returns the flags for linking against art, including the system
+// libraries of the configured backend.
+func (b *Builder) CgoFlags(art *Artifacts) CgoFlags {
+	return b.cgoFlags(art, b.webview2Loader())
+}
+
+// cgoFlags returns the CgoFlags of art, linking the WebView2 loader library
+// loader if set.
+func (b *Builder) cgoFlags(art *Artifacts, loader string) CgoFlags {
+	rtn := CgoFlags{
+		GOOS:     b.cfg.Target.GOOS,
+		GOARCH:   b.cfg.Target.GOARCH,
+		CXXFlags: []string{"-std=c++23"},
+		LDFlags:  []string{"-L" + filepath.Dir(art.Library), "-lsaucer"},
+	}
+
+	for _, dir := range art.IncludeDirs {
+		rtn.CXXFlags = append(rtn.CXXFlags, "-I"+dir)
+	}
+
+	// Bindings supporting several releases select the API by the major
+	// version
+	if major, _, _ := strings.Cut(b.release.Version, "."); major != "" {
+		rtn.CXXFlags = append(rtn.CXXFlags, "-DSAUCER_RELEASE_MAJOR="+major)
+	}
+
+	// The code calling into an instrumented library is instrumented too and
+	// links the sanitizer runtime
+	if sanitize := b.cfg.sanitizerFlags(); sanitize != nil {
+		rtn.CXXFlags = append(rtn.CXXFlags, sanitize...)
+		rtn.LDFlags = append(rtn.LDFlags, sanitize[0])
+	}
+
+	switch b.cfg.Backend.resolve(rtn.GOOS) {
+	case BackendQt:
+		rtn.CXXFlags = append(rtn.CXXFlags, "-DSAUCER_QT")
+		rtn.PkgConfig = []string{"Qt6Widgets", "Qt6WebChannel", "Qt6WebEngineWidgets"}
+	case BackendWebKitGtk:
+		rtn.CXXFlags = append(rtn.CXXFlags, "-DSAUCER_WEBKITGTK")
+		rtn.PkgConfig = []string{"gtk4", "libadwaita-1", "webkitgtk-6.0", "json-glib-1.0", "gio-unix-2.0"}
+	case BackendWebView2:
+		rtn.CXXFlags = append(rtn.CXXFlags, "-DSAUCER_WEBVIEW2", "-DUNICODE", "-D_UNICODE")
+		if loader != "" {
+			rtn.LDFlags = append(rtn.LDFlags, "-L"+filepath.Dir(loader), "-lWebView2LoaderStatic")
+		}
+		rtn.LDFlags = append(rtn.LDFlags, "-lCoreMessaging", "-lRuntimeObject", "-lWininet", "-lShlwapi", "-lgdiplus", "-lole32")
+	case BackendWebKit:
+		rtn.CXXFlags = append(rtn.CXXFlags, "-DSAUCER_WEBKIT")
+		rtn.LDFlags = append(rtn.LDFlags, "-framework", "Cocoa", "-framework", "WebKit", "-framework", "CoreImage")
+	}
+
+	return rtn
+}
+
+// webview2Loader returns the WebView2 loader library installed from NuGet by
+// the CMake configure step, if present.
+func (b *Builder) webview2Loader() string {
+	arch := map[string]string{"amd64": "x64", "386": "x86", "arm64": "arm64"}[b.cfg.Target.GOARCH]
+	pattern := filepath.Join(b.BuildDir(), "nuget", "packages", "Microsoft.Web.WebView2.*", "build", "native", arch, "WebView2LoaderStatic.lib")
+
+	matches, _ := filepath.Glob(pattern)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+// WriteCgoFlags writes flags as a Go source file declaring the cgo
+// directives, constrained to flags.GOOS, flags.GOARCH if set and the
+// "saucer" build tag. The
+// package name is taken from the other Go files in the target directory.
+func WriteCgoFlags(name string, flags CgoFlags) error {
+	pkg, err := packageName(filepath.Dir(name), filepath.Base(name))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("// Code generated by saucer/build. DO NOT EDIT.\n\n")
+	constraint := flags.GOOS
+	if flags.GOARCH != "" {
+		constraint += " && " + flags.GOARCH
+	}
+
+	fmt.Fprintf(&buf, "//go:build cgo && saucer && %s\n\npackage %s\n\n/*\n", constraint, pkg)
+
+	writeDirective(&buf, "CXXFLAGS", flags.CXXFlags)
+	writeDirective(&buf, "LDFLAGS", flags.LDFlags)
+	writeDirective(&buf, "pkg-config", flags.PkgConfig)
+
+	buf.WriteString("*/\nimport \"C\"\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, src, 0o644)
+}
+
+// writeDirective writes a #cgo directive, quoting arguments with spaces.
+func writeDirective(buf *bytes.Buffer, kind string, args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, "#cgo %s: %s\n", kind, strings.Join(quoteFlags(args), " "))
+}
+
+// quoteFlags quotes the arguments with spaces, as cgo and pkg-config expect.
+func quoteFlags(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if strings.ContainsAny(arg, " \t") {
+			quoted[i] = "'" + arg + "'"
+		}
+	}
+	return quoted
+}
+
+// packageName returns the package declared by the Go files in dir, ignoring
+// exclude and test files.
+func packageName(dir, exclude string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range files {
+		if filepath.Base(file) == exclude || strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		return f.Name.Name, nil
+	}
+
+	return "", errors.New("build: no Go package in " + dir)
+}
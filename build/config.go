@@ -0,0 +1,162 @@
+package build
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+)
+
+// Backend selects the saucer webview backend.
+type Backend string
+
+const (
+	// BackendDefault picks the native backend of the host platform.
+	BackendDefault Backend = "Default"
+	// BackendQt uses Qt WebEngine.
+	BackendQt Backend = "Qt"
+	// BackendWebKitGtk uses WebKitGTK.
+	BackendWebKitGtk Backend = "WebKitGtk"
+	// BackendWebView2 uses Microsoft Edge WebView2.
+	BackendWebView2 Backend = "WebView2"
+	// BackendWebKit uses WKWebView.
+	BackendWebKit Backend = "WebKit"
+)
+
+// BuildType is the CMake build type.
+type BuildType string
+
+// Build types understood by CMake.
+const (
+	Debug          BuildType = "Debug"
+	Release        BuildType = "Release"
+	RelWithDebInfo BuildType = "RelWithDebInfo"
+	MinSizeRel     BuildType = "MinSizeRel"
+)
+
+// Config configures a Builder.
+type Config struct {
+	// Dir is the working directory. Sources are extracted to Dir/src and
+	// built in Dir/build. Required.
+	Dir string
+	// Source is the source tree to build. Defaults to the source of the
+	// embedded release Version.
+	Source fs.FS
+	// Version selects the saucer release to build, in the syntax of
+	// saucer.LookupRelease. Defaults to the default release. With Source set
+	// it names the release of Source.
+	Version string
+	// CMake is the cmake executable. Defaults to "cmake".
+	CMake string
+	// BuildType defaults to Release.
+	BuildType BuildType
+	// Profile, if set, replaces BuildType with the build type of the profile
+	// and instruments the build with its sanitizer, also in CgoFlags.
+	Profile Profile
+	// Backend defaults to BackendDefault.
+	Backend Backend
+	// Generator is the CMake generator, e.g. "Ninja". Empty uses the CMake default.
+	Generator string
+	// Defines are passed to CMake as -D<key>=<value>.
+	Defines map[string]string
+	// CacheDir is the root of the build cache. Defaults to "saucer" below
+	// os.UserCacheDir.
+	CacheDir string
+	// CgoFile, if set, is written with the cgo directives for linking against
+	// the build after every successful Build, see WriteCgoFlags.
+	CgoFile string
+	// NoCache always compiles and neither reads nor populates the cache.
+	NoCache bool
+	// Target is the platform to build for, defaults to HostTarget. Other
+	// targets are cross compiled with a generated CMake toolchain file using
+	// the GNU, MinGW or osxcross compilers, see CheckToolchain. CMake
+	// cannot switch the toolchain of a build tree, so use a Dir per target.
+	Target Target
+	// Sysroot is the root of the headers and libraries of the target, e.g.
+	// a Debian arm64 root with the development packages of the backend. It
+	// is passed to CMake and pkg-config when cross compiling.
+	Sysroot string
+	// ToolchainFile is a CMake toolchain file used instead of the generated
+	// one, for toolchains the builder does not know. The toolchain files of
+	// vcpkg (vcpkg.cmake) and Conan (conan_toolchain.cmake) resolve the
+	// native dependencies with the package manager, see ExportVcpkgManifest
+	// and ExportConanfile. Cross builds chainload the generated toolchain
+	// file from vcpkg.
+	ToolchainFile string
+	// Zig compiles with zig cc for any target, using the toolchain file of
+	// the saucer sources.
+	Zig bool
+	// CompilerCache wraps the compilers with ccache or sccache, which does
+	// not change the cache key of the build.
+	CompilerCache CompilerCache
+	// Jobs is the number of parallel compile jobs, the default of the CMake
+	// generator if zero.
+	Jobs int
+	// DepsDir holds the sources of the CMake dependencies of saucer, which
+	// CPM downloads at configure time, see Builder.Vendor. Builds fetch
+	// missing dependencies to it unless Offline. Optional.
+	DepsDir string
+	// Offline builds without network access from the dependencies vendored
+	// to DepsDir, failing with the list of missing ones.
+	Offline bool
+	// Log receives the output of CMake and the compiler as it is printed.
+	// Optional.
+	Log io.Writer
+	// Progress is called with the phase of the build and, while compiling,
+	// the progress and the current translation unit. Optional.
+	Progress func(Progress)
+}
+
+// Option changes the Config of NewBuilder.
+type Option func(*Config)
+
+// UseCompilerCache wraps the compilers with cache, see Config.CompilerCache.
+func UseCompilerCache(cache CompilerCache) Option {
+	return func(c *Config) { c.CompilerCache = cache }
+}
+
+// Jobs runs n compile jobs in parallel, passed to CMake as --parallel, see
+// Config.Jobs.
+func Jobs(n int) Option {
+	return func(c *Config) { c.Jobs = n }
+}
+
+// OfflineMode builds without network access from the dependencies vendored
+// to Config.DepsDir, see Config.Offline.
+func OfflineMode(offline bool) Option {
+	return func(c *Config) { c.Offline = offline }
+}
+
+// configureArgs returns the arguments for the cmake configure step.
+func (c *Config) configureArgs(src, build string) []string {
+	args := []string{"-S", src, "-B", build}
+
+	if c.Generator != "" {
+		args = append(args, "-G", c.Generator)
+	}
+
+	args = append(args,
+		"-DCMAKE_BUILD_TYPE="+string(c.BuildType),
+		"-Dsaucer_backend="+string(c.Backend),
+	)
+	args = append(args, c.profileArgs()...)
+
+	for _, key := range slices.Sorted(maps.Keys(c.Defines)) {
+		args = append(args, "-D"+key+"="+c.Defines[key])
+	}
+	return args
+}
+
+// checkSerializer verifies that the sources of the serializer selected by
+// saucer_serializer are present, as the saucer_no_rflpp build tag excludes
+// rfl++ from saucer.SourceFS.
+func (c *Config) checkSerializer() error {
+	if c.Defines["saucer_serializer"] != "Rflpp" {
+		return nil
+	}
+	if _, err := fs.Stat(c.Source, "src/rfl.serializer.cpp"); err != nil {
+		return errors.New("build: the source tree lacks the rfl++ serializer, remove -tags saucer_no_rflpp")
+	}
+	return nil
+}
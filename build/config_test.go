@@ -0,0 +1,76 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// fakeCompilerCache installs an executable named cache on a PATH of its own
+// and returns its path.
+func fakeCompilerCache(t *testing.T, cache CompilerCache) string {
+	t.Helper()
+
+	name := string(cache)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return filepath.ToSlash(path)
+}
+
+func TestUseCompilerCache(t *testing.T) {
+	path := fakeCompilerCache(t, CompilerCacheCCache)
+
+	for _, cache := range []CompilerCache{CompilerCacheCCache, CompilerCacheAuto} {
+		b := NewBuilder(Config{}, UseCompilerCache(cache))
+
+		args, err := b.cfg.launcherArgs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "-DCMAKE_CXX_COMPILER_LAUNCHER=" + path; !slices.Contains(args, want) {
+			t.Errorf("%s: arguments %q, expected %s", cache, args, want)
+		}
+	}
+
+	if _, err := NewBuilder(Config{}, UseCompilerCache(CompilerCacheSCCache)).cfg.launcherArgs(); err == nil {
+		t.Error("missing sccache not reported")
+	}
+
+	args, err := NewBuilder(Config{CompilerCache: CompilerCacheCCache}, UseCompilerCache(CompilerCacheNone)).cfg.launcherArgs()
+	if err != nil || !slices.Contains(args, "-DCMAKE_CXX_COMPILER_LAUNCHER=") {
+		t.Errorf("arguments %q, %v, expected the launcher cleared", args, err)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	args := NewBuilder(Config{}, Jobs(6)).cfg.buildArgs("out")
+	if i := slices.Index(args, "--parallel"); i < 0 || i+1 == len(args) || args[i+1] != "6" {
+		t.Errorf("arguments %q, expected --parallel 6", args)
+	}
+
+	if args := NewBuilder(Config{Jobs: 6}, Jobs(0)).cfg.buildArgs("out"); slices.Contains(args, "--parallel") {
+		t.Errorf("arguments %q, expected the default of the generator", args)
+	}
+}
+
+func TestOfflineMode(t *testing.T) {
+	if _, err := NewBuilder(Config{}, OfflineMode(true)).depsArgs(); err == nil {
+		t.Error("offline build without a dependency dir")
+	}
+
+	dir := t.TempDir()
+	args, err := NewBuilder(Config{DepsDir: dir, Offline: true}, OfflineMode(false)).depsArgs()
+	if err != nil || slices.Contains(args, "-DFETCHCONTENT_FULLY_DISCONNECTED=ON") {
+		t.Errorf("arguments %q, %v, expected an online build", args, err)
+	}
+}
@@ -0,0 +1,281 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Target is the platform a build produces the library for.
+type Target struct {
+	GOOS   string `json:"goos"`
+	GOARCH string `json:"goarch"`
+}
+
+// HostTarget returns the platform of the running binary.
+func HostTarget() Target {
+	return Target{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+}
+
+// ParseTarget parses a target written like GOOS/GOARCH, e.g. "windows/amd64".
+func ParseTarget(s string) (Target, error) {
+	goos, goarch, ok := strings.Cut(s, "/")
+	if !ok || goos == "" || goarch == "" {
+		return Target{}, fmt.Errorf("build: target %q is not GOOS/GOARCH", s)
+	}
+
+	t := Target{GOOS: goos, GOARCH: goarch}
+	if _, ok := crossTargets[t]; !ok && t != HostTarget() {
+		return Target{}, fmt.Errorf("build: unsupported target %s", t)
+	}
+	return t, nil
+}
+
+func (t Target) String() string {
+	return t.GOOS + "/" + t.GOARCH
+}
+
+// crossTarget describes how CMake cross compiles for a target.
+type crossTarget struct {
+	// system and processor are CMAKE_SYSTEM_NAME and CMAKE_SYSTEM_PROCESSOR.
+	system, processor string
+	// prefix is prepended to the compiler names, cc and cxx.
+	prefix, cc, cxx string
+	// root is searched for libraries and headers if no sysroot is set.
+	root string
+	// multiarch is the Debian multiarch tuple of the pkg-config directory
+	// in a sysroot.
+	multiarch string
+	// zig is the target of zig cc.
+	zig string
+	// hint explains how to install the compilers.
+	hint string
+}
+
+// crossTargets lists the targets the builder cross compiles for with the
+// GNU, MinGW and osxcross toolchains.
+var crossTargets = map[Target]crossTarget{
+	{"linux", "amd64"}: {
+		system: "Linux", processor: "x86_64", prefix: "x86_64-linux-gnu-", cc: "gcc", cxx: "g++",
+		multiarch: "x86_64-linux-gnu", zig: "x86_64-linux-gnu",
+		hint: "install g++-x86-64-linux-gnu (Debian/Ubuntu) or gcc-c++-x86_64-linux-gnu (Fedora)",
+	},
+	{"linux", "arm64"}: {
+		system: "Linux", processor: "aarch64", prefix: "aarch64-linux-gnu-", cc: "gcc", cxx: "g++",
+		multiarch: "aarch64-linux-gnu", zig: "aarch64-linux-gnu",
+		hint: "install g++-aarch64-linux-gnu (Debian/Ubuntu) or gcc-c++-aarch64-linux-gnu (Fedora)",
+	},
+	{"linux", "arm"}: {
+		system: "Linux", processor: "armv7", prefix: "arm-linux-gnueabihf-", cc: "gcc", cxx: "g++",
+		multiarch: "arm-linux-gnueabihf", zig: "arm-linux-gnueabihf",
+		hint: "install g++-arm-linux-gnueabihf (Debian/Ubuntu)",
+	},
+	{"linux", "riscv64"}: {
+		system: "Linux", processor: "riscv64", prefix: "riscv64-linux-gnu-", cc: "gcc", cxx: "g++",
+		multiarch: "riscv64-linux-gnu", zig: "riscv64-linux-gnu",
+		hint: "install g++-riscv64-linux-gnu (Debian/Ubuntu)",
+	},
+	{"windows", "amd64"}: {
+		system: "Windows", processor: "AMD64", prefix: "x86_64-w64-mingw32-", cc: "gcc", cxx: "g++",
+		root: "/usr/x86_64-w64-mingw32", zig: "x86_64-windows-gnu",
+		hint: "install g++-mingw-w64-x86-64 (Debian/Ubuntu) or mingw64-gcc-c++ (Fedora)",
+	},
+	{"windows", "386"}: {
+		system: "Windows", processor: "x86", prefix: "i686-w64-mingw32-", cc: "gcc", cxx: "g++",
+		root: "/usr/i686-w64-mingw32", zig: "x86-windows-gnu",
+		hint: "install g++-mingw-w64-i686 (Debian/Ubuntu) or mingw32-gcc-c++ (Fedora)",
+	},
+	{"windows", "arm64"}: {
+		system: "Windows", processor: "ARM64", prefix: "aarch64-w64-mingw32-", cc: "clang", cxx: "clang++",
+		zig:  "aarch64-windows-gnu",
+		hint: "install llvm-mingw from https://github.com/mstorsjo/llvm-mingw and add its bin directory to PATH",
+	},
+	{"darwin", "amd64"}: {
+		system: "Darwin", processor: "x86_64", prefix: "o64-", cc: "clang", cxx: "clang++",
+		zig:  "x86_64-macos",
+		hint: "install osxcross from https://github.com/tpoechtrager/osxcross and add its bin directory to PATH",
+	},
+	{"darwin", "arm64"}: {
+		system: "Darwin", processor: "arm64", prefix: "oa64-", cc: "clang", cxx: "clang++",
+		zig:  "aarch64-macos",
+		hint: "install osxcross from https://github.com/tpoechtrager/osxcross and add its bin directory to PATH",
+	},
+}
+
+// cross reports whether the configuration builds for another platform than
+// the host.
+func (c *Config) cross() bool {
+	return c.Target != HostTarget()
+}
+
+// toolchain is the CMake toolchain of a configuration.
+type toolchain struct {
+	// file is the toolchain file to use, relative to the extracted sources
+	// if it is the one of the sources.
+	file string
+	// generated is the content of the toolchain file to generate, if any.
+	generated string
+	// defines are passed to CMake along with the toolchain file.
+	defines []string
+	// chainload passes the generated toolchain file to the vcpkg toolchain
+	// file of the user.
+	chainload bool
+}
+
+// toolchain selects the toolchain of the configuration: the toolchain file
+// of the user, zig cc or a generated toolchain file for cross targets.
+func (c *Config) toolchain() (toolchain, error) {
+	target, known := crossTargets[c.Target]
+
+	if c.ToolchainFile != "" {
+		if c.packageManager() == vcpkg && c.cross() && known {
+			return toolchain{file: c.ToolchainFile, generated: target.file(c.Sysroot), chainload: true}, nil
+		}
+		return toolchain{file: c.ToolchainFile}, nil
+	}
+
+	if c.Zig {
+		if !known {
+			return toolchain{}, fmt.Errorf("build: zig cannot target %s", c.Target)
+		}
+
+		rtn := toolchain{
+			file:    filepath.Join("cmake", "toolchain", "zig.cmake"),
+			defines: []string{"-DTARGET=" + target.zig},
+		}
+		if c.cross() {
+			rtn.defines = append(rtn.defines, "-DCMAKE_SYSTEM_NAME="+target.system, "-DCMAKE_SYSTEM_PROCESSOR="+target.processor)
+		}
+		return rtn, nil
+	}
+
+	if !c.cross() {
+		return toolchain{}, nil
+	}
+	if !known {
+		return toolchain{}, fmt.Errorf("build: unsupported target %s", c.Target)
+	}
+
+	return toolchain{generated: target.file(c.Sysroot)}, nil
+}
+
+// file returns the content of the CMake toolchain file cross compiling for
+// the target with the optional sysroot.
+func (t crossTarget) file(sysroot string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Generated by saucer/build.\n\n")
+	fmt.Fprintf(&b, "set(CMAKE_SYSTEM_NAME %s)\nset(CMAKE_SYSTEM_PROCESSOR %s)\n\n", t.system, t.processor)
+	fmt.Fprintf(&b, "set(CMAKE_C_COMPILER %s%s)\nset(CMAKE_CXX_COMPILER %s%s)\n", t.prefix, t.cc, t.prefix, t.cxx)
+
+	switch t.system {
+	case "Windows":
+		fmt.Fprintf(&b, "set(CMAKE_RC_COMPILER %swindres)\n", t.prefix)
+	case "Darwin":
+		fmt.Fprintf(&b, "set(CMAKE_OSX_ARCHITECTURES %s)\n", t.processor)
+	}
+
+	root := t.root
+	if sysroot != "" {
+		root = filepath.ToSlash(sysroot)
+
+		fmt.Fprintf(&b, "\nset(CMAKE_SYSROOT %q)\n", root)
+		fmt.Fprintf(&b, "set(ENV{PKG_CONFIG_SYSROOT_DIR} %q)\n", root)
+
+		if t.multiarch != "" {
+			fmt.Fprintf(&b, "set(ENV{PKG_CONFIG_LIBDIR} %q)\n",
+				root+"/usr/lib/"+t.multiarch+"/pkgconfig:"+root+"/usr/lib/pkgconfig:"+root+"/usr/share/pkgconfig")
+		}
+	}
+
+	if root != "" {
+		fmt.Fprintf(&b, "\nset(CMAKE_FIND_ROOT_PATH %q)\n", root)
+		b.WriteString("set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)\n")
+		b.WriteString("set(CMAKE_FIND_ROOT_PATH_MODE_LIBRARY ONLY)\n")
+		b.WriteString("set(CMAKE_FIND_ROOT_PATH_MODE_INCLUDE ONLY)\n")
+		b.WriteString("set(CMAKE_FIND_ROOT_PATH_MODE_PACKAGE ONLY)\n")
+	}
+
+	return b.String()
+}
+
+// toolchainArgs writes the toolchain file of the configuration, if it is
+// generated, and returns the CMake arguments selecting the toolchain.
+func (b *Builder) toolchainArgs() ([]string, error) {
+	tc, err := b.cfg.toolchain()
+	if err != nil {
+		return nil, err
+	}
+
+	file := tc.file
+	switch {
+	case tc.generated != "":
+		generated := filepath.Join(b.cfg.Dir, "toolchain-"+b.cfg.Target.GOOS+"-"+b.cfg.Target.GOARCH+".cmake")
+		if err := os.WriteFile(generated, []byte(tc.generated), 0o644); err != nil {
+			return nil, fmt.Errorf("build: write toolchain file: %w", err)
+		}
+
+		if !tc.chainload {
+			file = generated
+			break
+		}
+
+		if generated, err = filepath.Abs(generated); err != nil {
+			return nil, err
+		}
+		tc.defines = append(tc.defines, "-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE="+filepath.ToSlash(generated))
+	case b.cfg.Zig && b.cfg.ToolchainFile == "":
+		file = filepath.Join(b.SourceDir(), file)
+	case file == "":
+		return nil, nil
+	}
+
+	if file, err = filepath.Abs(file); err != nil {
+		return nil, err
+	}
+	return append([]string{"-DCMAKE_TOOLCHAIN_FILE=" + filepath.ToSlash(file)}, tc.defines...), nil
+}
+
+// CheckToolchain verifies that the compilers and the sysroot of the
+// configured target exist. Native builds are checked by CheckDependencies.
+func (b *Builder) CheckToolchain() error {
+	if b.cfg.ToolchainFile != "" {
+		if _, err := os.Stat(b.cfg.ToolchainFile); err != nil {
+			return fmt.Errorf("build: toolchain file: %w", err)
+		}
+		return nil
+	}
+
+	if b.cfg.Sysroot != "" {
+		if info, err := os.Stat(b.cfg.Sysroot); err != nil || !info.IsDir() {
+			return fmt.Errorf("build: sysroot %s is not a directory", b.cfg.Sysroot)
+		}
+	}
+
+	if b.cfg.Zig {
+		if _, err := exec.LookPath("zig"); err != nil {
+			return errors.New("build: zig not found, install it from https://ziglang.org/download")
+		}
+		return nil
+	}
+
+	if !b.cfg.cross() {
+		return nil
+	}
+
+	target, ok := crossTargets[b.cfg.Target]
+	if !ok {
+		return fmt.Errorf("build: unsupported target %s", b.cfg.Target)
+	}
+
+	for _, tool := range []string{target.prefix + target.cc, target.prefix + target.cxx} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("build: %s for %s not found, %s", tool, b.cfg.Target, target.hint)
+		}
+	}
+	return nil
+}
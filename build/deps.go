@@ -0,0 +1,160 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// MinCMakeVersion is the oldest CMake release able to configure saucer.
+const MinCMakeVersion = "3.25"
+
+// Dependency is the result of probing a single build requirement.
+type Dependency struct {
+	// Name identifies the requirement, e.g. "cmake" or a pkg-config package.
+	Name string `json:"name"`
+	// MinVersion is the oldest supported version, empty if any will do.
+	MinVersion string `json:"minVersion,omitempty"`
+	// Version is the detected version, empty if unknown or not found.
+	Version string `json:"version,omitempty"`
+	// Found reports whether the requirement is satisfied.
+	Found bool `json:"found"`
+	// Hint explains how to install the requirement when it is not found.
+	Hint string `json:"hint,omitempty"`
+}
+
+// ErrMissingDependencies is wrapped by the error of CheckDependencies.
+var ErrMissingDependencies = errors.New("build: missing dependencies")
+
+// cxx23Probe uses C++23 language and library features saucer depends on.
+const cxx23Probe = `#include <expected>
+struct probe
+{
+    std::expected<int, int> value(this const probe &) { return 0; }
+};
+`
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// CheckDependencies probes the host for everything needed to build saucer
+// with backend, see Dependency. The returned error wraps
+// ErrMissingDependencies and lists the unsatisfied requirements; the
+// dependencies are returned either way.
+func CheckDependencies(backend Backend) ([]Dependency, error) {
+	ctx := context.Background()
+
+	rtn := []Dependency{checkCMake(ctx, "cmake"), checkCompiler(ctx, (&Config{}).compiler())}
+
+	// The WebView2 SDK is fetched from NuGet by the configure step and needs
+	// no probing.
+	switch backend.resolve(runtime.GOOS) {
+	case BackendQt:
+		for _, pkg := range []string{"Qt6Widgets", "Qt6WebChannel", "Qt6WebEngineWidgets"} {
+			rtn = append(rtn, checkPkgConfig(ctx, pkg, "6.7.0",
+				"install the Qt 6 WebEngine development files, e.g. qt6-webengine-dev (Debian/Ubuntu) or qt6-qtwebengine-devel (Fedora)"))
+		}
+	case BackendWebKitGtk:
+		rtn = append(rtn,
+			checkPkgConfig(ctx, "gtk4", "4.12", "install libgtk-4-dev (Debian/Ubuntu) or gtk4-devel (Fedora)"),
+			checkPkgConfig(ctx, "libadwaita-1", "", "install libadwaita-1-dev (Debian/Ubuntu) or libadwaita-devel (Fedora)"),
+			checkPkgConfig(ctx, "webkitgtk-6.0", "", "install libwebkitgtk-6.0-dev (Debian/Ubuntu) or webkitgtk6.0-devel (Fedora)"),
+			checkPkgConfig(ctx, "json-glib-1.0", "", "install libjson-glib-dev (Debian/Ubuntu) or json-glib-devel (Fedora)"),
+			checkPkgConfig(ctx, "gio-unix-2.0", "", "install libglib2.0-dev (Debian/Ubuntu) or glib2-devel (Fedora)"),
+		)
+	case BackendWebKit:
+		rtn = append(rtn, checkTool(ctx, "xcrun", []string{"--show-sdk-version"},
+			"install the Xcode command line tools: xcode-select --install"))
+	}
+
+	var missing []string
+	for _, dep := range rtn {
+		if !dep.Found {
+			missing = append(missing, dep.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return rtn, fmt.Errorf("%w: %s", ErrMissingDependencies, strings.Join(missing, ", "))
+	}
+	return rtn, nil
+}
+
+// checkCMake probes the cmake executable.
+func checkCMake(ctx context.Context, cmake string) Dependency {
+	dep := checkTool(ctx, cmake, []string{"--version"}, "install CMake "+MinCMakeVersion+" or newer from https://cmake.org/download")
+	dep.Name, dep.MinVersion = "cmake", MinCMakeVersion
+	dep.Found = dep.Found && versionAtLeast(dep.Version, MinCMakeVersion)
+	return dep
+}
+
+// checkCompiler verifies that cxx compiles C++23.
+func checkCompiler(ctx context.Context, cxx string) Dependency {
+	dep := checkTool(ctx, cxx, []string{"--version"}, "install a C++23 compiler: GCC 14, Clang 18, Apple Clang 16 or MSVC 19.40 or newer")
+	dep.Name, dep.MinVersion = "C++23 compiler ("+cxx+")", ""
+
+	if !dep.Found {
+		return dep
+	}
+
+	cmd := exec.CommandContext(ctx, cxx, "-std=c++23", "-fsyntax-only", "-x", "c++", "-")
+	cmd.Stdin = strings.NewReader(cxx23Probe)
+
+	dep.Found = cmd.Run() == nil
+	return dep
+}
+
+// checkPkgConfig probes a pkg-config package.
+func checkPkgConfig(ctx context.Context, pkg, min, hint string) Dependency {
+	dep := Dependency{Name: pkg, MinVersion: min, Hint: hint}
+
+	out, err := exec.CommandContext(ctx, "pkg-config", "--modversion", pkg).Output()
+	if err != nil {
+		if _, err := exec.LookPath("pkg-config"); err != nil {
+			dep.Hint = "install pkg-config, then " + hint
+		}
+		return dep
+	}
+
+	dep.Version = strings.TrimSpace(string(out))
+	dep.Found = min == "" || versionAtLeast(dep.Version, min)
+	return dep
+}
+
+// checkTool runs tool with args and extracts a version from its output.
+func checkTool(ctx context.Context, tool string, args []string, hint string) Dependency {
+	dep := Dependency{Name: tool, Hint: hint}
+
+	out, err := exec.CommandContext(ctx, tool, args...).CombinedOutput()
+	if err != nil {
+		return dep
+	}
+
+	dep.Found = true
+	dep.Version = versionPattern.FindString(string(bytes.TrimSpace(out)))
+	return dep
+}
+
+// versionAtLeast reports whether the dotted version v is at least min.
+func versionAtLeast(v, min string) bool {
+	have, want := strings.Split(v, "."), strings.Split(min, ".")
+
+	for i, w := range want {
+		if i >= len(have) {
+			return false
+		}
+
+		h, _ := strconv.Atoi(have[i])
+		n, _ := strconv.Atoi(w)
+
+		if h != n {
+			return h > n
+		}
+	}
+	return true
+}
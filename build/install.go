@@ -0,0 +1,242 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefixVar stands for the install prefix in the flags of an install tree.
+const prefixVar = "${prefix}"
+
+// Installation is an install tree written by Builder.Install.
+type Installation struct {
+	// Prefix is the absolute directory of the tree.
+	Prefix string `json:"-"`
+	// Version and UpstreamTag identify the saucer release of the library.
+	Version     string `json:"version"`
+	UpstreamTag string `json:"upstreamTag"`
+	// Target, Backend, BuildType and Profile are those of the build, the
+	// backend resolved for the target.
+	Target    Target    `json:"target"`
+	Backend   Backend   `json:"backend"`
+	BuildType BuildType `json:"buildType"`
+	Profile   Profile   `json:"profile,omitempty"`
+	// Artifacts are the library and include directories below Prefix.
+	Artifacts *Artifacts `json:"artifacts"`
+	// Flags are the cgo directives for linking against the tree.
+	Flags CgoFlags `json:"flags"`
+}
+
+// InstallName returns the name of the versioned directory of the build
+// below an artifact store, e.g. "saucer-8.1.0-linux-amd64-webkitgtk-release",
+// so builds of other releases and configurations can share a prefix:
+//
+//	b.Install(ctx, filepath.Join(store, b.InstallName()))
+func (b *Builder) InstallName() string {
+	config := string(b.cfg.BuildType)
+	if b.cfg.Profile != "" {
+		config = string(b.cfg.Profile)
+	}
+
+	return strings.ToLower(strings.Join([]string{
+		"saucer", b.release.Version, b.cfg.Target.GOOS, b.cfg.Target.GOARCH,
+		string(b.cfg.Backend.resolve(b.cfg.Target.GOOS)), config,
+	}, "-"))
+}
+
+// Install builds the library like Build and installs it to prefix, so it can
+// be checked into an artifact store or mounted into containers and linked
+// without rebuilding, see LoadInstalled:
+//
+//	prefix/lib/libsaucer.a (saucer.lib with MSVC) and the WebView2 loader
+//	prefix/lib/pkgconfig/saucer.pc
+//	prefix/include/<project>, one include root per project
+//	prefix/share/saucer/VERSION
+//	prefix/share/saucer/install.json
+//
+// The tree is relocatable: install.json records the flags relative to the
+// prefix and pkg-config relocates saucer.pc with --define-prefix. Other files
+// in prefix are left alone, install.json is written last and marks a
+// complete tree.
+func (b *Builder) Install(ctx context.Context, prefix string) (*Installation, error) {
+	if prefix == "" {
+		return nil, errors.New("build: install prefix is required")
+	}
+
+	prefix, err := filepath.Abs(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	art, err := b.Build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rtn, err := b.install(art, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("build: install: %w", err)
+	}
+	return rtn, nil
+}
+
+// install copies art and the WebView2 loader to prefix and writes the
+// metadata of the tree.
+func (b *Builder) install(art *Artifacts, prefix string) (*Installation, error) {
+	lib := filepath.Join(prefix, "lib")
+
+	// The tree counts as incomplete until install.json is written again
+	if err := os.Remove(filepath.Join(prefix, "share", "saucer", "install.json")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	installed := &Artifacts{Library: filepath.Join(lib, filepath.Base(art.Library))}
+	if err := copyFile(installed.Library, art.Library); err != nil {
+		return nil, err
+	}
+
+	for _, dir := range art.IncludeDirs {
+		dst := filepath.Join(prefix, "include", includeName(dir))
+		if err := os.RemoveAll(dst); err != nil {
+			return nil, err
+		}
+		if err := os.CopyFS(dst, os.DirFS(dir)); err != nil {
+			return nil, err
+		}
+		installed.IncludeDirs = append(installed.IncludeDirs, dst)
+	}
+
+	loader := b.webview2Loader()
+	if loader != "" {
+		dst := filepath.Join(lib, filepath.Base(loader))
+		if err := copyFile(dst, loader); err != nil {
+			return nil, err
+		}
+		loader = dst
+	}
+
+	rtn := &Installation{
+		Prefix:      prefix,
+		Version:     b.release.Version,
+		UpstreamTag: b.release.Tag,
+		Target:      b.cfg.Target,
+		Backend:     b.cfg.Backend.resolve(b.cfg.Target.GOOS),
+		BuildType:   b.cfg.BuildType,
+		Profile:     b.cfg.Profile,
+		Artifacts:   installed,
+		Flags:       b.cgoFlags(installed, loader),
+	}
+
+	share := filepath.Join(prefix, "share", "saucer")
+	if err := os.MkdirAll(share, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(share, "VERSION"), []byte(rtn.Version+"\n"), 0o644); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(lib, "pkgconfig"), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(lib, "pkgconfig", "saucer.pc"), rtn.pkgConfig(), 0o644); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(rtn.relative(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(share, "install.json"), append(data, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	return rtn, nil
+}
+
+// relative returns i with the paths below the prefix replaced by prefixVar.
+func (i *Installation) relative() *Installation {
+	return i.rewrite(func(path string) string {
+		return strings.ReplaceAll(path, i.Prefix, prefixVar)
+	})
+}
+
+// rewrite returns a copy of i with fn applied to its paths and flags.
+func (i *Installation) rewrite(fn func(string) string) *Installation {
+	rtn := *i
+
+	rtn.Artifacts = &Artifacts{Library: fn(i.Artifacts.Library)}
+	for _, dir := range i.Artifacts.IncludeDirs {
+		rtn.Artifacts.IncludeDirs = append(rtn.Artifacts.IncludeDirs, fn(dir))
+	}
+
+	rewriteAll := func(flags []string) []string {
+		out := make([]string, len(flags))
+		for j, flag := range flags {
+			out[j] = fn(flag)
+		}
+		return out
+	}
+
+	rtn.Flags.CXXFlags = rewriteAll(i.Flags.CXXFlags)
+	rtn.Flags.LDFlags = rewriteAll(i.Flags.LDFlags)
+	return &rtn
+}
+
+// pkgConfig returns the pkg-config file of the tree.
+func (i *Installation) pkgConfig() []byte {
+	rel := i.relative()
+
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "prefix=%s\n\n", filepath.ToSlash(i.Prefix))
+	fmt.Fprintf(&buf, "Name: saucer\nDescription: saucer %s webview library, %s %s\nVersion: %s\n",
+		i.UpstreamTag, i.Backend, i.Target, i.Version)
+
+	if len(i.Flags.PkgConfig) > 0 {
+		fmt.Fprintf(&buf, "Requires: %s\n", strings.Join(i.Flags.PkgConfig, " "))
+	}
+
+	fmt.Fprintf(&buf, "Cflags: %s\n", strings.Join(quoteFlags(rel.Flags.CXXFlags), " "))
+	fmt.Fprintf(&buf, "Libs: %s\n", strings.Join(quoteFlags(rel.Flags.LDFlags), " "))
+	return []byte(strings.ReplaceAll(buf.String(), `\`, "/"))
+}
+
+// LoadInstalled returns the install tree Builder.Install wrote to prefix,
+// its artifacts and flags resolved against prefix, wherever the tree was
+// moved or mounted:
+//
+//	inst, err := build.LoadInstalled("/opt/saucer")
+//	err = build.WriteCgoFlags("zz_saucer.go", inst.Flags)
+func LoadInstalled(prefix string) (*Installation, error) {
+	prefix, err := filepath.Abs(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(prefix, "share", "saucer", "install.json"))
+	if err != nil {
+		return nil, fmt.Errorf("build: no complete install tree in %s: %w", prefix, err)
+	}
+
+	var rel Installation
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return nil, fmt.Errorf("build: %s: %w", prefix, err)
+	}
+	if rel.Artifacts == nil {
+		return nil, fmt.Errorf("build: %s: install.json lacks the artifacts", prefix)
+	}
+
+	rtn := rel.rewrite(func(path string) string {
+		return filepath.FromSlash(strings.ReplaceAll(path, prefixVar, filepath.ToSlash(prefix)))
+	})
+	rtn.Prefix = prefix
+
+	if _, err := os.Stat(rtn.Artifacts.Library); err != nil {
+		return nil, fmt.Errorf("build: %s: %w", prefix, err)
+	}
+	return rtn, nil
+}
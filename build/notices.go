@@ -0,0 +1,70 @@
+package build
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/aperturerobotics/saucer"
+)
+
+// licenseFile matches the names of the license files of dependencies.
+var licenseFile = regexp.MustCompile(`(?i)^(license|licence|copying)([.-].*)?$`)
+
+// Notices returns the notices of the C++ dependencies of the build: the
+// license texts of the packages vendored to Config.DepsDir, see Vendor, and
+// a reference to the repository of the others.
+func (b *Builder) Notices() ([]saucer.Notice, error) {
+	pkgs, err := b.lockedPackages()
+	if err != nil {
+		return nil, err
+	}
+
+	rtn := make([]saucer.Notice, len(pkgs))
+	for i, pkg := range pkgs {
+		rtn[i] = saucer.Notice{Name: pkg.Name, Version: pkg.Version + pkg.Tag, URL: pkg.Repository}
+
+		if pkg.Dir != "" {
+			rtn[i].Text = readLicense(filepath.Join(b.cfg.DepsDir, pkg.Dir))
+			rtn[i].License = saucer.DetectLicense(rtn[i].Text)
+		}
+	}
+	return rtn, nil
+}
+
+// WriteNotices writes the THIRD_PARTY_NOTICES document of the sources and
+// the C++ dependencies of the build, see saucer.WriteNotices.
+func (b *Builder) WriteNotices(w io.Writer) error {
+	notices, err := saucer.NoticesFS(b.cfg.Source)
+	if err != nil {
+		return err
+	}
+
+	deps, err := b.Notices()
+	if err != nil {
+		return err
+	}
+
+	return saucer.RenderNotices(w, append(notices, deps...))
+}
+
+// readLicense returns the text of the license file in dir, empty if there
+// is none.
+func readLicense(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !licenseFile.MatchString(entry.Name()) {
+			continue
+		}
+
+		if data, err := os.ReadFile(filepath.Join(dir, entry.Name())); err == nil {
+			return string(data)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Package managers recognized by their toolchain files.
+const (
+	vcpkg = "vcpkg"
+	conan = "conan"
+)
+
+// packageManager returns the package manager whose CMake toolchain file is
+// Config.ToolchainFile: vcpkg.cmake of vcpkg or conan_toolchain.cmake
+// generated by the CMakeToolchain generator of Conan. It is empty for other
+// toolchain files.
+func (c *Config) packageManager() string {
+	switch strings.ToLower(filepath.Base(c.ToolchainFile)) {
+	case "vcpkg.cmake":
+		return vcpkg
+	case "conan_toolchain.cmake":
+		return conan
+	}
+	return ""
+}
+
+// vcpkgTriplets maps the targets to the vcpkg triplets building static
+// libraries for them.
+var vcpkgTriplets = map[Target]string{
+	{"linux", "amd64"}:   "x64-linux",
+	{"linux", "arm64"}:   "arm64-linux",
+	{"linux", "arm"}:     "arm-linux",
+	{"linux", "riscv64"}: "riscv64-linux",
+	{"windows", "amd64"}: "x64-windows-static-md",
+	{"windows", "386"}:   "x86-windows-static-md",
+	{"windows", "arm64"}: "arm64-windows-static-md",
+	{"darwin", "amd64"}:  "x64-osx",
+	{"darwin", "arm64"}:  "arm64-osx",
+}
+
+// nativeDeps are the dependencies of a build the package managers resolve
+// and those they leave to the system.
+type nativeDeps struct {
+	// vcpkg are the vcpkg ports.
+	vcpkg       []string
+	vcpkgSystem []string
+	// conan are the Conan references, conanOptions their options.
+	conan        []string
+	conanOptions []string
+	conanSystem  []string
+}
+
+// nativeDeps returns the dependencies of the backend and serializer of the
+// configuration. The header-only libraries of the saucer project, such as
+// lockpp and coco, are packaged by neither and always fetched by CPM.
+func (c *Config) nativeDeps() nativeDeps {
+	var rtn nativeDeps
+
+	switch c.Defines["saucer_serializer"] {
+	case "", "Glaze":
+		rtn.vcpkg = append(rtn.vcpkg, "glaze")
+		rtn.conan = append(rtn.conan, "glaze/[>=6.4.0 <7]")
+	case "Rflpp":
+		rtn.vcpkg = append(rtn.vcpkg, "reflectcpp")
+		rtn.conan = append(rtn.conan, "reflect-cpp/[>=0.22.0]")
+	}
+
+	switch c.Backend.resolve(c.Target.GOOS) {
+	case BackendQt:
+		rtn.vcpkg = append(rtn.vcpkg, "qtbase", "qtwebchannel", "qtwebengine")
+		rtn.conan = append(rtn.conan, "qt/[>=6.7.0 <7]")
+		rtn.conanOptions = append(rtn.conanOptions, "qt/*:qtwebchannel=True", "qt/*:qtwebengine=True")
+	case BackendWebKitGtk:
+		rtn.vcpkg = append(rtn.vcpkg, "gtk", "json-glib")
+		rtn.vcpkgSystem = append(rtn.vcpkgSystem, "libadwaita-1", "webkitgtk-6.0")
+		rtn.conanSystem = append(rtn.conanSystem, "gtk4", "json-glib-1.0", "libadwaita-1", "webkitgtk-6.0")
+	case BackendWebView2:
+		rtn.vcpkg = append(rtn.vcpkg, "webview2")
+		rtn.conanSystem = append(rtn.conanSystem, "the Microsoft.Web.WebView2 NuGet package")
+	}
+
+	return rtn
+}
+
+// ExportVcpkgManifest returns a vcpkg.json manifest of the native
+// dependencies of the build, for teams resolving them with vcpkg instead of
+// system packages. Configuring with the vcpkg.cmake toolchain file as
+// Config.ToolchainFile installs them with the builder.
+func (b *Builder) ExportVcpkgManifest() ([]byte, error) {
+	deps := b.cfg.nativeDeps()
+
+	comment := fmt.Sprintf("Generated by saucer/build for saucer %s, backend %s on %s.",
+		b.release.Version, b.cfg.Backend.resolve(b.cfg.Target.GOOS), b.cfg.Target)
+	if len(deps.vcpkgSystem) > 0 {
+		comment += " Not packaged by vcpkg, install from the system: " + strings.Join(deps.vcpkgSystem, ", ") + "."
+	}
+
+	manifest := struct {
+		Comment      string   `json:"$comment"`
+		Name         string   `json:"name"`
+		Version      string   `json:"version-string"`
+		Dependencies []string `json:"dependencies"`
+	}{comment, "saucer-deps", b.release.Version, deps.vcpkg}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ExportConanfile returns a conanfile.txt of the native dependencies of the
+// build. Install them with conan install and configure with the generated
+// conan_toolchain.cmake as Config.ToolchainFile.
+func (b *Builder) ExportConanfile() ([]byte, error) {
+	deps := b.cfg.nativeDeps()
+
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "# Generated by saucer/build for saucer %s, backend %s on %s.\n",
+		b.release.Version, b.cfg.Backend.resolve(b.cfg.Target.GOOS), b.cfg.Target)
+
+	if len(deps.conanSystem) > 0 {
+		fmt.Fprintf(&buf, "# Not packaged by Conan, install from the system: %s.\n", strings.Join(deps.conanSystem, ", "))
+	}
+
+	buf.WriteString("\n[requires]\n")
+	for _, ref := range deps.conan {
+		buf.WriteString(ref + "\n")
+	}
+
+	if len(deps.conanOptions) > 0 {
+		buf.WriteString("\n[options]\n")
+		for _, opt := range deps.conanOptions {
+			buf.WriteString(opt + "\n")
+		}
+	}
+
+	buf.WriteString("\n[generators]\nCMakeDeps\nCMakeToolchain\n")
+	return []byte(buf.String()), nil
+}
+
+// packageManagerArgs returns the CMake arguments of a package manager
+// toolchain file: CPM looks for installed packages first, and vcpkg reads the
+// exported manifest from the build dir and the triplet of the target.
+func (b *Builder) packageManagerArgs() ([]string, error) {
+	pm := b.cfg.packageManager()
+	if pm == "" {
+		return nil, nil
+	}
+
+	args := []string{"-Dsaucer_prefer_remote=OFF"}
+	if pm != vcpkg {
+		return args, nil
+	}
+
+	manifest, err := b.ExportVcpkgManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(filepath.Join(b.cfg.Dir, "vcpkg"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "vcpkg.json"), manifest, 0o644); err != nil {
+		return nil, fmt.Errorf("build: write vcpkg manifest: %w", err)
+	}
+
+	args = append(args, "-DVCPKG_MANIFEST_DIR="+filepath.ToSlash(dir))
+	if _, ok := b.cfg.Defines["VCPKG_TARGET_TRIPLET"]; !ok {
+		if triplet, ok := vcpkgTriplets[b.cfg.Target]; ok {
+			args = append(args, "-DVCPKG_TARGET_TRIPLET="+triplet)
+		}
+	}
+	return args, nil
+}
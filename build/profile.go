@@ -0,0 +1,85 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Profile is a named build configuration: a CMake build type, optionally
+// instrumented with a sanitizer.
+type Profile string
+
+// Build profiles. The sanitizer profiles compile with debug information and
+// frame pointers and are supported with GCC and Clang on Linux and macOS.
+const (
+	ProfileRelease        Profile = "Release"
+	ProfileDebug          Profile = "Debug"
+	ProfileRelWithDebInfo Profile = "RelWithDebInfo"
+	// ProfileASan instruments the library with AddressSanitizer. Build the
+	// Go program with -asan so Go and C++ share the runtime.
+	ProfileASan Profile = "ASan"
+	// ProfileUBSan instruments the library with UndefinedBehaviorSanitizer.
+	ProfileUBSan Profile = "UBSan"
+	// ProfileTSan instruments the library with ThreadSanitizer. It cannot be
+	// combined with the race detector of Go, which brings its own runtime.
+	ProfileTSan Profile = "TSan"
+)
+
+// profile is what a Profile configures.
+type profile struct {
+	buildType BuildType
+	sanitizer string
+}
+
+// profiles maps the profiles to their configuration.
+var profiles = map[Profile]profile{
+	ProfileRelease:        {buildType: Release},
+	ProfileDebug:          {buildType: Debug},
+	ProfileRelWithDebInfo: {buildType: RelWithDebInfo},
+	ProfileASan:           {buildType: RelWithDebInfo, sanitizer: "address"},
+	ProfileUBSan:          {buildType: RelWithDebInfo, sanitizer: "undefined"},
+	ProfileTSan:           {buildType: RelWithDebInfo, sanitizer: "thread"},
+}
+
+// checkProfile reports an unknown profile or a sanitizer the target does not
+// support.
+func (c *Config) checkProfile() error {
+	if c.Profile == "" {
+		return nil
+	}
+
+	p, ok := profiles[c.Profile]
+	if !ok {
+		return fmt.Errorf("build: unknown profile %q", c.Profile)
+	}
+
+	if p.sanitizer != "" && c.Target.GOOS == "windows" {
+		return fmt.Errorf("build: profile %s is not supported for windows", c.Profile)
+	}
+	return nil
+}
+
+// sanitizerFlags returns the compiler and linker flags of the sanitizer of
+// the profile, nil without one.
+func (c *Config) sanitizerFlags() []string {
+	p := profiles[c.Profile]
+	if p.sanitizer == "" {
+		return nil
+	}
+	return []string{"-fsanitize=" + p.sanitizer, "-fno-omit-frame-pointer"}
+}
+
+// profileArgs returns the CMake arguments adding the sanitizer flags to the
+// compiler flags, keeping those of the environment.
+func (c *Config) profileArgs() []string {
+	flags := c.sanitizerFlags()
+	if flags == nil {
+		return nil
+	}
+
+	sanitize := strings.Join(flags, " ")
+	join := func(env string) string { return strings.TrimSpace(os.Getenv(env) + " " + sanitize) }
+
+	return []string{"-DCMAKE_C_FLAGS=" + join("CFLAGS"), "-DCMAKE_CXX_FLAGS=" + join("CXXFLAGS")}
+}
@@ -0,0 +1,231 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Phase is a stage of a build.
+type Phase string
+
+const (
+	// PhaseConfigure runs the CMake configure step.
+	PhaseConfigure Phase = "configure"
+	// PhaseCompile compiles the translation units.
+	PhaseCompile Phase = "compile"
+	// PhaseLink archives the static library.
+	PhaseLink Phase = "link"
+)
+
+// Progress reports the state of a build to Config.Progress.
+type Progress struct {
+	// Phase is the current stage.
+	Phase Phase
+	// Percent is the completed share of the build step, -1 if unknown.
+	Percent int
+	// Unit is the translation unit being compiled or the library being
+	// linked, e.g. "src/app.cpp".
+	Unit string
+}
+
+// CompileError is the first error reported by the compiler or CMake in a
+// failed step, extracted from its output.
+type CompileError struct {
+	// Step is the failed cmake step, "configure" or "build".
+	Step string
+	// File, Line and Column locate the error, Column being 0 if unknown.
+	File   string
+	Line   int
+	Column int
+	// Message is the error message of the compiler.
+	Message string
+	// Log holds the last lines of the output of the step.
+	Log string
+	// Err is the error of the cmake process.
+	Err error
+}
+
+func (e *CompileError) Error() string {
+	pos := e.File + ":" + strconv.Itoa(e.Line)
+	if e.Column > 0 {
+		pos += ":" + strconv.Itoa(e.Column)
+	}
+	return fmt.Sprintf("build: cmake %s: %s: %s", e.Step, pos, e.Message)
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// makeProgress matches the progress lines of the Makefile generators,
+	// e.g. "[ 42%] Building CXX object CMakeFiles/saucer.dir/src/app.cpp.o".
+	makeProgress = regexp.MustCompile(`^\[\s*(\d+)%\] (Building|Linking) \S+ (?:object|static library) (\S+)`)
+	// ninjaProgress matches the progress lines of Ninja, e.g.
+	// "[3/40] Building CXX object CMakeFiles/saucer.dir/src/app.cpp.o".
+	ninjaProgress = regexp.MustCompile(`^\[(\d+)/(\d+)\] (Building|Linking) \S+ (?:object|static library) (\S+)`)
+
+	// gccError matches GCC and Clang diagnostics, e.g.
+	// "/src/app.cpp:12:3: error: expected ';'".
+	gccError = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)? (?:fatal )?error: (.+)$`)
+	// msvcError matches MSVC diagnostics, e.g.
+	// "C:\src\app.cpp(12,3): error C2143: syntax error".
+	msvcError = regexp.MustCompile(`^\s*(.+?)\((\d+)(?:,(\d+))?\): (?:fatal )?error (\w+: .+?)(?: \[.+\])?$`)
+	// cmakeError matches the errors of CMake scripts, e.g.
+	// "CMake Error at CMakeLists.txt:42 (message):", the message following
+	// on the next lines.
+	cmakeError = regexp.MustCompile(`^CMake Error at (.+?):(\d+)`)
+)
+
+// logTail is the number of lines of output kept for errors.
+const logTail = 40
+
+// output processes the output of a cmake step line by line: it is copied to
+// Config.Log, reported to Config.Progress and scanned for the first error.
+type output struct {
+	step     string
+	log      io.Writer
+	progress func(Progress)
+
+	partial []byte
+	lines   []string
+	first   *CompileError
+	// cmake is set while the message of a CMake error is collected.
+	cmake bool
+}
+
+func (o *output) Write(data []byte) (int, error) {
+	if o.log != nil {
+		// A failing log must not fail the build
+		_, _ = o.log.Write(data)
+	}
+
+	o.partial = append(o.partial, data...)
+
+	for {
+		i := bytes.IndexByte(o.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		o.line(strings.TrimRight(string(o.partial[:i]), "\r"))
+		o.partial = o.partial[i+1:]
+	}
+
+	return len(data), nil
+}
+
+// line processes a complete line of output.
+func (o *output) line(line string) {
+	o.lines = append(o.lines, line)
+	if len(o.lines) > logTail {
+		o.lines = o.lines[1:]
+	}
+
+	o.report(line)
+	o.scan(line)
+}
+
+// report passes the progress of line to Config.Progress.
+func (o *output) report(line string) {
+	if o.progress == nil || o.step != "build" {
+		return
+	}
+
+	var (
+		percent      int
+		action, unit string
+	)
+
+	if m := makeProgress.FindStringSubmatch(line); m != nil {
+		percent, _ = strconv.Atoi(m[1])
+		action, unit = m[2], m[3]
+	} else if m := ninjaProgress.FindStringSubmatch(line); m != nil {
+		done, _ := strconv.Atoi(m[1])
+		total, _ := strconv.Atoi(m[2])
+
+		percent = -1
+		if total > 0 {
+			percent = done * 100 / total
+		}
+		action, unit = m[3], m[4]
+	} else {
+		return
+	}
+
+	phase := PhaseCompile
+	if action == "Linking" {
+		phase = PhaseLink
+	}
+
+	o.progress(Progress{Phase: phase, Percent: percent, Unit: objectSource(unit)})
+}
+
+// objectSource returns the source of the object file of a CMake target,
+// e.g. "src/app.cpp" for "CMakeFiles/saucer.dir/src/app.cpp.o".
+func objectSource(object string) string {
+	if _, rest, ok := strings.Cut(object, ".dir/"); ok {
+		object = rest
+	}
+
+	for _, ext := range []string{".o", ".obj"} {
+		if trimmed, ok := strings.CutSuffix(object, ext); ok {
+			return trimmed
+		}
+	}
+	return object
+}
+
+// scan records the first error found in the output.
+func (o *output) scan(line string) {
+	if o.cmake {
+		// The message of a CMake error is indented on the following lines
+		if text := strings.TrimSpace(line); text != "" {
+			o.first.Message = strings.TrimSpace(o.first.Message + " " + text)
+		} else if o.first.Message != "" {
+			o.cmake = false
+		}
+		return
+	}
+
+	if o.first != nil {
+		return
+	}
+
+	if m := gccError.FindStringSubmatch(line); m != nil {
+		o.first = &CompileError{File: m[1], Line: atoi(m[2]), Column: atoi(m[3]), Message: m[4]}
+	} else if m := msvcError.FindStringSubmatch(line); m != nil {
+		o.first = &CompileError{File: m[1], Line: atoi(m[2]), Column: atoi(m[3]), Message: m[4]}
+	} else if m := cmakeError.FindStringSubmatch(line); m != nil {
+		o.first = &CompileError{File: m[1], Line: atoi(m[2])}
+		o.cmake = true
+	}
+}
+
+// result returns the error of the step failing with err: the first error
+// found in the output or err with the last lines of the output.
+func (o *output) result(err error) error {
+	if len(o.partial) > 0 {
+		o.line(string(o.partial))
+		o.partial = nil
+	}
+
+	log := strings.Join(o.lines, "\n")
+
+	if o.first == nil || o.first.Message == "" {
+		return fmt.Errorf("build: cmake %s: %w\n%s", o.step, err, log)
+	}
+
+	o.first.Step, o.first.Log, o.first.Err = o.step, log, err
+	return o.first
+}
+
+// atoi parses a decimal number, 0 if s is empty.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,41 @@
+package build
+
+import (
+	"path/filepath"
+
+	"github.com/aperturerobotics/saucer"
+)
+
+// SBOMPackages returns the locked packages for saucer.SBOM.
+func (l *Lock) SBOMPackages() []saucer.SBOMPackage {
+	rtn := make([]saucer.SBOMPackage, len(l.Packages))
+	for i, pkg := range l.Packages {
+		rtn[i] = saucer.SBOMPackage{
+			Name:       pkg.Name,
+			Version:    pkg.Version + pkg.Tag,
+			Repository: pkg.Repository,
+			Commit:     pkg.Commit,
+		}
+	}
+	return rtn
+}
+
+// SBOM returns the software bill of materials of the sources of the build
+// in format. It lists the C++ dependencies with their commits and licenses
+// if they were vendored to Config.DepsDir, see Vendor, and as declared by
+// the sources otherwise.
+func (b *Builder) SBOM(format saucer.SBOMFormat) ([]byte, error) {
+	pkgs, err := b.lockedPackages()
+	if err != nil {
+		return nil, err
+	}
+
+	deps := (&Lock{Packages: pkgs}).SBOMPackages()
+	for i, pkg := range pkgs {
+		if pkg.Dir != "" {
+			deps[i].License = saucer.DetectLicense(readLicense(filepath.Join(b.cfg.DepsDir, pkg.Dir)))
+		}
+	}
+
+	return saucer.SBOMFS(b.cfg.Source, format, deps...)
+}
@@ -0,0 +1,275 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// LockFile is the name of the lockfile Vendor writes to Config.DepsDir.
+const LockFile = "saucer-deps.lock"
+
+// Package is a CMake dependency of saucer, fetched by CPM.
+type Package struct {
+	// Name is the CPM package name, e.g. "lockpp".
+	Name string `json:"name"`
+	// Version is the required version, empty if pinned by Tag.
+	Version string `json:"version,omitempty"`
+	// Repository is the git repository the package is fetched from.
+	Repository string `json:"repository"`
+	// Tag is the git tag or commit, empty if derived from Version.
+	Tag string `json:"tag,omitempty"`
+	// Dir is the directory of the sources relative to Config.DepsDir. It is
+	// only set in a Lock.
+	Dir string `json:"dir,omitempty"`
+	// Commit is the checked out commit of the sources, only set in a Lock.
+	Commit string `json:"commit,omitempty"`
+}
+
+func (p Package) String() string {
+	return p.Name + " " + p.Version + p.Tag + " (" + p.Repository + ")"
+}
+
+// Lock pins the dependencies vendored to Config.DepsDir.
+type Lock struct {
+	Packages []Package `json:"packages"`
+}
+
+var (
+	// cpmCall matches the CPMFindPackage calls of CMakeLists.txt.
+	cpmCall = regexp.MustCompile(`(?s)CPM(?:Find|Add)Package\((.*?)\n\s*\)`)
+	// cpmArg matches the single valued arguments of a CPM call.
+	cpmArg = regexp.MustCompile(`(?m)^\s*(NAME|VERSION|GIT_REPOSITORY|GIT_TAG)\s+"?([^"\s]+)"?`)
+)
+
+// Packages returns the CMake dependencies declared by the CMakeLists.txt of
+// src, including those of optional features such as the serializers.
+func Packages(src fs.FS) ([]Package, error) {
+	data, err := fs.ReadFile(src, "CMakeLists.txt")
+	if err != nil {
+		return nil, fmt.Errorf("build: %w", err)
+	}
+
+	var rtn []Package
+	for _, call := range cpmCall.FindAllSubmatch(data, -1) {
+		var pkg Package
+
+		for _, arg := range cpmArg.FindAllSubmatch(call[1], -1) {
+			value := string(arg[2])
+
+			switch string(arg[1]) {
+			case "NAME":
+				pkg.Name = value
+			case "VERSION":
+				pkg.Version = value
+			case "GIT_REPOSITORY":
+				pkg.Repository = value
+			case "GIT_TAG":
+				pkg.Tag = value
+			}
+		}
+
+		if pkg.Name != "" {
+			rtn = append(rtn, pkg)
+		}
+	}
+
+	return rtn, nil
+}
+
+// depsArgs returns the CMake arguments fetching the dependencies to
+// Config.DepsDir, and only reading them from there when offline.
+func (b *Builder) depsArgs() ([]string, error) {
+	if b.cfg.DepsDir == "" {
+		if b.cfg.Offline {
+			return nil, errors.New("build: offline builds need a dependency dir")
+		}
+		return nil, nil
+	}
+
+	dir, err := filepath.Abs(b.cfg.DepsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-DCPM_SOURCE_CACHE=" + filepath.ToSlash(dir)}
+	if !b.cfg.Offline {
+		return args, nil
+	}
+
+	if err := b.checkVendored(); err != nil {
+		return nil, err
+	}
+	return append(args, "-DFETCHCONTENT_FULLY_DISCONNECTED=ON"), nil
+}
+
+// checkVendored reports the dependencies missing from Config.DepsDir, or
+// whose commit differs from the lockfile. Without a lockfile every package
+// declared by the sources is required.
+func (b *Builder) checkVendored() error {
+	var pkgs []Package
+
+	lock, err := ReadLock(b.cfg.DepsDir)
+	switch {
+	case err == nil:
+		pkgs = lock.Packages
+	case errors.Is(err, fs.ErrNotExist):
+		if pkgs, err = Packages(b.cfg.Source); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	missing := []string{}
+	if scripts, _ := filepath.Glob(filepath.Join(b.cfg.DepsDir, "cpm", "CPM_*.cmake")); len(scripts) == 0 {
+		missing = append(missing, "CPM.cmake")
+	}
+
+	for _, pkg := range pkgs {
+		dir, ok := vendoredDir(b.cfg.DepsDir, pkg)
+		if pkg.Dir != "" {
+			dir, ok = pkg.Dir, dirExists(filepath.Join(b.cfg.DepsDir, pkg.Dir))
+		}
+		if !ok {
+			missing = append(missing, pkg.String())
+			continue
+		}
+
+		if pkg.Commit == "" {
+			continue
+		}
+
+		if commit := gitCommit(filepath.Join(b.cfg.DepsDir, dir)); commit != "" && commit != pkg.Commit {
+			return fmt.Errorf("build: vendored %s is at commit %s, the lockfile pins %s", pkg.Name, commit, pkg.Commit)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("build: offline build is missing dependencies in %s, run Vendor (saucer vendor) with network access: %s",
+			b.cfg.DepsDir, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// vendoredDir returns the directory of the sources of pkg fetched by CPM,
+// relative to deps. CPM stores them below the lowercase package name.
+func vendoredDir(deps string, pkg Package) (string, bool) {
+	name := strings.ToLower(pkg.Name)
+
+	entries, err := os.ReadDir(filepath.Join(deps, name))
+	if err != nil {
+		return "", false
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return filepath.ToSlash(filepath.Join(name, entry.Name())), true
+		}
+	}
+	return "", false
+}
+
+// dirExists reports whether dir is a directory.
+func dirExists(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// gitCommit returns the commit checked out in dir, empty if unknown or dir
+// is not the root of a git checkout.
+func gitCommit(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return ""
+	}
+
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Vendor fetches the dependencies of the build to Config.DepsDir by running
+// the CMake configure step with network access, and writes the LockFile
+// pinning their commits. Builds with Config.Offline then use them without
+// network access.
+//
+// Dependencies of the native backends, such as the system libraries of
+// WebKitGTK and Qt or the WebView2 SDK installed from NuGet, are not
+// vendored.
+func (b *Builder) Vendor(ctx context.Context) (*Lock, error) {
+	if b.cfg.Dir == "" || b.cfg.DepsDir == "" {
+		return nil, errors.New("build: config dir and dependency dir are required")
+	}
+
+	cfg := b.cfg
+	cfg.Offline = false
+
+	if err := NewBuilder(cfg).configure(ctx); err != nil {
+		return nil, err
+	}
+
+	pkgs, err := Packages(b.cfg.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{}
+	for _, pkg := range pkgs {
+		dir, ok := vendoredDir(b.cfg.DepsDir, pkg)
+		if !ok {
+			// Dependencies of disabled features are not fetched
+			continue
+		}
+
+		pkg.Dir, pkg.Commit = dir, gitCommit(filepath.Join(b.cfg.DepsDir, dir))
+		lock.Packages = append(lock.Packages, pkg)
+	}
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(filepath.Join(b.cfg.DepsDir, LockFile), append(data, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("build: write lockfile: %w", err)
+	}
+	return lock, nil
+}
+
+// lockedPackages returns the packages of the LockFile in Config.DepsDir,
+// or those declared by the sources without one.
+func (b *Builder) lockedPackages() ([]Package, error) {
+	if b.cfg.DepsDir != "" {
+		lock, err := ReadLock(b.cfg.DepsDir)
+		if err == nil {
+			return lock.Packages, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return Packages(b.cfg.Source)
+}
+
+// ReadLock reads the LockFile of the dependency dir deps.
+func ReadLock(deps string) (*Lock, error) {
+	data, err := os.ReadFile(filepath.Join(deps, LockFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("build: %s: %w", LockFile, err)
+	}
+	return &lock, nil
+}
@@ -0,0 +1,189 @@
+package saucer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Serializers accepted by BazelOptions and MesonOptions, matching the
+// saucer_serializer CMake option.
+const (
+	SerializerGlaze = "glaze"
+	SerializerRflpp = "rflpp"
+	SerializerNone  = "none"
+)
+
+// cppDep is a C++ dependency fetched by CPM in CMakeLists.txt.
+type cppDep struct {
+	// name is the CPM package name.
+	name string
+	// target is the imported CMake target.
+	target string
+	// serializer is set for the dependencies of a serializer only.
+	serializer string
+}
+
+// cppDeps lists the C++ dependencies of the library.
+var cppDeps = []cppDep{
+	{name: "lockpp", target: "cr::lockpp"},
+	{name: "coco", target: "cr::coco"},
+	{name: "rebind", target: "cr::rebind"},
+	{name: "ereignis", target: "cr::ereignis"},
+	{name: "flagpp", target: "cr::flagpp"},
+	{name: "polo", target: "cr::polo"},
+	{name: "saucer-fill", target: "saucer::fill"},
+	{name: "glaze", target: "glaze::glaze", serializer: SerializerGlaze},
+	{name: "reflectcpp", target: "reflectcpp", serializer: SerializerRflpp},
+}
+
+// sourcePrefixes maps the file name prefixes of the sources compiled only
+// for a backend or serializer, like the globs of CMakeLists.txt, to it.
+var sourcePrefixes = map[string]string{
+	"qt":    backendQt6,
+	"gtk":   backendWebKitGTK,
+	"wkg":   backendWebKitGTK,
+	"win32": backendWebView2,
+	"wv2":   backendWebView2,
+	"cocoa": backendWKWebView,
+	"wk":    backendWKWebView,
+	"glaze": SerializerGlaze,
+	"rfl":   SerializerRflpp,
+}
+
+// backendDefines are the public compile definitions selecting a backend.
+var backendDefines = map[string][]string{
+	backendQt6:       {"SAUCER_QT"},
+	backendWebKitGTK: {"SAUCER_WEBKITGTK"},
+	backendWebView2:  {"SAUCER_WEBVIEW2", "UNICODE", "_UNICODE"},
+	backendWKWebView: {"SAUCER_WEBKIT"},
+}
+
+// layout is a source tree grouped into the targets of CMakeLists.txt.
+type layout struct {
+	// headers are the public headers below include.
+	headers []string
+	// private are the headers below private.
+	private []string
+	// moc are the headers Qt moc has to process, declaring Q_OBJECT.
+	moc []string
+	// sources maps "" for the sources of every build, the backend and the
+	// serializer names to their sources.
+	sources map[string][]string
+}
+
+// readLayout groups the files of src. It is derived from the tree rather
+// than listed, so generated build definitions follow upstream changes.
+func readLayout(src fs.FS) (*layout, error) {
+	l := &layout{sources: map[string][]string{}}
+
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		dir, _, _ := strings.Cut(name, "/")
+		switch ext := path.Ext(name); {
+		case dir == "include" && (ext == ".hpp" || ext == ".inl"):
+			l.headers = append(l.headers, name)
+		case dir == "private":
+			l.private = append(l.private, name)
+
+			data, err := fs.ReadFile(src, name)
+			if err != nil {
+				return err
+			}
+			if bytes.Contains(data, []byte("Q_OBJECT")) {
+				l.moc = append(l.moc, name)
+			}
+		case dir == "src" && (ext == ".cpp" || ext == ".mm"):
+			prefix, _, _ := strings.Cut(path.Base(name), ".")
+			group := sourcePrefixes[prefix]
+			l.sources[group] = append(l.sources[group], name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(l.sources[""]) == 0 {
+		return nil, errors.New("saucer: no sources found, is it a saucer tree?")
+	}
+	return l, nil
+}
+
+// backends returns the backends the tree has sources for, sorted.
+func (l *layout) backends() []string {
+	var rtn []string
+	for name := range backendDefines {
+		if len(l.sources[name]) > 0 {
+			rtn = append(rtn, name)
+		}
+	}
+	slices.Sort(rtn)
+	return rtn
+}
+
+// checkSerializer validates serializer, defaulting to glaze.
+func checkSerializer(serializer string) (string, error) {
+	switch serializer {
+	case "":
+		return SerializerGlaze, nil
+	case SerializerGlaze, SerializerRflpp, SerializerNone:
+		return serializer, nil
+	}
+	return "", fmt.Errorf("saucer: unknown serializer %q", serializer)
+}
+
+// configVars returns the variables configure_config of CMakeLists.txt sets
+// for the template of include/saucer/config.hpp.
+func configVars(serializer string) TemplateVars {
+	if serializer == SerializerNone {
+		return TemplateVars{"DEFAULT_SERIALIZER": "void"}
+	}
+	return TemplateVars{
+		"INCLUDE_SERIALIZER": fmt.Sprintf("#include \"serializers/%s/%s.hpp\"", serializer, serializer),
+		"DEFAULT_SERIALIZER": fmt.Sprintf("serializers::%s::serializer", serializer),
+	}
+}
+
+// generate extracts src to dir, configures it for serializer and writes the
+// build files returned by render.
+func generate(src fs.FS, dir, serializer string, render func(*layout, string) map[string]string) error {
+	if src == nil {
+		src = source
+	}
+
+	serializer, err := checkSerializer(serializer)
+	if err != nil {
+		return err
+	}
+
+	l, err := readLayout(src)
+	if err != nil {
+		return err
+	}
+	if serializer == SerializerRflpp && len(l.sources[SerializerRflpp]) == 0 {
+		return errors.New("saucer: the source tree lacks the rfl++ serializer, remove -tags saucer_no_rflpp")
+	}
+
+	if err := ExtractFS(src, dir, ExtractOptions{OnlyIfChanged: true, Templates: configVars(serializer)}); err != nil {
+		return err
+	}
+
+	files := render(l, serializer)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(files[name]), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Command bridgeprof measures the latency, throughput and allocations of calls
+// of exposed functions through the saucerw bridge.
+//
+// It calls functions taking small, large and binary arguments, exposed with
+// Expose, which decodes the arguments by reflection, and with ExposeRaw,
+// which does not, through webviews with and without batching. The results are
+// printed in the format of go test -bench, so that benchstat compares runs of
+// two releases:
+//
+//	bridgeprof -count 10 > old.txt
+//	git checkout main && bridgeprof -count 10 > new.txt
+//	benchstat old.txt new.txt
+//
+// By default the calls go through the fake page of package saucertest,
+// measuring the Go side of the bridge without cgo or a display, like the
+// BenchmarkBridge benchmarks of go test -bench Bridge ./saucerw. With -native
+// they are made by a page in a native webview, covering the cgo layer and the
+// JavaScript of the bridge; build it with cgo and -tags saucer for that:
+//
+//	go build -tags saucer ./cmd/bridgeprof
+//
+// The allocations are those of the whole process during the calls, and the
+// fake page finds the results in the scripts Go runs with regular expressions,
+// which dominates calls with large results; compare the results of a driver
+// with those of the same driver only. Profiles of all benchmarks are written
+// with -cpuprofile and -memprofile.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("bridgeprof: ")
+
+	native := flag.Bool("native", false, "call from a page in a native webview instead of the fake page")
+	run := flag.String("run", ".", "run only the benchmarks matching the regular expression")
+	benchtime := flag.String("benchtime", "1s", "run each benchmark for this long, or this many times with the suffix x")
+	count := flag.Int("count", 1, "run each benchmark this many times")
+	size := flag.Int("size", 1<<20, "size in bytes of the large and binary arguments")
+	parallel := flag.Int("parallel", 64, "calls in flight in the throughput benchmarks")
+	cpuprofile := flag.String("cpuprofile", "", "write a CPU profile to `file`")
+	memprofile := flag.String("memprofile", "", "write an allocation profile to `file`")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: bridgeprof [flags]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		log.Fatalf("-run: %v", err)
+	}
+
+	testing.Init()
+	if err := flag.Set("test.benchtime", *benchtime); err != nil {
+		log.Fatalf("-benchtime: %v", err)
+	}
+
+	s := &suite{filter: filter, count: *count, size: *size, parallel: *parallel}
+
+	var app *saucerw.Application
+	if *native {
+		app, err = saucerw.NewApplication(saucerw.AppOptions{ID: "com.aperturerobotics.bridgeprof", Headless: true})
+	} else {
+		s.fake = saucertest.New()
+		app, err = saucerw.NewApplicationWithDriver(s.fake, saucerw.AppOptions{ID: "com.aperturerobotics.bridgeprof"})
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *cpuprofile != "" {
+		f, err := os.Create(*cpuprofile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		if err := pprof.StartCPUProfile(f); err != nil {
+			log.Fatal(err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	var failed error
+	app.Run(func(app *saucerw.Application) {
+		if err := s.start(app); err != nil {
+			failed = err
+			app.Quit()
+			return
+		}
+
+		go func() {
+			defer app.Quit()
+			failed = s.run()
+		}()
+	})
+
+	if *memprofile != "" {
+		if err := writeHeapProfile(*memprofile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if failed != nil {
+		pprof.StopCPUProfile()
+		log.Fatal(failed)
+	}
+}
+
+// writeHeapProfile writes the allocations since the start to path.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	runtime.GC()
+	return pprof.Lookup("allocs").WriteTo(f, 0)
+}
+
+// header prints the configuration lines benchstat groups the results by.
+func header(driver string) {
+	fmt.Printf("goos: %s\n", runtime.GOOS)
+	fmt.Printf("goarch: %s\n", runtime.GOARCH)
+	fmt.Printf("pkg: github.com/aperturerobotics/saucer/saucerw\n")
+	fmt.Printf("driver: %s\n", driver)
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Printf("version: %s\n", info.Main.Version)
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				fmt.Printf("revision: %s\n", setting.Value)
+			}
+		}
+	}
+}
+
+// suffix is the suffix go test appends to the names of benchmarks.
+func suffix() string {
+	if procs := runtime.GOMAXPROCS(0); procs > 1 {
+		return fmt.Sprintf("-%d", procs)
+	}
+	return ""
+}
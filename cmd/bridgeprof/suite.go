@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/aperturerobotics/saucer/internal/bridgebench"
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// target is a webview the calls are made in.
+type target struct {
+	name string
+	view *saucerw.Webview
+	// page is the fake page of view, nil for a native one.
+	page *saucertest.Webview
+}
+
+// suite runs the benchmarks.
+type suite struct {
+	fake     *saucertest.Driver
+	filter   *regexp.Regexp
+	count    int
+	size     int
+	parallel int
+
+	targets []*target
+}
+
+// start creates the webviews, on the event loop thread.
+func (s *suite) start(app *saucerw.Application) error {
+	win, err := app.NewWindow(saucerw.WindowOptions{Offscreen: true})
+	if err != nil {
+		return err
+	}
+
+	for _, batching := range []struct {
+		name string
+		opts saucerw.BatchOptions
+	}{
+		{"batched", saucerw.BatchOptions{}},
+		{"unbatched", saucerw.BatchOptions{Disabled: true}},
+	} {
+		view, err := saucerw.NewWebview(saucerw.WebviewOptions{Window: win, Batching: batching.opts})
+		if err != nil {
+			return err
+		}
+		if err := bridgebench.Expose(view); err != nil {
+			return err
+		}
+		s.targets = append(s.targets, &target{name: batching.name, view: view})
+	}
+
+	if s.fake != nil {
+		pages := s.fake.App().Windows()[0].Webviews()
+		for i, t := range s.targets {
+			t.page = pages[i]
+		}
+		return nil
+	}
+
+	win.Show()
+	return nil
+}
+
+// run runs the benchmarks matching the filter and prints their results.
+func (s *suite) run() error {
+	driver := "fake"
+	if s.fake == nil {
+		driver = "native"
+		s.load()
+	}
+	header(driver)
+
+	for range s.count {
+		for _, mode := range []string{"Latency", "Throughput"} {
+			for _, c := range bridgebench.Cases {
+				for _, t := range s.targets {
+					name := fmt.Sprintf("%s/%s/%s", mode, c.Name(), t.name)
+					if !s.filter.MatchString(name) {
+						continue
+					}
+
+					var failed error
+					result := testing.Benchmark(func(b *testing.B) {
+						if failed = s.bench(b, mode == "Throughput", t, c); failed != nil {
+							b.SkipNow()
+						}
+					})
+					if failed != nil {
+						return fmt.Errorf("%s: %w", name, failed)
+					}
+
+					fmt.Printf("Benchmark%s%s\t%s\t%s\n", name, suffix(), result.String(), result.MemString())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// load shows the benchmark page in the native webviews and waits until their
+// bridges are ready.
+func (s *suite) load() {
+	for _, t := range s.targets {
+		ready := make(chan struct{}, 1)
+		sub := t.view.OnReady(func() {
+			select {
+			case ready <- struct{}{}:
+			default:
+			}
+		})
+
+		t.view.SetHTML("<!doctype html><html><body><script>" + bridgebench.Script + "</script></body></html>")
+		<-ready
+		sub.Cancel()
+	}
+}
+
+// bench makes b.N calls of c in t, one at a time or in parallel.
+func (s *suite) bench(b *testing.B, parallel bool, t *target, c bridgebench.Case) error {
+	inFlight := 1
+	if parallel {
+		inFlight = s.parallel
+	}
+
+	if t.page == nil {
+		return bridgebench.RunNative(b, t.view, c, s.size, inFlight)
+	}
+	return bridgebench.Run(b, t.page, c, s.size, inFlight)
+}
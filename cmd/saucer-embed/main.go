@@ -0,0 +1,111 @@
+// Command saucer-embed generates the C++ headers embedding a directory of web
+// assets into a saucer application.
+//
+// Usage:
+//
+//	saucer-embed [-o dir] assets
+//
+// The headers are written below dir (default "embedded"). Adding dir to the
+// include path makes the assets available through
+//
+//	#include <saucer/embedded/all.hpp>
+//	webview->embed(saucer::embedded::all());
+//
+// Go applications embed assets with go:embed and saucerw.Webview.Embed
+// instead.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/aperturerobotics/saucer/internal/mimetype"
+)
+
+func main() {
+	out := flag.String("o", "embedded", "output directory")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: saucer-embed [-o dir] assets\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := generate(os.DirFS(flag.Arg(0)), filepath.Join(*out, "saucer", "embedded")); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate writes one header per file of src and all.hpp to dir.
+func generate(src fs.FS, dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0o755); err != nil {
+		return err
+	}
+
+	var all bytes.Buffer
+	var entries bytes.Buffer
+
+	all.WriteString("#pragma once\n\n#include <saucer/webview.hpp>\n\n#include <filesystem>\n#include <unordered_map>\n\n")
+
+	index := 0
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+
+		header := fmt.Sprintf("file_%d.hpp", index)
+		if err := os.WriteFile(filepath.Join(dir, "files", header), fileHeader(index, data), 0o644); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&all, "#include \"files/%s\"\n", header)
+		fmt.Fprintf(&entries, "            {%q, {.content = stash::view(files::file_%d), .mime = %q}},\n", "/"+name, index, mimetype.Detect(name, data))
+
+		index++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	all.WriteString("\nnamespace saucer::embedded\n{\n")
+	all.WriteString("    inline std::unordered_map<std::filesystem::path, embedded_file> all()\n    {\n")
+	all.WriteString("        return {\n")
+	all.Write(entries.Bytes())
+	all.WriteString("        };\n    }\n} // namespace saucer::embedded\n")
+
+	return os.WriteFile(filepath.Join(dir, "all.hpp"), all.Bytes(), 0o644)
+}
+
+// fileHeader returns a header defining the content of file index as a byte
+// array.
+func fileHeader(index int, data []byte) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("#pragma once\n\n#include <array>\n#include <cstdint>\n\n")
+	fmt.Fprintf(&buf, "namespace saucer::embedded::files\n{\n    inline constexpr std::array<std::uint8_t, %d> file_%d = {", len(data), index)
+
+	for i, b := range data {
+		if i%16 == 0 {
+			buf.WriteString("\n        ")
+		}
+		fmt.Fprintf(&buf, "0x%02x,", b)
+	}
+
+	buf.WriteString("\n    };\n} // namespace saucer::embedded::files\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,431 @@
+// Command saucer-sync updates the embedded saucer sources to an upstream
+// release.
+//
+// It clones the given tag of the upstream repository, replaces the embedded
+// tree of the module, regenerates the archives, manifest and version
+// constants through genmanifest and prints the files added, removed and
+// changed. Files no //go:embed pattern picks up are listed with the pattern
+// to add, as are patterns left without files, which break the build.
+//
+// Usage:
+//
+//	saucer-sync [flags] tag
+//
+// Forks pass their own repository with -repo. Run it from the module root or
+// pass it with -dir.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// upstream is the repository synced from by default.
+const upstream = "https://github.com/saucer/saucer"
+
+// syncPaths are the files and directories of the module replaced by their
+// upstream copy.
+var syncPaths = []string{"CMakeLists.txt", "LICENSE", "cmake", "include", "private", "src", "template"}
+
+// embedFiles are the files declaring the //go:embed patterns of the
+// uncompressed tree, the backend independent one first.
+var embedFiles = []string{"embed.go", "source_qt6.go", "source_webkitgtk.go", "source_webview2.go", "source_wkwebview.go"}
+
+// report is the outcome of a sync, printed as text or with -json.
+type report struct {
+	Tag     string   `json:"tag"`
+	Commit  string   `json:"commit"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+	// Globs are the patterns to add for the files not embedded.
+	Globs []glob `json:"globs"`
+	// Stale are the patterns matching no file anymore.
+	Stale []glob `json:"stale"`
+}
+
+// glob is a //go:embed pattern of a file.
+type glob struct {
+	File    string   `json:"file"`
+	Pattern string   `json:"pattern"`
+	Matches []string `json:"matches,omitempty"`
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("saucer-sync: ")
+
+	repo := flag.String("repo", upstream, "git repository to sync from")
+	dir := flag.String("dir", ".", "root of the saucer module")
+	dryRun := flag.Bool("n", false, "only report the changes, leave the module untouched")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	key := flag.String("key", os.Getenv("SAUCER_SIGNING_KEY"), "PEM file of the Ed25519 key signing the manifest")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: saucer-sync [flags] tag")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	r, err := syncTree(ctx, *repo, flag.Arg(0), *dir, *key, *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		r.print()
+	}
+
+	if len(r.Globs) > 0 || len(r.Stale) > 0 {
+		os.Exit(1)
+	}
+}
+
+// syncTree syncs the module in dir to tag of repo and reports the changes. The
+// sources are only regenerated if the embed patterns cover the new tree.
+func syncTree(ctx context.Context, repo, tag, dir, key string, dryRun bool) (*report, error) {
+	if _, err := os.Stat(filepath.Join(dir, "embed.go")); err != nil {
+		return nil, fmt.Errorf("%s is not the root of the saucer module: %w", dir, err)
+	}
+
+	src, err := os.MkdirTemp("", "saucer-sync-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(src)
+
+	if err := git(ctx, "", "-c", "advice.detachedHead=false", "clone", "--quiet", "--depth", "1", "--branch", tag, repo, src); err != nil {
+		return nil, err
+	}
+
+	commit, err := gitOutput(ctx, src, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := gitOutput(ctx, src, "log", "-1", "--format=%cI")
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := digests(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := digests(src)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &report{Tag: tag, Commit: commit}
+	for _, name := range slices.Sorted(maps.Keys(after)) {
+		old, ok := before[name]
+		switch {
+		case !ok:
+			r.Added = append(r.Added, name)
+		case old != after[name]:
+			r.Changed = append(r.Changed, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[name]; !ok {
+			r.Removed = append(r.Removed, name)
+		}
+	}
+
+	patterns, err := readPatterns(dir)
+	if err != nil {
+		return nil, err
+	}
+	r.coverage(patterns, slices.Sorted(maps.Keys(after)))
+
+	if dryRun {
+		return r, nil
+	}
+
+	for _, name := range syncPaths {
+		if err := replace(filepath.Join(src, name), filepath.Join(dir, name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(r.Globs) > 0 || len(r.Stale) > 0 {
+		// The build fails or misses files until the patterns are updated
+		return r, nil
+	}
+
+	args := []string{"run", "-tags", "saucer_raw", "./internal/genmanifest", "-tag", tag, "-commit", commit, "-date", date}
+	if key != "" {
+		args = append(args, "-key", key)
+	}
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir, cmd.Stdout, cmd.Stderr = dir, os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("genmanifest: %w", err)
+	}
+
+	return r, nil
+}
+
+// digests returns the SHA-256 of every file of syncPaths below dir, by
+// slash-separated path.
+func digests(dir string) (map[string][sha256.Size]byte, error) {
+	rtn := map[string][sha256.Size]byte{}
+
+	for _, root := range syncPaths {
+		err := filepath.WalkDir(filepath.Join(dir, root), func(name string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && name == filepath.Join(dir, root) {
+				return nil
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(dir, name)
+			if err != nil {
+				return err
+			}
+
+			rtn[filepath.ToSlash(rel)] = sha256.Sum256(data)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rtn, nil
+}
+
+// readPatterns returns the //go:embed patterns of embedFiles in dir.
+func readPatterns(dir string) ([]glob, error) {
+	var rtn []glob
+
+	for _, file := range embedFiles {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "//go:embed ")
+			if !ok {
+				continue
+			}
+
+			for pattern := range strings.FieldsSeq(line) {
+				rtn = append(rtn, glob{File: file, Pattern: strings.Trim(pattern, "\"`")})
+			}
+		}
+	}
+
+	return rtn, nil
+}
+
+// matches reports whether the //go:embed pattern matches the file name or a
+// directory containing it.
+func matches(pattern, name string) bool {
+	for ; name != "."; name = path.Dir(name) {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// coverage records the patterns matching none of files and the patterns to
+// add for the files matched by none.
+func (r *report) coverage(patterns []glob, files []string) {
+	for i, p := range patterns {
+		for _, name := range files {
+			if matches(p.Pattern, name) {
+				patterns[i].Matches = append(patterns[i].Matches, name)
+			}
+		}
+
+		if len(patterns[i].Matches) == 0 {
+			r.Stale = append(r.Stale, p)
+		}
+	}
+
+	// LICENSE is embedded by notices.go in every build
+	suggested := map[[2]string]int{}
+	for _, name := range files {
+		if name == "LICENSE" || slices.ContainsFunc(patterns, func(p glob) bool { return matches(p.Pattern, name) }) {
+			continue
+		}
+
+		g := suggest(patterns, name)
+		if i, ok := suggested[[2]string{g.File, g.Pattern}]; ok {
+			r.Globs[i].Matches = append(r.Globs[i].Matches, name)
+			continue
+		}
+
+		suggested[[2]string{g.File, g.Pattern}] = len(r.Globs)
+		g.Matches = []string{name}
+		r.Globs = append(r.Globs, g)
+	}
+}
+
+// suggest returns the pattern embedding the file name: a glob of its backend
+// prefix, e.g. "src/qt.*.cpp", in the file of the backend using the prefix in
+// the same directory, otherwise a pattern in the backend independent file.
+func suggest(patterns []glob, name string) glob {
+	dir, base := path.Split(name)
+	ext := path.Ext(base)
+
+	if prefix, _, ok := strings.Cut(base, "."); ok && prefix+ext != base {
+		for _, p := range patterns {
+			if p.File != embedFiles[0] && strings.HasPrefix(p.Pattern, dir+prefix+".") {
+				return glob{File: p.File, Pattern: dir + prefix + ".*" + ext}
+			}
+		}
+	}
+
+	// A directory without patterns is embedded by extension, the explicitly
+	// listed files of mixed directories one by one
+	if !slices.ContainsFunc(patterns, func(p glob) bool { return path.Dir(p.Pattern) == path.Dir(name) }) {
+		return glob{File: embedFiles[0], Pattern: dir + "*" + ext}
+	}
+	return glob{File: embedFiles[0], Pattern: name}
+}
+
+// replace replaces the file or directory dst with a copy of src, removing it
+// if src does not exist.
+func replace(src, dst string) error {
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return filepath.WalkDir(src, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+}
+
+// git runs git in dir.
+func git(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir, cmd.Stderr = dir, os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// gitOutput runs git in dir and returns its trimmed output.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir, cmd.Stderr = dir, os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// print writes the report as text to stdout.
+func (r *report) print() {
+	fmt.Printf("saucer %s (%s)\n", r.Tag, r.Commit)
+
+	section := func(title, mark string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+
+		fmt.Printf("\n%s (%d):\n", title, len(names))
+		for _, name := range names {
+			fmt.Printf("  %s %s\n", mark, name)
+		}
+	}
+
+	section("added", "+", r.Added)
+	section("removed", "-", r.Removed)
+	section("changed", "~", r.Changed)
+
+	if len(r.Added)+len(r.Removed)+len(r.Changed) == 0 {
+		fmt.Println("\nno changes")
+	}
+
+	if len(r.Globs) > 0 {
+		fmt.Println("\nnot embedded, add to the //go:embed patterns:")
+		for _, g := range r.Globs {
+			fmt.Printf("  %s: //go:embed %s (%s)\n", g.File, g.Pattern, strings.Join(g.Matches, ", "))
+		}
+	}
+
+	if len(r.Stale) > 0 {
+		fmt.Println("\nmatching no files, remove from the //go:embed patterns:")
+		for _, g := range r.Stale {
+			fmt.Printf("  %s: //go:embed %s\n", g.File, g.Pattern)
+		}
+	}
+
+	if len(r.Globs) > 0 || len(r.Stale) > 0 {
+		fmt.Println("\nthen regenerate the sources with go generate")
+	}
+}
@@ -0,0 +1,744 @@
+// Command saucer extracts and builds the embedded saucer sources outside of
+// go generate, e.g. from Makefiles and CI pipelines.
+//
+// Usage:
+//
+//	saucer extract [flags] dir
+//	saucer build [flags]
+//	saucer install [flags] prefix
+//	saucer clean [flags]
+//	saucer doctor [flags]
+//	saucer flags [flags]
+//	saucer vendor [flags]
+//	saucer sbom [flags]
+//	saucer notices [flags]
+//	saucer verify [dir]
+//	saucer generate [flags] dir
+//	saucer export [flags]
+//	saucer pack [flags] binary
+//	saucer versions
+//
+// Run "saucer <command> -h" for the flags of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aperturerobotics/saucer"
+	"github.com/aperturerobotics/saucer/build"
+	"github.com/aperturerobotics/saucer/doctor"
+	"github.com/aperturerobotics/saucer/pack"
+)
+
+// commands maps the subcommand names to their implementation.
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"extract":  extract,
+	"build":    buildCmd,
+	"install":  installCmd,
+	"clean":    clean,
+	"doctor":   doctorCmd,
+	"flags":    flags,
+	"vendor":   vendor,
+	"sbom":     sbom,
+	"notices":  notices,
+	"verify":   verify,
+	"generate": generateCmd,
+	"export":   export,
+	"pack":     packCmd,
+	"versions": versions,
+}
+
+// errFailed reports a failure already printed to the user.
+var errFailed = errors.New("failed")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd(ctx, os.Args[2:]); err != nil {
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintln(os.Stderr, "saucer:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: saucer <extract|build|install|clean|doctor|flags|vendor|sbom|notices|verify|generate|export|pack|versions> [flags]")
+	os.Exit(2)
+}
+
+// backends lists the accepted -backend values.
+var backends = []build.Backend{build.BackendDefault, build.BackendQt, build.BackendWebKitGtk, build.BackendWebView2, build.BackendWebKit}
+
+// parseBackend resolves a case insensitive backend name.
+func parseBackend(name string) (build.Backend, error) {
+	for _, backend := range backends {
+		if strings.EqualFold(name, string(backend)) {
+			return backend, nil
+		}
+	}
+	return "", fmt.Errorf("unknown backend %q", name)
+}
+
+// profiles lists the accepted -profile values.
+var profiles = []build.Profile{build.ProfileRelease, build.ProfileDebug, build.ProfileRelWithDebInfo, build.ProfileASan, build.ProfileUBSan, build.ProfileTSan}
+
+// parseProfile resolves a case insensitive profile name.
+func parseProfile(name string) (build.Profile, error) {
+	for _, profile := range profiles {
+		if strings.EqualFold(name, string(profile)) {
+			return profile, nil
+		}
+	}
+	return "", fmt.Errorf("unknown profile %q", name)
+}
+
+// defines collects repeated -D key=value flags.
+type defines map[string]string
+
+func (d defines) String() string { return "" }
+
+func (d defines) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	d[key] = val
+	return nil
+}
+
+// builderFlags registers the flags shared by the commands driving a Builder.
+func builderFlags(fs *flag.FlagSet) func() (*build.Builder, error) {
+	var cfg build.Config
+	var backend, buildType, profile, target, compilerCache string
+	var verbose bool
+
+	cfg.Defines = defines{}
+
+	fs.StringVar(&cfg.Dir, "dir", ".saucer", "working directory for the sources and the build tree")
+	fs.StringVar(&cfg.Version, "version", "", "embedded saucer release to build, e.g. 8 or 8.1.0 (default: the default release)")
+	fs.StringVar(&backend, "backend", string(build.BackendDefault), "webview backend: Default, Qt, WebKitGtk, WebView2 or WebKit")
+	fs.StringVar(&buildType, "type", string(build.Release), "CMake build type")
+	fs.StringVar(&profile, "profile", "", "build profile replacing -type: Release, Debug, RelWithDebInfo, ASan, UBSan or TSan")
+	fs.StringVar(&cfg.Generator, "G", "", "CMake generator")
+	fs.StringVar(&cfg.CMake, "cmake", "cmake", "cmake executable")
+	fs.StringVar(&cfg.CacheDir, "cache-dir", "", "build cache directory (default: user cache dir)")
+	fs.BoolVar(&cfg.NoCache, "no-cache", false, "neither read nor populate the build cache")
+	fs.Var(defines(cfg.Defines), "D", "CMake definition key=value, repeatable")
+	fs.StringVar(&target, "target", build.HostTarget().String(), "GOOS/GOARCH to build for, e.g. windows/amd64")
+	fs.StringVar(&cfg.Sysroot, "sysroot", "", "root of the target headers and libraries when cross compiling")
+	fs.StringVar(&cfg.ToolchainFile, "toolchain-file", "", "CMake toolchain file replacing the generated one")
+	fs.BoolVar(&cfg.Zig, "zig", false, "compile with zig cc")
+	fs.StringVar(&compilerCache, "compiler-cache", "none", "compiler cache: none, auto, ccache or sccache")
+	fs.IntVar(&cfg.Jobs, "j", 0, "parallel compile jobs (default: generator default)")
+	fs.BoolVar(&verbose, "v", false, "print the output of CMake and the compiler")
+	fs.StringVar(&cfg.DepsDir, "deps-dir", "", "directory of the vendored CMake dependencies")
+	fs.BoolVar(&cfg.Offline, "offline", false, "build without network access from -deps-dir")
+
+	return func() (*build.Builder, error) {
+		var err error
+		if _, err = saucer.LookupRelease(cfg.Version); err != nil {
+			return nil, err
+		}
+		if cfg.Backend, err = parseBackend(backend); err != nil {
+			return nil, err
+		}
+		if cfg.Target, err = build.ParseTarget(target); err != nil {
+			return nil, err
+		}
+
+		if compilerCache != "none" {
+			cfg.CompilerCache = build.CompilerCache(compilerCache)
+		}
+
+		if profile != "" {
+			if cfg.Profile, err = parseProfile(profile); err != nil {
+				return nil, err
+			}
+		}
+
+		if verbose {
+			cfg.Log = os.Stderr
+		}
+
+		cfg.BuildType = build.BuildType(buildType)
+		return build.NewBuilder(cfg), nil
+	}
+}
+
+func extract(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+
+	backend := fs.String("backend", "", "only extract the sources of this backend")
+	goos := fs.String("goos", runtime.GOOS, "target operating system for -backend")
+	version := fs.String("version", "", "embedded saucer release to extract (default: the default release)")
+	changed := fs.Bool("only-if-changed", true, "leave files with unchanged content untouched")
+	reproducible := fs.Bool("reproducible", false, "normalize modes and timestamps for bit-for-bit reproducible trees")
+
+	var skip []string
+	fs.Func("skip", "path.Match pattern of files to skip, repeatable", func(pattern string) error {
+		skip = append(skip, pattern)
+		return nil
+	})
+
+	var vars saucer.TemplateVars
+	fs.Func("D", "NAME=VALUE variable rendering the templates into the tree like configure_file, repeatable", func(def string) error {
+		name, value, ok := strings.Cut(def, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid variable %q, want NAME=VALUE", def)
+		}
+		if vars == nil {
+			vars = saucer.TemplateVars{}
+		}
+		vars[name] = value
+		return nil
+	})
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: saucer extract [flags] dir")
+	}
+
+	release, err := saucer.LookupRelease(*version)
+	if err != nil {
+		return err
+	}
+
+	src := release.Source
+	if *backend != "" {
+		if !release.Default {
+			return fmt.Errorf("-backend is not supported for release %s, embedded with all backends", release.Version)
+		}
+		if src, err = saucer.SourceForTarget(*goos, *backend); err != nil {
+			return err
+		}
+	}
+
+	return saucer.ExtractFS(src, fs.Arg(0), saucer.ExtractOptions{OnlyIfChanged: *changed, Skip: skip, Reproducible: *reproducible, Templates: vars})
+}
+
+func buildCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+
+	builder := builderFlags(fs)
+	cgoFile := fs.String("cgo-file", "", "write the cgo directives for the build to this Go file")
+	compileCommands := fs.String("compile-commands", "", "write compile_commands.json for clangd to this path")
+
+	fs.Parse(args)
+
+	b, err := builder()
+	if err != nil {
+		return err
+	}
+
+	art, err := b.Build(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *cgoFile != "" {
+		if err := build.WriteCgoFlags(*cgoFile, b.CgoFlags(art)); err != nil {
+			return err
+		}
+	}
+
+	if *compileCommands != "" {
+		if err := b.ExportCompileCommands(ctx, *compileCommands); err != nil {
+			return err
+		}
+	}
+
+	stats := b.CacheStats()
+	if stats.Hits > 0 {
+		fmt.Println("cached:", stats.Key)
+	}
+
+	fmt.Println("library:", art.Library)
+	for _, dir := range art.IncludeDirs {
+		fmt.Println("include:", dir)
+	}
+	return nil
+}
+
+func installCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("install", flag.ExitOnError)
+
+	builder := builderFlags(fs)
+	versioned := fs.Bool("versioned", false, "install to a directory below prefix named after the version and configuration")
+	cgoFile := fs.String("cgo-file", "", "write the cgo directives for the install tree to this Go file")
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: saucer install [flags] prefix")
+	}
+
+	b, err := builder()
+	if err != nil {
+		return err
+	}
+
+	prefix := fs.Arg(0)
+	if *versioned {
+		prefix = filepath.Join(prefix, b.InstallName())
+	}
+
+	inst, err := b.Install(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	if *cgoFile != "" {
+		if err := build.WriteCgoFlags(*cgoFile, inst.Flags); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("installed:", inst.Prefix)
+	return nil
+}
+
+func clean(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+
+	builder := builderFlags(fs)
+	cache := fs.Bool("cache", false, "also remove the build cache")
+
+	fs.Parse(args)
+
+	b, err := builder()
+	if err != nil {
+		return err
+	}
+
+	if err := b.Clean(); err != nil {
+		return err
+	}
+
+	if *cache {
+		return b.CleanCache()
+	}
+	return nil
+}
+
+func doctorCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	backend := fs.String("backend", string(build.BackendDefault), "webview backend to check")
+	target := fs.String("target", build.HostTarget().String(), "GOOS/GOARCH whose cross toolchain to check")
+	asJSON := fs.Bool("json", false, "print the environment, toolchain, runtimes and GPUs as JSON")
+	report := fs.Bool("report", false, "print the environment redacted for pasting into bug reports")
+	fs.Parse(args)
+
+	b, err := parseBackend(*backend)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON || *report {
+		env := doctor.Report(ctx, doctor.Options{Backend: b})
+		if *report {
+			fmt.Print(env.Text())
+			return nil
+		}
+
+		data, err := env.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	t, err := build.ParseTarget(*target)
+	if err != nil {
+		return err
+	}
+
+	if t != build.HostTarget() {
+		fmt.Printf("saucer %s (%s), cross compiling for %s\n\n", saucer.Version(), saucer.UpstreamTag(), t)
+
+		if err := build.NewBuilder(build.Config{Target: t, Backend: b}).CheckToolchain(); err != nil {
+			fmt.Println("not ready:", err)
+			return errFailed
+		}
+
+		fmt.Println("ready to build")
+		return nil
+	}
+
+	fmt.Printf("saucer %s (%s), %s/%s, backend %s\n\n", saucer.Version(), saucer.UpstreamTag(), runtime.GOOS, runtime.GOARCH, b)
+
+	deps, err := build.CheckDependencies(b)
+	for _, dep := range deps {
+		mark := "ok     "
+		if !dep.Found {
+			mark = "missing"
+		}
+
+		line := fmt.Sprintf("  %s  %s", mark, dep.Name)
+		if dep.Version != "" {
+			line += " " + dep.Version
+		}
+		if dep.MinVersion != "" {
+			line += " (>= " + dep.MinVersion + ")"
+		}
+		fmt.Println(line)
+
+		if !dep.Found && dep.Hint != "" {
+			fmt.Println("           " + dep.Hint)
+		}
+	}
+
+	if cache, _ := build.LookupCompilerCache(build.CompilerCacheAuto); cache != "" {
+		fmt.Println("\n  compiler cache", cache)
+	}
+
+	if err != nil {
+		fmt.Println("\nnot ready:", err)
+		return errFailed
+	}
+
+	fmt.Println("\nready to build")
+	return nil
+}
+
+func flags(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("flags", flag.ExitOnError)
+
+	builder := builderFlags(fs)
+	out := fs.String("o", "", "write a Go file with cgo directives instead of printing environment variables")
+	installed := fs.String("installed", "", "take the flags of the install tree at this prefix instead of building")
+
+	fs.Parse(args)
+
+	cgo, err := cgoFlags(ctx, builder, *installed)
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		return build.WriteCgoFlags(*out, cgo)
+	}
+
+	ldflags := strings.Join(cgo.LDFlags, " ")
+	if len(cgo.PkgConfig) > 0 {
+		ldflags += " $(pkg-config --libs " + strings.Join(cgo.PkgConfig, " ") + ")"
+	}
+
+	cxxflags := strings.Join(cgo.CXXFlags, " ")
+	if len(cgo.PkgConfig) > 0 {
+		cxxflags += " $(pkg-config --cflags " + strings.Join(cgo.PkgConfig, " ") + ")"
+	}
+
+	fmt.Printf("CGO_CXXFLAGS=\"%s\"\nCGO_LDFLAGS=\"%s\"\n", cxxflags, ldflags)
+	return nil
+}
+
+// cgoFlags returns the flags of the install tree at installed, or those of
+// the build of builder if it is empty.
+func cgoFlags(ctx context.Context, builder func() (*build.Builder, error), installed string) (build.CgoFlags, error) {
+	if installed != "" {
+		inst, err := build.LoadInstalled(installed)
+		if err != nil {
+			return build.CgoFlags{}, err
+		}
+		return inst.Flags, nil
+	}
+
+	b, err := builder()
+	if err != nil {
+		return build.CgoFlags{}, err
+	}
+
+	art, err := b.Build(ctx)
+	if err != nil {
+		return build.CgoFlags{}, err
+	}
+	return b.CgoFlags(art), nil
+}
+
+func vendor(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("vendor", flag.ExitOnError)
+
+	builder := builderFlags(fs)
+
+	fs.Parse(args)
+
+	b, err := builder()
+	if err != nil {
+		return err
+	}
+
+	lock, err := b.Vendor(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range lock.Packages {
+		fmt.Println("vendored:", pkg.Name, pkg.Version+pkg.Tag, pkg.Commit)
+	}
+	return nil
+}
+
+func sbom(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("sbom", flag.ExitOnError)
+
+	builder := builderFlags(fs)
+	format := fs.String("format", string(saucer.SBOMSPDX), "format: spdx or cyclonedx")
+	out := fs.String("o", "", "write the bill of materials to this file instead of stdout")
+
+	fs.Parse(args)
+
+	b, err := builder()
+	if err != nil {
+		return err
+	}
+
+	data, err := b.SBOM(saucer.SBOMFormat(*format))
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		return os.WriteFile(*out, data, 0o644)
+	}
+
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+func notices(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("notices", flag.ExitOnError)
+
+	builder := builderFlags(fs)
+	out := fs.String("o", "", "write the notices to this file instead of stdout")
+
+	fs.Parse(args)
+
+	b, err := builder()
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return b.WriteNotices(os.Stdout)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+
+	if err := b.WriteNotices(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func verify(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: saucer verify [dir]\n\nVerifies the signed provenance of the embedded sources and, with dir, their extracted copy.")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		return errors.New("usage: saucer verify [dir]")
+	}
+
+	if err := saucer.VerifyProvenance(); err != nil {
+		return err
+	}
+
+	p := saucer.SourceProvenance()
+	fmt.Printf("saucer %s (%s) from %s, manifest sha256:%s\n", p.Version, p.Tag, p.Repository, p.Manifest)
+
+	if fs.NArg() == 1 {
+		if err := saucer.VerifyExtracted(fs.Arg(0)); err != nil {
+			return err
+		}
+		fmt.Println("extracted sources in", fs.Arg(0), "match")
+	}
+	return nil
+}
+
+func versions(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("versions", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: saucer versions\n\nLists the embedded saucer releases, selected by -version.")
+	}
+	fs.Parse(args)
+
+	for _, r := range saucer.Versions() {
+		line := fmt.Sprintf("%s\t%s", r.Version, r.Tag)
+		if !r.Date.IsZero() {
+			line += "\t" + r.Date.Format(time.DateOnly)
+		}
+		if r.Default {
+			line += "\t(default)"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func generateCmd(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	format := fs.String("format", "bazel", "build system to generate for, bazel or meson")
+	serializer := fs.String("serializer", saucer.SerializerGlaze, "built-in serializer, glaze, rflpp or none")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: saucer generate [flags] dir")
+	}
+
+	switch strings.ToLower(*format) {
+	case "bazel":
+		return saucer.GenerateBazel(fs.Arg(0), saucer.BazelOptions{Serializer: *serializer})
+	case "meson":
+		return saucer.GenerateMeson(fs.Arg(0), saucer.MesonOptions{Serializer: *serializer})
+	}
+	return fmt.Errorf("unknown format %q, expected bazel or meson", *format)
+}
+
+func export(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+
+	builder := builderFlags(fs)
+	format := fs.String("format", "vcpkg", "package manager to export the native dependencies for, vcpkg or conan")
+	out := fs.String("o", "", "write the manifest to this file instead of stdout")
+
+	fs.Parse(args)
+
+	b, err := builder()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch strings.ToLower(*format) {
+	case "vcpkg":
+		data, err = b.ExportVcpkgManifest()
+	case "conan":
+		data, err = b.ExportConanfile()
+	default:
+		return fmt.Errorf("unknown format %q, expected vcpkg or conan", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0o644)
+}
+
+func packCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("pack", flag.ExitOnError)
+
+	var meta pack.Metadata
+	format := fs.String("format", defaultPackFormat(), "package to create: app, appdir, appimage, msix, msixlayout or syso")
+	out := fs.String("o", ".", "directory to create the package in, the main package for syso")
+	categories := fs.String("categories", "", "comma separated freedesktop menu categories")
+	fs.StringVar(&meta.Name, "name", "", "display name of the application")
+	fs.StringVar(&meta.ID, "id", "", "reverse DNS identifier of the application")
+	fs.StringVar(&meta.Version, "version", "", "version of the application")
+	fs.StringVar(&meta.Icon, "icon", "", "icon, .icns for app and PNG otherwise")
+	fs.StringVar(&meta.Description, "description", "", "one line summary of the application")
+	fs.StringVar(&meta.Copyright, "copyright", "", "copyright notice of the Windows version information")
+	fs.StringVar(&meta.Publisher, "publisher", "", "subject of the MSIX signing certificate, e.g. CN=Example")
+	fs.StringVar(&meta.GOARCH, "goarch", "", "architecture of the binary (default: GOARCH of the host)")
+	identity := fs.String("sign", "", "codesign identity for app, certificate thumbprint for msix")
+	entitlements := fs.String("entitlements", "", "entitlements plist to codesign app with")
+	profile := fs.String("notarize", "", "notarytool keychain profile to notarize app with")
+	cert := fs.String("cert", "", "PFX certificate to sign msix with, its password read from $SAUCER_CERT_PASSWORD")
+
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("expected the path to the binary")
+	}
+	meta.Binary = fs.Arg(0)
+	if *categories != "" {
+		meta.Categories = strings.Split(*categories, ",")
+	}
+
+	if *cert != "" || (*identity != "" && !strings.EqualFold(*format, "app")) {
+		meta.Signer = &pack.Signtool{Certificate: pack.CertificateFunc(func(context.Context) (pack.Certificate, error) {
+			if *cert == "" {
+				return pack.Certificate{Thumbprint: *identity}, nil
+			}
+
+			pfx, err := os.ReadFile(*cert)
+			return pack.Certificate{PFX: pfx, Password: os.Getenv("SAUCER_CERT_PASSWORD")}, err
+		})}
+	}
+
+	var (
+		path string
+		err  error
+	)
+	switch strings.ToLower(*format) {
+	case "app":
+		path, err = pack.AppBundle(*out, meta)
+		if err == nil && *identity != "" {
+			signers := []pack.Signer{&pack.Codesign{Identity: *identity, Entitlements: *entitlements}}
+			if *profile != "" {
+				signers = append(signers, &pack.Notarize{Credentials: pack.NotaryAuthFunc(func(context.Context) (pack.NotaryAuth, error) {
+					return pack.NotaryAuth{Profile: *profile}, nil
+				})})
+			}
+			err = pack.Sign(ctx, path, signers...)
+		}
+	case "appdir":
+		path, err = pack.AppDir(*out, meta)
+	case "appimage":
+		path, err = pack.AppImage(ctx, *out, meta)
+	case "msix":
+		path, err = pack.MSIX(ctx, *out, meta)
+	case "msixlayout":
+		path, err = pack.MSIXLayout(*out, meta)
+	case "syso":
+		path, err = pack.WriteSyso(*out, meta)
+	default:
+		return fmt.Errorf("unknown format %q, expected app, appdir, appimage, msix, msixlayout or syso", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(path)
+	return nil
+}
+
+// defaultPackFormat returns the package format of the host.
+func defaultPackFormat() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "app"
+	case "windows":
+		return "msix"
+	}
+	return "appimage"
+}
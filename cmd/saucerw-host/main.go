@@ -0,0 +1,17 @@
+// Command saucerw-host runs the webviews of a saucerw application started
+// with the driver of package remote in a separate process.
+//
+// The driver starts it, it is not run by hand. Build it with cgo and -tags
+// saucer and install it next to the application or in PATH:
+//
+//	go build -tags saucer -o $(dirname app)/saucerw-host ./cmd/saucerw-host
+package main
+
+import (
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/remote"
+)
+
+func main() {
+	remote.Main(saucerw.DefaultDriver())
+}
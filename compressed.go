@@ -0,0 +1,32 @@
+package saucer
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"sync"
+)
+
+// compressedFS serves an embedded zip archive. The archive index is read on
+// first use, files are inflated when they are opened.
+type compressedFS struct {
+	reader func() (*zip.Reader, error)
+}
+
+// newCompressedFS returns a file system reading the zip archive data.
+func newCompressedFS(data []byte) *compressedFS {
+	return &compressedFS{
+		reader: sync.OnceValues(func() (*zip.Reader, error) {
+			return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		}),
+	}
+}
+
+// Open implements fs.FS.
+func (c *compressedFS) Open(name string) (fs.File, error) {
+	r, err := c.reader()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return r.Open(name)
+}
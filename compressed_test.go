@@ -0,0 +1,45 @@
+//go:build !saucer_raw
+
+package saucer
+
+import (
+	"io/fs"
+	"testing"
+)
+
+// BenchmarkCompressedFirstAccess reads a single file from freshly opened
+// archives, the latency paid on the first access to Source. It reports the
+// embedded archive size next to the size of the inflated tree.
+func BenchmarkCompressedFirstAccess(b *testing.B) {
+	// The trees excluded by build tags are empty and skipped.
+	trees := []fs.FS{SourceCore, SourceQt6, SourceWebKitGTK, SourceWebView2, SourceWKWebView, SourceRflpp}
+
+	var embedded int
+	for _, tree := range trees {
+		c, ok := tree.(*compressedFS)
+		if !ok {
+			continue
+		}
+		r, err := c.reader()
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, f := range r.File {
+			embedded += int(f.CompressedSize64)
+		}
+	}
+
+	for b.Loop() {
+		if _, err := fs.ReadFile(newCompressedFS(sourceCoreZip), "CMakeLists.txt"); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	source := 0
+	for _, entry := range SourceManifest() {
+		source += int(entry.Size)
+	}
+
+	b.ReportMetric(float64(embedded), "embedded-bytes")
+	b.ReportMetric(float64(source), "source-bytes")
+}
@@ -0,0 +1,254 @@
+package saucer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fileDiff is the part of a unified diff changing a single file.
+type fileDiff struct {
+	// oldPath is empty for created files, newPath for deleted files.
+	oldPath string
+	newPath string
+	hunks   []hunk
+}
+
+// hunk is a single @@ section of a unified diff.
+type hunk struct {
+	// oldLine is the 1-based line the hunk starts at in the original file.
+	oldLine int
+	// old and new are the lines of the hunk before and after the change,
+	// including their line endings.
+	old []string
+	new []string
+}
+
+// parseDiff parses a unified diff into per-file changes. Text outside of the
+// file sections, e.g. a commit message, is ignored.
+func parseDiff(diff []byte) ([]*fileDiff, error) {
+	var (
+		rtn     []*fileDiff
+		current *fileDiff
+		h       *hunk
+
+		// Lines of the current hunk still expected on each side.
+		oldLeft, newLeft int
+	)
+
+	lines := splitLines(diff)
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if h != nil && (oldLeft > 0 || newLeft > 0) {
+			switch {
+			case strings.HasPrefix(line, " "), line == "\n", line == "\r\n":
+				h.old = append(h.old, strings.TrimPrefix(line, " "))
+				h.new = append(h.new, strings.TrimPrefix(line, " "))
+				oldLeft, newLeft = oldLeft-1, newLeft-1
+			case strings.HasPrefix(line, "-"):
+				h.old = append(h.old, line[1:])
+				oldLeft--
+			case strings.HasPrefix(line, "+"):
+				h.new = append(h.new, line[1:])
+				newLeft--
+			case strings.HasPrefix(line, `\`):
+				trimLast(h, lines[i-1])
+			default:
+				return nil, fmt.Errorf("line %d: unexpected line in hunk", i+1)
+			}
+			continue
+		}
+
+		switch {
+		case h != nil && strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" after the last line of a hunk.
+			trimLast(h, lines[i-1])
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			current = &fileDiff{oldPath: diffPath(line[4:]), newPath: diffPath(lines[i+1][4:])}
+			rtn, h = append(rtn, current), nil
+			i++
+		case strings.HasPrefix(line, "@@ "):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: hunk without file header", i+1)
+			}
+
+			start, oldCount, newCount, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+
+			current.hunks = append(current.hunks, hunk{oldLine: start})
+			h = &current.hunks[len(current.hunks)-1]
+			oldLeft, newLeft = oldCount, newCount
+		default:
+			// Preamble, extended git headers or trailing text.
+			h = nil
+		}
+	}
+
+	if h != nil && (oldLeft > 0 || newLeft > 0) {
+		return nil, errors.New("diff ends inside a hunk")
+	}
+
+	if len(rtn) == 0 && len(bytes.TrimSpace(diff)) > 0 {
+		return nil, errors.New("no file changes found in diff")
+	}
+	return rtn, nil
+}
+
+// trimLast handles a "\\ No newline at end of file" marker following prev by
+// removing the line ending of the last line on the side(s) prev belongs to.
+func trimLast(h *hunk, prev string) {
+	trim := func(lines []string) {
+		if len(lines) > 0 {
+			lines[len(lines)-1] = strings.TrimRight(lines[len(lines)-1], "\r\n")
+		}
+	}
+
+	if !strings.HasPrefix(prev, "+") {
+		trim(h.old)
+	}
+	if !strings.HasPrefix(prev, "-") {
+		trim(h.new)
+	}
+}
+
+// diffPath converts a path of a ---/+++ header into a source tree path.
+func diffPath(header string) string {
+	name, _, _ := strings.Cut(strings.TrimRight(header, "\r\n"), "\t")
+
+	if name == "/dev/null" {
+		return ""
+	}
+
+	for _, prefix := range []string{"a/", "b/"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			return rest
+		}
+	}
+	return name
+}
+
+// parseHunkHeader parses a "@@ -l,s +l,s @@" header into the original start
+// line and the line counts of both sides.
+func parseHunkHeader(header string) (start, oldCount, newCount int, err error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header %q", strings.TrimSpace(header))
+	}
+
+	start, oldCount, err = parseRange(fields[1][1:])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	_, newCount, err = parseRange(fields[2][1:])
+	return start, oldCount, newCount, err
+}
+
+// parseRange parses "l,s" or "l", where a missing count means one line.
+func parseRange(r string) (line, count int, err error) {
+	first, second, ok := strings.Cut(r, ",")
+
+	if line, err = strconv.Atoi(first); err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk range %q", r)
+	}
+
+	if !ok {
+		return line, 1, nil
+	}
+
+	if count, err = strconv.Atoi(second); err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk range %q", r)
+	}
+	return line, count, nil
+}
+
+// apply returns content with the hunks of d applied. Hunks are matched at
+// their recorded position first and searched for in the whole file otherwise.
+func (d *fileDiff) apply(content []byte) ([]byte, error) {
+	lines := splitLines(content)
+
+	var (
+		out    []string
+		cursor int
+	)
+
+	for i, h := range d.hunks {
+		at := h.find(lines, cursor)
+		if at < 0 {
+			return nil, fmt.Errorf("hunk #%d at line %d does not apply%s", i+1, h.oldLine, h.context())
+		}
+
+		out = append(out, lines[cursor:at]...)
+		out = append(out, h.new...)
+		cursor = at + len(h.old)
+	}
+
+	out = append(out, lines[cursor:]...)
+	return []byte(strings.Join(out, "")), nil
+}
+
+// find returns the index of the first line of h in lines at or after from,
+// preferring the recorded position, or -1.
+func (h *hunk) find(lines []string, from int) int {
+	want := max(h.oldLine-1, from)
+	if len(h.old) == 0 {
+		// Insertions into an empty or new file use line 0.
+		want = max(h.oldLine, from)
+	}
+
+	if h.matches(lines, want) {
+		return want
+	}
+
+	for at := from; at+len(h.old) <= len(lines); at++ {
+		if h.matches(lines, at) {
+			return at
+		}
+	}
+	return -1
+}
+
+// context describes the first original line of h for errors.
+func (h *hunk) context() string {
+	if len(h.old) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", expected %q", strings.TrimRight(h.old[0], "\r\n"))
+}
+
+// matches reports whether the original lines of h appear in lines at at.
+func (h *hunk) matches(lines []string, at int) bool {
+	if at < 0 || at+len(h.old) > len(lines) {
+		return false
+	}
+
+	for i, line := range h.old {
+		if strings.TrimRight(lines[at+i], "\r\n") != strings.TrimRight(line, "\r\n") {
+			return false
+		}
+	}
+	return true
+}
+
+// splitLines splits data into lines, keeping the line endings.
+func splitLines(data []byte) []string {
+	var rtn []string
+
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			rtn = append(rtn, string(data))
+			break
+		}
+
+		rtn = append(rtn, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return rtn
+}
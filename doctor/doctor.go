@@ -0,0 +1,232 @@
+// Package doctor captures the environment saucer is built and run in: the
+// operating system, the toolchain, the webview runtimes and the graphics
+// stack, for machine-readable diagnostics and bug reports.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/aperturerobotics/saucer"
+	"github.com/aperturerobotics/saucer/build"
+)
+
+// probeTimeout bounds every command run by Report.
+const probeTimeout = 10 * time.Second
+
+// Environment is the result of Report.
+type Environment struct {
+	// Saucer describes the embedded saucer sources.
+	Saucer Saucer `json:"saucer"`
+	// OS describes the host.
+	OS OS `json:"os"`
+	// Backend is the backend the toolchain was checked for.
+	Backend build.Backend `json:"backend"`
+	// Toolchain lists the build requirements of Backend, see
+	// build.CheckDependencies.
+	Toolchain []build.Dependency `json:"toolchain"`
+	// CompilerCache is the path of the compiler cache in use, if any.
+	CompilerCache string `json:"compilerCache,omitempty"`
+	// Runtimes lists the webview runtimes available to applications.
+	Runtimes []Runtime `json:"runtimes"`
+	// GPUs lists the graphics adapters and drivers found.
+	GPUs []GPU `json:"gpus"`
+	// Env holds the set environment variables affecting rendering and the
+	// display connection.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Saucer describes the embedded saucer sources.
+type Saucer struct {
+	Version     string `json:"version"`
+	UpstreamTag string `json:"upstreamTag"`
+	Commit      string `json:"commit,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+}
+
+// OS describes the host.
+type OS struct {
+	// GOOS and GOARCH are the platform of the running binary.
+	GOOS   string `json:"goos"`
+	GOARCH string `json:"goarch"`
+	// Name is the name and version of the distribution or release, e.g.
+	// "Ubuntu 24.04.1 LTS" or "macOS 15.1".
+	Name string `json:"name,omitempty"`
+	// Kernel is the kernel or build version.
+	Kernel string `json:"kernel,omitempty"`
+	// Session is the display session, e.g. "wayland" or "x11" on Linux.
+	Session string `json:"session,omitempty"`
+	// CPUs is the number of logical CPUs.
+	CPUs int `json:"cpus"`
+	// Go is the Go release the binary was built with.
+	Go string `json:"go"`
+}
+
+// Runtime is a webview runtime of a backend.
+type Runtime struct {
+	// Backend is the backend using the runtime.
+	Backend build.Backend `json:"backend"`
+	// Name identifies the runtime, e.g. "WebKitGTK 6.0".
+	Name string `json:"name"`
+	// Version is the detected version, empty if unknown.
+	Version string `json:"version,omitempty"`
+	// Found reports whether the runtime is installed.
+	Found bool `json:"found"`
+}
+
+// GPU is a graphics adapter.
+type GPU struct {
+	// Name is the adapter, e.g. "NVIDIA Corporation GA104 [GeForce RTX 3070]".
+	Name string `json:"name"`
+	// Driver is the driver or OpenGL renderer in use, if known.
+	Driver string `json:"driver,omitempty"`
+}
+
+// Options configures Report.
+type Options struct {
+	// Backend selects the backend whose toolchain is checked, BackendDefault
+	// if empty.
+	Backend build.Backend
+}
+
+// envHints are the environment variables reported in Env.
+var envHints = []string{
+	"XDG_SESSION_TYPE", "XDG_CURRENT_DESKTOP", "WAYLAND_DISPLAY", "DISPLAY",
+	"GDK_BACKEND", "QT_QPA_PLATFORM", "LIBGL_ALWAYS_SOFTWARE",
+	"WEBKIT_DISABLE_DMABUF_RENDERER", "WEBKIT_DISABLE_COMPOSITING_MODE",
+	"QTWEBENGINE_CHROMIUM_FLAGS", "WEBVIEW2_BROWSER_EXECUTABLE_FOLDER",
+	"WEBVIEW2_ADDITIONAL_BROWSER_ARGUMENTS",
+}
+
+// Report probes the host. Probes failing or unavailable on the platform are
+// left empty rather than reported as errors.
+func Report(ctx context.Context, opts Options) *Environment {
+	if opts.Backend == "" {
+		opts.Backend = build.BackendDefault
+	}
+
+	env := &Environment{
+		Saucer: Saucer{
+			Version:     saucer.Version(),
+			UpstreamTag: saucer.UpstreamTag(),
+			Commit:      saucer.UpstreamCommit(),
+		},
+		OS:      probeOS(ctx),
+		Backend: opts.Backend,
+	}
+	if date := saucer.ReleaseDate(); !date.IsZero() {
+		env.Saucer.ReleaseDate = date.Format(time.DateOnly)
+	}
+
+	env.Toolchain, _ = build.CheckDependencies(opts.Backend)
+	env.CompilerCache, _ = build.LookupCompilerCache(build.CompilerCacheAuto)
+	env.Runtimes = probeRuntimes(ctx)
+	env.GPUs = probeGPUs(ctx)
+
+	for _, name := range envHints {
+		if value, ok := os.LookupEnv(name); ok {
+			if env.Env == nil {
+				env.Env = map[string]string{}
+			}
+			env.Env[name] = value
+		}
+	}
+	return env
+}
+
+// JSON returns the indented JSON encoding of the environment.
+func (e *Environment) JSON() ([]byte, error) {
+	return json.MarshalIndent(e, "", "  ")
+}
+
+// Text renders the environment for pasting into bug reports. The home
+// directory, the user and the host names are redacted.
+func (e *Environment) Text() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "saucer %s (%s)", e.Saucer.Version, e.Saucer.UpstreamTag)
+	if e.Saucer.ReleaseDate != "" {
+		fmt.Fprintf(&b, ", released %s", e.Saucer.ReleaseDate)
+	}
+	fmt.Fprintf(&b, "\n%s/%s", e.OS.GOOS, e.OS.GOARCH)
+	for _, part := range []string{e.OS.Name, e.OS.Kernel, e.OS.Session} {
+		if part != "" {
+			b.WriteString(", " + part)
+		}
+	}
+	fmt.Fprintf(&b, ", %d CPUs, %s\n", e.OS.CPUs, e.OS.Go)
+
+	fmt.Fprintf(&b, "\ntoolchain (backend %s):\n", e.Backend)
+	for _, dep := range e.Toolchain {
+		writeItem(&b, dep.Found, dep.Name, dep.Version)
+	}
+	if e.CompilerCache != "" {
+		fmt.Fprintf(&b, "  compiler cache %s\n", e.CompilerCache)
+	}
+
+	b.WriteString("\nruntimes:\n")
+	for _, rt := range e.Runtimes {
+		writeItem(&b, rt.Found, rt.Name, rt.Version)
+	}
+
+	b.WriteString("\ngpus:\n")
+	if len(e.GPUs) == 0 {
+		b.WriteString("  unknown\n")
+	}
+	for _, gpu := range e.GPUs {
+		line := "  " + gpu.Name
+		if gpu.Driver != "" {
+			line += " (" + gpu.Driver + ")"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if len(e.Env) > 0 {
+		b.WriteString("\nenvironment:\n")
+		for _, name := range envHints {
+			if value, ok := e.Env[name]; ok {
+				fmt.Fprintf(&b, "  %s=%s\n", name, value)
+			}
+		}
+	}
+	return redact(b.String())
+}
+
+// writeItem writes a line of a found or missing item.
+func writeItem(b *strings.Builder, found bool, name, version string) {
+	mark := "ok     "
+	if !found {
+		mark = "missing"
+	}
+	line := "  " + mark + "  " + name
+	if version != "" {
+		line += " " + version
+	}
+	b.WriteString(line + "\n")
+}
+
+// redact replaces the home directory, the user name in paths and the host
+// name in s.
+func redact(s string) string {
+	var pairs []string
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		pairs = append(pairs, home, "~")
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// Windows user names are DOMAIN\name
+		name := u.Username[strings.LastIndex(u.Username, `\`)+1:]
+		pairs = append(pairs, "/"+name+"/", "/<user>/", `\`+name+`\`, `\<user>\`)
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		pairs = append(pairs, host, "<host>")
+	}
+	if len(pairs) == 0 {
+		return s
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
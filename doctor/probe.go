@@ -0,0 +1,221 @@
+package doctor
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/aperturerobotics/saucer/build"
+)
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// output runs name with args and returns its trimmed output, empty if it
+// fails.
+func output(ctx context.Context, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// probeOS describes the host.
+func probeOS(ctx context.Context) OS {
+	rtn := OS{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH, CPUs: runtime.NumCPU(), Go: runtime.Version()}
+
+	switch runtime.GOOS {
+	case "linux":
+		rtn.Name = osRelease()["PRETTY_NAME"]
+		if data, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+			rtn.Kernel = strings.TrimSpace(string(data))
+		}
+		rtn.Session = os.Getenv("XDG_SESSION_TYPE")
+	case "darwin":
+		if v := output(ctx, "sw_vers", "-productVersion"); v != "" {
+			rtn.Name = "macOS " + v
+		}
+		rtn.Kernel = output(ctx, "sw_vers", "-buildVersion")
+	case "windows":
+		// "Microsoft Windows [Version 10.0.22631.4460]"
+		ver := output(ctx, "cmd", "/c", "ver")
+		if v := versionPattern.FindString(ver); v != "" {
+			rtn.Name, rtn.Kernel = "Windows", v
+		}
+		if v := registryValue(ctx, `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion`, "DisplayVersion"); v != "" {
+			rtn.Name += " " + v
+		}
+	default:
+		rtn.Kernel = output(ctx, "uname", "-sr")
+	}
+	return rtn
+}
+
+// osRelease parses /etc/os-release.
+func osRelease() map[string]string {
+	rtn := map[string]string{}
+
+	f, err := os.Open("/etc/os-release")
+	if err != nil {
+		return rtn
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			rtn[key] = strings.Trim(value, `"'`)
+		}
+	}
+	return rtn
+}
+
+// registryValue returns the value name of the Windows registry key, empty if
+// it is not set.
+func registryValue(ctx context.Context, key, name string) string {
+	// "    pv    REG_SZ    131.0.2903.70"
+	for _, line := range strings.Split(output(ctx, "reg", "query", key, "/v", name), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && strings.EqualFold(fields[0], name) && strings.HasPrefix(fields[1], "REG_") {
+			return strings.Join(fields[2:], " ")
+		}
+	}
+	return ""
+}
+
+// webView2Client is the EdgeUpdate client id of the WebView2 runtime.
+const webView2Client = `{F3017226-FE2A-4295-8BDF-00C3A9A7E4C5}`
+
+// probeRuntimes detects the webview runtimes of the backends of the host.
+func probeRuntimes(ctx context.Context) []Runtime {
+	switch runtime.GOOS {
+	case "windows":
+		rt := Runtime{Backend: build.BackendWebView2, Name: "WebView2 Runtime"}
+		for _, key := range []string{
+			`HKLM\SOFTWARE\WOW6432Node\Microsoft\EdgeUpdate\Clients\` + webView2Client,
+			`HKLM\SOFTWARE\Microsoft\EdgeUpdate\Clients\` + webView2Client,
+			`HKCU\SOFTWARE\Microsoft\EdgeUpdate\Clients\` + webView2Client,
+		} {
+			if v := registryValue(ctx, key, "pv"); v != "" && v != "0.0.0.0" {
+				rt.Version, rt.Found = v, true
+				break
+			}
+		}
+		return []Runtime{rt}
+	case "darwin":
+		rt := Runtime{Backend: build.BackendWebKit, Name: "WebKit.framework"}
+		rt.Version = output(ctx, "defaults", "read", "/System/Library/Frameworks/WebKit.framework/Resources/Info", "CFBundleVersion")
+		rt.Found = rt.Version != ""
+		return []Runtime{rt, probeQt(ctx)}
+	default:
+		return []Runtime{probeWebKitGTK(ctx), probeQt(ctx)}
+	}
+}
+
+// probeWebKitGTK detects WebKitGTK 6.0, by pkg-config or the shared library
+// registered with the dynamic linker.
+func probeWebKitGTK(ctx context.Context) Runtime {
+	rt := Runtime{Backend: build.BackendWebKitGtk, Name: "WebKitGTK 6.0"}
+
+	if v := output(ctx, "pkg-config", "--modversion", "webkitgtk-6.0"); v != "" {
+		rt.Version, rt.Found = v, true
+		return rt
+	}
+	rt.Found = strings.Contains(output(ctx, "ldconfig", "-p"), "libwebkitgtk-6.0.so")
+	return rt
+}
+
+// probeQt detects Qt WebEngine 6.
+func probeQt(ctx context.Context) Runtime {
+	rt := Runtime{Backend: build.BackendQt, Name: "Qt 6 WebEngine"}
+
+	if v := output(ctx, "pkg-config", "--modversion", "Qt6WebEngineCore"); v != "" {
+		rt.Version, rt.Found = v, true
+		return rt
+	}
+	for _, tool := range []string{"qtpaths6", "qtpaths"} {
+		if v := versionPattern.FindString(output(ctx, tool, "--qt-version")); strings.HasPrefix(v, "6.") {
+			rt.Version, rt.Found = v, true
+			break
+		}
+	}
+	return rt
+}
+
+// probeGPUs lists the graphics adapters of the host.
+func probeGPUs(ctx context.Context) []GPU {
+	var rtn []GPU
+
+	switch runtime.GOOS {
+	case "linux":
+		// "01:00.0 VGA compatible controller: NVIDIA Corporation ..."
+		for _, line := range strings.Split(output(ctx, "lspci"), "\n") {
+			if _, name, ok := strings.Cut(line, " VGA compatible controller: "); ok {
+				rtn = append(rtn, GPU{Name: name})
+			} else if _, name, ok := strings.Cut(line, " 3D controller: "); ok {
+				rtn = append(rtn, GPU{Name: name})
+			}
+		}
+
+		driver := glRenderer(ctx)
+		if data, err := os.ReadFile("/proc/driver/nvidia/version"); err == nil {
+			if v := versionPattern.FindString(string(data)); v != "" {
+				if driver != "" {
+					driver += ", "
+				}
+				driver += "NVIDIA " + v
+			}
+		}
+		if driver != "" {
+			if len(rtn) == 0 {
+				rtn = append(rtn, GPU{Name: "unknown"})
+			}
+			rtn[0].Driver = driver
+		}
+	case "darwin":
+		// "      Chipset Model: Apple M2"
+		for _, line := range strings.Split(output(ctx, "system_profiler", "SPDisplaysDataType"), "\n") {
+			if _, name, ok := strings.Cut(line, "Chipset Model: "); ok {
+				rtn = append(rtn, GPU{Name: strings.TrimSpace(name)})
+			}
+		}
+	case "windows":
+		// "NVIDIA GeForce RTX 3070|32.0.15.6603"
+		out := output(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command",
+			`Get-CimInstance Win32_VideoController | ForEach-Object { $_.Name + '|' + $_.DriverVersion }`)
+		for _, line := range strings.Split(out, "\n") {
+			if name, driver, ok := strings.Cut(strings.TrimSpace(line), "|"); ok && name != "" {
+				rtn = append(rtn, GPU{Name: name, Driver: driver})
+			}
+		}
+	}
+	return rtn
+}
+
+// glRenderer returns the OpenGL renderer and version reported by glxinfo.
+func glRenderer(ctx context.Context) string {
+	var renderer, version string
+	for _, line := range strings.Split(output(ctx, "glxinfo", "-B"), "\n") {
+		line = strings.TrimSpace(line)
+		if v, ok := strings.CutPrefix(line, "OpenGL renderer string: "); ok {
+			renderer = v
+		} else if v, ok := strings.CutPrefix(line, "OpenGL version string: "); ok {
+			version = v
+		}
+	}
+	if renderer == "" {
+		return ""
+	}
+	if version != "" {
+		return renderer + ", OpenGL " + version
+	}
+	return renderer
+}
@@ -1,8 +1,11 @@
+//go:build saucer_raw
+
 package saucer
 
 import "embed"
 
-// Source embeds the saucer C++ source files for Go vendoring.
+// sourceCore embeds the backend and serializer independent sources
+// uncompressed, with glaze, the default serializer.
 //
 //go:embed CMakeLists.txt
 //go:embed cmake/*.cmake
@@ -13,26 +16,44 @@ import "embed"
 //go:embed include/saucer/error/*.hpp
 //go:embed include/saucer/error/*.inl
 //go:embed include/saucer/modules/*.hpp
-//go:embed include/saucer/modules/stable/*.hpp
 //go:embed include/saucer/serializers/*.hpp
 //go:embed include/saucer/serializers/*.inl
 //go:embed include/saucer/serializers/format/*.hpp
 //go:embed include/saucer/serializers/format/*.inl
 //go:embed include/saucer/serializers/glaze/*.hpp
 //go:embed include/saucer/serializers/glaze/*.inl
-//go:embed include/saucer/serializers/rflpp/*.hpp
-//go:embed include/saucer/serializers/rflpp/*.inl
 //go:embed include/saucer/stash/*.hpp
 //go:embed include/saucer/stash/*.inl
 //go:embed include/saucer/traits/*.hpp
 //go:embed include/saucer/traits/*.inl
 //go:embed include/saucer/utils/*.hpp
 //go:embed include/saucer/utils/*.inl
-//go:embed private/saucer/*.hpp
-//go:embed private/saucer/*.inl
-//go:embed src/*.cpp
-//go:embed src/*.mm
-//go:embed src/module/*.cpp
-//go:embed src/module/*.mm
+//go:embed private/saucer/app.impl.hpp
+//go:embed private/saucer/error.impl.hpp
+//go:embed private/saucer/handle.hpp
+//go:embed private/saucer/handle.inl
+//go:embed private/saucer/instantiate.hpp
+//go:embed private/saucer/invoke.hpp
+//go:embed private/saucer/invoke.inl
+//go:embed private/saucer/lease.hpp
+//go:embed private/saucer/lease.inl
+//go:embed private/saucer/ref_obj.hpp
+//go:embed private/saucer/ref_obj.inl
+//go:embed private/saucer/request.hpp
+//go:embed private/saucer/request.utils.hpp
+//go:embed private/saucer/request.utils.inl
+//go:embed private/saucer/scripts.hpp
+//go:embed private/saucer/webview.impl.hpp
+//go:embed private/saucer/window.impl.hpp
+//go:embed src/app.cpp
+//go:embed src/error.cpp
+//go:embed src/error.impl.cpp
+//go:embed src/request.cpp
+//go:embed src/smartview.cpp
+//go:embed src/webview.cpp
+//go:embed src/webview.impl.cpp
+//go:embed src/window.cpp
+//go:embed src/glaze.*.cpp
+//go:embed src/module/unstable.cpp
 //go:embed template/*.in
-var Source embed.FS
+var sourceCore embed.FS
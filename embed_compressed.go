@@ -0,0 +1,10 @@
+//go:build !saucer_raw
+
+package saucer
+
+import _ "embed"
+
+//go:embed zz_source_core.zip
+var sourceCoreZip []byte
+
+var sourceCore = newCompressedFS(sourceCoreZip)
@@ -0,0 +1,208 @@
+package saucer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// ExtractOptions configures Extract and ExtractFS.
+type ExtractOptions struct {
+	// OnlyIfChanged leaves files untouched when their content on disk already
+	// matches the embedded content (compared by SHA-256). This keeps mtimes
+	// stable so CMake does not rebuild unchanged sources.
+	OnlyIfChanged bool
+	// ModTime is applied to every written file when non-zero.
+	ModTime time.Time
+	// Skip is a list of path.Match patterns matched against the slash-separated
+	// path of every file and directory. Matching directories are skipped entirely.
+	Skip []string
+	// Reproducible makes the extracted tree bit-for-bit reproducible, e.g. for
+	// hashing by Nix or Bazel: files and directories get the modes 0644 and
+	// 0755 regardless of the umask and the timestamp ModTime, the ReleaseDate
+	// if ModTime is zero, or 1980-01-01 UTC if that is unknown. Files are
+	// written in sorted order, unchanged ones are normalized as well.
+	Reproducible bool
+	// Templates, when set, renders every template of the tree with the
+	// variables and writes it to its Output, as configuring the CMake project
+	// does, see ListTemplatesFS. Templates matched by Skip are not rendered.
+	Templates TemplateVars
+}
+
+// ExtractError is returned by Extract and ExtractFS when a file or directory of
+// the tree could not be extracted.
+type ExtractError struct {
+	// Path is the slash-separated name of the file or directory in the tree.
+	Path string
+	// Err is the error reading or writing it.
+	Err error
+}
+
+func (e *ExtractError) Error() string {
+	return fmt.Sprintf("saucer: extract %s: %v", e.Path, e.Err)
+}
+
+func (e *ExtractError) Unwrap() error {
+	return e.Err
+}
+
+// Extract writes the tree of SourceFS to dir.
+func Extract(dir string, opts ExtractOptions) error {
+	return ExtractFS(source, dir, opts)
+}
+
+// ExtractFS writes the tree in src to dir. Failing files and directories
+// return an *ExtractError.
+func ExtractFS(src fs.FS, dir string, opts ExtractOptions) error {
+	if err := checkPatterns(opts.Skip); err != nil {
+		return err
+	}
+
+	var dirs []string
+
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return &ExtractError{Path: name, Err: err}
+		}
+
+		if name != "." && skipped(opts.Skip, name) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if d.IsDir() {
+			dirs = append(dirs, name)
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return &ExtractError{Path: name, Err: err}
+			}
+			return nil
+		}
+
+		data, err := fs.ReadFile(src, name)
+		if err == nil {
+			err = opts.write(target, data)
+		}
+		if err != nil {
+			return &ExtractError{Path: name, Err: err}
+		}
+		return nil
+	})
+	if err == nil && opts.Templates != nil {
+		err = renderTemplates(src, dir, &opts)
+	}
+	if err != nil || !opts.Reproducible {
+		return err
+	}
+
+	// Directories are normalized last, writing their files changes their
+	// timestamps
+	mtime := opts.modTime()
+	for _, name := range dirs {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.Chmod(target, 0o755); err != nil {
+			return &ExtractError{Path: name, Err: err}
+		}
+		if err := os.Chtimes(target, mtime, mtime); err != nil {
+			return &ExtractError{Path: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// renderTemplates writes the templates of src rendered with opts.Templates
+// below dir.
+func renderTemplates(src fs.FS, dir string, opts *ExtractOptions) error {
+	templates, err := ListTemplatesFS(src)
+	if err != nil {
+		return err
+	}
+
+	for _, tmpl := range templates {
+		if skipped(opts.Skip, tmpl.Name) {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(tmpl.Output))
+		data, err := tmpl.Render(src, opts.Templates)
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(target), 0o755)
+		}
+		if err == nil {
+			err = opts.write(target, data)
+		}
+		if err != nil {
+			return &ExtractError{Path: tmpl.Output, Err: err}
+		}
+	}
+	return nil
+}
+
+// checkPatterns validates skip patterns.
+func checkPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("skip pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// skipped reports whether name matches one of the skip patterns.
+func skipped(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// write writes data to target honoring the extraction options.
+func (o *ExtractOptions) write(target string, data []byte) error {
+	unchanged := o.OnlyIfChanged && sameContent(target, data)
+
+	if !unchanged {
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	if o.Reproducible {
+		if err := os.Chmod(target, 0o644); err != nil {
+			return err
+		}
+	} else if unchanged || o.ModTime.IsZero() {
+		return nil
+	}
+
+	mtime := o.modTime()
+	return os.Chtimes(target, mtime, mtime)
+}
+
+// modTime returns the timestamp of the written files, see Reproducible.
+func (o *ExtractOptions) modTime() time.Time {
+	switch {
+	case !o.ModTime.IsZero():
+		return o.ModTime
+	case !ReleaseDate().IsZero():
+		return ReleaseDate()
+	}
+	return archiveEpoch
+}
+
+// sameContent reports whether the file at target has the same SHA-256 as data.
+func sameContent(target string, data []byte) bool {
+	existing, err := os.ReadFile(target)
+	if err != nil {
+		return false
+	}
+
+	return sha256.Sum256(existing) == sha256.Sum256(data)
+}
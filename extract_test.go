@@ -0,0 +1,92 @@
+package saucer
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestExtractReproducible extracts Source into a fresh directory and over a
+// stale copy with other content and modes, and checks both trees are
+// byte-identical including modes and timestamps.
+func TestExtractReproducible(t *testing.T) {
+	fresh, stale := t.TempDir(), t.TempDir()
+
+	if err := ExtractFS(Source, fresh, ExtractOptions{Reproducible: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExtractFS(Source, stale, ExtractOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(stale, "CMakeLists.txt"), []byte("stale"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(stale, "src", "app.cpp"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExtractFS(Source, stale, ExtractOptions{Reproducible: true, OnlyIfChanged: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	want, got := snapshot(t, fresh), snapshot(t, stale)
+	if len(got) != len(want) {
+		t.Fatalf("extracted %d entries, want %d", len(got), len(want))
+	}
+
+	for name, w := range want {
+		g, ok := got[name]
+		switch {
+		case !ok:
+			t.Errorf("%s: missing", name)
+		case g.mode != w.mode || !g.modTime.Equal(w.modTime):
+			t.Errorf("%s: %v %v, want %v %v", name, g.mode, g.modTime, w.mode, w.modTime)
+		case !bytes.Equal(g.data, w.data):
+			t.Errorf("%s: content differs", name)
+		}
+	}
+}
+
+// snapshotEntry is the state of an extracted file or directory.
+type snapshotEntry struct {
+	mode    fs.FileMode
+	modTime time.Time
+	data    []byte
+}
+
+// snapshot records every entry below dir by slash-separated path.
+func snapshot(t *testing.T, dir string) map[string]snapshotEntry {
+	t.Helper()
+
+	rtn := map[string]snapshotEntry{}
+
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == dir {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		e := snapshotEntry{mode: info.Mode(), modTime: info.ModTime()}
+		if !d.IsDir() {
+			if e.data, err = os.ReadFile(name); err != nil {
+				return err
+			}
+		}
+
+		rel, err := filepath.Rel(dir, name)
+		rtn[filepath.ToSlash(rel)] = e
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rtn
+}
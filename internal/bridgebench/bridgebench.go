@@ -0,0 +1,163 @@
+// Package bridgebench measures calls of exposed functions through the saucerw
+// bridge, for the benchmarks of package saucerw and cmd/bridgeprof.
+//
+// Calls take small, large and binary arguments and go to functions exposed
+// with Expose, which decodes the arguments by reflection, and with ExposeRaw,
+// which does not.
+package bridgebench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// Script installs window.bench in a native page, making n calls of the
+// exposed function name with at most parallel in flight.
+const Script = `
+window.bench = async (name, params, n, parallel) =>
+{
+    let started = 0;
+
+    const worker = async () =>
+    {
+        while (started < n)
+        {
+            started++;
+            await window.saucer.call(name, params);
+        }
+    };
+
+    await Promise.all(Array.from({ length: Math.min(parallel, n) }, worker));
+};
+`
+
+// Argument is the kind of argument of the calls of a benchmark.
+type Argument struct {
+	Name string
+	// Params returns the arguments for the fake page.
+	Params func(size int) []any
+	// JS returns the JavaScript expression of the arguments for the native
+	// page.
+	JS func(size int) string
+	// Bytes returns the bytes sent and received by a call.
+	Bytes func(size int) int64
+}
+
+var (
+	// Small are two integers.
+	Small = Argument{
+		Name:   "small",
+		Params: func(int) []any { return []any{1, 2} },
+		JS:     func(int) string { return "[1, 2]" },
+		Bytes:  func(int) int64 { return 0 },
+	}
+	// Large is a string of size bytes, which is returned.
+	Large = Argument{
+		Name:   "large",
+		Params: func(size int) []any { return []any{strings.Repeat("x", size)} },
+		JS:     func(size int) string { return fmt.Sprintf(`["x".repeat(%d)]`, size) },
+		Bytes:  func(size int) int64 { return 2 * int64(size) },
+	}
+	// Binary is an ArrayBuffer of size bytes.
+	Binary = Argument{
+		Name:   "binary",
+		Params: func(size int) []any { return []any{make([]byte, size)} },
+		JS:     func(size int) string { return fmt.Sprintf("[new Uint8Array(%d)]", size) },
+		Bytes:  func(size int) int64 { return int64(size) },
+	}
+)
+
+// Case is a benchmark of the calls of an exposed function.
+type Case struct {
+	// Path is the way the function decodes its arguments.
+	Path string
+	Fn   string
+	Arg  Argument
+}
+
+// Name returns the name of the benchmark, e.g. "expose/small".
+func (c Case) Name() string {
+	return c.Path + "/" + c.Arg.Name
+}
+
+// Cases are the calls measured. The raw function gets binary arguments as
+// references to the stash it does not resolve, so they are left out.
+var Cases = []Case{
+	{"expose", "add", Small},
+	{"expose", "echo", Large},
+	{"expose", "length", Binary},
+	{"raw", "raw", Small},
+	{"raw", "raw", Large},
+}
+
+// Expose exposes the functions of Cases on v.
+func Expose(v *saucerw.Webview) error {
+	if err := v.Expose("add", func(a, b int) int { return a + b }); err != nil {
+		return err
+	}
+	if err := v.Expose("echo", func(s string) string { return s }); err != nil {
+		return err
+	}
+	if err := v.Expose("length", func(data []byte) int { return len(data) }); err != nil {
+		return err
+	}
+
+	v.ExposeRaw("raw", func(_ context.Context, params []json.RawMessage) (any, error) {
+		return params[0], nil
+	})
+	return nil
+}
+
+// Run makes b.N calls of c with arguments of size bytes from the fake page,
+// one at a time or, if parallel is above 1, with that many in flight.
+func Run(b *testing.B, page *saucertest.Webview, c Case, size, parallel int) error {
+	b.ReportAllocs()
+	b.SetBytes(c.Arg.Bytes(size))
+
+	params := c.Arg.Params(size)
+	b.ResetTimer()
+
+	if parallel <= 1 {
+		for range b.N {
+			if _, err := page.Call(context.Background(), c.Fn, params...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		failed error
+		once   = make(chan struct{}, 1)
+	)
+	b.SetParallelism(max(1, parallel/runtime.GOMAXPROCS(0)))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := page.Call(context.Background(), c.Fn, params...); err != nil {
+				select {
+				case once <- struct{}{}:
+					failed = err
+				default:
+				}
+				return
+			}
+		}
+	})
+	return failed
+}
+
+// RunNative is Run for a native page showing Script.
+func RunNative(b *testing.B, view *saucerw.Webview, c Case, size, parallel int) error {
+	b.ReportAllocs()
+	b.SetBytes(c.Arg.Bytes(size))
+
+	expr := fmt.Sprintf("window.bench(%q, %s, %d, %d)", c.Fn, c.Arg.JS(size), b.N, max(1, parallel))
+	return view.Eval(context.Background(), expr, nil)
+}
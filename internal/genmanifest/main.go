@@ -0,0 +1,318 @@
+//go:build saucer_raw
+
+// Command genmanifest regenerates the compressed source archives, manifest and
+// version metadata of package saucer.
+//
+// It is run through go generate from the module root and has to be built with
+// the saucer_raw tag, reading the uncompressed source tree. The upstream tag,
+// commit and commit date are kept unless overridden with -tag, -commit and
+// -date.
+//
+// With -release, it instead generates an additional release from the
+// uncompressed upstream tree in the given directory, embedded behind the
+// build tag of saucer.ReleaseTag: zz_release_<version>.zip and
+// zz_release_<version>.go, the dots of the version replaced by underscores.
+// The tag defaults to "v" and the version.
+//
+// The provenance statement is signed with the PEM encoded Ed25519 private
+// key (PKCS #8) read from -key or $SAUCER_SIGNING_KEY. Without a key the
+// signature is left as it is, and genmanifest fails if the regenerated
+// manifest no longer matches it.
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aperturerobotics/saucer"
+)
+
+var projectVersion = regexp.MustCompile(`project\(saucer\b[^)]*\bVERSION\s+([0-9.]+)`)
+
+func main() {
+	tag := flag.String("tag", saucer.UpstreamTag(), "upstream git tag")
+	commit := flag.String("commit", saucer.UpstreamCommit(), "upstream git commit")
+	date := flag.String("date", releaseDate(), "upstream commit date (RFC 3339)")
+	key := flag.String("key", os.Getenv("SAUCER_SIGNING_KEY"), "PEM file of the Ed25519 signing key")
+	release := flag.String("release", "", "upstream tree of an additional release")
+	flag.Parse()
+
+	if *release != "" {
+		set := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		if !set["tag"] {
+			*tag = ""
+		}
+		if !set["commit"] {
+			*commit = ""
+		}
+		if !set["date"] {
+			*date = ""
+		}
+
+		if err := writeRelease(os.DirFS(*release), *tag, *commit, *date); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := writeArchives(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeManifest(); err != nil {
+		log.Fatal(err)
+	}
+
+	version, err := writeVersion(*tag, *commit, *date)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeSignature(*key, version, *tag, *commit); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeArchives generates the zz_source_*.zip archives embedded by default.
+func writeArchives() error {
+	archives := map[string]fs.FS{
+		"core":      saucer.SourceCore,
+		"qt6":       saucer.SourceQt6,
+		"webkitgtk": saucer.SourceWebKitGTK,
+		"rflpp":     saucer.SourceRflpp,
+		"webview2":  saucer.SourceWebView2,
+		"wkwebview": saucer.SourceWKWebView,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(archives)) {
+		var buf bytes.Buffer
+
+		if err := saucer.WriteZipFS(&buf, archives[name]); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile("zz_source_"+name+".zip", buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeManifest generates zz_manifest.go from saucer.SourceFS.
+func writeManifest() error {
+	manifest, err := saucer.ManifestFS(saucer.SourceFS())
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("// Code generated by genmanifest. DO NOT EDIT.\n\npackage saucer\n\n")
+	buf.WriteString("var manifestEntries = Manifest{\n")
+
+	for _, name := range slices.Sorted(maps.Keys(manifest)) {
+		entry := manifest[name]
+		fmt.Fprintf(&buf, "\t%q: {Size: %d, SHA256: %q},\n", name, entry.Size, entry.SHA256)
+	}
+
+	buf.WriteString("}\n")
+	return write("zz_manifest.go", &buf)
+}
+
+// writeVersion generates zz_version.go from the CMake project version and
+// returns the version.
+func writeVersion(tag, commit, date string) (string, error) {
+	if date != "" {
+		if _, err := time.Parse(time.RFC3339, date); err != nil {
+			return "", fmt.Errorf("-date: %w", err)
+		}
+	}
+
+	version, err := cmakeVersion(saucer.SourceFS())
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("// Code generated by genmanifest. DO NOT EDIT.\n\npackage saucer\n\n")
+	fmt.Fprintf(&buf, "const (\n\tversion = %q\n\tupstreamTag = %q\n\tupstreamCommit = %q\n\tupstreamDate = %q\n)\n", version, tag, commit, date)
+
+	return version, write("zz_version.go", &buf)
+}
+
+// cmakeVersion returns the version of the CMake project of the tree fsys.
+func cmakeVersion(fsys fs.FS) (string, error) {
+	cmake, err := fs.ReadFile(fsys, "CMakeLists.txt")
+	if err != nil {
+		return "", err
+	}
+
+	match := projectVersion.FindSubmatch(cmake)
+	if match == nil {
+		return "", fmt.Errorf("project version not found in CMakeLists.txt")
+	}
+	return string(match[1]), nil
+}
+
+// writeRelease generates the archive and registration of the additional
+// release in the tree fsys.
+func writeRelease(fsys fs.FS, tag, commit, date string) error {
+	if date != "" {
+		if _, err := time.Parse(time.RFC3339, date); err != nil {
+			return fmt.Errorf("-date: %w", err)
+		}
+	}
+
+	version, err := cmakeVersion(fsys)
+	if err != nil {
+		return err
+	}
+	if version == saucer.Version() {
+		return fmt.Errorf("%s is the default release", version)
+	}
+	if tag == "" {
+		tag = "v" + version
+	}
+
+	var zip bytes.Buffer
+
+	if err := saucer.WriteZipFS(&zip, fsys); err != nil {
+		return err
+	}
+
+	name := "zz_release_" + strings.ReplaceAll(version, ".", "_")
+	if err := os.WriteFile(name+".zip", zip.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	ident := "release" + strings.ReplaceAll(version, ".", "_")
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by genmanifest. DO NOT EDIT.\n\n//go:build %s\n\npackage saucer\n\n", saucer.ReleaseTag(version))
+	buf.WriteString("import (\n\t_ \"embed\"\n\t\"time\"\n)\n\n")
+	fmt.Fprintf(&buf, "//go:embed %s.zip\nvar %sZip []byte\n\n", name, ident)
+	fmt.Fprintf(&buf, "func init() {\n\tdate, _ := time.Parse(time.RFC3339, %q)\n\n", date)
+	fmt.Fprintf(&buf, "\tregisterRelease(Release{\n\t\tVersion: %q,\n\t\tTag: %q,\n\t\tCommit: %q,\n\t\tDate: date,\n\t\tSource: newCompressedFS(%sZip),\n\t})\n}\n", version, tag, commit, ident)
+
+	return write(name+".go", &buf)
+}
+
+// releaseDate returns the recorded upstream commit date, empty if unknown.
+func releaseDate() string {
+	if t := saucer.ReleaseDate(); !t.IsZero() {
+		return t.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// writeSignature generates zz_manifest.sig, the signature of the provenance
+// statement of saucer.SourceFS, with the key in the PEM file keyFile. Without
+// a key the existing signature is kept, failing if it does not sign the
+// statement anymore.
+func writeSignature(keyFile, version, tag, commit string) error {
+	manifest, err := saucer.ManifestFS(saucer.SourceFS())
+	if err != nil {
+		return err
+	}
+
+	statement := saucer.Provenance{
+		Repository: saucer.SourceProvenance().Repository,
+		Version:    version,
+		Tag:        tag,
+		Commit:     commit,
+		Manifest:   manifest.Hash(),
+	}.Statement()
+
+	if keyFile == "" {
+		return checkSignature(statement)
+	}
+
+	key, err := readKey(keyFile)
+	if err != nil {
+		return err
+	}
+
+	if saucer.ProvenanceKey == "" {
+		return fmt.Errorf("%s: saucer.ProvenanceKey is not set, publish the public key first", keyFile)
+	}
+	if hex.EncodeToString(key.Public().(ed25519.PublicKey)) != saucer.ProvenanceKey {
+		return fmt.Errorf("%s: key does not match saucer.ProvenanceKey", keyFile)
+	}
+
+	return os.WriteFile("zz_manifest.sig", ed25519.Sign(key, statement), 0o644)
+}
+
+// checkSignature checks that zz_manifest.sig, if the sources are signed,
+// still signs statement.
+func checkSignature(statement []byte) error {
+	signature, err := os.ReadFile("zz_manifest.sig")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if len(signature) == 0 {
+		log.Print("no signing key, the source manifest stays unsigned")
+		return nil
+	}
+
+	key, err := hex.DecodeString(saucer.ProvenanceKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("zz_manifest.sig: saucer.ProvenanceKey is invalid")
+	}
+	if !ed25519.Verify(key, statement, signature) {
+		return errors.New("zz_manifest.sig: the manifest changed since it was signed, sign it again with -key")
+	}
+	return nil
+}
+
+// readKey reads the Ed25519 private key of the PEM file name.
+func readKey(name string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", name)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if key, ok := key.(ed25519.PrivateKey); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: not an Ed25519 key", name)
+}
+
+// write formats buf and writes it to name.
+func write(name string, buf *bytes.Buffer) error {
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, src, 0o644)
+}
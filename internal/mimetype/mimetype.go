@@ -0,0 +1,33 @@
+// Package mimetype detects the MIME type of embedded web assets.
+package mimetype
+
+import (
+	"mime"
+	"net/http"
+	"path"
+)
+
+// overrides pins types that vary between the system MIME databases.
+var overrides = map[string]string{
+	".css":  "text/css; charset=utf-8",
+	".html": "text/html; charset=utf-8",
+	".js":   "text/javascript; charset=utf-8",
+	".json": "application/json",
+	".mjs":  "text/javascript; charset=utf-8",
+	".svg":  "image/svg+xml",
+	".wasm": "application/wasm",
+}
+
+// Detect returns the MIME type of the file name with content data, judged by
+// its extension and falling back to content sniffing.
+func Detect(name string, data []byte) string {
+	ext := path.Ext(name)
+
+	if typ, ok := overrides[ext]; ok {
+		return typ
+	}
+	if typ := mime.TypeByExtension(ext); typ != "" {
+		return typ
+	}
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,123 @@
+package saucer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+//go:generate go run -tags saucer_raw ./internal/genmanifest
+
+// ManifestEntry is the digest of a single source file.
+type ManifestEntry struct {
+	Size   int64
+	SHA256 string
+}
+
+// Manifest maps the slash-separated path of every file in a source tree to
+// its digest.
+type Manifest map[string]ManifestEntry
+
+// SourceManifest returns the manifest of the files in SourceFS. It is generated
+// from the full tree at vendoring time and restricted to the backends compiled
+// into this binary.
+func SourceManifest() Manifest {
+	rtn := make(Manifest, len(manifestEntries))
+
+	for name, entry := range manifestEntries {
+		if _, err := fs.Stat(source, name); err == nil {
+			rtn[name] = entry
+		}
+	}
+
+	return rtn
+}
+
+// ManifestFS computes the manifest of every file in fsys.
+func ManifestFS(fsys fs.FS) (Manifest, error) {
+	rtn := Manifest{}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		rtn[name] = ManifestEntry{Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rtn, nil
+}
+
+// Hash returns a hex encoded SHA-256 identifying the set of files in m and
+// their contents.
+func (m Manifest) Hash() string {
+	h := sha256.New()
+
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		fmt.Fprintf(h, "%s\x00%d\x00%s\n", name, m[name].Size, m[name].SHA256)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyExtracted checks that dir contains an unmodified copy of every file in
+// SourceFS. Extra files in dir are ignored.
+func VerifyExtracted(dir string) error {
+	return SourceManifest().VerifyDir(dir)
+}
+
+// VerifyDir checks every file in m against its copy below dir and reports all
+// missing or modified files.
+func (m Manifest) VerifyDir(dir string) error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		want := m[name]
+
+		got, err := digestFile(filepath.Join(dir, filepath.FromSlash(name)))
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case got != want:
+			errs = append(errs, fmt.Errorf("saucer: %s: content does not match manifest", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// digestFile computes the manifest entry of the file at name.
+func digestFile(name string) (ManifestEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	return ManifestEntry{Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
@@ -0,0 +1,184 @@
+package saucer
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// MesonOptions configures GenerateMeson.
+type MesonOptions struct {
+	// Source is the tree to generate for, SourceFS if nil.
+	Source fs.FS
+	// Serializer is the built-in serializer, SerializerGlaze if empty.
+	Serializer string
+}
+
+// GenerateMeson extracts the tree of opts.Source to dir and writes a
+// meson.build and meson_options.txt building it without CMake, e.g. as a
+// subproject below subprojects/saucer. The subproject provides the
+// dependency "saucer" and chooses the backend with the option "backend",
+// the default backend of the host by default.
+//
+// The C++ dependencies are looked up as CMake packages, the WebView2 SDK in
+// the NuGet package directory of the option "webview2_dir". The file lists
+// are derived from the tree, like those of GenerateBazel.
+func GenerateMeson(dir string, opts MesonOptions) error {
+	return generate(opts.Source, dir, opts.Serializer, func(l *layout, serializer string) map[string]string {
+		return map[string]string{
+			"meson.build":       mesonBuild(l, serializer),
+			"meson_options.txt": mesonOptions(l),
+		}
+	})
+}
+
+// mesonBuild returns the meson.build of l.
+func mesonBuild(l *layout, serializer string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Code generated by saucer.GenerateMeson from saucer %s (%s). DO NOT EDIT.\n\n", version, upstreamTag)
+	fmt.Fprintf(&b, "project('saucer', 'cpp', version: %s, license: %s, meson_version: '>= 1.1.0',\n", mesonString(version), mesonString(license))
+	b.WriteString("  default_options: ['cpp_std=c++23', 'default_library=static'])\n\n")
+
+	b.WriteString(`cxx = meson.get_compiler('cpp')
+
+backend = get_option('backend')
+if backend == 'default'
+  backend = {'windows': 'webview2', 'darwin': 'wkwebview'}.get(host_machine.system(), 'webkitgtk')
+endif
+
+`)
+
+	b.WriteString("deps = []\n")
+	for _, dep := range cppDeps {
+		if dep.serializer == "" || dep.serializer == serializer {
+			fmt.Fprintf(&b, "deps += dependency(%s, method: 'cmake', modules: [%s])\n", mesonString(dep.name), mesonString(dep.target))
+		}
+	}
+
+	fmt.Fprintf(&b, "\nsources = files(%s)\n", mesonFiles("", append(slices.Clone(l.sources[""]), l.sources[serializer]...)))
+	b.WriteString("public_args = ['-DSAUCER_WEBKIT_PRIVATE']\nprivate_args = []\n\n")
+
+	b.WriteString(`if cxx.get_argument_syntax() == 'msvc'
+  public_args += ['/Zc:preprocessor', '/utf-8', '/wd5030']
+elif cxx.get_id() == 'gcc'
+  public_args += ['-Wno-attributes=sc::']
+else
+  public_args += ['-Wno-unknown-attributes']
+endif
+
+`)
+
+	for i, backend := range l.backends() {
+		keyword := "elif"
+		if i == 0 {
+			keyword = "if"
+		}
+
+		fmt.Fprintf(&b, "%s backend == %s\n", keyword, mesonString(backend))
+		fmt.Fprintf(&b, "  sources += files(%s)\n", mesonFiles("  ", l.sources[backend]))
+
+		defines := make([]string, len(backendDefines[backend]))
+		for i, define := range backendDefines[backend] {
+			defines[i] = "-D" + define
+		}
+		fmt.Fprintf(&b, "  public_args += [%s]\n", mesonList(defines))
+
+		b.WriteString(mesonBackend(l, backend))
+	}
+	if len(l.backends()) > 0 {
+		b.WriteString("else\n  error('unsupported backend ' + backend)\nendif\n\n")
+	}
+
+	fmt.Fprintf(&b, "inc = include_directories(%s)\n\n", mesonList([]string{"include", "include/saucer", "private", "private/saucer"}))
+	b.WriteString(`saucer_lib = library('saucer', sources,
+  include_directories: inc,
+  cpp_args: public_args + private_args,
+  objcpp_args: public_args + private_args,
+  dependencies: deps,
+)
+
+saucer_dep = declare_dependency(
+  link_with: saucer_lib,
+  include_directories: inc,
+  compile_args: public_args,
+  dependencies: deps,
+)
+
+meson.override_dependency('saucer', saucer_dep)
+`)
+
+	return b.String()
+}
+
+// mesonBackend returns the statements adding the system dependencies of
+// backend.
+func mesonBackend(l *layout, backend string) string {
+	switch backend {
+	case backendQt6:
+		return "  qt6 = import('qt6')\n" +
+			"  deps += dependency('qt6', modules: ['Widgets', 'WebEngineWidgets', 'WebChannel'], version: '>= 6.7.0')\n" +
+			fmt.Sprintf("  sources += qt6.compile_moc(headers: files(%s))\n", mesonList(l.moc))
+	case backendWebKitGTK:
+		return `  deps += dependency('gtk4', version: '>= 4.12')
+  deps += dependency('libadwaita-1')
+  deps += dependency('json-glib-1.0')
+  deps += dependency('webkitgtk-6.0')
+  deps += dependency('gio-unix-2.0')
+`
+	case backendWebView2:
+		return `  private_args += ['-DNOMINMAX']
+  arch = {'x86_64': 'x64', 'x86': 'x86', 'aarch64': 'arm64'}.get(host_machine.cpu_family())
+  webview2 = get_option('webview2_dir') / 'build' / 'native'
+  deps += declare_dependency(
+    include_directories: include_directories(webview2 / 'include'),
+    dependencies: cxx.find_library('WebView2LoaderStatic', dirs: [webview2 / arch]),
+  )
+  foreach lib : ['CoreMessaging', 'RuntimeObject', 'Wininet', 'Shlwapi', 'gdiplus', 'ole32']
+    deps += cxx.find_library(lib)
+  endforeach
+`
+	case backendWKWebView:
+		return `  add_languages('objcpp', native: false)
+  add_project_arguments('-std=c++23', language: 'objcpp')
+  deps += dependency('appleframeworks', modules: ['Cocoa', 'WebKit', 'CoreImage'])
+`
+	}
+	return ""
+}
+
+// mesonOptions returns the meson_options.txt of l.
+func mesonOptions(l *layout) string {
+	choices := append([]string{"default"}, l.backends()...)
+
+	return fmt.Sprintf("# Code generated by saucer.GenerateMeson. DO NOT EDIT.\n\n"+
+		"option('backend', type: 'combo', choices: [%s], value: 'default',\n"+
+		"  description: 'The webview backend, the default backend of the host by default')\n"+
+		"option('webview2_dir', type: 'string', value: '',\n"+
+		"  description: 'Directory of the Microsoft.Web.WebView2 NuGet package')\n", mesonList(choices))
+}
+
+// mesonList formats items as the elements of a Meson array.
+func mesonList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = mesonString(item)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// mesonFiles formats the arguments of files(), one file per line below the
+// statement indented by indent.
+func mesonFiles(indent string, files []string) string {
+	var b strings.Builder
+	for _, name := range files {
+		b.WriteString("\n" + indent + "  " + mesonString(name) + ",")
+	}
+	return b.String() + "\n" + indent
+}
+
+// mesonString quotes s as a Meson string literal.
+func mesonString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,171 @@
+package saucer
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// licenseText is the license of the saucer sources.
+//
+//go:embed LICENSE
+var licenseText string
+
+// Notice is the license of a component to attribute, e.g. in the about
+// dialog of an application.
+type Notice struct {
+	// Name and Version identify the component.
+	Name    string
+	Version string
+	// URL is the home of the component, if known.
+	URL string
+	// License is the SPDX license expression, empty if unknown.
+	License string
+	// Text is the license text including the copyright, empty if unknown.
+	Text string
+}
+
+// licenseNames matches the base names of license files.
+var licenseNames = regexp.MustCompile(`(?i)^(license|licence|copying|notice)([.-].*)?$`)
+
+// licensePatterns detects common licenses by phrases of their texts, in the
+// order they are tried.
+var licensePatterns = []struct {
+	id     string
+	phrase *regexp.Regexp
+}{
+	{"Apache-2.0", regexp.MustCompile(`(?i)Apache License,?\s+Version 2\.0`)},
+	{"BSL-1.0", regexp.MustCompile(`(?i)Boost Software License`)},
+	{"MPL-2.0", regexp.MustCompile(`(?i)Mozilla Public License,?\s+(Version|v\.?)\s*2\.0`)},
+	{"LGPL-3.0", regexp.MustCompile(`(?i)GNU LESSER GENERAL PUBLIC LICENSE\s+Version 3`)},
+	{"LGPL-2.1", regexp.MustCompile(`(?i)GNU LESSER GENERAL PUBLIC LICENSE\s+Version 2\.1`)},
+	{"GPL-3.0", regexp.MustCompile(`(?i)GNU GENERAL PUBLIC LICENSE\s+Version 3`)},
+	{"GPL-2.0", regexp.MustCompile(`(?i)GNU GENERAL PUBLIC LICENSE\s+Version 2`)},
+	{"Unlicense", regexp.MustCompile(`(?i)This is free and unencumbered software released into the public domain`)},
+	{"Zlib", regexp.MustCompile(`(?i)This software is provided 'as-is', without any express or implied`)},
+	{"BSD-3-Clause", regexp.MustCompile(`(?is)Redistribution and use in source and binary forms.*Neither the name`)},
+	{"BSD-2-Clause", regexp.MustCompile(`(?i)Redistribution and use in source and binary forms`)},
+	{"ISC", regexp.MustCompile(`(?i)Permission to use, copy, modify, and(/or)? distribute this software for any`)},
+	{"MIT", regexp.MustCompile(`(?i)Permission is hereby granted, free of charge, to any person obtaining a copy`)},
+}
+
+// DetectLicense returns the SPDX identifier of the license text, judged by
+// phrases of common licenses, empty if it is not recognized.
+func DetectLicense(text string) string {
+	for _, pattern := range licensePatterns {
+		if pattern.phrase.MatchString(text) {
+			return pattern.id
+		}
+	}
+	return ""
+}
+
+// Notices returns the notices of SourceFS, see NoticesFS.
+func Notices() ([]Notice, error) {
+	return NoticesFS(source)
+}
+
+// NoticesFS returns the notices of the saucer sources of src: the license of
+// saucer, the license files found in src and the files carrying their own
+// SPDX license and copyright tags, such as bundled CMake scripts.
+func NoticesFS(src fs.FS) ([]Notice, error) {
+	rtn := []Notice{{Name: "saucer", Version: version, URL: upstreamRepository, License: license, Text: licenseText}}
+
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+
+		if licenseNames.MatchString(path.Base(name)) {
+			text := string(data)
+			rtn = append(rtn, Notice{Name: name, License: DetectLicense(text), Text: text})
+			return nil
+		}
+
+		if tags := spdxTags(data); tags != "" {
+			rtn = append(rtn, Notice{Name: name, License: declaredLicense(data), Text: tags})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rtn, nil
+}
+
+// spdxTags returns the copyright and license tags in the head of a file that
+// declares a copyright holder, empty otherwise.
+func spdxTags(data []byte) string {
+	head := string(data[:min(len(data), 4096)])
+	if !strings.Contains(head, "SPDX-FileCopyrightText:") {
+		return ""
+	}
+
+	var lines []string
+	for line := range strings.Lines(head) {
+		if _, tag, ok := strings.Cut(line, "SPDX-"); ok {
+			lines = append(lines, "SPDX-"+strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(tag), "*/")))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// WriteNotices writes the THIRD_PARTY_NOTICES document of SourceFS and extra,
+// e.g. the dependencies resolved by a build.
+func WriteNotices(w io.Writer, extra ...Notice) error {
+	notices, err := Notices()
+	if err != nil {
+		return err
+	}
+	return RenderNotices(w, append(notices, extra...))
+}
+
+// RenderNotices writes notices as a plain text THIRD_PARTY_NOTICES document,
+// one section per notice.
+func RenderNotices(w io.Writer, notices []Notice) error {
+	const rule = "================================================================================\n"
+
+	var b strings.Builder
+
+	b.WriteString("THIRD-PARTY SOFTWARE NOTICES\n\n")
+	b.WriteString("This software includes the following third-party components.\n\n")
+
+	for _, n := range notices {
+		title := n.Name
+		if n.Version != "" {
+			title += " " + n.Version
+		}
+		if n.License != "" {
+			title += " (" + n.License + ")"
+		}
+
+		b.WriteString(rule)
+		b.WriteString(title + "\n")
+		if n.URL != "" {
+			b.WriteString(n.URL + "\n")
+		}
+		b.WriteString(rule + "\n")
+
+		switch {
+		case n.Text != "":
+			b.WriteString(strings.TrimRight(n.Text, "\n") + "\n\n")
+		case n.URL != "":
+			fmt.Fprintf(&b, "The license text is available at %s.\n\n", n.URL)
+		default:
+			b.WriteString("The license text is not available.\n\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,309 @@
+package saucer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Patch modifies a source tree, see Overlay.
+type Patch struct {
+	name  string
+	files fs.FS
+	diff  []byte
+
+	// data holds the files of PatchOverrides, taking precedence over files.
+	data map[string][]byte
+	// override restricts files and data to replacing existing files, except
+	// those matching allowNew.
+	override bool
+	allowNew []string
+}
+
+// PatchFiles returns a patch adding every file of fsys to the tree, replacing
+// files with the same path.
+func PatchFiles(fsys fs.FS) Patch {
+	return Patch{name: "files", files: fsys}
+}
+
+// PatchDiff returns a patch applying the unified diff, e.g. the output of
+// git diff or git format-patch. Paths may carry the "a/" and "b/" prefixes
+// used by git. The name identifies the patch in errors.
+func PatchDiff(name string, diff []byte) Patch {
+	return Patch{name: name, diff: diff}
+}
+
+// Overlay returns base with patches applied in order.
+//
+// Patches are applied on first access of the returned file system. If one of
+// them fails to apply, every operation returns an error naming the patch, the
+// file and the rejected hunk, so Extract and Builder.Build fail loudly.
+func Overlay(base fs.FS, patches ...Patch) fs.FS {
+	return &overlayFS{base: base, patches: patches}
+}
+
+// overlayFS is the file system returned by Overlay.
+type overlayFS struct {
+	base    fs.FS
+	patches []Patch
+
+	once    sync.Once
+	files   map[string][]byte
+	deleted map[string]bool
+	err     error
+}
+
+// resolve applies the patches once.
+func (o *overlayFS) resolve() error {
+	o.once.Do(func() {
+		o.files, o.deleted = map[string][]byte{}, map[string]bool{}
+
+		for _, patch := range o.patches {
+			if err := o.apply(patch); err != nil {
+				o.err = fmt.Errorf("saucer: patch %s: %w", patch.name, err)
+				return
+			}
+		}
+	})
+
+	return o.err
+}
+
+// apply records the changes of patch on top of the current state.
+func (o *overlayFS) apply(patch Patch) error {
+	for _, pattern := range patch.allowNew {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("allowNew pattern %q: %w", pattern, err)
+		}
+	}
+
+	if patch.data != nil {
+		for _, name := range slices.Sorted(maps.Keys(patch.data)) {
+			if !fs.ValidPath(name) || name == "." {
+				return fmt.Errorf("%s: %w", name, fs.ErrInvalid)
+			}
+
+			if err := o.replace(patch, name, patch.data[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if patch.files != nil {
+		return fs.WalkDir(patch.files, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			data, err := fs.ReadFile(patch.files, name)
+			if err != nil {
+				return err
+			}
+
+			return o.replace(patch, name, data)
+		})
+	}
+
+	diffs, err := parseDiff(patch.diff)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range diffs {
+		for _, name := range []string{d.oldPath, d.newPath} {
+			if name != "" && (!fs.ValidPath(name) || name == ".") {
+				return fmt.Errorf("%s: %w", name, fs.ErrInvalid)
+			}
+		}
+
+		var current []byte
+
+		if d.oldPath != "" {
+			if current, err = o.read(d.oldPath); err != nil {
+				return err
+			}
+		}
+
+		if d.newPath == "" {
+			o.deleted[d.oldPath] = true
+			delete(o.files, d.oldPath)
+			continue
+		}
+
+		patched, err := d.apply(current)
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.newPath, err)
+		}
+
+		if d.oldPath != "" && d.oldPath != d.newPath {
+			o.deleted[d.oldPath] = true
+			delete(o.files, d.oldPath)
+		}
+
+		o.files[d.newPath] = patched
+		delete(o.deleted, d.newPath)
+	}
+
+	return nil
+}
+
+// replace sets the content of name, checking it exists for overrides.
+func (o *overlayFS) replace(patch Patch, name string, data []byte) error {
+	if patch.override && !skipped(patch.allowNew, name) {
+		if _, err := o.read(name); err != nil {
+			return fmt.Errorf("no such file to override: %w", err)
+		}
+	}
+
+	o.files[name] = data
+	delete(o.deleted, name)
+
+	return nil
+}
+
+// read returns the current content of name.
+func (o *overlayFS) read(name string) ([]byte, error) {
+	if o.deleted[name] {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	if data, ok := o.files[name]; ok {
+		return data, nil
+	}
+	return fs.ReadFile(o.base, name)
+}
+
+// Open implements fs.FS.
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if err := o.resolve(); err != nil {
+		return nil, err
+	}
+
+	if o.deleted[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if data, ok := o.files[name]; ok {
+		return &memFile{Reader: bytes.NewReader(data), info: memInfo{name: path.Base(name), size: int64(len(data))}}, nil
+	}
+
+	entries, err := o.ReadDir(name)
+	if err == nil {
+		return &unionDir{info: memInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+	}
+
+	return o.base.Open(name)
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	if err := o.resolve(); err != nil {
+		return nil, err
+	}
+
+	data, err := o.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(data), nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := o.resolve(); err != nil {
+		return nil, err
+	}
+
+	entries := map[string]fs.DirEntry{}
+
+	base, err := fs.ReadDir(o.base, name)
+	found := err == nil
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, entry := range base {
+		if !o.deleted[path.Join(name, entry.Name())] {
+			entries[entry.Name()] = entry
+		}
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+
+	for file, data := range o.files {
+		rest, ok := strings.CutPrefix(file, prefix)
+		if !ok {
+			continue
+		}
+
+		found = true
+
+		if child, _, nested := strings.Cut(rest, "/"); nested {
+			if _, ok := entries[child]; !ok {
+				entries[child] = fs.FileInfoToDirEntry(memInfo{name: child, dir: true})
+			}
+			continue
+		}
+
+		entries[rest] = fs.FileInfoToDirEntry(memInfo{name: rest, size: int64(len(data))})
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	rtn := make([]fs.DirEntry, 0, len(entries))
+	for _, key := range slices.Sorted(maps.Keys(entries)) {
+		rtn = append(rtn, entries[key])
+	}
+	return rtn, nil
+}
+
+// memFile is a patched file opened from an overlayFS.
+type memFile struct {
+	*bytes.Reader
+	info memInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+// memInfo describes a patched file or a directory implied by one.
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
@@ -0,0 +1,160 @@
+package saucer
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// overlayBase is the tree the diffs of TestOverlayDiff apply to.
+var overlayBase = fstest.MapFS{
+	"src/app.cpp":    {Data: []byte("one\ntwo\nthree\nfour\nfive\n")},
+	"src/noeol.txt":  {Data: []byte("first\nlast")},
+	"src/remove.txt": {Data: []byte("gone\n")},
+	"README.md":      {Data: []byte("# saucer\n")},
+}
+
+func TestOverlayDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		// want maps paths to their content, "" to a deleted file.
+		want map[string]string
+		err  string
+	}{
+		{
+			name: "change",
+			diff: "--- a/src/app.cpp\n+++ b/src/app.cpp\n@@ -2,3 +2,3 @@\n two\n-three\n+THREE\n four\n",
+			want: map[string]string{"src/app.cpp": "one\ntwo\nTHREE\nfour\nfive\n"},
+		},
+		{
+			name: "moved hunk",
+			diff: "--- a/src/app.cpp\n+++ b/src/app.cpp\n@@ -10,2 +10,2 @@\n four\n-five\n+FIVE\n",
+			want: map[string]string{"src/app.cpp": "one\ntwo\nthree\nfour\nFIVE\n"},
+		},
+		{
+			name: "no newline removed",
+			diff: "--- a/src/noeol.txt\n+++ b/src/noeol.txt\n@@ -1,2 +1,2 @@\n first\n-last\n\\ No newline at end of file\n+last\n",
+			want: map[string]string{"src/noeol.txt": "first\nlast\n"},
+		},
+		{
+			name: "no newline added",
+			diff: "--- a/src/app.cpp\n+++ b/src/app.cpp\n@@ -4,2 +4,2 @@\n four\n-five\n+five\n\\ No newline at end of file\n",
+			want: map[string]string{"src/app.cpp": "one\ntwo\nthree\nfour\nfive"},
+		},
+		{
+			name: "create",
+			diff: "--- /dev/null\n+++ b/src/new.txt\n@@ -0,0 +1,2 @@\n+new\n+file\n",
+			want: map[string]string{"src/new.txt": "new\nfile\n"},
+		},
+		{
+			name: "delete",
+			diff: "--- a/src/remove.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-gone\n",
+			want: map[string]string{"src/remove.txt": ""},
+		},
+		{
+			name: "rename",
+			diff: "diff --git a/README.md b/docs/README.md\nrename from README.md\nrename to docs/README.md\n--- a/README.md\n+++ b/docs/README.md\n@@ -1 +1 @@\n-# saucer\n+# Saucer\n",
+			want: map[string]string{"README.md": "", "docs/README.md": "# Saucer\n"},
+		},
+		{
+			name: "commit message",
+			diff: "Subject: fix\n\nChange a line.\n---\n--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-# saucer\n+# Saucer\n-- \n2.43.0\n",
+			want: map[string]string{"README.md": "# Saucer\n"},
+		},
+		{
+			name: "rejected hunk",
+			diff: "--- a/src/app.cpp\n+++ b/src/app.cpp\n@@ -2,2 +2,2 @@\n two\n-six\n+SIX\n",
+			err:  `saucer: patch test: src/app.cpp: hunk #1 at line 2 does not apply, expected "two"`,
+		},
+		{
+			name: "missing file",
+			diff: "--- a/src/missing.cpp\n+++ b/src/missing.cpp\n@@ -1 +1 @@\n-a\n+b\n",
+			err:  "file does not exist",
+		},
+		{
+			name: "parent path",
+			diff: "--- /dev/null\n+++ b/../outside.txt\n@@ -0,0 +1 @@\n+x\n",
+			err:  "../outside.txt: invalid argument",
+		},
+		{
+			name: "absolute path",
+			diff: "--- /etc/passwd\n+++ /etc/passwd\n@@ -1 +1 @@\n-a\n+b\n",
+			err:  "/etc/passwd: invalid argument",
+		},
+		{
+			name: "truncated hunk",
+			diff: "--- a/README.md\n+++ b/README.md\n@@ -1,2 +1,2 @@\n-# saucer\n",
+			err:  "diff ends inside a hunk",
+		},
+		{
+			name: "malformed header",
+			diff: "--- a/README.md\n+++ b/README.md\n@@ -x +1 @@\n",
+			err:  `line 3: malformed hunk range "x"`,
+		},
+		{
+			name: "not a diff",
+			diff: "just text\n",
+			err:  "no file changes found in diff",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fsys := Overlay(overlayBase, PatchDiff("test", []byte(test.diff)))
+
+			if test.err != "" {
+				_, err := fs.ReadFile(fsys, "README.md")
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error %v, expected %q", err, test.err)
+				}
+				return
+			}
+
+			for name, want := range test.want {
+				got, err := fs.ReadFile(fsys, name)
+				if want == "" {
+					if !errors.Is(err, fs.ErrNotExist) {
+						t.Errorf("%s: %q, %v, expected it deleted", name, got, err)
+					}
+					continue
+				}
+				if err != nil || string(got) != want {
+					t.Errorf("%s: %q, %v, expected %q", name, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+// TestOverlayOrder checks that patches apply on top of each other and that
+// directories list the patched tree.
+func TestOverlayOrder(t *testing.T) {
+	fsys := Overlay(overlayBase,
+		PatchFiles(fstest.MapFS{"src/extra.cpp": {Data: []byte("extra\n")}}),
+		PatchDiff("second", []byte("--- a/src/extra.cpp\n+++ b/src/extra.cpp\n@@ -1 +1 @@\n-extra\n+patched\n")),
+		PatchDiff("third", []byte("--- a/src/remove.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-gone\n")),
+	)
+
+	if got, err := fs.ReadFile(fsys, "src/extra.cpp"); err != nil || string(got) != "patched\n" {
+		t.Errorf("src/extra.cpp: %q, %v", got, err)
+	}
+
+	entries, err := fs.ReadDir(fsys, "src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if got := strings.Join(names, " "); got != "app.cpp extra.cpp noeol.txt" {
+		t.Errorf("src lists %s", got)
+	}
+
+	if err := fstest.TestFS(fsys, "README.md", "src/app.cpp", "src/extra.cpp", "src/noeol.txt"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,35 @@
+package saucer
+
+import (
+	"io/fs"
+	"os"
+)
+
+// PatchOverrides returns a patch replacing single files of the tree with the
+// content in files, keyed by slash-separated path. Unlike PatchFiles it
+// fails if a path is not a file of the tree, catching overrides left behind
+// by an upstream rename, unless the path matches one of the path.Match
+// patterns of allowNew.
+func PatchOverrides(files map[string][]byte, allowNew ...string) Patch {
+	if files == nil {
+		files = map[string][]byte{}
+	}
+	return Patch{name: "overrides", data: files, override: true, allowNew: allowNew}
+}
+
+// PatchOverridesDir is PatchOverrides with the files below dir.
+func PatchOverridesDir(dir string, allowNew ...string) Patch {
+	return Patch{name: "overrides " + dir, files: os.DirFS(dir), override: true, allowNew: allowNew}
+}
+
+// WithOverrides returns SourceFS with the files of PatchOverrides shadowing
+// the embedded ones, e.g. a patched header or CMake script.
+func WithOverrides(files map[string][]byte, allowNew ...string) fs.FS {
+	return Overlay(source, PatchOverrides(files, allowNew...))
+}
+
+// WithOverridesDir returns SourceFS with the files below dir shadowing the
+// embedded ones, see WithOverrides.
+func WithOverridesDir(dir string, allowNew ...string) fs.FS {
+	return Overlay(source, PatchOverridesDir(dir, allowNew...))
+}
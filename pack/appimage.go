@@ -0,0 +1,122 @@
+package pack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ErrNoAppImageTool is returned by AppImage if appimagetool is not found.
+var ErrNoAppImageTool = errors.New("pack: appimagetool not found, set APPIMAGETOOL or add it to PATH")
+
+// appImageArch maps GOARCH to the architecture names of AppImage.
+var appImageArch = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "aarch64",
+	"arm":   "armhf",
+	"386":   "i686",
+}
+
+// AppDir creates the AppDir dir/<Name>.AppDir: the binary in usr/bin, the
+// AppRun launcher, the desktop entry <ID>.desktop and the PNG icon. It
+// returns the path of the AppDir.
+func AppDir(dir string, meta Metadata, opts ...Option) (string, error) {
+	meta.apply(opts)
+	if err := meta.normalize(true); err != nil {
+		return "", err
+	}
+
+	img, err := meta.loadIcon()
+	if err != nil {
+		return "", err
+	}
+	if img == nil {
+		return "", errors.New("pack: an AppDir needs an icon")
+	}
+
+	icon, err := iconPNG(img, 256)
+	if err != nil {
+		return "", err
+	}
+
+	appdir := filepath.Join(dir, meta.Name+".AppDir")
+	if err := recreate(appdir); err != nil {
+		return "", err
+	}
+
+	if err := copyFile(filepath.Join(appdir, "usr", "bin", meta.Executable), meta.Binary, 0o755); err != nil {
+		return "", err
+	}
+
+	name := meta.ID + ".png"
+	if err := writeFile(filepath.Join(appdir, "usr", "share", "icons", "hicolor", "256x256", "apps", name), icon, 0o644); err != nil {
+		return "", err
+	}
+	if err := writeFile(filepath.Join(appdir, name), icon, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Symlink(name, filepath.Join(appdir, ".DirIcon")); err != nil {
+		return "", err
+	}
+
+	if err := render(filepath.Join(appdir, "AppRun"), "AppRun", &meta, 0o755); err != nil {
+		return "", err
+	}
+
+	desktop := filepath.Join(appdir, "usr", "share", "applications", meta.ID+".desktop")
+	if err := os.MkdirAll(filepath.Dir(desktop), 0o755); err != nil {
+		return "", err
+	}
+	if err := render(desktop, "app.desktop", &meta, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Symlink(filepath.Join("usr", "share", "applications", meta.ID+".desktop"), filepath.Join(appdir, meta.ID+".desktop")); err != nil {
+		return "", err
+	}
+
+	return appdir, nil
+}
+
+// AppImage creates the AppDir of meta below dir and packs it into
+// dir/<Name>-<Version>-<arch>.AppImage with appimagetool, taken from the
+// APPIMAGETOOL environment variable or PATH. It returns the path of the
+// AppImage.
+//
+// The AppImage bundles the binary only: the WebKitGTK or Qt libraries it
+// links against are expected on the system.
+func AppImage(ctx context.Context, dir string, meta Metadata, opts ...Option) (string, error) {
+	meta.apply(opts)
+	tool := os.Getenv("APPIMAGETOOL")
+	if tool == "" {
+		var err error
+		if tool, err = exec.LookPath("appimagetool"); err != nil {
+			return "", ErrNoAppImageTool
+		}
+	}
+
+	if err := meta.normalize(true); err != nil {
+		return "", err
+	}
+
+	arch, ok := appImageArch[meta.GOARCH]
+	if !ok {
+		return "", fmt.Errorf("pack: AppImage does not support GOARCH %s", meta.GOARCH)
+	}
+
+	appdir, err := AppDir(dir, meta)
+	if err != nil {
+		return "", err
+	}
+
+	out := filepath.Join(dir, fmt.Sprintf("%s-%s-%s.AppImage", meta.Name, meta.Version, arch))
+
+	cmd := exec.CommandContext(ctx, tool, appdir, out)
+	cmd.Env = append(os.Environ(), "ARCH="+arch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("pack: appimagetool: %w: %s", err, output)
+	}
+	return out, nil
+}
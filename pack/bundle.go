@@ -0,0 +1,69 @@
+package pack
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// infoPlist is the data of the Info.plist template.
+type infoPlist struct {
+	*Metadata
+	// IconFile is the icon in Contents/Resources, if any.
+	IconFile string
+}
+
+// AppBundle creates the macOS application bundle dir/<Name>.app with the
+// binary in Contents/MacOS, the icon in Contents/Resources and an Info.plist
+// rendered from the metadata. An .icns Icon is copied, the .icns of other
+// icons is generated. It returns the path of the bundle.
+//
+// The bundle is unsigned; sign it with Codesign before distribution.
+func AppBundle(dir string, meta Metadata, opts ...Option) (string, error) {
+	meta.apply(opts)
+	if err := meta.normalize(true); err != nil {
+		return "", err
+	}
+
+	bundle := filepath.Join(dir, meta.Name+".app")
+	if err := recreate(bundle); err != nil {
+		return "", err
+	}
+
+	contents := filepath.Join(bundle, "Contents")
+	if err := copyFile(filepath.Join(contents, "MacOS", meta.Executable), meta.Binary, 0o755); err != nil {
+		return "", err
+	}
+
+	plist := infoPlist{Metadata: &meta}
+
+	switch {
+	case meta.IconImage == nil && strings.EqualFold(filepath.Ext(meta.Icon), ".icns"):
+		plist.IconFile = filepath.Base(meta.Icon)
+		if err := copyFile(filepath.Join(contents, "Resources", plist.IconFile), meta.Icon, 0o644); err != nil {
+			return "", err
+		}
+	default:
+		img, err := meta.loadIcon()
+		if err != nil {
+			return "", err
+		}
+		if img == nil {
+			break
+		}
+
+		icns, err := ICNS(img)
+		if err != nil {
+			return "", err
+		}
+
+		plist.IconFile = "AppIcon.icns"
+		if err := writeFile(filepath.Join(contents, "Resources", plist.IconFile), icns, 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	if err := render(filepath.Join(contents, "Info.plist"), "Info.plist", &plist, 0o644); err != nil {
+		return "", err
+	}
+	return bundle, nil
+}
@@ -0,0 +1,164 @@
+package pack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Codesign signs macOS bundles and binaries with codesign, enabling the
+// hardened runtime notarization requires.
+type Codesign struct {
+	// Identity is the signing identity, e.g. "Developer ID Application:
+	// Example (TEAMID)" or the SHA-1 of the certificate.
+	Identity string
+	// Entitlements is the path to an entitlements plist, if any.
+	Entitlements string
+	// Keychain is the keychain holding the identity, the search list if
+	// empty. CI typically imports the certificate into a temporary keychain.
+	Keychain string
+}
+
+// Sign implements Signer.
+func (c *Codesign) Sign(ctx context.Context, path string) error {
+	if c.Identity == "" {
+		return errors.New("codesign: missing identity")
+	}
+
+	args := []string{"--force", "--timestamp", "--options", "runtime", "--sign", c.Identity}
+	if c.Entitlements != "" {
+		args = append(args, "--entitlements", c.Entitlements)
+	}
+	if c.Keychain != "" {
+		args = append(args, "--keychain", c.Keychain)
+	}
+
+	_, err := run(ctx, "codesign", append(args, path)...)
+	return err
+}
+
+// NotaryAuth authenticates notarytool. Set one of Profile, Key or AppleID.
+type NotaryAuth struct {
+	// Profile is a keychain profile stored with notarytool store-credentials.
+	Profile string
+
+	// Key is the content of an App Store Connect API key (.p8), KeyID and
+	// Issuer identify it.
+	Key    []byte
+	KeyID  string
+	Issuer string
+
+	// AppleID, an app-specific Password and the TeamID authenticate a
+	// developer account.
+	AppleID  string
+	Password string
+	TeamID   string
+}
+
+// NotaryCredentials supplies NotaryAuth, e.g. from a secrets manager, when
+// Notarize needs it.
+type NotaryCredentials interface {
+	NotaryAuth(ctx context.Context) (NotaryAuth, error)
+}
+
+// NotaryAuthFunc adapts a function to NotaryCredentials.
+type NotaryAuthFunc func(ctx context.Context) (NotaryAuth, error)
+
+// NotaryAuth implements NotaryCredentials.
+func (f NotaryAuthFunc) NotaryAuth(ctx context.Context) (NotaryAuth, error) {
+	return f(ctx)
+}
+
+// Notarize submits signed bundles, disk images and installer packages to the
+// Apple notary service, waits for the result and staples the ticket.
+type Notarize struct {
+	// Credentials authenticate the submission.
+	Credentials NotaryCredentials
+}
+
+// Sign implements Signer. Bundles are submitted as a zip archive created with
+// ditto, which notarytool accepts in place of the bundle.
+func (n *Notarize) Sign(ctx context.Context, path string) error {
+	if n.Credentials == nil {
+		return errors.New("notarize: missing credentials")
+	}
+
+	auth, err := n.Credentials.NotaryAuth(ctx)
+	if err != nil {
+		return fmt.Errorf("notarize: credentials: %w", err)
+	}
+
+	args, cleanup, err := auth.args()
+	if err != nil {
+		return fmt.Errorf("notarize: %w", err)
+	}
+	defer cleanup()
+
+	submit := path
+	if info, err := os.Stat(path); err != nil {
+		return err
+	} else if info.IsDir() {
+		dir, err := os.MkdirTemp("", "saucer-notarize-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		submit = filepath.Join(dir, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))+".zip")
+		if _, err := run(ctx, "ditto", "-c", "-k", "--keepParent", path, submit); err != nil {
+			return err
+		}
+	}
+
+	out, err := run(ctx, "xcrun", append([]string{"notarytool", "submit", submit, "--wait", "--output-format", "json"}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return fmt.Errorf("notarytool: %w: %s", err, out)
+	}
+	if result.Status != "Accepted" {
+		return fmt.Errorf("notarytool: submission %s is %s, see xcrun notarytool log %s", result.ID, result.Status, result.ID)
+	}
+
+	_, err = run(ctx, "xcrun", "stapler", "staple", path)
+	return err
+}
+
+// args returns the notarytool arguments of a, writing the API key to a
+// temporary file removed by cleanup.
+func (a *NotaryAuth) args() (args []string, cleanup func(), err error) {
+	cleanup = func() {}
+
+	switch {
+	case a.Profile != "":
+		return []string{"--keychain-profile", a.Profile}, cleanup, nil
+	case len(a.Key) > 0:
+		if a.KeyID == "" {
+			return nil, cleanup, errors.New("missing API key id")
+		}
+
+		key, err := secretFile("saucer-notary-*.p8", a.Key)
+		if err != nil {
+			return nil, cleanup, err
+		}
+
+		args = []string{"--key", key, "--key-id", a.KeyID}
+		if a.Issuer != "" {
+			args = append(args, "--issuer", a.Issuer)
+		}
+		return args, func() { os.Remove(key) }, nil
+	case a.AppleID != "":
+		return []string{"--apple-id", a.AppleID, "--password", a.Password, "--team-id", a.TeamID}, cleanup, nil
+	}
+	return nil, cleanup, errors.New("empty credentials")
+}
@@ -0,0 +1,278 @@
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+)
+
+// SetIcon sets the image the icons of the packages are generated from:
+// the .icns of AppBundle, the PNG of AppDir, the logos of MSIXLayout and the
+// icon resource of WindowsResources. It takes precedence over Icon. Use a
+// square image of at least 1024 pixels for crisp icons at every size.
+func (m *Metadata) SetIcon(img image.Image) {
+	m.IconImage = img
+}
+
+// SetIcon is the Option of Metadata.SetIcon.
+func SetIcon(img image.Image) Option {
+	return func(m *Metadata) { m.SetIcon(img) }
+}
+
+// loadIcon returns IconImage, or the decoded PNG of Icon, nil if neither is
+// set.
+func (m *Metadata) loadIcon() (image.Image, error) {
+	if m.IconImage != nil {
+		if m.IconImage.Bounds().Empty() {
+			return nil, errEmptyIcon
+		}
+		return m.IconImage, nil
+	}
+	if m.Icon == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(m.Icon)
+	if err != nil {
+		return nil, fmt.Errorf("pack: %w", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pack: decode icon %s: %w", m.Icon, err)
+	}
+	if img.Bounds().Empty() {
+		return nil, errEmptyIcon
+	}
+	return img, nil
+}
+
+// icoSizes are the sizes of the images of ICO.
+var icoSizes = []int{16, 24, 32, 48, 64, 128, 256}
+
+// icoImage is an image of an ICO file or icon resource.
+type icoImage struct {
+	size int
+	data []byte
+}
+
+// icoImages returns the images of the icon of img: bitmaps for the small
+// sizes, read by every API, and PNG for 256 pixels.
+func icoImages(img image.Image) ([]icoImage, error) {
+	rtn := make([]icoImage, len(icoSizes))
+	for i, size := range icoSizes {
+		if size < 256 {
+			rtn[i] = icoImage{size, dib(resize(img, size))}
+			continue
+		}
+
+		data, err := iconPNG(img, size)
+		if err != nil {
+			return nil, err
+		}
+		rtn[i] = icoImage{size, data}
+	}
+	return rtn, nil
+}
+
+// icoEntry returns the directory entry fields shared by ICO files and group
+// icon resources, up to the size of the image.
+func icoEntry(img icoImage) []byte {
+	// 256 pixels are stored as 0.
+	size := byte(img.size)
+	return binary.LittleEndian.AppendUint32([]byte{size, size, 0, 0, 1, 0, 32, 0}, uint32(len(img.data)))
+}
+
+// ICO returns a Windows .ico file of img with the standard sizes from 16 to
+// 256 pixels.
+func ICO(img image.Image) ([]byte, error) {
+	images, err := icoImages(img)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := binary.LittleEndian.AppendUint16([]byte{0, 0, 1, 0}, uint16(len(images)))
+
+	offset := 6 + 16*len(images)
+	for _, img := range images {
+		buf = binary.LittleEndian.AppendUint32(append(buf, icoEntry(img)...), uint32(offset))
+		offset += len(img.data)
+	}
+	for _, img := range images {
+		buf = append(buf, img.data...)
+	}
+	return buf, nil
+}
+
+// icnsTypes are the PNG element types of ICNS by size in pixels. The
+// retina variants repeat sizes of other types.
+var icnsTypes = []struct {
+	kind string
+	size int
+}{
+	{"icp4", 16},
+	{"icp5", 32},
+	{"ic11", 32},
+	{"ic12", 64},
+	{"ic07", 128},
+	{"ic08", 256},
+	{"ic13", 256},
+	{"ic09", 512},
+	{"ic14", 512},
+	{"ic10", 1024},
+}
+
+// ICNS returns a macOS .icns file of img with the sizes from 16 to 1024
+// pixels.
+func ICNS(img image.Image) ([]byte, error) {
+	var body []byte
+
+	encoded := map[int][]byte{}
+	for _, t := range icnsTypes {
+		data, ok := encoded[t.size]
+		if !ok {
+			var err error
+			if data, err = iconPNG(img, t.size); err != nil {
+				return nil, err
+			}
+			encoded[t.size] = data
+		}
+
+		body = binary.BigEndian.AppendUint32(append(body, t.kind...), uint32(8+len(data)))
+		body = append(body, data...)
+	}
+
+	return append(binary.BigEndian.AppendUint32([]byte("icns"), uint32(8+len(body))), body...), nil
+}
+
+// iconPNG returns the PNG of img scaled to size.
+func iconPNG(img image.Image, size int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, resize(img, size)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// errEmptyIcon is returned for icon images without pixels.
+var errEmptyIcon = errors.New("pack: empty icon image")
+
+// resize scales img to a size by size square, centering it with transparent
+// borders if it is not square. It averages the covered pixels when shrinking
+// and interpolates bilinearly when growing.
+func resize(img image.Image, size int) *image.NRGBA {
+	b := img.Bounds()
+
+	// Work on premultiplied pixels so transparent pixels do not bleed color.
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Rect, img, b.Min, draw.Src)
+
+	// Fit the longer side to size.
+	scale := float64(size) / float64(max(b.Dx(), b.Dy()))
+	w, h := max(1, int(float64(b.Dx())*scale+0.5)), max(1, int(float64(b.Dy())*scale+0.5))
+	ox, oy := (size-w)/2, (size-h)/2
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	if b.Empty() {
+		return dst
+	}
+
+	for y := range h {
+		for x := range w {
+			var r, g, bl, a float64
+			if scale < 1 {
+				r, g, bl, a = area(src, float64(x)/scale, float64(y)/scale, float64(x+1)/scale, float64(y+1)/scale)
+			} else {
+				r, g, bl, a = bilinear(src, (float64(x)+0.5)/scale-0.5, (float64(y)+0.5)/scale-0.5)
+			}
+
+			c := color.NRGBA{}
+			if a > 0 {
+				c = color.NRGBA{R: uint8(r/a*255 + 0.5), G: uint8(g/a*255 + 0.5), B: uint8(bl/a*255 + 0.5), A: uint8(a*255 + 0.5)}
+			}
+			dst.SetNRGBA(ox+x, oy+y, c)
+		}
+	}
+	return dst
+}
+
+// pixel returns the premultiplied color of src at x, y in [0, 1].
+func pixel(src *image.RGBA, x, y int) (r, g, b, a float64) {
+	c := src.RGBAAt(x, y)
+	return float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255, float64(c.A) / 255
+}
+
+// area returns the average color of the rectangle x0, y0, x1, y1 of src,
+// weighting partially covered pixels by their coverage.
+func area(src *image.RGBA, x0, y0, x1, y1 float64) (r, g, b, a float64) {
+	var total float64
+	for y := int(y0); float64(y) < y1 && y < src.Rect.Dy(); y++ {
+		wy := min(y1, float64(y+1)) - max(y0, float64(y))
+		for x := int(x0); float64(x) < x1 && x < src.Rect.Dx(); x++ {
+			wx := min(x1, float64(x+1)) - max(x0, float64(x))
+
+			pr, pg, pb, pa := pixel(src, x, y)
+			w := wx * wy
+			r, g, b, a, total = r+pr*w, g+pg*w, b+pb*w, a+pa*w, total+w
+		}
+	}
+	if total == 0 {
+		return 0, 0, 0, 0
+	}
+	return r / total, g / total, b / total, a / total
+}
+
+// bilinear interpolates the color of src at x, y, clamping to the edges.
+func bilinear(src *image.RGBA, x, y float64) (r, g, b, a float64) {
+	clamp := func(v float64, n int) (int, int, float64) {
+		v = max(0, min(v, float64(n-1)))
+		i := int(v)
+		return i, min(i+1, n-1), v - float64(i)
+	}
+
+	x0, x1, fx := clamp(x, src.Rect.Dx())
+	y0, y1, fy := clamp(y, src.Rect.Dy())
+
+	for _, p := range []struct {
+		x, y int
+		w    float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x1, y0, fx * (1 - fy)},
+		{x0, y1, (1 - fx) * fy},
+		{x1, y1, fx * fy},
+	} {
+		pr, pg, pb, pa := pixel(src, p.x, p.y)
+		r, g, b, a = r+pr*p.w, g+pg*p.w, b+pb*p.w, a+pa*p.w
+	}
+	return r, g, b, a
+}
+
+// dib encodes img as the device independent bitmap of an icon: a
+// BITMAPINFOHEADER of twice the height, the BGRA rows bottom up and an empty
+// AND mask, the alpha channel taking its place.
+func dib(img *image.NRGBA) []byte {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+
+	buf := make([]byte, 0, 40+4*w*h+h*((w+31)/32*4))
+	buf = binary.LittleEndian.AppendUint32(buf, 40)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(w))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(2*h))
+	buf = binary.LittleEndian.AppendUint16(buf, 1)
+	buf = binary.LittleEndian.AppendUint16(buf, 32)
+	buf = append(buf, make([]byte, 24)...)
+
+	for y := h - 1; y >= 0; y-- {
+		for x := range w {
+			c := img.NRGBAAt(x, y)
+			buf = append(buf, c.B, c.G, c.R, c.A)
+		}
+	}
+	return append(buf, make([]byte, h*((w+31)/32*4))...)
+}
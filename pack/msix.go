@@ -0,0 +1,178 @@
+package pack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrNoMakeAppx is returned by MSIX if makeappx of the Windows SDK is not
+// found in PATH.
+var ErrNoMakeAppx = errors.New("pack: makeappx not found, install the Windows SDK and add its bin directory to PATH")
+
+// msixArch maps GOARCH to the processor architectures of MSIX.
+var msixArch = map[string]string{
+	"amd64": "x64",
+	"386":   "x86",
+	"arm64": "arm64",
+}
+
+// msixLogos are the logo assets referenced by AppxManifest.xml and their
+// sizes.
+var msixLogos = []struct {
+	name string
+	size int
+}{
+	{"StoreLogo.png", 50},
+	{"Square150x150Logo.png", 150},
+	{"Square44x44Logo.png", 44},
+}
+
+// windowsManifest is the data of the AppxManifest.xml and app.manifest
+// templates.
+type windowsManifest struct {
+	*Metadata
+	WindowsVersion string
+	MSIXArch       string
+	Summary        string
+}
+
+// newWindowsManifest returns the manifest data of meta.
+func newWindowsManifest(meta *Metadata) (*windowsManifest, error) {
+	version, err := windowsVersion(meta.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	rtn := &windowsManifest{Metadata: meta, WindowsVersion: version, Summary: meta.Description}
+	if rtn.Summary == "" {
+		rtn.Summary = meta.Name
+	}
+	return rtn, nil
+}
+
+// windowsVersion converts version to the four part version MSIX requires,
+// e.g. "v1.2.3-rc.1" to "1.2.3.0".
+func windowsVersion(version string) (string, error) {
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+
+	parts := strings.Split(version, ".")
+	if len(parts) > 4 {
+		return "", fmt.Errorf("pack: version %q has more than four parts", version)
+	}
+
+	for _, part := range parts {
+		if n, err := strconv.ParseUint(part, 10, 16); err != nil || n > 65535 {
+			return "", fmt.Errorf("pack: invalid MSIX version %q", version)
+		}
+	}
+	for len(parts) < 4 {
+		parts = append(parts, "0")
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// MSIXLayout creates the package layout dir/<Name>.layout: the binary, an
+// AppxManifest.xml declaring a full trust desktop application and the logo
+// assets scaled from the icon. It returns the path of the layout.
+//
+// The WebView2 runtime is not bundled, it ships with Windows 11 and is
+// installed by the Evergreen bootstrapper elsewhere.
+func MSIXLayout(dir string, meta Metadata, opts ...Option) (string, error) {
+	meta.apply(opts)
+	return msixLayout(dir, &meta)
+}
+
+// msixLayout implements MSIXLayout, normalizing meta.
+func msixLayout(dir string, meta *Metadata) (string, error) {
+	if err := meta.normalize(true); err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(strings.ToLower(meta.Executable), ".exe") {
+		meta.Executable += ".exe"
+	}
+	img, err := meta.loadIcon()
+	if err != nil {
+		return "", err
+	}
+	if img == nil {
+		return "", errors.New("pack: an MSIX package needs an icon")
+	}
+	if meta.Publisher == "" {
+		return "", errors.New("pack: an MSIX package needs a publisher")
+	}
+
+	manifest, err := newWindowsManifest(meta)
+	if err != nil {
+		return "", err
+	}
+
+	var ok bool
+	if manifest.MSIXArch, ok = msixArch[meta.GOARCH]; !ok {
+		return "", fmt.Errorf("pack: MSIX does not support GOARCH %s", meta.GOARCH)
+	}
+
+	layout := filepath.Join(dir, meta.Name+".layout")
+	if err := recreate(layout); err != nil {
+		return "", err
+	}
+
+	if err := copyFile(filepath.Join(layout, meta.Executable), meta.Binary, 0o755); err != nil {
+		return "", err
+	}
+	for _, logo := range msixLogos {
+		data, err := iconPNG(img, logo.size)
+		if err != nil {
+			return "", err
+		}
+		if err := writeFile(filepath.Join(layout, "Assets", logo.name), data, 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	if err := render(filepath.Join(layout, "AppxManifest.xml"), "AppxManifest.xml", manifest, 0o644); err != nil {
+		return "", err
+	}
+	return layout, nil
+}
+
+// MSIX creates the layout of meta below dir and packs it into
+// dir/<Name>-<Version>.msix with makeappx. It returns the path of the
+// package. Packages install only once signed, with meta.Signer signing the
+// binary before and the package after packing, e.g. a Signtool.
+func MSIX(ctx context.Context, dir string, meta Metadata, opts ...Option) (string, error) {
+	meta.apply(opts)
+	tool, err := exec.LookPath("makeappx")
+	if err != nil {
+		return "", ErrNoMakeAppx
+	}
+
+	layout, err := msixLayout(dir, &meta)
+	if err != nil {
+		return "", err
+	}
+
+	if meta.Signer != nil {
+		if err := Sign(ctx, filepath.Join(layout, meta.Executable), meta.Signer); err != nil {
+			return "", err
+		}
+	}
+
+	out := filepath.Join(dir, fmt.Sprintf("%s-%s.msix", meta.Name, meta.Version))
+	if output, err := exec.CommandContext(ctx, tool, "pack", "/o", "/d", layout, "/p", out).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("pack: makeappx: %w: %s", err, output)
+	}
+
+	if meta.Signer != nil {
+		if err := Sign(ctx, out, meta.Signer); err != nil {
+			return "", err
+		}
+	}
+	return out, nil
+}
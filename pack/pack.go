@@ -0,0 +1,225 @@
+// Package pack lays out built saucer applications for distribution: a macOS
+// .app bundle, a Linux AppDir packed into an AppImage and a Windows MSIX
+// package.
+package pack
+
+import (
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+//go:embed template
+var templates embed.FS
+
+// Metadata describes the application to package.
+type Metadata struct {
+	// Name is the display name of the application.
+	Name string
+	// ID is the reverse DNS identifier, e.g. "com.example.app". It is the
+	// bundle identifier on macOS, the desktop file ID on Linux and the package
+	// identity name of MSIX.
+	ID string
+	// Version is the version of the application, e.g. "1.2.3".
+	Version string
+	// Binary is the path to the built Go binary.
+	Binary string
+	// Executable is the file name of the binary in the package, the base name
+	// of Binary by default.
+	Executable string
+	// Icon is the path to the icon: an .icns file or a PNG for AppBundle, a
+	// PNG for the other packages. AppDir, AppImage and MSIXLayout require an
+	// icon, see also SetIcon.
+	Icon string
+	// IconImage is the icon image set by SetIcon.
+	IconImage image.Image
+	// Description is a one line summary of the application.
+	Description string
+	// Copyright is the copyright notice, e.g. "Copyright 2025 Example".
+	Copyright string
+	// Categories are the freedesktop menu categories, "Utility" by default.
+	Categories []string
+	// Publisher is the subject of the MSIX signing certificate, e.g.
+	// "CN=Example". It is required by MSIXLayout.
+	Publisher string
+	// PublisherName is the displayed publisher, the CN of Publisher by
+	// default.
+	PublisherName string
+	// MinMacOS is the minimum macOS version, "11.0" by default.
+	MinMacOS string
+	// Plist are further Info.plist entries, e.g. those of deeplink.InfoPlist.
+	Plist string
+	// GOARCH is the architecture of Binary, runtime.GOARCH by default.
+	GOARCH string
+
+	// Signer signs the binary MSIX packs and the package, unsigned if nil.
+	// Sign other packages with Sign once created.
+	Signer Signer
+}
+
+// Option changes the Metadata of a package, applied before it is created.
+type Option func(*Metadata)
+
+// apply changes m with opts.
+func (m *Metadata) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(m)
+	}
+}
+
+// idPattern matches valid application identifiers.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)+$`)
+
+// exePattern matches valid executable names, usable unquoted in the Exec key
+// of desktop entries and the AppRun script.
+var exePattern = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+
+// normalize validates m and fills in the defaults, requiring Binary if
+// binary is set.
+func (m *Metadata) normalize(binary bool) error {
+	if m.Name == "" {
+		return errors.New("pack: missing name")
+	}
+	if !idPattern.MatchString(m.ID) {
+		return fmt.Errorf("pack: invalid id %q, expected reverse DNS such as com.example.app", m.ID)
+	}
+	if m.Version == "" {
+		return errors.New("pack: missing version")
+	}
+	if binary && m.Binary == "" {
+		return errors.New("pack: missing binary")
+	}
+
+	if m.Executable == "" && m.Binary != "" {
+		m.Executable = filepath.Base(m.Binary)
+	}
+	if m.Executable == "" {
+		return errors.New("pack: missing binary")
+	}
+	if !exePattern.MatchString(m.Executable) {
+		return fmt.Errorf("pack: invalid executable name %q", m.Executable)
+	}
+
+	if len(m.Categories) == 0 {
+		m.Categories = []string{"Utility"}
+	}
+	if m.MinMacOS == "" {
+		m.MinMacOS = "11.0"
+	}
+	if m.GOARCH == "" {
+		m.GOARCH = runtime.GOARCH
+	}
+	if m.PublisherName == "" {
+		m.PublisherName = publisherName(m.Publisher)
+	}
+	return nil
+}
+
+// publisherName returns the CN of the distinguished name dn.
+func publisherName(dn string) string {
+	for _, part := range strings.Split(dn, ",") {
+		if cn, ok := strings.CutPrefix(strings.TrimSpace(part), "CN="); ok {
+			return cn
+		}
+	}
+	return dn
+}
+
+// funcs are the functions of the templates.
+var funcs = template.FuncMap{
+	"xml": func(s string) (string, error) {
+		var b strings.Builder
+		err := xml.EscapeText(&b, []byte(s))
+		return b.String(), err
+	},
+	"desktop": func(s string) string {
+		return strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, ";", `\;`).Replace(s)
+	},
+	"indent": func(s string) string {
+		lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = "    " + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+}
+
+// execute executes the embedded template name with data.
+func execute(name string, data any) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(funcs).ParseFS(templates, "template/"+name)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("pack: %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// render executes the embedded template name with data and writes it to dst.
+func render(dst, name string, data any, perm os.FileMode) error {
+	out, err := execute(name, data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, out, perm)
+}
+
+// copyFile copies src to dst with perm, creating the parent directories.
+func copyFile(dst, src string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("pack: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// Apply perm even if dst existed with another mode.
+	return os.Chmod(dst, perm)
+}
+
+// writeFile writes data to dst, creating the parent directories.
+func writeFile(dst string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, perm)
+}
+
+// recreate removes dir and creates it empty, so stale files of a previous
+// run do not end up in the package.
+func recreate(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, 0o755)
+}
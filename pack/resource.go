@@ -0,0 +1,326 @@
+package pack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Resource types of the Windows resources.
+const (
+	rtIcon      = 3
+	rtGroupIcon = 14
+	rtVersion   = 16
+	rtManifest  = 24
+)
+
+// resourceLang is the language of the resources, US English.
+const resourceLang = 0x0409
+
+// coffMachines maps GOARCH to the COFF machine type and the relocation type
+// of image relative addresses.
+var coffMachines = map[string]struct{ machine, reloc uint16 }{
+	"amd64": {0x8664, 3},
+	"386":   {0x14c, 7},
+	"arm64": {0xaa64, 2},
+}
+
+// resource is a Windows resource.
+type resource struct {
+	typ, id uint16
+	data    []byte
+}
+
+// WindowsResources returns a COFF object (.syso) with the resources of a
+// Windows executable of meta: the icon, if set, the VERSIONINFO shown in the
+// file properties and a manifest enabling per-monitor DPI awareness, common
+// controls 6, long paths and the UTF-8 code page. The Go linker links .syso
+// files of the main package into the executable, see WriteSyso.
+func WindowsResources(meta Metadata, opts ...Option) ([]byte, error) {
+	meta.apply(opts)
+	if err := meta.normalize(false); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(meta.Executable), ".exe") {
+		meta.Executable += ".exe"
+	}
+
+	machine, ok := coffMachines[meta.GOARCH]
+	if !ok {
+		return nil, fmt.Errorf("pack: Windows resources do not support GOARCH %s", meta.GOARCH)
+	}
+
+	manifest, err := newWindowsManifest(&meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []resource
+
+	img, err := meta.loadIcon()
+	if err != nil {
+		return nil, err
+	}
+	if img != nil {
+		images, err := icoImages(img)
+		if err != nil {
+			return nil, err
+		}
+
+		group := binary.LittleEndian.AppendUint16([]byte{0, 0, 1, 0}, uint16(len(images)))
+		for i, img := range images {
+			resources = append(resources, resource{rtIcon, uint16(i + 1), img.data})
+			group = binary.LittleEndian.AppendUint16(append(group, icoEntry(img)...), uint16(i+1))
+		}
+		resources = append(resources, resource{rtGroupIcon, 1, group})
+	}
+
+	resources = append(resources, resource{rtVersion, 1, versionInfo(manifest)})
+
+	xml, err := execute("app.manifest", manifest)
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, resource{rtManifest, 1, xml})
+
+	return coff(machine.machine, machine.reloc, resources), nil
+}
+
+// WriteSyso writes the resources of meta to dir/rsrc_windows_<GOARCH>.syso
+// and returns its path. Write it to the directory of the main package, e.g.
+// from go generate, for go build to link it on Windows; the file name limits
+// it to the architecture.
+func WriteSyso(dir string, meta Metadata, opts ...Option) (string, error) {
+	meta.apply(opts)
+	if err := meta.normalize(false); err != nil {
+		return "", err
+	}
+
+	data, err := WindowsResources(meta)
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(dir, "rsrc_windows_"+meta.GOARCH+".syso")
+	if err := writeFile(name, data, 0o644); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// coff returns an object file of the .rsrc section of resources, which have
+// to be sorted by type and id.
+func coff(machine, reloc uint16, resources []resource) []byte {
+	var types []uint16
+	byType := map[uint16][]resource{}
+	for _, r := range resources {
+		if len(byType[r.typ]) == 0 {
+			types = append(types, r.typ)
+		}
+		byType[r.typ] = append(byType[r.typ], r)
+	}
+
+	// The directory tree: types, ids and languages, followed by the data
+	// entries and the data.
+	offset := 16 + 8*len(types)
+	typeDirs := make([]int, len(types))
+	for i, typ := range types {
+		typeDirs[i] = offset
+		offset += 16 + 8*len(byType[typ])
+	}
+
+	langDirs := offset
+	entries := langDirs + 24*len(resources)
+	offset = align(entries+16*len(resources), 8)
+
+	data := make([]int, len(resources))
+	for i, r := range resources {
+		data[i] = offset
+		offset = align(offset+len(r.data), 8)
+	}
+
+	le := binary.LittleEndian
+	section := make([]byte, 0, offset)
+
+	dir := func(ids int) {
+		section = append(section, make([]byte, 12)...)
+		section = le.AppendUint16(section, 0)
+		section = le.AppendUint16(section, uint16(ids))
+	}
+	entry := func(id uint16, offset uint32) {
+		section = le.AppendUint32(section, uint32(id))
+		section = le.AppendUint32(section, offset)
+	}
+
+	dir(len(types))
+	for i, typ := range types {
+		entry(typ, 1<<31|uint32(typeDirs[i]))
+	}
+
+	n := 0
+	for _, typ := range types {
+		dir(len(byType[typ]))
+		for _, r := range byType[typ] {
+			entry(r.id, 1<<31|uint32(langDirs+24*n))
+			n++
+		}
+	}
+
+	for i := range resources {
+		dir(1)
+		entry(resourceLang, uint32(entries+16*i))
+	}
+
+	var relocs []byte
+	for i, r := range resources {
+		// OffsetToData is an RVA, relocated relative to the section.
+		relocs = le.AppendUint32(relocs, uint32(len(section)))
+		relocs = le.AppendUint32(relocs, 0)
+		relocs = le.AppendUint16(relocs, reloc)
+
+		section = le.AppendUint32(section, uint32(data[i]))
+		section = le.AppendUint32(section, uint32(len(r.data)))
+		section = append(section, make([]byte, 8)...)
+	}
+
+	for _, r := range resources {
+		section = append(section, make([]byte, align(len(section), 8)-len(section))...)
+		section = append(section, r.data...)
+	}
+	section = append(section, make([]byte, align(len(section), 8)-len(section))...)
+
+	const headers = 20 + 40
+
+	// File header.
+	out := le.AppendUint16(nil, machine)
+	out = le.AppendUint16(out, 1)
+	out = le.AppendUint32(out, 0)
+	out = le.AppendUint32(out, uint32(headers+len(section)+len(relocs)))
+	out = le.AppendUint32(out, 1)
+	out = le.AppendUint16(out, 0)
+	out = le.AppendUint16(out, 0)
+
+	// Section header of .rsrc, initialized readable data.
+	out = append(out, ".rsrc\x00\x00\x00"...)
+	out = le.AppendUint32(out, 0)
+	out = le.AppendUint32(out, 0)
+	out = le.AppendUint32(out, uint32(len(section)))
+	out = le.AppendUint32(out, headers)
+	out = le.AppendUint32(out, uint32(headers+len(section)))
+	out = le.AppendUint32(out, 0)
+	out = le.AppendUint16(out, uint16(len(resources)))
+	out = le.AppendUint16(out, 0)
+	out = le.AppendUint32(out, 0x40000040)
+
+	out = append(append(out, section...), relocs...)
+
+	// The static symbol of the section the relocations refer to and an empty
+	// string table.
+	out = append(out, ".rsrc\x00\x00\x00"...)
+	out = le.AppendUint32(out, 0)
+	out = le.AppendUint16(out, 1)
+	out = le.AppendUint16(out, 0)
+	out = append(out, 3, 0)
+	return le.AppendUint32(out, 4)
+}
+
+// align rounds n up to a multiple of a.
+func align(n, a int) int {
+	return (n + a - 1) / a * a
+}
+
+// versionInfo returns the VS_VERSIONINFO resource of m.
+func versionInfo(m *windowsManifest) []byte {
+	var parts [4]uint32
+	for i, part := range strings.Split(m.WindowsVersion, ".") {
+		n, _ := strconv.ParseUint(part, 10, 16)
+		parts[i] = uint32(n)
+	}
+
+	le := binary.LittleEndian
+
+	// VS_FIXEDFILEINFO of an application for Windows NT.
+	fixed := le.AppendUint32(nil, 0xfeef04bd)
+	fixed = le.AppendUint32(fixed, 0x00010000)
+	for range 2 {
+		fixed = le.AppendUint32(fixed, parts[0]<<16|parts[1])
+		fixed = le.AppendUint32(fixed, parts[2]<<16|parts[3])
+	}
+	fixed = le.AppendUint32(fixed, 0x3f)
+	fixed = le.AppendUint32(fixed, 0)
+	fixed = le.AppendUint32(fixed, 0x40004)
+	fixed = le.AppendUint32(fixed, 1)
+	fixed = append(fixed, make([]byte, 12)...)
+
+	var strs []vsBlock
+	for _, kv := range [][2]string{
+		{"CompanyName", m.PublisherName},
+		{"FileDescription", m.Summary},
+		{"FileVersion", m.WindowsVersion},
+		{"InternalName", strings.TrimSuffix(m.Executable, filepath.Ext(m.Executable))},
+		{"LegalCopyright", m.Copyright},
+		{"OriginalFilename", m.Executable},
+		{"ProductName", m.Name},
+		{"ProductVersion", m.Version},
+	} {
+		if kv[1] != "" {
+			strs = append(strs, vsBlock{key: kv[0], value: utf16z(kv[1]), text: true})
+		}
+	}
+
+	return vsBlock{key: "VS_VERSION_INFO", value: fixed, children: []vsBlock{
+		{key: "StringFileInfo", text: true, children: []vsBlock{
+			{key: "040904b0", text: true, children: strs},
+		}},
+		{key: "VarFileInfo", text: true, children: []vsBlock{
+			{key: "Translation", value: le.AppendUint16(le.AppendUint16(nil, resourceLang), 1200)},
+		}},
+	}}.encode()
+}
+
+// vsBlock is a block of a VS_VERSIONINFO resource.
+type vsBlock struct {
+	key   string
+	value []byte
+	// text marks text blocks, their value length counts UTF-16 code units.
+	text     bool
+	children []vsBlock
+}
+
+// encode returns the block, its value and children aligned to 32 bits.
+func (b vsBlock) encode() []byte {
+	le := binary.LittleEndian
+
+	valueLen, typ := len(b.value), uint16(0)
+	if b.text {
+		valueLen, typ = len(b.value)/2, 1
+	}
+
+	buf := le.AppendUint16(make([]byte, 2), uint16(valueLen))
+	buf = le.AppendUint16(buf, typ)
+	buf = append(buf, utf16z(b.key)...)
+
+	if len(b.value) > 0 {
+		buf = append(buf, make([]byte, align(len(buf), 4)-len(buf))...)
+		buf = append(buf, b.value...)
+	}
+	for _, child := range b.children {
+		buf = append(buf, make([]byte, align(len(buf), 4)-len(buf))...)
+		buf = append(buf, child.encode()...)
+	}
+
+	le.PutUint16(buf, uint16(len(buf)))
+	return buf
+}
+
+// utf16z encodes s as NUL terminated UTF-16LE.
+func utf16z(s string) []byte {
+	var buf []byte
+	for _, c := range utf16.Encode([]rune(s + "\x00")) {
+		buf = binary.LittleEndian.AppendUint16(buf, c)
+	}
+	return buf
+}
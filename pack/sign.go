@@ -0,0 +1,66 @@
+package pack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Signer is a signing step, e.g. Codesign, Notarize or Signtool.
+type Signer interface {
+	// Sign signs the file or bundle at path in place.
+	Sign(ctx context.Context, path string) error
+}
+
+// Sign runs signers on path in order, e.g. Codesign and Notarize on an
+// application bundle. Unsigned applications are blocked by Gatekeeper and
+// SmartScreen.
+func Sign(ctx context.Context, path string, signers ...Signer) error {
+	for _, signer := range signers {
+		if err := signer.Sign(ctx, path); err != nil {
+			return fmt.Errorf("pack: sign %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ErrNoSigningTool is returned by the signers if their tool is not found in
+// PATH.
+var ErrNoSigningTool = errors.New("signing tool not found")
+
+// run runs the signing tool name with args.
+func run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	tool, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, ErrNoSigningTool)
+	}
+
+	out, err := exec.CommandContext(ctx, tool, args...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s: %w: %s", name, err, out)
+	}
+	return out, nil
+}
+
+// secretFile writes a secret to a temporary file only the user can read and
+// returns its path, for tools reading keys from files. Remove it with
+// os.Remove once the tool is done.
+func secretFile(pattern string, data []byte) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
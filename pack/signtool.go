@@ -0,0 +1,91 @@
+package pack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Certificate is an Authenticode code signing certificate. Set PFX or
+// Thumbprint.
+type Certificate struct {
+	// PFX is the content of a PKCS #12 file and Password its password.
+	PFX      []byte
+	Password string
+	// Thumbprint is the SHA-1 hash of a certificate in the certificate store,
+	// e.g. one backed by a hardware token or a cloud HSM.
+	Thumbprint string
+}
+
+// CertificateProvider supplies the Certificate, e.g. from a secrets manager,
+// when Signtool needs it.
+type CertificateProvider interface {
+	Certificate(ctx context.Context) (Certificate, error)
+}
+
+// CertificateFunc adapts a function to CertificateProvider.
+type CertificateFunc func(ctx context.Context) (Certificate, error)
+
+// Certificate implements CertificateProvider.
+func (f CertificateFunc) Certificate(ctx context.Context) (Certificate, error) {
+	return f(ctx)
+}
+
+// defaultTimestampURL is the RFC 3161 timestamp server of Signtool.
+const defaultTimestampURL = "http://timestamp.digicert.com"
+
+// Signtool signs executables and MSIX packages with Authenticode using
+// signtool of the Windows SDK. The publisher of an MSIX package has to match
+// the subject of the certificate.
+type Signtool struct {
+	// Certificate supplies the signing certificate.
+	Certificate CertificateProvider
+	// TimestampURL is the RFC 3161 timestamp server, DigiCert by default.
+	TimestampURL string
+	// Description is shown in the UAC prompt, if set.
+	Description string
+}
+
+// Sign implements Signer.
+func (s *Signtool) Sign(ctx context.Context, path string) error {
+	if s.Certificate == nil {
+		return errors.New("signtool: missing certificate")
+	}
+
+	cert, err := s.Certificate.Certificate(ctx)
+	if err != nil {
+		return fmt.Errorf("signtool: certificate: %w", err)
+	}
+
+	url := s.TimestampURL
+	if url == "" {
+		url = defaultTimestampURL
+	}
+
+	args := []string{"sign", "/fd", "SHA256", "/td", "SHA256", "/tr", url}
+	if s.Description != "" {
+		args = append(args, "/d", s.Description)
+	}
+
+	switch {
+	case len(cert.PFX) > 0:
+		pfx, err := secretFile("saucer-cert-*.pfx", cert.PFX)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(pfx)
+
+		args = append(args, "/f", pfx)
+		if cert.Password != "" {
+			args = append(args, "/p", cert.Password)
+		}
+	case cert.Thumbprint != "":
+		args = append(args, "/sha1", cert.Thumbprint)
+	default:
+		return errors.New("signtool: empty certificate")
+	}
+
+	_, err = run(ctx, "signtool", append(args, path)...)
+	return err
+}
@@ -0,0 +1,270 @@
+// Package prebuilt provides compiled saucer static libraries for common
+// targets, so machines without a C++23 toolchain can link the bindings.
+//
+// Archives are gzip compressed tarballs using the layout of the build cache
+// (see build.LoadArtifacts). They are pinned by SHA-256 and verified before
+// use; a digest mismatch is always an error.
+package prebuilt
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/aperturerobotics/saucer"
+	"github.com/aperturerobotics/saucer/build"
+)
+
+// ErrNotAvailable is returned when no archive is pinned for a target.
+var ErrNotAvailable = errors.New("prebuilt: no archive for target")
+
+// Target identifies a platform and backend.
+type Target struct {
+	GOOS    string
+	GOARCH  string
+	Backend build.Backend
+}
+
+// HostTarget returns the target of the running binary with the native backend.
+func HostTarget() Target {
+	return Target{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH, Backend: build.BackendDefault}
+}
+
+// String returns the target as goos/goarch/backend.
+func (t Target) String() string {
+	return t.GOOS + "/" + t.GOARCH + "/" + string(t.Backend)
+}
+
+// Archive is a pinned prebuilt library.
+type Archive struct {
+	Target Target
+	// Version is the saucer version the archive was built from.
+	Version string
+	// URL is the download location.
+	URL string
+	// SHA256 is the hex encoded digest of the archive.
+	SHA256 string
+}
+
+// Pinned lists the archives published for this release. It is extended by
+// the release process; applications may append their own mirrors.
+var Pinned []Archive
+
+// Lookup returns the pinned archive for target matching saucer.Version.
+func Lookup(target Target) (Archive, error) {
+	return lookup(target, saucer.Version())
+}
+
+// lookup returns the pinned archive for target of the saucer release version.
+func lookup(target Target, version string) (Archive, error) {
+	for _, archive := range Pinned {
+		if archive.Target == target && archive.Version == version {
+			return archive, nil
+		}
+	}
+	return Archive{}, fmt.Errorf("%w %s", ErrNotAvailable, target)
+}
+
+// Options configures Fetch.
+type Options struct {
+	// Dir is where archives are unpacked. Defaults to "saucer-prebuilt" below
+	// os.UserCacheDir.
+	Dir string
+	// Client downloads the archives. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Version is the saucer release of the archive, see build.Config.Version.
+	// Defaults to saucer.Version.
+	Version string
+}
+
+// Fetch returns the artifacts of the pinned archive for target, downloading
+// and verifying it unless it was unpacked before.
+func Fetch(ctx context.Context, target Target, opts Options) (*build.Artifacts, error) {
+	version := opts.Version
+	if version == "" {
+		version = saucer.Version()
+	}
+
+	archive, err := lookup(target, version)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := opts.dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := filepath.Join(dir, archive.SHA256)
+	if art, err := build.LoadArtifacts(entry); err == nil {
+		return art, nil
+	}
+
+	data, err := download(ctx, opts.client(), archive.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Install(bytes.NewReader(data), archive.SHA256, entry); err != nil {
+		return nil, err
+	}
+	return build.LoadArtifacts(entry)
+}
+
+// Build returns prebuilt artifacts for the host if available and falls back
+// to building cfg from source otherwise.
+func Build(ctx context.Context, cfg build.Config, opts Options) (*build.Artifacts, error) {
+	target := HostTarget()
+	if cfg.Backend != "" {
+		target.Backend = cfg.Backend
+	}
+
+	b := build.NewBuilder(cfg)
+	opts.Version = b.Release().Version
+
+	art, err := Fetch(ctx, target, opts)
+	if errors.Is(err, ErrNotAvailable) {
+		return b.Build(ctx)
+	}
+	return art, err
+}
+
+// Install verifies the archive read from r against the hex encoded SHA-256
+// digest and unpacks it to dir. It can be used for archives embedded into a
+// binary or shipped out of band.
+func Install(r io.Reader, digest string, dir string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, digest) {
+		return fmt.Errorf("prebuilt: digest mismatch: got %s, want %s", got, digest)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := unpack(data, tmp); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, lerr := build.LoadArtifacts(dir); lerr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// unpack extracts the gzip compressed tarball data to dir.
+func unpack(data []byte, dir string) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("prebuilt: %w", err)
+	}
+
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("prebuilt: %w", err)
+		}
+
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("prebuilt: invalid path %q in archive", hdr.Name)
+		}
+
+		target := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0o755)
+		case tar.TypeReg:
+			err = writeFile(target, tr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeFile writes the content of r to name, creating its parent.
+func writeFile(name string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// download fetches url into memory.
+func download(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("prebuilt: download: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prebuilt: download %s: %s", url, res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+func (o *Options) dir() (string, error) {
+	if o.Dir != "" {
+		return o.Dir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("prebuilt: cache dir: %w", err)
+	}
+	return filepath.Join(dir, "saucer-prebuilt"), nil
+}
+
+func (o *Options) client() *http.Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	return http.DefaultClient
+}
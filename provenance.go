@@ -0,0 +1,117 @@
+package saucer
+
+import (
+	"crypto/ed25519"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ProvenanceKey is the hex encoded Ed25519 public key the manifest of the
+// vendored sources is signed with at vendoring time. The private key is held
+// by the maintainers cutting releases; it is empty until they publish it, and
+// the sources are unsigned until then.
+const ProvenanceKey = ""
+
+// manifestSignature is the detached Ed25519 signature of the provenance
+// statement, see Provenance.Statement. It is empty if the sources were
+// vendored without the signing key.
+//
+//go:embed zz_manifest.sig
+var manifestSignature []byte
+
+var (
+	// ErrUnsigned is returned by VerifyProvenance if the manifest was not
+	// signed at vendoring time or ProvenanceKey is not published.
+	ErrUnsigned = errors.New("saucer: source manifest is not signed")
+	// ErrBadSignature is returned by VerifyProvenance if the signature does
+	// not match the provenance statement.
+	ErrBadSignature = errors.New("saucer: source manifest signature is invalid")
+)
+
+// Provenance is what the embedded sources claim to be: the upstream release
+// and the hash of the manifest of every vendored file.
+type Provenance struct {
+	// Repository is the upstream git repository.
+	Repository string
+	// Version, Tag and Commit identify the upstream release, see Version,
+	// UpstreamTag and UpstreamCommit.
+	Version string
+	Tag     string
+	Commit  string
+	// Manifest is the Manifest.Hash of the full vendored tree, including the
+	// backends not compiled into this binary.
+	Manifest string
+}
+
+// SourceProvenance returns the provenance of the embedded sources.
+func SourceProvenance() Provenance {
+	return Provenance{
+		Repository: upstreamRepository,
+		Version:    version,
+		Tag:        upstreamTag,
+		Commit:     upstreamCommit,
+		Manifest:   manifestEntries.Hash(),
+	}
+}
+
+// Statement returns the canonical text signed with the key of
+// ProvenanceKey.
+func (p Provenance) Statement() []byte {
+	var b strings.Builder
+
+	b.WriteString("saucer provenance v1\n")
+	fmt.Fprintf(&b, "repository %s\n", p.Repository)
+	fmt.Fprintf(&b, "version %s\n", p.Version)
+	fmt.Fprintf(&b, "tag %s\n", p.Tag)
+	fmt.Fprintf(&b, "commit %s\n", p.Commit)
+	fmt.Fprintf(&b, "manifest sha256:%s\n", p.Manifest)
+
+	return []byte(b.String())
+}
+
+// VerifyProvenance checks that the embedded sources are the upstream release
+// they claim to be: the provenance statement must carry a valid signature of
+// ProvenanceKey and every embedded file must match the signed manifest.
+func VerifyProvenance() error {
+	if ProvenanceKey == "" || len(manifestSignature) == 0 {
+		return ErrUnsigned
+	}
+
+	key, err := hex.DecodeString(ProvenanceKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("saucer: invalid provenance key")
+	}
+
+	return verifyProvenance(key, manifestSignature)
+}
+
+// verifyProvenance checks the signature of the provenance statement with key
+// and the embedded files against the manifest.
+func verifyProvenance(key ed25519.PublicKey, signature []byte) error {
+	if !ed25519.Verify(key, SourceProvenance().Statement(), signature) {
+		return ErrBadSignature
+	}
+
+	embedded, err := ManifestFS(source)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(embedded)) {
+		want, ok := manifestEntries[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("saucer: %s: not in the signed manifest", name))
+		case embedded[name] != want:
+			errs = append(errs, fmt.Errorf("saucer: %s: content does not match the signed manifest", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,41 @@
+package saucer
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+)
+
+// TestVerifyProvenance checks that the embedded signature signs the embedded
+// manifest, once the sources are signed with ProvenanceKey.
+func TestVerifyProvenance(t *testing.T) {
+	err := VerifyProvenance()
+	if ProvenanceKey == "" || len(manifestSignature) == 0 {
+		if !errors.Is(err, ErrUnsigned) {
+			t.Fatalf("unsigned sources: %v, expected ErrUnsigned", err)
+		}
+		t.Skip("the sources are not signed")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestVerifyProvenanceKey checks the signature and the embedded files
+// against the statement signed with a key of the test.
+func TestVerifyProvenanceKey(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := SourceProvenance()
+	if err := verifyProvenance(public, ed25519.Sign(private, p.Statement())); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Manifest = "0000"
+	if err := verifyProvenance(public, ed25519.Sign(private, p.Statement())); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("signature of another manifest: %v, expected ErrBadSignature", err)
+	}
+}
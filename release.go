@@ -0,0 +1,123 @@
+package saucer
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Release is an upstream saucer release embedded in the module.
+type Release struct {
+	// Version is the version declared by the CMake project of the release.
+	Version string
+	// Tag, Commit and Date identify the upstream commit the sources were
+	// vendored from. Commit and Date may be empty or zero.
+	Tag    string
+	Commit string
+	Date   time.Time
+	// Source is the source tree of the release.
+	Source fs.FS
+	// Default reports whether the release is the one the package-level
+	// functions and SourceFS refer to.
+	Default bool
+}
+
+// releases holds the additional releases compiled into this binary.
+var releases []Release
+
+// registerRelease records an additional embedded release.
+func registerRelease(r Release) {
+	releases = append(releases, r)
+}
+
+// Versions returns the releases embedded in this binary, the default release
+// first and the others newest first.
+//
+// Only the default release, described by Version and served by SourceFS, is
+// embedded unless built with the tag of another release, e.g.
+// saucer_v7_2_0 for 7.2.0. Additional releases give applications pinned to
+// an older C++ API a migration window across module updates. They are
+// embedded whole with all their backends and without a signed manifest.
+func Versions() []Release {
+	rtn := []Release{{
+		Version: version,
+		Tag:     upstreamTag,
+		Commit:  upstreamCommit,
+		Date:    ReleaseDate(),
+		Source:  source,
+		Default: true,
+	}}
+
+	others := slices.Clone(releases)
+	slices.SortFunc(others, func(a, b Release) int {
+		return compareVersions(b.Version, a.Version)
+	})
+
+	return append(rtn, others...)
+}
+
+// LookupRelease returns the embedded release of version, a version such as
+// "8.1.0", its tag, or a version prefix such as "8" or "v8.1" selecting the
+// default release if it matches and the newest matching release otherwise.
+// An empty version is the default release.
+func LookupRelease(version string) (Release, error) {
+	all := Versions()
+	if version == "" {
+		return all[0], nil
+	}
+
+	want := strings.TrimPrefix(version, "v")
+	for _, r := range all {
+		if r.Tag == version || r.Version == want {
+			return r, nil
+		}
+	}
+
+	for _, r := range all {
+		if strings.HasPrefix(r.Version, want+".") {
+			return r, nil
+		}
+	}
+
+	if strings.Count(want, ".") < 2 {
+		return Release{}, fmt.Errorf("saucer: release %s is not embedded, build with the %s_* tag of a release", version, ReleaseTag(want))
+	}
+	return Release{}, fmt.Errorf("saucer: release %s is not embedded, build with -tags %s", version, ReleaseTag(want))
+}
+
+// SourceForVersion returns the source tree of the embedded release of
+// version, as selected by LookupRelease.
+func SourceForVersion(version string) (fs.FS, error) {
+	r, err := LookupRelease(version)
+	if err != nil {
+		return nil, err
+	}
+	return r.Source, nil
+}
+
+// ReleaseTag returns the build tag embedding the additional release of
+// version, e.g. "saucer_v7_2_0".
+func ReleaseTag(version string) string {
+	return "saucer_v" + strings.ReplaceAll(strings.TrimPrefix(version, "v"), ".", "_")
+}
+
+// compareVersions compares the dotted versions a and b numerically.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			fmt.Sscan(as[i], &x)
+		}
+		if i < len(bs) {
+			fmt.Sscan(bs[i], &y)
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
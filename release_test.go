@@ -0,0 +1,97 @@
+package saucer
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var projectVersion = regexp.MustCompile(`project\(saucer\b[^)]*\bVERSION\s+([0-9.]+)`)
+
+// checkVersions checks that SourceForVersion serves every release of
+// Versions under its version and its tag, with sources declaring the version.
+func checkVersions(t *testing.T) {
+	t.Helper()
+
+	all := Versions()
+	if !all[0].Default || all[0].Version != Version() {
+		t.Fatalf("first release %+v, expected the default release %s", all[0], Version())
+	}
+
+	for _, r := range all {
+		for _, version := range []string{r.Version, r.Tag} {
+			fsys, err := SourceForVersion(version)
+			if err != nil {
+				t.Errorf("%s: %v", version, err)
+				continue
+			}
+			if got := cmakeVersion(t, fsys); got != r.Version {
+				t.Errorf("%s: served the sources of %s, expected %s", version, got, r.Version)
+			}
+		}
+	}
+}
+
+// cmakeVersion returns the version of the CMake project of fsys.
+func cmakeVersion(t *testing.T, fsys fs.FS) string {
+	cmake, err := fs.ReadFile(fsys, "CMakeLists.txt")
+	if err != nil {
+		t.Error(err)
+		return ""
+	}
+	if match := projectVersion.FindSubmatch(cmake); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+// TestVersions checks the releases of this build, including those embedded
+// with the tag of an additional release.
+func TestVersions(t *testing.T) {
+	checkVersions(t)
+}
+
+// TestVersionsAdditional checks the selection among additional releases as
+// registered by the files genmanifest -release generates.
+func TestVersionsAdditional(t *testing.T) {
+	defer func(registered []Release) { releases = registered }(releases)
+
+	for _, version := range []string{"7.2.0", "7.10.1", "6.0.0"} {
+		registerRelease(Release{
+			Version: version,
+			Tag:     "v" + version,
+			Source: fstest.MapFS{
+				"CMakeLists.txt": {Data: []byte("project(saucer LANGUAGES CXX VERSION " + version + ")\n")},
+			},
+		})
+	}
+
+	checkVersions(t)
+
+	var order []string
+	for _, r := range Versions()[1:] {
+		order = append(order, r.Version)
+	}
+	if got := strings.Join(order, " "); got != "7.10.1 7.2.0 6.0.0" {
+		t.Errorf("additional releases %s, expected newest first", got)
+	}
+
+	tests := map[string]string{
+		"":      Version(),
+		"7":     "7.10.1",
+		"v7.2":  "7.2.0",
+		"6.0.0": "6.0.0",
+	}
+	for version, want := range tests {
+		r, err := LookupRelease(version)
+		if err != nil || r.Version != want {
+			t.Errorf("%q: %s, %v, expected %s", version, r.Version, err, want)
+		}
+	}
+
+	if _, err := SourceForVersion("7.3.0"); err == nil || !strings.Contains(err.Error(), ReleaseTag("7.3.0")) {
+		t.Errorf("missing release: %v, expected the tag to build with", err)
+	}
+}
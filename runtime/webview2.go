@@ -0,0 +1,94 @@
+// Package runtime checks and installs the native runtimes saucer depends on
+// at run time.
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// BootstrapperURL is Microsoft's download link of the Evergreen WebView2
+// bootstrapper.
+const BootstrapperURL = "https://go.microsoft.com/fwlink/p/?LinkId=2124703"
+
+// fixedVersionEnv is read by the WebView2 loader to locate a fixed-version
+// runtime instead of the installed Evergreen one.
+const fixedVersionEnv = "WEBVIEW2_BROWSER_EXECUTABLE_FOLDER"
+
+// Fallback selects what EnsureWebView2 does when no runtime is installed.
+type Fallback uint8
+
+const (
+	// FallbackError returns a *MissingError.
+	FallbackError Fallback = iota
+	// FallbackBootstrap downloads and runs the Evergreen bootstrapper.
+	FallbackBootstrap
+	// FallbackFixedVersion uses the fixed-version runtime in
+	// WebView2Options.FixedVersionDir.
+	FallbackFixedVersion
+)
+
+// WebView2Options configures EnsureWebView2.
+type WebView2Options struct {
+	Fallback Fallback
+	// BootstrapperURL overrides the bootstrapper download location.
+	BootstrapperURL string
+	// FixedVersionDir is the folder of a bundled fixed-version runtime,
+	// containing msedgewebview2.exe.
+	FixedVersionDir string
+	// Client downloads the bootstrapper. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// WebView2 describes the runtime webviews are going to use.
+type WebView2 struct {
+	// Version is the installed Evergreen version, empty for fixed-version
+	// runtimes.
+	Version string
+	// FixedVersionDir is set when a fixed-version runtime is used.
+	FixedVersionDir string
+}
+
+// MissingError is returned when no WebView2 runtime is available.
+type MissingError struct {
+	// Err is the reason the fallback failed, nil for FallbackError.
+	Err error
+}
+
+func (e *MissingError) Error() string {
+	if e.Err == nil {
+		return "runtime: WebView2 runtime is not installed"
+	}
+	return fmt.Sprintf("runtime: WebView2 runtime is not installed: %v", e.Err)
+}
+
+func (e *MissingError) Unwrap() error {
+	return e.Err
+}
+
+// EnsureWebView2 makes sure a WebView2 runtime is available before the first
+// webview is created. If the Evergreen runtime is not installed, it applies
+// opts.Fallback. On other systems than Windows it does nothing.
+func EnsureWebView2(ctx context.Context, opts WebView2Options) (WebView2, error) {
+	return ensureWebView2(ctx, opts)
+}
+
+// client returns the HTTP client for downloads.
+func (o *WebView2Options) client() *http.Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	return http.DefaultClient
+}
+
+// bootstrapperURL returns the bootstrapper download location.
+func (o *WebView2Options) bootstrapperURL() string {
+	if o.BootstrapperURL != "" {
+		return o.BootstrapperURL
+	}
+	return BootstrapperURL
+}
+
+var errNoFixedVersionDir = errors.New("no fixed-version runtime folder configured")
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package runtime
+
+import "context"
+
+func ensureWebView2(context.Context, WebView2Options) (WebView2, error) {
+	return WebView2{}, nil
+}
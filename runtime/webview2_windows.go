@@ -0,0 +1,139 @@
+//go:build windows
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// webView2Client is the EdgeUpdate client id of the Evergreen runtime.
+const webView2Client = `\Microsoft\EdgeUpdate\Clients\{F3017226-FE2A-4295-8BDF-00C3A9A7E4C5}`
+
+func ensureWebView2(ctx context.Context, opts WebView2Options) (WebView2, error) {
+	if version := installedWebView2(); version != "" {
+		return WebView2{Version: version}, nil
+	}
+
+	switch opts.Fallback {
+	case FallbackBootstrap:
+		if err := bootstrap(ctx, &opts); err != nil {
+			return WebView2{}, &MissingError{Err: err}
+		}
+
+		if version := installedWebView2(); version != "" {
+			return WebView2{Version: version}, nil
+		}
+		return WebView2{}, &MissingError{Err: fmt.Errorf("bootstrapper finished without installing the runtime")}
+	case FallbackFixedVersion:
+		if opts.FixedVersionDir == "" {
+			return WebView2{}, &MissingError{Err: errNoFixedVersionDir}
+		}
+
+		if _, err := os.Stat(filepath.Join(opts.FixedVersionDir, "msedgewebview2.exe")); err != nil {
+			return WebView2{}, &MissingError{Err: err}
+		}
+
+		if err := os.Setenv(fixedVersionEnv, opts.FixedVersionDir); err != nil {
+			return WebView2{}, &MissingError{Err: err}
+		}
+		return WebView2{FixedVersionDir: opts.FixedVersionDir}, nil
+	default:
+		return WebView2{}, &MissingError{}
+	}
+}
+
+// installedWebView2 returns the version of the installed Evergreen runtime,
+// checking the machine-wide and per-user installations.
+func installedWebView2() string {
+	keys := []struct {
+		root   syscall.Handle
+		path   string
+		access uint32
+	}{
+		{syscall.HKEY_LOCAL_MACHINE, `SOFTWARE\WOW6432Node` + webView2Client, 0},
+		{syscall.HKEY_LOCAL_MACHINE, `SOFTWARE` + webView2Client, syscall.KEY_WOW64_64KEY},
+		{syscall.HKEY_CURRENT_USER, `Software` + webView2Client, 0},
+	}
+
+	for _, key := range keys {
+		version := registryString(key.root, key.path, "pv", key.access)
+		if version != "" && version != "0.0.0.0" {
+			return version
+		}
+	}
+	return ""
+}
+
+// registryString reads a REG_SZ value, returning an empty string on failure.
+func registryString(root syscall.Handle, path, name string, access uint32) string {
+	var key syscall.Handle
+
+	if syscall.RegOpenKeyEx(root, syscall.StringToUTF16Ptr(path), 0, syscall.KEY_READ|access, &key) != nil {
+		return ""
+	}
+	defer syscall.RegCloseKey(key)
+
+	var typ, size uint32
+	if syscall.RegQueryValueEx(key, syscall.StringToUTF16Ptr(name), nil, &typ, nil, &size) != nil || typ != syscall.REG_SZ || size == 0 {
+		return ""
+	}
+
+	buf := make([]uint16, size/2)
+	if syscall.RegQueryValueEx(key, syscall.StringToUTF16Ptr(name), nil, &typ, (*byte)(unsafe.Pointer(&buf[0])), &size) != nil {
+		return ""
+	}
+	return syscall.UTF16ToString(buf)
+}
+
+// bootstrap downloads and silently runs the Evergreen bootstrapper.
+func bootstrap(ctx context.Context, opts *WebView2Options) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.bootstrapperURL(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := opts.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("download bootstrapper: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("download bootstrapper: %s", res.Status)
+	}
+
+	dir, err := os.MkdirTemp("", "webview2-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	setup := filepath.Join(dir, "MicrosoftEdgeWebview2Setup.exe")
+
+	f, err := os.Create(setup)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, res.Body); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if out, err := exec.CommandContext(ctx, setup, "/silent", "/install").CombinedOutput(); err != nil {
+		return fmt.Errorf("run bootstrapper: %w: %s", err, out)
+	}
+	return nil
+}
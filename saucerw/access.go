@@ -0,0 +1,116 @@
+package saucerw
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// BridgeRule allows the pages of an origin to call exposed functions, see
+// SecurityPolicy.Bridge. It does not isolate the bridge from the other
+// scripts of the pages it allows.
+type BridgeRule struct {
+	// Origin is the scheme, host and port of the pages, e.g.
+	// "app://localhost" or "https://example.com:8443". "*" matches all
+	// origins and "null" the pages without a host, e.g. about:blank and data
+	// URLs.
+	Origin string
+	// Functions lists the functions the pages may call as path.Match
+	// patterns, e.g. "files.*". All functions if empty.
+	Functions []string
+	// Frames applies the rule to the child frames of Origin instead of the
+	// pages, giving them window.saucer.call and window.saucer.exposed. Their
+	// calls are relayed by the main frame and also need to be allowed for
+	// the page. Child frames get no bridge without such a rule.
+	Frames bool
+}
+
+// validate checks the origin and patterns of r.
+func (r *BridgeRule) validate() error {
+	if r.Origin != "*" && r.Origin != "null" && originOf(r.Origin) != r.Origin {
+		return fmt.Errorf("saucerw: bridge access: invalid origin %q, expected scheme and host like app://localhost", r.Origin)
+	}
+
+	for _, pattern := range r.Functions {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("saucerw: bridge access: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// allows reports whether r allows the pages, or child frames if frames is
+// set, of origin to call name.
+func (r *BridgeRule) allows(origin, name string, frames bool) bool {
+	if r.Frames != frames || r.Origin != "*" && r.Origin != origin {
+		return false
+	}
+	if len(r.Functions) == 0 {
+		return true
+	}
+
+	for _, pattern := range r.Functions {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// originOf returns the origin of addr, "null" if it has no host.
+func originOf(addr string) string {
+	u, err := url.Parse(addr)
+	if err != nil || u.Host == "" {
+		return "null"
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host)
+}
+
+// permit returns an error rejecting the call msg unless the rules allow the
+// current page, and the child frame relaying it if any, to call it. It runs
+// on the event loop thread, before the page can navigate away.
+func (b *bridge) permit(msg bridgeMessage) error {
+	if b.policy.Bridge == nil && msg.Frame == "" {
+		return nil
+	}
+
+	name := msg.Name
+	addr := b.native.URL()
+	origin := originOf(addr)
+	allowed := b.policy.allows(origin, name, false)
+
+	if msg.Frame != "" {
+		addr, origin = msg.URL, msg.Origin
+		allowed = allowed && b.policy.allows(origin, name, true)
+	}
+
+	if allowed {
+		return nil
+	}
+
+	log().Warn("page may not call exposed function", "component", "saucerw", "origin", origin, "frame", msg.Frame, "function", name)
+	if b.policy.OnDenied != nil {
+		func() {
+			defer guard("denied call handler")
+			b.policy.OnDenied(DeniedCall{Time: time.Now(), URL: addr, Origin: origin, Frame: msg.Frame, Function: name})
+		}()
+	}
+	return &BridgeError{Code: CodePermissionDenied, Err: fmt.Errorf("%s may not call '%s'", origin, name)}
+}
+
+// allows reports whether a rule of p allows the pages or child frames of
+// origin to call name, all pages if p restricts nothing.
+func (p *SecurityPolicy) allows(origin, name string, frames bool) bool {
+	if p.Bridge == nil {
+		return !frames
+	}
+
+	for _, rule := range p.Bridge {
+		if rule.allows(origin, name, frames) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package saucerw
+
+// forceAccessibilityFlag makes Chromium build the accessibility trees of the
+// pages without an assistive technology asking for them.
+const forceAccessibilityFlag = "--force-renderer-accessibility"
+
+// The flags of the native accessibility state, see native.h.
+const (
+	accessibilityScreenReader = 1 << iota
+	accessibilityReducedMotion
+	accessibilityHighContrast
+)
+
+// Accessibility is the state of the assistive technologies and the
+// accessibility preferences of the system, see Application.Accessibility.
+type Accessibility struct {
+	// ScreenReader reports whether a screen reader is running, e.g. Narrator,
+	// VoiceOver or Orca.
+	ScreenReader bool
+	// ReducedMotion reports whether the user asked for fewer animations.
+	ReducedMotion bool
+	// HighContrast reports whether the user asked for more contrast.
+	HighContrast bool
+}
+
+// accessibilityOf returns the Accessibility of the native flags.
+func accessibilityOf(flags int) Accessibility {
+	return Accessibility{
+		ScreenReader:  flags&accessibilityScreenReader != 0,
+		ReducedMotion: flags&accessibilityReducedMotion != 0,
+		HighContrast:  flags&accessibilityHighContrast != 0,
+	}
+}
+
+// features returns the media features forced for s, those without a
+// preference are left to the engine.
+func (s Accessibility) features() map[string]string {
+	rtn := map[string]string{"prefers-reduced-motion": "", "prefers-contrast": ""}
+	if s.ReducedMotion {
+		rtn["prefers-reduced-motion"] = "reduce"
+	}
+	if s.HighContrast {
+		rtn["prefers-contrast"] = "more"
+	}
+	return rtn
+}
+
+// forces reports whether s forces a media feature.
+func (s Accessibility) forces() bool {
+	return s.ReducedMotion || s.HighContrast
+}
+
+// Accessibility returns the accessibility state of the system.
+//
+// Windows reports Narrator and other screen readers setting the screen reader
+// flag of the system, the client area animation setting and high contrast
+// mode. WebKit on macOS reports VoiceOver and the Reduce motion and Increase
+// contrast settings. WebKitGTK reports the screen reader setting of the
+// desktop, the animation setting of GTK and the contrast preference of the
+// desktop portal or a high contrast theme. Qt reports ScreenReader while an
+// assistive technology uses the application and no preferences.
+func (a *Application) Accessibility() Accessibility {
+	return a.native.Accessibility()
+}
+
+// OnAccessibilityChange registers fn, called with the new state when a
+// screen reader started or stopped or the user changed the accessibility
+// preferences. Windows is polled every two seconds.
+func (a *Application) OnAccessibilityChange(fn func(Accessibility)) *Subscription {
+	return a.access.subscribe(fn)
+}
+
+// accessibilityChanged reports state and passes its preferences to all
+// webviews following them.
+func (a *Application) accessibilityChanged(state Accessibility) {
+	for _, w := range a.Windows() {
+		for _, v := range w.Webviews() {
+			v.followAccessibility(state, false)
+		}
+	}
+	a.access.emit(state)
+}
+
+// followAccessibility matches the prefers-reduced-motion and
+// prefers-contrast media features of the pages to state, unless the webview
+// opted out with Preferences.IgnoreAccessibilityPreferences. For the initial
+// state the override is only added if it forces a feature.
+func (v *Webview) followAccessibility(state Accessibility, initial bool) {
+	if v.options.Preferences.IgnoreAccessibilityPreferences || (initial && !state.forces()) {
+		return
+	}
+	v.access.set(v.native, state.features())
+}
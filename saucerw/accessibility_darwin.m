@@ -0,0 +1,85 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+
+#include "native.h"
+
+// SaucerwAccessibilityObserver reports changes of VoiceOver and the display options of the accessibility settings to
+// its handler.
+@interface SaucerwAccessibilityObserver : NSObject
+@property(nonatomic) uintptr_t handler;
+@property(nonatomic) int flags;
+- (void)changed;
+@end
+
+@implementation SaucerwAccessibilityObserver
+- (void)changed
+{
+    const int flags = saucerw_cocoa_accessibility();
+
+    if (flags == self.flags)
+    {
+        return;
+    }
+
+    self.flags = flags;
+    saucerwAccessibility(self.handler, flags);
+}
+
+- (void)observeValueForKeyPath:(NSString *)path
+                      ofObject:(id)object
+                        change:(NSDictionary<NSKeyValueChangeKey, id> *)change
+                       context:(void *)context
+{
+    [self changed];
+}
+@end
+
+int saucerw_cocoa_accessibility(void)
+{
+    NSWorkspace *workspace = NSWorkspace.sharedWorkspace;
+    int rtn                = 0;
+
+    if (workspace.isVoiceOverEnabled)
+    {
+        rtn |= SAUCERW_ACCESSIBILITY_SCREEN_READER;
+    }
+
+    if (workspace.accessibilityDisplayShouldReduceMotion)
+    {
+        rtn |= SAUCERW_ACCESSIBILITY_REDUCED_MOTION;
+    }
+
+    if (workspace.accessibilityDisplayShouldIncreaseContrast)
+    {
+        rtn |= SAUCERW_ACCESSIBILITY_HIGH_CONTRAST;
+    }
+
+    return rtn;
+}
+
+void *saucerw_cocoa_watch_accessibility(uintptr_t handler)
+{
+    SaucerwAccessibilityObserver *observer = [SaucerwAccessibilityObserver new];
+    NSWorkspace *workspace                 = NSWorkspace.sharedWorkspace;
+
+    observer.handler = handler;
+    observer.flags   = saucerw_cocoa_accessibility();
+
+    [workspace addObserver:observer forKeyPath:@"voiceOverEnabled" options:0 context:nil];
+    [workspace.notificationCenter addObserver:observer
+                                     selector:@selector(changed)
+                                         name:NSWorkspaceAccessibilityDisplayOptionsDidChangeNotification
+                                       object:nil];
+
+    return (__bridge_retained void *)observer;
+}
+
+void saucerw_cocoa_unwatch_accessibility(void *watcher)
+{
+    SaucerwAccessibilityObserver *observer = (__bridge_transfer SaucerwAccessibilityObserver *)watcher;
+    NSWorkspace *workspace                 = NSWorkspace.sharedWorkspace;
+
+    [workspace removeObserver:observer forKeyPath:@"voiceOverEnabled"];
+    [workspace.notificationCenter removeObserver:observer];
+}
@@ -0,0 +1,345 @@
+package saucerw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw/display"
+)
+
+// AppOptions configures a new Application.
+type AppOptions struct {
+	// ID is the application identifier, e.g. "com.example.app".
+	ID string
+	// Args are the command line arguments forwarded to the toolkit.
+	Args []string
+	// KeepRunning keeps the event loop alive after the last window closed.
+	KeepRunning bool
+	// Schemes lists the custom URL schemes served with Webview.HandleScheme.
+	// They have to be registered before the toolkit is initialized.
+	Schemes []string
+	// Headless runs the application without a screen, e.g. for integration
+	// tests on CI or rendering thumbnails and PDFs on a server: windows are
+	// created Offscreen and NewApplication starts a virtual display if the
+	// machine has none, see package display. It is stopped when Run returns.
+	Headless bool
+	// RemoteDebugging opts in to remote debugging, see
+	// Webview.EnableRemoteDebugging: the loopback address, e.g.
+	// "127.0.0.1:9222", the debugging endpoint of the backend listens on.
+	// Anyone able to connect to it controls the pages, leave it empty in
+	// production builds.
+	RemoteDebugging string
+	// QuitTimeout bounds the handlers asked before a window closes or the
+	// application quits, 10 seconds if zero. Afterwards the window closes or
+	// the application quits regardless, see OnQuitRequested.
+	QuitTimeout time.Duration
+	// Paths overrides the directories of the application, see
+	// Application.Paths. Empty directories default to those of DefaultPaths
+	// of ID, or PortablePaths if Portable is set.
+	Paths Paths
+	// Portable keeps the files of the application next to its executable,
+	// see PortablePaths.
+	Portable bool
+	// PreviousIDs lists the ids the application had before, newest first.
+	// Their directories are moved to those of ID if these do not exist yet,
+	// see Paths.Migrate. The directories of earlier releases, which kept the
+	// data below os.UserConfigDir, are migrated as well.
+	PreviousIDs []string
+	// ProfileDir is the directory the website data of the named profiles is
+	// stored in, see Preferences.Profile. Defaults to the directory
+	// "profiles" in the Data directory of Paths.
+	ProfileDir string
+	// Metrics serves Application.MetricsHandler at /debug/saucerw/ on this
+	// loopback address, e.g. "127.0.0.1:6061", until Run returns, for
+	// watching long-running deployments for leaks. Anyone able to connect
+	// learns the URLs of the pages.
+	Metrics string
+}
+
+// Application owns the native event loop.
+type Application struct {
+	native  AppDriver
+	running atomic.Bool
+	// stopped is closed once the event loop returned.
+	stopped  chan struct{}
+	headless bool
+	display  *display.Display
+
+	remoteDebugging string
+	metrics         *http.Server
+
+	keepRunning bool
+	quitTimeout time.Duration
+	quits       chain[context.Context, QuitDecision]
+	intercept   atomic.Bool
+	quitting    atomic.Bool
+
+	paths      Paths
+	profileDir string
+
+	mu       sync.Mutex
+	windows  []*Window
+	windowID uint64
+
+	keys    shortcuts
+	pressed emitter[func()]
+
+	power     emitter[PowerEvent]
+	shutdowns chain[context.Context, struct{}]
+
+	clipboard emitter[struct{}]
+	scheme    emitter[ColorScheme]
+	locales   emitter[[]string]
+	access    emitter[Accessibility]
+}
+
+// NewApplication creates the application using the default driver.
+//
+// Only one application may exist per process. It must be created from the
+// main goroutine.
+func NewApplication(opts AppOptions) (*Application, error) {
+	if defaultDriver == nil {
+		return nil, ErrNoDriver
+	}
+
+	if !opts.Headless || display.Available() {
+		return NewApplicationWithDriver(defaultDriver, opts)
+	}
+
+	d, err := display.Start(context.Background(), display.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("saucerw: headless: %w", err)
+	}
+
+	a, err := func() (*Application, error) {
+		if err := d.Setenv(); err != nil {
+			return nil, err
+		}
+		return NewApplicationWithDriver(defaultDriver, opts)
+	}()
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+
+	a.display = d
+	return a, nil
+}
+
+// NewApplicationWithDriver creates the application using drv.
+func NewApplicationWithDriver(drv Driver, opts AppOptions) (*Application, error) {
+	if opts.ID == "" {
+		return nil, errors.New("saucerw: application id is required")
+	}
+
+	if opts.RemoteDebugging != "" {
+		if err := checkRemoteDebugging(opts.RemoteDebugging); err != nil {
+			return nil, err
+		}
+		if err := setupRemoteDebugging(opts.RemoteDebugging); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Metrics != "" {
+		if err := checkLoopback("metrics", opts.Metrics); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, scheme := range []string{stashScheme, fetchScheme} {
+		if !slices.Contains(opts.Schemes, scheme) {
+			opts.Schemes = append(slices.Clip(opts.Schemes), scheme)
+		}
+	}
+
+	paths, err := resolvePaths(opts)
+	if err == nil {
+		err = migratePaths(paths, opts)
+	}
+	if err != nil {
+		log().Warn("resolving the application directories failed", "component", "saucerw", "error", err)
+	}
+
+	native, err := drv.NewApp(opts)
+	if err != nil {
+		return nil, err
+	}
+	a := &Application{
+		paths:           paths,
+		native:          native,
+		stopped:         make(chan struct{}),
+		headless:        opts.Headless,
+		remoteDebugging: opts.RemoteDebugging,
+		keepRunning:     opts.KeepRunning,
+		quitTimeout:     opts.QuitTimeout,
+		profileDir:      opts.ProfileDir,
+	}
+	if a.quitTimeout <= 0 {
+		a.quitTimeout = defaultQuitTimeout
+	}
+	if a.profileDir == "" && paths.Data != "" {
+		a.profileDir = filepath.Join(paths.Data, "profiles")
+	}
+	a.pressed.subscribe(func(fn func()) { fn() })
+	native.HandleClipboard(func() { a.clipboard.emit(struct{}{}) })
+	native.HandleColorScheme(a.scheme.emit)
+	native.HandleLocales(a.locales.emit)
+	native.HandleAccessibility(a.accessibilityChanged)
+	native.HandlePower(a.powerChanged)
+
+	if opts.Metrics != "" {
+		if err := a.serveMetrics(opts.Metrics); err != nil {
+			native.Release()
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// Run runs the event loop until the application quits and returns its exit
+// code. The start callback is invoked on the event loop thread once the loop
+// is running. Native resources of all windows are released when Run returns.
+func (a *Application) Run(start func(*Application)) int {
+	defer a.release()
+	defer close(a.stopped)
+	defer a.running.Store(false)
+
+	return a.native.Run(func() {
+		a.running.Store(true)
+
+		if start != nil {
+			start(a)
+		}
+	})
+}
+
+// Quit stops the event loop.
+func (a *Application) Quit() {
+	a.native.Quit()
+}
+
+// Post schedules fn to run on the event loop thread and returns immediately.
+func (a *Application) Post(fn func()) {
+	a.native.Post(fn)
+}
+
+// Screens lists the monitors attached to the system, see package screens
+// for finding and placing windows on them.
+func (a *Application) Screens() []Screen {
+	return a.native.Screens()
+}
+
+// NewWindow creates a new hidden window configured by opts. It returns
+// ErrNotRunning when called off the event loop thread before the event loop
+// started.
+func (a *Application) NewWindow(opts WindowOptions) (*Window, error) {
+	if err := a.checkLoop(); err != nil {
+		return nil, err
+	}
+
+	raw, err := a.native.NewWindow()
+	if err != nil {
+		return nil, err
+	}
+	native := newWindowHandle(raw)
+
+	opts.apply(native)
+
+	w := &Window{app: a, native: native, id: a.nextWindowID(), offscreen: opts.Offscreen || a.headless}
+	w.clicks.subscribe(func(c menuClick) { c.fn(c.item) })
+	w.on(WindowCloseRequested, func(WindowEvent) { go w.requestClose() })
+
+	native.HandleEvents(w.events.emit)
+	native.HandleMenu(w.activateMenu)
+
+	if opts.Kiosk {
+		w.SetKiosk(true)
+	}
+
+	a.mu.Lock()
+	a.windows = append(a.windows, w)
+	a.mu.Unlock()
+
+	if a.intercept.Load() {
+		w.interceptClose()
+	}
+
+	return w, nil
+}
+
+// Windows returns the windows of the application in the order they were
+// created, until they are destroyed.
+func (a *Application) Windows() []*Window {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return slices.Clone(a.windows)
+}
+
+// Window returns the window with the given ID, nil if it does not exist or
+// was destroyed.
+func (a *Application) Window(id uint64) *Window {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if i := slices.IndexFunc(a.windows, func(w *Window) bool { return w.id == id }); i >= 0 {
+		return a.windows[i]
+	}
+	return nil
+}
+
+// Broadcast runs code in the pages of all webviews of all windows, without
+// waiting for a result, e.g. to notify every window of a changed setting.
+func (a *Application) Broadcast(code string) {
+	for _, w := range a.Windows() {
+		for _, v := range w.Webviews() {
+			v.Execute(code)
+		}
+	}
+}
+
+// nextWindowID returns the ID of a new window.
+func (a *Application) nextWindowID() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.windowID++
+	return a.windowID
+}
+
+// forget removes w from the window registry.
+func (a *Application) forget(w *Window) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.windows = slices.DeleteFunc(a.windows, func(c *Window) bool { return c == w })
+}
+
+// release frees the native resources of all windows and the application.
+func (a *Application) release() {
+	a.mu.Lock()
+	windows := a.windows
+	a.windows = nil
+	a.mu.Unlock()
+
+	for _, w := range windows {
+		w.release()
+	}
+	a.native.Release()
+
+	if a.display != nil {
+		a.display.Close()
+	}
+	if a.metrics != nil {
+		a.metrics.Close()
+	}
+}
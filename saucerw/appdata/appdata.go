@@ -0,0 +1,81 @@
+// Package appdata keeps the durable data of an application, e.g. its
+// settings, in the directories saucerw keeps website data in, and shares it
+// with pages.
+//
+// Dir resolves the data directory of an application id, AppDir that of an
+// Application and ProfileDir that of a profile, which RemoveProfile deletes
+// along with its website data. They hold DB files, small crash-safe
+// key/value stores of JSON values:
+//
+//	dir, err := appdata.Dir("com.example.app")
+//	db, err := appdata.Open(filepath.Join(dir, "settings.db"), appdata.Options{})
+//	defer db.Close()
+//
+//	db.Set("theme", "dark")
+//
+//	var theme string
+//	ok, err := db.Get("theme", &theme)
+//
+// Expose lets the pages of a webview read and write a DB, limited to some
+// keys. Restrict the pages allowed to call its functions with the Bridge
+// rules of saucerw.SecurityPolicy:
+//
+//	appdata.Expose(view, db, appdata.ExposeOptions{Keys: []string{"ui."}})
+//
+//	await window.saucer.call("appdata.set", ["ui.sidebar", true]);
+package appdata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// Dir returns the data directory of the application id, the Data directory
+// of saucerw.DefaultPaths, creating it. It holds the directory "profiles" of
+// the website data, unless saucerw.AppOptions.ProfileDir moves it.
+func Dir(id string) (string, error) {
+	if id == "" {
+		return "", errors.New("appdata: application id is required")
+	}
+
+	paths, err := saucerw.DefaultPaths(id)
+	if err != nil {
+		return "", err
+	}
+	return mkdir(paths.Data)
+}
+
+// AppDir returns the data directory of app, the Data directory of its
+// Paths, creating it. Unlike Dir it follows saucerw.AppOptions.Paths and
+// Portable.
+func AppDir(app *saucerw.Application) (string, error) {
+	dir := app.Paths().Data
+	if dir == "" {
+		return "", errors.New("appdata: the application has no data directory")
+	}
+	return mkdir(dir)
+}
+
+// mkdir creates dir.
+func mkdir(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("appdata: %w", err)
+	}
+	return dir, nil
+}
+
+// ProfileDir returns the data directory of the profile name of app, the
+// directory "appdata" in its website data directory, creating it. Removing
+// the profile with Application.RemoveProfile removes its data too.
+func ProfileDir(app *saucerw.Application, name string) (string, error) {
+	profile, err := app.ProfilePath(name)
+	if err != nil {
+		return "", err
+	}
+
+	return mkdir(filepath.Join(profile, "appdata"))
+}
@@ -0,0 +1,302 @@
+package appdata
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrClosed is returned by the methods of a closed DB.
+var ErrClosed = errors.New("appdata: db closed")
+
+// compactAfter is the number of superseded records a DB keeps before it
+// rewrites its file, unless there are more live ones.
+const compactAfter = 1024
+
+// Options configures a DB.
+type Options struct {
+	// NoSync skips flushing the file to disk after every write, which is
+	// faster but may lose the last writes if the system crashes. A crashing
+	// application loses nothing either way.
+	NoSync bool
+}
+
+// DB is a key/value store of JSON values in a file, which every write appends
+// a record to. The file is rewritten once most of its records are
+// superseded. A write interrupted by a crash is dropped when the file is
+// opened again.
+//
+// The file must not be opened by more than one DB at a time, see
+// saucerw.EnsureSingleInstance for keeping an application to one process.
+// The methods of a DB are safe for concurrent use.
+type DB struct {
+	path string
+	opts Options
+
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string]json.RawMessage
+	// garbage is the number of records superseded by later ones.
+	garbage int
+	// compactErr is the error of the last compaction if it failed, which
+	// the next write retries.
+	compactErr error
+}
+
+// record is a line of the file.
+type record struct {
+	Key     string          `json:"k"`
+	Value   json.RawMessage `json:"v,omitempty"`
+	Deleted bool            `json:"d,omitempty"`
+}
+
+// Open opens the DB in the file at path, creating it.
+func Open(path string, opts Options) (*DB, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("appdata: %w", err)
+	}
+
+	db := &DB{path: path, opts: opts, file: file, entries: map[string]json.RawMessage{}}
+	if err := db.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// load reads the records of the file, truncating a record cut short by a
+// crash.
+func (db *DB) load() error {
+	r := bufio.NewReader(db.file)
+
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) != 0 {
+				return db.truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("appdata: %s: %w", db.path, err)
+		}
+
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			// Only the last record can be torn
+			if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+				return db.truncate(offset)
+			}
+			return fmt.Errorf("appdata: %s: corrupt record at offset %d: %w", db.path, offset, err)
+		}
+		offset += int64(len(line))
+
+		if _, ok := db.entries[rec.Key]; ok {
+			db.garbage++
+		}
+
+		if rec.Deleted {
+			delete(db.entries, rec.Key)
+			db.garbage++
+		} else {
+			db.entries[rec.Key] = rec.Value
+		}
+	}
+}
+
+// truncate drops the end of the file from offset on.
+func (db *DB) truncate(offset int64) error {
+	if err := db.file.Truncate(offset); err != nil {
+		return fmt.Errorf("appdata: %s: %w", db.path, err)
+	}
+	return nil
+}
+
+// Path returns the path of the file.
+func (db *DB) Path() string {
+	return db.path
+}
+
+// Get decodes the value of key into out, which may be nil, reporting whether
+// key is set.
+func (db *DB) Get(key string, out any) (bool, error) {
+	db.mu.Lock()
+	if db.file == nil {
+		db.mu.Unlock()
+		return false, ErrClosed
+	}
+	value, ok := db.entries[key]
+	db.mu.Unlock()
+
+	if !ok || out == nil {
+		return ok, nil
+	}
+
+	if err := json.Unmarshal(value, out); err != nil {
+		return true, fmt.Errorf("appdata: %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Keys returns the keys starting with prefix, sorted.
+func (db *DB) Keys(prefix string) []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var rtn []string
+	for key := range db.entries {
+		if strings.HasPrefix(key, prefix) {
+			rtn = append(rtn, key)
+		}
+	}
+
+	slices.Sort(rtn)
+	return rtn
+}
+
+// Set sets key to the JSON encoding of value, which is on disk once Set
+// returned.
+func (db *DB) Set(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("appdata: %s: %w", key, err)
+	}
+	return db.write(record{Key: key, Value: data})
+}
+
+// Delete deletes key, if it is set.
+func (db *DB) Delete(key string) error {
+	db.mu.Lock()
+	_, ok := db.entries[key]
+	db.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return db.write(record{Key: key, Deleted: true})
+}
+
+// write appends rec to the file and applies it.
+func (db *DB) write(rec record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("appdata: %s: %w", rec.Key, err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.file == nil {
+		return ErrClosed
+	}
+
+	if _, err := db.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("appdata: %s: %w", db.path, err)
+	}
+
+	if !db.opts.NoSync {
+		if err := db.file.Sync(); err != nil {
+			return fmt.Errorf("appdata: %s: %w", db.path, err)
+		}
+	}
+
+	if _, ok := db.entries[rec.Key]; ok {
+		db.garbage++
+	}
+
+	if rec.Deleted {
+		delete(db.entries, rec.Key)
+		db.garbage++
+	} else {
+		db.entries[rec.Key] = rec.Value
+	}
+
+	// The record is written and applied, a failed compaction only leaves
+	// the file larger and is returned by Close
+	if db.garbage > compactAfter && db.garbage > len(db.entries) {
+		db.compactErr = db.compact()
+	}
+	return nil
+}
+
+// compact replaces the file by one holding the live records only. db.mu is
+// held.
+func (db *DB) compact() error {
+	var buf bytes.Buffer
+	for _, key := range slices.Sorted(maps.Keys(db.entries)) {
+		line, _ := json.Marshal(record{Key: key, Value: db.entries[key]})
+		buf.Write(append(line, '\n'))
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+".*")
+	if err != nil {
+		return fmt.Errorf("appdata: %s: %w", db.path, err)
+	}
+
+	_, err = tmp.Write(buf.Bytes())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("appdata: %s: %w", db.path, err)
+	}
+
+	// Windows cannot replace an open file
+	db.file.Close()
+	err = os.Rename(tmp.Name(), db.path)
+	if err != nil {
+		os.Remove(tmp.Name())
+	} else {
+		db.garbage = 0
+	}
+
+	// The previous file if renaming failed
+	file, oerr := os.OpenFile(db.path, os.O_RDWR|os.O_APPEND, 0o600)
+	db.file = file
+	if oerr != nil {
+		db.file = nil
+		err = oerr
+	}
+
+	if err != nil {
+		return fmt.Errorf("appdata: %s: %w", db.path, err)
+	}
+	return nil
+}
+
+// Close closes the file. It also returns the error of the last compaction
+// of the file if it failed.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// A compaction failing to reopen the file closed it
+	if db.file == nil && db.compactErr != nil {
+		return fmt.Errorf("%w: %w", ErrClosed, db.compactErr)
+	}
+	if db.file == nil {
+		return ErrClosed
+	}
+
+	err := db.file.Close()
+	db.file = nil
+	if db.compactErr != nil {
+		return errors.Join(db.compactErr, err)
+	}
+	return err
+}
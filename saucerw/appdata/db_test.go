@@ -0,0 +1,180 @@
+package appdata
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// open opens the DB at path, failing t on errors.
+func open(t *testing.T, path string) *DB {
+	t.Helper()
+
+	db, err := Open(path, Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestDBReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db := open(t, path)
+	for key, value := range map[string]any{"theme": "dark", "size": 12, "gone": true} {
+		if err := db.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Delete("gone"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set("theme", "light"); !errors.Is(err, ErrClosed) {
+		t.Errorf("write to a closed db returned %v", err)
+	}
+
+	db = open(t, path)
+	defer db.Close()
+
+	var theme string
+	if ok, err := db.Get("theme", &theme); !ok || err != nil || theme != "dark" {
+		t.Errorf("theme %q, %v, %v", theme, ok, err)
+	}
+	if ok, _ := db.Get("gone", nil); ok {
+		t.Error("deleted key is set")
+	}
+	if keys := db.Keys(""); len(keys) != 2 || keys[0] != "size" || keys[1] != "theme" {
+		t.Errorf("keys %v", keys)
+	}
+}
+
+func TestDBTornRecord(t *testing.T) {
+	for name, torn := range map[string]string{
+		"cut short":    `{"k":"theme","v":"li`,
+		"with newline": "{\"k\":\"theme\",\"v\":\x00\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+
+			db := open(t, path)
+			if err := db.Set("theme", "dark"); err != nil {
+				t.Fatal(err)
+			}
+			db.Close()
+
+			intact, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, append(bytes.Clone(intact), torn...), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			// The torn record is dropped and the file truncated before
+			// the next record is appended
+			db = open(t, path)
+			var theme string
+			if ok, err := db.Get("theme", &theme); !ok || err != nil || theme != "dark" {
+				t.Errorf("theme %q, %v, %v", theme, ok, err)
+			}
+			if data, _ := os.ReadFile(path); !bytes.Equal(data, intact) {
+				t.Errorf("file %q after opening, expected %q", data, intact)
+			}
+			if err := db.Set("size", 12); err != nil {
+				t.Fatal(err)
+			}
+			db.Close()
+
+			db = open(t, path)
+			defer db.Close()
+			if keys := db.Keys(""); len(keys) != 2 {
+				t.Errorf("keys %v after reopening", keys)
+			}
+		})
+	}
+}
+
+func TestDBCorruptRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	data := "{\"k\":\"a\",\"v\":1}\nnot a record\n{\"k\":\"b\",\"v\":2}\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the last record may be torn, others are not dropped silently
+	if _, err := Open(path, Options{}); err == nil {
+		t.Fatal("opened a db with a corrupt record")
+	}
+}
+
+func TestDBCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db := open(t, path)
+	if err := db.Set("kept", "value"); err != nil {
+		t.Fatal(err)
+	}
+	// Superseding compactAfter+1 records compacts the file
+	for i := range compactAfter + 2 {
+		if err := db.Set("counter", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Errorf("%d records after compacting, expected 2", lines)
+	}
+
+	// The compacted file is appended to
+	if err := db.Set("after", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db = open(t, path)
+	defer db.Close()
+
+	var counter int
+	if ok, err := db.Get("counter", &counter); !ok || err != nil || counter != compactAfter+1 {
+		t.Errorf("counter %d, %v, %v, expected %d", counter, ok, err, compactAfter+1)
+	}
+	if keys := db.Keys(""); len(keys) != 3 {
+		t.Errorf("keys %v", keys)
+	}
+}
+
+func TestDBCompactFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+
+	db := open(t, path)
+	// Without the directory the compacted file cannot be created
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range compactAfter + 2 {
+		if err := db.Set("counter", i); err != nil {
+			t.Fatalf("write %d failed with the compaction: %v", i, err)
+		}
+	}
+	var counter int
+	if ok, err := db.Get("counter", &counter); !ok || err != nil || counter != compactAfter+1 {
+		t.Errorf("counter %d, %v, %v", counter, ok, err)
+	}
+
+	if err := db.Close(); err == nil {
+		t.Error("close did not report the failed compaction")
+	}
+}
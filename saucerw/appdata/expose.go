@@ -0,0 +1,109 @@
+package appdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// ExposeOptions configures Expose.
+type ExposeOptions struct {
+	// Name is the prefix of the exposed functions, "appdata" if empty.
+	Name string
+	// Keys lists the prefixes of the keys the pages may access, e.g. "ui.";
+	// "" allows all keys. At least one is required.
+	Keys []string
+	// ReadOnly keeps the pages from writing.
+	ReadOnly bool
+}
+
+// allowed reports whether the pages may access key.
+func (o *ExposeOptions) allowed(key string) bool {
+	for _, prefix := range o.Keys {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns the error of an access to key the options do not allow.
+func (o *ExposeOptions) check(key string, write bool) error {
+	switch {
+	case !o.allowed(key):
+		return &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: fmt.Errorf("appdata: key %q is not exposed", key)}
+	case write && o.ReadOnly:
+		return &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: errors.New("appdata: db is read-only")}
+	}
+	return nil
+}
+
+// Expose lets the pages of v access the keys of db opts allows, through the
+// functions "<name>.get", "<name>.set", "<name>.delete" and "<name>.keys":
+//
+//	const sidebar = await window.saucer.call("appdata.get", ["ui.sidebar"]);
+//	await window.saucer.call("appdata.set", ["ui.sidebar", !sidebar]);
+//	const keys = await window.saucer.call("appdata.keys", ["ui."]);
+//
+// get resolves to null for keys that are not set. Other keys reject with
+// saucerw.CodePermissionDenied, as do writes to a read-only db. Which pages
+// may call the functions at all is up to the Bridge rules of the
+// saucerw.SecurityPolicy of v.
+func Expose(v *saucerw.Webview, db *DB, opts ExposeOptions) error {
+	if len(opts.Keys) == 0 {
+		return errors.New("appdata: no keys exposed, set ExposeOptions.Keys")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = "appdata"
+	}
+
+	err := v.Expose(name+".get", func(key string) (json.RawMessage, error) {
+		if err := opts.check(key, false); err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		if ok, err := db.Get(key, &value); err != nil || !ok {
+			return json.RawMessage("null"), err
+		}
+		return value, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = v.Expose(name+".set", func(key string, value json.RawMessage) error {
+		if err := opts.check(key, true); err != nil {
+			return err
+		}
+		return db.Set(key, value)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = v.Expose(name+".delete", func(key string) error {
+		if err := opts.check(key, true); err != nil {
+			return err
+		}
+		return db.Delete(key)
+	})
+	if err != nil {
+		return err
+	}
+
+	return v.Expose(name+".keys", func(prefix string) []string {
+		rtn := []string{}
+		for _, key := range db.Keys(prefix) {
+			if opts.allowed(key) {
+				rtn = append(rtn, key)
+			}
+		}
+		return rtn
+	})
+}
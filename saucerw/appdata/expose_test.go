@@ -0,0 +1,151 @@
+package appdata_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/appdata"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// expose exposes db to a fake page with opts and runs test with the page.
+func expose(t *testing.T, db *appdata.DB, opts appdata.ExposeOptions, test func(page *saucertest.Webview)) {
+	t.Helper()
+
+	drv := saucertest.New()
+	app, err := saucerw.NewApplicationWithDriver(drv, saucerw.AppOptions{ID: "com.example.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.Run(func(app *saucerw.Application) {
+		win, err := app.NewWindow(saucerw.WindowOptions{})
+		if err != nil {
+			t.Error(err)
+			app.Quit()
+			return
+		}
+		view, err := saucerw.NewWebview(saucerw.WebviewOptions{Window: win})
+		if err != nil {
+			t.Error(err)
+			app.Quit()
+			return
+		}
+		if err := appdata.Expose(view, db, opts); err != nil {
+			t.Error(err)
+			app.Quit()
+			return
+		}
+
+		page := drv.App().Windows()[0].Webviews()[0]
+		go func() {
+			defer app.Quit()
+			test(page)
+		}()
+	})
+}
+
+// callCode returns the code a call rejected with, "" if it succeeded.
+func callCode(err error) string {
+	var ce *saucertest.CallError
+	if errors.As(err, &ce) {
+		return ce.Code
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func TestExpose(t *testing.T) {
+	db, err := appdata.Open(filepath.Join(t.TempDir(), "test.db"), appdata.Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.Set("ui.sidebar", true)
+	db.Set("secret.token", "hunter2")
+
+	denied := string(saucerw.CodePermissionDenied)
+	expose(t, db, appdata.ExposeOptions{Keys: []string{"ui."}}, func(page *saucertest.Webview) {
+		ctx := context.Background()
+
+		tests := []struct {
+			name   string
+			fn     string
+			params []any
+			code   string
+			result string
+		}{
+			{name: "get", fn: "appdata.get", params: []any{"ui.sidebar"}, result: "true"},
+			{name: "get unset", fn: "appdata.get", params: []any{"ui.missing"}, result: "null"},
+			{name: "set", fn: "appdata.set", params: []any{"ui.width", 300}},
+			{name: "delete", fn: "appdata.delete", params: []any{"ui.sidebar"}},
+			{name: "get unexposed", fn: "appdata.get", params: []any{"secret.token"}, code: denied},
+			{name: "set unexposed", fn: "appdata.set", params: []any{"secret.token", "x"}, code: denied},
+			{name: "delete unexposed", fn: "appdata.delete", params: []any{"secret.token"}, code: denied},
+			{name: "prefix without dot", fn: "appdata.get", params: []any{"ui"}, code: denied},
+			{name: "keys", fn: "appdata.keys", params: []any{""}, result: `["ui.width"]`},
+			{name: "keys of unexposed prefix", fn: "appdata.keys", params: []any{"secret."}, result: `[]`},
+		}
+		for _, test := range tests {
+			result, err := page.Call(ctx, test.fn, test.params...)
+			if got := callCode(err); got != test.code {
+				t.Errorf("%s: error %v, expected code %q", test.name, err, test.code)
+				continue
+			}
+			if test.result != "" && string(result) != test.result {
+				t.Errorf("%s: result %s, expected %s", test.name, result, test.result)
+			}
+		}
+	})
+
+	var width int
+	if ok, err := db.Get("ui.width", &width); !ok || err != nil || width != 300 {
+		t.Errorf("ui.width %d, %v, %v", width, ok, err)
+	}
+	if ok, _ := db.Get("ui.sidebar", nil); ok {
+		t.Error("ui.sidebar not deleted")
+	}
+	var token string
+	if _, err := db.Get("secret.token", &token); err != nil || token != "hunter2" {
+		t.Errorf("secret.token %q, %v", token, err)
+	}
+}
+
+func TestExposeReadOnly(t *testing.T) {
+	db, err := appdata.Open(filepath.Join(t.TempDir(), "test.db"), appdata.Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.Set("theme", "dark")
+
+	denied := string(saucerw.CodePermissionDenied)
+	expose(t, db, appdata.ExposeOptions{Name: "settings", Keys: []string{""}, ReadOnly: true}, func(page *saucertest.Webview) {
+		ctx := context.Background()
+
+		if result, err := page.Call(ctx, "settings.get", "theme"); err != nil || string(result) != `"dark"` {
+			t.Errorf("get returned %s, %v", result, err)
+		}
+		if _, err := page.Call(ctx, "settings.set", "theme", json.RawMessage(`"light"`)); callCode(err) != denied {
+			t.Errorf("set returned %v, expected code %q", err, denied)
+		}
+		if _, err := page.Call(ctx, "settings.delete", "theme"); callCode(err) != denied {
+			t.Errorf("delete returned %v, expected code %q", err, denied)
+		}
+	})
+
+	var theme string
+	if _, err := db.Get("theme", &theme); err != nil || theme != "dark" {
+		t.Errorf("theme %q, %v", theme, err)
+	}
+
+	if err := appdata.Expose(nil, db, appdata.ExposeOptions{}); err == nil {
+		t.Error("exposed a db without keys")
+	}
+}
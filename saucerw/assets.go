@@ -0,0 +1,228 @@
+package saucerw
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aperturerobotics/saucer/internal/mimetype"
+)
+
+// immutableCache is the Cache-Control of files that never change.
+const immutableCache = "public, max-age=31536000, immutable"
+
+// encodings are the pre-compressed variants, by file extension, in the
+// order they are preferred.
+var encodings = []struct{ ext, name string }{
+	{".br", "br"},
+	{".gz", "gzip"},
+}
+
+// AssetOptions configures NewAssetHandler.
+type AssetOptions struct {
+	// Immutable reports whether the file at path never changes under its
+	// name, e.g. a bundle whose name carries its hash. The page caches those
+	// without asking again and revalidates the others with their ETag on
+	// every request. Optional.
+	Immutable func(path string) bool
+	// Fallback is the file served for paths without an extension that match
+	// no file, e.g. "/index.html" for the client-side routes of a single page
+	// application. If empty, those are not found.
+	Fallback string
+	// Precompressed serves the variants "name.br" and "name.gz" of a file
+	// with Content-Encoding to pages accepting the encoding. They are served
+	// instead of the plain file only, never on their own.
+	Precompressed bool
+}
+
+// asset is a file served by an assetHandler.
+type asset struct {
+	mime      string
+	immutable bool
+	// variants holds the plain content first, then the pre-compressed ones
+	// in the order of encodings.
+	variants []variant
+}
+
+// variant is an encoding of an asset.
+type variant struct {
+	encoding string
+	etag     string
+	data     []byte
+}
+
+// assetHandler serves the files of a file system from memory.
+type assetHandler struct {
+	files    map[string]*asset
+	fallback string
+}
+
+// NewAssetHandler returns a handler serving every file of fsys from memory,
+// for HandleScheme and HandleStreamScheme. Unlike Embed, responses carry an
+// ETag derived from the content, a Cache-Control header and honor range
+// requests, so media can be seeked and unchanged files are not sent again.
+// "/" and paths ending in a slash serve the index.html below them.
+func NewAssetHandler(fsys fs.FS, opts AssetOptions) (http.Handler, error) {
+	contents := map[string][]byte{}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		contents["/"+name] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h := &assetHandler{files: map[string]*asset{}, fallback: opts.Fallback}
+
+	for name, data := range contents {
+		if opts.Precompressed && compressedVariant(contents, name) {
+			continue
+		}
+
+		file := &asset{
+			mime:      mimetype.Detect(name, data),
+			immutable: opts.Immutable != nil && opts.Immutable(name),
+			variants:  []variant{{etag: etag(data), data: data}},
+		}
+
+		if opts.Precompressed {
+			for _, enc := range encodings {
+				if data, ok := contents[name+enc.ext]; ok {
+					file.variants = append(file.variants, variant{encoding: enc.name, etag: etag(data), data: data})
+				}
+			}
+		}
+
+		h.files[name] = file
+	}
+
+	return h, nil
+}
+
+// ServeAssets serves the files of fsys below name://, which has to be listed
+// in AppOptions.Schemes, see NewAssetHandler. Navigate to e.g.
+// "app://frontend/index.html" to show them.
+func (v *Webview) ServeAssets(name string, fsys fs.FS, opts AssetOptions) error {
+	handler, err := NewAssetHandler(fsys, opts)
+	if err != nil {
+		return err
+	}
+
+	v.HandleStreamScheme(name, handler)
+	return nil
+}
+
+// compressedVariant reports whether name is a pre-compressed variant of
+// another file.
+func compressedVariant(contents map[string][]byte, name string) bool {
+	for _, enc := range encodings {
+		if plain, ok := strings.CutSuffix(name, enc.ext); ok {
+			if _, ok := contents[plain]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// etag returns the strong entity tag of data.
+func etag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+}
+
+func (h *assetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	file := h.lookup(r.URL.Path)
+	if file == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", file.mime)
+
+	if file.immutable {
+		header.Set("Cache-Control", immutableCache)
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+
+	body := file.variants[0]
+	if len(file.variants) > 1 {
+		header.Set("Vary", "Accept-Encoding")
+		body = file.negotiate(r.Header.Get("Accept-Encoding"))
+	}
+
+	if body.encoding != "" {
+		header.Set("Content-Encoding", body.encoding)
+	}
+	header.Set("ETag", body.etag)
+
+	// ServeContent answers conditional and range requests
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body.data))
+}
+
+// lookup returns the file served for the URL path p, nil if there is none.
+func (h *assetHandler) lookup(p string) *asset {
+	name := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") {
+		name = path.Join(name, "index.html")
+	}
+
+	if file, ok := h.files[name]; ok {
+		return file
+	}
+
+	if h.fallback != "" && path.Ext(name) == "" {
+		return h.files[path.Clean("/"+h.fallback)]
+	}
+	return nil
+}
+
+// negotiate returns the preferred variant of the file accepted by a page
+// sending the Accept-Encoding header accept.
+func (f *asset) negotiate(accept string) variant {
+	accepted := map[string]bool{}
+
+	for part := range strings.SplitSeq(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q > 0
+	}
+
+	for _, v := range f.variants[1:] {
+		if ok, listed := accepted[v.encoding]; ok || (!listed && accepted["*"]) {
+			return v
+		}
+	}
+	return f.variants[0]
+}
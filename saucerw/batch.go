@@ -0,0 +1,190 @@
+package saucerw
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// batchScript installs window.saucer.internal.post, through which the bridge
+// scripts send their messages. Messages posted in a burst are sent together as
+// one "saucer:batch" message, see BatchOptions.
+const batchScript = `
+window.saucer.internal.post = (() =>
+{
+    const size     = %d;
+    const interval = %d;
+
+    let queue = [];
+    let timer = undefined;
+
+    const flush = () =>
+    {
+        clearTimeout(timer);
+        timer = undefined;
+
+        const messages = queue;
+        queue          = [];
+
+        if (messages.length === 1)
+        {
+            window.saucer.internal.message(messages[0]);
+        }
+        else if (messages.length > 1)
+        {
+            window.saucer.internal.message('{"saucer:batch":[' + messages.join(",") + "]}");
+        }
+    };
+
+    return (message) =>
+    {
+        if (size <= 1)
+        {
+            window.saucer.internal.message(message);
+            return;
+        }
+
+        queue.push(message);
+
+        if (queue.length >= size)
+        {
+            flush();
+        }
+        else if (queue.length === 1 && interval > 0)
+        {
+            timer = setTimeout(flush, interval);
+        }
+        else if (queue.length === 1)
+        {
+            queueMicrotask(flush);
+        }
+    };
+})();
+`
+
+// defaultBatchSize is the BatchOptions.MaxSize used if it is zero.
+const defaultBatchSize = 128
+
+// BatchOptions tunes how the bridge coalesces the messages exchanged with the
+// page: the results of calls, evaluations, channel messages and console
+// output. Coalescing saves the cost of crossing to the event loop thread and
+// into the browser engine for every message, which dominates when the page
+// sends thousands of small ones.
+type BatchOptions struct {
+	// Disabled sends every message on its own, as soon as it is ready.
+	Disabled bool
+	// MaxSize is the largest number of messages sent together, 128 if zero.
+	MaxSize int
+	// Interval is how long the first message of a batch waits for others.
+	// If zero, no time is added: the page sends the messages posted by the
+	// current task once it finished, Go those which piled up while the
+	// previous batch was being delivered.
+	Interval time.Duration
+}
+
+// size returns the largest batch, 1 if batching is disabled.
+func (o BatchOptions) size() int {
+	switch {
+	case o.Disabled:
+		return 1
+	case o.MaxSize <= 0:
+		return defaultBatchSize
+	}
+	return o.MaxSize
+}
+
+// script returns the batchScript for o.
+func (o BatchOptions) script() string {
+	return fmt.Sprintf(batchScript, o.size(), o.Interval.Milliseconds())
+}
+
+// batched is a script queued by a batcher.
+type batched struct {
+	code string
+	// alone keeps code out of the scripts joined with others, e.g. because it
+	// is not ours and may not parse.
+	alone bool
+}
+
+// batcher coalesces the scripts the bridge runs in the page. A single flusher
+// runs them in order, callers never wait for the event loop thread.
+type batcher struct {
+	native WebviewDriver
+	opts   BatchOptions
+
+	mu      sync.Mutex
+	pending []batched
+	// scheduled is set while a flush is pending or running, timer is the
+	// pending one of an Interval.
+	scheduled bool
+	timer     *time.Timer
+}
+
+// execute queues code, a statement of the bridge.
+func (b *batcher) execute(code string) {
+	b.queue(batched{code: code})
+}
+
+// executeAlone queues code to be run on its own, after the scripts already
+// queued.
+func (b *batcher) executeAlone(code string) {
+	b.queue(batched{code: code, alone: true})
+}
+
+// queue adds code and schedules a flush.
+func (b *batcher) queue(code batched) {
+	if b.opts.Disabled {
+		b.native.Execute(code.code)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending = append(b.pending, code)
+
+	full := len(b.pending) >= b.opts.size()
+
+	switch {
+	case !b.scheduled && b.opts.Interval > 0 && !full:
+		b.scheduled = true
+		b.timer = time.AfterFunc(b.opts.Interval, b.flush)
+	case !b.scheduled:
+		b.scheduled = true
+		go b.flush()
+	case full && b.timer != nil && b.timer.Stop():
+		// Unless the timer fired already and its flush is about to run
+		b.timer = nil
+		go b.flush()
+	}
+}
+
+// flush runs the pending scripts until none are left.
+func (b *batcher) flush() {
+	b.mu.Lock()
+	b.timer = nil
+
+	for len(b.pending) != 0 {
+		n := 1
+		if !b.pending[0].alone {
+			for n < len(b.pending) && n < b.opts.size() && !b.pending[n].alone {
+				n++
+			}
+		}
+
+		codes := make([]string, n)
+		for i := range codes {
+			codes[i] = b.pending[i].code
+		}
+		b.pending = b.pending[n:]
+		b.mu.Unlock()
+
+		b.native.Execute(strings.Join(codes, "\n"))
+
+		b.mu.Lock()
+	}
+
+	b.scheduled = false
+	b.mu.Unlock()
+}
@@ -0,0 +1,91 @@
+package saucerw_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// batching are the options the batching benchmarks compare.
+var batching = []struct {
+	name string
+	opts saucerw.BatchOptions
+}{
+	{"batched", saucerw.BatchOptions{}},
+	{"unbatched", saucerw.BatchOptions{Disabled: true}},
+}
+
+// BenchmarkBatchResults measures calls made in parallel, whose results Go
+// sends to the page together or one at a time.
+func BenchmarkBatchResults(b *testing.B) {
+	for _, batch := range batching {
+		b.Run(batch.name, func(b *testing.B) {
+			runPage(b, saucerw.WebviewOptions{Batching: batch.opts}, func(v *saucerw.Webview) {
+				v.Expose("add", func(a, b int) int { return a + b })
+			}, func(page *saucertest.Webview) {
+				b.ReportAllocs()
+				b.SetParallelism(16)
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if _, err := page.Call(context.Background(), "add", 1, 2); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		})
+	}
+}
+
+// BenchmarkBatchMessages measures the page logging 64 console messages, posted
+// as one batch or one by one.
+func BenchmarkBatchMessages(b *testing.B) {
+	const size = 64
+
+	message, _ := json.Marshal(map[string]any{"saucer:console": true, "level": "log", "args": []string{"message"}})
+	messages := slices.Repeat([]string{string(message)}, size)
+	batch := `{"saucer:batch": [` + strings.Join(messages, ",") + `]}`
+
+	for _, batched := range []bool{true, false} {
+		name := "batched"
+		if !batched {
+			name = "unbatched"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			var logged sync.WaitGroup
+
+			runPage(b, saucerw.WebviewOptions{}, func(v *saucerw.Webview) {
+				v.OnConsoleMessage(func(saucerw.ConsoleMessage) { logged.Done() })
+			}, func(page *saucertest.Webview) {
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for range b.N {
+					logged.Add(size)
+
+					posts := messages
+					if batched {
+						posts = []string{batch}
+					}
+					for _, post := range posts {
+						if _, err := page.Post(post); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				}
+
+				// Console messages are delivered on another goroutine
+				logged.Wait()
+			})
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package saucerw_test
+
+import (
+	"testing"
+
+	"github.com/aperturerobotics/saucer/internal/bridgebench"
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// benchSize is the size in bytes of the large and binary arguments.
+const benchSize = 64 << 10
+
+// benchBridge runs the cases of bridgebench from the fake page with parallel
+// calls in flight, batched and unbatched.
+func benchBridge(b *testing.B, parallel int) {
+	for _, batch := range batching {
+		runPage(b, saucerw.WebviewOptions{Batching: batch.opts}, func(v *saucerw.Webview) {
+			if err := bridgebench.Expose(v); err != nil {
+				b.Error(err)
+			}
+		}, func(page *saucertest.Webview) {
+			for _, c := range bridgebench.Cases {
+				b.Run(c.Name()+"/"+batch.name, func(b *testing.B) {
+					if err := bridgebench.Run(b, page, c, benchSize, parallel); err != nil {
+						b.Fatal(err)
+					}
+				})
+			}
+		})
+	}
+}
+
+// BenchmarkBridgeLatency measures calls made one at a time. cmd/bridgeprof
+// runs the same calls, also from a native page.
+func BenchmarkBridgeLatency(b *testing.B) {
+	benchBridge(b, 1)
+}
+
+// BenchmarkBridgeThroughput measures calls with 64 in flight.
+func BenchmarkBridgeThroughput(b *testing.B) {
+	benchBridge(b, 64)
+}
@@ -0,0 +1,768 @@
+package saucerw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// bridgeScript installs window.saucer.call and window.saucer.exposed on top
+// of the IPC primitives saucer injects into every page. Calls go through
+// window.saucer.internal.post rather than saucer's send to be batched.
+const bridgeScript = `
+window.saucer.call = async (name, params, options) =>
+{
+    if (!Array.isArray(params))
+    {
+        throw 'Bad arguments, expected array';
+    }
+
+    if (typeof name !== 'string' && !(name instanceof String))
+    {
+        throw 'Bad name, expected string';
+    }
+
+    const signal = options?.signal;
+    signal?.throwIfAborted();
+
+    const packed  = await window.saucer.internal.pack(params);
+    const id      = ++window.saucer.internal.idc;
+    const promise = new Promise((resolve, reject) =>
+    {
+        window.saucer.internal.rpc[id] = { resolve, reject };
+    });
+
+    window.saucer.internal.post(JSON.stringify({ ["saucer:call"]: true, name, params: packed, id }));
+
+    if (!signal)
+    {
+        return promise;
+    }
+
+    const abort = () =>
+    {
+        window.saucer.internal.rpc[id]?.reject(signal.reason);
+        delete window.saucer.internal.rpc[id];
+
+        window.saucer.internal.post(JSON.stringify({ ["saucer:abort"]: true, id }));
+    };
+
+    signal.addEventListener("abort", abort, { once: true });
+
+    try
+    {
+        return await promise;
+    } finally
+    {
+        signal.removeEventListener("abort", abort);
+    }
+};
+
+window.saucer.ready = () =>
+{
+    window.saucer.internal.post(JSON.stringify({ ["saucer:ready"]: true }));
+};
+
+window.saucer.internal.quit = new Set();
+
+window.saucer.onQuit = (handler) =>
+{
+    window.saucer.internal.quit.add(handler);
+    window.saucer.internal.post(JSON.stringify({ ["saucer:quit"]: true }));
+
+    return () => window.saucer.internal.quit.delete(handler);
+};
+
+window.saucer.internal.quitRequested = async () =>
+{
+    for (const handler of [...window.saucer.internal.quit])
+    {
+        if (await handler() === false)
+        {
+            return false;
+        }
+    }
+
+    return true;
+};
+
+window.saucer.exposed = new Proxy({}, {
+    get: (_, prop) => (...args) => window.saucer.call(prop, args),
+});
+
+window.saucer.internal.error = ({ message, code, chain, data }) =>
+{
+    const error = new Error(message);
+
+    error.name  = "GoError";
+    error.code  = code;
+    error.chain = chain;
+    error.data  = data;
+
+    return error;
+};
+
+window.saucer.internal.exception = (e) =>
+{
+    if (!(e instanceof Error))
+    {
+        return String(e);
+    }
+
+    return {
+        name: e.name,
+        message: e.message,
+        code: e.code === undefined ? undefined : String(e.code),
+        stack: e.stack,
+    };
+};
+
+window.saucer.internal.resolve = async (id, fn) =>
+{
+    let value     = undefined;
+    let exception = false;
+
+    try
+    {
+        value = await fn();
+    } catch (e)
+    {
+        value     = window.saucer.internal.exception(e);
+        exception = true;
+    }
+
+    let message = undefined;
+
+    try
+    {
+        message = JSON.stringify({
+            ["saucer:resolve"]: true,
+            id,
+            exception,
+            result: value === undefined ? null : value,
+        });
+    } catch (e)
+    {
+        message = JSON.stringify({
+            ["saucer:resolve"]: true,
+            id,
+            exception: true,
+            result: window.saucer.internal.exception(e),
+        });
+    }
+
+    window.saucer.internal.post(message);
+};
+`
+
+// Scripts settling the promise of a bridge call, see saucer's webview::impl.
+// The promise is gone if the caller aborted the call.
+const (
+	resolveScript = "window.saucer.internal.rpc[%d]?.resolve(%s); delete window.saucer.internal.rpc[%d];"
+	rejectScript  = "window.saucer.internal.rpc[%d]?.reject(window.saucer.internal.error(%s)); delete window.saucer.internal.rpc[%d];"
+)
+
+var (
+	errorType   = reflect.TypeFor[error]()
+	contextType = reflect.TypeFor[context.Context]()
+)
+
+// bridgeMessage is a message posted by the bridge script: a call to an
+// exposed function, the result of an evaluation, console output, an error of
+// the page, a change of its title, favicon or theme color, the target of a
+// context menu, a channel or stream operation, a pressed shortcut, a batch of
+// these or one of them compressed. Calls relayed from a child frame carry
+// the frame, its origin and URL.
+type bridgeMessage struct {
+	Batch       []json.RawMessage `json:"saucer:batch"`
+	Deflate     string            `json:"saucer:deflate"`
+	Compression string            `json:"saucer:compression"`
+
+	Call     bool            `json:"saucer:call"`
+	Abort    bool            `json:"saucer:abort"`
+	Resolve  bool            `json:"saucer:resolve"`
+	Console  bool            `json:"saucer:console"`
+	Context  bool            `json:"saucer:context"`
+	Ready    bool            `json:"saucer:ready"`
+	Quit     bool            `json:"saucer:quit"`
+	Channel  string          `json:"saucer:channel"`
+	Shortcut string          `json:"saucer:shortcut"`
+	Stream   uint64          `json:"saucer:stream"`
+	Proxy    string          `json:"saucer:proxy"`
+	Error    json.RawMessage `json:"saucer:error"`
+	Chrome   json.RawMessage `json:"saucer:chrome"`
+	ID       uint64          `json:"id"`
+
+	Name   string            `json:"name"`
+	Params []json.RawMessage `json:"params"`
+
+	Exception bool            `json:"exception"`
+	Result    json.RawMessage `json:"result"`
+
+	Level string   `json:"level"`
+	Args  []string `json:"args"`
+
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Link      string `json:"link"`
+	Image     string `json:"image"`
+	Media     string `json:"media"`
+	Selection string `json:"selection"`
+	Editable  bool   `json:"editable"`
+
+	Frame  string `json:"frame"`
+	Origin string `json:"origin"`
+	URL    string `json:"url"`
+
+	Op     string          `json:"op"`
+	Epoch  string          `json:"epoch"`
+	Credit int             `json:"credit"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// exposed is a Go function callable from the page.
+type exposed struct {
+	fn     reflect.Value
+	ctx    bool
+	in     []reflect.Type
+	result bool
+	err    bool
+	// writer is set if fn takes a *StreamWriter, ch if it returns a channel.
+	writer bool
+	ch     bool
+
+	// raw, if set, receives the undecoded parameters instead of fn.
+	raw func(ctx context.Context, params []json.RawMessage) (any, error)
+}
+
+// newExposed validates fn and prepares it for calls from the page.
+//
+// fn may take a context.Context followed by any JSON decodable arguments and
+// return nothing, a value, an error or a value and an error. A *StreamWriter
+// after the context or a receive channel as the value streams the result.
+func newExposed(fn any) (*exposed, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, errors.New("saucerw: exposed value must be a function")
+	}
+
+	t := v.Type()
+	if t.IsVariadic() {
+		return nil, errors.New("saucerw: exposed function must not be variadic")
+	}
+
+	rtn := &exposed{fn: v}
+	for i := range t.NumIn() {
+		if i == 0 && t.In(i) == contextType {
+			rtn.ctx = true
+			continue
+		}
+		if len(rtn.in) == 0 && !rtn.writer && t.In(i) == streamWriterType {
+			rtn.writer = true
+			continue
+		}
+		rtn.in = append(rtn.in, t.In(i))
+	}
+
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		rtn.err = t.Out(0) == errorType
+		rtn.result = !rtn.err
+	case 2:
+		if t.Out(1) != errorType {
+			return nil, errors.New("saucerw: second result of exposed function must be an error")
+		}
+		rtn.result, rtn.err = true, true
+	default:
+		return nil, errors.New("saucerw: exposed function must return at most two values")
+	}
+
+	if rtn.result && t.Out(0).Kind() == reflect.Chan && t.Out(0).ChanDir()&reflect.RecvDir != 0 {
+		rtn.ch = true
+	}
+
+	if rtn.writer && rtn.result {
+		return nil, errors.New("saucerw: exposed function taking a StreamWriter must return nothing or an error")
+	}
+
+	return rtn, nil
+}
+
+// call decodes params, calls the function and returns its JSON encoded result.
+// Binary arguments uploaded to st are passed to []byte parameters directly.
+// A streamed result is registered with b, which is nil for functions called
+// over other transports.
+func (e *exposed) call(ctx context.Context, st *stash, b *bridge, params []json.RawMessage) ([]byte, error) {
+	if e.raw != nil {
+		for i, param := range params {
+			if data, ok := st.take(param); ok {
+				params[i], _ = json.Marshal(data)
+			}
+		}
+
+		result, err := e.raw(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(result)
+	}
+
+	if len(params) != len(e.in) {
+		return nil, &BridgeError{Code: CodeInvalidArgument, Err: fmt.Errorf("Bad arguments, expected %d got %d", len(e.in), len(params))}
+	}
+
+	var s *stream
+	if e.writer || e.ch {
+		if b == nil {
+			return nil, &BridgeError{Code: CodeInternal, Err: errors.New("Streamed results need a webview")}
+		}
+
+		// The page aborting the call still cancels the function until it
+		// returned the stream
+		s = b.newStream(ctx)
+		stop := context.AfterFunc(ctx, s.cancel)
+		defer stop()
+		ctx = s.ctx
+	}
+
+	args := make([]reflect.Value, 0, len(e.in)+2)
+	if e.ctx {
+		args = append(args, reflect.ValueOf(&ctx).Elem())
+	}
+	if e.writer {
+		args = append(args, reflect.ValueOf(&StreamWriter{s: s}))
+	}
+
+	for i, param := range params {
+		if data, ok := st.take(param); ok && e.in[i] == bytesType {
+			args = append(args, reflect.ValueOf(data))
+			continue
+		}
+
+		arg := reflect.New(e.in[i])
+		if err := json.Unmarshal(param, arg.Interface()); err != nil {
+			if s != nil {
+				b.dropStream(s.id)
+			}
+			return nil, &BridgeError{Code: CodeInvalidArgument, Err: fmt.Errorf("Bad argument %d: %w", i, err)}
+		}
+		args = append(args, arg.Elem())
+	}
+
+	if e.writer {
+		s.run = func() {
+			s.end(func() (err error) {
+				defer recoverError("exposed function", &err)
+
+				out := e.fn.Call(args)
+				if e.err {
+					err, _ = out[0].Interface().(error)
+				}
+				return err
+			}())
+		}
+		return s.ref(), nil
+	}
+
+	out := e.fn.Call(args)
+
+	if e.err {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			if s != nil {
+				b.dropStream(s.id)
+			}
+			return nil, err
+		}
+	}
+
+	if e.ch {
+		s.run = s.drain(out[0])
+		return s.ref(), nil
+	}
+
+	if !e.result {
+		return []byte("null"), nil
+	}
+	return json.Marshal(out[0].Interface())
+}
+
+// bridge dispatches calls from the page to exposed Go functions.
+type bridge struct {
+	native  WebviewDriver
+	batch   *batcher
+	stash   *stash
+	console func(ConsoleMessage)
+	target  func(ContextInfo)
+	ready   func()
+	quit    func()
+
+	// policy decides which pages may call the exposed functions.
+	policy SecurityPolicy
+	// shortcut handles the shortcuts intercepted by the page, see
+	// Application.RegisterShortcut.
+	shortcut func(string)
+	// compression compresses the large messages, see CompressionOptions.
+	compression compression
+	// pageError reports the errors of the page, see Webview.OnPageError.
+	pageError func(json.RawMessage)
+	// chrome reports the title, favicon and theme color of the page, see
+	// Webview.OnPageChrome.
+	chrome func(json.RawMessage)
+	// frameKey authorizes the evaluations in the child frames, see
+	// injectFrames.
+	frameKey string
+	// fetch is set once Webview.ProxyFetch was called.
+	fetch atomic.Pointer[fetchProxy]
+
+	// ctx is canceled when the webview is released.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu          sync.RWMutex
+	middleware  []func(BridgeHandler) BridgeHandler
+	functions   map[string]*exposed
+	calls       map[uint64]context.CancelFunc
+	evaluations map[uint64]chan<- bridgeMessage
+	channels    map[string]*Channel
+	streams     map[uint64]*stream
+	lastID      uint64
+}
+
+// newBridge installs the bridge script and message handler on native. Console
+// output of the page is passed to console, context menu targets to target,
+// calls of window.saucer.ready to ready and of window.saucer.onQuit to quit.
+func newBridge(native WebviewDriver, batch BatchOptions, console func(ConsoleMessage), target func(ContextInfo), ready, quit func()) *bridge {
+	b := &bridge{
+		native:      native,
+		batch:       &batcher{native: native, opts: batch},
+		stash:       newStash(),
+		console:     console,
+		target:      target,
+		ready:       ready,
+		quit:        quit,
+		functions:   map[string]*exposed{},
+		calls:       map[uint64]context.CancelFunc{},
+		evaluations: map[uint64]chan<- bridgeMessage{},
+		channels:    map[string]*Channel{},
+		streams:     map[uint64]*stream{},
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+
+	native.Inject(Script{Code: batch.script(), Time: AtCreation, Permanent: true})
+	native.Inject(Script{Code: bridgeScript, Time: AtCreation, Permanent: true})
+	native.Inject(Script{Code: stashScript, Time: AtCreation, Permanent: true})
+	native.Inject(Script{Code: consoleScript, Time: AtCreation, Permanent: true})
+	native.Inject(Script{Code: pageErrorScript, Time: AtCreation, Permanent: true})
+	native.Inject(Script{Code: pageChromeScript, Time: AtCreation, Permanent: true})
+	native.Inject(Script{Code: channelScript, Time: AtCreation, Permanent: true})
+	native.Inject(Script{Code: streamScript, Time: AtCreation, Permanent: true})
+	native.Inject(Script{Code: funcScript, Time: AtCreation, Permanent: true})
+	native.HandleMessage(b.onMessage)
+
+	return b
+}
+
+// expose registers e under name.
+func (b *bridge) expose(name string, e *exposed) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.functions[name] = e
+}
+
+// onMessage handles a message posted by the page.
+func (b *bridge) onMessage(message string) bool {
+	return b.handleMessage(message, false)
+}
+
+// handleMessage handles a message posted by the page, inflated from a
+// compressed one if inflated is set. Compressed messages cannot compress
+// messages again, which would inflate without bounds.
+func (b *bridge) handleMessage(message string, inflated bool) bool {
+	if !strings.Contains(message, `"saucer:`) {
+		return false
+	}
+
+	var msg bridgeMessage
+	if err := json.Unmarshal([]byte(message), &msg); err != nil {
+		log().Debug("ignoring malformed bridge message", "component", "saucerw", "error", err)
+		return false
+	}
+
+	switch {
+	case msg.Deflate != "" && inflated:
+		log().Debug("ignoring compressed bridge message inside a compressed one", "component", "saucerw")
+		return false
+	case msg.Deflate != "" && b.compression.opts.Enabled:
+		message, err := b.inflate(msg.Deflate)
+		if err != nil {
+			log().Debug("ignoring malformed compressed bridge message", "component", "saucerw", "error", err)
+			return false
+		}
+		b.handleMessage(message, true)
+	case msg.Compression == "deflate-raw":
+		b.compression.accepted.Store(b.compression.opts.Enabled)
+	case msg.Batch != nil:
+		for _, item := range msg.Batch {
+			b.handleMessage(string(item), inflated)
+		}
+	case msg.Call:
+		b.call(msg)
+	case msg.Abort:
+		b.abort(msg.ID)
+	case msg.Resolve:
+		b.settle(msg)
+	case msg.Console:
+		b.console(ConsoleMessage{Level: consoleLevels[msg.Level], Args: msg.Args})
+	case msg.Channel != "":
+		b.onChannel(msg)
+	case msg.Stream != 0:
+		b.onStream(msg)
+	case msg.Proxy != "":
+		b.onProxy(msg)
+	case msg.Error != nil && b.pageError != nil:
+		b.pageError(msg.Error)
+	case msg.Chrome != nil && b.chrome != nil:
+		b.chrome(msg.Chrome)
+	case msg.Shortcut != "" && b.shortcut != nil:
+		b.shortcut(msg.Shortcut)
+	case msg.Ready:
+		b.ready()
+	case msg.Quit:
+		b.quit()
+	case msg.Context:
+		b.target(ContextInfo{
+			Position:  Position{X: msg.X, Y: msg.Y},
+			LinkURL:   msg.Link,
+			ImageURL:  msg.Image,
+			MediaURL:  msg.Media,
+			Selection: msg.Selection,
+			Editable:  msg.Editable,
+		})
+	default:
+		return false
+	}
+
+	return true
+}
+
+// call runs the exposed function requested by msg through the middleware.
+func (b *bridge) call(msg bridgeMessage) {
+	if err := b.permit(msg); err != nil {
+		b.reject(msg.ID, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(b.ctx)
+
+	b.mu.Lock()
+	b.calls[msg.ID] = cancel
+	handler := BridgeHandler(b.invoke)
+	for _, mw := range slices.Backward(b.middleware) {
+		handler = mw(handler)
+	}
+	b.mu.Unlock()
+
+	// Exposed functions may block, keep them off the event loop thread.
+	go func() {
+		defer b.abort(msg.ID)
+
+		result, err := func() (result json.RawMessage, err error) {
+			defer recoverError("bridge middleware", &err)
+			return handler(ctx, &BridgeCall{Name: msg.Name, Params: msg.Params})
+		}()
+
+		// Nobody waits for the result of an aborted call or of a released
+		// webview.
+		if ctx.Err() != nil {
+			b.dropStreamOf(result)
+			return
+		}
+
+		// The frames only relay plain results
+		if s := b.streamOf(result); s != nil && msg.Frame != "" {
+			b.dropStream(s.id)
+			err = &BridgeError{Code: CodeInternal, Err: errors.New("Streamed results are not relayed to frames")}
+		}
+
+		if err != nil {
+			b.reject(msg.ID, err)
+			return
+		}
+
+		if !b.started(msg.ID, result) {
+			b.resolve(msg.ID, result)
+		}
+	}()
+}
+
+// invoke is the innermost BridgeHandler, calling the exposed function.
+func (b *bridge) invoke(ctx context.Context, call *BridgeCall) (_ json.RawMessage, err error) {
+	b.mu.RLock()
+	fn, ok := b.functions[call.Name]
+	b.mu.RUnlock()
+
+	if !ok {
+		return nil, &BridgeError{Code: CodeNotFound, Err: fmt.Errorf("No exposed function '%s'", call.Name)}
+	}
+
+	defer recoverError("exposed function "+call.Name, &err)
+	return fn.call(ctx, b.stash, b, call.Params)
+}
+
+// abort cancels the context of call id, if it is still running.
+func (b *bridge) abort(id uint64) {
+	b.mu.Lock()
+	cancel, ok := b.calls[id]
+	delete(b.calls, id)
+	b.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// close cancels all running calls and closes the channels.
+func (b *bridge) close() {
+	b.cancel()
+	b.closeChannels()
+}
+
+// settle delivers the result of an evaluation to its waiter, if any.
+func (b *bridge) settle(msg bridgeMessage) {
+	b.mu.Lock()
+	ch, ok := b.evaluations[msg.ID]
+	delete(b.evaluations, msg.ID)
+	b.mu.Unlock()
+
+	if ok {
+		ch <- msg
+	}
+}
+
+// evaluate runs expr in the page, or in its child frame if set, and returns
+// a channel receiving its result along with the id used to cancel the
+// evaluation.
+func (b *bridge) evaluate(frame, expr string) (uint64, <-chan bridgeMessage) {
+	ch := make(chan bridgeMessage, 1)
+
+	b.mu.Lock()
+	b.lastID++
+	id := b.lastID
+	b.evaluations[id] = ch
+	b.mu.Unlock()
+
+	if frame != "" {
+		args, _ := json.Marshal([]any{b.frameKey, frame, id, expr})
+		b.batch.execute(fmt.Sprintf("window.saucerFrames.eval(...%s);", args))
+		return id, ch
+	}
+
+	b.batch.executeAlone(fmt.Sprintf("window.saucer.internal.resolve(%d, async () => (%s));", id, expr))
+	return id, ch
+}
+
+// forget drops a pending evaluation.
+func (b *bridge) forget(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.evaluations, id)
+}
+
+// resolve fulfills the promise of call id with a JSON encoded value.
+func (b *bridge) resolve(id uint64, value []byte) {
+	if data, ok := b.deflate(value); ok {
+		b.batch.execute(fmt.Sprintf(inflateScript, data, id, id, id))
+		return
+	}
+	b.batch.execute(fmt.Sprintf(resolveScript, id, value, id))
+}
+
+// reject rejects the promise of call id with an Error describing err, see
+// BridgeError.
+func (b *bridge) reject(id uint64, err error) {
+	reason, _ := json.Marshal(newGoError(err))
+	b.batch.execute(fmt.Sprintf(rejectScript, id, reason, id))
+}
+
+// Expose makes fn callable from the page as window.saucer.exposed[name] and
+// through window.saucer.call(name, params). Calls resolve to the JSON encoding
+// of the result, a non-nil error rejects the promise with an Error carrying
+// its message, see BridgeError.
+//
+// fn may take any number of JSON decodable arguments and return nothing, a
+// value, an error, or a value and an error. It runs on its own goroutine.
+// Exposing a name again replaces the previous function.
+//
+// Any script running in the page can call fn, there is no world isolating
+// the bridge from the scripts the page loads: restrict the origins with
+// SecurityPolicy.Bridge and treat the arguments as untrusted input.
+//
+// If the first parameter of fn is a context.Context, it receives a context
+// canceled when the webview is released or the page aborts the call through
+// window.saucer.call(name, params, {signal}).
+//
+// Arguments and results are (de)serialized with encoding/json, so types
+// implementing json.Marshaler or json.Unmarshaler are supported and a
+// json.RawMessage argument receives the undecoded value. A []byte argument
+// accepts an ArrayBuffer, typed array or Blob, which is transferred without
+// encoding it.
+//
+// A function returning a receive channel instead of a value streams the
+// values it receives until the channel is closed, and one taking a
+// *StreamWriter after the context streams the values it sends until it
+// returns; its error, unless nil, ends the stream with an Error. The call
+// resolves to a ReadableStream right away, which the page reads with for
+// await:
+//
+//	for await (const line of await window.saucer.call("logs.tail", []))
+//	{
+//	    console.log(line);
+//	}
+//
+// The page holds up to 64 values it did not read yet, sends wait meanwhile.
+// Leaving the loop early, cancelling the stream or navigating away cancels
+// the context of the function, which has to stop sending; the context of a
+// streaming function is not canceled when the call returns.
+func (v *Webview) Expose(name string, fn any) error {
+	e, err := newExposed(fn)
+	if err != nil {
+		return err
+	}
+
+	v.bridge.expose(name, e)
+	return nil
+}
+
+// ExposeRaw is like Expose but passes the undecoded JSON arguments to fn,
+// whatever their number, along with the context of the call. The result is
+// encoded with encoding/json; return a json.RawMessage to send pre-encoded
+// JSON. Binary arguments are passed as base64 encoded JSON strings, which
+// decode into a []byte.
+func (v *Webview) ExposeRaw(name string, fn func(ctx context.Context, params []json.RawMessage) (any, error)) {
+	v.bridge.expose(name, &exposed{raw: fn})
+}
+
+// Unexpose removes the exposed function name.
+func (v *Webview) Unexpose(name string) {
+	v.bridge.mu.Lock()
+	defer v.bridge.mu.Unlock()
+
+	delete(v.bridge.functions, name)
+}
+
+// UnexposeAll removes every exposed function.
+func (v *Webview) UnexposeAll() {
+	v.bridge.mu.Lock()
+	defer v.bridge.mu.Unlock()
+
+	clear(v.bridge.functions)
+}
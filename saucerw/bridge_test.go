@@ -0,0 +1,229 @@
+package saucerw_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// runPage creates a webview with opts on the fake driver, calls setup with
+// it on the event loop and then test with its page on another goroutine,
+// quitting once test returns.
+func runPage(t testing.TB, opts saucerw.WebviewOptions, setup func(*saucerw.Webview), test func(*saucertest.Webview)) {
+	t.Helper()
+
+	drv := saucertest.New()
+	app, err := saucerw.NewApplicationWithDriver(drv, saucerw.AppOptions{ID: "com.example.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.Run(func(app *saucerw.Application) {
+		win, err := app.NewWindow(saucerw.WindowOptions{})
+		if err != nil {
+			t.Error(err)
+			app.Quit()
+			return
+		}
+
+		opts.Window = win
+		view, err := saucerw.NewWebview(opts)
+		if err != nil {
+			t.Error(err)
+			app.Quit()
+			return
+		}
+		if setup != nil {
+			setup(view)
+		}
+
+		page := drv.App().Windows()[0].Webviews()[0]
+		go func() {
+			defer app.Quit()
+			test(page)
+		}()
+	})
+}
+
+// callError returns the code err rejected the call with.
+func callError(err error) string {
+	var ce *saucertest.CallError
+	if errors.As(err, &ce) {
+		return ce.Code
+	}
+	return ""
+}
+
+func TestBridgeCall(t *testing.T) {
+	runPage(t, saucerw.WebviewOptions{}, func(v *saucerw.Webview) {
+		v.Expose("add", func(a, b int) int { return a + b })
+		v.Expose("fail", func() error {
+			return &saucerw.BridgeError{Code: saucerw.CodeInvalidArgument, Err: errors.New("no")}
+		})
+		v.Expose("wait", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}, func(page *saucertest.Webview) {
+		ctx := context.Background()
+
+		result, err := page.Call(ctx, "add", 1, 2)
+		if err != nil || string(result) != "3" {
+			t.Errorf("add = %s, %v", result, err)
+		}
+
+		if _, err := page.Call(ctx, "add", "one", 2); callError(err) != saucerw.CodeInvalidArgument {
+			t.Errorf("add with a string: %v, expected %s", err, saucerw.CodeInvalidArgument)
+		}
+		if _, err := page.Call(ctx, "fail"); callError(err) != saucerw.CodeInvalidArgument {
+			t.Errorf("fail: %v, expected %s", err, saucerw.CodeInvalidArgument)
+		}
+		if _, err := page.Call(ctx, "missing"); callError(err) != saucerw.CodeNotFound {
+			t.Errorf("missing: %v, expected %s", err, saucerw.CodeNotFound)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if _, err := page.Call(ctx, "wait"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("aborted call: %v", err)
+		}
+	})
+}
+
+func TestBridgePermit(t *testing.T) {
+	var denied []saucerw.DeniedCall
+
+	opts := saucerw.WebviewOptions{Security: saucerw.SecurityPolicy{
+		Bridge: []saucerw.BridgeRule{
+			{Origin: "app://localhost"},
+			{Origin: "https://example.com", Functions: []string{"files.*"}},
+			{Origin: "https://widget.example.com", Functions: []string{"files.list"}, Frames: true},
+		},
+		OnDenied: func(call saucerw.DeniedCall) { denied = append(denied, call) },
+	}}
+
+	runPage(t, opts, func(v *saucerw.Webview) {
+		v.Expose("files.list", func() []string { return []string{"a"} })
+		v.Expose("settings.reset", func() {})
+	}, func(page *saucertest.Webview) {
+		ctx := context.Background()
+
+		tests := []struct {
+			url, function string
+			frame         *saucerw.Frame
+			allowed       bool
+		}{
+			{"app://localhost/index.html", "settings.reset", nil, true},
+			{"https://example.com/", "files.list", nil, true},
+			{"https://example.com/", "settings.reset", nil, false},
+			{"https://EXAMPLE.com/", "files.list", nil, true},
+			{"https://example.com:8443/", "files.list", nil, false},
+			{"https://evil.example/", "files.list", nil, false},
+			{"about:blank", "files.list", nil, false},
+
+			// Frames need a rule of their own and one of the page
+			{"https://example.com/", "files.list", &saucerw.Frame{ID: "1", Origin: "https://widget.example.com", URL: "https://widget.example.com/"}, true},
+			{"https://example.com/", "files.list", &saucerw.Frame{ID: "1", Origin: "https://ads.example", URL: "https://ads.example/"}, false},
+			{"app://localhost/", "settings.reset", &saucerw.Frame{ID: "1", Origin: "https://widget.example.com", URL: "https://widget.example.com/"}, false},
+		}
+
+		for _, test := range tests {
+			page.SetURL(test.url)
+
+			var err error
+			if test.frame != nil {
+				_, err = page.CallFrame(ctx, *test.frame, test.function)
+			} else {
+				_, err = page.Call(ctx, test.function)
+			}
+
+			if test.allowed && err != nil {
+				t.Errorf("%s calling %s: %v", test.url, test.function, err)
+			}
+			if !test.allowed && callError(err) != saucerw.CodePermissionDenied {
+				t.Errorf("%s calling %s: %v, expected %s", test.url, test.function, err, saucerw.CodePermissionDenied)
+			}
+		}
+	})
+
+	if len(denied) != 6 {
+		t.Fatalf("%d denied calls reported, expected 6", len(denied))
+	}
+	if d := denied[0]; d.Origin != "https://example.com" || d.Function != "settings.reset" || d.Frame != "" {
+		t.Errorf("denied call %+v", d)
+	}
+	if d := denied[4]; d.Origin != "https://ads.example" || d.Frame != "1" {
+		t.Errorf("denied frame call %+v", d)
+	}
+}
+
+// deflate compresses message like the compression script of the page.
+func deflate(message string) string {
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	w.Write([]byte(message))
+	w.Close()
+
+	data, _ := json.Marshal(map[string]string{"saucer:deflate": base64.StdEncoding.EncodeToString(buf.Bytes())})
+	return string(data)
+}
+
+func TestBridgeCompression(t *testing.T) {
+	logged := make(chan string, 8)
+
+	opts := saucerw.WebviewOptions{Compression: saucerw.CompressionOptions{Enabled: true, MaxInflated: 1 << 10}}
+	runPage(t, opts, func(v *saucerw.Webview) {
+		v.OnConsoleMessage(func(m saucerw.ConsoleMessage) { logged <- strings.Join(m.Args, " ") })
+	}, func(page *saucertest.Webview) {
+		if _, err := page.Post(`{"saucer:compression": "deflate-raw"}`); err != nil {
+			t.Error(err)
+			return
+		}
+
+		console := func(text string) string {
+			data, _ := json.Marshal(map[string]any{"saucer:console": true, "level": "log", "args": []string{text}})
+			return string(data)
+		}
+
+		posts := []string{
+			deflate(console("compressed")),
+			deflate(`{"saucer:batch": [` + console("batched") + `]}`),
+			// Compressed twice, past the limit once inflated, and inside a
+			// compressed batch
+			deflate(deflate(console("nested"))),
+			deflate(console(strings.Repeat("x", 2<<10))),
+			deflate(`{"saucer:batch": [` + deflate(console("nested batch")) + `]}`),
+			console("done"),
+		}
+		for _, post := range posts {
+			if _, err := page.Post(post); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		// Console messages are delivered in order on another goroutine
+		var got []string
+		for len(got) == 0 || got[len(got)-1] != "done" {
+			select {
+			case text := <-logged:
+				got = append(got, text)
+			case <-time.After(5 * time.Second):
+				t.Errorf("logged %q, then nothing", got)
+				return
+			}
+		}
+		if want := []string{"compressed", "batched", "done"}; strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("logged %q, expected %q", got, want)
+		}
+	})
+}
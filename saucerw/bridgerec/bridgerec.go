@@ -0,0 +1,245 @@
+// Package bridgerec records the calls from a page to the exposed functions
+// of a webview and replays them against a fake page of package saucertest,
+// for regression tests and for reproducing bugs from traces of users.
+//
+// Recording is opt-in through the middleware of the bridge:
+//
+//	rec, err := bridgerec.Create("bridge.jsonl", bridgerec.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	defer rec.Close()
+//
+//	view.UseBridge(rec.Middleware)
+//
+// A recording is a file of JSON lines, one Record per call and one per its
+// result. Options.Redact removes what must not leave the machine of the user
+// before a record is written.
+package bridgerec
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// Direction tells the calls of the page from the results of Go.
+type Direction string
+
+const (
+	// DirectionCall is a call from the page.
+	DirectionCall Direction = "call"
+	// DirectionResult is the result Go returned to the page.
+	DirectionResult Direction = "result"
+)
+
+// Record is a message of the bridge.
+type Record struct {
+	// Offset is the time since the recording started.
+	Offset time.Duration `json:"offset"`
+	// Direction is the direction of the message.
+	Direction Direction `json:"direction"`
+	// ID pairs a call with its result. It is unique within a recording.
+	ID uint64 `json:"id"`
+	// Name is the name of the called function.
+	Name string `json:"name"`
+	// Params are the arguments of a call.
+	Params []json.RawMessage `json:"params,omitempty"`
+	// Result is the JSON encoded result of a call that succeeded.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error is the message the call was rejected with.
+	Error string `json:"error,omitempty"`
+	// Aborted is set on the result of a call the page aborted or whose
+	// webview was released, which the page never received.
+	Aborted bool `json:"aborted,omitempty"`
+}
+
+// Options configures a Recorder.
+type Options struct {
+	// Redact is called with every record before it is written and may modify
+	// it, e.g. to mask personal data in the arguments. Records it returns
+	// false for are dropped. Optional.
+	Redact func(rec *Record) bool
+}
+
+// Recorder writes the bridge messages of the webviews whose bridge it wraps.
+// It is safe for concurrent use.
+type Recorder struct {
+	opts  Options
+	start time.Time
+
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closer io.Closer
+	lastID uint64
+	err    error
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w io.Writer, opts Options) *Recorder {
+	return &Recorder{opts: opts, start: time.Now(), w: bufio.NewWriter(w)}
+}
+
+// Create returns a Recorder writing to the file path, which is truncated.
+func Create(path string, opts Options) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("bridgerec: %w", err)
+	}
+
+	r := NewRecorder(f, opts)
+	r.closer = f
+
+	return r, nil
+}
+
+// Middleware records the calls passing through it, see Webview.UseBridge.
+// Calls are recorded as the middleware sees them: added after other
+// middleware it records the calls those let through.
+func (r *Recorder) Middleware(next saucerw.BridgeHandler) saucerw.BridgeHandler {
+	return func(ctx context.Context, call *saucerw.BridgeCall) (json.RawMessage, error) {
+		r.mu.Lock()
+		r.lastID++
+		id := r.lastID
+		r.mu.Unlock()
+
+		r.write(Record{
+			Offset:    time.Since(r.start),
+			Direction: DirectionCall,
+			ID:        id,
+			Name:      call.Name,
+			Params:    call.Params,
+		})
+
+		result, err := next(ctx, call)
+
+		rec := Record{
+			Offset:    time.Since(r.start),
+			Direction: DirectionResult,
+			ID:        id,
+			Name:      call.Name,
+			Aborted:   ctx.Err() != nil,
+		}
+
+		if err != nil {
+			rec.Error = err.Error()
+		} else {
+			rec.Result = result
+		}
+
+		r.write(rec)
+		return result, err
+	}
+}
+
+// write redacts and writes rec. The first error is kept for Err and Close.
+func (r *Recorder) write(rec Record) {
+	if r.opts.Redact != nil && !r.redact(&rec) {
+		return
+	}
+
+	data, err := json.Marshal(rec)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	if err == nil {
+		_, err = r.w.Write(append(data, '\n'))
+	}
+	if err != nil {
+		r.err = fmt.Errorf("bridgerec: %w", err)
+	}
+}
+
+// redact calls Options.Redact, dropping rec if it panics.
+func (r *Recorder) redact(rec *Record) (keep bool) {
+	// Params alias the arguments of the call, which the handler still reads
+	rec.Params = append([]json.RawMessage(nil), rec.Params...)
+
+	defer func() {
+		if recover() != nil {
+			keep = false
+		}
+	}()
+
+	return r.opts.Redact(rec)
+}
+
+// Flush writes the buffered records, returning the first error of the
+// recorder.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil {
+		if err := r.w.Flush(); err != nil {
+			r.err = fmt.Errorf("bridgerec: %w", err)
+		}
+	}
+	return r.err
+}
+
+// Close flushes the recorder and closes the file of Create. Calls recorded
+// afterwards are dropped.
+func (r *Recorder) Close() error {
+	err := r.Flush()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil {
+		r.err = errors.New("bridgerec: recorder closed")
+	}
+
+	if r.closer != nil {
+		if cerr := r.closer.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("bridgerec: %w", cerr)
+		}
+		r.closer = nil
+	}
+
+	return err
+}
+
+// Read decodes the records of a recording.
+func Read(r io.Reader) ([]Record, error) {
+	var rtn []Record
+
+	dec := json.NewDecoder(r)
+	for {
+		var rec Record
+
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return rtn, nil
+		}
+		if err != nil {
+			return rtn, fmt.Errorf("bridgerec: record %d: %w", len(rtn)+1, err)
+		}
+
+		rtn = append(rtn, rec)
+	}
+}
+
+// Open reads the recording at path.
+func Open(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("bridgerec: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
@@ -0,0 +1,177 @@
+package bridgerec
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Speed scales the time between the calls, 2 replaying them twice as
+	// fast as recorded. Calls are then made concurrently like the page did.
+	// If zero, each call is made once the previous one returned.
+	Speed float64
+}
+
+// Mismatch is a replayed call whose result differs from the recorded one.
+type Mismatch struct {
+	// Call is the recorded call.
+	Call Record
+	// Want is the recorded result.
+	Want Record
+	// Result and Error are what the replayed call returned.
+	Result json.RawMessage
+	Error  string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("call %d %s: got %s %q, want %s %q", m.Call.ID, m.Call.Name, m.Result, m.Error,
+		m.Want.Result, m.Want.Error)
+}
+
+// Replay makes the recorded calls from page, the fake page of a webview
+// exposing the functions, and returns the calls whose results differ from
+// the recorded ones, ordered by call. Results are compared as JSON values; calls recorded
+// without a result or with an aborted one are made but not compared.
+// Binary arguments are passed as the references to their uploads, which the
+// recording does not hold.
+//
+// Replay returns early with ctx.Err() if ctx is done.
+func Replay(ctx context.Context, page *saucertest.Webview, records []Record, opts ReplayOptions) ([]Mismatch, error) {
+	results := map[uint64]Record{}
+	for _, rec := range records {
+		if rec.Direction == DirectionResult {
+			results[rec.ID] = rec
+		}
+	}
+
+	var (
+		mu         sync.Mutex
+		mismatches []Mismatch
+		wg         sync.WaitGroup
+		first      *time.Duration
+		started    = time.Now()
+	)
+
+	replay := func(call Record) {
+		result, err := page.Call(ctx, call.Name, params(call.Params)...)
+		if ctx.Err() != nil {
+			return
+		}
+
+		want, ok := results[call.ID]
+		if !ok || want.Aborted {
+			return
+		}
+
+		got := Mismatch{Call: call, Want: want, Result: result}
+
+		var callErr *saucertest.CallError
+		switch {
+		case errors.As(err, &callErr):
+			got.Error = callErr.Message
+		case err != nil:
+			got.Error = err.Error()
+		}
+
+		if got.Error == want.Error && equalJSON(result, want.Result) {
+			return
+		}
+
+		mu.Lock()
+		mismatches = append(mismatches, got)
+		mu.Unlock()
+	}
+
+	for _, rec := range records {
+		if rec.Direction != DirectionCall {
+			continue
+		}
+
+		if opts.Speed <= 0 {
+			replay(rec)
+		} else {
+			if first == nil {
+				first = &rec.Offset
+			}
+
+			due := time.Duration(float64(rec.Offset-*first) / opts.Speed)
+			if err := sleep(ctx, due-time.Since(started)); err != nil {
+				break
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				replay(rec)
+			}()
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	wg.Wait()
+	slices.SortFunc(mismatches, func(a, b Mismatch) int { return cmp.Compare(a.Call.ID, b.Call.ID) })
+
+	if err := ctx.Err(); err != nil {
+		return mismatches, err
+	}
+	return mismatches, nil
+}
+
+// params passes the recorded arguments to the page without encoding them
+// again.
+func params(raw []json.RawMessage) []any {
+	rtn := make([]any, len(raw))
+	for i, param := range raw {
+		rtn[i] = param
+	}
+	return rtn
+}
+
+// equalJSON reports whether a and b encode the same value, both being empty
+// or null counting as equal.
+func equalJSON(a, b json.RawMessage) bool {
+	a, b = bytes.TrimSpace(a), bytes.TrimSpace(b)
+	if len(a) == 0 {
+		a = []byte("null")
+	}
+	if len(b) == 0 {
+		b = []byte("null")
+	}
+
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,428 @@
+package saucerw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// channelBuffer is the number of messages each side of a channel buffers
+// before the other side's sends wait, the size in channelScript.
+const channelBuffer = 64
+
+// channelScript installs window.saucer.channel. Each side grants the other as
+// many messages as it has room for and grants one more for every message
+// received, so a send waits while the receiving buffer is full. The epoch of a
+// page tells its messages from those meant for the page before a reload.
+const channelScript = `
+window.saucer.internal.channels = new Map();
+
+window.saucer.internal.channel = (name, epoch, op, value) =>
+{
+    const state = window.saucer.internal.channels.get(name);
+
+    if (state?.epoch === epoch)
+    {
+        state.handle(op, value);
+    }
+};
+
+window.saucer.channel = (name) =>
+{
+    const channels = window.saucer.internal.channels;
+
+    if (channels.has(name))
+    {
+        return channels.get(name).channel;
+    }
+
+    const size      = 64;
+    const epoch     = Date.now() + "-" + Math.random();
+    const queue     = [];
+    const receivers = [];
+    const senders   = [];
+
+    let credit = 0;
+    let closed = false;
+
+    const post = (op, fields) =>
+    {
+        window.saucer.internal.post(JSON.stringify({ ["saucer:channel"]: name, op, epoch, ...fields }));
+    };
+
+    const error = () => new Error("Channel '" + name + "' closed");
+
+    const flush = () =>
+    {
+        while (credit > 0 && senders.length)
+        {
+            const { data, resolve } = senders.shift();
+
+            credit--;
+            post("send", { data });
+            resolve();
+        }
+    };
+
+    const shutdown = () =>
+    {
+        closed = true;
+        channels.delete(name);
+
+        for (const pending of [...senders.splice(0), ...receivers.splice(0)])
+        {
+            pending.reject(error());
+        }
+    };
+
+    const handle = (op, value) =>
+    {
+        switch (op)
+        {
+        case "data":
+            if (receivers.length)
+            {
+                receivers.shift().resolve(value);
+                post("credit", { credit: 1 });
+            }
+            else
+            {
+                queue.push(value);
+            }
+            break;
+        case "credit":
+            credit += value;
+            flush();
+            break;
+        case "close":
+            shutdown();
+            break;
+        }
+    };
+
+    const channel = {
+        name,
+        send: (value) => new Promise((resolve, reject) =>
+        {
+            if (closed)
+            {
+                throw error();
+            }
+
+            // Copied right away, unserializable values reject this send and later changes are not sent
+            senders.push({ data: JSON.parse(JSON.stringify(value ?? null)), resolve, reject });
+            flush();
+        }),
+        recv: () => new Promise((resolve, reject) =>
+        {
+            if (queue.length)
+            {
+                resolve(queue.shift());
+                post("credit", { credit: 1 });
+            }
+            else if (closed)
+            {
+                reject(error());
+            }
+            else
+            {
+                receivers.push({ resolve, reject });
+            }
+        }),
+        close: () =>
+        {
+            if (!closed)
+            {
+                shutdown();
+                post("close");
+            }
+        },
+        [Symbol.asyncIterator]: async function* ()
+        {
+            while (!closed || queue.length)
+            {
+                try
+                {
+                    yield await channel.recv();
+                } catch (e)
+                {
+                    if (!closed)
+                    {
+                        throw e;
+                    }
+                }
+            }
+        },
+    };
+
+    channels.set(name, { channel, epoch, handle });
+    post("open", { credit: size });
+
+    return channel;
+};
+`
+
+// ErrChannelClosed is returned by the methods of a Channel closed by either
+// side.
+var ErrChannelClosed = errors.New("saucerw: channel closed")
+
+// Channel is a named stream of JSON messages between Go and the page, in both
+// directions. The page opens it with window.saucer.channel(name), which
+// returns an object whose send(value) resolves once the message was
+// accepted, whose recv() resolves with the next message and which can be
+// iterated with for await.
+//
+// Each side buffers up to 64 messages the other side did not receive yet,
+// sends wait while the buffer of the receiving side is full. Until the page
+// opened the channel, sends from Go wait as well. Messages in flight when the
+// page navigates away are lost; the next page opening the channel continues
+// it.
+type Channel struct {
+	name   string
+	bridge *bridge
+
+	mu sync.Mutex
+	// queue are the messages received from the page and not read yet.
+	queue []json.RawMessage
+	// credit is the number of messages the page has room for.
+	credit int
+	// epoch identifies the page that opened the channel.
+	epoch  string
+	closed bool
+	// changed is closed and replaced whenever the fields above change.
+	changed chan struct{}
+}
+
+// Channel returns the channel name, creating it unless it is open. The page
+// reaches the same channel through window.saucer.channel(name).
+func (v *Webview) Channel(name string) *Channel {
+	return v.bridge.channel(name)
+}
+
+// Name returns the name of the channel.
+func (c *Channel) Name() string {
+	return c.name
+}
+
+// Send sends the JSON encoding of value to the page. It waits until the page
+// has room for the message, ctx is done or the channel is closed.
+func (c *Channel) Send(ctx context.Context, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("saucerw: channel %s: %w", c.name, err)
+	}
+
+	c.mu.Lock()
+
+	for c.credit == 0 && !c.closed {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if c.closed {
+		c.mu.Unlock()
+		return ErrChannelClosed
+	}
+
+	c.credit--
+	epoch := c.epoch
+	c.mu.Unlock()
+
+	c.post(epoch, "data", data)
+	return nil
+}
+
+// Recv decodes the next message of the page into out, which may be nil to
+// discard it or a *json.RawMessage to keep it encoded. It waits until a
+// message arrives, ctx is done or the channel is closed; the messages received
+// before the channel was closed are still returned.
+func (c *Channel) Recv(ctx context.Context, out any) error {
+	c.mu.Lock()
+
+	for len(c.queue) == 0 && !c.closed {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if len(c.queue) == 0 {
+		c.mu.Unlock()
+		return ErrChannelClosed
+	}
+
+	data := c.queue[0]
+	c.queue = c.queue[1:]
+	epoch, closed := c.epoch, c.closed
+	c.mu.Unlock()
+
+	if !closed {
+		c.post(epoch, "credit", []byte("1"))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("saucerw: channel %s: %w", c.name, err)
+	}
+	return nil
+}
+
+// Close closes the channel on both sides. Pending and later sends of either
+// side fail, receives once the messages already buffered were read. The name
+// is free for a new channel.
+func (c *Channel) Close() {
+	if !c.shutdown() {
+		return
+	}
+
+	c.mu.Lock()
+	epoch := c.epoch
+	c.mu.Unlock()
+
+	c.post(epoch, "close", []byte("null"))
+}
+
+// wait unlocks c until it changed or ctx is done, relocking it unless it
+// returns an error.
+func (c *Channel) wait(ctx context.Context) error {
+	changed := c.changed
+	c.mu.Unlock()
+
+	select {
+	case <-changed:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.bridge.ctx.Done():
+		return ErrReleased
+	}
+
+	c.mu.Lock()
+	return nil
+}
+
+// notify wakes the waiters. The caller holds the lock.
+func (c *Channel) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// shutdown marks c closed and removes it from the bridge, reporting whether it
+// was open.
+func (c *Channel) shutdown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	c.closed = true
+	c.notify()
+
+	c.bridge.mu.Lock()
+	if c.bridge.channels[c.name] == c {
+		delete(c.bridge.channels, c.name)
+	}
+	c.bridge.mu.Unlock()
+
+	return true
+}
+
+// post runs op with the JSON encoded value in the page of epoch.
+func (c *Channel) post(epoch, op string, value []byte) {
+	if epoch == "" {
+		return
+	}
+
+	name, _ := json.Marshal(c.name)
+	quoted, _ := json.Marshal(epoch)
+
+	c.bridge.batch.execute(fmt.Sprintf("window.saucer.internal.channel(%s, %s, %q, %s);", name, quoted, op, value))
+}
+
+// channel returns the open channel name, creating it if needed.
+func (b *bridge) channel(name string) *Channel {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.channels[name]; ok {
+		return c
+	}
+
+	c := &Channel{name: name, bridge: b, changed: make(chan struct{})}
+	b.channels[name] = c
+
+	return c
+}
+
+// onChannel handles a channel message posted by the page. Channels the page
+// opens before Go asks for them are created and hold its messages.
+func (b *bridge) onChannel(msg bridgeMessage) {
+	var c *Channel
+
+	if msg.Op == "open" || msg.Op == "send" {
+		c = b.channel(msg.Channel)
+	} else {
+		b.mu.RLock()
+		c = b.channels[msg.Channel]
+		b.mu.RUnlock()
+	}
+
+	if c == nil {
+		return
+	}
+
+	switch msg.Op {
+	case "open":
+		c.mu.Lock()
+		c.epoch, c.credit = msg.Epoch, msg.Credit
+		free := max(channelBuffer-len(c.queue), 0)
+		c.notify()
+		c.mu.Unlock()
+
+		c.post(msg.Epoch, "credit", []byte(fmt.Sprint(free)))
+	case "send":
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if msg.Epoch == c.epoch && !c.closed {
+			c.queue = append(c.queue, msg.Data)
+			c.notify()
+		}
+	case "credit":
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if msg.Epoch == c.epoch {
+			c.credit += msg.Credit
+			c.notify()
+		}
+	case "close":
+		c.mu.Lock()
+		current := msg.Epoch == c.epoch
+		c.mu.Unlock()
+
+		if current {
+			c.shutdown()
+		}
+	}
+}
+
+// closeChannels closes the channels of a released webview.
+func (b *bridge) closeChannels() {
+	b.mu.RLock()
+	channels := make([]*Channel, 0, len(b.channels))
+	for _, c := range b.channels {
+		channels = append(channels, c)
+	}
+	b.mu.RUnlock()
+
+	for _, c := range channels {
+		c.shutdown()
+	}
+}
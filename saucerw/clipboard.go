@@ -0,0 +1,113 @@
+package saucerw
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+)
+
+// ErrClipboardEmpty is returned when the clipboard holds no data of the
+// requested kind.
+var ErrClipboardEmpty = errors.New("saucerw: clipboard holds no such data")
+
+// Clipboard is the system clipboard. It is accessed through the toolkit
+// rather than the page, so it works while no page is focused.
+type Clipboard struct {
+	app *Application
+}
+
+// Clipboard returns the system clipboard.
+func (a *Application) Clipboard() *Clipboard {
+	return &Clipboard{app: a}
+}
+
+// ReadText returns the text on the clipboard and ErrClipboardEmpty if it
+// holds none.
+//
+// Reads wait for the event loop and, on some platforms, the application
+// owning the clipboard. They return ErrLoopThread when called from the event
+// loop thread.
+func (c *Clipboard) ReadText(ctx context.Context) (string, error) {
+	if err := c.app.checkWait(); err != nil {
+		return "", err
+	}
+
+	type result struct {
+		text string
+		ok   bool
+	}
+
+	ch := make(chan result, 1)
+	c.app.native.ReadClipboardText(func(text string, ok bool) { ch <- result{text, ok} })
+
+	select {
+	case res := <-ch:
+		if !res.ok {
+			return "", ErrClipboardEmpty
+		}
+		return res.text, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// ReadImage returns the image on the clipboard and ErrClipboardEmpty if it
+// holds none. It waits like ReadText.
+func (c *Clipboard) ReadImage(ctx context.Context) (image.Image, error) {
+	if err := c.app.checkWait(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *image.NRGBA, 1)
+	c.app.native.ReadClipboardImage(func(img *image.NRGBA) { ch <- img })
+
+	select {
+	case img := <-ch:
+		if img == nil {
+			return nil, ErrClipboardEmpty
+		}
+		return img, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WriteText replaces the clipboard contents with text.
+func (c *Clipboard) WriteText(text string) error {
+	if err := c.app.checkLoop(); err != nil {
+		return err
+	}
+
+	c.app.native.WriteClipboardText(text)
+	return nil
+}
+
+// WriteImage replaces the clipboard contents with img.
+func (c *Clipboard) WriteImage(img image.Image) error {
+	if err := c.app.checkLoop(); err != nil {
+		return err
+	}
+
+	c.app.native.WriteClipboardImage(toNRGBA(img))
+	return nil
+}
+
+// OnChange registers fn, called when the clipboard contents changed, by this
+// or any other application. Writing the clipboard may report a change even
+// if the contents stayed the same.
+func (c *Clipboard) OnChange(fn func()) *Subscription {
+	return c.app.clipboard.subscribe(func(struct{}) { fn() })
+}
+
+// toNRGBA returns img as a non-premultiplied image starting at the origin.
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	if rgba, ok := img.(*image.NRGBA); ok && bounds.Min == (image.Point{}) && rgba.Stride == 4*bounds.Dx() {
+		return rgba
+	}
+
+	rtn := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rtn, rtn.Bounds(), img, bounds.Min, draw.Src)
+	return rtn
+}
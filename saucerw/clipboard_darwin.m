@@ -0,0 +1,126 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+
+#include "native.h"
+
+void saucerw_cocoa_read_text(uintptr_t handle)
+{
+    NSString *text = [NSPasteboard.generalPasteboard stringForType:NSPasteboardTypeString];
+
+    if (!text)
+    {
+        saucerwClipboardText(handle, NULL, 0);
+        return;
+    }
+
+    const char *utf8 = text.UTF8String;
+    saucerwClipboardText(handle, (char *)utf8, strlen(utf8));
+}
+
+void saucerw_cocoa_read_image(uintptr_t handle)
+{
+    NSImage *image = [[NSImage alloc] initWithPasteboard:NSPasteboard.generalPasteboard];
+    CGImageRef cg  = image ? [image CGImageForProposedRect:NULL context:nil hints:nil] : NULL;
+
+    if (!cg)
+    {
+        saucerwClipboardImage(handle, NULL);
+        return;
+    }
+
+    const size_t w = CGImageGetWidth(cg);
+    const size_t h = CGImageGetHeight(cg);
+
+    NSMutableData *data = [NSMutableData dataWithLength:4 * w * h];
+    uint8_t *pixels     = data.mutableBytes;
+
+    // Core Graphics only draws into premultiplied bitmaps
+    CGColorSpaceRef space = CGColorSpaceCreateWithName(kCGColorSpaceSRGB);
+    CGContextRef context  = CGBitmapContextCreate(pixels, w, h, 8, 4 * w, space,
+                                                  kCGImageAlphaPremultipliedLast | kCGBitmapByteOrder32Big);
+
+    CGContextDrawImage(context, CGRectMake(0, 0, w, h), cg);
+
+    CGContextRelease(context);
+    CGColorSpaceRelease(space);
+
+    for (size_t i = 0; 4 * w * h > i; i += 4)
+    {
+        const uint8_t alpha = pixels[i + 3];
+
+        if (alpha == 0 || alpha == 255)
+        {
+            continue;
+        }
+
+        for (size_t c = 0; 3 > c; c++)
+        {
+            pixels[i + c] = (uint8_t)((pixels[i + c] * 255 + alpha / 2) / alpha);
+        }
+    }
+
+    saucerw_image rtn = {.w = (int)w, .h = (int)h, .pixels = pixels};
+    saucerwClipboardImage(handle, &rtn);
+}
+
+void saucerw_cocoa_write_text(const char *text, size_t size)
+{
+    NSString *value = [[NSString alloc] initWithBytes:text length:size encoding:NSUTF8StringEncoding];
+
+    [NSPasteboard.generalPasteboard clearContents];
+    [NSPasteboard.generalPasteboard setString:value forType:NSPasteboardTypeString];
+}
+
+void saucerw_cocoa_write_image(saucerw_image image)
+{
+    NSBitmapImageRep *rep = [[NSBitmapImageRep alloc] initWithBitmapDataPlanes:NULL
+                                                                    pixelsWide:image.w
+                                                                    pixelsHigh:image.h
+                                                                 bitsPerSample:8
+                                                               samplesPerPixel:4
+                                                                      hasAlpha:YES
+                                                                      isPlanar:NO
+                                                                colorSpaceName:NSDeviceRGBColorSpace
+                                                                  bitmapFormat:NSBitmapFormatAlphaNonpremultiplied
+                                                                   bytesPerRow:4 * image.w
+                                                                  bitsPerPixel:32];
+
+    memcpy(rep.bitmapData, image.pixels, 4 * (size_t)image.w * image.h);
+
+    NSPasteboard *pasteboard = NSPasteboard.generalPasteboard;
+
+    [pasteboard clearContents];
+    [pasteboard setData:[rep representationUsingType:NSBitmapImageFileTypePNG properties:@{}]
+                forType:NSPasteboardTypePNG];
+    [pasteboard setData:rep.TIFFRepresentation forType:NSPasteboardTypeTIFF];
+}
+
+void *saucerw_cocoa_watch_clipboard(uintptr_t handler)
+{
+    // The pasteboard posts no notifications, poll its change count instead
+    __block NSInteger count = NSPasteboard.generalPasteboard.changeCount;
+
+    NSTimer *timer = [NSTimer scheduledTimerWithTimeInterval:0.5
+                                                     repeats:YES
+                                                       block:^(NSTimer *fired) {
+                                                         const NSInteger current =
+                                                             NSPasteboard.generalPasteboard.changeCount;
+
+                                                         if (current == count)
+                                                         {
+                                                             return;
+                                                         }
+
+                                                         count = current;
+                                                         saucerwInvoke(handler);
+                                                       }];
+
+    return (__bridge_retained void *)timer;
+}
+
+void saucerw_cocoa_unwatch_clipboard(void *watcher)
+{
+    NSTimer *timer = (__bridge_transfer NSTimer *)watcher;
+    [timer invalidate];
+}
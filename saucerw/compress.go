@@ -0,0 +1,231 @@
+package saucerw
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// compressionScript compresses the messages the page sends from
+// CompressionOptions.MinSize on and adds window.saucer.internal.inflate for
+// the results Go compresses. It is formatted with the size; a page whose
+// engine cannot compress does not announce it, so nothing is compressed.
+const compressionScript = `
+(() =>
+{
+    if (typeof CompressionStream === "undefined" || typeof DecompressionStream === "undefined")
+    {
+        return;
+    }
+
+    const min     = %d;
+    const message = window.saucer.internal.message;
+
+    const pipe = (data, stream) => new Response(new Blob([data]).stream().pipeThrough(stream));
+
+    const encode = (bytes) =>
+    {
+        let binary = "";
+
+        for (let i = 0; i < bytes.length; i += 0x8000)
+        {
+            binary += String.fromCharCode(...bytes.subarray(i, i + 0x8000));
+        }
+
+        return btoa(binary);
+    };
+
+    const deflate = async (text) =>
+    {
+        const data = await pipe(text, new CompressionStream("deflate-raw")).arrayBuffer();
+        return JSON.stringify({ ["saucer:deflate"]: encode(new Uint8Array(data)) });
+    };
+
+    // Compressing takes a task, the messages posted meanwhile wait for it to
+    // keep their order
+    let tail = undefined;
+
+    window.saucer.internal.message = (text) =>
+    {
+        if (!tail && text.length < min)
+        {
+            message(text);
+            return;
+        }
+
+        const current = (tail ?? Promise.resolve()).then(async () => message(text.length < min ? text : await deflate(text)));
+
+        tail = current;
+        current.finally(() =>
+        {
+            if (tail === current)
+            {
+                tail = undefined;
+            }
+        });
+    };
+
+    window.saucer.internal.inflate = async (data) =>
+    {
+        const bytes = Uint8Array.from(atob(data), (c) => c.charCodeAt(0));
+        return JSON.parse(await pipe(bytes, new DecompressionStream("deflate-raw")).text());
+    };
+
+    message(JSON.stringify({ ["saucer:compression"]: "deflate-raw" }));
+})();
+`
+
+// inflateScript settles the promise of a bridge call with a compressed
+// result, see resolveScript.
+const inflateScript = "window.saucer.internal.inflate(\"%s\").then((value) => window.saucer.internal.rpc[%d]?.resolve(value), (error) => window.saucer.internal.rpc[%d]?.reject(error)).finally(() => delete window.saucer.internal.rpc[%d]);"
+
+const (
+	// defaultCompressionSize is the CompressionOptions.MinSize used if it
+	// is zero.
+	defaultCompressionSize = 16 << 10
+	// defaultInflateSize is the CompressionOptions.MaxInflated used if it is
+	// zero.
+	defaultInflateSize = 64 << 20
+)
+
+// errInflateTooLarge is returned by inflate for messages larger than
+// CompressionOptions.MaxInflated.
+var errInflateTooLarge = errors.New("saucerw: compressed message too large")
+
+// CompressionOptions compresses the large messages of the bridge, e.g. for
+// applications moving editor states or query results between Go and the
+// page. The messages are compressed with DEFLATE and encoded as base64, which
+// pays off for JSON but not for data that is compressed already; send that
+// with SendBytes instead.
+//
+// The page announces whether its engine can decompress when it loads, until
+// then and in engines without CompressionStream nothing is compressed.
+type CompressionOptions struct {
+	// Enabled compresses the results of exposed functions and the messages
+	// of the page from MinSize bytes on.
+	Enabled bool
+	// MinSize is the size of the smallest message compressed, 16 KiB if
+	// zero. The page measures its messages in UTF-16 code units.
+	MinSize int
+	// Level is the compress/flate level, flate.DefaultCompression if zero.
+	Level int
+	// MaxInflated is the size of the largest message the page may send
+	// compressed, once inflated, 64 MiB if zero. Larger messages are dropped
+	// before they are inflated in full.
+	MaxInflated int
+}
+
+// minSize returns the size of the smallest message compressed.
+func (o CompressionOptions) minSize() int {
+	if o.MinSize <= 0 {
+		return defaultCompressionSize
+	}
+	return o.MinSize
+}
+
+// maxInflated returns the size of the largest message inflated.
+func (o CompressionOptions) maxInflated() int {
+	if o.MaxInflated <= 0 {
+		return defaultInflateSize
+	}
+	return o.MaxInflated
+}
+
+// CompressionStats count the messages the bridge compressed in both
+// directions, see WebviewMetrics.
+type CompressionStats struct {
+	// Messages is the number of compressed messages.
+	Messages int64 `json:"messages"`
+	// Size is the bytes of the messages before and Compressed after
+	// compressing them, before encoding them as base64.
+	Size       int64 `json:"size"`
+	Compressed int64 `json:"compressed"`
+}
+
+// Ratio returns Size divided by Compressed, 0 if nothing was compressed.
+func (s CompressionStats) Ratio() float64 {
+	if s.Compressed == 0 {
+		return 0
+	}
+	return float64(s.Size) / float64(s.Compressed)
+}
+
+// compression is the compression state of a bridge.
+type compression struct {
+	opts CompressionOptions
+	// accepted is set once the page announced it decompresses.
+	accepted atomic.Bool
+
+	messages, size, compressed atomic.Int64
+}
+
+// count records a message of size bytes compressed to compressed bytes.
+func (c *compression) count(size, compressed int) {
+	c.messages.Add(1)
+	c.size.Add(int64(size))
+	c.compressed.Add(int64(compressed))
+}
+
+// stats returns the counts.
+func (c *compression) stats() CompressionStats {
+	return CompressionStats{Messages: c.messages.Load(), Size: c.size.Load(), Compressed: c.compressed.Load()}
+}
+
+// compress installs the compression of opts, if enabled.
+func (b *bridge) compress(opts CompressionOptions) {
+	b.compression.opts = opts
+
+	if opts.Enabled {
+		b.native.Inject(Script{Code: fmt.Sprintf(compressionScript, opts.minSize()), Time: AtCreation, Permanent: true})
+	}
+}
+
+// deflate returns value compressed and encoded as base64, if the page accepts
+// it and it is large enough to be worth it.
+func (b *bridge) deflate(value []byte) (string, bool) {
+	c := &b.compression
+	if !c.opts.Enabled || !c.accepted.Load() || len(value) < c.opts.minSize() {
+		return "", false
+	}
+
+	level := c.opts.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return "", false
+	}
+	w.Write(value)
+	w.Close()
+
+	c.count(len(value), buf.Len())
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), true
+}
+
+// inflate decodes a message the page compressed, failing with
+// errInflateTooLarge past CompressionOptions.MaxInflated.
+func (b *bridge) inflate(data string) (string, error) {
+	compressed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+
+	limit := b.compression.opts.maxInflated()
+	message, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), int64(limit)+1))
+	if err != nil {
+		return "", err
+	}
+	if len(message) > limit {
+		return "", errInflateTooLarge
+	}
+
+	b.compression.count(len(message), len(compressed))
+	return string(message), nil
+}
@@ -0,0 +1,36 @@
+package saucerw
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// deflated returns s compressed and encoded like the messages of the page.
+func deflated(t testing.TB, s string) string {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(s))
+	w.Close()
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestInflateLimit(t *testing.T) {
+	b := &bridge{}
+	b.compression.opts = CompressionOptions{Enabled: true, MaxInflated: 1 << 10}
+
+	message, err := b.inflate(deflated(t, strings.Repeat("a", 1<<10)))
+	if err != nil || len(message) != 1<<10 {
+		t.Fatalf("message at the limit: %d bytes, %v", len(message), err)
+	}
+
+	if _, err := b.inflate(deflated(t, strings.Repeat("a", 1<<10+1))); !errors.Is(err, errInflateTooLarge) {
+		t.Fatalf("message past the limit: %v, expected errInflateTooLarge", err)
+	}
+}
@@ -0,0 +1,416 @@
+// Package config loads the options of an application, its window and its
+// webview from a file, so that packagers and users can change the window
+// size, the developer tools, the proxy or the backend without recompiling:
+//
+//	cfg, err := config.Load("app.toml", config.Options{Defaults: &defaults})
+//	app, err := cfg.NewApplication()
+//	...
+//	win, err := cfg.NewWindow(app)
+//	view, err := cfg.NewWebview(win)
+//
+// The file is TOML, JSON or YAML, picked by its extension:
+//
+//	[app]
+//	id          = "com.example.app"
+//	quitTimeout = "5s"
+//
+//	[window]
+//	title = "Example"
+//	size  = { w = 1280, h = 800 }
+//
+//	[webview]
+//	devTools = true
+//	proxy    = { url = "socks5://127.0.0.1:1080" }
+//
+//	[backend]
+//	driver = "remote"
+//
+// Keys are the names of the fields of the options in lowerCamelCase, see
+// Document for all of them. Keys the file leaves out keep their defaults,
+// unknown keys and values of the wrong type are errors. Only the subsets of
+// TOML and YAML used by configuration files are supported: tables, inline
+// tables and arrays in TOML, block and flow mappings and sequences in YAML,
+// no multi-line strings, dates, anchors or multiple documents.
+//
+// Environment variables override the file: the key in upper snake case
+// below Options.EnvPrefix, e.g. SAUCERW_WINDOW_SIZE_W=1280 or
+// SAUCERW_WEBVIEW_LOCALES=de-DE,en-US, lists separated by commas.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/remote"
+)
+
+// FileEnv is the environment variable naming the file Load reads instead of
+// its path.
+const FileEnv = "SAUCERW_CONFIG"
+
+// DefaultEnvPrefix is the prefix of the environment variables overriding the
+// keys if Options.EnvPrefix is empty.
+const DefaultEnvPrefix = "SAUCERW"
+
+// Format is the syntax of a configuration file.
+type Format string
+
+const (
+	TOML Format = "toml"
+	JSON Format = "json"
+	YAML Format = "yaml"
+)
+
+// The drivers of Backend.
+const (
+	// DriverNative runs the webviews in the process, with the backend the
+	// application was built with.
+	DriverNative = "native"
+	// DriverRemote runs them in a host process, see package remote.
+	DriverRemote = "remote"
+)
+
+// Config is the configuration of an application with one main window.
+type Config struct {
+	App     saucerw.AppOptions
+	Window  Window
+	Webview Webview
+	Backend Backend
+}
+
+// Window configures the main window.
+type Window struct {
+	saucerw.WindowOptions
+	// Title is the title of the window, that of the page if empty.
+	Title string
+	// Size is the size of the content of the window, the default of the
+	// backend if zero.
+	Size saucerw.Size
+	// Position places the window if non-nil, the system does otherwise.
+	Position *saucerw.Position
+	// Resizable lets the user resize the window.
+	Resizable bool
+	// Maximized and Fullscreen are the state the window is shown in.
+	Maximized  bool
+	Fullscreen bool
+}
+
+// Webview configures the webview of the main window.
+type Webview struct {
+	saucerw.Preferences
+	// URL is the page loaded once the webview is created, if non-empty.
+	URL string
+	// DisableAttributes is saucerw.WebviewOptions.DisableAttributes.
+	DisableAttributes bool
+	// Proxy and AllowedHosts are those of saucerw.NetworkOptions.
+	Proxy        *saucerw.Proxy
+	AllowedHosts []string
+}
+
+// Backend selects where the native side of the application runs. The
+// browser engine is picked when building, a remote host binary built with
+// another backend selects it at runtime.
+type Backend struct {
+	// Driver is DriverNative or DriverRemote.
+	Driver string
+	// Host and HostArgs are the command and arguments of the host process
+	// of DriverRemote, remote.HostCommand if Host is empty.
+	Host     string
+	HostArgs []string
+}
+
+// Default returns the configuration keys default to unless
+// Options.Defaults is set.
+func Default() Config {
+	return Config{
+		Window:  Window{Size: saucerw.Size{W: 800, H: 600}, Resizable: true},
+		Backend: Backend{Driver: DriverNative},
+	}
+}
+
+// Options configures Load and Parse.
+type Options struct {
+	// Defaults are the values of the keys neither the file nor the
+	// environment set, Default() if nil.
+	Defaults *Config
+	// Format is the syntax of the file, chosen by the extension of the path
+	// if empty: .toml, .json, .yaml or .yml.
+	Format Format
+	// EnvPrefix is the prefix of the environment variables overriding the
+	// keys, DefaultEnvPrefix if empty.
+	EnvPrefix string
+	// IgnoreEnv turns off the environment overrides, FileEnv included.
+	IgnoreEnv bool
+}
+
+// Load reads the configuration file at path, or the one FileEnv names, and
+// applies the environment overrides and validates the result. An empty path
+// without FileEnv reads no file, the result is Options.Defaults with the
+// overrides applied; as app.id is required, the defaults or SAUCERW_APP_ID
+// have to set it, Default() alone fails to validate.
+func Load(path string, opts Options) (*Config, error) {
+	if file := os.Getenv(FileEnv); file != "" && !opts.IgnoreEnv {
+		path = file
+	}
+
+	var data []byte
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	}
+
+	if opts.Format == "" && path != "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".toml":
+			opts.Format = TOML
+		case ".json":
+			opts.Format = JSON
+		case ".yaml", ".yml":
+			opts.Format = YAML
+		default:
+			return nil, fmt.Errorf("config: %s: unknown format, set Options.Format", path)
+		}
+	}
+
+	cfg, err := Parse(data, opts)
+	if err != nil && path != "" {
+		return nil, fmt.Errorf("%w in %s", err, path)
+	}
+	return cfg, err
+}
+
+// Parse is Load for the contents of a file, in Options.Format.
+func Parse(data []byte, opts Options) (*Config, error) {
+	cfg := Default()
+	if opts.Defaults != nil {
+		cfg = clone(*opts.Defaults)
+	}
+
+	var doc map[string]any
+	var err error
+
+	switch opts.Format {
+	case TOML:
+		doc, err = parseTOML(data)
+	case JSON:
+		doc, err = parseJSON(data)
+	case YAML:
+		doc, err = parseYAML(data)
+	case "":
+		if len(data) > 0 {
+			return nil, errors.New("config: Options.Format is required")
+		}
+	default:
+		return nil, fmt.Errorf("config: unknown format %q", opts.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := decode("", reflect.ValueOf(&cfg).Elem(), doc); err != nil {
+		return nil, err
+	}
+
+	if !opts.IgnoreEnv {
+		prefix := opts.EnvPrefix
+		if prefix == "" {
+			prefix = DefaultEnvPrefix
+		}
+		if err := applyEnv(reflect.ValueOf(&cfg).Elem(), prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate reports the values of c saucerw would reject, naming their keys.
+// The remaining options are checked when the application, window and
+// webview are created.
+func (c *Config) Validate() error {
+	if c.App.ID == "" {
+		return errors.New("config: app.id is required")
+	}
+	if c.App.QuitTimeout < 0 {
+		return errors.New("config: app.quitTimeout cannot be negative")
+	}
+
+	addrs := []struct{ key, addr string }{
+		{"app.remoteDebugging", c.App.RemoteDebugging},
+		{"app.metrics", c.App.Metrics},
+	}
+	for _, a := range addrs {
+		if a.addr == "" {
+			continue
+		}
+		if host, _, err := net.SplitHostPort(a.addr); err != nil {
+			return fmt.Errorf("config: %s: %w", a.key, err)
+		} else if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("config: %s: %s is not a loopback address", a.key, a.addr)
+		}
+	}
+
+	sizes := []struct {
+		key  string
+		size saucerw.Size
+	}{
+		{"window.size", c.Window.Size},
+		{"window.minSize", c.Window.MinSize},
+		{"window.maxSize", c.Window.MaxSize},
+	}
+	for _, s := range sizes {
+		if s.size.W < 0 || s.size.H < 0 {
+			return fmt.Errorf("config: %s cannot be negative", s.key)
+		}
+	}
+	if limit := c.Window.MaxSize; limit.W > 0 && c.Window.MinSize.W > limit.W || limit.H > 0 && c.Window.MinSize.H > limit.H {
+		return errors.New("config: window.minSize exceeds window.maxSize")
+	}
+
+	if c.Webview.URL != "" {
+		if u, err := url.Parse(c.Webview.URL); err != nil {
+			return fmt.Errorf("config: webview.url: %w", err)
+		} else if u.Scheme == "" {
+			return fmt.Errorf("config: webview.url: %s is not absolute", c.Webview.URL)
+		}
+	}
+
+	if proxy := c.Webview.Proxy; proxy != nil {
+		u, err := url.Parse(proxy.URL)
+		if err != nil {
+			return fmt.Errorf("config: webview.proxy.url: %w", err)
+		}
+		if !slices.Contains([]string{"http", "https", "socks4", "socks5"}, u.Scheme) || u.Host == "" {
+			return fmt.Errorf("config: webview.proxy.url: %q is not a http, https, socks4 or socks5 server", proxy.URL)
+		}
+	}
+
+	switch c.Backend.Driver {
+	case DriverNative:
+		if c.Backend.Host != "" || len(c.Backend.HostArgs) > 0 {
+			return errors.New("config: backend.host requires the remote driver")
+		}
+	case DriverRemote:
+	default:
+		return fmt.Errorf("config: backend.driver: unknown driver %q, expected %q or %q", c.Backend.Driver, DriverNative, DriverRemote)
+	}
+	return nil
+}
+
+// NewApplication creates the application with the options of c.App on the
+// driver of c.Backend. The remote driver does not start a virtual display
+// for Headless applications.
+func (c *Config) NewApplication() (*saucerw.Application, error) {
+	if c.Backend.Driver != DriverRemote {
+		return saucerw.NewApplication(c.App)
+	}
+
+	drv := remote.New(remote.Options{Command: c.Backend.Host, Args: c.Backend.HostArgs})
+	return saucerw.NewApplicationWithDriver(drv, c.App)
+}
+
+// NewWindow creates the main window of app and applies the title, size and
+// state of c.Window. It has to be called from the event loop like
+// Application.NewWindow.
+func (c *Config) NewWindow(app *saucerw.Application) (*saucerw.Window, error) {
+	w, err := app.NewWindow(c.Window.WindowOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Window.Title != "" {
+		w.SetTitle(c.Window.Title)
+	}
+	if c.Window.Size != (saucerw.Size{}) {
+		w.SetSize(c.Window.Size)
+	}
+	if c.Window.Position != nil {
+		w.SetPosition(*c.Window.Position)
+	}
+	w.SetResizable(c.Window.Resizable)
+	if c.Window.Maximized {
+		w.SetMaximized(true)
+	}
+	if c.Window.Fullscreen {
+		w.SetFullscreen(true)
+	}
+	return w, nil
+}
+
+// WebviewOptions returns the options of a webview in window configured by
+// c.Webview, for setting the options the file cannot hold before calling
+// saucerw.NewWebview.
+func (c *Config) WebviewOptions(window *saucerw.Window) saucerw.WebviewOptions {
+	rtn := saucerw.WebviewOptions{
+		Window:            window,
+		DisableAttributes: c.Webview.DisableAttributes,
+		Preferences:       c.Webview.Preferences,
+	}
+
+	rtn.Network.AllowedHosts = slices.Clone(c.Webview.AllowedHosts)
+	if c.Webview.Proxy != nil {
+		proxy := *c.Webview.Proxy
+		rtn.Network.Proxy = &proxy
+	}
+	return rtn
+}
+
+// NewWebview creates the webview of window with WebviewOptions and loads
+// c.Webview.URL.
+func (c *Config) NewWebview(window *saucerw.Window) (*saucerw.Webview, error) {
+	opts := c.WebviewOptions(window)
+
+	v, err := saucerw.NewWebview(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Webview.URL != "" {
+		v.Navigate(c.Webview.URL)
+	}
+	return v, nil
+}
+
+// clone returns a copy of cfg sharing no slices or pointers with it, so that
+// decoding does not change the defaults of the caller.
+func clone(cfg Config) Config {
+	v := reflect.ValueOf(&cfg).Elem()
+	deepCopy(v)
+	return cfg
+}
+
+// deepCopy replaces the slices and pointers below v with copies.
+func deepCopy(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				deepCopy(v.Field(i))
+			}
+		}
+	case reflect.Pointer:
+		if !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+			elem := reflect.New(v.Elem().Type())
+			elem.Elem().Set(v.Elem())
+			deepCopy(elem.Elem())
+			v.Set(elem)
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			v.Set(reflect.AppendSlice(reflect.MakeSlice(v.Type(), 0, v.Len()), v))
+		}
+	}
+}
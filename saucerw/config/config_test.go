@@ -0,0 +1,235 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// documents are the same configuration in every format, with nested and
+// inline tables, lists, durations, enumerations and a table behind a
+// pointer.
+var documents = map[Format]string{
+	TOML: `
+[app]
+id          = "com.example.app"
+quitTimeout = "5s"
+
+[window]
+title     = "Example"
+size      = { w = 1280, h = 800 }
+resizable = false
+
+[window.minSize]
+w = 400
+h = 300
+
+[webview]
+devTools     = true
+autoplay     = "muted"
+locales      = ["de-DE", "en-US"]
+allowedHosts = [
+	"example.com",
+	"*.example.org", # comment
+]
+proxy = { url = "socks5://127.0.0.1:1080", bypass = ["localhost"] }
+`,
+	JSON: `{
+	"app": {"id": "com.example.app", "quitTimeout": "5s"},
+	"window": {
+		"title": "Example",
+		"size": {"w": 1280, "h": 800},
+		"resizable": false,
+		"minSize": {"w": 400, "h": 300}
+	},
+	"webview": {
+		"devTools": true,
+		"autoplay": "muted",
+		"locales": ["de-DE", "en-US"],
+		"allowedHosts": ["example.com", "*.example.org"],
+		"proxy": {"url": "socks5://127.0.0.1:1080", "bypass": ["localhost"]}
+	}
+}`,
+	YAML: `
+app:
+  id: com.example.app
+  quitTimeout: 5s
+
+window:
+  title: "Example"
+  size: {w: 1280, h: 800}
+  resizable: false
+  minSize:
+    w: 400
+    h: 300
+
+webview:
+  devTools: true
+  autoplay: muted
+  locales: [de-DE, en-US]
+  allowedHosts:
+  - example.com
+  - '*.example.org' # comment
+  proxy:
+    url: socks5://127.0.0.1:1080
+    bypass: [localhost]
+`,
+}
+
+// expected is the configuration of documents.
+func expected() *Config {
+	cfg := Default()
+	cfg.App.ID = "com.example.app"
+	cfg.App.QuitTimeout = 5 * time.Second
+	cfg.Window.Title = "Example"
+	cfg.Window.Size = saucerw.Size{W: 1280, H: 800}
+	cfg.Window.Resizable = false
+	cfg.Window.MinSize = saucerw.Size{W: 400, H: 300}
+	cfg.Webview.DevTools = true
+	cfg.Webview.Autoplay = saucerw.AutoplayMuted
+	cfg.Webview.Locales = []string{"de-DE", "en-US"}
+	cfg.Webview.AllowedHosts = []string{"example.com", "*.example.org"}
+	cfg.Webview.Proxy = &saucerw.Proxy{URL: "socks5://127.0.0.1:1080", Bypass: []string{"localhost"}}
+	return &cfg
+}
+
+func TestParse(t *testing.T) {
+	for format, doc := range documents {
+		t.Run(string(format), func(t *testing.T) {
+			cfg, err := Parse([]byte(doc), Options{Format: format, IgnoreEnv: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := expected(); !reflect.DeepEqual(cfg, want) {
+				t.Errorf("got %+v\nexpected %+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		doc    string
+		err    string
+	}{
+		{"toml syntax", TOML, "[app\nid = \"a\"", "line 1: expected ']'"},
+		{"toml unquoted string", TOML, "[app]\nid = com.example", "strings have to be quoted"},
+		{"toml unterminated inline table", TOML, "[window]\nsize = { w = 1", "expected ',' or '}'"},
+		{"toml table twice", TOML, "[app]\nid = \"a\"\n[app]\nid = \"b\"", "table app defined twice"},
+		{"toml type mismatch", TOML, "[app]\nid = \"a\"\n[window]\nsize = { w = \"wide\" }", "window.size.w: expected an integer"},
+		{"toml table mismatch", TOML, "app = 1", "app: expected a table"},
+		{"toml unknown key", TOML, "[app]\nid = \"a\"\nname = \"b\"", "app.name: unknown key"},
+
+		{"json syntax", JSON, `{"app": {"id": "a"`, "unexpected EOF"},
+		{"json trailing data", JSON, `{"app": {"id": "a"}} {}`, "data after the JSON object"},
+		{"json type mismatch", JSON, `{"app": {"id": "a"}, "window": {"resizable": "yes"}}`, "window.resizable: expected true or false"},
+		{"json list mismatch", JSON, `{"app": {"id": "a"}, "webview": {"locales": "de-DE"}}`, "webview.locales: expected a list"},
+		{"json unknown key", JSON, `{"app": {"id": "a"}, "windows": {}}`, "windows: unknown key"},
+
+		{"yaml tabs", YAML, "app:\n\tid: a", "tabs cannot indent"},
+		{"yaml indentation", YAML, "app:\n    id: a\n  name: b", "bad indentation"},
+		{"yaml unterminated flow", YAML, "window:\n  size: {w: 1", "expected ',' or '}'"},
+		{"yaml anchors", YAML, "app: &app\n  id: a", "anchors, aliases and tags are not supported"},
+		{"yaml type mismatch", YAML, "app:\n  id: a\nwindow:\n  size:\n    w: 12.5", "window.size.w: expected an integer"},
+		{"yaml duration mismatch", YAML, "app:\n  id: a\n  quitTimeout: soon", "app.quitTimeout"},
+		{"yaml enum mismatch", YAML, "app:\n  id: a\nwebview:\n  autoplay: always", "webview.autoplay: expected one of default, allow, muted, block"},
+
+		{"missing id", TOML, "[window]\ntitle = \"a\"", "app.id is required"},
+		{"missing format", "", "app = 1", "Options.Format is required"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.doc), Options{Format: test.format, IgnoreEnv: true})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("error %v, expected %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	t.Setenv("SAUCERW_WINDOW_SIZE_W", "1920")
+	t.Setenv("SAUCERW_WEBVIEW_LOCALES", "fr-FR, en-GB")
+
+	cfg, err := Parse([]byte(documents[TOML]), Options{Format: TOML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Window.Size.W != 1920 || !reflect.DeepEqual(cfg.Webview.Locales, []string{"fr-FR", "en-GB"}) {
+		t.Errorf("environment not applied: %+v, %v", cfg.Window.Size, cfg.Webview.Locales)
+	}
+
+	t.Setenv("SAUCERW_WINDOW_RESIZABLE", "sometimes")
+	if _, err := Parse([]byte(documents[TOML]), Options{Format: TOML}); err == nil || !strings.Contains(err.Error(), "from SAUCERW_WINDOW_RESIZABLE") {
+		t.Fatalf("error %v, expected the variable", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for format, doc := range documents {
+		path := filepath.Join(dir, "app."+string(format))
+		if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := Load(path, Options{IgnoreEnv: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := expected(); !reflect.DeepEqual(cfg, want) {
+			t.Errorf("%s: got %+v\nexpected %+v", path, cfg, want)
+		}
+	}
+
+	if _, err := Load(filepath.Join(dir, "app.ini"), Options{IgnoreEnv: true}); err == nil {
+		t.Error("loaded a file of unknown format")
+	}
+}
+
+// TestLoadWithoutFile checks that without a file the defaults and the
+// environment have to name the application.
+func TestLoadWithoutFile(t *testing.T) {
+	t.Setenv(FileEnv, "")
+
+	if _, err := Load("", Options{}); err == nil || !strings.Contains(err.Error(), "app.id is required") {
+		t.Fatalf("error %v, expected app.id to be required", err)
+	}
+
+	defaults := Default()
+	defaults.App.ID = "com.example.app"
+	cfg, err := Load("", Options{Defaults: &defaults})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*cfg, defaults) {
+		t.Errorf("got %+v, expected the defaults", cfg)
+	}
+
+	t.Setenv("SAUCERW_APP_ID", "com.example.env")
+	if cfg, err := Load("", Options{}); err != nil || cfg.App.ID != "com.example.env" {
+		t.Fatalf("id from the environment: %v, %v", cfg, err)
+	}
+}
+
+// TestParseDefaults checks that decoding leaves the defaults of the caller
+// alone.
+func TestParseDefaults(t *testing.T) {
+	defaults := Default()
+	defaults.Webview.Locales = []string{"en-US"}
+
+	if _, err := Parse([]byte(documents[JSON]), Options{Format: JSON, Defaults: &defaults, IgnoreEnv: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(defaults.Webview.Locales, []string{"en-US"}) || defaults.App.ID != "" {
+		t.Errorf("defaults changed: %+v", defaults)
+	}
+}
@@ -0,0 +1,367 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// scalar is an unquoted value of YAML or the environment, whose type follows
+// the field it is decoded into.
+type scalar string
+
+// enums are the names of the values of the enumerations among the options.
+var enums = map[reflect.Type][]string{
+	reflect.TypeFor[saucerw.AutoplayPolicy](): {"default", "allow", "muted", "block"},
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// field is a key of a struct, declared in owner.
+type field struct {
+	key   string
+	index []int
+	sf    reflect.StructField
+	owner reflect.Type
+}
+
+// fields returns the keys of the struct type t, those of embedded structs
+// included. Fields of types a file cannot hold, functions and interfaces,
+// are left out.
+func fields(t reflect.Type) []field {
+	var rtn []field
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			for _, f := range fields(sf.Type) {
+				f.index = append([]int{i}, f.index...)
+				rtn = append(rtn, f)
+			}
+			continue
+		}
+
+		if !supported(sf.Type) {
+			continue
+		}
+		rtn = append(rtn, field{key: keyName(sf.Name), index: []int{i}, sf: sf, owner: t})
+	}
+	return rtn
+}
+
+// supported reports whether values of t can be decoded.
+func supported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && supported(t.Elem())
+	case reflect.Struct:
+		return true
+	case reflect.Pointer:
+		return t.Elem().Kind() == reflect.Struct
+	}
+	return false
+}
+
+// keyName returns the key of the field name, in lowerCamelCase: "ID" is
+// "id", "UserAgent" "userAgent" and "URLPath" "urlPath".
+func keyName(name string) string {
+	runes := []rune(name)
+
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) {
+		n--
+	}
+
+	for i := range n {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// envName returns the environment variable of key below prefix, e.g.
+// "SAUCERW_WEBVIEW_DEV_TOOLS" for "webview.devTools".
+func envName(prefix, key string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+
+	for _, part := range strings.Split(key, ".") {
+		b.WriteByte('_')
+		runes := []rune(part)
+		for i, r := range runes {
+			// Words start at an upper case letter after a lower case one or
+			// at the last of an acronym, "IDs" being a word
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !(runes[i+1] == 's' && i+2 == len(runes))
+			if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || next) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// join returns the key of name below path.
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// decode stores raw, a value of a parsed file, in v. An explicit null
+// resets v to its zero value.
+func decode(path string, v reflect.Value, raw any) error {
+	if raw == nil {
+		if path != "" {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	t := v.Type()
+
+	if names, ok := enums[t]; ok {
+		s, ok := text(raw)
+		i := slices.Index(names, s)
+		if !ok || i < 0 {
+			return fmt.Errorf("config: %s: expected one of %s", path, strings.Join(names, ", "))
+		}
+		v.SetUint(uint64(i))
+		return nil
+	}
+
+	if t == durationType {
+		s, ok := text(raw)
+		if !ok {
+			return fmt.Errorf("config: %s: expected a duration such as \"10s\"", path)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return decode(path, v.Elem(), raw)
+
+	case reflect.Struct:
+		table, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("config: %s: expected a table", label(path))
+		}
+
+		known := map[string]field{}
+		for _, f := range fields(t) {
+			known[f.key] = f
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(table)) {
+			f, ok := known[key]
+			if !ok {
+				return fmt.Errorf("config: %s: unknown key", join(path, key))
+			}
+			if err := decode(join(path, key), v.FieldByIndex(f.index), table[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice:
+		var items []any
+		switch raw := raw.(type) {
+		case []any:
+			items = raw
+		case scalar:
+			// Lists of the environment are separated by commas
+			for _, item := range strings.Split(string(raw), ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, scalar(item))
+				}
+			}
+		default:
+			return fmt.Errorf("config: %s: expected a list", path)
+		}
+
+		rtn := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := decode(fmt.Sprintf("%s[%d]", path, i), rtn.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(rtn)
+		return nil
+
+	case reflect.String:
+		s, ok := text(raw)
+		if !ok {
+			return fmt.Errorf("config: %s: expected a string", path)
+		}
+		v.SetString(s)
+		return nil
+
+	case reflect.Bool:
+		switch raw := raw.(type) {
+		case bool:
+			v.SetBool(raw)
+			return nil
+		case scalar:
+			if b, err := strconv.ParseBool(string(raw)); err == nil {
+				v.SetBool(b)
+				return nil
+			}
+		}
+		return fmt.Errorf("config: %s: expected true or false", path)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(number(raw), 10, t.Bits())
+		if err != nil {
+			return fmt.Errorf("config: %s: expected an integer of %d bits", path, t.Bits())
+		}
+		v.SetInt(n)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(number(raw), 10, t.Bits())
+		if err != nil {
+			return fmt.Errorf("config: %s: expected a non-negative integer of %d bits", path, t.Bits())
+		}
+		v.SetUint(n)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(number(raw), t.Bits())
+		if err != nil {
+			return fmt.Errorf("config: %s: expected a number", path)
+		}
+		v.SetFloat(n)
+		return nil
+	}
+
+	return fmt.Errorf("config: %s: unsupported key", path)
+}
+
+// label names the table of path in errors.
+func label(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return path
+}
+
+// text returns raw if it is a string.
+func text(raw any) (string, bool) {
+	switch raw := raw.(type) {
+	case string:
+		return raw, true
+	case scalar:
+		return string(raw), true
+	}
+	return "", false
+}
+
+// number returns raw if it is a number, "" otherwise.
+func number(raw any) string {
+	switch raw := raw.(type) {
+	case json.Number:
+		return string(raw)
+	case scalar:
+		return string(raw)
+	}
+	return ""
+}
+
+// applyEnv decodes the overrides of the environment variables below prefix
+// into v.
+func applyEnv(v reflect.Value, prefix string) error {
+	for _, leaf := range leaves("", "", v.Type()) {
+		value, ok := os.LookupEnv(envName(prefix, leaf.key))
+		if !ok {
+			continue
+		}
+
+		target := v
+		for _, index := range leaf.index {
+			// Pointers to tables are allocated by their first key set
+			if target.Kind() == reflect.Pointer {
+				if target.IsNil() {
+					target.Set(reflect.New(target.Type().Elem()))
+				}
+				target = target.Elem()
+			}
+			target = target.FieldByIndex(index)
+		}
+
+		if err := decode(leaf.key, target, scalar(value)); err != nil {
+			return fmt.Errorf("%w from %s", err, envName(prefix, leaf.key))
+		}
+	}
+	return nil
+}
+
+// leaf is a key holding a value rather than a table, with the field indices
+// leading to it from the root and the Go selector of its field, e.g.
+// "saucerw.WindowOptions.MinSize.W".
+type leaf struct {
+	key      string
+	index    [][]int
+	sf       reflect.StructField
+	selector string
+}
+
+// leaves returns the keys of the values below the struct type t, selector
+// being that of t if it is not Config.
+func leaves(path, selector string, t reflect.Type) []leaf {
+	var rtn []leaf
+
+	for _, f := range fields(t) {
+		key := join(path, f.key)
+
+		sel := ""
+		if path != "" && selector == "" {
+			sel = f.owner.String() + "." + f.sf.Name
+		} else if selector != "" {
+			sel = selector + "." + f.sf.Name
+		}
+
+		elem := f.sf.Type
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			rtn = append(rtn, leaf{key: key, index: [][]int{f.index}, sf: f.sf, selector: sel})
+			continue
+		}
+
+		for _, l := range leaves(key, sel, elem) {
+			l.index = append([][]int{f.index}, l.index...)
+			rtn = append(rtn, l)
+		}
+	}
+	return rtn
+}
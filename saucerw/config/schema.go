@@ -0,0 +1,101 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+// Key describes a key of the configuration, see Document.
+type Key struct {
+	// Name is the dotted path of the key, e.g. "window.size.w".
+	Name string `json:"name"`
+	// Type is "string", "bool", "int", "uint", "number", "duration", a string
+	// such as "10s", or "[]" followed by the type of the items.
+	Type string `json:"type"`
+	// Choices are the strings the key accepts, if it is an enumeration.
+	Choices []string `json:"choices,omitempty"`
+	// Default is the value of Default, a string for durations.
+	Default any `json:"default"`
+	// Env is the environment variable overriding the key with
+	// DefaultEnvPrefix.
+	Env string `json:"env"`
+	// Field is the Go field of the key holding its documentation, e.g.
+	// "saucerw.Preferences.DevTools" or "saucerw.WindowOptions.MinSize.W".
+	Field string `json:"field"`
+}
+
+// Document returns the keys of the configuration in the order of the fields
+// of Config, for generating the documentation of a packaged application or
+// the schema of an editor:
+//
+//	json.NewEncoder(os.Stdout).Encode(config.Document())
+func Document() []Key {
+	defaults := reflect.ValueOf(Default())
+
+	var rtn []Key
+	for _, l := range leaves("", "", defaults.Type()) {
+		key := Key{
+			Name:    l.key,
+			Type:    typeName(l.sf.Type),
+			Choices: enums[l.sf.Type],
+			Env:     envName(DefaultEnvPrefix, l.key),
+			Field:   l.selector,
+		}
+
+		value, ok := defaults, true
+		for _, index := range l.index {
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					ok = false
+					break
+				}
+				value = value.Elem()
+			}
+			value = value.FieldByIndex(index)
+		}
+		if ok {
+			key.Default = defaultValue(value)
+		}
+
+		rtn = append(rtn, key)
+	}
+	return rtn
+}
+
+// typeName returns the Type of a Key of type t.
+func typeName(t reflect.Type) string {
+	if _, ok := enums[t]; ok {
+		return "string"
+	}
+	if t == durationType {
+		return "duration"
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Bool:
+		return "bool"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	}
+	return "number"
+}
+
+// defaultValue returns the Default of a Key holding v.
+func defaultValue(v reflect.Value) any {
+	if names, ok := enums[v.Type()]; ok {
+		return names[v.Uint()]
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	return v.Interface()
+}
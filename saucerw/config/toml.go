@@ -0,0 +1,358 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// syntaxError is an error of the syntax of a file at line.
+func syntaxError(line int, format string, args ...any) error {
+	return fmt.Errorf("config: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// parseJSON parses a JSON document with an object at the top.
+func parseJSON(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var rtn map[string]any
+	if err := dec.Decode(&rtn); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("config: data after the JSON object")
+	}
+	return rtn, nil
+}
+
+// tomlParser parses the subset of TOML of configuration files: key/value
+// pairs with bare, quoted and dotted keys, standard tables, basic and literal
+// strings, integers, floats, booleans, arrays and inline tables, which may
+// span lines in arrays.
+type tomlParser struct {
+	src  []byte
+	pos  int
+	line int
+}
+
+// parseTOML parses a TOML document.
+func parseTOML(data []byte) (map[string]any, error) {
+	p := &tomlParser{src: data, line: 1}
+	root := map[string]any{}
+	table := root
+	defined := map[string]bool{}
+
+	for {
+		p.skip()
+		if p.pos >= len(p.src) {
+			return root, nil
+		}
+
+		if p.src[p.pos] == '[' {
+			p.pos++
+			if p.peek() == '[' {
+				return nil, syntaxError(p.line, "arrays of tables are not supported")
+			}
+
+			keys, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			p.space()
+			if p.peek() != ']' {
+				return nil, syntaxError(p.line, "expected ']'")
+			}
+			p.pos++
+
+			name := strings.Join(keys, ".")
+			if defined[name] {
+				return nil, syntaxError(p.line, "table %s defined twice", name)
+			}
+			defined[name] = true
+
+			if table, err = p.descend(root, keys); err != nil {
+				return nil, err
+			}
+		} else if err := p.pair(table); err != nil {
+			return nil, err
+		}
+
+		if err := p.end(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// pair parses a key/value pair into table.
+func (p *tomlParser) pair(table map[string]any) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	p.space()
+	if p.peek() != '=' {
+		return syntaxError(p.line, "expected '=' after %s", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.space()
+
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.descend(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+	if _, ok := parent[last]; ok {
+		return syntaxError(p.line, "key %s defined twice", strings.Join(keys, "."))
+	}
+	parent[last] = value
+	return nil
+}
+
+// descend returns the table below table at keys, creating it if needed.
+func (p *tomlParser) descend(table map[string]any, keys []string) (map[string]any, error) {
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			child := map[string]any{}
+			table[key] = child
+			table = child
+		case map[string]any:
+			table = next
+		default:
+			return nil, syntaxError(p.line, "key %s is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// key parses a possibly dotted key.
+func (p *tomlParser) key() ([]string, error) {
+	var rtn []string
+
+	for {
+		p.space()
+
+		var part string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		default:
+			start := p.pos
+			for p.pos < len(p.src) && isBare(p.src[p.pos]) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, syntaxError(p.line, "expected a key")
+			}
+			part = string(p.src[start:p.pos])
+		}
+		rtn = append(rtn, part)
+
+		p.space()
+		if p.peek() != '.' {
+			return rtn, nil
+		}
+		p.pos++
+	}
+}
+
+// value parses a value.
+func (p *tomlParser) value() (any, error) {
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.string()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inline()
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n,]}#", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	word := string(p.src[start:p.pos])
+
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "":
+		return nil, syntaxError(p.line, "expected a value")
+	}
+
+	n := strings.ReplaceAll(word, "_", "")
+	if i, err := strconv.ParseInt(n, 0, 64); err == nil {
+		if digits := strings.TrimLeft(n, "+-"); len(digits) > 1 && digits[0] == '0' && !strings.ContainsAny(digits[1:2], "xob") {
+			return nil, syntaxError(p.line, "leading zeros in %s", word)
+		}
+		return json.Number(strconv.FormatInt(i, 10)), nil
+	}
+	if _, err := strconv.ParseFloat(n, 64); err == nil {
+		return json.Number(n), nil
+	}
+	return nil, syntaxError(p.line, "invalid value %s, strings have to be quoted", word)
+}
+
+// array parses an array.
+func (p *tomlParser) array() (any, error) {
+	p.pos++
+	rtn := []any{}
+
+	for {
+		p.skip()
+		if p.peek() == ']' {
+			p.pos++
+			return rtn, nil
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, value)
+
+		p.skip()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, syntaxError(p.line, "expected ',' or ']'")
+		}
+	}
+}
+
+// inline parses an inline table.
+func (p *tomlParser) inline() (any, error) {
+	p.pos++
+	rtn := map[string]any{}
+
+	p.space()
+	if p.peek() == '}' {
+		p.pos++
+		return rtn, nil
+	}
+
+	for {
+		if err := p.pair(rtn); err != nil {
+			return nil, err
+		}
+
+		p.space()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return rtn, nil
+		default:
+			return nil, syntaxError(p.line, "expected ',' or '}'")
+		}
+	}
+}
+
+// string parses a basic or literal string on one line.
+func (p *tomlParser) string() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+
+	if bytes.HasPrefix(p.src[p.pos:], []byte{quote, quote}) {
+		return "", syntaxError(p.line, "multi-line strings are not supported")
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != quote && p.src[p.pos] != '\n' {
+		if quote == '"' && p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) || p.src[p.pos] != quote {
+		return "", syntaxError(p.line, "unterminated string")
+	}
+
+	raw := string(p.src[start:p.pos])
+	p.pos++
+
+	if quote == '\'' {
+		return raw, nil
+	}
+
+	// The escapes of TOML are those of Go besides \e
+	s, err := strconv.Unquote(`"` + strings.ReplaceAll(raw, `\e`, `\x1b`) + `"`)
+	if err != nil {
+		return "", syntaxError(p.line, "invalid escape in string")
+	}
+	return s, nil
+}
+
+// end consumes the rest of a line after a pair or table header.
+func (p *tomlParser) end() error {
+	p.space()
+	if p.peek() == '#' {
+		for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+	if p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '\r' {
+		return syntaxError(p.line, "expected the end of the line")
+	}
+	return nil
+}
+
+// skip skips whitespace, comments and line breaks.
+func (p *tomlParser) skip() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\r':
+		case '\n':
+			p.line++
+		case '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+// space skips spaces and tabs.
+func (p *tomlParser) space() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek returns the current byte, 0 at the end.
+func (p *tomlParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// isBare reports whether c may appear in a bare key.
+func isBare(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
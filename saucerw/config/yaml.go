@@ -0,0 +1,414 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a line of a YAML document without its indentation and
+// comment.
+type yamlLine struct {
+	indent int
+	text   string
+	num    int
+}
+
+// yamlParser parses the subset of YAML of configuration files: block
+// mappings and sequences, flow mappings and sequences on one line, plain,
+// single- and double-quoted scalars and comments. Plain scalars keep their
+// text, their type follows the key they are decoded into, so "on" stays
+// a string and 0755 is not octal.
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+// parseYAML parses a YAML document with a mapping at the top.
+func parseYAML(data []byte) (map[string]any, error) {
+	p := &yamlParser{}
+
+	for i, text := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		num := i + 1
+
+		trimmed := strings.TrimLeft(text, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, syntaxError(num, "tabs cannot indent")
+		}
+
+		trimmed = strings.TrimRight(stripComment(trimmed), " \t")
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed == "---" && len(p.lines) == 0:
+			continue
+		case trimmed == "---" || trimmed == "...":
+			return nil, syntaxError(num, "multiple documents are not supported")
+		case strings.HasPrefix(trimmed, "%"):
+			return nil, syntaxError(num, "directives are not supported")
+		}
+
+		p.lines = append(p.lines, yamlLine{indent: len(text) - len(strings.TrimLeft(text, " ")), text: trimmed, num: num})
+	}
+
+	if len(p.lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	root, err := p.node()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.lines) {
+		return nil, syntaxError(p.lines[p.i].num, "bad indentation")
+	}
+
+	table, ok := root.(map[string]any)
+	if !ok {
+		return nil, syntaxError(p.lines[0].num, "expected a mapping at the top")
+	}
+	return table, nil
+}
+
+// node parses the block node starting at the current line.
+func (p *yamlParser) node() (any, error) {
+	l := p.lines[p.i]
+	if isItem(l.text) {
+		return p.sequence(l.indent)
+	}
+	if _, _, ok, err := splitKey(l); ok || err != nil {
+		return p.mapping(l.indent)
+	}
+
+	// A scalar or flow node on a line of its own
+	p.i++
+	return flowValue(l.text, l.num)
+}
+
+// sequence parses the items of a block sequence at indent.
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	rtn := []any{}
+
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isItem(p.lines[p.i].text) {
+		l := p.lines[p.i]
+		rest := strings.TrimLeft(l.text[1:], " ")
+
+		if rest == "" {
+			p.i++
+			value, err := p.child(indent, false)
+			if err != nil {
+				return nil, err
+			}
+			rtn = append(rtn, value)
+			continue
+		}
+
+		// "- key: value" starts a mapping at the column of the key
+		p.lines[p.i] = yamlLine{indent: indent + len(l.text) - len(rest), text: rest, num: l.num}
+		value, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, value)
+	}
+	return rtn, nil
+}
+
+// mapping parses the entries of a block mapping at indent.
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	rtn := map[string]any{}
+
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent {
+		l := p.lines[p.i]
+
+		key, rest, ok, err := splitKey(l)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, syntaxError(l.num, "expected a key")
+		}
+		if _, dup := rtn[key]; dup {
+			return nil, syntaxError(l.num, "key %s defined twice", key)
+		}
+		p.i++
+
+		var value any
+		if rest == "" {
+			value, err = p.child(indent, true)
+		} else {
+			value, err = flowValue(rest, l.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rtn[key] = value
+	}
+	return rtn, nil
+}
+
+// child parses the block node below a key or item at indent, nil if there
+// is none. The items of a sequence below a key may be at the indentation of
+// the key.
+func (p *yamlParser) child(indent int, key bool) (any, error) {
+	if p.i >= len(p.lines) {
+		return nil, nil
+	}
+
+	next := p.lines[p.i]
+	if next.indent > indent || key && next.indent == indent && isItem(next.text) {
+		return p.node()
+	}
+	return nil, nil
+}
+
+// isItem reports whether text is an item of a block sequence.
+func isItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits the line of a mapping entry into its key and value, ok
+// being false if it is no entry.
+func splitKey(l yamlLine) (key, rest string, ok bool, err error) {
+	text := l.text
+
+	if text[0] == '"' || text[0] == '\'' {
+		key, n, err := unquote(text, l.num)
+		if err != nil {
+			return "", "", false, err
+		}
+		after := strings.TrimLeft(text[n:], " ")
+		if !strings.HasPrefix(after, ":") {
+			return "", "", false, nil
+		}
+		return key, strings.TrimLeft(after[1:], " "), true, nil
+	}
+
+	if strings.ContainsRune("[{?&*!|>", rune(text[0])) {
+		return "", "", false, nil
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimRight(text[:i], " "), strings.TrimLeft(text[i+1:], " "), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// flowValue parses the scalar or flow collection text.
+func flowValue(text string, num int) (any, error) {
+	switch text[0] {
+	case '|', '>':
+		return nil, syntaxError(num, "block scalars are not supported")
+	case '&', '*', '!':
+		return nil, syntaxError(num, "anchors, aliases and tags are not supported")
+	case '[', '{', '"', '\'':
+	default:
+		// Plain scalars outside of flow collections may contain commas
+		return plain(text), nil
+	}
+
+	f := &flowParser{s: text, line: num}
+	value, err := f.value(false)
+	if err != nil {
+		return nil, err
+	}
+
+	f.space()
+	if f.pos < len(f.s) {
+		return nil, syntaxError(num, "unexpected %q", f.s[f.pos:])
+	}
+	return value, nil
+}
+
+// plain returns the plain scalar text, nil for null.
+func plain(text string) any {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	}
+	return scalar(text)
+}
+
+// flowParser parses a flow node on one line.
+type flowParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+// value parses a node, a key of a flow mapping if key is set.
+func (f *flowParser) value(key bool) (any, error) {
+	f.space()
+	if f.pos >= len(f.s) {
+		return nil, nil
+	}
+
+	switch f.s[f.pos] {
+	case '[':
+		return f.sequence()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		s, n, err := unquote(f.s[f.pos:], f.line)
+		f.pos += n
+		return s, err
+	}
+
+	stop := ",]}"
+	if key {
+		stop += ":"
+	}
+
+	start := f.pos
+	for f.pos < len(f.s) && !strings.ContainsRune(stop, rune(f.s[f.pos])) {
+		f.pos++
+	}
+	return plain(strings.TrimRight(f.s[start:f.pos], " ")), nil
+}
+
+// sequence parses a flow sequence.
+func (f *flowParser) sequence() (any, error) {
+	f.pos++
+	rtn := []any{}
+
+	for {
+		f.space()
+		if f.peek() == ']' {
+			f.pos++
+			return rtn, nil
+		}
+
+		value, err := f.value(false)
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, value)
+
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// mapping parses a flow mapping.
+func (f *flowParser) mapping() (any, error) {
+	f.pos++
+	rtn := map[string]any{}
+
+	for {
+		f.space()
+		if f.peek() == '}' {
+			f.pos++
+			return rtn, nil
+		}
+
+		key, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := text(key)
+		if !ok {
+			return nil, syntaxError(f.line, "expected a key")
+		}
+
+		f.space()
+		if f.peek() != ':' {
+			return nil, syntaxError(f.line, "expected ':' after %s", name)
+		}
+		f.pos++
+
+		value, err := f.value(false)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := rtn[name]; dup {
+			return nil, syntaxError(f.line, "key %s defined twice", name)
+		}
+		rtn[name] = value
+
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator consumes the ',' between the entries of a collection closed by
+// end.
+func (f *flowParser) separator(end byte) error {
+	f.space()
+	switch f.peek() {
+	case ',':
+		f.pos++
+	case end:
+	default:
+		return syntaxError(f.line, "expected ',' or '%c'", end)
+	}
+	return nil
+}
+
+// space skips spaces.
+func (f *flowParser) space() {
+	for f.pos < len(f.s) && f.s[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+// peek returns the current byte, 0 at the end.
+func (f *flowParser) peek() byte {
+	if f.pos >= len(f.s) {
+		return 0
+	}
+	return f.s[f.pos]
+}
+
+// unquote parses the quoted scalar at the start of s and returns it with
+// the length of its source.
+func unquote(s string, num int) (string, int, error) {
+	quote := s[0]
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			if quote == '\'' {
+				return strings.ReplaceAll(s[1:i], "''", "'"), i + 1, nil
+			}
+			rtn, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, syntaxError(num, "invalid escape in %s", s[:i+1])
+			}
+			return rtn, i + 1, nil
+		}
+	}
+	return "", 0, syntaxError(num, "unterminated string")
+}
+
+// stripComment removes the comment at the end of a line, a '#' outside of
+// quotes at its start or after whitespace.
+func stripComment(line string) string {
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\', quote == '\'' && c == '\'' && i+1 < len(line) && line[i+1] == '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			// Quotes only start scalars, "it's" is plain
+			if i == 0 || strings.ContainsRune(" [{,:-", rune(line[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,72 @@
+package saucerw
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// consoleScript forwards the console output of the page to the bridge.
+const consoleScript = `
+for (const level of ["debug", "log", "info", "warn", "error"])
+{
+    const original = console[level];
+
+    console[level] = (...args) =>
+    {
+        original.apply(console, args);
+
+        const strings = args.map((arg) =>
+        {
+            if (typeof arg === "string")
+            {
+                return arg;
+            }
+
+            try
+            {
+                return JSON.stringify(arg) ?? String(arg);
+            } catch (e)
+            {
+                return String(arg);
+            }
+        });
+
+        try
+        {
+            window.saucer.internal.post(JSON.stringify({ ["saucer:console"]: true, level, args: strings }));
+        } catch (e)
+        {
+        }
+    };
+}
+`
+
+// ConsoleMessage is a message logged through the console of the page.
+type ConsoleMessage struct {
+	// Level is slog.LevelDebug for console.debug, slog.LevelInfo for
+	// console.log and console.info, slog.LevelWarn for console.warn and
+	// slog.LevelError for console.error.
+	Level slog.Level
+	// Args are the logged values, JSON encoded unless they are strings.
+	Args []string
+}
+
+// Message returns the arguments joined by spaces, as the console shows them.
+func (m ConsoleMessage) Message() string {
+	return strings.Join(m.Args, " ")
+}
+
+// consoleLevels maps the console methods to log levels.
+var consoleLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"log":   slog.LevelInfo,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// OnConsoleMessage calls fn for every message the page logs to its console,
+// for example to record it with the logger set by SetLogger.
+func (v *Webview) OnConsoleMessage(fn func(ConsoleMessage)) *Subscription {
+	return v.console.subscribe(fn)
+}
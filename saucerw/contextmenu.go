@@ -0,0 +1,183 @@
+package saucerw
+
+import (
+	"cmp"
+	"sync"
+)
+
+// contextScript reports what the context menu of the page is opened on,
+// before the engine shows it. It fills in what the engines do not tell: the
+// selection on WebKitGTK and on macOS the whole target.
+const contextScript = `
+(() =>
+{
+    const fields = /^(button|checkbox|color|file|hidden|image|radio|range|reset|submit)$/;
+
+    window.addEventListener("contextmenu", (event) =>
+    {
+        const target = event.composedPath()[0];
+        const element = target instanceof Element ? target : target?.parentElement;
+
+        const link = element?.closest("a[href], area[href]")?.href;
+        const field = element?.closest("input, textarea");
+        const text = field && !field.readOnly && !field.disabled && !fields.test(field.type);
+
+        let selection = window.getSelection()?.toString() ?? "";
+
+        try
+        {
+            if (text && field.selectionStart !== field.selectionEnd)
+            {
+                selection = field.value.substring(field.selectionStart, field.selectionEnd);
+            }
+        } catch (e)
+        {
+        }
+
+        const message = {
+            ["saucer:context"]: true,
+            x: Math.round(event.clientX),
+            y: Math.round(event.clientY),
+            link: typeof link === "string" ? link : "",
+            image: element?.closest("img")?.currentSrc ?? "",
+            media: element?.closest("video, audio")?.currentSrc ?? "",
+            selection,
+            editable: !!(text || element?.isContentEditable),
+        };
+
+        window.saucer.internal.message(JSON.stringify(message));
+    }, true);
+})();
+`
+
+// ContextInfo describes what the context menu of a webview was opened on.
+type ContextInfo struct {
+	// Position is where the menu was opened, relative to the webview.
+	Position Position
+	// LinkURL is the target of the link under the cursor, if any.
+	LinkURL string
+	// ImageURL is the source of the image under the cursor, if any.
+	ImageURL string
+	// MediaURL is the source of the video or audio under the cursor, if any.
+	MediaURL string
+	// Selection is the selected text.
+	Selection string
+	// Editable is set if the menu was opened on a text field or editable
+	// content.
+	Editable bool
+}
+
+// fill sets the empty fields of c to those of page.
+func (c ContextInfo) fill(page ContextInfo) ContextInfo {
+	return ContextInfo{
+		Position:  cmp.Or(c.Position, page.Position),
+		LinkURL:   cmp.Or(c.LinkURL, page.LinkURL),
+		ImageURL:  cmp.Or(c.ImageURL, page.ImageURL),
+		MediaURL:  cmp.Or(c.MediaURL, page.MediaURL),
+		Selection: cmp.Or(c.Selection, page.Selection),
+		Editable:  c.Editable || page.Editable,
+	}
+}
+
+// contextMenu is the context menu handler of a webview.
+type contextMenu struct {
+	once sync.Once
+
+	mu sync.Mutex
+	fn func(ContextInfo) []MenuItem
+	// page is the target reported by the script for the next menu.
+	page ContextInfo
+	// state holds the items of the menu shown last.
+	state *menuState
+}
+
+// probed records the target reported by the script.
+func (c *contextMenu) probed(info ContextInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.page = info
+}
+
+// SetContextMenu replaces the context menu of the webview with the items fn
+// returns for what it was opened on. If fn returns nil the default menu of the
+// engine is shown, an empty non-nil slice shows no menu at all. A nil fn
+// restores the default menu.
+//
+// The items behave like those of Window.SetMenu, their roles acting on this
+// webview. Accelerators are only displayed, use Window.SetMenu to bind them.
+// fn is called on the event loop thread and must not block; if it panics the
+// default menu is shown. Pages that prevent the default of the contextmenu
+// event show no menu, fn is not called then.
+func (v *Webview) SetContextMenu(fn func(ContextInfo) []MenuItem) {
+	v.menu.mu.Lock()
+	v.menu.fn = fn
+	v.menu.mu.Unlock()
+
+	v.menu.once.Do(func() {
+		v.native.Inject(Script{Code: contextScript, Time: AtCreation, Permanent: true})
+		v.native.Execute(contextScript)
+		v.native.HandleContextMenu(v.openContextMenu, v.activateContextMenu)
+	})
+}
+
+// openContextMenu returns the entries of the menu opened on info, ok being
+// false for the default menu. It runs on the event loop thread.
+func (v *Webview) openContextMenu(info ContextInfo, partial bool) (_ []MenuEntry, ok bool) {
+	v.menu.mu.Lock()
+	fn, page := v.menu.fn, v.menu.page
+	v.menu.page = ContextInfo{}
+	v.menu.mu.Unlock()
+
+	if fn == nil {
+		return nil, false
+	}
+
+	if partial {
+		info = info.fill(page)
+	}
+
+	items := fn(info)
+	if items == nil {
+		return nil, false
+	}
+
+	state := &menuState{items: map[int32]*MenuItem{}}
+
+	entries, err := state.flatten(nil, new(int32), 0, cloneMenuItems(items))
+	if err != nil {
+		log().Warn("showing default context menu", "component", "saucerw", "error", err)
+		return nil, false
+	}
+
+	v.menu.mu.Lock()
+	v.menu.state = state
+	v.menu.mu.Unlock()
+
+	return entries, true
+}
+
+// activateContextMenu handles a click on the context menu entry id. It runs on
+// the event loop thread.
+func (v *Webview) activateContextMenu(id int32) {
+	v.menu.mu.Lock()
+
+	state := v.menu.state
+	if state == nil {
+		v.menu.mu.Unlock()
+		return
+	}
+
+	click, ok := state.click(id)
+	v.menu.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	v.window.performRole(click.item.Role, []*Webview{v})
+
+	if click.fn != nil {
+		v.window.clicks.emit(click)
+	}
+}
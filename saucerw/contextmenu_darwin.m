@@ -0,0 +1,87 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <WebKit/WebKit.h>
+#import <objc/runtime.h>
+
+#include "native.h"
+
+// Implemented in cookies_darwin.m
+WKWebView *saucerw_cocoa_webview(const void *impl);
+
+// Implemented in menu_darwin.m
+void saucerw_cocoa_populate_menu(NSMenu *menu, uintptr_t handle, size_t count, const saucerw_menu_item *items);
+
+struct saucerw_context_menu
+{
+    __unsafe_unretained NSMenu *menu;
+    uintptr_t click;
+};
+
+// The key of the handlers associated with a web view
+static char context_key;
+
+// The implementation of willOpenMenu:withEvent: populating the menu
+static IMP will_open_menu;
+
+// will_open_menu_with_event lets the handler replace the menu the web view populated.
+static void will_open_menu_with_event(NSView *self, SEL cmd, NSMenu *menu, NSEvent *event)
+{
+    ((void (*)(id, SEL, NSMenu *, NSEvent *))will_open_menu)(self, cmd, menu, event);
+
+    NSArray<NSNumber *> *handlers = objc_getAssociatedObject(self, &context_key);
+
+    if (!handlers)
+    {
+        return;
+    }
+
+    NSPoint point = [self convertPoint:event.locationInWindow fromView:nil];
+
+    // WebKit does not tell what the menu was opened on, the page reports it
+    saucerw_context context = {
+        .x       = (int)point.x,
+        .y       = (int)(self.isFlipped ? point.y : NSHeight(self.bounds) - point.y),
+        .partial = true,
+    };
+
+    struct saucerw_context_menu custom = {.menu = menu, .click = handlers[1].unsignedLongLongValue};
+
+    saucerwContextMenu(handlers[0].unsignedLongLongValue, &context, &custom);
+}
+
+void saucerw_context_menu_set(saucerw_context_menu *self, size_t count, const saucerw_menu_item *items)
+{
+    saucerw_cocoa_populate_menu(self->menu, self->click, count, items);
+}
+
+void saucerw_cocoa_on_context_menu(const void *webview, uintptr_t handler, uintptr_t click)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+
+    if (!native)
+    {
+        return;
+    }
+
+    objc_setAssociatedObject(native, &context_key, @[ @(handler), @(click) ], OBJC_ASSOCIATION_RETAIN_NONATOMIC);
+
+    if (will_open_menu)
+    {
+        return;
+    }
+
+    // The web views of saucer share one class, which overrides the method to disable the menu
+    Class cls       = object_getClass(native);
+    SEL selector    = @selector(willOpenMenu:withEvent:);
+    Method original = class_getInstanceMethod(cls, selector);
+
+    if (class_addMethod(cls, selector, (IMP)will_open_menu_with_event, method_getTypeEncoding(original)))
+    {
+        will_open_menu = method_getImplementation(original);
+    }
+    else
+    {
+        will_open_menu = method_setImplementation(original, (IMP)will_open_menu_with_event);
+    }
+}
@@ -0,0 +1,178 @@
+package saucerw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CookieStore is the cookie jar of the data store a webview uses, shared by
+// all webviews of that store.
+type CookieStore struct {
+	view *Webview
+}
+
+// Cookies returns the cookie store of the webview.
+//
+// Its methods wait for the event loop and return ErrLoopThread when called
+// from its thread.
+func (v *Webview) Cookies() *CookieStore {
+	return &CookieStore{view: v}
+}
+
+// Get returns the cookies of domain and its subdomains, all cookies if domain
+// is empty.
+//
+// The Qt backend only learns about stored cookies once they were loaded in
+// the background, shortly after the webview was created.
+func (s *CookieStore) Get(ctx context.Context, domain string) ([]*http.Cookie, error) {
+	if err := s.view.window.app.checkWait(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		cookies []*http.Cookie
+		err     error
+	}
+
+	ch := make(chan result, 1)
+	s.view.native.Cookies(func(cookies []*http.Cookie, err error) { ch <- result{cookies, err} })
+
+	select {
+	case res := <-ch:
+		if res.err != nil || domain == "" {
+			return res.cookies, res.err
+		}
+
+		rtn := res.cookies[:0]
+		for _, cookie := range res.cookies {
+			if matchDomain(cookie.Domain, domain) {
+				rtn = append(rtn, cookie)
+			}
+		}
+		return rtn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Set adds cookie or replaces the one of the same name, domain and path. The
+// cookie needs a name and a domain, its path defaults to "/". A zero Expires
+// stores a session cookie, MaxAge is ignored.
+func (s *CookieStore) Set(ctx context.Context, cookie *http.Cookie) error {
+	if cookie.Name == "" || cookie.Domain == "" {
+		return errors.New("saucerw: cookie name and domain are required")
+	}
+
+	if cookie.Path == "" {
+		copied := *cookie
+		copied.Path = "/"
+		cookie = &copied
+	}
+
+	return waitDone(ctx, s.view.window.app, func(done func(error)) { s.view.native.SetCookie(cookie, done) })
+}
+
+// Delete removes the cookie of the name, domain and path of cookie, the path
+// defaulting to "/". Deleting a cookie that does not exist is no error.
+func (s *CookieStore) Delete(ctx context.Context, cookie *http.Cookie) error {
+	if cookie.Path == "" {
+		copied := *cookie
+		copied.Path = "/"
+		cookie = &copied
+	}
+
+	return waitDone(ctx, s.view.window.app, func(done func(error)) { s.view.native.DeleteCookie(cookie, done) })
+}
+
+// DeleteDomain removes the cookies of domain and its subdomains, all cookies
+// if domain is empty.
+func (s *CookieStore) DeleteDomain(ctx context.Context, domain string) error {
+	cookies, err := s.Get(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	for _, cookie := range cookies {
+		if err := s.Delete(ctx, cookie); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BrowsingData is a set of kinds of data stored by the pages.
+type BrowsingData uint8
+
+const (
+	// BrowsingCache is the HTTP cache and the Cache API storage.
+	BrowsingCache BrowsingData = 1 << iota
+	// BrowsingCookies are the cookies.
+	BrowsingCookies
+	// BrowsingLocalStorage is localStorage and sessionStorage.
+	BrowsingLocalStorage
+	// BrowsingIndexedDB are the IndexedDB databases.
+	BrowsingIndexedDB
+	// BrowsingServiceWorkers are the service worker registrations.
+	BrowsingServiceWorkers
+
+	// BrowsingAll is every kind of data.
+	BrowsingAll = BrowsingCache | BrowsingCookies | BrowsingLocalStorage | BrowsingIndexedDB | BrowsingServiceWorkers
+)
+
+var browsingNames = [...]string{"cache", "cookies", "local storage", "IndexedDB", "service workers"}
+
+// String returns the names of the kinds in d separated by commas.
+func (d BrowsingData) String() string {
+	var names []string
+	for i, name := range browsingNames {
+		if d&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// ClearBrowsingData removes the kinds of data modified at or after since from
+// the data store of the webview, all of it if since is zero. It waits like
+// the methods of CookieStore.
+//
+// Kinds the backend cannot clear are reported as an error wrapping
+// ErrUnsupported after the others were cleared. The Qt backend only clears
+// the cache and the cookies and ignores since.
+func (v *Webview) ClearBrowsingData(ctx context.Context, kinds BrowsingData, since time.Time) error {
+	if kinds&^BrowsingAll != 0 {
+		return fmt.Errorf("saucerw: unknown browsing data %#x", uint8(kinds&^BrowsingAll))
+	}
+
+	return waitDone(ctx, v.window.app, func(done func(error)) { v.native.ClearData(kinds, since, done) })
+}
+
+// waitDone calls fn on the driver and waits for the error it reports.
+func waitDone(ctx context.Context, app *Application, fn func(done func(error))) error {
+	if err := app.checkWait(); err != nil {
+		return err
+	}
+
+	ch := make(chan error, 1)
+	fn(func(err error) { ch <- err })
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// matchDomain reports whether the cookie domain is domain or one of its
+// subdomains.
+func matchDomain(cookie, domain string) bool {
+	cookie = strings.ToLower(strings.TrimPrefix(cookie, "."))
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+
+	return cookie == domain || strings.HasSuffix(cookie, "."+domain)
+}
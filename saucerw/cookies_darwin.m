@@ -0,0 +1,267 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <CommonCrypto/CommonDigest.h>
+#import <WebKit/WebKit.h>
+#import <objc/runtime.h>
+
+#include <string.h>
+
+#include "native.h"
+
+// find_webview returns the web view below view created by the saucer::webview::impl, which saucer keeps in the
+// "me" ivar of its WKWebView subclass.
+static WKWebView *find_webview(NSView *view, const void *impl)
+{
+    if ([view isKindOfClass:WKWebView.class])
+    {
+        Ivar ivar = class_getInstanceVariable(object_getClass(view), "me");
+
+        if (ivar && *(const void **)((uint8_t *)(__bridge void *)view + ivar_getOffset(ivar)) == impl)
+        {
+            return (WKWebView *)view;
+        }
+    }
+
+    for (NSView *child in view.subviews)
+    {
+        WKWebView *rtn = find_webview(child, impl);
+
+        if (rtn)
+        {
+            return rtn;
+        }
+    }
+
+    return nil;
+}
+
+// saucerw_cocoa_webview returns the web view of the saucer::webview::impl, nil if it is in no window.
+WKWebView *saucerw_cocoa_webview(const void *impl)
+{
+    for (NSWindow *window in NSApp.windows)
+    {
+        WKWebView *webview = find_webview(window.contentView, impl);
+
+        if (webview)
+        {
+            return webview;
+        }
+    }
+
+    return nil;
+}
+
+static WKWebsiteDataStore *data_store(const void *impl)
+{
+    return saucerw_cocoa_webview(impl).configuration.websiteDataStore;
+}
+
+static NSString *string(const char *value)
+{
+    return [NSString stringWithUTF8String:value];
+}
+
+static NSHTTPCookie *native_cookie(const saucerw_cookie *cookie)
+{
+    NSMutableDictionary<NSHTTPCookiePropertyKey, id> *properties = [@{
+        NSHTTPCookieName : string(cookie->name),
+        NSHTTPCookieValue : string(cookie->value),
+        NSHTTPCookieDomain : string(cookie->domain),
+        NSHTTPCookiePath : string(cookie->path),
+    } mutableCopy];
+
+    if (cookie->expires)
+    {
+        properties[NSHTTPCookieExpires] = [NSDate dateWithTimeIntervalSince1970:cookie->expires];
+    }
+
+    if (cookie->secure)
+    {
+        properties[NSHTTPCookieSecure] = @"TRUE";
+    }
+
+    // There is no constant for the HttpOnly attribute
+    if (cookie->http_only)
+    {
+        properties[@"HttpOnly"] = @"TRUE";
+    }
+
+    switch (cookie->same_site)
+    {
+    case SAUCERW_SAME_SITE_LAX:
+        properties[NSHTTPCookieSameSitePolicy] = NSHTTPCookieSameSiteLax;
+        break;
+    case SAUCERW_SAME_SITE_STRICT:
+        properties[NSHTTPCookieSameSitePolicy] = NSHTTPCookieSameSiteStrict;
+        break;
+    }
+
+    return [NSHTTPCookie cookieWithProperties:properties];
+}
+
+static NSSet<NSString *> *data_types(int kinds)
+{
+    NSMutableSet<NSString *> *rtn = [NSMutableSet set];
+
+    if (kinds & SAUCERW_DATA_CACHE)
+    {
+        [rtn addObjectsFromArray:@[
+            WKWebsiteDataTypeDiskCache, WKWebsiteDataTypeMemoryCache, WKWebsiteDataTypeFetchCache
+        ]];
+    }
+
+    if (kinds & SAUCERW_DATA_COOKIES)
+    {
+        [rtn addObject:WKWebsiteDataTypeCookies];
+    }
+
+    if (kinds & SAUCERW_DATA_LOCAL_STORAGE)
+    {
+        [rtn addObjectsFromArray:@[ WKWebsiteDataTypeLocalStorage, WKWebsiteDataTypeSessionStorage ]];
+    }
+
+    if (kinds & SAUCERW_DATA_INDEXED_DB)
+    {
+        [rtn addObject:WKWebsiteDataTypeIndexedDBDatabases];
+    }
+
+    if (kinds & SAUCERW_DATA_SERVICE_WORKERS)
+    {
+        [rtn addObject:WKWebsiteDataTypeServiceWorkerRegistrations];
+    }
+
+    return rtn;
+}
+
+void saucerw_cocoa_cookies(const void *webview, uintptr_t handle)
+{
+    WKWebsiteDataStore *store = data_store(webview);
+
+    if (!store)
+    {
+        saucerwCookies(handle, 0, NULL, "webview not found");
+        return;
+    }
+
+    [store.httpCookieStore getAllCookies:^(NSArray<NSHTTPCookie *> *cookies) {
+      const NSUInteger count = cookies.count;
+      saucerw_cookie *rtn    = calloc(count ? count : 1, sizeof(saucerw_cookie));
+
+      for (NSUInteger i = 0; count > i; i++)
+      {
+          NSHTTPCookie *cookie = cookies[i];
+          int same_site        = SAUCERW_SAME_SITE_NONE;
+
+          if ([cookie.sameSitePolicy isEqualToString:NSHTTPCookieSameSiteLax])
+          {
+              same_site = SAUCERW_SAME_SITE_LAX;
+          }
+          else if ([cookie.sameSitePolicy isEqualToString:NSHTTPCookieSameSiteStrict])
+          {
+              same_site = SAUCERW_SAME_SITE_STRICT;
+          }
+
+          rtn[i] = (saucerw_cookie){
+              .name      = cookie.name.UTF8String,
+              .value     = cookie.value.UTF8String,
+              .domain    = cookie.domain.UTF8String,
+              .path      = cookie.path.UTF8String,
+              .expires   = cookie.expiresDate ? (int64_t)cookie.expiresDate.timeIntervalSince1970 : 0,
+              .secure    = cookie.isSecure,
+              .http_only = cookie.isHTTPOnly,
+              .same_site = same_site,
+          };
+      }
+
+      saucerwCookies(handle, count, rtn, NULL);
+      free(rtn);
+    }];
+}
+
+void saucerw_cocoa_set_cookie(const void *webview, const saucerw_cookie *cookie, uintptr_t handle)
+{
+    WKWebsiteDataStore *store = data_store(webview);
+    NSHTTPCookie *native      = native_cookie(cookie);
+
+    if (!store || !native)
+    {
+        saucerwDone(handle, store ? "invalid cookie" : "webview not found");
+        return;
+    }
+
+    [store.httpCookieStore setCookie:native
+                   completionHandler:^{
+                     saucerwDone(handle, NULL);
+                   }];
+}
+
+void saucerw_cocoa_delete_cookie(const void *webview, const saucerw_cookie *cookie, uintptr_t handle)
+{
+    WKWebsiteDataStore *store = data_store(webview);
+    NSHTTPCookie *native      = native_cookie(cookie);
+
+    if (!store || !native)
+    {
+        saucerwDone(handle, store ? "invalid cookie" : "webview not found");
+        return;
+    }
+
+    [store.httpCookieStore deleteCookie:native
+                      completionHandler:^{
+                        saucerwDone(handle, NULL);
+                      }];
+}
+
+void saucerw_cocoa_clear_data(const void *webview, int kinds, double since, uintptr_t handle)
+{
+    WKWebsiteDataStore *store = data_store(webview);
+
+    if (!store)
+    {
+        saucerwDone(handle, "webview not found");
+        return;
+    }
+
+    NSDate *date = since > 0 ? [NSDate dateWithTimeIntervalSince1970:since] : NSDate.distantPast;
+
+    [store removeDataOfTypes:data_types(kinds)
+               modifiedSince:date
+           completionHandler:^{
+             saucerwDone(handle, NULL);
+           }];
+}
+
+// store_id derives the identifier of the data store saucer uses for storage_path, like its utils::uuid_from
+static NSUUID *store_id(const char *storage_path)
+{
+    unsigned char hash[CC_SHA256_DIGEST_LENGTH];
+    CC_SHA256(storage_path, (CC_LONG)strlen(storage_path), hash);
+
+    unsigned char *bytes = hash + 16;
+
+    bytes[6] = (bytes[6] & 0x0F) | 0x50;
+    bytes[8] = (bytes[8] & 0x3F) | 0x80;
+
+    return [[NSUUID alloc] initWithUUIDBytes:bytes];
+}
+
+void saucerw_cocoa_remove_data_store(const char *storage_path, uintptr_t handle)
+{
+    if (@available(macOS 14.0, *))
+    {
+        [WKWebsiteDataStore removeDataStoreForIdentifier:store_id(storage_path)
+                                       completionHandler:^(NSError *error) {
+                                         if (error)
+                                         {
+                                             saucerwDone(handle, (char *)error.localizedDescription.UTF8String);
+                                             return;
+                                         }
+
+                                         saucerwDone(handle, NULL);
+                                       }];
+        return;
+    }
+
+    saucerwDone(handle, NULL);
+}
@@ -0,0 +1,83 @@
+package saucerw
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// CrashReport describes a panic recovered by the bindings or a fatal error of
+// the native backend.
+type CrashReport struct {
+	// Source names the code that failed, e.g. "exposed function items" or
+	// the backend component of a native error.
+	Source string
+	// Value is the value passed to panic or the message of a native error.
+	Value any
+	// Stack is the stack trace of the panicking goroutine. It is empty for
+	// native errors.
+	Stack []byte
+	// Fatal is set for native errors; the process aborts once the hook
+	// returns.
+	Fatal bool
+}
+
+var crashHook atomic.Pointer[func(CrashReport)]
+
+// OnPanic sets the function receiving crash reports, e.g. to forward them to
+// an error tracker. A nil fn removes it. Reports are logged either way.
+//
+// Panics in exposed functions, event and navigation handlers, scheme handlers
+// and dispatched functions are recovered instead of aborting the process: a
+// call from the page is rejected with the panic message, a scheme request is
+// answered with status 500 and a navigation is blocked. Native fatal errors,
+// such as GTK or Qt fatal log messages and uncaught C++ exceptions, are
+// reported before the process aborts. fn runs synchronously on the failing
+// goroutine or thread.
+func OnPanic(fn func(CrashReport)) {
+	if fn == nil {
+		crashHook.Store(nil)
+		return
+	}
+	crashHook.Store(&fn)
+}
+
+// report logs rep and passes it to the OnPanic hook.
+func report(rep CrashReport) {
+	msg := "recovered from panic"
+	if rep.Fatal {
+		msg = "fatal native error"
+	}
+	log().Error(msg, "component", "saucerw", "source", rep.Source, "value", fmt.Sprint(rep.Value), "stack", string(rep.Stack))
+
+	hook := crashHook.Load()
+	if hook == nil {
+		return
+	}
+
+	// A panicking hook must not take down the caller it protects.
+	defer func() { _ = recover() }()
+	(*hook)(rep)
+}
+
+// reportPanic reports the recovered panic value r.
+func reportPanic(source string, r any) {
+	report(CrashReport{Source: source, Value: r, Stack: debug.Stack()})
+}
+
+// guard reports a panic of the calling function. It has to be deferred
+// directly.
+func guard(source string) {
+	if r := recover(); r != nil {
+		reportPanic(source, r)
+	}
+}
+
+// recoverError reports a panic of the calling function and returns it as *err
+// instead. It has to be deferred directly.
+func recoverError(source string, err *error) {
+	if r := recover(); r != nil {
+		reportPanic(source, r)
+		*err = &PanicError{Source: source, Value: r}
+	}
+}
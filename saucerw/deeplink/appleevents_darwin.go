@@ -0,0 +1,26 @@
+//go:build darwin && cgo && saucer
+
+package deeplink
+
+/*
+#cgo CFLAGS: -fobjc-arc
+#cgo LDFLAGS: -framework Foundation -framework CoreServices
+
+void deeplink_listen(void);
+*/
+import "C"
+
+import "sync"
+
+var appleEvents sync.Once
+
+// listenAppleEvents installs the handler of the kAEGetURL Apple Event, which
+// macOS sends instead of passing URLs on the command line.
+func listenAppleEvents() {
+	appleEvents.Do(func() { C.deeplink_listen() })
+}
+
+//export deeplinkURL
+func deeplinkURL(url *C.char) {
+	deliver(C.GoString(url))
+}
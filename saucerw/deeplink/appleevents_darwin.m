@@ -0,0 +1,36 @@
+//go:build darwin && cgo && saucer
+
+#import <Foundation/Foundation.h>
+#import <CoreServices/CoreServices.h>
+
+#include "_cgo_export.h"
+
+@interface DeeplinkHandler : NSObject
+- (void)handleURL:(NSAppleEventDescriptor *)event withReply:(NSAppleEventDescriptor *)reply;
+@end
+
+@implementation DeeplinkHandler
+- (void)handleURL:(NSAppleEventDescriptor *)event withReply:(NSAppleEventDescriptor *)reply
+{
+    NSString *url = [[event paramDescriptorForKeyword:keyDirectObject] stringValue];
+
+    if (!url)
+    {
+        return;
+    }
+
+    deeplinkURL((char *)[url UTF8String]);
+}
+@end
+
+static DeeplinkHandler *handler;
+
+void deeplink_listen(void)
+{
+    handler = [DeeplinkHandler new];
+
+    [[NSAppleEventManager sharedAppleEventManager] setEventHandler:handler
+                                                       andSelector:@selector(handleURL:withReply:)
+                                                     forEventClass:kInternetEventClass
+                                                        andEventID:kAEGetURL];
+}
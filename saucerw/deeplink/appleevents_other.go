@@ -0,0 +1,6 @@
+//go:build !darwin || !cgo || !saucer
+
+package deeplink
+
+// listenAppleEvents is a no-op, URLs only arrive on the command line.
+func listenAppleEvents() {}
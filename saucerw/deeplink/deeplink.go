@@ -0,0 +1,243 @@
+// Package deeplink registers custom URL schemes such as myapp:// with the
+// operating system and delivers the URLs the application is opened with.
+//
+// Webview.HandleScheme serves schemes inside the page; this package covers
+// activation from outside the application, e.g. a link clicked in a browser.
+// Combined with saucerw.EnsureSingleInstance, links opened while the
+// application runs reach the running instance.
+package deeplink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// ErrUnsupported is returned by Register when the scheme has to be declared
+// by other means, see InfoPlist.
+var ErrUnsupported = errors.New("deeplink: registration is not supported on this system")
+
+// Options describes a URL scheme to register.
+type Options struct {
+	// Scheme is the URL scheme without "://", e.g. "myapp". Required.
+	Scheme string
+	// Name is the human readable application name. Defaults to Scheme.
+	Name string
+	// Executable is the program opened with the URL as its last argument.
+	// Defaults to the running executable.
+	Executable string
+	// DesktopID names the .desktop entry on Linux. Defaults to Scheme.
+	DesktopID string
+}
+
+// normalize validates the options and fills in the defaults.
+func (o *Options) normalize() error {
+	if !validScheme(o.Scheme) {
+		return fmt.Errorf("deeplink: invalid scheme %q", o.Scheme)
+	}
+
+	if o.Name == "" {
+		o.Name = o.Scheme
+	}
+
+	if o.DesktopID == "" {
+		o.DesktopID = o.Scheme
+	}
+
+	if o.Executable == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("deeplink: %w", err)
+		}
+		o.Executable = exe
+	}
+
+	return nil
+}
+
+// validScheme reports whether scheme is a valid URL scheme (RFC 3986).
+func validScheme(scheme string) bool {
+	if scheme == "" || !isAlpha(scheme[0]) {
+		return false
+	}
+
+	for _, c := range []byte(scheme) {
+		if !isAlpha(c) && !('0' <= c && c <= '9') && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAlpha(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// Register makes the operating system open URLs of opts.Scheme with the
+// application, for the current user. It writes registry keys on Windows and a
+// .desktop entry on Linux and other freedesktop systems. On macOS schemes are
+// declared in the Info.plist of the application bundle and Register returns
+// ErrUnsupported.
+func Register(opts Options) error {
+	if err := opts.normalize(); err != nil {
+		return err
+	}
+	return register(&opts)
+}
+
+// Unregister removes a registration made by Register.
+func Unregister(opts Options) error {
+	if err := opts.normalize(); err != nil {
+		return err
+	}
+	return unregister(&opts)
+}
+
+// InfoPlist returns the CFBundleURLTypes entry declaring opts.Scheme, to be
+// added to the Info.plist of a macOS application bundle.
+func InfoPlist(opts Options) string {
+	name := opts.Name
+	if name == "" {
+		name = opts.Scheme
+	}
+
+	return fmt.Sprintf(`<key>CFBundleURLTypes</key>
+<array>
+    <dict>
+        <key>CFBundleURLName</key>
+        <string>%s</string>
+        <key>CFBundleURLSchemes</key>
+        <array>
+            <string>%s</string>
+        </array>
+    </dict>
+</array>
+`, name, opts.Scheme)
+}
+
+// Listener delivers the URLs of a scheme the application is opened with.
+type Listener struct {
+	scheme string
+	urls   chan string
+	done   chan struct{}
+	sub    *saucerw.Subscription
+
+	once sync.Once
+}
+
+var (
+	mu        sync.Mutex
+	listeners = map[*Listener]struct{}{}
+	// pending holds URLs received before a listener for their scheme exists.
+	pending []string
+)
+
+// Listen delivers the URLs of scheme: those this process was started with,
+// those forwarded by later instances to inst, if non-nil, and on macOS those
+// received as Apple Events. It should be called from the main goroutine
+// before the application runs.
+func Listen(scheme string, inst *saucerw.Instance) *Listener {
+	l := &Listener{
+		scheme: strings.ToLower(scheme),
+		urls:   make(chan string, 16),
+		done:   make(chan struct{}),
+	}
+
+	listenAppleEvents()
+
+	mu.Lock()
+	listeners[l] = struct{}{}
+
+	var urls []string
+	pending = slices.DeleteFunc(pending, func(url string) bool {
+		if l.matches(url) {
+			urls = append(urls, url)
+			return true
+		}
+		return false
+	})
+	mu.Unlock()
+
+	for _, arg := range os.Args[1:] {
+		if l.matches(arg) {
+			urls = append(urls, arg)
+		}
+	}
+
+	for _, url := range urls {
+		l.send(url)
+	}
+
+	if inst != nil {
+		l.sub = inst.OnLaunch(func(launch saucerw.Launch) {
+			for _, url := range launch.URLs {
+				if l.matches(url) {
+					l.send(url)
+				}
+			}
+		})
+	}
+
+	return l
+}
+
+// URLs returns the channel receiving the URLs. It is not closed by Close.
+func (l *Listener) URLs() <-chan string {
+	return l.urls
+}
+
+// Close stops delivering URLs.
+func (l *Listener) Close() {
+	l.once.Do(func() {
+		mu.Lock()
+		delete(listeners, l)
+		mu.Unlock()
+
+		if l.sub != nil {
+			l.sub.Cancel()
+		}
+		close(l.done)
+	})
+}
+
+// matches reports whether url belongs to the scheme of l.
+func (l *Listener) matches(url string) bool {
+	scheme, _, ok := strings.Cut(url, ":")
+	return ok && strings.ToLower(scheme) == l.scheme
+}
+
+// send delivers url unless l is closed.
+func (l *Listener) send(url string) {
+	select {
+	case l.urls <- url:
+	case <-l.done:
+	}
+}
+
+// deliver hands a URL received from the system to the matching listeners,
+// or keeps it until one is created.
+func deliver(url string) {
+	mu.Lock()
+
+	var targets []*Listener
+	for l := range listeners {
+		if l.matches(url) {
+			targets = append(targets, l)
+		}
+	}
+
+	if len(targets) == 0 {
+		pending = append(pending, url)
+	}
+	mu.Unlock()
+
+	for _, l := range targets {
+		go l.send(url)
+	}
+}
@@ -0,0 +1,9 @@
+package deeplink
+
+func register(*Options) error {
+	return ErrUnsupported
+}
+
+func unregister(*Options) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,78 @@
+//go:build !windows && !darwin
+
+package deeplink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// desktopFile returns the path of the .desktop entry of opts.
+func desktopFile(opts *Options) (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "applications", opts.DesktopID+".desktop"), nil
+}
+
+// desktopQuote quotes an argument of the Exec key of a desktop entry.
+func desktopQuote(arg string) string {
+	r := strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", "$", `\\$`)
+	return `"` + r.Replace(arg) + `"`
+}
+
+func register(opts *Options) error {
+	name, err := desktopFile(opts)
+	if err != nil {
+		return fmt.Errorf("deeplink: %w", err)
+	}
+
+	entry := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=%s
+Exec=%s %%u
+NoDisplay=true
+Terminal=false
+MimeType=x-scheme-handler/%s;
+`, opts.Name, desktopQuote(opts.Executable), opts.Scheme)
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("deeplink: %w", err)
+	}
+
+	if err := os.WriteFile(name, []byte(entry), 0o644); err != nil {
+		return fmt.Errorf("deeplink: %w", err)
+	}
+
+	// Refreshing the cache is optional, not every desktop ships the tool.
+	_ = exec.Command("update-desktop-database", filepath.Dir(name)).Run()
+
+	out, err := exec.Command("xdg-mime", "default", filepath.Base(name), "x-scheme-handler/"+opts.Scheme).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("deeplink: xdg-mime: %w: %s", err, out)
+	}
+	return nil
+}
+
+func unregister(opts *Options) error {
+	name, err := desktopFile(opts)
+	if err != nil {
+		return fmt.Errorf("deeplink: %w", err)
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("deeplink: %w", err)
+	}
+
+	_ = exec.Command("update-desktop-database", filepath.Dir(name)).Run()
+	return nil
+}
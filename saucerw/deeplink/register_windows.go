@@ -0,0 +1,86 @@
+package deeplink
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32         = syscall.NewLazyDLL("advapi32.dll")
+	procRegCreateKey = advapi32.NewProc("RegCreateKeyExW")
+	procRegSetValue  = advapi32.NewProc("RegSetValueExW")
+	procRegDeleteKey = advapi32.NewProc("RegDeleteTreeW")
+)
+
+// classesKey returns the per-user registry key of opts.Scheme.
+func classesKey(opts *Options) string {
+	return `Software\Classes\` + opts.Scheme
+}
+
+func register(opts *Options) error {
+	key := classesKey(opts)
+	command := fmt.Sprintf(`"%s" "%%1"`, opts.Executable)
+
+	values := []struct{ key, name, value string }{
+		{key, "", "URL:" + opts.Name},
+		{key, "URL Protocol", ""},
+		{key + `\shell\open\command`, "", command},
+	}
+
+	for _, v := range values {
+		if err := setString(v.key, v.name, v.value); err != nil {
+			return fmt.Errorf("deeplink: %s: %w", v.key, err)
+		}
+	}
+
+	return nil
+}
+
+func unregister(opts *Options) error {
+	key, err := syscall.UTF16PtrFromString(classesKey(opts))
+	if err != nil {
+		return err
+	}
+
+	r, _, _ := procRegDeleteKey.Call(uintptr(syscall.HKEY_CURRENT_USER), uintptr(unsafe.Pointer(key)))
+	if r != 0 && syscall.Errno(r) != syscall.ERROR_FILE_NOT_FOUND {
+		return fmt.Errorf("deeplink: %w", syscall.Errno(r))
+	}
+	return nil
+}
+
+// setString creates path below HKEY_CURRENT_USER and sets its REG_SZ value
+// name.
+func setString(path, name, value string) error {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+
+	var key syscall.Handle
+
+	r, _, _ := procRegCreateKey.Call(uintptr(syscall.HKEY_CURRENT_USER), uintptr(unsafe.Pointer(p)), 0, 0, 0,
+		uintptr(syscall.KEY_WRITE), 0, uintptr(unsafe.Pointer(&key)), 0)
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	defer syscall.RegCloseKey(key)
+
+	n, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := syscall.UTF16FromString(value)
+	if err != nil {
+		return err
+	}
+
+	r, _, _ = procRegSetValue.Call(uintptr(key), uintptr(unsafe.Pointer(n)), 0, uintptr(syscall.REG_SZ),
+		uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)*2))
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
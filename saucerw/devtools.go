@@ -0,0 +1,61 @@
+package saucerw
+
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+// DevToolsEnv is the environment variable overriding Preferences.DevTools,
+// parsed with strconv.ParseBool. It allows enabling the developer tools of a
+// shipped application in the field.
+const DevToolsEnv = "SAUCERW_DEVTOOLS"
+
+// ErrDevToolsDisabled is returned by OpenDevTools when the developer tools
+// are disabled.
+var ErrDevToolsDisabled = errors.New("saucerw: developer tools are disabled")
+
+// devTools reports whether the developer tools are enabled, honoring
+// DevToolsEnv.
+func (p *Preferences) devTools() bool {
+	if enabled, err := strconv.ParseBool(os.Getenv(DevToolsEnv)); err == nil {
+		return enabled
+	}
+	return p.DevTools
+}
+
+// DevTools reports whether the developer tools are enabled.
+func (v *Webview) DevTools() bool {
+	return v.devTools.Load()
+}
+
+// SetDevTools enables or disables the developer tools. Disabling them closes
+// them if they are open.
+func (v *Webview) SetDevTools(enabled bool) {
+	v.devTools.Store(enabled)
+
+	if !enabled {
+		v.native.SetDevTools(false)
+	}
+}
+
+// OpenDevTools opens the developer tools. It returns ErrDevToolsDisabled if
+// they are disabled.
+func (v *Webview) OpenDevTools() error {
+	if !v.devTools.Load() {
+		return ErrDevToolsDisabled
+	}
+
+	v.native.SetDevTools(true)
+	return nil
+}
+
+// CloseDevTools closes the developer tools.
+func (v *Webview) CloseDevTools() {
+	v.native.SetDevTools(false)
+}
+
+// DevToolsOpen reports whether the developer tools are open.
+func (v *Webview) DevToolsOpen() bool {
+	return v.native.DevTools()
+}
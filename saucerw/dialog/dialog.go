@@ -0,0 +1,102 @@
+// Package dialog shows the native dialogs for opening and saving files and
+// picking folders, which pages cannot reach through <input type=file>.
+//
+// The dialogs come from the XDG desktop portal on Linux and other
+// freedesktop systems, the common item dialogs on Windows and the open and
+// save panels on macOS. The functions block until the user closed the
+// dialog; call them from a goroutine, never from the event loop thread.
+//
+//	path, err := dialog.OpenFile(ctx, dialog.Options{
+//		Title:   "Open image",
+//		Filters: []dialog.Filter{{Name: "Images", Patterns: []string{"*.png", "*.jpg"}}},
+//	})
+//	if errors.Is(err, dialog.ErrCancelled) {
+//		return
+//	}
+package dialog
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrCancelled is returned when the user closed the dialog without
+	// choosing.
+	ErrCancelled = errors.New("dialog: cancelled")
+	// ErrUnsupported is returned when the system provides no dialogs or the
+	// backend is not compiled in.
+	ErrUnsupported = errors.New("dialog: dialogs are not supported")
+)
+
+// Options configure a dialog.
+type Options struct {
+	// Title is the title of the dialog window. Optional.
+	Title string
+	// Path is the folder shown first or, for SaveFile, the suggested file.
+	// Optional.
+	Path string
+	// Filters restrict the files shown, the first one is selected. Ignored
+	// by PickFolder.
+	Filters []Filter
+}
+
+// Filter is a named set of glob patterns such as "*.png".
+type Filter struct {
+	Name     string
+	Patterns []string
+}
+
+// kind is the type of dialog.
+type kind int
+
+const (
+	openFile kind = iota
+	openFiles
+	saveFile
+	pickFolder
+)
+
+// OpenFile asks for an existing file.
+func OpenFile(ctx context.Context, opts Options) (string, error) {
+	return first(show(ctx, openFile, &opts))
+}
+
+// OpenFiles asks for one or more existing files.
+func OpenFiles(ctx context.Context, opts Options) ([]string, error) {
+	return show(ctx, openFiles, &opts)
+}
+
+// SaveFile asks for the path to save a file to. The dialog confirms
+// overwriting existing files.
+func SaveFile(ctx context.Context, opts Options) (string, error) {
+	return first(show(ctx, saveFile, &opts))
+}
+
+// PickFolder asks for an existing folder.
+func PickFolder(ctx context.Context, opts Options) (string, error) {
+	return first(show(ctx, pickFolder, &opts))
+}
+
+// show runs the dialog and maps an empty choice to ErrCancelled.
+func show(ctx context.Context, k kind, opts *Options) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	paths, err := pick(ctx, k, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, ErrCancelled
+	}
+	return paths, nil
+}
+
+func first(paths []string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
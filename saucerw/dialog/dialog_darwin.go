@@ -0,0 +1,72 @@
+//go:build darwin && cgo && saucer
+
+package dialog
+
+/*
+#cgo CFLAGS: -fobjc-arc
+#cgo LDFLAGS: -framework AppKit -framework UniformTypeIdentifiers
+
+#include <stdint.h>
+#include <stdlib.h>
+
+void dialog_show(uintptr_t handle, int kind, const char *title, const char *path, size_t count, char **extensions);
+void dialog_cancel(uintptr_t handle);
+*/
+import "C"
+
+import (
+	"context"
+	"runtime/cgo"
+	"strings"
+	"unsafe"
+)
+
+//export dialogResult
+func dialogResult(handle C.uintptr_t, count C.size_t, paths **C.char) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+
+	rtn := make([]string, 0, count)
+	for _, path := range unsafe.Slice(paths, count) {
+		rtn = append(rtn, C.GoString(path))
+	}
+	h.Value().(chan []string) <- rtn
+}
+
+func pick(ctx context.Context, k kind, opts *Options) ([]string, error) {
+	title := C.CString(opts.Title)
+	defer C.free(unsafe.Pointer(title))
+
+	path := C.CString(opts.Path)
+	defer C.free(unsafe.Pointer(path))
+
+	// The panels filter by content type, which are derived from the
+	// extensions of the patterns.
+	var extensions []*C.char
+	if k != pickFolder {
+		for _, filter := range opts.Filters {
+			for _, pattern := range filter.Patterns {
+				ext := strings.TrimPrefix(pattern, "*.")
+				if ext == pattern || strings.ContainsAny(ext, "*?[") {
+					continue
+				}
+				extensions = append(extensions, C.CString(ext))
+				defer C.free(unsafe.Pointer(extensions[len(extensions)-1]))
+			}
+		}
+	}
+
+	ch := make(chan []string, 1)
+	handle := C.uintptr_t(cgo.NewHandle(ch))
+	C.dialog_show(handle, C.int(k), title, path, C.size_t(len(extensions)), unsafe.SliceData(extensions))
+
+	select {
+	case paths := <-ch:
+		return paths, nil
+	case <-ctx.Done():
+		// The panel still reports, with nothing chosen.
+		C.dialog_cancel(handle)
+		<-ch
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,149 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <UniformTypeIdentifiers/UniformTypeIdentifiers.h>
+
+#include "_cgo_export.h"
+
+// Matches kind in dialog.go.
+enum
+{
+    DIALOG_OPEN_FILE,
+    DIALOG_OPEN_FILES,
+    DIALOG_SAVE_FILE,
+    DIALOG_PICK_FOLDER,
+};
+
+// The open panels, keyed by handle. Only accessed on the main queue.
+static NSMutableDictionary<NSNumber *, NSSavePanel *> *panels;
+
+static void finish(uintptr_t handle, NSArray<NSURL *> *urls)
+{
+    [panels removeObjectForKey:@(handle)];
+
+    NSMutableArray<NSString *> *strings = [NSMutableArray arrayWithCapacity:urls.count];
+    for (NSURL *url in urls)
+    {
+        if (url.fileURL)
+        {
+            [strings addObject:url.path];
+        }
+    }
+
+    char **paths = malloc(sizeof(char *) * (strings.count + 1));
+    for (NSUInteger i = 0; i < strings.count; i++)
+    {
+        paths[i] = (char *)strings[i].fileSystemRepresentation;
+    }
+
+    dialogResult(handle, strings.count, paths);
+    free(paths);
+}
+
+static NSSavePanel *panel_new(int kind, NSString *path, NSArray<NSString *> *extensions)
+{
+    NSSavePanel *panel;
+
+    if (kind == DIALOG_SAVE_FILE)
+    {
+        panel = [NSSavePanel savePanel];
+        panel.canCreateDirectories = YES;
+
+        if (path.length > 0 && ![path hasSuffix:@"/"])
+        {
+            panel.nameFieldStringValue = path.lastPathComponent;
+            path                       = path.stringByDeletingLastPathComponent;
+        }
+    }
+    else
+    {
+        NSOpenPanel *open            = [NSOpenPanel openPanel];
+        open.canChooseFiles          = kind != DIALOG_PICK_FOLDER;
+        open.canChooseDirectories    = kind == DIALOG_PICK_FOLDER;
+        open.allowsMultipleSelection = kind == DIALOG_OPEN_FILES;
+        panel                        = open;
+    }
+
+    if (path.length > 0)
+    {
+        panel.directoryURL = [NSURL fileURLWithPath:path isDirectory:YES];
+    }
+
+    if (extensions.count > 0)
+    {
+        NSMutableArray<UTType *> *types = [NSMutableArray array];
+        for (NSString *extension in extensions)
+        {
+            UTType *type = [UTType typeWithFilenameExtension:extension];
+            if (type)
+            {
+                [types addObject:type];
+            }
+        }
+        panel.allowedContentTypes = types;
+    }
+
+    return panel;
+}
+
+void dialog_show(uintptr_t handle, int kind, const char *title, const char *path, size_t count, char **extensions)
+{
+    // Copied before returning, the panel is shown asynchronously
+    NSString *message = [NSString stringWithUTF8String:title];
+    NSString *folder  = [NSString stringWithUTF8String:path];
+
+    NSMutableArray<NSString *> *types = [NSMutableArray arrayWithCapacity:count];
+    for (size_t i = 0; i < count; i++)
+    {
+        [types addObject:[NSString stringWithUTF8String:extensions[i]]];
+    }
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+      if (!panels)
+      {
+          panels = [NSMutableDictionary dictionary];
+      }
+
+      NSSavePanel *panel = panel_new(kind, folder, types);
+      if (message.length > 0)
+      {
+          panel.title   = message;
+          panel.message = message;
+      }
+
+      panels[@(handle)] = panel;
+
+      void (^completion)(NSModalResponse) = ^(NSModalResponse response) {
+        if (response != NSModalResponseOK)
+        {
+            finish(handle, @[]);
+            return;
+        }
+
+        if (kind == DIALOG_SAVE_FILE)
+        {
+            finish(handle, panel.URL ? @[ panel.URL ] : @[]);
+            return;
+        }
+
+        finish(handle, ((NSOpenPanel *)panel).URLs);
+      };
+
+      NSWindow *parent = NSApp.keyWindow;
+      if (parent)
+      {
+          [panel beginSheetModalForWindow:parent completionHandler:completion];
+      }
+      else
+      {
+          [panel beginWithCompletionHandler:completion];
+      }
+    });
+}
+
+void dialog_cancel(uintptr_t handle)
+{
+    dispatch_async(dispatch_get_main_queue(), ^{
+      [panels[@(handle)] cancel:nil];
+    });
+}
@@ -0,0 +1,10 @@
+//go:build darwin && !(cgo && saucer)
+
+package dialog
+
+import "context"
+
+// pick fails, the panels need the AppKit event loop of saucer.
+func pick(context.Context, kind, *Options) ([]string, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,297 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	ole32   = syscall.NewLazyDLL("ole32.dll")
+	shell32 = syscall.NewLazyDLL("shell32.dll")
+
+	procCoInitializeEx              = ole32.NewProc("CoInitializeEx")
+	procCoUninitialize              = ole32.NewProc("CoUninitialize")
+	procCoCreateInstance            = ole32.NewProc("CoCreateInstance")
+	procCoTaskMemFree               = ole32.NewProc("CoTaskMemFree")
+	procSHCreateItemFromParsingName = shell32.NewProc("SHCreateItemFromParsingName")
+)
+
+const (
+	coinitApartmentThreaded = 0x2
+	coinitDisableOLE1DDE    = 0x4
+	clsctxInprocServer      = 0x1
+
+	errorCancelled = 0x800704c7 // HRESULT_FROM_WIN32(ERROR_CANCELLED)
+
+	sigdnFileSysPath = 0x80058000
+
+	fosOverwritePrompt  = 0x2
+	fosPickFolders      = 0x20
+	fosForceFileSystem  = 0x40
+	fosAllowMultiselect = 0x200
+	fosPathMustExist    = 0x800
+	fosFileMustExist    = 0x1000
+)
+
+var (
+	clsidFileOpenDialog = syscall.GUID{Data1: 0xdc1c5a9c, Data2: 0xe88a, Data3: 0x4dde, Data4: [8]byte{0xa5, 0xa1, 0x60, 0xf8, 0x2a, 0x20, 0xae, 0xf7}}
+	clsidFileSaveDialog = syscall.GUID{Data1: 0xc0b4e2f3, Data2: 0xba21, Data3: 0x4773, Data4: [8]byte{0x8d, 0xba, 0x33, 0x5e, 0xc9, 0x46, 0xeb, 0x8b}}
+	iidFileOpenDialog   = syscall.GUID{Data1: 0xd57c7288, Data2: 0xd4ad, Data3: 0x4768, Data4: [8]byte{0xbe, 0x02, 0x9d, 0x96, 0x95, 0x32, 0xd9, 0x60}}
+	iidFileSaveDialog   = syscall.GUID{Data1: 0x84bccd23, Data2: 0x5fde, Data3: 0x4cdb, Data4: [8]byte{0xae, 0xa4, 0xaf, 0x64, 0xb8, 0x3d, 0x78, 0xab}}
+	iidShellItem        = syscall.GUID{Data1: 0x43826d1e, Data2: 0xe718, Data3: 0x42ee, Data4: [8]byte{0xbc, 0x55, 0xa1, 0xe2, 0x61, 0xc3, 0x7b, 0xfe}}
+)
+
+// The vtable indexes of the methods used.
+const (
+	methodRelease = 2
+
+	// IFileDialog and IFileOpenDialog.
+	methodShow         = 3
+	methodSetFileTypes = 4
+	methodSetOptions   = 9
+	methodGetOptions   = 10
+	methodSetFolder    = 12
+	methodSetFileName  = 15
+	methodSetTitle     = 17
+	methodGetResult    = 20
+	methodGetResults   = 27
+
+	// IShellItem and IShellItemArray.
+	methodGetDisplayName = 5
+	methodGetCount       = 7
+	methodGetItemAt      = 8
+)
+
+// object is a COM interface pointer.
+type object struct {
+	vtbl *[32]uintptr
+}
+
+func (o *object) call(method int, args ...uintptr) uint32 {
+	r, _, _ := syscall.SyscallN(o.vtbl[method], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...)
+	return uint32(r)
+}
+
+func (o *object) release() {
+	o.call(methodRelease)
+}
+
+// failed reports whether hr is an error HRESULT.
+func failed(hr uint32) bool {
+	return int32(hr) < 0
+}
+
+// filterSpec is a COMDLG_FILTERSPEC.
+type filterSpec struct {
+	name, spec *uint16
+}
+
+func pick(ctx context.Context, k kind, opts *Options) ([]string, error) {
+	if err := ole32.Load(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	type result struct {
+		paths []string
+		err   error
+	}
+
+	// The dialog needs a single threaded apartment of its own.
+	ch := make(chan result, 1)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		paths, err := run(k, opts)
+		ch <- result{paths, err}
+	}()
+
+	// The dialog is modal and cannot be closed from another thread, it
+	// stays open when ctx ends.
+	select {
+	case r := <-ch:
+		return r.paths, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run shows the dialog on the current thread.
+func run(k kind, opts *Options) ([]string, error) {
+	if hr, _, _ := procCoInitializeEx.Call(0, coinitApartmentThreaded|coinitDisableOLE1DDE); failed(uint32(hr)) {
+		return nil, fmt.Errorf("dialog: CoInitializeEx: HRESULT %#x", uint32(hr))
+	}
+	defer procCoUninitialize.Call()
+
+	clsid, iid := &clsidFileOpenDialog, &iidFileOpenDialog
+	if k == saveFile {
+		clsid, iid = &clsidFileSaveDialog, &iidFileSaveDialog
+	}
+
+	var dialog *object
+	if hr, _, _ := procCoCreateInstance.Call(uintptr(unsafe.Pointer(clsid)), 0, clsctxInprocServer, uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&dialog))); failed(uint32(hr)) {
+		return nil, fmt.Errorf("%w: HRESULT %#x", ErrUnsupported, uint32(hr))
+	}
+	defer dialog.release()
+
+	if err := configure(dialog, k, opts); err != nil {
+		return nil, err
+	}
+
+	switch hr := dialog.call(methodShow, 0); {
+	case hr == errorCancelled:
+		return nil, ErrCancelled
+	case failed(hr):
+		return nil, fmt.Errorf("dialog: show: HRESULT %#x", hr)
+	}
+
+	if k == openFiles {
+		return results(dialog)
+	}
+
+	var item *object
+	if hr := dialog.call(methodGetResult, uintptr(unsafe.Pointer(&item))); failed(hr) {
+		return nil, fmt.Errorf("dialog: result: HRESULT %#x", hr)
+	}
+	defer item.release()
+
+	path, err := displayName(item)
+	if err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+// configure applies opts to the dialog.
+func configure(dialog *object, k kind, opts *Options) error {
+	var options uint32
+	dialog.call(methodGetOptions, uintptr(unsafe.Pointer(&options)))
+
+	options |= fosForceFileSystem | fosPathMustExist
+	switch k {
+	case openFile:
+		options |= fosFileMustExist
+	case openFiles:
+		options |= fosFileMustExist | fosAllowMultiselect
+	case saveFile:
+		options |= fosOverwritePrompt
+	case pickFolder:
+		options |= fosPickFolders
+	}
+	dialog.call(methodSetOptions, uintptr(options))
+
+	if opts.Title != "" {
+		title, err := syscall.UTF16PtrFromString(opts.Title)
+		if err != nil {
+			return err
+		}
+		dialog.call(methodSetTitle, uintptr(unsafe.Pointer(title)))
+	}
+
+	if k != pickFolder && len(opts.Filters) > 0 {
+		specs := make([]filterSpec, 0, len(opts.Filters))
+		for _, filter := range opts.Filters {
+			name, err := syscall.UTF16PtrFromString(filter.Name)
+			if err != nil {
+				return err
+			}
+			spec, err := syscall.UTF16PtrFromString(strings.Join(filter.Patterns, ";"))
+			if err != nil {
+				return err
+			}
+			specs = append(specs, filterSpec{name, spec})
+		}
+		dialog.call(methodSetFileTypes, uintptr(len(specs)), uintptr(unsafe.Pointer(&specs[0])))
+	}
+
+	folder := opts.Path
+	if k == saveFile && opts.Path != "" {
+		dir, name := filepath.Split(opts.Path)
+		if name != "" {
+			file, err := syscall.UTF16PtrFromString(name)
+			if err != nil {
+				return err
+			}
+			dialog.call(methodSetFileName, uintptr(unsafe.Pointer(file)))
+		}
+		folder = dir
+	}
+
+	if folder != "" {
+		if item := shellItem(folder); item != nil {
+			dialog.call(methodSetFolder, uintptr(unsafe.Pointer(item)))
+			item.release()
+		}
+	}
+
+	return nil
+}
+
+// shellItem returns the shell item of path, nil if it does not exist.
+func shellItem(path string) *object {
+	p, err := syscall.UTF16PtrFromString(filepath.Clean(path))
+	if err != nil {
+		return nil
+	}
+
+	var item *object
+	if hr, _, _ := procSHCreateItemFromParsingName.Call(uintptr(unsafe.Pointer(p)), 0, uintptr(unsafe.Pointer(&iidShellItem)), uintptr(unsafe.Pointer(&item))); failed(uint32(hr)) {
+		return nil
+	}
+	return item
+}
+
+// results returns the paths chosen in a multi-select dialog.
+func results(dialog *object) ([]string, error) {
+	var items *object
+	if hr := dialog.call(methodGetResults, uintptr(unsafe.Pointer(&items))); failed(hr) {
+		return nil, fmt.Errorf("dialog: results: HRESULT %#x", hr)
+	}
+	defer items.release()
+
+	var count uint32
+	items.call(methodGetCount, uintptr(unsafe.Pointer(&count)))
+
+	rtn := make([]string, 0, count)
+	for i := range count {
+		var item *object
+		if hr := items.call(methodGetItemAt, uintptr(i), uintptr(unsafe.Pointer(&item))); failed(hr) {
+			return nil, fmt.Errorf("dialog: result %d: HRESULT %#x", i, hr)
+		}
+
+		path, err := displayName(item)
+		item.release()
+
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, path)
+	}
+
+	return rtn, nil
+}
+
+// displayName returns the file system path of item.
+func displayName(item *object) (string, error) {
+	var name *uint16
+	if hr := item.call(methodGetDisplayName, sigdnFileSysPath, uintptr(unsafe.Pointer(&name))); failed(hr) {
+		return "", fmt.Errorf("dialog: path: HRESULT %#x", hr)
+	}
+	defer procCoTaskMemFree.Call(uintptr(unsafe.Pointer(name)))
+
+	return syscall.UTF16ToString(unsafe.Slice(name, wcslen(name))), nil
+}
+
+// wcslen returns the length of the null terminated string s.
+func wcslen(s *uint16) int {
+	n := 0
+	for p := unsafe.Pointer(s); *(*uint16)(p) != 0; p = unsafe.Add(p, 2) {
+		n++
+	}
+	return n
+}
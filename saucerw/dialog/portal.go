@@ -0,0 +1,207 @@
+//go:build !windows && !darwin
+
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aperturerobotics/saucer/saucerw/internal/dbus"
+)
+
+// The file chooser of the XDG desktop portal.
+const (
+	portalName    = "org.freedesktop.portal.Desktop"
+	portalPath    = "/org/freedesktop/portal/desktop"
+	chooserIface  = "org.freedesktop.portal.FileChooser"
+	requestIface  = "org.freedesktop.portal.Request"
+	responseOK    = 0
+	responseAbort = 1
+)
+
+// portal is the session bus connection with the requests waiting for their
+// response.
+type portal struct {
+	conn *dbus.Conn
+
+	mu      sync.Mutex
+	waiting map[dbus.ObjectPath]chan []any
+}
+
+var (
+	portalOnce sync.Once
+	shared     *portal
+	sharedErr  error
+
+	tokens atomic.Uint64
+)
+
+// connect returns the shared connection, connecting on first use.
+func connect() (*portal, error) {
+	portalOnce.Do(func() {
+		conn, err := dbus.SessionBus()
+		if err != nil {
+			sharedErr = fmt.Errorf("%w: %w", ErrUnsupported, err)
+			return
+		}
+
+		shared = &portal{conn: conn, waiting: map[dbus.ObjectPath]chan []any{}}
+		conn.Handle(shared.handle)
+	})
+	return shared, sharedErr
+}
+
+// handle delivers Response signals. It runs on the goroutine reading the
+// connection.
+func (p *portal) handle(msg *dbus.Message) {
+	if msg.Type != dbus.TypeSignal || msg.Interface != requestIface || msg.Member != "Response" {
+		return
+	}
+
+	p.mu.Lock()
+	ch := p.waiting[msg.Path]
+	p.mu.Unlock()
+
+	if ch != nil {
+		select {
+		case ch <- msg.Body:
+		default:
+		}
+	}
+}
+
+func pick(ctx context.Context, k kind, opts *Options) ([]string, error) {
+	p, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	// The request object path is known in advance, so the response cannot
+	// arrive before the match is in place.
+	token := "saucerw" + strconv.FormatUint(tokens.Add(1), 10)
+	sender := strings.ReplaceAll(strings.TrimPrefix(p.conn.Name(), ":"), ".", "_")
+	path := dbus.ObjectPath(portalPath + "/request/" + sender + "/" + token)
+
+	match := fmt.Sprintf("type='signal',interface='%s',member='Response',path='%s'", requestIface, path)
+	if _, err := p.conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", match); err != nil {
+		return nil, fmt.Errorf("dialog: %w", err)
+	}
+	defer p.conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "RemoveMatch", "s", match)
+
+	ch := make(chan []any, 1)
+
+	p.mu.Lock()
+	p.waiting[path] = ch
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.waiting, path)
+		p.mu.Unlock()
+	}()
+
+	method, options := request(k, opts)
+	options["handle_token"] = dbus.MakeVariant("s", token)
+
+	if _, err := p.conn.Call(portalName, portalPath, chooserIface, method, "ssa{sv}", "", opts.Title, options); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	select {
+	case body := <-ch:
+		return response(body)
+	case <-ctx.Done():
+		_, _ = p.conn.Call(portalName, path, requestIface, "Close", "")
+		return nil, ctx.Err()
+	case <-p.conn.Done():
+		return nil, fmt.Errorf("dialog: %w", dbus.ErrClosed)
+	}
+}
+
+// request returns the method and options of the portal call for k.
+func request(k kind, opts *Options) (string, map[string]dbus.Variant) {
+	options := map[string]dbus.Variant{
+		"modal": dbus.MakeVariant("b", true),
+	}
+
+	if k != pickFolder && len(opts.Filters) > 0 {
+		var filters []any
+		for _, filter := range opts.Filters {
+			var patterns []any
+			for _, pattern := range filter.Patterns {
+				patterns = append(patterns, []any{uint32(0), pattern})
+			}
+			filters = append(filters, []any{filter.Name, patterns})
+		}
+
+		options["filters"] = dbus.MakeVariant("a(sa(us))", filters)
+		options["current_filter"] = dbus.MakeVariant("(sa(us))", filters[0])
+	}
+
+	switch k {
+	case openFiles:
+		options["multiple"] = dbus.MakeVariant("b", true)
+	case pickFolder:
+		options["directory"] = dbus.MakeVariant("b", true)
+	}
+
+	if k == saveFile {
+		dir, name := filepath.Split(opts.Path)
+		if name != "" {
+			options["current_name"] = dbus.MakeVariant("s", name)
+		}
+		if dir != "" {
+			options["current_folder"] = dbus.MakeVariant("ay", bytestring(dir))
+		}
+		return "SaveFile", options
+	}
+
+	if opts.Path != "" {
+		options["current_folder"] = dbus.MakeVariant("ay", bytestring(opts.Path))
+	}
+	return "OpenFile", options
+}
+
+// bytestring returns path as the null terminated byte string of the portal.
+func bytestring(path string) []byte {
+	return append([]byte(path), 0)
+}
+
+// response returns the paths chosen in a Response signal.
+func response(body []any) ([]string, error) {
+	if len(body) != 2 {
+		return nil, errors.New("dialog: invalid portal response")
+	}
+
+	switch code, _ := body[0].(uint32); code {
+	case responseOK:
+	case responseAbort:
+		return nil, ErrCancelled
+	default:
+		return nil, fmt.Errorf("dialog: portal failed with response %d", code)
+	}
+
+	results, _ := body[1].(map[string]any)
+	uris, _ := results["uris"].(dbus.Variant)
+	values, _ := uris.Value.([]any)
+
+	var rtn []string
+	for _, value := range values {
+		uri, _ := value.(string)
+
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Scheme != "file" {
+			return nil, fmt.Errorf("dialog: unsupported portal uri %q", uri)
+		}
+		rtn = append(rtn, parsed.Path)
+	}
+
+	return rtn, nil
+}
@@ -0,0 +1,97 @@
+package saucerw
+
+import "errors"
+
+var (
+	// ErrNotRunning is returned by calls that need the event loop thread when
+	// they are made from another goroutine while the event loop is not
+	// running.
+	ErrNotRunning = errors.New("saucerw: event loop is not running")
+	// ErrLoopThread is returned by calls that wait for the event loop when
+	// they are made from its thread.
+	ErrLoopThread = errors.New("saucerw: call would block the event loop thread")
+)
+
+// onLoop reports whether the caller runs on the event loop thread.
+func (a *Application) onLoop() bool {
+	return a.native.ThreadSafe()
+}
+
+// checkLoop returns ErrNotRunning if a call that blocks on the event loop
+// thread would never return.
+func (a *Application) checkLoop() error {
+	if a.running.Load() || a.onLoop() {
+		return nil
+	}
+	return ErrNotRunning
+}
+
+// checkWait returns the error of a call waiting for an asynchronous result of
+// the event loop that would never return.
+func (a *Application) checkWait() error {
+	if a.onLoop() {
+		return ErrLoopThread
+	}
+	if !a.running.Load() {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+// Dispatch runs fn on the event loop thread and waits for it to return. It
+// runs fn directly when called from the event loop thread.
+//
+// A panic in fn is recovered and returned as an error instead of crashing the
+// native event loop. Dispatch returns ErrNotRunning if the event loop is not
+// running and the caller is not on its thread, or if it quits before running
+// fn.
+func (a *Application) Dispatch(fn func()) error {
+	_, err := DispatchResult(a, func() struct{} {
+		fn()
+		return struct{}{}
+	})
+	return err
+}
+
+// DispatchResult runs fn on the event loop thread of app like Dispatch and
+// returns its result.
+func DispatchResult[T any](app *Application, fn func() T) (T, error) {
+	if app.onLoop() {
+		return protect(fn)
+	}
+
+	var zero T
+	if !app.running.Load() {
+		return zero, ErrNotRunning
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+
+	done := make(chan result, 1)
+	app.native.Post(func() {
+		value, err := protect(fn)
+		done <- result{value, err}
+	})
+
+	// The loop may quit before it runs fn
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-app.stopped:
+		select {
+		case res := <-done:
+			return res.value, res.err
+		default:
+			return zero, ErrNotRunning
+		}
+	}
+}
+
+// protect calls fn, converting a panic into an error.
+func protect[T any](fn func() T) (rtn T, err error) {
+	defer recoverError("dispatched function", &err)
+	return fn(), nil
+}
@@ -0,0 +1,45 @@
+package saucerw_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// TestDispatchQuit checks that a dispatch pending when the event loop quits
+// returns instead of waiting for a function the loop drops.
+func TestDispatchQuit(t *testing.T) {
+	app, err := saucerw.NewApplicationWithDriver(saucertest.New(), saucerw.AppOptions{ID: "com.example.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatching := make(chan struct{})
+	result := make(chan error, 1)
+
+	app.Run(func(app *saucerw.Application) {
+		go func() {
+			close(dispatching)
+			result <- app.Dispatch(func() { t.Error("dispatched function ran after quitting") })
+		}()
+
+		// Blocks the loop while the dispatch is posted, then quits it
+		app.Post(func() {
+			<-dispatching
+			time.Sleep(20 * time.Millisecond)
+			app.Quit()
+		})
+	})
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, saucerw.ErrNotRunning) {
+			t.Errorf("dispatch returned %v, expected %v", err, saucerw.ErrNotRunning)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatch still pending after the loop quit")
+	}
+}
@@ -0,0 +1,272 @@
+// Package display runs a virtual display server, so windows and webviews can
+// be created on machines without a screen, e.g. for integration tests on CI
+// or rendering thumbnails and PDFs on a server.
+//
+// On Linux and other X11 systems Start launches Xvfb or a headless Weston and
+// returns the environment connecting to it. Windows and macOS always have a
+// display session, Available reports true there.
+//
+//	if !display.Available() {
+//		d, err := display.Start(ctx, display.Options{})
+//		if err != nil {
+//			return err
+//		}
+//		defer d.Close()
+//
+//		if err := d.Setenv(); err != nil {
+//			return err
+//		}
+//	}
+//
+// The environment has to be set before the application is created, the
+// toolkit connects to the display once. saucerw.AppOptions.Headless does
+// this for NewApplication.
+package display
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrNoServer is returned by Start if neither Xvfb nor weston is installed.
+var ErrNoServer = errors.New("display: no virtual display server found, install Xvfb or weston")
+
+// Server is a virtual display server.
+type Server string
+
+// Servers started by Start.
+const (
+	// Auto starts Xvfb if installed and weston otherwise.
+	Auto Server = ""
+	// Xvfb is the virtual framebuffer X server.
+	Xvfb Server = "xvfb"
+	// Weston is the reference Wayland compositor with its headless backend.
+	Weston Server = "weston"
+)
+
+// Options configures Start.
+type Options struct {
+	// Server is the server to start, Auto if empty.
+	Server Server
+	// Width and Height are the size of the screen, 1920x1080 if zero.
+	Width, Height int
+	// Log receives the output of the server. Optional.
+	Log io.Writer
+}
+
+// Display is a running virtual display server.
+type Display struct {
+	// Server is the server running.
+	Server Server
+	// Env holds the environment variables connecting to the display, e.g.
+	// "DISPLAY=:99".
+	Env []string
+
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+	dir  string
+}
+
+// Available reports whether the process can connect to a display: on X11
+// systems if DISPLAY or WAYLAND_DISPLAY is set, always on Windows and macOS.
+func Available() bool {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "android":
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// Start starts a virtual display server and waits until it accepts
+// connections, ctx bounding the wait. The server runs until Close.
+func Start(ctx context.Context, opts Options) (*Display, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		opts.Width, opts.Height = 1920, 1080
+	}
+
+	server := opts.Server
+	if server == Auto {
+		switch {
+		case lookPath("Xvfb"):
+			server = Xvfb
+		case lookPath("weston"):
+			server = Weston
+		default:
+			return nil, ErrNoServer
+		}
+	}
+
+	switch server {
+	case Xvfb:
+		return startXvfb(ctx, opts)
+	case Weston:
+		return startWeston(ctx, opts)
+	}
+	return nil, fmt.Errorf("display: unknown server %q", server)
+}
+
+// lookPath reports whether the executable name is installed.
+func lookPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// startXvfb starts Xvfb, which writes the number of the display it picked to
+// the pipe passed as -displayfd.
+func startXvfb(ctx context.Context, opts Options) (*Display, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	cmd := exec.Command("Xvfb", "-displayfd", "3", "-screen", "0",
+		fmt.Sprintf("%dx%dx24", opts.Width, opts.Height), "-nolisten", "tcp", "-noreset")
+	cmd.ExtraFiles = []*os.File{w}
+
+	d, err := start(cmd, Xvfb, opts)
+	w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	number := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(r).ReadString('\n')
+		number <- strings.TrimSpace(line)
+	}()
+
+	select {
+	case n := <-number:
+		if _, err := strconv.Atoi(n); err != nil {
+			d.Close()
+			return nil, d.exited("Xvfb")
+		}
+		d.Env = []string{"DISPLAY=:" + n}
+		return d, nil
+	case <-ctx.Done():
+		d.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// startWeston starts weston with the headless backend listening on a socket
+// of its own in XDG_RUNTIME_DIR, a temporary directory if unset.
+func startWeston(ctx context.Context, opts Options) (*Display, error) {
+	runtimeDir, tmp := os.Getenv("XDG_RUNTIME_DIR"), ""
+	if runtimeDir == "" {
+		var err error
+		if tmp, err = os.MkdirTemp("", "saucer-display-"); err != nil {
+			return nil, err
+		}
+		runtimeDir = tmp
+	}
+
+	socket := fmt.Sprintf("saucer-%d", os.Getpid())
+	cmd := exec.Command("weston", "--backend=headless", "--socket="+socket,
+		fmt.Sprintf("--width=%d", opts.Width), fmt.Sprintf("--height=%d", opts.Height), "--idle-time=0")
+	cmd.Env = append(os.Environ(), "XDG_RUNTIME_DIR="+runtimeDir)
+
+	d, err := start(cmd, Weston, opts)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	d.dir = tmp
+
+	d.Env = []string{"WAYLAND_DISPLAY=" + socket, "XDG_RUNTIME_DIR=" + runtimeDir, "GDK_BACKEND=wayland", "QT_QPA_PLATFORM=wayland"}
+
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		if _, err := os.Stat(filepath.Join(runtimeDir, socket)); err == nil {
+			return d, nil
+		}
+
+		select {
+		case <-tick.C:
+		case <-d.done:
+			d.Close()
+			return nil, d.exited("weston")
+		case <-ctx.Done():
+			d.Close()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// start starts cmd as the process of a Display.
+func start(cmd *exec.Cmd, server Server, opts Options) (*Display, error) {
+	cmd.Stdout, cmd.Stderr = opts.Log, opts.Log
+	cmd.SysProcAttr = procAttr()
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("display: start %s: %w", server, err)
+	}
+
+	d := &Display{Server: server, cmd: cmd, done: make(chan struct{})}
+	go func() {
+		d.err = cmd.Wait()
+		close(d.done)
+	}()
+	return d, nil
+}
+
+// exited returns the error of a server that quit during startup.
+func (d *Display) exited(name string) error {
+	select {
+	case <-d.done:
+		if d.err != nil {
+			return fmt.Errorf("display: %s exited: %w", name, d.err)
+		}
+		return fmt.Errorf("display: %s exited", name)
+	default:
+		return fmt.Errorf("display: %s did not report a display", name)
+	}
+}
+
+// Setenv sets the environment variables of Env in the process, for the
+// toolkit and child processes to use the display.
+func (d *Display) Setenv() error {
+	for _, kv := range d.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops the server, killing it if it does not exit within five seconds.
+func (d *Display) Close() error {
+	defer os.RemoveAll(d.dir)
+
+	select {
+	case <-d.done:
+		return nil
+	default:
+	}
+
+	_ = d.cmd.Process.Signal(syscall.SIGTERM)
+
+	select {
+	case <-d.done:
+	case <-time.After(5 * time.Second):
+		_ = d.cmd.Process.Kill()
+		<-d.done
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package display
+
+import "syscall"
+
+// procAttr stops the server when the process exits without calling Close,
+// e.g. a test binary provisioning a display for all its tests.
+func procAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Pdeathsig: syscall.SIGTERM}
+}
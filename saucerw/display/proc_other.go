@@ -0,0 +1,10 @@
+//go:build !linux
+
+package display
+
+import "syscall"
+
+// procAttr returns nil, the server outlives a process exiting without Close.
+func procAttr() *syscall.SysProcAttr {
+	return nil
+}
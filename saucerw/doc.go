@@ -0,0 +1,25 @@
+// Package saucerw provides Go bindings for the saucer webview library.
+//
+// The Go types mirror the saucer C++ API: an Application owns the native event
+// loop, a Window is a native top-level window and a Webview renders web content
+// inside a Window. Native work is delegated to a Driver, the default one being
+// the cgo driver which links against a saucer static library built from the
+// sources embedded in the parent package.
+//
+// The cgo driver is compiled when cgo is enabled and the "saucer" build tag is
+// set. The compiler and linker flags for the native library have to be supplied
+// through CGO_CXXFLAGS and CGO_LDFLAGS. The bindings follow the API of saucer
+// 8 and fail to compile against a library the builder made from another
+// embedded release, see saucer.Versions.
+//
+// Like every GUI toolkit, saucer requires the event loop to run on the main
+// thread: NewApplication and Application.Run must be called from the main
+// goroutine. Windows and webviews are created once the loop has started, for
+// example in the callback passed to Run.
+//
+// Once the loop runs, the methods of Application, Window and Webview may be
+// called from any goroutine; saucer marshals them onto the event loop thread
+// and waits for the result. Calls that would wait for a loop that is not
+// running return ErrNotRunning instead of deadlocking. Application.Dispatch
+// and DispatchResult run arbitrary code on the event loop thread.
+package saucerw
@@ -0,0 +1,92 @@
+package saucerw
+
+import "errors"
+
+// ErrDownloadCancelled is reported to DownloadDecision.OnDone when the
+// download was cancelled, by the user or because the webview was released.
+var ErrDownloadCancelled = errors.New("saucerw: download cancelled")
+
+// DownloadRequest describes a download started in the webview, by the page
+// or by the user.
+type DownloadRequest struct {
+	// URL is the address the file is downloaded from.
+	URL string
+	// SuggestedName is the file name proposed by the server or the page.
+	SuggestedName string
+	// MIMEType is the type of the file, empty if unknown.
+	MIMEType string
+	// Size is the expected length in bytes, -1 if unknown.
+	Size int64
+}
+
+// DownloadDecision tells the webview what to do with a download. The zero
+// value saves the file to the default location of the backend, usually the
+// downloads folder of the user.
+type DownloadDecision struct {
+	// Cancel stops the download before any data is saved.
+	Cancel bool
+	// Path is the file the download is saved to, the backend default if
+	// empty.
+	Path string
+	// OnProgress is called as data arrives with the number of bytes received
+	// and the expected total, -1 if unknown. Optional.
+	OnProgress func(received, total int64)
+	// OnDone is called once the download ended with the path of the saved
+	// file, or the reason it failed. Optional.
+	OnDone func(path string, err error)
+}
+
+// decided reports whether d does not leave the download to the backend.
+func (d *DownloadDecision) decided() bool {
+	return d.Cancel || d.Path != "" || d.OnProgress != nil || d.OnDone != nil
+}
+
+// downloadEvent is the progress or the end of a download.
+type downloadEvent struct {
+	received, total int64
+
+	done bool
+	path string
+	err  error
+}
+
+// OnDownload calls fn for every download started in the webview. Handlers are
+// asked in the order they were registered until one returns a decision other
+// than the zero value; a panicking handler cancels the download.
+//
+// Like navigation handlers, download handlers run on the event loop thread
+// and must not block. The callbacks of the decision run on another goroutine.
+// The macOS backend does not report downloads.
+func (v *Webview) OnDownload(fn func(DownloadRequest) DownloadDecision) *Subscription {
+	return v.downloads.subscribe(fn)
+}
+
+// decideDownload returns the first decision other than the zero value. Its
+// callbacks are replaced by ones that queue the events, so they may block.
+func (v *Webview) decideDownload(req DownloadRequest) DownloadDecision {
+	rtn := v.downloads.first(req, (*DownloadDecision).decided, DownloadDecision{Cancel: true})
+
+	if rtn.Cancel || (rtn.OnProgress == nil && rtn.OnDone == nil) {
+		return rtn
+	}
+
+	onProgress, onDone := rtn.OnProgress, rtn.OnDone
+
+	events := &emitter[downloadEvent]{}
+	events.subscribe(func(ev downloadEvent) {
+		switch {
+		case ev.done && onDone != nil:
+			onDone(ev.path, ev.err)
+		case !ev.done && onProgress != nil:
+			onProgress(ev.received, ev.total)
+		}
+	})
+
+	rtn.OnProgress = func(received, total int64) {
+		events.emit(downloadEvent{received: received, total: total})
+	}
+	rtn.OnDone = func(path string, err error) {
+		events.emit(downloadEvent{done: true, path: path, err: err})
+	}
+	return rtn
+}
@@ -0,0 +1,451 @@
+package saucerw
+
+import (
+	"errors"
+	"image"
+	"net/http"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw/pdf"
+)
+
+var (
+	// ErrNoDriver is returned when no native driver is available.
+	ErrNoDriver = errors.New("saucerw: no native driver, build with cgo and -tags saucer")
+	// ErrUnsupported is returned when the backend cannot perform a call.
+	ErrUnsupported = errors.New("saucerw: not supported by the backend")
+)
+
+// Driver creates native applications.
+//
+// The cgo driver backed by saucer is the default. Alternative drivers can be
+// used for testing or to host the native side out of process.
+type Driver interface {
+	// NewApp creates the native application, registering opts.Schemes
+	// before the toolkit is initialized.
+	NewApp(opts AppOptions) (AppDriver, error)
+}
+
+// AppDriver is the native side of an Application.
+type AppDriver interface {
+	// Run runs the event loop, calling start once it is running.
+	Run(start func()) int
+	// Quit stops the event loop.
+	Quit()
+	// Post schedules fn on the event loop thread.
+	Post(fn func())
+	// ThreadSafe reports whether the caller runs on the event loop thread.
+	ThreadSafe() bool
+	// Screens lists the attached monitors.
+	Screens() []Screen
+
+	// ReadClipboardText calls done with the text on the clipboard, ok being
+	// false when it holds none. done may be called on any thread, after the
+	// event loop processed the read.
+	ReadClipboardText(done func(text string, ok bool))
+	// ReadClipboardImage calls done with the image on the clipboard or nil,
+	// like ReadClipboardText.
+	ReadClipboardImage(done func(*image.NRGBA))
+	// WriteClipboardText replaces the clipboard contents with text.
+	WriteClipboardText(text string)
+	// WriteClipboardImage replaces the clipboard contents with img, whose
+	// bounds start at the origin.
+	WriteClipboardImage(img *image.NRGBA)
+	// HandleClipboard sets the function called when the clipboard contents
+	// changed. It is called on the event loop thread and must not block.
+	HandleClipboard(fn func())
+
+	// ColorScheme returns the color scheme of the system.
+	ColorScheme() ColorScheme
+	// HandleColorScheme sets the function called with the new color scheme
+	// when the user switched it, on the event loop thread. It must not block.
+	HandleColorScheme(fn func(ColorScheme))
+
+	// Locales returns the languages the user prefers, see
+	// Application.SystemLocales.
+	Locales() []string
+	// HandleLocales sets the function called with the new locales when the
+	// user changed them, on the event loop thread. It must not block.
+	HandleLocales(fn func([]string))
+
+	// Accessibility returns the accessibility state of the system.
+	Accessibility() Accessibility
+	// HandleAccessibility sets the function called with the new state when
+	// it changed, on the event loop thread. It must not block.
+	HandleAccessibility(fn func(Accessibility))
+
+	// HandlePower sets the function called with the power and session
+	// events of the system, on the event loop thread. It must not block. The
+	// backend delays a PowerShutdown, where the system lets it, until release
+	// is called; release may be called on any thread.
+	HandlePower(fn func(ev PowerEvent, release func()))
+
+	// RemoveWebsiteData removes the website data the backend keeps outside
+	// of storagePath for the webviews created with it and calls done, on any
+	// thread.
+	RemoveWebsiteData(storagePath string, done func(error))
+
+	// NewWindow creates a native window.
+	NewWindow() (WindowDriver, error)
+	// Release frees the native application.
+	Release()
+}
+
+// WindowDriver is the native side of a Window.
+type WindowDriver interface {
+	Visible() bool
+	Focused() bool
+	Minimized() bool
+	Maximized() bool
+	Resizable() bool
+	Fullscreen() bool
+	AlwaysOnTop() bool
+	ClickThrough() bool
+	Title() string
+	Background() Color
+	Decorations() Decoration
+	Size() Size
+	MinSize() Size
+	MaxSize() Size
+	Position() Position
+	// Scale is the number of device pixels per logical pixel of the screen
+	// the window is on.
+	Scale() float64
+	// OuterSize is the size of the window including its decorations, Size
+	// the one of its content.
+	OuterSize() Size
+	// SafeArea is the part of the content the system draws over.
+	SafeArea() Insets
+
+	Show()
+	Hide()
+	Close()
+	Focus()
+
+	// StartDrag and StartResize hand the window to the window manager while
+	// the mouse button is held.
+	StartDrag()
+	StartResize(edges Edge)
+
+	SetMinimized(bool)
+	SetMaximized(bool)
+	SetResizable(bool)
+	SetFullscreen(bool)
+	SetAlwaysOnTop(bool)
+	SetClickThrough(bool)
+	SetTitle(string)
+	// SetIcon sets the window icon from PNG image data.
+	SetIcon(png []byte) error
+	SetBackground(Color)
+	// SetTitleBarColor colors the title bar drawn by the system and reports
+	// whether the backend supports it. The zero Color restores the default.
+	SetTitleBarColor(Color) bool
+	SetDecorations(Decoration)
+	SetSize(Size)
+	SetMinSize(Size)
+	SetMaxSize(Size)
+	SetPosition(Position)
+
+	// SetKiosk blocks closing the window by the user and the escape
+	// shortcuts of kiosk mode, the window state is set separately.
+	SetKiosk(bool)
+
+	// InterceptClose emits WindowCloseRequested instead of closing the window
+	// when the user closes it, Close still closes it.
+	InterceptClose(bool)
+
+	// HandleEvents sets the receiver of the window events. It is called on
+	// the event loop thread and must not block.
+	HandleEvents(fn func(WindowEvent))
+
+	// SetMenu replaces the menu bar, nil removes it.
+	SetMenu(entries []MenuEntry)
+	// HandleMenu sets the receiver of the ids of clicked menu entries. It is
+	// called on the event loop thread and must not block. The platform may
+	// perform the editing and macOS roles itself without calling fn.
+	HandleMenu(fn func(id int32))
+
+	// NewWebview creates a webview inside the window. It returns an error
+	// wrapping ErrUnsupported for opts.Network it cannot apply.
+	NewWebview(opts WebviewOptions) (WebviewDriver, error)
+	// Release frees the native window.
+	Release()
+}
+
+// WebviewDriver is the native side of a Webview.
+type WebviewDriver interface {
+	URL() string
+	PageTitle() string
+
+	SetURL(string)
+	SetHTML(string)
+
+	Back()
+	Forward()
+	Reload()
+
+	Background() Color
+	SetBackground(Color)
+
+	// Edit performs one of the editing roles, RoleUndo to RoleSelectAll, in
+	// the page.
+	Edit(role Role)
+
+	// DevTools reports whether the developer tools are open.
+	DevTools() bool
+	// SetDevTools opens or closes the developer tools.
+	SetDevTools(open bool)
+
+	// Muted reports whether the audio of the webview is muted.
+	Muted() bool
+	// SetMuted mutes or unmutes the audio of the webview.
+	SetMuted(bool)
+
+	// Embed serves files from memory below saucer://embedded/.
+	Embed(files []EmbeddedFile)
+	// Serve navigates to the embedded file at path.
+	Serve(path string)
+	// Unembed removes all embedded files.
+	Unembed()
+
+	// Execute runs code in the page without waiting for a result.
+	Execute(code string)
+	// Inject adds a script run on every page load and returns its id.
+	Inject(script Script) uint64
+	// Uninject removes the script id.
+	Uninject(id uint64)
+	// UninjectAll removes all scripts that are not permanent.
+	UninjectAll()
+
+	// HandleMessage sets the receiver of messages posted by the page through
+	// window.saucer.internal.message. It reports whether it handled a message.
+	HandleMessage(fn func(message string) bool)
+
+	// HandleNavigate sets the function deciding whether a navigation may
+	// proceed. It is called on the event loop thread.
+	HandleNavigate(fn func(NavigationEvent) Policy)
+
+	// HandlePermission sets the function deciding on permission requests of
+	// the page. It is called on the event loop thread and must not block. If
+	// it returns PermissionPending, answer is called later from any
+	// goroutine, exactly once.
+	HandlePermission(fn func(url string, types Permission, answer func(granted bool)) PermissionDecision)
+
+	// HandleDownload sets the function deciding on downloads. It is called
+	// on the event loop thread and must not block, like the callbacks of the
+	// decision. OnDone is called once the download ended, unless the
+	// decision cancelled it.
+	HandleDownload(fn func(DownloadRequest) DownloadDecision)
+
+	// HandleEvents sets the receiver of the webview events. It is called on
+	// the event loop thread and must not block.
+	HandleEvents(fn func(WebviewEvent))
+
+	// HandleFileDrop sets the receiver of the files dropped onto the webview,
+	// like HandleEvents. The WebView2 backend only reports the drops posted by
+	// the script of Webview.OnFileDrop.
+	HandleFileDrop(fn func(FileDrop))
+
+	// HandleContextMenu sets the function deciding on the context menu. It is
+	// called on the event loop thread when the menu opens, partial being set
+	// if the backend cannot tell the whole target; the fields it left empty
+	// are then taken from the page. It returns the entries to show, listed
+	// parents first with the top level having parent 0, or ok false for the
+	// default menu. Clicked entries are passed to click like those of
+	// WindowDriver.HandleMenu.
+	HandleContextMenu(fn func(info ContextInfo, partial bool) (entries []MenuEntry, ok bool), click func(id int32))
+
+	// Cookies calls done with all cookies of the data store of the webview.
+	// done may be called on any thread, after the event loop processed the
+	// call, like the callbacks of the other cookie and data methods.
+	Cookies(done func([]*http.Cookie, error))
+	// SetCookie adds cookie or replaces the one of the same name, domain and
+	// path.
+	SetCookie(cookie *http.Cookie, done func(error))
+	// DeleteCookie removes the cookie of the name, domain and path of cookie.
+	DeleteCookie(cookie *http.Cookie, done func(error))
+	// ClearData removes the kinds of data modified at or after since, all of
+	// it if since is zero. Kinds the backend cannot clear are reported as an
+	// error wrapping ErrUnsupported after clearing the others.
+	ClearData(kinds BrowsingData, since time.Time, done func(error))
+
+	// Zoom returns the zoom factor of the page.
+	Zoom() float64
+	// SetZoom sets the zoom factor, between MinZoom and MaxZoom.
+	SetZoom(factor float64)
+	// Print saves the page as PDF to the absolute opts.Path, or shows the
+	// print dialog if it is empty. done is called like the callbacks of the
+	// cookie methods, a dialog the backend cannot show is reported as an
+	// error wrapping ErrUnsupported.
+	Print(opts PrintOptions, done func(error))
+	// SavePDF writes the page to the absolute path as PDF laid out with
+	// opts, calling done like Print.
+	SavePDF(path string, opts pdf.Options, done func(error))
+	// Capture calls done with a PNG screenshot of the visible part of the
+	// page, like the callbacks of the cookie methods.
+	Capture(done func(png []byte, err error))
+	// SetDarkMode forces the color scheme of the pages and reports whether
+	// the backend supports it, Webview.ForceDarkMode rewrites the media
+	// queries of the page otherwise.
+	SetDarkMode(mode DarkMode) bool
+	// SendKey sends the key event to the page as if typed and reports
+	// whether the backend can synthesize input, like SendText and
+	// SendMouse. They may be called from any goroutine.
+	SendKey(key KeyInput) bool
+	// SendText inserts text at the focus of the page as if committed by an
+	// input method.
+	SendText(text string) bool
+	// SendMouse sends a MouseMove, MouseDown, MouseUp or MouseWheel event,
+	// the click count of a press being at least 1.
+	SendMouse(mouse MouseInput) bool
+	// NewSharedBuffer allocates size bytes of memory mapped into the page,
+	// or returns an error wrapping ErrUnsupported.
+	NewSharedBuffer(size int) (SharedMemory, error)
+
+	// HandleScheme routes requests for the custom scheme name to handler.
+	// The handler is called on the event loop thread and must not block,
+	// respond may be called later from any goroutine.
+	HandleScheme(name string, handler func(req SchemeRequest, respond func(SchemeResponse)))
+	RemoveScheme(name string)
+
+	// HandleStreamScheme is like HandleScheme but answers requests through a
+	// SchemeStream, delivering the body as it is written.
+	HandleStreamScheme(name string, handler func(req SchemeRequest, stream SchemeStream))
+	RemoveStreamScheme(name string)
+
+	// Release frees the native webview.
+	Release()
+}
+
+// SharedMemory is memory shared between Go and the page. Its methods may be
+// called from any goroutine.
+type SharedMemory interface {
+	// Bytes returns the memory, valid until Close.
+	Bytes() []byte
+	// Post hands the memory to the page along with the JSON encoded detail.
+	Post(readOnly bool, detail string)
+	// Close unmaps the memory from the page and frees it.
+	Close()
+}
+
+// SchemeStream writes a streamed response to a scheme request. Its methods
+// may be called from any goroutine, but not concurrently.
+type SchemeStream interface {
+	// Start sends the status, MIME type and headers of res, its body is
+	// ignored. It is called once, before Write.
+	Start(res SchemeResponse)
+	// Write sends a chunk of the body.
+	Write(data []byte)
+	// Finish completes the response.
+	Finish()
+	// Reject fails the request instead of starting the response. Status 400,
+	// 401 and 404 are reported as such, anything else as a network error.
+	Reject(status int)
+}
+
+// InjectTime selects when an injected script runs.
+type InjectTime uint8
+
+const (
+	// AtCreation runs the script before any page script.
+	AtCreation InjectTime = iota
+	// AtReady runs the script once the DOM is ready.
+	AtReady
+)
+
+// FrameScope selects the frames an injected script runs in.
+type FrameScope uint8
+
+const (
+	// MainFrame runs the script in the top-level frame only.
+	MainFrame FrameScope = iota
+	// AllFrames runs the script in every frame.
+	AllFrames
+)
+
+// Script is a script injected into every page.
+type Script struct {
+	Code   string
+	Time   InjectTime
+	Frames FrameScope
+	// Origins, if set, restricts the script to the frames of these origins
+	// in the syntax of BridgeRule.Origin, e.g. the child frames of the app
+	// with AllFrames. The code runs in a block then.
+	Origins []string
+	// Permanent scripts survive clearing the injected scripts.
+	Permanent bool
+}
+
+// WindowEventType identifies a window event.
+type WindowEventType uint8
+
+const (
+	// WindowDecorated is emitted when the decorations changed.
+	WindowDecorated WindowEventType = iota
+	// WindowMaximize is emitted when the window was maximized or restored.
+	WindowMaximize
+	// WindowMinimize is emitted when the window was minimized or restored.
+	WindowMinimize
+	// WindowClosed is emitted after the window was closed.
+	WindowClosed
+	// WindowResize is emitted when the window was resized.
+	WindowResize
+	// WindowFocus is emitted when the window gained or lost focus.
+	WindowFocus
+	// WindowCloseRequested is emitted when the user closed a window
+	// intercepting it, see InterceptClose.
+	WindowCloseRequested
+	// WindowScale is emitted when the scale of the window changed, e.g. when
+	// it was moved to a screen of another resolution.
+	WindowScale
+)
+
+// WindowEvent is an event emitted by a native window.
+type WindowEvent struct {
+	Type WindowEventType
+	// Value is the new state for WindowMaximize, WindowMinimize and
+	// WindowFocus.
+	Value bool
+	// Size is the new size for WindowResize.
+	Size Size
+	// Decoration is the new decoration for WindowDecorated.
+	Decoration Decoration
+	// Scale is the new scale for WindowScale.
+	Scale float64
+}
+
+// WebviewEventType identifies a webview event.
+type WebviewEventType uint8
+
+const (
+	// WebviewDomReady is emitted when the DOM of a page is ready.
+	WebviewDomReady WebviewEventType = iota
+	// WebviewLoad is emitted when a page started or finished loading.
+	WebviewLoad
+	// WebviewPlayingAudio is emitted when a page started or stopped playing
+	// audio.
+	WebviewPlayingAudio
+	// WebviewProcessGone is emitted when the render process of the page
+	// crashed, was killed or stopped responding.
+	WebviewProcessGone
+)
+
+// WebviewEvent is an event emitted by a native webview.
+type WebviewEvent struct {
+	Type WebviewEventType
+	// Load is the load state for WebviewLoad.
+	Load LoadState
+	// Playing is whether the page plays audio for WebviewPlayingAudio.
+	Playing bool
+	// Gone is why the render process went away for WebviewProcessGone.
+	Gone GoneReason
+}
+
+// defaultDriver is set by the cgo driver when it is compiled in.
+var defaultDriver Driver
+
+// DefaultDriver returns the native driver NewApplication uses, nil unless the
+// package is built with cgo and -tags saucer.
+func DefaultDriver() Driver {
+	return defaultDriver
+}
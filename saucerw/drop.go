@@ -0,0 +1,69 @@
+package saucerw
+
+// dropScript hands files dropped onto the page to the WebView2 backend, which
+// resolves their paths from the File objects. The other engines report drops
+// natively and provide no chrome.webview, the script does nothing there.
+const dropScript = `
+(() =>
+{
+    const webview = window.chrome?.webview;
+
+    if (!webview?.postMessageWithAdditionalObjects)
+    {
+        return;
+    }
+
+    const files = (event) => event.dataTransfer?.types.includes("Files");
+
+    // Unless the drag is accepted, the engine opens the file instead of dispatching the drop. Listening on the
+    // window in the bubble phase leaves the page to handle it first.
+    for (const type of ["dragover", "drop"])
+    {
+        window.addEventListener(type, (event) =>
+        {
+            if (files(event))
+            {
+                event.preventDefault();
+            }
+        });
+    }
+
+    window.addEventListener("drop", (event) =>
+    {
+        const dropped = [...(event.dataTransfer?.files ?? [])];
+
+        if (!dropped.length)
+        {
+            return;
+        }
+
+        const message = { ["saucer:drop"]: true, x: Math.round(event.clientX), y: Math.round(event.clientY) };
+        webview.postMessageWithAdditionalObjects(JSON.stringify(message), dropped);
+    }, true);
+})();
+`
+
+// FileDrop describes files dropped onto a webview from the file manager or
+// another application.
+type FileDrop struct {
+	// Paths are the absolute paths of the dropped files and directories.
+	Paths []string
+	// Position is where they were dropped, relative to the webview.
+	Position Position
+}
+
+// OnFileDrop calls fn with the paths of the files dropped onto the webview.
+// The page receives the drop as well, through the HTML drag and drop API
+// whose File objects carry no paths.
+//
+// The engines open a dropped file in place of the page unless the page
+// handles the drop. On WebView2 the paths are read from the page, registering
+// fn keeps dropped files from replacing it there.
+func (v *Webview) OnFileDrop(fn func(FileDrop)) *Subscription {
+	v.dropping.Do(func() {
+		v.native.Inject(Script{Code: dropScript, Time: AtCreation, Permanent: true})
+		v.native.Execute(dropScript)
+	})
+
+	return v.drops.subscribe(fn)
+}
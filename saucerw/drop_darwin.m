@@ -0,0 +1,74 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <WebKit/WebKit.h>
+#import <objc/runtime.h>
+
+#include "native.h"
+
+// Implemented in cookies_darwin.m
+WKWebView *saucerw_cocoa_webview(const void *impl);
+
+// The key of the drop handler associated with a web view
+static char drop_key;
+
+// The implementation of performDragOperation: that handles the drop in the page
+static IMP perform_drag;
+
+// perform_drag_operation reports the files of a drop before the web view handles it.
+static BOOL perform_drag_operation(NSView *self, SEL cmd, id<NSDraggingInfo> info)
+{
+    NSNumber *handler = objc_getAssociatedObject(self, &drop_key);
+    NSArray<NSURL *> *urls =
+        [info.draggingPasteboard readObjectsForClasses:@[ NSURL.class ]
+                                               options:@{NSPasteboardURLReadingFileURLsOnlyKey : @YES}];
+
+    if (handler && urls.count)
+    {
+        NSPoint point = [self convertPoint:info.draggingLocation fromView:nil];
+        char **paths  = calloc(urls.count, sizeof(char *));
+
+        for (NSUInteger i = 0; urls.count > i; i++)
+        {
+            paths[i] = (char *)urls[i].fileSystemRepresentation;
+        }
+
+        saucerwDrop(handler.unsignedLongLongValue, paths, urls.count, (int)point.x,
+                    (int)(self.isFlipped ? point.y : NSHeight(self.bounds) - point.y));
+
+        free(paths);
+    }
+
+    return ((BOOL (*)(id, SEL, id<NSDraggingInfo>))perform_drag)(self, cmd, info);
+}
+
+void saucerw_cocoa_on_drop(const void *webview, uintptr_t handler)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+
+    if (!native)
+    {
+        return;
+    }
+
+    objc_setAssociatedObject(native, &drop_key, @(handler), OBJC_ASSOCIATION_RETAIN_NONATOMIC);
+
+    if (perform_drag)
+    {
+        return;
+    }
+
+    // The web views of saucer share one class, it inherits the method from WKWebView unless it overrides it
+    Class cls       = object_getClass(native);
+    SEL selector    = @selector(performDragOperation:);
+    Method original = class_getInstanceMethod(cls, selector);
+
+    if (class_addMethod(cls, selector, (IMP)perform_drag_operation, method_getTypeEncoding(original)))
+    {
+        perform_drag = method_getImplementation(original);
+    }
+    else
+    {
+        perform_drag = method_setImplementation(original, (IMP)perform_drag_operation);
+    }
+}
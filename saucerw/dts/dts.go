@@ -0,0 +1,424 @@
+// Package dts generates TypeScript definitions and a typed JavaScript client
+// for the Go functions a page calls through the saucerw bridge.
+//
+// A Generator records functions like Webview.Expose does, so the code
+// registering the bridge surface can be shared with a small generator run by
+// go generate:
+//
+//	func expose(e interface{ Expose(string, any) error }) error {
+//		return e.Expose("items", listItems)
+//	}
+//
+//	// gen/main.go, run with //go:generate go run ./gen
+//	g := dts.New()
+//	if err := expose(g); err != nil { ... }
+//	err := g.WriteFiles("frontend/src/api")
+package dts
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+var (
+	errorType         = reflect.TypeFor[error]()
+	contextType       = reflect.TypeFor[context.Context]()
+	bytesType         = reflect.TypeFor[[]byte]()
+	rawType           = reflect.TypeFor[json.RawMessage]()
+	timeType          = reflect.TypeFor[time.Time]()
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	writerType        = reflect.TypeFor[*saucerw.StreamWriter]()
+)
+
+// function is a recorded exposed function.
+type function struct {
+	params []reflect.Type
+	result reflect.Type
+	// stream is set if the result is streamed, item is the type of its
+	// items if known.
+	stream bool
+	item   reflect.Type
+}
+
+// Generator collects exposed functions and writes their definitions.
+type Generator struct {
+	functions map[string]function
+
+	// names maps the struct types emitted as interfaces to their names.
+	names map[reflect.Type]string
+	taken map[string]reflect.Type
+	defs  []reflect.Type
+}
+
+// New returns an empty Generator.
+func New() *Generator {
+	return &Generator{functions: map[string]function{}}
+}
+
+// Expose records fn under name, accepting the same functions as
+// Webview.Expose.
+func (g *Generator) Expose(name string, fn any) error {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return errors.New("dts: exposed value must be a function")
+	}
+
+	if t.IsVariadic() {
+		return errors.New("dts: exposed function must not be variadic")
+	}
+
+	var rtn function
+	for i := range t.NumIn() {
+		if i == 0 && t.In(i) == contextType {
+			continue
+		}
+		if len(rtn.params) == 0 && !rtn.stream && t.In(i) == writerType {
+			rtn.stream = true
+			continue
+		}
+		rtn.params = append(rtn.params, t.In(i))
+	}
+
+	switch {
+	case t.NumOut() > 2:
+		return errors.New("dts: exposed function must return at most two values")
+	case t.NumOut() == 2 && t.Out(1) != errorType:
+		return errors.New("dts: second result of exposed function must be an error")
+	case t.NumOut() > 0 && t.Out(0) != errorType:
+		rtn.result = t.Out(0)
+	}
+
+	if rtn.result != nil && rtn.result.Kind() == reflect.Chan && rtn.result.ChanDir()&reflect.RecvDir != 0 {
+		rtn.stream, rtn.item, rtn.result = true, rtn.result.Elem(), nil
+	}
+
+	g.functions[name] = rtn
+	return nil
+}
+
+// WriteFiles writes api.d.ts and api.js to dir.
+func (g *Generator) WriteFiles(dir string) error {
+	var dts, js bytes.Buffer
+
+	if err := g.WriteDTS(&dts); err != nil {
+		return err
+	}
+
+	if err := g.WriteClient(&js); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "api.d.ts"), dts.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "api.js"), js.Bytes(), 0o644)
+}
+
+// WriteDTS writes the TypeScript definitions of the client written by
+// WriteClient: an interface per Go struct, the Exposed interface listing
+// the functions, GoError, the Error calls reject with, and the typing of
+// window.saucer.
+func (g *Generator) WriteDTS(w io.Writer) error {
+	g.names, g.taken, g.defs = map[reflect.Type]string{}, map[string]reflect.Type{}, nil
+
+	var api strings.Builder
+
+	api.WriteString("export interface Exposed {\n")
+
+	for _, name := range slices.Sorted(maps.Keys(g.functions)) {
+		fn := g.functions[name]
+
+		params := make([]string, len(fn.params))
+		for i, param := range fn.params {
+			params[i] = fmt.Sprintf("arg%d: %s", i, g.param(param))
+		}
+
+		result := "void"
+		switch {
+		case fn.stream && fn.item != nil:
+			result = fmt.Sprintf("GoStream<%s>", g.typ(fn.item))
+		case fn.stream:
+			result = "GoStream<unknown>"
+		case fn.result != nil:
+			result = g.typ(fn.result)
+		}
+
+		fmt.Fprintf(&api, "  %s(%s): Promise<%s>;\n", quoteKey(name), strings.Join(params, ", "), result)
+	}
+
+	api.WriteString("}\n")
+
+	var buf bytes.Buffer
+
+	buf.WriteString("// Code generated by saucerw/dts. DO NOT EDIT.\n\n")
+
+	// Emitting a struct may discover further structs.
+	for i := 0; i < len(g.defs); i++ {
+		g.writeInterface(&buf, g.defs[i])
+	}
+
+	buf.WriteString(api.String())
+	buf.WriteString(`
+export interface CallOptions {
+  signal?: AbortSignal;
+}
+
+export interface GoError extends Error {
+  name: "GoError";
+  code: "unknown" | "not_found" | "invalid_argument" | "canceled" | "deadline_exceeded" | "internal" | "permission_denied" | (string & {});
+  chain: string[];
+  data?: unknown;
+}
+
+export interface GoStream<T = unknown> extends ReadableStream<T>, AsyncIterable<T> {}
+
+export interface Channel<T = unknown> extends AsyncIterable<T> {
+  readonly name: string;
+  send(value: T): Promise<void>;
+  recv(): Promise<T>;
+  close(): void;
+}
+
+declare global {
+  interface Window {
+    saucer: {
+      exposed: Exposed;
+      call(name: string, params: unknown[], options?: CallOptions): Promise<unknown>;
+      channel<T = unknown>(name: string): Channel<T>;
+      ready(): void;
+      onQuit(handler: () => boolean | void | Promise<boolean | void>): () => void;
+    };
+  }
+}
+
+export declare const api: Exposed;
+`)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteClient writes a JavaScript module exporting api, an object with a
+// wrapper per exposed function.
+func (g *Generator) WriteClient(w io.Writer) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("// Code generated by saucerw/dts. DO NOT EDIT.\n\nexport const api = {\n")
+
+	for _, name := range slices.Sorted(maps.Keys(g.functions)) {
+		params := make([]string, len(g.functions[name].params))
+		for i := range params {
+			params[i] = fmt.Sprintf("arg%d", i)
+		}
+
+		args := strings.Join(params, ", ")
+		quoted, _ := json.Marshal(name)
+
+		fmt.Fprintf(&buf, "  %s: (%s) => window.saucer.call(%s, [%s]),\n", quoteKey(name), args, quoted, args)
+	}
+
+	buf.WriteString("};\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// param returns the TypeScript type accepted for a parameter of type t.
+func (g *Generator) param(t reflect.Type) string {
+	if t == bytesType {
+		// Binary arguments are transferred without encoding them.
+		return "ArrayBuffer | ArrayBufferView | Blob"
+	}
+	return g.typ(t)
+}
+
+// typ returns the TypeScript type of the JSON encoding of t.
+func (g *Generator) typ(t reflect.Type) string {
+	switch {
+	case t == rawType:
+		return "unknown"
+	case t == timeType:
+		return "string"
+	case t == bytesType:
+		// encoding/json encodes byte slices as base64.
+		return "string"
+	case t.Implements(marshalerType):
+		return "unknown"
+	case t.Implements(textMarshalerType):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Pointer:
+		return g.typ(t.Elem()) + " | null"
+	case reflect.Slice, reflect.Array:
+		elem := g.typ(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + g.typ(t.Elem()) + ">"
+	case reflect.Struct:
+		return g.name(t)
+	default:
+		return "unknown"
+	}
+}
+
+// name returns the interface name of the struct type t, scheduling its
+// definition.
+func (g *Generator) name(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if name == "" {
+		name = "Anonymous"
+	}
+
+	for base, n := name, 2; g.taken[name] != nil; n++ {
+		name = fmt.Sprintf("%s%d", base, n)
+	}
+
+	g.names[t], g.taken[name] = name, t
+	g.defs = append(g.defs, t)
+
+	return name
+}
+
+// writeInterface writes the interface of the struct type t.
+func (g *Generator) writeInterface(buf *bytes.Buffer, t reflect.Type) {
+	fmt.Fprintf(buf, "export interface %s {\n", g.names[t])
+
+	for _, f := range fields(t) {
+		optional := ""
+		if f.omitempty {
+			optional = "?"
+		}
+
+		typ := g.typ(f.typ)
+		if f.quoted {
+			typ = "string"
+		}
+
+		fmt.Fprintf(buf, "  %s%s: %s;\n", quoteKey(f.name), optional, typ)
+	}
+
+	buf.WriteString("}\n\n")
+}
+
+// field is a JSON encoded struct field.
+type field struct {
+	name      string
+	typ       reflect.Type
+	omitempty bool
+	quoted    bool
+}
+
+// fields lists the JSON encoded fields of t like encoding/json, promoting the
+// fields of embedded structs without a name tag unless t declares them.
+func fields(t reflect.Type) []field {
+	var rtn, promoted []field
+	seen := map[string]bool{}
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				promoted = append(promoted, fields(ft)...)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		rtn = append(rtn, field{
+			name:      name,
+			typ:       sf.Type,
+			omitempty: hasOption(opts, "omitempty") || hasOption(opts, "omitzero"),
+			quoted:    hasOption(opts, "string"),
+		})
+	}
+
+	for _, f := range promoted {
+		if !seen[f.name] {
+			seen[f.name] = true
+			rtn = append(rtn, f)
+		}
+	}
+
+	return rtn
+}
+
+// hasOption reports whether the comma separated tag options contain opt.
+func hasOption(opts, opt string) bool {
+	return slices.Contains(strings.Split(opts, ","), opt)
+}
+
+// quoteKey returns name as a property key, quoted unless it is a valid
+// identifier.
+func quoteKey(name string) string {
+	valid := name != ""
+
+	for i, c := range name {
+		if !(c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			valid = false
+		}
+	}
+
+	if valid {
+		return name
+	}
+
+	quoted, _ := json.Marshal(name)
+	return string(quoted)
+}
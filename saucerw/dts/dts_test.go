@@ -0,0 +1,134 @@
+package dts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+type Base struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type Item struct {
+	Base
+	Name   string            `json:"name"`
+	Tags   []string          `json:"tags,omitempty"`
+	Count  int64             `json:"count,string"`
+	Parent *Item             `json:"parent"`
+	Labels map[string]string `json:"labels,omitzero"`
+	Extra  json.RawMessage   `json:"extra"`
+	Hidden string            `json:"-"`
+	hidden string
+}
+
+type Page struct {
+	Items []*Item `json:"items"`
+	Next  string
+}
+
+// level is a TextMarshaler, encoded as a string.
+type level int
+
+func (l level) MarshalText() ([]byte, error) { return []byte("level"), nil }
+
+// anonymous is a struct without a name.
+type anonymous = struct {
+	Value float64 `json:"value"`
+}
+
+// expose records a representative set of signatures.
+func expose(g *Generator) error {
+	// other takes a struct named like Item, which gets a numbered name
+	other := func() any {
+		type Item struct {
+			Other bool `json:"other"`
+		}
+		return func(Item) {}
+	}()
+
+	functions := map[string]any{
+		"add":          func(a, b int) int { return a + b },
+		"list-items":   func(ctx context.Context, page Page) (Page, error) { return page, nil },
+		"item":         func(id int) (*Item, error) { return nil, nil },
+		"upload":       func(name string, data []byte) error { return nil },
+		"download":     func() []byte { return nil },
+		"levels":       func() map[string]level { return nil },
+		"anonymous":    func(v anonymous) []anonymous { return nil },
+		"other":        other,
+		"matrix":       func(m [][]float32) []*int { return nil },
+		"raw":          func(json.RawMessage) (any, error) { return nil, nil },
+		"follow":       func(ctx context.Context, w *saucerw.StreamWriter, from int) error { return nil },
+		"watch":        func() <-chan Item { return nil },
+		"notify":       func(bool) {},
+		"$sum2":        func(values []uint8) float64 { return 0 },
+		"uses_time":    func(t time.Time) time.Duration { return 0 },
+		"optional_ptr": func(p *string) {},
+	}
+	for name, fn := range functions {
+		if err := g.Expose(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestGolden compares the generated files with testdata/api.d.ts and
+// testdata/api.js. Run go test -update to rewrite them.
+func TestGolden(t *testing.T) {
+	g := New()
+	if err := expose(g); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, write := range map[string]func(*bytes.Buffer) error{
+		"api.d.ts": func(buf *bytes.Buffer) error { return g.WriteDTS(buf) },
+		"api.js":   func(buf *bytes.Buffer) error { return g.WriteClient(buf) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := write(&buf); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", name)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("generated %s differs from %s:\n%s", name, path, got)
+			}
+		})
+	}
+}
+
+func TestExposeInvalid(t *testing.T) {
+	for name, fn := range map[string]any{
+		"not a function": 42,
+		"nil":            nil,
+		"variadic":       func(...int) {},
+		"three results":  func() (int, int, error) { return 0, 0, nil },
+		"second result":  func() (int, int) { return 0, 0 },
+	} {
+		if err := New().Expose("fn", fn); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package saucerw
+
+import (
+	"io/fs"
+
+	"github.com/aperturerobotics/saucer/internal/mimetype"
+)
+
+// EmbeddedFile is a file served from memory by the webview.
+type EmbeddedFile struct {
+	// Path is the absolute, slash-separated path of the file, e.g. "/index.html".
+	Path string
+	// Mime is the MIME type sent with the file.
+	Mime    string
+	Content []byte
+}
+
+// Embed serves every file of fsys below saucer://embedded/, replacing files
+// embedded earlier under the same path. The MIME types are derived from the
+// file extensions.
+//
+// This is the native counterpart of HandleScheme for static assets, for
+// example a frontend bundled with go:embed.
+func (v *Webview) Embed(fsys fs.FS) error {
+	var files []EmbeddedFile
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, EmbeddedFile{Path: "/" + name, Mime: mimetype.Detect(name, data), Content: data})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	v.native.Embed(files)
+	return nil
+}
+
+// Serve navigates to the embedded file at path, e.g. "/index.html".
+func (v *Webview) Serve(path string) {
+	v.native.Serve(path)
+}
+
+// Unembed removes every embedded file.
+func (v *Webview) Unembed() {
+	v.native.Unembed()
+}
@@ -0,0 +1,211 @@
+package saucerw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Codes of a BridgeError, the code property of the Error a bridge call
+// rejects with.
+const (
+	CodeUnknown          = "unknown"
+	CodeNotFound         = "not_found"
+	CodeInvalidArgument  = "invalid_argument"
+	CodeCanceled         = "canceled"
+	CodeDeadlineExceeded = "deadline_exceeded"
+	CodeInternal         = "internal"
+	CodePermissionDenied = "permission_denied"
+)
+
+// BridgeError is an error of an exposed function or bridge middleware with a
+// code for the page. The promise of the call rejects with an Error named
+// "GoError" whose message is the message of the error and whose code, chain
+// and data properties hold Code, the messages of the wrapped errors, outermost
+// first, and the JSON encoding of Data:
+//
+//	try {
+//		await window.saucer.exposed.load(id);
+//	} catch (e) {
+//		if (e.code === "not_found") { ... }
+//	}
+//
+// Other errors reject with code CodeCanceled or CodeDeadlineExceeded if they
+// wrap those of package context, CodeInternal for panics and CodeUnknown
+// otherwise.
+type BridgeError struct {
+	// Code is the code for the page, CodeUnknown if empty.
+	Code string
+	// Err is the error, its message is the message of the Error.
+	Err error
+	// Data is passed to the page, it has to be JSON encodable.
+	Data any
+}
+
+func (e *BridgeError) Error() string {
+	if e.Err == nil {
+		return e.code()
+	}
+	return e.Err.Error()
+}
+
+func (e *BridgeError) Unwrap() error {
+	return e.Err
+}
+
+// code returns Code, CodeUnknown if empty.
+func (e *BridgeError) code() string {
+	if e.Code == "" {
+		return CodeUnknown
+	}
+	return e.Code
+}
+
+// PanicError is the error of a call whose exposed function or middleware
+// panicked.
+type PanicError struct {
+	// Source names what panicked, e.g. "exposed function load".
+	Source string
+	// Value is the recovered value.
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("saucerw: %s panicked: %v", e.Source, e.Value)
+}
+
+// CallError is returned by Eval and Call when the page threw an exception.
+type CallError struct {
+	// Method is the function passed to Call, empty for Eval.
+	Method string
+	// Name is the name of the Error thrown, e.g. "TypeError", empty if
+	// something else was thrown.
+	Name string
+	// Message is the message of the Error, the string representation of
+	// other values.
+	Message string
+	// Code is the code property of the Error, if any.
+	Code string
+	// JSStack is the stack property of the Error, if any.
+	JSStack string
+}
+
+func (e *CallError) Error() string {
+	msg := e.Message
+	if e.Name != "" {
+		msg = e.Name + ": " + msg
+	}
+
+	if e.Method == "" {
+		return "saucerw: eval: " + msg
+	}
+	return "saucerw: call " + e.Method + ": " + msg
+}
+
+// Is reports whether target is a *CallError whose non-empty fields other than
+// JSStack match those of e, so errors.Is(err, &CallError{Name: "TypeError"})
+// matches every TypeError.
+func (e *CallError) Is(target error) bool {
+	t, ok := target.(*CallError)
+	if !ok {
+		return false
+	}
+
+	match := func(a, b string) bool { return b == "" || a == b }
+	return match(e.Method, t.Method) && match(e.Name, t.Name) && match(e.Message, t.Message) && match(e.Code, t.Code)
+}
+
+// jsException is the shape the bridge script posts for a thrown Error.
+type jsException struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+	Stack   string `json:"stack"`
+}
+
+// newCallError returns the error of an exception of the page, either a
+// jsException or the string representation of the value thrown.
+func newCallError(method string, result json.RawMessage) *CallError {
+	rtn := &CallError{Method: method}
+	if json.Unmarshal(result, &rtn.Message) == nil {
+		return rtn
+	}
+
+	var ex jsException
+	if err := json.Unmarshal(result, &ex); err != nil {
+		rtn.Message = string(result)
+		return rtn
+	}
+
+	rtn.Name, rtn.Message, rtn.Code, rtn.JSStack = ex.Name, ex.Message, ex.Code, ex.Stack
+	return rtn
+}
+
+// maxChain limits the messages of the wrapped errors passed to the page.
+const maxChain = 16
+
+// goError is the shape of an error passed to window.saucer.internal.error.
+type goError struct {
+	Message string          `json:"message"`
+	Code    string          `json:"code"`
+	Chain   []string        `json:"chain"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// newGoError returns the shape of err for the page.
+func newGoError(err error) goError {
+	rtn := goError{Message: err.Error(), Code: CodeUnknown}
+
+	var (
+		be *BridgeError
+		pe *PanicError
+	)
+	switch {
+	case errors.As(err, &be):
+		rtn.Code = be.code()
+		if be.Data != nil {
+			if data, merr := json.Marshal(be.Data); merr == nil {
+				rtn.Data = data
+			}
+		}
+	case errors.As(err, &pe):
+		rtn.Code = CodeInternal
+	case errors.Is(err, context.Canceled):
+		rtn.Code = CodeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		rtn.Code = CodeDeadlineExceeded
+	}
+
+	// Depth first, as errors.Is walks the tree. Wrappers adding nothing to
+	// the message, like BridgeError, are skipped.
+	var walk func(error)
+	walk = func(err error) {
+		if err == nil || len(rtn.Chain) == maxChain {
+			return
+		}
+
+		if msg := err.Error(); len(rtn.Chain) == 0 || rtn.Chain[len(rtn.Chain)-1] != msg {
+			rtn.Chain = append(rtn.Chain, msg)
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			walk(u.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, err := range u.Unwrap() {
+				walk(err)
+			}
+		}
+	}
+	walk(err)
+
+	return rtn
+}
+
+// MarshalBridgeError returns the JSON object a bridge call rejected with err
+// passes to the page: its message, code, chain and data properties, see
+// BridgeError.
+func MarshalBridgeError(err error) json.RawMessage {
+	data, _ := json.Marshal(newGoError(err))
+	return data
+}
@@ -0,0 +1,74 @@
+package saucerw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrReleased is returned by Eval and Call when the webview was released
+// before the page answered.
+var ErrReleased = errors.New("saucerw: webview was released")
+
+// Execute runs code in the page without waiting for it to finish. It runs
+// after the messages the bridge has queued for the page, see BatchOptions.
+func (v *Webview) Execute(code string) {
+	v.bridge.batch.executeAlone(code)
+}
+
+// Eval evaluates the JavaScript expression expr in the page and decodes its
+// JSON encoded result into out, which may be nil to discard it. A Promise
+// returned by expr is awaited. An exception thrown by expr is returned as a
+// *CallError.
+//
+// Eval returns ctx.Err() if ctx is done before the page answered, for example
+// when its deadline passed.
+func (v *Webview) Eval(ctx context.Context, expr string, out any) error {
+	return v.eval(ctx, "", "", expr, out)
+}
+
+// eval is Eval of the expression calling method, if any, in the child frame
+// with the ID frame or the main frame if empty.
+func (v *Webview) eval(ctx context.Context, method, frame, expr string, out any) (err error) {
+	_, span := v.startSpan(ctx, "saucerw.eval", SpanKindClient)
+	defer func() { endSpan(span, err) }()
+
+	id, ch := v.bridge.evaluate(frame, expr)
+
+	var msg bridgeMessage
+	select {
+	case msg = <-ch:
+	case <-ctx.Done():
+		v.bridge.forget(id)
+		return ctx.Err()
+	case <-v.bridge.ctx.Done():
+		return ErrReleased
+	}
+
+	if msg.Exception {
+		return newCallError(method, msg.Result)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(msg.Result, out)
+}
+
+// Call calls the JavaScript function fn of the page, e.g. "window.app.load",
+// with the JSON encoded args and decodes its result into out like Eval. An
+// exception is returned as a *CallError with Method fn. See Func for typed
+// stubs of the functions a page defines.
+func (v *Webview) Call(ctx context.Context, fn string, out any, args ...any) error {
+	if args == nil {
+		args = []any{}
+	}
+
+	params, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	return v.eval(ctx, fn, "", fmt.Sprintf("%s(...%s)", fn, params), out)
+}
@@ -0,0 +1,264 @@
+package saucerw
+
+import (
+	"slices"
+	"sync"
+)
+
+// Subscription is a registered event handler.
+type Subscription struct {
+	once   sync.Once
+	cancel func()
+}
+
+// Cancel removes the handler. Events already being delivered may still reach
+// it. Cancel is safe to call more than once.
+func (s *Subscription) Cancel() {
+	s.once.Do(s.cancel)
+}
+
+// emitter fans events out to subscribed handlers.
+//
+// Events are queued without blocking the caller, typically the event loop
+// thread, and delivered in order on a separate goroutine, so handlers may
+// block or call back into the bindings.
+type emitter[E any] struct {
+	mu       sync.Mutex
+	lastID   uint64
+	handlers map[uint64]func(E)
+	queue    []E
+	draining bool
+}
+
+// subscribe registers fn for all future events.
+func (e *emitter[E]) subscribe(fn func(E)) *Subscription {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.handlers == nil {
+		e.handlers = map[uint64]func(E){}
+	}
+
+	e.lastID++
+	id := e.lastID
+	e.handlers[id] = fn
+
+	return &Subscription{cancel: func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		delete(e.handlers, id)
+	}}
+}
+
+// emit queues ev for delivery.
+func (e *emitter[E]) emit(ev E) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.handlers) == 0 {
+		return
+	}
+
+	e.queue = append(e.queue, ev)
+
+	if !e.draining {
+		e.draining = true
+		go e.drain()
+	}
+}
+
+// drain delivers queued events until the queue is empty.
+func (e *emitter[E]) drain() {
+	for {
+		e.mu.Lock()
+		if len(e.queue) == 0 {
+			e.draining = false
+			e.mu.Unlock()
+			return
+		}
+
+		ev := e.queue[0]
+		e.queue = e.queue[1:]
+
+		handlers := make([]func(E), 0, len(e.handlers))
+		for _, fn := range e.handlers {
+			handlers = append(handlers, fn)
+		}
+		e.mu.Unlock()
+
+		for _, fn := range handlers {
+			deliver(fn, ev)
+		}
+	}
+}
+
+// deliver calls the event handler fn, recovering a panic so that the other
+// handlers still receive ev.
+func deliver[E any](fn func(E), ev E) {
+	defer guard("event handler")
+	fn(ev)
+}
+
+// on subscribes fn to the window events of type typ.
+func (w *Window) on(typ WindowEventType, fn func(WindowEvent)) *Subscription {
+	return w.events.subscribe(func(ev WindowEvent) {
+		if ev.Type == typ {
+			fn(ev)
+		}
+	})
+}
+
+// OnClosed calls fn after the window was closed.
+func (w *Window) OnClosed(fn func()) *Subscription {
+	return w.on(WindowClosed, func(WindowEvent) { fn() })
+}
+
+// OnResize calls fn with the new size whenever the window was resized.
+func (w *Window) OnResize(fn func(Size)) *Subscription {
+	return w.on(WindowResize, func(ev WindowEvent) { fn(ev.Size) })
+}
+
+// OnScale calls fn with the new scale whenever the scale of the window
+// changed, e.g. when it was moved to a screen of another resolution, see
+// Window.Scale. GTK only reports integer scales.
+func (w *Window) OnScale(fn func(scale float64)) *Subscription {
+	return w.on(WindowScale, func(ev WindowEvent) { fn(ev.Scale) })
+}
+
+// OnFocus calls fn whenever the window gained or lost focus.
+func (w *Window) OnFocus(fn func(focused bool)) *Subscription {
+	return w.on(WindowFocus, func(ev WindowEvent) { fn(ev.Value) })
+}
+
+// OnMinimize calls fn whenever the window was minimized or restored.
+func (w *Window) OnMinimize(fn func(minimized bool)) *Subscription {
+	return w.on(WindowMinimize, func(ev WindowEvent) { fn(ev.Value) })
+}
+
+// OnMaximize calls fn whenever the window was maximized or restored.
+func (w *Window) OnMaximize(fn func(maximized bool)) *Subscription {
+	return w.on(WindowMaximize, func(ev WindowEvent) { fn(ev.Value) })
+}
+
+// OnDecorated calls fn whenever the window decorations changed.
+func (w *Window) OnDecorated(fn func(Decoration)) *Subscription {
+	return w.on(WindowDecorated, func(ev WindowEvent) { fn(ev.Decoration) })
+}
+
+// deciders collects handlers that synchronously decide on an action.
+type deciders[E any] struct {
+	mu       sync.Mutex
+	lastID   uint64
+	handlers map[uint64]func(E) Policy
+}
+
+// subscribe registers fn for all future decisions.
+func (d *deciders[E]) subscribe(fn func(E) Policy) *Subscription {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.handlers == nil {
+		d.handlers = map[uint64]func(E) Policy{}
+	}
+
+	d.lastID++
+	id := d.lastID
+	d.handlers[id] = fn
+
+	return &Subscription{cancel: func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		delete(d.handlers, id)
+	}}
+}
+
+// decide asks every handler and blocks if any of them does.
+func (d *deciders[E]) decide(ev E) Policy {
+	d.mu.Lock()
+	handlers := make([]func(E) Policy, 0, len(d.handlers))
+	for _, fn := range d.handlers {
+		handlers = append(handlers, fn)
+	}
+	d.mu.Unlock()
+
+	rtn := Allow
+	for _, fn := range handlers {
+		if askDecider(fn, ev) == Block {
+			rtn = Block
+		}
+	}
+	return rtn
+}
+
+// askDecider calls the decision handler fn, blocking the action if it
+// panics.
+func askDecider[E any](fn func(E) Policy, ev E) (rtn Policy) {
+	defer func() {
+		if r := recover(); r != nil {
+			reportPanic("decision handler", r)
+			rtn = Block
+		}
+	}()
+	return fn(ev)
+}
+
+// chain collects handlers that are asked in registration order until one of
+// them decides on an action.
+type chain[E, D any] struct {
+	mu       sync.Mutex
+	lastID   uint64
+	ids      []uint64
+	handlers []func(E) D
+}
+
+// subscribe appends fn to the chain.
+func (c *chain[E, D]) subscribe(fn func(E) D) *Subscription {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastID++
+	id := c.lastID
+
+	c.ids = append(c.ids, id)
+	c.handlers = append(c.handlers, fn)
+
+	return &Subscription{cancel: func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if i := slices.Index(c.ids, id); i >= 0 {
+			c.ids = slices.Delete(c.ids, i, i+1)
+			c.handlers = slices.Delete(c.handlers, i, i+1)
+		}
+	}}
+}
+
+// first returns the first decision of a handler for which decided reports
+// true, or the zero value. A panicking handler decides on fallback.
+func (c *chain[E, D]) first(ev E, decided func(*D) bool, fallback D) D {
+	c.mu.Lock()
+	handlers := slices.Clone(c.handlers)
+	c.mu.Unlock()
+
+	for _, fn := range handlers {
+		if rtn := askChain(fn, ev, fallback); decided(&rtn) {
+			return rtn
+		}
+	}
+
+	var zero D
+	return zero
+}
+
+// askChain calls the decision handler fn, deciding on fallback if it panics.
+func askChain[E, D any](fn func(E) D, ev E, fallback D) (rtn D) {
+	defer func() {
+		if r := recover(); r != nil {
+			reportPanic("decision handler", r)
+			rtn = fallback
+		}
+	}()
+	return fn(ev)
+}
@@ -0,0 +1,643 @@
+package saucerw
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// fetchScheme is the custom scheme the page sends proxied requests to. It is
+// registered by every Application.
+const fetchScheme = "saucerw-fetch"
+
+// fetchErrorHeader carries the error of a request the proxy could not send,
+// which fetch rejects with.
+const fetchErrorHeader = "Saucer-Fetch-Error"
+
+// fetchFunction is the name the rules of SecurityPolicy.Bridge allow the
+// fetch proxy with, like a function of the page.
+const fetchFunction = "saucerw.fetch"
+
+// FetchProxy routes the requests of pages to some hosts through Go, see
+// Webview.ProxyFetch.
+type FetchProxy struct {
+	// Hosts lists the hosts whose requests are proxied, in the patterns of
+	// NetworkOptions.AllowedHosts. At least one is required.
+	Hosts []string
+	// Client sends the requests, http.DefaultClient if nil. Its transport
+	// decides on proxies and client certificates, its jar on cookies.
+	Client *http.Client
+	// Prepare, if non-nil, is called with every request before it is sent,
+	// including the handshakes of WebSockets, e.g. to add credentials. An
+	// error fails the request of the page.
+	Prepare func(*http.Request) error
+	// Dial, if non-nil, connects WebSockets to addr, the host and port of
+	// their URL. By default they are dialed like the transport of Client
+	// does, through the HTTP proxy it selects.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// TLSConfig configures wss:// WebSockets, by default the TLSClientConfig
+	// of the transport of Client.
+	TLSConfig *tls.Config
+}
+
+// fetchProxy is the FetchProxy of a webview with the requests and sockets it
+// serves.
+type fetchProxy struct {
+	bridge *bridge
+
+	mu     sync.Mutex
+	opts   FetchProxy
+	script uint64
+	// token authenticates the requests of the page of origin to the fetch
+	// scheme, until it navigates away.
+	token    string
+	origin   string
+	requests map[string]context.CancelFunc
+	sockets  map[string]*proxySocket
+}
+
+// options returns the current FetchProxy, with its client.
+func (p *fetchProxy) options() FetchProxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	opts := p.opts
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return opts
+}
+
+// fetchScript replaces fetch and WebSocket for the hosts it is formatted
+// with, by their JSON encoding. Requests are sent to the fetch scheme, the
+// messages of sockets posted to and executed by the bridge. Formatting it
+// again updates the hosts.
+const fetchScript = `
+(() =>
+{
+    if (!window.saucer)
+    {
+        return;
+    }
+
+    const hosts = %s;
+
+    if (window.saucer.internal.proxy)
+    {
+        window.saucer.internal.proxy.hosts = hosts;
+        return;
+    }
+
+    const state = window.saucer.internal.proxy = { hosts, sockets: new Map() };
+    const token = new Promise((resolve) => state.grant = resolve);
+
+    let last = 0;
+
+    const id = () => Date.now() + "-" + ++last;
+
+    const post = (id, op, data) =>
+    {
+        window.saucer.internal.post(JSON.stringify({ ["saucer:proxy"]: id, op, data }));
+    };
+
+    post(id(), "token");
+
+    const target = (url, schemes) =>
+    {
+        try
+        {
+            url = new URL(url, location.href);
+        } catch
+        {
+            return null;
+        }
+
+        const host = url.hostname.toLowerCase().replace(/\.$/, "");
+        const match = (pattern) =>
+        {
+            pattern = pattern.toLowerCase();
+            return pattern.startsWith("*.") ? host.endsWith(pattern.slice(1)) : host === pattern;
+        };
+
+        return schemes.includes(url.protocol) && state.hosts.some(match) ? url : null;
+    };
+
+    const fetch = window.fetch;
+
+    window.fetch = async (input, init) =>
+    {
+        const request = new Request(input, init);
+
+        if (!target(request.url, ["http:", "https:"]))
+        {
+            return fetch(request);
+        }
+
+        request.signal.throwIfAborted();
+
+        const granted = await token;
+        if (granted === null)
+        {
+            throw new TypeError("saucerw: the page may not proxy requests");
+        }
+
+        const ref  = id();
+        const body = ["GET", "HEAD"].includes(request.method) ? undefined : await request.arrayBuffer();
+
+        request.signal.addEventListener("abort", () => post(ref, "abort"), { once: true });
+
+        const url      = "` + fetchScheme + `://fetch/?" + new URLSearchParams({ id: ref, token: granted, url: request.url });
+        const response = await fetch(url, {
+            method: request.method,
+            headers: request.headers,
+            body,
+            signal: request.signal,
+            credentials: "omit",
+            cache: "no-store",
+        });
+
+        const error = response.headers.get("` + fetchErrorHeader + `");
+        if (error !== null)
+        {
+            throw new TypeError(error);
+        }
+
+        return response;
+    };
+
+    const base64 = (bytes) =>
+    {
+        let binary = "";
+
+        for (let i = 0; i < bytes.length; i += 0x8000)
+        {
+            binary += String.fromCharCode(...bytes.subarray(i, i + 0x8000));
+        }
+
+        return btoa(binary);
+    };
+
+    const NativeSocket = window.WebSocket;
+
+    class ProxiedSocket extends EventTarget
+    {
+        onopen    = null;
+        onmessage = null;
+        onerror   = null;
+        onclose   = null;
+
+        #id;
+        #url;
+        #ready      = 0;
+        #protocol   = "";
+        #extensions = "";
+        #buffered   = 0;
+        #binary     = "blob";
+        #queue      = Promise.resolve();
+
+        constructor(url, protocols = [])
+        {
+            super();
+
+            url = target(url, ["ws:", "wss:", "http:", "https:"]);
+            url.protocol = url.protocol.replace(/^http/, "ws");
+
+            this.#id  = id();
+            this.#url = url.href;
+
+            state.sockets.set(this.#id, (op, value) => this.#handle(op, value));
+            post(this.#id, "open", { url: this.#url, protocols: [].concat(protocols), origin: location.origin });
+        }
+
+        get url() { return this.#url; }
+        get readyState() { return this.#ready; }
+        get protocol() { return this.#protocol; }
+        get extensions() { return this.#extensions; }
+        get bufferedAmount() { return this.#buffered; }
+        get binaryType() { return this.#binary; }
+
+        set binaryType(type)
+        {
+            if (type === "blob" || type === "arraybuffer")
+            {
+                this.#binary = type;
+            }
+        }
+
+        send(data)
+        {
+            if (this.#ready === 0)
+            {
+                throw new DOMException("Still in CONNECTING state.", "InvalidStateError");
+            }
+
+            if (this.#ready !== 1)
+            {
+                return;
+            }
+
+            const text = !(data instanceof Blob || data instanceof ArrayBuffer || ArrayBuffer.isView(data));
+            data       = text ? String(data) : data;
+
+            const size = text ? new TextEncoder().encode(data).length : (data.byteLength ?? data.size);
+
+            this.#buffered += size;
+            this.#enqueue("send", async () =>
+            {
+                if (text)
+                {
+                    return { text: data };
+                }
+
+                const buffer = data instanceof Blob ? await data.arrayBuffer() : data;
+                const bytes  = ArrayBuffer.isView(buffer) ? new Uint8Array(buffer.buffer, buffer.byteOffset, buffer.byteLength) : new Uint8Array(buffer);
+
+                return { binary: base64(bytes) };
+            });
+        }
+
+        close(code, reason = "")
+        {
+            if (code !== undefined && code !== 1000 && (code < 3000 || code > 4999))
+            {
+                throw new DOMException("The close code must be 1000 or between 3000 and 4999.", "InvalidAccessError");
+            }
+
+            if (this.#ready >= 2)
+            {
+                return;
+            }
+
+            this.#ready = 2;
+            this.#enqueue("close", () => ({ code: code ?? 1000, reason }));
+        }
+
+        #enqueue(op, data)
+        {
+            this.#queue = this.#queue.then(async () => post(this.#id, op, await data())).catch(console.error);
+        }
+
+        #emit(event)
+        {
+            this.dispatchEvent(event);
+            this["on" + event.type]?.call(this, event);
+        }
+
+        #handle(op, value)
+        {
+            switch (op)
+            {
+            case "open":
+                // Closed while connecting, Go closes it right away
+                if (this.#ready !== 0)
+                {
+                    break;
+                }
+
+                this.#ready      = 1;
+                this.#protocol   = value.protocol;
+                this.#extensions = value.extensions;
+                this.#emit(new Event("open"));
+                break;
+            case "message": {
+                let data = value.text;
+
+                if (value.binary !== undefined)
+                {
+                    const bytes = Uint8Array.from(atob(value.binary), (c) => c.charCodeAt(0));
+                    data        = this.#binary === "blob" ? new Blob([bytes]) : bytes.buffer;
+                }
+
+                this.#emit(new MessageEvent("message", { data, origin: new URL(this.#url).origin }));
+                break;
+            }
+            case "sent":
+                this.#buffered = Math.max(0, this.#buffered - value.bytes);
+                break;
+            case "error":
+                this.#emit(new Event("error"));
+                break;
+            case "close":
+                this.#ready = 3;
+                state.sockets.delete(this.#id);
+                this.#emit(new CloseEvent("close", { code: value.code, reason: value.reason, wasClean: value.clean }));
+                break;
+            }
+        }
+    }
+
+    for (const [name, value] of Object.entries({ CONNECTING: 0, OPEN: 1, CLOSING: 2, CLOSED: 3 }))
+    {
+        Object.defineProperty(ProxiedSocket, name, { value });
+        Object.defineProperty(ProxiedSocket.prototype, name, { value });
+    }
+
+    Object.setPrototypeOf(ProxiedSocket.prototype, NativeSocket.prototype);
+
+    window.WebSocket = new Proxy(NativeSocket, {
+        construct: (native, args, next) =>
+        {
+            if (!target(args[0], ["ws:", "wss:", "http:", "https:"]))
+            {
+                return Reflect.construct(native, args, next);
+            }
+
+            return new ProxiedSocket(...args);
+        },
+    });
+
+    state.socket = (id, op, value) => state.sockets.get(id)?.(op, value);
+})();
+`
+
+// ProxyFetch routes the fetch calls and WebSockets of the pages of v to the
+// hosts of p through Go: requests are sent by p.Client, honoring its
+// proxies, client certificates and cookies, and WebSockets connect from Go.
+// The network policy of the application lives in one place and credentials
+// stay in Go. Calling it again replaces p.
+//
+// Response bodies are streamed to the page, request bodies are read whole
+// before the request is sent. The proxied responses are not tied to the
+// page: their URL is that of an internal scheme, redirects are followed by
+// the client and cookies of the engine are not sent. Requests of the engine
+// itself, e.g. of images or forms, are not proxied.
+//
+// Only the main frame of a page proxies requests, if the rules of
+// SecurityPolicy.Bridge allow its origin to call "saucerw.fetch". The page
+// gets a token for its requests to the internal scheme, which is replaced
+// when it navigates away, so child frames and other pages cannot send them.
+func (v *Webview) ProxyFetch(p FetchProxy) error {
+	if len(p.Hosts) == 0 {
+		return errors.New("saucerw: no hosts proxied, set FetchProxy.Hosts")
+	}
+
+	for _, host := range p.Hosts {
+		if strings.TrimPrefix(host, "*.") == "" || strings.ContainsAny(host, "/:") {
+			return fmt.Errorf("saucerw: invalid proxied host %q", host)
+		}
+	}
+
+	hosts, _ := json.Marshal(p.Hosts)
+	code := fmt.Sprintf(fetchScript, hosts)
+	script := v.InjectScript(Script{Code: code, Time: AtCreation, Frames: MainFrame, Permanent: true})
+
+	proxy := &fetchProxy{bridge: v.bridge, requests: map[string]context.CancelFunc{}, sockets: map[string]*proxySocket{}}
+	if v.bridge.fetch.CompareAndSwap(nil, proxy) {
+		v.HandleStreamScheme(fetchScheme, proxy)
+	} else {
+		proxy = v.bridge.fetch.Load()
+	}
+
+	proxy.mu.Lock()
+	previous := proxy.script
+	proxy.opts, proxy.script = p, script
+	proxy.mu.Unlock()
+
+	if previous != 0 {
+		v.Uninject(previous)
+	}
+
+	v.Execute(code)
+	return nil
+}
+
+// hopHeaders are the headers of a connection rather than a request, which
+// are not passed on. Accept-Encoding is left to the transport, which then
+// decompresses the response.
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Connection", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade", "Host", "Accept-Encoding",
+}
+
+// passHeaders copies the headers of from to to, without the hop-by-hop and
+// CORS headers.
+func passHeaders(to, from http.Header) {
+	for key, values := range from {
+		if hop(key) || strings.HasPrefix(key, "Access-Control-") {
+			continue
+		}
+		to[key] = values
+	}
+}
+
+// hop reports whether key is a hop-by-hop header.
+func hop(key string) bool {
+	for _, name := range hopHeaders {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// issue returns the token of the requests of the page of origin, a new one
+// unless the page already got it.
+func (p *fetchProxy) issue(origin string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == "" || p.origin != origin {
+		key := make([]byte, 16)
+		rand.Read(key)
+		p.token, p.origin = hex.EncodeToString(key), origin
+	}
+	return p.token
+}
+
+// allow returns an error unless r carries the token of the current page and
+// comes from its origin.
+func (p *fetchProxy) allow(r *http.Request) error {
+	p.mu.Lock()
+	token, origin := p.token, p.origin
+	p.mu.Unlock()
+
+	if token == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(token)) != 1 {
+		return errors.New("saucerw: request without the token of the page")
+	}
+	if from := strings.ToLower(r.Header.Get("Origin")); from != origin {
+		return fmt.Errorf("saucerw: request from origin %q, expected %q", from, origin)
+	}
+	return nil
+}
+
+// ServeHTTP sends the request of the page named by the query of r and
+// streams the response back.
+func (p *fetchProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := p.allow(r); err != nil {
+		log().Warn("refusing proxied request", "component", "saucerw", "error", err)
+		fetchError(w, http.StatusForbidden, err)
+		return
+	}
+
+	// The response goes to the page only, which sends no credentials
+	w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+	w.Header().Set("Access-Control-Expose-Headers", "*")
+	w.Header().Set("Vary", "Origin")
+
+	if method := r.Header.Get("Access-Control-Request-Method"); r.Method == http.MethodOptions && method != "" {
+		w.Header().Set("Access-Control-Allow-Methods", method)
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	opts := p.options()
+	id, target := r.URL.Query().Get("id"), r.URL.Query().Get("url")
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !matchHost(opts.Hosts, u.Hostname()) {
+		fetchError(w, http.StatusForbidden, fmt.Errorf("saucerw: %s is not proxied", target))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(p.bridge.ctx)
+	defer cancel()
+
+	p.mu.Lock()
+	_, taken := p.requests[id]
+	if !taken && id != "" {
+		p.requests[id] = cancel
+	}
+	p.mu.Unlock()
+
+	if taken || id == "" {
+		fetchError(w, http.StatusBadRequest, fmt.Errorf("saucerw: invalid request id %q", id))
+		return
+	}
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.requests, id)
+		p.mu.Unlock()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, u.String(), r.Body)
+	if err != nil {
+		fetchError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req.ContentLength = r.ContentLength
+	passHeaders(req.Header, r.Header)
+
+	if opts.Prepare != nil {
+		if err := opts.Prepare(req); err != nil {
+			fetchError(w, http.StatusBadGateway, err)
+			return
+		}
+	}
+
+	res, err := opts.Client.Do(req)
+	if err != nil {
+		fetchError(w, http.StatusBadGateway, err)
+		return
+	}
+	defer res.Body.Close()
+
+	passHeaders(w.Header(), res.Header)
+	w.WriteHeader(res.StatusCode)
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := res.Body.Read(buf)
+		if n > 0 {
+			w.Write(buf[:n])
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// fetchError answers a request the proxy could not send with err, which the
+// page rejects with.
+func fetchError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set(fetchErrorHeader, strings.ReplaceAll(err.Error(), "\n", " "))
+	w.WriteHeader(status)
+}
+
+// onProxy handles the messages of the requests and sockets of the fetch
+// proxy.
+func (b *bridge) onProxy(msg bridgeMessage) {
+	p := b.fetch.Load()
+	if p == nil {
+		return
+	}
+
+	switch msg.Op {
+	case "token":
+		token := "null"
+		if b.permitProxy(msg) == nil {
+			data, _ := json.Marshal(p.issue(originOf(b.native.URL())))
+			token = string(data)
+		}
+		b.batch.execute(fmt.Sprintf("window.saucer.internal.proxy?.grant(%s);", token))
+	case "abort":
+		p.mu.Lock()
+		cancel := p.requests[msg.Proxy]
+		p.mu.Unlock()
+
+		if cancel != nil {
+			cancel()
+		}
+	case "open":
+		if b.permitProxy(msg) != nil {
+			p.refuse(msg.Proxy)
+			return
+		}
+		p.open(msg.Proxy, msg.Data)
+	case "send", "close":
+		p.mu.Lock()
+		s := p.sockets[msg.Proxy]
+		p.mu.Unlock()
+
+		if s != nil {
+			s.enqueue(msg.Op, msg.Data)
+		}
+	}
+}
+
+// permitProxy returns an error unless the rules of the policy allow the page
+// posting msg to proxy requests.
+func (b *bridge) permitProxy(msg bridgeMessage) error {
+	msg.Name = fetchFunction
+	return b.permit(msg)
+}
+
+// cancelProxied cancels the proxied requests and sockets of a page that
+// navigated away and revokes its token.
+func (b *bridge) cancelProxied() {
+	p := b.fetch.Load()
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	requests, sockets := p.requests, p.sockets
+	p.requests, p.sockets = map[string]context.CancelFunc{}, map[string]*proxySocket{}
+	p.token, p.origin = "", ""
+	p.mu.Unlock()
+
+	for _, cancel := range requests {
+		cancel()
+	}
+	for _, s := range sockets {
+		s.cancel()
+	}
+}
@@ -0,0 +1,108 @@
+package saucerw_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// grantScript matches the script passing the token of the fetch proxy to
+// the page.
+var grantScript = regexp.MustCompile(`proxy\?\.grant\((.*)\);`)
+
+// TestFetchProxyToken checks that the fetch scheme only serves the requests
+// carrying the token of the current page, from its origin, and that pages
+// the policy does not allow get no token.
+func TestFetchProxyToken(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "proxied")
+	}))
+	defer backend.Close()
+
+	const origin = "app://localhost"
+	opts := saucerw.WebviewOptions{
+		Batching: saucerw.BatchOptions{Disabled: true},
+		Security: saucerw.SecurityPolicy{Bridge: []saucerw.BridgeRule{{Origin: origin}}},
+	}
+
+	runPage(t, opts, func(v *saucerw.Webview) {
+		if err := v.ProxyFetch(saucerw.FetchProxy{Hosts: []string{"127.0.0.1"}}); err != nil {
+			t.Error(err)
+		}
+	}, func(page *saucertest.Webview) {
+		// grant asks for the token of the page like the proxy script, "" if
+		// the page got none
+		grant := func() string {
+			if _, err := page.Post(`{"saucer:proxy": "1-1", "op": "token"}`); err != nil {
+				t.Error(err)
+			}
+
+			var token string
+			executed := page.Executed()
+			if m := grantScript.FindStringSubmatch(executed[len(executed)-1]); m != nil {
+				json.Unmarshal([]byte(m[1]), &token)
+			}
+			return token
+		}
+
+		fetch := func(id, token, from string) saucerw.SchemeResponse {
+			query := url.Values{"id": {id}, "token": {token}, "url": {backend.URL}}
+			res, err := page.Fetch(context.Background(), saucerw.SchemeRequest{
+				URL:     "saucerw-fetch://fetch/?" + query.Encode(),
+				Headers: map[string]string{"Origin": from},
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			return res
+		}
+
+		page.SetURL(origin + "/index.html")
+		token := grant()
+		if token == "" {
+			t.Error("the page got no token")
+			return
+		}
+		if again := grant(); again != token {
+			t.Errorf("token %q, then %q", token, again)
+		}
+
+		res := fetch("1", token, origin)
+		if res.Status != http.StatusOK || string(res.Body) != "proxied" || res.Headers["Access-Control-Allow-Origin"] != origin {
+			t.Errorf("request of the page answered %d %q with %v", res.Status, res.Body, res.Headers)
+		}
+
+		tests := []struct {
+			name, id, token, origin string
+			status                  int
+		}{
+			{"no token", "2", "", origin, http.StatusForbidden},
+			{"wrong token", "3", token + "0", origin, http.StatusForbidden},
+			{"other origin", "4", token, "https://example.com", http.StatusForbidden},
+			{"no origin", "5", token, "", http.StatusForbidden},
+			{"no id", "", token, origin, http.StatusBadRequest},
+		}
+		for _, test := range tests {
+			res := fetch(test.id, test.token, test.origin)
+			if res.Status != test.status || res.Headers["Access-Control-Allow-Origin"] != "" && test.status == http.StatusForbidden {
+				t.Errorf("%s: answered %d with %v, expected %d", test.name, res.Status, res.Headers, test.status)
+			}
+		}
+
+		page.SetURL("https://example.com/")
+		if res := fetch("6", token, origin); res.Status != http.StatusForbidden {
+			t.Errorf("token of the previous page answered %d", res.Status)
+		}
+		if token := grant(); token != "" {
+			t.Errorf("page of another origin got token %q", token)
+		}
+	})
+}
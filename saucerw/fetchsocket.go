@@ -0,0 +1,457 @@
+package saucerw
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw/internal/websocket"
+)
+
+const (
+	// socketMaxMessage is the largest message a proxied socket receives.
+	socketMaxMessage = 64 << 20
+	// socketTimeout bounds connecting a socket and its closing handshake.
+	socketTimeout = 30 * time.Second
+)
+
+// socketData is the data of the messages of the page about a socket.
+type socketData struct {
+	URL       string   `json:"url"`
+	Protocols []string `json:"protocols"`
+	Origin    string   `json:"origin"`
+	Text      *string  `json:"text"`
+	Binary    []byte   `json:"binary"`
+	Code      uint16   `json:"code"`
+	Reason    string   `json:"reason"`
+}
+
+// socketOp is a message or the close of the page, sent in order.
+type socketOp struct {
+	close bool
+	data  socketData
+}
+
+// proxySocket is a WebSocket of the page connected from Go.
+type proxySocket struct {
+	proxy  *fetchProxy
+	id     string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	queue []socketOp
+	wake  chan struct{}
+}
+
+// open connects the socket id of the page described by raw.
+func (p *fetchProxy) open(id string, raw json.RawMessage) {
+	var data socketData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(p.bridge.ctx)
+	s := &proxySocket{proxy: p, id: id, ctx: ctx, cancel: cancel, wake: make(chan struct{}, 1)}
+
+	p.mu.Lock()
+	p.sockets[id] = s
+	p.mu.Unlock()
+
+	go s.run(data)
+}
+
+// refuse fails the socket id of a page that may not proxy requests.
+func (p *fetchProxy) refuse(id string) {
+	s := &proxySocket{proxy: p, id: id, ctx: p.bridge.ctx}
+	s.event("error", nil)
+	s.event("close", socketClosed{Code: websocket.CloseAbnormal})
+}
+
+// enqueue queues the message or close op of the page.
+func (s *proxySocket) enqueue(op string, raw json.RawMessage) {
+	var data socketData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.queue = append(s.queue, socketOp{close: op == "close", data: data})
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// event passes op with value to the socket in the page, unless the page
+// navigated away.
+func (s *proxySocket) event(op string, value any) {
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	id, _ := json.Marshal(s.id)
+	data, _ := json.Marshal(value)
+	s.proxy.bridge.batch.execute(fmt.Sprintf("window.saucer.internal.proxy?.socket(%s, %q, %s);", id, op, data))
+}
+
+// socketClosed is the value of the close event.
+type socketClosed struct {
+	Code   uint16 `json:"code"`
+	Reason string `json:"reason"`
+	Clean  bool   `json:"clean"`
+}
+
+// run connects the socket and relays its messages until it is closed.
+func (s *proxySocket) run(data socketData) {
+	defer func() {
+		s.proxy.mu.Lock()
+		if s.proxy.sockets[s.id] == s {
+			delete(s.proxy.sockets, s.id)
+		}
+		s.proxy.mu.Unlock()
+
+		s.cancel()
+	}()
+
+	ws, protocol, err := s.proxy.dial(s.ctx, data)
+	if err != nil {
+		log().Debug("proxied WebSocket failed", "component", "saucerw", "url", data.URL, "error", err)
+		s.event("error", nil)
+		s.event("close", socketClosed{Code: websocket.CloseAbnormal})
+		return
+	}
+	defer ws.NetConn().Close()
+
+	// Going away, like a page navigating away from an open socket
+	stop := context.AfterFunc(s.ctx, func() {
+		ws.NetConn().SetWriteDeadline(time.Now().Add(time.Second))
+		ws.WriteClose(websocket.CloseGoingAway, "")
+		ws.NetConn().Close()
+	})
+	defer stop()
+
+	s.event("open", map[string]string{"protocol": protocol, "extensions": ""})
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.write(ws, done)
+
+	closed := s.read(ws)
+	if !closed.Clean {
+		s.event("error", nil)
+	}
+	s.event("close", closed)
+}
+
+// write sends the queued ops of the page until the socket is closed.
+func (s *proxySocket) write(ws *websocket.Conn, done <-chan struct{}) {
+	for {
+		select {
+		case <-s.wake:
+		case <-done:
+			return
+		case <-s.ctx.Done():
+			return
+		}
+
+		for {
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			op := s.queue[0]
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+
+			var err error
+			switch {
+			case op.close:
+				// The server answers, or the socket is dropped
+				ws.NetConn().SetReadDeadline(time.Now().Add(socketTimeout))
+				ws.WriteClose(op.data.Code, op.data.Reason)
+				return
+			case op.data.Text != nil:
+				err = ws.WriteFrame(websocket.OpText, []byte(*op.data.Text))
+				s.event("sent", map[string]int{"bytes": len(*op.data.Text)})
+			default:
+				err = ws.WriteFrame(websocket.OpBinary, op.data.Binary)
+				s.event("sent", map[string]int{"bytes": len(op.data.Binary)})
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// read passes the messages of the server to the page until the socket is
+// closed and returns how.
+func (s *proxySocket) read(ws *websocket.Conn) socketClosed {
+	for {
+		op, payload, err := ws.ReadMessage()
+		if err != nil {
+			return socketClosed{Code: websocket.CloseAbnormal}
+		}
+
+		switch op {
+		case websocket.OpText:
+			s.event("message", map[string]string{"text": string(payload)})
+		case websocket.OpBinary:
+			s.event("message", map[string][]byte{"binary": payload})
+		case websocket.OpClose:
+			closed := socketClosed{Code: websocket.CloseNoStatus, Clean: true}
+			if len(payload) >= 2 {
+				closed.Code = binary.BigEndian.Uint16(payload)
+				closed.Reason = string(payload[2:])
+			}
+
+			ws.WriteClose(closed.Code, "")
+			return closed
+		}
+	}
+}
+
+// dial connects and upgrades the socket described by data.
+func (p *fetchProxy) dial(ctx context.Context, data socketData) (*websocket.Conn, string, error) {
+	opts := p.options()
+
+	u, err := url.Parse(data.URL)
+	if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || !matchHost(opts.Hosts, u.Hostname()) {
+		return nil, "", fmt.Errorf("saucerw: %s is not proxied", data.URL)
+	}
+
+	target := *u
+	target.Scheme, target.Fragment = "http", ""
+	port := "80"
+	if u.Scheme == "wss" {
+		target.Scheme, port = "https", "443"
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, socketTimeout)
+	defer cancel()
+
+	conn, err := dialSocket(ctx, opts, &target, addr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The deadline of ctx, or now once it is canceled
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) })
+
+	ws, protocol, err := handshake(ctx, conn, opts, &target, addr, data)
+	if err != nil || !stop() {
+		conn.Close()
+		return nil, "", errors.Join(err, ctx.Err())
+	}
+
+	conn.SetDeadline(time.Time{})
+	return ws, protocol, nil
+}
+
+// handshake upgrades conn to a WebSocket connection to target, TLS first if
+// its scheme is https.
+func handshake(ctx context.Context, conn net.Conn, opts FetchProxy, target *url.URL, addr string, data socketData) (*websocket.Conn, string, error) {
+	if target.Scheme == "https" {
+		config := opts.TLSConfig
+		if t, ok := transport(opts); config == nil && ok {
+			config = t.TLSClientConfig
+		}
+
+		if config == nil {
+			config = &tls.Config{}
+		}
+		config = config.Clone()
+		if config.ServerName == "" {
+			config.ServerName = target.Hostname()
+		}
+		config.NextProtos = []string{"http/1.1"}
+
+		tc := tls.Client(conn, config)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return nil, "", fmt.Errorf("saucerw: %s: %w", addr, err)
+		}
+		conn = tc
+	}
+
+	key := websocket.NewKey()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("saucerw: %w", err)
+	}
+
+	websocket.SetRequest(req, key)
+	if len(data.Protocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(data.Protocols, ", "))
+	}
+	if data.Origin != "" && data.Origin != "null" {
+		req.Header.Set("Origin", data.Origin)
+	}
+
+	if jar := opts.Client.Jar; jar != nil {
+		for _, cookie := range jar.Cookies(target) {
+			req.AddCookie(cookie)
+		}
+	}
+
+	if opts.Prepare != nil {
+		if err := opts.Prepare(req); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, "", fmt.Errorf("saucerw: %s: %w", addr, err)
+	}
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("saucerw: %s: %w", addr, err)
+	}
+
+	if jar := opts.Client.Jar; jar != nil {
+		jar.SetCookies(target, res.Cookies())
+	}
+
+	if err := websocket.CheckResponse(res, key); err != nil {
+		return nil, "", fmt.Errorf("saucerw: %s: %w", target, err)
+	}
+	if protocol := res.Header.Get("Sec-WebSocket-Protocol"); protocol != "" && !slices.Contains(data.Protocols, protocol) {
+		return nil, "", fmt.Errorf("saucerw: %s: server chose unrequested subprotocol %q", target, protocol)
+	}
+
+	return websocket.NewConn(conn, r, true, socketMaxMessage), res.Header.Get("Sec-WebSocket-Protocol"), nil
+}
+
+// transport returns the *http.Transport of the client of opts.
+func transport(opts FetchProxy) (*http.Transport, bool) {
+	if opts.Client.Transport == nil {
+		t, ok := http.DefaultTransport.(*http.Transport)
+		return t, ok
+	}
+
+	t, ok := opts.Client.Transport.(*http.Transport)
+	return t, ok
+}
+
+// dialSocket connects to addr with opts.Dial, or like the transport of the
+// client of opts, tunneling through its HTTP proxy.
+func dialSocket(ctx context.Context, opts FetchProxy, target *url.URL, addr string) (net.Conn, error) {
+	if opts.Dial != nil {
+		conn, err := opts.Dial(ctx, "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("saucerw: %s: %w", addr, err)
+		}
+		return conn, nil
+	}
+
+	var dialer net.Dialer
+	dial := dialer.DialContext
+
+	t, ok := transport(opts)
+	if ok && t.DialContext != nil {
+		dial = t.DialContext
+	}
+
+	var proxy *url.URL
+	if ok && t.Proxy != nil {
+		var err error
+		if proxy, err = t.Proxy(&http.Request{URL: target, Header: http.Header{}}); err != nil {
+			return nil, fmt.Errorf("saucerw: proxy: %w", err)
+		}
+	}
+
+	if proxy == nil {
+		conn, err := dial(ctx, "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("saucerw: %s: %w", addr, err)
+		}
+		return conn, nil
+	}
+
+	if proxy.Scheme != "http" && proxy.Scheme != "https" {
+		return nil, fmt.Errorf("saucerw: %s proxies are not supported for WebSockets", proxy.Scheme)
+	}
+
+	paddr := proxy.Host
+	if proxy.Port() == "" {
+		paddr = net.JoinHostPort(proxy.Hostname(), map[string]string{"http": "80", "https": "443"}[proxy.Scheme])
+	}
+
+	conn, err := dial(ctx, "tcp", paddr)
+	if err != nil {
+		return nil, fmt.Errorf("saucerw: proxy %s: %w", paddr, err)
+	}
+
+	conn, err = tunnel(ctx, conn, t, proxy, addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// tunnel asks the proxy on conn to connect to addr. It returns conn, which it
+// wraps in TLS for an https proxy.
+func tunnel(ctx context.Context, conn net.Conn, t *http.Transport, proxy *url.URL, addr string) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	if proxy.Scheme == "https" {
+		tc := tls.Client(conn, &tls.Config{ServerName: proxy.Hostname()})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return conn, fmt.Errorf("saucerw: proxy %s: %w", proxy.Host, err)
+		}
+		conn = tc
+	}
+
+	req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: addr}, Host: addr, Header: http.Header{}}
+	for key, values := range t.ProxyConnectHeader {
+		req.Header[key] = values
+	}
+	if user := proxy.User; user != nil {
+		password, _ := user.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+	}
+
+	if err := req.Write(conn); err != nil {
+		return conn, fmt.Errorf("saucerw: proxy %s: %w", proxy.Host, err)
+	}
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		return conn, fmt.Errorf("saucerw: proxy %s: %w", proxy.Host, err)
+	}
+
+	if res.StatusCode != http.StatusOK || r.Buffered() > 0 {
+		return conn, fmt.Errorf("saucerw: proxy %s refused to connect to %s: %s", proxy.Host, addr, res.Status)
+	}
+	return conn, nil
+}
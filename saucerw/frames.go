@@ -0,0 +1,350 @@
+package saucerw
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// frameRouterScript tracks the child frames of the page in the main frame
+// and relays the evaluations of Go and the calls of the frames allowed to
+// call exposed functions. window.saucerFrames cannot be replaced by the
+// page and evaluations need the key of the webview and the token of the
+// frame, held in closures. This keeps frames from posting evaluations, not
+// a hostile page, which shares the world of the script, from the bridge.
+const frameRouterScript = `
+(() =>
+{
+    const key       = %q;
+    const stringify = JSON.stringify;
+    const frames    = Object.create(null);
+    const pending   = Object.create(null);
+
+    let last = 0;
+
+    const lookup = (source) =>
+    {
+        for (const id in frames)
+        {
+            if (frames[id].window === source)
+            {
+                return frames[id];
+            }
+        }
+
+        return undefined;
+    };
+
+    const send = (frame, message) =>
+    {
+        // Keep messages away from a document of another origin the frame navigated to
+        const target = /^https?:/.test(frame.origin) ? frame.origin : "*";
+        frame.window.postMessage({ ...message, token: frame.token }, target);
+    };
+
+    const resolve = (id, exception, result) =>
+    {
+        window.saucer.internal.post(stringify({ ["saucer:resolve"]: true, id, exception, result }));
+    };
+
+    const list = () =>
+    {
+        const rtn = [];
+
+        for (const id in frames)
+        {
+            const frame = frames[id];
+
+            if (frame.window.closed)
+            {
+                delete frames[id];
+                continue;
+            }
+
+            const parent = frame.window.parent === window ? undefined : lookup(frame.window.parent);
+            rtn.push({ id, parent: parent?.id ?? "", url: frame.url, origin: frame.origin, name: frame.name });
+        }
+
+        return rtn;
+    };
+
+    const evaluate = (secret, id, evaluation, code) =>
+    {
+        if (secret !== key)
+        {
+            return;
+        }
+
+        const frame = frames[id];
+
+        if (!frame || frame.window.closed)
+        {
+            resolve(evaluation, true, { name: "Error", message: "No frame '" + id + "'" });
+            return;
+        }
+
+        pending[evaluation] = frame;
+        send(frame, { ["saucer:frame"]: "eval", id: evaluation, code });
+    };
+
+    const call = async (frame, { id, name, params }) =>
+    {
+        const settle = (ok, value) =>
+        {
+            const error = ok ? undefined : window.saucer.internal.exception(value);
+
+            if (typeof error === "object")
+            {
+                error.chain = value.chain;
+                error.data  = value.data;
+            }
+
+            send(frame, { ["saucer:frame"]: "settle", id, ok, value: ok ? value : undefined, error });
+        };
+
+        try
+        {
+            const packed = await window.saucer.internal.pack(params);
+            const rpc    = ++window.saucer.internal.idc;
+
+            window.saucer.internal.rpc[rpc] = { resolve: (value) => settle(true, value), reject: (error) => settle(false, error) };
+            window.saucer.internal.post(stringify({ ["saucer:call"]: true, name, params: packed, id: rpc, frame: frame.id, origin: frame.origin, url: frame.url }));
+        } catch (e)
+        {
+            settle(false, e);
+        }
+    };
+
+    window.addEventListener("message", (event) =>
+    {
+        const data = event.data;
+        const kind = data instanceof Object ? data["saucer:frame"] : undefined;
+
+        if (typeof kind !== "string" || !event.source || event.source === window)
+        {
+            return;
+        }
+
+        let frame = lookup(event.source);
+
+        if (kind === "hello")
+        {
+            if (!frame)
+            {
+                frame = { id: "f" + ++last, window: event.source };
+                frames[frame.id] = frame;
+            }
+
+            frame.token  = String(data.token);
+            frame.origin = event.origin;
+            frame.url    = String(data.url);
+            frame.name   = String(data.name);
+
+            return;
+        }
+
+        if (!frame)
+        {
+            return;
+        }
+
+        if (kind === "result" && pending[data.id] === frame)
+        {
+            delete pending[data.id];
+
+            try
+            {
+                resolve(data.id, data.exception === true, JSON.parse(data.result));
+            } catch (e)
+            {
+                resolve(data.id, true, window.saucer.internal.exception(e));
+            }
+        }
+        else if (kind === "call" && Array.isArray(data.params))
+        {
+            call(frame, data);
+        }
+    });
+
+    Object.defineProperty(window, "saucerFrames", {
+        value: Object.freeze({ list, eval: evaluate }),
+        writable: false,
+        configurable: false,
+    });
+})();
+`
+
+// frameScript runs in the child frames and answers the router of the main
+// frame. Frames of the listed origins get window.saucer.call and
+// window.saucer.exposed, relayed by the main frame.
+const frameScript = `
+(() =>
+{
+    if (window === window.top)
+    {
+        return;
+    }
+
+    const origins = %s;
+    const top     = window.top;
+    const bytes   = crypto.getRandomValues(new Uint8Array(16));
+    const token   = Array.from(bytes, (b) => b.toString(16).padStart(2, "0")).join("");
+    const rpc     = new Map();
+
+    let idc = 0;
+
+    const post = (kind, message) =>
+    {
+        top.postMessage({ ["saucer:frame"]: kind, ...message }, "*");
+    };
+
+    const describe = (e) =>
+    {
+        if (!(e instanceof Error))
+        {
+            return String(e);
+        }
+
+        return { name: e.name, message: e.message, stack: e.stack };
+    };
+
+    const evaluate = async ({ id, code }) =>
+    {
+        let result    = "null";
+        let exception = false;
+
+        try
+        {
+            const value = await (0, eval)("(async () => (" + code + "))")();
+            result      = JSON.stringify(value === undefined ? null : value);
+        } catch (e)
+        {
+            result    = JSON.stringify(describe(e));
+            exception = true;
+        }
+
+        post("result", { id, exception, result });
+    };
+
+    window.addEventListener("message", (event) =>
+    {
+        const data = event.data;
+
+        if (event.source !== top || !(data instanceof Object) || data.token !== token)
+        {
+            return;
+        }
+
+        if (data["saucer:frame"] === "eval")
+        {
+            evaluate(data);
+        }
+        else if (data["saucer:frame"] === "settle" && rpc.has(data.id))
+        {
+            const { resolve, reject } = rpc.get(data.id);
+            rpc.delete(data.id);
+
+            if (data.ok)
+            {
+                resolve(data.value);
+                return;
+            }
+
+            const error = new Error(data.error?.message ?? String(data.error));
+
+            error.name  = data.error?.name ?? "GoError";
+            error.code  = data.error?.code;
+            error.chain = data.error?.chain;
+            error.data  = data.error?.data;
+
+            reject(error);
+        }
+    });
+
+    if (origins.includes("*") || origins.includes(location.origin))
+    {
+        const call = (name, params) =>
+        {
+            if (!Array.isArray(params))
+            {
+                return Promise.reject('Bad arguments, expected array');
+            }
+
+            const id = ++idc;
+
+            return new Promise((resolve, reject) =>
+            {
+                rpc.set(id, { resolve, reject });
+                post("call", { id, name: String(name), params });
+            });
+        };
+
+        window.saucer = {
+            call,
+            exposed: new Proxy({}, { get: (_, prop) => (...args) => call(prop, args) }),
+        };
+    }
+
+    post("hello", { token, url: location.href, name: window.name });
+})();
+`
+
+// Frame is a child frame of the page, see Webview.Frames.
+type Frame struct {
+	// ID identifies the frame element while the page is loaded, also across
+	// navigations of the frame.
+	ID string `json:"id"`
+	// Parent is the ID of the frame embedding the frame, empty for the
+	// frames of the main frame.
+	Parent string `json:"parent"`
+	// URL is the address of the document of the frame, Origin its origin as
+	// reported by the engine, "null" for opaque origins.
+	URL    string `json:"url"`
+	Origin string `json:"origin"`
+	// Name is the name of the frame, typically its name attribute.
+	Name string `json:"name"`
+}
+
+// injectFrames installs the scripts tracking the child frames, the frames
+// of the rules of the policy with Frames set getting the bridge.
+func (b *bridge) injectFrames() {
+	key := make([]byte, 16)
+	rand.Read(key)
+	b.frameKey = hex.EncodeToString(key)
+
+	origins := []string{}
+	for _, rule := range b.policy.Bridge {
+		if rule.Frames && !slices.Contains(origins, rule.Origin) {
+			origins = append(origins, rule.Origin)
+		}
+	}
+	list, _ := json.Marshal(origins)
+
+	b.native.Inject(Script{Code: fmt.Sprintf(frameRouterScript, b.frameKey), Time: AtCreation, Frames: MainFrame, Permanent: true})
+	b.native.Inject(Script{Code: fmt.Sprintf(frameScript, list), Time: AtCreation, Frames: AllFrames, Permanent: true})
+}
+
+// Frames returns the child frames of the page, nested ones included, in the
+// order they first loaded. Frames are listed once their document started
+// running scripts and until they are removed.
+//
+// Child frames get no bridge unless a BridgeRule with Frames set allows
+// their origin, see SecurityPolicy.Bridge.
+func (v *Webview) Frames(ctx context.Context) ([]Frame, error) {
+	rtn := []Frame{}
+	if err := v.Eval(ctx, "window.saucerFrames.list()", &rtn); err != nil {
+		return nil, err
+	}
+	return rtn, nil
+}
+
+// EvalFrame evaluates expr in the child frame id of Frames like Eval. The
+// frame evaluates the code with eval, which fails in frames whose
+// Content-Security-Policy does not allow 'unsafe-eval'. An evaluation in a
+// frame navigating away does not answer before ctx is done.
+func (v *Webview) EvalFrame(ctx context.Context, id string, expr string, out any) error {
+	return v.eval(ctx, "", id, expr, out)
+}
@@ -0,0 +1,238 @@
+// Package frontend loads the web frontend of an application into a webview:
+// the embedded build in production and, during development, the page of a
+// dev server such as Vite or webpack, reloaded when the server restarts or
+// watched files change.
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, _ := fs.Sub(dist, "dist")
+//	f, err := frontend.Load(view, frontend.Options{
+//		Assets: assets,
+//		DevURL: "http://localhost:5173",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//
+// Development mode is only compiled in with the saucerdev build tag, so a
+// production build always serves the embedded assets whatever its
+// environment. Built with the tag, it is enabled if Options.DevURL is set or
+// the DevURLEnv environment variable holds the address of the dev server;
+// setting the variable to an empty value serves the embedded assets.
+package frontend
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// DevURLEnv is the environment variable overriding Options.DevURL in builds
+// with the saucerdev tag.
+const DevURLEnv = "SAUCERW_DEV_URL"
+
+// defaultInterval is the Options.Interval used if it is zero.
+const defaultInterval = 500 * time.Millisecond
+
+// waitingPage is shown until the dev server answers.
+const waitingPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Waiting for the dev server</title></head>
+<body style="font-family: system-ui, sans-serif; color: #888; display: grid; place-items: center; height: 100vh; margin: 0">
+<p>Waiting for the dev server at %s&hellip;</p>
+</body>
+</html>
+`
+
+// Options configures Load.
+type Options struct {
+	// Assets is the built frontend, served below saucer://embedded/ or Scheme
+	// outside of development mode. Required unless only development is intended.
+	Assets fs.FS
+	// Scheme serves Assets below Scheme://frontend/ with saucerw.ServeAssets,
+	// which caches and seeks them unlike the native embedding used if empty.
+	// It has to be listed in AppOptions.Schemes.
+	Scheme string
+	// AssetOptions configures the serving of Assets with Scheme.
+	AssetOptions saucerw.AssetOptions
+	// Index is the path of the page to show, "/index.html" if empty. In
+	// development mode it is resolved against DevURL unless it is the
+	// default, so the dev server serves its root.
+	Index string
+	// DevURL is the address of the dev server, e.g. "http://localhost:5173".
+	// It enables development mode in builds with the saucerdev tag.
+	DevURL string
+	// Watch lists files or directories whose changes reload the page in
+	// development mode, for frontends served without hot module replacement.
+	Watch []string
+	// Interval is how often the dev server and the watched files are
+	// checked, 500ms if zero.
+	Interval time.Duration
+}
+
+// Frontend is the frontend loaded into a webview.
+type Frontend struct {
+	view *saucerw.Webview
+	dev  *url.URL
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Load shows the frontend in view. In development mode it enables the
+// developer tools, shows a waiting page until the dev server answers and
+// watches the server and Options.Watch until Close is called.
+func Load(view *saucerw.Webview, opts Options) (*Frontend, error) {
+	f := &Frontend{view: view}
+
+	dev, err := devURL(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if dev == nil {
+		if opts.Assets == nil {
+			return nil, errors.New("frontend: no assets and no dev server")
+		}
+
+		index := cmp.Or(opts.Index, "/index.html")
+
+		if opts.Scheme != "" {
+			if err := view.ServeAssets(opts.Scheme, opts.Assets, opts.AssetOptions); err != nil {
+				return nil, fmt.Errorf("frontend: %w", err)
+			}
+
+			view.Navigate(opts.Scheme + "://frontend" + index)
+			return f, nil
+		}
+
+		if err := view.Embed(opts.Assets); err != nil {
+			return nil, fmt.Errorf("frontend: %w", err)
+		}
+
+		view.Serve(index)
+		return f, nil
+	}
+
+	if opts.Index != "" && opts.Index != "/index.html" {
+		dev = dev.ResolveReference(&url.URL{Path: opts.Index})
+	}
+
+	f.dev = dev
+	view.SetDevTools(true)
+	view.SetHTML(fmt.Sprintf(waitingPage, html.EscapeString(dev.String())))
+
+	interval := cmp.Or(opts.Interval, defaultInterval)
+
+	var ctx context.Context
+	ctx, f.cancel = context.WithCancel(context.Background())
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.poll(ctx, interval)
+	}()
+
+	if len(opts.Watch) != 0 {
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			watch(ctx, opts.Watch, interval, view.Reload)
+		}()
+	}
+
+	return f, nil
+}
+
+// devURL returns the address of the dev server in development mode, nil
+// otherwise.
+func devURL(opts Options) (*url.URL, error) {
+	if !devMode {
+		return nil, nil
+	}
+
+	raw := opts.DevURL
+	if env, ok := os.LookupEnv(DevURLEnv); ok {
+		raw = env
+	}
+
+	if raw == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("frontend: bad dev server url %q", raw)
+	}
+	return u, nil
+}
+
+// Dev reports whether the frontend is served by the dev server.
+func (f *Frontend) Dev() bool {
+	return f.dev != nil
+}
+
+// Close stops watching the dev server and the files. The page stays shown.
+func (f *Frontend) Close() {
+	if f.cancel != nil {
+		f.cancel()
+		f.wg.Wait()
+	}
+}
+
+// poll loads the page of the dev server once it answers and again whenever
+// it answers after it stopped, e.g. because it was restarted.
+func (f *Frontend) poll(ctx context.Context, interval time.Duration) {
+	client := &http.Client{Timeout: interval}
+	up := false
+
+	for {
+		alive := reachable(ctx, client, f.dev)
+
+		if alive && !up {
+			// Keep the route of a single page application if the page is
+			// still the one of the dev server
+			if current := f.view.URL(); strings.HasPrefix(current, f.dev.Scheme+"://"+f.dev.Host+"/") {
+				f.view.Navigate(current)
+			} else {
+				f.view.Navigate(f.dev.String())
+			}
+		}
+		up = alive
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// reachable reports whether the server of u answers, whatever its status.
+func reachable(ctx context.Context, client *http.Client, u *url.URL) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+
+	res.Body.Close()
+	return true
+}
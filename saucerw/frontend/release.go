@@ -0,0 +1,6 @@
+//go:build !saucerdev
+
+package frontend
+
+// devMode enables development mode, see the package documentation.
+const devMode = false
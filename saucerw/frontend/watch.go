@@ -0,0 +1,63 @@
+package frontend
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"time"
+)
+
+// stamp identifies the version of a watched file.
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// watch calls changed whenever files below paths were added, removed or
+// modified, checking them every interval. Modifications are reported once
+// the files stopped changing, so a build writing many files reloads once.
+func watch(ctx context.Context, paths []string, interval time.Duration, changed func()) {
+	last := scan(paths)
+	pending := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		current := scan(paths)
+		same := maps.Equal(last, current)
+		last = current
+
+		switch {
+		case !same:
+			pending = true
+		case pending:
+			pending = false
+			changed()
+		}
+	}
+}
+
+// scan stamps the files below paths. Files that cannot be read are left out.
+func scan(paths []string) map[string]stamp {
+	rtn := map[string]stamp{}
+
+	for _, root := range paths {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+
+			if info, err := d.Info(); err == nil {
+				rtn[path] = stamp{size: info.Size(), modTime: info.ModTime()}
+			}
+			return nil
+		})
+	}
+
+	return rtn
+}
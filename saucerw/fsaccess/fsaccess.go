@@ -0,0 +1,516 @@
+// Package fsaccess lets pages read and write files through Go, which decides
+// on every file a page asks for, instead of each application exposing its own
+// functions for it.
+//
+// Expose adds window.saucer.fs to the page of a webview. The page opens files
+// by path or lets the user pick them, and reads and writes them through the
+// handles it gets back:
+//
+//	const [file] = await window.saucer.fs.pick({ filters: [{ name: "Text", patterns: ["*.txt"] }] });
+//	const text = await file.text();
+//
+//	const out = await window.saucer.fs.open("/home/user/notes.txt", { write: true, create: true });
+//	await out.write("hello");
+//
+// Options.Decide approves or denies each file before the page gets a handle,
+// by default only files the user picked are allowed. Contents travel over the
+// binary transport of the bridge, see Webview.SendBytes, without being
+// encoded as JSON.
+package fsaccess
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/dialog"
+)
+
+// chunkSize bounds the bytes a single read transfers, larger reads are split
+// by the page.
+const chunkSize = 4 << 20
+
+// Mode is the access a page asks for.
+type Mode uint8
+
+const (
+	// Read allows reading the file.
+	Read Mode = 1 << iota
+	// Write allows writing and truncating the file.
+	Write
+)
+
+// Request is a file a page asks for.
+type Request struct {
+	// URL is the URL of the page.
+	URL string
+	// Path is the absolute path of the file, with symbolic links resolved.
+	Path string
+	// Mode is the access the page asks for.
+	Mode Mode
+	// Picked reports whether the user chose the file in a dialog.
+	Picked bool
+}
+
+// Options configures the file access of a webview.
+type Options struct {
+	// Decide returns whether the page may access the file of req, e.g. after
+	// asking the user. It may block until ctx is done. If nil, the page may
+	// access the files the user picked and no others.
+	Decide func(ctx context.Context, req Request) bool
+	// Roots, if non-empty, confines access to the files below these
+	// directories, including files the user picked. Requests outside of them
+	// are denied without asking Decide.
+	Roots []string
+	// Audit, if non-nil, is called for every request with the decision, e.g.
+	// to keep a log of the files pages accessed.
+	Audit func(req Request, allowed bool)
+}
+
+// fileInfo describes a handle for the page.
+type fileInfo struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	Write bool   `json:"write"`
+}
+
+// pickOptions are the options of window.saucer.fs.pick.
+type pickOptions struct {
+	Write    bool            `json:"write"`
+	Multiple bool            `json:"multiple"`
+	Save     bool            `json:"save"`
+	Title    string          `json:"title"`
+	Name     string          `json:"suggestedName"`
+	Filters  []dialog.Filter `json:"filters"`
+}
+
+// access holds the files a page was granted.
+type access struct {
+	view *saucerw.Webview
+	opts Options
+
+	reads atomic.Uint64
+
+	mu     sync.Mutex
+	grants map[string]*grant
+}
+
+// grant is a file a page may access, opened when it was granted: a file
+// replacing it at its path is not accessed.
+type grant struct {
+	file *os.File
+	mode Mode
+}
+
+// Expose adds window.saucer.fs to the page of v, see the package
+// documentation. Call it before the page loads, the script is injected into
+// the pages loaded afterwards and the current one.
+//
+// The page-facing functions are exposed as "fs.open", "fs.pick", "fs.read",
+// "fs.write", "fs.truncate" and "fs.close" and reject with code
+// "permission_denied" for files that were not granted.
+func Expose(v *saucerw.Webview, opts Options) error {
+	a := &access{view: v, opts: opts, grants: map[string]*grant{}}
+
+	a.opts.Roots = nil
+	for _, root := range opts.Roots {
+		resolved, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return fmt.Errorf("fsaccess: root: %w", err)
+		}
+
+		abs, err := filepath.Abs(resolved)
+		if err != nil {
+			return fmt.Errorf("fsaccess: root: %w", err)
+		}
+		a.opts.Roots = append(a.opts.Roots, abs)
+	}
+
+	functions := map[string]any{
+		"fs.open":     a.open,
+		"fs.pick":     a.pick,
+		"fs.read":     a.read,
+		"fs.write":    a.write,
+		"fs.truncate": a.truncate,
+		"fs.close":    a.close,
+	}
+	for name, fn := range functions {
+		if err := v.Expose(name, fn); err != nil {
+			return err
+		}
+	}
+
+	// The handles of a page are gone with it, its files are closed when the
+	// next one loads
+	v.OnLoad(func(state saucerw.LoadState) {
+		if state == saucerw.LoadStarted {
+			a.closeAll()
+		}
+	})
+
+	v.InjectScript(saucerw.Script{Code: script, Time: saucerw.AtCreation, Frames: saucerw.MainFrame, Permanent: true})
+	v.Execute(script)
+
+	return nil
+}
+
+// open grants the file at path if it is allowed.
+func (a *access) open(ctx context.Context, path string, write, create bool) (*fileInfo, error) {
+	if !filepath.IsAbs(path) {
+		return nil, &saucerw.BridgeError{Code: saucerw.CodeInvalidArgument, Err: fmt.Errorf("fsaccess: %q is not an absolute path", path)}
+	}
+
+	resolved, err := resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.grant(ctx, Request{Path: resolved, Mode: mode(write)}, create && write)
+}
+
+// pick lets the user choose the files to grant.
+func (a *access) pick(ctx context.Context, opts pickOptions) ([]*fileInfo, error) {
+	dopts := dialog.Options{Title: opts.Title, Filters: opts.Filters}
+
+	var paths []string
+	var err error
+	switch {
+	case opts.Save:
+		dopts.Path = filepath.Base(opts.Name)
+		var path string
+		if path, err = dialog.SaveFile(ctx, dopts); err == nil {
+			paths = []string{path}
+		}
+	case opts.Multiple:
+		paths, err = dialog.OpenFiles(ctx, dopts)
+	default:
+		var path string
+		if path, err = dialog.OpenFile(ctx, dopts); err == nil {
+			paths = []string{path}
+		}
+	}
+	if errors.Is(err, dialog.ErrCancelled) {
+		return nil, &saucerw.BridgeError{Code: saucerw.CodeCanceled, Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rtn []*fileInfo
+	for _, path := range paths {
+		resolved, err := resolve(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// The file to save to may not exist yet
+		info, err := a.grant(ctx, Request{Path: resolved, Mode: mode(opts.Write || opts.Save), Picked: true}, opts.Save)
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, info)
+	}
+	return rtn, nil
+}
+
+// read sends up to length bytes of the file at offset to the page and returns
+// the name they are sent as.
+func (a *access) read(id string, offset int64, length int) (string, error) {
+	g, err := a.lookup(id, Read)
+	if err != nil {
+		return "", err
+	}
+
+	data := make([]byte, min(max(length, 0), chunkSize))
+	n, err := g.file.ReadAt(data, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", failure(err)
+	}
+
+	name := fmt.Sprintf("fsaccess:%s:%d", id, a.reads.Add(1))
+	a.view.SendBytes(name, data[:n])
+
+	return name, nil
+}
+
+// write writes data to the file at offset and returns its new size.
+func (a *access) write(id string, offset int64, data []byte) (int64, error) {
+	g, err := a.lookup(id, Write)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := g.file.WriteAt(data, offset); err != nil {
+		return 0, failure(err)
+	}
+
+	info, err := g.file.Stat()
+	if err != nil {
+		return 0, failure(err)
+	}
+	return info.Size(), nil
+}
+
+// truncate changes the size of the file.
+func (a *access) truncate(id string, size int64) error {
+	g, err := a.lookup(id, Write)
+	if err != nil {
+		return err
+	}
+	return failure(g.file.Truncate(size))
+}
+
+// close revokes the grant of id and closes its file.
+func (a *access) close(id string) {
+	a.mu.Lock()
+	g, ok := a.grants[id]
+	delete(a.grants, id)
+	a.mu.Unlock()
+
+	if ok {
+		g.file.Close()
+	}
+}
+
+// closeAll revokes all grants and closes their files.
+func (a *access) closeAll() {
+	a.mu.Lock()
+	grants := a.grants
+	a.grants = map[string]*grant{}
+	a.mu.Unlock()
+
+	for _, g := range grants {
+		g.file.Close()
+	}
+}
+
+// grant decides on req and returns the handle of the granted file, opening
+// it and, if create is set, creating it if it does not exist.
+func (a *access) grant(ctx context.Context, req Request, create bool) (*fileInfo, error) {
+	req.URL = a.view.URL()
+
+	root, allowed := a.root(req.Path)
+	if allowed {
+		if a.opts.Decide != nil {
+			allowed = a.opts.Decide(ctx, req)
+		} else {
+			allowed = req.Picked
+		}
+	}
+
+	if a.opts.Audit != nil {
+		a.opts.Audit(req, allowed)
+	}
+
+	if !allowed {
+		return nil, &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: fmt.Errorf("fsaccess: access to %s denied", req.Path)}
+	}
+
+	f, size, err := openFile(root, req.Path, req.Mode, create)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw [16]byte
+	rand.Read(raw[:])
+	id := hex.EncodeToString(raw[:])
+
+	a.mu.Lock()
+	a.grants[id] = &grant{file: f, mode: req.Mode}
+	a.mu.Unlock()
+
+	return &fileInfo{ID: id, Name: filepath.Base(req.Path), Path: req.Path, Size: size, Write: req.Mode&Write != 0}, nil
+}
+
+// openFile opens the regular file at path, which is below root, for mode and
+// returns it with its size, creating it if create is set and it does not
+// exist. Symbolic links are refused, the path was resolved and a link at it
+// replaced the file or one of its directories since: the file opened has to
+// be the one at path.
+func openFile(root, path string, mode Mode, create bool) (*os.File, int64, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	names := strings.Split(rel, string(filepath.Separator))
+
+	dir, err := os.OpenRoot(root)
+	if err != nil {
+		return nil, 0, failure(err)
+	}
+	// Each directory is opened in the one before, a link swapped in for one
+	// of them cannot lead out of them
+	for _, name := range names[:len(names)-1] {
+		sub, err := openDir(dir, name, path)
+		dir.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+		dir = sub
+	}
+	defer dir.Close()
+	name := names[len(names)-1]
+
+	flag := os.O_RDONLY
+	if mode&Write != 0 {
+		flag = os.O_RDWR
+	}
+	flag |= noFollow
+
+	// Opening a fifo or device could block or have side effects
+	if st, err := dir.Lstat(name); err == nil && !st.Mode().IsRegular() {
+		return nil, 0, notRegular(path, st)
+	}
+
+	f, err := dir.OpenFile(name, flag, 0)
+	if create && errors.Is(err, os.ErrNotExist) {
+		f, err = dir.OpenFile(name, flag|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			f, err = dir.OpenFile(name, flag, 0)
+		}
+	}
+	if err != nil {
+		return nil, 0, failure(err)
+	}
+
+	opened, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, failure(err)
+	}
+	st, err := dir.Lstat(name)
+	if err != nil {
+		f.Close()
+		return nil, 0, failure(err)
+	}
+	if !st.Mode().IsRegular() {
+		f.Close()
+		return nil, 0, notRegular(path, st)
+	}
+	if !os.SameFile(opened, st) {
+		f.Close()
+		return nil, 0, replaced(path)
+	}
+	return f, opened.Size(), nil
+}
+
+// openDir opens the directory name in parent for the request of path,
+// refusing a link or a directory that took its place while it was opened.
+func openDir(parent *os.Root, name, path string) (*os.Root, error) {
+	st, err := parent.Lstat(name)
+	if err != nil {
+		return nil, failure(err)
+	}
+	if !st.IsDir() {
+		return nil, replaced(path)
+	}
+
+	dir, err := parent.OpenRoot(name)
+	if err != nil {
+		return nil, failure(err)
+	}
+	opened, err := dir.Stat(".")
+	if err != nil {
+		dir.Close()
+		return nil, failure(err)
+	}
+	if !os.SameFile(opened, st) {
+		dir.Close()
+		return nil, replaced(path)
+	}
+	return dir, nil
+}
+
+// replaced returns the error of a request for path, whose file or one of its
+// directories was replaced since the path was resolved.
+func replaced(path string) error {
+	return &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: fmt.Errorf("fsaccess: %s was replaced while it was opened", path)}
+}
+
+// notRegular returns the error of a request for path, which is no regular
+// file.
+func notRegular(path string, st os.FileInfo) error {
+	if st.IsDir() {
+		return &saucerw.BridgeError{Code: saucerw.CodeInvalidArgument, Err: fmt.Errorf("fsaccess: %s is a directory", path)}
+	}
+	return &saucerw.BridgeError{Code: saucerw.CodeInvalidArgument, Err: fmt.Errorf("fsaccess: %s is not a regular file", path)}
+}
+
+// lookup returns the grant of id if it allows mode.
+func (a *access) lookup(id string, mode Mode) (*grant, error) {
+	a.mu.Lock()
+	g, ok := a.grants[id]
+	a.mu.Unlock()
+
+	if !ok || g.mode&mode != mode {
+		return nil, &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: errors.New("fsaccess: file not granted")}
+	}
+	return g, nil
+}
+
+// root returns the root path is below, the root of its volume if there are
+// no roots, and reports whether there is one.
+func (a *access) root(path string) (string, bool) {
+	if len(a.opts.Roots) == 0 {
+		return filepath.VolumeName(path) + string(filepath.Separator), true
+	}
+
+	for _, root := range a.opts.Roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+// resolve cleans path and resolves its symbolic links, those of its directory
+// if the file does not exist yet.
+func resolve(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", failure(err)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return "", failure(err)
+	}
+	return filepath.Join(dir, filepath.Base(abs)), nil
+}
+
+// mode returns the mode of a request for reading and, if write, writing.
+func mode(write bool) Mode {
+	if write {
+		return Read | Write
+	}
+	return Read
+}
+
+// failure gives errors of package os a code for the page.
+func failure(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, os.ErrNotExist):
+		return &saucerw.BridgeError{Code: saucerw.CodeNotFound, Err: err}
+	case errors.Is(err, os.ErrPermission):
+		return &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: err}
+	}
+	return err
+}
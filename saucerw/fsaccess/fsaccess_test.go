@@ -0,0 +1,146 @@
+package fsaccess
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, size, err := openFile(dir, path, Read|Write, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if size != 5 {
+		t.Errorf("size %d, expected 5", size)
+	}
+
+	// The handle keeps accessing the granted file after another one took
+	// its place
+	if err := os.Rename(path, path+".old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte("HELLO"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "other" {
+		t.Errorf("replacing file changed to %q", data)
+	}
+}
+
+func TestOpenFileCreate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "new.txt")
+
+	if _, _, err := openFile(dir, path, Read, false); err == nil {
+		t.Fatal("opened a missing file without create")
+	}
+
+	f, size, err := openFile(dir, path, Read|Write, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if size != 0 {
+		t.Errorf("size %d, expected 0", size)
+	}
+}
+
+func TestOpenFileRefused(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	if err := os.WriteFile(target, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := openFile(dir, dir, Read, false); err == nil {
+		t.Error("opened a directory")
+	}
+
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symbolic links:", err)
+	}
+	if _, _, err := openFile(dir, link, Read, false); err == nil {
+		t.Error("opened a symbolic link")
+	}
+	if _, _, err := openFile(dir, link, Read|Write, true); err == nil {
+		t.Error("created through a symbolic link")
+	}
+
+	dangling := filepath.Join(dir, "dangling.txt")
+	if err := os.Symlink(filepath.Join(dir, "missing.txt"), dangling); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := openFile(dir, dangling, Read|Write, true); err == nil {
+		t.Error("created the target of a dangling link")
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("created the target of a dangling link")
+	}
+}
+
+// TestOpenFileSwappedParent swaps a directory of a resolved path for a link
+// before the file is opened, which must not lead out of the root.
+func TestOpenFileSwappedParent(t *testing.T) {
+	// Paths are resolved before they are opened
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	for _, dir := range []string{filepath.Join(root, "docs", "sub"), filepath.Join(outside, "sub")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(dir), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := resolve(filepath.Join(root, "docs", "sub", "notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(root, "docs"), filepath.Join(root, "docs.old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "docs")); err != nil {
+		t.Skip("symbolic links:", err)
+	}
+
+	for _, dir := range []string{root, filepath.VolumeName(root) + string(filepath.Separator)} {
+		if f, _, err := openFile(dir, path, Read|Write, true); err == nil {
+			data, _ := io.ReadAll(f)
+			f.Close()
+			t.Errorf("opened %q below %s through the swapped directory", data, dir)
+		}
+	}
+
+	// A link to a directory within the root is refused as well
+	other := filepath.Join(root, "other")
+	if err := os.Mkdir(other, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "docs")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "docs.old"), filepath.Join(root, "docs")); err != nil {
+		t.Fatal(err)
+	}
+	if f, _, err := openFile(root, path, Read, false); err == nil {
+		f.Close()
+		t.Error("opened the file through a link to another directory")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package fsaccess
+
+// noFollow is not supported, openFile compares the file opened with the one
+// at the path instead.
+const noFollow = 0
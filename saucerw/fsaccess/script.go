@@ -0,0 +1,141 @@
+package fsaccess
+
+// script adds window.saucer.fs, wrapping the exposed functions in file
+// handles. Reads larger than chunkSize are split, their data arrives through
+// window.saucer.bytes.
+const script = `
+(() =>
+{
+    if (!window.saucer || window.saucer.fs)
+    {
+        return;
+    }
+
+    const chunk = 4 * 1024 * 1024;
+    const call  = (name, ...params) => window.saucer.call(` + "`fs.${name}`" + `, params);
+
+    const bytes = (name) => new Promise((resolve) =>
+    {
+        const take = () =>
+        {
+            const data = window.saucer.bytes.get(name);
+            window.saucer.bytes.delete(name);
+            resolve(data);
+        };
+
+        if (window.saucer.bytes.has(name))
+        {
+            take();
+            return;
+        }
+
+        const listener = (e) =>
+        {
+            if (e.detail.name !== name)
+            {
+                return;
+            }
+
+            window.removeEventListener("saucer:bytes", listener);
+            take();
+        };
+
+        window.addEventListener("saucer:bytes", listener);
+    });
+
+    class FileHandle
+    {
+        constructor({ id, name, path, size, write })
+        {
+            this.id       = id;
+            this.name     = name;
+            this.path     = path;
+            this.size     = size;
+            this.writable = write;
+        }
+
+        async chunk(offset, length)
+        {
+            return new Uint8Array(await bytes(await call("read", this.id, offset, Math.min(length, chunk))));
+        }
+
+        async read(offset = 0, length = this.size - offset)
+        {
+            const rtn = new Uint8Array(Math.max(length, 0));
+            let read  = 0;
+
+            while (read < rtn.length)
+            {
+                const data = await this.chunk(offset + read, rtn.length - read);
+
+                if (data.length === 0)
+                {
+                    break;
+                }
+
+                rtn.set(data, read);
+                read += data.length;
+            }
+
+            return rtn.buffer.slice(0, read);
+        }
+
+        async arrayBuffer()
+        {
+            return this.read(0, this.size);
+        }
+
+        async text()
+        {
+            return new TextDecoder().decode(await this.arrayBuffer());
+        }
+
+        stream()
+        {
+            let offset = 0;
+
+            return new ReadableStream({
+                pull: async (controller) =>
+                {
+                    const data = await this.chunk(offset, chunk);
+
+                    if (data.length === 0)
+                    {
+                        controller.close();
+                        return;
+                    }
+
+                    offset += data.length;
+                    controller.enqueue(data);
+                },
+            });
+        }
+
+        async write(data, offset = 0)
+        {
+            if (typeof data === "string")
+            {
+                data = new TextEncoder().encode(data);
+            }
+
+            this.size = await call("write", this.id, offset, data);
+        }
+
+        async truncate(size)
+        {
+            await call("truncate", this.id, size);
+            this.size = size;
+        }
+
+        async close()
+        {
+            await call("close", this.id);
+        }
+    }
+
+    window.saucer.fs = {
+        open: async (path, { write = false, create = false } = {}) => new FileHandle(await call("open", path, write, create)),
+        pick: async (options = {}) => (await call("pick", options)).map((info) => new FileHandle(info)),
+    };
+})();
+`
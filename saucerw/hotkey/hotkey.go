@@ -0,0 +1,129 @@
+// Package hotkey registers global keyboard shortcuts, which are pressed while
+// the application is not focused, e.g. to bring a window of a background
+// application to the front. Use Application.RegisterShortcut for those of a
+// focused window.
+//
+// The shortcuts are hot keys on Windows, Carbon hot keys on macOS and bound
+// through the GlobalShortcuts interface of the XDG desktop portal on Linux
+// and other freedesktop systems, where the desktop asks the user to confirm
+// them and may assign another trigger. Handlers run on their own goroutine;
+// use Application.Dispatch to touch windows from them.
+//
+//	h, err := hotkey.Register("CmdOrCtrl+Shift+Space", "Show the quick entry", func() {
+//		app.Post(window.Show)
+//	})
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+var (
+	// ErrUnsupported is returned by Register when the system has no global
+	// shortcuts or the backend is not compiled in.
+	ErrUnsupported = errors.New("hotkey: global shortcuts are not supported")
+	// ErrConflict is returned by Register for a shortcut registered already,
+	// by this or another application.
+	ErrConflict = errors.New("hotkey: shortcut is already registered")
+)
+
+// backend is a registered native shortcut.
+type backend interface {
+	unregister()
+}
+
+// Hotkey is a registered global shortcut. Its methods are safe to call from
+// any goroutine.
+type Hotkey struct {
+	accelerator saucerw.Accelerator
+	fn          func()
+
+	mu     sync.Mutex
+	native backend
+	closed bool
+}
+
+var (
+	mu         sync.Mutex
+	registered = map[string]*Hotkey{}
+)
+
+// Register registers fn, called when accelerator is pressed anywhere on the
+// desktop, see saucerw.ParseAccelerator. The description tells the user what
+// the shortcut does where the desktop lists the shortcuts of applications.
+//
+// On macOS it has to be called once the event loop of the application runs.
+func Register(accelerator, description string, fn func()) (*Hotkey, error) {
+	parsed, err := saucerw.ParseAccelerator(accelerator)
+	if err != nil {
+		return nil, err
+	}
+	name := parsed.String()
+
+	h := &Hotkey{accelerator: parsed, fn: fn}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if registered[name] != nil {
+		return nil, fmt.Errorf("%w: %s", ErrConflict, name)
+	}
+
+	// Presses arriving before the backend is stored wait for the lock.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	native, err := register(h, description)
+	if err != nil {
+		return nil, err
+	}
+
+	h.native = native
+	registered[name] = h
+
+	return h, nil
+}
+
+// Accelerator returns the shortcut.
+func (h *Hotkey) Accelerator() saucerw.Accelerator {
+	return h.accelerator
+}
+
+// Unregister removes the shortcut, afterwards pressing it does nothing.
+// Unregister is safe to call more than once.
+func (h *Hotkey) Unregister() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	h.native.unregister()
+
+	delete(registered, h.accelerator.String())
+}
+
+// press handles a press of the shortcut. The backends call it on a new
+// goroutine, so that the lock does not hold up their events.
+func (h *Hotkey) press() {
+	h.mu.Lock()
+	closed := h.closed
+	h.mu.Unlock()
+
+	if !closed && h.fn != nil {
+		h.fn()
+	}
+}
+
+// unsupported returns the error for a key the backend cannot register.
+func unsupported(a saucerw.Accelerator) error {
+	return fmt.Errorf("%w: key %s", ErrUnsupported, a.Key)
+}
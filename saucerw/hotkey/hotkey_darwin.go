@@ -0,0 +1,111 @@
+//go:build darwin && cgo && saucer
+
+package hotkey
+
+/*
+#cgo CFLAGS: -fobjc-arc
+#cgo LDFLAGS: -framework Carbon -framework Foundation
+
+#include <stdint.h>
+
+int32_t hotkey_register(uint32_t id, uint32_t code, uint32_t modifiers, void **ref);
+void hotkey_unregister(void *ref);
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// Carbon modifier flags and errors, see Events.h and CarbonEvents.h.
+const (
+	cmdKey     = 0x0100
+	shiftKey   = 0x0200
+	optionKey  = 0x0800
+	controlKey = 0x1000
+
+	eventHotKeyExistsErr = -9878
+)
+
+// keyCodes are the virtual key codes of the keys of saucerw.Accelerator on
+// the ANSI keyboard, see kVK_* in Events.h.
+var keyCodes = map[string]uint32{
+	"A": 0x00, "S": 0x01, "D": 0x02, "F": 0x03, "H": 0x04, "G": 0x05, "Z": 0x06,
+	"X": 0x07, "C": 0x08, "V": 0x09, "B": 0x0B, "Q": 0x0C, "W": 0x0D, "E": 0x0E,
+	"R": 0x0F, "Y": 0x10, "T": 0x11, "1": 0x12, "2": 0x13, "3": 0x14, "4": 0x15,
+	"6": 0x16, "5": 0x17, "=": 0x18, "9": 0x19, "7": 0x1A, "-": 0x1B, "8": 0x1C,
+	"0": 0x1D, "]": 0x1E, "O": 0x1F, "U": 0x20, "[": 0x21, "I": 0x22, "P": 0x23,
+	"L": 0x25, "J": 0x26, "'": 0x27, "K": 0x28, ";": 0x29, "\\": 0x2A, ",": 0x2B,
+	"/": 0x2C, "N": 0x2D, "M": 0x2E, ".": 0x2F, "`": 0x32,
+
+	"Enter": 0x24, "Tab": 0x30, "Space": 0x31, "Backspace": 0x33, "Escape": 0x35,
+	"Delete": 0x75, "Insert": 0x72, "Home": 0x73, "End": 0x77, "PageUp": 0x74,
+	"PageDown": 0x79, "Left": 0x7B, "Right": 0x7C, "Down": 0x7D, "Up": 0x7E,
+	"Plus": 0x18, "Minus": 0x1B,
+
+	"F1": 0x7A, "F2": 0x78, "F3": 0x63, "F4": 0x76, "F5": 0x60, "F6": 0x61,
+	"F7": 0x62, "F8": 0x64, "F9": 0x65, "F10": 0x6D, "F11": 0x67, "F12": 0x6F,
+	"F13": 0x69, "F14": 0x6B, "F15": 0x71, "F16": 0x6A, "F17": 0x40, "F18": 0x4F,
+	"F19": 0x50, "F20": 0x5A,
+}
+
+var (
+	lastID  atomic.Uint32
+	hotkeys sync.Map // id → *Hotkey
+)
+
+// carbonHotkey is a registered Carbon hot key.
+type carbonHotkey struct {
+	id  uint32
+	ref unsafe.Pointer
+}
+
+func register(h *Hotkey, _ string) (backend, error) {
+	code, ok := keyCodes[h.accelerator.Key]
+	if !ok {
+		return nil, unsupported(h.accelerator)
+	}
+
+	var modifiers uint32
+	for mod, flag := range map[saucerw.Modifier]uint32{
+		saucerw.ModCtrl:  controlKey,
+		saucerw.ModShift: shiftKey,
+		saucerw.ModAlt:   optionKey,
+		saucerw.ModSuper: cmdKey,
+	} {
+		if h.accelerator.Modifiers&mod != 0 {
+			modifiers |= flag
+		}
+	}
+
+	k := &carbonHotkey{id: lastID.Add(1)}
+	hotkeys.Store(k.id, h)
+
+	switch status := C.hotkey_register(C.uint32_t(k.id), C.uint32_t(code), C.uint32_t(modifiers), &k.ref); status {
+	case 0:
+		return k, nil
+	case eventHotKeyExistsErr:
+		hotkeys.Delete(k.id)
+		return nil, fmt.Errorf("%w: %s", ErrConflict, h.accelerator)
+	default:
+		hotkeys.Delete(k.id)
+		return nil, fmt.Errorf("hotkey: RegisterEventHotKey %s failed with %d", h.accelerator, status)
+	}
+}
+
+//export hotkeyPressed
+func hotkeyPressed(id C.uint32_t) {
+	if h, ok := hotkeys.Load(uint32(id)); ok {
+		go h.(*Hotkey).press()
+	}
+}
+
+func (k *carbonHotkey) unregister() {
+	hotkeys.Delete(k.id)
+	C.hotkey_unregister(k.ref)
+}
@@ -0,0 +1,68 @@
+//go:build darwin && cgo && saucer
+
+#import <Carbon/Carbon.h>
+#import <Foundation/Foundation.h>
+
+#include "_cgo_export.h"
+
+// The signature of the hot keys of the process, "svhk".
+static const OSType hotkey_signature = 'svhk';
+
+static OSStatus hotkey_handler(EventHandlerCallRef next, EventRef event, void *data)
+{
+    EventHotKeyID id;
+
+    if (GetEventParameter(event, kEventParamDirectObject, typeEventHotKeyID, NULL, sizeof(id), NULL, &id) != noErr)
+    {
+        return eventNotHandledErr;
+    }
+
+    if (id.signature != hotkey_signature)
+    {
+        return eventNotHandledErr;
+    }
+
+    hotkeyPressed(id.id);
+    return noErr;
+}
+
+// Hot keys are delivered to the application event target of the main thread.
+static void hotkey_on_main(dispatch_block_t block)
+{
+    if ([NSThread isMainThread])
+    {
+        block();
+        return;
+    }
+
+    dispatch_sync(dispatch_get_main_queue(), block);
+}
+
+int32_t hotkey_register(uint32_t id, uint32_t code, uint32_t modifiers, void **ref)
+{
+    __block OSStatus rtn = noErr;
+
+    hotkey_on_main(^{
+      static dispatch_once_t once;
+
+      dispatch_once(&once, ^{
+        EventTypeSpec type = {kEventClassKeyboard, kEventHotKeyPressed};
+        InstallApplicationEventHandler(hotkey_handler, 1, &type, NULL, NULL);
+      });
+
+      EventHotKeyID hotkey = {hotkey_signature, id};
+      EventHotKeyRef handle = NULL;
+
+      rtn  = RegisterEventHotKey(code, modifiers, hotkey, GetApplicationEventTarget(), 0, &handle);
+      *ref = handle;
+    });
+
+    return rtn;
+}
+
+void hotkey_unregister(void *ref)
+{
+    hotkey_on_main(^{
+      UnregisterEventHotKey((EventHotKeyRef)ref);
+    });
+}
@@ -0,0 +1,8 @@
+//go:build darwin && !(cgo && saucer)
+
+package hotkey
+
+// register fails, Carbon hot keys need the AppKit event loop of saucer.
+func register(h *Hotkey, _ string) (backend, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,211 @@
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessage         = user32.NewProc("GetMessageW")
+	procPeekMessage        = user32.NewProc("PeekMessageW")
+	procPostThreadMessage  = user32.NewProc("PostThreadMessageW")
+	procGetCurrentThreadID = kernel32.NewProc("GetCurrentThreadId")
+)
+
+const (
+	wmHotkey = 0x0312
+	wmApp    = 0x8000
+
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modShift    = 0x0004
+	modWin      = 0x0008
+	modNoRepeat = 0x4000
+
+	errorHotkeyAlreadyRegistered = 1409
+)
+
+type point struct {
+	x, y int32
+}
+
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      point
+}
+
+// virtualKeys are the virtual key codes of the keys named by
+// saucerw.Accelerator, besides letters, digits and function keys.
+var virtualKeys = map[string]uintptr{
+	"Enter": 0x0D, "Escape": 0x1B, "Tab": 0x09, "Space": 0x20, "Backspace": 0x08,
+	"Delete": 0x2E, "Insert": 0x2D, "Home": 0x24, "End": 0x23, "PageUp": 0x21,
+	"PageDown": 0x22, "Left": 0x25, "Up": 0x26, "Right": 0x27, "Down": 0x28,
+	"Plus": 0xBB, "Minus": 0xBD, "=": 0xBB, "-": 0xBD, ",": 0xBC, ".": 0xBE,
+	"/": 0xBF, ";": 0xBA, "'": 0xDE, "[": 0xDB, "]": 0xDD, "\\": 0xDC, "`": 0xC0,
+}
+
+// virtualKey returns the virtual key code of key.
+func virtualKey(key string) (uintptr, bool) {
+	if vk, ok := virtualKeys[key]; ok {
+		return vk, true
+	}
+
+	if len(key) == 1 && (key[0] >= 'A' && key[0] <= 'Z' || key[0] >= '0' && key[0] <= '9') {
+		return uintptr(key[0]), true
+	}
+
+	var n int
+	if _, err := fmt.Sscanf(key, "F%d", &n); err == nil && n >= 1 && n <= 24 {
+		return 0x70 + uintptr(n-1), true
+	}
+	return 0, false
+}
+
+// thread is the thread messages of the hot keys are posted to. Hot keys
+// registered without a window belong to the thread registering them, so
+// they are registered and unregistered there too.
+type thread struct {
+	id       uintptr
+	requests chan func()
+	hotkeys  sync.Map // id → *Hotkey
+	lastID   uintptr
+}
+
+var (
+	threadOnce sync.Once
+	shared     *thread
+)
+
+// start returns the thread, starting it on first use.
+func start() *thread {
+	threadOnce.Do(func() {
+		shared = &thread{requests: make(chan func(), 1)}
+
+		ready := make(chan struct{})
+		go shared.run(ready)
+		<-ready
+	})
+	return shared
+}
+
+// run pumps the messages of the thread.
+func (t *thread) run(ready chan<- struct{}) {
+	runtime.LockOSThread()
+
+	t.id, _, _ = procGetCurrentThreadID.Call()
+
+	// Create the message queue before anything is posted to it
+	var m msg
+	procPeekMessage.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0, 0)
+	close(ready)
+
+	for {
+		r, _, _ := procGetMessage.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(r) <= 0 {
+			return
+		}
+
+		switch m.message {
+		case wmHotkey:
+			if h, ok := t.hotkeys.Load(m.wParam); ok {
+				go h.(*Hotkey).press()
+			}
+		case wmApp:
+			t.drain()
+		}
+	}
+}
+
+// drain runs the queued requests.
+func (t *thread) drain() {
+	for {
+		select {
+		case fn := <-t.requests:
+			fn()
+		default:
+			return
+		}
+	}
+}
+
+// do runs fn on the thread and waits for it.
+func (t *thread) do(fn func() error) error {
+	done := make(chan error, 1)
+	t.requests <- func() { done <- fn() }
+
+	if r, _, err := procPostThreadMessage.Call(t.id, wmApp, 0, 0); r == 0 {
+		return fmt.Errorf("hotkey: PostThreadMessage: %w", err)
+	}
+	return <-done
+}
+
+// hotkey is a hot key registered on the thread.
+type hotkey struct {
+	thread *thread
+	id     uintptr
+}
+
+func register(h *Hotkey, _ string) (backend, error) {
+	vk, ok := virtualKey(h.accelerator.Key)
+	if !ok {
+		return nil, unsupported(h.accelerator)
+	}
+
+	mods := uintptr(modNoRepeat)
+	for mod, flag := range map[saucerw.Modifier]uintptr{
+		saucerw.ModCtrl:  modControl,
+		saucerw.ModShift: modShift,
+		saucerw.ModAlt:   modAlt,
+		saucerw.ModSuper: modWin,
+	} {
+		if h.accelerator.Modifiers&mod != 0 {
+			mods |= flag
+		}
+	}
+
+	t := start()
+	k := &hotkey{thread: t}
+
+	err := t.do(func() error {
+		t.lastID++
+		k.id = t.lastID
+
+		if r, _, err := procRegisterHotKey.Call(0, k.id, mods, vk); r == 0 {
+			var errno syscall.Errno
+			if errors.As(err, &errno) && errno == errorHotkeyAlreadyRegistered {
+				return fmt.Errorf("%w: %s", ErrConflict, h.accelerator)
+			}
+			return fmt.Errorf("hotkey: RegisterHotKey: %w", err)
+		}
+
+		t.hotkeys.Store(k.id, h)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *hotkey) unregister() {
+	k.thread.do(func() error {
+		k.thread.hotkeys.Delete(k.id)
+		procUnregisterHotKey.Call(0, k.id)
+		return nil
+	})
+}
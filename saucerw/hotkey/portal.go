@@ -0,0 +1,289 @@
+//go:build !windows && !darwin
+
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/internal/dbus"
+)
+
+// The global shortcuts of the XDG desktop portal.
+const (
+	portalName    = "org.freedesktop.portal.Desktop"
+	portalPath    = "/org/freedesktop/portal/desktop"
+	shortcutIface = "org.freedesktop.portal.GlobalShortcuts"
+	requestIface  = "org.freedesktop.portal.Request"
+	sessionIface  = "org.freedesktop.portal.Session"
+	responseOK    = 0
+	responseAbort = 1
+
+	// shortcutID identifies the shortcut in its session, each hot key has a
+	// session of its own.
+	shortcutID = "hotkey"
+)
+
+// keysyms are the names of the keys of saucerw.Accelerator in the shortcuts
+// specification, which uses the names of XKB keysyms, besides letters,
+// digits and function keys.
+var keysyms = map[string]string{
+	"Enter": "Return", "Escape": "Escape", "Tab": "Tab", "Space": "space",
+	"Backspace": "BackSpace", "Delete": "Delete", "Insert": "Insert",
+	"Home": "Home", "End": "End", "PageUp": "Page_Up", "PageDown": "Page_Down",
+	"Left": "Left", "Up": "Up", "Right": "Right", "Down": "Down",
+	"Plus": "plus", "Minus": "minus", "=": "equal", "-": "minus", ",": "comma",
+	".": "period", "/": "slash", ";": "semicolon", "'": "apostrophe",
+	"[": "bracketleft", "]": "bracketright", "\\": "backslash", "`": "grave",
+}
+
+// trigger returns a in the syntax of the shortcuts specification, e.g.
+// "CTRL+SHIFT+k".
+func trigger(a saucerw.Accelerator) (string, bool) {
+	key, ok := keysyms[a.Key]
+	switch {
+	case ok:
+	case len(a.Key) == 1 && (a.Key[0] >= 'A' && a.Key[0] <= 'Z' || a.Key[0] >= '0' && a.Key[0] <= '9'):
+		key = strings.ToLower(a.Key)
+	case len(a.Key) > 1 && a.Key[0] == 'F':
+		key = a.Key
+	default:
+		return "", false
+	}
+
+	var parts []string
+	for _, mod := range []struct {
+		mod  saucerw.Modifier
+		name string
+	}{{saucerw.ModCtrl, "CTRL"}, {saucerw.ModAlt, "ALT"}, {saucerw.ModShift, "SHIFT"}, {saucerw.ModSuper, "LOGO"}} {
+		if a.Modifiers&mod.mod != 0 {
+			parts = append(parts, mod.name)
+		}
+	}
+
+	return strings.Join(append(parts, key), "+"), true
+}
+
+// portal is the session bus connection with the requests waiting for their
+// response and the sessions of the hot keys.
+type portal struct {
+	conn *dbus.Conn
+
+	mu       sync.Mutex
+	waiting  map[dbus.ObjectPath]chan []any
+	sessions map[dbus.ObjectPath]*Hotkey
+}
+
+var (
+	portalOnce sync.Once
+	shared     *portal
+	sharedErr  error
+
+	tokens atomic.Uint64
+)
+
+// connect returns the shared connection, connecting on first use.
+func connect() (*portal, error) {
+	portalOnce.Do(func() {
+		conn, err := dbus.SessionBus()
+		if err != nil {
+			sharedErr = fmt.Errorf("%w: %w", ErrUnsupported, err)
+			return
+		}
+
+		match := fmt.Sprintf("type='signal',interface='%s',member='Activated'", shortcutIface)
+		if _, err := conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", match); err != nil {
+			conn.Close()
+			sharedErr = fmt.Errorf("hotkey: %w", err)
+			return
+		}
+
+		shared = &portal{conn: conn, waiting: map[dbus.ObjectPath]chan []any{}, sessions: map[dbus.ObjectPath]*Hotkey{}}
+		conn.Handle(shared.handle)
+	})
+	return shared, sharedErr
+}
+
+// handle delivers Response and Activated signals. It runs on the goroutine
+// reading the connection.
+func (p *portal) handle(msg *dbus.Message) {
+	if msg.Type != dbus.TypeSignal {
+		return
+	}
+
+	switch {
+	case msg.Interface == requestIface && msg.Member == "Response":
+		p.mu.Lock()
+		ch := p.waiting[msg.Path]
+		p.mu.Unlock()
+
+		if ch != nil {
+			select {
+			case ch <- msg.Body:
+			default:
+			}
+		}
+	case msg.Interface == shortcutIface && msg.Member == "Activated" && len(msg.Body) > 0:
+		session, _ := msg.Body[0].(dbus.ObjectPath)
+
+		p.mu.Lock()
+		h := p.sessions[session]
+		p.mu.Unlock()
+
+		if h != nil {
+			go h.press()
+		}
+	}
+}
+
+// request calls method of the portal, whose last argument are the options
+// it is passed, and returns the results of its response.
+func (p *portal) request(method, sig string, args []any, options map[string]dbus.Variant) (map[string]any, error) {
+	// The request object path is known in advance, so the response cannot
+	// arrive before the match is in place.
+	token := "saucerw" + strconv.FormatUint(tokens.Add(1), 10)
+	sender := strings.ReplaceAll(strings.TrimPrefix(p.conn.Name(), ":"), ".", "_")
+	path := dbus.ObjectPath(portalPath + "/request/" + sender + "/" + token)
+
+	match := fmt.Sprintf("type='signal',interface='%s',member='Response',path='%s'", requestIface, path)
+	if _, err := p.conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", match); err != nil {
+		return nil, fmt.Errorf("hotkey: %w", err)
+	}
+	defer p.conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "RemoveMatch", "s", match)
+
+	ch := make(chan []any, 1)
+
+	p.mu.Lock()
+	p.waiting[path] = ch
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.waiting, path)
+		p.mu.Unlock()
+	}()
+
+	options["handle_token"] = dbus.MakeVariant("s", token)
+
+	if _, err := p.conn.Call(portalName, portalPath, shortcutIface, method, sig, append(args, options)...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	// The user may take a while to confirm the shortcut.
+	select {
+	case body := <-ch:
+		if len(body) != 2 {
+			return nil, errors.New("hotkey: invalid portal response")
+		}
+
+		switch code, _ := body[0].(uint32); code {
+		case responseOK:
+		case responseAbort:
+			return nil, fmt.Errorf("hotkey: %s was cancelled", method)
+		default:
+			return nil, fmt.Errorf("hotkey: %s failed with response %d", method, code)
+		}
+
+		results, _ := body[1].(map[string]any)
+		return results, nil
+	case <-p.conn.Done():
+		return nil, fmt.Errorf("hotkey: %w", dbus.ErrClosed)
+	}
+}
+
+// session is the portal session of a hot key.
+type session struct {
+	portal *portal
+	path   dbus.ObjectPath
+}
+
+func register(h *Hotkey, description string) (backend, error) {
+	preferred, ok := trigger(h.accelerator)
+	if !ok {
+		return nil, unsupported(h.accelerator)
+	}
+
+	p, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	token := "saucerw" + strconv.FormatUint(tokens.Add(1), 10)
+	results, err := p.request("CreateSession", "a{sv}", nil, map[string]dbus.Variant{
+		"session_handle_token": dbus.MakeVariant("s", token),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	handle, _ := results["session_handle"].(dbus.Variant)
+	path, ok := stringValue(handle.Value)
+	if !ok {
+		return nil, errors.New("hotkey: portal created no session")
+	}
+
+	s := &session{portal: p, path: dbus.ObjectPath(path)}
+
+	p.mu.Lock()
+	p.sessions[s.path] = h
+	p.mu.Unlock()
+
+	if description == "" {
+		description = h.accelerator.String()
+	}
+
+	shortcuts := []any{[]any{shortcutID, map[string]dbus.Variant{
+		"description":       dbus.MakeVariant("s", description),
+		"preferred_trigger": dbus.MakeVariant("s", preferred),
+	}}}
+
+	results, err = p.request("BindShortcuts", "oa(sa{sv})sa{sv}", []any{s.path, shortcuts, ""}, map[string]dbus.Variant{})
+	if err == nil && !bound(results) {
+		err = fmt.Errorf("%w: %s", ErrConflict, h.accelerator)
+	}
+	if err != nil {
+		s.unregister()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// bound reports whether the results of BindShortcuts list the shortcut. The
+// desktop leaves out shortcuts whose trigger the user declined or which
+// conflict with another.
+func bound(results map[string]any) bool {
+	variant, _ := results["shortcuts"].(dbus.Variant)
+	shortcuts, _ := variant.Value.([]any)
+
+	for _, shortcut := range shortcuts {
+		if fields, _ := shortcut.([]any); len(fields) == 2 && fields[0] == shortcutID {
+			return true
+		}
+	}
+	return false
+}
+
+// stringValue returns the string or object path v.
+func stringValue(v any) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case dbus.ObjectPath:
+		return string(s), true
+	}
+	return "", false
+}
+
+func (s *session) unregister() {
+	s.portal.mu.Lock()
+	delete(s.portal.sessions, s.path)
+	s.portal.mu.Unlock()
+
+	_, _ = s.portal.conn.Call(portalName, s.path, sessionIface, "Close", "")
+}
@@ -0,0 +1,41 @@
+package saucerw
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Inject adds code to every page loaded from now on and returns an id for
+// Uninject. The script runs at the given time in the selected frames and is
+// removed by UninjectAll.
+func (v *Webview) Inject(code string, when InjectTime, frames FrameScope) uint64 {
+	return v.native.Inject(Script{Code: code, Time: when, Frames: frames})
+}
+
+// InjectScript adds script to every page loaded from now on and returns an
+// id for Uninject. Permanent scripts are kept by UninjectAll.
+func (v *Webview) InjectScript(script Script) uint64 {
+	return v.native.Inject(script.scoped())
+}
+
+// scoped returns s with its code skipped in the frames of other origins
+// than s.Origins.
+func (s Script) scoped() Script {
+	if len(s.Origins) == 0 {
+		return s
+	}
+
+	origins, _ := json.Marshal(s.Origins)
+	s.Code = fmt.Sprintf("if (%s.includes(location.origin))\n{\n%s\n}", origins, s.Code)
+	return s
+}
+
+// Uninject removes the injected script id, permanent or not.
+func (v *Webview) Uninject(id uint64) {
+	v.native.Uninject(id)
+}
+
+// UninjectAll removes every injected script that is not permanent.
+func (v *Webview) UninjectAll() {
+	v.native.UninjectAll()
+}
@@ -0,0 +1,222 @@
+package saucerw
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyInput is a key event sent to the page, see Webview.SendKey.
+type KeyInput struct {
+	Accelerator Accelerator
+	// Up releases the key, otherwise it is pressed.
+	Up bool
+	// Key and Code are the key and code of the KeyboardEvent on a US
+	// layout, Text is the text the key types, if any.
+	Key  string
+	Code string
+	Text string
+}
+
+// MouseAction is the kind of a MouseInput.
+type MouseAction uint8
+
+const (
+	// MouseMove moves the pointer to Position.
+	MouseMove MouseAction = iota
+	// MouseClick moves the pointer to Position and presses and releases
+	// Button Clicks times.
+	MouseClick
+	// MouseDown presses Button at Position.
+	MouseDown
+	// MouseUp releases Button at Position.
+	MouseUp
+	// MouseWheel scrolls by DeltaX and DeltaY at Position.
+	MouseWheel
+)
+
+// MouseButton is a button of the mouse, the primary, auxiliary and
+// secondary button of a right-handed mouse.
+type MouseButton uint8
+
+const (
+	MouseLeft MouseButton = iota
+	MouseMiddle
+	MouseRight
+)
+
+// MouseInput is a mouse event sent to the page, see Webview.SendMouse.
+type MouseInput struct {
+	Action MouseAction
+	// Position is the position of the pointer in the CSS pixels of the
+	// page, the clientX and clientY of the events of the main frame.
+	Position Position
+	Button   MouseButton
+	// Clicks is the number of clicks of MouseClick, 2 for a double click,
+	// or the click count of MouseDown and MouseUp. Zero is one click.
+	Clicks int
+	// DeltaX and DeltaY are the pixels MouseWheel scrolls by, positive
+	// values scroll right and down.
+	DeltaX    float64
+	DeltaY    float64
+	Modifiers Modifier
+}
+
+// namedKeys are the KeyboardEvent key and code of the named keys of
+// Accelerator.
+var namedKeys = map[string][2]string{
+	"Enter":     {"Enter", "Enter"},
+	"Escape":    {"Escape", "Escape"},
+	"Tab":       {"Tab", "Tab"},
+	"Space":     {" ", "Space"},
+	"Backspace": {"Backspace", "Backspace"},
+	"Delete":    {"Delete", "Delete"},
+	"Insert":    {"Insert", "Insert"},
+	"Home":      {"Home", "Home"},
+	"End":       {"End", "End"},
+	"PageUp":    {"PageUp", "PageUp"},
+	"PageDown":  {"PageDown", "PageDown"},
+	"Up":        {"ArrowUp", "ArrowUp"},
+	"Down":      {"ArrowDown", "ArrowDown"},
+	"Left":      {"ArrowLeft", "ArrowLeft"},
+	"Right":     {"ArrowRight", "ArrowRight"},
+	"Plus":      {"+", "Equal"},
+	"Minus":     {"-", "Minus"},
+}
+
+// The characters of the digit and punctuation keys of a US layout, without
+// and with Shift.
+const (
+	usKeys    = "1234567890-=[]\\;',./`"
+	usShifted = "!@#$%^&*()_+{}|:\"<>?~"
+)
+
+// punctuationCodes are the KeyboardEvent codes of the punctuation keys of a
+// US layout.
+var punctuationCodes = map[byte]string{
+	'-': "Minus", '=': "Equal", '[': "BracketLeft", ']': "BracketRight", '\\': "Backslash",
+	';': "Semicolon", '\'': "Quote", ',': "Comma", '.': "Period", '/': "Slash", '`': "Backquote",
+}
+
+// keyInput returns the KeyInput pressing a.
+func keyInput(a Accelerator) KeyInput {
+	rtn := KeyInput{Accelerator: a}
+
+	switch named, ok := namedKeys[a.Key]; {
+	case ok:
+		rtn.Key, rtn.Code = named[0], named[1]
+		switch a.Key {
+		case "Enter":
+			rtn.Text = "\r"
+		case "Space", "Plus", "Minus":
+			rtn.Text = rtn.Key
+		}
+	case len(a.Key) > 1:
+		// F1 to F24
+		rtn.Key, rtn.Code = a.Key, a.Key
+	case a.Key[0] >= 'A' && a.Key[0] <= 'Z':
+		rtn.Key, rtn.Code = strings.ToLower(a.Key), "Key"+a.Key
+		if a.Modifiers&ModShift != 0 {
+			rtn.Key = a.Key
+		}
+		rtn.Text = rtn.Key
+	default:
+		// A shifted character is typed with Shift on its key
+		key := a.Key[0]
+		if i := strings.IndexByte(usShifted, key); i >= 0 {
+			key = usKeys[i]
+			rtn.Accelerator = Accelerator{Modifiers: a.Modifiers | ModShift, Key: string(key)}
+			rtn.Key = a.Key
+		} else if i := strings.IndexByte(usKeys, key); i >= 0 && a.Modifiers&ModShift != 0 {
+			rtn.Key = usShifted[i : i+1]
+		}
+
+		if rtn.Key == "" {
+			rtn.Key = a.Key
+		}
+		rtn.Text = rtn.Key
+		rtn.Code = punctuationCodes[key]
+		if key >= '0' && key <= '9' {
+			rtn.Code = "Digit" + string(key)
+		}
+	}
+
+	// Shortcuts type nothing
+	if a.Modifiers&(ModCtrl|ModAlt|ModSuper) != 0 {
+		rtn.Text = ""
+	}
+	return rtn
+}
+
+// SendKey presses and releases key, a shortcut in the syntax of
+// ParseAccelerator such as "Enter" or "CmdOrCtrl+A", as if typed on the
+// keyboard: the page receives trusted events, the focused element types the
+// character and the engine handles the shortcut. Unlike the events of
+// Execute they reach focus-sensitive and trusted-event-gated UI.
+//
+// The key is sent to the webview whether or not its window is focused. It
+// returns an error wrapping ErrUnsupported on WebKitGTK, which cannot
+// synthesize input.
+func (v *Webview) SendKey(key string) error {
+	accelerator, err := ParseAccelerator(key)
+	if err != nil {
+		return err
+	}
+
+	input := keyInput(accelerator)
+	if !v.native.SendKey(input) {
+		return fmt.Errorf("%w: input", ErrUnsupported)
+	}
+
+	input.Up = true
+	v.native.SendKey(input)
+	return nil
+}
+
+// SendText inserts text at the focus of the page as if typed with an input
+// method, firing the beforeinput and input events but no key events. It is
+// supported like SendKey.
+func (v *Webview) SendText(text string) error {
+	if !v.native.SendText(text) {
+		return fmt.Errorf("%w: input", ErrUnsupported)
+	}
+	return nil
+}
+
+// SendMouse sends the mouse event input to the page like SendKey. Only
+// MouseClick moves the pointer before pressing the button, pages tracking
+// hover may expect a MouseMove before MouseDown.
+func (v *Webview) SendMouse(input MouseInput) error {
+	if input.Button > MouseRight {
+		return fmt.Errorf("saucerw: unknown mouse button %d", input.Button)
+	}
+
+	clicks := max(input.Clicks, 1)
+
+	switch input.Action {
+	case MouseMove, MouseWheel:
+	case MouseDown, MouseUp:
+		input.Clicks = clicks
+	case MouseClick:
+		input.Action, input.Clicks = MouseMove, 0
+		if !v.native.SendMouse(input) {
+			return fmt.Errorf("%w: input", ErrUnsupported)
+		}
+
+		// The click count grows like that of a user clicking repeatedly
+		for i := 1; i <= clicks; i++ {
+			input.Clicks = i
+			input.Action = MouseDown
+			v.native.SendMouse(input)
+			input.Action = MouseUp
+			v.native.SendMouse(input)
+		}
+		return nil
+	default:
+		return fmt.Errorf("saucerw: unknown mouse action %d", input.Action)
+	}
+
+	if !v.native.SendMouse(input) {
+		return fmt.Errorf("%w: input", ErrUnsupported)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <Carbon/Carbon.h>
+#import <WebKit/WebKit.h>
+
+#include "native.h"
+
+// Implemented in cookies_darwin.m
+WKWebView *saucerw_cocoa_webview(const void *impl);
+
+// The characters of the ANSI layout in the order of their key codes, from kVK_ANSI_A to kVK_ANSI_Grave, the gaps
+// being other keys
+static const char layout[] = "asdfhgzxcv\1bqweryt123465=97-80]ou[ip\1lj'k;\\,/nm.\1\1`";
+
+static const struct
+{
+    const char *name;
+    unsigned short code;
+    unichar key;
+} named_keys[] = {
+    {"Enter", kVK_Return, '\r'},
+    {"Escape", kVK_Escape, 0x1b},
+    {"Tab", kVK_Tab, '\t'},
+    {"Space", kVK_Space, ' '},
+    {"Backspace", kVK_Delete, NSBackspaceCharacter},
+    {"Delete", kVK_ForwardDelete, NSDeleteFunctionKey},
+    {"Insert", kVK_Help, NSInsertFunctionKey},
+    {"Home", kVK_Home, NSHomeFunctionKey},
+    {"End", kVK_End, NSEndFunctionKey},
+    {"PageUp", kVK_PageUp, NSPageUpFunctionKey},
+    {"PageDown", kVK_PageDown, NSPageDownFunctionKey},
+    {"Up", kVK_UpArrow, NSUpArrowFunctionKey},
+    {"Down", kVK_DownArrow, NSDownArrowFunctionKey},
+    {"Left", kVK_LeftArrow, NSLeftArrowFunctionKey},
+    {"Right", kVK_RightArrow, NSRightArrowFunctionKey},
+    {"Plus", kVK_ANSI_Equal, '='},
+    {"Minus", kVK_ANSI_Minus, '-'},
+    {"F1", kVK_F1, NSF1FunctionKey},
+    {"F2", kVK_F2, NSF2FunctionKey},
+    {"F3", kVK_F3, NSF3FunctionKey},
+    {"F4", kVK_F4, NSF4FunctionKey},
+    {"F5", kVK_F5, NSF5FunctionKey},
+    {"F6", kVK_F6, NSF6FunctionKey},
+    {"F7", kVK_F7, NSF7FunctionKey},
+    {"F8", kVK_F8, NSF8FunctionKey},
+    {"F9", kVK_F9, NSF9FunctionKey},
+    {"F10", kVK_F10, NSF10FunctionKey},
+    {"F11", kVK_F11, NSF11FunctionKey},
+    {"F12", kVK_F12, NSF12FunctionKey},
+    {"F13", kVK_F13, NSF13FunctionKey},
+    {"F14", kVK_F14, NSF14FunctionKey},
+    {"F15", kVK_F15, NSF15FunctionKey},
+    {"F16", kVK_F16, NSF16FunctionKey},
+    {"F17", kVK_F17, NSF17FunctionKey},
+    {"F18", kVK_F18, NSF18FunctionKey},
+    {"F19", kVK_F19, NSF19FunctionKey},
+    {"F20", kVK_F20, NSF20FunctionKey},
+};
+
+// Looks up the key code and the unmodified character of the key named like saucerw.Accelerator
+static bool lookup_key(const char *name, unsigned short *code, unichar *key)
+{
+    for (size_t i = 0; i < sizeof(named_keys) / sizeof(named_keys[0]); ++i)
+    {
+        if (strcmp(named_keys[i].name, name) == 0)
+        {
+            *code = named_keys[i].code;
+            *key  = named_keys[i].key;
+            return true;
+        }
+    }
+
+    const char *found = name[0] != '\0' && name[1] == '\0' ? strchr(layout, tolower(name[0])) : NULL;
+
+    if (!found || *found == '\1')
+    {
+        return false;
+    }
+
+    *code = (unsigned short)(found - layout);
+    *key  = (unichar)*found;
+
+    return true;
+}
+
+static NSEventModifierFlags modifier_flags(int modifiers)
+{
+    NSEventModifierFlags rtn = 0;
+
+    if (modifiers & SAUCERW_MOD_CTRL)
+    {
+        rtn |= NSEventModifierFlagControl;
+    }
+
+    if (modifiers & SAUCERW_MOD_SHIFT)
+    {
+        rtn |= NSEventModifierFlagShift;
+    }
+
+    if (modifiers & SAUCERW_MOD_ALT)
+    {
+        rtn |= NSEventModifierFlagOption;
+    }
+
+    if (modifiers & SAUCERW_MOD_SUPER)
+    {
+        rtn |= NSEventModifierFlagCommand;
+    }
+
+    return rtn;
+}
+
+bool saucerw_cocoa_send_key(const void *webview, const saucerw_key_input *input)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+    unsigned short code;
+    unichar key;
+
+    if (!native || !native.window || !lookup_key(input->name, &code, &key))
+    {
+        return false;
+    }
+
+    NSString *ignoring   = [NSString stringWithCharacters:&key length:1];
+    NSString *characters = input->text[0] != '\0' ? [NSString stringWithUTF8String:input->text] : ignoring;
+    NSEventModifierFlags flags = modifier_flags(input->modifiers);
+
+    NSEvent *event = [NSEvent keyEventWithType:input->up ? NSEventTypeKeyUp : NSEventTypeKeyDown
+                                      location:NSZeroPoint
+                                 modifierFlags:flags
+                                     timestamp:NSProcessInfo.processInfo.systemUptime
+                                  windowNumber:native.window.windowNumber
+                                       context:nil
+                                    characters:characters
+                   charactersIgnoringModifiers:ignoring
+                                     isARepeat:NO
+                                       keyCode:code];
+
+    if (input->up)
+    {
+        [native keyUp:event];
+        return true;
+    }
+
+    // Shortcuts with Command are key equivalents, which the responder chain sees before keyDown
+    if (!(flags & NSEventModifierFlagCommand) || ![native performKeyEquivalent:event])
+    {
+        [native keyDown:event];
+    }
+
+    return true;
+}
+
+bool saucerw_cocoa_send_text(const void *webview, const char *text)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+
+    if (!native)
+    {
+        return false;
+    }
+
+    [(id<NSTextInputClient>)native insertText:[NSString stringWithUTF8String:text]
+                             replacementRange:NSMakeRange(NSNotFound, 0)];
+
+    return true;
+}
+
+bool saucerw_cocoa_send_mouse(const void *webview, const saucerw_mouse_input *input)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+
+    if (!native || !native.window)
+    {
+        return false;
+    }
+
+    const CGFloat zoom = native.pageZoom;
+    const CGFloat y    = native.isFlipped ? input->y * zoom : NSHeight(native.bounds) - input->y * zoom;
+    const NSPoint location           = [native convertPoint:NSMakePoint(input->x * zoom, y) toView:nil];
+    const NSEventModifierFlags flags = modifier_flags(input->modifiers);
+
+    if (input->action == SAUCERW_MOUSE_WHEEL)
+    {
+        // Core Graphics places the origin of the screen at the top left and scrolls by positive deltas up and left
+        const NSPoint screen = [native.window convertPointToScreen:location];
+        CGEventRef wheel     = CGEventCreateScrollWheelEvent(NULL, kCGScrollEventUnitPixel, 2,
+                                                             (int32_t)-input->dy, (int32_t)-input->dx);
+
+        CGEventSetLocation(wheel, CGPointMake(screen.x, NSMaxY(NSScreen.screens[0].frame) - screen.y));
+        CGEventSetFlags(wheel, (CGEventFlags)flags);
+
+        [native scrollWheel:[NSEvent eventWithCGEvent:wheel]];
+        CFRelease(wheel);
+
+        return true;
+    }
+
+    static const NSEventType down[] = {NSEventTypeLeftMouseDown, NSEventTypeOtherMouseDown, NSEventTypeRightMouseDown};
+    static const NSEventType up[]   = {NSEventTypeLeftMouseUp, NSEventTypeOtherMouseUp, NSEventTypeRightMouseUp};
+
+    NSEventType type = NSEventTypeMouseMoved;
+
+    if (input->action == SAUCERW_MOUSE_DOWN)
+    {
+        type = down[input->button];
+    }
+    else if (input->action == SAUCERW_MOUSE_UP)
+    {
+        type = up[input->button];
+    }
+
+    NSEvent *event = [NSEvent mouseEventWithType:type
+                                        location:location
+                                   modifierFlags:flags
+                                       timestamp:NSProcessInfo.processInfo.systemUptime
+                                    windowNumber:native.window.windowNumber
+                                         context:nil
+                                     eventNumber:0
+                                      clickCount:input->clicks
+                                        pressure:input->action == SAUCERW_MOUSE_DOWN ? 1 : 0];
+
+    switch (type)
+    {
+    case NSEventTypeLeftMouseDown:
+        [native mouseDown:event];
+        break;
+    case NSEventTypeLeftMouseUp:
+        [native mouseUp:event];
+        break;
+    case NSEventTypeRightMouseDown:
+        [native rightMouseDown:event];
+        break;
+    case NSEventTypeRightMouseUp:
+        [native rightMouseUp:event];
+        break;
+    case NSEventTypeOtherMouseDown:
+        [native otherMouseDown:event];
+        break;
+    case NSEventTypeOtherMouseUp:
+        [native otherMouseUp:event];
+        break;
+    default:
+        [native mouseMoved:event];
+        break;
+    }
+
+    return true;
+}
@@ -0,0 +1,184 @@
+package saucerw
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAlreadyRunning is returned by EnsureSingleInstance after the launch was
+// forwarded to the instance already running.
+var ErrAlreadyRunning = errors.New("saucerw: application is already running")
+
+// Launch describes a second launch of a single-instance application.
+type Launch struct {
+	// Args are the command line arguments without the program name.
+	Args []string
+	// URLs are the arguments that look like URLs, e.g. deep links of a
+	// registered scheme.
+	URLs []string
+	// Dir is the working directory of the second launch.
+	Dir string
+}
+
+// Instance is the running instance of a single-instance application. It
+// receives the launches forwarded by later instances.
+type Instance struct {
+	listener instanceListener
+	launches emitter[Launch]
+
+	once sync.Once
+}
+
+// EnsureSingleInstance makes the calling process the single instance of the
+// application id, typically the AppOptions.ID. It should be called early in
+// main, before the application is created.
+//
+// If another instance is running, the command line arguments of this process
+// are forwarded to it and ErrAlreadyRunning is returned; the caller should
+// exit. Instances listen on a unix socket in a directory only the user can
+// access, below the runtime or temporary directory, or on Windows on a named
+// pipe only the user can open. Both ends check that the other runs as the
+// same user before a launch is forwarded; other users can neither take over
+// the instance nor read the arguments.
+func EnsureSingleInstance(id string) (*Instance, error) {
+	if id == "" {
+		return nil, errors.New("saucerw: application id is required")
+	}
+
+	// Two launches finding the socket of a crashed instance would otherwise
+	// both remove it, one of them the socket the other just listens on
+	unlock, err := lockInstance(id)
+	if err != nil {
+		return nil, fmt.Errorf("saucerw: single instance: %w", err)
+	}
+	defer unlock()
+
+	for range 2 {
+		listener, err := listenInstance(id)
+		if err == nil {
+			i := &Instance{listener: listener}
+			go i.serve()
+
+			return i, nil
+		}
+		if !errors.Is(err, errInstanceExists) {
+			return nil, fmt.Errorf("saucerw: single instance: %w", err)
+		}
+
+		conn, err := dialInstance(id)
+		if errors.Is(err, errInstanceGone) {
+			// Nobody answers, the socket was left behind by a crashed instance.
+			if err := removeInstance(id); err != nil {
+				return nil, fmt.Errorf("saucerw: single instance: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("saucerw: single instance: %w", err)
+		}
+
+		if err := forwardLaunch(conn); err != nil {
+			return nil, fmt.Errorf("saucerw: single instance: %w", err)
+		}
+		return nil, ErrAlreadyRunning
+	}
+
+	return nil, errors.New("saucerw: single instance: could not become the instance")
+}
+
+// The errors of the platform listeners and dialers of instances.
+var (
+	// errInstanceExists is returned by listenInstance if another process
+	// listens or left its socket behind.
+	errInstanceExists = errors.New("instance exists")
+	// errInstanceGone is returned by dialInstance if nobody listens.
+	errInstanceGone = errors.New("instance is gone")
+)
+
+// instanceListener accepts the connections of later instances, from
+// processes of the same user only.
+type instanceListener interface {
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// instanceName returns the name of the socket or pipe of the application id
+// for key, which identifies the user. The id is hashed to stay below the
+// socket path limit of 104 bytes.
+func instanceName(id, key string) string {
+	sum := sha256.Sum256([]byte(id + "\x00" + key))
+	return "saucerw-" + hex.EncodeToString(sum[:8])
+}
+
+// timeout closes conn after the time a launch may take to forward.
+func timeout(conn io.Closer) *time.Timer {
+	return time.AfterFunc(5*time.Second, func() { conn.Close() })
+}
+
+// forwardLaunch sends the launch of this process over conn.
+func forwardLaunch(conn io.ReadWriteCloser) error {
+	defer conn.Close()
+	defer timeout(conn).Stop()
+
+	launch := Launch{Args: os.Args[1:]}
+	launch.Dir, _ = os.Getwd()
+
+	for _, arg := range launch.Args {
+		if strings.Contains(arg, "://") {
+			launch.URLs = append(launch.URLs, arg)
+		}
+	}
+
+	return json.NewEncoder(conn).Encode(launch)
+}
+
+// serve receives forwarded launches until the instance is closed.
+func (i *Instance) serve() {
+	for {
+		conn, err := i.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+			defer timeout(conn).Stop()
+
+			var launch Launch
+			if json.NewDecoder(conn).Decode(&launch) == nil {
+				i.launches.emit(launch)
+			}
+		}()
+	}
+}
+
+// OnLaunch calls fn for every launch forwarded by a later instance. Launches
+// forwarded while no handler is registered are dropped.
+func (i *Instance) OnLaunch(fn func(Launch)) *Subscription {
+	return i.launches.subscribe(fn)
+}
+
+// Raise shows, restores and focuses w whenever a later instance is launched.
+func (i *Instance) Raise(w *Window) *Subscription {
+	return i.OnLaunch(func(Launch) {
+		w.Show()
+		w.SetMinimized(false)
+		w.Focus()
+	})
+}
+
+// Close stops receiving launches and lets another process become the
+// instance.
+func (i *Instance) Close() error {
+	var err error
+	i.once.Do(func() { err = i.listener.Close() })
+	return err
+}
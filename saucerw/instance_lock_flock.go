@@ -0,0 +1,35 @@
+//go:build unix && !aix && !solaris
+
+package saucerw
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockInstance takes the lock of the application id, a lock file next to the
+// socket in the private directory, waiting for the process holding it.
+func lockInstance(id string) (func(), error) {
+	path, err := instanceSocket(id)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE|syscall.O_NOFOLLOW, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// Closing the file releases the lock
+	return func() { f.Close() }, nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package saucerw
+
+import (
+	"errors"
+	"io"
+)
+
+var errNoInstance = errors.New("not supported on this platform")
+
+func listenInstance(id string) (instanceListener, error) { return nil, errNoInstance }
+
+func dialInstance(id string) (io.ReadWriteCloser, error) { return nil, errNoInstance }
+
+func removeInstance(id string) error { return errNoInstance }
@@ -0,0 +1,34 @@
+//go:build darwin || freebsd || dragonfly
+
+package saucerw
+
+import (
+	"encoding/binary"
+	"syscall"
+	"unsafe"
+)
+
+// The getsockopt of unix sockets returning a struct xucred, whose cr_uid
+// follows the cr_version.
+const (
+	solLocal       = 0
+	localPeerCred  = 1
+	xucredUIDStart = 4
+)
+
+// peerUID returns the user of the process at the other end of the unix
+// socket fd.
+func peerUID(fd int) (int, bool, error) {
+	var xucred [128]byte
+	size := uint32(len(xucred))
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, uintptr(fd), solLocal, localPeerCred,
+		uintptr(unsafe.Pointer(&xucred[0])), uintptr(unsafe.Pointer(&size)), 0)
+	if errno != 0 {
+		return 0, false, errno
+	}
+	if size < xucredUIDStart+4 {
+		return 0, false, syscall.EINVAL
+	}
+	return int(binary.NativeEndian.Uint32(xucred[xucredUIDStart:])), true, nil
+}
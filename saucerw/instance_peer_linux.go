@@ -0,0 +1,13 @@
+package saucerw
+
+import "syscall"
+
+// peerUID returns the user of the process at the other end of the unix
+// socket fd.
+func peerUID(fd int) (int, bool, error) {
+	cred, err := syscall.GetsockoptUcred(fd, syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return 0, false, err
+	}
+	return int(cred.Uid), true, nil
+}
@@ -0,0 +1,9 @@
+//go:build unix && !linux && !darwin && !freebsd && !dragonfly
+
+package saucerw
+
+// peerUID reports that the user at the other end of unix sockets is unknown,
+// the private directory of the sockets alone keeps other users out.
+func peerUID(fd int) (int, bool, error) {
+	return 0, false, nil
+}
@@ -0,0 +1,152 @@
+//go:build unix
+
+package saucerw
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// instanceDir returns the directory of the instance sockets of the user,
+// creating it. Outside of the runtime directory, which belongs to the user,
+// it is a directory of the shared temporary directory another user may have
+// created first or replaced by a link, so it is only used if it belongs to
+// the user and only the user can access it.
+func instanceDir() (string, error) {
+	dir := filepath.Join(os.TempDir(), "saucerw-"+strconv.Itoa(os.Getuid()))
+	if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+		dir = filepath.Join(runtime, "saucerw")
+	}
+
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", err
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || !ok || int(st.Uid) != os.Getuid() || info.Mode().Perm() != 0o700 {
+		return "", fmt.Errorf("%s is not a directory only the user can access", dir)
+	}
+	return dir, nil
+}
+
+// instanceSocket returns the path of the socket of the application id.
+func instanceSocket(id string) (string, error) {
+	dir, err := instanceDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, instanceName(id, strconv.Itoa(os.Getuid()))+".sock"), nil
+}
+
+// unixInstanceListener accepts the connections of processes of the user.
+type unixInstanceListener struct {
+	*net.UnixListener
+}
+
+// listenInstance listens on the socket of the application id.
+func listenInstance(id string) (instanceListener, error) {
+	path, err := instanceSocket(id)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return nil, errInstanceExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unixInstanceListener{listener}, nil
+}
+
+// Accept returns the next connection of a process of the user, closing those
+// of other users before anything is read from them.
+func (l unixInstanceListener) Accept() (io.ReadWriteCloser, error) {
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPeer(conn); err != nil {
+			conn.Close()
+			continue
+		}
+		return conn, nil
+	}
+}
+
+// dialInstance connects to the socket of the application id and checks that
+// the instance listening runs as the user.
+func dialInstance(id string) (io.ReadWriteCloser, error) {
+	path, err := instanceSocket(id)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT) {
+		return nil, errInstanceGone
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPeer(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// removeInstance removes the socket left behind by the instance of the
+// application id. The private directory only holds sockets of the user.
+func removeInstance(id string) error {
+	path, err := instanceSocket(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// checkPeer returns an error if the process at the other end of conn does
+// not run as the user.
+func checkPeer(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var (
+		uid    int
+		ok     bool
+		sysErr error
+	)
+	if err := raw.Control(func(fd uintptr) {
+		uid, ok, sysErr = peerUID(int(fd))
+	}); err != nil {
+		return err
+	}
+	if sysErr != nil {
+		return fmt.Errorf("peer credentials: %w", sysErr)
+	}
+
+	// Without peer credentials the private directory keeps other users out
+	if ok && uid != os.Getuid() {
+		return fmt.Errorf("peer runs as user %d", uid)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+//go:build unix
+
+package saucerw
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEnsureSingleInstance(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	first, err := EnsureSingleInstance("test.single")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	launches := make(chan Launch, 1)
+	first.OnLaunch(func(l Launch) { launches <- l })
+
+	if _, err := EnsureSingleInstance("test.single"); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second instance: %v", err)
+	}
+
+	select {
+	case l := <-launches:
+		if wd, _ := os.Getwd(); l.Dir != wd {
+			t.Errorf("forwarded dir %q, expected %q", l.Dir, wd)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("launch not forwarded")
+	}
+
+	// A closed instance hands over to the next process
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	next, err := EnsureSingleInstance("test.single")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next.Close()
+}
+
+func TestEnsureSingleInstanceStale(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	path, err := instanceSocket("test.stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := EnsureSingleInstance("test.stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.Close()
+}
+
+// TestEnsureSingleInstanceConcurrent launches at once while a crashed
+// instance left its socket behind: one launch becomes the instance and the
+// others forward to it instead of removing its socket.
+func TestEnsureSingleInstanceConcurrent(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	path, err := instanceSocket("test.concurrent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	const launches = 8
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		instances []*Instance
+		forwarded int
+	)
+	for range launches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			i, err := EnsureSingleInstance("test.concurrent")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				instances = append(instances, i)
+			case errors.Is(err, ErrAlreadyRunning):
+				forwarded++
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, i := range instances {
+		defer i.Close()
+	}
+	if len(instances) != 1 || forwarded != launches-1 {
+		t.Fatalf("%d instances and %d forwarded launches, expected 1 and %d", len(instances), forwarded, launches-1)
+	}
+}
+
+// TestEnsureSingleInstanceLocked checks that a launch waits for the launch
+// holding the lock, here the test removing the stale socket and listening,
+// and then forwards to it instead of removing its socket in turn.
+func TestEnsureSingleInstanceLocked(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	path, err := instanceSocket("test.locked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lockInstance("test.locked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(chan error, 1)
+	go func() {
+		i, err := EnsureSingleInstance("test.locked")
+		if err == nil {
+			i.Close()
+		}
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		t.Fatalf("launch did not wait for the lock: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := removeInstance("test.locked"); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := listenInstance("test.locked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := &Instance{listener: listener}
+	go i.serve()
+	defer i.Close()
+	unlock()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrAlreadyRunning) {
+			t.Fatalf("launch returned %v, expected %v", err, ErrAlreadyRunning)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("launch still waiting after the lock was released")
+	}
+}
+
+func TestInstanceDirPrivate(t *testing.T) {
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	dir := filepath.Join(runtime, "saucerw")
+
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnsureSingleInstance("test.private"); err == nil {
+		t.Fatal("accepted a directory other users can access")
+	}
+
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnsureSingleInstance("test.private"); err == nil {
+		t.Fatal("accepted a link to a directory")
+	}
+}
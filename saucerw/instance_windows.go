@@ -0,0 +1,274 @@
+package saucerw
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+	advapi32 = syscall.NewLazyDLL("advapi32.dll")
+
+	procCreateNamedPipe             = kernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe            = kernel32.NewProc("ConnectNamedPipe")
+	procDisconnectNamedPipe         = kernel32.NewProc("DisconnectNamedPipe")
+	procGetNamedPipeClientProcessId = kernel32.NewProc("GetNamedPipeClientProcessId")
+	procGetNamedPipeServerProcessId = kernel32.NewProc("GetNamedPipeServerProcessId")
+	procConvertSecurityDescriptor   = advapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+)
+
+const (
+	pipeAccessDuplex         = 0x3
+	fileFlagFirstPipeInst    = 0x00080000
+	pipeRejectRemoteClients  = 0x8
+	pipeUnlimitedInstances   = 255
+	securitySqosPresent      = 0x00100000
+	securityIdentification   = 0x00010000
+	processQueryLimitedInfo  = 0x1000
+	sddlRevision1            = 1
+	errorPipeConnected       = syscall.Errno(535)
+	errorPipeBusy            = syscall.Errno(231)
+	errorAccessDenied        = syscall.ERROR_ACCESS_DENIED
+	errorFileNotFound        = syscall.ERROR_FILE_NOT_FOUND
+	pipeBufferSize           = 4096
+	pipeDefaultTimeoutMillis = 0
+)
+
+// userSID returns the security identifier of the user of the process pid, of
+// this process if pid is 0.
+func userSID(pid uint32) (string, error) {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return "", err
+	}
+	if pid != 0 {
+		if process, err = syscall.OpenProcess(processQueryLimitedInfo, false, pid); err != nil {
+			return "", err
+		}
+		defer syscall.CloseHandle(process)
+	}
+
+	var token syscall.Token
+	if err := syscall.OpenProcessToken(process, syscall.TOKEN_QUERY, &token); err != nil {
+		return "", err
+	}
+	defer token.Close()
+
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return user.User.Sid.String()
+}
+
+// instancePipe returns the name of the pipe of the application id and the
+// security identifier of the user.
+func instancePipe(id string) (string, string, error) {
+	sid, err := userSID(0)
+	if err != nil {
+		return "", "", err
+	}
+	return `\\.\pipe\` + instanceName(id, sid), sid, nil
+}
+
+// pipeInstanceListener accepts the connections of processes of the user on
+// the instances of a named pipe only the user can open.
+type pipeInstanceListener struct {
+	name  string
+	sid   string
+	attrs *syscall.SecurityAttributes
+
+	mu     sync.Mutex
+	next   syscall.Handle
+	closed bool
+}
+
+// listenInstance creates the first instance of the pipe of the application
+// id, failing if another process owns the pipe.
+func listenInstance(id string) (instanceListener, error) {
+	name, sid, err := instancePipe(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Owned by and only accessible to the user, without inherited entries
+	sddl, err := syscall.UTF16PtrFromString("O:" + sid + "D:P(A;;GA;;;" + sid + ")")
+	if err != nil {
+		return nil, err
+	}
+	var sd uintptr
+	if r, _, err := procConvertSecurityDescriptor.Call(uintptr(unsafe.Pointer(sddl)), sddlRevision1, uintptr(unsafe.Pointer(&sd)), 0); r == 0 {
+		return nil, fmt.Errorf("security descriptor: %w", err)
+	}
+
+	l := &pipeInstanceListener{
+		name:  name,
+		sid:   sid,
+		attrs: &syscall.SecurityAttributes{SecurityDescriptor: sd},
+	}
+	l.attrs.Length = uint32(unsafe.Sizeof(*l.attrs))
+
+	l.next, err = l.create(true)
+	if errors.Is(err, errorAccessDenied) || errors.Is(err, errorPipeBusy) {
+		syscall.LocalFree(syscall.Handle(sd))
+		return nil, errInstanceExists
+	}
+	if err != nil {
+		syscall.LocalFree(syscall.Handle(sd))
+		return nil, err
+	}
+	return l, nil
+}
+
+// create creates an instance of the pipe, the first failing if the pipe
+// exists.
+func (l *pipeInstanceListener) create(first bool) (syscall.Handle, error) {
+	name, err := syscall.UTF16PtrFromString(l.name)
+	if err != nil {
+		return syscall.InvalidHandle, err
+	}
+
+	mode := uintptr(pipeAccessDuplex)
+	if first {
+		mode |= fileFlagFirstPipeInst
+	}
+
+	h, _, err := procCreateNamedPipe.Call(uintptr(unsafe.Pointer(name)), mode, pipeRejectRemoteClients,
+		pipeUnlimitedInstances, pipeBufferSize, pipeBufferSize, pipeDefaultTimeoutMillis, uintptr(unsafe.Pointer(l.attrs)))
+	if syscall.Handle(h) == syscall.InvalidHandle {
+		return syscall.InvalidHandle, err
+	}
+	return syscall.Handle(h), nil
+}
+
+// Accept waits for the next client of the pipe, disconnecting those of other
+// users before anything is read from them.
+func (l *pipeInstanceListener) Accept() (io.ReadWriteCloser, error) {
+	for {
+		l.mu.Lock()
+		h, closed := l.next, l.closed
+		l.mu.Unlock()
+		if closed {
+			return nil, net.ErrClosed
+		}
+
+		if r, _, err := procConnectNamedPipe.Call(uintptr(h), 0); r == 0 && !errors.Is(err, errorPipeConnected) {
+			syscall.CloseHandle(h)
+			return nil, err
+		}
+
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			syscall.CloseHandle(h)
+			return nil, net.ErrClosed
+		}
+		next, err := l.create(false)
+		if err != nil {
+			l.mu.Unlock()
+			return nil, err
+		}
+		l.next = next
+		l.mu.Unlock()
+
+		if err := checkPipePeer(h, procGetNamedPipeClientProcessId, l.sid); err != nil {
+			procDisconnectNamedPipe.Call(uintptr(h))
+			syscall.CloseHandle(h)
+			continue
+		}
+		return os.NewFile(uintptr(h), l.name), nil
+	}
+}
+
+// Close stops accepting clients, waking Accept by connecting to the waiting
+// instance of the pipe, which Accept then closes.
+func (l *pipeInstanceListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	// No instance is created after closed is set
+	syscall.LocalFree(syscall.Handle(l.attrs.SecurityDescriptor))
+
+	name, err := syscall.UTF16PtrFromString(l.name)
+	if err != nil {
+		return err
+	}
+	wake, err := syscall.CreateFile(name, syscall.GENERIC_READ, 0, nil, syscall.OPEN_EXISTING, securitySqosPresent|securityIdentification, 0)
+	if err != nil {
+		return err
+	}
+	return syscall.CloseHandle(wake)
+}
+
+// dialInstance opens the pipe of the application id and checks that the
+// instance serving it runs as the user. The instance may only identify, not
+// impersonate, this process.
+func dialInstance(id string) (io.ReadWriteCloser, error) {
+	name, sid, err := instancePipe(id)
+	if err != nil {
+		return nil, err
+	}
+	path, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var h syscall.Handle
+	for range 50 {
+		h, err = syscall.CreateFile(path, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+			syscall.OPEN_EXISTING, securitySqosPresent|securityIdentification, 0)
+		if !errors.Is(err, errorPipeBusy) {
+			break
+		}
+		// All instances are connected, the instance creates the next one
+		time.Sleep(20 * time.Millisecond)
+	}
+	if errors.Is(err, errorFileNotFound) {
+		return nil, errInstanceGone
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPipePeer(h, procGetNamedPipeServerProcessId, sid); err != nil {
+		syscall.CloseHandle(h)
+		return nil, err
+	}
+	return os.NewFile(uintptr(h), name), nil
+}
+
+// removeInstance does nothing, pipes are gone with the processes that
+// created them.
+func removeInstance(id string) error {
+	return nil
+}
+
+// checkPipePeer returns an error if the process at the other end of the pipe
+// h, as returned by proc, does not run as the user sid.
+func checkPipePeer(h syscall.Handle, proc *syscall.LazyProc, sid string) error {
+	var pid uint32
+	if r, _, err := proc.Call(uintptr(h), uintptr(unsafe.Pointer(&pid))); r == 0 {
+		return fmt.Errorf("pipe peer: %w", err)
+	}
+
+	peer, err := userSID(pid)
+	if err != nil {
+		return fmt.Errorf("pipe peer: %w", err)
+	}
+	if peer != sid {
+		return fmt.Errorf("pipe peer runs as %s", peer)
+	}
+	return nil
+}
@@ -0,0 +1,513 @@
+package dbus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Message types.
+const (
+	TypeMethodCall   byte = 1
+	TypeMethodReturn byte = 2
+	TypeError        byte = 3
+	TypeSignal       byte = 4
+)
+
+// FlagNoReplyExpected marks a method call without a reply.
+const FlagNoReplyExpected byte = 1
+
+// Header field codes.
+const (
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSender      = 7
+	fieldSignature   = 8
+)
+
+// maxMessage is the maximum message size allowed by the specification.
+const maxMessage = 128 << 20
+
+// Message is a D-Bus message.
+type Message struct {
+	Type        byte
+	Flags       byte
+	Serial      uint32
+	Path        ObjectPath
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Signature   string
+	Body        []any
+}
+
+// Error is an error reply.
+type Error struct {
+	Name    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return "dbus: " + e.Name
+	}
+	return "dbus: " + e.Name + ": " + e.Message
+}
+
+// ErrClosed is returned by calls on a closed connection.
+var ErrClosed = errors.New("dbus: connection closed")
+
+// Conn is a connection to a message bus.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	name   string
+	serial atomic.Uint32
+
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[uint32]chan *Message
+	handler func(*Message)
+
+	closed chan struct{}
+	once   sync.Once
+}
+
+// SessionBus connects to the session bus of the user.
+func SessionBus() (*Conn, error) {
+	addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if addr == "" {
+		dir := os.Getenv("XDG_RUNTIME_DIR")
+		if dir == "" {
+			return nil, errors.New("dbus: session bus address is unknown")
+		}
+		addr = "unix:path=" + dir + "/bus"
+	}
+	return Dial(addr)
+}
+
+// Dial connects to the bus at the D-Bus server address addr and
+// authenticates. Only unix socket transports are supported.
+func Dial(addr string) (*Conn, error) {
+	var errs []error
+
+	for _, entry := range strings.Split(addr, ";") {
+		conn, err := dialEntry(entry)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// dialEntry connects to a single server address.
+func dialEntry(entry string) (*Conn, error) {
+	transport, params, _ := strings.Cut(entry, ":")
+	if transport != "unix" {
+		return nil, fmt.Errorf("dbus: unsupported transport %q", transport)
+	}
+
+	var path string
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(param, "=")
+		switch key {
+		case "path":
+			path = unescape(value)
+		case "abstract":
+			path = "@" + unescape(value)
+		}
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("dbus: unsupported address %q", entry)
+	}
+
+	nc, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		conn:    nc,
+		reader:  bufio.NewReader(nc),
+		pending: map[uint32]chan *Message{},
+		closed:  make(chan struct{}),
+	}
+
+	if err := c.auth(); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	go c.read()
+
+	body, err := c.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", "")
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	if len(body) > 0 {
+		c.name, _ = body[0].(string)
+	}
+
+	return c, nil
+}
+
+// unescape decodes the %xx escapes of an address value.
+func unescape(value string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] == '%' && i+2 < len(value) {
+			if v, err := strconv.ParseUint(value[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(value[i])
+	}
+
+	return b.String()
+}
+
+// auth performs the EXTERNAL authentication of the current user.
+func (c *Conn) auth() error {
+	_ = c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := io.WriteString(c.conn, "\x00AUTH EXTERNAL "+uid+"\r\n"); err != nil {
+		return err
+	}
+
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("dbus: authentication failed: %s", strings.TrimSpace(line))
+	}
+
+	_, err = io.WriteString(c.conn, "BEGIN\r\n")
+	return err
+}
+
+// Name returns the unique name of the connection on the bus.
+func (c *Conn) Name() string {
+	return c.name
+}
+
+// Handle sets the function receiving incoming method calls and signals. It
+// runs on the goroutine reading the connection and must not block, replies
+// are sent with Reply and ReplyError.
+func (c *Conn) Handle(fn func(*Message)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.handler = fn
+}
+
+// Call calls a method and returns the body of the reply.
+func (c *Conn) Call(dest string, path ObjectPath, iface, member, sig string, args ...any) ([]any, error) {
+	msg := &Message{
+		Type:        TypeMethodCall,
+		Path:        path,
+		Interface:   iface,
+		Member:      member,
+		Destination: dest,
+		Signature:   sig,
+		Body:        args,
+	}
+
+	ch := make(chan *Message, 1)
+	msg.Serial = c.serial.Add(1)
+
+	c.mu.Lock()
+	c.pending[msg.Serial] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, msg.Serial)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(msg); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(25 * time.Second)
+	defer timer.Stop()
+
+	select {
+	case reply := <-ch:
+		if reply.Type == TypeError {
+			rtn := &Error{Name: reply.ErrorName}
+			if len(reply.Body) > 0 {
+				rtn.Message, _ = reply.Body[0].(string)
+			}
+			return nil, rtn
+		}
+		return reply.Body, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("dbus: %s.%s timed out", iface, member)
+	case <-c.closed:
+		return nil, ErrClosed
+	}
+}
+
+// Emit emits a signal.
+func (c *Conn) Emit(path ObjectPath, iface, member, sig string, args ...any) error {
+	return c.send(&Message{
+		Type:      TypeSignal,
+		Serial:    c.serial.Add(1),
+		Path:      path,
+		Interface: iface,
+		Member:    member,
+		Signature: sig,
+		Body:      args,
+	})
+}
+
+// Reply answers the method call call.
+func (c *Conn) Reply(call *Message, sig string, args ...any) error {
+	if call.Flags&FlagNoReplyExpected != 0 {
+		return nil
+	}
+
+	return c.send(&Message{
+		Type:        TypeMethodReturn,
+		Serial:      c.serial.Add(1),
+		ReplySerial: call.Serial,
+		Destination: call.Sender,
+		Signature:   sig,
+		Body:        args,
+	})
+}
+
+// ReplyError answers the method call call with the error name.
+func (c *Conn) ReplyError(call *Message, name, text string) error {
+	if call.Flags&FlagNoReplyExpected != 0 {
+		return nil
+	}
+
+	return c.send(&Message{
+		Type:        TypeError,
+		Serial:      c.serial.Add(1),
+		ReplySerial: call.Serial,
+		ErrorName:   name,
+		Destination: call.Sender,
+		Signature:   "s",
+		Body:        []any{text},
+	})
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	var err error
+	c.once.Do(func() {
+		err = c.conn.Close()
+		close(c.closed)
+	})
+	return err
+}
+
+// Done returns a channel closed once the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.closed
+}
+
+// send marshals and writes msg.
+func (c *Conn) send(msg *Message) error {
+	data, err := msg.marshal()
+	if err != nil {
+		return err
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if _, err := c.conn.Write(data); err != nil {
+		c.Close()
+		return err
+	}
+	return nil
+}
+
+// read receives messages until the connection fails.
+func (c *Conn) read() {
+	defer c.Close()
+
+	for {
+		msg, err := readMessage(c.reader)
+		if err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case TypeMethodReturn, TypeError:
+			c.mu.Lock()
+			ch := c.pending[msg.ReplySerial]
+			c.mu.Unlock()
+
+			if ch != nil {
+				ch <- msg
+			}
+		case TypeMethodCall, TypeSignal:
+			c.mu.Lock()
+			handler := c.handler
+			c.mu.Unlock()
+
+			switch {
+			case handler != nil:
+				handler(msg)
+			case msg.Type == TypeMethodCall:
+				_ = c.ReplyError(msg, "org.freedesktop.DBus.Error.UnknownObject", "no object at "+string(msg.Path))
+			}
+		}
+	}
+}
+
+// marshal encodes msg.
+func (msg *Message) marshal() ([]byte, error) {
+	var body encoder
+	if err := body.encodeAll(msg.Signature, msg.Body); err != nil {
+		return nil, err
+	}
+
+	var fields []any
+	field := func(code byte, sig string, value any) {
+		fields = append(fields, []any{code, MakeVariant(sig, value)})
+	}
+
+	if msg.Path != "" {
+		field(fieldPath, "o", msg.Path)
+	}
+	if msg.Interface != "" {
+		field(fieldInterface, "s", msg.Interface)
+	}
+	if msg.Member != "" {
+		field(fieldMember, "s", msg.Member)
+	}
+	if msg.ErrorName != "" {
+		field(fieldErrorName, "s", msg.ErrorName)
+	}
+	if msg.ReplySerial != 0 {
+		field(fieldReplySerial, "u", msg.ReplySerial)
+	}
+	if msg.Destination != "" {
+		field(fieldDestination, "s", msg.Destination)
+	}
+	if msg.Signature != "" {
+		field(fieldSignature, "g", Signature(msg.Signature))
+	}
+
+	e := encoder{buf: []byte{'l', msg.Type, msg.Flags, 1}}
+	e.uint32(uint32(len(body.buf)))
+	e.uint32(msg.Serial)
+
+	if err := e.encode("a(yv)", fields); err != nil {
+		return nil, err
+	}
+
+	e.align(8)
+	return append(e.buf, body.buf...), nil
+}
+
+// readMessage reads and decodes a message.
+func readMessage(r io.Reader) (*Message, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("dbus: invalid byte order")
+	}
+
+	bodyLen := order.Uint32(fixed[4:])
+	fieldsLen := order.Uint32(fixed[12:])
+
+	if bodyLen > maxMessage || fieldsLen > maxMessage {
+		return nil, errors.New("dbus: message too large")
+	}
+
+	headerLen := (16 + int(fieldsLen) + 7) &^ 7
+
+	data := make([]byte, headerLen+int(bodyLen))
+	copy(data, fixed)
+
+	if _, err := io.ReadFull(r, data[16:]); err != nil {
+		return nil, err
+	}
+
+	msg := &Message{Type: fixed[1], Flags: fixed[2], Serial: order.Uint32(fixed[8:])}
+
+	header := &decoder{buf: data[:headerLen], pos: 12, order: order}
+
+	fields, err := header.decode("a(yv)")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields.([]any) {
+		f := f.([]any)
+		value := f[1].(Variant).Value
+
+		switch f[0].(byte) {
+		case fieldPath:
+			msg.Path, _ = value.(ObjectPath)
+		case fieldInterface:
+			msg.Interface, _ = value.(string)
+		case fieldMember:
+			msg.Member, _ = value.(string)
+		case fieldErrorName:
+			msg.ErrorName, _ = value.(string)
+		case fieldReplySerial:
+			msg.ReplySerial, _ = value.(uint32)
+		case fieldDestination:
+			msg.Destination, _ = value.(string)
+		case fieldSender:
+			msg.Sender, _ = value.(string)
+		case fieldSignature:
+			sig, _ := value.(Signature)
+			msg.Signature = string(sig)
+		}
+	}
+
+	body := &decoder{buf: data[headerLen:], order: order}
+	if msg.Body, err = body.decodeAll(msg.Signature); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
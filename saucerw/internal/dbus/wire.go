@@ -0,0 +1,538 @@
+// Package dbus implements the subset of the D-Bus protocol used by the
+// bindings to talk to desktop services on Linux and other freedesktop
+// systems: connecting to the session bus, calling methods, emitting signals
+// and answering calls to exported objects.
+//
+// Values are marshaled according to a signature. Basic types map to byte,
+// bool, int16, uint16, int32, uint32, int64, uint64, float64, string,
+// ObjectPath and Signature, arrays to slices (decoded as []any, or []byte for
+// "ay"), dictionaries to maps with string keys, structs to []any and variants
+// to Variant.
+package dbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+)
+
+// ObjectPath is a value of type "o".
+type ObjectPath string
+
+// Signature is a value of type "g".
+type Signature string
+
+// Variant is a value of type "v".
+type Variant struct {
+	Sig   Signature
+	Value any
+}
+
+// MakeVariant returns a Variant of sig holding value.
+func MakeVariant(sig string, value any) Variant {
+	return Variant{Sig: Signature(sig), Value: value}
+}
+
+var errSignature = errors.New("dbus: invalid signature")
+
+// split returns the first complete type of sig and the remainder.
+func split(sig string) (string, string, error) {
+	if sig == "" {
+		return "", "", errSignature
+	}
+
+	switch sig[0] {
+	case 'y', 'b', 'n', 'q', 'i', 'u', 'x', 't', 'd', 's', 'o', 'g', 'v', 'h':
+		return sig[:1], sig[1:], nil
+	case 'a':
+		elem, rest, err := split(sig[1:])
+		if err != nil {
+			return "", "", err
+		}
+		return "a" + elem, rest, nil
+	case '(', '{':
+		closing := byte(')')
+		if sig[0] == '{' {
+			closing = '}'
+		}
+
+		for i := 1; i < len(sig); {
+			if sig[i] == closing {
+				if i == 1 {
+					return "", "", errSignature
+				}
+				return sig[:i+1], sig[i+1:], nil
+			}
+
+			elem, _, err := split(sig[i:])
+			if err != nil {
+				return "", "", err
+			}
+			i += len(elem)
+		}
+	}
+
+	return "", "", errSignature
+}
+
+// types splits sig into its complete types.
+func types(sig string) ([]string, error) {
+	var rtn []string
+
+	for sig != "" {
+		typ, rest, err := split(sig)
+		if err != nil {
+			return nil, err
+		}
+
+		rtn = append(rtn, typ)
+		sig = rest
+	}
+
+	return rtn, nil
+}
+
+// alignment returns the alignment of values of the type starting sig.
+func alignment(sig string) int {
+	switch sig[0] {
+	case 'y', 'g', 'v':
+		return 1
+	case 'n', 'q':
+		return 2
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	default:
+		return 4
+	}
+}
+
+// encoder marshals values in little endian byte order.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+// encodeAll marshals values according to sig, one value per complete type.
+func (e *encoder) encodeAll(sig string, values []any) error {
+	typs, err := types(sig)
+	if err != nil {
+		return err
+	}
+
+	if len(typs) != len(values) {
+		return fmt.Errorf("dbus: signature %q needs %d values, got %d", sig, len(typs), len(values))
+	}
+
+	for i, typ := range typs {
+		if err := e.encode(typ, values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encode marshals v as the complete type sig.
+func (e *encoder) encode(sig string, v any) error {
+	mismatch := fmt.Errorf("dbus: cannot encode %T as %q", v, sig)
+
+	switch sig[0] {
+	case 'y':
+		b, ok := v.(byte)
+		if !ok {
+			return mismatch
+		}
+		e.buf = append(e.buf, b)
+	case 'b':
+		b, ok := v.(bool)
+		if !ok {
+			return mismatch
+		}
+		var u uint32
+		if b {
+			u = 1
+		}
+		e.uint32(u)
+	case 'n', 'q':
+		var u uint16
+		switch n := v.(type) {
+		case int16:
+			u = uint16(n)
+		case uint16:
+			u = n
+		default:
+			return mismatch
+		}
+		e.align(2)
+		e.buf = binary.LittleEndian.AppendUint16(e.buf, u)
+	case 'i', 'u', 'h':
+		var u uint32
+		switch n := v.(type) {
+		case int32:
+			u = uint32(n)
+		case uint32:
+			u = n
+		case int:
+			u = uint32(n)
+		default:
+			return mismatch
+		}
+		e.uint32(u)
+	case 'x', 't', 'd':
+		var u uint64
+		switch n := v.(type) {
+		case int64:
+			u = uint64(n)
+		case uint64:
+			u = n
+		case float64:
+			u = math.Float64bits(n)
+		default:
+			return mismatch
+		}
+		e.align(8)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, u)
+	case 's', 'o':
+		s, ok := stringValue(v)
+		if !ok {
+			return mismatch
+		}
+		e.uint32(uint32(len(s)))
+		e.buf = append(append(e.buf, s...), 0)
+	case 'g':
+		s, ok := stringValue(v)
+		if !ok || len(s) > 255 {
+			return mismatch
+		}
+		e.buf = append(append(append(e.buf, byte(len(s))), s...), 0)
+	case 'v':
+		variant, ok := v.(Variant)
+		if !ok {
+			return mismatch
+		}
+		if _, rest, err := split(string(variant.Sig)); err != nil || rest != "" {
+			return errSignature
+		}
+		if err := e.encode("g", variant.Sig); err != nil {
+			return err
+		}
+		return e.encode(string(variant.Sig), variant.Value)
+	case 'a':
+		return e.encodeArray(sig[1:], v, mismatch)
+	case '(':
+		fields, ok := v.([]any)
+		if !ok {
+			return mismatch
+		}
+		e.align(8)
+		return e.encodeAll(sig[1:len(sig)-1], fields)
+	default:
+		return errSignature
+	}
+
+	return nil
+}
+
+// encodeArray marshals the slice or map v as an array of elem.
+func (e *encoder) encodeArray(elem string, v any, mismatch error) error {
+	e.uint32(0)
+	at := len(e.buf) - 4
+
+	// Padding to the first element is not part of the array length.
+	e.align(alignment(elem))
+	start := len(e.buf)
+
+	rv := reflect.ValueOf(v)
+
+	switch {
+	case elem[0] == '{' && rv.Kind() == reflect.Map:
+		key, value, err := split(elem[1 : len(elem)-1])
+		if err != nil {
+			return err
+		}
+
+		keys := rv.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+
+		for _, k := range keys {
+			e.align(8)
+			if err := e.encode(key, k.Interface()); err != nil {
+				return err
+			}
+			if err := e.encode(value, rv.MapIndex(k).Interface()); err != nil {
+				return err
+			}
+		}
+	case elem[0] != '{' && (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array):
+		for i := range rv.Len() {
+			if err := e.encode(elem, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	case v == nil:
+	default:
+		return mismatch
+	}
+
+	binary.LittleEndian.PutUint32(e.buf[at:], uint32(len(e.buf)-start))
+	return nil
+}
+
+func compare(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// stringValue returns v if it is one of the string types.
+func stringValue(v any) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case ObjectPath:
+		return string(s), true
+	case Signature:
+		return string(s), true
+	}
+	return "", false
+}
+
+var errTruncated = errors.New("dbus: truncated message")
+
+// decoder unmarshals values.
+type decoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+	depth int
+}
+
+func (d *decoder) align(n int) error {
+	for d.pos%n != 0 {
+		d.pos++
+	}
+	if d.pos > len(d.buf) {
+		return errTruncated
+	}
+	return nil
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.buf)-d.pos < n {
+		return nil, errTruncated
+	}
+	rtn := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return rtn, nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	if err := d.align(4); err != nil {
+		return 0, err
+	}
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint32(b), nil
+}
+
+// decodeAll unmarshals a value per complete type of sig.
+func (d *decoder) decodeAll(sig string) ([]any, error) {
+	typs, err := types(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	rtn := make([]any, len(typs))
+	for i, typ := range typs {
+		if rtn[i], err = d.decode(typ); err != nil {
+			return nil, err
+		}
+	}
+
+	return rtn, nil
+}
+
+// decode unmarshals a value of the complete type sig.
+func (d *decoder) decode(sig string) (any, error) {
+	// The specification limits nesting to 64 levels.
+	if d.depth++; d.depth > 64 {
+		return nil, errSignature
+	}
+	defer func() { d.depth-- }()
+
+	switch sig[0] {
+	case 'y':
+		b, err := d.read(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		u, err := d.uint32()
+		return u != 0, err
+	case 'n', 'q':
+		if err := d.align(2); err != nil {
+			return nil, err
+		}
+		b, err := d.read(2)
+		if err != nil {
+			return nil, err
+		}
+		if sig[0] == 'n' {
+			return int16(d.order.Uint16(b)), nil
+		}
+		return d.order.Uint16(b), nil
+	case 'i':
+		u, err := d.uint32()
+		return int32(u), err
+	case 'u', 'h':
+		return d.uint32()
+	case 'x', 't', 'd':
+		if err := d.align(8); err != nil {
+			return nil, err
+		}
+		b, err := d.read(8)
+		if err != nil {
+			return nil, err
+		}
+		u := d.order.Uint64(b)
+		switch sig[0] {
+		case 'x':
+			return int64(u), nil
+		case 'd':
+			return math.Float64frombits(u), nil
+		}
+		return u, nil
+	case 's', 'o':
+		n, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(int(n) + 1)
+		if err != nil {
+			return nil, err
+		}
+		if sig[0] == 'o' {
+			return ObjectPath(b[:n]), nil
+		}
+		return string(b[:n]), nil
+	case 'g':
+		n, err := d.read(1)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(int(n[0]) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Signature(b[:n[0]]), nil
+	case 'v':
+		s, err := d.decode("g")
+		if err != nil {
+			return nil, err
+		}
+		sig := string(s.(Signature))
+		if _, rest, err := split(sig); err != nil || rest != "" {
+			return nil, errSignature
+		}
+		value, err := d.decode(sig)
+		return Variant{Sig: Signature(sig), Value: value}, err
+	case 'a':
+		return d.decodeArray(sig[1:])
+	case '(':
+		if err := d.align(8); err != nil {
+			return nil, err
+		}
+		return d.decodeAll(sig[1 : len(sig)-1])
+	}
+
+	return nil, errSignature
+}
+
+// decodeArray unmarshals an array of elem.
+func (d *decoder) decodeArray(elem string) (any, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.align(alignment(elem)); err != nil {
+		return nil, err
+	}
+
+	body, err := d.read(int(n))
+	if err != nil {
+		return nil, err
+	}
+
+	if elem == "y" {
+		return slices.Clone(body), nil
+	}
+
+	end := d.pos
+	d.pos -= len(body)
+
+	if elem[0] == '{' {
+		key, value, err := split(elem[1 : len(elem)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		rtn := map[string]any{}
+		for d.pos < end {
+			if err := d.align(8); err != nil {
+				return nil, err
+			}
+
+			k, err := d.decode(key)
+			if err != nil {
+				return nil, err
+			}
+
+			ks, ok := stringValue(k)
+			if !ok {
+				ks = fmt.Sprint(k)
+			}
+
+			if rtn[ks], err = d.decode(value); err != nil {
+				return nil, err
+			}
+		}
+		return rtn, nil
+	}
+
+	var rtn []any
+	for d.pos < end {
+		v, err := d.decode(elem)
+		if err != nil {
+			return nil, err
+		}
+		rtn = append(rtn, v)
+	}
+
+	if d.pos != end {
+		return nil, errTruncated
+	}
+	return rtn, nil
+}
@@ -0,0 +1,57 @@
+// Package goid tells the goroutine of an event loop from the others, for the
+// event loops that run in Go, the fake one of package saucertest and those of
+// package remote.
+//
+// Go has no goroutine local state, so the id of a goroutine is parsed from
+// its stack trace. A Loop is marked idle while it waits for work, and callers
+// only pay for the trace while the loop runs a function.
+package goid
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// Loop is the goroutine of an event loop.
+type Loop struct {
+	id uint64
+	// idle is set while the loop waits, when no caller can be on it.
+	idle atomic.Bool
+}
+
+// New returns the Loop of the calling goroutine.
+func New() *Loop {
+	return &Loop{id: id()}
+}
+
+// On reports whether the caller runs on the loop.
+func (l *Loop) On() bool {
+	return !l.idle.Load() && id() == l.id
+}
+
+// Idle marks the loop as waiting for work, or as running again. Only the
+// goroutine of the loop calls it, right before and after it blocks.
+func (l *Loop) Idle(idle bool) {
+	l.idle.Store(idle)
+}
+
+// id returns the id of the calling goroutine.
+func id() uint64 {
+	var buf [64]byte
+	trace := buf[:runtime.Stack(buf[:], false)]
+
+	// The trace starts with "goroutine 1 [running]:"
+	fields := bytes.Fields(trace)
+	if len(fields) < 2 {
+		panic(fmt.Sprintf("goid: unexpected stack %q", trace))
+	}
+
+	n, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("goid: unexpected stack %q", trace))
+	}
+	return n
+}
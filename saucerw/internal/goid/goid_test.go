@@ -0,0 +1,44 @@
+package goid
+
+import "testing"
+
+func TestLoop(t *testing.T) {
+	l := New()
+	if !l.On() {
+		t.Error("goroutine of the loop not on it")
+	}
+
+	other := make(chan bool)
+	go func() { other <- l.On() }()
+	if <-other {
+		t.Error("other goroutine on the loop")
+	}
+
+	// An idle loop has no caller on it
+	l.Idle(true)
+	go func() { other <- l.On() }()
+	if <-other {
+		t.Error("other goroutine on the idle loop")
+	}
+	l.Idle(false)
+
+	if !l.On() {
+		t.Error("goroutine of the loop not on it after waiting")
+	}
+}
+
+func BenchmarkOn(b *testing.B) {
+	l := New()
+	b.Run("running", func(b *testing.B) {
+		for range b.N {
+			l.On()
+		}
+	})
+	b.Run("idle", func(b *testing.B) {
+		l.Idle(true)
+		defer l.Idle(false)
+		for range b.N {
+			l.On()
+		}
+	})
+}
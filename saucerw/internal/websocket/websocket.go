@@ -0,0 +1,342 @@
+// Package websocket implements RFC 6455 without extensions for both ends of
+// a connection: the server of package wire, which pages connect to, and the
+// client of the fetch proxy, which connects the sockets of pages from Go.
+//
+// It covers the opening handshake and the framing. What the endpoints do
+// with the messages, and how they close, is up to them.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// guid is appended to the key of the client to accept it.
+const guid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes of frames.
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xa
+)
+
+// Status codes of close frames.
+const (
+	CloseNormal    = 1000
+	CloseGoingAway = 1001
+	CloseProtocol  = 1002
+	CloseNoStatus  = 1005
+	CloseAbnormal  = 1006
+	CloseTooBig    = 1009
+)
+
+// maxControlFrame is the size limit of the payload of control frames.
+const maxControlFrame = 125
+
+// Accept returns the Sec-WebSocket-Accept of the Sec-WebSocket-Key key.
+func Accept(key string) string {
+	sum := sha1.Sum([]byte(key + guid))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// NewKey returns a random Sec-WebSocket-Key.
+func NewKey() string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	return base64.StdEncoding.EncodeToString(nonce)
+}
+
+// SetRequest sets the headers of the opening handshake of a client with key
+// on req.
+func SetRequest(req *http.Request, key string) {
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+}
+
+// CheckResponse returns an error unless res accepts the opening handshake of
+// a client with key.
+func CheckResponse(res *http.Response, key string) error {
+	switch {
+	case res.StatusCode != http.StatusSwitchingProtocols:
+		return fmt.Errorf("websocket: handshake failed with status %s", res.Status)
+	case !strings.EqualFold(res.Header.Get("Upgrade"), "websocket"),
+		res.Header.Get("Sec-WebSocket-Accept") != Accept(key):
+		return errors.New("websocket: invalid handshake")
+	}
+	return nil
+}
+
+// Upgrade answers the opening handshake of r and returns the server end of
+// the connection, whose messages are limited to max bytes. It answers the
+// request with an error if it is not a handshake.
+func Upgrade(w http.ResponseWriter, r *http.Request, max int64) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+
+	switch {
+	case r.Method != http.MethodGet,
+		!headerContains(r.Header, "Connection", "upgrade"),
+		!headerContains(r.Header, "Upgrade", "websocket"),
+		key == "":
+		http.Error(w, "expected a WebSocket handshake", http.StatusBadRequest)
+		return nil, errors.New("websocket: not a handshake")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "cannot upgrade the connection", http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", Accept(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+
+	return NewConn(conn, rw.Reader, false, max), nil
+}
+
+// headerContains reports whether the comma separated header name contains
+// token.
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for item := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Conn is an end of a connection after the opening handshake. Clients mask
+// the frames they send and servers expect them masked.
+type Conn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	client bool
+	max    int64
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewConn returns the client or server end of the connection conn, read
+// through r, which may hold data buffered during the handshake. Messages are
+// limited to max bytes.
+func NewConn(conn net.Conn, r *bufio.Reader, client bool, max int64) *Conn {
+	return &Conn{conn: conn, r: r, client: client, max: max}
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
+
+// ReadMessage returns the next text or binary message with its opcode,
+// answering pings on the way. A close frame of the peer is returned as
+// OpClose with its payload. A connection dropped without a close frame
+// returns io.EOF, a peer violating the protocol is sent a close frame and
+// reported with an error.
+func (c *Conn) ReadMessage() (byte, []byte, error) {
+	var (
+		op      byte
+		message []byte
+	)
+
+	for {
+		fin, frameOp, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch frameOp {
+		case OpPing:
+			// Pings after the close frame of this end go unanswered
+			if err := c.WriteFrame(OpPong, payload); err != nil && !errors.Is(err, net.ErrClosed) {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			return OpClose, payload, nil
+		case OpText, OpBinary:
+			if op != 0 {
+				return 0, nil, c.fail(CloseProtocol, "unexpected data frame inside a fragmented message")
+			}
+			op = frameOp
+		case OpContinuation:
+			if op == 0 {
+				return 0, nil, c.fail(CloseProtocol, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocol, "unknown opcode")
+		}
+
+		if int64(len(message))+int64(len(payload)) > c.max {
+			return 0, nil, c.fail(CloseTooBig, "message too big")
+		}
+		message = append(message, payload...)
+
+		if fin {
+			return op, message, nil
+		}
+	}
+}
+
+// readFrame reads a frame, unmasking it.
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return false, 0, nil, eof(err)
+	}
+
+	fin, op = head[0]&0x80 != 0, head[0]&0x0f
+	masked := head[1]&0x80 != 0
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocol, "reserved bits set")
+	}
+	if masked == c.client {
+		return false, 0, nil, c.fail(CloseProtocol, "frame masked by the wrong end")
+	}
+
+	size := uint64(head[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, eof(err)
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, eof(err)
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if op >= OpClose && (size > maxControlFrame || !fin) {
+		return false, 0, nil, c.fail(CloseProtocol, "bad control frame")
+	}
+	if size > uint64(c.max) {
+		return false, 0, nil, c.fail(CloseTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, eof(err)
+		}
+	}
+
+	payload = make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, eof(err)
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// WriteFrame sends a frame with the payload, masked by clients. It may be
+// called concurrently and fails with net.ErrClosed once a close frame was
+// sent.
+func (c *Conn) WriteFrame(op byte, payload []byte) error {
+	frame := []byte{0x80 | op}
+
+	var bit byte
+	if c.client {
+		bit = 0x80
+	}
+	switch size := len(payload); {
+	case size < 126:
+		frame = append(frame, bit|byte(size))
+	case size <= 0xffff:
+		frame = binary.BigEndian.AppendUint16(append(frame, bit|126), uint16(size))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, bit|127), uint64(size))
+	}
+
+	start := len(frame)
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start += 4
+
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		return err
+	}
+	if op == OpClose {
+		c.closed = true
+	}
+	return nil
+}
+
+// WriteClose sends a close frame with code and reason, without a payload for
+// CloseNoStatus. Sending it again does nothing.
+func (c *Conn) WriteClose(code uint16, reason string) error {
+	var payload []byte
+	if code != CloseNoStatus && code != 0 {
+		payload = append(binary.BigEndian.AppendUint16(nil, code), reason...)
+	}
+
+	if err := c.WriteFrame(OpClose, payload); !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// fail closes the connection with code and reason after the peer violated
+// the protocol.
+func (c *Conn) fail(code uint16, reason string) error {
+	c.WriteClose(code, reason)
+	return fmt.Errorf("websocket: %s", reason)
+}
+
+// eof reports a connection closed without a close frame as io.EOF.
+func eof(err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return io.EOF
+	}
+	return err
+}
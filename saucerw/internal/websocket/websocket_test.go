@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandshake(t *testing.T) {
+	server := make(chan *Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := Upgrade(w, r, 1<<10)
+		if err != nil {
+			t.Error(err)
+		}
+		server <- ws
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	key := NewKey()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	SetRequest(req, key)
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckResponse(res, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckResponse(res, NewKey()); err == nil {
+		t.Error("accepted the response to another key")
+	}
+
+	client, ws := NewConn(conn, r, true, 1<<10), <-server
+	if ws == nil {
+		t.FailNow()
+	}
+	defer ws.NetConn().Close()
+
+	if err := client.WriteFrame(OpText, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if op, msg, err := ws.ReadMessage(); err != nil || op != OpText || string(msg) != "hello" {
+		t.Fatalf("read %d %q, %v", op, msg, err)
+	}
+
+	if err := ws.WriteClose(CloseNormal, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.WriteFrame(OpText, nil); err != net.ErrClosed {
+		t.Errorf("write after close: %v", err)
+	}
+	if op, msg, err := client.ReadMessage(); err != nil || op != OpClose || string(msg[2:]) != "bye" {
+		t.Fatalf("read %d %q, %v", op, msg, err)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames [][]byte
+		want   string
+		err    string
+	}{
+		{"fragmented", [][]byte{frame(OpText, false, "hel"), frame(OpPing, true, "p"), frame(OpContinuation, true, "lo")}, "hello", ""},
+		{"unmasked", [][]byte{{0x81, 0x01, 'a'}}, "", "masked by the wrong end"},
+		{"too big", [][]byte{frame(OpBinary, true, strings.Repeat("x", 17))}, "", "message too big"},
+		{"too big fragmented", [][]byte{frame(OpBinary, false, strings.Repeat("x", 9)), frame(OpContinuation, true, strings.Repeat("x", 9))}, "", "message too big"},
+		{"fragmented control", [][]byte{frame(OpPing, false, "")}, "", "bad control frame"},
+		{"lone continuation", [][]byte{frame(OpContinuation, true, "a")}, "", "unexpected continuation frame"},
+		{"reserved bits", [][]byte{append([]byte{0xc1}, frame(OpText, true, "a")[1:]...)}, "", "reserved bits set"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			ws := NewConn(&bufferConn{buf: &out}, bufio.NewReader(bytes.NewReader(bytes.Join(test.frames, nil))), false, 16)
+
+			_, msg, err := ws.ReadMessage()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error %v, expected %q", err, test.err)
+				}
+				if out.Len() == 0 || out.Bytes()[0] != 0x80|OpClose {
+					t.Error("violation not answered with a close frame")
+				}
+				return
+			}
+			if err != nil || string(msg) != test.want {
+				t.Fatalf("read %q, %v", msg, err)
+			}
+		})
+	}
+}
+
+// frame returns a masked frame as clients send it.
+func frame(op byte, fin bool, payload string) []byte {
+	head := op
+	if fin {
+		head |= 0x80
+	}
+	mask := []byte{1, 2, 3, 4}
+	out := append([]byte{head, 0x80 | byte(len(payload))}, mask...)
+	for i := range len(payload) {
+		out = append(out, payload[i]^mask[i%4])
+	}
+	return out
+}
+
+// bufferConn is a connection writing to a buffer.
+type bufferConn struct {
+	net.Conn
+	buf *bytes.Buffer
+}
+
+func (c *bufferConn) Write(p []byte) (int, error) {
+	return c.buf.Write(p)
+}
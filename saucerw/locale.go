@@ -0,0 +1,82 @@
+package saucerw
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LocalesEnv is the environment variable overriding the locales of the
+// system with comma separated BCP 47 tags, e.g. "de-DE,en" to try a
+// translation. It applies to SystemLocales and to the webviews without
+// Preferences.Locales.
+const LocalesEnv = "SAUCER_LOCALES"
+
+// localeTag matches the BCP 47 tags accepted for Preferences.Locales.
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// localeScript makes navigator.language and navigator.languages report the
+// locales of the webview, which the backends otherwise take from the system.
+const localeScript = `
+(() =>
+{
+    const languages = Object.freeze(%s);
+
+    Object.defineProperty(Navigator.prototype, "languages", { get: () => languages, configurable: true });
+    Object.defineProperty(Navigator.prototype, "language", { get: () => languages[0], configurable: true });
+})();
+`
+
+// SystemLocales returns the languages the user prefers as BCP 47 tags in
+// order of preference, e.g. "de-DE", for translating native menus and
+// formatting in Go like the pages. LocalesEnv overrides them.
+func (a *Application) SystemLocales() []string {
+	if locales := splitLocales(os.Getenv(LocalesEnv)); len(locales) > 0 {
+		return locales
+	}
+	return a.native.Locales()
+}
+
+// OnLocaleChange registers fn, called with the new locales when the user
+// changed the languages of the system. Only Windows and macOS report changes,
+// processes on Linux keep the locale of their environment. Webviews keep the
+// locales they were created with.
+func (a *Application) OnLocaleChange(fn func([]string)) *Subscription {
+	return a.locales.subscribe(fn)
+}
+
+// locales returns the locales of a webview, from LocalesEnv if
+// Preferences.Locales is empty.
+func (p *Preferences) locales() ([]string, error) {
+	locales := p.Locales
+	if len(locales) == 0 {
+		locales = splitLocales(os.Getenv(LocalesEnv))
+	}
+
+	for _, tag := range slices.Concat(locales, p.SpellCheckLanguages) {
+		if !localeTag.MatchString(tag) {
+			return nil, fmt.Errorf("saucerw: bad locale %q, expected a BCP 47 tag like de-DE", tag)
+		}
+	}
+	return locales, nil
+}
+
+// localesScript returns the script reporting locales to the pages.
+func localesScript(locales []string) string {
+	data, _ := json.Marshal(locales)
+	return fmt.Sprintf(localeScript, data)
+}
+
+// splitLocales splits a comma separated list of locales, nil if it is empty.
+func splitLocales(list string) []string {
+	var rtn []string
+	for tag := range strings.SplitSeq(list, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			rtn = append(rtn, tag)
+		}
+	}
+	return rtn
+}
@@ -0,0 +1,43 @@
+//go:build darwin && cgo && saucer
+
+#import <Foundation/Foundation.h>
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "native.h"
+
+char *saucerw_cocoa_locales(void)
+{
+    return strdup([NSLocale.preferredLanguages componentsJoinedByString:@","].UTF8String);
+}
+
+void *saucerw_cocoa_watch_locales(uintptr_t handler)
+{
+    __block NSString *last = [NSLocale.preferredLanguages componentsJoinedByString:@","];
+
+    // Posted for changes of the region too, which leave the languages alone
+    id observer = [NSNotificationCenter.defaultCenter
+        addObserverForName:NSCurrentLocaleDidChangeNotification
+                    object:nil
+                     queue:NSOperationQueue.mainQueue
+                usingBlock:^(NSNotification *notification) {
+                  NSString *current = [NSLocale.preferredLanguages componentsJoinedByString:@","];
+
+                  if ([current isEqualToString:last])
+                  {
+                      return;
+                  }
+
+                  last = current;
+                  saucerwLocales(handler, (char *)current.UTF8String);
+                }];
+
+    return (__bridge_retained void *)observer;
+}
+
+void saucerw_cocoa_unwatch_locales(void *watcher)
+{
+    id observer = (__bridge_transfer id)watcher;
+    [NSNotificationCenter.defaultCenter removeObserver:observer];
+}
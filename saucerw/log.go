@@ -0,0 +1,26 @@
+package saucerw
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger routes the log output of saucer and its backend, such as GTK or
+// Qt warnings, and of the bindings to l. Records carry a "component"
+// attribute naming their origin. A nil logger restores slog.Default.
+//
+// Native output is captured once the first Application is created and goes
+// to stderr unformatted before.
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// log returns the logger set with SetLogger.
+func log() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
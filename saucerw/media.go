@@ -0,0 +1,170 @@
+package saucerw
+
+import "fmt"
+
+// AutoplayPolicy selects the media a page may play before the user
+// interacted with it.
+type AutoplayPolicy uint8
+
+const (
+	// AutoplayDefault keeps the policy of the backend, which differs between
+	// them.
+	AutoplayDefault AutoplayPolicy = iota
+	// AutoplayAllow plays all media without a user gesture.
+	AutoplayAllow
+	// AutoplayMuted plays muted media only.
+	AutoplayMuted
+	// AutoplayBlock plays no media before the user interacted with the page.
+	AutoplayBlock
+)
+
+// mediaScript enforces the autoplay policy and background suspension in the
+// page the same way on all backends, rejecting play() and pausing media
+// started by the autoplay attribute until the user clicked or typed. It also
+// mutes the media elements for the backends that cannot mute the page.
+const mediaScript = `
+(() =>
+{
+    // Whether no media or only muted media plays before the user interacted
+    const block     = %t;
+    const mutedOnly = %t;
+    const suspend   = %t;
+
+    if (window["saucer:media"])
+    {
+        return;
+    }
+
+    const media = window["saucer:media"] = { muted: false, forced: new WeakSet(), suspended: new Set() };
+    let activated = false;
+
+    for (const type of ["pointerdown", "keydown", "touchend"])
+    {
+        window.addEventListener(type, (e) => { activated ||= e.isTrusted; }, { capture: true, passive: true });
+    }
+
+    const elements = () => document.querySelectorAll("audio, video");
+    const blocked  = (element) => !activated && (block || (mutedOnly && !element.muted));
+
+    media.mute = (muted) =>
+    {
+        media.muted = muted;
+
+        for (const element of elements())
+        {
+            if (muted && !element.muted)
+            {
+                media.forced.add(element);
+                element.muted = true;
+            }
+            else if (!muted && media.forced.has(element))
+            {
+                media.forced.delete(element);
+                element.muted = false;
+            }
+        }
+    };
+
+    media.suspend = (hidden) =>
+    {
+        if (!suspend)
+        {
+            return;
+        }
+
+        if (hidden)
+        {
+            for (const element of elements())
+            {
+                if (!element.paused)
+                {
+                    media.suspended.add(element);
+                    element.pause();
+                }
+            }
+            return;
+        }
+
+        for (const element of media.suspended)
+        {
+            element.play().catch(() => {});
+        }
+        media.suspended.clear();
+    };
+
+    if (block || mutedOnly)
+    {
+        const play = HTMLMediaElement.prototype.play;
+
+        HTMLMediaElement.prototype.play = function ()
+        {
+            if (blocked(this))
+            {
+                return Promise.reject(new DOMException("play() needs the user to interact with the page first", "NotAllowedError"));
+            }
+
+            return play.call(this);
+        };
+    }
+
+    // Media started by the autoplay attribute does not call play()
+    document.addEventListener("play", (e) =>
+    {
+        const element = e.target;
+
+        if (!(element instanceof HTMLMediaElement))
+        {
+            return;
+        }
+
+        if (media.muted && !element.muted)
+        {
+            media.forced.add(element);
+            element.muted = true;
+        }
+
+        if (blocked(element))
+        {
+            element.pause();
+        }
+    }, true);
+
+    document.addEventListener("visibilitychange", () => media.suspend(document.hidden));
+})();
+`
+
+// mediaScript returns the media script of the preferences.
+func (p *Preferences) mediaScript() string {
+	return fmt.Sprintf(mediaScript, p.Autoplay == AutoplayBlock, p.Autoplay == AutoplayMuted, p.SuspendBackgroundMedia)
+}
+
+// Muted reports whether the audio of the webview is muted.
+func (v *Webview) Muted() bool {
+	return v.native.Muted()
+}
+
+// SetMuted mutes or unmutes the audio of the webview, e.g. for a mute button
+// next to a tab. WebKit on macOS, which cannot mute a page, mutes its audio
+// and video elements instead, leaving audio played through Web Audio alone.
+func (v *Webview) SetMuted(muted bool) {
+	v.native.SetMuted(muted)
+}
+
+// OnPlayingAudio registers fn, called when the page started or stopped
+// playing audio, e.g. to show a speaker icon. WebKit on macOS does not report
+// it.
+func (v *Webview) OnPlayingAudio(fn func(playing bool)) *Subscription {
+	return v.events.subscribe(func(ev WebviewEvent) {
+		if ev.Type == WebviewPlayingAudio {
+			fn(ev.Playing)
+		}
+	})
+}
+
+// suspendMedia pauses the media of the page while its window is minimized,
+// which not all backends report as hidden to the page.
+func (v *Webview) suspendMedia() {
+	v.window.OnMinimize(func(minimized bool) {
+		v.Execute(fmt.Sprintf(`window["saucer:media"]?.suspend(%t)`, minimized))
+	})
+}
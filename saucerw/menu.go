@@ -0,0 +1,490 @@
+package saucerw
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Menu is a menu of the menu bar, e.g. "File" or "Edit".
+type Menu struct {
+	Label string
+	Items []MenuItem
+}
+
+// MenuItem is an entry of a Menu.
+type MenuItem struct {
+	// Label is the text of the item. Role items default to the label of the
+	// platform.
+	Label string
+	// Role makes the item perform a standard action instead of, or before,
+	// calling OnClick.
+	Role Role
+	// Accelerator is the keyboard shortcut of the item, e.g. "Ctrl+Shift+P"
+	// or "CmdOrCtrl+Q", see ParseAccelerator. Role items default to the
+	// shortcut of the platform.
+	Accelerator string
+	// Disabled greys the item out.
+	Disabled bool
+	// Checkable items show a check mark and toggle Checked when clicked.
+	Checkable bool
+	// Checked is the state of a checkable item.
+	Checked bool
+	// Separator turns the item into a separator line; the other fields are
+	// ignored.
+	Separator bool
+	// Submenu, if non-empty, opens when the item is hovered.
+	Submenu []MenuItem
+	// OnClick is called when the item is clicked or its accelerator pressed,
+	// with the state after the click.
+	OnClick func(MenuItem)
+}
+
+// Role is a standard menu action implemented by the bindings or the
+// platform.
+type Role uint8
+
+const (
+	// RoleNone is a plain item.
+	RoleNone Role = iota
+	// RoleQuit quits the application.
+	RoleQuit
+	// RoleClose closes the window.
+	RoleClose
+	// RoleMinimize minimizes the window.
+	RoleMinimize
+	// RoleFullscreen toggles fullscreen mode.
+	RoleFullscreen
+	// RoleReload reloads the webviews of the window.
+	RoleReload
+	// RoleDevTools toggles the developer tools of the webviews.
+	RoleDevTools
+	// RoleUndo and the following editing roles act on the page of the
+	// first webview of the window.
+	RoleUndo
+	RoleRedo
+	RoleCut
+	RoleCopy
+	RolePaste
+	RoleSelectAll
+	// RoleAbout shows the standard about panel. Only on macOS, elsewhere the
+	// item is left out.
+	RoleAbout
+	// RoleHide hides the application. Only on macOS.
+	RoleHide
+	// RoleHideOthers hides the other applications. Only on macOS.
+	RoleHideOthers
+	// RoleShowAll shows all applications. Only on macOS.
+	RoleShowAll
+)
+
+// roleDefaults are the labels and accelerators of the roles.
+var roleDefaults = map[Role]struct{ label, accelerator string }{
+	RoleQuit:       {"Quit", "CmdOrCtrl+Q"},
+	RoleClose:      {"Close Window", "CmdOrCtrl+W"},
+	RoleMinimize:   {"Minimize", "CmdOrCtrl+M"},
+	RoleFullscreen: {"Toggle Full Screen", "F11"},
+	RoleReload:     {"Reload", "CmdOrCtrl+R"},
+	RoleDevTools:   {"Toggle Developer Tools", "CmdOrCtrl+Alt+I"},
+	RoleUndo:       {"Undo", "CmdOrCtrl+Z"},
+	RoleRedo:       {"Redo", "CmdOrCtrl+Shift+Z"},
+	RoleCut:        {"Cut", "CmdOrCtrl+X"},
+	RoleCopy:       {"Copy", "CmdOrCtrl+C"},
+	RolePaste:      {"Paste", "CmdOrCtrl+V"},
+	RoleSelectAll:  {"Select All", "CmdOrCtrl+A"},
+	RoleAbout:      {"About", ""},
+	RoleHide:       {"Hide", "Cmd+H"},
+	RoleHideOthers: {"Hide Others", "Cmd+Alt+H"},
+	RoleShowAll:    {"Show All", ""},
+}
+
+// editing reports whether the role edits the page. Their accelerators are
+// left to the browser engine, which handles them natively.
+func (r Role) editing() bool {
+	return r >= RoleUndo && r <= RoleSelectAll
+}
+
+// escapes reports whether the role would let the user leave kiosk mode.
+func (r Role) escapes() bool {
+	switch r {
+	case RoleQuit, RoleClose, RoleMinimize, RoleFullscreen, RoleDevTools:
+		return true
+	}
+	return false
+}
+
+// macOnly reports whether the role only exists on macOS.
+func (r Role) macOnly() bool {
+	return r >= RoleAbout
+}
+
+// Modifier is a modifier key of an Accelerator.
+type Modifier uint8
+
+const (
+	// ModCtrl is the Control key.
+	ModCtrl Modifier = 1 << iota
+	// ModShift is the Shift key.
+	ModShift
+	// ModAlt is the Alt key, Option on macOS.
+	ModAlt
+	// ModSuper is the Command key on macOS and the Windows or Super key
+	// elsewhere.
+	ModSuper
+)
+
+// Accelerator is a parsed keyboard shortcut.
+type Accelerator struct {
+	Modifiers Modifier
+	// Key is an uppercase letter, a digit, a punctuation character or one of
+	// F1 to F24, Enter, Escape, Tab, Space, Backspace, Delete, Insert, Home,
+	// End, PageUp, PageDown, Up, Down, Left, Right, Plus and Minus.
+	Key string
+}
+
+var modifierNames = map[string]Modifier{
+	"ctrl":    ModCtrl,
+	"control": ModCtrl,
+	"shift":   ModShift,
+	"alt":     ModAlt,
+	"option":  ModAlt,
+	"super":   ModSuper,
+	"meta":    ModSuper,
+	"cmd":     ModSuper,
+	"command": ModSuper,
+}
+
+var keyNames = []string{
+	"Enter", "Escape", "Tab", "Space", "Backspace", "Delete", "Insert", "Home", "End",
+	"PageUp", "PageDown", "Up", "Down", "Left", "Right", "Plus", "Minus",
+}
+
+// ParseAccelerator parses a shortcut such as "Ctrl+Shift+P": modifiers and a
+// key joined by "+", case-insensitively. The modifiers are Ctrl, Shift, Alt
+// (Option), Super (Cmd, Meta) and CmdOrCtrl, which is Cmd on macOS and Ctrl
+// elsewhere.
+func ParseAccelerator(s string) (Accelerator, error) {
+	var rtn Accelerator
+
+	parts := strings.Split(s, "+")
+
+	// "Ctrl++" ends with the plus key.
+	if strings.HasSuffix(s, "++") {
+		parts = append(parts[:len(parts)-2], "Plus")
+	}
+
+	for i, part := range parts {
+		name := strings.ToLower(strings.TrimSpace(part))
+
+		if i < len(parts)-1 {
+			switch mod, ok := modifierNames[name]; {
+			case ok:
+				rtn.Modifiers |= mod
+			case name == "cmdorctrl" || name == "commandorcontrol":
+				rtn.Modifiers |= cmdOrCtrl()
+			default:
+				return Accelerator{}, fmt.Errorf("saucerw: accelerator %q: unknown modifier %q", s, part)
+			}
+			continue
+		}
+
+		key, err := parseKey(strings.TrimSpace(part))
+		if err != nil {
+			return Accelerator{}, fmt.Errorf("saucerw: accelerator %q: %w", s, err)
+		}
+		rtn.Key = key
+	}
+
+	return rtn, nil
+}
+
+// cmdOrCtrl returns the primary modifier of the platform.
+func cmdOrCtrl() Modifier {
+	if runtime.GOOS == "darwin" {
+		return ModSuper
+	}
+	return ModCtrl
+}
+
+// parseKey returns the canonical name of key.
+func parseKey(key string) (string, error) {
+	if len(key) == 1 && key[0] > ' ' && key[0] < 0x7f {
+		return strings.ToUpper(key), nil
+	}
+
+	for _, name := range keyNames {
+		if strings.EqualFold(key, name) {
+			return name, nil
+		}
+	}
+
+	switch {
+	case strings.EqualFold(key, "return"):
+		return "Enter", nil
+	case strings.EqualFold(key, "esc"):
+		return "Escape", nil
+	case strings.EqualFold(key, "del"):
+		return "Delete", nil
+	}
+
+	if len(key) > 1 && (key[0] == 'F' || key[0] == 'f') && key[1] >= '1' && key[1] <= '9' {
+		if n, err := strconv.Atoi(key[1:]); err == nil && n >= 1 && n <= 24 {
+			return "F" + key[1:], nil
+		}
+	}
+
+	if key == "" {
+		return "", errors.New("missing key")
+	}
+	return "", fmt.Errorf("unknown key %q", key)
+}
+
+// String formats a in the syntax accepted by ParseAccelerator.
+func (a Accelerator) String() string {
+	var parts []string
+
+	for _, mod := range []struct {
+		mod  Modifier
+		name string
+	}{{ModCtrl, "Ctrl"}, {ModAlt, "Alt"}, {ModShift, "Shift"}, {ModSuper, "Super"}} {
+		if a.Modifiers&mod.mod != 0 {
+			parts = append(parts, mod.name)
+		}
+	}
+
+	return strings.Join(append(parts, a.Key), "+")
+}
+
+// MenuEntry is a menu item as passed to WindowDriver.SetMenu. Entries are
+// listed parents first; the menus of the bar have parent 0.
+type MenuEntry struct {
+	ID        int32
+	Parent    int32
+	Label     string
+	Role      Role
+	Disabled  bool
+	Checkable bool
+	Checked   bool
+	Separator bool
+	// Accelerator is the shortcut of the item, if any.
+	Accelerator *Accelerator
+	// Passthrough leaves the shortcut to the page, the native menu only
+	// displays it.
+	Passthrough bool
+}
+
+// menuState is the menu bar of a window.
+type menuState struct {
+	menus []Menu
+	items map[int32]*MenuItem
+}
+
+// SetMenu replaces the menu bar of the window; nil removes it. On macOS the
+// menu bar belongs to the application: it shows the menu of the focused
+// window, the first Menu becoming the application menu.
+//
+// Accelerators trigger their items while the window is focused. Those of
+// the editing roles are left to the page, so the browser engine keeps
+// handling copy and paste itself.
+func (w *Window) SetMenu(menus []Menu) error {
+	menus = slices.Clone(menus)
+	for i := range menus {
+		menus[i].Items = cloneMenuItems(menus[i].Items)
+	}
+
+	state := &menuState{menus: menus, items: map[int32]*MenuItem{}}
+
+	entries, err := state.entries()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.menu = state
+	w.mu.Unlock()
+
+	w.native.SetMenu(entries)
+	return nil
+}
+
+// cloneMenuItems deep copies items, so they can be updated in place.
+func cloneMenuItems(items []MenuItem) []MenuItem {
+	rtn := slices.Clone(items)
+	for i := range rtn {
+		rtn[i].Submenu = cloneMenuItems(rtn[i].Submenu)
+	}
+	return rtn
+}
+
+// entries numbers the items and flattens them for the driver.
+func (s *menuState) entries() ([]MenuEntry, error) {
+	var rtn []MenuEntry
+	var next int32
+
+	for _, menu := range s.menus {
+		next++
+		rtn = append(rtn, MenuEntry{ID: next, Label: menu.Label})
+
+		var err error
+		if rtn, err = s.flatten(rtn, &next, next, menu.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	return rtn, nil
+}
+
+// flatten appends items to rtn as children of parent, numbering them after
+// next.
+func (s *menuState) flatten(rtn []MenuEntry, next *int32, parent int32, items []MenuItem) ([]MenuEntry, error) {
+	for i := range items {
+		item := &items[i]
+
+		if item.Role.macOnly() && runtime.GOOS != "darwin" {
+			continue
+		}
+
+		*next++
+		id := *next
+		s.items[id] = item
+
+		entry := MenuEntry{
+			ID:        id,
+			Parent:    parent,
+			Label:     item.Label,
+			Role:      item.Role,
+			Disabled:  item.Disabled,
+			Checkable: item.Checkable,
+			Checked:   item.Checked,
+			Separator: item.Separator,
+		}
+
+		defaults := roleDefaults[item.Role]
+		if entry.Label == "" {
+			entry.Label = defaults.label
+		}
+
+		accelerator := item.Accelerator
+		if accelerator == "" {
+			accelerator = defaults.accelerator
+		}
+
+		if accelerator != "" && !item.Separator {
+			parsed, err := ParseAccelerator(accelerator)
+			if err != nil {
+				return nil, err
+			}
+
+			entry.Accelerator = &parsed
+			entry.Passthrough = item.Role.editing()
+		}
+
+		rtn = append(rtn, entry)
+
+		var err error
+		if rtn, err = s.flatten(rtn, next, id, item.Submenu); err != nil {
+			return nil, err
+		}
+	}
+
+	return rtn, nil
+}
+
+// menuClick is a clicked menu item with its handler.
+type menuClick struct {
+	fn   func(MenuItem)
+	item MenuItem
+}
+
+// click toggles the item id and returns it with its handler, ok being false if
+// it cannot be clicked. The caller holds the lock guarding s.
+func (s *menuState) click(id int32) (_ menuClick, ok bool) {
+	item := s.items[id]
+	if item == nil || item.Disabled || item.Separator {
+		return menuClick{}, false
+	}
+
+	if item.Checkable {
+		item.Checked = !item.Checked
+	}
+
+	click := menuClick{fn: item.OnClick, item: *item}
+	click.item.Submenu = cloneMenuItems(item.Submenu)
+
+	return click, true
+}
+
+// activateMenu handles a click on the menu entry id. It runs on the event
+// loop thread.
+func (w *Window) activateMenu(id int32) {
+	w.mu.Lock()
+
+	state := w.menu
+	if state == nil {
+		w.mu.Unlock()
+		return
+	}
+
+	click, ok := state.click(id)
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+
+	webviews := slices.Clone(w.webviews)
+	w.mu.Unlock()
+
+	if click.item.Checkable {
+		// Rebuild the menu once the native callback returned.
+		w.app.Post(func() {
+			w.mu.Lock()
+			current := w.menu == state
+			w.mu.Unlock()
+
+			if current {
+				entries, _ := state.entries()
+				w.native.SetMenu(entries)
+			}
+		})
+	}
+
+	w.performRole(click.item.Role, webviews)
+
+	if click.fn != nil {
+		w.clicks.emit(click)
+	}
+}
+
+// performRole runs the standard action of role. The macOS roles and, on
+// macOS, the editing roles are performed by the platform.
+func (w *Window) performRole(role Role, webviews []*Webview) {
+	if w.kiosk.Load() && role.escapes() {
+		return
+	}
+
+	switch {
+	case role == RoleQuit:
+		w.app.Quit()
+	case role == RoleClose:
+		w.native.Close()
+	case role == RoleMinimize:
+		w.native.SetMinimized(true)
+	case role == RoleFullscreen:
+		w.native.SetFullscreen(!w.native.Fullscreen())
+	case role == RoleReload:
+		for _, v := range webviews {
+			v.native.Reload()
+		}
+	case role == RoleDevTools:
+		for _, v := range webviews {
+			if v.devTools.Load() {
+				v.native.SetDevTools(!v.native.DevTools())
+			}
+		}
+	case role.editing() && len(webviews) > 0:
+		webviews[0].native.Edit(role)
+	}
+}
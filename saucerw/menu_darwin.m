@@ -0,0 +1,274 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <objc/runtime.h>
+
+#include "native.h"
+
+@interface SaucerwMenu : NSObject
+@property uintptr_t handle;
+@property(strong) NSMenu *menu;
+- (void)activate:(NSMenuItem *)sender;
+@end
+
+@implementation SaucerwMenu
+- (void)activate:(NSMenuItem *)sender
+{
+    if (self.handle)
+    {
+        saucerwMenu(self.handle, (int32_t)sender.tag);
+    }
+}
+@end
+
+// The menus of the windows, keyed by window. All functions run on the main thread.
+static NSMutableDictionary<NSValue *, SaucerwMenu *> *menus;
+
+// The menu installed by saucer, shown for windows without a menu.
+static NSMenu *fallback;
+
+// The key of the target associated with a context menu
+static char target_key;
+
+// Roles performed by the responder chain, the first responder being the focused webview.
+static SEL role_selector(int role)
+{
+    switch (role)
+    {
+    case SAUCERW_ROLE_UNDO:
+        return @selector(undo:);
+    case SAUCERW_ROLE_REDO:
+        return @selector(redo:);
+    case SAUCERW_ROLE_CUT:
+        return @selector(cut:);
+    case SAUCERW_ROLE_COPY:
+        return @selector(copy:);
+    case SAUCERW_ROLE_PASTE:
+        return @selector(paste:);
+    case SAUCERW_ROLE_SELECT_ALL:
+        return @selector(selectAll:);
+    case SAUCERW_ROLE_ABOUT:
+        return @selector(orderFrontStandardAboutPanel:);
+    case SAUCERW_ROLE_HIDE:
+        return @selector(hide:);
+    case SAUCERW_ROLE_HIDE_OTHERS:
+        return @selector(hideOtherApplications:);
+    case SAUCERW_ROLE_SHOW_ALL:
+        return @selector(unhideAllApplications:);
+    default:
+        return nil;
+    }
+}
+
+static NSString *key_equivalent(const char *key)
+{
+    static const struct
+    {
+        const char *name;
+        unichar key;
+    } keys[] = {
+        {"Enter", '\r'},
+        {"Escape", 0x1b},
+        {"Tab", '\t'},
+        {"Space", ' '},
+        {"Backspace", NSBackspaceCharacter},
+        {"Delete", NSDeleteFunctionKey},
+        {"Insert", NSInsertFunctionKey},
+        {"Home", NSHomeFunctionKey},
+        {"End", NSEndFunctionKey},
+        {"PageUp", NSPageUpFunctionKey},
+        {"PageDown", NSPageDownFunctionKey},
+        {"Up", NSUpArrowFunctionKey},
+        {"Down", NSDownArrowFunctionKey},
+        {"Left", NSLeftArrowFunctionKey},
+        {"Right", NSRightArrowFunctionKey},
+        {"Plus", '+'},
+        {"Minus", '-'},
+    };
+
+    for (size_t i = 0; i < sizeof(keys) / sizeof(keys[0]); ++i)
+    {
+        if (strcmp(keys[i].name, key) == 0)
+        {
+            return [NSString stringWithCharacters:&keys[i].key length:1];
+        }
+    }
+
+    if (key[0] == 'F' && key[1] != '\0')
+    {
+        const unichar function = NSF1FunctionKey + atoi(key + 1) - 1;
+        return [NSString stringWithCharacters:&function length:1];
+    }
+
+    return [[NSString stringWithUTF8String:key] lowercaseString];
+}
+
+static NSEventModifierFlags modifier_mask(int modifiers)
+{
+    NSEventModifierFlags rtn = 0;
+
+    if (modifiers & SAUCERW_MOD_CTRL)
+    {
+        rtn |= NSEventModifierFlagControl;
+    }
+
+    if (modifiers & SAUCERW_MOD_SHIFT)
+    {
+        rtn |= NSEventModifierFlagShift;
+    }
+
+    if (modifiers & SAUCERW_MOD_ALT)
+    {
+        rtn |= NSEventModifierFlagOption;
+    }
+
+    if (modifiers & SAUCERW_MOD_SUPER)
+    {
+        rtn |= NSEventModifierFlagCommand;
+    }
+
+    return rtn;
+}
+
+// The top level items of the menu bar are menus, even those without items
+static void populate(NSMenu *menu, SaucerwMenu *target, size_t count, const saucerw_menu_item *items, int32_t parent,
+                     bool bar)
+{
+    menu.autoenablesItems = NO;
+
+    for (size_t i = 0; i < count; ++i)
+    {
+        const saucerw_menu_item *entry = &items[i];
+
+        if (entry->parent != parent)
+        {
+            continue;
+        }
+
+        if (entry->separator)
+        {
+            [menu addItem:[NSMenuItem separatorItem]];
+            continue;
+        }
+
+        NSString *label = [NSString stringWithUTF8String:entry->label];
+        NSMenuItem *item = [[NSMenuItem alloc] initWithTitle:label action:nil keyEquivalent:@""];
+        NSMenu *submenu = [[NSMenu alloc] initWithTitle:label];
+
+        item.tag = entry->id;
+        item.enabled = !entry->disabled;
+
+        populate(submenu, target, count, items, entry->id, false);
+
+        if (submenu.numberOfItems > 0 || (bar && parent == 0))
+        {
+            item.submenu = submenu;
+            [menu addItem:item];
+            continue;
+        }
+
+        // Actions without a target are sent along the responder chain
+        SEL selector = role_selector(entry->role);
+
+        item.action = selector ? selector : @selector(activate:);
+        item.target = selector ? nil : target;
+        item.state = entry->checkable && entry->checked ? NSControlStateValueOn : NSControlStateValueOff;
+
+        if (entry->key)
+        {
+            item.keyEquivalent = key_equivalent(entry->key);
+            item.keyEquivalentModifierMask = modifier_mask(entry->modifiers);
+        }
+
+        [menu addItem:item];
+    }
+}
+
+void saucerw_cocoa_set_menu(const void *window, uintptr_t handle, size_t count, const saucerw_menu_item *items,
+                            bool focused)
+{
+    if (!menus)
+    {
+        menus = [NSMutableDictionary dictionary];
+        fallback = NSApp.mainMenu;
+    }
+
+    NSValue *key = [NSValue valueWithPointer:window];
+
+    if (count == 0)
+    {
+        [menus removeObjectForKey:key];
+    }
+    else
+    {
+        SaucerwMenu *menu = [SaucerwMenu new];
+
+        menu.handle = handle;
+        menu.menu = [NSMenu new];
+
+        // The first menu becomes the application menu
+        populate(menu.menu, menu, count, items, 0, true);
+
+        menus[key] = menu;
+    }
+
+    if (focused)
+    {
+        saucerw_cocoa_focus_menu(window);
+    }
+}
+
+void saucerw_cocoa_focus_menu(const void *window)
+{
+    SaucerwMenu *menu = menus[[NSValue valueWithPointer:window]];
+
+    if (menu)
+    {
+        NSApp.mainMenu = menu.menu;
+    }
+    else if (fallback)
+    {
+        NSApp.mainMenu = fallback;
+    }
+}
+
+void saucerw_cocoa_free_menu(const void *window)
+{
+    NSValue *key = [NSValue valueWithPointer:window];
+    SaucerwMenu *menu = menus[key];
+
+    if (!menu)
+    {
+        return;
+    }
+
+    menu.handle = 0;
+    [menus removeObjectForKey:key];
+
+    if (NSApp.mainMenu == menu.menu && fallback)
+    {
+        NSApp.mainMenu = fallback;
+    }
+}
+
+void saucerw_cocoa_populate_menu(NSMenu *menu, uintptr_t handle, size_t count, const saucerw_menu_item *items)
+{
+    SaucerwMenu *target = [SaucerwMenu new];
+    target.handle = handle;
+
+    // Menu items only reference their target weakly
+    objc_setAssociatedObject(menu, &target_key, target, OBJC_ASSOCIATION_RETAIN_NONATOMIC);
+
+    [menu removeAllItems];
+    populate(menu, target, count, items, 0, false);
+}
+
+void saucerw_cocoa_edit(int role)
+{
+    SEL selector = role_selector(role);
+
+    if (selector)
+    {
+        [NSApp sendAction:selector to:nil from:nil];
+    }
+}
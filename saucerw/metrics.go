@@ -0,0 +1,178 @@
+package saucerw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// metricsPath is the path AppOptions.Metrics serves MetricsHandler at.
+const metricsPath = "/debug/saucerw/"
+
+// metricsTimeout bounds how long MetricsHandler waits for a page.
+const metricsTimeout = 2 * time.Second
+
+// metricsScript evaluates to the statistics only the page knows.
+// performance.memory is only implemented by Chromium.
+const metricsScript = `({
+    heapUsed:  performance.memory?.usedJSHeapSize ?? 0,
+    heapTotal: performance.memory?.totalJSHeapSize ?? 0,
+    nodes:     document.getElementsByTagName("*").length,
+})`
+
+// WebviewMetrics are resource statistics of a webview, see Webview.Metrics.
+// Counts that keep growing while the application is idle point to a leak.
+type WebviewMetrics struct {
+	// Window is the ID of the window of the webview.
+	Window uint64 `json:"window"`
+	URL    string `json:"url"`
+	// HeapUsed and HeapTotal are the bytes of the JavaScript heap of the page
+	// in use and allocated. They are only reported by WebView2 and Qt
+	// WebEngine, zero elsewhere.
+	HeapUsed  int64 `json:"heapUsed"`
+	HeapTotal int64 `json:"heapTotal"`
+	// Nodes is the number of elements of the document.
+	Nodes int `json:"nodes"`
+	// Streams is the number of responses of HandleStreamScheme handlers not
+	// finished yet.
+	Streams int `json:"streams"`
+	// Calls is the number of calls of exposed functions running.
+	Calls int `json:"calls"`
+	// Evaluations is the number of Eval and Call waiting for the page.
+	Evaluations int `json:"evaluations"`
+	// Queued is the number of scripts waiting for the next batch.
+	Queued int `json:"queued"`
+	// Channels is the number of open channels.
+	Channels int `json:"channels"`
+	// Stashed is the number of binary payloads uploaded by the page or sent
+	// to it that were not picked up yet.
+	Stashed int `json:"stashed"`
+	// Compression counts the messages the bridge compressed, see
+	// WebviewOptions.Compression.
+	Compression CompressionStats `json:"compression"`
+}
+
+// Metrics returns the resource statistics of the webview. It waits for the
+// page like the methods of CookieStore; if the page does not answer, the
+// statistics of the Go side are returned with the error.
+func (v *Webview) Metrics(ctx context.Context) (WebviewMetrics, error) {
+	m := WebviewMetrics{Window: v.window.id, URL: v.native.URL(), Streams: int(v.streams.Load())}
+	v.bridge.metrics(&m)
+
+	if err := v.window.app.checkWait(); err != nil {
+		return m, err
+	}
+
+	var page struct {
+		HeapUsed  int64 `json:"heapUsed"`
+		HeapTotal int64 `json:"heapTotal"`
+		Nodes     int   `json:"nodes"`
+	}
+	if err := v.Eval(ctx, metricsScript, &page); err != nil {
+		return m, err
+	}
+
+	m.HeapUsed, m.HeapTotal, m.Nodes = page.HeapUsed, page.HeapTotal, page.Nodes
+	return m, nil
+}
+
+// metrics fills in the statistics of the bridge.
+func (b *bridge) metrics(m *WebviewMetrics) {
+	b.mu.RLock()
+	m.Calls, m.Evaluations, m.Channels = len(b.calls), len(b.evaluations), len(b.channels)
+	b.mu.RUnlock()
+
+	b.batch.mu.Lock()
+	m.Queued = len(b.batch.pending)
+	b.batch.mu.Unlock()
+
+	b.stash.mu.Lock()
+	m.Stashed = len(b.stash.in) + len(b.stash.out)
+	b.stash.mu.Unlock()
+
+	m.Compression = b.compression.stats()
+}
+
+// AppMetrics are the resource statistics MetricsHandler serves.
+type AppMetrics struct {
+	// Goroutines is the number of goroutines of the process.
+	Goroutines int `json:"goroutines"`
+	// HeapAlloc is the bytes of the Go heap in use, Sys those obtained from
+	// the system by the Go runtime.
+	HeapAlloc uint64 `json:"heapAlloc"`
+	Sys       uint64 `json:"sys"`
+	// Webviews are the statistics of the webviews of all windows.
+	Webviews []WebviewReport `json:"webviews"`
+}
+
+// WebviewReport is the entry of a webview in AppMetrics.
+type WebviewReport struct {
+	WebviewMetrics
+	// Error tells why the page did not answer, if it did not.
+	Error string `json:"error,omitempty"`
+}
+
+// MetricsHandler returns a handler for inspecting a running application, see
+// AppOptions.Metrics. It serves the AppMetrics as JSON, except for requests of
+// a path ending in the name of a runtime/pprof profile, e.g.
+// "/debug/saucerw/heap" or "/debug/saucerw/goroutine", which it answers with
+// the profile for go tool pprof.
+func (a *Application) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p := pprof.Lookup(path.Base(r.URL.Path)); p != nil {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", p.Name()))
+			p.WriteTo(w, 0)
+			return
+		}
+
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+
+		m := AppMetrics{Goroutines: runtime.NumGoroutine(), HeapAlloc: mem.HeapAlloc, Sys: mem.Sys, Webviews: []WebviewReport{}}
+
+		for _, win := range a.Windows() {
+			for _, v := range win.Webviews() {
+				ctx, cancel := context.WithTimeout(r.Context(), metricsTimeout)
+				vm, err := v.Metrics(ctx)
+				cancel()
+
+				report := WebviewReport{WebviewMetrics: vm}
+				if err != nil {
+					report.Error = err.Error()
+				}
+				m.Webviews = append(m.Webviews, report)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&m)
+	})
+}
+
+// serveMetrics serves MetricsHandler on addr until the application is
+// released.
+func (a *Application) serveMetrics(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("saucerw: metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, a.MetricsHandler())
+
+	a.metrics = &http.Server{Handler: mux}
+	go func() {
+		if err := a.metrics.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log().Warn("serving metrics failed", "component", "saucerw", "error", err)
+		}
+	}()
+	return nil
+}
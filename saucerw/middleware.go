@@ -0,0 +1,61 @@
+package saucerw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// BridgeCall is a call from the page to an exposed function.
+type BridgeCall struct {
+	// Name is the name the function was exposed under.
+	Name string
+	// Params are the undecoded arguments. Binary arguments are references to
+	// their uploads, {"saucer:bytes": id}.
+	Params []json.RawMessage
+}
+
+// BridgeHandler handles a call from the page and returns the JSON encoded
+// result. A non-nil error rejects the promise of the caller, see BridgeError.
+// ctx is canceled when the caller aborts the call or the webview is released.
+type BridgeHandler func(ctx context.Context, call *BridgeCall) (json.RawMessage, error)
+
+// UseBridge adds middleware wrapping every call from the page, including
+// calls of functions that are not exposed, e.g. for logging, authorization or
+// metrics. Middleware added first runs outermost and applies to calls made
+// after UseBridge returns.
+//
+//	v.UseBridge(func(next saucerw.BridgeHandler) saucerw.BridgeHandler {
+//		return func(ctx context.Context, call *saucerw.BridgeCall) (json.RawMessage, error) {
+//			start := time.Now()
+//			result, err := next(ctx, call)
+//			slog.Info("bridge call", "name", call.Name, "duration", time.Since(start), "error", err)
+//			return result, err
+//		}
+//	})
+func (v *Webview) UseBridge(middleware ...func(next BridgeHandler) BridgeHandler) {
+	v.bridge.mu.Lock()
+	defer v.bridge.mu.Unlock()
+
+	v.bridge.middleware = append(v.bridge.middleware, middleware...)
+}
+
+// ExposedHandler returns a BridgeHandler calling fn, which takes and returns
+// what Webview.Expose accepts, for serving functions to pages over other
+// transports, see package wire. Binary arguments are base64 encoded JSON
+// strings. Streamed results are only supported by webviews.
+func ExposedHandler(fn any) (BridgeHandler, error) {
+	e, err := newExposed(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.writer || e.ch {
+		return nil, errors.New("saucerw: streamed results need a webview")
+	}
+
+	return func(ctx context.Context, call *BridgeCall) (_ json.RawMessage, err error) {
+		defer recoverError("exposed function "+call.Name, &err)
+		return e.call(ctx, newStash(), nil, call.Params)
+	}, nil
+}
@@ -0,0 +1,6869 @@
+//go:build cgo && saucer
+
+#include "native.h"
+
+#include <saucer/webview.hpp>
+
+// The bindings are written against the API of saucer 8, libraries of other releases are rejected
+#if defined(SAUCER_RELEASE_MAJOR) && SAUCER_RELEASE_MAJOR != 8
+#error "saucerw requires saucer 8, build the library with build.Config.Version \"8\""
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+#include <glib.h>
+#include <gio/gunixfdlist.h>
+#include <unistd.h>
+#include <saucer/modules/stable/webkitgtk.hpp>
+#elif defined(SAUCER_QT)
+#include <QString>
+#include <QtGlobal>
+#include <QMenu>
+#include <QAction>
+#include <QMenuBar>
+#include <QKeySequence>
+#include <QWebEnginePage>
+#include <QClipboard>
+#include <QGuiApplication>
+#include <QImage>
+#include <QScreen>
+#include <QKeyEvent>
+#include <QApplication>
+#include <QMimeData>
+#include <QDir>
+#include <QFileInfo>
+#include <QWebEngineProfile>
+#include <QWebEngineDownloadRequest>
+#include <QWebEngineCookieStore>
+#include <QWebEngineCertificateError>
+#include <QWebEngineUrlRequestInterceptor>
+#include <QAuthenticator>
+#include <QSslCertificate>
+#include <QNetworkCookie>
+#include <QDateTime>
+#include <QWebEngineSettings>
+#include <QPageLayout>
+#include <QPageSize>
+#include <QSizeF>
+#include <QMarginsF>
+#include <QBuffer>
+#include <QByteArray>
+#include <QPixmap>
+#include <QStyleHints>
+#include <QAccessible>
+#include <QLocale>
+#include <QStringList>
+#include <QDropEvent>
+#include <QUrl>
+#include <QWebEngineContextMenuRequest>
+#include <QSessionManager>
+#include <QInputMethodEvent>
+#include <QMouseEvent>
+#include <QWheelEvent>
+#include <saucer/modules/stable/qt.hpp>
+#elif defined(SAUCER_WEBVIEW2)
+#include <wrl.h>
+#include <dwmapi.h>
+#include <shellscalingapi.h>
+#include <wtsapi32.h>
+#include <saucer/modules/stable/webview2.hpp>
+#endif
+
+#include <chrono>
+#include <cmath>
+#include <cstdint>
+#include <cstdio>
+#include <cstdlib>
+#include <cstring>
+#include <cwchar>
+#include <exception>
+
+#include <map>
+#include <array>
+#include <memory>
+#include <algorithm>
+#include <format>
+#include <functional>
+#include <string>
+#include <vector>
+#include <iterator>
+#include <utility>
+#include <optional>
+#include <thread>
+#include <string_view>
+#include <ranges>
+
+namespace
+{
+    struct menu_entry
+    {
+        int32_t id;
+        int32_t parent;
+        std::string label;
+        int role;
+        int modifiers;
+        std::string key;
+        bool disabled;
+        bool checkable;
+        bool checked;
+        bool separator;
+        bool passthrough;
+    };
+
+    struct menu_state
+    {
+        uintptr_t handle{};
+        std::vector<menu_entry> entries;
+
+#if defined(SAUCER_WEBKITGTK)
+        GtkWidget *bar{};
+        GtkEventController *shortcuts{};
+#elif defined(SAUCER_WEBVIEW2)
+        WNDPROC original{};
+#endif
+    };
+
+    struct kiosk_state
+    {
+        bool enabled{};
+        // Set while saucerw_window_close closes the window
+        bool closing{};
+        // Whether closing by the user is reported to the events handler instead, see saucerw_window_intercept_close
+        bool intercept{};
+        uintptr_t events{};
+
+#if defined(SAUCER_WEBKITGTK)
+        GtkEventController *keys{};
+#elif defined(SAUCER_QT)
+        std::unique_ptr<QObject> filter;
+#endif
+    };
+} // namespace
+
+struct saucerw_app
+{
+    std::optional<saucer::application> app;
+
+  public:
+    std::vector<std::string> args;
+    std::vector<char *> argv;
+
+#if defined(SAUCER_WEBVIEW2)
+    HWND clipboard{};
+#elif defined(SAUCER_WEBKIT)
+    void *clipboard{};
+#endif
+
+  public:
+    // The handler of the color scheme changes and the last scheme reported to it
+    uintptr_t scheme_handler{};
+    int scheme{};
+
+#if defined(SAUCER_WEBKITGTK)
+    GDBusConnection *bus{};
+    guint scheme_signal{};
+#elif defined(SAUCER_WEBVIEW2)
+    HANDLE scheme_stop{};
+    std::thread scheme_watcher;
+#elif defined(SAUCER_WEBKIT)
+    void *appearance{};
+#endif
+
+  public:
+    // The handler of the locale changes and the last locales reported to it
+    uintptr_t locale_handler{};
+    std::string locales;
+
+#if defined(SAUCER_WEBVIEW2)
+    HANDLE locale_stop{};
+    std::thread locale_watcher;
+#elif defined(SAUCER_WEBKIT)
+    void *locale_observer{};
+#endif
+
+  public:
+    // The handler of the accessibility changes and the last flags reported to it
+    uintptr_t accessibility_handler{};
+    int accessibility{};
+
+#if defined(SAUCER_WEBKITGTK)
+    guint reader_signal{};
+    guint contrast_signal{};
+#elif defined(SAUCER_QT)
+    std::unique_ptr<QAccessible::ActivationObserver> reader_observer;
+#elif defined(SAUCER_WEBVIEW2)
+    HANDLE accessibility_stop{};
+    std::thread accessibility_watcher;
+#elif defined(SAUCER_WEBKIT)
+    void *accessibility_observer{};
+#endif
+
+  public:
+    // The handler of the power and session events
+    uintptr_t power_handler{};
+
+#if defined(SAUCER_WEBKITGTK)
+    GDBusConnection *system_bus{};
+    GDBusConnection *session_bus{};
+    guint sleep_signal{};
+    guint shutdown_signal{};
+    guint lock_signal{};
+    bool locked{};
+    // The delay inhibitor lock of logind, -1 while none is held
+    int inhibitor{-1};
+#elif defined(SAUCER_WEBVIEW2)
+    // The hidden top-level window receiving the power and session messages, which are not sent to message-only ones
+    HWND power{};
+    bool shutdown_pending{};
+#elif defined(SAUCER_WEBKIT)
+    void *power_observer{};
+#endif
+};
+
+struct saucerw_window
+{
+    std::shared_ptr<saucer::window> window;
+    std::shared_ptr<menu_state> menu{std::make_shared<menu_state>()};
+    // Shared with the key handlers of the webviews, only used on the event loop thread
+    std::shared_ptr<kiosk_state> kiosk{std::make_shared<kiosk_state>()};
+
+  public:
+    // The last scale reported to the event handler
+    double scale{};
+
+#if defined(SAUCER_QT)
+    std::unique_ptr<QObject> scale_filter;
+#elif defined(SAUCER_WEBKIT)
+    void *scale_observer{};
+#endif
+};
+
+struct saucerw_webview
+{
+    std::optional<saucer::webview> webview;
+
+#if defined(SAUCER_WEBKITGTK)
+    gulong download_started{};
+#elif defined(SAUCER_QT)
+    // The cookie store only reports its cookies through signals
+    std::shared_ptr<std::vector<QNetworkCookie>> cookies{std::make_shared<std::vector<QNetworkCookie>>()};
+#elif defined(SAUCER_WEBKIT)
+    // Applied to every page by the script of the media policy, see saucerw_webview_set_muted
+    bool muted{};
+#endif
+};
+
+#if !defined(SAUCER_WEBKIT)
+struct saucerw_context_menu
+{
+    std::shared_ptr<menu_state> state;
+};
+#endif
+
+struct saucerw_permission
+{
+    saucer::application *app;
+    std::shared_ptr<saucer::permission::request> request;
+};
+
+struct saucerw_shared_buffer
+{
+    saucer::application *app;
+#if defined(SAUCER_WEBVIEW2)
+    Microsoft::WRL::ComPtr<ICoreWebView2SharedBuffer> buffer;
+#endif
+};
+
+struct saucerw_executor
+{
+    saucer::scheme::executor executor;
+};
+
+struct saucerw_stream
+{
+    saucer::scheme::stream_writer writer;
+};
+
+namespace
+{
+    char *dup(std::string_view value)
+    {
+        auto *const rtn = static_cast<char *>(std::malloc(value.size() + 1));
+
+        std::memcpy(rtn, value.data(), value.size());
+        rtn[value.size()] = '\0';
+
+        return rtn;
+    }
+
+    void fail(char **error, const saucer::error &err)
+    {
+        if (!error)
+        {
+            return;
+        }
+
+        *error = dup(err.message());
+    }
+
+    void log(saucerw_log_level level, std::string component, const std::string &message)
+    {
+        saucerwLog(level, component.data(), const_cast<char *>(message.data()), message.size());
+    }
+
+    void fatal(std::string component, const std::string &message)
+    {
+        saucerwFatal(component.data(), const_cast<char *>(message.data()), message.size());
+    }
+
+    [[noreturn]] void terminate()
+    {
+        std::string message{"terminate called without an active exception"};
+
+        if (auto error = std::current_exception(); error)
+        {
+            try
+            {
+                std::rethrow_exception(error);
+            }
+            catch (const std::exception &e)
+            {
+                message = e.what();
+            }
+            catch (...)
+            {
+                message = "terminate called after throwing an unknown exception";
+            }
+        }
+
+        fatal("saucer", message);
+        std::abort();
+    }
+
+#if defined(SAUCER_WEBKITGTK)
+    GLogWriterOutput glib_writer(GLogLevelFlags flags, const GLogField *fields, gsize count, gpointer)
+    {
+        std::string domain{"glib"};
+        std::string message;
+
+        for (auto i = 0uz; count > i; ++i)
+        {
+            const auto &field = fields[i];
+            const auto *value = static_cast<const char *>(field.value);
+            const auto length = field.length < 0 ? std::strlen(value) : static_cast<std::size_t>(field.length);
+
+            if (std::strcmp(field.key, "MESSAGE") == 0)
+            {
+                message.assign(value, length);
+            }
+            else if (std::strcmp(field.key, "GLIB_DOMAIN") == 0)
+            {
+                domain.assign(value, length);
+            }
+        }
+
+        if (g_log_writer_default_would_drop(flags, domain.c_str()))
+        {
+            return G_LOG_WRITER_HANDLED;
+        }
+
+        auto level = SAUCERW_LOG_DEBUG;
+
+        if (flags & (G_LOG_LEVEL_ERROR | G_LOG_LEVEL_CRITICAL))
+        {
+            level = SAUCERW_LOG_ERROR;
+        }
+        else if (flags & G_LOG_LEVEL_WARNING)
+        {
+            level = SAUCERW_LOG_WARN;
+        }
+        else if (flags & (G_LOG_LEVEL_MESSAGE | G_LOG_LEVEL_INFO))
+        {
+            level = SAUCERW_LOG_INFO;
+        }
+
+        if (flags & G_LOG_FLAG_FATAL)
+        {
+            fatal(domain, message);
+        }
+
+        log(level, std::move(domain), message);
+        return G_LOG_WRITER_HANDLED;
+    }
+#elif defined(SAUCER_QT)
+    void qt_handler(QtMsgType type, const QMessageLogContext &context, const QString &message)
+    {
+        auto level = SAUCERW_LOG_ERROR;
+
+        switch (type)
+        {
+        case QtDebugMsg:
+            level = SAUCERW_LOG_DEBUG;
+            break;
+        case QtInfoMsg:
+            level = SAUCERW_LOG_INFO;
+            break;
+        case QtWarningMsg:
+            level = SAUCERW_LOG_WARN;
+            break;
+        default:
+            break;
+        }
+
+        const auto *category = context.category ? context.category : "qt";
+
+        if (type == QtFatalMsg)
+        {
+            fatal(category, message.toStdString());
+        }
+
+        log(level, category, message.toStdString());
+    }
+#endif
+
+    coco::stray start(uintptr_t handle)
+    {
+        saucerwInvoke(handle);
+        co_return;
+    }
+
+    template <typename Callback>
+    void convert(const saucer::scheme::request &request, Callback &&callback)
+    {
+        const auto url     = request.url().string();
+        const auto method  = request.method();
+        const auto content = request.content();
+        const auto headers = request.headers();
+
+        std::vector<const char *> keys;
+        std::vector<const char *> values;
+
+        for (const auto &[key, value] : headers)
+        {
+            keys.emplace_back(key.c_str());
+            values.emplace_back(value.c_str());
+        }
+
+        auto data = saucerw_scheme_request{
+            .url           = url.c_str(),
+            .method        = method.c_str(),
+            .headers       = headers.size(),
+            .header_keys   = keys.data(),
+            .header_values = values.data(),
+            .body          = content.data(),
+            .body_size     = content.size(),
+        };
+
+        std::forward<Callback>(callback)(&data);
+    }
+
+    std::map<std::string, std::string> header_map(size_t count, const char **keys, const char **values)
+    {
+        std::map<std::string, std::string> rtn;
+
+        for (auto i = 0uz; count > i; ++i)
+        {
+            rtn.emplace(keys[i], values[i]);
+        }
+
+        return rtn;
+    }
+
+    std::vector<menu_entry> menu_entries(size_t count, const saucerw_menu_item *items)
+    {
+        std::vector<menu_entry> rtn;
+        rtn.reserve(count);
+
+        for (auto i = 0uz; count > i; ++i)
+        {
+            const auto &item = items[i];
+
+            rtn.emplace_back(menu_entry{
+                .id          = item.id,
+                .parent      = item.parent,
+                .label       = item.label,
+                .role        = item.role,
+                .modifiers   = item.modifiers,
+                .key         = item.key ? item.key : "",
+                .disabled    = item.disabled,
+                .checkable   = item.checkable,
+                .checked     = item.checked,
+                .separator   = item.separator,
+                .passthrough = item.passthrough,
+            });
+        }
+
+        return rtn;
+    }
+
+    void activate(const menu_state &state, int32_t id)
+    {
+        if (!state.handle)
+        {
+            return;
+        }
+
+        saucerwMenu(state.handle, id);
+    }
+
+    template <typename Callback>
+    void each_child(const menu_state &state, int32_t parent, Callback &&callback)
+    {
+        for (const auto &entry : state.entries)
+        {
+            if (entry.parent != parent)
+            {
+                continue;
+            }
+
+            callback(entry);
+        }
+    }
+
+    bool has_children(const menu_state &state, int32_t id)
+    {
+        return std::ranges::any_of(state.entries, [id](const auto &entry) { return entry.parent == id; });
+    }
+
+    // Doubles the mnemonic character, so labels show it literally
+    std::string escape(std::string_view label, char mnemonic)
+    {
+        std::string rtn;
+
+        for (const auto c : label)
+        {
+            if (c == mnemonic)
+            {
+                rtn += c;
+            }
+
+            rtn += c;
+        }
+
+        return rtn;
+    }
+
+#if defined(SAUCER_WEBKITGTK)
+    struct menu_target
+    {
+        std::shared_ptr<menu_state> state;
+        int32_t id;
+    };
+
+    std::string gtk_accelerator(const menu_entry &entry)
+    {
+        static const std::map<std::string_view, std::string_view> keys{
+            {"Enter", "Return"},      {"Space", "space"}, {"Backspace", "BackSpace"}, {"PageUp", "Page_Up"},
+            {"PageDown", "Page_Down"}, {"Plus", "plus"},  {"Minus", "minus"},
+        };
+
+        std::string rtn;
+
+        if (entry.modifiers & SAUCERW_MOD_CTRL)
+        {
+            rtn += "<Control>";
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_SHIFT)
+        {
+            rtn += "<Shift>";
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_ALT)
+        {
+            rtn += "<Alt>";
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_SUPER)
+        {
+            rtn += "<Super>";
+        }
+
+        if (auto it = keys.find(entry.key); it != keys.end())
+        {
+            return rtn + std::string{it->second};
+        }
+
+        if (entry.key.size() == 1)
+        {
+            const auto *name = gdk_keyval_name(gdk_keyval_to_lower(gdk_unicode_to_keyval(entry.key[0])));
+            return name ? rtn + name : std::string{};
+        }
+
+        return rtn + entry.key;
+    }
+
+    std::string gtk_action(const menu_entry &entry)
+    {
+        return "item-" + std::to_string(entry.id);
+    }
+
+    GSimpleAction *gtk_item_action(const std::shared_ptr<menu_state> &state, const menu_entry &entry)
+    {
+        const auto name = gtk_action(entry);
+
+        GSimpleAction *rtn{};
+
+        if (entry.checkable)
+        {
+            rtn = g_simple_action_new_stateful(name.c_str(), nullptr, g_variant_new_boolean(entry.checked));
+        }
+        else
+        {
+            rtn = g_simple_action_new(name.c_str(), nullptr);
+        }
+
+        g_simple_action_set_enabled(rtn, !entry.disabled);
+
+        g_signal_connect_data(
+            rtn, "activate",
+            G_CALLBACK(+[](GSimpleAction *, GVariant *, gpointer data)
+                       {
+                           const auto *target = static_cast<menu_target *>(data);
+                           activate(*target->state, target->id);
+                       }),
+            new menu_target{state, entry.id},
+            +[](gpointer data, GClosure *) { delete static_cast<menu_target *>(data); },
+            static_cast<GConnectFlags>(0));
+
+        return rtn;
+    }
+
+    GMenu *gtk_menu(const std::shared_ptr<menu_state> &state, GSimpleActionGroup *group, int32_t parent)
+    {
+        auto *const menu = g_menu_new();
+        auto *section    = g_menu_new();
+
+        // Separators split the menu into sections
+        auto flush = [&]
+        {
+            if (g_menu_model_get_n_items(G_MENU_MODEL(section)) > 0)
+            {
+                g_menu_append_section(menu, nullptr, G_MENU_MODEL(section));
+            }
+
+            g_object_unref(section);
+            section = g_menu_new();
+        };
+
+        each_child(*state, parent,
+                   [&](const menu_entry &entry)
+                   {
+                       if (entry.separator)
+                       {
+                           flush();
+                           return;
+                       }
+
+                       const auto label = escape(entry.label, '_');
+
+                       if (has_children(*state, entry.id))
+                       {
+                           auto *const submenu = gtk_menu(state, group, entry.id);
+                           g_menu_append_submenu(section, label.c_str(), G_MENU_MODEL(submenu));
+                           g_object_unref(submenu);
+                           return;
+                       }
+
+                       auto *const action = gtk_item_action(state, entry);
+                       g_action_map_add_action(G_ACTION_MAP(group), G_ACTION(action));
+                       g_object_unref(action);
+
+                       auto *const item = g_menu_item_new(label.c_str(), ("saucerw." + gtk_action(entry)).c_str());
+
+                       if (const auto accelerator = entry.key.empty() ? "" : gtk_accelerator(entry); !accelerator.empty())
+                       {
+                           g_menu_item_set_attribute(item, "accel", "s", accelerator.c_str());
+                       }
+
+                       g_menu_append_item(section, item);
+                       g_object_unref(item);
+                   });
+
+        flush();
+        g_object_unref(section);
+
+        return menu;
+    }
+
+    void apply_menu(saucerw_window &self)
+    {
+        auto &state         = self.menu;
+        auto *const window  = self.window->native<true>().window;
+        auto *const content = adw_application_window_get_content(ADW_APPLICATION_WINDOW(window));
+
+        if (state->bar)
+        {
+            gtk_box_remove(GTK_BOX(content), std::exchange(state->bar, nullptr));
+        }
+
+        if (state->shortcuts)
+        {
+            gtk_widget_remove_controller(GTK_WIDGET(window), std::exchange(state->shortcuts, nullptr));
+        }
+
+        if (state->entries.empty())
+        {
+            gtk_widget_insert_action_group(GTK_WIDGET(window), "saucerw", nullptr);
+            return;
+        }
+
+        auto *const group = g_simple_action_group_new();
+        auto *const model = g_menu_new();
+
+        each_child(*state, 0,
+                   [&](const menu_entry &entry)
+                   {
+                       auto *const menu = gtk_menu(state, group, entry.id);
+                       g_menu_append_submenu(model, escape(entry.label, '_').c_str(), G_MENU_MODEL(menu));
+                       g_object_unref(menu);
+                   });
+
+        gtk_widget_insert_action_group(GTK_WIDGET(window), "saucerw", G_ACTION_GROUP(group));
+        g_object_unref(group);
+
+        // The content is a box of the header bar and the webviews, the menu bar goes in between
+        state->bar = gtk_popover_menu_bar_new_from_model(G_MENU_MODEL(model));
+        gtk_box_insert_child_after(GTK_BOX(content), state->bar, gtk_widget_get_first_child(content));
+        g_object_unref(model);
+
+        // Shortcuts are captured before the webview sees the key, the passthrough ones are left to it
+        state->shortcuts = gtk_shortcut_controller_new();
+        gtk_event_controller_set_propagation_phase(state->shortcuts, GTK_PHASE_CAPTURE);
+
+        for (const auto &entry : state->entries)
+        {
+            if (entry.key.empty() || entry.passthrough || entry.separator || has_children(*state, entry.id))
+            {
+                continue;
+            }
+
+            auto *const trigger = gtk_shortcut_trigger_parse_string(gtk_accelerator(entry).c_str());
+
+            if (!trigger)
+            {
+                continue;
+            }
+
+            auto *const action = gtk_named_action_new(("saucerw." + gtk_action(entry)).c_str());
+            gtk_shortcut_controller_add_shortcut(GTK_SHORTCUT_CONTROLLER(state->shortcuts), gtk_shortcut_new(trigger, action));
+        }
+
+        gtk_widget_add_controller(GTK_WIDGET(window), state->shortcuts);
+    }
+#elif defined(SAUCER_QT)
+    QKeySequence key_sequence(const menu_entry &entry)
+    {
+        static const std::map<std::string_view, std::string_view> keys{
+            {"Enter", "Return"}, {"Escape", "Esc"},     {"Delete", "Del"}, {"Insert", "Ins"},
+            {"PageUp", "PgUp"},  {"PageDown", "PgDown"}, {"Plus", "+"},     {"Minus", "-"},
+        };
+
+#if defined(Q_OS_MACOS)
+        // Qt maps Ctrl to the Command key on macOS
+        constexpr auto *ctrl  = "Meta+";
+        constexpr auto *super = "Ctrl+";
+#else
+        constexpr auto *ctrl  = "Ctrl+";
+        constexpr auto *super = "Meta+";
+#endif
+
+        std::string rtn;
+
+        if (entry.modifiers & SAUCERW_MOD_CTRL)
+        {
+            rtn += ctrl;
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_SHIFT)
+        {
+            rtn += "Shift+";
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_ALT)
+        {
+            rtn += "Alt+";
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_SUPER)
+        {
+            rtn += super;
+        }
+
+        const auto it = keys.find(entry.key);
+        rtn += it != keys.end() ? std::string{it->second} : entry.key;
+
+        return QKeySequence::fromString(QString::fromStdString(rtn), QKeySequence::PortableText);
+    }
+
+    void populate(const std::shared_ptr<menu_state> &state, QMenu *menu, int32_t parent)
+    {
+        each_child(*state, parent,
+                   [&](const menu_entry &entry)
+                   {
+                       if (entry.separator)
+                       {
+                           menu->addSeparator();
+                           return;
+                       }
+
+                       const auto label = QString::fromStdString(escape(entry.label, '&'));
+
+                       if (has_children(*state, entry.id))
+                       {
+                           auto *const submenu = menu->addMenu(label);
+                           submenu->setEnabled(!entry.disabled);
+                           populate(state, submenu, entry.id);
+                           return;
+                       }
+
+                       auto *const action = menu->addAction(label);
+
+                       action->setEnabled(!entry.disabled);
+                       action->setCheckable(entry.checkable);
+                       action->setChecked(entry.checked);
+
+                       if (!entry.key.empty())
+                       {
+                           action->setShortcut(key_sequence(entry));
+                       }
+
+                       if (entry.passthrough)
+                       {
+                           // Only active while the menu itself has the focus, the webview handles the key
+                           action->setShortcutContext(Qt::WidgetShortcut);
+                       }
+
+                       QObject::connect(action, &QAction::triggered, [state, id = entry.id] { activate(*state, id); });
+                   });
+    }
+
+    void apply_menu(saucerw_window &self)
+    {
+        auto &state        = self.menu;
+        auto *const window = self.window->native<true>().window;
+
+        if (state->entries.empty())
+        {
+            window->setMenuBar(nullptr);
+            return;
+        }
+
+        auto *const bar = new QMenuBar;
+
+        each_child(*state, 0,
+                   [&](const menu_entry &entry)
+                   { populate(state, bar->addMenu(QString::fromStdString(escape(entry.label, '&'))), entry.id); });
+
+        // Takes ownership and deletes the previous menu bar
+        window->setMenuBar(bar);
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    constexpr auto *menu_property = L"saucerw.menu";
+
+    std::wstring widen(std::string_view value)
+    {
+        const auto size = MultiByteToWideChar(CP_UTF8, 0, value.data(), static_cast<int>(value.size()), nullptr, 0);
+        std::wstring rtn(size, L'\0');
+
+        MultiByteToWideChar(CP_UTF8, 0, value.data(), static_cast<int>(value.size()), rtn.data(), size);
+
+        return rtn;
+    }
+
+    std::string accelerator_label(const menu_entry &entry)
+    {
+        std::string rtn;
+
+        if (entry.modifiers & SAUCERW_MOD_CTRL)
+        {
+            rtn += "Ctrl+";
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_ALT)
+        {
+            rtn += "Alt+";
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_SHIFT)
+        {
+            rtn += "Shift+";
+        }
+
+        if (entry.modifiers & SAUCERW_MOD_SUPER)
+        {
+            rtn += "Win+";
+        }
+
+        return rtn + entry.key;
+    }
+
+    UINT virtual_key(const std::string &key)
+    {
+        static const std::map<std::string_view, UINT> keys{
+            {"Enter", VK_RETURN}, {"Escape", VK_ESCAPE}, {"Tab", VK_TAB},       {"Space", VK_SPACE},
+            {"Backspace", VK_BACK}, {"Delete", VK_DELETE}, {"Insert", VK_INSERT}, {"Home", VK_HOME},
+            {"End", VK_END},      {"PageUp", VK_PRIOR},  {"PageDown", VK_NEXT},  {"Up", VK_UP},
+            {"Down", VK_DOWN},    {"Left", VK_LEFT},     {"Right", VK_RIGHT},    {"Plus", VK_OEM_PLUS},
+            {"Minus", VK_OEM_MINUS},
+        };
+
+        if (auto it = keys.find(key); it != keys.end())
+        {
+            return it->second;
+        }
+
+        if (key.size() > 1 && key[0] == 'F')
+        {
+            return VK_F1 + std::stoi(key.substr(1)) - 1;
+        }
+
+        // Letters and digits are their own virtual key codes
+        if ((key[0] >= 'A' && key[0] <= 'Z') || (key[0] >= '0' && key[0] <= '9'))
+        {
+            return key[0];
+        }
+
+        return LOBYTE(VkKeyScanW(static_cast<WCHAR>(key[0])));
+    }
+
+    int32_t match_accelerator(const menu_state &state, UINT key)
+    {
+        auto modifiers = 0;
+
+        if (GetKeyState(VK_CONTROL) & 0x8000)
+        {
+            modifiers |= SAUCERW_MOD_CTRL;
+        }
+
+        if (GetKeyState(VK_SHIFT) & 0x8000)
+        {
+            modifiers |= SAUCERW_MOD_SHIFT;
+        }
+
+        if (GetKeyState(VK_MENU) & 0x8000)
+        {
+            modifiers |= SAUCERW_MOD_ALT;
+        }
+
+        if ((GetKeyState(VK_LWIN) | GetKeyState(VK_RWIN)) & 0x8000)
+        {
+            modifiers |= SAUCERW_MOD_SUPER;
+        }
+
+        for (const auto &entry : state.entries)
+        {
+            if (entry.key.empty() || entry.passthrough || entry.disabled || entry.modifiers != modifiers)
+            {
+                continue;
+            }
+
+            if (virtual_key(entry.key) == key)
+            {
+                return entry.id;
+            }
+        }
+
+        return 0;
+    }
+
+    HMENU win32_menu(const menu_state &state, int32_t parent, bool popup)
+    {
+        auto *const menu = popup ? CreatePopupMenu() : CreateMenu();
+
+        each_child(state, parent,
+                   [&](const menu_entry &entry)
+                   {
+                       if (entry.separator)
+                       {
+                           AppendMenuW(menu, MF_SEPARATOR, 0, nullptr);
+                           return;
+                       }
+
+                       auto label = widen(escape(entry.label, '&'));
+                       UINT flags = MF_STRING;
+
+                       if (entry.disabled)
+                       {
+                           flags |= MF_GRAYED;
+                       }
+
+                       if (entry.checkable && entry.checked)
+                       {
+                           flags |= MF_CHECKED;
+                       }
+
+                       if (has_children(state, entry.id))
+                       {
+                           const auto submenu = reinterpret_cast<UINT_PTR>(win32_menu(state, entry.id, true));
+                           AppendMenuW(menu, flags | MF_POPUP, submenu, label.c_str());
+                           return;
+                       }
+
+                       if (!entry.key.empty())
+                       {
+                           label += L"\t" + widen(accelerator_label(entry));
+                       }
+
+                       AppendMenuW(menu, flags, static_cast<UINT_PTR>(entry.id), label.c_str());
+                   });
+
+        return menu;
+    }
+
+    LRESULT CALLBACK menu_proc(HWND hwnd, UINT message, WPARAM w_param, LPARAM l_param)
+    {
+        auto *const state = static_cast<menu_state *>(GetPropW(hwnd, menu_property));
+
+        // Menu commands have a zero notification code and no control
+        if (message == WM_COMMAND && HIWORD(w_param) == 0 && l_param == 0)
+        {
+            activate(*state, LOWORD(w_param));
+            return 0;
+        }
+
+        return CallWindowProcW(state->original, hwnd, message, w_param, l_param);
+    }
+
+    void apply_menu(saucerw_window &self)
+    {
+        auto &state      = self.menu;
+        auto *const hwnd = self.window->native<true>().hwnd;
+
+        if (!state->original)
+        {
+            SetPropW(hwnd, menu_property, state.get());
+            state->original = reinterpret_cast<WNDPROC>(
+                SetWindowLongPtrW(hwnd, GWLP_WNDPROC, reinterpret_cast<LONG_PTR>(menu_proc)));
+        }
+
+        auto *const previous = GetMenu(hwnd);
+
+        SetMenu(hwnd, state->entries.empty() ? nullptr : win32_menu(*state, 0, false));
+        DrawMenuBar(hwnd);
+
+        if (previous)
+        {
+            DestroyMenu(previous);
+        }
+    }
+#endif
+
+    enum : int
+    {
+        key_f4 = 0x100,
+        key_f11,
+        key_left,
+        key_right,
+        key_home,
+    };
+
+    // Whether kiosk mode swallows the key, an uppercase letter or one of the values above: the shortcuts leaving
+    // fullscreen, closing, opening windows, printing and navigating the history
+    bool kiosk_blocked(int key, bool control, bool alt)
+    {
+        if (key == key_f11)
+        {
+            return true;
+        }
+
+        if (alt)
+        {
+            return key == key_f4 || key == key_left || key == key_right || key == key_home;
+        }
+
+        return control && key < key_f4 && std::string_view{"WQNTOP"}.contains(static_cast<char>(key));
+    }
+
+#if defined(SAUCER_WEBKITGTK)
+    gboolean kiosk_key(GtkEventControllerKey *, guint value, guint, GdkModifierType state, gpointer)
+    {
+        int key{};
+
+        switch (value = gdk_keyval_to_upper(value))
+        {
+        case GDK_KEY_F4:
+            key = key_f4;
+            break;
+        case GDK_KEY_F11:
+            key = key_f11;
+            break;
+        case GDK_KEY_Left:
+            key = key_left;
+            break;
+        case GDK_KEY_Right:
+            key = key_right;
+            break;
+        case GDK_KEY_Home:
+            key = key_home;
+            break;
+        default:
+            key = value >= GDK_KEY_A && value <= GDK_KEY_Z ? static_cast<int>('A' + (value - GDK_KEY_A)) : 0;
+            break;
+        }
+
+        return kiosk_blocked(key, state & GDK_CONTROL_MASK, state & GDK_ALT_MASK);
+    }
+#elif defined(SAUCER_QT)
+    class kiosk_filter : public QObject
+    {
+        QWidget *m_window;
+
+      public:
+        kiosk_filter(QWidget *window) : m_window(window) {}
+
+      public:
+        bool eventFilter(QObject *watched, QEvent *event) override
+        {
+            if (event->type() != QEvent::KeyPress && event->type() != QEvent::ShortcutOverride)
+            {
+                return false;
+            }
+
+            auto *const widget = qobject_cast<QWidget *>(watched);
+
+            if (!widget || widget->window() != m_window)
+            {
+                return false;
+            }
+
+            auto *const press = static_cast<QKeyEvent *>(event);
+            int key{};
+
+            switch (press->key())
+            {
+            case Qt::Key_F4:
+                key = key_f4;
+                break;
+            case Qt::Key_F11:
+                key = key_f11;
+                break;
+            case Qt::Key_Left:
+                key = key_left;
+                break;
+            case Qt::Key_Right:
+                key = key_right;
+                break;
+            case Qt::Key_Home:
+                key = key_home;
+                break;
+            default:
+                key = press->key() >= Qt::Key_A && press->key() <= Qt::Key_Z ? press->key() : 0;
+                break;
+            }
+
+            const auto modifiers = press->modifiers();
+
+            if (!kiosk_blocked(key, modifiers & Qt::ControlModifier, modifiers & Qt::AltModifier))
+            {
+                return false;
+            }
+
+            // Accepting the override keeps shortcuts from firing, the key press that follows is dropped
+            event->accept();
+            return true;
+        }
+    };
+#elif defined(SAUCER_WEBVIEW2)
+    bool kiosk_blocked(UINT value)
+    {
+        int key{};
+
+        switch (value)
+        {
+        case VK_F4:
+            key = key_f4;
+            break;
+        case VK_F11:
+            key = key_f11;
+            break;
+        case VK_LEFT:
+            key = key_left;
+            break;
+        case VK_RIGHT:
+            key = key_right;
+            break;
+        case VK_HOME:
+            key = key_home;
+            break;
+        default:
+            key = value >= 'A' && value <= 'Z' ? static_cast<int>(value) : 0;
+            break;
+        }
+
+        return kiosk_blocked(key, GetKeyState(VK_CONTROL) & 0x8000, GetKeyState(VK_MENU) & 0x8000);
+    }
+#endif
+
+    std::vector<double> screen_scales([[maybe_unused]] std::size_t count)
+    {
+        std::vector<double> rtn;
+
+#if defined(SAUCER_WEBKITGTK)
+        auto *const monitors = gdk_display_get_monitors(gdk_display_get_default());
+
+        for (auto i = 0u; g_list_model_get_n_items(monitors) > i; ++i)
+        {
+            auto *const monitor = static_cast<GdkMonitor *>(g_list_model_get_item(monitors, i));
+
+            rtn.emplace_back(gdk_monitor_get_scale_factor(monitor));
+            g_object_unref(monitor);
+        }
+#elif defined(SAUCER_QT)
+        for (const auto *screen : QGuiApplication::screens())
+        {
+            rtn.emplace_back(screen->devicePixelRatio());
+        }
+#elif defined(SAUCER_WEBVIEW2)
+        // Enumerated in the order saucer lists the screens in
+        auto callback = [](HMONITOR monitor, HDC, LPRECT, LPARAM data) -> BOOL
+        {
+            UINT x{}, y{};
+
+            if (FAILED(GetDpiForMonitor(monitor, MDT_EFFECTIVE_DPI, &x, &y)))
+            {
+                x = USER_DEFAULT_SCREEN_DPI;
+            }
+
+            auto *const scales = reinterpret_cast<std::vector<double> *>(data);
+            scales->emplace_back(static_cast<double>(x) / USER_DEFAULT_SCREEN_DPI);
+
+            return TRUE;
+        };
+
+        EnumDisplayMonitors(nullptr, nullptr, callback, reinterpret_cast<LPARAM>(&rtn));
+#elif defined(SAUCER_WEBKIT)
+        rtn.resize(count);
+        saucerw_cocoa_screen_scales(rtn.data(), rtn.size());
+#endif
+
+        return rtn;
+    }
+
+    double window_scale(saucerw_window &self)
+    {
+#if defined(SAUCER_WEBKITGTK)
+        auto *const window = GTK_WIDGET(self.window->native<true>().window);
+
+#if GTK_CHECK_VERSION(4, 12, 0)
+        // The fractional scale is only known once the window has a surface
+        if (auto *const native = gtk_widget_get_native(window); native && gtk_native_get_surface(native))
+        {
+            return gdk_surface_get_scale(gtk_native_get_surface(native));
+        }
+#endif
+
+        return gtk_widget_get_scale_factor(window);
+#elif defined(SAUCER_QT)
+        return self.window->native<true>().window->devicePixelRatioF();
+#elif defined(SAUCER_WEBVIEW2)
+        return static_cast<double>(GetDpiForWindow(self.window->native<true>().hwnd)) / USER_DEFAULT_SCREEN_DPI;
+#elif defined(SAUCER_WEBKIT)
+        return saucerw_cocoa_window_scale(self.window->native<false>());
+#endif
+    }
+
+    // Reports the scale of the window if it changed since the last report
+    void report_scale(saucerw_window &self)
+    {
+        const auto scale = window_scale(self);
+
+        if (scale == self.scale)
+        {
+            return;
+        }
+
+        self.scale = scale;
+        saucerwWindowEvent(self.kiosk->events, SAUCERW_WINDOW_SCALE, static_cast<int>(std::lround(scale * 1000)), 0, 0);
+    }
+
+#if defined(SAUCER_WEBKITGTK)
+    void scale_notify(GObject *, GParamSpec *, gpointer data)
+    {
+        report_scale(*static_cast<saucerw_window *>(data));
+    }
+#elif defined(SAUCER_QT)
+    class scale_filter : public QObject
+    {
+        saucerw_window *m_window;
+
+      public:
+        scale_filter(saucerw_window *window) : m_window(window) {}
+
+      public:
+        bool eventFilter(QObject *, QEvent *event) override
+        {
+            switch (event->type())
+            {
+            case QEvent::ScreenChangeInternal:
+#if QT_VERSION >= QT_VERSION_CHECK(6, 6, 0)
+            case QEvent::DevicePixelRatioChange:
+#endif
+                report_scale(*m_window);
+                break;
+            default:
+                break;
+            }
+
+            return false;
+        }
+    };
+#endif
+
+#if !defined(SAUCER_WEBKIT)
+    struct clipboard_image
+    {
+        int w, h;
+        std::vector<uint8_t> pixels;
+    };
+
+    void deliver(uintptr_t handle, const std::optional<std::string> &text)
+    {
+        if (!text)
+        {
+            saucerwClipboardText(handle, nullptr, 0);
+            return;
+        }
+
+        saucerwClipboardText(handle, const_cast<char *>(text->data()), text->size());
+    }
+
+    void deliver(uintptr_t handle, std::optional<clipboard_image> image)
+    {
+        if (!image)
+        {
+            saucerwClipboardImage(handle, nullptr);
+            return;
+        }
+
+        saucerw_image rtn{.w = image->w, .h = image->h, .pixels = image->pixels.data()};
+        saucerwClipboardImage(handle, &rtn);
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+    GdkClipboard *clipboard()
+    {
+        return gdk_display_get_clipboard(gdk_display_get_default());
+    }
+
+    void read_text(GObject *source, GAsyncResult *result, gpointer data)
+    {
+        auto *const text = gdk_clipboard_read_text_finish(GDK_CLIPBOARD(source), result, nullptr);
+        const auto handle = reinterpret_cast<uintptr_t>(data);
+
+        deliver(handle, text ? std::optional<std::string>{text} : std::nullopt);
+        g_free(text);
+    }
+
+    void read_texture(GObject *source, GAsyncResult *result, gpointer data)
+    {
+        auto *const texture = gdk_clipboard_read_texture_finish(GDK_CLIPBOARD(source), result, nullptr);
+        const auto handle   = reinterpret_cast<uintptr_t>(data);
+
+        if (!texture)
+        {
+            deliver(handle, std::optional<clipboard_image>{});
+            return;
+        }
+
+        clipboard_image image{
+            .w = gdk_texture_get_width(texture),
+            .h = gdk_texture_get_height(texture),
+        };
+        image.pixels.resize(4uz * image.w * image.h);
+
+        auto *const downloader = gdk_texture_downloader_new(texture);
+        gdk_texture_downloader_set_format(downloader, GDK_MEMORY_R8G8B8A8);
+        gdk_texture_downloader_download_into(downloader, image.pixels.data(), 4uz * image.w);
+
+        gdk_texture_downloader_free(downloader);
+        g_object_unref(texture);
+
+        deliver(handle, std::move(image));
+    }
+#elif defined(SAUCER_QT)
+    QClipboard *clipboard()
+    {
+        return QGuiApplication::clipboard();
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    constexpr auto *clipboard_class = L"saucerw.clipboard";
+
+    std::string narrow(std::wstring_view value)
+    {
+        const auto size =
+            WideCharToMultiByte(CP_UTF8, 0, value.data(), static_cast<int>(value.size()), nullptr, 0, nullptr, nullptr);
+        std::string rtn(size, '\0');
+
+        WideCharToMultiByte(CP_UTF8, 0, value.data(), static_cast<int>(value.size()), rtn.data(), size, nullptr, nullptr);
+
+        return rtn;
+    }
+
+    LRESULT CALLBACK clipboard_proc(HWND hwnd, UINT message, WPARAM w_param, LPARAM l_param)
+    {
+        if (message != WM_CLIPBOARDUPDATE)
+        {
+            return DefWindowProcW(hwnd, message, w_param, l_param);
+        }
+
+        if (const auto handler = GetWindowLongPtrW(hwnd, GWLP_USERDATA); handler)
+        {
+            saucerwInvoke(static_cast<uintptr_t>(handler));
+        }
+
+        return 0;
+    }
+
+    // The message-only window owning the written contents and receiving the clipboard updates
+    HWND clipboard_window(saucerw_app &self)
+    {
+        if (self.clipboard)
+        {
+            return self.clipboard;
+        }
+
+        static const auto registered = []
+        {
+            const WNDCLASSW cls{
+                .lpfnWndProc   = clipboard_proc,
+                .hInstance     = GetModuleHandleW(nullptr),
+                .lpszClassName = clipboard_class,
+            };
+            return RegisterClassW(&cls) != 0;
+        }();
+
+        if (registered)
+        {
+            self.clipboard = CreateWindowExW(0, clipboard_class, L"", 0, 0, 0, 0, 0, HWND_MESSAGE, nullptr,
+                                             GetModuleHandleW(nullptr), nullptr);
+        }
+
+        return self.clipboard;
+    }
+
+    bool open_clipboard(HWND owner)
+    {
+        // Other applications hold the clipboard while they access it
+        for (auto i = 0; 10 > i; ++i)
+        {
+            if (OpenClipboard(owner))
+            {
+                return true;
+            }
+
+            Sleep(10);
+        }
+
+        return false;
+    }
+
+    void set_clipboard(HWND owner, UINT format, HGLOBAL memory)
+    {
+        if (!open_clipboard(owner))
+        {
+            GlobalFree(memory);
+            return;
+        }
+
+        EmptyClipboard();
+
+        if (!SetClipboardData(format, memory))
+        {
+            GlobalFree(memory);
+        }
+
+        CloseClipboard();
+    }
+
+    std::optional<std::string> clipboard_text(HWND owner)
+    {
+        if (!IsClipboardFormatAvailable(CF_UNICODETEXT) || !open_clipboard(owner))
+        {
+            return std::nullopt;
+        }
+
+        std::optional<std::string> rtn;
+
+        if (auto *const data = GetClipboardData(CF_UNICODETEXT); data)
+        {
+            if (const auto *const text = static_cast<const wchar_t *>(GlobalLock(data)); text)
+            {
+                rtn = narrow(text);
+                GlobalUnlock(data);
+            }
+        }
+
+        CloseClipboard();
+
+        return rtn;
+    }
+
+    // Converts a device independent bitmap of 24 or 32 bits per pixel, the clipboard synthesizes it from other
+    // bitmap formats
+    std::optional<clipboard_image> decode_dib(const uint8_t *data, std::size_t size)
+    {
+        if (sizeof(BITMAPINFOHEADER) > size)
+        {
+            return std::nullopt;
+        }
+
+        const auto *const header = reinterpret_cast<const BITMAPINFOHEADER *>(data);
+        const auto bits          = header->biBitCount;
+        const auto compression   = header->biCompression;
+
+        if ((bits != 24 && bits != 32) || (compression != BI_RGB && compression != BI_BITFIELDS) || header->biWidth <= 0)
+        {
+            return std::nullopt;
+        }
+
+        auto offset = header->biSize + header->biClrUsed * sizeof(RGBQUAD);
+
+        if (compression == BI_BITFIELDS && header->biSize == sizeof(BITMAPINFOHEADER))
+        {
+            offset += 3 * sizeof(DWORD);
+        }
+
+        const auto w      = static_cast<int>(header->biWidth);
+        const auto h      = static_cast<int>(std::abs(header->biHeight));
+        const auto stride = ((static_cast<std::size_t>(w) * bits + 31) / 32) * 4;
+
+        if (offset + stride * h > size)
+        {
+            return std::nullopt;
+        }
+
+        clipboard_image rtn{.w = w, .h = h};
+        rtn.pixels.resize(4uz * w * h);
+
+        bool transparent = true;
+
+        for (auto y = 0; h > y; ++y)
+        {
+            // Rows are stored bottom-up unless the height is negative
+            const auto row = header->biHeight < 0 ? y : h - 1 - y;
+            const auto *src = data + offset + stride * row;
+            auto *dst       = rtn.pixels.data() + 4uz * w * y;
+
+            for (auto x = 0; w > x; ++x, src += bits / 8, dst += 4)
+            {
+                dst[0] = src[2];
+                dst[1] = src[1];
+                dst[2] = src[0];
+                dst[3] = bits == 32 ? src[3] : 255;
+
+                transparent = transparent && dst[3] == 0;
+            }
+        }
+
+        // Most applications leave the alpha channel of 32 bit bitmaps empty
+        if (bits == 32 && transparent)
+        {
+            for (auto i = 3uz; rtn.pixels.size() > i; i += 4)
+            {
+                rtn.pixels[i] = 255;
+            }
+        }
+
+        return rtn;
+    }
+
+    std::optional<clipboard_image> clipboard_bitmap(HWND owner)
+    {
+        if (!IsClipboardFormatAvailable(CF_DIB) || !open_clipboard(owner))
+        {
+            return std::nullopt;
+        }
+
+        std::optional<clipboard_image> rtn;
+
+        if (auto *const data = GetClipboardData(CF_DIB); data)
+        {
+            if (const auto *const dib = static_cast<const uint8_t *>(GlobalLock(data)); dib)
+            {
+                rtn = decode_dib(dib, GlobalSize(data));
+                GlobalUnlock(data);
+            }
+        }
+
+        CloseClipboard();
+
+        return rtn;
+    }
+#endif
+
+    struct download_decision
+    {
+        bool cancel;
+        std::optional<std::string> path;
+        uintptr_t events;
+    };
+
+    download_decision decide_download(uintptr_t handler, const std::string &url, const std::string &name,
+                                      const std::string &mime, int64_t size)
+    {
+        saucerw_download_request request{
+            .url            = url.c_str(),
+            .suggested_name = name.c_str(),
+            .mime           = mime.c_str(),
+            .size           = size,
+        };
+
+        bool cancel{};
+        char *path{};
+
+        const auto events = saucerwDownload(handler, &request, &cancel, &path);
+        download_decision rtn{.cancel = cancel, .events = events};
+
+        if (path)
+        {
+            rtn.path.emplace(path);
+            std::free(path);
+        }
+
+        return rtn;
+    }
+
+    // Reports the progress and the end of a download, which is only reported once
+    struct download_tracker
+    {
+        uintptr_t events;
+        bool done{};
+
+        void progress(int64_t received, int64_t total) const
+        {
+            if (!done)
+            {
+                saucerwDownloadEvent(events, SAUCERW_DOWNLOAD_PROGRESS, received, total, nullptr, nullptr);
+            }
+        }
+
+        void end(saucerw_download_event event, int64_t received, int64_t total, std::string path = {},
+                 std::string reason = {})
+        {
+            if (std::exchange(done, true))
+            {
+                return;
+            }
+
+            saucerwDownloadEvent(events, event, received, total, path.data(), reason.data());
+        }
+    };
+
+#if defined(SAUCER_WEBKITGTK)
+    struct download_listener
+    {
+        WebKitWebView *webview;
+        uintptr_t handler;
+    };
+
+    int64_t download_size(WebKitDownload *download)
+    {
+        auto *const response = webkit_download_get_response(download);
+        const auto length    = response ? webkit_uri_response_get_content_length(response) : 0;
+
+        return length ? static_cast<int64_t>(length) : -1;
+    }
+
+    void track_download(WebKitDownload *download, uintptr_t events)
+    {
+        // Destroyed with the download, which ends it if it was still running
+        auto *const tracker = new download_tracker{.events = events};
+
+        g_object_set_data_full(G_OBJECT(download), "saucerw-download", tracker,
+                               +[](gpointer data)
+                               {
+                                   auto *const tracker = static_cast<download_tracker *>(data);
+                                   tracker->end(SAUCERW_DOWNLOAD_CANCELLED, 0, -1);
+                                   delete tracker;
+                               });
+
+        g_signal_connect(download, "received-data",
+                         G_CALLBACK(+[](WebKitDownload *download, guint64, gpointer data)
+                                    {
+                                        static_cast<download_tracker *>(data)->progress(
+                                            static_cast<int64_t>(webkit_download_get_received_data_length(download)),
+                                            download_size(download));
+                                    }),
+                         tracker);
+
+        // Emitted before finished for failed downloads
+        g_signal_connect(download, "failed",
+                         G_CALLBACK(+[](WebKitDownload *download, GError *error, gpointer data)
+                                    {
+                                        const auto received =
+                                            static_cast<int64_t>(webkit_download_get_received_data_length(download));
+                                        auto *const tracker = static_cast<download_tracker *>(data);
+
+                                        if (g_error_matches(error, WEBKIT_DOWNLOAD_ERROR,
+                                                            WEBKIT_DOWNLOAD_ERROR_CANCELLED_BY_USER))
+                                        {
+                                            tracker->end(SAUCERW_DOWNLOAD_CANCELLED, received, download_size(download));
+                                            return;
+                                        }
+
+                                        tracker->end(SAUCERW_DOWNLOAD_FAILED, received, download_size(download), {},
+                                                     error->message);
+                                    }),
+                         tracker);
+
+        g_signal_connect(download, "finished",
+                         G_CALLBACK(+[](WebKitDownload *download, gpointer data)
+                                    {
+                                        const auto *const destination = webkit_download_get_destination(download);
+                                        const auto received =
+                                            static_cast<int64_t>(webkit_download_get_received_data_length(download));
+
+                                        static_cast<download_tracker *>(data)->end(
+                                            SAUCERW_DOWNLOAD_FINISHED, received, download_size(download),
+                                            destination ? destination : "");
+                                    }),
+                         tracker);
+    }
+
+    gboolean decide_destination(WebKitDownload *download, gchar *suggested, gpointer data)
+    {
+        const auto *const uri  = webkit_uri_request_get_uri(webkit_download_get_request(download));
+        auto *const response   = webkit_download_get_response(download);
+        const auto *const mime = response ? webkit_uri_response_get_mime_type(response) : nullptr;
+
+        const auto decision = decide_download(reinterpret_cast<uintptr_t>(data), uri ? uri : "",
+                                              suggested ? suggested : "", mime ? mime : "", download_size(download));
+
+        if (decision.cancel)
+        {
+            webkit_download_cancel(download);
+            return TRUE;
+        }
+
+        if (decision.events)
+        {
+            track_download(download, decision.events);
+        }
+
+        if (!decision.path)
+        {
+            return FALSE;
+        }
+
+        webkit_download_set_allow_overwrite(download, TRUE);
+        webkit_download_set_destination(download, decision.path->c_str());
+
+        return TRUE;
+    }
+
+    void download_started(WebKitNetworkSession *, WebKitDownload *download, gpointer data)
+    {
+        const auto *const listener = static_cast<download_listener *>(data);
+
+        // The network session is shared by all webviews
+        if (webkit_download_get_web_view(download) != listener->webview)
+        {
+            return;
+        }
+
+        g_signal_connect(download, "decide-destination", G_CALLBACK(decide_destination),
+                         reinterpret_cast<gpointer>(listener->handler));
+    }
+#elif defined(SAUCER_QT)
+    void track_download(QWebEngineDownloadRequest *download, uintptr_t events)
+    {
+        auto tracker = std::make_shared<download_tracker>(download_tracker{.events = events});
+
+        QObject::connect(download, &QWebEngineDownloadRequest::receivedBytesChanged, download,
+                         [download, tracker] { tracker->progress(download->receivedBytes(), download->totalBytes()); });
+
+        QObject::connect(download, &QWebEngineDownloadRequest::isFinishedChanged, download,
+                         [download, tracker]
+                         {
+                             if (!download->isFinished())
+                             {
+                                 return;
+                             }
+
+                             const auto received = download->receivedBytes();
+                             const auto total    = download->totalBytes();
+
+                             switch (download->state())
+                             {
+                             case QWebEngineDownloadRequest::DownloadCompleted:
+                             {
+                                 const QDir directory{download->downloadDirectory()};
+                                 tracker->end(SAUCERW_DOWNLOAD_FINISHED, received, total,
+                                              directory.filePath(download->downloadFileName()).toStdString());
+                                 break;
+                             }
+                             case QWebEngineDownloadRequest::DownloadCancelled:
+                                 tracker->end(SAUCERW_DOWNLOAD_CANCELLED, received, total);
+                                 break;
+                             default:
+                                 tracker->end(SAUCERW_DOWNLOAD_FAILED, received, total, {},
+                                              download->interruptReasonString().toStdString());
+                                 break;
+                             }
+                         });
+
+        QObject::connect(download, &QObject::destroyed, [tracker] { tracker->end(SAUCERW_DOWNLOAD_CANCELLED, 0, -1); });
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    std::string take(LPWSTR value)
+    {
+        if (!value)
+        {
+            return {};
+        }
+
+        auto rtn = narrow(value);
+        CoTaskMemFree(value);
+
+        return rtn;
+    }
+
+    void track_download(ICoreWebView2DownloadOperation *operation, uintptr_t events)
+    {
+        auto tracker = std::make_shared<download_tracker>(download_tracker{.events = events});
+
+        auto progress = Microsoft::WRL::Callback<ICoreWebView2BytesReceivedChangedEventHandler>(
+            [tracker](ICoreWebView2DownloadOperation *download, IUnknown *)
+            {
+                INT64 received{}, total{};
+
+                download->get_BytesReceived(&received);
+                download->get_TotalBytesToReceive(&total);
+
+                tracker->progress(received, total);
+
+                return S_OK;
+            });
+
+        // Interrupted downloads may be resumed, they are reported as ended regardless
+        auto state = Microsoft::WRL::Callback<ICoreWebView2StateChangedEventHandler>(
+            [tracker](ICoreWebView2DownloadOperation *download, IUnknown *)
+            {
+                COREWEBVIEW2_DOWNLOAD_STATE state{};
+                download->get_State(&state);
+
+                if (state == COREWEBVIEW2_DOWNLOAD_STATE_IN_PROGRESS)
+                {
+                    return S_OK;
+                }
+
+                INT64 received{}, total{};
+
+                download->get_BytesReceived(&received);
+                download->get_TotalBytesToReceive(&total);
+
+                if (state == COREWEBVIEW2_DOWNLOAD_STATE_COMPLETED)
+                {
+                    LPWSTR path{};
+                    download->get_ResultFilePath(&path);
+
+                    tracker->end(SAUCERW_DOWNLOAD_FINISHED, received, total, take(path));
+                    return S_OK;
+                }
+
+                COREWEBVIEW2_DOWNLOAD_INTERRUPT_REASON reason{};
+                download->get_InterruptReason(&reason);
+
+                if (reason == COREWEBVIEW2_DOWNLOAD_INTERRUPT_REASON_USER_CANCELED)
+                {
+                    tracker->end(SAUCERW_DOWNLOAD_CANCELLED, received, total);
+                    return S_OK;
+                }
+
+                tracker->end(SAUCERW_DOWNLOAD_FAILED, received, total, {},
+                             "interrupt reason " + std::to_string(static_cast<int>(reason)));
+
+                return S_OK;
+            });
+
+        EventRegistrationToken token{};
+
+        operation->add_BytesReceivedChanged(progress.Get(), &token);
+        operation->add_StateChanged(state.Get(), &token);
+    }
+#endif
+
+    struct cookie
+    {
+        std::string name;
+        std::string value;
+        std::string domain;
+        std::string path;
+        std::int64_t expires;
+        bool secure;
+        bool http_only;
+        int same_site;
+    };
+
+    cookie copy(const saucerw_cookie &value)
+    {
+        return {
+            .name      = value.name,
+            .value     = value.value,
+            .domain    = value.domain,
+            .path      = value.path,
+            .expires   = value.expires,
+            .secure    = value.secure,
+            .http_only = value.http_only,
+            .same_site = value.same_site,
+        };
+    }
+
+    saucerw_cookie borrow(const cookie &value)
+    {
+        return {
+            .name      = value.name.c_str(),
+            .value     = value.value.c_str(),
+            .domain    = value.domain.c_str(),
+            .path      = value.path.c_str(),
+            .expires   = value.expires,
+            .secure    = value.secure,
+            .http_only = value.http_only,
+            .same_site = value.same_site,
+        };
+    }
+
+    void finish(uintptr_t handle, std::string error = {})
+    {
+        saucerwDone(handle, error.empty() ? nullptr : error.data());
+    }
+
+#if !defined(SAUCER_WEBKIT)
+    void deliver(uintptr_t handle, const std::vector<cookie> &cookies)
+    {
+        std::vector<saucerw_cookie> rtn;
+        rtn.reserve(cookies.size());
+
+        std::ranges::transform(cookies, std::back_inserter(rtn), borrow);
+
+        saucerwCookies(handle, rtn.size(), rtn.data(), nullptr);
+    }
+
+    void cookies_failed(uintptr_t handle, std::string error)
+    {
+        saucerwCookies(handle, 0, nullptr, error.data());
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+    WebKitNetworkSession *session(saucerw_webview &self)
+    {
+        return webkit_web_view_get_network_session(self.webview->native<true>().webview);
+    }
+
+    std::string message(GError *error)
+    {
+        if (!error)
+        {
+            return {};
+        }
+
+        std::string rtn = error->message;
+        g_error_free(error);
+
+        return rtn;
+    }
+
+    SoupCookie *native_cookie(const cookie &value)
+    {
+        auto *const rtn =
+            soup_cookie_new(value.name.c_str(), value.value.c_str(), value.domain.c_str(), value.path.c_str(), -1);
+
+        if (value.expires)
+        {
+            auto *const expires = g_date_time_new_from_unix_utc(value.expires);
+
+            soup_cookie_set_expires(rtn, expires);
+            g_date_time_unref(expires);
+        }
+
+        soup_cookie_set_secure(rtn, value.secure);
+        soup_cookie_set_http_only(rtn, value.http_only);
+
+        switch (value.same_site)
+        {
+        case SAUCERW_SAME_SITE_LAX:
+            soup_cookie_set_same_site_policy(rtn, SOUP_SAME_SITE_POLICY_LAX);
+            break;
+        case SAUCERW_SAME_SITE_STRICT:
+            soup_cookie_set_same_site_policy(rtn, SOUP_SAME_SITE_POLICY_STRICT);
+            break;
+        case SAUCERW_SAME_SITE_NONE:
+            soup_cookie_set_same_site_policy(rtn, SOUP_SAME_SITE_POLICY_NONE);
+            break;
+        }
+
+        return rtn;
+    }
+
+    cookie to_cookie(SoupCookie *value)
+    {
+        auto *const expires = soup_cookie_get_expires(value);
+
+        auto same_site = SAUCERW_SAME_SITE_NONE;
+
+        switch (soup_cookie_get_same_site_policy(value))
+        {
+        case SOUP_SAME_SITE_POLICY_LAX:
+            same_site = SAUCERW_SAME_SITE_LAX;
+            break;
+        case SOUP_SAME_SITE_POLICY_STRICT:
+            same_site = SAUCERW_SAME_SITE_STRICT;
+            break;
+        default:
+            break;
+        }
+
+        return {
+            .name      = soup_cookie_get_name(value),
+            .value     = soup_cookie_get_value(value),
+            .domain    = soup_cookie_get_domain(value),
+            .path      = soup_cookie_get_path(value),
+            .expires   = expires ? g_date_time_to_unix(expires) : 0,
+            .secure    = static_cast<bool>(soup_cookie_get_secure(value)),
+            .http_only = static_cast<bool>(soup_cookie_get_http_only(value)),
+            .same_site = same_site,
+        };
+    }
+
+    void got_cookies(GObject *source, GAsyncResult *result, gpointer data)
+    {
+        GError *error{};
+
+        auto *const list  = webkit_cookie_manager_get_all_cookies_finish(WEBKIT_COOKIE_MANAGER(source), result, &error);
+        const auto handle = reinterpret_cast<uintptr_t>(data);
+
+        if (error)
+        {
+            cookies_failed(handle, message(error));
+            return;
+        }
+
+        std::vector<cookie> cookies;
+
+        for (auto *it = list; it; it = it->next)
+        {
+            cookies.emplace_back(to_cookie(static_cast<SoupCookie *>(it->data)));
+        }
+
+        g_list_free_full(list, reinterpret_cast<GDestroyNotify>(soup_cookie_free));
+
+        deliver(handle, cookies);
+    }
+
+    template <typename T, gboolean (*Finish)(T *, GAsyncResult *, GError **)>
+    void finished(GObject *source, GAsyncResult *result, gpointer data)
+    {
+        GError *error{};
+        Finish(reinterpret_cast<T *>(source), result, &error);
+
+        finish(reinterpret_cast<uintptr_t>(data), message(error));
+    }
+
+    WebKitWebsiteDataTypes website_data(int kinds)
+    {
+        int rtn{};
+
+        if (kinds & SAUCERW_DATA_CACHE)
+        {
+            rtn |= WEBKIT_WEBSITE_DATA_MEMORY_CACHE | WEBKIT_WEBSITE_DATA_DISK_CACHE | WEBKIT_WEBSITE_DATA_DOM_CACHE;
+        }
+
+        if (kinds & SAUCERW_DATA_COOKIES)
+        {
+            rtn |= WEBKIT_WEBSITE_DATA_COOKIES;
+        }
+
+        if (kinds & SAUCERW_DATA_LOCAL_STORAGE)
+        {
+            rtn |= WEBKIT_WEBSITE_DATA_LOCAL_STORAGE | WEBKIT_WEBSITE_DATA_SESSION_STORAGE;
+        }
+
+        if (kinds & SAUCERW_DATA_INDEXED_DB)
+        {
+            rtn |= WEBKIT_WEBSITE_DATA_INDEXEDDB_DATABASES;
+        }
+
+        if (kinds & SAUCERW_DATA_SERVICE_WORKERS)
+        {
+            rtn |= WEBKIT_WEBSITE_DATA_SERVICE_WORKER_REGISTRATIONS;
+        }
+
+        return static_cast<WebKitWebsiteDataTypes>(rtn);
+    }
+#elif defined(SAUCER_QT)
+    QNetworkCookie native_cookie(const cookie &value)
+    {
+        QNetworkCookie rtn{QByteArray::fromStdString(value.name), QByteArray::fromStdString(value.value)};
+
+        rtn.setDomain(QString::fromStdString(value.domain));
+        rtn.setPath(QString::fromStdString(value.path));
+        rtn.setSecure(value.secure);
+        rtn.setHttpOnly(value.http_only);
+
+        if (value.expires)
+        {
+            rtn.setExpirationDate(QDateTime::fromSecsSinceEpoch(value.expires));
+        }
+
+        switch (value.same_site)
+        {
+        case SAUCERW_SAME_SITE_LAX:
+            rtn.setSameSitePolicy(QNetworkCookie::SameSite::Lax);
+            break;
+        case SAUCERW_SAME_SITE_STRICT:
+            rtn.setSameSitePolicy(QNetworkCookie::SameSite::Strict);
+            break;
+        case SAUCERW_SAME_SITE_NONE:
+            rtn.setSameSitePolicy(QNetworkCookie::SameSite::None);
+            break;
+        }
+
+        return rtn;
+    }
+
+    cookie to_cookie(const QNetworkCookie &value)
+    {
+        auto same_site = SAUCERW_SAME_SITE_UNSET;
+
+        switch (value.sameSitePolicy())
+        {
+        case QNetworkCookie::SameSite::Lax:
+            same_site = SAUCERW_SAME_SITE_LAX;
+            break;
+        case QNetworkCookie::SameSite::Strict:
+            same_site = SAUCERW_SAME_SITE_STRICT;
+            break;
+        case QNetworkCookie::SameSite::None:
+            same_site = SAUCERW_SAME_SITE_NONE;
+            break;
+        default:
+            break;
+        }
+
+        return {
+            .name      = value.name().toStdString(),
+            .value     = value.value().toStdString(),
+            .domain    = value.domain().toStdString(),
+            .path      = value.path().toStdString(),
+            .expires   = value.isSessionCookie() ? 0 : value.expirationDate().toSecsSinceEpoch(),
+            .secure    = value.isSecure(),
+            .http_only = value.isHttpOnly(),
+            .same_site = same_site,
+        };
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    std::string hresult(HRESULT result)
+    {
+        return std::format("HRESULT {:#010x}", static_cast<std::uint32_t>(result));
+    }
+
+    template <typename T>
+    Microsoft::WRL::ComPtr<T> revision(saucerw_webview &self)
+    {
+        Microsoft::WRL::ComPtr<ICoreWebView2> core;
+        self.webview->native<true>().controller->get_CoreWebView2(&core);
+
+        Microsoft::WRL::ComPtr<T> rtn;
+
+        if (core)
+        {
+            core.As(&rtn);
+        }
+
+        return rtn;
+    }
+
+    Microsoft::WRL::ComPtr<ICoreWebView2CookieManager> cookie_manager(saucerw_webview &self)
+    {
+        Microsoft::WRL::ComPtr<ICoreWebView2CookieManager> rtn;
+
+        // The cookie manager is available since the second revision of the interface
+        if (auto webview = revision<ICoreWebView2_2>(self); webview)
+        {
+            webview->get_CookieManager(&rtn);
+        }
+
+        return rtn;
+    }
+
+    Microsoft::WRL::ComPtr<ICoreWebView2Cookie> native_cookie(ICoreWebView2CookieManager *manager, const cookie &value)
+    {
+        Microsoft::WRL::ComPtr<ICoreWebView2Cookie> rtn;
+
+        if (FAILED(manager->CreateCookie(widen(value.name).c_str(), widen(value.value).c_str(),
+                                         widen(value.domain).c_str(), widen(value.path).c_str(), &rtn)))
+        {
+            return nullptr;
+        }
+
+        if (value.expires)
+        {
+            rtn->put_Expires(static_cast<double>(value.expires));
+        }
+
+        rtn->put_IsSecure(value.secure);
+        rtn->put_IsHttpOnly(value.http_only);
+
+        switch (value.same_site)
+        {
+        case SAUCERW_SAME_SITE_LAX:
+            rtn->put_SameSite(COREWEBVIEW2_COOKIE_SAME_SITE_KIND_LAX);
+            break;
+        case SAUCERW_SAME_SITE_STRICT:
+            rtn->put_SameSite(COREWEBVIEW2_COOKIE_SAME_SITE_KIND_STRICT);
+            break;
+        case SAUCERW_SAME_SITE_NONE:
+            rtn->put_SameSite(COREWEBVIEW2_COOKIE_SAME_SITE_KIND_NONE);
+            break;
+        }
+
+        return rtn;
+    }
+
+    cookie to_cookie(ICoreWebView2Cookie *value)
+    {
+        LPWSTR name{}, content{}, domain{}, path{};
+
+        value->get_Name(&name);
+        value->get_Value(&content);
+        value->get_Domain(&domain);
+        value->get_Path(&path);
+
+        double expires{};
+        BOOL session{}, secure{}, http_only{};
+        COREWEBVIEW2_COOKIE_SAME_SITE_KIND kind{};
+
+        value->get_Expires(&expires);
+        value->get_IsSession(&session);
+        value->get_IsSecure(&secure);
+        value->get_IsHttpOnly(&http_only);
+        value->get_SameSite(&kind);
+
+        auto same_site = SAUCERW_SAME_SITE_NONE;
+
+        switch (kind)
+        {
+        case COREWEBVIEW2_COOKIE_SAME_SITE_KIND_LAX:
+            same_site = SAUCERW_SAME_SITE_LAX;
+            break;
+        case COREWEBVIEW2_COOKIE_SAME_SITE_KIND_STRICT:
+            same_site = SAUCERW_SAME_SITE_STRICT;
+            break;
+        default:
+            break;
+        }
+
+        return {
+            .name      = take(name),
+            .value     = take(content),
+            .domain    = take(domain),
+            .path      = take(path),
+            .expires   = session ? 0 : static_cast<std::int64_t>(expires),
+            .secure    = static_cast<bool>(secure),
+            .http_only = static_cast<bool>(http_only),
+            .same_site = same_site,
+        };
+    }
+
+    COREWEBVIEW2_BROWSING_DATA_KINDS browsing_data(int kinds)
+    {
+        int rtn{};
+
+        if (kinds & SAUCERW_DATA_CACHE)
+        {
+            rtn |= COREWEBVIEW2_BROWSING_DATA_KINDS_DISK_CACHE | COREWEBVIEW2_BROWSING_DATA_KINDS_CACHE_STORAGE;
+        }
+
+        if (kinds & SAUCERW_DATA_COOKIES)
+        {
+            rtn |= COREWEBVIEW2_BROWSING_DATA_KINDS_COOKIES;
+        }
+
+        if (kinds & SAUCERW_DATA_LOCAL_STORAGE)
+        {
+            rtn |= COREWEBVIEW2_BROWSING_DATA_KINDS_LOCAL_STORAGE;
+        }
+
+        if (kinds & SAUCERW_DATA_INDEXED_DB)
+        {
+            rtn |= COREWEBVIEW2_BROWSING_DATA_KINDS_INDEXED_DB;
+        }
+
+        if (kinds & SAUCERW_DATA_SERVICE_WORKERS)
+        {
+            rtn |= COREWEBVIEW2_BROWSING_DATA_KINDS_SERVICE_WORKERS;
+        }
+
+        return static_cast<COREWEBVIEW2_BROWSING_DATA_KINDS>(rtn);
+    }
+#endif
+#if defined(SAUCER_WEBKITGTK)
+    gboolean authenticate(WebKitWebView *, WebKitAuthenticationRequest *request, gpointer data)
+    {
+        if (!webkit_authentication_request_is_for_proxy(request))
+        {
+            return FALSE;
+        }
+
+        char *user{}, *password{};
+        auto *const host = const_cast<char *>(webkit_authentication_request_get_host(request));
+
+        if (!saucerwCredentials(reinterpret_cast<uintptr_t>(data), host, &user, &password))
+        {
+            return FALSE;
+        }
+
+        auto *const credential = webkit_credential_new(user, password, WEBKIT_CREDENTIAL_PERSISTENCE_FOR_SESSION);
+        webkit_authentication_request_authenticate(request, credential);
+
+        webkit_credential_free(credential);
+        std::free(user);
+        std::free(password);
+
+        return TRUE;
+    }
+
+    gboolean tls_error(WebKitWebView *webview, gchar *uri, GTlsCertificate *certificate, GTlsCertificateFlags,
+                       gpointer data)
+    {
+        std::string pem;
+
+        for (auto *it = certificate; it; it = g_tls_certificate_get_issuer(it))
+        {
+            gchar *encoded{};
+            g_object_get(it, "certificate-pem", &encoded, nullptr);
+
+            if (encoded)
+            {
+                pem += encoded;
+                g_free(encoded);
+            }
+        }
+
+        if (!saucerwCertificate(reinterpret_cast<uintptr_t>(data), uri, pem.data(), pem.size()))
+        {
+            return FALSE;
+        }
+
+        auto *const parsed = g_uri_parse(uri, G_URI_FLAGS_NONE, nullptr);
+
+        if (!parsed)
+        {
+            return FALSE;
+        }
+
+        // Only page loads report their certificate, the page is loaded again once it is allowed
+        webkit_network_session_allow_tls_certificate_for_host(webkit_web_view_get_network_session(webview),
+                                                              certificate, g_uri_get_host(parsed));
+        g_uri_unref(parsed);
+
+        webkit_web_view_load_uri(webview, uri);
+
+        return TRUE;
+    }
+
+    void filter_saved(GObject *source, GAsyncResult *result, gpointer data)
+    {
+        auto *const webview = static_cast<WebKitWebView *>(data);
+
+        GError *error{};
+        auto *const filter =
+            webkit_user_content_filter_store_save_finish(WEBKIT_USER_CONTENT_FILTER_STORE(source), result, &error);
+
+        if (filter)
+        {
+            webkit_user_content_manager_add_filter(webkit_web_view_get_user_content_manager(webview), filter);
+            webkit_user_content_filter_unref(filter);
+        }
+        else
+        {
+            log(SAUCERW_LOG_ERROR, "network", "allowed hosts filter failed to compile: " + message(error));
+        }
+
+        g_object_unref(webview);
+    }
+
+    std::string apply_network(saucerw_webview &self, const saucerw_network_options &network)
+    {
+        auto *const webview = self.webview->native<true>().webview;
+        auto *const session = webkit_web_view_get_network_session(webview);
+
+        if (network.proxy)
+        {
+            std::vector<const char *> ignore{network.bypass_hosts, network.bypass_hosts + network.bypass};
+            ignore.emplace_back(nullptr);
+
+            auto *const settings = webkit_network_proxy_settings_new(network.proxy, ignore.data());
+
+            webkit_network_session_set_proxy_settings(session, WEBKIT_NETWORK_PROXY_MODE_CUSTOM, settings);
+            webkit_network_proxy_settings_free(settings);
+        }
+
+        if (network.credentials)
+        {
+            g_signal_connect(webview, "authenticate", G_CALLBACK(authenticate),
+                             reinterpret_cast<gpointer>(network.credentials));
+        }
+
+        if (network.certificates)
+        {
+            g_signal_connect(webview, "load-failed-with-tls-errors", G_CALLBACK(tls_error),
+                             reinterpret_cast<gpointer>(network.certificates));
+        }
+
+        if (network.content_rules)
+        {
+            const std::string_view rules{network.content_rules};
+            const auto identifier = std::format("allowed-hosts-{:x}", std::hash<std::string_view>{}(rules));
+
+            auto *const path   = g_build_filename(g_get_user_cache_dir(), "saucerw", "filters", nullptr);
+            auto *const store  = webkit_user_content_filter_store_new(path);
+            auto *const source = g_bytes_new(rules.data(), rules.size());
+
+            webkit_user_content_filter_store_save(store, identifier.c_str(), source, nullptr, filter_saved,
+                                                  g_object_ref(webview));
+
+            g_bytes_unref(source);
+            g_object_unref(store);
+            g_free(path);
+        }
+
+        return {};
+    }
+#elif defined(SAUCER_QT)
+    struct request_filter : QWebEngineUrlRequestInterceptor
+    {
+        uintptr_t handler;
+
+      public:
+        request_filter(uintptr_t handler, QObject *parent)
+            : QWebEngineUrlRequestInterceptor(parent), handler(handler)
+        {
+        }
+
+        void interceptRequest(QWebEngineUrlRequestInfo &info) override
+        {
+            auto url = info.requestUrl().toString().toStdString();
+
+            if (!saucerwRequest(handler, url.data()))
+            {
+                info.block(true);
+            }
+        }
+    };
+
+    std::string apply_network(saucerw_webview &self, const saucerw_network_options &network)
+    {
+        auto *const page = self.webview->native<true>().webview->page();
+
+        if (network.credentials)
+        {
+            QObject::connect(page, &QWebEnginePage::proxyAuthenticationRequired, page,
+                             [handler = network.credentials](const QUrl &, QAuthenticator *auth, const QString &host)
+                             {
+                                 char *user{}, *password{};
+                                 auto name = host.toStdString();
+
+                                 if (!saucerwCredentials(handler, name.data(), &user, &password))
+                                 {
+                                     return;
+                                 }
+
+                                 auth->setUser(QString::fromUtf8(user));
+                                 auth->setPassword(QString::fromUtf8(password));
+
+                                 std::free(user);
+                                 std::free(password);
+                             });
+        }
+
+        if (network.certificates)
+        {
+            QObject::connect(page, &QWebEnginePage::certificateError, page,
+                             [handler = network.certificates](QWebEngineCertificateError error)
+                             {
+                                 std::string pem;
+
+                                 for (const auto &certificate : error.certificateChain())
+                                 {
+                                     pem += certificate.toPem().toStdString();
+                                 }
+
+                                 auto url = error.url().toString().toStdString();
+
+                                 if (saucerwCertificate(handler, url.data(), pem.data(), pem.size()))
+                                 {
+                                     error.acceptCertificate();
+                                     return;
+                                 }
+
+                                 error.rejectCertificate();
+                             });
+        }
+
+        if (network.requests)
+        {
+            page->setUrlRequestInterceptor(new request_filter{network.requests, page});
+        }
+
+        return {};
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    std::string apply_network(saucerw_webview &self, const saucerw_network_options &network)
+    {
+        if (network.certificates)
+        {
+            auto webview = revision<ICoreWebView2_14>(self);
+
+            // Certificate errors are reported since the fourteenth revision of the interface
+            if (!webview)
+            {
+                return "certificate error handling needs a newer WebView2 runtime";
+            }
+
+            using handler_t = ICoreWebView2ServerCertificateErrorDetectedEventHandler;
+            using args_t    = ICoreWebView2ServerCertificateErrorDetectedEventArgs;
+
+            auto callback = Microsoft::WRL::Callback<handler_t>(
+                [handler = network.certificates](ICoreWebView2 *, args_t *args)
+                {
+                    LPWSTR uri{}, leaf{};
+                    Microsoft::WRL::ComPtr<ICoreWebView2Certificate> certificate;
+                    Microsoft::WRL::ComPtr<ICoreWebView2StringCollection> chain;
+
+                    args->get_RequestUri(&uri);
+                    args->get_ServerCertificate(&certificate);
+
+                    auto url = take(uri);
+                    std::string pem;
+
+                    if (certificate && SUCCEEDED(certificate->ToPemEncoding(&leaf)))
+                    {
+                        pem += take(leaf);
+                    }
+
+                    if (certificate && SUCCEEDED(certificate->get_PemEncodedIssuerCertificateChain(&chain)))
+                    {
+                        UINT count{};
+                        chain->get_Count(&count);
+
+                        for (UINT i = 0; count > i; ++i)
+                        {
+                            LPWSTR issuer{};
+
+                            if (SUCCEEDED(chain->GetValueAtIndex(i, &issuer)))
+                            {
+                                pem += take(issuer);
+                            }
+                        }
+                    }
+
+                    const auto accepted = saucerwCertificate(handler, url.data(), pem.data(), pem.size());
+                    args->put_Action(accepted ? COREWEBVIEW2_SERVER_CERTIFICATE_ERROR_ACTION_ALWAYS_ALLOW
+                                              : COREWEBVIEW2_SERVER_CERTIFICATE_ERROR_ACTION_DEFAULT);
+
+                    return S_OK;
+                });
+
+            EventRegistrationToken token{};
+            webview->add_ServerCertificateErrorDetected(callback.Get(), &token);
+        }
+
+        if (network.requests)
+        {
+            Microsoft::WRL::ComPtr<ICoreWebView2Environment> environment;
+
+            if (auto webview = revision<ICoreWebView2_2>(self); webview)
+            {
+                webview->get_Environment(&environment);
+            }
+
+            auto webview = revision<ICoreWebView2>(self);
+
+            if (!environment || !webview)
+            {
+                return "allowed hosts need a newer WebView2 runtime";
+            }
+
+            auto callback = Microsoft::WRL::Callback<ICoreWebView2WebResourceRequestedEventHandler>(
+                [handler = network.requests, environment](ICoreWebView2 *,
+                                                          ICoreWebView2WebResourceRequestedEventArgs *args)
+                {
+                    Microsoft::WRL::ComPtr<ICoreWebView2WebResourceRequest> request;
+                    args->get_Request(&request);
+
+                    LPWSTR uri{};
+                    request->get_Uri(&uri);
+
+                    if (auto url = take(uri); saucerwRequest(handler, url.data()))
+                    {
+                        return S_OK;
+                    }
+
+                    Microsoft::WRL::ComPtr<ICoreWebView2WebResourceResponse> response;
+                    environment->CreateWebResourceResponse(nullptr, 403, L"Forbidden", L"", &response);
+
+                    args->put_Response(response.Get());
+
+                    return S_OK;
+                });
+
+            EventRegistrationToken token{};
+
+            webview->AddWebResourceRequestedFilter(L"*", COREWEBVIEW2_WEB_RESOURCE_CONTEXT_ALL);
+            webview->add_WebResourceRequested(callback.Get(), &token);
+        }
+
+        return {};
+    }
+#elif defined(SAUCER_WEBKIT)
+    std::string apply_network(saucerw_webview &self, const saucerw_network_options &network)
+    {
+        if (!network.proxy && !network.content_rules)
+        {
+            return {};
+        }
+
+        char *user{}, *password{};
+
+        // The proxy is configured before any load, the credentials are asked for upfront
+        if (network.credentials)
+        {
+            saucerwCredentials(network.credentials, const_cast<char *>(network.proxy_host), &user, &password);
+        }
+
+        saucerw_cocoa_apply_network(self.webview->native<false>(), &network, user, password);
+
+        std::free(user);
+        std::free(password);
+
+        return {};
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK) || defined(SAUCER_QT)
+    // Splits the comma separated tags, replacing the dashes with separator
+    std::vector<std::string> tags(const char *list, char separator = '-')
+    {
+        std::vector<std::string> rtn;
+
+        for (const auto part : std::views::split(std::string_view{list}, ','))
+        {
+            auto tag = std::string{std::string_view{part}};
+            std::ranges::replace(tag, '-', separator);
+
+            rtn.emplace_back(std::move(tag));
+        }
+
+        return rtn;
+    }
+#endif
+
+    // Applies the autoplay policy, WebView2 takes it as a flag of the browser and WebKit on macOS leaves it to the
+    // script of the policy. Muted media needs no gesture on WebKitGTK, which cannot tell it apart
+    void apply_autoplay([[maybe_unused]] saucerw_webview &self, [[maybe_unused]] saucerw_autoplay autoplay)
+    {
+        if (autoplay == SAUCERW_AUTOPLAY_DEFAULT)
+        {
+            return;
+        }
+
+#if defined(SAUCER_WEBKITGTK)
+        auto *const settings = webkit_web_view_get_settings(self.webview->native<true>().webview);
+        webkit_settings_set_media_playback_requires_user_gesture(settings, autoplay == SAUCERW_AUTOPLAY_BLOCK);
+#elif defined(SAUCER_QT)
+        auto *const settings = self.webview->native<true>().webview->page()->settings();
+        settings->setAttribute(QWebEngineSettings::PlaybackRequiresUserGesture, autoplay != SAUCERW_AUTOPLAY_ALLOW);
+#endif
+    }
+
+    // Applies the languages of the pages, WebView2 takes them as flags of the browser and WebKit follows the system
+    void apply_locales([[maybe_unused]] saucerw_webview &self, [[maybe_unused]] const saucerw_webview_options &options)
+    {
+#if defined(SAUCER_WEBKITGTK)
+        // Set on the web context, which the webviews of the application share
+        auto *const context = webkit_web_view_get_context(self.webview->native<true>().webview);
+
+        const auto apply = [](const char *list, char separator, auto &&setter)
+        {
+            auto values = tags(list, separator);
+
+            std::vector<const gchar *> ptrs;
+            std::ranges::transform(values, std::back_inserter(ptrs), &std::string::c_str);
+            ptrs.emplace_back(nullptr);
+
+            setter(ptrs.data());
+        };
+
+        if (options.locales)
+        {
+            apply(options.locales, '-',
+                  [context](const gchar *const *values) { webkit_web_context_set_preferred_languages(context, values); });
+        }
+
+        if (options.spellcheck)
+        {
+            webkit_web_context_set_spell_checking_enabled(context, TRUE);
+
+            // Enchant names the dictionaries like the locales of the environment
+            apply(options.spellcheck, '_', [context](const gchar *const *values)
+                  { webkit_web_context_set_spell_checking_languages(context, values); });
+        }
+#elif defined(SAUCER_QT)
+        auto *const profile = self.webview->native<true>().webview->page()->profile();
+
+        if (options.locales)
+        {
+            profile->setHttpAcceptLanguage(QString::fromUtf8(options.locales));
+        }
+
+        if (options.spellcheck)
+        {
+            QStringList languages;
+
+            for (const auto &tag : tags(options.spellcheck))
+            {
+                languages.append(QString::fromStdString(tag));
+            }
+
+            // The dictionaries are looked up in the qtwebengine_dictionaries directory
+            profile->setSpellCheckLanguages(languages);
+            profile->setSpellCheckEnabled(true);
+        }
+#endif
+    }
+#if defined(SAUCER_QT)
+    Qt::KeyboardModifiers qt_modifiers(int modifiers)
+    {
+        Qt::KeyboardModifiers rtn;
+
+#if defined(Q_OS_MACOS)
+        // Qt maps Ctrl to the Command key on macOS, like key_sequence
+        constexpr auto ctrl  = Qt::MetaModifier;
+        constexpr auto super = Qt::ControlModifier;
+#else
+        constexpr auto ctrl  = Qt::ControlModifier;
+        constexpr auto super = Qt::MetaModifier;
+#endif
+
+        if (modifiers & SAUCERW_MOD_CTRL)
+        {
+            rtn |= ctrl;
+        }
+
+        if (modifiers & SAUCERW_MOD_SHIFT)
+        {
+            rtn |= Qt::ShiftModifier;
+        }
+
+        if (modifiers & SAUCERW_MOD_ALT)
+        {
+            rtn |= Qt::AltModifier;
+        }
+
+        if (modifiers & SAUCERW_MOD_SUPER)
+        {
+            rtn |= super;
+        }
+
+        return rtn;
+    }
+
+    // The widget of Qt WebEngine receiving the input of the view
+    QWidget *input_target(saucerw_webview &self)
+    {
+        auto *const webview = self.webview->native<true>().webview;
+        auto *const proxy   = webview->focusProxy();
+
+        return proxy ? proxy : webview;
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    std::string json_string(std::string_view value)
+    {
+        std::string rtn{'"'};
+
+        for (const auto c : value)
+        {
+            if (c == '"' || c == '\\')
+            {
+                rtn += '\\';
+                rtn += c;
+            }
+            else if (static_cast<unsigned char>(c) < 0x20)
+            {
+                rtn += std::format("\\u{:04x}", static_cast<int>(c));
+            }
+            else
+            {
+                rtn += c;
+            }
+        }
+
+        return rtn + '"';
+    }
+
+    // The modifiers of the input events of the DevTools protocol: Alt, Ctrl, Meta and Shift
+    int devtools_modifiers(int modifiers)
+    {
+        auto rtn = 0;
+
+        if (modifiers & SAUCERW_MOD_ALT)
+        {
+            rtn |= 1;
+        }
+
+        if (modifiers & SAUCERW_MOD_CTRL)
+        {
+            rtn |= 2;
+        }
+
+        if (modifiers & SAUCERW_MOD_SUPER)
+        {
+            rtn |= 4;
+        }
+
+        if (modifiers & SAUCERW_MOD_SHIFT)
+        {
+            rtn |= 8;
+        }
+
+        return rtn;
+    }
+
+    // Input sent through the DevTools protocol reaches the page as trusted events, whether or not it is focused
+    bool devtools(saucerw_webview &self, const wchar_t *method, const std::string &params)
+    {
+        auto webview = revision<ICoreWebView2>(self);
+
+        if (!webview)
+        {
+            return false;
+        }
+
+        auto callback = Microsoft::WRL::Callback<ICoreWebView2CallDevToolsProtocolMethodCompletedHandler>(
+            [](HRESULT, LPCWSTR) { return S_OK; });
+
+        return SUCCEEDED(webview->CallDevToolsProtocolMethod(method, widen(params).c_str(), callback.Get()));
+    }
+#endif
+
+#if !defined(SAUCER_WEBKIT)
+    void captured(uintptr_t handle, const void *png, std::size_t size)
+    {
+        saucerwCapture(handle, static_cast<uint8_t *>(const_cast<void *>(png)), size, nullptr);
+    }
+
+    void capture_failed(uintptr_t handle, std::string error)
+    {
+        saucerwCapture(handle, nullptr, 0, error.data());
+    }
+#endif
+
+#if defined(SAUCER_QT)
+    QPageLayout page_layout(const saucerw_print_options &options)
+    {
+        const auto orientation = options.landscape ? QPageLayout::Landscape : QPageLayout::Portrait;
+
+        if (!options.width)
+        {
+            return {QPageSize{QPageSize::A4}, orientation, QMarginsF{10, 10, 10, 10}, QPageLayout::Millimeter};
+        }
+
+        return {QPageSize{QSizeF{options.width, options.height}, QPageSize::Inch}, orientation,
+                QMarginsF{options.left, options.top, options.right, options.bottom}, QPageLayout::Inch};
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+    struct print_job
+    {
+        uintptr_t handle;
+        std::string error;
+    };
+
+    GtkPageSetup *page_setup(const saucerw_print_options &options)
+    {
+        auto *const rtn = gtk_page_setup_new();
+        gtk_page_setup_set_orientation(rtn, options.landscape ? GTK_PAGE_ORIENTATION_LANDSCAPE
+                                                              : GTK_PAGE_ORIENTATION_PORTRAIT);
+
+        if (!options.width)
+        {
+            return rtn;
+        }
+
+        auto *const paper =
+            gtk_paper_size_new_custom("saucerw", "Custom", options.width, options.height, GTK_UNIT_INCH);
+
+        // Setting the paper resets the margins to its defaults
+        gtk_page_setup_set_paper_size(rtn, paper);
+        gtk_paper_size_free(paper);
+
+        gtk_page_setup_set_top_margin(rtn, options.top, GTK_UNIT_INCH);
+        gtk_page_setup_set_right_margin(rtn, options.right, GTK_UNIT_INCH);
+        gtk_page_setup_set_bottom_margin(rtn, options.bottom, GTK_UNIT_INCH);
+        gtk_page_setup_set_left_margin(rtn, options.left, GTK_UNIT_INCH);
+
+        return rtn;
+    }
+
+    void print_failed(WebKitPrintOperation *, GError *error, gpointer data)
+    {
+        // The error is owned by the operation
+        static_cast<print_job *>(data)->error = error->message;
+    }
+
+    void print_finished(WebKitPrintOperation *operation, gpointer data)
+    {
+        auto *const job = static_cast<print_job *>(data);
+
+        finish(job->handle, job->error);
+        delete job;
+
+        g_object_unref(operation);
+    }
+
+    void snapshot_taken(GObject *source, GAsyncResult *result, gpointer data)
+    {
+        GError *error{};
+
+        auto *const texture = webkit_web_view_get_snapshot_finish(WEBKIT_WEB_VIEW(source), result, &error);
+        const auto handle   = reinterpret_cast<uintptr_t>(data);
+
+        if (!texture)
+        {
+            capture_failed(handle, message(error));
+            return;
+        }
+
+        auto *const bytes = gdk_texture_save_to_png_bytes(texture);
+
+        gsize size{};
+        const auto *const png = g_bytes_get_data(bytes, &size);
+
+        captured(handle, png, size);
+
+        g_bytes_unref(bytes);
+        g_object_unref(texture);
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    Microsoft::WRL::ComPtr<ICoreWebView2PrintSettings> print_settings(saucerw_webview &self,
+                                                                      const saucerw_print_options &options)
+    {
+        Microsoft::WRL::ComPtr<ICoreWebView2Environment> environment;
+        Microsoft::WRL::ComPtr<ICoreWebView2Environment6> factory;
+
+        if (auto webview = revision<ICoreWebView2_2>(self); webview)
+        {
+            webview->get_Environment(&environment);
+        }
+
+        Microsoft::WRL::ComPtr<ICoreWebView2PrintSettings> rtn;
+
+        if (!environment || FAILED(environment.As(&factory)) || FAILED(factory->CreatePrintSettings(&rtn)))
+        {
+            return nullptr;
+        }
+
+        rtn->put_Orientation(options.landscape ? COREWEBVIEW2_PRINT_ORIENTATION_LANDSCAPE
+                                               : COREWEBVIEW2_PRINT_ORIENTATION_PORTRAIT);
+        rtn->put_ShouldPrintBackgrounds(options.background);
+
+        if (options.width)
+        {
+            rtn->put_PageWidth(options.width);
+            rtn->put_PageHeight(options.height);
+
+            rtn->put_MarginTop(options.top);
+            rtn->put_MarginRight(options.right);
+            rtn->put_MarginBottom(options.bottom);
+            rtn->put_MarginLeft(options.left);
+        }
+
+        return rtn;
+    }
+#endif
+
+    // Reports scheme to the handler of the application unless it was the last one reported
+    void scheme_changed(saucerw_app &self, int scheme)
+    {
+        if (scheme == self.scheme)
+        {
+            return;
+        }
+
+        self.scheme = scheme;
+        saucerwColorScheme(self.scheme_handler, scheme);
+    }
+
+#if defined(SAUCER_WEBKITGTK)
+    constexpr auto *portal_name        = "org.freedesktop.portal.Desktop";
+    constexpr auto *portal_path        = "/org/freedesktop/portal/desktop";
+    constexpr auto *settings_interface = "org.freedesktop.portal.Settings";
+
+    // The portal reports 1 for dark, 2 for light and 0 without a preference
+    std::optional<int> portal_scheme(GVariant *value)
+    {
+        if (!g_variant_is_of_type(value, G_VARIANT_TYPE_UINT32))
+        {
+            return std::nullopt;
+        }
+
+        switch (g_variant_get_uint32(value))
+        {
+        case 1:
+            return SAUCERW_SCHEME_DARK;
+        case 2:
+            return SAUCERW_SCHEME_LIGHT;
+        default:
+            return std::nullopt;
+        }
+    }
+
+    // Without a portal preference the GTK settings decide, a dark theme is marked by its name
+    int settings_scheme()
+    {
+        gboolean dark{};
+        gchar *theme{};
+
+        g_object_get(gtk_settings_get_default(), "gtk-application-prefer-dark-theme", &dark, "gtk-theme-name", &theme,
+                     nullptr);
+
+        if (theme)
+        {
+            auto *const lower = g_ascii_strdown(theme, -1);
+            dark              = dark || std::strstr(lower, "dark");
+
+            g_free(lower);
+            g_free(theme);
+        }
+
+        return dark ? SAUCERW_SCHEME_DARK : SAUCERW_SCHEME_LIGHT;
+    }
+
+    int color_scheme(GDBusConnection *bus)
+    {
+        if (!bus)
+        {
+            return settings_scheme();
+        }
+
+        auto *const reply = g_dbus_connection_call_sync(
+            bus, portal_name, portal_path, settings_interface, "ReadOne",
+            g_variant_new("(ss)", "org.freedesktop.appearance", "color-scheme"), G_VARIANT_TYPE("(v)"),
+            G_DBUS_CALL_FLAGS_NONE, 1000, nullptr, nullptr);
+
+        if (!reply)
+        {
+            return settings_scheme();
+        }
+
+        GVariant *value{};
+        g_variant_get(reply, "(v)", &value);
+
+        const auto rtn = portal_scheme(value);
+
+        g_variant_unref(value);
+        g_variant_unref(reply);
+
+        return rtn.value_or(settings_scheme());
+    }
+#elif defined(SAUCER_QT)
+    int color_scheme()
+    {
+        const auto scheme = QGuiApplication::styleHints()->colorScheme();
+        return scheme == Qt::ColorScheme::Dark ? SAUCERW_SCHEME_DARK : SAUCERW_SCHEME_LIGHT;
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    constexpr auto *personalize = L"Software\\Microsoft\\Windows\\CurrentVersion\\Themes\\Personalize";
+
+    int color_scheme()
+    {
+        DWORD light{};
+        DWORD size = sizeof(light);
+
+        if (RegGetValueW(HKEY_CURRENT_USER, personalize, L"AppsUseLightTheme", RRF_RT_REG_DWORD, nullptr, &light,
+                         &size) != ERROR_SUCCESS)
+        {
+            return SAUCERW_SCHEME_LIGHT;
+        }
+
+        return light ? SAUCERW_SCHEME_LIGHT : SAUCERW_SCHEME_DARK;
+    }
+
+    // Windows broadcasts no setting change to message-only windows, the watcher waits for changes of the registry key
+    // at path instead and posts changed to the event loop until stop is set
+    void watch_registry(saucerw_app &self, const wchar_t *path, bool subtree, HANDLE &stop, std::thread &watcher,
+                        void (*changed)(saucerw_app &))
+    {
+        HKEY key{};
+
+        if (RegOpenKeyExW(HKEY_CURRENT_USER, path, 0, KEY_NOTIFY | KEY_QUERY_VALUE, &key) != ERROR_SUCCESS)
+        {
+            return;
+        }
+
+        stop    = CreateEventW(nullptr, TRUE, FALSE, nullptr);
+        watcher = std::thread{
+            [&self, key, stop, subtree, changed]
+            {
+                auto *const event = CreateEventW(nullptr, FALSE, FALSE, nullptr);
+                const std::array<HANDLE, 2> events{stop, event};
+
+                while (RegNotifyChangeKeyValue(key, subtree, REG_NOTIFY_CHANGE_LAST_SET, event, TRUE) == ERROR_SUCCESS)
+                {
+                    if (WaitForMultipleObjects(2, events.data(), FALSE, INFINITE) != WAIT_OBJECT_0 + 1)
+                    {
+                        break;
+                    }
+
+                    self.app->post([&self, changed] { changed(self); });
+                }
+
+                CloseHandle(event);
+                RegCloseKey(key);
+            }};
+    }
+
+    void watch_color_scheme(saucerw_app &self)
+    {
+        watch_registry(self, personalize, false, self.scheme_stop, self.scheme_watcher,
+                       [](saucerw_app &self) { scheme_changed(self, color_scheme()); });
+    }
+#endif
+
+    // Reports locales to the handler of the application unless they were the last ones reported
+    void locales_changed(saucerw_app &self, std::string locales)
+    {
+        if (locales == self.locales)
+        {
+            return;
+        }
+
+        self.locales = std::move(locales);
+        saucerwLocales(self.locale_handler, self.locales.data());
+    }
+
+    // The preferred languages of the user as comma separated BCP 47 tags
+#if defined(SAUCER_WEBKITGTK)
+    std::string system_locales()
+    {
+        std::vector<std::string> tags;
+
+        // The names of the environment, e.g. de_DE.UTF-8, de_DE, de.UTF-8, de and C
+        for (const auto *const *name = g_get_language_names(); *name; ++name)
+        {
+            const std::string_view value{*name};
+
+            if (value == "C" || value == "POSIX" || value.find_first_of(".@") != std::string_view::npos)
+            {
+                continue;
+            }
+
+            auto tag = std::string{value};
+            std::ranges::replace(tag, '_', '-');
+
+            if (std::ranges::find(tags, tag) == tags.end())
+            {
+                tags.emplace_back(std::move(tag));
+            }
+        }
+
+        std::string rtn;
+
+        for (const auto &tag : tags)
+        {
+            rtn += (rtn.empty() ? "" : ",") + tag;
+        }
+
+        return rtn;
+    }
+#elif defined(SAUCER_QT)
+    std::string system_locales()
+    {
+        return QLocale::system().uiLanguages().join(',').toStdString();
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    constexpr auto *international = L"Control Panel\\International";
+
+    std::string system_locales()
+    {
+        ULONG count{}, size{};
+
+        if (!GetUserPreferredUILanguages(MUI_LANGUAGE_NAME, &count, nullptr, &size))
+        {
+            return {};
+        }
+
+        std::wstring buffer(size, L'\0');
+
+        if (!GetUserPreferredUILanguages(MUI_LANGUAGE_NAME, &count, buffer.data(), &size))
+        {
+            return {};
+        }
+
+        // A list of strings, each terminated by a null character, ending with an empty one
+        std::string rtn;
+
+        for (const auto *name = buffer.data(); *name; name += std::wcslen(name) + 1)
+        {
+            rtn += (rtn.empty() ? "" : ",") + narrow(name);
+        }
+
+        return rtn;
+    }
+#elif defined(SAUCER_WEBKIT)
+    std::string system_locales()
+    {
+        auto *const locales = saucerw_cocoa_locales();
+        std::string rtn{locales};
+
+        std::free(locales);
+
+        return rtn;
+    }
+#endif
+
+    // Reports flags to the handler of the application unless they were the last ones reported
+    void accessibility_changed(saucerw_app &self, int flags)
+    {
+        if (flags == self.accessibility)
+        {
+            return;
+        }
+
+        self.accessibility = flags;
+        saucerwAccessibility(self.accessibility_handler, flags);
+    }
+
+#if defined(SAUCER_WEBKITGTK)
+    constexpr auto *a11y_name       = "org.a11y.Bus";
+    constexpr auto *a11y_path       = "/org/a11y/bus";
+    constexpr auto *properties_name = "org.freedesktop.DBus.Properties";
+
+    // The screen reader setting of the desktop, which AT-SPI keeps in the status of its bus
+    bool screen_reader(GDBusConnection *bus)
+    {
+        if (!bus)
+        {
+            return false;
+        }
+
+        auto *const reply = g_dbus_connection_call_sync(
+            bus, a11y_name, a11y_path, properties_name, "Get",
+            g_variant_new("(ss)", "org.a11y.Status", "ScreenReaderEnabled"), G_VARIANT_TYPE("(v)"),
+            G_DBUS_CALL_FLAGS_NONE, 1000, nullptr, nullptr);
+
+        if (!reply)
+        {
+            return false;
+        }
+
+        GVariant *value{};
+        g_variant_get(reply, "(v)", &value);
+
+        const bool rtn = g_variant_is_of_type(value, G_VARIANT_TYPE_BOOLEAN) && g_variant_get_boolean(value);
+
+        g_variant_unref(value);
+        g_variant_unref(reply);
+
+        return rtn;
+    }
+
+    // The portal reports 1 for a preference of higher contrast, without a portal the name of the GTK theme decides
+    bool high_contrast(GDBusConnection *bus)
+    {
+        auto *const reply =
+            bus ? g_dbus_connection_call_sync(bus, portal_name, portal_path, settings_interface, "ReadOne",
+                                              g_variant_new("(ss)", "org.freedesktop.appearance", "contrast"),
+                                              G_VARIANT_TYPE("(v)"), G_DBUS_CALL_FLAGS_NONE, 1000, nullptr, nullptr)
+                : nullptr;
+
+        if (reply)
+        {
+            GVariant *value{};
+            g_variant_get(reply, "(v)", &value);
+
+            const auto typed = g_variant_is_of_type(value, G_VARIANT_TYPE_UINT32);
+            const auto rtn   = typed && g_variant_get_uint32(value) == 1;
+
+            g_variant_unref(value);
+            g_variant_unref(reply);
+
+            if (typed)
+            {
+                return rtn;
+            }
+        }
+
+        gchar *theme{};
+        g_object_get(gtk_settings_get_default(), "gtk-theme-name", &theme, nullptr);
+
+        const bool rtn = theme && std::strstr(theme, "HighContrast");
+        g_free(theme);
+
+        return rtn;
+    }
+
+    int system_accessibility(GDBusConnection *bus)
+    {
+        gboolean animations{TRUE};
+        g_object_get(gtk_settings_get_default(), "gtk-enable-animations", &animations, nullptr);
+
+        int rtn{};
+
+        if (screen_reader(bus))
+        {
+            rtn |= SAUCERW_ACCESSIBILITY_SCREEN_READER;
+        }
+
+        if (!animations)
+        {
+            rtn |= SAUCERW_ACCESSIBILITY_REDUCED_MOTION;
+        }
+
+        if (high_contrast(bus))
+        {
+            rtn |= SAUCERW_ACCESSIBILITY_HIGH_CONTRAST;
+        }
+
+        return rtn;
+    }
+#elif defined(SAUCER_QT)
+    // Qt reports whether an assistive technology uses the application, it knows no motion or contrast preferences
+    int system_accessibility()
+    {
+        return QAccessible::isActive() ? SAUCERW_ACCESSIBILITY_SCREEN_READER : 0;
+    }
+
+    class activation_observer : public QAccessible::ActivationObserver
+    {
+        saucerw_app *self;
+
+      public:
+        activation_observer(saucerw_app *self) : self(self) {}
+
+      public:
+        void accessibilityActiveChanged(bool) override
+        {
+            accessibility_changed(*self, system_accessibility());
+        }
+    };
+#elif defined(SAUCER_WEBVIEW2)
+    int system_accessibility()
+    {
+        BOOL reader{}, animations{TRUE};
+        HIGHCONTRASTW contrast{.cbSize = sizeof(HIGHCONTRASTW)};
+
+        int rtn{};
+
+        if (SystemParametersInfoW(SPI_GETSCREENREADER, 0, &reader, 0) && reader)
+        {
+            rtn |= SAUCERW_ACCESSIBILITY_SCREEN_READER;
+        }
+
+        if (SystemParametersInfoW(SPI_GETCLIENTAREAANIMATION, 0, &animations, 0) && !animations)
+        {
+            rtn |= SAUCERW_ACCESSIBILITY_REDUCED_MOTION;
+        }
+
+        if (SystemParametersInfoW(SPI_GETHIGHCONTRAST, sizeof(contrast), &contrast, 0) &&
+            (contrast.dwFlags & HCF_HIGHCONTRASTON))
+        {
+            rtn |= SAUCERW_ACCESSIBILITY_HIGH_CONTRAST;
+        }
+
+        return rtn;
+    }
+
+    // The changes are broadcast as WM_SETTINGCHANGE to top-level windows only and screen readers set their flag
+    // without touching the registry, the watcher polls the flags every two seconds instead until stopped
+    void watch_accessibility(saucerw_app &self)
+    {
+        self.accessibility_stop    = CreateEventW(nullptr, TRUE, FALSE, nullptr);
+        self.accessibility_watcher = std::thread{
+            [&self, stop = self.accessibility_stop]
+            {
+                while (WaitForSingleObject(stop, 2000) == WAIT_TIMEOUT)
+                {
+                    self.app->post([&self] { accessibility_changed(self, system_accessibility()); });
+                }
+            }};
+    }
+#elif defined(SAUCER_WEBKIT)
+    int system_accessibility()
+    {
+        return saucerw_cocoa_accessibility();
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+    constexpr auto *logind_name    = "org.freedesktop.login1";
+    constexpr auto *logind_path    = "/org/freedesktop/login1";
+    constexpr auto *logind_manager = "org.freedesktop.login1.Manager";
+
+    // Takes the delay inhibitor lock of logind, which holds the shutdown after PrepareForShutdown until it is closed
+    void inhibit_shutdown(saucerw_app &self)
+    {
+        if (!self.system_bus || self.inhibitor >= 0)
+        {
+            return;
+        }
+
+        const auto *const who = g_get_prgname() ? g_get_prgname() : "saucerw";
+        GUnixFDList *fds{};
+
+        auto *const reply = g_dbus_connection_call_with_unix_fd_list_sync(
+            self.system_bus, logind_name, logind_path, logind_manager, "Inhibit",
+            g_variant_new("(ssss)", "shutdown", who, "Saving state", "delay"), G_VARIANT_TYPE("(h)"),
+            G_DBUS_CALL_FLAGS_NONE, 1000, nullptr, &fds, nullptr, nullptr);
+
+        if (!reply)
+        {
+            return;
+        }
+
+        gint32 index{};
+        g_variant_get(reply, "(h)", &index);
+
+        // The list closes its own descriptor
+        self.inhibitor = g_unix_fd_list_get(fds, index, nullptr);
+
+        g_object_unref(fds);
+        g_variant_unref(reply);
+    }
+
+    void logind_signal(GDBusConnection *, const gchar *, const gchar *, const gchar *, const gchar *signal,
+                       GVariant *parameters, gpointer data)
+    {
+        auto *const self = static_cast<saucerw_app *>(data);
+
+        if (!g_variant_is_of_type(parameters, G_VARIANT_TYPE("(b)")))
+        {
+            return;
+        }
+
+        gboolean start{};
+        g_variant_get(parameters, "(b)", &start);
+
+        if (std::string_view{signal} == "PrepareForSleep")
+        {
+            saucerwPower(self->power_handler, start ? SAUCERW_POWER_SUSPEND : SAUCERW_POWER_RESUME);
+            return;
+        }
+
+        if (!start)
+        {
+            // The shutdown was cancelled, hold the next one too
+            inhibit_shutdown(*self);
+            return;
+        }
+
+        saucerwPower(self->power_handler, SAUCERW_POWER_SHUTDOWN);
+    }
+
+    // The screen savers of the desktops report whether they lock the screen, GNOME under its own name
+    void screensaver_signal(GDBusConnection *, const gchar *, const gchar *, const gchar *iface, const gchar *,
+                            GVariant *parameters, gpointer data)
+    {
+        auto *const self = static_cast<saucerw_app *>(data);
+        const std::string_view name{iface};
+
+        if (name != "org.freedesktop.ScreenSaver" && name != "org.gnome.ScreenSaver")
+        {
+            return;
+        }
+
+        if (!g_variant_is_of_type(parameters, G_VARIANT_TYPE("(b)")))
+        {
+            return;
+        }
+
+        gboolean active{};
+        g_variant_get(parameters, "(b)", &active);
+
+        if (static_cast<bool>(active) == self->locked)
+        {
+            return;
+        }
+
+        self->locked = active;
+        saucerwPower(self->power_handler, active ? SAUCERW_POWER_LOCK : SAUCERW_POWER_UNLOCK);
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    constexpr auto *power_class = L"saucerw-power";
+
+    LRESULT CALLBACK power_proc(HWND hwnd, UINT message, WPARAM w_param, LPARAM l_param)
+    {
+        auto *const self = reinterpret_cast<saucerw_app *>(GetWindowLongPtrW(hwnd, GWLP_USERDATA));
+
+        if (!self)
+        {
+            return DefWindowProcW(hwnd, message, w_param, l_param);
+        }
+
+        switch (message)
+        {
+        case WM_POWERBROADCAST:
+            if (w_param == PBT_APMSUSPEND)
+            {
+                saucerwPower(self->power_handler, SAUCERW_POWER_SUSPEND);
+            }
+            else if (w_param == PBT_APMRESUMEAUTOMATIC)
+            {
+                saucerwPower(self->power_handler, SAUCERW_POWER_RESUME);
+            }
+            return TRUE;
+        case WM_WTSSESSION_CHANGE:
+            if (w_param == WTS_SESSION_LOCK)
+            {
+                saucerwPower(self->power_handler, SAUCERW_POWER_LOCK);
+            }
+            else if (w_param == WTS_SESSION_UNLOCK)
+            {
+                saucerwPower(self->power_handler, SAUCERW_POWER_UNLOCK);
+            }
+            return 0;
+        case WM_QUERYENDSESSION:
+            if (!self->shutdown_pending)
+            {
+                self->shutdown_pending = true;
+                ShutdownBlockReasonCreate(hwnd, L"Saving state");
+                saucerwPower(self->power_handler, SAUCERW_POWER_SHUTDOWN);
+            }
+            return TRUE;
+        case WM_ENDSESSION: {
+            // The process may be terminated once this returns, so the messages are processed here until the handlers
+            // released the shutdown
+            MSG msg{};
+
+            while (w_param && self->shutdown_pending && GetMessageW(&msg, nullptr, 0, 0) > 0)
+            {
+                TranslateMessage(&msg);
+                DispatchMessageW(&msg);
+            }
+
+            return 0;
+        }
+        }
+
+        return DefWindowProcW(hwnd, message, w_param, l_param);
+    }
+
+    HWND power_window(saucerw_app &self)
+    {
+        static const auto registered = []
+        {
+            const WNDCLASSW cls{
+                .lpfnWndProc   = power_proc,
+                .hInstance     = GetModuleHandleW(nullptr),
+                .lpszClassName = power_class,
+            };
+            return RegisterClassW(&cls) != 0;
+        }();
+
+        if (!registered)
+        {
+            return nullptr;
+        }
+
+        auto *const rtn = CreateWindowExW(WS_EX_TOOLWINDOW, power_class, L"", WS_OVERLAPPED, 0, 0, 0, 0, nullptr,
+                                          nullptr, GetModuleHandleW(nullptr), nullptr);
+
+        if (rtn)
+        {
+            SetWindowLongPtrW(rtn, GWLP_USERDATA, reinterpret_cast<LONG_PTR>(&self));
+            WTSRegisterSessionNotification(rtn, NOTIFY_FOR_THIS_SESSION);
+        }
+
+        return rtn;
+    }
+#endif
+
+    // The paths are only valid during the call, the handler copies them
+    void dropped(uintptr_t handle, const std::vector<std::string> &paths, int x, int y)
+    {
+        if (paths.empty())
+        {
+            return;
+        }
+
+        std::vector<char *> raw;
+        raw.reserve(paths.size());
+
+        for (const auto &path : paths)
+        {
+            raw.emplace_back(const_cast<char *>(path.c_str()));
+        }
+
+        saucerwDrop(handle, raw.data(), raw.size(), x, y);
+    }
+
+#if defined(SAUCER_WEBKITGTK)
+    constexpr auto *drop_key = "saucerw-drop";
+
+    struct pending_drop
+    {
+        uintptr_t handle;
+        int x, y;
+    };
+
+    void read_drop(GObject *source, GAsyncResult *result, gpointer data)
+    {
+        auto drop         = std::unique_ptr<pending_drop>{static_cast<pending_drop *>(data)};
+        const auto *value = gdk_drop_read_value_finish(GDK_DROP(source), result, nullptr);
+
+        if (!value)
+        {
+            return;
+        }
+
+        std::vector<std::string> paths;
+
+        for (auto *it = static_cast<GSList *>(g_value_get_boxed(value)); it; it = it->next)
+        {
+            auto *const path = g_file_get_path(G_FILE(it->data));
+
+            // Files without a local path, e.g. from a remote share, cannot be reported
+            if (!path)
+            {
+                continue;
+            }
+
+            paths.emplace_back(path);
+            g_free(path);
+        }
+
+        dropped(drop->handle, paths, drop->x, drop->y);
+    }
+
+    // WebKit accepts drops through a GtkDropTargetAsync of the web view, whose "drop" handler claims them. The
+    // emission hook runs before it and reads the files alongside WebKit, which keeps handling the drop.
+    gboolean drop_hook(GSignalInvocationHint *, guint count, const GValue *values, gpointer)
+    {
+        if (count < 4)
+        {
+            return TRUE;
+        }
+
+        auto *const target = GTK_EVENT_CONTROLLER(g_value_get_object(&values[0]));
+        auto *const widget = gtk_event_controller_get_widget(target);
+        const auto handle  = reinterpret_cast<uintptr_t>(g_object_get_data(G_OBJECT(widget), drop_key));
+
+        if (!handle)
+        {
+            return TRUE;
+        }
+
+        auto *const drop = GDK_DROP(g_value_get_object(&values[1]));
+
+        if (!gdk_content_formats_contain_gtype(gdk_drop_get_formats(drop), GDK_TYPE_FILE_LIST))
+        {
+            return TRUE;
+        }
+
+        auto *const pending = new pending_drop{
+            .handle = handle,
+            .x      = static_cast<int>(g_value_get_double(&values[2])),
+            .y      = static_cast<int>(g_value_get_double(&values[3])),
+        };
+
+        gdk_drop_read_value_async(drop, GDK_TYPE_FILE_LIST, G_PRIORITY_DEFAULT, nullptr, read_drop, pending);
+
+        return TRUE;
+    }
+#elif defined(SAUCER_QT)
+    // Watches the drops onto the web view without consuming them, the page still receives them
+    class drop_filter : public QObject
+    {
+        uintptr_t m_handle;
+
+      public:
+        drop_filter(uintptr_t handle, QObject *parent) : QObject(parent), m_handle(handle) {}
+
+      public:
+        bool eventFilter(QObject *, QEvent *event) override
+        {
+            if (event->type() != QEvent::Drop)
+            {
+                return false;
+            }
+
+            auto *const drop = static_cast<QDropEvent *>(event);
+            std::vector<std::string> paths;
+
+            for (const auto &url : drop->mimeData()->urls())
+            {
+                if (url.isLocalFile())
+                {
+                    paths.emplace_back(url.toLocalFile().toStdString());
+                }
+            }
+
+            const auto position = drop->position().toPoint();
+            dropped(m_handle, paths, position.x(), position.y());
+
+            return false;
+        }
+    };
+#elif defined(SAUCER_WEBVIEW2)
+    // The drop script posts the File objects of the drop, only the additional objects of a message carry their paths
+    void posted_drop(uintptr_t handle, ICoreWebView2WebMessageReceivedEventArgs *args)
+    {
+        LPWSTR raw{};
+
+        if (FAILED(args->TryGetWebMessageAsString(&raw)))
+        {
+            return;
+        }
+
+        const auto message = take(raw);
+        int x{}, y{};
+
+        if (std::sscanf(message.c_str(), R"({"saucer:drop":true,"x":%d,"y":%d})", &x, &y) != 2)
+        {
+            return;
+        }
+
+        Microsoft::WRL::ComPtr<ICoreWebView2WebMessageReceivedEventArgs2> extended;
+        Microsoft::WRL::ComPtr<ICoreWebView2ObjectCollectionView> objects;
+
+        if (FAILED(args->QueryInterface(IID_PPV_ARGS(&extended))) || FAILED(extended->get_AdditionalObjects(&objects)))
+        {
+            return;
+        }
+
+        UINT32 count{};
+        objects->get_Count(&count);
+
+        std::vector<std::string> paths;
+
+        for (UINT32 i = 0; count > i; ++i)
+        {
+            Microsoft::WRL::ComPtr<IUnknown> object;
+            Microsoft::WRL::ComPtr<ICoreWebView2File> file;
+
+            if (FAILED(objects->GetValueAtIndex(i, &object)) || FAILED(object.As(&file)))
+            {
+                continue;
+            }
+
+            LPWSTR path{};
+
+            if (SUCCEEDED(file->get_Path(&path)))
+            {
+                paths.emplace_back(take(path));
+            }
+        }
+
+        dropped(handle, paths, x, y);
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+    struct context_target
+    {
+        uintptr_t handler;
+        uintptr_t click;
+    };
+
+    void webkit_menu(const std::shared_ptr<menu_state> &state, WebKitContextMenu *menu, int32_t parent)
+    {
+        each_child(*state, parent,
+                   [&](const menu_entry &entry)
+                   {
+                       if (entry.separator)
+                       {
+                           webkit_context_menu_append(menu, webkit_context_menu_item_new_separator());
+                           return;
+                       }
+
+                       const auto label = escape(entry.label, '_');
+
+                       if (has_children(*state, entry.id))
+                       {
+                           auto *const submenu = webkit_context_menu_new();
+                           webkit_menu(state, submenu, entry.id);
+
+                           auto *const item = webkit_context_menu_item_new_with_submenu(label.c_str(), submenu);
+                           webkit_context_menu_append(menu, item);
+                           g_object_unref(submenu);
+                           return;
+                       }
+
+                       auto *const action = gtk_item_action(state, entry);
+
+                       webkit_context_menu_append(
+                           menu, webkit_context_menu_item_new_from_gaction(G_ACTION(action), label.c_str(), nullptr));
+                       g_object_unref(action);
+                   });
+    }
+
+    gboolean context_menu(WebKitWebView *, WebKitContextMenu *menu, WebKitHitTestResult *hit, gpointer data)
+    {
+        const auto *target = static_cast<context_target *>(data);
+
+        // The hit test tells neither the position nor the selected text
+        saucerw_context context{
+            .link     = webkit_hit_test_result_get_link_uri(hit),
+            .image    = webkit_hit_test_result_get_image_uri(hit),
+            .media    = webkit_hit_test_result_get_media_uri(hit),
+            .editable = static_cast<bool>(webkit_hit_test_result_context_is_editable(hit)),
+            .partial  = true,
+        };
+
+        saucerw_context_menu custom{std::make_shared<menu_state>()};
+        custom.state->handle = target->click;
+
+        if (!saucerwContextMenu(target->handler, &context, &custom))
+        {
+            return FALSE;
+        }
+
+        webkit_context_menu_remove_all(menu);
+        webkit_menu(custom.state, menu, 0);
+
+        // Handling the signal without items shows no menu
+        return webkit_context_menu_get_n_items(menu) == 0;
+    }
+#elif defined(SAUCER_QT)
+    void context_menu(QWebEngineView *view, uintptr_t handler, uintptr_t click, const QPoint &position)
+    {
+        const auto *request = view->lastContextMenuRequest();
+
+        if (!request)
+        {
+            return;
+        }
+
+        const auto link      = request->linkUrl().toString().toStdString();
+        const auto source    = request->mediaUrl().toString().toStdString();
+        const auto selection = request->selectedText().toStdString();
+        const auto image     = request->mediaType() == QWebEngineContextMenuRequest::MediaTypeImage;
+        const auto media     = request->mediaType() == QWebEngineContextMenuRequest::MediaTypeVideo ||
+                           request->mediaType() == QWebEngineContextMenuRequest::MediaTypeAudio;
+
+        saucerw_context context{
+            .x         = position.x(),
+            .y         = position.y(),
+            .link      = link.c_str(),
+            .image     = image ? source.c_str() : "",
+            .media     = media ? source.c_str() : "",
+            .selection = selection.c_str(),
+            .editable  = request->isContentEditable(),
+        };
+
+        saucerw_context_menu custom{std::make_shared<menu_state>()};
+        custom.state->handle = click;
+
+        QMenu *menu{};
+
+        if (!saucerwContextMenu(handler, &context, &custom))
+        {
+            // What the web view does for the default context menu policy
+            menu = view->createStandardContextMenu();
+        }
+        else if (!custom.state->entries.empty())
+        {
+            menu = new QMenu{view};
+            populate(custom.state, menu, 0);
+        }
+
+        if (menu)
+        {
+            menu->setAttribute(Qt::WA_DeleteOnClose);
+            menu->popup(view->mapToGlobal(position));
+        }
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    void context_menu(saucerw_webview &self, uintptr_t handler, uintptr_t click,
+                      ICoreWebView2ContextMenuRequestedEventArgs *args)
+    {
+        Microsoft::WRL::ComPtr<ICoreWebView2ContextMenuTarget> target;
+
+        if (FAILED(args->get_ContextMenuTarget(&target)))
+        {
+            return;
+        }
+
+        COREWEBVIEW2_CONTEXT_MENU_TARGET_KIND kind{};
+        BOOL has_link{}, has_source{}, has_selection{}, editable{};
+        POINT location{};
+
+        target->get_Kind(&kind);
+        target->get_HasLinkUri(&has_link);
+        target->get_HasSourceUri(&has_source);
+        target->get_HasSelection(&has_selection);
+        target->get_IsEditable(&editable);
+        args->get_Location(&location);
+
+        LPWSTR raw{};
+        std::string link, source, selection;
+
+        if (has_link && SUCCEEDED(target->get_LinkUri(&raw)))
+        {
+            link = take(raw);
+        }
+
+        if (has_source && SUCCEEDED(target->get_SourceUri(&raw)))
+        {
+            source = take(raw);
+        }
+
+        if (has_selection && SUCCEEDED(target->get_SelectionText(&raw)))
+        {
+            selection = take(raw);
+        }
+
+        const auto image = kind == COREWEBVIEW2_CONTEXT_MENU_TARGET_KIND_IMAGE;
+        const auto media =
+            kind == COREWEBVIEW2_CONTEXT_MENU_TARGET_KIND_VIDEO || kind == COREWEBVIEW2_CONTEXT_MENU_TARGET_KIND_AUDIO;
+
+        saucerw_context context{
+            .x         = static_cast<int>(location.x),
+            .y         = static_cast<int>(location.y),
+            .link      = link.c_str(),
+            .image     = image ? source.c_str() : "",
+            .media     = media ? source.c_str() : "",
+            .selection = selection.c_str(),
+            .editable  = static_cast<bool>(editable),
+        };
+
+        saucerw_context_menu custom{std::make_shared<menu_state>()};
+        custom.state->handle = click;
+
+        if (!saucerwContextMenu(handler, &context, &custom))
+        {
+            return;
+        }
+
+        args->put_Handled(TRUE);
+
+        if (custom.state->entries.empty())
+        {
+            return;
+        }
+
+        // The location is relative to the web view, which may not fill the window
+        RECT bounds{};
+        self.webview->native<true>().controller->get_Bounds(&bounds);
+
+        auto *const hwnd = self.webview->parent().native<true>().hwnd;
+        POINT point{bounds.left + location.x, bounds.top + location.y};
+
+        ClientToScreen(hwnd, &point);
+
+        // The menu loop runs once the event returned
+        self.webview->parent().parent().post(
+            [hwnd, point, state = custom.state]
+            {
+                constexpr auto flags = TPM_RETURNCMD | TPM_RIGHTBUTTON;
+
+                auto *const menu = win32_menu(*state, 0, true);
+                const auto id    = TrackPopupMenu(menu, flags, point.x, point.y, 0, hwnd, nullptr);
+
+                DestroyMenu(menu);
+
+                if (id)
+                {
+                    activate(*state, static_cast<int32_t>(id));
+                }
+            });
+    }
+#endif
+} // namespace
+
+void saucerw_register_scheme(const char *name)
+{
+    saucer::webview::register_scheme(name);
+}
+
+void saucerw_capture_logs()
+{
+#if defined(SAUCER_WEBKITGTK)
+    g_log_set_writer_func(glib_writer, nullptr, nullptr);
+#elif defined(SAUCER_QT)
+    qInstallMessageHandler(qt_handler);
+#endif
+}
+
+void saucerw_capture_fatal()
+{
+    std::set_terminate(terminate);
+}
+
+saucerw_app *saucerw_app_new(const char *id, int argc, char **argv, bool quit_on_last_window_closed, char **error)
+{
+    auto *const rtn = new saucerw_app;
+
+    rtn->args.assign(argv, argv + argc);
+
+    for (auto &arg : rtn->args)
+    {
+        rtn->argv.emplace_back(arg.data());
+    }
+
+    auto app = saucer::application::create({
+        .id                         = id,
+        .argc                       = argc,
+        .argv                       = rtn->argv.data(),
+        .quit_on_last_window_closed = quit_on_last_window_closed,
+    });
+
+    if (!app.has_value())
+    {
+        fail(error, app.error());
+        delete rtn;
+        return nullptr;
+    }
+
+    rtn->app.emplace(std::move(app.value()));
+
+    return rtn;
+}
+
+void saucerw_app_free(saucerw_app *self)
+{
+#if defined(SAUCER_WEBVIEW2)
+    if (self->clipboard)
+    {
+        DestroyWindow(self->clipboard);
+    }
+#elif defined(SAUCER_WEBKIT)
+    if (self->clipboard)
+    {
+        saucerw_cocoa_unwatch_clipboard(self->clipboard);
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+    for (const auto signal : {self->reader_signal, self->contrast_signal})
+    {
+        if (signal)
+        {
+            g_dbus_connection_signal_unsubscribe(self->bus, signal);
+        }
+    }
+#elif defined(SAUCER_QT)
+    if (self->reader_observer)
+    {
+        QAccessible::removeActivationObserver(self->reader_observer.get());
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    if (self->accessibility_watcher.joinable())
+    {
+        SetEvent(self->accessibility_stop);
+        self->accessibility_watcher.join();
+        CloseHandle(self->accessibility_stop);
+    }
+#elif defined(SAUCER_WEBKIT)
+    if (self->accessibility_observer)
+    {
+        saucerw_cocoa_unwatch_accessibility(self->accessibility_observer);
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+    if (self->scheme_handler)
+    {
+        g_signal_handlers_disconnect_by_data(gtk_settings_get_default(), self);
+    }
+
+    if (self->scheme_signal)
+    {
+        g_dbus_connection_signal_unsubscribe(self->bus, self->scheme_signal);
+    }
+
+    if (self->bus)
+    {
+        g_object_unref(self->bus);
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    if (self->scheme_watcher.joinable())
+    {
+        SetEvent(self->scheme_stop);
+        self->scheme_watcher.join();
+        CloseHandle(self->scheme_stop);
+    }
+#elif defined(SAUCER_WEBKIT)
+    if (self->appearance)
+    {
+        saucerw_cocoa_unwatch_color_scheme(self->appearance);
+    }
+#endif
+
+#if defined(SAUCER_WEBKITGTK)
+    for (const auto signal : {self->sleep_signal, self->shutdown_signal})
+    {
+        if (signal)
+        {
+            g_dbus_connection_signal_unsubscribe(self->system_bus, signal);
+        }
+    }
+
+    if (self->lock_signal)
+    {
+        g_dbus_connection_signal_unsubscribe(self->session_bus, self->lock_signal);
+    }
+
+    if (self->inhibitor >= 0)
+    {
+        close(self->inhibitor);
+    }
+
+    for (auto *const bus : {self->system_bus, self->session_bus})
+    {
+        if (bus)
+        {
+            g_object_unref(bus);
+        }
+    }
+#elif defined(SAUCER_WEBVIEW2)
+    if (self->power)
+    {
+        WTSUnRegisterSessionNotification(self->power);
+        DestroyWindow(self->power);
+    }
+#elif defined(SAUCER_WEBKIT)
+    if (self->power_observer)
+    {
+        saucerw_cocoa_unwatch_power(self->power_observer);
+    }
+#endif
+
+#if defined(SAUCER_WEBVIEW2)
+    if (self->locale_watcher.joinable())
+    {
+        SetEvent(self->locale_stop);
+        self->locale_watcher.join();
+        CloseHandle(self->locale_stop);
+    }
+#elif defined(SAUCER_WEBKIT)
+    if (self->locale_observer)
+    {
+        saucerw_cocoa_unwatch_locales(self->locale_observer);
+    }
+#endif
+
+    delete self;
+}
+
+int saucerw_app_run(saucerw_app *self, uintptr_t handle)
+{
+    return self->app->run([handle](saucer::application *) { return start(handle); });
+}
+
+void saucerw_app_quit(saucerw_app *self)
+{
+    self->app->quit();
+}
+
+void saucerw_app_post(saucerw_app *self, uintptr_t handle)
+{
+    self->app->post([handle] { saucerwInvokeOnce(handle); });
+}
+
+bool saucerw_app_thread_safe(saucerw_app *self)
+{
+    return self->app->thread_safe();
+}
+
+size_t saucerw_app_screens(saucerw_app *self, saucerw_screen **screens)
+{
+    const auto all = self->app->screens();
+    *screens       = static_cast<saucerw_screen *>(std::malloc(sizeof(saucerw_screen) * all.size()));
+
+    std::vector<double> scales;
+    self->app->invoke([&] { scales = screen_scales(all.size()); });
+
+    // A screen attached in between shifts the lists, their scales are unknown
+    if (scales.size() != all.size())
+    {
+        scales.assign(all.size(), 1);
+    }
+
+    for (auto i = 0uz; all.size() > i; ++i)
+    {
+        const auto &screen = all[i];
+
+        (*screens)[i] = {
+            .name  = dup(screen.name),
+            .w     = screen.size.w,
+            .h     = screen.size.h,
+            .x     = screen.position.x,
+            .y     = screen.position.y,
+            .scale = scales[i],
+        };
+    }
+
+    return all.size();
+}
+
+void saucerw_app_read_text(saucerw_app *self, uintptr_t handle)
+{
+    self->app->post(
+        [self, handle]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            gdk_clipboard_read_text_async(clipboard(), nullptr, read_text, reinterpret_cast<gpointer>(handle));
+#elif defined(SAUCER_QT)
+            const auto *const mime = clipboard()->mimeData();
+            deliver(handle, mime && mime->hasText() ? std::optional{mime->text().toStdString()} : std::nullopt);
+#elif defined(SAUCER_WEBVIEW2)
+            deliver(handle, clipboard_text(clipboard_window(*self)));
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_read_text(handle);
+#endif
+        });
+}
+
+void saucerw_app_read_image(saucerw_app *self, uintptr_t handle)
+{
+    self->app->post(
+        [self, handle]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            gdk_clipboard_read_texture_async(clipboard(), nullptr, read_texture, reinterpret_cast<gpointer>(handle));
+#elif defined(SAUCER_QT)
+            const auto *const mime = clipboard()->mimeData();
+
+            if (!mime || !mime->hasImage())
+            {
+                deliver(handle, std::optional<clipboard_image>{});
+                return;
+            }
+
+            const auto image = clipboard()->image().convertToFormat(QImage::Format_RGBA8888);
+
+            clipboard_image rtn{.w = image.width(), .h = image.height()};
+            rtn.pixels.resize(4uz * rtn.w * rtn.h);
+
+            for (auto y = 0; rtn.h > y; ++y)
+            {
+                std::memcpy(rtn.pixels.data() + 4uz * rtn.w * y, image.constScanLine(y), 4uz * rtn.w);
+            }
+
+            deliver(handle, std::move(rtn));
+#elif defined(SAUCER_WEBVIEW2)
+            deliver(handle, clipboard_bitmap(clipboard_window(*self)));
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_read_image(handle);
+#endif
+        });
+}
+
+void saucerw_app_write_text(saucerw_app *self, const char *text, size_t size)
+{
+    self->app->invoke(
+        [&]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            const std::string value{text, size};
+            gdk_clipboard_set_text(clipboard(), value.c_str());
+#elif defined(SAUCER_QT)
+            clipboard()->setText(QString::fromUtf8(text, static_cast<qsizetype>(size)));
+#elif defined(SAUCER_WEBVIEW2)
+            const auto value = widen({text, size});
+            auto *const memory = GlobalAlloc(GMEM_MOVEABLE, (value.size() + 1) * sizeof(wchar_t));
+
+            if (!memory)
+            {
+                return;
+            }
+
+            std::memcpy(GlobalLock(memory), value.c_str(), (value.size() + 1) * sizeof(wchar_t));
+            GlobalUnlock(memory);
+
+            set_clipboard(clipboard_window(*self), CF_UNICODETEXT, memory);
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_write_text(text, size);
+#endif
+        });
+}
+
+void saucerw_app_write_image(saucerw_app *self, saucerw_image image)
+{
+    const auto size = 4uz * image.w * image.h;
+
+    self->app->invoke(
+        [&]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            auto *const bytes   = g_bytes_new(image.pixels, size);
+            auto *const texture = gdk_memory_texture_new(image.w, image.h, GDK_MEMORY_R8G8B8A8, bytes, 4uz * image.w);
+
+            gdk_clipboard_set_texture(clipboard(), texture);
+
+            g_object_unref(texture);
+            g_bytes_unref(bytes);
+#elif defined(SAUCER_QT)
+            const QImage value{image.pixels, image.w, image.h, 4 * image.w, QImage::Format_RGBA8888};
+            // The image does not own the pixels
+            clipboard()->setImage(value.copy());
+#elif defined(SAUCER_WEBVIEW2)
+            auto *const memory = GlobalAlloc(GMEM_MOVEABLE, sizeof(BITMAPINFOHEADER) + size);
+
+            if (!memory)
+            {
+                return;
+            }
+
+            auto *const header = static_cast<BITMAPINFOHEADER *>(GlobalLock(memory));
+
+            *header = {
+                .biSize        = sizeof(BITMAPINFOHEADER),
+                .biWidth       = image.w,
+                .biHeight      = image.h,
+                .biPlanes      = 1,
+                .biBitCount    = 32,
+                .biCompression = BI_RGB,
+                .biSizeImage   = static_cast<DWORD>(size),
+            };
+
+            auto *const bits = reinterpret_cast<uint8_t *>(header + 1);
+
+            for (auto y = 0; image.h > y; ++y)
+            {
+                const auto *src = image.pixels + 4uz * image.w * y;
+                auto *dst       = bits + 4uz * image.w * (image.h - 1 - y);
+
+                for (auto x = 0; image.w > x; ++x, src += 4, dst += 4)
+                {
+                    dst[0] = src[2];
+                    dst[1] = src[1];
+                    dst[2] = src[0];
+                    dst[3] = src[3];
+                }
+            }
+
+            GlobalUnlock(memory);
+
+            set_clipboard(clipboard_window(*self), CF_DIB, memory);
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_write_image(image);
+#endif
+        });
+}
+
+void saucerw_app_on_clipboard(saucerw_app *self, uintptr_t handle)
+{
+    // Deferred until the toolkit runs, the clipboard is not available before
+    self->app->post(
+        [self, handle]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            g_signal_connect(clipboard(), "changed",
+                             G_CALLBACK(+[](GdkClipboard *, gpointer data)
+                                        { saucerwInvoke(reinterpret_cast<uintptr_t>(data)); }),
+                             reinterpret_cast<gpointer>(handle));
+#elif defined(SAUCER_QT)
+            QObject::connect(clipboard(), &QClipboard::dataChanged, [handle] { saucerwInvoke(handle); });
+#elif defined(SAUCER_WEBVIEW2)
+            if (auto *const hwnd = clipboard_window(*self); hwnd)
+            {
+                SetWindowLongPtrW(hwnd, GWLP_USERDATA, static_cast<LONG_PTR>(handle));
+                AddClipboardFormatListener(hwnd);
+            }
+#elif defined(SAUCER_WEBKIT)
+            self->clipboard = saucerw_cocoa_watch_clipboard(handle);
+#endif
+        });
+}
+
+int saucerw_app_color_scheme(saucerw_app *self)
+{
+#if defined(SAUCER_WEBKITGTK)
+    int rtn{};
+    self->app->invoke([&] { rtn = color_scheme(self->bus); });
+    return rtn;
+#elif defined(SAUCER_QT)
+    int rtn{};
+    self->app->invoke([&] { rtn = color_scheme(); });
+    return rtn;
+#elif defined(SAUCER_WEBVIEW2)
+    return color_scheme();
+#elif defined(SAUCER_WEBKIT)
+    int rtn{};
+    self->app->invoke([&] { rtn = saucerw_cocoa_color_scheme(); });
+    return rtn;
+#endif
+}
+
+void saucerw_app_on_color_scheme(saucerw_app *self, uintptr_t handle)
+{
+    self->scheme_handler = handle;
+
+#if defined(SAUCER_WEBKITGTK)
+    // Without a session bus only the GTK settings are watched
+    self->bus = g_bus_get_sync(G_BUS_TYPE_SESSION, nullptr, nullptr);
+#endif
+
+    // Deferred until the toolkit runs like the clipboard, the changes are then delivered on the event loop thread
+    self->app->post(
+        [self]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            self->scheme = color_scheme(self->bus);
+
+            if (self->bus)
+            {
+                auto callback = +[](GDBusConnection *, const gchar *, const gchar *, const gchar *, const gchar *,
+                                    GVariant *parameters, gpointer data)
+                {
+                    auto *const self = static_cast<saucerw_app *>(data);
+
+                    const gchar *ns{}, *key{};
+                    GVariant *value{};
+
+                    g_variant_get(parameters, "(&s&sv)", &ns, &key, &value);
+
+                    if (std::string_view{ns} == "org.freedesktop.appearance" && std::string_view{key} == "color-scheme")
+                    {
+                        scheme_changed(*self, portal_scheme(value).value_or(settings_scheme()));
+                    }
+
+                    g_variant_unref(value);
+                };
+
+                self->scheme_signal =
+                    g_dbus_connection_signal_subscribe(self->bus, portal_name, settings_interface, "SettingChanged",
+                                                       portal_path, nullptr, G_DBUS_SIGNAL_FLAGS_NONE, callback, self,
+                                                       nullptr);
+            }
+
+            auto notify = +[](GtkSettings *, GParamSpec *, gpointer data)
+            {
+                auto *const self = static_cast<saucerw_app *>(data);
+                scheme_changed(*self, color_scheme(self->bus));
+            };
+
+            auto *const settings = gtk_settings_get_default();
+
+            g_signal_connect(settings, "notify::gtk-application-prefer-dark-theme", G_CALLBACK(notify), self);
+            g_signal_connect(settings, "notify::gtk-theme-name", G_CALLBACK(notify), self);
+#elif defined(SAUCER_QT)
+            self->scheme = color_scheme();
+
+            QObject::connect(QGuiApplication::styleHints(), &QStyleHints::colorSchemeChanged,
+                             [self](Qt::ColorScheme) { scheme_changed(*self, color_scheme()); });
+#elif defined(SAUCER_WEBVIEW2)
+            self->scheme = color_scheme();
+            watch_color_scheme(*self);
+#elif defined(SAUCER_WEBKIT)
+            self->appearance = saucerw_cocoa_watch_color_scheme(self->scheme_handler);
+#endif
+        });
+}
+
+int saucerw_app_accessibility(saucerw_app *self)
+{
+    int rtn{};
+#if defined(SAUCER_WEBKITGTK)
+    self->app->invoke([&] { rtn = system_accessibility(self->bus); });
+#else
+    self->app->invoke([&] { rtn = system_accessibility(); });
+#endif
+    return rtn;
+}
+
+void saucerw_app_on_accessibility(saucerw_app *self, uintptr_t handle)
+{
+    self->accessibility_handler = handle;
+
+#if defined(SAUCER_WEBKITGTK)
+    if (!self->bus)
+    {
+        self->bus = g_bus_get_sync(G_BUS_TYPE_SESSION, nullptr, nullptr);
+    }
+#endif
+
+    self->app->post(
+        [self]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            self->accessibility = system_accessibility(self->bus);
+
+            if (self->bus)
+            {
+                auto callback = +[](GDBusConnection *, const gchar *, const gchar *, const gchar *, const gchar *,
+                                    GVariant *, gpointer data)
+                {
+                    auto *const self = static_cast<saucerw_app *>(data);
+                    accessibility_changed(*self, system_accessibility(self->bus));
+                };
+
+                self->reader_signal =
+                    g_dbus_connection_signal_subscribe(self->bus, a11y_name, properties_name, "PropertiesChanged",
+                                                       a11y_path, "org.a11y.Status", G_DBUS_SIGNAL_FLAGS_NONE, callback,
+                                                       self, nullptr);
+
+                self->contrast_signal = g_dbus_connection_signal_subscribe(
+                    self->bus, portal_name, settings_interface, "SettingChanged", portal_path,
+                    "org.freedesktop.appearance", G_DBUS_SIGNAL_FLAGS_NONE, callback, self, nullptr);
+            }
+
+            auto notify = +[](GtkSettings *, GParamSpec *, gpointer data)
+            {
+                auto *const self = static_cast<saucerw_app *>(data);
+                accessibility_changed(*self, system_accessibility(self->bus));
+            };
+
+            auto *const settings = gtk_settings_get_default();
+
+            g_signal_connect(settings, "notify::gtk-enable-animations", G_CALLBACK(notify), self);
+            g_signal_connect(settings, "notify::gtk-theme-name", G_CALLBACK(notify), self);
+#elif defined(SAUCER_QT)
+            self->accessibility   = system_accessibility();
+            self->reader_observer = std::make_unique<activation_observer>(self);
+
+            QAccessible::installActivationObserver(self->reader_observer.get());
+#elif defined(SAUCER_WEBVIEW2)
+            self->accessibility = system_accessibility();
+            watch_accessibility(*self);
+#elif defined(SAUCER_WEBKIT)
+            self->accessibility_observer = saucerw_cocoa_watch_accessibility(self->accessibility_handler);
+#endif
+        });
+}
+
+char *saucerw_app_locales(saucerw_app *self)
+{
+    std::string rtn;
+    self->app->invoke([&] { rtn = system_locales(); });
+    return dup(rtn);
+}
+
+void saucerw_app_on_locales(saucerw_app *self, uintptr_t handle)
+{
+    self->locale_handler = handle;
+
+    // Processes on Linux keep the locale of their environment, only Windows and macOS report changes
+    self->app->post(
+        [self]
+        {
+            self->locales = system_locales();
+
+#if defined(SAUCER_WEBVIEW2)
+            watch_registry(*self, international, true, self->locale_stop, self->locale_watcher,
+                           [](saucerw_app &self) { locales_changed(self, system_locales()); });
+#elif defined(SAUCER_WEBKIT)
+            self->locale_observer = saucerw_cocoa_watch_locales(self->locale_handler);
+#endif
+        });
+}
+
+void saucerw_app_on_power(saucerw_app *self, uintptr_t handle)
+{
+    self->power_handler = handle;
+
+#if defined(SAUCER_WEBKITGTK)
+    // Without the buses neither sleep nor screen locks are reported
+    self->system_bus  = g_bus_get_sync(G_BUS_TYPE_SYSTEM, nullptr, nullptr);
+    self->session_bus = g_bus_get_sync(G_BUS_TYPE_SESSION, nullptr, nullptr);
+#endif
+
+    // Deferred until the toolkit runs like the color scheme, the events are then delivered on the event loop thread
+    self->app->post(
+        [self]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            if (self->system_bus)
+            {
+                self->sleep_signal = g_dbus_connection_signal_subscribe(
+                    self->system_bus, logind_name, logind_manager, "PrepareForSleep", logind_path, nullptr,
+                    G_DBUS_SIGNAL_FLAGS_NONE, logind_signal, self, nullptr);
+                self->shutdown_signal = g_dbus_connection_signal_subscribe(
+                    self->system_bus, logind_name, logind_manager, "PrepareForShutdown", logind_path, nullptr,
+                    G_DBUS_SIGNAL_FLAGS_NONE, logind_signal, self, nullptr);
+
+                inhibit_shutdown(*self);
+            }
+
+            if (self->session_bus)
+            {
+                self->lock_signal =
+                    g_dbus_connection_signal_subscribe(self->session_bus, nullptr, nullptr, "ActiveChanged", nullptr,
+                                                       nullptr, G_DBUS_SIGNAL_FLAGS_NONE, screensaver_signal, self,
+                                                       nullptr);
+            }
+#elif defined(SAUCER_QT)
+            QObject::connect(qApp, &QGuiApplication::commitDataRequest, [self](QSessionManager &)
+                             { saucerwPower(self->power_handler, SAUCERW_POWER_SHUTDOWN); });
+#elif defined(SAUCER_WEBVIEW2)
+            self->power = power_window(*self);
+#elif defined(SAUCER_WEBKIT)
+            self->power_observer = saucerw_cocoa_watch_power(self->power_handler);
+#endif
+        });
+}
+
+void saucerw_app_release_shutdown(saucerw_app *self)
+{
+    self->app->post(
+        [self]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            if (self->inhibitor >= 0)
+            {
+                close(self->inhibitor);
+                self->inhibitor = -1;
+            }
+#elif defined(SAUCER_WEBVIEW2)
+            if (self->shutdown_pending)
+            {
+                self->shutdown_pending = false;
+                ShutdownBlockReasonDestroy(self->power);
+            }
+#else
+            // The backend cannot delay the shutdown
+            (void)self;
+#endif
+        });
+}
+
+void saucerw_app_remove_website_data(saucerw_app *self, const char *storage_path, uintptr_t handle)
+{
+    self->app->post(
+        [handle, path = std::string{storage_path}]
+        {
+#if defined(SAUCER_WEBKIT)
+            saucerw_cocoa_remove_data_store(path.c_str(), handle);
+#else
+            // The other backends keep all data of a storage path inside it
+            saucerwDone(handle, nullptr);
+#endif
+        });
+}
+
+saucerw_window *saucerw_window_new(saucerw_app *app, char **error)
+{
+    auto window = saucer::window::create(&app->app.value());
+
+    if (!window.has_value())
+    {
+        fail(error, window.error());
+        return nullptr;
+    }
+
+    auto *const rtn = new saucerw_window{std::move(window.value())};
+
+    rtn->window->on<saucer::window::event::close>({{
+        .func =
+            [kiosk = rtn->kiosk]
+        {
+            if (kiosk->closing)
+            {
+                return saucer::policy::allow;
+            }
+
+            if (kiosk->enabled)
+            {
+                return saucer::policy::block;
+            }
+
+            if (kiosk->intercept && kiosk->events)
+            {
+                saucerwWindowEvent(kiosk->events, SAUCERW_WINDOW_CLOSE_REQUESTED, 0, 0, 0);
+                return saucer::policy::block;
+            }
+
+            return saucer::policy::allow;
+        },
+        .clearable = false,
+    }});
+
+#if defined(SAUCER_WEBKIT)
+    rtn->window->on<saucer::window::event::focus>({{
+        .func =
+            [rtn](bool focused)
+        {
+            if (focused)
+            {
+                saucerw_cocoa_focus_menu(rtn);
+            }
+        },
+        .clearable = false,
+    }});
+#endif
+
+    return rtn;
+}
+
+void saucerw_window_free(saucerw_window *self)
+{
+#if defined(SAUCER_QT)
+    if (self->kiosk->filter)
+    {
+        self->window->parent().invoke([self] { self->kiosk->filter.reset(); });
+    }
+
+    self->window->parent().invoke([self] { self->scale_filter.reset(); });
+#elif defined(SAUCER_WEBKITGTK)
+    self->window->parent().invoke([self]
+                                  { g_signal_handlers_disconnect_by_data(self->window->native<true>().window, self); });
+#endif
+
+#if defined(SAUCER_WEBKIT)
+    self->window->parent().invoke(
+        [self]
+        {
+            saucerw_cocoa_free_menu(self);
+
+            if (self->scale_observer)
+            {
+                saucerw_cocoa_unwatch_scale(self->scale_observer);
+            }
+        });
+#elif defined(SAUCER_WEBVIEW2)
+    if (self->menu->original)
+    {
+        self->window->parent().invoke(
+            [self]
+            {
+                auto *const hwnd = self->window->native<true>().hwnd;
+
+                SetWindowLongPtrW(hwnd, GWLP_WNDPROC, reinterpret_cast<LONG_PTR>(self->menu->original));
+                RemovePropW(hwnd, menu_property);
+            });
+    }
+#endif
+
+    delete self;
+}
+
+bool saucerw_window_visible(saucerw_window *self)
+{
+    return self->window->visible();
+}
+
+bool saucerw_window_focused(saucerw_window *self)
+{
+    return self->window->focused();
+}
+
+bool saucerw_window_minimized(saucerw_window *self)
+{
+    return self->window->minimized();
+}
+
+bool saucerw_window_maximized(saucerw_window *self)
+{
+    return self->window->maximized();
+}
+
+bool saucerw_window_resizable(saucerw_window *self)
+{
+    return self->window->resizable();
+}
+
+bool saucerw_window_fullscreen(saucerw_window *self)
+{
+    return self->window->fullscreen();
+}
+
+bool saucerw_window_always_on_top(saucerw_window *self)
+{
+    return self->window->always_on_top();
+}
+
+bool saucerw_window_click_through(saucerw_window *self)
+{
+    return self->window->click_through();
+}
+
+char *saucerw_window_title(saucerw_window *self)
+{
+    return dup(self->window->title());
+}
+
+saucerw_color saucerw_window_background(saucerw_window *self)
+{
+    const auto color = self->window->background();
+    return {.r = color.r, .g = color.g, .b = color.b, .a = color.a};
+}
+
+int saucerw_window_decorations(saucerw_window *self)
+{
+    return static_cast<int>(self->window->decorations());
+}
+
+void saucerw_window_size(saucerw_window *self, int *w, int *h)
+{
+    const auto size = self->window->size();
+
+    *w = size.w;
+    *h = size.h;
+}
+
+void saucerw_window_min_size(saucerw_window *self, int *w, int *h)
+{
+    const auto size = self->window->min_size();
+
+    *w = size.w;
+    *h = size.h;
+}
+
+void saucerw_window_max_size(saucerw_window *self, int *w, int *h)
+{
+    const auto size = self->window->max_size();
+
+    *w = size.w;
+    *h = size.h;
+}
+
+void saucerw_window_position(saucerw_window *self, int *x, int *y)
+{
+    const auto position = self->window->position();
+
+    *x = position.x;
+    *y = position.y;
+}
+
+double saucerw_window_scale(saucerw_window *self)
+{
+    return self->window->parent().invoke([self] { return window_scale(*self); });
+}
+
+void saucerw_window_outer_size(saucerw_window *self, int *w, int *h)
+{
+    self->window->parent().invoke(
+        [&]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            // The title bar is drawn by GTK, the decorations of the compositor are not known
+            auto *const window = GTK_WIDGET(self->window->native<true>().window);
+
+            *w = gtk_widget_get_width(window);
+            *h = gtk_widget_get_height(window);
+
+            if (*w == 0 || *h == 0)
+            {
+                const auto size = self->window->size();
+
+                *w = size.w;
+                *h = size.h;
+            }
+#elif defined(SAUCER_QT)
+            const auto size = self->window->native<true>().window->frameGeometry().size();
+
+            *w = size.width();
+            *h = size.height();
+#elif defined(SAUCER_WEBVIEW2)
+            RECT rect{};
+            GetWindowRect(self->window->native<true>().hwnd, &rect);
+
+            // The rect is in device pixels
+            const auto scale = window_scale(*self);
+
+            *w = static_cast<int>(std::lround((rect.right - rect.left) / scale));
+            *h = static_cast<int>(std::lround((rect.bottom - rect.top) / scale));
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_outer_size(self->window->native<false>(), w, h);
+#endif
+        });
+}
+
+void saucerw_window_safe_area(saucerw_window *self, int *top, int *right, int *bottom, int *left)
+{
+    *top = *right = *bottom = *left = 0;
+
+#if defined(SAUCER_WEBKIT)
+    self->window->parent().invoke([&]
+                                  { saucerw_cocoa_safe_area(self->window->native<false>(), top, right, bottom, left); });
+#else
+    // Only macOS draws over the content
+    (void)self;
+#endif
+}
+
+void saucerw_window_show(saucerw_window *self)
+{
+    self->window->show();
+}
+
+void saucerw_window_hide(saucerw_window *self)
+{
+    self->window->hide();
+}
+
+void saucerw_window_close(saucerw_window *self)
+{
+    // Closing is synchronous on all backends, the flag only lets this call through kiosk mode
+    self->window->parent().invoke(
+        [self]
+        {
+            self->kiosk->closing = true;
+            self->window->close();
+            self->kiosk->closing = false;
+        });
+}
+
+void saucerw_window_focus(saucerw_window *self)
+{
+    self->window->focus();
+}
+
+void saucerw_window_start_drag(saucerw_window *self)
+{
+    self->window->start_drag();
+}
+
+void saucerw_window_start_resize(saucerw_window *self, int edges)
+{
+    self->window->start_resize(static_cast<saucer::window::edge>(edges));
+}
+
+void saucerw_window_set_minimized(saucerw_window *self, bool value)
+{
+    self->window->set_minimized(value);
+}
+
+void saucerw_window_set_maximized(saucerw_window *self, bool value)
+{
+    self->window->set_maximized(value);
+}
+
+void saucerw_window_set_resizable(saucerw_window *self, bool value)
+{
+    self->window->set_resizable(value);
+}
+
+void saucerw_window_set_fullscreen(saucerw_window *self, bool value)
+{
+    self->window->set_fullscreen(value);
+}
+
+void saucerw_window_set_always_on_top(saucerw_window *self, bool value)
+{
+    self->window->set_always_on_top(value);
+}
+
+void saucerw_window_set_click_through(saucerw_window *self, bool value)
+{
+    self->window->set_click_through(value);
+}
+
+void saucerw_window_set_title(saucerw_window *self, const char *title)
+{
+    self->window->set_title(title);
+}
+
+bool saucerw_window_set_icon(saucerw_window *self, const uint8_t *data, size_t size, char **error)
+{
+    auto icon = saucer::icon::from(saucer::stash::from(std::vector<std::uint8_t>(data, data + size)));
+
+    if (!icon.has_value())
+    {
+        fail(error, icon.error());
+        return false;
+    }
+
+    self->window->set_icon(icon.value());
+    return true;
+}
+
+void saucerw_window_set_background(saucerw_window *self, saucerw_color color)
+{
+    self->window->set_background({.r = color.r, .g = color.g, .b = color.b, .a = color.a});
+}
+
+void saucerw_window_set_decorations(saucerw_window *self, int value)
+{
+    self->window->set_decorations(static_cast<saucer::window::decoration>(value));
+}
+
+void saucerw_window_set_size(saucerw_window *self, int w, int h)
+{
+    self->window->set_size({.w = w, .h = h});
+}
+
+void saucerw_window_set_min_size(saucerw_window *self, int w, int h)
+{
+    self->window->set_min_size({.w = w, .h = h});
+}
+
+void saucerw_window_set_max_size(saucerw_window *self, int w, int h)
+{
+    self->window->set_max_size({.w = w, .h = h});
+}
+
+void saucerw_window_set_position(saucerw_window *self, int x, int y)
+{
+    self->window->set_position({.x = x, .y = y});
+}
+
+void saucerw_window_set_kiosk(saucerw_window *self, bool value)
+{
+    self->window->parent().invoke(
+        [self, value]
+        {
+            auto &kiosk = *self->kiosk;
+
+            if (kiosk.enabled == value)
+            {
+                return;
+            }
+
+            kiosk.enabled = value;
+
+#if defined(SAUCER_WEBKITGTK)
+            auto *const window = GTK_WIDGET(self->window->native<true>().window);
+
+            if (!value)
+            {
+                gtk_widget_remove_controller(window, std::exchange(kiosk.keys, nullptr));
+                return;
+            }
+
+            // The capture phase sees the keys before the webview and the shortcuts of the menu
+            kiosk.keys = gtk_event_controller_key_new();
+            gtk_event_controller_set_propagation_phase(kiosk.keys, GTK_PHASE_CAPTURE);
+
+            g_signal_connect(kiosk.keys, "key-pressed", G_CALLBACK(kiosk_key), nullptr);
+            gtk_widget_add_controller(window, kiosk.keys);
+#elif defined(SAUCER_QT)
+            if (!value)
+            {
+                kiosk.filter.reset();
+                return;
+            }
+
+            kiosk.filter = std::make_unique<kiosk_filter>(self->window->native<true>().window);
+            QApplication::instance()->installEventFilter(kiosk.filter.get());
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_set_kiosk(self->window->native<false>(), value);
+#endif
+        });
+}
+
+bool saucerw_window_set_titlebar_color(saucerw_window *self, saucerw_color color)
+{
+#if defined(SAUCER_WEBVIEW2)
+    self->window->parent().invoke(
+        [self, color]
+        {
+            // DWMWA_CAPTION_COLOR and DWMWA_TEXT_COLOR, ignored before Windows 11
+            static constexpr DWORD caption_attribute = 35;
+            static constexpr DWORD text_attribute    = 36;
+            static constexpr COLORREF default_color  = 0xFFFFFFFF;
+
+            auto *const hwnd = self->window->native<true>().hwnd;
+            COLORREF caption = default_color;
+            COLORREF text    = default_color;
+
+            if (color.a != 0)
+            {
+                caption   = RGB(color.r, color.g, color.b);
+                auto luma = (299 * color.r + 587 * color.g + 114 * color.b) / 1000;
+                text      = luma > 128 ? RGB(0, 0, 0) : RGB(255, 255, 255);
+            }
+
+            DwmSetWindowAttribute(hwnd, caption_attribute, &caption, sizeof(caption));
+            DwmSetWindowAttribute(hwnd, text_attribute, &text, sizeof(text));
+        });
+
+    return true;
+#elif defined(SAUCER_WEBKIT)
+    self->window->parent().invoke([self, color]
+                                  { saucerw_cocoa_set_titlebar_color(self->window->native<false>(), color); });
+    return true;
+#else
+    // Neither GTK nor Qt can color the title bar drawn by the window manager
+    (void)self;
+    (void)color;
+    return false;
+#endif
+}
+
+void saucerw_window_intercept_close(saucerw_window *self, bool value)
+{
+    self->window->parent().invoke([self, value] { self->kiosk->intercept = value; });
+}
+
+void saucerw_window_on_events(saucerw_window *self, uintptr_t handle)
+{
+    using saucer::window;
+    auto &target = *self->window;
+
+    self->kiosk->events = handle;
+
+    target.on<window::event::decorated>({{
+        .func      = [handle](window::decoration value)
+        { saucerwWindowEvent(handle, SAUCERW_WINDOW_DECORATED, static_cast<int>(value), 0, 0); },
+        .clearable = false,
+    }});
+
+    target.on<window::event::maximize>({{
+        .func      = [handle](bool value) { saucerwWindowEvent(handle, SAUCERW_WINDOW_MAXIMIZE, value, 0, 0); },
+        .clearable = false,
+    }});
+
+    target.on<window::event::minimize>({{
+        .func      = [handle](bool value) { saucerwWindowEvent(handle, SAUCERW_WINDOW_MINIMIZE, value, 0, 0); },
+        .clearable = false,
+    }});
+
+    target.on<window::event::closed>({{
+        .func      = [handle] { saucerwWindowEvent(handle, SAUCERW_WINDOW_CLOSED, 0, 0, 0); },
+        .clearable = false,
+    }});
+
+    target.on<window::event::resize>({{
+        .func =
+            [self, handle](int w, int h)
+        {
+            saucerwWindowEvent(handle, SAUCERW_WINDOW_RESIZE, 0, w, h);
+
+#if defined(SAUCER_WEBVIEW2)
+            // saucer resizes the window for its new DPI, which is the only notice of it
+            report_scale(*self);
+#endif
+        },
+        .clearable = false,
+    }});
+
+    target.on<window::event::focus>({{
+        .func      = [handle](bool value) { saucerwWindowEvent(handle, SAUCERW_WINDOW_FOCUS, value, 0, 0); },
+        .clearable = false,
+    }});
+
+    target.parent().invoke(
+        [self, handle]
+        {
+            self->scale = window_scale(*self);
+
+#if defined(SAUCER_WEBKITGTK)
+            g_signal_connect(self->window->native<true>().window, "notify::scale-factor", G_CALLBACK(scale_notify),
+                             self);
+#elif defined(SAUCER_QT)
+            self->scale_filter = std::make_unique<scale_filter>(self);
+            self->window->native<true>().window->installEventFilter(self->scale_filter.get());
+#elif defined(SAUCER_WEBKIT)
+            self->scale_observer = saucerw_cocoa_watch_scale(self->window->native<false>(), handle);
+#else
+            (void)handle;
+#endif
+        });
+}
+
+void saucerw_window_set_menu(saucerw_window *self, size_t count, const saucerw_menu_item *items)
+{
+    auto entries = menu_entries(count, items);
+
+    self->window->parent().invoke(
+        [&]
+        {
+            self->menu->entries = std::move(entries);
+#if defined(SAUCER_WEBKIT)
+            saucerw_cocoa_set_menu(self, self->menu->handle, count, items, self->window->focused());
+#else
+            apply_menu(*self);
+#endif
+        });
+}
+
+void saucerw_window_on_menu(saucerw_window *self, uintptr_t handle)
+{
+    self->window->parent().invoke([self, handle] { self->menu->handle = handle; });
+}
+
+int saucerw_network_features()
+{
+#if defined(SAUCER_WEBKITGTK) || defined(SAUCER_QT)
+    return SAUCERW_NETWORK_PROXY | SAUCERW_NETWORK_CREDENTIALS | SAUCERW_NETWORK_CERTIFICATES |
+           SAUCERW_NETWORK_ALLOWED_HOSTS;
+#elif defined(SAUCER_WEBVIEW2)
+    return SAUCERW_NETWORK_PROXY | SAUCERW_NETWORK_CERTIFICATES | SAUCERW_NETWORK_ALLOWED_HOSTS;
+#elif defined(SAUCER_WEBKIT)
+    return saucerw_cocoa_network_features();
+#endif
+}
+
+saucerw_webview *saucerw_webview_new(saucerw_window *window, const saucerw_webview_options *options, size_t flags,
+                                     const char **flag_values, char **error)
+{
+    saucer::webview::options opts{
+        .window                    = window->window,
+        .attributes                = options->attributes,
+        .persistent_cookies        = options->persistent_cookies,
+        .non_persistent_data_store = options->non_persistent_data_store,
+        .hardware_acceleration     = options->hardware_acceleration,
+    };
+
+    if (options->storage_path)
+    {
+        opts.storage_path = options->storage_path;
+    }
+
+    if (options->user_agent)
+    {
+        opts.user_agent = options->user_agent;
+    }
+
+#if defined(SAUCER_QT) || defined(SAUCER_WEBVIEW2)
+    if (!options->media_keys)
+    {
+        opts.browser_flags.emplace("--disable-features=HardwareMediaKeyHandling,MediaSessionService");
+    }
+#endif
+
+#if defined(SAUCER_WEBVIEW2)
+    // Chromium plays muted media without a gesture regardless, the script of the policy pauses it
+    switch (options->autoplay)
+    {
+    case SAUCERW_AUTOPLAY_ALLOW:
+        opts.browser_flags.emplace("--autoplay-policy=no-user-gesture-required");
+        break;
+    case SAUCERW_AUTOPLAY_MUTED:
+    case SAUCERW_AUTOPLAY_BLOCK:
+        opts.browser_flags.emplace("--autoplay-policy=user-gesture-required");
+        break;
+    default:
+        break;
+    }
+
+    if (const auto *const locales = options->locales; locales)
+    {
+        const std::string_view list{locales};
+
+        opts.browser_flags.emplace(std::format("--accept-lang={}", list));
+        opts.browser_flags.emplace(std::format("--lang={}", list.substr(0, list.find(','))));
+    }
+#endif
+
+    for (size_t i = 0; i < flags; ++i)
+    {
+        opts.browser_flags.emplace(flag_values[i]);
+    }
+
+#if defined(SAUCER_QT) || defined(SAUCER_WEBVIEW2)
+    // Chromium only takes the proxy as a flag of the whole browser
+    if (const auto &network = options->network; network.proxy)
+    {
+        opts.browser_flags.emplace(std::format("--proxy-server={}", network.proxy));
+
+        std::string bypass;
+
+        for (auto i = 0uz; network.bypass > i; ++i)
+        {
+            bypass += (i ? ";" : "") + std::string{network.bypass_hosts[i]};
+        }
+
+        if (!bypass.empty())
+        {
+            opts.browser_flags.emplace(std::format("--proxy-bypass-list={}", bypass));
+        }
+    }
+#endif
+
+    auto webview = saucer::webview::create(opts);
+
+    if (!webview.has_value())
+    {
+        fail(error, webview.error());
+        return nullptr;
+    }
+
+    auto *const rtn = new saucerw_webview;
+    rtn->webview.emplace(std::move(webview.value()));
+
+    std::string failure;
+    rtn->webview->parent().parent().invoke(
+        [&]
+        {
+            apply_locales(*rtn, *options);
+            apply_autoplay(*rtn, options->autoplay);
+            failure = apply_network(*rtn, options->network);
+        });
+
+    if (!failure.empty())
+    {
+        delete rtn;
+
+        if (error)
+        {
+            *error = dup(failure);
+        }
+
+        return nullptr;
+    }
+
+#if defined(SAUCER_WEBVIEW2)
+    // Keys pressed in the webview never reach the window, the accelerators of the menu are matched here
+    auto handler = Microsoft::WRL::Callback<ICoreWebView2AcceleratorKeyPressedEventHandler>(
+        [state = window->menu, kiosk = window->kiosk](ICoreWebView2Controller *,
+                                                       ICoreWebView2AcceleratorKeyPressedEventArgs *args)
+        {
+            COREWEBVIEW2_KEY_EVENT_KIND kind{};
+            args->get_KeyEventKind(&kind);
+
+            if (kind != COREWEBVIEW2_KEY_EVENT_KIND_KEY_DOWN && kind != COREWEBVIEW2_KEY_EVENT_KIND_SYSTEM_KEY_DOWN)
+            {
+                return S_OK;
+            }
+
+            UINT key{};
+            args->get_VirtualKey(&key);
+
+            if (kiosk->enabled && kiosk_blocked(key))
+            {
+                args->put_Handled(TRUE);
+                return S_OK;
+            }
+
+            if (const auto id = match_accelerator(*state, key); id != 0)
+            {
+                args->put_Handled(TRUE);
+                activate(*state, id);
+            }
+
+            return S_OK;
+        });
+
+    EventRegistrationToken token{};
+    rtn->webview->native<true>().controller->add_AcceleratorKeyPressed(handler.Get(), &token);
+#elif defined(SAUCER_QT)
+    auto *const webview = rtn->webview->native<true>().webview;
+    auto *const store   = webview->page()->profile()->cookieStore();
+
+    auto same = [](const QNetworkCookie &cookie)
+    {
+        return [&cookie](const QNetworkCookie &entry)
+        {
+            return entry.hasSameIdentifier(cookie);
+        };
+    };
+
+    rtn->webview->parent().parent().invoke(
+        [&]
+        {
+            // Connected to the webview, the profile may outlive it
+            QObject::connect(store, &QWebEngineCookieStore::cookieAdded, webview,
+                             [cookies = rtn->cookies, same](const QNetworkCookie &cookie)
+                             {
+                                 std::erase_if(*cookies, same(cookie));
+                                 cookies->emplace_back(cookie);
+                             });
+
+            QObject::connect(store, &QWebEngineCookieStore::cookieRemoved, webview,
+                             [cookies = rtn->cookies, same](const QNetworkCookie &cookie)
+                             { std::erase_if(*cookies, same(cookie)); });
+
+            store->loadAllCookies();
+        });
+#endif
+
+    return rtn;
+}
+
+void saucerw_webview_free(saucerw_webview *self)
+{
+#if defined(SAUCER_WEBKITGTK)
+    if (self->download_started)
+    {
+        auto *const webview = self->webview->native<true>().webview;
+
+        self->webview->parent().parent().invoke(
+            [&] { g_signal_handler_disconnect(webkit_web_view_get_network_session(webview), self->download_started); });
+    }
+#endif
+
+    delete self;
+}
+
+char *saucerw_webview_url(saucerw_webview *self)
+{
+    return dup(self->webview->url().string());
+}
+
+char *saucerw_webview_page_title(saucerw_webview *self)
+{
+    return dup(self->webview->page_title());
+}
+
+void saucerw_webview_set_url(saucerw_webview *self, const char *url)
+{
+    self->webview->set_url(url);
+}
+
+void saucerw_webview_set_html(saucerw_webview *self, const char *html)
+{
+    self->webview->set_html(html);
+}
+
+void saucerw_webview_back(saucerw_webview *self)
+{
+    self->webview->back();
+}
+
+void saucerw_webview_forward(saucerw_webview *self)
+{
+    self->webview->forward();
+}
+
+void saucerw_webview_reload(saucerw_webview *self)
+{
+    self->webview->reload();
+}
+
+saucerw_color saucerw_webview_background(saucerw_webview *self)
+{
+    const auto color = self->webview->background();
+    return {.r = color.r, .g = color.g, .b = color.b, .a = color.a};
+}
+
+void saucerw_webview_set_background(saucerw_webview *self, saucerw_color color)
+{
+    self->webview->set_background({.r = color.r, .g = color.g, .b = color.b, .a = color.a});
+}
+
+bool saucerw_webview_dev_tools(saucerw_webview *self)
+{
+    return self->webview->dev_tools();
+}
+
+void saucerw_webview_set_dev_tools(saucerw_webview *self, bool value)
+{
+    self->webview->set_dev_tools(value);
+}
+
+void saucerw_webview_edit(saucerw_webview *self, int role)
+{
+    if (role < SAUCERW_ROLE_UNDO || role > SAUCERW_ROLE_SELECT_ALL)
+    {
+        return;
+    }
+
+    const auto index = static_cast<std::size_t>(role - SAUCERW_ROLE_UNDO);
+
+#if defined(SAUCER_WEBKITGTK)
+    static constexpr std::array commands{
+        WEBKIT_EDITING_COMMAND_UNDO, WEBKIT_EDITING_COMMAND_REDO,  WEBKIT_EDITING_COMMAND_CUT,
+        WEBKIT_EDITING_COMMAND_COPY, WEBKIT_EDITING_COMMAND_PASTE, WEBKIT_EDITING_COMMAND_SELECT_ALL,
+    };
+
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { webkit_web_view_execute_editing_command(webview, commands[index]); });
+#elif defined(SAUCER_QT)
+    static constexpr std::array actions{
+        QWebEnginePage::Undo, QWebEnginePage::Redo,  QWebEnginePage::Cut,
+        QWebEnginePage::Copy, QWebEnginePage::Paste, QWebEnginePage::SelectAll,
+    };
+
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { webview->page()->triggerAction(actions[index]); });
+#elif defined(SAUCER_WEBVIEW2)
+    // Pages may not read the clipboard, so paste only works through the keyboard shortcut
+    static constexpr std::array commands{"undo", "redo", "cut", "copy", "paste", "selectAll"};
+    self->webview->execute(std::string{"document.execCommand('"} + commands[index] + "')");
+#elif defined(SAUCER_WEBKIT)
+    self->webview->parent().parent().invoke([&] { saucerw_cocoa_edit(role); });
+#endif
+}
+
+void saucerw_webview_embed(saucerw_webview *self, const char *path, const char *mime, const uint8_t *data, size_t size)
+{
+    auto file = saucer::embedded_file{
+        .content = saucer::stash::from({data, data + size}),
+        .mime    = mime,
+    };
+
+    // Merging keeps existing entries, drop the previous file first.
+    self->webview->unembed(path);
+    self->webview->embed({{path, std::move(file)}});
+}
+
+void saucerw_webview_serve(saucerw_webview *self, const char *path)
+{
+    self->webview->serve(path);
+}
+
+void saucerw_webview_unembed(saucerw_webview *self)
+{
+    self->webview->unembed();
+}
+
+void saucerw_webview_execute(saucerw_webview *self, const char *code)
+{
+    self->webview->execute(code);
+}
+
+size_t saucerw_webview_inject(saucerw_webview *self, const char *code, bool ready, bool all_frames, bool permanent)
+{
+    return self->webview->inject({
+        .code      = code,
+        .run_at    = ready ? saucer::script::time::ready : saucer::script::time::creation,
+        .no_frames = !all_frames,
+        .clearable = !permanent,
+    });
+}
+
+void saucerw_webview_uninject(saucerw_webview *self, size_t id)
+{
+    self->webview->uninject(id);
+}
+
+void saucerw_webview_uninject_all(saucerw_webview *self)
+{
+    self->webview->uninject();
+}
+
+void saucerw_webview_on_message(saucerw_webview *self, uintptr_t handler)
+{
+    auto callback = [handler](std::string_view message)
+    {
+        auto handled = saucerwMessage(handler, const_cast<char *>(message.data()), message.size());
+        return handled ? saucer::status::handled : saucer::status::unhandled;
+    };
+
+    self->webview->on<saucer::webview::event::message>({{.func = std::move(callback), .clearable = false}});
+}
+
+void saucerw_webview_on_navigate(saucerw_webview *self, uintptr_t handler)
+{
+    auto callback = [handler](const saucer::navigation &navigation)
+    {
+        auto url     = navigation.url().string();
+        auto allowed = saucerwNavigate(handler, url.data(), navigation.new_window(), navigation.redirection(),
+                                       navigation.user_initiated());
+
+        return allowed ? saucer::policy::allow : saucer::policy::block;
+    };
+
+    self->webview->on<saucer::webview::event::navigate>({{.func = std::move(callback), .clearable = false}});
+}
+
+void saucerw_webview_on_permission(saucerw_webview *self, uintptr_t handler)
+{
+    auto callback = [self, handler](const std::shared_ptr<saucer::permission::request> &request)
+    {
+        auto url            = request->url().string();
+        auto *const pending = new saucerw_permission{.app = &self->webview->parent().parent(), .request = request};
+
+        const auto decision = saucerwPermission(handler, url.data(), static_cast<int>(request->type()), pending);
+
+        if (decision == SAUCERW_PERMISSION_PENDING)
+        {
+            return saucer::status::handled;
+        }
+
+        delete pending;
+
+        if (decision == SAUCERW_PERMISSION_DEFAULT)
+        {
+            return saucer::status::unhandled;
+        }
+
+        request->accept(decision == SAUCERW_PERMISSION_GRANT);
+
+        return saucer::status::handled;
+    };
+
+    self->webview->on<saucer::webview::event::permission>({{.func = std::move(callback), .clearable = false}});
+}
+
+void saucerw_permission_accept(saucerw_permission *self, bool granted)
+{
+    self->app->post(
+        [self, granted]
+        {
+            self->request->accept(granted);
+            delete self;
+        });
+}
+
+void saucerw_webview_on_download(saucerw_webview *self, uintptr_t handler)
+{
+#if defined(SAUCER_WEBKITGTK)
+    auto *const webview = self->webview->native<true>().webview;
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            self->download_started = g_signal_connect_data(
+                webkit_web_view_get_network_session(webview), "download-started", G_CALLBACK(download_started),
+                new download_listener{.webview = webview, .handler = handler},
+                +[](gpointer data, GClosure *) { delete static_cast<download_listener *>(data); }, G_CONNECT_DEFAULT);
+        });
+#elif defined(SAUCER_QT)
+    auto *const webview = self->webview->native<true>().webview;
+    auto *const page    = webview->page();
+
+    auto callback = [page, handler](QWebEngineDownloadRequest *download)
+    {
+        // The profile may be shared by several webviews
+        if (download->page() != page)
+        {
+            return;
+        }
+
+        const auto decision =
+            decide_download(handler, download->url().toString().toStdString(), download->downloadFileName().toStdString(),
+                            download->mimeType().toStdString(), download->totalBytes());
+
+        if (decision.cancel)
+        {
+            download->cancel();
+            return;
+        }
+
+        if (decision.path)
+        {
+            const QFileInfo info{QString::fromStdString(*decision.path)};
+
+            download->setDownloadDirectory(info.absolutePath());
+            download->setDownloadFileName(info.fileName());
+        }
+
+        if (decision.events)
+        {
+            track_download(download, decision.events);
+        }
+
+        // Requests that are not accepted are cancelled
+        download->accept();
+    };
+
+    self->webview->parent().parent().invoke(
+        [&] { QObject::connect(page->profile(), &QWebEngineProfile::downloadRequested, webview, std::move(callback)); });
+#elif defined(SAUCER_WEBVIEW2)
+    Microsoft::WRL::ComPtr<ICoreWebView2> core;
+    self->webview->native<true>().controller->get_CoreWebView2(&core);
+
+    Microsoft::WRL::ComPtr<ICoreWebView2_4> webview;
+
+    // Downloads are reported since the fourth revision of the interface
+    if (!core || FAILED(core.As(&webview)))
+    {
+        return;
+    }
+
+    auto callback = Microsoft::WRL::Callback<ICoreWebView2DownloadStartingEventHandler>(
+        [handler](ICoreWebView2 *, ICoreWebView2DownloadStartingEventArgs *args)
+        {
+            Microsoft::WRL::ComPtr<ICoreWebView2DownloadOperation> download;
+            args->get_DownloadOperation(&download);
+
+            LPWSTR uri{}, mime{}, path{};
+            INT64 total{};
+
+            download->get_Uri(&uri);
+            download->get_MimeType(&mime);
+            download->get_TotalBytesToReceive(&total);
+            args->get_ResultFilePath(&path);
+
+            // The default path ends with the suggested name
+            const auto fallback = take(path);
+            const auto name     = fallback.substr(fallback.find_last_of("\\/") + 1);
+
+            const auto decision = decide_download(handler, take(uri), name, take(mime), total);
+
+            if (decision.cancel)
+            {
+                args->put_Cancel(TRUE);
+                return S_OK;
+            }
+
+            if (decision.path)
+            {
+                args->put_ResultFilePath(widen(*decision.path).c_str());
+            }
+
+            if (decision.events)
+            {
+                track_download(download.Get(), decision.events);
+            }
+
+            return S_OK;
+        });
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            EventRegistrationToken token{};
+            webview->add_DownloadStarting(callback.Get(), &token);
+        });
+#else
+    // The navigation delegate of the WebKit backend belongs to saucer, which does not report downloads
+    (void)self;
+    (void)handler;
+#endif
+}
+
+void saucerw_webview_on_events(saucerw_webview *self, uintptr_t handler)
+{
+    auto dom_ready = [handler]
+    {
+        saucerwWebviewEvent(handler, SAUCERW_WEBVIEW_DOM_READY, 0);
+    };
+
+    auto load = [handler](const saucer::state &state)
+    {
+        saucerwWebviewEvent(handler, SAUCERW_WEBVIEW_LOAD, static_cast<int>(state));
+    };
+
+    self->webview->on<saucer::webview::event::dom_ready>({{.func = std::move(dom_ready), .clearable = false}});
+    self->webview->on<saucer::webview::event::load>({{.func = std::move(load), .clearable = false}});
+
+#if defined(SAUCER_WEBKITGTK)
+    auto playing = +[](WebKitWebView *webview, GParamSpec *, gpointer handler)
+    {
+        saucerwWebviewEvent(reinterpret_cast<uintptr_t>(handler), SAUCERW_WEBVIEW_PLAYING_AUDIO,
+                            webkit_web_view_is_playing_audio(webview));
+    };
+
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            g_signal_connect(webview, "notify::is-playing-audio", G_CALLBACK(playing),
+                             reinterpret_cast<gpointer>(handler));
+        });
+
+    auto terminated = +[](WebKitWebView *, WebKitWebProcessTerminationReason reason, gpointer handler)
+    {
+        auto gone = SAUCERW_GONE_CRASHED;
+
+        if (reason == WEBKIT_WEB_PROCESS_EXCEEDED_MEMORY_LIMIT)
+        {
+            gone = SAUCERW_GONE_OUT_OF_MEMORY;
+        }
+        else if (reason == WEBKIT_WEB_PROCESS_TERMINATED_BY_API)
+        {
+            gone = SAUCERW_GONE_KILLED;
+        }
+
+        saucerwWebviewEvent(reinterpret_cast<uintptr_t>(handler), SAUCERW_WEBVIEW_PROCESS_GONE, gone);
+    };
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            g_signal_connect(webview, "web-process-terminated", G_CALLBACK(terminated),
+                             reinterpret_cast<gpointer>(handler));
+        });
+#elif defined(SAUCER_QT)
+    auto *const page = self->webview->native<true>().webview->page();
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            QObject::connect(page, &QWebEnginePage::recentlyAudibleChanged, page,
+                             [handler](bool audible)
+                             { saucerwWebviewEvent(handler, SAUCERW_WEBVIEW_PLAYING_AUDIO, audible); });
+
+            QObject::connect(page, &QWebEnginePage::renderProcessTerminated, page,
+                             [handler](QWebEnginePage::RenderProcessTerminationStatus status, int)
+                             {
+                                 auto gone = SAUCERW_GONE_CRASHED;
+
+                                 if (status == QWebEnginePage::NormalTerminationStatus ||
+                                     status == QWebEnginePage::KilledTerminationStatus)
+                                 {
+                                     gone = SAUCERW_GONE_KILLED;
+                                 }
+
+                                 saucerwWebviewEvent(handler, SAUCERW_WEBVIEW_PROCESS_GONE, gone);
+                             });
+        });
+#elif defined(SAUCER_WEBVIEW2)
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            // Playing audio is reported since the eighth revision of the interface
+            auto webview = revision<ICoreWebView2_8>(*self);
+
+            if (!webview)
+            {
+                return;
+            }
+
+            auto callback = Microsoft::WRL::Callback<ICoreWebView2IsDocumentPlayingAudioChangedEventHandler>(
+                [handler](ICoreWebView2 *sender, IUnknown *)
+                {
+                    Microsoft::WRL::ComPtr<ICoreWebView2_8> webview;
+                    BOOL playing{};
+
+                    if (SUCCEEDED(sender->QueryInterface(IID_PPV_ARGS(&webview))))
+                    {
+                        webview->get_IsDocumentPlayingAudio(&playing);
+                    }
+
+                    saucerwWebviewEvent(handler, SAUCERW_WEBVIEW_PLAYING_AUDIO, playing);
+                    return S_OK;
+                });
+
+            EventRegistrationToken token{};
+            webview->add_IsDocumentPlayingAudioChanged(callback.Get(), &token);
+        });
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            auto webview = revision<ICoreWebView2>(*self);
+
+            if (!webview)
+            {
+                return;
+            }
+
+            auto callback = Microsoft::WRL::Callback<ICoreWebView2ProcessFailedEventHandler>(
+                [handler](ICoreWebView2 *, ICoreWebView2ProcessFailedEventArgs *args)
+                {
+                    COREWEBVIEW2_PROCESS_FAILED_KIND kind{};
+                    args->get_ProcessFailedKind(&kind);
+
+                    auto gone = SAUCERW_GONE_CRASHED;
+
+                    switch (kind)
+                    {
+                    case COREWEBVIEW2_PROCESS_FAILED_KIND_RENDER_PROCESS_UNRESPONSIVE:
+                        gone = SAUCERW_GONE_UNRESPONSIVE;
+                        break;
+                    case COREWEBVIEW2_PROCESS_FAILED_KIND_RENDER_PROCESS_EXITED:
+                    case COREWEBVIEW2_PROCESS_FAILED_KIND_BROWSER_PROCESS_EXITED:
+                        break;
+                    default:
+                        // Frames and the other processes are restarted by WebView2
+                        return S_OK;
+                    }
+
+                    // The reason is reported since the second revision of the arguments
+                    Microsoft::WRL::ComPtr<ICoreWebView2ProcessFailedEventArgs2> detailed;
+                    COREWEBVIEW2_PROCESS_FAILED_REASON reason{};
+
+                    if (gone == SAUCERW_GONE_CRASHED && SUCCEEDED(args->QueryInterface(IID_PPV_ARGS(&detailed))) &&
+                        SUCCEEDED(detailed->get_Reason(&reason)))
+                    {
+                        if (reason == COREWEBVIEW2_PROCESS_FAILED_REASON_OUT_OF_MEMORY)
+                        {
+                            gone = SAUCERW_GONE_OUT_OF_MEMORY;
+                        }
+                        else if (reason == COREWEBVIEW2_PROCESS_FAILED_REASON_TERMINATED)
+                        {
+                            gone = SAUCERW_GONE_KILLED;
+                        }
+                        else if (reason == COREWEBVIEW2_PROCESS_FAILED_REASON_UNRESPONSIVE)
+                        {
+                            gone = SAUCERW_GONE_UNRESPONSIVE;
+                        }
+                    }
+
+                    saucerwWebviewEvent(handler, SAUCERW_WEBVIEW_PROCESS_GONE, gone);
+                    return S_OK;
+                });
+
+            EventRegistrationToken token{};
+            webview->add_ProcessFailed(callback.Get(), &token);
+        });
+#elif defined(SAUCER_WEBKIT)
+    auto remute = [self]
+    {
+        if (self->muted)
+        {
+            self->webview->execute("window['saucer:media']?.mute(true)");
+        }
+    };
+
+    self->webview->on<saucer::webview::event::dom_ready>({{.func = std::move(remute), .clearable = false}});
+#endif
+}
+
+void saucerw_webview_on_drop(saucerw_webview *self, uintptr_t handler)
+{
+#if defined(SAUCER_WEBKITGTK)
+    auto *const webview = self->webview->native<true>().webview;
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            // Shared by all drop targets, the hook picks the web views by their data
+            [[maybe_unused]] static const auto hook = g_signal_add_emission_hook(
+                g_signal_lookup("drop", GTK_TYPE_DROP_TARGET_ASYNC), 0, drop_hook, nullptr, nullptr);
+
+            g_object_set_data(G_OBJECT(webview), drop_key, reinterpret_cast<gpointer>(handler));
+        });
+#elif defined(SAUCER_QT)
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { webview->installEventFilter(new drop_filter{handler, webview}); });
+#elif defined(SAUCER_WEBVIEW2)
+    auto core = revision<ICoreWebView2>(*self);
+
+    if (!core)
+    {
+        return;
+    }
+
+    auto callback = Microsoft::WRL::Callback<ICoreWebView2WebMessageReceivedEventHandler>(
+        [handler](ICoreWebView2 *, ICoreWebView2WebMessageReceivedEventArgs *args)
+        {
+            posted_drop(handler, args);
+            return S_OK;
+        });
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            EventRegistrationToken token{};
+            core->add_WebMessageReceived(callback.Get(), &token);
+        });
+#elif defined(SAUCER_WEBKIT)
+    self->webview->parent().parent().invoke([&] { saucerw_cocoa_on_drop(self->webview->native<false>(), handler); });
+#endif
+}
+
+void saucerw_webview_on_context_menu(saucerw_webview *self, uintptr_t handler, uintptr_t click)
+{
+#if defined(SAUCER_WEBKITGTK)
+    auto *const webview = self->webview->native<true>().webview;
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            g_signal_connect_data(webview, "context-menu", G_CALLBACK(context_menu), new context_target{handler, click},
+                                  +[](gpointer data, GClosure *) { delete static_cast<context_target *>(data); },
+                                  static_cast<GConnectFlags>(0));
+        });
+#elif defined(SAUCER_QT)
+    auto *const webview = self->webview->native<true>().webview;
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            webview->setContextMenuPolicy(Qt::CustomContextMenu);
+            QObject::connect(webview, &QWidget::customContextMenuRequested, webview,
+                             [webview, handler, click](const QPoint &position)
+                             { context_menu(webview, handler, click, position); });
+        });
+#elif defined(SAUCER_WEBVIEW2)
+    auto core = revision<ICoreWebView2_11>(*self);
+
+    if (!core)
+    {
+        return;
+    }
+
+    auto callback = Microsoft::WRL::Callback<ICoreWebView2ContextMenuRequestedEventHandler>(
+        [self, handler, click](ICoreWebView2 *, ICoreWebView2ContextMenuRequestedEventArgs *args)
+        {
+            context_menu(*self, handler, click, args);
+            return S_OK;
+        });
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            EventRegistrationToken token{};
+            core->add_ContextMenuRequested(callback.Get(), &token);
+        });
+#elif defined(SAUCER_WEBKIT)
+    self->webview->parent().parent().invoke(
+        [&] { saucerw_cocoa_on_context_menu(self->webview->native<false>(), handler, click); });
+#endif
+}
+
+#if !defined(SAUCER_WEBKIT)
+void saucerw_context_menu_set(saucerw_context_menu *self, size_t count, const saucerw_menu_item *items)
+{
+    self->state->entries = menu_entries(count, items);
+}
+#endif
+
+void saucerw_webview_cookies(saucerw_webview *self, uintptr_t handle)
+{
+    self->webview->parent().parent().post(
+        [self, handle]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            webkit_cookie_manager_get_all_cookies(webkit_network_session_get_cookie_manager(session(*self)), nullptr,
+                                                  got_cookies, reinterpret_cast<gpointer>(handle));
+#elif defined(SAUCER_QT)
+            std::vector<cookie> cookies;
+            std::ranges::transform(*self->cookies, std::back_inserter(cookies), to_cookie);
+
+            deliver(handle, cookies);
+#elif defined(SAUCER_WEBVIEW2)
+            auto manager = cookie_manager(*self);
+
+            if (!manager)
+            {
+                cookies_failed(handle, "cookies need a newer WebView2 runtime");
+                return;
+            }
+
+            auto callback = Microsoft::WRL::Callback<ICoreWebView2GetCookiesCompletedHandler>(
+                [handle](HRESULT result, ICoreWebView2CookieList *list)
+                {
+                    if (FAILED(result))
+                    {
+                        cookies_failed(handle, hresult(result));
+                        return S_OK;
+                    }
+
+                    UINT count{};
+                    list->get_Count(&count);
+
+                    std::vector<cookie> cookies;
+
+                    for (UINT i = 0; count > i; ++i)
+                    {
+                        Microsoft::WRL::ComPtr<ICoreWebView2Cookie> entry;
+
+                        if (SUCCEEDED(list->GetValueAtIndex(i, &entry)))
+                        {
+                            cookies.emplace_back(to_cookie(entry.Get()));
+                        }
+                    }
+
+                    deliver(handle, cookies);
+
+                    return S_OK;
+                });
+
+            // An empty address lists the cookies of every site
+            if (const auto result = manager->GetCookies(L"", callback.Get()); FAILED(result))
+            {
+                cookies_failed(handle, hresult(result));
+            }
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_cookies(self->webview->native<false>(), handle);
+#endif
+        });
+}
+
+void saucerw_webview_set_cookie(saucerw_webview *self, const saucerw_cookie *value, uintptr_t handle)
+{
+    self->webview->parent().parent().post(
+        [self, handle, value = copy(*value)]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            auto *const manager = webkit_network_session_get_cookie_manager(session(*self));
+            auto *const native  = native_cookie(value);
+
+            webkit_cookie_manager_add_cookie(manager, native, nullptr,
+                                             finished<WebKitCookieManager, webkit_cookie_manager_add_cookie_finish>,
+                                             reinterpret_cast<gpointer>(handle));
+
+            soup_cookie_free(native);
+#elif defined(SAUCER_QT)
+            self->webview->native<true>().webview->page()->profile()->cookieStore()->setCookie(native_cookie(value));
+            finish(handle);
+#elif defined(SAUCER_WEBVIEW2)
+            auto manager = cookie_manager(*self);
+            Microsoft::WRL::ComPtr<ICoreWebView2Cookie> native;
+
+            if (manager)
+            {
+                native = native_cookie(manager.Get(), value);
+            }
+
+            if (!native)
+            {
+                finish(handle, "cookies need a newer WebView2 runtime");
+                return;
+            }
+
+            const auto result = manager->AddOrUpdateCookie(native.Get());
+            finish(handle, FAILED(result) ? hresult(result) : "");
+#elif defined(SAUCER_WEBKIT)
+            const auto native = borrow(value);
+            saucerw_cocoa_set_cookie(self->webview->native<false>(), &native, handle);
+#endif
+        });
+}
+
+void saucerw_webview_delete_cookie(saucerw_webview *self, const saucerw_cookie *value, uintptr_t handle)
+{
+    self->webview->parent().parent().post(
+        [self, handle, value = copy(*value)]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            auto *const manager = webkit_network_session_get_cookie_manager(session(*self));
+            auto *const native  = native_cookie(value);
+
+            webkit_cookie_manager_delete_cookie(manager, native, nullptr,
+                                                finished<WebKitCookieManager, webkit_cookie_manager_delete_cookie_finish>,
+                                                reinterpret_cast<gpointer>(handle));
+
+            soup_cookie_free(native);
+#elif defined(SAUCER_QT)
+            self->webview->native<true>().webview->page()->profile()->cookieStore()->deleteCookie(native_cookie(value));
+            finish(handle);
+#elif defined(SAUCER_WEBVIEW2)
+            auto manager = cookie_manager(*self);
+
+            if (!manager)
+            {
+                finish(handle, "cookies need a newer WebView2 runtime");
+                return;
+            }
+
+            const auto result = manager->DeleteCookiesWithDomainAndPath(
+                widen(value.name).c_str(), widen(value.domain).c_str(), widen(value.path).c_str());
+
+            finish(handle, FAILED(result) ? hresult(result) : "");
+#elif defined(SAUCER_WEBKIT)
+            const auto native = borrow(value);
+            saucerw_cocoa_delete_cookie(self->webview->native<false>(), &native, handle);
+#endif
+        });
+}
+
+int saucerw_clearable_data()
+{
+#if defined(SAUCER_QT)
+    return SAUCERW_DATA_CACHE | SAUCERW_DATA_COOKIES;
+#else
+    return SAUCERW_DATA_CACHE | SAUCERW_DATA_COOKIES | SAUCERW_DATA_LOCAL_STORAGE | SAUCERW_DATA_INDEXED_DB |
+           SAUCERW_DATA_SERVICE_WORKERS;
+#endif
+}
+
+void saucerw_webview_clear_data(saucerw_webview *self, int kinds, double since, uintptr_t handle)
+{
+    if (!kinds)
+    {
+        finish(handle);
+        return;
+    }
+
+    self->webview->parent().parent().post(
+        [self, kinds, since, handle]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            // The manager takes the age of the data to remove rather than a point in time
+            GTimeSpan span{};
+
+            if (since > 0)
+            {
+                span = std::max<GTimeSpan>(1, g_get_real_time() - static_cast<GTimeSpan>(since * G_USEC_PER_SEC));
+            }
+
+            webkit_website_data_manager_clear(
+                webkit_network_session_get_website_data_manager(session(*self)), website_data(kinds), span, nullptr,
+                finished<WebKitWebsiteDataManager, webkit_website_data_manager_clear_finish>,
+                reinterpret_cast<gpointer>(handle));
+#elif defined(SAUCER_QT)
+            auto *const profile = self->webview->native<true>().webview->page()->profile();
+
+            if (kinds & SAUCERW_DATA_CACHE)
+            {
+                profile->clearHttpCache();
+            }
+
+            if (kinds & SAUCERW_DATA_COOKIES)
+            {
+                profile->cookieStore()->deleteAllCookies();
+            }
+
+            finish(handle);
+#elif defined(SAUCER_WEBVIEW2)
+            Microsoft::WRL::ComPtr<ICoreWebView2Profile> profile;
+            Microsoft::WRL::ComPtr<ICoreWebView2Profile2> data;
+
+            if (auto webview = revision<ICoreWebView2_13>(*self); webview)
+            {
+                webview->get_Profile(&profile);
+            }
+
+            if (!profile || FAILED(profile.As(&data)))
+            {
+                finish(handle, "clearing data needs a newer WebView2 runtime");
+                return;
+            }
+
+            auto callback = Microsoft::WRL::Callback<ICoreWebView2ClearBrowsingDataCompletedHandler>(
+                [handle](HRESULT result)
+                {
+                    finish(handle, FAILED(result) ? hresult(result) : "");
+                    return S_OK;
+                });
+
+            HRESULT result{};
+
+            if (since > 0)
+            {
+                const auto now = std::chrono::duration<double>(std::chrono::system_clock::now().time_since_epoch());
+                result = data->ClearBrowsingDataInTimeRange(browsing_data(kinds), since, now.count(), callback.Get());
+            }
+            else
+            {
+                result = data->ClearBrowsingData(browsing_data(kinds), callback.Get());
+            }
+
+            if (FAILED(result))
+            {
+                finish(handle, hresult(result));
+            }
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_clear_data(self->webview->native<false>(), kinds, since, handle);
+#endif
+        });
+}
+
+double saucerw_webview_zoom(saucerw_webview *self)
+{
+    double rtn{1};
+
+#if defined(SAUCER_WEBKITGTK)
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { rtn = webkit_web_view_get_zoom_level(webview); });
+#elif defined(SAUCER_QT)
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { rtn = webview->zoomFactor(); });
+#elif defined(SAUCER_WEBVIEW2)
+    auto *const controller = self->webview->native<true>().controller;
+    self->webview->parent().parent().invoke([&] { controller->get_ZoomFactor(&rtn); });
+#elif defined(SAUCER_WEBKIT)
+    self->webview->parent().parent().invoke([&] { rtn = saucerw_cocoa_zoom(self->webview->native<false>()); });
+#endif
+
+    return rtn;
+}
+
+void saucerw_webview_set_zoom(saucerw_webview *self, double factor)
+{
+#if defined(SAUCER_WEBKITGTK)
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { webkit_web_view_set_zoom_level(webview, factor); });
+#elif defined(SAUCER_QT)
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { webview->setZoomFactor(factor); });
+#elif defined(SAUCER_WEBVIEW2)
+    auto *const controller = self->webview->native<true>().controller;
+    self->webview->parent().parent().invoke([&] { controller->put_ZoomFactor(factor); });
+#elif defined(SAUCER_WEBKIT)
+    self->webview->parent().parent().invoke([&] { saucerw_cocoa_set_zoom(self->webview->native<false>(), factor); });
+#endif
+}
+
+bool saucerw_webview_muted(saucerw_webview *self)
+{
+    bool rtn{};
+
+#if defined(SAUCER_WEBKITGTK)
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { rtn = webkit_web_view_get_is_muted(webview); });
+#elif defined(SAUCER_QT)
+    auto *const page = self->webview->native<true>().webview->page();
+    self->webview->parent().parent().invoke([&] { rtn = page->isAudioMuted(); });
+#elif defined(SAUCER_WEBVIEW2)
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            BOOL muted{};
+
+            if (auto webview = revision<ICoreWebView2_8>(*self); webview)
+            {
+                webview->get_IsMuted(&muted);
+            }
+
+            rtn = muted;
+        });
+#elif defined(SAUCER_WEBKIT)
+    rtn = self->muted;
+#endif
+
+    return rtn;
+}
+
+void saucerw_webview_set_muted(saucerw_webview *self, bool value)
+{
+#if defined(SAUCER_WEBKITGTK)
+    auto *const webview = self->webview->native<true>().webview;
+    self->webview->parent().parent().invoke([&] { webkit_web_view_set_is_muted(webview, value); });
+#elif defined(SAUCER_QT)
+    auto *const page = self->webview->native<true>().webview->page();
+    self->webview->parent().parent().invoke([&] { page->setAudioMuted(value); });
+#elif defined(SAUCER_WEBVIEW2)
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            if (auto webview = revision<ICoreWebView2_8>(*self); webview)
+            {
+                webview->put_IsMuted(value);
+            }
+        });
+#elif defined(SAUCER_WEBKIT)
+    // WKWebView has no public way to mute the page
+    self->muted = value;
+    self->webview->execute(std::format("window['saucer:media']?.mute({})", value));
+#endif
+}
+
+bool saucerw_print_dialog()
+{
+#if defined(SAUCER_QT)
+    // Showing the dialog needs the Qt Print Support module, which saucer does not link
+    return false;
+#else
+    return true;
+#endif
+}
+
+void saucerw_webview_print(saucerw_webview *self, const saucerw_print_options *options, uintptr_t handle)
+{
+    const auto path = std::string{options->path ? options->path : ""};
+
+    auto page = *options;
+    page.path = nullptr;
+
+    self->webview->parent().parent().post(
+        [self, path, page, handle]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            auto *const webview = self->webview->native<true>().webview;
+            webkit_settings_set_print_backgrounds(webkit_web_view_get_settings(webview), page.background);
+
+            auto *const operation = webkit_print_operation_new(webview);
+            auto *const setup     = page_setup(page);
+
+            webkit_print_operation_set_page_setup(operation, setup);
+
+            auto *const job = new print_job{.handle = handle};
+
+            g_signal_connect(operation, "failed", G_CALLBACK(print_failed), job);
+            g_signal_connect(operation, "finished", G_CALLBACK(print_finished), job);
+
+            if (!path.empty())
+            {
+                auto *const settings = gtk_print_settings_new();
+                auto *const uri      = g_filename_to_uri(path.c_str(), nullptr, nullptr);
+
+                gtk_print_settings_set_printer(settings, "Print to File");
+                gtk_print_settings_set(settings, GTK_PRINT_SETTINGS_OUTPUT_URI, uri);
+                gtk_print_settings_set(settings, GTK_PRINT_SETTINGS_OUTPUT_FILE_FORMAT, "pdf");
+                gtk_print_settings_set_orientation(settings, gtk_page_setup_get_orientation(setup));
+                gtk_print_settings_set_paper_size(settings, gtk_page_setup_get_paper_size(setup));
+
+                webkit_print_operation_set_print_settings(operation, settings);
+                webkit_print_operation_print(operation);
+
+                g_free(uri);
+                g_object_unref(settings);
+                g_object_unref(setup);
+
+                return;
+            }
+
+            g_object_unref(setup);
+
+            auto *const window = self->webview->parent().native<true>().window;
+
+            // A cancelled dialog prints nothing and never finishes the operation
+            if (webkit_print_operation_run_dialog(operation, window) == WEBKIT_PRINT_OPERATION_RESPONSE_CANCEL)
+            {
+                print_finished(operation, job);
+            }
+#elif defined(SAUCER_QT)
+            auto *const view = self->webview->native<true>().webview->page();
+            view->settings()->setAttribute(QWebEngineSettings::PrintElementBackgrounds, page.background);
+
+            const auto file = QString::fromStdString(path);
+
+            // The signal reports every print of the page, only the one to this file is ours
+            auto connection = std::make_shared<QMetaObject::Connection>();
+
+            *connection = QObject::connect(view, &QWebEnginePage::pdfPrintingFinished,
+                                           [connection, file, handle](const QString &written, bool success)
+                                           {
+                                               if (written != file)
+                                               {
+                                                   return;
+                                               }
+
+                                               QObject::disconnect(*connection);
+                                               finish(handle, success ? "" : "writing the PDF failed");
+                                           });
+
+            view->printToPdf(file, page_layout(page));
+#elif defined(SAUCER_WEBVIEW2)
+            if (path.empty())
+            {
+                auto webview = revision<ICoreWebView2_16>(*self);
+
+                if (!webview)
+                {
+                    finish(handle, "the print dialog needs a newer WebView2 runtime");
+                    return;
+                }
+
+                const auto result = webview->ShowPrintUI(COREWEBVIEW2_PRINT_DIALOG_KIND_BROWSER);
+                finish(handle, FAILED(result) ? hresult(result) : "");
+
+                return;
+            }
+
+            auto webview = revision<ICoreWebView2_7>(*self);
+
+            if (!webview)
+            {
+                finish(handle, "printing needs a newer WebView2 runtime");
+                return;
+            }
+
+            auto callback = Microsoft::WRL::Callback<ICoreWebView2PrintToPdfCompletedHandler>(
+                [handle](HRESULT result, BOOL success)
+                {
+                    if (FAILED(result))
+                    {
+                        finish(handle, hresult(result));
+                    }
+                    else
+                    {
+                        finish(handle, success ? "" : "writing the PDF failed");
+                    }
+
+                    return S_OK;
+                });
+
+            const auto settings = print_settings(*self, page);
+
+            if (const auto result = webview->PrintToPdf(widen(path).c_str(), settings.Get(), callback.Get());
+                FAILED(result))
+            {
+                finish(handle, hresult(result));
+            }
+#elif defined(SAUCER_WEBKIT)
+            auto options = page;
+            options.path = path.empty() ? nullptr : path.c_str();
+
+            saucerw_cocoa_print(self->webview->native<false>(), &options, handle);
+#endif
+        });
+}
+
+void saucerw_webview_capture(saucerw_webview *self, uintptr_t handle)
+{
+    self->webview->parent().parent().post(
+        [self, handle]
+        {
+#if defined(SAUCER_WEBKITGTK)
+            webkit_web_view_get_snapshot(self->webview->native<true>().webview, WEBKIT_SNAPSHOT_REGION_VISIBLE,
+                                         WEBKIT_SNAPSHOT_OPTIONS_NONE, nullptr, snapshot_taken,
+                                         reinterpret_cast<gpointer>(handle));
+#elif defined(SAUCER_QT)
+            const auto pixmap = self->webview->native<true>().webview->grab();
+
+            QByteArray png;
+            QBuffer buffer{&png};
+
+            if (!buffer.open(QIODevice::WriteOnly) || !pixmap.save(&buffer, "PNG"))
+            {
+                capture_failed(handle, "encoding the screenshot failed");
+                return;
+            }
+
+            captured(handle, png.constData(), static_cast<std::size_t>(png.size()));
+#elif defined(SAUCER_WEBVIEW2)
+            auto webview = revision<ICoreWebView2>(*self);
+            Microsoft::WRL::ComPtr<IStream> stream;
+
+            if (!webview || FAILED(CreateStreamOnHGlobal(nullptr, TRUE, &stream)))
+            {
+                capture_failed(handle, "creating the screenshot stream failed");
+                return;
+            }
+
+            auto callback = Microsoft::WRL::Callback<ICoreWebView2CapturePreviewCompletedHandler>(
+                [handle, stream](HRESULT result)
+                {
+                    if (FAILED(result))
+                    {
+                        capture_failed(handle, hresult(result));
+                        return S_OK;
+                    }
+
+                    STATSTG stat{};
+                    HGLOBAL memory{};
+
+                    if (FAILED(stream->Stat(&stat, STATFLAG_NONAME)) ||
+                        FAILED(GetHGlobalFromStream(stream.Get(), &memory)))
+                    {
+                        capture_failed(handle, "reading the screenshot failed");
+                        return S_OK;
+                    }
+
+                    captured(handle, GlobalLock(memory), static_cast<std::size_t>(stat.cbSize.QuadPart));
+                    GlobalUnlock(memory);
+
+                    return S_OK;
+                });
+
+            if (const auto result = webview->CapturePreview(COREWEBVIEW2_CAPTURE_PREVIEW_IMAGE_FORMAT_PNG,
+                                                            stream.Get(), callback.Get());
+                FAILED(result))
+            {
+                capture_failed(handle, hresult(result));
+            }
+#elif defined(SAUCER_WEBKIT)
+            saucerw_cocoa_capture(self->webview->native<false>(), handle);
+#endif
+        });
+}
+
+bool saucerw_webview_send_key(saucerw_webview *self, const saucerw_key_input *input)
+{
+#if defined(SAUCER_QT)
+    menu_entry entry{};
+    entry.key       = input->name;
+    entry.modifiers = input->modifiers;
+
+    const auto combination = key_sequence(entry)[0];
+    const auto text        = QString::fromUtf8(input->text);
+    const auto type        = input->up ? QEvent::KeyRelease : QEvent::KeyPress;
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            QKeyEvent event{type, combination.key(), combination.keyboardModifiers(), text};
+            QCoreApplication::sendEvent(input_target(*self), &event);
+        });
+
+    return true;
+#elif defined(SAUCER_WEBVIEW2)
+    const auto *const type = input->up ? "keyUp" : *input->text ? "keyDown" : "rawKeyDown";
+    const auto key         = virtual_key(input->name);
+
+    const auto params = std::format(R"({{"type":"{}","modifiers":{},"key":{},"code":{},"text":{},)"
+                                    R"("windowsVirtualKeyCode":{},"nativeVirtualKeyCode":{}}})",
+                                    type, devtools_modifiers(input->modifiers), json_string(input->key),
+                                    json_string(input->code), json_string(input->text), key, key);
+
+    bool rtn{};
+    self->webview->parent().parent().invoke([&] { rtn = devtools(*self, L"Input.dispatchKeyEvent", params); });
+
+    return rtn;
+#elif defined(SAUCER_WEBKIT)
+    bool rtn{};
+    self->webview->parent().parent().invoke(
+        [&] { rtn = saucerw_cocoa_send_key(self->webview->native<false>(), input); });
+
+    return rtn;
+#else
+    // GTK 4 cannot synthesize events and WebKitGTK offers no other way
+    return false;
+#endif
+}
+
+bool saucerw_webview_send_text(saucerw_webview *self, const char *text)
+{
+#if defined(SAUCER_QT)
+    const auto commit = QString::fromUtf8(text);
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            QInputMethodEvent event;
+            event.setCommitString(commit);
+
+            QCoreApplication::sendEvent(input_target(*self), &event);
+        });
+
+    return true;
+#elif defined(SAUCER_WEBVIEW2)
+    const auto params = std::format(R"({{"text":{}}})", json_string(text));
+
+    bool rtn{};
+    self->webview->parent().parent().invoke([&] { rtn = devtools(*self, L"Input.insertText", params); });
+
+    return rtn;
+#elif defined(SAUCER_WEBKIT)
+    bool rtn{};
+    self->webview->parent().parent().invoke(
+        [&] { rtn = saucerw_cocoa_send_text(self->webview->native<false>(), text); });
+
+    return rtn;
+#else
+    return false;
+#endif
+}
+
+bool saucerw_webview_send_mouse(saucerw_webview *self, const saucerw_mouse_input *input)
+{
+#if defined(SAUCER_QT)
+    static constexpr Qt::MouseButton buttons[] = {Qt::LeftButton, Qt::MiddleButton, Qt::RightButton};
+
+    auto *const webview = self->webview->native<true>().webview;
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            auto *const target   = input_target(*self);
+            const auto zoom      = webview->zoomFactor();
+            const auto modifiers = qt_modifiers(input->modifiers);
+
+            const QPointF local{input->x * zoom, input->y * zoom};
+            const auto global = target->mapToGlobal(local);
+
+            if (input->action == SAUCERW_MOUSE_WHEEL)
+            {
+                // Wheel deltas point away from the user, a notch of 120 scrolls by about 100 pixels
+                const QPoint pixels{-static_cast<int>(input->dx * zoom), -static_cast<int>(input->dy * zoom)};
+                QWheelEvent event{local, global, pixels, pixels * 6 / 5, Qt::NoButton, modifiers,
+                                  Qt::NoScrollPhase, false};
+
+                QCoreApplication::sendEvent(target, &event);
+                return;
+            }
+
+            auto type   = QEvent::MouseMove;
+            auto button = Qt::NoButton;
+            auto held   = Qt::MouseButtons{};
+
+            // Qt WebEngine counts the clicks itself by their time and position
+            if (input->action == SAUCERW_MOUSE_DOWN)
+            {
+                type   = QEvent::MouseButtonPress;
+                button = buttons[input->button];
+                held   = button;
+            }
+            else if (input->action == SAUCERW_MOUSE_UP)
+            {
+                type   = QEvent::MouseButtonRelease;
+                button = buttons[input->button];
+            }
+
+            QMouseEvent event{type, local, global, button, held, modifiers};
+            QCoreApplication::sendEvent(target, &event);
+        });
+
+    return true;
+#elif defined(SAUCER_WEBVIEW2)
+    static constexpr std::string_view buttons[] = {"left", "middle", "right"};
+    // The bits of the buttons in the buttons field of the protocol
+    static constexpr int held[] = {1, 4, 2};
+
+    std::string_view type = "mouseMoved";
+    std::string_view button{"none"};
+    auto pressed = 0;
+
+    if (input->action == SAUCERW_MOUSE_DOWN)
+    {
+        type    = "mousePressed";
+        button  = buttons[input->button];
+        pressed = held[input->button];
+    }
+    else if (input->action == SAUCERW_MOUSE_UP)
+    {
+        type   = "mouseReleased";
+        button = buttons[input->button];
+    }
+    else if (input->action == SAUCERW_MOUSE_WHEEL)
+    {
+        type = "mouseWheel";
+    }
+
+    const auto params = std::format(R"({{"type":"{}","x":{},"y":{},"modifiers":{},"button":"{}","buttons":{},)"
+                                    R"("clickCount":{},"deltaX":{},"deltaY":{}}})",
+                                    type, input->x, input->y, devtools_modifiers(input->modifiers), button, pressed,
+                                    input->clicks, input->dx, input->dy);
+
+    bool rtn{};
+    self->webview->parent().parent().invoke([&] { rtn = devtools(*self, L"Input.dispatchMouseEvent", params); });
+
+    return rtn;
+#elif defined(SAUCER_WEBKIT)
+    bool rtn{};
+    self->webview->parent().parent().invoke(
+        [&] { rtn = saucerw_cocoa_send_mouse(self->webview->native<false>(), input); });
+
+    return rtn;
+#else
+    return false;
+#endif
+}
+
+bool saucerw_webview_set_dark_mode(saucerw_webview *self, int mode)
+{
+#if defined(SAUCER_WEBVIEW2)
+    bool rtn{};
+
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            Microsoft::WRL::ComPtr<ICoreWebView2Profile> profile;
+
+            if (auto webview = revision<ICoreWebView2_13>(*self); !webview || FAILED(webview->get_Profile(&profile)))
+            {
+                return;
+            }
+
+            auto scheme = COREWEBVIEW2_PREFERRED_COLOR_SCHEME_AUTO;
+
+            if (mode == SAUCERW_DARK_MODE_OFF)
+            {
+                scheme = COREWEBVIEW2_PREFERRED_COLOR_SCHEME_LIGHT;
+            }
+            else if (mode == SAUCERW_DARK_MODE_ON)
+            {
+                scheme = COREWEBVIEW2_PREFERRED_COLOR_SCHEME_DARK;
+            }
+
+            rtn = SUCCEEDED(profile->put_PreferredColorScheme(scheme));
+        });
+
+    return rtn;
+#elif defined(SAUCER_WEBKIT)
+    self->webview->parent().parent().invoke([&] { saucerw_cocoa_set_dark_mode(self->webview->native<false>(), mode); });
+    return true;
+#else
+    // Neither WebKitGTK nor Qt WebEngine can override the scheme of a single webview
+    return false;
+#endif
+}
+
+saucerw_shared_buffer *saucerw_webview_shared_buffer(saucerw_webview *self, uint64_t size, uint8_t **data)
+{
+#if defined(SAUCER_WEBVIEW2)
+    saucerw_shared_buffer *rtn{};
+    auto &app = self->webview->parent().parent();
+
+    app.invoke(
+        [&]
+        {
+            Microsoft::WRL::ComPtr<ICoreWebView2Environment> environment;
+            Microsoft::WRL::ComPtr<ICoreWebView2Environment12> factory;
+
+            if (auto webview = revision<ICoreWebView2_2>(*self); webview)
+            {
+                webview->get_Environment(&environment);
+            }
+
+            Microsoft::WRL::ComPtr<ICoreWebView2SharedBuffer> buffer;
+
+            if (!environment || FAILED(environment.As(&factory)) || FAILED(factory->CreateSharedBuffer(size, &buffer)))
+            {
+                return;
+            }
+
+            if (FAILED(buffer->get_Buffer(data)))
+            {
+                buffer->Close();
+                return;
+            }
+
+            rtn = new saucerw_shared_buffer{.app = &app, .buffer = std::move(buffer)};
+        });
+
+    return rtn;
+#else
+    // WebKitGTK, Qt WebEngine and WKWebView cannot share memory with the page
+    return nullptr;
+#endif
+}
+
+void saucerw_shared_buffer_post(saucerw_webview *self, saucerw_shared_buffer *buffer, bool readonly,
+                                const char *detail)
+{
+#if defined(SAUCER_WEBVIEW2)
+    self->webview->parent().parent().invoke(
+        [&]
+        {
+            auto webview = revision<ICoreWebView2_17>(*self);
+
+            if (!webview)
+            {
+                return;
+            }
+
+            const auto access =
+                readonly ? COREWEBVIEW2_SHARED_BUFFER_ACCESS_READ_ONLY : COREWEBVIEW2_SHARED_BUFFER_ACCESS_READ_WRITE;
+
+            webview->PostSharedBufferToScript(buffer->buffer.Get(), access, widen(detail).c_str());
+        });
+#endif
+}
+
+void saucerw_shared_buffer_free(saucerw_shared_buffer *buffer)
+{
+    auto owned = std::unique_ptr<saucerw_shared_buffer>{buffer};
+
+#if defined(SAUCER_WEBVIEW2)
+    // Detaches the ArrayBuffers of the page, the memory is unmapped once they are collected
+    owned->app->invoke([&] { owned->buffer->Close(); });
+#endif
+}
+
+void saucerw_webview_handle_scheme(saucerw_webview *self, const char *name, uintptr_t handler)
+{
+    auto callback = [handler](saucer::scheme::request request, saucer::scheme::executor executor)
+    {
+        convert(request, [&](saucerw_scheme_request *data)
+                { saucerwScheme(handler, data, new saucerw_executor{std::move(executor)}); });
+    };
+
+    self->webview->handle_scheme(name, std::move(callback));
+}
+
+void saucerw_webview_remove_scheme(saucerw_webview *self, const char *name)
+{
+    self->webview->remove_scheme(name);
+}
+
+void saucerw_webview_handle_stream_scheme(saucerw_webview *self, const char *name, uintptr_t handler)
+{
+    auto callback = [handler](saucer::scheme::request request, saucer::scheme::stream_writer writer)
+    {
+        convert(request, [&](saucerw_scheme_request *data)
+                { saucerwStreamScheme(handler, data, new saucerw_stream{std::move(writer)}); });
+    };
+
+    self->webview->handle_stream_scheme(name, std::move(callback));
+}
+
+void saucerw_webview_remove_stream_scheme(saucerw_webview *self, const char *name)
+{
+    self->webview->remove_stream_scheme(name);
+}
+
+void saucerw_scheme_resolve(saucerw_executor *executor, int status, const char *mime, size_t count, const char **keys,
+                            const char **values, const uint8_t *data, size_t size)
+{
+    auto owned = std::unique_ptr<saucerw_executor>{executor};
+
+    owned->executor.resolve({
+        .data    = saucer::stash::from(std::vector<std::uint8_t>(data, data + size)),
+        .mime    = mime,
+        .headers = header_map(count, keys, values),
+        .status  = status,
+    });
+}
+
+void saucerw_stream_start(saucerw_stream *stream, int status, const char *mime, size_t count, const char **keys,
+                          const char **values)
+{
+    stream->writer.start({
+        .mime    = mime,
+        .headers = header_map(count, keys, values),
+        .status  = status,
+    });
+}
+
+void saucerw_stream_write(saucerw_stream *stream, const uint8_t *data, size_t size)
+{
+    stream->writer.write(saucer::stash::from(std::vector<std::uint8_t>(data, data + size)));
+}
+
+void saucerw_stream_finish(saucerw_stream *stream)
+{
+    auto owned = std::unique_ptr<saucerw_stream>{stream};
+    owned->writer.finish();
+}
+
+void saucerw_stream_reject(saucerw_stream *stream, int status)
+{
+    auto owned = std::unique_ptr<saucerw_stream>{stream};
+
+    switch (status)
+    {
+    case 400:
+    case 401:
+    case 404:
+        owned->writer.reject(static_cast<saucer::scheme::error>(status));
+        break;
+    default:
+        owned->writer.reject(saucer::scheme::error::failed);
+    }
+}
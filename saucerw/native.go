@@ -0,0 +1,1348 @@
+//go:build cgo && saucer
+
+package saucerw
+
+/*
+#cgo CXXFLAGS: -std=c++23 -I${SRCDIR}/../include
+#cgo LDFLAGS: -lsaucer
+#cgo darwin CFLAGS: -fobjc-arc
+#cgo darwin LDFLAGS: -framework AppKit -framework WebKit -framework Network
+#cgo windows LDFLAGS: -ldwmapi -lshcore -lwtsapi32
+
+#include <stdlib.h>
+#include "native.h"
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime"
+	"runtime/cgo"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/aperturerobotics/saucer/saucerw/pdf"
+)
+
+func init() {
+	// The native event loop has to run on the main thread.
+	runtime.LockOSThread()
+
+	defaultDriver = nativeDriver{}
+}
+
+//export saucerwInvoke
+func saucerwInvoke(handle C.uintptr_t) {
+	defer guard("native callback")
+	cgo.Handle(handle).Value().(func())()
+}
+
+//export saucerwInvokeOnce
+func saucerwInvokeOnce(handle C.uintptr_t) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+	defer guard("posted function")
+
+	h.Value().(func())()
+}
+
+//export saucerwMessage
+func saucerwMessage(handle C.uintptr_t, message *C.char, size C.size_t) C.bool {
+	defer guard("message handler")
+
+	fn := cgo.Handle(handle).Value().(func(string) bool)
+	return C.bool(fn(C.GoStringN(message, C.int(size))))
+}
+
+//export saucerwScheme
+func saucerwScheme(handle C.uintptr_t, request *C.saucerw_scheme_request, executor *C.saucerw_executor) {
+	handler := cgo.Handle(handle).Value().(func(SchemeRequest, func(SchemeResponse)))
+	handler(goSchemeRequest(request), func(res SchemeResponse) { resolveScheme(executor, res) })
+}
+
+//export saucerwStreamScheme
+func saucerwStreamScheme(handle C.uintptr_t, request *C.saucerw_scheme_request, stream *C.saucerw_stream) {
+	handler := cgo.Handle(handle).Value().(func(SchemeRequest, SchemeStream))
+	handler(goSchemeRequest(request), &nativeStream{ptr: stream})
+}
+
+// goSchemeRequest copies a scheme request passed by the shim.
+func goSchemeRequest(request *C.saucerw_scheme_request) SchemeRequest {
+	keys := unsafe.Slice(request.header_keys, request.headers)
+	values := unsafe.Slice(request.header_values, request.headers)
+
+	req := SchemeRequest{
+		URL:     C.GoString(request.url),
+		Method:  C.GoString(request.method),
+		Headers: make(map[string]string, len(keys)),
+		Body:    C.GoBytes(unsafe.Pointer(request.body), C.int(request.body_size)),
+	}
+
+	for i := range keys {
+		req.Headers[C.GoString(keys[i])] = C.GoString(values[i])
+	}
+
+	return req
+}
+
+//export saucerwWindowEvent
+func saucerwWindowEvent(handle C.uintptr_t, event C.saucerw_window_event, value, w, h C.int) {
+	fn := cgo.Handle(handle).Value().(func(WindowEvent))
+
+	fn(WindowEvent{
+		Type:       WindowEventType(event),
+		Value:      value != 0,
+		Size:       Size{W: int(w), H: int(h)},
+		Decoration: Decoration(value),
+		Scale:      float64(value) / 1000,
+	})
+}
+
+//export saucerwWebviewEvent
+func saucerwWebviewEvent(handle C.uintptr_t, event C.saucerw_webview_event, value C.int) {
+	fn := cgo.Handle(handle).Value().(func(WebviewEvent))
+	fn(WebviewEvent{Type: WebviewEventType(event), Load: LoadState(value), Playing: value != 0, Gone: GoneReason(value)})
+}
+
+//export saucerwMenu
+func saucerwMenu(handle C.uintptr_t, id C.int32_t) {
+	defer guard("menu handler")
+	cgo.Handle(handle).Value().(func(int32))(int32(id))
+}
+
+//export saucerwClipboardText
+func saucerwClipboardText(handle C.uintptr_t, text *C.char, size C.size_t) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+
+	done := h.Value().(func(string, bool))
+	if text == nil {
+		done("", false)
+		return
+	}
+	done(C.GoStringN(text, C.int(size)), true)
+}
+
+//export saucerwClipboardImage
+func saucerwClipboardImage(handle C.uintptr_t, img *C.saucerw_image) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+
+	done := h.Value().(func(*image.NRGBA))
+	if img == nil {
+		done(nil)
+		return
+	}
+
+	width, height := int(img.w), int(img.h)
+	done(&image.NRGBA{
+		Pix:    C.GoBytes(unsafe.Pointer(img.pixels), C.int(4*width*height)),
+		Stride: 4 * width,
+		Rect:   image.Rect(0, 0, width, height),
+	})
+}
+
+//export saucerwCookies
+func saucerwCookies(handle C.uintptr_t, count C.size_t, cookies *C.saucerw_cookie, err *C.char) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+
+	done := h.Value().(func([]*http.Cookie, error))
+	if err != nil {
+		done(nil, errors.New("saucerw: "+C.GoString(err)))
+		return
+	}
+
+	rtn := make([]*http.Cookie, 0, int(count))
+	for _, cookie := range unsafe.Slice(cookies, int(count)) {
+		c := &http.Cookie{
+			Name:     C.GoString(cookie.name),
+			Value:    C.GoString(cookie.value),
+			Domain:   C.GoString(cookie.domain),
+			Path:     C.GoString(cookie.path),
+			Secure:   bool(cookie.secure),
+			HttpOnly: bool(cookie.http_only),
+			SameSite: http.SameSite(cookie.same_site),
+		}
+		if cookie.expires != 0 {
+			c.Expires = time.Unix(int64(cookie.expires), 0)
+		}
+		rtn = append(rtn, c)
+	}
+	done(rtn, nil)
+}
+
+//export saucerwDone
+func saucerwDone(handle C.uintptr_t, err *C.char) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+
+	done := h.Value().(func(error))
+	if err != nil {
+		done(errors.New("saucerw: " + C.GoString(err)))
+		return
+	}
+	done(nil)
+}
+
+//export saucerwCapture
+func saucerwCapture(handle C.uintptr_t, data *C.uint8_t, size C.size_t, err *C.char) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+
+	done := h.Value().(func([]byte, error))
+	if err != nil {
+		done(nil, errors.New("saucerw: "+C.GoString(err)))
+		return
+	}
+	done(C.GoBytes(unsafe.Pointer(data), C.int(size)), nil)
+}
+
+//export saucerwColorScheme
+func saucerwColorScheme(handle C.uintptr_t, scheme C.int) {
+	defer guard("color scheme handler")
+	cgo.Handle(handle).Value().(func(ColorScheme))(ColorScheme(scheme))
+}
+
+//export saucerwLocales
+func saucerwLocales(handle C.uintptr_t, locales *C.char) {
+	defer guard("locale handler")
+	cgo.Handle(handle).Value().(func([]string))(splitLocales(C.GoString(locales)))
+}
+
+//export saucerwAccessibility
+func saucerwAccessibility(handle C.uintptr_t, flags C.int) {
+	defer guard("accessibility handler")
+	cgo.Handle(handle).Value().(func(Accessibility))(accessibilityOf(int(flags)))
+}
+
+//export saucerwPower
+func saucerwPower(handle C.uintptr_t, event C.saucerw_power_event) {
+	defer guard("power handler")
+	cgo.Handle(handle).Value().(func(PowerEvent))(PowerEvent(event))
+}
+
+//export saucerwDrop
+func saucerwDrop(handle C.uintptr_t, paths **C.char, count C.size_t, x, y C.int) {
+	defer guard("file drop handler")
+
+	drop := FileDrop{Position: Position{X: int(x), Y: int(y)}}
+	for _, path := range unsafe.Slice(paths, count) {
+		drop.Paths = append(drop.Paths, C.GoString(path))
+	}
+
+	cgo.Handle(handle).Value().(func(FileDrop))(drop)
+}
+
+//export saucerwContextMenu
+func saucerwContextMenu(handle C.uintptr_t, context *C.saucerw_context, menu *C.saucerw_context_menu) C.bool {
+	defer guard("context menu handler")
+
+	info := ContextInfo{
+		Position:  Position{X: int(context.x), Y: int(context.y)},
+		LinkURL:   C.GoString(context.link),
+		ImageURL:  C.GoString(context.image),
+		MediaURL:  C.GoString(context.media),
+		Selection: C.GoString(context.selection),
+		Editable:  bool(context.editable),
+	}
+
+	fn := cgo.Handle(handle).Value().(func(ContextInfo, bool) ([]MenuEntry, bool))
+
+	entries, ok := fn(info, bool(context.partial))
+	if !ok {
+		return false
+	}
+
+	items, free := cMenuItems(entries)
+	defer free()
+
+	C.saucerw_context_menu_set(menu, C.size_t(len(items)), unsafe.SliceData(items))
+	return true
+}
+
+//export saucerwCredentials
+func saucerwCredentials(handle C.uintptr_t, host *C.char, user, password **C.char) C.bool {
+	fn := cgo.Handle(handle).Value().(func(string) (string, string, bool))
+
+	name, secret, ok := fn(C.GoString(host))
+	if !ok {
+		return false
+	}
+
+	*user = C.CString(name)
+	*password = C.CString(secret)
+	return true
+}
+
+//export saucerwCertificate
+func saucerwCertificate(handle C.uintptr_t, url, pem *C.char, size C.size_t) C.bool {
+	fn := cgo.Handle(handle).Value().(func(string, []byte) bool)
+	return C.bool(fn(C.GoString(url), C.GoBytes(unsafe.Pointer(pem), C.int(size))))
+}
+
+//export saucerwRequest
+func saucerwRequest(handle C.uintptr_t, url *C.char) C.bool {
+	fn := cgo.Handle(handle).Value().(func(string) bool)
+	return C.bool(fn(C.GoString(url)))
+}
+
+//export saucerwNavigate
+func saucerwNavigate(handle C.uintptr_t, url *C.char, newWindow, redirection, userInitiated C.bool) C.bool {
+	fn := cgo.Handle(handle).Value().(func(NavigationEvent) Policy)
+
+	policy := fn(NavigationEvent{
+		URL:           C.GoString(url),
+		NewWindow:     bool(newWindow),
+		Redirect:      bool(redirection),
+		UserInitiated: bool(userInitiated),
+	})
+
+	return C.bool(policy == Allow)
+}
+
+//export saucerwPermission
+func saucerwPermission(handle C.uintptr_t, url *C.char, types C.int, req *C.saucerw_permission) C.int {
+	fn := cgo.Handle(handle).Value().(func(string, Permission, func(bool)) PermissionDecision)
+
+	answer := func(granted bool) { C.saucerw_permission_accept(req, C.bool(granted)) }
+	return C.int(fn(C.GoString(url), Permission(types), answer))
+}
+
+//export saucerwDownload
+func saucerwDownload(handle C.uintptr_t, req *C.saucerw_download_request, cancel *C.bool, path **C.char) C.uintptr_t {
+	fn := cgo.Handle(handle).Value().(func(DownloadRequest) DownloadDecision)
+
+	decision := fn(DownloadRequest{
+		URL:           C.GoString(req.url),
+		SuggestedName: C.GoString(req.suggested_name),
+		MIMEType:      C.GoString(req.mime),
+		Size:          int64(req.size),
+	})
+
+	if decision.Cancel {
+		*cancel = true
+		return 0
+	}
+
+	if decision.Path != "" {
+		*path = C.CString(decision.Path)
+	}
+
+	if decision.OnProgress == nil && decision.OnDone == nil {
+		return 0
+	}
+	return C.uintptr_t(cgo.NewHandle(&decision))
+}
+
+//export saucerwDownloadEvent
+func saucerwDownloadEvent(handle C.uintptr_t, event C.saucerw_download_event, received, total C.int64_t, path, reason *C.char) {
+	h := cgo.Handle(handle)
+	decision := h.Value().(*DownloadDecision)
+
+	var err error
+	switch event {
+	case C.SAUCERW_DOWNLOAD_PROGRESS:
+		if decision.OnProgress != nil {
+			decision.OnProgress(int64(received), int64(total))
+		}
+		return
+	case C.SAUCERW_DOWNLOAD_CANCELLED:
+		err = ErrDownloadCancelled
+	case C.SAUCERW_DOWNLOAD_FAILED:
+		err = errors.New("saucerw: download failed: " + C.GoString(reason))
+	}
+
+	h.Delete()
+
+	if decision.OnDone != nil {
+		decision.OnDone(C.GoString(path), err)
+	}
+}
+
+// nativeHeaders converts headers to C strings, released by free.
+func nativeHeaders(headers map[string]string) (keys, values []*C.char, free func()) {
+	keys = make([]*C.char, 0, len(headers)+1)
+	values = make([]*C.char, 0, len(headers)+1)
+
+	for key, value := range headers {
+		keys, values = append(keys, C.CString(key)), append(values, C.CString(value))
+	}
+
+	return keys, values, func() {
+		for i := range keys {
+			C.free(unsafe.Pointer(keys[i]))
+			C.free(unsafe.Pointer(values[i]))
+		}
+	}
+}
+
+// nativeBytes returns a pointer to the first byte of data, nil if empty.
+func nativeBytes(data []byte) *C.uint8_t {
+	if len(data) == 0 {
+		return nil
+	}
+	return (*C.uint8_t)(unsafe.Pointer(&data[0]))
+}
+
+// resolveScheme answers a scheme request, freeing its executor.
+func resolveScheme(executor *C.saucerw_executor, res SchemeResponse) {
+	mime := C.CString(res.Mime)
+	defer C.free(unsafe.Pointer(mime))
+
+	keys, values, free := nativeHeaders(res.Headers)
+	defer free()
+
+	C.saucerw_scheme_resolve(executor, C.int(res.Status), mime, C.size_t(len(keys)),
+		unsafe.SliceData(keys), unsafe.SliceData(values), nativeBytes(res.Body), C.size_t(len(res.Body)))
+}
+
+// nativeStream implements SchemeStream, the shim frees it once finished or
+// rejected.
+type nativeStream struct {
+	ptr *C.saucerw_stream
+}
+
+func (s *nativeStream) Start(res SchemeResponse) {
+	mime := C.CString(res.Mime)
+	defer C.free(unsafe.Pointer(mime))
+
+	keys, values, free := nativeHeaders(res.Headers)
+	defer free()
+
+	C.saucerw_stream_start(s.ptr, C.int(res.Status), mime, C.size_t(len(keys)),
+		unsafe.SliceData(keys), unsafe.SliceData(values))
+}
+
+func (s *nativeStream) Write(data []byte) {
+	C.saucerw_stream_write(s.ptr, nativeBytes(data), C.size_t(len(data)))
+}
+
+func (s *nativeStream) Finish() {
+	C.saucerw_stream_finish(s.ptr)
+}
+
+func (s *nativeStream) Reject(status int) {
+	C.saucerw_stream_reject(s.ptr, C.int(status))
+}
+
+// nativeError converts an error string allocated by the shim.
+func nativeError(msg *C.char) error {
+	defer C.free(unsafe.Pointer(msg))
+	return errors.New("saucer: " + C.GoString(msg))
+}
+
+// nativeString converts and frees a string allocated by the shim.
+func nativeString(str *C.char) string {
+	defer C.free(unsafe.Pointer(str))
+	return C.GoString(str)
+}
+
+// goColor converts a color returned by the shim.
+func goColor(c C.saucerw_color) Color {
+	return Color{R: uint8(c.r), G: uint8(c.g), B: uint8(c.b), A: uint8(c.a)}
+}
+
+// nativeColor converts a color passed to the shim.
+func nativeColor(c Color) C.saucerw_color {
+	return C.saucerw_color{r: C.uint8_t(c.R), g: C.uint8_t(c.G), b: C.uint8_t(c.B), a: C.uint8_t(c.A)}
+}
+
+// captureLogs routes the native log output to SetLogger and fatal errors to
+// OnPanic once.
+var captureLogs sync.Once
+
+// nativeLevels maps the shim log levels.
+var nativeLevels = [...]slog.Level{
+	C.SAUCERW_LOG_DEBUG: slog.LevelDebug,
+	C.SAUCERW_LOG_INFO:  slog.LevelInfo,
+	C.SAUCERW_LOG_WARN:  slog.LevelWarn,
+	C.SAUCERW_LOG_ERROR: slog.LevelError,
+}
+
+//export saucerwFatal
+func saucerwFatal(component, message *C.char, size C.size_t) {
+	report(CrashReport{Source: C.GoString(component), Value: C.GoStringN(message, C.int(size)), Fatal: true})
+}
+
+//export saucerwLog
+func saucerwLog(level C.saucerw_log_level, component, message *C.char, size C.size_t) {
+	log().Log(context.Background(), nativeLevels[level], C.GoStringN(message, C.int(size)), "component", C.GoString(component))
+}
+
+// nativeDriver implements Driver on top of the saucer C++ library.
+type nativeDriver struct{}
+
+func (nativeDriver) NewApp(opts AppOptions) (AppDriver, error) {
+	captureLogs.Do(func() {
+		C.saucerw_capture_logs()
+		C.saucerw_capture_fatal()
+	})
+
+	for _, scheme := range opts.Schemes {
+		name := C.CString(scheme)
+		C.saucerw_register_scheme(name)
+		C.free(unsafe.Pointer(name))
+	}
+
+	id := C.CString(opts.ID)
+	defer C.free(unsafe.Pointer(id))
+
+	argv := make([]*C.char, len(opts.Args)+1)
+	for i, arg := range opts.Args {
+		argv[i] = C.CString(arg)
+		defer C.free(unsafe.Pointer(argv[i]))
+	}
+
+	var msg *C.char
+	ptr := C.saucerw_app_new(id, C.int(len(opts.Args)), &argv[0], C.bool(!opts.KeepRunning), &msg)
+	if ptr == nil {
+		return nil, nativeError(msg)
+	}
+	return &nativeApp{ptr: ptr}, nil
+}
+
+type nativeApp struct {
+	ptr     *C.saucerw_app
+	handles []cgo.Handle
+
+	// mu guards released against the shutdown being released late.
+	mu       sync.Mutex
+	released bool
+}
+
+func (a *nativeApp) Run(start func()) int {
+	h := cgo.NewHandle(start)
+	defer h.Delete()
+
+	return int(C.saucerw_app_run(a.ptr, C.uintptr_t(h)))
+}
+
+func (a *nativeApp) Quit() {
+	C.saucerw_app_quit(a.ptr)
+}
+
+func (a *nativeApp) Post(fn func()) {
+	C.saucerw_app_post(a.ptr, C.uintptr_t(cgo.NewHandle(fn)))
+}
+
+func (a *nativeApp) ThreadSafe() bool {
+	return bool(C.saucerw_app_thread_safe(a.ptr))
+}
+
+func (a *nativeApp) Screens() []Screen {
+	var screens *C.saucerw_screen
+
+	n := C.saucerw_app_screens(a.ptr, &screens)
+	defer C.free(unsafe.Pointer(screens))
+
+	rtn := make([]Screen, 0, n)
+	for _, s := range unsafe.Slice(screens, n) {
+		rtn = append(rtn, Screen{
+			Name:     nativeString(s.name),
+			Size:     Size{W: int(s.w), H: int(s.h)},
+			Position: Position{X: int(s.x), Y: int(s.y)},
+			Scale:    float64(s.scale),
+		})
+	}
+	return rtn
+}
+
+func (a *nativeApp) ReadClipboardText(done func(text string, ok bool)) {
+	C.saucerw_app_read_text(a.ptr, C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (a *nativeApp) ReadClipboardImage(done func(*image.NRGBA)) {
+	C.saucerw_app_read_image(a.ptr, C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (a *nativeApp) WriteClipboardText(text string) {
+	str := C.CString(text)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_app_write_text(a.ptr, str, C.size_t(len(text)))
+}
+
+func (a *nativeApp) WriteClipboardImage(img *image.NRGBA) {
+	size := img.Rect.Size()
+	if size.X == 0 || size.Y == 0 {
+		return
+	}
+
+	pixels := C.CBytes(img.Pix[:4*size.X*size.Y])
+	defer C.free(pixels)
+
+	C.saucerw_app_write_image(a.ptr, C.saucerw_image{w: C.int(size.X), h: C.int(size.Y), pixels: (*C.uint8_t)(pixels)})
+}
+
+func (a *nativeApp) HandleClipboard(fn func()) {
+	h := cgo.NewHandle(fn)
+	a.handles = append(a.handles, h)
+
+	C.saucerw_app_on_clipboard(a.ptr, C.uintptr_t(h))
+}
+
+func (a *nativeApp) ColorScheme() ColorScheme {
+	return ColorScheme(C.saucerw_app_color_scheme(a.ptr))
+}
+
+func (a *nativeApp) HandleColorScheme(fn func(ColorScheme)) {
+	h := cgo.NewHandle(fn)
+	a.handles = append(a.handles, h)
+
+	C.saucerw_app_on_color_scheme(a.ptr, C.uintptr_t(h))
+}
+
+func (a *nativeApp) Locales() []string {
+	ptr := C.saucerw_app_locales(a.ptr)
+	defer C.free(unsafe.Pointer(ptr))
+
+	return splitLocales(C.GoString(ptr))
+}
+
+func (a *nativeApp) HandleLocales(fn func([]string)) {
+	h := cgo.NewHandle(fn)
+	a.handles = append(a.handles, h)
+
+	C.saucerw_app_on_locales(a.ptr, C.uintptr_t(h))
+}
+
+func (a *nativeApp) Accessibility() Accessibility {
+	return accessibilityOf(int(C.saucerw_app_accessibility(a.ptr)))
+}
+
+func (a *nativeApp) HandleAccessibility(fn func(Accessibility)) {
+	h := cgo.NewHandle(fn)
+	a.handles = append(a.handles, h)
+
+	C.saucerw_app_on_accessibility(a.ptr, C.uintptr_t(h))
+}
+
+func (a *nativeApp) HandlePower(fn func(PowerEvent, func())) {
+	release := func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		if !a.released {
+			C.saucerw_app_release_shutdown(a.ptr)
+		}
+	}
+
+	h := cgo.NewHandle(func(ev PowerEvent) { fn(ev, release) })
+	a.handles = append(a.handles, h)
+
+	C.saucerw_app_on_power(a.ptr, C.uintptr_t(h))
+}
+
+func (a *nativeApp) RemoveWebsiteData(storagePath string, done func(error)) {
+	path := C.CString(storagePath)
+	defer C.free(unsafe.Pointer(path))
+
+	C.saucerw_app_remove_website_data(a.ptr, path, C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (a *nativeApp) NewWindow() (WindowDriver, error) {
+	var msg *C.char
+	ptr := C.saucerw_window_new(a.ptr, &msg)
+	if ptr == nil {
+		return nil, nativeError(msg)
+	}
+	return &nativeWindow{ptr: ptr}, nil
+}
+
+func (a *nativeApp) Release() {
+	a.mu.Lock()
+	a.released = true
+	C.saucerw_app_free(a.ptr)
+	a.mu.Unlock()
+
+	for _, h := range a.handles {
+		h.Delete()
+	}
+}
+
+type nativeWindow struct {
+	ptr     *C.saucerw_window
+	handles []cgo.Handle
+}
+
+func (w *nativeWindow) Visible() bool   { return bool(C.saucerw_window_visible(w.ptr)) }
+func (w *nativeWindow) Focused() bool   { return bool(C.saucerw_window_focused(w.ptr)) }
+func (w *nativeWindow) Minimized() bool { return bool(C.saucerw_window_minimized(w.ptr)) }
+func (w *nativeWindow) Maximized() bool { return bool(C.saucerw_window_maximized(w.ptr)) }
+func (w *nativeWindow) Resizable() bool { return bool(C.saucerw_window_resizable(w.ptr)) }
+
+func (w *nativeWindow) Fullscreen() bool   { return bool(C.saucerw_window_fullscreen(w.ptr)) }
+func (w *nativeWindow) AlwaysOnTop() bool  { return bool(C.saucerw_window_always_on_top(w.ptr)) }
+func (w *nativeWindow) ClickThrough() bool { return bool(C.saucerw_window_click_through(w.ptr)) }
+
+func (w *nativeWindow) Title() string {
+	return nativeString(C.saucerw_window_title(w.ptr))
+}
+
+func (w *nativeWindow) Background() Color {
+	return goColor(C.saucerw_window_background(w.ptr))
+}
+
+func (w *nativeWindow) Decorations() Decoration {
+	return Decoration(C.saucerw_window_decorations(w.ptr))
+}
+
+func (w *nativeWindow) Size() Size {
+	var width, height C.int
+	C.saucerw_window_size(w.ptr, &width, &height)
+	return Size{W: int(width), H: int(height)}
+}
+
+func (w *nativeWindow) MinSize() Size {
+	var width, height C.int
+	C.saucerw_window_min_size(w.ptr, &width, &height)
+	return Size{W: int(width), H: int(height)}
+}
+
+func (w *nativeWindow) MaxSize() Size {
+	var width, height C.int
+	C.saucerw_window_max_size(w.ptr, &width, &height)
+	return Size{W: int(width), H: int(height)}
+}
+
+func (w *nativeWindow) Position() Position {
+	var x, y C.int
+	C.saucerw_window_position(w.ptr, &x, &y)
+	return Position{X: int(x), Y: int(y)}
+}
+
+func (w *nativeWindow) Scale() float64 {
+	return float64(C.saucerw_window_scale(w.ptr))
+}
+
+func (w *nativeWindow) OuterSize() Size {
+	var width, height C.int
+	C.saucerw_window_outer_size(w.ptr, &width, &height)
+	return Size{W: int(width), H: int(height)}
+}
+
+func (w *nativeWindow) SafeArea() Insets {
+	var top, right, bottom, left C.int
+	C.saucerw_window_safe_area(w.ptr, &top, &right, &bottom, &left)
+	return Insets{Top: int(top), Right: int(right), Bottom: int(bottom), Left: int(left)}
+}
+
+func (w *nativeWindow) Show()  { C.saucerw_window_show(w.ptr) }
+func (w *nativeWindow) Hide()  { C.saucerw_window_hide(w.ptr) }
+func (w *nativeWindow) Close() { C.saucerw_window_close(w.ptr) }
+func (w *nativeWindow) Focus() { C.saucerw_window_focus(w.ptr) }
+
+func (w *nativeWindow) StartDrag()             { C.saucerw_window_start_drag(w.ptr) }
+func (w *nativeWindow) StartResize(edges Edge) { C.saucerw_window_start_resize(w.ptr, C.int(edges)) }
+
+func (w *nativeWindow) SetMinimized(v bool) { C.saucerw_window_set_minimized(w.ptr, C.bool(v)) }
+func (w *nativeWindow) SetMaximized(v bool) { C.saucerw_window_set_maximized(w.ptr, C.bool(v)) }
+func (w *nativeWindow) SetResizable(v bool) { C.saucerw_window_set_resizable(w.ptr, C.bool(v)) }
+
+func (w *nativeWindow) SetFullscreen(v bool)   { C.saucerw_window_set_fullscreen(w.ptr, C.bool(v)) }
+func (w *nativeWindow) SetAlwaysOnTop(v bool)  { C.saucerw_window_set_always_on_top(w.ptr, C.bool(v)) }
+func (w *nativeWindow) SetClickThrough(v bool) { C.saucerw_window_set_click_through(w.ptr, C.bool(v)) }
+
+func (w *nativeWindow) SetTitle(title string) {
+	str := C.CString(title)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_window_set_title(w.ptr, str)
+}
+
+func (w *nativeWindow) SetIcon(png []byte) error {
+	data := C.CBytes(png)
+	defer C.free(data)
+
+	var msg *C.char
+	if !C.saucerw_window_set_icon(w.ptr, (*C.uint8_t)(data), C.size_t(len(png)), &msg) {
+		return nativeError(msg)
+	}
+	return nil
+}
+
+func (w *nativeWindow) SetBackground(color Color) {
+	C.saucerw_window_set_background(w.ptr, nativeColor(color))
+}
+
+func (w *nativeWindow) SetTitleBarColor(color Color) bool {
+	return bool(C.saucerw_window_set_titlebar_color(w.ptr, nativeColor(color)))
+}
+
+func (w *nativeWindow) SetDecorations(decoration Decoration) {
+	C.saucerw_window_set_decorations(w.ptr, C.int(decoration))
+}
+
+func (w *nativeWindow) SetSize(size Size) {
+	C.saucerw_window_set_size(w.ptr, C.int(size.W), C.int(size.H))
+}
+
+func (w *nativeWindow) SetMinSize(size Size) {
+	C.saucerw_window_set_min_size(w.ptr, C.int(size.W), C.int(size.H))
+}
+
+func (w *nativeWindow) SetMaxSize(size Size) {
+	C.saucerw_window_set_max_size(w.ptr, C.int(size.W), C.int(size.H))
+}
+
+func (w *nativeWindow) SetPosition(pos Position) {
+	C.saucerw_window_set_position(w.ptr, C.int(pos.X), C.int(pos.Y))
+}
+
+func (w *nativeWindow) SetKiosk(kiosk bool) {
+	C.saucerw_window_set_kiosk(w.ptr, C.bool(kiosk))
+}
+
+func (w *nativeWindow) InterceptClose(intercept bool) {
+	C.saucerw_window_intercept_close(w.ptr, C.bool(intercept))
+}
+
+func (w *nativeWindow) HandleEvents(fn func(WindowEvent)) {
+	h := cgo.NewHandle(fn)
+	w.handles = append(w.handles, h)
+
+	C.saucerw_window_on_events(w.ptr, C.uintptr_t(h))
+}
+
+func (w *nativeWindow) SetMenu(entries []MenuEntry) {
+	items, free := cMenuItems(entries)
+	defer free()
+
+	C.saucerw_window_set_menu(w.ptr, C.size_t(len(items)), unsafe.SliceData(items))
+}
+
+// cMenuItems converts entries, the strings are valid until free is called.
+func cMenuItems(entries []MenuEntry) (_ []C.saucerw_menu_item, free func()) {
+	items := make([]C.saucerw_menu_item, len(entries))
+	strs := make([]*C.char, 0, 2*len(entries))
+
+	free = func() {
+		for _, str := range strs {
+			C.free(unsafe.Pointer(str))
+		}
+	}
+
+	cstring := func(s string) *C.char {
+		str := C.CString(s)
+		strs = append(strs, str)
+		return str
+	}
+
+	for i, entry := range entries {
+		items[i] = C.saucerw_menu_item{
+			id:        C.int32_t(entry.ID),
+			parent:    C.int32_t(entry.Parent),
+			label:     cstring(entry.Label),
+			role:      C.int(entry.Role),
+			disabled:  C.bool(entry.Disabled),
+			checkable: C.bool(entry.Checkable),
+			checked:   C.bool(entry.Checked),
+			separator: C.bool(entry.Separator),
+		}
+
+		if entry.Accelerator != nil {
+			items[i].modifiers = C.int(entry.Accelerator.Modifiers)
+			items[i].key = cstring(entry.Accelerator.Key)
+			items[i].passthrough = C.bool(entry.Passthrough)
+		}
+	}
+
+	return items, free
+}
+
+func (w *nativeWindow) HandleMenu(fn func(id int32)) {
+	h := cgo.NewHandle(fn)
+	w.handles = append(w.handles, h)
+
+	C.saucerw_window_on_menu(w.ptr, C.uintptr_t(h))
+}
+
+func (w *nativeWindow) NewWebview(opts WebviewOptions) (WebviewDriver, error) {
+	prefs := &opts.Preferences
+
+	options := C.saucerw_webview_options{
+		attributes:                C.bool(!opts.DisableAttributes),
+		persistent_cookies:        C.bool(!prefs.DisablePersistentCookies),
+		non_persistent_data_store: C.bool(prefs.Ephemeral),
+		hardware_acceleration:     C.bool(!prefs.DisableHardwareAcceleration),
+		autoplay:                  C.saucerw_autoplay(prefs.Autoplay),
+		media_keys:                C.bool(!prefs.DisableMediaKeys),
+	}
+
+	if prefs.StoragePath != "" {
+		options.storage_path = C.CString(prefs.StoragePath)
+		defer C.free(unsafe.Pointer(options.storage_path))
+	}
+
+	if prefs.UserAgent != "" {
+		options.user_agent = C.CString(prefs.UserAgent)
+		defer C.free(unsafe.Pointer(options.user_agent))
+	}
+
+	if len(prefs.Locales) > 0 {
+		options.locales = C.CString(strings.Join(prefs.Locales, ","))
+		defer C.free(unsafe.Pointer(options.locales))
+	}
+
+	if len(prefs.SpellCheckLanguages) > 0 {
+		options.spellcheck = C.CString(strings.Join(prefs.SpellCheckLanguages, ","))
+		defer C.free(unsafe.Pointer(options.spellcheck))
+	}
+
+	flags := make([]*C.char, 0, len(prefs.BrowserFlags)+1)
+	for _, flag := range prefs.BrowserFlags {
+		flags = append(flags, C.CString(flag))
+	}
+
+	defer func() {
+		for _, flag := range flags {
+			C.free(unsafe.Pointer(flag))
+		}
+	}()
+
+	network := &opts.Network
+	network.blockInsecure = opts.Security.BlockMixedContent
+	if missing := network.features() &^ int(C.saucerw_network_features()); missing != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, networkFeatures(missing))
+	}
+
+	var handles []cgo.Handle
+	keep := func(fn any) C.uintptr_t {
+		h := cgo.NewHandle(fn)
+		handles = append(handles, h)
+		return C.uintptr_t(h)
+	}
+
+	var strs []*C.char
+	str := func(value string) *C.char {
+		rtn := C.CString(value)
+		strs = append(strs, rtn)
+		return rtn
+	}
+
+	defer func() {
+		for _, s := range strs {
+			C.free(unsafe.Pointer(s))
+		}
+	}()
+
+	if proxy := network.Proxy; proxy != nil {
+		// Validated by NewWebview
+		u, _ := url.Parse(proxy.URL)
+		port, _ := strconv.Atoi(u.Port())
+
+		// The options may not point to Go memory
+		bypass := (**C.char)(C.malloc(C.size_t(len(proxy.Bypass)+1) * C.size_t(unsafe.Sizeof((*C.char)(nil)))))
+		defer C.free(unsafe.Pointer(bypass))
+
+		for i, host := range proxy.Bypass {
+			unsafe.Slice(bypass, len(proxy.Bypass))[i] = str(host)
+		}
+
+		options.network.proxy = str(proxy.URL)
+		options.network.proxy_host = str(u.Hostname())
+		options.network.proxy_port = C.int(port)
+		options.network.proxy_socks = C.bool(strings.HasPrefix(u.Scheme, "socks"))
+		options.network.bypass = C.size_t(len(proxy.Bypass))
+		options.network.bypass_hosts = bypass
+
+		if proxy.Credentials != nil {
+			options.network.credentials = keep(network.credentials)
+		}
+	}
+
+	if network.features()&networkCertificates != 0 {
+		options.network.certificates = keep(network.acceptCertificate)
+	}
+
+	if network.filtered() {
+		options.network.requests = keep(network.allowURL)
+		options.network.content_rules = str(network.contentRules())
+	}
+
+	var msg *C.char
+	ptr := C.saucerw_webview_new(w.ptr, &options, C.size_t(len(prefs.BrowserFlags)), unsafe.SliceData(flags), &msg)
+	if ptr == nil {
+		for _, h := range handles {
+			h.Delete()
+		}
+		return nil, nativeError(msg)
+	}
+	return &nativeWebview{ptr: ptr, handles: handles}, nil
+}
+
+func (w *nativeWindow) Release() {
+	C.saucerw_window_free(w.ptr)
+
+	for _, h := range w.handles {
+		h.Delete()
+	}
+}
+
+type nativeWebview struct {
+	ptr     *C.saucerw_webview
+	handles []cgo.Handle
+}
+
+func (v *nativeWebview) URL() string {
+	return nativeString(C.saucerw_webview_url(v.ptr))
+}
+
+func (v *nativeWebview) PageTitle() string {
+	return nativeString(C.saucerw_webview_page_title(v.ptr))
+}
+
+func (v *nativeWebview) SetURL(url string) {
+	str := C.CString(url)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_webview_set_url(v.ptr, str)
+}
+
+func (v *nativeWebview) SetHTML(html string) {
+	str := C.CString(html)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_webview_set_html(v.ptr, str)
+}
+
+func (v *nativeWebview) Back()    { C.saucerw_webview_back(v.ptr) }
+func (v *nativeWebview) Forward() { C.saucerw_webview_forward(v.ptr) }
+func (v *nativeWebview) Reload()  { C.saucerw_webview_reload(v.ptr) }
+
+func (v *nativeWebview) Background() Color {
+	return goColor(C.saucerw_webview_background(v.ptr))
+}
+
+func (v *nativeWebview) SetBackground(color Color) {
+	C.saucerw_webview_set_background(v.ptr, nativeColor(color))
+}
+
+func (v *nativeWebview) Edit(role Role) { C.saucerw_webview_edit(v.ptr, C.int(role)) }
+
+func (v *nativeWebview) DevTools() bool { return bool(C.saucerw_webview_dev_tools(v.ptr)) }
+
+func (v *nativeWebview) SetDevTools(open bool) { C.saucerw_webview_set_dev_tools(v.ptr, C.bool(open)) }
+
+func (v *nativeWebview) Muted() bool         { return bool(C.saucerw_webview_muted(v.ptr)) }
+func (v *nativeWebview) SetMuted(muted bool) { C.saucerw_webview_set_muted(v.ptr, C.bool(muted)) }
+
+func (v *nativeWebview) Embed(files []EmbeddedFile) {
+	for _, file := range files {
+		path, mime := C.CString(file.Path), C.CString(file.Mime)
+
+		C.saucerw_webview_embed(v.ptr, path, mime, nativeBytes(file.Content), C.size_t(len(file.Content)))
+
+		C.free(unsafe.Pointer(path))
+		C.free(unsafe.Pointer(mime))
+	}
+}
+
+func (v *nativeWebview) Serve(path string) {
+	str := C.CString(path)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_webview_serve(v.ptr, str)
+}
+
+func (v *nativeWebview) Unembed() {
+	C.saucerw_webview_unembed(v.ptr)
+}
+
+func (v *nativeWebview) Execute(code string) {
+	str := C.CString(code)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_webview_execute(v.ptr, str)
+}
+
+func (v *nativeWebview) Inject(script Script) uint64 {
+	str := C.CString(script.Code)
+	defer C.free(unsafe.Pointer(str))
+
+	ready, frames := script.Time == AtReady, script.Frames == AllFrames
+	return uint64(C.saucerw_webview_inject(v.ptr, str, C.bool(ready), C.bool(frames), C.bool(script.Permanent)))
+}
+
+func (v *nativeWebview) Uninject(id uint64) {
+	C.saucerw_webview_uninject(v.ptr, C.size_t(id))
+}
+
+func (v *nativeWebview) UninjectAll() {
+	C.saucerw_webview_uninject_all(v.ptr)
+}
+
+func (v *nativeWebview) HandleMessage(fn func(string) bool) {
+	C.saucerw_webview_on_message(v.ptr, C.uintptr_t(v.handle(fn)))
+}
+
+func (v *nativeWebview) HandleNavigate(fn func(NavigationEvent) Policy) {
+	C.saucerw_webview_on_navigate(v.ptr, C.uintptr_t(v.handle(fn)))
+}
+
+func (v *nativeWebview) HandlePermission(fn func(string, Permission, func(bool)) PermissionDecision) {
+	C.saucerw_webview_on_permission(v.ptr, C.uintptr_t(v.handle(fn)))
+}
+
+func (v *nativeWebview) HandleDownload(fn func(DownloadRequest) DownloadDecision) {
+	C.saucerw_webview_on_download(v.ptr, C.uintptr_t(v.handle(fn)))
+}
+
+func (v *nativeWebview) HandleEvents(fn func(WebviewEvent)) {
+	C.saucerw_webview_on_events(v.ptr, C.uintptr_t(v.handle(fn)))
+}
+
+func (v *nativeWebview) HandleFileDrop(fn func(FileDrop)) {
+	C.saucerw_webview_on_drop(v.ptr, C.uintptr_t(v.handle(fn)))
+}
+
+func (v *nativeWebview) HandleContextMenu(fn func(ContextInfo, bool) ([]MenuEntry, bool), click func(id int32)) {
+	C.saucerw_webview_on_context_menu(v.ptr, C.uintptr_t(v.handle(fn)), C.uintptr_t(v.handle(click)))
+}
+
+func (v *nativeWebview) Cookies(done func([]*http.Cookie, error)) {
+	C.saucerw_webview_cookies(v.ptr, C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (v *nativeWebview) SetCookie(cookie *http.Cookie, done func(error)) {
+	c, free := cCookie(cookie)
+	defer free()
+
+	C.saucerw_webview_set_cookie(v.ptr, &c, C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (v *nativeWebview) DeleteCookie(cookie *http.Cookie, done func(error)) {
+	c, free := cCookie(cookie)
+	defer free()
+
+	C.saucerw_webview_delete_cookie(v.ptr, &c, C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (v *nativeWebview) ClearData(kinds BrowsingData, since time.Time, done func(error)) {
+	clearable := BrowsingData(C.saucerw_clearable_data())
+
+	if rest := kinds &^ clearable; rest != 0 {
+		inner := done
+		done = func(err error) {
+			if err == nil {
+				err = fmt.Errorf("%w: clearing %v", ErrUnsupported, rest)
+			}
+			inner(err)
+		}
+	}
+
+	var seconds float64
+	if !since.IsZero() {
+		seconds = float64(since.UnixNano()) / 1e9
+	}
+
+	C.saucerw_webview_clear_data(v.ptr, C.int(kinds&clearable), C.double(seconds), C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (v *nativeWebview) Zoom() float64 {
+	return float64(C.saucerw_webview_zoom(v.ptr))
+}
+
+func (v *nativeWebview) SetZoom(factor float64) {
+	C.saucerw_webview_set_zoom(v.ptr, C.double(factor))
+}
+
+func (v *nativeWebview) Print(opts PrintOptions, done func(error)) {
+	if opts.Path == "" && !bool(C.saucerw_print_dialog()) {
+		done(fmt.Errorf("%w: print dialog", ErrUnsupported))
+		return
+	}
+
+	v.print(opts.Path, C.saucerw_print_options{
+		landscape:  C.bool(opts.Landscape),
+		background: C.bool(opts.Background),
+	}, done)
+}
+
+func (v *nativeWebview) SavePDF(path string, opts pdf.Options, done func(error)) {
+	size := opts.Size
+	if size == (pdf.Size{}) {
+		size = pdf.A4
+	}
+
+	v.print(path, C.saucerw_print_options{
+		landscape:  C.bool(opts.Orientation == pdf.Landscape),
+		background: C.bool(opts.Background),
+		width:      C.double(size.Width),
+		height:     C.double(size.Height),
+		top:        C.double(opts.Margins.Top),
+		right:      C.double(opts.Margins.Right),
+		bottom:     C.double(opts.Margins.Bottom),
+		left:       C.double(opts.Margins.Left),
+	}, done)
+}
+
+// print prints to the PDF at path with the options c, showing the dialog if
+// path is empty.
+func (v *nativeWebview) print(path string, c C.saucerw_print_options, done func(error)) {
+	if path != "" {
+		c.path = C.CString(path)
+		defer C.free(unsafe.Pointer(c.path))
+	}
+
+	C.saucerw_webview_print(v.ptr, &c, C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (v *nativeWebview) Capture(done func([]byte, error)) {
+	C.saucerw_webview_capture(v.ptr, C.uintptr_t(cgo.NewHandle(done)))
+}
+
+func (v *nativeWebview) SetDarkMode(mode DarkMode) bool {
+	return bool(C.saucerw_webview_set_dark_mode(v.ptr, C.int(mode)))
+}
+
+func (v *nativeWebview) SendKey(key KeyInput) bool {
+	name, k, code, text := C.CString(key.Accelerator.Key), C.CString(key.Key), C.CString(key.Code), C.CString(key.Text)
+	defer func() {
+		for _, str := range []*C.char{name, k, code, text} {
+			C.free(unsafe.Pointer(str))
+		}
+	}()
+
+	return bool(C.saucerw_webview_send_key(v.ptr, &C.saucerw_key_input{
+		modifiers: C.int(key.Accelerator.Modifiers),
+		up:        C.bool(key.Up),
+		name:      name,
+		key:       k,
+		code:      code,
+		text:      text,
+	}))
+}
+
+func (v *nativeWebview) SendText(text string) bool {
+	str := C.CString(text)
+	defer C.free(unsafe.Pointer(str))
+
+	return bool(C.saucerw_webview_send_text(v.ptr, str))
+}
+
+func (v *nativeWebview) SendMouse(mouse MouseInput) bool {
+	return bool(C.saucerw_webview_send_mouse(v.ptr, &C.saucerw_mouse_input{
+		action:    C.int(mouse.Action),
+		button:    C.int(mouse.Button),
+		clicks:    C.int(mouse.Clicks),
+		modifiers: C.int(mouse.Modifiers),
+		x:         C.double(mouse.Position.X),
+		y:         C.double(mouse.Position.Y),
+		dx:        C.double(mouse.DeltaX),
+		dy:        C.double(mouse.DeltaY),
+	}))
+}
+
+func (v *nativeWebview) NewSharedBuffer(size int) (SharedMemory, error) {
+	var data *C.uint8_t
+
+	ptr := C.saucerw_webview_shared_buffer(v.ptr, C.uint64_t(size), &data)
+	if ptr == nil {
+		return nil, fmt.Errorf("%w: shared memory", ErrUnsupported)
+	}
+
+	return &nativeSharedBuffer{
+		webview: v.ptr,
+		ptr:     ptr,
+		data:    unsafe.Slice((*byte)(unsafe.Pointer(data)), size),
+	}, nil
+}
+
+// nativeSharedBuffer is the SharedMemory of the backend.
+type nativeSharedBuffer struct {
+	webview *C.saucerw_webview
+	ptr     *C.saucerw_shared_buffer
+	data    []byte
+}
+
+func (b *nativeSharedBuffer) Bytes() []byte {
+	return b.data
+}
+
+func (b *nativeSharedBuffer) Post(readOnly bool, detail string) {
+	str := C.CString(detail)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_shared_buffer_post(b.webview, b.ptr, C.bool(readOnly), str)
+}
+
+func (b *nativeSharedBuffer) Close() {
+	C.saucerw_shared_buffer_free(b.ptr)
+}
+
+// cCookie converts cookie, its strings stay valid until free is called.
+func cCookie(cookie *http.Cookie) (c C.saucerw_cookie, free func()) {
+	c = C.saucerw_cookie{
+		name:      C.CString(cookie.Name),
+		value:     C.CString(cookie.Value),
+		domain:    C.CString(cookie.Domain),
+		path:      C.CString(cookie.Path),
+		secure:    C.bool(cookie.Secure),
+		http_only: C.bool(cookie.HttpOnly),
+		same_site: C.int(cookie.SameSite),
+	}
+
+	if !cookie.Expires.IsZero() {
+		c.expires = C.int64_t(cookie.Expires.Unix())
+	}
+
+	return c, func() {
+		for _, str := range []*C.char{c.name, c.value, c.domain, c.path} {
+			C.free(unsafe.Pointer(str))
+		}
+	}
+}
+
+func (v *nativeWebview) HandleScheme(name string, handler func(SchemeRequest, func(SchemeResponse))) {
+	str := C.CString(name)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_webview_handle_scheme(v.ptr, str, C.uintptr_t(v.handle(handler)))
+}
+
+func (v *nativeWebview) RemoveScheme(name string) {
+	str := C.CString(name)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_webview_remove_scheme(v.ptr, str)
+}
+
+func (v *nativeWebview) HandleStreamScheme(name string, handler func(SchemeRequest, SchemeStream)) {
+	str := C.CString(name)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_webview_handle_stream_scheme(v.ptr, str, C.uintptr_t(v.handle(handler)))
+}
+
+func (v *nativeWebview) RemoveStreamScheme(name string) {
+	str := C.CString(name)
+	defer C.free(unsafe.Pointer(str))
+
+	C.saucerw_webview_remove_stream_scheme(v.ptr, str)
+}
+
+// handle creates a cgo handle released together with the webview.
+func (v *nativeWebview) handle(value any) cgo.Handle {
+	h := cgo.NewHandle(value)
+	v.handles = append(v.handles, h)
+	return h
+}
+
+func (v *nativeWebview) Release() {
+	C.saucerw_webview_free(v.ptr)
+
+	for _, h := range v.handles {
+		h.Delete()
+	}
+}
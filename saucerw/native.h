@@ -0,0 +1,701 @@
+#pragma once
+
+#include <stddef.h>
+#include <stdint.h>
+#include <stdbool.h>
+
+#ifdef __cplusplus
+extern "C"
+{
+#endif
+
+    typedef struct saucerw_app saucerw_app;
+    typedef struct saucerw_window saucerw_window;
+    typedef struct saucerw_webview saucerw_webview;
+
+    typedef struct
+    {
+        char *name;
+        int w, h;
+        int x, y;
+        // Device pixels per logical pixel
+        double scale;
+    } saucerw_screen;
+
+    typedef struct
+    {
+        uint8_t r, g, b, a;
+    } saucerw_color;
+
+    // Non-premultiplied RGBA pixels, rows are 4 * w bytes
+    typedef struct
+    {
+        int w, h;
+        uint8_t *pixels;
+    } saucerw_image;
+
+    // Matches the network features, see network.go
+
+    typedef enum
+    {
+        SAUCERW_NETWORK_PROXY         = 1 << 0,
+        SAUCERW_NETWORK_CREDENTIALS   = 1 << 1,
+        SAUCERW_NETWORK_CERTIFICATES  = 1 << 2,
+        SAUCERW_NETWORK_ALLOWED_HOSTS = 1 << 3,
+    } saucerw_network_feature;
+
+    typedef struct
+    {
+        // The proxy server, NULL for none. The URL is split for the backends configuring the parts separately.
+        const char *proxy;
+        const char *proxy_host;
+        int proxy_port;
+        bool proxy_socks;
+        size_t bypass;
+        const char **bypass_hosts;
+        // Handles of the callbacks in Go, zero if unused
+        uintptr_t credentials;
+        uintptr_t certificates;
+        uintptr_t requests;
+        // WebKit content blocker rules enforcing the allowed hosts, NULL if all are allowed
+        const char *content_rules;
+    } saucerw_network_options;
+
+    // Matches AutoplayPolicy, see media.go
+
+    typedef enum
+    {
+        SAUCERW_AUTOPLAY_DEFAULT,
+        SAUCERW_AUTOPLAY_ALLOW,
+        SAUCERW_AUTOPLAY_MUTED,
+        SAUCERW_AUTOPLAY_BLOCK,
+    } saucerw_autoplay;
+
+    typedef struct
+    {
+        bool attributes;
+        bool persistent_cookies;
+        bool non_persistent_data_store;
+        bool hardware_acceleration;
+        const char *storage_path;
+        const char *user_agent;
+        // Comma separated BCP 47 tags, NULL for the defaults of the backend
+        const char *locales;
+        const char *spellcheck;
+        saucerw_autoplay autoplay;
+        bool media_keys;
+        saucerw_network_options network;
+    } saucerw_webview_options;
+
+    // Matches PowerEvent, see power.go
+
+    typedef enum
+    {
+        SAUCERW_POWER_SUSPEND,
+        SAUCERW_POWER_RESUME,
+        SAUCERW_POWER_LOCK,
+        SAUCERW_POWER_UNLOCK,
+        SAUCERW_POWER_SHUTDOWN,
+    } saucerw_power_event;
+
+    // Matches ColorScheme and DarkMode, see theme.go
+
+    typedef enum
+    {
+        SAUCERW_SCHEME_LIGHT,
+        SAUCERW_SCHEME_DARK,
+    } saucerw_color_scheme;
+
+    typedef enum
+    {
+        SAUCERW_DARK_MODE_SYSTEM,
+        SAUCERW_DARK_MODE_OFF,
+        SAUCERW_DARK_MODE_ON,
+    } saucerw_dark_mode;
+
+    // The flags of Accessibility, see accessibility.go
+
+    typedef enum
+    {
+        SAUCERW_ACCESSIBILITY_SCREEN_READER  = 1 << 0,
+        SAUCERW_ACCESSIBILITY_REDUCED_MOTION = 1 << 1,
+        SAUCERW_ACCESSIBILITY_HIGH_CONTRAST  = 1 << 2,
+    } saucerw_accessibility;
+
+    typedef struct saucerw_executor saucerw_executor;
+    typedef struct saucerw_permission saucerw_permission;
+    typedef struct saucerw_stream saucerw_stream;
+    typedef struct saucerw_shared_buffer saucerw_shared_buffer;
+
+    typedef struct
+    {
+        const char *url;
+        const char *method;
+        size_t headers;
+        const char **header_keys;
+        const char **header_values;
+        const uint8_t *body;
+        size_t body_size;
+    } saucerw_scheme_request;
+
+    typedef enum
+    {
+        SAUCERW_WINDOW_DECORATED,
+        SAUCERW_WINDOW_MAXIMIZE,
+        SAUCERW_WINDOW_MINIMIZE,
+        SAUCERW_WINDOW_CLOSED,
+        SAUCERW_WINDOW_RESIZE,
+        SAUCERW_WINDOW_FOCUS,
+        SAUCERW_WINDOW_CLOSE_REQUESTED,
+        // The value is the new scale in thousandths
+        SAUCERW_WINDOW_SCALE,
+    } saucerw_window_event;
+
+    typedef enum
+    {
+        SAUCERW_WEBVIEW_DOM_READY,
+        SAUCERW_WEBVIEW_LOAD,
+        SAUCERW_WEBVIEW_PLAYING_AUDIO,
+        SAUCERW_WEBVIEW_PROCESS_GONE,
+    } saucerw_webview_event;
+
+    // Matches GoneReason, see recovery.go
+
+    typedef enum
+    {
+        SAUCERW_GONE_CRASHED,
+        SAUCERW_GONE_OUT_OF_MEMORY,
+        SAUCERW_GONE_KILLED,
+        SAUCERW_GONE_UNRESPONSIVE,
+    } saucerw_gone_reason;
+
+
+    // Matches PermissionDecision, see permission.go
+
+    typedef enum
+    {
+        SAUCERW_PERMISSION_DEFAULT,
+        SAUCERW_PERMISSION_GRANT,
+        SAUCERW_PERMISSION_DENY,
+        SAUCERW_PERMISSION_PENDING,
+    } saucerw_permission_decision;
+
+    typedef struct
+    {
+        const char *url;
+        const char *suggested_name;
+        const char *mime;
+        int64_t size;
+    } saucerw_download_request;
+
+    typedef enum
+    {
+        SAUCERW_DOWNLOAD_PROGRESS,
+        SAUCERW_DOWNLOAD_FINISHED,
+        SAUCERW_DOWNLOAD_CANCELLED,
+        SAUCERW_DOWNLOAD_FAILED,
+    } saucerw_download_event;
+
+    // Matches BrowsingData, see cookies.go, and net/http.SameSite
+
+    typedef enum
+    {
+        SAUCERW_DATA_CACHE           = 1 << 0,
+        SAUCERW_DATA_COOKIES         = 1 << 1,
+        SAUCERW_DATA_LOCAL_STORAGE   = 1 << 2,
+        SAUCERW_DATA_INDEXED_DB      = 1 << 3,
+        SAUCERW_DATA_SERVICE_WORKERS = 1 << 4,
+    } saucerw_browsing_data;
+
+    typedef enum
+    {
+        SAUCERW_SAME_SITE_UNSET,
+        SAUCERW_SAME_SITE_DEFAULT,
+        SAUCERW_SAME_SITE_LAX,
+        SAUCERW_SAME_SITE_STRICT,
+        SAUCERW_SAME_SITE_NONE,
+    } saucerw_same_site;
+
+    typedef struct
+    {
+        const char *name;
+        const char *value;
+        const char *domain;
+        const char *path;
+        // Unix time in seconds, zero for session cookies
+        int64_t expires;
+        bool secure;
+        bool http_only;
+        int same_site;
+    } saucerw_cookie;
+
+    typedef struct
+    {
+        // Absolute path of the PDF to write, NULL shows the print dialog
+        const char *path;
+        bool landscape;
+        bool background;
+        // The portrait page size and the margins in inches, a zero width keeps the defaults of the backend
+        double width;
+        double height;
+        double top;
+        double right;
+        double bottom;
+        double left;
+    } saucerw_print_options;
+
+    typedef struct
+    {
+        // SAUCERW_MOD_* flags
+        int modifiers;
+        bool up;
+        // The key as named by saucerw.Accelerator, the key and code of the KeyboardEvent and the text the key types
+        const char *name;
+        const char *key;
+        const char *code;
+        const char *text;
+    } saucerw_key_input;
+
+    // The values of saucerw.MouseAction, MouseClick is sent as its moves and presses
+    typedef enum
+    {
+        SAUCERW_MOUSE_MOVE  = 0,
+        SAUCERW_MOUSE_DOWN  = 2,
+        SAUCERW_MOUSE_UP    = 3,
+        SAUCERW_MOUSE_WHEEL = 4,
+    } saucerw_mouse_action;
+
+    typedef enum
+    {
+        SAUCERW_MOUSE_LEFT,
+        SAUCERW_MOUSE_MIDDLE,
+        SAUCERW_MOUSE_RIGHT,
+    } saucerw_mouse_button;
+
+    typedef struct
+    {
+        int action;
+        int button;
+        int clicks;
+        int modifiers;
+        // The position in CSS pixels and the pixels to scroll, positive values scroll right and down
+        double x;
+        double y;
+        double dx;
+        double dy;
+    } saucerw_mouse_input;
+
+    typedef enum
+    {
+        SAUCERW_LOG_DEBUG,
+        SAUCERW_LOG_INFO,
+        SAUCERW_LOG_WARN,
+        SAUCERW_LOG_ERROR,
+    } saucerw_log_level;
+
+    // Matches Role and Modifier, see menu.go
+
+    typedef enum
+    {
+        SAUCERW_ROLE_NONE,
+        SAUCERW_ROLE_QUIT,
+        SAUCERW_ROLE_CLOSE,
+        SAUCERW_ROLE_MINIMIZE,
+        SAUCERW_ROLE_FULLSCREEN,
+        SAUCERW_ROLE_RELOAD,
+        SAUCERW_ROLE_DEV_TOOLS,
+        SAUCERW_ROLE_UNDO,
+        SAUCERW_ROLE_REDO,
+        SAUCERW_ROLE_CUT,
+        SAUCERW_ROLE_COPY,
+        SAUCERW_ROLE_PASTE,
+        SAUCERW_ROLE_SELECT_ALL,
+        SAUCERW_ROLE_ABOUT,
+        SAUCERW_ROLE_HIDE,
+        SAUCERW_ROLE_HIDE_OTHERS,
+        SAUCERW_ROLE_SHOW_ALL,
+    } saucerw_menu_role;
+
+    typedef enum
+    {
+        SAUCERW_MOD_CTRL  = 1 << 0,
+        SAUCERW_MOD_SHIFT = 1 << 1,
+        SAUCERW_MOD_ALT   = 1 << 2,
+        SAUCERW_MOD_SUPER = 1 << 3,
+    } saucerw_modifier;
+
+    typedef struct
+    {
+        int32_t id;
+        int32_t parent;
+        const char *label;
+        int role;
+        int modifiers;
+        const char *key;
+        bool disabled;
+        bool checkable;
+        bool checked;
+        bool separator;
+        bool passthrough;
+    } saucerw_menu_item;
+
+    // What a context menu was opened on. Backends that cannot tell the whole target set partial, the page reports
+    // the rest.
+    typedef struct
+    {
+        int x;
+        int y;
+        const char *link;
+        const char *image;
+        const char *media;
+        const char *selection;
+        bool editable;
+        bool partial;
+    } saucerw_context;
+
+    // Receives the entries of a custom context menu
+    typedef struct saucerw_context_menu saucerw_context_menu;
+
+    // Implemented in Go, see native.go
+
+    extern void saucerwInvoke(uintptr_t handle);
+    extern void saucerwInvokeOnce(uintptr_t handle);
+    extern bool saucerwMessage(uintptr_t handle, char *message, size_t size);
+    extern void saucerwScheme(uintptr_t handle, saucerw_scheme_request *request, saucerw_executor *executor);
+    extern void saucerwStreamScheme(uintptr_t handle, saucerw_scheme_request *request, saucerw_stream *stream);
+    extern void saucerwWindowEvent(uintptr_t handle, saucerw_window_event event, int value, int w, int h);
+    extern void saucerwWebviewEvent(uintptr_t handle, saucerw_webview_event event, int value);
+    extern void saucerwLog(saucerw_log_level level, char *component, char *message, size_t size);
+    extern void saucerwFatal(char *component, char *message, size_t size);
+    extern bool saucerwNavigate(uintptr_t handle, char *url, bool new_window, bool redirection, bool user_initiated);
+    extern int saucerwPermission(uintptr_t handle, char *url, int types, saucerw_permission *request);
+    extern uintptr_t saucerwDownload(uintptr_t handle, saucerw_download_request *request, bool *cancel, char **path);
+    extern void saucerwDownloadEvent(uintptr_t handle, saucerw_download_event event, int64_t received, int64_t total,
+                                     char *path, char *reason);
+    extern void saucerwMenu(uintptr_t handle, int32_t id);
+    extern void saucerwClipboardText(uintptr_t handle, char *text, size_t size);
+    extern void saucerwClipboardImage(uintptr_t handle, saucerw_image *image);
+    extern void saucerwCookies(uintptr_t handle, size_t count, saucerw_cookie *cookies, char *error);
+    extern void saucerwDone(uintptr_t handle, char *error);
+    extern void saucerwCapture(uintptr_t handle, uint8_t *png, size_t size, char *error);
+    extern void saucerwColorScheme(uintptr_t handle, int scheme);
+    extern void saucerwLocales(uintptr_t handle, char *locales);
+    extern void saucerwAccessibility(uintptr_t handle, int flags);
+    extern void saucerwPower(uintptr_t handle, saucerw_power_event event);
+    extern void saucerwDrop(uintptr_t handle, char **paths, size_t count, int x, int y);
+    extern bool saucerwContextMenu(uintptr_t handle, saucerw_context *context, saucerw_context_menu *menu);
+    extern bool saucerwCredentials(uintptr_t handle, char *host, char **user, char **password);
+    extern bool saucerwCertificate(uintptr_t handle, char *url, char *pem, size_t size);
+    extern bool saucerwRequest(uintptr_t handle, char *url);
+
+    // Implemented in menu_darwin.m, the menu bar of the focused window is the one of the application
+
+    void saucerw_cocoa_set_menu(const void *window, uintptr_t handle, size_t count, const saucerw_menu_item *items,
+                                bool focused);
+    void saucerw_cocoa_focus_menu(const void *window);
+    void saucerw_cocoa_free_menu(const void *window);
+    void saucerw_cocoa_edit(int role);
+
+    // Implemented in clipboard_darwin.m, called on the main thread
+
+    void saucerw_cocoa_read_text(uintptr_t handle);
+    void saucerw_cocoa_read_image(uintptr_t handle);
+    void saucerw_cocoa_write_text(const char *text, size_t size);
+    void saucerw_cocoa_write_image(saucerw_image image);
+    void *saucerw_cocoa_watch_clipboard(uintptr_t handler);
+    void saucerw_cocoa_unwatch_clipboard(void *watcher);
+
+    // Implemented in cookies_darwin.m, called on the main thread with the saucer::webview::impl of the webview
+
+    void saucerw_cocoa_cookies(const void *webview, uintptr_t handle);
+    void saucerw_cocoa_set_cookie(const void *webview, const saucerw_cookie *cookie, uintptr_t handle);
+    void saucerw_cocoa_delete_cookie(const void *webview, const saucerw_cookie *cookie, uintptr_t handle);
+    void saucerw_cocoa_clear_data(const void *webview, int kinds, double since, uintptr_t handle);
+    void saucerw_cocoa_remove_data_store(const char *storage_path, uintptr_t handle);
+
+    // Implemented in network_darwin.m, called on the main thread
+
+    int saucerw_cocoa_network_features(void);
+    void saucerw_cocoa_apply_network(const void *webview, const saucerw_network_options *options, const char *user,
+                                     const char *password);
+
+    // Implemented in window_darwin.m, called on the main thread. window is the saucer::window::impl of the window
+
+    void saucerw_cocoa_set_kiosk(const void *window, bool kiosk);
+    void saucerw_cocoa_set_titlebar_color(const void *window, saucerw_color color);
+    void saucerw_cocoa_screen_scales(double *scales, size_t count);
+    double saucerw_cocoa_window_scale(const void *window);
+    void saucerw_cocoa_outer_size(const void *window, int *w, int *h);
+    void saucerw_cocoa_safe_area(const void *window, int *top, int *right, int *bottom, int *left);
+    // Reports the changes of the scale of window as SAUCERW_WINDOW_SCALE
+    void *saucerw_cocoa_watch_scale(const void *window, uintptr_t handler);
+    void saucerw_cocoa_unwatch_scale(void *watcher);
+
+    // Implemented in page_darwin.m, called on the main thread
+
+    double saucerw_cocoa_zoom(const void *webview);
+    void saucerw_cocoa_set_zoom(const void *webview, double factor);
+    void saucerw_cocoa_print(const void *webview, const saucerw_print_options *options, uintptr_t handle);
+    void saucerw_cocoa_capture(const void *webview, uintptr_t handle);
+
+    // Implemented in input_darwin.m, called on the main thread
+
+    bool saucerw_cocoa_send_key(const void *webview, const saucerw_key_input *input);
+    bool saucerw_cocoa_send_text(const void *webview, const char *text);
+    bool saucerw_cocoa_send_mouse(const void *webview, const saucerw_mouse_input *input);
+
+    // Implemented in theme_darwin.m, called on the main thread
+
+    int saucerw_cocoa_color_scheme(void);
+    void *saucerw_cocoa_watch_color_scheme(uintptr_t handler);
+    void saucerw_cocoa_unwatch_color_scheme(void *watcher);
+    void saucerw_cocoa_set_dark_mode(const void *webview, int mode);
+
+    // Implemented in accessibility_darwin.m, called on the main thread. The flags are saucerw_accessibility values
+
+    int saucerw_cocoa_accessibility(void);
+    void *saucerw_cocoa_watch_accessibility(uintptr_t handler);
+    void saucerw_cocoa_unwatch_accessibility(void *watcher);
+
+    // Implemented in locale_darwin.m, called on the main thread. Locales are comma separated BCP 47 tags
+
+    char *saucerw_cocoa_locales(void);
+    void *saucerw_cocoa_watch_locales(uintptr_t handler);
+    void saucerw_cocoa_unwatch_locales(void *watcher);
+
+    // Implemented in power_darwin.m, called on the main thread
+
+    void *saucerw_cocoa_watch_power(uintptr_t handler);
+    void saucerw_cocoa_unwatch_power(void *watcher);
+
+    // Implemented in drop_darwin.m, called on the main thread
+
+    void saucerw_cocoa_on_drop(const void *webview, uintptr_t handler);
+
+    // Implemented in contextmenu_darwin.m, called on the main thread
+
+    void saucerw_cocoa_on_context_menu(const void *webview, uintptr_t handler, uintptr_t click);
+
+    // Strings and arrays returned from these functions are allocated with malloc
+
+    void saucerw_register_scheme(const char *name);
+    void saucerw_capture_logs(void);
+    void saucerw_capture_fatal(void);
+
+    saucerw_app *saucerw_app_new(const char *id, int argc, char **argv, bool quit_on_last_window_closed, char **error);
+    void saucerw_app_free(saucerw_app *);
+
+    int saucerw_app_run(saucerw_app *, uintptr_t start);
+    void saucerw_app_quit(saucerw_app *);
+    void saucerw_app_post(saucerw_app *, uintptr_t callback);
+    bool saucerw_app_thread_safe(saucerw_app *);
+
+    size_t saucerw_app_screens(saucerw_app *, saucerw_screen **screens);
+
+    // The clipboard reads call saucerwClipboardText and saucerwClipboardImage with NULL when it holds no such data
+
+    void saucerw_app_read_text(saucerw_app *, uintptr_t handle);
+    void saucerw_app_read_image(saucerw_app *, uintptr_t handle);
+    void saucerw_app_write_text(saucerw_app *, const char *text, size_t size);
+    void saucerw_app_write_image(saucerw_app *, saucerw_image image);
+    void saucerw_app_on_clipboard(saucerw_app *, uintptr_t handler);
+
+    // saucerw_app_on_color_scheme calls saucerwColorScheme with the new scheme whenever it changed
+
+    int saucerw_app_color_scheme(saucerw_app *);
+    void saucerw_app_on_color_scheme(saucerw_app *, uintptr_t handler);
+
+    // The saucerw_accessibility flags of the system, saucerw_app_on_accessibility calls saucerwAccessibility with the
+    // new ones whenever they changed
+
+    int saucerw_app_accessibility(saucerw_app *);
+    void saucerw_app_on_accessibility(saucerw_app *, uintptr_t handler);
+
+    // The preferred languages of the user as comma separated BCP 47 tags, saucerw_app_on_locales calls saucerwLocales
+    // with the new ones whenever they changed
+    char *saucerw_app_locales(saucerw_app *);
+    void saucerw_app_on_locales(saucerw_app *, uintptr_t handler);
+
+    // saucerw_app_on_power calls saucerwPower with the power and session events of the system. A shutdown is delayed,
+    // where the system lets applications delay it, until saucerw_app_release_shutdown is called
+    void saucerw_app_on_power(saucerw_app *, uintptr_t handler);
+    void saucerw_app_release_shutdown(saucerw_app *);
+
+    // saucerw_app_remove_website_data calls saucerwDone once the data the backend keeps outside of storage_path for its
+    // webviews is removed
+    void saucerw_app_remove_website_data(saucerw_app *, const char *storage_path, uintptr_t handle);
+
+    saucerw_window *saucerw_window_new(saucerw_app *, char **error);
+    void saucerw_window_free(saucerw_window *);
+
+    bool saucerw_window_visible(saucerw_window *);
+    bool saucerw_window_focused(saucerw_window *);
+    bool saucerw_window_minimized(saucerw_window *);
+    bool saucerw_window_maximized(saucerw_window *);
+    bool saucerw_window_resizable(saucerw_window *);
+    bool saucerw_window_fullscreen(saucerw_window *);
+    bool saucerw_window_always_on_top(saucerw_window *);
+    bool saucerw_window_click_through(saucerw_window *);
+
+    char *saucerw_window_title(saucerw_window *);
+    saucerw_color saucerw_window_background(saucerw_window *);
+    int saucerw_window_decorations(saucerw_window *);
+    void saucerw_window_size(saucerw_window *, int *w, int *h);
+    void saucerw_window_min_size(saucerw_window *, int *w, int *h);
+    void saucerw_window_max_size(saucerw_window *, int *w, int *h);
+    void saucerw_window_position(saucerw_window *, int *x, int *y);
+    double saucerw_window_scale(saucerw_window *);
+    void saucerw_window_outer_size(saucerw_window *, int *w, int *h);
+    void saucerw_window_safe_area(saucerw_window *, int *top, int *right, int *bottom, int *left);
+
+    void saucerw_window_show(saucerw_window *);
+    void saucerw_window_hide(saucerw_window *);
+    void saucerw_window_close(saucerw_window *);
+    void saucerw_window_focus(saucerw_window *);
+
+    // Only effective while the mouse button that started the move or resize is held
+    void saucerw_window_start_drag(saucerw_window *);
+    void saucerw_window_start_resize(saucerw_window *, int edges);
+
+    void saucerw_window_set_minimized(saucerw_window *, bool);
+    void saucerw_window_set_maximized(saucerw_window *, bool);
+    void saucerw_window_set_resizable(saucerw_window *, bool);
+    void saucerw_window_set_fullscreen(saucerw_window *, bool);
+    void saucerw_window_set_always_on_top(saucerw_window *, bool);
+    void saucerw_window_set_click_through(saucerw_window *, bool);
+
+    void saucerw_window_set_title(saucerw_window *, const char *);
+    bool saucerw_window_set_icon(saucerw_window *, const uint8_t *data, size_t size, char **error);
+    void saucerw_window_set_background(saucerw_window *, saucerw_color);
+    void saucerw_window_set_decorations(saucerw_window *, int);
+    void saucerw_window_set_size(saucerw_window *, int w, int h);
+    void saucerw_window_set_min_size(saucerw_window *, int w, int h);
+    void saucerw_window_set_max_size(saucerw_window *, int w, int h);
+    void saucerw_window_set_position(saucerw_window *, int x, int y);
+
+    // Kiosk mode blocks closing the window by the user, except through saucerw_window_close, and swallows the
+    // shortcuts leaving it
+    void saucerw_window_set_kiosk(saucerw_window *, bool);
+    // Returns false if the backend cannot color the title bar, a color with zero alpha restores the default
+    bool saucerw_window_set_titlebar_color(saucerw_window *, saucerw_color);
+
+    // Reports closing the window by the user as SAUCERW_WINDOW_CLOSE_REQUESTED instead of closing it, except through
+    // saucerw_window_close
+    void saucerw_window_intercept_close(saucerw_window *, bool);
+
+    void saucerw_window_on_events(saucerw_window *, uintptr_t handler);
+
+    void saucerw_window_set_menu(saucerw_window *, size_t count, const saucerw_menu_item *items);
+    void saucerw_window_on_menu(saucerw_window *, uintptr_t handler);
+
+    // Returns the saucerw_network_feature set the backend can apply
+    int saucerw_network_features(void);
+
+    saucerw_webview *saucerw_webview_new(saucerw_window *, const saucerw_webview_options *options, size_t flags,
+                                         const char **flag_values, char **error);
+    void saucerw_webview_free(saucerw_webview *);
+
+    char *saucerw_webview_url(saucerw_webview *);
+    char *saucerw_webview_page_title(saucerw_webview *);
+
+    void saucerw_webview_set_url(saucerw_webview *, const char *);
+    void saucerw_webview_set_html(saucerw_webview *, const char *);
+
+    void saucerw_webview_back(saucerw_webview *);
+    void saucerw_webview_forward(saucerw_webview *);
+    void saucerw_webview_reload(saucerw_webview *);
+
+    saucerw_color saucerw_webview_background(saucerw_webview *);
+    void saucerw_webview_set_background(saucerw_webview *, saucerw_color);
+
+    bool saucerw_webview_dev_tools(saucerw_webview *);
+    void saucerw_webview_set_dev_tools(saucerw_webview *, bool);
+
+    void saucerw_webview_edit(saucerw_webview *, int role);
+
+    void saucerw_webview_embed(saucerw_webview *, const char *path, const char *mime, const uint8_t *data, size_t size);
+    void saucerw_webview_serve(saucerw_webview *, const char *path);
+    void saucerw_webview_unembed(saucerw_webview *);
+
+    void saucerw_webview_execute(saucerw_webview *, const char *code);
+    size_t saucerw_webview_inject(saucerw_webview *, const char *code, bool ready, bool all_frames, bool permanent);
+    void saucerw_webview_uninject(saucerw_webview *, size_t id);
+    void saucerw_webview_uninject_all(saucerw_webview *);
+
+    void saucerw_webview_on_message(saucerw_webview *, uintptr_t handler);
+    void saucerw_webview_on_navigate(saucerw_webview *, uintptr_t handler);
+    void saucerw_webview_on_permission(saucerw_webview *, uintptr_t handler);
+    void saucerw_webview_on_download(saucerw_webview *, uintptr_t handler);
+
+    // Answers and frees a permission request kept pending
+    void saucerw_permission_accept(saucerw_permission *, bool granted);
+    void saucerw_webview_on_events(saucerw_webview *, uintptr_t handler);
+
+    // The paths passed to saucerwDrop are only valid during the call
+    void saucerw_webview_on_drop(saucerw_webview *, uintptr_t handler);
+
+    // The handler decides on every context menu, the ids of the clicked custom entries are passed to click like
+    // those of the window menu. The entries set are copied.
+    void saucerw_webview_on_context_menu(saucerw_webview *, uintptr_t handler, uintptr_t click);
+    void saucerw_context_menu_set(saucerw_context_menu *, size_t count, const saucerw_menu_item *items);
+
+    // The cookie and data functions report their result to the handle once, from any thread. since is in Unix
+    // seconds, zero clears all data regardless of its age. saucerw_clearable_data returns the kinds the backend
+    // can clear.
+
+    void saucerw_webview_cookies(saucerw_webview *, uintptr_t handle);
+    void saucerw_webview_set_cookie(saucerw_webview *, const saucerw_cookie *cookie, uintptr_t handle);
+    void saucerw_webview_delete_cookie(saucerw_webview *, const saucerw_cookie *cookie, uintptr_t handle);
+    int saucerw_clearable_data(void);
+    void saucerw_webview_clear_data(saucerw_webview *, int kinds, double since, uintptr_t handle);
+
+    double saucerw_webview_zoom(saucerw_webview *);
+    void saucerw_webview_set_zoom(saucerw_webview *, double factor);
+
+    // WebKit on macOS mutes the media elements of the page instead and reports no SAUCERW_WEBVIEW_PLAYING_AUDIO
+    bool saucerw_webview_muted(saucerw_webview *);
+    void saucerw_webview_set_muted(saucerw_webview *, bool);
+
+    // Printing and capturing report their result to the handle once like the cookie functions, the PNG passed to
+    // saucerwCapture is only valid during the call. saucerw_print_dialog reports whether the backend can show the
+    // print dialog.
+
+    bool saucerw_print_dialog(void);
+    void saucerw_webview_print(saucerw_webview *, const saucerw_print_options *options, uintptr_t handle);
+    void saucerw_webview_capture(saucerw_webview *, uintptr_t handle);
+
+    // Synthesize input in the webview, returning false if the backend cannot
+    bool saucerw_webview_send_key(saucerw_webview *, const saucerw_key_input *input);
+    bool saucerw_webview_send_text(saucerw_webview *, const char *text);
+    bool saucerw_webview_send_mouse(saucerw_webview *, const saucerw_mouse_input *input);
+
+    // Returns false if the backend cannot force the color scheme of the pages
+    bool saucerw_webview_set_dark_mode(saucerw_webview *, int mode);
+
+    // Shared buffers are memory mapped into the page, only WebView2 supports them and saucerw_webview_shared_buffer
+    // returns NULL elsewhere. data points to the memory until the buffer is freed, which may outlive the webview.
+
+    saucerw_shared_buffer *saucerw_webview_shared_buffer(saucerw_webview *, uint64_t size, uint8_t **data);
+    void saucerw_shared_buffer_post(saucerw_webview *, saucerw_shared_buffer *, bool readonly, const char *detail);
+    void saucerw_shared_buffer_free(saucerw_shared_buffer *);
+
+    void saucerw_webview_handle_scheme(saucerw_webview *, const char *name, uintptr_t handler);
+    void saucerw_webview_remove_scheme(saucerw_webview *, const char *name);
+
+    void saucerw_webview_handle_stream_scheme(saucerw_webview *, const char *name, uintptr_t handler);
+    void saucerw_webview_remove_stream_scheme(saucerw_webview *, const char *name);
+
+    void saucerw_scheme_resolve(saucerw_executor *, int status, const char *mime, size_t headers, const char **keys,
+                                const char **values, const uint8_t *data, size_t size);
+
+    // saucerw_stream_finish and saucerw_stream_reject free the stream
+
+    void saucerw_stream_start(saucerw_stream *, int status, const char *mime, size_t headers, const char **keys,
+                              const char **values);
+    void saucerw_stream_write(saucerw_stream *, const uint8_t *data, size_t size);
+    void saucerw_stream_finish(saucerw_stream *);
+    void saucerw_stream_reject(saucerw_stream *, int status);
+
+#ifdef __cplusplus
+}
+#endif
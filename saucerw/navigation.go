@@ -0,0 +1,68 @@
+package saucerw
+
+// Policy decides whether an action may proceed.
+type Policy uint8
+
+const (
+	// Allow lets the action proceed.
+	Allow Policy = iota
+	// Block cancels the action.
+	Block
+)
+
+// LoadState is the progress of a page load.
+type LoadState uint8
+
+const (
+	// LoadStarted is reported when a page started loading.
+	LoadStarted LoadState = iota
+	// LoadFinished is reported when a page finished loading.
+	LoadFinished
+)
+
+// NavigationEvent describes a pending navigation.
+type NavigationEvent struct {
+	// URL is the navigation target.
+	URL string
+	// NewWindow is set if the page requested a new window, e.g. through
+	// window.open or a target="_blank" link. Unless a handler blocks it, the
+	// handlers of Webview.OnNewWindow decide what happens.
+	NewWindow bool
+	// Redirect is set for server or client side redirects.
+	Redirect bool
+	// UserInitiated is set if the navigation was caused by user input.
+	UserInitiated bool
+}
+
+// OnNavigate calls fn before every navigation; returning Block cancels it.
+// If several handlers are registered, any of them can block the navigation.
+//
+// Navigation handlers run on the event loop thread, because the decision is
+// needed before the native call returns. They must not block.
+func (v *Webview) OnNavigate(fn func(NavigationEvent) Policy) *Subscription {
+	return v.navigate.subscribe(fn)
+}
+
+// OnLoad calls fn whenever a page started or finished loading.
+func (v *Webview) OnLoad(fn func(LoadState)) *Subscription {
+	return v.events.subscribe(func(ev WebviewEvent) {
+		if ev.Type == WebviewLoad {
+			fn(ev.Load)
+		}
+	})
+}
+
+// OnDomReady calls fn whenever the DOM of a page is ready.
+func (v *Webview) OnDomReady(fn func()) *Subscription {
+	return v.events.subscribe(func(ev WebviewEvent) {
+		if ev.Type == WebviewDomReady {
+			fn()
+		}
+	})
+}
+
+// OnReady calls fn whenever the page calls window.saucer.ready(), e.g. once
+// a single-page application rendered its first screen, see package splash.
+func (v *Webview) OnReady(fn func()) *Subscription {
+	return v.ready.subscribe(func(struct{}) { fn() })
+}
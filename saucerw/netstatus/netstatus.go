@@ -0,0 +1,337 @@
+// Package netstatus tracks whether the system is online, as one source of
+// truth for Go and the pages of webviews, e.g. for offline-first
+// applications deciding when to sync.
+//
+// A Monitor polls the network interfaces and, once one is connected, the
+// Probe of its options, which tells a working connection from one a captive
+// portal intercepts:
+//
+//	m := netstatus.Start(netstatus.Options{
+//		Probe: netstatus.HTTPProbe("http://connectivitycheck.gstatic.com/generate_204", nil),
+//	})
+//	defer m.Stop()
+//
+//	m.OnChange(func(s netstatus.Status) { log.Println("network is", s) })
+//	m.Attach(view)
+//
+// Attach keeps navigator.onLine of the page in line with the monitor, which
+// some engines misreport, and adds window.saucer.network:
+//
+//	window.addEventListener("saucer:network", (e) => console.log(e.detail.status));
+//	const status = await window.saucer.network.check();
+//
+// The interfaces are polled, so a change may take Options.Interval to be
+// noticed. Call Check to probe right away, e.g. on saucerw.PowerResume.
+package netstatus
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// Status is the state of the network connection.
+type Status uint8
+
+const (
+	// Offline means no network interface is connected or the probe failed.
+	Offline Status = iota
+	// Online means the probe succeeded.
+	Online
+	// Captive means a captive portal answered instead of the probed server,
+	// e.g. the login page of a hotel network. Pages see it as offline.
+	Captive
+)
+
+func (s Status) String() string {
+	switch s {
+	case Offline:
+		return "offline"
+	case Online:
+		return "online"
+	case Captive:
+		return "captive"
+	}
+	return fmt.Sprintf("Status(%d)", uint8(s))
+}
+
+// MarshalText encodes the status as its name.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Probe checks the connection once an interface is connected. It should
+// return before ctx is done.
+type Probe func(ctx context.Context) Status
+
+// HTTPProbe returns a Probe requesting url, which has to answer 204 No
+// Content. Any other answer, e.g. the redirect of a captive portal, is
+// reported as Captive and a failed request as Offline. Redirects are not
+// followed. A nil client uses http.DefaultTransport.
+func HTTPProbe(url string, client *http.Client) Probe {
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	probe := *client
+	probe.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return func(ctx context.Context) Status {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return Offline
+		}
+		req.Header.Set("Cache-Control", "no-cache")
+
+		resp, err := probe.Do(req)
+		if err != nil {
+			return Offline
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNoContent {
+			return Captive
+		}
+		return Online
+	}
+}
+
+// Options configures a Monitor.
+type Options struct {
+	// Probe, if non-nil, checks the connection once an interface is
+	// connected, see HTTPProbe. Otherwise a connected interface is Online.
+	Probe Probe
+	// Interval is the time between two checks. Defaults to 10 seconds.
+	Interval time.Duration
+	// Timeout bounds a probe. Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// Monitor tracks the status of the network. Its methods are safe to call
+// from any goroutine.
+type Monitor struct {
+	opts   Options
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// probing serializes the checks, so their results arrive in order.
+	probing sync.Mutex
+
+	mu        sync.Mutex
+	status    Status
+	listeners map[int]func(Status)
+	next      int
+	views     map[*saucerw.Webview]*attached
+}
+
+// attached is a webview whose page follows the status.
+type attached struct {
+	script uint64
+	sub    *saucerw.Subscription
+}
+
+// Start checks the status, which takes up to Options.Timeout, and keeps
+// checking it until Stop is called.
+func Start(opts Options) *Monitor {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Monitor{
+		opts:      opts,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		listeners: map[int]func(Status){},
+		views:     map[*saucerw.Webview]*attached{},
+	}
+
+	m.Check(ctx)
+	go m.run(ctx)
+
+	return m
+}
+
+// run checks the status every interval until ctx is done.
+func (m *Monitor) run(ctx context.Context) {
+	defer close(m.done)
+
+	tick := time.NewTicker(m.opts.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			m.Check(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop ends the checks and waits for them. The pages keep the last status.
+func (m *Monitor) Stop() {
+	m.cancel()
+	<-m.done
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for v, a := range m.views {
+		a.sub.Cancel()
+		delete(m.views, v)
+	}
+}
+
+// Status returns the last status checked.
+func (m *Monitor) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.status
+}
+
+// OnChange adds a receiver of the changes of the status, called on the
+// goroutine that checked it. It returns a function removing it.
+func (m *Monitor) OnChange(fn func(Status)) (remove func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.next
+	m.next++
+	m.listeners[id] = fn
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		delete(m.listeners, id)
+	}
+}
+
+// Check checks the status right away and returns it, reporting a change to
+// the receivers and the attached pages.
+func (m *Monitor) Check(ctx context.Context) Status {
+	m.probing.Lock()
+	defer m.probing.Unlock()
+
+	status := Offline
+	if connected() {
+		status = Online
+
+		if m.opts.Probe != nil {
+			ctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+			status = m.opts.Probe(ctx)
+			cancel()
+		}
+	}
+
+	// A probe interrupted by Stop or the caller tells nothing
+	if ctx.Err() != nil {
+		return m.Status()
+	}
+
+	m.mu.Lock()
+	if status == m.status {
+		m.mu.Unlock()
+		return status
+	}
+	m.status = status
+
+	fns := make([]func(Status), 0, len(m.listeners))
+	for _, fn := range m.listeners {
+		fns = append(fns, fn)
+	}
+
+	for v, a := range m.views {
+		m.update(v, a, status)
+	}
+	m.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(status)
+	}
+	return status
+}
+
+// connected reports whether a network interface other than the loopback is
+// up and has an address beyond its own link.
+func connected() bool {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return false
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			if ip, ok := addr.(*net.IPNet); ok && ip.IP.IsGlobalUnicast() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Attach keeps navigator.onLine in the page of v in line with the status and
+// adds window.saucer.network, see the package documentation, until the
+// window of v is closed. Call it before the page loads, the script is
+// injected into the pages loaded afterwards and the current one.
+//
+// The page-facing function is exposed as "netstatus.check".
+func (m *Monitor) Attach(v *saucerw.Webview) error {
+	if err := v.Expose("netstatus.check", m.Check); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.views[v]; ok {
+		return nil
+	}
+
+	a := &attached{}
+	a.sub = v.Parent().OnClosed(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		delete(m.views, v)
+	})
+
+	m.views[v] = a
+	m.update(v, a, m.status)
+
+	return nil
+}
+
+// update replaces the script of the status injected into v and reports it to
+// the current page. m.mu is held.
+func (m *Monitor) update(v *saucerw.Webview, a *attached, status Status) {
+	if a.script != 0 {
+		v.Uninject(a.script)
+	}
+
+	code := fmt.Sprintf(script, status.String())
+	a.script = v.InjectScript(saucerw.Script{Code: code, Time: saucerw.AtCreation, Frames: saucerw.MainFrame, Permanent: true})
+	v.Execute(code)
+}
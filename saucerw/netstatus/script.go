@@ -0,0 +1,83 @@
+package netstatus
+
+// script keeps navigator.onLine in line with the monitor and adds
+// window.saucer.network. It is formatted with the name of the current status;
+// running it again reports a changed one.
+//
+// The online and offline events of the engine are suppressed, it dispatches
+// them itself whenever navigator.onLine changes.
+const script = `
+(() =>
+{
+    if (!window.saucer)
+    {
+        return;
+    }
+
+    const status = %q;
+    const state  = window.saucer.network;
+
+    if (state)
+    {
+        state.update(status);
+        return;
+    }
+
+    let current    = status;
+    let dispatched = false;
+
+    const online = () => current === "online";
+
+    Object.defineProperty(Navigator.prototype, "onLine", { get: online, configurable: true });
+
+    for (const type of ["online", "offline"])
+    {
+        window.addEventListener(type, (e) =>
+        {
+            if (!dispatched)
+            {
+                e.stopImmediatePropagation();
+            }
+        }, true);
+    }
+
+    const dispatch = (event) =>
+    {
+        dispatched = true;
+
+        try
+        {
+            window.dispatchEvent(event);
+        }
+        finally
+        {
+            dispatched = false;
+        }
+    };
+
+    window.saucer.network = {
+        get status()
+        {
+            return current;
+        },
+        check: async () => window.saucer.call("netstatus.check", []),
+        update: (status) =>
+        {
+            if (status === current)
+            {
+                return;
+            }
+
+            const was = online();
+            current   = status;
+
+            if (was !== online())
+            {
+                dispatch(new Event(online() ? "online" : "offline"));
+            }
+
+            dispatch(new CustomEvent("saucer:network", { detail: { status } }));
+        },
+    };
+})();
+`
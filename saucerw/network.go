@@ -0,0 +1,301 @@
+package saucerw
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// NetworkOptions configures how a webview reaches the network. They are fixed
+// once the webview is created; options the backend cannot apply make
+// NewWebview fail with an error wrapping ErrUnsupported.
+//
+// The macOS backend supports proxies since macOS 14 and does not report
+// certificate errors.
+type NetworkOptions struct {
+	// Proxy routes the traffic of the webview through a proxy server if
+	// non-nil.
+	Proxy *Proxy
+	// RootCAs are trusted in addition to the system roots: a server
+	// certificate the engine rejected is accepted for its host if it
+	// verifies against them.
+	RootCAs *x509.CertPool
+	// OnCertificateError decides on server certificates the engine rejected
+	// that do not verify against RootCAs, returning true to accept the
+	// certificate for its host. If nil, they are rejected. It runs on the
+	// event loop thread and must not block.
+	//
+	// The WebKitGTK backend only reports the certificates of page loads, the
+	// resources of a page failing verification are not loaded.
+	OnCertificateError func(CertificateError) bool
+	// AllowedHosts, if non-empty, restricts the hosts pages may reach over
+	// http, https and WebSockets. "example.com" allows the host itself,
+	// "*.example.com" its subdomains. Custom schemes and data URLs are not
+	// restricted.
+	AllowedHosts []string
+
+	// blockInsecure is SecurityPolicy.BlockMixedContent.
+	blockInsecure bool
+}
+
+// Proxy is a proxy server for the traffic of a webview.
+//
+// On the Qt and WebView2 backends the proxy is a setting of the browser
+// engine of the whole application, all webviews have to use the same one.
+type Proxy struct {
+	// URL is the address of the server, with the scheme http, https, socks4
+	// or socks5, e.g. "socks5://proxy.example.com:1080".
+	URL string
+	// Bypass lists hosts reached directly, in the patterns of AllowedHosts.
+	Bypass []string
+	// Credentials, if non-nil, is called when the proxy asks for
+	// authentication with its host. It returns the user name and password,
+	// ok false leaves the request unauthenticated. It runs on the event loop
+	// thread and must not block. The macOS backend calls it once, while the
+	// webview is created, and the WebView2 backend does not support it.
+	Credentials func(host string) (user, password string, ok bool)
+}
+
+// CertificateError describes a server certificate the engine rejected.
+type CertificateError struct {
+	// URL is the address whose server presented the certificate.
+	URL string
+	// Host is the host of URL.
+	Host string
+	// Certificates is the chain presented by the server, leaf first.
+	Certificates []*x509.Certificate
+}
+
+// The network options a backend supports, matching saucerw_network_feature.
+const (
+	networkProxy = 1 << iota
+	networkCredentials
+	networkCertificates
+	networkAllowedHosts
+)
+
+var networkNames = [...]string{"proxies", "proxy credentials", "certificate error handling", "allowed hosts"}
+
+// networkFeatures returns the names of the network features in set.
+func networkFeatures(set int) string {
+	var names []string
+	for i, name := range networkNames {
+		if set&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// validate returns an error for options that cannot work.
+func (n *NetworkOptions) validate() error {
+	if n.Proxy != nil {
+		u, err := url.Parse(n.Proxy.URL)
+		if err != nil {
+			return fmt.Errorf("saucerw: proxy: %w", err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks4", "socks5":
+		default:
+			return fmt.Errorf("saucerw: proxy: unsupported scheme %q", u.Scheme)
+		}
+
+		if u.Hostname() == "" || u.Port() == "" {
+			return fmt.Errorf("saucerw: proxy: %q needs a host and a port", n.Proxy.URL)
+		}
+	}
+
+	for _, host := range n.AllowedHosts {
+		if strings.TrimPrefix(host, "*.") == "" || strings.ContainsAny(host, "/:") {
+			return fmt.Errorf("saucerw: invalid allowed host %q", host)
+		}
+	}
+	return nil
+}
+
+// features returns the network features n uses.
+func (n *NetworkOptions) features() int {
+	var rtn int
+
+	if n.Proxy != nil {
+		rtn |= networkProxy
+
+		if n.Proxy.Credentials != nil {
+			rtn |= networkCredentials
+		}
+	}
+
+	if n.RootCAs != nil || n.OnCertificateError != nil {
+		rtn |= networkCertificates
+	}
+
+	if n.filtered() {
+		rtn |= networkAllowedHosts
+	}
+	return rtn
+}
+
+// filtered reports whether the requests of the pages have to be filtered.
+func (n *NetworkOptions) filtered() bool {
+	return len(n.AllowedHosts) != 0 || n.blockInsecure
+}
+
+// allowURL reports whether a page may reach addr.
+func (n *NetworkOptions) allowURL(addr string) bool {
+	if !n.filtered() {
+		return true
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return false
+	}
+
+	switch u.Scheme {
+	case "http", "ws":
+		if n.blockInsecure && !slices.Contains(loopbackHosts, strings.Trim(u.Hostname(), "[]")) {
+			return false
+		}
+	case "https", "wss":
+	default:
+		return true
+	}
+
+	return len(n.AllowedHosts) == 0 || matchHost(n.AllowedHosts, u.Hostname())
+}
+
+// acceptCertificate decides on the rejected certificate chain in pemChain
+// presented for addr.
+func (n *NetworkOptions) acceptCertificate(addr string, pemChain []byte) bool {
+	ev := CertificateError{URL: addr}
+	if u, err := url.Parse(addr); err == nil {
+		ev.Host = u.Hostname()
+	}
+
+	for block, rest := pem.Decode(pemChain); block != nil; block, rest = pem.Decode(rest) {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			ev.Certificates = append(ev.Certificates, cert)
+		}
+	}
+
+	if n.RootCAs != nil && len(ev.Certificates) != 0 {
+		intermediates := x509.NewCertPool()
+		for _, cert := range ev.Certificates[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := ev.Certificates[0].Verify(x509.VerifyOptions{
+			DNSName:       ev.Host,
+			Roots:         n.RootCAs,
+			Intermediates: intermediates,
+		})
+		if err == nil {
+			return true
+		}
+	}
+
+	if n.OnCertificateError == nil {
+		return false
+	}
+
+	defer guard("certificate error handler")
+	return n.OnCertificateError(ev)
+}
+
+// credentials asks the proxy credentials handler for host.
+func (n *NetworkOptions) credentials(host string) (user, password string, ok bool) {
+	defer guard("proxy credentials handler")
+	return n.Proxy.Credentials(host)
+}
+
+// loopbackHosts are the hosts reached over http and ws despite
+// blockInsecure, as their traffic does not leave the machine.
+var loopbackHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// contentRules returns the WebKit content blocker rules enforcing
+// AllowedHosts and blockInsecure: all network loads are blocked, then the
+// allowed hosts are exempted again, after which insecure loads are blocked
+// and the allowed loopback hosts exempted.
+func (n *NetworkOptions) contentRules() string {
+	type trigger struct {
+		URLFilter string `json:"url-filter"`
+	}
+	type action struct {
+		Type string `json:"type"`
+	}
+	type rule struct {
+		Trigger trigger `json:"trigger"`
+		Action  action  `json:"action"`
+	}
+
+	// Content blockers support no alternation, the schemes get a rule each
+	schemes := []string{"^https?://", "^wss?://"}
+
+	var rules []rule
+	if len(n.AllowedHosts) != 0 {
+		for _, scheme := range schemes {
+			rules = append(rules, rule{trigger{scheme}, action{"block"}})
+		}
+	}
+
+	for _, host := range n.AllowedHosts {
+		filter := regexp.QuoteMeta(strings.ToLower(host)) + "[:/]"
+		if sub, ok := strings.CutPrefix(host, "*."); ok {
+			filter = `[^/:]*\.` + regexp.QuoteMeta(strings.ToLower(sub)) + "[:/]"
+		}
+
+		for _, scheme := range schemes {
+			rules = append(rules, rule{trigger{scheme + filter}, action{"ignore-previous-rules"}})
+		}
+	}
+
+	if n.blockInsecure {
+		insecure := []string{"^http://", "^ws://"}
+		for _, scheme := range insecure {
+			rules = append(rules, rule{trigger{scheme}, action{"block"}})
+		}
+
+		for _, host := range loopbackHosts {
+			if len(n.AllowedHosts) != 0 && !matchHost(n.AllowedHosts, host) {
+				continue
+			}
+
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			for _, scheme := range insecure {
+				rules = append(rules, rule{trigger{scheme + regexp.QuoteMeta(host) + "[:/]"}, action{"ignore-previous-rules"}})
+			}
+		}
+	}
+
+	data, _ := json.Marshal(rules)
+	return string(data)
+}
+
+// matchHost reports whether host matches one of patterns, see AllowedHosts.
+func matchHost(patterns []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+
+		if sub, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+sub) {
+				return true
+			}
+			continue
+		}
+
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
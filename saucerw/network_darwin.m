@@ -0,0 +1,107 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <Network/Network.h>
+#import <WebKit/WebKit.h>
+
+#include "native.h"
+
+// Implemented in cookies_darwin.m
+WKWebView *saucerw_cocoa_webview(const void *impl);
+
+int saucerw_cocoa_network_features(void)
+{
+    int rtn = SAUCERW_NETWORK_ALLOWED_HOSTS;
+
+    if (@available(macOS 14.0, *))
+    {
+        rtn |= SAUCERW_NETWORK_PROXY | SAUCERW_NETWORK_CREDENTIALS;
+    }
+
+    return rtn;
+}
+
+static void apply_proxy(WKWebView *webview, const saucerw_network_options *options, const char *user,
+                        const char *password) API_AVAILABLE(macos(14.0))
+{
+    const char *port        = [NSString stringWithFormat:@"%d", options->proxy_port].UTF8String;
+    nw_endpoint_t endpoint  = nw_endpoint_create_host(options->proxy_host, port);
+    nw_proxy_config_t proxy = NULL;
+
+    // Network.framework only speaks SOCKS5, SOCKS4 servers are asked the same way
+    if (options->proxy_socks)
+    {
+        proxy = nw_proxy_config_create_socksv5(endpoint);
+    }
+    else
+    {
+        const bool tls = strncmp(options->proxy, "https:", 6) == 0;
+        proxy          = nw_proxy_config_create_http_connect(endpoint, tls ? nw_tls_create_options() : NULL);
+    }
+
+    if (user)
+    {
+        nw_proxy_config_set_username_and_password(proxy, user, password);
+    }
+
+    for (size_t i = 0; options->bypass > i; i++)
+    {
+        const char *host = options->bypass_hosts[i];
+
+        // Excluded domains include their subdomains
+        if (strncmp(host, "*.", 2) == 0)
+        {
+            host += 2;
+        }
+
+        nw_proxy_config_add_excluded_domain(proxy, host);
+    }
+
+    webview.configuration.websiteDataStore.proxyConfigurations = @[ proxy ];
+}
+
+static void apply_rules(WKWebView *webview, const char *rules)
+{
+    NSString *source     = [NSString stringWithUTF8String:rules];
+    NSString *identifier = [NSString stringWithFormat:@"saucerw.allowed-hosts.%lx", (unsigned long)source.hash];
+
+    __weak WKWebView *weak = webview;
+
+    [WKContentRuleListStore.defaultStore
+        compileContentRuleListForIdentifier:identifier
+                     encodedContentRuleList:source
+                          completionHandler:^(WKContentRuleList *list, NSError *error) {
+                            if (!list)
+                            {
+                                const char *message = error.localizedDescription.UTF8String;
+                                saucerwLog(SAUCERW_LOG_ERROR, "network", (char *)message, strlen(message));
+                                return;
+                            }
+
+                            [weak.configuration.userContentController addContentRuleList:list];
+                          }];
+}
+
+void saucerw_cocoa_apply_network(const void *impl, const saucerw_network_options *options, const char *user,
+                                 const char *password)
+{
+    WKWebView *webview = saucerw_cocoa_webview(impl);
+
+    if (!webview)
+    {
+        return;
+    }
+
+    if (options->proxy)
+    {
+        if (@available(macOS 14.0, *))
+        {
+            apply_proxy(webview, options, user, password);
+        }
+    }
+
+    if (options->content_rules)
+    {
+        apply_rules(webview, options->content_rules);
+    }
+}
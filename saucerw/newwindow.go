@@ -0,0 +1,152 @@
+package saucerw
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+)
+
+// NewWindowRequest describes a page asking for a new window, through
+// window.open or a link with target="_blank".
+type NewWindowRequest struct {
+	// URL is the address the new window is to show.
+	URL string
+	// UserInitiated is set if the request was caused by user input, e.g. a
+	// click, rather than a script on its own.
+	UserInitiated bool
+}
+
+// NewWindowAction is what a webview does with a NewWindowRequest.
+type NewWindowAction uint8
+
+const (
+	// NewWindowDefault leaves the request to the next handler. Requests no
+	// handler decides on are denied.
+	NewWindowDefault NewWindowAction = iota
+	// NewWindowDeny ignores the request.
+	NewWindowDeny
+	// NewWindowBrowser opens the URL with the default browser of the system.
+	// Only http and https URLs are opened, others are denied.
+	NewWindowBrowser
+	// NewWindowCurrent navigates the requesting webview to the URL.
+	NewWindowCurrent
+	// NewWindowCreate opens the URL in a new window with a webview of its
+	// own, which is destroyed when the user closes it.
+	NewWindowCreate
+)
+
+// NewWindowDecision tells a webview what to do with a NewWindowRequest.
+type NewWindowDecision struct {
+	Action NewWindowAction
+	// Window configures the window of NewWindowCreate.
+	Window WindowOptions
+	// Webview configures its webview, the Window field is ignored.
+	Webview WebviewOptions
+	// OnCreate is called with the window and webview of NewWindowCreate
+	// before the webview navigates to the URL and the window is shown, e.g. to
+	// expose functions. It runs on the event loop thread. Optional.
+	OnCreate func(*Window, *Webview)
+}
+
+// OnNewWindow calls fn whenever the page asks for a new window. Handlers are
+// asked in the order they were registered until one returns an action other
+// than NewWindowDefault; a panicking handler denies the request.
+//
+// The request is only decided on if no navigation handler blocked it, see
+// NavigationEvent.NewWindow. Windows created for a request are independent
+// of the page that asked for them, which sees no window.opener. Like
+// navigation handlers, new window handlers run on the event loop thread and
+// must not block.
+func (v *Webview) OnNewWindow(fn func(NewWindowRequest) NewWindowDecision) *Subscription {
+	return v.windows.subscribe(fn)
+}
+
+// decideNavigation asks the navigation handlers and performs the new window
+// requests they allowed. The backends never open windows themselves.
+func (v *Webview) decideNavigation(ev NavigationEvent) Policy {
+	if policy := v.navigate.decide(ev); policy == Block || !ev.NewWindow {
+		return policy
+	}
+
+	req := NewWindowRequest{URL: ev.URL, UserInitiated: ev.UserInitiated}
+	decision := v.windows.first(req, func(d *NewWindowDecision) bool { return d.Action != NewWindowDefault },
+		NewWindowDecision{Action: NewWindowDeny})
+
+	// Performed once the backend finished deciding on the navigation
+	switch decision.Action {
+	case NewWindowBrowser:
+		go v.openBrowser(ev.URL)
+	case NewWindowCurrent:
+		v.window.app.Post(func() { v.Navigate(ev.URL) })
+	case NewWindowCreate:
+		v.window.app.Post(func() { v.openWindow(ev.URL, decision) })
+	}
+
+	return Block
+}
+
+// openBrowser opens rawURL with the default browser if it is a web address.
+func (v *Webview) openBrowser(rawURL string) {
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		log().Warn("not opening new window in the browser", "component", "saucerw", "url", rawURL)
+		return
+	}
+
+	if err := OpenURL(rawURL); err != nil {
+		log().Warn("opening new window in the browser failed", "component", "saucerw", "url", rawURL, "error", err)
+	}
+}
+
+// openWindow creates the window of a NewWindowCreate decision.
+func (v *Webview) openWindow(rawURL string, decision NewWindowDecision) {
+	w, err := v.window.app.NewWindow(decision.Window)
+	if err != nil {
+		log().Warn("creating new window failed", "component", "saucerw", "url", rawURL, "error", err)
+		return
+	}
+
+	opts := decision.Webview
+	opts.Window = w
+
+	child, err := NewWebview(opts)
+	if err != nil {
+		log().Warn("creating new window failed", "component", "saucerw", "url", rawURL, "error", err)
+		_ = w.Destroy()
+		return
+	}
+
+	w.OnClosed(func() { _ = w.Destroy() })
+
+	if decision.OnCreate != nil {
+		decision.OnCreate(w, child)
+	}
+
+	child.Navigate(rawURL)
+	w.Show()
+}
+
+// OpenURL opens rawURL with the default application of the system for its
+// scheme, the browser for web addresses. It does not wait for the application
+// to start.
+func OpenURL(rawURL string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", rawURL)
+	case "darwin":
+		cmd = exec.Command("open", rawURL)
+	default:
+		cmd = exec.Command("xdg-open", rawURL)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("saucerw: open url: %w", err)
+	}
+
+	// Reap the process, the launchers exit once the application started
+	go cmd.Wait()
+
+	return nil
+}
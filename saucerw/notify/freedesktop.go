@@ -0,0 +1,159 @@
+//go:build !windows && !darwin
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/png"
+	"strconv"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw/internal/dbus"
+)
+
+// The desktop notifications specification.
+const (
+	serviceName   = "org.freedesktop.Notifications"
+	servicePath   = "/org/freedesktop/Notifications"
+	defaultAction = "default"
+)
+
+// freedesktop sends notifications to the notification service on the
+// session bus.
+type freedesktop struct {
+	conn    *dbus.Conn
+	signals chan *dbus.Message
+
+	// mu is held while a notification is sent, so its signals are handled
+	// after it was tracked.
+	mu sync.Mutex
+}
+
+func newBackend() (backend, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	f := &freedesktop{conn: conn, signals: make(chan *dbus.Message, 64)}
+	conn.Handle(f.handle)
+
+	match := "type='signal',interface='" + serviceName + "'"
+	if _, err := conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", match); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("notify: %w", err)
+	}
+
+	go f.dispatch()
+	return f, nil
+}
+
+// handle queues the signals of the service. It runs on the goroutine
+// reading the connection.
+func (f *freedesktop) handle(msg *dbus.Message) {
+	if msg.Type != dbus.TypeSignal || msg.Interface != serviceName || len(msg.Body) != 2 {
+		return
+	}
+
+	select {
+	case f.signals <- msg:
+	default:
+	}
+}
+
+// dispatch delivers the queued signals in order.
+func (f *freedesktop) dispatch() {
+	for {
+		select {
+		case msg := <-f.signals:
+			f.signal(msg)
+		case <-f.conn.Done():
+			return
+		}
+	}
+}
+
+func (f *freedesktop) signal(msg *dbus.Message) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id, ok := msg.Body[0].(uint32)
+	if !ok {
+		return
+	}
+
+	switch msg.Member {
+	case "ActionInvoked":
+		key, _ := msg.Body[1].(string)
+		if key == defaultAction {
+			activated(uint64(id), -1)
+			return
+		}
+
+		index, err := strconv.Atoi(key)
+		if err == nil {
+			activated(uint64(id), index)
+		}
+	case "NotificationClosed":
+		closed(uint64(id))
+	}
+}
+
+func (f *freedesktop) send(n *Notification) error {
+	var actions []string
+	if n.OnClick != nil {
+		actions = append(actions, defaultAction, "")
+	}
+	for i, action := range n.Actions {
+		actions = append(actions, strconv.Itoa(i), action.Label)
+	}
+
+	hints := map[string]dbus.Variant{
+		"desktop-entry": dbus.MakeVariant("s", AppID),
+	}
+
+	if len(n.Icon) > 0 {
+		data, err := imageData(n.Icon)
+		if err != nil {
+			return err
+		}
+		hints["image-data"] = dbus.MakeVariant("(iiibiiay)", data)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, err := f.conn.Call(serviceName, servicePath, serviceName, "Notify", "susssasa{sv}i",
+		AppID, uint32(0), "", n.Title, n.Body, actions, hints, int32(-1))
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+
+	if id, ok := body[0].(uint32); ok {
+		track(uint64(id), n)
+	}
+	return nil
+}
+
+// imageData converts icon to the raw RGBA image of the image-data hint.
+func imageData(icon []byte) ([]any, error) {
+	img, _, err := image.Decode(bytes.NewReader(icon))
+	if err != nil {
+		return nil, fmt.Errorf("notify: decode icon: %w", err)
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return []any{int32(bounds.Dx()), int32(bounds.Dy()), int32(rgba.Stride), true, int32(8), int32(4), rgba.Pix}, nil
+}
+
+// permission reports true, the service needs no permission.
+func (f *freedesktop) permission(context.Context) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,165 @@
+// Package notify shows desktop notifications through the notification
+// center of the system, with buttons calling back into Go.
+//
+// Notifications are sent to the freedesktop notification service on Linux
+// and other freedesktop systems, shown as toasts on Windows and delivered
+// through the user notification center on macOS, which asks the user for
+// permission first. Handlers run on their own goroutine; use
+// Application.Dispatch to touch windows from them.
+//
+//	err := notify.Send(notify.Notification{
+//		Title: "Download finished",
+//		Body:  "report.pdf was saved to Downloads",
+//		Actions: []notify.Action{
+//			{Label: "Open", OnClick: func() { open("report.pdf") }},
+//		},
+//	})
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnsupported is returned when the system has no notification
+	// center or the backend is not compiled in.
+	ErrUnsupported = errors.New("notify: notifications are not supported")
+	// ErrDenied is returned when the user did not allow notifications.
+	ErrDenied = errors.New("notify: notifications are not permitted")
+)
+
+// AppID identifies the application to the notification center: the
+// AppUserModelID on Windows and the application name on Linux. It defaults
+// to the name of the executable and has to be set before the first
+// notification is sent. macOS uses the bundle identifier instead.
+var AppID string
+
+// Notification is a desktop notification.
+type Notification struct {
+	// Title is the summary line of the notification.
+	Title string
+	// Body is the text below the title. Optional.
+	Body string
+	// Icon is PNG image data shown next to the text. Optional; on macOS the
+	// application icon is always used.
+	Icon []byte
+	// Actions are buttons shown with the notification. Notification centers
+	// may show only the first few.
+	Actions []Action
+	// OnClick is called when the notification itself is clicked. Optional.
+	OnClick func()
+}
+
+// Action is a button of a Notification.
+type Action struct {
+	Label   string
+	OnClick func()
+}
+
+// backend is the notification center of the system.
+type backend interface {
+	send(n *Notification) error
+	permission(ctx context.Context) (bool, error)
+}
+
+var (
+	load    sync.Once
+	native  backend
+	loadErr error
+
+	mu      sync.Mutex
+	pending = map[uint64]*Notification{}
+)
+
+// center returns the backend, connecting on first use.
+func center() (backend, error) {
+	load.Do(func() {
+		if AppID == "" {
+			AppID = strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+		}
+		native, loadErr = newBackend()
+	})
+	return native, loadErr
+}
+
+// Send shows n. It returns once the notification center accepted it; the
+// handlers are called later, at most one of them per notification.
+func Send(n Notification) error {
+	if n.Title == "" {
+		return errors.New("notify: notification without title")
+	}
+
+	b, err := center()
+	if err != nil {
+		return err
+	}
+	return b.send(&n)
+}
+
+// RequestPermission asks the user to allow notifications where the system
+// requires it and reports whether they are allowed. Send asks on its own,
+// this allows asking at a better moment.
+func RequestPermission(ctx context.Context) (bool, error) {
+	b, err := center()
+	if err != nil {
+		return false, err
+	}
+	return b.permission(ctx)
+}
+
+// track remembers n under the backend id until it was activated or closed.
+func track(id uint64, n *Notification) {
+	if n.OnClick == nil && !hasHandlers(n.Actions) {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	pending[id] = n
+}
+
+// hasHandlers reports whether one of the actions has a handler.
+func hasHandlers(actions []Action) bool {
+	for _, action := range actions {
+		if action.OnClick != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// activated handles a click on the notification id, on the action with
+// the index or, if negative, on the notification itself.
+func activated(id uint64, action int) {
+	mu.Lock()
+	n := pending[id]
+	delete(pending, id)
+	mu.Unlock()
+
+	if n == nil {
+		return
+	}
+
+	fn := n.OnClick
+	if action >= 0 && action < len(n.Actions) {
+		fn = n.Actions[action].OnClick
+	}
+
+	if fn != nil {
+		go fn()
+	}
+}
+
+// closed forgets the notification id.
+func closed(id uint64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(pending, id)
+}
@@ -0,0 +1,132 @@
+//go:build darwin && cgo && saucer
+
+package notify
+
+/*
+#cgo CFLAGS: -fobjc-arc
+#cgo LDFLAGS: -framework Foundation -framework UserNotifications
+
+#include <stdbool.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+bool notify_available(void);
+void notify_request(uintptr_t handle);
+void notify_send(uintptr_t handle, uint64_t id, const char *title, const char *body, size_t actions, char **labels);
+
+enum
+{
+    NOTIFY_DEFAULT   = -1,
+    NOTIFY_DISMISSED = -2,
+};
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"runtime/cgo"
+	"sync/atomic"
+	"unsafe"
+)
+
+// userNotifications delivers notifications through UNUserNotificationCenter, which
+// asks the user for permission on first use.
+type userNotifications struct {
+	next atomic.Uint64
+}
+
+// permissionResult is the answer to an authorization request.
+type permissionResult struct {
+	granted bool
+	err     error
+}
+
+func newBackend() (backend, error) {
+	// The notification center raises an exception for processes outside an
+	// application bundle.
+	if !C.notify_available() {
+		return nil, fmt.Errorf("%w: the application is not bundled", ErrUnsupported)
+	}
+	return &userNotifications{}, nil
+}
+
+//export notifyPermission
+func notifyPermission(handle C.uintptr_t, granted C.bool, msg *C.char) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+
+	result := permissionResult{granted: bool(granted)}
+	if msg != nil {
+		result.err = fmt.Errorf("notify: %s", C.GoString(msg))
+	}
+	h.Value().(chan permissionResult) <- result
+}
+
+//export notifySent
+func notifySent(handle C.uintptr_t, msg *C.char) {
+	h := cgo.Handle(handle)
+	defer h.Delete()
+
+	var err error
+	if msg != nil {
+		err = fmt.Errorf("notify: %s", C.GoString(msg))
+	}
+	h.Value().(chan error) <- err
+}
+
+//export notifyResponse
+func notifyResponse(id C.uint64_t, action C.int) {
+	if action == C.NOTIFY_DISMISSED {
+		closed(uint64(id))
+		return
+	}
+	activated(uint64(id), int(action))
+}
+
+func (u *userNotifications) permission(ctx context.Context) (bool, error) {
+	ch := make(chan permissionResult, 1)
+	C.notify_request(C.uintptr_t(cgo.NewHandle(ch)))
+
+	select {
+	case result := <-ch:
+		return result.granted, result.err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+func (u *userNotifications) send(n *Notification) error {
+	granted, err := u.permission(context.Background())
+	if err != nil {
+		return err
+	}
+	if !granted {
+		return ErrDenied
+	}
+
+	id := u.next.Add(1)
+
+	title := C.CString(n.Title)
+	defer C.free(unsafe.Pointer(title))
+
+	body := C.CString(n.Body)
+	defer C.free(unsafe.Pointer(body))
+
+	labels := make([]*C.char, len(n.Actions))
+	for i, action := range n.Actions {
+		labels[i] = C.CString(action.Label)
+		defer C.free(unsafe.Pointer(labels[i]))
+	}
+
+	track(id, n)
+
+	ch := make(chan error, 1)
+	C.notify_send(C.uintptr_t(cgo.NewHandle(ch)), C.uint64_t(id), title, body, C.size_t(len(labels)), unsafe.SliceData(labels))
+
+	if err := <-ch; err != nil {
+		closed(id)
+		return err
+	}
+	return nil
+}
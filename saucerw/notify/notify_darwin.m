@@ -0,0 +1,159 @@
+//go:build darwin && cgo && saucer
+
+#import <Foundation/Foundation.h>
+#import <UserNotifications/UserNotifications.h>
+
+#include "_cgo_export.h"
+
+enum
+{
+    NOTIFY_DEFAULT   = -1,
+    NOTIFY_DISMISSED = -2,
+};
+
+@interface NotifyDelegate : NSObject <UNUserNotificationCenterDelegate>
+@end
+
+@implementation NotifyDelegate
+- (void)userNotificationCenter:(UNUserNotificationCenter *)center
+       willPresentNotification:(UNNotification *)notification
+         withCompletionHandler:(void (^)(UNNotificationPresentationOptions))handler
+{
+    // Show notifications while the application is active too
+    if (@available(macOS 11.0, *))
+    {
+        handler(UNNotificationPresentationOptionBanner | UNNotificationPresentationOptionList |
+                UNNotificationPresentationOptionSound);
+    }
+    else
+    {
+        handler(UNNotificationPresentationOptionAlert | UNNotificationPresentationOptionSound);
+    }
+}
+
+- (void)userNotificationCenter:(UNUserNotificationCenter *)center
+    didReceiveNotificationResponse:(UNNotificationResponse *)response
+             withCompletionHandler:(void (^)(void))handler
+{
+    uint64_t id      = strtoull(response.notification.request.identifier.UTF8String, NULL, 10);
+    NSString *action = response.actionIdentifier;
+    int index        = NOTIFY_DEFAULT;
+
+    if ([action isEqualToString:UNNotificationDismissActionIdentifier])
+    {
+        index = NOTIFY_DISMISSED;
+    }
+    else if ([action hasPrefix:@"action-"])
+    {
+        index = [[action substringFromIndex:7] intValue];
+    }
+
+    notifyResponse(id, index);
+    handler();
+}
+@end
+
+static UNUserNotificationCenter *notify_center(void)
+{
+    static NotifyDelegate *delegate;
+    static dispatch_once_t once;
+
+    UNUserNotificationCenter *center = [UNUserNotificationCenter currentNotificationCenter];
+
+    dispatch_once(&once, ^{
+      delegate        = [NotifyDelegate new];
+      center.delegate = delegate;
+    });
+
+    return center;
+}
+
+static char *notify_error(NSError *error)
+{
+    return error ? (char *)error.localizedDescription.UTF8String : NULL;
+}
+
+bool notify_available(void)
+{
+    return NSBundle.mainBundle.bundleIdentifier != nil;
+}
+
+void notify_request(uintptr_t handle)
+{
+    UNAuthorizationOptions options = UNAuthorizationOptionAlert | UNAuthorizationOptionSound;
+
+    [notify_center() requestAuthorizationWithOptions:options
+                                   completionHandler:^(BOOL granted, NSError *error) {
+                                     notifyPermission(handle, granted, notify_error(error));
+                                   }];
+}
+
+// Categories declare the buttons, notifications with the same labels share one.
+static void notify_category(UNUserNotificationCenter *center, NSString *identifier, NSArray<NSString *> *labels,
+                            void (^done)(void))
+{
+    if (labels.count == 0)
+    {
+        done();
+        return;
+    }
+
+    NSMutableArray<UNNotificationAction *> *actions = [NSMutableArray array];
+
+    for (NSUInteger i = 0; i < labels.count; ++i)
+    {
+        NSString *action = [NSString stringWithFormat:@"action-%lu", (unsigned long)i];
+        [actions addObject:[UNNotificationAction actionWithIdentifier:action
+                                                                title:labels[i]
+                                                              options:UNNotificationActionOptionForeground]];
+    }
+
+    UNNotificationCategory *category =
+        [UNNotificationCategory categoryWithIdentifier:identifier
+                                               actions:actions
+                                     intentIdentifiers:@[]
+                                               options:UNNotificationCategoryOptionCustomDismissAction];
+
+    [center getNotificationCategoriesWithCompletionHandler:^(NSSet<UNNotificationCategory *> *categories) {
+      NSMutableSet *rtn = [categories mutableCopy];
+
+      [rtn addObject:category];
+      [center setNotificationCategories:rtn];
+
+      done();
+    }];
+}
+
+void notify_send(uintptr_t handle, uint64_t id, const char *title, const char *body, size_t actions, char **labels)
+{
+    UNUserNotificationCenter *center      = notify_center();
+    UNMutableNotificationContent *content = [UNMutableNotificationContent new];
+    NSMutableArray<NSString *> *names     = [NSMutableArray array];
+
+    content.title = [NSString stringWithUTF8String:title];
+    content.body  = [NSString stringWithUTF8String:body];
+    content.sound = [UNNotificationSound defaultSound];
+
+    for (size_t i = 0; i < actions; ++i)
+    {
+        [names addObject:[NSString stringWithUTF8String:labels[i]]];
+    }
+
+    if (names.count > 0)
+    {
+        content.categoryIdentifier = [@"saucerw." stringByAppendingString:[names componentsJoinedByString:@"\x1f"]];
+    }
+
+    NSString *identifier = [NSString stringWithFormat:@"%llu", (unsigned long long)id];
+
+    notify_category(center, content.categoryIdentifier, names, ^{
+      UNNotificationRequest *request = [UNNotificationRequest requestWithIdentifier:identifier
+                                                                            content:content
+                                                                            trigger:nil];
+
+      [center addNotificationRequest:request
+               withCompletionHandler:^(NSError *error) {
+                 notifySent(handle, notify_error(error));
+               }];
+    });
+}
@@ -0,0 +1,9 @@
+//go:build darwin && !(cgo && saucer)
+
+package notify
+
+// newBackend fails, the user notification center needs the AppKit event
+// loop of saucer.
+func newBackend() (backend, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,507 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	combase  = syscall.NewLazyDLL("combase.dll")
+	shell32  = syscall.NewLazyDLL("shell32.dll")
+	advapi32 = syscall.NewLazyDLL("advapi32.dll")
+
+	procRoInitialize              = combase.NewProc("RoInitialize")
+	procRoGetActivationFactory    = combase.NewProc("RoGetActivationFactory")
+	procRoActivateInstance        = combase.NewProc("RoActivateInstance")
+	procWindowsCreateString       = combase.NewProc("WindowsCreateString")
+	procWindowsDeleteString       = combase.NewProc("WindowsDeleteString")
+	procWindowsGetStringRawBuffer = combase.NewProc("WindowsGetStringRawBuffer")
+	procSetAppUserModelID         = shell32.NewProc("SetCurrentProcessExplicitAppUserModelID")
+	procRegCreateKey              = advapi32.NewProc("RegCreateKeyExW")
+	procRegSetValue               = advapi32.NewProc("RegSetValueExW")
+)
+
+const (
+	roInitMultithreaded = 1
+	rpcChangedMode      = 0x80010106
+	noInterface         = 0x80004002
+)
+
+// The interfaces of the toast notification API. Methods of WinRT
+// interfaces start at index 6, after those of IInspectable.
+var (
+	iidUnknown             = syscall.GUID{Data1: 0x00000000, Data2: 0x0000, Data3: 0x0000, Data4: [8]byte{0xc0, 0, 0, 0, 0, 0, 0, 0x46}}
+	iidAgileObject         = syscall.GUID{Data1: 0x94ea2b94, Data2: 0xe9cc, Data3: 0x49e0, Data4: [8]byte{0xc0, 0xff, 0xee, 0x64, 0xca, 0x8f, 0x5b, 0x90}}
+	iidManagerStatics      = syscall.GUID{Data1: 0x50ac103f, Data2: 0xd235, Data3: 0x4598, Data4: [8]byte{0xbb, 0xef, 0x98, 0xfe, 0x4d, 0x1a, 0x3a, 0xd4}}
+	iidNotificationFactory = syscall.GUID{Data1: 0x04124b20, Data2: 0x82c6, Data3: 0x4229, Data4: [8]byte{0xb1, 0x09, 0xfd, 0x9e, 0xd4, 0x66, 0x2b, 0x53}}
+	iidXMLDocument         = syscall.GUID{Data1: 0xf7f3a506, Data2: 0x1e87, Data3: 0x42d6, Data4: [8]byte{0xbc, 0xfb, 0xb8, 0xc8, 0x09, 0xfa, 0x54, 0x94}}
+	iidXMLDocumentIO       = syscall.GUID{Data1: 0x6cd0e74e, Data2: 0xee65, Data3: 0x4489, Data4: [8]byte{0x9e, 0xbf, 0xca, 0x43, 0xe8, 0x7b, 0xa6, 0x37}}
+	iidActivatedArgs       = syscall.GUID{Data1: 0xe3bf92f3, Data2: 0xc197, Data3: 0x436f, Data4: [8]byte{0x82, 0x65, 0x06, 0x25, 0x82, 0x4f, 0x8d, 0xac}}
+
+	// TypedEventHandler<ToastNotification, IInspectable> and
+	// TypedEventHandler<ToastNotification, ToastDismissedEventArgs>.
+	iidActivatedHandler = syscall.GUID{Data1: 0xab54de2d, Data2: 0x97d9, Data3: 0x5528, Data4: [8]byte{0xb6, 0xad, 0x10, 0x5a, 0xfe, 0x15, 0x65, 0x30}}
+	iidDismissedHandler = syscall.GUID{Data1: 0x61c2402f, Data2: 0x0ed0, Data3: 0x5a18, Data4: [8]byte{0xab, 0x69, 0x59, 0xf4, 0xaa, 0x99, 0xa3, 0x68}}
+)
+
+const (
+	methodCreateToastNotifierWithID = 7
+	methodShow                      = 6
+	methodLoadXML                   = 6
+	methodCreateToastNotification   = 6
+	methodAddDismissed              = 9
+	methodAddActivated              = 11
+	methodGetArguments              = 6
+)
+
+// object is a COM interface pointer.
+type object struct {
+	vtbl *[32]uintptr
+}
+
+func (o *object) call(method int, args ...uintptr) error {
+	r, _, _ := syscall.SyscallN(o.vtbl[method], append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...)
+	if int32(r) < 0 {
+		return fmt.Errorf("notify: HRESULT %#x", uint32(r))
+	}
+	return nil
+}
+
+func (o *object) query(iid *syscall.GUID) (*object, error) {
+	var rtn *object
+	err := o.call(0, uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&rtn)))
+	return rtn, err
+}
+
+func (o *object) release() {
+	syscall.SyscallN(o.vtbl[2], uintptr(unsafe.Pointer(o)))
+}
+
+// hstring is a WinRT string.
+type hstring uintptr
+
+func newHString(s string) (hstring, error) {
+	text, err := syscall.UTF16FromString(s)
+	if err != nil {
+		return 0, err
+	}
+
+	var h hstring
+	if r, _, _ := procWindowsCreateString.Call(uintptr(unsafe.Pointer(&text[0])), uintptr(len(text)-1), uintptr(unsafe.Pointer(&h))); int32(r) < 0 {
+		return 0, fmt.Errorf("notify: create string: HRESULT %#x", uint32(r))
+	}
+	return h, nil
+}
+
+func (h hstring) String() string {
+	var length uint32
+
+	r, _, _ := procWindowsGetStringRawBuffer.Call(uintptr(h), uintptr(unsafe.Pointer(&length)))
+	if r == 0 || length == 0 {
+		return ""
+	}
+
+	return syscall.UTF16ToString(unsafe.Slice(*(**uint16)(unsafe.Pointer(&r)), length))
+}
+
+func (h hstring) free() {
+	procWindowsDeleteString.Call(uintptr(h))
+}
+
+// withString calls fn with s as WinRT string.
+func withString(s string, fn func(hstring) error) error {
+	h, err := newHString(s)
+	if err != nil {
+		return err
+	}
+	defer h.free()
+
+	return fn(h)
+}
+
+// handler is a COM event handler calling a Go function.
+type handler struct {
+	vtbl *handlerVtbl
+	refs atomic.Int32
+	iid  *syscall.GUID
+	fn   func(args *object)
+}
+
+type handlerVtbl struct {
+	queryInterface, addRef, release, invoke uintptr
+}
+
+var (
+	handlerVtblOnce sync.Once
+	handlerMethods  handlerVtbl
+
+	// handlers keeps the handlers referenced by the system alive.
+	handlers sync.Map
+)
+
+// newHandler returns a handler implementing iid with one reference.
+func newHandler(iid *syscall.GUID, fn func(args *object)) *handler {
+	handlerVtblOnce.Do(func() {
+		handlerMethods = handlerVtbl{
+			queryInterface: syscall.NewCallback(handlerQueryInterface),
+			addRef:         syscall.NewCallback(handlerAddRef),
+			release:        syscall.NewCallback(handlerRelease),
+			invoke:         syscall.NewCallback(handlerInvoke),
+		}
+	})
+
+	h := &handler{vtbl: &handlerMethods, iid: iid, fn: fn}
+	h.refs.Store(1)
+	handlers.Store(h, struct{}{})
+
+	return h
+}
+
+func handlerQueryInterface(this *handler, iid *syscall.GUID, out **handler) uintptr {
+	if *iid != *this.iid && *iid != iidUnknown && *iid != iidAgileObject {
+		*out = nil
+		return noInterface
+	}
+
+	this.refs.Add(1)
+	*out = this
+	return 0
+}
+
+func handlerAddRef(this *handler) uintptr {
+	return uintptr(this.refs.Add(1))
+}
+
+func handlerRelease(this *handler) uintptr {
+	refs := this.refs.Add(-1)
+	if refs == 0 {
+		handlers.Delete(this)
+	}
+	return uintptr(refs)
+}
+
+func handlerInvoke(this *handler, _, args *object) uintptr {
+	this.fn(args)
+	return 0
+}
+
+// release drops the reference of the creator.
+func (h *handler) release() {
+	handlerRelease(h)
+}
+
+// toasts shows toast notifications. The toast API is called from a thread
+// of its own in the multithreaded apartment, the handlers run on threads of
+// the system.
+type toasts struct {
+	calls    chan func()
+	notifier *object
+	factory  *object
+	next     atomic.Uint64
+}
+
+func newBackend() (backend, error) {
+	if err := combase.Load(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	// Unpackaged applications are identified by a registered
+	// AppUserModelID.
+	if err := setString(`Software\Classes\AppUserModelId\`+AppID, "DisplayName", AppID); err != nil {
+		return nil, fmt.Errorf("notify: register %s: %w", AppID, err)
+	}
+
+	if id, err := syscall.UTF16PtrFromString(AppID); err == nil {
+		procSetAppUserModelID.Call(uintptr(unsafe.Pointer(id)))
+	}
+
+	t := &toasts{calls: make(chan func())}
+
+	ready := make(chan error, 1)
+	go t.run(ready)
+
+	if err := <-ready; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// run initializes the toast API and runs the queued calls.
+func (t *toasts) run(ready chan<- error) {
+	runtime.LockOSThread()
+
+	if r, _, _ := procRoInitialize.Call(roInitMultithreaded); int32(r) < 0 && uint32(r) != rpcChangedMode {
+		ready <- fmt.Errorf("notify: RoInitialize: HRESULT %#x", uint32(r))
+		return
+	}
+
+	if err := t.init(); err != nil {
+		ready <- fmt.Errorf("%w: %w", ErrUnsupported, err)
+		return
+	}
+
+	ready <- nil
+
+	for fn := range t.calls {
+		fn()
+	}
+}
+
+func (t *toasts) init() error {
+	statics, err := factory("Windows.UI.Notifications.ToastNotificationManager", &iidManagerStatics)
+	if err != nil {
+		return err
+	}
+	defer statics.release()
+
+	err = withString(AppID, func(id hstring) error {
+		return statics.call(methodCreateToastNotifierWithID, uintptr(id), uintptr(unsafe.Pointer(&t.notifier)))
+	})
+	if err != nil {
+		return err
+	}
+
+	t.factory, err = factory("Windows.UI.Notifications.ToastNotification", &iidNotificationFactory)
+	return err
+}
+
+// factory returns the activation factory of class.
+func factory(class string, iid *syscall.GUID) (*object, error) {
+	var rtn *object
+
+	err := withString(class, func(name hstring) error {
+		r, _, _ := procRoGetActivationFactory.Call(uintptr(name), uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&rtn)))
+		if int32(r) < 0 {
+			return fmt.Errorf("notify: %s: HRESULT %#x", class, uint32(r))
+		}
+		return nil
+	})
+	return rtn, err
+}
+
+// document parses content into an XmlDocument.
+func document(content string) (*object, error) {
+	var inspectable *object
+
+	err := withString("Windows.Data.Xml.Dom.XmlDocument", func(name hstring) error {
+		r, _, _ := procRoActivateInstance.Call(uintptr(name), uintptr(unsafe.Pointer(&inspectable)))
+		if int32(r) < 0 {
+			return fmt.Errorf("notify: XmlDocument: HRESULT %#x", uint32(r))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer inspectable.release()
+
+	io, err := inspectable.query(&iidXMLDocumentIO)
+	if err != nil {
+		return nil, err
+	}
+	defer io.release()
+
+	err = withString(content, func(text hstring) error {
+		return io.call(methodLoadXML, uintptr(text))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inspectable.query(&iidXMLDocument)
+}
+
+func (t *toasts) send(n *Notification) error {
+	id := t.next.Add(1)
+
+	content, err := toastXML(n)
+	if err != nil {
+		return err
+	}
+
+	track(id, n)
+
+	done := make(chan error, 1)
+	t.calls <- func() { done <- t.show(id, content) }
+
+	if err := <-done; err != nil {
+		closed(id)
+		return err
+	}
+	return nil
+}
+
+// show creates the toast id from content and shows it.
+func (t *toasts) show(id uint64, content string) error {
+	doc, err := document(content)
+	if err != nil {
+		return err
+	}
+	defer doc.release()
+
+	var toast *object
+	if err := t.factory.call(methodCreateToastNotification, uintptr(unsafe.Pointer(doc)), uintptr(unsafe.Pointer(&toast))); err != nil {
+		return err
+	}
+	defer toast.release()
+
+	activatedHandler := newHandler(&iidActivatedHandler, func(args *object) {
+		activated(id, activation(args))
+	})
+	defer activatedHandler.release()
+
+	dismissedHandler := newHandler(&iidDismissedHandler, func(*object) {
+		closed(id)
+	})
+	defer dismissedHandler.release()
+
+	var token int64
+	if err := toast.call(methodAddActivated, uintptr(unsafe.Pointer(activatedHandler)), uintptr(unsafe.Pointer(&token))); err != nil {
+		return err
+	}
+
+	// Without it the notification is only forgotten once activated.
+	_ = toast.call(methodAddDismissed, uintptr(unsafe.Pointer(dismissedHandler)), uintptr(unsafe.Pointer(&token)))
+
+	return t.notifier.call(methodShow, uintptr(unsafe.Pointer(toast)))
+}
+
+// activation returns the index of the action a toast was activated with, -1
+// for the toast itself.
+func activation(args *object) int {
+	if args == nil {
+		return -1
+	}
+
+	event, err := args.query(&iidActivatedArgs)
+	if err != nil {
+		return -1
+	}
+	defer event.release()
+
+	var arguments hstring
+	if err := event.call(methodGetArguments, uintptr(unsafe.Pointer(&arguments))); err != nil {
+		return -1
+	}
+	defer arguments.free()
+
+	index, err := strconv.Atoi(strings.TrimPrefix(arguments.String(), "action="))
+	if err != nil {
+		return -1
+	}
+	return index
+}
+
+// toastXML returns the toast content of n.
+func toastXML(n *Notification) (string, error) {
+	var b strings.Builder
+
+	escape := func(s string) {
+		_ = xml.EscapeText(&b, []byte(s))
+	}
+
+	b.WriteString(`<toast launch="default"><visual><binding template="ToastGeneric"><text>`)
+	escape(n.Title)
+	b.WriteString(`</text>`)
+
+	if n.Body != "" {
+		b.WriteString(`<text>`)
+		escape(n.Body)
+		b.WriteString(`</text>`)
+	}
+
+	if len(n.Icon) > 0 {
+		path, err := iconFile(n.Icon)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(`<image placement="appLogoOverride" src="`)
+		escape("file:///" + filepath.ToSlash(path))
+		b.WriteString(`"/>`)
+	}
+
+	b.WriteString(`</binding></visual>`)
+
+	if len(n.Actions) > 0 {
+		b.WriteString(`<actions>`)
+		for i, action := range n.Actions {
+			b.WriteString(`<action content="`)
+			escape(action.Label)
+			fmt.Fprintf(&b, `" arguments="action=%d" activationType="foreground"/>`, i)
+		}
+		b.WriteString(`</actions>`)
+	}
+
+	b.WriteString(`</toast>`)
+	return b.String(), nil
+}
+
+// iconFile writes icon to a temporary file, toasts only show images from
+// files. Files are named by content and reused.
+func iconFile(icon []byte) (string, error) {
+	sum := sha256.Sum256(icon)
+	path := filepath.Join(os.TempDir(), "saucerw-notify-"+hex.EncodeToString(sum[:8])+".png")
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if err := os.WriteFile(path, icon, 0o644); err != nil {
+		return "", fmt.Errorf("notify: write icon: %w", err)
+	}
+	return path, nil
+}
+
+// permission reports true, toasts need no permission. Users may still turn
+// them off in the settings.
+func (t *toasts) permission(context.Context) (bool, error) {
+	return true, nil
+}
+
+// setString creates path below HKEY_CURRENT_USER and sets its REG_SZ value
+// name.
+func setString(path, name, value string) error {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+
+	var key syscall.Handle
+
+	r, _, _ := procRegCreateKey.Call(uintptr(syscall.HKEY_CURRENT_USER), uintptr(unsafe.Pointer(p)), 0, 0, 0,
+		uintptr(syscall.KEY_WRITE), 0, uintptr(unsafe.Pointer(&key)), 0)
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	defer syscall.RegCloseKey(key)
+
+	n, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := syscall.UTF16FromString(value)
+	if err != nil {
+		return err
+	}
+
+	r, _, _ = procRegSetValue.Call(uintptr(key), uintptr(unsafe.Pointer(n)), 0, uintptr(syscall.REG_SZ),
+		uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)*2))
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package saucerw
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"path/filepath"
+
+	"github.com/aperturerobotics/saucer/saucerw/pdf"
+)
+
+// The zoom factors SetZoom accepts, the range all backends support.
+const (
+	MinZoom = 0.25
+	MaxZoom = 5
+)
+
+// Zoom returns the zoom factor of the page, 1 being its natural size.
+func (v *Webview) Zoom() float64 {
+	return v.native.Zoom()
+}
+
+// SetZoom scales the page by factor, clamped to MinZoom and MaxZoom.
+func (v *Webview) SetZoom(factor float64) {
+	v.native.SetZoom(min(max(factor, MinZoom), MaxZoom))
+}
+
+// PrintOptions configures Webview.Print.
+type PrintOptions struct {
+	// Path, if non-empty, is the file the page is saved to as PDF. Otherwise
+	// the print dialog of the system is shown.
+	Path string
+	// Landscape prints in landscape rather than portrait orientation.
+	Landscape bool
+	// Background prints the background colors and images of the page.
+	Background bool
+}
+
+// Print prints the page, waiting like the methods of CookieStore. Saving a
+// PDF returns once the file was written, showing the dialog once the user
+// closed it, whether they printed or not.
+//
+// The Qt backend cannot show the print dialog, Print returns an error
+// wrapping ErrUnsupported. The WebView2 backend returns as soon as its dialog
+// is shown and ignores Landscape and Background for it.
+func (v *Webview) Print(ctx context.Context, opts PrintOptions) error {
+	if opts.Path != "" {
+		path, err := filepath.Abs(opts.Path)
+		if err != nil {
+			return fmt.Errorf("saucerw: print: %w", err)
+		}
+		opts.Path = path
+	}
+
+	return waitDone(ctx, v.window.app, func(done func(error)) { v.native.Print(opts, done) })
+}
+
+// SavePDF lays the page out with opts and writes it to path as PDF, waiting
+// like the methods of CookieStore. The engines report no progress, SavePDF
+// returns once the file was written or with the error that stopped it.
+func (v *Webview) SavePDF(ctx context.Context, path string, opts pdf.Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("saucerw: save pdf: %w", err)
+	}
+
+	return waitDone(ctx, v.window.app, func(done func(error)) { v.native.SavePDF(path, opts, done) })
+}
+
+// CaptureImage takes a screenshot of the visible part of the page, waiting like
+// the methods of CookieStore. If rect is not empty, the screenshot is cropped
+// to it. The screenshot is in device pixels, on high density displays it is
+// larger than the webview in window coordinates.
+func (v *Webview) CaptureImage(ctx context.Context, rect image.Rectangle) (image.Image, error) {
+	if err := v.window.app.checkWait(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	ch := make(chan result, 1)
+	v.native.Capture(func(data []byte, err error) { ch <- result{data, err} })
+
+	var res result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	img, err := png.Decode(bytes.NewReader(res.data))
+	if err != nil {
+		return nil, fmt.Errorf("saucerw: capture: %w", err)
+	}
+
+	if rect.Empty() {
+		return img, nil
+	}
+
+	if rect = rect.Intersect(img.Bounds()); rect.Empty() {
+		return nil, errors.New("saucerw: capture: rectangle outside of the page")
+	}
+
+	sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("saucerw: capture: cannot crop %T", img)
+	}
+	return sub.SubImage(rect), nil
+}
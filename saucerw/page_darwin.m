@@ -0,0 +1,119 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <WebKit/WebKit.h>
+
+#include "native.h"
+
+// Implemented in cookies_darwin.m
+WKWebView *saucerw_cocoa_webview(const void *impl);
+
+// SaucerwPrintDelegate reports the end of a print operation to its handle.
+@interface SaucerwPrintDelegate : NSObject
+@property(nonatomic) uintptr_t handle;
+@end
+
+@implementation SaucerwPrintDelegate
+- (void)printOperationDidRun:(NSPrintOperation *)operation success:(BOOL)success contextInfo:(void *)info
+{
+    // Balances the retain keeping the delegate alive while printing
+    CFBridgingRelease(info);
+
+    // A cancelled dialog is no failure
+    saucerwDone(self.handle, success || operation.showsPrintPanel ? NULL : "writing the PDF failed");
+}
+@end
+
+double saucerw_cocoa_zoom(const void *webview)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+    return native ? native.pageZoom : 1;
+}
+
+void saucerw_cocoa_set_zoom(const void *webview, double factor)
+{
+    saucerw_cocoa_webview(webview).pageZoom = factor;
+}
+
+void saucerw_cocoa_print(const void *webview, const saucerw_print_options *options, uintptr_t handle)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+
+    if (!native)
+    {
+        saucerwDone(handle, "webview not found");
+        return;
+    }
+
+    if (@available(macOS 13.3, *))
+    {
+        native.configuration.preferences.shouldPrintBackgrounds = options->background;
+    }
+
+    NSPrintInfo *info = [NSPrintInfo.sharedPrintInfo copy];
+
+    // AppKit measures in points, the paper is set before the orientation rotates it
+    if (options->width)
+    {
+        info.paperSize    = NSMakeSize(options->width * 72, options->height * 72);
+        info.topMargin    = options->top * 72;
+        info.rightMargin  = options->right * 72;
+        info.bottomMargin = options->bottom * 72;
+        info.leftMargin   = options->left * 72;
+    }
+
+    info.orientation = options->landscape ? NSPaperOrientationLandscape : NSPaperOrientationPortrait;
+
+    if (options->path)
+    {
+        info.jobDisposition                  = NSPrintSaveJob;
+        info.dictionary[NSPrintJobSavingURL] = [NSURL fileURLWithPath:[NSString stringWithUTF8String:options->path]];
+    }
+
+    NSPrintOperation *operation = [native printOperationWithPrintInfo:info];
+
+    operation.showsPrintPanel    = !options->path;
+    operation.showsProgressPanel = !options->path;
+
+    // The operation prints nothing unless its view has a size
+    operation.view.frame = native.bounds;
+
+    SaucerwPrintDelegate *delegate = [SaucerwPrintDelegate new];
+    delegate.handle                = handle;
+
+    [operation runOperationModalForWindow:native.window
+                                 delegate:delegate
+                           didRunSelector:@selector(printOperationDidRun:success:contextInfo:)
+                              contextInfo:(__bridge_retained void *)delegate];
+}
+
+void saucerw_cocoa_capture(const void *webview, uintptr_t handle)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+
+    if (!native)
+    {
+        saucerwCapture(handle, NULL, 0, "webview not found");
+        return;
+    }
+
+    [native takeSnapshotWithConfiguration:nil
+                        completionHandler:^(NSImage *image, NSError *error) {
+                          if (!image)
+                          {
+                              saucerwCapture(handle, NULL, 0, (char *)error.localizedDescription.UTF8String);
+                              return;
+                          }
+
+                          NSBitmapImageRep *bitmap = [NSBitmapImageRep imageRepWithData:image.TIFFRepresentation];
+                          NSData *png = [bitmap representationUsingType:NSBitmapImageFileTypePNG properties:@{}];
+
+                          if (!png)
+                          {
+                              saucerwCapture(handle, NULL, 0, "encoding the screenshot failed");
+                              return;
+                          }
+
+                          saucerwCapture(handle, (uint8_t *)png.bytes, png.length, NULL);
+                        }];
+}
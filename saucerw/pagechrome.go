@@ -0,0 +1,458 @@
+package saucerw
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// pageChromeIconSize is the largest edge of the favicons pageChromeScript
+// reports, the larger are scaled down.
+const pageChromeIconSize = 256
+
+// maxPageChromeIcon bounds the PNG data of a reported favicon.
+const maxPageChromeIcon = 1 << 20
+
+// pageChromeScript reports the title, the favicon and the theme-color of the
+// top-level page whenever they change. The favicon is drawn to a canvas to
+// report it as PNG, which fails for cross-origin icons served without CORS
+// headers, and the theme color is resolved to RGBA the same way.
+var pageChromeScript = fmt.Sprintf(`
+(() =>
+{
+    if (window !== window.top)
+    {
+        return;
+    }
+
+    const limit = %d;
+    const last  = {};
+
+    let iconURL = null, loading = 0, scheduled = false;
+
+    const post = (changes) =>
+    {
+        try
+        {
+            window.saucer.internal.post(JSON.stringify({ ["saucer:chrome"]: changes }));
+        } catch (e)
+        {
+        }
+    };
+
+    const favicon = () =>
+    {
+        let best = null, size = -1;
+
+        for (const link of document.querySelectorAll("link[rel~='icon' i][href]"))
+        {
+            const sizes = (link.getAttribute("sizes") ?? "").toLowerCase().split(/\s+/);
+            const value = sizes.includes("any") ? Infinity : Math.max(0, ...sizes.map((s) => parseInt(s, 10) || 0));
+
+            // Later links win ties, as in browsers
+            if (value >= size)
+            {
+                best = link.href;
+                size = value;
+            }
+        }
+
+        if (!best && /^https?:$/.test(location.protocol))
+        {
+            best = new URL("/favicon.ico", location.href).href;
+        }
+
+        return best;
+    };
+
+    const draw = (url) => new Promise((resolve) =>
+    {
+        const image = new Image();
+
+        image.crossOrigin = "anonymous";
+        image.onerror     = () => resolve("");
+        image.onload      = () =>
+        {
+            const width  = image.naturalWidth || limit;
+            const height = image.naturalHeight || limit;
+            const scale  = Math.min(1, limit / Math.max(width, height));
+            const canvas = document.createElement("canvas");
+
+            canvas.width  = Math.max(1, Math.round(width * scale));
+            canvas.height = Math.max(1, Math.round(height * scale));
+
+            try
+            {
+                canvas.getContext("2d").drawImage(image, 0, 0, canvas.width, canvas.height);
+                resolve(canvas.toDataURL("image/png"));
+            } catch (e)
+            {
+                resolve("");
+            }
+        };
+
+        image.src = url;
+    });
+
+    const rgba = (value) =>
+    {
+        const canvas = document.createElement("canvas");
+        canvas.width = canvas.height = 1;
+
+        const context     = canvas.getContext("2d");
+        context.fillStyle = "rgba(0, 0, 0, 0)";
+        context.fillStyle = value;
+        context.fillRect(0, 0, 1, 1);
+
+        const data = Array.from(context.getImageData(0, 0, 1, 1).data);
+        return data[3] ? data : [];
+    };
+
+    const themeColor = () =>
+    {
+        for (const meta of document.querySelectorAll("meta[name='theme-color' i][content]"))
+        {
+            const media = meta.getAttribute("media");
+
+            if (!media || matchMedia(media).matches)
+            {
+                return rgba(meta.content.trim());
+            }
+        }
+
+        return [];
+    };
+
+    const scan = () =>
+    {
+        scheduled     = false;
+        const changes = {};
+
+        const title = document.title;
+        if (title !== last.title)
+        {
+            changes.title = last.title = title;
+        }
+
+        const color = themeColor();
+        if (color.join() !== last.color)
+        {
+            last.color    = color.join();
+            changes.color = color;
+        }
+
+        const url = favicon();
+        if (url !== iconURL)
+        {
+            iconURL     = url;
+            const token = ++loading;
+
+            (url ? draw(url) : Promise.resolve("")).then((icon) =>
+            {
+                if (token === loading && icon !== last.icon)
+                {
+                    last.icon = icon;
+                    post({ icon });
+                }
+            });
+        }
+
+        if (Object.keys(changes).length)
+        {
+            post(changes);
+        }
+    };
+
+    const schedule = () =>
+    {
+        if (!scheduled)
+        {
+            scheduled = true;
+            setTimeout(scan, 0);
+        }
+    };
+
+    const observe = () =>
+    {
+        new MutationObserver(schedule).observe(document.head ?? document.documentElement, {
+            subtree: true,
+            childList: true,
+            characterData: true,
+            attributes: true,
+            attributeFilter: ["href", "rel", "sizes", "name", "content", "media"],
+        });
+
+        scan();
+    };
+
+    matchMedia("(prefers-color-scheme: dark)").addEventListener("change", schedule);
+
+    if (document.readyState === "loading")
+    {
+        document.addEventListener("DOMContentLoaded", observe, { once: true });
+    }
+    else
+    {
+        observe();
+    }
+})();
+`, pageChromeIconSize)
+
+// PageChrome is the title, favicon and theme color the page of a webview
+// declares, see Webview.OnPageChrome.
+type PageChrome struct {
+	// Title is the document title.
+	Title string
+	// Icon is the favicon as PNG data, scaled to at most 256 pixels, nil if
+	// the page has none or it cannot be read.
+	Icon []byte
+	// ThemeColor is the content of the theme-color meta element matching
+	// the color scheme, the zero Color without one.
+	ThemeColor Color
+}
+
+// MirrorOptions selects what a webview mirrors from its page onto its
+// window, see Webview.OnPageChrome. The zero value mirrors everything.
+type MirrorOptions struct {
+	// DisableTitle keeps the window title from following the page title.
+	DisableTitle bool
+	// DisableIcon keeps the window icon from following the favicon.
+	DisableIcon bool
+	// DisableThemeColor keeps the title bar color from following the
+	// theme-color of the page.
+	DisableThemeColor bool
+}
+
+// chromeParts selects the parts of a PageChrome.
+type chromeParts uint8
+
+const (
+	chromeTitle chromeParts = 1 << iota
+	chromeIcon
+	chromeColor
+
+	chromeAll = chromeTitle | chromeIcon | chromeColor
+)
+
+// parts returns the parts the options mirror.
+func (o MirrorOptions) parts() chromeParts {
+	rtn := chromeAll
+	if o.DisableTitle {
+		rtn &^= chromeTitle
+	}
+	if o.DisableIcon {
+		rtn &^= chromeIcon
+	}
+	if o.DisableThemeColor {
+		rtn &^= chromeColor
+	}
+	return rtn
+}
+
+// pageChromeMessage is a change posted by pageChromeScript, the fields left
+// out did not change.
+type pageChromeMessage struct {
+	Title *string `json:"title"`
+	Icon  *string `json:"icon"`
+	Color []int   `json:"color"`
+}
+
+// pageChrome is the PageChrome of a webview, the parts the page reported
+// and its MirrorOptions.
+type pageChrome struct {
+	emitter[PageChrome]
+
+	mu       sync.Mutex
+	current  PageChrome
+	reported chromeParts
+	opts     MirrorOptions
+}
+
+// pngSignature starts every PNG file.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// report applies the change posted as data and mirrors it onto the window of
+// v.
+func (p *pageChrome) report(v *Webview, data json.RawMessage) {
+	var msg pageChromeMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log().Debug("ignoring malformed page chrome", "component", "saucerw", "error", err)
+		return
+	}
+
+	var changed chromeParts
+	var icon []byte
+	if msg.Icon != nil {
+		var err error
+		if icon, err = decodeIconURL(*msg.Icon); err != nil {
+			log().Debug("ignoring favicon", "component", "saucerw", "error", err)
+		}
+		changed |= chromeIcon
+	}
+
+	p.mu.Lock()
+	if msg.Title != nil {
+		p.current.Title = *msg.Title
+		changed |= chromeTitle
+	}
+	if msg.Icon != nil {
+		p.current.Icon = icon
+	}
+	if msg.Color != nil {
+		p.current.ThemeColor = Color{}
+		if len(msg.Color) == 4 {
+			p.current.ThemeColor = Color{R: channel(msg.Color[0]), G: channel(msg.Color[1]), B: channel(msg.Color[2]), A: channel(msg.Color[3])}
+		}
+		changed |= chromeColor
+	}
+	p.reported |= changed
+	current, mirrored := p.current, p.opts.parts()
+	p.mu.Unlock()
+
+	if changed == 0 {
+		return
+	}
+
+	v.window.mirror.apply(v.window, current, changed&mirrored)
+	p.emit(current)
+}
+
+// channel clamps a color channel posted by pageChromeScript.
+func channel(value int) uint8 {
+	return uint8(min(max(value, 0), 255))
+}
+
+// decodeIconURL returns the PNG data of a data: URL posted by
+// pageChromeScript, nil if it is empty.
+func decodeIconURL(url string) ([]byte, error) {
+	if url == "" {
+		return nil, nil
+	}
+
+	payload, ok := strings.CutPrefix(url, "data:image/png;base64,")
+	if !ok {
+		return nil, errors.New("saucerw: favicon is not a PNG data URL")
+	}
+	if base64.StdEncoding.DecodedLen(len(payload)) > maxPageChromeIcon {
+		return nil, fmt.Errorf("saucerw: favicon exceeds %d bytes", maxPageChromeIcon)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("saucerw: favicon: %w", err)
+	}
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("saucerw: favicon is not a PNG")
+	}
+	return data, nil
+}
+
+// windowMirror is the state of a window mirroring its pages: the parts the
+// application pinned by setting them itself and the PageChrome last mirrored.
+type windowMirror struct {
+	mu     sync.Mutex
+	pinned chromeParts
+	page   PageChrome
+	known  chromeParts
+}
+
+// apply mirrors the parts of page onto w, except those pinned by the
+// application.
+func (m *windowMirror) apply(w *Window, page PageChrome, parts chromeParts) {
+	m.mu.Lock()
+	if parts&chromeTitle != 0 {
+		m.page.Title = page.Title
+	}
+	if parts&chromeIcon != 0 {
+		m.page.Icon = page.Icon
+	}
+	if parts&chromeColor != 0 {
+		m.page.ThemeColor = page.ThemeColor
+	}
+	m.known |= parts
+	parts &^= m.pinned
+	m.mu.Unlock()
+
+	// Unlocked, the backend may wait for the event loop thread
+	setChrome(w, page, parts)
+}
+
+// setChrome sets parts of page on w. Empty titles and missing icons leave the
+// window as is.
+func setChrome(w *Window, page PageChrome, parts chromeParts) {
+	if parts&chromeTitle != 0 && page.Title != "" {
+		w.native.SetTitle(page.Title)
+	}
+	if parts&chromeIcon != 0 && page.Icon != nil {
+		if err := w.native.SetIcon(page.Icon); err != nil {
+			log().Debug("failed to set favicon", "component", "saucerw", "error", err)
+		}
+	}
+	if parts&chromeColor != 0 {
+		w.native.SetTitleBarColor(page.ThemeColor)
+	}
+}
+
+// pin stops mirroring parts onto the window, the application set them.
+func (m *windowMirror) pin(parts chromeParts) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pinned |= parts
+}
+
+// FollowPage drops the title, icon and title bar color set through SetTitle,
+// SetIcon and SetTitleBarColor, which pin them, so that the window mirrors
+// them from its webviews again, see Webview.OnPageChrome. What the pages
+// declared meanwhile is applied right away.
+func (w *Window) FollowPage() {
+	m := &w.mirror
+
+	m.mu.Lock()
+	parts := m.pinned & m.known
+	m.pinned = 0
+	page := m.page
+	m.mu.Unlock()
+
+	setChrome(w, page, parts)
+}
+
+// OnPageChrome calls fn when the title, favicon or theme-color of the page
+// changed, with all three. Unless disabled by MirrorOptions, the webview
+// mirrors them onto its window: the title, the window and task bar icon and,
+// on Windows 11 and macOS, the title bar color. Parts the application set on
+// the window itself are not overwritten, see Window.FollowPage. When several
+// webviews of a window mirror, the last change wins.
+func (v *Webview) OnPageChrome(fn func(PageChrome)) *Subscription {
+	return v.chrome.subscribe(fn)
+}
+
+// PageChrome returns the title, favicon and theme color last reported by the
+// page.
+func (v *Webview) PageChrome() PageChrome {
+	v.chrome.mu.Lock()
+	defer v.chrome.mu.Unlock()
+
+	rtn := v.chrome.current
+	rtn.Icon = slices.Clone(rtn.Icon)
+	return rtn
+}
+
+// SetMirror replaces the MirrorOptions of the webview, see
+// WebviewOptions.Mirror. Parts enabled again are mirrored right away.
+func (v *Webview) SetMirror(opts MirrorOptions) {
+	v.chrome.mu.Lock()
+	enabled := opts.parts() &^ v.chrome.opts.parts() & v.chrome.reported
+	v.chrome.opts = opts
+	current := v.chrome.current
+	v.chrome.mu.Unlock()
+
+	if enabled != 0 {
+		v.window.mirror.apply(v.window, current, enabled)
+	}
+}
@@ -0,0 +1,231 @@
+package saucerw
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// pageErrorLimit is the number of errors a page reports per second, errors
+// beyond it are counted in PageError.Dropped.
+const pageErrorLimit = 10
+
+// pageErrorScript forwards the uncaught errors, unhandled rejections and
+// Content-Security-Policy violations of the page to the bridge.
+var pageErrorScript = fmt.Sprintf(`
+(() =>
+{
+    const limit = %d;
+    let start = 0, sent = 0, dropped = 0;
+
+    const report = (error) =>
+    {
+        const now = Date.now();
+
+        if (now - start >= 1000)
+        {
+            start = now;
+            sent = 0;
+        }
+
+        if (sent >= limit)
+        {
+            dropped++;
+            return;
+        }
+
+        sent++;
+
+        try
+        {
+            window.saucer.internal.post(JSON.stringify({ ["saucer:error"]: { ...error, url: location.href, dropped } }));
+            dropped = 0;
+        } catch (e)
+        {
+        }
+    };
+
+    const describe = (value) =>
+    {
+        if (value instanceof Error)
+        {
+            return { message: String(value.message), stack: String(value.stack ?? "") };
+        }
+
+        if (typeof value === "string")
+        {
+            return { message: value, stack: "" };
+        }
+
+        try
+        {
+            return { message: JSON.stringify(value) ?? String(value), stack: "" };
+        } catch (e)
+        {
+            return { message: String(value), stack: "" };
+        }
+    };
+
+    window.addEventListener("error", (event) =>
+    {
+        const { message, stack } = describe(event.error ?? event.message);
+        report({ kind: "error", message: event.message || message, stack, source: event.filename ?? "", line: event.lineno ?? 0, column: event.colno ?? 0 });
+    });
+
+    window.addEventListener("unhandledrejection", (event) =>
+    {
+        report({ kind: "unhandledrejection", ...describe(event.reason) });
+    });
+
+    document.addEventListener("securitypolicyviolation", (event) =>
+    {
+        const directive = event.effectiveDirective || event.violatedDirective;
+        report({
+            kind: "csp",
+            message: "Content-Security-Policy " + directive + " blocked " + (event.blockedURI || "inline"),
+            source: event.sourceFile ?? "",
+            line: event.lineNumber ?? 0,
+            column: event.columnNumber ?? 0,
+            directive,
+            blocked: event.blockedURI ?? "",
+            sample: event.sample ?? "",
+        });
+    });
+})();
+`, pageErrorLimit)
+
+// PageErrorKind is the origin of a PageError.
+type PageErrorKind string
+
+const (
+	// PageErrorUncaught is an exception no script caught.
+	PageErrorUncaught PageErrorKind = "error"
+	// PageErrorRejection is a rejected promise without a rejection handler.
+	PageErrorRejection PageErrorKind = "unhandledrejection"
+	// PageErrorCSP is a resource or script blocked by the
+	// Content-Security-Policy of the page.
+	PageErrorCSP PageErrorKind = "csp"
+)
+
+// PageError is an error of a page, see Webview.OnPageError.
+type PageError struct {
+	Kind PageErrorKind
+	// Message is the message of the error, the JSON encoding of the
+	// rejection reason unless it is an Error or a string.
+	Message string
+	// Source is the URL of the script raising the error or violating the
+	// policy, Line and Column its position. They are empty for rejections.
+	Source string
+	Line   int
+	Column int
+	// Stack is the stack trace of the error, if it is an Error.
+	Stack string
+	// URL is the URL of the page.
+	URL string
+	// Directive is the violated directive of a PageErrorCSP, e.g.
+	// "script-src-elem", Blocked the blocked URL or "inline" and Sample the
+	// start of the blocked inline code, if the policy has 'report-sample'.
+	Directive string
+	Blocked   string
+	Sample    string
+	// Dropped counts the errors dropped since the previous report, a page
+	// reports at most 10 errors per second.
+	Dropped int
+}
+
+// pageErrorMessage is a PageError posted by pageErrorScript.
+type pageErrorMessage struct {
+	Kind      PageErrorKind `json:"kind"`
+	Message   string        `json:"message"`
+	Source    string        `json:"source"`
+	Line      int           `json:"line"`
+	Column    int           `json:"column"`
+	Stack     string        `json:"stack"`
+	URL       string        `json:"url"`
+	Directive string        `json:"directive"`
+	Blocked   string        `json:"blocked"`
+	Sample    string        `json:"sample"`
+	Dropped   int           `json:"dropped"`
+}
+
+// pageErrors applies the rate limit of pageErrorScript to the posted errors,
+// which pages can bypass, and the toggle of Webview.SetPageErrorReporting.
+type pageErrors struct {
+	emitter[PageError]
+
+	mu       sync.Mutex
+	disabled bool
+	start    time.Time
+	sent     int
+	dropped  int
+}
+
+// report emits the error posted as data.
+func (p *pageErrors) report(data json.RawMessage) {
+	var msg pageErrorMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log().Debug("ignoring malformed page error", "component", "saucerw", "error", err)
+		return
+	}
+
+	switch msg.Kind {
+	case PageErrorUncaught, PageErrorRejection, PageErrorCSP:
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	if p.disabled {
+		p.mu.Unlock()
+		return
+	}
+	if now := time.Now(); now.Sub(p.start) >= time.Second {
+		p.start, p.sent = now, 0
+	}
+	if p.sent >= pageErrorLimit {
+		p.dropped += 1 + max(msg.Dropped, 0)
+		p.mu.Unlock()
+		return
+	}
+	p.sent++
+	dropped := p.dropped + max(msg.Dropped, 0)
+	p.dropped = 0
+	p.mu.Unlock()
+
+	log().Debug("page error", "component", "saucerw", "kind", string(msg.Kind), "message", msg.Message, "source", msg.Source, "line", msg.Line, "url", msg.URL)
+
+	p.emit(PageError{
+		Kind:      msg.Kind,
+		Message:   msg.Message,
+		Source:    msg.Source,
+		Line:      msg.Line,
+		Column:    msg.Column,
+		Stack:     msg.Stack,
+		URL:       msg.URL,
+		Directive: msg.Directive,
+		Blocked:   msg.Blocked,
+		Sample:    msg.Sample,
+		Dropped:   dropped,
+	})
+}
+
+// OnPageError calls fn for the uncaught exceptions, unhandled promise
+// rejections and Content-Security-Policy violations of the pages, e.g. to
+// forward them to an error tracker. They are logged at debug level with the
+// logger set by SetLogger as well.
+//
+// Errors of cross-origin scripts come without message, source and stack
+// unless the page loads them with the crossorigin attribute.
+func (v *Webview) OnPageError(fn func(PageError)) *Subscription {
+	return v.pageErrors.subscribe(fn)
+}
+
+// SetPageErrorReporting enables or disables reporting the errors of the
+// pages, see OnPageError. It is enabled by default.
+func (v *Webview) SetPageErrorReporting(enabled bool) {
+	v.pageErrors.mu.Lock()
+	defer v.pageErrors.mu.Unlock()
+
+	v.pageErrors.disabled = !enabled
+}
@@ -0,0 +1,171 @@
+package saucerw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// funcScript adds window.saucer.internal.func, which calls the function at a
+// path of properties of window with its parent as this. Waiting for it to
+// be defined polls the path until Go cancels the wait.
+const funcScript = `
+window.saucer.internal.waiting = new Map();
+
+window.saucer.internal.func = async (path, args, wait) =>
+{
+    const waiting = window.saucer.internal.waiting;
+
+    const lookup = () =>
+    {
+        let self  = undefined;
+        let value = window;
+
+        for (const key of path)
+        {
+            if (value === undefined || value === null)
+            {
+                return undefined;
+            }
+
+            self  = value;
+            value = value[key];
+        }
+
+        return typeof value === "function" ? { fn: value, self } : undefined;
+    };
+
+    let found = lookup();
+
+    if (!found && wait !== null)
+    {
+        found = await new Promise((resolve, reject) =>
+        {
+            const timer = setInterval(() =>
+            {
+                const fn = lookup();
+
+                if (fn)
+                {
+                    clearInterval(timer);
+                    waiting.delete(wait);
+                    resolve(fn);
+                }
+            }, 50);
+
+            waiting.set(wait, () =>
+            {
+                clearInterval(timer);
+                waiting.delete(wait);
+                reject(new Error("Canceled"));
+            });
+        });
+    }
+
+    if (!found)
+    {
+        const error = new Error(path.join(".") + " is not a function");
+        error.code  = "saucer:undefined";
+
+        throw error;
+    }
+
+    return found.fn.apply(found.self, args);
+};
+`
+
+// ErrUndefined is returned by PageFunc.Call if the page did not define the
+// function.
+var ErrUndefined = errors.New("saucerw: page function is not defined")
+
+// waits numbers the calls waiting for their function to be defined.
+var waits atomic.Uint64
+
+// NoArgs is the argument of page functions taking none, see Func.
+type NoArgs struct{}
+
+// FuncOptions configures a PageFunc.
+type FuncOptions struct {
+	// Timeout bounds each call, including the wait for the function, if
+	// non-zero.
+	Timeout time.Duration
+	// Wait waits for the page to define the function, e.g. until a
+	// single-page application registered its handlers, instead of failing
+	// with ErrUndefined.
+	Wait bool
+}
+
+// PageFunc is a function defined by the page, callable from Go with typed
+// arguments and results, see Func.
+type PageFunc[A, R any] struct {
+	view *Webview
+	name string
+	path []byte
+	opts FuncOptions
+}
+
+// Func returns a stub calling the function of the page at name, a path of
+// properties of window like "appApi.refresh", which receives the JSON
+// encoding of the argument A, nothing for NoArgs, and whose result, or that
+// of the Promise it returns, is decoded into R:
+//
+//	refresh := saucerw.Func[Filter, int](view, "appApi.refresh", saucerw.FuncOptions{Timeout: 5 * time.Second})
+//	count, err := refresh.Call(ctx, Filter{Tag: "inbox"})
+//
+// The function is looked up on every call, so the page may define it after
+// Func was called or redefine it.
+func Func[A, R any](v *Webview, name string, opts FuncOptions) *PageFunc[A, R] {
+	path, _ := json.Marshal(strings.Split(name, "."))
+	return &PageFunc[A, R]{view: v, name: name, path: path, opts: opts}
+}
+
+// Name returns the path of the function.
+func (f *PageFunc[A, R]) Name() string {
+	return f.name
+}
+
+// Call calls the function with arg and returns its result. It fails with
+// ErrUndefined if the page did not define the function, unless
+// FuncOptions.Wait is set, with a *CallError with Method Name if the
+// function threw, and with ctx.Err() if ctx is done or the timeout passed
+// before the page answered.
+func (f *PageFunc[A, R]) Call(ctx context.Context, arg A) (R, error) {
+	var rtn R
+
+	args := []any{arg}
+	if _, ok := any(arg).(NoArgs); ok {
+		args = []any{}
+	}
+
+	params, err := json.Marshal(args)
+	if err != nil {
+		return rtn, err
+	}
+
+	if f.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.opts.Timeout)
+		defer cancel()
+	}
+
+	wait := "null"
+	if f.opts.Wait {
+		wait = fmt.Sprint(waits.Add(1))
+	}
+
+	err = f.view.eval(ctx, f.name, "", fmt.Sprintf("window.saucer.internal.func(%s, %s, %s)", f.path, params, wait), &rtn)
+
+	if f.opts.Wait && ctx.Err() != nil {
+		f.view.Execute(fmt.Sprintf("window.saucer.internal.waiting.get(%s)?.();", wait))
+	}
+
+	var ce *CallError
+	if errors.As(err, &ce) && ce.Code == "saucer:undefined" {
+		return rtn, fmt.Errorf("%w: %s", ErrUndefined, f.name)
+	}
+	return rtn, err
+}
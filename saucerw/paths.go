@@ -0,0 +1,259 @@
+package saucerw
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Paths are the directories an application keeps its files in. The
+// directories are not created until used.
+type Paths struct {
+	// Data holds the durable data, e.g. the website data of the profiles and
+	// the stores of package appdata.
+	Data string
+	// Cache holds data that can be recreated, the system may remove it.
+	Cache string
+	// Logs holds log files, see CreateLog.
+	Logs string
+}
+
+// DefaultPaths returns the directories of the application id following the
+// conventions of the system:
+//
+//   - Linux and other Unix systems: $XDG_DATA_HOME/id, $XDG_CACHE_HOME/id and
+//     $XDG_STATE_HOME/id/logs, defaulting to ~/.local/share, ~/.cache and
+//     ~/.local/state.
+//   - Windows: %APPDATA%\id, %LOCALAPPDATA%\id\Cache and
+//     %LOCALAPPDATA%\id\Logs.
+//   - macOS: ~/Library/Application Support/id, ~/Library/Caches/id and
+//     ~/Library/Logs/id.
+func DefaultPaths(id string) (Paths, error) {
+	if id == "" {
+		return Paths{}, errors.New("saucerw: application id is required")
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		data, err := os.UserConfigDir()
+		if err != nil {
+			return Paths{}, fmt.Errorf("saucerw: paths: %w", err)
+		}
+		local, err := os.UserCacheDir()
+		if err != nil {
+			return Paths{}, fmt.Errorf("saucerw: paths: %w", err)
+		}
+		return Paths{
+			Data:  filepath.Join(data, id),
+			Cache: filepath.Join(local, id, "Cache"),
+			Logs:  filepath.Join(local, id, "Logs"),
+		}, nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Paths{}, fmt.Errorf("saucerw: paths: %w", err)
+		}
+		library := filepath.Join(home, "Library")
+		return Paths{
+			Data:  filepath.Join(library, "Application Support", id),
+			Cache: filepath.Join(library, "Caches", id),
+			Logs:  filepath.Join(library, "Logs", id),
+		}, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Paths{}, fmt.Errorf("saucerw: paths: %w", err)
+	}
+	return Paths{
+		Data:  filepath.Join(xdgDir("XDG_DATA_HOME", home, ".local", "share"), id),
+		Cache: filepath.Join(xdgDir("XDG_CACHE_HOME", home, ".cache"), id),
+		Logs:  filepath.Join(xdgDir("XDG_STATE_HOME", home, ".local", "state"), id, "logs"),
+	}, nil
+}
+
+// xdgDir returns the XDG base directory of the environment variable env, the
+// elements below home if it is unset or not absolute, as the specification
+// requires.
+func xdgDir(env, home string, elem ...string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(append([]string{home}, elem...)...)
+}
+
+// PortablePaths returns the directories "data", "cache" and "logs" next to
+// the executable, keeping an application and its files in one directory,
+// e.g. on a removable drive.
+func PortablePaths() (Paths, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return Paths{}, fmt.Errorf("saucerw: paths: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	dir := filepath.Dir(exe)
+	return Paths{
+		Data:  filepath.Join(dir, "data"),
+		Cache: filepath.Join(dir, "cache"),
+		Logs:  filepath.Join(dir, "logs"),
+	}, nil
+}
+
+// legacyPaths returns the directories of id of earlier releases, which kept
+// the data below os.UserConfigDir, the Data directory outside of Linux and
+// other Unix systems.
+func legacyPaths(id string) Paths {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return Paths{}
+	}
+	return Paths{Data: filepath.Join(dir, id)}
+}
+
+// migratePaths migrates the directories of the previous ids of opts and of
+// earlier releases to the directories of paths left to their defaults. The
+// first existing directory wins, newer ones are tried first.
+func migratePaths(paths Paths, opts AppOptions) error {
+	if opts.Portable {
+		return nil
+	}
+
+	if opts.Paths.Data != "" {
+		paths.Data = ""
+	}
+	if opts.Paths.Cache != "" {
+		paths.Cache = ""
+	}
+	if opts.Paths.Logs != "" {
+		paths.Logs = ""
+	}
+
+	olds := []Paths{legacyPaths(opts.ID)}
+	for _, id := range opts.PreviousIDs {
+		def, err := DefaultPaths(id)
+		if err != nil {
+			return err
+		}
+		olds = append(olds, def, legacyPaths(id))
+	}
+
+	for _, old := range olds {
+		if err := paths.Migrate(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePaths returns the paths of opts: opts.Paths with its empty
+// directories resolved by PortablePaths or DefaultPaths. They stay empty if
+// that fails.
+func resolvePaths(opts AppOptions) (Paths, error) {
+	rtn := opts.Paths
+	if rtn.Data != "" && rtn.Cache != "" && rtn.Logs != "" {
+		return rtn, nil
+	}
+
+	var def Paths
+	var err error
+	if opts.Portable {
+		def, err = PortablePaths()
+	} else {
+		def, err = DefaultPaths(opts.ID)
+	}
+	if err != nil {
+		return rtn, err
+	}
+
+	if rtn.Data == "" {
+		rtn.Data = def.Data
+	}
+	if rtn.Cache == "" {
+		rtn.Cache = def.Cache
+	}
+	if rtn.Logs == "" {
+		rtn.Logs = def.Logs
+	}
+	return rtn, nil
+}
+
+// Migrate moves the directories of old to those of p, e.g. after the
+// application id changed:
+//
+//	old, _ := saucerw.DefaultPaths("com.example.old")
+//	err := paths.Migrate(old)
+//
+// A directory is moved only if it exists and its destination does not, so
+// Migrate can run on every start. Directories on different file systems are
+// copied and removed. AppOptions.PreviousIDs migrates the default paths of
+// earlier ids when the application is created.
+func (p Paths) Migrate(old Paths) error {
+	for _, dirs := range [][2]string{{old.Data, p.Data}, {old.Cache, p.Cache}, {old.Logs, p.Logs}} {
+		if err := migrateDir(dirs[0], dirs[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateDir moves the directory from to to, see Paths.Migrate.
+func migrateDir(from, to string) error {
+	if from == "" || to == "" || filepath.Clean(from) == filepath.Clean(to) {
+		return nil
+	}
+
+	if info, err := os.Stat(from); err != nil || !info.IsDir() {
+		return nil
+	}
+	if _, err := os.Lstat(to); !errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(to), 0o700); err != nil {
+		return fmt.Errorf("saucerw: migrate %s: %w", from, err)
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	// Rename fails across file systems
+	if err := os.CopyFS(to, os.DirFS(from)); err != nil {
+		os.RemoveAll(to)
+		return fmt.Errorf("saucerw: migrate %s: %w", from, err)
+	}
+	if err := os.RemoveAll(from); err != nil {
+		return fmt.Errorf("saucerw: migrate %s: %w", from, err)
+	}
+	return nil
+}
+
+// CreateLog opens the file name in the Logs directory for appending, creating
+// both, e.g. for a slog handler:
+//
+//	f, err := app.Paths().CreateLog("app.log")
+//	saucerw.SetLogger(slog.New(slog.NewTextHandler(f, nil)))
+func (p Paths) CreateLog(name string) (*os.File, error) {
+	if p.Logs == "" {
+		return nil, errors.New("saucerw: no logs directory")
+	}
+	if err := os.MkdirAll(p.Logs, 0o700); err != nil {
+		return nil, fmt.Errorf("saucerw: logs: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(p.Logs, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("saucerw: logs: %w", err)
+	}
+	return f, nil
+}
+
+// Paths returns the directories of the application, see AppOptions.Paths.
+func (a *Application) Paths() Paths {
+	return a.paths
+}
@@ -0,0 +1,109 @@
+// Package pdf describes the page layout of the PDF files written by
+// Webview.SavePDF, which lets applications use the webview as the layout
+// engine of invoices and reports.
+//
+//	err := view.SavePDF(ctx, "invoice.pdf", pdf.Options{
+//		Size:    pdf.A4,
+//		Margins: pdf.Uniform(0.5),
+//	})
+//
+// Lengths are in inches, the unit all engines support.
+package pdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Size is the width and height of a page in portrait orientation.
+type Size struct {
+	Width, Height float64
+}
+
+// Common paper sizes.
+var (
+	A3     = Size{Width: 11.69, Height: 16.54}
+	A4     = Size{Width: 8.27, Height: 11.69}
+	A5     = Size{Width: 5.83, Height: 8.27}
+	Letter = Size{Width: 8.5, Height: 11}
+	Legal  = Size{Width: 8.5, Height: 14}
+)
+
+// Orientation is the direction pages are printed in.
+type Orientation uint8
+
+const (
+	// Portrait prints pages upright, as described by their Size.
+	Portrait Orientation = iota
+	// Landscape prints pages rotated, swapping their width and height.
+	Landscape
+)
+
+// String returns "portrait" or "landscape".
+func (o Orientation) String() string {
+	switch o {
+	case Portrait:
+		return "portrait"
+	case Landscape:
+		return "landscape"
+	}
+	return fmt.Sprintf("Orientation(%d)", uint8(o))
+}
+
+// Margins are the blank space kept at the edges of a page.
+type Margins struct {
+	Top, Right, Bottom, Left float64
+}
+
+// Uniform returns margins of the same width on all edges.
+func Uniform(width float64) Margins {
+	return Margins{Top: width, Right: width, Bottom: width, Left: width}
+}
+
+// Options configure the layout of the PDF.
+type Options struct {
+	// Size is the size of the pages, A4 if zero.
+	Size Size
+	// Orientation rotates the pages.
+	Orientation Orientation
+	// Margins surround the content of every page. CSS @page rules of the
+	// document may override them.
+	Margins Margins
+	// Background prints the background colors and images of the page.
+	Background bool
+}
+
+// Page returns the page size of o in its orientation.
+func (o *Options) Page() Size {
+	size := o.Size
+	if size == (Size{}) {
+		size = A4
+	}
+
+	if o.Orientation == Landscape {
+		size.Width, size.Height = size.Height, size.Width
+	}
+	return size
+}
+
+// Validate returns an error for options no engine can lay out.
+func (o *Options) Validate() error {
+	if o.Orientation > Landscape {
+		return fmt.Errorf("pdf: invalid orientation %v", o.Orientation)
+	}
+
+	if o.Size != (Size{}) && (o.Size.Width <= 0 || o.Size.Height <= 0) {
+		return fmt.Errorf("pdf: invalid page size %vx%v", o.Size.Width, o.Size.Height)
+	}
+
+	m := o.Margins
+	if m.Top < 0 || m.Right < 0 || m.Bottom < 0 || m.Left < 0 {
+		return errors.New("pdf: margins must not be negative")
+	}
+
+	page := o.Page()
+	if m.Left+m.Right >= page.Width || m.Top+m.Bottom >= page.Height {
+		return errors.New("pdf: margins leave no room for content")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package saucerw
+
+import "sync"
+
+// Permission is a set of capabilities a page can ask for.
+type Permission uint8
+
+// The permissions of saucer, matching saucer::permission::type.
+const (
+	// PermissionAudio is access to the microphone.
+	PermissionAudio Permission = 1 << iota
+	// PermissionVideo is access to the camera.
+	PermissionVideo
+	// PermissionDesktop is capturing the screen or a window.
+	PermissionDesktop
+	// PermissionMouseLock is locking the pointer to the page.
+	PermissionMouseLock
+	// PermissionDeviceInfo is enumerating the media devices.
+	PermissionDeviceInfo
+	// PermissionLocation is the geolocation of the user.
+	PermissionLocation
+	// PermissionClipboard is reading the clipboard.
+	PermissionClipboard
+	// PermissionNotification is showing notifications.
+	PermissionNotification
+)
+
+// Has reports whether p contains all of q.
+func (p Permission) Has(q Permission) bool {
+	return p&q == q
+}
+
+// PermissionDecision answers a PermissionRequest.
+type PermissionDecision uint8
+
+const (
+	// PermissionDefault leaves the request to the next handler or, if none
+	// decides, to the backend, which may prompt the user or deny it.
+	PermissionDefault PermissionDecision = iota
+	// PermissionGrant grants the request.
+	PermissionGrant
+	// PermissionDeny denies the request.
+	PermissionDeny
+	// PermissionPending keeps the request waiting until Grant or Deny is
+	// called on it, for example after asking the user.
+	PermissionPending
+)
+
+// PermissionRequest is a request of the page for permissions.
+type PermissionRequest struct {
+	// URL is the address of the requesting page or frame.
+	URL string
+	// Types are the requested permissions, zero if the backend does not
+	// know them.
+	Types Permission
+
+	answer func(granted bool)
+}
+
+// Grant grants a request kept pending by its handler. It may be called from
+// any goroutine; only the first call to Grant or Deny has an effect.
+func (r PermissionRequest) Grant() {
+	if r.answer != nil {
+		r.answer(true)
+	}
+}
+
+// Deny denies a request kept pending by its handler, like Grant.
+func (r PermissionRequest) Deny() {
+	if r.answer != nil {
+		r.answer(false)
+	}
+}
+
+// OnPermissionRequest calls fn when the page asks for permissions, for
+// example through getUserMedia or the geolocation API. Handlers are asked in
+// the order they were registered until one returns a decision other than
+// PermissionDefault; a panicking handler denies the request.
+//
+// Permission handlers run on the event loop thread and must not block. To
+// ask the user, return PermissionPending and answer the request once the
+// user decided. A pending request must be answered eventually, the page
+// waits for it.
+func (v *Webview) OnPermissionRequest(fn func(PermissionRequest) PermissionDecision) *Subscription {
+	return v.permissions.subscribe(fn)
+}
+
+// decidePermission asks the handlers. answer is the native function granting
+// or denying the request, called only if the decision is PermissionPending.
+func (v *Webview) decidePermission(url string, types Permission, answer func(granted bool)) PermissionDecision {
+	var (
+		mu       sync.Mutex
+		decided  bool
+		answered *bool
+	)
+
+	req := PermissionRequest{URL: url, Types: types, answer: func(granted bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case answered != nil:
+		case !decided:
+			// Answered by the handler itself, applied once it returned
+			answered = &granted
+		default:
+			answered = &granted
+			answer(granted)
+		}
+	}}
+
+	rtn := v.permissions.first(req, func(d *PermissionDecision) bool { return *d != PermissionDefault }, PermissionDeny)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	decided = true
+
+	if rtn == PermissionPending && answered != nil {
+		if *answered {
+			return PermissionGrant
+		}
+		return PermissionDeny
+	}
+
+	if rtn != PermissionPending {
+		// Later answers have no effect
+		answered = new(bool)
+	}
+	return rtn
+}
@@ -0,0 +1,89 @@
+package saucerw
+
+import (
+	"context"
+	"fmt"
+)
+
+// PowerEvent is a change of the power or session state of the system, see
+// Application.OnPowerEvent.
+type PowerEvent uint8
+
+// Matches saucerw_power_event, see native.h
+const (
+	// PowerSuspend is reported before the system goes to sleep.
+	PowerSuspend PowerEvent = iota
+	// PowerResume is reported after the system woke up, e.g. to reconnect
+	// sockets that timed out meanwhile.
+	PowerResume
+	// PowerLock is reported when the screen was locked.
+	PowerLock
+	// PowerUnlock is reported when the screen was unlocked.
+	PowerUnlock
+	// PowerShutdown is reported before the system shuts down or the user
+	// logs out, see Application.OnShutdown.
+	PowerShutdown
+)
+
+func (e PowerEvent) String() string {
+	switch e {
+	case PowerSuspend:
+		return "suspend"
+	case PowerResume:
+		return "resume"
+	case PowerLock:
+		return "lock"
+	case PowerUnlock:
+		return "unlock"
+	case PowerShutdown:
+		return "shutdown"
+	}
+	return fmt.Sprintf("PowerEvent(%d)", uint8(e))
+}
+
+// OnPowerEvent calls fn whenever the system is about to sleep or woke up,
+// the screen was locked or unlocked, or the system is about to shut down,
+// e.g. to pause sync engines while the system sleeps.
+//
+// Sleep is reported by logind on Linux, screen locks by the screen saver of
+// the desktop. The Qt backend only reports PowerShutdown, when the session
+// manager asks the application to save its state.
+func (a *Application) OnPowerEvent(fn func(PowerEvent)) *Subscription {
+	return a.power.subscribe(fn)
+}
+
+// OnShutdown calls fn before the system shuts down or the user logs out, e.g.
+// to flush state to disk. The handlers are called one after another in the
+// order they were registered and the shutdown waits for them; ctx is done
+// once AppOptions.QuitTimeout passed.
+//
+// The system bounds the delay as well: logind waits InhibitDelayMaxSec, five
+// seconds by default, and Windows lets the user shut down regardless. The Qt
+// backend and WebKit on macOS cannot delay the shutdown; on macOS the
+// application is asked to quit afterwards, see OnQuitRequested.
+func (a *Application) OnShutdown(fn func(ctx context.Context)) *Subscription {
+	return a.shutdowns.subscribe(func(ctx context.Context) struct{} {
+		fn(ctx)
+		return struct{}{}
+	})
+}
+
+// powerChanged handles a power event of the system, on the event loop thread.
+func (a *Application) powerChanged(ev PowerEvent, release func()) {
+	a.power.emit(ev)
+
+	if ev != PowerShutdown {
+		return
+	}
+
+	// The handlers may need the event loop thread
+	go func() {
+		defer release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), a.quitTimeout)
+		defer cancel()
+
+		// No handler decides, so all of them are called
+		a.shutdowns.first(ctx, func(*struct{}) bool { return false }, struct{}{})
+	}()
+}
@@ -0,0 +1,47 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+
+#include "native.h"
+
+// observe adds an observer reporting event to the handler and keeps it in observers
+static void observe(NSMutableArray *observers, NSNotificationCenter *center, NSNotificationName name,
+                    uintptr_t handler, saucerw_power_event event)
+{
+    id observer = [center addObserverForName:name
+                                      object:nil
+                                       queue:NSOperationQueue.mainQueue
+                                  usingBlock:^(NSNotification *notification) {
+                                    saucerwPower(handler, event);
+                                  }];
+
+    [observers addObject:@[ center, observer ]];
+}
+
+void *saucerw_cocoa_watch_power(uintptr_t handler)
+{
+    NSMutableArray *observers = [NSMutableArray array];
+    NSNotificationCenter *workspace = NSWorkspace.sharedWorkspace.notificationCenter;
+
+    observe(observers, workspace, NSWorkspaceWillSleepNotification, handler, SAUCERW_POWER_SUSPEND);
+    observe(observers, workspace, NSWorkspaceDidWakeNotification, handler, SAUCERW_POWER_RESUME);
+    observe(observers, workspace, NSWorkspaceWillPowerOffNotification, handler, SAUCERW_POWER_SHUTDOWN);
+
+    // The screen locks are only posted by the login window, under names it does not document
+    NSNotificationCenter *distributed = NSDistributedNotificationCenter.defaultCenter;
+
+    observe(observers, distributed, @"com.apple.screenIsLocked", handler, SAUCERW_POWER_LOCK);
+    observe(observers, distributed, @"com.apple.screenIsUnlocked", handler, SAUCERW_POWER_UNLOCK);
+
+    return (__bridge_retained void *)observers;
+}
+
+void saucerw_cocoa_unwatch_power(void *watcher)
+{
+    NSArray *observers = (__bridge_transfer NSArray *)watcher;
+
+    for (NSArray *pair in observers)
+    {
+        [(NSNotificationCenter *)pair[0] removeObserver:pair[1]];
+    }
+}
@@ -0,0 +1,93 @@
+package saucerw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// profileName matches the names of profiles, which are directory names.
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Profiles returns the names of the profiles with stored website data, see
+// Preferences.Profile.
+func (a *Application) Profiles() ([]string, error) {
+	entries, err := os.ReadDir(a.profileDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("saucerw: profiles: %w", err)
+	}
+
+	var rtn []string
+	for _, entry := range entries {
+		if entry.IsDir() && profileName.MatchString(entry.Name()) {
+			rtn = append(rtn, entry.Name())
+		}
+	}
+	return rtn, nil
+}
+
+// RemoveProfile deletes the website data of the profile name, e.g. when the
+// user signs out of an account. No webview may use the profile. It waits for
+// the event loop and must not be called on its thread.
+func (a *Application) RemoveProfile(ctx context.Context, name string) error {
+	dir, err := a.profilePath(name)
+	if err != nil {
+		return err
+	}
+
+	if err := waitDone(ctx, a, func(done func(error)) { a.native.RemoveWebsiteData(dir, done) }); err != nil {
+		return fmt.Errorf("saucerw: profile %s: %w", name, err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("saucerw: profile %s: %w", name, err)
+	}
+	return nil
+}
+
+// ProfilePath returns the directory the website data of the profile name is
+// stored in, which may not exist yet, see Preferences.Profile.
+func (a *Application) ProfilePath(name string) (string, error) {
+	return a.profilePath(name)
+}
+
+// profilePath returns the directory of the profile name.
+func (a *Application) profilePath(name string) (string, error) {
+	if !profileName.MatchString(name) {
+		return "", fmt.Errorf("saucerw: invalid profile name %q, expected letters, digits, '.', '-' and '_'", name)
+	}
+	if a.profileDir == "" {
+		return "", errors.New("saucerw: profiles: no profile directory, set AppOptions.ProfileDir")
+	}
+	return filepath.Join(a.profileDir, name), nil
+}
+
+// profile points the StoragePath of p to the directory of its profile,
+// creating it.
+func (a *Application) profile(p *Preferences) error {
+	if p.Profile == "" {
+		return nil
+	}
+	if p.StoragePath != "" || p.Ephemeral {
+		return fmt.Errorf("saucerw: profile %s: cannot be combined with StoragePath or Ephemeral", p.Profile)
+	}
+
+	dir, err := a.profilePath(p.Profile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("saucerw: profile %s: %w", p.Profile, err)
+	}
+
+	p.StoragePath = dir
+	return nil
+}
@@ -0,0 +1,167 @@
+package saucerw
+
+import (
+	"context"
+	"time"
+)
+
+// defaultQuitTimeout bounds close and quit requests without
+// AppOptions.QuitTimeout.
+const defaultQuitTimeout = 10 * time.Second
+
+// QuitDecision answers a request to close a window or quit the application.
+type QuitDecision uint8
+
+const (
+	// QuitAllow lets the window close or the application quit, unless a
+	// later handler cancels it.
+	QuitAllow QuitDecision = iota
+	// QuitCancel keeps the window open and the application running, e.g.
+	// after the user chose to keep editing a document with unsaved changes.
+	QuitCancel
+)
+
+// OnQuitRequested calls fn before the application quits through
+// RequestQuit or because the user closed its last window, e.g. to flush
+// databases or stop workers. fn may block until ctx is done; once
+// AppOptions.QuitTimeout passed the application quits regardless.
+//
+// The pages of the windows are asked first, through the handlers registered
+// with window.saucer.onQuit, then the close handlers of the windows, see
+// Window.OnCloseRequested, then the quit handlers in the order they were
+// registered. The first QuitCancel keeps the application running and skips
+// the remaining handlers, so cleanup belongs in the handlers registered
+// last. A panicking handler allows quitting.
+//
+// Quit, and quitting by the system, e.g. when the user logs out, do not ask
+// the handlers.
+func (a *Application) OnQuitRequested(fn func(ctx context.Context) QuitDecision) *Subscription {
+	sub := a.quits.subscribe(fn)
+
+	a.intercept.Store(true)
+	for _, w := range a.Windows() {
+		w.interceptClose()
+	}
+	return sub
+}
+
+// RequestQuit asks the pages and handlers like OnQuitRequested describes and
+// quits unless one of them cancels. It returns immediately and does nothing
+// while a request is pending.
+func (a *Application) RequestQuit() {
+	if !a.quitting.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer a.quitting.Store(false)
+
+		if a.decide(a.Windows(), true) {
+			a.Quit()
+		}
+	}()
+}
+
+// OnCloseRequested calls fn before the window closes because the user closed
+// it, e.g. through its close button, like Application.OnQuitRequested. The
+// page of the window is asked first, see window.saucer.onQuit. Closing the
+// last window asks the quit handlers of the application as well, unless it
+// keeps running.
+//
+// Close, Destroy and kiosk mode, which blocks closing by the user, do not
+// ask the handlers.
+func (w *Window) OnCloseRequested(fn func(ctx context.Context) QuitDecision) *Subscription {
+	sub := w.closes.subscribe(fn)
+
+	w.interceptClose()
+	return sub
+}
+
+// interceptClose lets the window ask the handlers before the user closes
+// it, once any handler may be interested.
+func (w *Window) interceptClose() {
+	if w.intercepting.CompareAndSwap(false, true) {
+		w.native.InterceptClose(true)
+	}
+}
+
+// requestClose asks the handlers whether the window the user closed closes.
+func (w *Window) requestClose() {
+	if !w.requesting.CompareAndSwap(false, true) {
+		return
+	}
+	defer w.requesting.Store(false)
+
+	a := w.app
+
+	last := !a.keepRunning
+	for _, other := range a.Windows() {
+		if other != w && other.Visible() {
+			last = false
+		}
+	}
+
+	if a.decide([]*Window{w}, last) {
+		w.Close()
+	}
+}
+
+// hookQuit records that the page registered a handler with
+// window.saucer.onQuit, which the window then asks before it closes.
+func (v *Webview) hookQuit() {
+	v.quitHooks.Store(true)
+	v.window.interceptClose()
+}
+
+// askQuit asks the handlers of the page whether it may close. Errors allow
+// closing, so that a broken page cannot keep the window open.
+func (v *Webview) askQuit(ctx context.Context) bool {
+	if !v.quitHooks.Load() {
+		return true
+	}
+
+	var rtn bool
+	if err := v.Eval(ctx, "window.saucer.internal.quitRequested()", &rtn); err != nil {
+		log().Debug("page failed to answer close request", "component", "saucerw", "error", err)
+		return true
+	}
+	return rtn
+}
+
+// decide asks the pages and close handlers of windows and, if quit, the quit
+// handlers of the application. It allows closing once the quit timeout
+// passed, even if a handler is still running.
+func (a *Application) decide(windows []*Window, quit bool) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), a.quitTimeout)
+	defer cancel()
+
+	done := make(chan bool, 1)
+	go func() { done <- a.ask(ctx, windows, quit) }()
+
+	select {
+	case rtn := <-done:
+		return rtn
+	case <-ctx.Done():
+		log().Warn("quit handlers timed out", "component", "saucerw", "timeout", a.quitTimeout)
+		return true
+	}
+}
+
+// ask asks the handlers in order until one cancels.
+func (a *Application) ask(ctx context.Context, windows []*Window, quit bool) bool {
+	canceled := func(d *QuitDecision) bool { return *d == QuitCancel }
+
+	for _, w := range windows {
+		for _, v := range w.Webviews() {
+			if !v.askQuit(ctx) {
+				return false
+			}
+		}
+
+		if w.closes.first(ctx, canceled, QuitAllow) == QuitCancel {
+			return false
+		}
+	}
+
+	return !quit || a.quits.first(ctx, canceled, QuitAllow) != QuitCancel
+}
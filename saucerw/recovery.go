@@ -0,0 +1,167 @@
+package saucerw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"sync/atomic"
+	"time"
+)
+
+// GoneReason tells why the render process of a page went away.
+type GoneReason uint8
+
+// Matches saucerw_gone_reason, see native.h
+const (
+	// GoneCrashed is reported when the render process crashed.
+	GoneCrashed GoneReason = iota
+	// GoneOutOfMemory is reported when the render process exceeded its
+	// memory limit.
+	GoneOutOfMemory
+	// GoneKilled is reported when the render process was killed or exited,
+	// e.g. by the task manager.
+	GoneKilled
+	// GoneUnresponsive is reported when the page stopped responding, by
+	// WebView2 and by Webview.Watchdog.
+	GoneUnresponsive
+)
+
+func (r GoneReason) String() string {
+	switch r {
+	case GoneCrashed:
+		return "crashed"
+	case GoneOutOfMemory:
+		return "out of memory"
+	case GoneKilled:
+		return "killed"
+	case GoneUnresponsive:
+		return "unresponsive"
+	}
+	return fmt.Sprintf("GoneReason(%d)", r)
+}
+
+// RecoveryAction is how a webview recovers from the loss of its render
+// process, see Webview.OnRenderProcessGone.
+type RecoveryAction uint8
+
+const (
+	// RecoverDefault leaves the decision to the next handler. If no handler
+	// decides, the page is left as the backend shows it.
+	RecoverDefault RecoveryAction = iota
+	// RecoverNone leaves the page as the backend shows it.
+	RecoverNone
+	// RecoverReload reloads the page.
+	RecoverReload
+	// RecoverRestart creates the native webview again, see Webview.Restart.
+	RecoverRestart
+	// RecoverErrorPage replaces the page with one telling what happened and
+	// linking to the lost page.
+	RecoverErrorPage
+)
+
+// errorPage is the page shown by RecoverErrorPage, formatted with the reason
+// and the link to the lost page.
+const errorPage = `<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width">
+<title>Page stopped working</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0; height: 100vh; display: flex; flex-direction: column; align-items: center; justify-content: center; color: #444; }
+a { color: inherit; }
+</style>
+</head>
+<body>
+<h1>This page stopped working</h1>
+<p>Its process %s.</p>
+%s
+</body>
+</html>`
+
+// OnRenderProcessGone registers fn, called on the event loop thread when the
+// render process of the page crashed, was killed or stopped responding. The
+// first handler returning an action other than RecoverDefault decides how
+// the webview recovers, in the order they were registered.
+//
+// WebKit on macOS does not report its render process, use Watchdog there.
+func (v *Webview) OnRenderProcessGone(fn func(GoneReason) RecoveryAction) *Subscription {
+	return v.gone.subscribe(fn)
+}
+
+// processGone recovers from the loss of the render process.
+func (v *Webview) processGone(reason GoneReason) {
+	action := v.gone.first(reason, func(a *RecoveryAction) bool { return *a != RecoverDefault }, RecoverNone)
+
+	switch action {
+	case RecoverReload:
+		v.Reload()
+	case RecoverRestart:
+		if err := v.restart(v.window.native.(*windowHandle).current(), nil); err != nil {
+			log().Warn("recreating gone webview failed", "component", "saucerw", "reason", reason, "error", err)
+			v.showErrorPage(reason)
+		}
+	case RecoverErrorPage:
+		v.showErrorPage(reason)
+	}
+}
+
+// showErrorPage replaces the page with errorPage.
+func (v *Webview) showErrorPage(reason GoneReason) {
+	link := ""
+	if url := v.URL(); url != "" && url != "about:blank" {
+		link = fmt.Sprintf(`<p><a href="%s">Reload</a></p>`, html.EscapeString(url))
+	}
+
+	v.SetHTML(fmt.Sprintf(errorPage, html.EscapeString(reason.String()), link))
+}
+
+// Watchdog evaluates a ping in the page every interval and, if the page does
+// not answer within timeout, reports it gone with GoneUnresponsive to the
+// handlers of OnRenderProcessGone. A page that hangs is reported once, until
+// it answers again or loads another page. Pings started before a page loads
+// are not counted, as the evaluation is lost with the old page.
+//
+// The watchdog runs until the subscription is canceled or the webview is
+// released.
+func (v *Webview) Watchdog(interval, timeout time.Duration) *Subscription {
+	var loads atomic.Uint64
+	loaded := v.OnLoad(func(LoadState) { loads.Add(1) })
+
+	ctx, cancel := context.WithCancel(v.bridge.ctx)
+
+	go func() {
+		defer loaded.Cancel()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		armed := true
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			before := loads.Load()
+
+			pingCtx, stop := context.WithTimeout(ctx, timeout)
+			err := v.Eval(pingCtx, "true", nil)
+			stop()
+
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err == nil || loads.Load() != before:
+				armed = true
+			case errors.Is(err, context.DeadlineExceeded) && armed:
+				armed = false
+				v.window.app.Post(func() { v.processGone(GoneUnresponsive) })
+			}
+		}
+	}()
+
+	return &Subscription{cancel: cancel}
+}
@@ -0,0 +1,184 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/internal/goid"
+)
+
+// The environment a Driver starts the host process with.
+const (
+	addrEnv  = "SAUCERW_REMOTE"
+	tokenEnv = "SAUCERW_REMOTE_TOKEN"
+)
+
+// Main runs the host process started by a Driver with drv, usually
+// saucerw.DefaultDriver(), and exits once the application released it. It
+// has to be called from the main goroutine of the host binary:
+//
+//	func main() {
+//		remote.Main(saucerw.DefaultDriver())
+//	}
+func Main(drv saucerw.Driver) {
+	if err := connect(drv); err != nil {
+		fmt.Fprintln(os.Stderr, "remote:", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// connect connects to the application that started the process and serves
+// it.
+func connect(drv saucerw.Driver) error {
+	if drv == nil {
+		return saucerw.ErrNoDriver
+	}
+
+	addr := os.Getenv(addrEnv)
+	if addr == "" {
+		return errors.New("not started by a remote driver, " + addrEnv + " is not set")
+	}
+
+	conn, err := net.Dial("unix", addr)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(conn, os.Getenv(tokenEnv)+"\n"); err != nil {
+		conn.Close()
+		return err
+	}
+
+	return Serve(conn, drv)
+}
+
+// host serves the objects of drv.
+type host struct {
+	*peer
+	drv saucerw.Driver
+	// app is the saucerw.AppDriver, once it was created.
+	app atomic.Value
+	// running is set while the event loop runs.
+	running atomic.Bool
+}
+
+// Serve serves drv to the application on the other end of conn until it
+// releases the application or the connection fails. Like the native event
+// loop, it has to run on the main goroutine.
+func Serve(conn io.ReadWriteCloser, drv saucerw.Driver) error {
+	main := goid.New()
+
+	h := &host{drv: drv}
+	h.peer = newPeer(conn, newLoop(main, func() bool {
+		if app, ok := h.app.Load().(saucerw.AppDriver); ok && app.ThreadSafe() {
+			return true
+		}
+		return main.On()
+	}))
+
+	go h.run(h.serve)
+
+	// Stop the event loop if the application went away while it runs
+	go func() {
+		<-h.closed
+		h.loop.post(func() {
+			if app, ok := h.app.Load().(saucerw.AppDriver); ok && h.running.Load() {
+				app.Quit()
+			}
+		})
+	}()
+
+	// Before the event loop runs and after it stopped, the main goroutine
+	// runs the requests.
+	for {
+		main.Idle(true)
+		select {
+		case <-h.loop.signal:
+			main.Idle(false)
+			h.loop.drain()
+		case <-h.closed:
+			main.Idle(false)
+			// Run the requests sent before the connection was closed, e.g.
+			// releasing the application
+			h.loop.drain()
+
+			if err := h.failure(); !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// serve runs the request m on the event loop.
+func (h *host) serve(m *message) {
+	if m.Method == "" {
+		h.serveFunc(m)
+		return
+	}
+
+	var obj any = h
+	if m.Object != 0 {
+		var ok bool
+		if obj, ok = h.object(m.Object); !ok {
+			h.reply(m, nil, fmt.Errorf("remote: unknown object %d", m.Object))
+			return
+		}
+	}
+
+	fn := reflect.ValueOf(obj).MethodByName(m.Method)
+	if !fn.IsValid() {
+		h.reply(m, nil, fmt.Errorf("remote: unknown method %s", m.Method))
+		return
+	}
+
+	var hook func([]reflect.Value)
+	if app, ok := obj.(saucerw.AppDriver); ok && m.Method == "Run" {
+		hook = func(args []reflect.Value) { args[0] = reflect.ValueOf(h.started(app, args[0].Interface().(func()))) }
+	}
+
+	h.invoke(m, fn, hook)
+
+	switch m.Method {
+	case "Run":
+		// The event loop stopped, the main goroutine runs the requests again
+		h.running.Store(false)
+		h.loop.mu.Lock()
+		h.loop.wake = nil
+		h.loop.mu.Unlock()
+	case "Release", "Finish", "Reject":
+		h.forget(m.Object)
+	}
+}
+
+// NewApp creates the application, called by the Driver.
+func (h *host) NewApp(opts saucerw.AppOptions) (saucerw.AppDriver, error) {
+	app, err := h.drv.NewApp(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	h.app.Store(app)
+	return app, nil
+}
+
+// started returns the start function of app, which lets the event loop run
+// the requests once it started.
+func (h *host) started(app saucerw.AppDriver, start func()) func() {
+	return func() {
+		h.loop.mu.Lock()
+		h.loop.wake = func() { app.Post(h.loop.drain) }
+		h.loop.mu.Unlock()
+		h.running.Store(true)
+
+		h.loop.drain()
+		start()
+	}
+}
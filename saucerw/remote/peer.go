@@ -0,0 +1,657 @@
+package remote
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/internal/goid"
+)
+
+// message is a request or reply on the connection, encoded as a line of
+// JSON. Requests either call a method of an object of the host, or a function
+// the other side passed as an argument or result.
+type message struct {
+	// ID identifies a request waiting for its reply, 0 if there is none.
+	ID uint64 `json:"id,omitempty"`
+	// Reply marks the reply to the request ID.
+	Reply bool `json:"reply,omitempty"`
+	// Object is the object whose Method is called, 0 for the driver.
+	Object uint64 `json:"object,omitempty"`
+	Method string `json:"method,omitempty"`
+	// Func is the function called.
+	Func uint64 `json:"func,omitempty"`
+	// Release lists functions the other side no longer references.
+	Release []uint64 `json:"release,omitempty"`
+	// Values are the arguments of a request or the results of a reply.
+	Values []json.RawMessage `json:"values,omitempty"`
+	// Error fails a request, e.g. because the method does not exist.
+	Error string `json:"error,omitempty"`
+}
+
+// ref replaces functions and objects, which stay on the side that owns them.
+type ref struct {
+	Func   uint64 `json:"$func,omitempty"`
+	Object uint64 `json:"$object,omitempty"`
+}
+
+// wireError replaces errors, keeping the sentinel errors of saucerw
+// comparable with errors.Is.
+type wireError struct {
+	Message  string `json:"$error"`
+	Sentinel string `json:"sentinel,omitempty"`
+}
+
+// sentinels are the errors of saucerw preserved across the connection.
+var sentinels = map[string]error{
+	"unsupported": saucerw.ErrUnsupported,
+	"no-driver":   saucerw.ErrNoDriver,
+	"not-running": saucerw.ErrNotRunning,
+	"loop-thread": saucerw.ErrLoopThread,
+}
+
+// remoteError is an error of the other side.
+type remoteError struct {
+	msg      string
+	sentinel error
+}
+
+func (e *remoteError) Error() string { return e.msg }
+func (e *remoteError) Unwrap() error { return e.sentinel }
+
+var (
+	errorType       = reflect.TypeFor[error]()
+	certificateType = reflect.TypeFor[*x509.Certificate]()
+)
+
+// objectTypes are passed by reference, they are implemented by the host.
+var objectTypes = []reflect.Type{
+	reflect.TypeFor[saucerw.AppDriver](),
+	reflect.TypeFor[saucerw.WindowDriver](),
+	reflect.TypeFor[saucerw.WebviewDriver](),
+	reflect.TypeFor[saucerw.SchemeStream](),
+}
+
+// loop runs functions on the event loop of a side: the loop drains the queue,
+// and so does a call made on the loop while it waits for its reply, so that
+// requests the call causes on the other side cannot deadlock.
+type loop struct {
+	// on reports whether the caller is on the loop, which runs on goroutine.
+	on        func() bool
+	goroutine *goid.Loop
+
+	signal chan struct{}
+
+	mu    sync.Mutex
+	queue []func()
+	// wake, if set, makes the loop drain the queue.
+	wake func()
+}
+
+func newLoop(goroutine *goid.Loop, on func() bool) *loop {
+	return &loop{on: on, goroutine: goroutine, signal: make(chan struct{}, 1)}
+}
+
+// post queues fn to run on the loop.
+func (l *loop) post(fn func()) {
+	l.mu.Lock()
+	l.queue = append(l.queue, fn)
+	wake := l.wake
+	l.mu.Unlock()
+
+	select {
+	case l.signal <- struct{}{}:
+	default:
+	}
+
+	if wake != nil {
+		wake()
+	}
+}
+
+// drain runs the queued functions.
+func (l *loop) drain() {
+	for {
+		l.mu.Lock()
+		if len(l.queue) == 0 {
+			l.mu.Unlock()
+			return
+		}
+
+		fn := l.queue[0]
+		l.queue = l.queue[1:]
+		l.mu.Unlock()
+
+		fn()
+	}
+}
+
+// peer is one side of the connection.
+type peer struct {
+	loop   *loop
+	conn   io.ReadWriteCloser
+	closed chan struct{}
+	// proxy returns the proxy of the object id of the other side.
+	proxy func(id uint64, t reflect.Type) reflect.Value
+	// lost, if set, is wrapped by the error the connection failed with.
+	lost error
+
+	wmu sync.Mutex
+	enc *json.Encoder
+
+	mu      sync.Mutex
+	err     error
+	lastID  uint64
+	pending map[uint64]chan *message
+	funcs   map[uint64]reflect.Value
+	objects map[uint64]any
+}
+
+func newPeer(conn io.ReadWriteCloser, l *loop) *peer {
+	return &peer{
+		loop:    l,
+		conn:    conn,
+		closed:  make(chan struct{}),
+		enc:     json.NewEncoder(conn),
+		pending: map[uint64]chan *message{},
+		funcs:   map[uint64]reflect.Value{},
+		objects: map[uint64]any{},
+	}
+}
+
+// run reads messages until the connection fails, passing requests to serve
+// on the loop.
+func (p *peer) run(serve func(*message)) {
+	dec := json.NewDecoder(bufio.NewReader(p.conn))
+
+	for {
+		m := new(message)
+		if err := dec.Decode(m); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			if p.lost != nil {
+				err = fmt.Errorf("%w: %w", p.lost, err)
+			}
+			p.close(err)
+			return
+		}
+
+		switch {
+		case m.Reply:
+			p.mu.Lock()
+			ch := p.pending[m.ID]
+			delete(p.pending, m.ID)
+			p.mu.Unlock()
+
+			if ch != nil {
+				ch <- m
+			}
+		case len(m.Release) > 0:
+			p.mu.Lock()
+			for _, id := range m.Release {
+				delete(p.funcs, id)
+			}
+			p.mu.Unlock()
+		default:
+			p.loop.post(func() { serve(m) })
+		}
+	}
+}
+
+// close fails the pending and later requests with err.
+func (p *peer) close(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return
+	}
+
+	p.err = err
+	close(p.closed)
+	p.conn.Close()
+}
+
+// failure returns the error the connection failed with, nil while it works.
+func (p *peer) failure() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+// send writes m.
+func (p *peer) send(m *message) error {
+	p.wmu.Lock()
+	defer p.wmu.Unlock()
+
+	if err := p.failure(); err != nil {
+		return err
+	}
+	return p.enc.Encode(m)
+}
+
+// request sends m and, if it has results, waits for its reply and decodes
+// them. A request made on the loop runs the requests of the other side while
+// it waits.
+func (p *peer) request(m *message, out []reflect.Type) ([]reflect.Value, error) {
+	if len(out) == 0 {
+		return nil, p.send(m)
+	}
+
+	ch := make(chan *message, 1)
+
+	p.mu.Lock()
+	p.lastID++
+	m.ID = p.lastID
+	p.pending[m.ID] = ch
+	p.mu.Unlock()
+
+	if err := p.send(m); err != nil {
+		p.mu.Lock()
+		delete(p.pending, m.ID)
+		p.mu.Unlock()
+		return nil, err
+	}
+
+	reply, err := p.wait(ch)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Error != "" {
+		return nil, errors.New(reply.Error)
+	}
+	if len(reply.Values) != len(out) {
+		return nil, fmt.Errorf("remote: %d results, expected %d", len(reply.Values), len(out))
+	}
+
+	rtn := make([]reflect.Value, len(out))
+	for i, t := range out {
+		if rtn[i], err = p.decode(reply.Values[i], t); err != nil {
+			return nil, err
+		}
+	}
+	return rtn, nil
+}
+
+// wait waits for the reply on ch.
+func (p *peer) wait(ch chan *message) (*message, error) {
+	if !p.loop.on() {
+		select {
+		case reply := <-ch:
+			return reply, nil
+		case <-p.closed:
+			return nil, p.failure()
+		}
+	}
+
+	for {
+		p.loop.goroutine.Idle(true)
+		select {
+		case reply := <-ch:
+			p.loop.goroutine.Idle(false)
+			return reply, nil
+		case <-p.loop.signal:
+			p.loop.goroutine.Idle(false)
+			p.loop.drain()
+		case <-p.closed:
+			p.loop.goroutine.Idle(false)
+			return nil, p.failure()
+		}
+	}
+}
+
+// reply answers the request m with results or err.
+func (p *peer) reply(m *message, results []reflect.Value, err error) {
+	if m.ID == 0 {
+		return
+	}
+
+	rtn := &message{ID: m.ID, Reply: true}
+	if err != nil {
+		rtn.Error = err.Error()
+	}
+
+	for _, v := range results {
+		data, err := json.Marshal(p.encode(v))
+		if err != nil {
+			rtn.Error, rtn.Values = "remote: "+err.Error(), nil
+			break
+		}
+		rtn.Values = append(rtn.Values, data)
+	}
+
+	p.send(rtn)
+}
+
+// invoke calls fn with the arguments of m and answers m. A panic of fn fails
+// the request instead of the process.
+func (p *peer) invoke(m *message, fn reflect.Value, hook func([]reflect.Value)) {
+	t := fn.Type()
+	if len(m.Values) != t.NumIn() {
+		p.reply(m, nil, fmt.Errorf("remote: %d arguments, expected %d", len(m.Values), t.NumIn()))
+		return
+	}
+
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		var err error
+		if args[i], err = p.decode(m.Values[i], t.In(i)); err != nil {
+			p.reply(m, nil, err)
+			return
+		}
+	}
+
+	if hook != nil {
+		hook(args)
+	}
+
+	results, err := func() (_ []reflect.Value, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = fmt.Errorf("remote: panic: %v", rec)
+			}
+		}()
+		return fn.Call(args), nil
+	}()
+
+	p.reply(m, results, err)
+}
+
+// serveFunc calls the function requested by m.
+func (p *peer) serveFunc(m *message) {
+	p.mu.Lock()
+	fn, ok := p.funcs[m.Func]
+	p.mu.Unlock()
+
+	if !ok {
+		p.reply(m, nil, fmt.Errorf("remote: unknown function %d", m.Func))
+		return
+	}
+	p.invoke(m, fn, nil)
+}
+
+// funcRef is referenced by the stub of a function of the other side, which
+// is released once the stub is collected.
+type funcRef struct {
+	id uint64
+}
+
+// stub returns a function of type t calling the function id of the other
+// side. It returns zero values once the connection failed.
+func (p *peer) stub(id uint64, t reflect.Type) reflect.Value {
+	r := &funcRef{id: id}
+	runtime.SetFinalizer(r, func(r *funcRef) {
+		go p.send(&message{Release: []uint64{r.id}})
+	})
+
+	out := make([]reflect.Type, t.NumOut())
+	for i := range out {
+		out[i] = t.Out(i)
+	}
+
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		m := &message{Func: r.id}
+		for _, arg := range args {
+			data, err := json.Marshal(p.encode(arg))
+			if err != nil {
+				return zeros(out)
+			}
+			m.Values = append(m.Values, data)
+		}
+
+		results, err := p.request(m, out)
+		if err != nil {
+			return zeros(out)
+		}
+		return results
+	})
+}
+
+// zeros returns the zero values of types.
+func zeros(types []reflect.Type) []reflect.Value {
+	rtn := make([]reflect.Value, len(types))
+	for i, t := range types {
+		rtn[i] = reflect.Zero(t)
+	}
+	return rtn
+}
+
+// register keeps v for the other side and returns its id.
+func (p *peer) register(v reflect.Value, object bool) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastID++
+	if object {
+		p.objects[p.lastID] = v.Interface()
+	} else {
+		p.funcs[p.lastID] = v
+	}
+	return p.lastID
+}
+
+// forget drops the object id.
+func (p *peer) forget(id uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.objects, id)
+}
+
+// object returns the object id.
+func (p *peer) object(id uint64) (any, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	obj, ok := p.objects[id]
+	return obj, ok
+}
+
+// encode returns the JSON encodable form of v, replacing functions and
+// objects by references, errors by their message and certificates by their
+// DER encoding. Interfaces other than the driver objects, e.g. a TracerProvider, do
+// not cross the connection.
+func (p *peer) encode(v reflect.Value) any {
+	t := v.Type()
+
+	switch {
+	case t == errorType:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeError(v.Interface().(error))
+	case t == certificateType:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Interface().(*x509.Certificate).Raw
+	case t.Kind() == reflect.Func:
+		if v.IsNil() {
+			return nil
+		}
+		return ref{Func: p.register(v, false)}
+	case slices.Contains(objectTypes, t):
+		if v.IsNil() {
+			return nil
+		}
+		return ref{Object: p.register(v, true)}
+	case t.Kind() == reflect.Interface:
+		return nil
+	case !hasRefs(t):
+		return v.Interface()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return p.encode(v.Elem())
+	case reflect.Struct:
+		rtn := map[string]any{}
+		for i := range t.NumField() {
+			if t.Field(i).IsExported() {
+				rtn[t.Field(i).Name] = p.encode(v.Field(i))
+			}
+		}
+		return rtn
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		rtn := make([]any, v.Len())
+		for i := range rtn {
+			rtn[i] = p.encode(v.Index(i))
+		}
+		return rtn
+	}
+
+	return nil
+}
+
+// decode is the inverse of encode, returning a value of type t.
+func (p *peer) decode(raw json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	null := len(raw) == 0 || string(raw) == "null"
+
+	switch {
+	case null && (t.Kind() == reflect.Interface || t.Kind() == reflect.Func || hasRefs(t)):
+		return v, nil
+	case t == errorType:
+		var e wireError
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return v, fmt.Errorf("remote: %w", err)
+		}
+		v.Set(reflect.ValueOf(&remoteError{msg: e.Message, sentinel: sentinels[e.Sentinel]}))
+		return v, nil
+	case t == certificateType:
+		var der []byte
+		if err := json.Unmarshal(raw, &der); err != nil {
+			return v, fmt.Errorf("remote: %w", err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return v, fmt.Errorf("remote: %w", err)
+		}
+		v.Set(reflect.ValueOf(cert))
+		return v, nil
+	case t.Kind() == reflect.Func, slices.Contains(objectTypes, t):
+		var r ref
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return v, fmt.Errorf("remote: %w", err)
+		}
+
+		if r.Func != 0 {
+			v.Set(p.stub(r.Func, t))
+		} else if p.proxy != nil {
+			v.Set(p.proxy(r.Object, t))
+		}
+		return v, nil
+	case t.Kind() == reflect.Interface:
+		return v, nil
+	case !hasRefs(t):
+		if err := json.Unmarshal(raw, v.Addr().Interface()); err != nil {
+			return v, fmt.Errorf("remote: %w", err)
+		}
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := p.decode(raw, t.Elem())
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(elem)
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return v, fmt.Errorf("remote: %w", err)
+		}
+
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			value, err := p.decode(fields[field.Name], field.Type)
+			if err != nil {
+				return v, err
+			}
+			v.Field(i).Set(value)
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return v, fmt.Errorf("remote: %w", err)
+		}
+
+		if t.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(t, len(items), len(items)))
+		}
+		for i := range min(len(items), v.Len()) {
+			item, err := p.decode(items[i], t.Elem())
+			if err != nil {
+				return v, err
+			}
+			v.Index(i).Set(item)
+		}
+	}
+
+	return v, nil
+}
+
+// encodeError returns the wire form of err.
+func encodeError(err error) wireError {
+	rtn := wireError{Message: err.Error()}
+	for name, sentinel := range sentinels {
+		if errors.Is(err, sentinel) {
+			rtn.Sentinel = name
+		}
+	}
+	return rtn
+}
+
+var refTypes sync.Map
+
+// hasRefs reports whether values of t contain functions or interfaces, which
+// encode has to replace.
+func hasRefs(t reflect.Type) bool {
+	if rtn, ok := refTypes.Load(t); ok {
+		return rtn.(bool)
+	}
+
+	// Recursive types are assumed to have none while they are examined
+	refTypes.Store(t, false)
+
+	var rtn bool
+	switch t.Kind() {
+	case reflect.Func, reflect.Interface:
+		rtn = true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		rtn = hasRefs(t.Elem())
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if t.Field(i).IsExported() && hasRefs(t.Field(i).Type) {
+				rtn = true
+				break
+			}
+		}
+	}
+
+	refTypes.Store(t, rtn)
+	return rtn
+}
@@ -0,0 +1,380 @@
+package remote
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"image"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/pdf"
+)
+
+// object is an object of the host.
+type object struct {
+	p  *peer
+	id uint64
+}
+
+// call calls method with args and stores its results in the pointers out. It
+// does not wait for methods without results.
+func (o object) call(method string, args []any, out ...any) error {
+	m := &message{Object: o.id, Method: method}
+	for _, arg := range args {
+		data, err := json.Marshal(o.p.encode(reflect.ValueOf(arg)))
+		if err != nil {
+			return fmt.Errorf("remote: %s: %w", method, err)
+		}
+		m.Values = append(m.Values, data)
+	}
+
+	types := make([]reflect.Type, len(out))
+	for i, ptr := range out {
+		types[i] = reflect.TypeOf(ptr).Elem()
+	}
+
+	results, err := o.p.request(m, types)
+	if err != nil {
+		return err
+	}
+	for i, ptr := range out {
+		reflect.ValueOf(ptr).Elem().Set(results[i])
+	}
+	return nil
+}
+
+// do calls method without waiting for it.
+func (o object) do(method string, args ...any) {
+	o.call(method, args)
+}
+
+// fails calls method returning an error.
+func (o object) fails(method string, args ...any) error {
+	var rtn error
+	if err := o.call(method, args, &rtn); err != nil {
+		return err
+	}
+	return rtn
+}
+
+// get calls method returning a T, the zero value once the host exited.
+func get[T any](o object, method string, args ...any) T {
+	var rtn T
+	o.call(method, args, &rtn)
+	return rtn
+}
+
+// appProxy is the application of the host.
+type appProxy struct {
+	object
+	client *client
+}
+
+func (a *appProxy) Run(start func()) int {
+	var code int
+	if err := a.call("Run", []any{start}, &code); err != nil {
+		return 1
+	}
+	return code
+}
+
+// Post and ThreadSafe refer to the event loop of the application, which runs
+// the callbacks of the host.
+func (a *appProxy) Post(fn func())   { a.client.loop.post(fn) }
+func (a *appProxy) ThreadSafe() bool { return a.client.loop.on() }
+
+func (a *appProxy) Quit()                     { a.do("Quit") }
+func (a *appProxy) Screens() []saucerw.Screen { return get[[]saucerw.Screen](a.object, "Screens") }
+
+func (a *appProxy) ReadClipboardText(done func(text string, ok bool)) {
+	a.do("ReadClipboardText", done)
+}
+func (a *appProxy) ReadClipboardImage(done func(*image.NRGBA)) { a.do("ReadClipboardImage", done) }
+func (a *appProxy) WriteClipboardText(text string)             { a.do("WriteClipboardText", text) }
+func (a *appProxy) WriteClipboardImage(img *image.NRGBA)       { a.do("WriteClipboardImage", img) }
+func (a *appProxy) HandleClipboard(fn func())                  { a.do("HandleClipboard", fn) }
+
+func (a *appProxy) ColorScheme() saucerw.ColorScheme {
+	return get[saucerw.ColorScheme](a.object, "ColorScheme")
+}
+func (a *appProxy) HandleColorScheme(fn func(saucerw.ColorScheme)) { a.do("HandleColorScheme", fn) }
+
+func (a *appProxy) Locales() []string               { return get[[]string](a.object, "Locales") }
+func (a *appProxy) HandleLocales(fn func([]string)) { a.do("HandleLocales", fn) }
+
+func (a *appProxy) Accessibility() saucerw.Accessibility {
+	return get[saucerw.Accessibility](a.object, "Accessibility")
+}
+func (a *appProxy) HandleAccessibility(fn func(saucerw.Accessibility)) {
+	a.do("HandleAccessibility", fn)
+}
+
+func (a *appProxy) RemoveWebsiteData(storagePath string, done func(error)) {
+	if err := a.call("RemoveWebsiteData", []any{storagePath, done}); err != nil {
+		done(err)
+	}
+}
+
+func (a *appProxy) NewWindow() (saucerw.WindowDriver, error) {
+	var (
+		win saucerw.WindowDriver
+		err error
+	)
+	if callErr := a.call("NewWindow", nil, &win, &err); callErr != nil {
+		return nil, callErr
+	}
+	return win, err
+}
+
+// Release releases the application and lets the host exit.
+func (a *appProxy) Release() {
+	a.do("Release")
+	a.client.release()
+}
+
+// windowProxy is a window of the host.
+type windowProxy struct {
+	object
+}
+
+func (w *windowProxy) Visible() bool      { return get[bool](w.object, "Visible") }
+func (w *windowProxy) Focused() bool      { return get[bool](w.object, "Focused") }
+func (w *windowProxy) Minimized() bool    { return get[bool](w.object, "Minimized") }
+func (w *windowProxy) Maximized() bool    { return get[bool](w.object, "Maximized") }
+func (w *windowProxy) Resizable() bool    { return get[bool](w.object, "Resizable") }
+func (w *windowProxy) Fullscreen() bool   { return get[bool](w.object, "Fullscreen") }
+func (w *windowProxy) AlwaysOnTop() bool  { return get[bool](w.object, "AlwaysOnTop") }
+func (w *windowProxy) ClickThrough() bool { return get[bool](w.object, "ClickThrough") }
+func (w *windowProxy) Title() string      { return get[string](w.object, "Title") }
+
+func (w *windowProxy) Background() saucerw.Color { return get[saucerw.Color](w.object, "Background") }
+func (w *windowProxy) Decorations() saucerw.Decoration {
+	return get[saucerw.Decoration](w.object, "Decorations")
+}
+func (w *windowProxy) Size() saucerw.Size         { return get[saucerw.Size](w.object, "Size") }
+func (w *windowProxy) MinSize() saucerw.Size      { return get[saucerw.Size](w.object, "MinSize") }
+func (w *windowProxy) MaxSize() saucerw.Size      { return get[saucerw.Size](w.object, "MaxSize") }
+func (w *windowProxy) Position() saucerw.Position { return get[saucerw.Position](w.object, "Position") }
+func (w *windowProxy) Scale() float64             { return get[float64](w.object, "Scale") }
+func (w *windowProxy) OuterSize() saucerw.Size    { return get[saucerw.Size](w.object, "OuterSize") }
+func (w *windowProxy) SafeArea() saucerw.Insets   { return get[saucerw.Insets](w.object, "SafeArea") }
+
+func (w *windowProxy) Show()                          { w.do("Show") }
+func (w *windowProxy) Hide()                          { w.do("Hide") }
+func (w *windowProxy) Close()                         { w.do("Close") }
+func (w *windowProxy) Focus()                         { w.do("Focus") }
+func (w *windowProxy) StartDrag()                     { w.do("StartDrag") }
+func (w *windowProxy) StartResize(edges saucerw.Edge) { w.do("StartResize", edges) }
+
+func (w *windowProxy) SetMinimized(v bool)    { w.do("SetMinimized", v) }
+func (w *windowProxy) SetMaximized(v bool)    { w.do("SetMaximized", v) }
+func (w *windowProxy) SetResizable(v bool)    { w.do("SetResizable", v) }
+func (w *windowProxy) SetFullscreen(v bool)   { w.do("SetFullscreen", v) }
+func (w *windowProxy) SetAlwaysOnTop(v bool)  { w.do("SetAlwaysOnTop", v) }
+func (w *windowProxy) SetClickThrough(v bool) { w.do("SetClickThrough", v) }
+func (w *windowProxy) SetTitle(v string)      { w.do("SetTitle", v) }
+func (w *windowProxy) SetIcon(png []byte) error {
+	return w.fails("SetIcon", png)
+}
+func (w *windowProxy) SetTitleBarColor(v saucerw.Color) bool {
+	return get[bool](w.object, "SetTitleBarColor", v)
+}
+func (w *windowProxy) SetBackground(v saucerw.Color)       { w.do("SetBackground", v) }
+func (w *windowProxy) SetDecorations(v saucerw.Decoration) { w.do("SetDecorations", v) }
+func (w *windowProxy) SetSize(v saucerw.Size)              { w.do("SetSize", v) }
+func (w *windowProxy) SetMinSize(v saucerw.Size)           { w.do("SetMinSize", v) }
+func (w *windowProxy) SetMaxSize(v saucerw.Size)           { w.do("SetMaxSize", v) }
+func (w *windowProxy) SetPosition(v saucerw.Position)      { w.do("SetPosition", v) }
+func (w *windowProxy) SetKiosk(v bool)                     { w.do("SetKiosk", v) }
+func (w *windowProxy) InterceptClose(v bool)               { w.do("InterceptClose", v) }
+
+func (w *windowProxy) HandleEvents(fn func(saucerw.WindowEvent)) { w.do("HandleEvents", fn) }
+func (w *windowProxy) SetMenu(entries []saucerw.MenuEntry)       { w.do("SetMenu", entries) }
+func (w *windowProxy) HandleMenu(fn func(id int32))              { w.do("HandleMenu", fn) }
+
+// NewWebview creates the webview in the host with the options that can cross
+// the connection: the window stays in the application, and certificates are
+// checked against RootCAs in it.
+func (w *windowProxy) NewWebview(opts saucerw.WebviewOptions) (saucerw.WebviewDriver, error) {
+	opts.Window, opts.TracerProvider, opts.Security.OnDenied = nil, nil, nil
+
+	if roots, fn := opts.Network.RootCAs, opts.Network.OnCertificateError; roots != nil {
+		opts.Network.RootCAs = nil
+		opts.Network.OnCertificateError = func(ev saucerw.CertificateError) bool {
+			return trusted(roots, ev) || (fn != nil && fn(ev))
+		}
+	}
+
+	var (
+		view saucerw.WebviewDriver
+		err  error
+	)
+	if callErr := w.call("NewWebview", []any{opts}, &view, &err); callErr != nil {
+		return nil, callErr
+	}
+	return view, err
+}
+
+func (w *windowProxy) Release() { w.do("Release") }
+
+// trusted reports whether the chain of ev verifies against roots.
+func trusted(roots *x509.CertPool, ev saucerw.CertificateError) bool {
+	if len(ev.Certificates) == 0 {
+		return false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range ev.Certificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := ev.Certificates[0].Verify(x509.VerifyOptions{
+		DNSName:       ev.Host,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err == nil
+}
+
+// webviewProxy is a webview of the host.
+type webviewProxy struct {
+	object
+}
+
+func (v *webviewProxy) URL() string       { return get[string](v.object, "URL") }
+func (v *webviewProxy) PageTitle() string { return get[string](v.object, "PageTitle") }
+func (v *webviewProxy) SetURL(u string)   { v.do("SetURL", u) }
+func (v *webviewProxy) SetHTML(h string)  { v.do("SetHTML", h) }
+func (v *webviewProxy) Back()             { v.do("Back") }
+func (v *webviewProxy) Forward()          { v.do("Forward") }
+func (v *webviewProxy) Reload()           { v.do("Reload") }
+
+func (v *webviewProxy) Background() saucerw.Color     { return get[saucerw.Color](v.object, "Background") }
+func (v *webviewProxy) SetBackground(c saucerw.Color) { v.do("SetBackground", c) }
+func (v *webviewProxy) Edit(role saucerw.Role)        { v.do("Edit", role) }
+
+func (v *webviewProxy) DevTools() bool        { return get[bool](v.object, "DevTools") }
+func (v *webviewProxy) SetDevTools(open bool) { v.do("SetDevTools", open) }
+func (v *webviewProxy) Muted() bool           { return get[bool](v.object, "Muted") }
+func (v *webviewProxy) SetMuted(muted bool)   { v.do("SetMuted", muted) }
+
+func (v *webviewProxy) Embed(files []saucerw.EmbeddedFile) { v.do("Embed", files) }
+func (v *webviewProxy) Serve(path string)                  { v.do("Serve", path) }
+func (v *webviewProxy) Unembed()                           { v.do("Unembed") }
+
+func (v *webviewProxy) Execute(code string) { v.do("Execute", code) }
+func (v *webviewProxy) Inject(script saucerw.Script) uint64 {
+	return get[uint64](v.object, "Inject", script)
+}
+func (v *webviewProxy) Uninject(id uint64) { v.do("Uninject", id) }
+func (v *webviewProxy) UninjectAll()       { v.do("UninjectAll") }
+
+func (v *webviewProxy) HandleMessage(fn func(message string) bool) { v.do("HandleMessage", fn) }
+func (v *webviewProxy) HandleNavigate(fn func(saucerw.NavigationEvent) saucerw.Policy) {
+	v.do("HandleNavigate", fn)
+}
+func (v *webviewProxy) HandlePermission(fn func(url string, types saucerw.Permission, answer func(granted bool)) saucerw.PermissionDecision) {
+	v.do("HandlePermission", fn)
+}
+func (v *webviewProxy) HandleDownload(fn func(saucerw.DownloadRequest) saucerw.DownloadDecision) {
+	v.do("HandleDownload", fn)
+}
+func (v *webviewProxy) HandleEvents(fn func(saucerw.WebviewEvent)) { v.do("HandleEvents", fn) }
+func (v *webviewProxy) HandleFileDrop(fn func(saucerw.FileDrop))   { v.do("HandleFileDrop", fn) }
+func (v *webviewProxy) HandleContextMenu(fn func(info saucerw.ContextInfo, partial bool) ([]saucerw.MenuEntry, bool), click func(id int32)) {
+	v.do("HandleContextMenu", fn, click)
+}
+
+// The callbacks of the data methods are called with the error of a failed
+// connection, the host does not call them anymore.
+
+func (v *webviewProxy) Cookies(done func([]*http.Cookie, error)) {
+	if err := v.call("Cookies", []any{done}); err != nil {
+		done(nil, err)
+	}
+}
+
+func (v *webviewProxy) SetCookie(cookie *http.Cookie, done func(error)) {
+	if err := v.call("SetCookie", []any{cookie, done}); err != nil {
+		done(err)
+	}
+}
+
+func (v *webviewProxy) DeleteCookie(cookie *http.Cookie, done func(error)) {
+	if err := v.call("DeleteCookie", []any{cookie, done}); err != nil {
+		done(err)
+	}
+}
+
+func (v *webviewProxy) ClearData(kinds saucerw.BrowsingData, since time.Time, done func(error)) {
+	if err := v.call("ClearData", []any{kinds, since, done}); err != nil {
+		done(err)
+	}
+}
+
+func (v *webviewProxy) Zoom() float64          { return get[float64](v.object, "Zoom") }
+func (v *webviewProxy) SetZoom(factor float64) { v.do("SetZoom", factor) }
+
+func (v *webviewProxy) Print(opts saucerw.PrintOptions, done func(error)) {
+	if err := v.call("Print", []any{opts, done}); err != nil {
+		done(err)
+	}
+}
+
+func (v *webviewProxy) SavePDF(path string, opts pdf.Options, done func(error)) {
+	if err := v.call("SavePDF", []any{path, opts, done}); err != nil {
+		done(err)
+	}
+}
+
+func (v *webviewProxy) Capture(done func(png []byte, err error)) {
+	if err := v.call("Capture", []any{done}); err != nil {
+		done(nil, err)
+	}
+}
+
+func (v *webviewProxy) SetDarkMode(mode saucerw.DarkMode) bool {
+	return get[bool](v.object, "SetDarkMode", mode)
+}
+
+func (v *webviewProxy) SendKey(key saucerw.KeyInput) bool {
+	return get[bool](v.object, "SendKey", key)
+}
+
+func (v *webviewProxy) SendText(text string) bool {
+	return get[bool](v.object, "SendText", text)
+}
+
+func (v *webviewProxy) SendMouse(mouse saucerw.MouseInput) bool {
+	return get[bool](v.object, "SendMouse", mouse)
+}
+
+// NewSharedBuffer is not supported, the memory of the host cannot be mapped
+// into the application.
+func (v *webviewProxy) NewSharedBuffer(size int) (saucerw.SharedMemory, error) {
+	return nil, fmt.Errorf("%w: shared buffers of a remote webview", saucerw.ErrUnsupported)
+}
+
+func (v *webviewProxy) HandleScheme(name string, handler func(req saucerw.SchemeRequest, respond func(saucerw.SchemeResponse))) {
+	v.do("HandleScheme", name, handler)
+}
+func (v *webviewProxy) RemoveScheme(name string) { v.do("RemoveScheme", name) }
+func (v *webviewProxy) HandleStreamScheme(name string, handler func(req saucerw.SchemeRequest, stream saucerw.SchemeStream)) {
+	v.do("HandleStreamScheme", name, handler)
+}
+func (v *webviewProxy) RemoveStreamScheme(name string) { v.do("RemoveStreamScheme", name) }
+
+func (v *webviewProxy) Release() { v.do("Release") }
+
+// streamProxy is a stream of the host answering a scheme request.
+type streamProxy struct {
+	object
+}
+
+func (s *streamProxy) Start(res saucerw.SchemeResponse) { s.do("Start", res) }
+func (s *streamProxy) Write(data []byte)                { s.do("Write", data) }
+func (s *streamProxy) Finish()                          { s.do("Finish") }
+func (s *streamProxy) Reject(status int)                { s.do("Reject", status) }
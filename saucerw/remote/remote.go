@@ -0,0 +1,321 @@
+// Package remote runs the native side of saucerw in a separate host process,
+// so that a crash of the toolkit or a webview does not take down the
+// application, and the application itself builds without cgo.
+//
+// The host is a small binary built with cgo and -tags saucer:
+//
+//	package main
+//
+//	import (
+//		"github.com/aperturerobotics/saucer/saucerw"
+//		"github.com/aperturerobotics/saucer/saucerw/remote"
+//	)
+//
+//	func main() {
+//		remote.Main(saucerw.DefaultDriver())
+//	}
+//
+// cmd/saucerw-host is such a binary. The application passes a Driver
+// starting it to saucerw.NewApplicationWithDriver:
+//
+//	drv := remote.New(remote.Options{OnExit: func(err error) { log.Println("host exited:", err) }})
+//	app, err := saucerw.NewApplicationWithDriver(drv, saucerw.AppOptions{ID: "com.example.app"})
+//
+// The Driver talks to the host over a Unix socket only the host can connect
+// to, forwarding every call of the driver interfaces and the callbacks the
+// host makes. Once the host exited, Application.Run returns and the calls
+// return zero values. Shared buffers are not supported, Webview.SharedBuffer
+// falls back to copying. TracerProviders and the Window of saucerw.WebviewOptions
+// stay in the application.
+package remote
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/internal/goid"
+)
+
+// HostCommand is the host binary a Driver starts by default, looked up next
+// to the executable of the application and then in PATH.
+const HostCommand = "saucerw-host"
+
+// ErrHostExited is wrapped by the errors of the calls made after the host
+// process exited or the connection to it was lost.
+var ErrHostExited = errors.New("remote: host process exited")
+
+// Options configures a Driver.
+type Options struct {
+	// Command is the host binary, HostCommand when empty.
+	Command string
+	// Args are passed to the host.
+	Args []string
+	// Env is added to the environment of the application for the host.
+	Env []string
+	// Stdout and Stderr receive the output of the host, that of the
+	// application by default.
+	Stdout io.Writer
+	Stderr io.Writer
+	// StartTimeout limits the time the host takes to connect, 30 seconds
+	// when zero.
+	StartTimeout time.Duration
+	// OnExit is called with the error the host exited with, nil when it
+	// exited after the application was released.
+	OnExit func(err error)
+}
+
+// Driver is a saucerw.Driver running the native side in a host process. A
+// Driver starts a host for each application.
+type Driver struct {
+	opts Options
+}
+
+// New returns a Driver starting hosts with opts.
+func New(opts Options) *Driver {
+	return &Driver{opts: opts}
+}
+
+// NewApp starts the host and creates the application in it. Like a native
+// application, the application runs its event loop on the calling goroutine.
+func (d *Driver) NewApp(opts saucerw.AppOptions) (saucerw.AppDriver, error) {
+	command, err := d.command()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "saucerw-remote-")
+	if err != nil {
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	addr := filepath.Join(dir, "host.sock")
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	defer ln.Close()
+
+	secret := make([]byte, 16)
+	rand.Read(secret)
+	token := hex.EncodeToString(secret)
+
+	cmd := exec.Command(command, d.opts.Args...)
+	cmd.Env = append(os.Environ(), d.opts.Env...)
+	cmd.Env = append(cmd.Env, addrEnv+"="+addr, tokenEnv+"="+token)
+	cmd.Stdout, cmd.Stderr = d.opts.Stdout, d.opts.Stderr
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("remote: start host: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	conn, err := d.accept(ln, token, exited)
+	if err != nil {
+		cmd.Process.Kill()
+		return nil, err
+	}
+
+	c := newClient(conn)
+	go c.watch(exited, d.opts.OnExit)
+
+	app, err := c.newApp(opts)
+	if err != nil {
+		cmd.Process.Kill()
+	}
+	return app, err
+}
+
+// command returns the path of the host binary.
+func (d *Driver) command() (string, error) {
+	if d.opts.Command != "" {
+		return d.opts.Command, nil
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		path := filepath.Join(filepath.Dir(exe), HostCommand+filepath.Ext(exe))
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	path, err := exec.LookPath(HostCommand)
+	if err != nil {
+		return "", fmt.Errorf("remote: %w", err)
+	}
+	return path, nil
+}
+
+// accept waits for the host to connect and present token.
+func (d *Driver) accept(ln net.Listener, token string, exited <-chan error) (net.Conn, error) {
+	timeout := d.opts.StartTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	accepted := make(chan result, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			conn.SetReadDeadline(time.Now().Add(timeout))
+			err = readToken(conn, token)
+			conn.SetReadDeadline(time.Time{})
+			if err != nil {
+				conn.Close()
+			}
+		}
+		accepted <- result{conn, err}
+	}()
+
+	select {
+	case res := <-accepted:
+		if res.err != nil {
+			return nil, fmt.Errorf("remote: connect host: %w", res.err)
+		}
+		return res.conn, nil
+	case err := <-exited:
+		return nil, fmt.Errorf("remote: host exited before connecting: %v", err)
+	case <-ctx.Done():
+		return nil, errors.New("remote: host did not connect in time")
+	}
+}
+
+// readToken reads the line the host starts with, which has to be token. It
+// reads byte by byte to leave the requests after it on the connection.
+func readToken(conn net.Conn, token string) error {
+	var line strings.Builder
+	buf := make([]byte, 1)
+
+	for line.Len() <= len(token) {
+		if _, err := conn.Read(buf); err != nil {
+			return err
+		}
+		if buf[0] == '\n' {
+			if subtle.ConstantTimeCompare([]byte(line.String()), []byte(token)) != 1 {
+				return errors.New("bad token")
+			}
+			return nil
+		}
+		line.WriteByte(buf[0])
+	}
+	return errors.New("bad token")
+}
+
+// client is the side of the application.
+type client struct {
+	*peer
+
+	releaseOnce sync.Once
+}
+
+// newClient returns the client of the host on conn, whose event loop runs on
+// the calling goroutine.
+func newClient(conn net.Conn) *client {
+	g := goid.New()
+
+	c := &client{peer: newPeer(conn, newLoop(g, g.On))}
+	c.proxy, c.lost = c.newProxy, ErrHostExited
+	go c.run(c.serveFunc)
+	return c
+}
+
+// newApp creates the application in the host.
+func (c *client) newApp(opts saucerw.AppOptions) (saucerw.AppDriver, error) {
+	var (
+		app saucerw.AppDriver
+		err error
+	)
+	if callErr := c.root().call("NewApp", []any{opts}, &app, &err); callErr != nil {
+		return nil, callErr
+	}
+	if err != nil {
+		c.release()
+		return nil, err
+	}
+	return app, nil
+}
+
+// root is the host itself, which creates the application.
+func (c *client) root() object {
+	return object{c.peer, 0}
+}
+
+// release closes the connection after the requests sent before, which makes
+// the host exit.
+func (c *client) release() {
+	c.releaseOnce.Do(func() { c.close(errReleased) })
+}
+
+// errReleased closes the connection once the application was released.
+var errReleased = errors.New("remote: application released")
+
+// watch reports the exit of the host to onExit.
+func (c *client) watch(exited <-chan error, onExit func(error)) {
+	err := <-exited
+
+	var exit *exec.ExitError
+	switch {
+	case errors.Is(c.failure(), errReleased) && err == nil:
+	case errors.As(err, &exit):
+		err = fmt.Errorf("%w: %v", ErrHostExited, exit)
+	case err == nil:
+		err = ErrHostExited
+	default:
+		err = fmt.Errorf("%w: %v", ErrHostExited, err)
+	}
+
+	c.close(err)
+	if onExit != nil {
+		onExit(err)
+	}
+}
+
+// newProxy returns the proxy of the object id of the host.
+func (c *client) newProxy(id uint64, t reflect.Type) reflect.Value {
+	obj := object{c.peer, id}
+
+	var proxy any
+	switch t {
+	case reflect.TypeFor[saucerw.AppDriver]():
+		proxy = &appProxy{object: obj, client: c}
+	case reflect.TypeFor[saucerw.WindowDriver]():
+		proxy = &windowProxy{obj}
+	case reflect.TypeFor[saucerw.WebviewDriver]():
+		proxy = &webviewProxy{obj}
+	case reflect.TypeFor[saucerw.SchemeStream]():
+		proxy = &streamProxy{obj}
+	default:
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(proxy)
+}
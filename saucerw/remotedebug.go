@@ -0,0 +1,91 @@
+package saucerw
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// ErrRemoteDebuggingDisabled is returned by EnableRemoteDebugging when the
+// application did not opt in with AppOptions.RemoteDebugging.
+var ErrRemoteDebuggingDisabled = errors.New("saucerw: remote debugging is disabled, see AppOptions.RemoteDebugging")
+
+// checkRemoteDebugging validates AppOptions.RemoteDebugging: a port on a
+// loopback address, since anyone reaching the endpoint controls the pages.
+func checkRemoteDebugging(addr string) error {
+	return checkLoopback("remote debugging", addr)
+}
+
+// checkLoopback validates addr, the address of the endpoint what: a port on a
+// loopback address.
+func checkLoopback(what, addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("saucerw: %s: %w", what, err)
+	}
+
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		return fmt.Errorf("saucerw: %s: bad port %q", what, port)
+	}
+
+	if ip, err := netip.ParseAddr(host); host != "localhost" && (err != nil || !ip.IsLoopback()) {
+		return fmt.Errorf("saucerw: %s: %s is not a loopback address", what, host)
+	}
+	return nil
+}
+
+// setupRemoteDebugging starts the debugging endpoints of the backends
+// configured when the toolkit is initialized: the inspector server of
+// WebKitGTK and the DevTools server of Qt WebEngine.
+func setupRemoteDebugging(addr string) error {
+	for _, kv := range [][2]string{
+		{"WEBKIT_INSPECTOR_HTTP_SERVER", addr},
+		{"QTWEBENGINE_REMOTE_DEBUGGING", addr},
+	} {
+		if err := os.Setenv(kv[0], kv[1]); err != nil {
+			return fmt.Errorf("saucerw: remote debugging: %w", err)
+		}
+	}
+	return nil
+}
+
+// remoteDebuggingFlags returns the browser flags starting the DevTools server
+// of WebView2, which is configured per browser process.
+func (a *Application) remoteDebuggingFlags() []string {
+	if a.remoteDebugging == "" || runtime.GOOS != "windows" {
+		return nil
+	}
+
+	_, port, _ := net.SplitHostPort(a.remoteDebugging)
+	return []string{"--remote-debugging-port=" + port}
+}
+
+// EnableRemoteDebugging allows external tools to attach to the webview,
+// e.g. Playwright's connectOverCDP or chrome://inspect with WebView2 and Qt,
+// or a browser opening the inspector of WebKitGTK, and returns the URL of the
+// endpoint. It enables the developer tools, see SetDevTools.
+//
+// The endpoint is started with the toolkit, which requires opting in with
+// AppOptions.RemoteDebugging; addr must be empty or that address. It returns
+// ErrRemoteDebuggingDisabled without opting in and an error wrapping
+// ErrUnsupported on macOS, whose pages Safari inspects through its Develop
+// menu once the developer tools are enabled.
+func (v *Webview) EnableRemoteDebugging(addr string) (string, error) {
+	enabled := v.window.app.remoteDebugging
+
+	switch {
+	case enabled == "":
+		return "", ErrRemoteDebuggingDisabled
+	case addr != "" && addr != enabled:
+		return "", fmt.Errorf("saucerw: remote debugging listens on %s, set by AppOptions.RemoteDebugging", enabled)
+	case runtime.GOOS == "darwin":
+		return "", fmt.Errorf("%w: remote debugging of WKWebView", ErrUnsupported)
+	}
+
+	v.SetDevTools(true)
+	return "http://" + enabled, nil
+}
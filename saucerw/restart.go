@@ -0,0 +1,632 @@
+package saucerw
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw/pdf"
+)
+
+// Restart tears down the native webview and creates it again in the same
+// window, keeping the Go side: exposed functions, middleware, subscriptions,
+// injected scripts, scheme handlers and embedded files carry over, and the
+// new page loads the URL of the old one. update, if not nil, changes the
+// options first, of which Preferences, Network and DisableAttributes take
+// effect; the others keep the values the webview was created with.
+//
+// It is meant for development, to try native options or to replace a page
+// that stopped responding without restarting the process and losing its
+// state. The page itself starts over as after Reload, evaluations pending in
+// the old page only end with their context and shared buffers have to be
+// created again.
+func (v *Webview) Restart(update func(*WebviewOptions)) error {
+	err, dispatchErr := DispatchResult(v.window.app, func() error {
+		return v.restart(v.window.native.(*windowHandle).current(), update)
+	})
+	if dispatchErr != nil {
+		return dispatchErr
+	}
+	return err
+}
+
+// restart creates the native webview anew inside the native window parent,
+// on the event loop thread.
+func (v *Webview) restart(parent WindowDriver, update func(*WebviewOptions)) error {
+	r, err := v.recreate(parent, update)
+	if err != nil {
+		return err
+	}
+	r.swap()
+	return nil
+}
+
+// replacement is a native webview created to replace the one of a Webview.
+type replacement struct {
+	v        *Webview
+	opts     WebviewOptions
+	prepared WebviewOptions
+	native   WebviewDriver
+}
+
+// recreate creates the native webview replacing the one of v inside parent.
+func (v *Webview) recreate(parent WindowDriver, update func(*WebviewOptions)) (*replacement, error) {
+	if v.bridge.ctx.Err() != nil {
+		return nil, ErrReleased
+	}
+
+	opts := v.options
+	if update != nil {
+		changed := v.options
+		update(&changed)
+		opts.Preferences, opts.Network, opts.DisableAttributes = changed.Preferences, changed.Network, changed.DisableAttributes
+	}
+
+	prepared := opts
+	if err := prepared.prepare(); err != nil {
+		return nil, err
+	}
+
+	native, err := parent.NewWebview(prepared)
+	if err != nil {
+		return nil, err
+	}
+	return &replacement{v: v, opts: opts, prepared: prepared, native: native}, nil
+}
+
+// swap makes the replacement the native webview of its Webview and releases
+// the old one.
+func (r *replacement) swap() {
+	handle := r.v.native.(*webviewHandle)
+	old := handle.current()
+	state := webviewState{
+		url:        old.URL(),
+		background: old.Background(),
+		zoom:       old.Zoom(),
+		muted:      old.Muted(),
+		devTools:   old.DevTools(),
+	}
+
+	r.v.options = r.opts
+	r.v.setup(r.native, &r.prepared)
+	handle.replace(r.native)
+	state.apply(r.native)
+
+	old.Release()
+}
+
+// webviewState is the state of a page a restart carries over.
+type webviewState struct {
+	url        string
+	background Color
+	zoom       float64
+	muted      bool
+	devTools   bool
+}
+
+func (s *webviewState) apply(native WebviewDriver) {
+	native.SetBackground(s.background)
+	native.SetZoom(s.zoom)
+	native.SetMuted(s.muted)
+	if s.devTools {
+		native.SetDevTools(true)
+	}
+	if s.url != "" {
+		native.SetURL(s.url)
+	}
+}
+
+// Restart tears down the native window and creates it again along with its
+// webviews, like Webview.Restart. The position, size, title, decorations,
+// menu, icon and state of the window carry over.
+func (w *Window) Restart() error {
+	err, dispatchErr := DispatchResult(w.app, w.restart)
+	if dispatchErr != nil {
+		return dispatchErr
+	}
+	return err
+}
+
+// restart creates the native window anew, on the event loop thread.
+func (w *Window) restart() error {
+	w.mu.Lock()
+	released := w.released
+	w.mu.Unlock()
+
+	if released {
+		return errors.New("saucerw: window was released")
+	}
+
+	raw, err := w.app.native.NewWindow()
+	if err != nil {
+		return err
+	}
+
+	handle := w.native.(*windowHandle)
+	old := handle.current()
+
+	raw.SetDecorations(old.Decorations())
+	raw.SetBackground(old.Background())
+	raw.SetTitle(old.Title())
+	raw.SetResizable(old.Resizable())
+	raw.SetAlwaysOnTop(old.AlwaysOnTop())
+	raw.SetClickThrough(old.ClickThrough())
+	raw.SetMinSize(old.MinSize())
+	raw.SetMaxSize(old.MaxSize())
+	raw.SetSize(old.Size())
+	raw.SetPosition(old.Position())
+
+	// Nothing changes unless all webviews could be created again
+	var replacements []*replacement
+	for _, v := range w.Webviews() {
+		r, err := v.recreate(raw, nil)
+		if err != nil {
+			for _, r := range replacements {
+				r.native.Release()
+			}
+			raw.Release()
+			return err
+		}
+		replacements = append(replacements, r)
+	}
+
+	for _, r := range replacements {
+		r.swap()
+	}
+
+	visible, fullscreen, maximized, minimized := old.Visible(), old.Fullscreen(), old.Maximized(), old.Minimized()
+	handle.replace(raw)
+
+	if visible {
+		raw.Show()
+	}
+	raw.SetFullscreen(fullscreen)
+	raw.SetMaximized(maximized)
+	raw.SetMinimized(minimized)
+
+	// The window being closed is not the one of w closing
+	old.HandleEvents(func(WindowEvent) {})
+	old.Release()
+	return nil
+}
+
+// windowHandle is the WindowDriver of a Window. It forwards to the current
+// native window and keeps what the bindings set on it, which Window.Restart
+// sets on the window replacing it.
+type windowHandle struct {
+	native atomic.Pointer[WindowDriver]
+
+	mu        sync.Mutex
+	events    func(WindowEvent)
+	menu      func(int32)
+	entries   []MenuEntry
+	icon      []byte
+	titleBar  *Color
+	kiosk     bool
+	intercept bool
+}
+
+func newWindowHandle(native WindowDriver) *windowHandle {
+	h := &windowHandle{}
+	h.native.Store(&native)
+	return h
+}
+
+func (h *windowHandle) current() WindowDriver {
+	return *h.native.Load()
+}
+
+// replace sets the kept state on native and makes it the current window.
+func (h *windowHandle) replace(native WindowDriver) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.events != nil {
+		native.HandleEvents(h.events)
+	}
+	if h.menu != nil {
+		native.HandleMenu(h.menu)
+	}
+	if h.entries != nil {
+		native.SetMenu(h.entries)
+	}
+	if h.icon != nil {
+		native.SetIcon(h.icon)
+	}
+	if h.titleBar != nil {
+		native.SetTitleBarColor(*h.titleBar)
+	}
+	native.SetKiosk(h.kiosk)
+	native.InterceptClose(h.intercept)
+
+	h.native.Store(&native)
+}
+
+func (h *windowHandle) Visible() bool           { return h.current().Visible() }
+func (h *windowHandle) Focused() bool           { return h.current().Focused() }
+func (h *windowHandle) Minimized() bool         { return h.current().Minimized() }
+func (h *windowHandle) Maximized() bool         { return h.current().Maximized() }
+func (h *windowHandle) Resizable() bool         { return h.current().Resizable() }
+func (h *windowHandle) Fullscreen() bool        { return h.current().Fullscreen() }
+func (h *windowHandle) AlwaysOnTop() bool       { return h.current().AlwaysOnTop() }
+func (h *windowHandle) ClickThrough() bool      { return h.current().ClickThrough() }
+func (h *windowHandle) Title() string           { return h.current().Title() }
+func (h *windowHandle) Background() Color       { return h.current().Background() }
+func (h *windowHandle) Decorations() Decoration { return h.current().Decorations() }
+func (h *windowHandle) Size() Size              { return h.current().Size() }
+func (h *windowHandle) MinSize() Size           { return h.current().MinSize() }
+func (h *windowHandle) MaxSize() Size           { return h.current().MaxSize() }
+func (h *windowHandle) Position() Position      { return h.current().Position() }
+func (h *windowHandle) Scale() float64          { return h.current().Scale() }
+func (h *windowHandle) OuterSize() Size         { return h.current().OuterSize() }
+func (h *windowHandle) SafeArea() Insets        { return h.current().SafeArea() }
+
+func (h *windowHandle) Show()                  { h.current().Show() }
+func (h *windowHandle) Hide()                  { h.current().Hide() }
+func (h *windowHandle) Close()                 { h.current().Close() }
+func (h *windowHandle) Focus()                 { h.current().Focus() }
+func (h *windowHandle) StartDrag()             { h.current().StartDrag() }
+func (h *windowHandle) StartResize(edges Edge) { h.current().StartResize(edges) }
+
+func (h *windowHandle) SetMinimized(v bool)         { h.current().SetMinimized(v) }
+func (h *windowHandle) SetMaximized(v bool)         { h.current().SetMaximized(v) }
+func (h *windowHandle) SetResizable(v bool)         { h.current().SetResizable(v) }
+func (h *windowHandle) SetFullscreen(v bool)        { h.current().SetFullscreen(v) }
+func (h *windowHandle) SetAlwaysOnTop(v bool)       { h.current().SetAlwaysOnTop(v) }
+func (h *windowHandle) SetClickThrough(v bool)      { h.current().SetClickThrough(v) }
+func (h *windowHandle) SetTitle(v string)           { h.current().SetTitle(v) }
+func (h *windowHandle) SetBackground(v Color)       { h.current().SetBackground(v) }
+func (h *windowHandle) SetDecorations(v Decoration) { h.current().SetDecorations(v) }
+func (h *windowHandle) SetSize(v Size)              { h.current().SetSize(v) }
+func (h *windowHandle) SetMinSize(v Size)           { h.current().SetMinSize(v) }
+func (h *windowHandle) SetMaxSize(v Size)           { h.current().SetMaxSize(v) }
+func (h *windowHandle) SetPosition(v Position)      { h.current().SetPosition(v) }
+func (h *windowHandle) NewWebview(opts WebviewOptions) (WebviewDriver, error) {
+	return h.current().NewWebview(opts)
+}
+func (h *windowHandle) Release() { h.current().Release() }
+
+func (h *windowHandle) SetIcon(png []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.current().SetIcon(png); err != nil {
+		return err
+	}
+	h.icon = png
+	return nil
+}
+
+func (h *windowHandle) SetTitleBarColor(color Color) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.current().SetTitleBarColor(color) {
+		return false
+	}
+	h.titleBar = &color
+	return true
+}
+
+func (h *windowHandle) SetKiosk(kiosk bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.kiosk = kiosk
+	h.current().SetKiosk(kiosk)
+}
+
+func (h *windowHandle) InterceptClose(intercept bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.intercept = intercept
+	h.current().InterceptClose(intercept)
+}
+
+func (h *windowHandle) HandleEvents(fn func(WindowEvent)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = fn
+	h.current().HandleEvents(fn)
+}
+
+func (h *windowHandle) SetMenu(entries []MenuEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = entries
+	h.current().SetMenu(entries)
+}
+
+func (h *windowHandle) HandleMenu(fn func(id int32)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.menu = fn
+	h.current().HandleMenu(fn)
+}
+
+// injected is a script injected through a webviewHandle.
+type injected struct {
+	script Script
+	// native is the id of the script in the current native webview.
+	native uint64
+}
+
+// webviewHandle is the WebviewDriver of a Webview, like windowHandle for
+// Webview.Restart.
+type webviewHandle struct {
+	native atomic.Pointer[WebviewDriver]
+
+	mu         sync.Mutex
+	lastID     uint64
+	scripts    map[uint64]*injected
+	message    func(string) bool
+	navigate   func(NavigationEvent) Policy
+	permission func(string, Permission, func(bool)) PermissionDecision
+	download   func(DownloadRequest) DownloadDecision
+	events     func(WebviewEvent)
+	drop       func(FileDrop)
+	menu       func(ContextInfo, bool) ([]MenuEntry, bool)
+	click      func(int32)
+	schemes    map[string]func(SchemeRequest, func(SchemeResponse))
+	streams    map[string]func(SchemeRequest, SchemeStream)
+	embedded   map[string]EmbeddedFile
+	darkMode   DarkMode
+}
+
+func newWebviewHandle(native WebviewDriver) *webviewHandle {
+	h := &webviewHandle{
+		scripts:  map[uint64]*injected{},
+		schemes:  map[string]func(SchemeRequest, func(SchemeResponse)){},
+		streams:  map[string]func(SchemeRequest, SchemeStream){},
+		embedded: map[string]EmbeddedFile{},
+	}
+	h.native.Store(&native)
+	return h
+}
+
+func (h *webviewHandle) current() WebviewDriver {
+	return *h.native.Load()
+}
+
+// replace sets the kept state on native and makes it the current webview.
+func (h *webviewHandle) replace(native WebviewDriver) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.message != nil {
+		native.HandleMessage(h.message)
+	}
+	if h.navigate != nil {
+		native.HandleNavigate(h.navigate)
+	}
+	if h.permission != nil {
+		native.HandlePermission(h.permission)
+	}
+	if h.download != nil {
+		native.HandleDownload(h.download)
+	}
+	if h.events != nil {
+		native.HandleEvents(h.events)
+	}
+	if h.drop != nil {
+		native.HandleFileDrop(h.drop)
+	}
+	if h.menu != nil {
+		native.HandleContextMenu(h.menu, h.click)
+	}
+
+	// In the order they were injected
+	for _, id := range slices.Sorted(maps.Keys(h.scripts)) {
+		h.scripts[id].native = native.Inject(h.scripts[id].script)
+	}
+
+	for name, handler := range h.schemes {
+		native.HandleScheme(name, handler)
+	}
+	for name, handler := range h.streams {
+		native.HandleStreamScheme(name, handler)
+	}
+
+	if len(h.embedded) > 0 {
+		native.Embed(slices.Collect(maps.Values(h.embedded)))
+	}
+	if h.darkMode != DarkModeSystem {
+		native.SetDarkMode(h.darkMode)
+	}
+
+	h.native.Store(&native)
+}
+
+func (h *webviewHandle) URL() string         { return h.current().URL() }
+func (h *webviewHandle) PageTitle() string   { return h.current().PageTitle() }
+func (h *webviewHandle) SetURL(url string)   { h.current().SetURL(url) }
+func (h *webviewHandle) SetHTML(html string) { h.current().SetHTML(html) }
+func (h *webviewHandle) Back()               { h.current().Back() }
+func (h *webviewHandle) Forward()            { h.current().Forward() }
+func (h *webviewHandle) Reload()             { h.current().Reload() }
+
+func (h *webviewHandle) Background() Color         { return h.current().Background() }
+func (h *webviewHandle) SetBackground(color Color) { h.current().SetBackground(color) }
+func (h *webviewHandle) Edit(role Role)            { h.current().Edit(role) }
+func (h *webviewHandle) DevTools() bool            { return h.current().DevTools() }
+func (h *webviewHandle) SetDevTools(open bool)     { h.current().SetDevTools(open) }
+func (h *webviewHandle) Muted() bool               { return h.current().Muted() }
+func (h *webviewHandle) SetMuted(muted bool)       { h.current().SetMuted(muted) }
+func (h *webviewHandle) Serve(path string)         { h.current().Serve(path) }
+func (h *webviewHandle) Execute(code string)       { h.current().Execute(code) }
+
+func (h *webviewHandle) Cookies(done func([]*http.Cookie, error)) { h.current().Cookies(done) }
+func (h *webviewHandle) SetCookie(cookie *http.Cookie, done func(error)) {
+	h.current().SetCookie(cookie, done)
+}
+func (h *webviewHandle) DeleteCookie(cookie *http.Cookie, done func(error)) {
+	h.current().DeleteCookie(cookie, done)
+}
+func (h *webviewHandle) ClearData(kinds BrowsingData, since time.Time, done func(error)) {
+	h.current().ClearData(kinds, since, done)
+}
+
+func (h *webviewHandle) Zoom() float64                             { return h.current().Zoom() }
+func (h *webviewHandle) SetZoom(factor float64)                    { h.current().SetZoom(factor) }
+func (h *webviewHandle) Print(opts PrintOptions, done func(error)) { h.current().Print(opts, done) }
+func (h *webviewHandle) SavePDF(path string, opts pdf.Options, done func(error)) {
+	h.current().SavePDF(path, opts, done)
+}
+func (h *webviewHandle) Capture(done func(png []byte, err error)) { h.current().Capture(done) }
+func (h *webviewHandle) NewSharedBuffer(size int) (SharedMemory, error) {
+	return h.current().NewSharedBuffer(size)
+}
+func (h *webviewHandle) Release()                        { h.current().Release() }
+func (h *webviewHandle) SendKey(key KeyInput) bool       { return h.current().SendKey(key) }
+func (h *webviewHandle) SendText(text string) bool       { return h.current().SendText(text) }
+func (h *webviewHandle) SendMouse(mouse MouseInput) bool { return h.current().SendMouse(mouse) }
+
+func (h *webviewHandle) SetDarkMode(mode DarkMode) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.darkMode = mode
+	return h.current().SetDarkMode(mode)
+}
+
+func (h *webviewHandle) Embed(files []EmbeddedFile) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, file := range files {
+		h.embedded[file.Path] = file
+	}
+	h.current().Embed(files)
+}
+
+func (h *webviewHandle) Unembed() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clear(h.embedded)
+	h.current().Unembed()
+}
+
+// Inject returns an id that stays valid across restarts.
+func (h *webviewHandle) Inject(script Script) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	h.scripts[h.lastID] = &injected{script: script, native: h.current().Inject(script)}
+	return h.lastID
+}
+
+func (h *webviewHandle) Uninject(id uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.scripts[id]; ok {
+		delete(h.scripts, id)
+		h.current().Uninject(s.native)
+	}
+}
+
+func (h *webviewHandle) UninjectAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	maps.DeleteFunc(h.scripts, func(_ uint64, s *injected) bool { return !s.script.Permanent })
+	h.current().UninjectAll()
+}
+
+func (h *webviewHandle) HandleMessage(fn func(message string) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.message = fn
+	h.current().HandleMessage(fn)
+}
+
+func (h *webviewHandle) HandleNavigate(fn func(NavigationEvent) Policy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.navigate = fn
+	h.current().HandleNavigate(fn)
+}
+
+func (h *webviewHandle) HandlePermission(fn func(url string, types Permission, answer func(granted bool)) PermissionDecision) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.permission = fn
+	h.current().HandlePermission(fn)
+}
+
+func (h *webviewHandle) HandleDownload(fn func(DownloadRequest) DownloadDecision) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.download = fn
+	h.current().HandleDownload(fn)
+}
+
+func (h *webviewHandle) HandleEvents(fn func(WebviewEvent)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = fn
+	h.current().HandleEvents(fn)
+}
+
+func (h *webviewHandle) HandleFileDrop(fn func(FileDrop)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.drop = fn
+	h.current().HandleFileDrop(fn)
+}
+
+func (h *webviewHandle) HandleContextMenu(fn func(info ContextInfo, partial bool) ([]MenuEntry, bool), click func(id int32)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.menu, h.click = fn, click
+	h.current().HandleContextMenu(fn, click)
+}
+
+func (h *webviewHandle) HandleScheme(name string, handler func(req SchemeRequest, respond func(SchemeResponse))) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.schemes[name] = handler
+	h.current().HandleScheme(name, handler)
+}
+
+func (h *webviewHandle) RemoveScheme(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.schemes, name)
+	h.current().RemoveScheme(name)
+}
+
+func (h *webviewHandle) HandleStreamScheme(name string, handler func(req SchemeRequest, stream SchemeStream)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.streams[name] = handler
+	h.current().HandleStreamScheme(name, handler)
+}
+
+func (h *webviewHandle) RemoveStreamScheme(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.streams, name)
+	h.current().RemoveStreamScheme(name)
+}
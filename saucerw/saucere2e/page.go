@@ -0,0 +1,511 @@
+package saucere2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// helperScript installs the DOM helpers of Page as window.saucere2e, as a
+// script and as an expression for Eval. An element missing for a selector
+// throws an Error named "NotFoundError".
+const helperScript = `window.saucere2e ??= (() =>
+{
+    const find = (selector) =>
+    {
+        const element = document.querySelector(selector);
+
+        if (!element)
+        {
+            const error = new Error("No element matches " + selector);
+            error.name  = "NotFoundError";
+            throw error;
+        }
+
+        return element;
+    };
+
+    const mouse = (element, type) =>
+    {
+        const rect = element.getBoundingClientRect();
+        const init = {
+            bubbles: true,
+            cancelable: true,
+            composed: true,
+            view: window,
+            button: 0,
+            clientX: rect.left + rect.width / 2,
+            clientY: rect.top + rect.height / 2,
+        };
+
+        if (type.startsWith("pointer"))
+        {
+            return element.dispatchEvent(new PointerEvent(type, { ...init, pointerId: 1, isPrimary: true }));
+        }
+
+        return element.dispatchEvent(new MouseEvent(type, init));
+    };
+
+    // Frameworks like React track the value through the setter of the prototype
+    const setValue = (element, value) =>
+    {
+        const setter = Object.getOwnPropertyDescriptor(Object.getPrototypeOf(element), "value")?.set;
+
+        if (setter)
+        {
+            setter.call(element, value);
+        }
+        else
+        {
+            element.value = value;
+        }
+    };
+
+    const input = (element, data, inputType) =>
+    {
+        element.dispatchEvent(new InputEvent("input", { data, inputType, bubbles: true, composed: true }));
+    };
+
+    return {
+        exists: (selector) => document.querySelector(selector) !== null,
+        count: (selector) => document.querySelectorAll(selector).length,
+        text: (selector) => find(selector).textContent,
+        html: (selector) => find(selector).outerHTML,
+        value: (selector) => find(selector).value ?? null,
+        attr: (selector, name) => find(selector).getAttribute(name),
+        visible: (selector) =>
+        {
+            const element = document.querySelector(selector);
+            return !!element && element.getClientRects().length > 0 && getComputedStyle(element).visibility !== "hidden";
+        },
+        click: (selector) =>
+        {
+            const element = find(selector);
+            element.scrollIntoView({ block: "center", inline: "center" });
+
+            mouse(element, "pointerdown");
+            mouse(element, "mousedown");
+            element.focus?.();
+            mouse(element, "pointerup");
+            mouse(element, "mouseup");
+            element.click();
+        },
+        center: (selector) =>
+        {
+            const element = find(selector);
+            element.scrollIntoView({ block: "center", inline: "center" });
+
+            const rect = element.getBoundingClientRect();
+            return [Math.round(rect.left + rect.width / 2), Math.round(rect.top + rect.height / 2)];
+        },
+        type: (selector, text) =>
+        {
+            const element = find(selector);
+            element.focus();
+
+            for (const key of text)
+            {
+                const init = { key, bubbles: true, cancelable: true, composed: true };
+
+                if (element.dispatchEvent(new KeyboardEvent("keydown", init)))
+                {
+                    if (element.isContentEditable)
+                    {
+                        document.execCommand("insertText", false, key);
+                    }
+                    else
+                    {
+                        setValue(element, (element.value ?? "") + key);
+                        input(element, key, "insertText");
+                    }
+                }
+
+                element.dispatchEvent(new KeyboardEvent("keyup", init));
+            }
+
+            element.dispatchEvent(new Event("change", { bubbles: true }));
+        },
+        clear: (selector) =>
+        {
+            const element = find(selector);
+            element.focus();
+
+            if (element.isContentEditable)
+            {
+                element.textContent = "";
+                input(element, null, "deleteContentBackward");
+            }
+            else
+            {
+                setValue(element, "");
+                input(element, null, "deleteContentBackward");
+                element.dispatchEvent(new Event("change", { bubbles: true }));
+            }
+        },
+    };
+})()`
+
+// pollInterval is the interval Wait evaluates its condition in.
+const pollInterval = 50 * time.Millisecond
+
+// unsafeName matches the runs of characters of a test name replaced in the
+// names of its screenshots.
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Page drives the page of the webview under test for a test, failing it on
+// errors. Actions wait for the page up to Options.Timeout. Selectors are CSS
+// selectors of the main frame; the first matching element is used.
+type Page struct {
+	t       testing.TB
+	view    *saucerw.Webview
+	timeout time.Duration
+
+	mu      sync.Mutex
+	console []string
+}
+
+// start installs the helpers in the current page and records its console,
+// which is logged along with a screenshot if the test fails.
+func (p *Page) start() {
+	p.t.Helper()
+
+	sub := p.view.OnConsoleMessage(func(m saucerw.ConsoleMessage) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.console = append(p.console, fmt.Sprintf("%s %s", m.Level, m.Message()))
+	})
+
+	p.t.Cleanup(func() {
+		sub.Cancel()
+
+		if p.t.Failed() {
+			p.artifacts()
+		}
+	})
+
+	p.Eval(helperScript, nil)
+}
+
+// artifacts logs the console output and saves a screenshot of a failed test.
+func (p *Page) artifacts() {
+	p.mu.Lock()
+	console := p.console
+	p.mu.Unlock()
+
+	if len(console) > 0 {
+		p.t.Logf("saucere2e: console of the page:\n%s", strings.Join(console, "\n"))
+	}
+
+	name := strings.Trim(unsafeName.ReplaceAllString(p.t.Name(), "_"), "_")
+
+	path, err := p.screenshot(name)
+	if err != nil {
+		p.t.Logf("saucere2e: screenshot of the failed test: %v", err)
+		return
+	}
+	p.t.Logf("saucere2e: screenshot of the failed test: %s", path)
+}
+
+// Webview returns the webview under test.
+func (p *Page) Webview() *saucerw.Webview {
+	return p.view
+}
+
+// context returns the context of an action.
+func (p *Page) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), p.timeout)
+}
+
+// Eval evaluates expr in the page and decodes its result into out, see
+// saucerw.Webview.Eval.
+func (p *Page) Eval(expr string, out any) {
+	p.t.Helper()
+
+	ctx, cancel := p.context()
+	defer cancel()
+
+	if err := p.view.Eval(ctx, expr, out); err != nil {
+		p.t.Fatalf("saucere2e: eval: %v", err)
+	}
+}
+
+// call calls the helper fn with args and decodes its result into out.
+func (p *Page) call(fn string, out any, args ...any) {
+	p.t.Helper()
+
+	ctx, cancel := p.context()
+	defer cancel()
+
+	if err := p.view.Call(ctx, "window.saucere2e."+fn, out, args...); err != nil {
+		quoted, _ := json.Marshal(args)
+		p.t.Fatalf("saucere2e: %s%s: %v", fn, quoted, err)
+	}
+}
+
+// Navigate loads url and waits until the page finished loading.
+func (p *Page) Navigate(url string) {
+	p.t.Helper()
+
+	loaded := make(chan struct{}, 1)
+	sub := p.view.OnLoad(func(state saucerw.LoadState) {
+		if state == saucerw.LoadFinished {
+			select {
+			case loaded <- struct{}{}:
+			default:
+			}
+		}
+	})
+	defer sub.Cancel()
+
+	p.view.Navigate(url)
+
+	select {
+	case <-loaded:
+	case <-time.After(p.timeout):
+		p.t.Fatalf("saucere2e: %s did not load within %v", url, p.timeout)
+	}
+}
+
+// Exists reports whether an element matches selector.
+func (p *Page) Exists(selector string) bool {
+	p.t.Helper()
+
+	var rtn bool
+	p.call("exists", &rtn, selector)
+	return rtn
+}
+
+// Count returns the number of elements matching selector.
+func (p *Page) Count(selector string) int {
+	p.t.Helper()
+
+	var rtn int
+	p.call("count", &rtn, selector)
+	return rtn
+}
+
+// Visible reports whether an element matches selector and is rendered.
+func (p *Page) Visible(selector string) bool {
+	p.t.Helper()
+
+	var rtn bool
+	p.call("visible", &rtn, selector)
+	return rtn
+}
+
+// Text returns the text content of the element matching selector.
+func (p *Page) Text(selector string) string {
+	p.t.Helper()
+
+	var rtn string
+	p.call("text", &rtn, selector)
+	return rtn
+}
+
+// HTML returns the outer HTML of the element matching selector.
+func (p *Page) HTML(selector string) string {
+	p.t.Helper()
+
+	var rtn string
+	p.call("html", &rtn, selector)
+	return rtn
+}
+
+// Value returns the value of the form control matching selector.
+func (p *Page) Value(selector string) string {
+	p.t.Helper()
+
+	var rtn string
+	p.call("value", &rtn, selector)
+	return rtn
+}
+
+// Attr returns the attribute name of the element matching selector, empty if
+// it is not set.
+func (p *Page) Attr(selector, name string) string {
+	p.t.Helper()
+
+	var rtn string
+	p.call("attr", &rtn, selector, name)
+	return rtn
+}
+
+// Click waits for the element matching selector, scrolls it into view and
+// clicks it, dispatching the pointer and mouse events of a click.
+func (p *Page) Click(selector string) {
+	p.t.Helper()
+
+	p.WaitFor(selector)
+	p.call("click", nil, selector)
+}
+
+// Type waits for the element matching selector, focuses it and types text
+// at the end of its value, dispatching the keyboard and input events of
+// every character and a change event.
+func (p *Page) Type(selector, text string) {
+	p.t.Helper()
+
+	p.WaitFor(selector)
+	p.call("type", nil, selector, text)
+}
+
+// NativeClick waits for the element matching selector, scrolls it into view
+// and clicks its center with the native input of saucerw.Webview.SendMouse,
+// which the page sees as a trusted click of the user.
+func (p *Page) NativeClick(selector string) {
+	p.t.Helper()
+
+	p.WaitFor(selector)
+
+	var center [2]int
+	p.call("center", &center, selector)
+
+	input := saucerw.MouseInput{Action: saucerw.MouseClick, Position: saucerw.Position{X: center[0], Y: center[1]}}
+	if err := p.view.SendMouse(input); err != nil {
+		p.t.Fatalf("saucere2e: click %s: %v", selector, err)
+	}
+}
+
+// NativeType clicks the element matching selector like NativeClick and
+// inserts text with saucerw.Webview.SendText.
+func (p *Page) NativeType(selector, text string) {
+	p.t.Helper()
+
+	p.NativeClick(selector)
+	if err := p.view.SendText(text); err != nil {
+		p.t.Fatalf("saucere2e: type %s: %v", selector, err)
+	}
+}
+
+// Press presses and releases key, e.g. "Enter" or "CmdOrCtrl+A", in the
+// focused element with saucerw.Webview.SendKey.
+func (p *Page) Press(key string) {
+	p.t.Helper()
+
+	if err := p.view.SendKey(key); err != nil {
+		p.t.Fatalf("saucere2e: press %s: %v", key, err)
+	}
+}
+
+// Clear waits for the element matching selector and clears its value.
+func (p *Page) Clear(selector string) {
+	p.t.Helper()
+
+	p.WaitFor(selector)
+	p.call("clear", nil, selector)
+}
+
+// Wait waits until the JavaScript expression cond is truthy, evaluating it
+// repeatedly. Exceptions thrown by cond count as false; the last one is
+// reported if the wait timed out.
+func (p *Page) Wait(cond string) {
+	p.t.Helper()
+
+	deadline := time.Now().Add(p.timeout)
+
+	var last error
+	for {
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+
+		var ok bool
+		err := p.view.Eval(ctx, "!!("+cond+")", &ok)
+		cancel()
+
+		switch {
+		case err == nil && ok:
+			return
+		case err != nil && !errors.Is(err, context.DeadlineExceeded):
+			last = err
+		}
+
+		if time.Until(deadline) < pollInterval {
+			if last != nil {
+				p.t.Fatalf("saucere2e: timed out after %v waiting for %s: %v", p.timeout, cond, last)
+			}
+			p.t.Fatalf("saucere2e: timed out after %v waiting for %s", p.timeout, cond)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// WaitFor waits until an element matches selector.
+func (p *Page) WaitFor(selector string) {
+	p.t.Helper()
+
+	p.Wait(fmt.Sprintf("document.querySelector(%s)", quote(selector)))
+}
+
+// WaitVisible waits until an element matching selector is rendered.
+func (p *Page) WaitVisible(selector string) {
+	p.t.Helper()
+
+	p.Wait(fmt.Sprintf("window.saucere2e.visible(%s)", quote(selector)))
+}
+
+// WaitText waits until the text content of the element matching selector
+// contains text.
+func (p *Page) WaitText(selector, text string) {
+	p.t.Helper()
+
+	p.Wait(fmt.Sprintf("document.querySelector(%s)?.textContent.includes(%s)", quote(selector), quote(text)))
+}
+
+// Screenshot saves a screenshot of the page as name.png in
+// Options.ArtifactsDir and returns its path.
+func (p *Page) Screenshot(name string) string {
+	p.t.Helper()
+
+	path, err := p.screenshot(name)
+	if err != nil {
+		p.t.Fatalf("saucere2e: screenshot: %v", err)
+	}
+	return path
+}
+
+// screenshot saves a screenshot as name.png in the artifacts directory.
+func (p *Page) screenshot(name string) (string, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	img, err := p.view.CaptureImage(ctx, image.Rectangle{})
+	if err != nil {
+		return "", err
+	}
+
+	dir := harness.opts.ArtifactsDir
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, name+".png")
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// quote returns s as a JavaScript string literal.
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,164 @@
+// Package saucere2e runs end-to-end tests of an application with go test,
+// against a real webview driven through the bridge. Unlike a browser pointed
+// at a dev server, the tests exercise the custom schemes, exposed functions
+// and injected scripts of the application.
+//
+// Main runs the application on the main goroutine of the test binary and
+// the tests on another. Each test drives the page with the Page returned by
+// Open, which queries the DOM, clicks, types and waits for conditions, and
+// saves a screenshot when the test failed:
+//
+//	func TestMain(m *testing.M) {
+//		saucere2e.Main(m, saucere2e.Options{
+//			App: saucerw.AppOptions{ID: "com.example.app", Schemes: []string{"app"}},
+//			Setup: func(app *saucerw.Application) (*saucerw.Webview, error) {
+//				return myapp.Open(app)
+//			},
+//		})
+//	}
+//
+//	func TestLogin(t *testing.T) {
+//		p := saucere2e.Open(t)
+//		p.Navigate("app://localhost/login")
+//		p.Type("#user", "alice")
+//		p.Click("button[type=submit]")
+//		p.WaitText("#greeting", "Hello alice")
+//	}
+//
+// Click and Type dispatch the DOM events from a script. NativeClick,
+// NativeType and Press send native input instead, which the page sees as
+// trusted events of the user, on the backends supporting it.
+//
+// The application is Headless unless Options.Visible, so the tests run on CI
+// machines without a screen. Tests share the webview and must not run in
+// parallel. Without a native driver, e.g. when built without cgo, the tests
+// calling Open are skipped.
+package saucere2e
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// ArtifactsEnv is the environment variable naming the default directory of
+// the screenshots of failed tests.
+const ArtifactsEnv = "SAUCER_E2E_ARTIFACTS"
+
+// Options configures Main.
+type Options struct {
+	// App configures the application, it is made Headless unless Visible.
+	App saucerw.AppOptions
+	// Driver creates the application, the native driver if nil.
+	Driver saucerw.Driver
+	// Setup creates the window and webview under test on the event loop
+	// thread, ideally with the code the application starts with. The window
+	// is shown afterwards.
+	Setup func(app *saucerw.Application) (*saucerw.Webview, error)
+	// Timeout bounds every action and wait of a Page, 10 seconds if zero.
+	Timeout time.Duration
+	// Visible shows the window on the screen, e.g. to watch the tests.
+	Visible bool
+	// ArtifactsDir receives the screenshots of failed tests, ArtifactsEnv or
+	// saucere2e below the temporary directory if empty.
+	ArtifactsDir string
+}
+
+// harness is the application run by Main.
+var harness struct {
+	opts Options
+	view *saucerw.Webview
+	err  error
+}
+
+// Main runs the application and the tests of m and exits with their exit
+// code. It has to be called by TestMain.
+func Main(m *testing.M, opts Options) {
+	os.Exit(run(m, opts))
+}
+
+// run runs the tests of m with the application of opts.
+func run(m *testing.M, opts Options) int {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.ArtifactsDir == "" {
+		opts.ArtifactsDir = os.Getenv(ArtifactsEnv)
+	}
+	if opts.ArtifactsDir == "" {
+		opts.ArtifactsDir = filepath.Join(os.TempDir(), "saucere2e")
+	}
+
+	app := opts.App
+	app.Headless = app.Headless || !opts.Visible
+
+	harness.opts = opts
+
+	var (
+		a   *saucerw.Application
+		err error
+	)
+	if opts.Driver != nil {
+		a, err = saucerw.NewApplicationWithDriver(opts.Driver, app)
+	} else {
+		a, err = saucerw.NewApplication(app)
+	}
+	if err != nil {
+		harness.err = err
+		return m.Run()
+	}
+
+	code := 1
+	a.Run(func(a *saucerw.Application) {
+		harness.view, harness.err = setup(a, opts)
+
+		go func() {
+			defer a.Quit()
+			code = m.Run()
+		}()
+	})
+	return code
+}
+
+// setup creates the webview under test and installs the helpers of Page.
+func setup(app *saucerw.Application, opts Options) (*saucerw.Webview, error) {
+	if opts.Setup == nil {
+		return nil, errors.New("saucere2e: Options.Setup is required")
+	}
+
+	view, err := opts.Setup(app)
+	if err != nil {
+		return nil, fmt.Errorf("saucere2e: setup: %w", err)
+	}
+	if view == nil {
+		return nil, errors.New("saucere2e: setup returned no webview")
+	}
+
+	view.InjectScript(saucerw.Script{Code: helperScript, Time: saucerw.AtCreation, Frames: saucerw.MainFrame, Permanent: true})
+	view.Parent().Show()
+	return view, nil
+}
+
+// Open returns the page under test for t, which it fails on errors. It skips
+// t without a native driver and fails it if Main did not run.
+func Open(t testing.TB) *Page {
+	t.Helper()
+
+	switch {
+	case errors.Is(harness.err, saucerw.ErrNoDriver):
+		t.Skipf("saucere2e: %v", harness.err)
+	case harness.err != nil:
+		t.Fatal(harness.err)
+	case harness.view == nil:
+		t.Fatal("saucere2e: Open needs Main to be called by TestMain")
+	}
+
+	p := &Page{t: t, view: harness.view, timeout: harness.opts.Timeout}
+	p.start()
+	return p
+}
@@ -0,0 +1,75 @@
+package saucertest
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw/display"
+)
+
+// VirtualDisplayEnv is the environment variable making RequireDisplay start a
+// virtual display instead of skipping: "1" or "auto" for any server, "xvfb"
+// or "weston" for that one.
+const VirtualDisplayEnv = "SAUCER_VIRTUAL_DISPLAY"
+
+// virtual is the display started by RequireDisplay, shared by the tests of
+// the process since the toolkit connects to a display once.
+var virtual struct {
+	sync.Mutex
+	started bool
+	err     error
+}
+
+// RequireDisplay skips t if the machine has no display, for tests using the
+// native driver rather than this package. With VirtualDisplayEnv set it
+// starts a virtual display for the process instead, failing t if that is not
+// possible; the server stops when the test binary exits.
+//
+//	func TestRender(t *testing.T) {
+//		saucertest.RequireDisplay(t)
+//		...
+//	}
+func RequireDisplay(t testing.TB) {
+	t.Helper()
+
+	virtual.Lock()
+	defer virtual.Unlock()
+
+	if virtual.started {
+		if virtual.err != nil {
+			t.Fatalf("saucertest: virtual display: %v", virtual.err)
+		}
+		return
+	}
+
+	if display.Available() {
+		return
+	}
+
+	server := os.Getenv(VirtualDisplayEnv)
+	switch server {
+	case "":
+		t.Skipf("saucertest: no display, set %s=1 to start a virtual one", VirtualDisplayEnv)
+	case "1", "auto":
+		server = ""
+	}
+
+	virtual.started = true
+	virtual.err = func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		d, err := display.Start(ctx, display.Options{Server: display.Server(server)})
+		if err != nil {
+			return err
+		}
+		return d.Setenv()
+	}()
+
+	if virtual.err != nil {
+		t.Fatalf("saucertest: virtual display: %v", virtual.err)
+	}
+}
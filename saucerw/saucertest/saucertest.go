@@ -0,0 +1,508 @@
+// Package saucertest implements the saucerw driver in Go, for testing
+// applications without a native toolkit, a display or cgo.
+//
+// The webviews show a scripted fake page instead of rendering anything: a
+// test calls exposed functions through it like the page would, answers the
+// expressions passed to Eval, fetches from custom schemes and fires
+// navigation, permission and window events.
+//
+// Like a native application, the application runs its event loop on the
+// goroutine that created it, the test's. Run the test on another goroutine
+// started once the loop is running:
+//
+//	drv := saucertest.New()
+//	app, err := saucerw.NewApplicationWithDriver(drv, saucerw.AppOptions{ID: "com.example.test"})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//
+//	app.Run(func(app *saucerw.Application) {
+//		win, _ := app.NewWindow(saucerw.WindowOptions{})
+//		view, _ := saucerw.NewWebview(saucerw.WebviewOptions{Window: win})
+//		view.Expose("add", func(a, b int) int { return a + b })
+//
+//		page := drv.App().Windows()[0].Webviews()[0]
+//
+//		go func() {
+//			defer app.Quit()
+//
+//			result, err := page.Call(context.Background(), "add", 1, 2)
+//			if err != nil || string(result) != "3" {
+//				t.Errorf("add = %s, %v", result, err)
+//			}
+//		}()
+//	})
+package saucertest
+
+import (
+	"errors"
+	"image"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/internal/goid"
+)
+
+// ErrLoopThread is returned by the calls of the fake page that wait for the
+// application when they are made from the event loop thread.
+var ErrLoopThread = errors.New("saucertest: call would block the event loop thread")
+
+// Driver is a saucerw.Driver creating applications that run entirely in Go.
+type Driver struct {
+	mu   sync.Mutex
+	apps []*App
+}
+
+// New returns a new driver.
+func New() *Driver {
+	return &Driver{}
+}
+
+// NewApp creates the fake application. It implements saucerw.Driver.
+func (d *Driver) NewApp(opts saucerw.AppOptions) (saucerw.AppDriver, error) {
+	a := &App{
+		opts:    opts,
+		loop:    goid.New(),
+		wake:    make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+		screens: []saucerw.Screen{{
+			Name:  "saucertest",
+			Size:  saucerw.Size{W: 1920, H: 1080},
+			Scale: 1,
+		}},
+		cookies: map[cookieKey]*http.Cookie{},
+	}
+
+	d.mu.Lock()
+	d.apps = append(d.apps, a)
+	d.mu.Unlock()
+
+	return a, nil
+}
+
+// App returns the application created last, nil if there is none.
+func (d *Driver) App() *App {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.apps) == 0 {
+		return nil
+	}
+	return d.apps[len(d.apps)-1]
+}
+
+// App is a fake application, whose event loop runs posted functions on the
+// goroutine that created it.
+type App struct {
+	opts saucerw.AppOptions
+	// loop is the goroutine running the event loop.
+	loop *goid.Loop
+
+	mu       sync.Mutex
+	posted   []func()
+	running  bool
+	quit     bool
+	released bool
+	windows  []*Window
+
+	wake    chan struct{}
+	stopped chan struct{}
+	stop    sync.Once
+
+	screens     []saucerw.Screen
+	text        string
+	hasText     bool
+	img         *image.NRGBA
+	clipboardFn func()
+	scheme      saucerw.ColorScheme
+	schemeFn    func(saucerw.ColorScheme)
+	locales     []string
+	localesFn   func([]string)
+	access      saucerw.Accessibility
+	accessFn    func(saucerw.Accessibility)
+	powerFn     func(saucerw.PowerEvent, func())
+
+	cookies map[cookieKey]*http.Cookie
+}
+
+// Options returns the options the application was created with.
+func (a *App) Options() saucerw.AppOptions {
+	return a.opts
+}
+
+// Run runs posted functions until Quit is called, calling start first.
+func (a *App) Run(start func()) int {
+	a.mu.Lock()
+	a.running = true
+	a.mu.Unlock()
+
+	defer a.stop.Do(func() { close(a.stopped) })
+
+	start()
+
+	for {
+		a.mu.Lock()
+		posted := a.posted
+		a.posted = nil
+		quit := a.quit
+		a.running = !quit
+		a.mu.Unlock()
+
+		if quit {
+			return 0
+		}
+
+		for _, fn := range posted {
+			fn()
+		}
+
+		if len(posted) == 0 {
+			a.loop.Idle(true)
+			<-a.wake
+			a.loop.Idle(false)
+		}
+	}
+}
+
+// Quit stops the event loop, functions posted but not run yet are dropped.
+func (a *App) Quit() {
+	a.mu.Lock()
+	a.quit = true
+	a.mu.Unlock()
+
+	a.notify()
+}
+
+// Post schedules fn on the event loop.
+func (a *App) Post(fn func()) {
+	a.mu.Lock()
+	if a.quit {
+		a.mu.Unlock()
+		return
+	}
+	a.posted = append(a.posted, fn)
+	a.mu.Unlock()
+
+	a.notify()
+}
+
+// notify wakes the event loop.
+func (a *App) notify() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ThreadSafe reports whether the caller runs on the goroutine of the event
+// loop.
+func (a *App) ThreadSafe() bool {
+	return a.loop.On()
+}
+
+// invoke runs fn on the event loop and waits for it, or runs it right away if
+// the caller is on the loop or the loop is not running.
+func (a *App) invoke(fn func()) {
+	a.mu.Lock()
+	running := a.running && !a.quit
+	a.mu.Unlock()
+
+	if !running || a.ThreadSafe() {
+		fn()
+		return
+	}
+
+	done := make(chan struct{})
+	a.Post(func() {
+		defer close(done)
+		fn()
+	})
+
+	select {
+	case <-done:
+	case <-a.stopped:
+	}
+}
+
+// call runs fn on the event loop without waiting for it, right away if the
+// caller is on the loop.
+func (a *App) call(fn func()) {
+	if a.ThreadSafe() {
+		fn()
+		return
+	}
+	a.Post(fn)
+}
+
+// wait runs fn on the event loop, which must be running, and waits for it.
+// It returns ErrLoopThread instead of blocking the loop.
+func (a *App) wait(fn func()) error {
+	if a.ThreadSafe() {
+		return ErrLoopThread
+	}
+
+	a.mu.Lock()
+	running := a.running && !a.quit
+	a.mu.Unlock()
+
+	if !running {
+		return saucerw.ErrNotRunning
+	}
+
+	a.invoke(fn)
+	return nil
+}
+
+// Screens returns the screens set with SetScreens, one screen of 1920x1080
+// pixels by default.
+func (a *App) Screens() []saucerw.Screen {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return slices.Clone(a.screens)
+}
+
+// SetScreens replaces the attached screens.
+func (a *App) SetScreens(screens []saucerw.Screen) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.screens = slices.Clone(screens)
+}
+
+// ReadClipboardText calls done with the text written last.
+func (a *App) ReadClipboardText(done func(text string, ok bool)) {
+	a.mu.Lock()
+	text, ok := a.text, a.hasText
+	a.mu.Unlock()
+
+	done(text, ok)
+}
+
+// ReadClipboardImage calls done with the image written last or nil.
+func (a *App) ReadClipboardImage(done func(*image.NRGBA)) {
+	a.mu.Lock()
+	img := a.img
+	a.mu.Unlock()
+
+	done(img)
+}
+
+// WriteClipboardText replaces the clipboard contents with text.
+func (a *App) WriteClipboardText(text string) {
+	a.mu.Lock()
+	a.text, a.hasText, a.img = text, true, nil
+	fn := a.clipboardFn
+	a.mu.Unlock()
+
+	if fn != nil {
+		a.call(fn)
+	}
+}
+
+// WriteClipboardImage replaces the clipboard contents with img.
+func (a *App) WriteClipboardImage(img *image.NRGBA) {
+	a.mu.Lock()
+	a.text, a.hasText, a.img = "", false, img
+	fn := a.clipboardFn
+	a.mu.Unlock()
+
+	if fn != nil {
+		a.call(fn)
+	}
+}
+
+// HandleClipboard sets the function called when the clipboard changed.
+func (a *App) HandleClipboard(fn func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.clipboardFn = fn
+}
+
+// ColorScheme returns the scheme set with SetColorScheme, light by default.
+func (a *App) ColorScheme() saucerw.ColorScheme {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.scheme
+}
+
+// SetColorScheme switches the color scheme of the system, like the user.
+func (a *App) SetColorScheme(scheme saucerw.ColorScheme) {
+	a.mu.Lock()
+	changed := a.scheme != scheme
+	a.scheme = scheme
+	fn := a.schemeFn
+	a.mu.Unlock()
+
+	if changed && fn != nil {
+		a.call(func() { fn(scheme) })
+	}
+}
+
+// HandleColorScheme sets the function called when the scheme changed.
+func (a *App) HandleColorScheme(fn func(saucerw.ColorScheme)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.schemeFn = fn
+}
+
+// Locales returns the locales set with SetLocales, en-US by default.
+func (a *App) Locales() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.locales == nil {
+		return []string{"en-US"}
+	}
+	return slices.Clone(a.locales)
+}
+
+// SetLocales switches the languages of the system, like the user.
+func (a *App) SetLocales(locales []string) {
+	locales = slices.Clone(locales)
+
+	a.mu.Lock()
+	changed := !slices.Equal(a.locales, locales)
+	a.locales = locales
+	fn := a.localesFn
+	a.mu.Unlock()
+
+	if changed && fn != nil {
+		a.call(func() { fn(slices.Clone(locales)) })
+	}
+}
+
+// HandleLocales sets the function called when the locales changed.
+func (a *App) HandleLocales(fn func([]string)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.localesFn = fn
+}
+
+// Accessibility returns the state set with SetAccessibility, without screen
+// reader and preferences by default.
+func (a *App) Accessibility() saucerw.Accessibility {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.access
+}
+
+// SetAccessibility switches the accessibility state of the system, like the
+// user starting a screen reader or changing the preferences.
+func (a *App) SetAccessibility(state saucerw.Accessibility) {
+	a.mu.Lock()
+	changed := a.access != state
+	a.access = state
+	fn := a.accessFn
+	a.mu.Unlock()
+
+	if changed && fn != nil {
+		a.call(func() { fn(state) })
+	}
+}
+
+// HandleAccessibility sets the function called when the state changed.
+func (a *App) HandleAccessibility(fn func(saucerw.Accessibility)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.accessFn = fn
+}
+
+// Power reports ev like the system. The returned channel is closed once the
+// application released a PowerShutdown, right away for the other events.
+func (a *App) Power(ev saucerw.PowerEvent) <-chan struct{} {
+	released := make(chan struct{})
+	release := sync.OnceFunc(func() { close(released) })
+
+	a.mu.Lock()
+	fn := a.powerFn
+	a.mu.Unlock()
+
+	if ev != saucerw.PowerShutdown {
+		release()
+	}
+	if fn != nil {
+		a.call(func() { fn(ev, release) })
+	} else {
+		release()
+	}
+	return released
+}
+
+// HandlePower sets the function called with the events passed to Power.
+func (a *App) HandlePower(fn func(saucerw.PowerEvent, func())) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.powerFn = fn
+}
+
+// RemoveWebsiteData does nothing, the fake keeps no data outside of
+// storagePath.
+func (a *App) RemoveWebsiteData(storagePath string, done func(error)) {
+	done(nil)
+}
+
+// NewWindow creates a hidden fake window.
+func (a *App) NewWindow() (saucerw.WindowDriver, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.released {
+		return nil, errors.New("saucertest: application released")
+	}
+
+	w := newWindow(a)
+	a.windows = append(a.windows, w)
+
+	return w, nil
+}
+
+// Windows returns the windows that were not released, in the order they were
+// created.
+func (a *App) Windows() []*Window {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return slices.Clone(a.windows)
+}
+
+// closed quits the application once every window was closed, unless it keeps
+// running.
+func (a *App) closed() {
+	if a.opts.KeepRunning {
+		return
+	}
+
+	for _, w := range a.Windows() {
+		if !w.isClosed() {
+			return
+		}
+	}
+
+	a.Quit()
+}
+
+// forget removes the released window w.
+func (a *App) forget(w *Window) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.windows = slices.DeleteFunc(a.windows, func(other *Window) bool { return other == w })
+}
+
+// Release frees the application.
+func (a *App) Release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.released = true
+}
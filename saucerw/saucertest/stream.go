@@ -0,0 +1,183 @@
+package saucertest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// The scripts of the bridge streaming the result of a call.
+var (
+	streamedScript = regexp.MustCompile(`^window\.saucer\.internal\.streamed\((\d+), (\d+)\);$`)
+	streamScript   = regexp.MustCompile(`^window\.saucer\.internal\.stream\((\d+), "(data|end|error)", (.*)\);$`)
+)
+
+// streamBuffer is the number of items a Stream has room for, like the page.
+const streamBuffer = 64
+
+// streamItem is an item, the end or the error of a stream.
+type streamItem struct {
+	op    string
+	value json.RawMessage
+}
+
+// Stream reads a streamed result like the page would, see Webview.Stream.
+type Stream struct {
+	view  *Webview
+	name  string
+	id    uint64
+	items chan streamItem
+	// done is closed once the stream is canceled or the page navigated away.
+	done chan struct{}
+}
+
+// Stream calls the exposed function name like Call, which has to stream its
+// result, and returns the stream. It grants Go the room of the page, 64
+// items, and one more for every item read.
+func (v *Webview) Stream(ctx context.Context, name string, params ...any) (*Stream, error) {
+	result, err := v.Call(ctx, name, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref struct {
+		ID uint64 `json:"saucer:stream"`
+	}
+	if json.Unmarshal(result, &ref) != nil || ref.ID == 0 {
+		return nil, fmt.Errorf("saucertest: %s did not stream its result", name)
+	}
+
+	v.mu.Lock()
+	s := v.results[ref.ID]
+	v.mu.Unlock()
+
+	if s == nil {
+		return nil, fmt.Errorf("saucertest: stream of %s is gone", name)
+	}
+	s.name = name
+
+	if err := v.send(map[string]any{"saucer:stream": s.id, "op": "credit", "credit": streamBuffer}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Next decodes the next item into out, which may be nil to discard it. It
+// returns io.EOF at the end of the stream, a *CallError if the function
+// failed and saucerw.ErrStreamCanceled once the stream was canceled.
+func (s *Stream) Next(ctx context.Context, out any) error {
+	var item streamItem
+
+	select {
+	case item = <-s.items:
+	case <-s.done:
+		return saucerw.ErrStreamCanceled
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	switch item.op {
+	case "end":
+		return io.EOF
+	case "error":
+		rtn := &CallError{Name: s.name}
+		if json.Unmarshal(item.value, rtn) != nil {
+			rtn.Message = string(item.value)
+		}
+		return rtn
+	}
+
+	if err := s.view.send(map[string]any{"saucer:stream": s.id, "op": "credit", "credit": 1}); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(item.value, out)
+}
+
+// Cancel stops reading the stream like leaving a for await loop early.
+func (s *Stream) Cancel() error {
+	if !s.view.dropStream(s.id) {
+		return nil
+	}
+	return s.view.send(map[string]any{"saucer:stream": s.id, "op": "cancel"})
+}
+
+// streamed handles a script of the bridge streaming a result and reports
+// whether line is one. It runs on the event loop.
+func (v *Webview) streamed(line string) bool {
+	if m := streamedScript.FindStringSubmatch(line); m != nil {
+		call, _ := strconv.ParseUint(m[1], 10, 64)
+		id, _ := strconv.ParseUint(m[2], 10, 64)
+
+		// Like the page, a stream nobody waits for is canceled right away
+		v.mu.Lock()
+		_, waiting := v.calls[call]
+		if waiting {
+			v.results[id] = &Stream{view: v, id: id, items: make(chan streamItem, streamBuffer+1), done: make(chan struct{})}
+		}
+		v.mu.Unlock()
+
+		if !waiting {
+			go v.send(map[string]any{"saucer:stream": id, "op": "cancel"})
+			return true
+		}
+
+		v.settle(call, settled{value: json.RawMessage(`{"saucer:stream":` + m[2] + `}`)})
+		return true
+	}
+
+	m := streamScript.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+
+	id, _ := strconv.ParseUint(m[1], 10, 64)
+
+	v.mu.Lock()
+	s := v.results[id]
+	if s != nil && m[2] != "data" {
+		delete(v.results, id)
+	}
+	v.mu.Unlock()
+
+	if s != nil {
+		select {
+		case s.items <- streamItem{op: m[2], value: json.RawMessage(m[3])}:
+		default:
+			// Go sent more than it was granted
+			panic(errors.New("saucertest: stream " + m[1] + " overflowed"))
+		}
+	}
+	return true
+}
+
+// dropStream forgets stream id, reporting whether it was open.
+func (v *Webview) dropStream(id uint64) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	s, ok := v.results[id]
+	if ok {
+		delete(v.results, id)
+		close(s.done)
+	}
+	return ok
+}
+
+// dropStreams forgets the streams of a page that navigated away. The caller
+// holds the lock.
+func (v *Webview) dropStreams() {
+	for id, s := range v.results {
+		delete(v.results, id)
+		close(s.done)
+	}
+}
@@ -0,0 +1,1215 @@
+package saucertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/pdf"
+)
+
+// ErrReleased is returned by the calls of the fake page that wait for a
+// webview released in the meantime.
+var ErrReleased = errors.New("saucertest: webview released")
+
+// CallError is returned by Webview.Call when the exposed function failed,
+// carrying the Error the promise of the page was rejected with, see
+// saucerw.BridgeError.
+type CallError struct {
+	Name    string
+	Message string
+	Code    string
+	Chain   []string
+	Data    json.RawMessage
+}
+
+func (e *CallError) Error() string {
+	return fmt.Sprintf("saucertest: call %s: %s", e.Name, e.Message)
+}
+
+// The scripts of the bridge the fake page understands.
+var (
+	settleScript  = regexp.MustCompile(`^window\.saucer\.internal\.rpc\[(\d+)\]\?\.(?:resolve\((.*)\)|reject\(window\.saucer\.internal\.error\((.*)\)\)); delete window\.saucer\.internal\.rpc\[\d+\];$`)
+	evalScript    = regexp.MustCompile(`(?s)^window\.saucer\.internal\.resolve\((\d+), async \(\) => \((.*)\)\);$`)
+	receiveScript = regexp.MustCompile(`^window\.saucer\.internal\.receive\((".*")\);$`)
+	frameScript   = regexp.MustCompile(`^window\.saucerFrames\.eval\(\.\.\.(\[.*\])\);$`)
+)
+
+// cookieKey identifies a cookie in the data store of the application.
+type cookieKey struct {
+	name, domain, path string
+}
+
+// settled is the result of a call settled by Go.
+type settled struct {
+	rejected bool
+	value    json.RawMessage
+}
+
+// Webview is a fake webview and the page it shows. As a driver it records
+// what the application does to the page, its methods not part of
+// saucerw.WebviewDriver act like the page or the user. Scripts are not run,
+// except those of the bridge: results of exposed functions settle the calls
+// made with Call and Stream, Eval expressions are answered by the function passed to
+// HandleEval and payloads of SendBytes are fetched into Received.
+type Webview struct {
+	window *Window
+	app    *App
+	opts   saucerw.WebviewOptions
+
+	mu         sync.Mutex
+	url        string
+	html       string
+	history    []string
+	current    int
+	title      string
+	background saucerw.Color
+	zoom       float64
+	devTools   bool
+	muted      bool
+	darkMode   saucerw.DarkMode
+	embedded   map[string]saucerw.EmbeddedFile
+	scripts    map[uint64]saucerw.Script
+	lastScript uint64
+	executed   []string
+	evals      []string
+	edits      []saucerw.Role
+	keys       []saucerw.KeyInput
+	texts      []string
+	mouse      []saucerw.MouseInput
+	received   map[string][]byte
+	calls      map[uint64]chan settled
+	results    map[uint64]*Stream
+	lastCall   uint64
+	uploads    uint64
+	released   bool
+	gone       chan struct{}
+
+	evalFn       func(expr string) (any, error)
+	frameEvalFn  func(frame, expr string) (any, error)
+	messageFn    func(string) bool
+	navigateFn   func(saucerw.NavigationEvent) saucerw.Policy
+	permissionFn func(string, saucerw.Permission, func(bool)) saucerw.PermissionDecision
+	downloadFn   func(saucerw.DownloadRequest) saucerw.DownloadDecision
+	eventsFn     func(saucerw.WebviewEvent)
+	dropFn       func(saucerw.FileDrop)
+	contextFn    func(saucerw.ContextInfo, bool) ([]saucerw.MenuEntry, bool)
+	contextClick func(int32)
+	schemes      map[string]func(saucerw.SchemeRequest, func(saucerw.SchemeResponse))
+	streams      map[string]func(saucerw.SchemeRequest, saucerw.SchemeStream)
+}
+
+func newWebview(w *Window, opts saucerw.WebviewOptions) *Webview {
+	return &Webview{
+		window:     w,
+		app:        w.app,
+		opts:       opts,
+		current:    -1,
+		background: saucerw.Color{R: 255, G: 255, B: 255, A: 255},
+		zoom:       1,
+		embedded:   map[string]saucerw.EmbeddedFile{},
+		scripts:    map[uint64]saucerw.Script{},
+		received:   map[string][]byte{},
+		calls:      map[uint64]chan settled{},
+		results:    map[uint64]*Stream{},
+		gone:       make(chan struct{}),
+		schemes:    map[string]func(saucerw.SchemeRequest, func(saucerw.SchemeResponse)){},
+		streams:    map[string]func(saucerw.SchemeRequest, saucerw.SchemeStream){},
+	}
+}
+
+// Options returns the options the webview was created with.
+func (v *Webview) Options() saucerw.WebviewOptions {
+	return v.opts
+}
+
+func (v *Webview) URL() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.url
+}
+
+func (v *Webview) PageTitle() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.title
+}
+
+// SetPageTitle changes the title of the page, like its document.title.
+func (v *Webview) SetPageTitle(title string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.title = title
+}
+
+// HTML returns the document set with SetHTML, empty once the page navigated
+// elsewhere.
+func (v *Webview) HTML() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.html
+}
+
+// SetURL navigates to url once the navigation handler allowed it.
+func (v *Webview) SetURL(url string) {
+	v.Navigate(saucerw.NavigationEvent{URL: url})
+}
+
+func (v *Webview) SetHTML(html string) {
+	v.app.invoke(func() {
+		v.mu.Lock()
+		v.pushHistory("about:blank")
+		v.html = html
+		v.mu.Unlock()
+
+		v.load()
+	})
+}
+
+// Navigate asks the navigation handler about ev like the page and loads the
+// URL if it is allowed and not meant for a new window. It returns the
+// decision of the handler.
+func (v *Webview) Navigate(ev saucerw.NavigationEvent) saucerw.Policy {
+	policy := saucerw.Allow
+
+	v.app.invoke(func() {
+		v.mu.Lock()
+		fn := v.navigateFn
+		v.mu.Unlock()
+
+		if fn != nil {
+			policy = fn(ev)
+		}
+
+		if policy == saucerw.Block || ev.NewWindow {
+			return
+		}
+
+		v.mu.Lock()
+		v.pushHistory(ev.URL)
+		v.html = ""
+		v.mu.Unlock()
+
+		v.load()
+	})
+
+	return policy
+}
+
+// pushHistory makes url the current entry of the history, dropping the
+// entries after the current one. The caller holds the lock.
+func (v *Webview) pushHistory(url string) {
+	v.history = append(v.history[:v.current+1], url)
+	v.current = len(v.history) - 1
+	v.url = url
+}
+
+// load reports the loading of the current page like a browser, forgetting
+// the state of the page before. It runs on the event loop.
+func (v *Webview) load() {
+	v.mu.Lock()
+	v.title = ""
+	clear(v.received)
+	v.dropStreams()
+	fn := v.eventsFn
+	v.mu.Unlock()
+
+	if fn == nil {
+		return
+	}
+
+	fn(saucerw.WebviewEvent{Type: saucerw.WebviewLoad, Load: saucerw.LoadStarted})
+	fn(saucerw.WebviewEvent{Type: saucerw.WebviewDomReady})
+	fn(saucerw.WebviewEvent{Type: saucerw.WebviewLoad, Load: saucerw.LoadFinished})
+}
+
+// step moves through the history by delta entries if there are any.
+func (v *Webview) step(delta int) {
+	v.app.invoke(func() {
+		v.mu.Lock()
+		next := v.current + delta
+		if next < 0 || next >= len(v.history) {
+			v.mu.Unlock()
+			return
+		}
+		v.current, v.url = next, v.history[next]
+		v.mu.Unlock()
+
+		v.load()
+	})
+}
+
+func (v *Webview) Back()    { v.step(-1) }
+func (v *Webview) Forward() { v.step(1) }
+func (v *Webview) Reload()  { v.step(0) }
+
+func (v *Webview) Background() saucerw.Color {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.background
+}
+
+func (v *Webview) SetBackground(color saucerw.Color) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.background = color
+}
+
+func (v *Webview) Edit(role saucerw.Role) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.edits = append(v.edits, role)
+}
+
+// Edits returns the editing roles performed in the page, in order.
+func (v *Webview) Edits() []saucerw.Role {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return slices.Clone(v.edits)
+}
+
+func (v *Webview) DevTools() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.devTools
+}
+
+func (v *Webview) SetDevTools(open bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.devTools = open
+}
+
+func (v *Webview) Muted() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.muted
+}
+
+func (v *Webview) SetMuted(muted bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.muted = muted
+}
+
+// PlayAudio reports that the page started or stopped playing audio, on the
+// event loop.
+func (v *Webview) PlayAudio(playing bool) {
+	v.mu.Lock()
+	fn := v.eventsFn
+	v.mu.Unlock()
+
+	if fn != nil {
+		v.app.call(func() { fn(saucerw.WebviewEvent{Type: saucerw.WebviewPlayingAudio, Playing: playing}) })
+	}
+}
+
+// Crash reports the render process of the page gone for reason, on the event
+// loop.
+func (v *Webview) Crash(reason saucerw.GoneReason) {
+	v.mu.Lock()
+	fn := v.eventsFn
+	v.mu.Unlock()
+
+	if fn != nil {
+		v.app.call(func() { fn(saucerw.WebviewEvent{Type: saucerw.WebviewProcessGone, Gone: reason}) })
+	}
+}
+
+func (v *Webview) Embed(files []saucerw.EmbeddedFile) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, file := range files {
+		v.embedded[file.Path] = file
+	}
+}
+
+func (v *Webview) Serve(path string) {
+	v.SetURL("saucer://embedded" + path)
+}
+
+func (v *Webview) Unembed() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	clear(v.embedded)
+}
+
+// Execute handles the scripts of the bridge and records the others, see
+// Executed. The lines of batched scripts are handled one by one.
+func (v *Webview) Execute(code string) {
+	if m := evalScript.FindStringSubmatch(code); m != nil {
+		id, _ := strconv.ParseUint(m[1], 10, 64)
+		v.evaluate(id, "", m[2])
+		return
+	}
+
+	var other []string
+
+	for _, line := range strings.Split(code, "\n") {
+		if m := settleScript.FindStringSubmatch(line); m != nil {
+			id, _ := strconv.ParseUint(m[1], 10, 64)
+			// A rejection is always an object, a resolved value never empty.
+			v.settle(id, settled{rejected: m[3] != "", value: json.RawMessage(m[2] + m[3])})
+			continue
+		}
+
+		if v.streamed(line) {
+			continue
+		}
+
+		if m := frameScript.FindStringSubmatch(line); m != nil {
+			var args []json.RawMessage
+			var frame, expr string
+			var id uint64
+			if json.Unmarshal([]byte(m[1]), &args) == nil && len(args) == 4 &&
+				json.Unmarshal(args[1], &frame) == nil && json.Unmarshal(args[2], &id) == nil && json.Unmarshal(args[3], &expr) == nil {
+				v.evaluate(id, frame, expr)
+				continue
+			}
+		}
+
+		if m := receiveScript.FindStringSubmatch(line); m != nil {
+			var name string
+			if json.Unmarshal([]byte(m[1]), &name) == nil {
+				go v.receive(name)
+				continue
+			}
+		}
+
+		other = append(other, line)
+	}
+
+	if len(other) != 0 {
+		v.mu.Lock()
+		v.executed = append(v.executed, strings.Join(other, "\n"))
+		v.mu.Unlock()
+	}
+}
+
+// Executed returns the scripts run in the page that are not part of the
+// bridge protocol, in order.
+func (v *Webview) Executed() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return slices.Clone(v.executed)
+}
+
+// settle delivers the result of the call id.
+func (v *Webview) settle(id uint64, result settled) {
+	v.mu.Lock()
+	ch, ok := v.calls[id]
+	delete(v.calls, id)
+	v.mu.Unlock()
+
+	if ok {
+		ch <- result
+	}
+}
+
+// HandleEval sets the function answering the expressions passed to Eval,
+// called on its own goroutine. Its result is JSON encoded, an error throws
+// its message, or the Error described by a *saucerw.CallError. Without a function every expression
+// evaluates to null.
+func (v *Webview) HandleEval(fn func(expr string) (any, error)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.evalFn = fn
+}
+
+// HandleFrameEval sets the function answering the expressions passed to
+// EvalFrame like HandleEval, with the ID of the frame. Without a function
+// every expression evaluates to null.
+func (v *Webview) HandleFrameEval(fn func(frame, expr string) (any, error)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.frameEvalFn = fn
+}
+
+// Evals returns the expressions evaluated in the page, in order.
+func (v *Webview) Evals() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return slices.Clone(v.evals)
+}
+
+// evaluate answers the evaluation id of expr in the main frame, or in the
+// child frame if set.
+func (v *Webview) evaluate(id uint64, frame, expr string) {
+	v.mu.Lock()
+	fn, frameFn := v.evalFn, v.frameEvalFn
+	if frame == "" {
+		v.evals = append(v.evals, expr)
+	}
+	v.mu.Unlock()
+
+	go func() {
+		var (
+			result any
+			err    error
+		)
+
+		switch {
+		case frame != "" && frameFn != nil:
+			result, err = frameFn(frame, expr)
+		case frame == "" && fn != nil:
+			result, err = fn(expr)
+		}
+
+		msg := map[string]any{"saucer:resolve": true, "id": id, "exception": err != nil, "result": result}
+		if err != nil {
+			msg["result"] = err.Error()
+
+			var ce *saucerw.CallError
+			if errors.As(err, &ce) {
+				msg["result"] = map[string]any{"name": ce.Name, "message": ce.Message, "code": ce.Code, "stack": ce.JSStack}
+			}
+		}
+
+		if data, merr := json.Marshal(msg); merr == nil {
+			_, _ = v.post(string(data))
+		}
+	}()
+}
+
+// receive fetches the payload name sent with SendBytes.
+func (v *Webview) receive(name string) {
+	res, err := v.Fetch(context.Background(), saucerw.SchemeRequest{
+		URL:    "saucerw://stash/out/" + url.PathEscape(name),
+		Method: http.MethodGet,
+	})
+	if err != nil || res.Status != http.StatusOK {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.received[name] = res.Body
+}
+
+// Received returns the payload sent with SendBytes under name, once the page
+// fetched it.
+func (v *Webview) Received(name string) ([]byte, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	data, ok := v.received[name]
+	return data, ok
+}
+
+func (v *Webview) Inject(script saucerw.Script) uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.lastScript++
+	v.scripts[v.lastScript] = script
+
+	return v.lastScript
+}
+
+func (v *Webview) Uninject(id uint64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.scripts, id)
+}
+
+func (v *Webview) UninjectAll() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for id, script := range v.scripts {
+		if !script.Permanent {
+			delete(v.scripts, id)
+		}
+	}
+}
+
+// Scripts returns the injected scripts in the order they were injected.
+func (v *Webview) Scripts() []saucerw.Script {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	ids := make([]uint64, 0, len(v.scripts))
+	for id := range v.scripts {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	rtn := make([]saucerw.Script, len(ids))
+	for i, id := range ids {
+		rtn[i] = v.scripts[id]
+	}
+	return rtn
+}
+
+func (v *Webview) HandleMessage(fn func(message string) bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.messageFn = fn
+}
+
+// Post posts message like window.saucer.internal.message of the page and
+// reports whether the application handled it. It waits for the event loop.
+func (v *Webview) Post(message string) (bool, error) {
+	return v.post(message)
+}
+
+// post passes message to the message handler on the event loop.
+func (v *Webview) post(message string) (handled bool, err error) {
+	if v.isReleased() {
+		return false, ErrReleased
+	}
+
+	err = v.app.wait(func() {
+		v.mu.Lock()
+		fn := v.messageFn
+		v.mu.Unlock()
+
+		if fn != nil {
+			handled = fn(message)
+		}
+	})
+	return handled, err
+}
+
+// send posts the JSON encoding of msg, which the application must handle.
+func (v *Webview) send(msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("saucertest: %w", err)
+	}
+
+	handled, err := v.post(string(data))
+	if err == nil && !handled {
+		err = errors.New("saucertest: message not handled")
+	}
+	return err
+}
+
+// Call calls the exposed function name with params like
+// window.saucer.call(name, params) and returns the JSON encoded result.
+// []byte parameters are uploaded like ArrayBuffers, others are JSON encoded.
+// If ctx is done before the call returned, the call is aborted like with an
+// AbortSignal. Call waits for the event loop and must not be called on its
+// thread.
+func (v *Webview) Call(ctx context.Context, name string, params ...any) (json.RawMessage, error) {
+	return v.call(ctx, nil, name, params)
+}
+
+// CallFrame calls the exposed function name like Call, as relayed by the
+// main frame for frame, a child frame with the bridge. Its ID, Origin and
+// URL are sent with the call.
+func (v *Webview) CallFrame(ctx context.Context, frame saucerw.Frame, name string, params ...any) (json.RawMessage, error) {
+	return v.call(ctx, &frame, name, params)
+}
+
+// call is Call, relayed for frame if non-nil.
+func (v *Webview) call(ctx context.Context, frame *saucerw.Frame, name string, params []any) (json.RawMessage, error) {
+	packed, err := v.pack(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan settled, 1)
+
+	v.mu.Lock()
+	v.lastCall++
+	id := v.lastCall
+	v.calls[id] = ch
+	v.mu.Unlock()
+
+	forget := func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		delete(v.calls, id)
+	}
+
+	msg := map[string]any{"saucer:call": true, "name": name, "params": packed, "id": id}
+	if frame != nil {
+		msg["frame"], msg["origin"], msg["url"] = frame.ID, frame.Origin, frame.URL
+	}
+
+	if err := v.send(msg); err != nil {
+		forget()
+		return nil, err
+	}
+
+	select {
+	case res := <-ch:
+		if !res.rejected {
+			return res.value, nil
+		}
+
+		rtn := &CallError{Name: name}
+		if json.Unmarshal(res.value, rtn) != nil {
+			rtn.Message = string(res.value)
+		}
+		return nil, rtn
+	case <-ctx.Done():
+		forget()
+		_ = v.send(map[string]any{"saucer:abort": true, "id": id})
+		return nil, ctx.Err()
+	case <-v.gone:
+		return nil, ErrReleased
+	}
+}
+
+// pack encodes params, uploading []byte values to the stash scheme.
+func (v *Webview) pack(ctx context.Context, params []any) ([]json.RawMessage, error) {
+	rtn := make([]json.RawMessage, len(params))
+
+	for i, param := range params {
+		data, ok := param.([]byte)
+		if !ok {
+			encoded, err := json.Marshal(param)
+			if err != nil {
+				return nil, fmt.Errorf("saucertest: argument %d: %w", i, err)
+			}
+			rtn[i] = encoded
+			continue
+		}
+
+		v.mu.Lock()
+		v.uploads++
+		id := fmt.Sprintf("%d-%d", time.Now().UnixMilli(), v.uploads)
+		v.mu.Unlock()
+
+		res, err := v.Fetch(ctx, saucerw.SchemeRequest{
+			URL:    "saucerw://stash/in/" + id,
+			Method: http.MethodPost,
+			Body:   data,
+		})
+		if err == nil && res.Status != http.StatusOK {
+			err = fmt.Errorf("status %d", res.Status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("saucertest: uploading argument %d: %w", i, err)
+		}
+
+		rtn[i], _ = json.Marshal(map[string]string{"saucer:bytes": id})
+	}
+
+	return rtn, nil
+}
+
+// Console logs args with the console method level, e.g. "log" or "error",
+// like the page. Strings are passed as they are, other values JSON encoded.
+func (v *Webview) Console(level string, args ...any) error {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		if str, ok := arg.(string); ok {
+			strs[i] = str
+			continue
+		}
+
+		data, err := json.Marshal(arg)
+		if err != nil {
+			data = []byte(fmt.Sprint(arg))
+		}
+		strs[i] = string(data)
+	}
+
+	return v.send(map[string]any{"saucer:console": true, "level": level, "args": strs})
+}
+
+// Ready calls window.saucer.ready() like the page, signaling that it
+// rendered its first screen.
+func (v *Webview) Ready() error {
+	return v.send(map[string]any{"saucer:ready": true})
+}
+
+// HookQuit registers a handler with window.saucer.onQuit like the page. The
+// window then asks the page before it closes by evaluating
+// window.saucer.internal.quitRequested(), answered by HandleEval: false
+// cancels closing.
+func (v *Webview) HookQuit() error {
+	return v.send(map[string]any{"saucer:quit": true})
+}
+
+func (v *Webview) HandleNavigate(fn func(saucerw.NavigationEvent) saucerw.Policy) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.navigateFn = fn
+}
+
+func (v *Webview) HandlePermission(fn func(url string, types saucerw.Permission, answer func(granted bool)) saucerw.PermissionDecision) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.permissionFn = fn
+}
+
+// RequestPermission requests types for the page and returns whether they were
+// granted. Requests nobody decides on are denied.
+func (v *Webview) RequestPermission(ctx context.Context, types saucerw.Permission) (bool, error) {
+	answer := make(chan bool, 1)
+
+	err := v.app.wait(func() {
+		v.mu.Lock()
+		fn, url := v.permissionFn, v.url
+		v.mu.Unlock()
+
+		if fn == nil {
+			answer <- false
+			return
+		}
+
+		var once sync.Once
+		reply := func(granted bool) { once.Do(func() { answer <- granted }) }
+
+		switch fn(url, types, reply) {
+		case saucerw.PermissionGrant:
+			reply(true)
+		case saucerw.PermissionDeny, saucerw.PermissionDefault:
+			reply(false)
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	select {
+	case granted := <-answer:
+		return granted, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-v.gone:
+		return false, ErrReleased
+	}
+}
+
+func (v *Webview) HandleDownload(fn func(saucerw.DownloadRequest) saucerw.DownloadDecision) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.downloadFn = fn
+}
+
+// Download starts downloading data described by req and returns the decision
+// on it. Unless the download was cancelled, data is written to the path of
+// the decision, if any, and its callbacks are called.
+func (v *Webview) Download(req saucerw.DownloadRequest, data []byte) saucerw.DownloadDecision {
+	var decision saucerw.DownloadDecision
+
+	v.app.invoke(func() {
+		v.mu.Lock()
+		fn := v.downloadFn
+		v.mu.Unlock()
+
+		if fn != nil {
+			decision = fn(req)
+		}
+	})
+
+	if decision.Cancel {
+		return decision
+	}
+
+	var err error
+	if decision.Path != "" {
+		err = os.WriteFile(decision.Path, data, 0o644)
+	}
+
+	if decision.OnProgress != nil && err == nil {
+		decision.OnProgress(int64(len(data)), req.Size)
+	}
+	if decision.OnDone != nil {
+		decision.OnDone(decision.Path, err)
+	}
+
+	return decision
+}
+
+func (v *Webview) HandleEvents(fn func(saucerw.WebviewEvent)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.eventsFn = fn
+}
+
+func (v *Webview) HandleFileDrop(fn func(saucerw.FileDrop)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.dropFn = fn
+}
+
+// Drop drops files onto the webview like the user.
+func (v *Webview) Drop(drop saucerw.FileDrop) {
+	v.mu.Lock()
+	fn := v.dropFn
+	v.mu.Unlock()
+
+	if fn != nil {
+		v.app.call(func() { fn(drop) })
+	}
+}
+
+func (v *Webview) HandleContextMenu(fn func(info saucerw.ContextInfo, partial bool) (entries []saucerw.MenuEntry, ok bool), click func(id int32)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.contextFn, v.contextClick = fn, click
+}
+
+// OpenContextMenu opens the context menu on info like the user and returns
+// its custom entries, ok being false for the default menu.
+func (v *Webview) OpenContextMenu(info saucerw.ContextInfo) (entries []saucerw.MenuEntry, ok bool) {
+	v.app.invoke(func() {
+		v.mu.Lock()
+		fn := v.contextFn
+		v.mu.Unlock()
+
+		if fn != nil {
+			entries, ok = fn(info, false)
+		}
+	})
+
+	return entries, ok
+}
+
+// ClickContextMenu clicks the custom context menu entry id.
+func (v *Webview) ClickContextMenu(id int32) {
+	v.mu.Lock()
+	fn := v.contextClick
+	v.mu.Unlock()
+
+	if fn != nil {
+		v.app.call(func() { fn(id) })
+	}
+}
+
+func (v *Webview) Cookies(done func([]*http.Cookie, error)) {
+	v.app.mu.Lock()
+	rtn := make([]*http.Cookie, 0, len(v.app.cookies))
+	for _, c := range v.app.cookies {
+		clone := *c
+		rtn = append(rtn, &clone)
+	}
+	v.app.mu.Unlock()
+
+	slices.SortFunc(rtn, func(a, b *http.Cookie) int { return strings.Compare(a.Name, b.Name) })
+	done(rtn, nil)
+}
+
+func (v *Webview) SetCookie(cookie *http.Cookie, done func(error)) {
+	clone := *cookie
+
+	v.app.mu.Lock()
+	v.app.cookies[cookieKey{cookie.Name, cookie.Domain, cookie.Path}] = &clone
+	v.app.mu.Unlock()
+
+	done(nil)
+}
+
+func (v *Webview) DeleteCookie(cookie *http.Cookie, done func(error)) {
+	v.app.mu.Lock()
+	delete(v.app.cookies, cookieKey{cookie.Name, cookie.Domain, cookie.Path})
+	v.app.mu.Unlock()
+
+	done(nil)
+}
+
+// ClearData clears the cookies, the only data the fake page keeps, whatever
+// their age.
+func (v *Webview) ClearData(kinds saucerw.BrowsingData, _ time.Time, done func(error)) {
+	if kinds&saucerw.BrowsingCookies != 0 {
+		v.app.mu.Lock()
+		clear(v.app.cookies)
+		v.app.mu.Unlock()
+	}
+
+	done(nil)
+}
+
+func (v *Webview) Zoom() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.zoom
+}
+
+func (v *Webview) SetZoom(factor float64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.zoom = factor
+}
+
+// Print reports printing as unsupported.
+func (v *Webview) Print(_ saucerw.PrintOptions, done func(error)) {
+	done(fmt.Errorf("%w: printing", saucerw.ErrUnsupported))
+}
+
+// SavePDF reports printing as unsupported.
+func (v *Webview) SavePDF(_ string, _ pdf.Options, done func(error)) {
+	done(fmt.Errorf("%w: printing", saucerw.ErrUnsupported))
+}
+
+// Capture calls done with an image of the size of the window filled with the
+// background color.
+func (v *Webview) Capture(done func([]byte, error)) {
+	size := v.window.Size()
+
+	img := image.NewNRGBA(image.Rect(0, 0, size.W, size.H))
+	bg := v.Background()
+
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = bg.R, bg.G, bg.B, bg.A
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		done(nil, err)
+		return
+	}
+	done(buf.Bytes(), nil)
+}
+
+func (v *Webview) SetDarkMode(mode saucerw.DarkMode) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.darkMode = mode
+	return true
+}
+
+// DarkMode returns the color scheme forced with SetDarkMode.
+func (v *Webview) DarkMode() saucerw.DarkMode {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.darkMode
+}
+
+func (v *Webview) SendKey(key saucerw.KeyInput) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.keys = append(v.keys, key)
+	return true
+}
+
+// Keys returns the key events sent to the page, in order.
+func (v *Webview) Keys() []saucerw.KeyInput {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return slices.Clone(v.keys)
+}
+
+func (v *Webview) SendText(text string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.texts = append(v.texts, text)
+	return true
+}
+
+// Texts returns the texts inserted into the page, in order.
+func (v *Webview) Texts() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return slices.Clone(v.texts)
+}
+
+func (v *Webview) SendMouse(mouse saucerw.MouseInput) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.mouse = append(v.mouse, mouse)
+	return true
+}
+
+// Mouse returns the mouse events sent to the page, in order.
+func (v *Webview) Mouse() []saucerw.MouseInput {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return slices.Clone(v.mouse)
+}
+
+// NewSharedBuffer reports shared memory as unsupported, shared buffers fall
+// back to SendBytes.
+func (v *Webview) NewSharedBuffer(int) (saucerw.SharedMemory, error) {
+	return nil, fmt.Errorf("%w: shared memory", saucerw.ErrUnsupported)
+}
+
+func (v *Webview) HandleScheme(name string, handler func(req saucerw.SchemeRequest, respond func(saucerw.SchemeResponse))) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.schemes[name] = handler
+}
+
+func (v *Webview) RemoveScheme(name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.schemes, name)
+}
+
+func (v *Webview) HandleStreamScheme(name string, handler func(req saucerw.SchemeRequest, stream saucerw.SchemeStream)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.streams[name] = handler
+}
+
+func (v *Webview) RemoveStreamScheme(name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.streams, name)
+}
+
+// Fetch requests req.URL like fetch in the page, from the handler of its
+// custom scheme or the embedded files below saucer://embedded/. Only the
+// custom schemes are served. Fetch waits for the event loop and must not be
+// called on its thread.
+func (v *Webview) Fetch(ctx context.Context, req saucerw.SchemeRequest) (saucerw.SchemeResponse, error) {
+	scheme, rest, ok := strings.Cut(req.URL, "://")
+	if !ok {
+		return saucerw.SchemeResponse{}, fmt.Errorf("saucertest: bad url %q", req.URL)
+	}
+
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+
+	v.mu.Lock()
+	handler, stream := v.schemes[scheme], v.streams[scheme]
+	file, embedded := v.embedded[strings.TrimPrefix(rest, "embedded")]
+	v.mu.Unlock()
+
+	if scheme == "saucer" && strings.HasPrefix(rest, "embedded/") {
+		if !embedded {
+			return saucerw.SchemeResponse{Status: http.StatusNotFound}, nil
+		}
+		return saucerw.SchemeResponse{Status: http.StatusOK, Mime: file.Mime, Body: file.Content}, nil
+	}
+
+	responses := make(chan fetched, 1)
+	var err error
+
+	switch {
+	case stream != nil:
+		s := &streamResponse{done: responses}
+		err = v.app.wait(func() { stream(req, s) })
+	case handler != nil:
+		var once sync.Once
+		respond := func(res saucerw.SchemeResponse) {
+			once.Do(func() { responses <- fetched{res: res} })
+		}
+		err = v.app.wait(func() { handler(req, respond) })
+	default:
+		return saucerw.SchemeResponse{}, fmt.Errorf("saucertest: no handler for scheme %q", scheme)
+	}
+
+	if err != nil {
+		return saucerw.SchemeResponse{}, err
+	}
+
+	select {
+	case f := <-responses:
+		return f.res, f.err
+	case <-ctx.Done():
+		return saucerw.SchemeResponse{}, ctx.Err()
+	case <-v.gone:
+		return saucerw.SchemeResponse{}, ErrReleased
+	}
+}
+
+// fetched is the response to a fetch.
+type fetched struct {
+	res saucerw.SchemeResponse
+	err error
+}
+
+// streamResponse collects a streamed response.
+type streamResponse struct {
+	res  saucerw.SchemeResponse
+	body bytes.Buffer
+	done chan<- fetched
+}
+
+func (s *streamResponse) Start(res saucerw.SchemeResponse) {
+	s.res = res
+	s.res.Body = nil
+}
+
+func (s *streamResponse) Write(data []byte) {
+	s.body.Write(data)
+}
+
+func (s *streamResponse) Finish() {
+	s.res.Body = s.body.Bytes()
+	s.done <- fetched{res: s.res}
+}
+
+func (s *streamResponse) Reject(status int) {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound:
+		s.done <- fetched{res: saucerw.SchemeResponse{Status: status}}
+	default:
+		s.done <- fetched{err: fmt.Errorf("saucertest: network error %d", status)}
+	}
+}
+
+// isReleased reports whether the webview was released.
+func (v *Webview) isReleased() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.released
+}
+
+func (v *Webview) Release() {
+	v.mu.Lock()
+	if v.released {
+		v.mu.Unlock()
+		return
+	}
+	v.released = true
+	close(v.gone)
+	v.mu.Unlock()
+
+	v.window.forget(v)
+}
@@ -0,0 +1,473 @@
+package saucertest
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// Window is a fake window. It keeps the state set by the application and
+// reports changes like a native window, the methods not part of
+// saucerw.WindowDriver act like the user.
+type Window struct {
+	app *App
+
+	mu           sync.Mutex
+	visible      bool
+	closed       bool
+	focused      bool
+	minimized    bool
+	maximized    bool
+	resizable    bool
+	fullscreen   bool
+	alwaysOnTop  bool
+	clickThrough bool
+	kiosk        bool
+	intercept    bool
+	title        string
+	icon         []byte
+	background   saucerw.Color
+	titleBar     saucerw.Color
+	decorations  saucerw.Decoration
+	size         saucerw.Size
+	minSize      saucerw.Size
+	maxSize      saucerw.Size
+	position     saucerw.Position
+	scale        float64
+	frame        saucerw.Insets
+	safeArea     saucerw.Insets
+	menu         []saucerw.MenuEntry
+	webviews     []*Webview
+	released     bool
+
+	eventsFn func(saucerw.WindowEvent)
+	menuFn   func(int32)
+}
+
+func newWindow(app *App) *Window {
+	return &Window{
+		app:         app,
+		resizable:   true,
+		background:  saucerw.Color{R: 255, G: 255, B: 255, A: 255},
+		decorations: saucerw.DecorationFull,
+		size:        saucerw.Size{W: 800, H: 600},
+		scale:       1,
+	}
+}
+
+// emit reports ev to the application on the event loop.
+func (w *Window) emit(ev saucerw.WindowEvent) {
+	w.mu.Lock()
+	fn := w.eventsFn
+	w.mu.Unlock()
+
+	if fn != nil {
+		w.app.call(func() { fn(ev) })
+	}
+}
+
+// set locks w to apply fn and emits ev if fn reports a change.
+func (w *Window) set(fn func() bool, ev saucerw.WindowEvent) {
+	w.mu.Lock()
+	changed := fn()
+	w.mu.Unlock()
+
+	if changed {
+		w.emit(ev)
+	}
+}
+
+// get returns the field selected by fn under the lock of w.
+func get[T any](w *Window, fn func() T) T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return fn()
+}
+
+func (w *Window) Visible() bool      { return get(w, func() bool { return w.visible }) }
+func (w *Window) Focused() bool      { return get(w, func() bool { return w.focused }) }
+func (w *Window) Minimized() bool    { return get(w, func() bool { return w.minimized }) }
+func (w *Window) Maximized() bool    { return get(w, func() bool { return w.maximized }) }
+func (w *Window) Resizable() bool    { return get(w, func() bool { return w.resizable }) }
+func (w *Window) Fullscreen() bool   { return get(w, func() bool { return w.fullscreen }) }
+func (w *Window) AlwaysOnTop() bool  { return get(w, func() bool { return w.alwaysOnTop }) }
+func (w *Window) ClickThrough() bool { return get(w, func() bool { return w.clickThrough }) }
+func (w *Window) Title() string      { return get(w, func() string { return w.title }) }
+
+func (w *Window) Background() saucerw.Color {
+	return get(w, func() saucerw.Color { return w.background })
+}
+
+func (w *Window) Decorations() saucerw.Decoration {
+	return get(w, func() saucerw.Decoration { return w.decorations })
+}
+
+func (w *Window) Size() saucerw.Size    { return get(w, func() saucerw.Size { return w.size }) }
+func (w *Window) MinSize() saucerw.Size { return get(w, func() saucerw.Size { return w.minSize }) }
+func (w *Window) MaxSize() saucerw.Size { return get(w, func() saucerw.Size { return w.maxSize }) }
+func (w *Window) Position() saucerw.Position {
+	return get(w, func() saucerw.Position { return w.position })
+}
+
+func (w *Window) Scale() float64 { return get(w, func() float64 { return w.scale }) }
+
+func (w *Window) SafeArea() saucerw.Insets {
+	return get(w, func() saucerw.Insets { return w.safeArea })
+}
+
+// OuterSize returns the size plus the frame set by SetFrame.
+func (w *Window) OuterSize() saucerw.Size {
+	return get(w, func() saucerw.Size {
+		return saucerw.Size{W: w.size.W + w.frame.Left + w.frame.Right, H: w.size.H + w.frame.Top + w.frame.Bottom}
+	})
+}
+
+// SetScale moves the window to a screen of scale, e.g. 2 for a HiDPI screen,
+// and reports it if it changed. The scale starts at 1.
+func (w *Window) SetScale(scale float64) {
+	w.set(func() bool {
+		changed := w.scale != scale
+		w.scale = scale
+		return changed
+	}, saucerw.WindowEvent{Type: saucerw.WindowScale, Scale: scale})
+}
+
+// SetFrame sets the decorations around the content of the window, which
+// OuterSize adds to its size. There are none by default.
+func (w *Window) SetFrame(frame saucerw.Insets) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.frame = frame
+}
+
+// SetSafeArea sets the insets of the content the system draws over.
+func (w *Window) SetSafeArea(insets saucerw.Insets) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.safeArea = insets
+}
+
+// Kiosk reports whether the window is in kiosk mode.
+func (w *Window) Kiosk() bool { return get(w, func() bool { return w.kiosk }) }
+
+// Show shows the window, reopening it if it was closed.
+func (w *Window) Show() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.visible, w.closed = true, false
+}
+
+func (w *Window) Hide() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.visible = false
+}
+
+// Close closes the window, quitting the application once every window was
+// closed unless it keeps running.
+func (w *Window) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.visible, w.closed, w.focused = false, true, false
+	w.mu.Unlock()
+
+	w.emit(saucerw.WindowEvent{Type: saucerw.WindowClosed})
+	w.app.call(w.app.closed)
+}
+
+// isClosed reports whether the window was closed and not shown again.
+func (w *Window) isClosed() bool {
+	return get(w, func() bool { return w.closed || w.released })
+}
+
+// Focus focuses the window, taking the focus from the other windows.
+func (w *Window) Focus() {
+	for _, other := range w.app.Windows() {
+		if other != w {
+			other.SetFocused(false)
+		}
+	}
+
+	w.SetFocused(true)
+}
+
+// SetFocused reports that the window gained or lost the focus, like the
+// window manager.
+func (w *Window) SetFocused(focused bool) {
+	w.set(func() bool {
+		changed := w.focused != focused
+		w.focused = focused
+		return changed
+	}, saucerw.WindowEvent{Type: saucerw.WindowFocus, Value: focused})
+}
+
+func (w *Window) StartDrag()               {}
+func (w *Window) StartResize(saucerw.Edge) {}
+
+func (w *Window) SetMinimized(minimized bool) {
+	w.set(func() bool {
+		changed := w.minimized != minimized
+		w.minimized = minimized
+		return changed
+	}, saucerw.WindowEvent{Type: saucerw.WindowMinimize, Value: minimized})
+}
+
+func (w *Window) SetMaximized(maximized bool) {
+	w.set(func() bool {
+		changed := w.maximized != maximized
+		w.maximized = maximized
+		return changed
+	}, saucerw.WindowEvent{Type: saucerw.WindowMaximize, Value: maximized})
+}
+
+func (w *Window) SetResizable(resizable bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.resizable = resizable
+}
+
+func (w *Window) SetFullscreen(fullscreen bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.fullscreen = fullscreen
+}
+
+func (w *Window) SetAlwaysOnTop(onTop bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.alwaysOnTop = onTop
+}
+
+func (w *Window) SetClickThrough(clickThrough bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.clickThrough = clickThrough
+}
+
+// Icon returns the PNG data of the icon set by the application.
+func (w *Window) Icon() []byte {
+	return get(w, func() []byte { return slices.Clone(w.icon) })
+}
+
+func (w *Window) SetIcon(png []byte) error {
+	if len(png) == 0 {
+		return errors.New("saucertest: empty icon")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.icon = slices.Clone(png)
+	return nil
+}
+
+func (w *Window) SetTitle(title string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.title = title
+}
+
+func (w *Window) SetBackground(color saucerw.Color) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.background = color
+}
+
+// TitleBarColor returns the title bar color set by the application, the zero
+// Color if it has the default.
+func (w *Window) TitleBarColor() saucerw.Color {
+	return get(w, func() saucerw.Color { return w.titleBar })
+}
+
+func (w *Window) SetTitleBarColor(color saucerw.Color) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.titleBar = color
+	return true
+}
+
+func (w *Window) SetDecorations(decorations saucerw.Decoration) {
+	w.set(func() bool {
+		changed := w.decorations != decorations
+		w.decorations = decorations
+		return changed
+	}, saucerw.WindowEvent{Type: saucerw.WindowDecorated, Decoration: decorations})
+}
+
+// SetSize resizes the window within its minimum and maximum size.
+func (w *Window) SetSize(size saucerw.Size) {
+	w.mu.Lock()
+	size = clamp(size, w.minSize, w.maxSize)
+	changed := w.size != size
+	w.size = size
+	w.mu.Unlock()
+
+	if changed {
+		w.emit(saucerw.WindowEvent{Type: saucerw.WindowResize, Size: size})
+	}
+}
+
+// clamp limits size to lo and hi, whose zero dimensions are no limit.
+func clamp(size, lo, hi saucerw.Size) saucerw.Size {
+	if lo.W > 0 {
+		size.W = max(size.W, lo.W)
+	}
+	if lo.H > 0 {
+		size.H = max(size.H, lo.H)
+	}
+	if hi.W > 0 {
+		size.W = min(size.W, hi.W)
+	}
+	if hi.H > 0 {
+		size.H = min(size.H, hi.H)
+	}
+	return size
+}
+
+func (w *Window) SetMinSize(size saucerw.Size) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.minSize = size
+}
+
+func (w *Window) SetMaxSize(size saucerw.Size) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.maxSize = size
+}
+
+func (w *Window) SetPosition(pos saucerw.Position) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.position = pos
+}
+
+func (w *Window) SetKiosk(kiosk bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.kiosk = kiosk
+}
+
+func (w *Window) InterceptClose(intercept bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.intercept = intercept
+}
+
+func (w *Window) HandleEvents(fn func(saucerw.WindowEvent)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.eventsFn = fn
+}
+
+func (w *Window) SetMenu(entries []saucerw.MenuEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.menu = slices.Clone(entries)
+}
+
+// Menu returns the entries of the menu bar.
+func (w *Window) Menu() []saucerw.MenuEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return slices.Clone(w.menu)
+}
+
+func (w *Window) HandleMenu(fn func(id int32)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.menuFn = fn
+}
+
+// ClickMenu clicks the menu bar entry id, on the event loop.
+func (w *Window) ClickMenu(id int32) {
+	w.mu.Lock()
+	fn := w.menuFn
+	w.mu.Unlock()
+
+	if fn != nil {
+		w.app.call(func() { fn(id) })
+	}
+}
+
+// UserClose closes the window like the user, unless it is in kiosk mode. A
+// window intercepting closes emits WindowCloseRequested instead.
+func (w *Window) UserClose() {
+	w.mu.Lock()
+	kiosk, intercept := w.kiosk, w.intercept
+	w.mu.Unlock()
+
+	switch {
+	case kiosk:
+	case intercept:
+		w.emit(saucerw.WindowEvent{Type: saucerw.WindowCloseRequested})
+	default:
+		w.Close()
+	}
+}
+
+// NewWebview creates a fake webview inside the window.
+func (w *Window) NewWebview(opts saucerw.WebviewOptions) (saucerw.WebviewDriver, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.released {
+		return nil, errors.New("saucertest: window released")
+	}
+
+	v := newWebview(w, opts)
+	w.webviews = append(w.webviews, v)
+
+	return v, nil
+}
+
+// Webviews returns the webviews of the window that were not released, in the
+// order they were created.
+func (w *Window) Webviews() []*Webview {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return slices.Clone(w.webviews)
+}
+
+// forget removes the released webview v.
+func (w *Window) forget(v *Webview) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.webviews = slices.DeleteFunc(w.webviews, func(other *Webview) bool { return other == v })
+}
+
+func (w *Window) Release() {
+	w.mu.Lock()
+	w.released = true
+	w.mu.Unlock()
+
+	w.app.forget(w)
+}
@@ -0,0 +1,226 @@
+package saucerw
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// SchemeRequest is a request for a custom scheme URL.
+type SchemeRequest struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	Body    []byte
+}
+
+// SchemeResponse answers a SchemeRequest.
+type SchemeResponse struct {
+	Status  int
+	Mime    string
+	Headers map[string]string
+	Body    []byte
+}
+
+// HandleScheme serves requests for name:// URLs with handler, e.g. an
+// http.FileServer over an embed.FS, without running a local HTTP server. The
+// scheme has to be listed in AppOptions.Schemes. Each request is served on its
+// own goroutine.
+//
+// The handler receives the method, headers and body of the request, so a
+// router serves fetch("app://api/items", {method: "POST"}) like any HTTP
+// request, with "api" as the host and "/items" as the path. The response is
+// buffered until the handler returns, see HandleStreamScheme.
+func (v *Webview) HandleScheme(name string, handler http.Handler) {
+	v.native.HandleScheme(name, func(req SchemeRequest, respond func(SchemeResponse)) {
+		go func() {
+			r, err := schemeRequest(req)
+			if err != nil {
+				respond(SchemeResponse{Status: http.StatusBadRequest, Mime: "text/plain", Body: []byte(err.Error())})
+				return
+			}
+
+			r, end := v.traceScheme(name, r)
+
+			w := &schemeWriter{header: http.Header{}}
+			v.bridge.policy.header(w.header)
+			serve(name, handler, w, r)
+
+			res := w.response()
+			end(res.Status)
+
+			respond(res)
+		}()
+	})
+}
+
+// RemoveScheme stops serving the custom scheme name.
+func (v *Webview) RemoveScheme(name string) {
+	v.native.RemoveScheme(name)
+}
+
+// HandleStreamScheme is like HandleScheme but streams the response: the
+// status and headers are sent on the first write or flush, the body as it is
+// written. Use it for large or long-running responses such as downloads or
+// server-sent events.
+func (v *Webview) HandleStreamScheme(name string, handler http.Handler) {
+	v.native.HandleStreamScheme(name, func(req SchemeRequest, stream SchemeStream) {
+		v.streams.Add(1)
+
+		go func() {
+			defer v.streams.Add(-1)
+
+			r, err := schemeRequest(req)
+			if err != nil {
+				stream.Reject(http.StatusBadRequest)
+				return
+			}
+
+			r, end := v.traceScheme(name, r)
+
+			w := &streamWriter{stream: stream, header: http.Header{}}
+			v.bridge.policy.header(w.header)
+			defer func() {
+				w.finish()
+				end(w.status)
+			}()
+
+			serve(name, handler, w, r)
+		}()
+	})
+}
+
+// RemoveStreamScheme stops serving the custom scheme name registered with
+// HandleStreamScheme.
+func (v *Webview) RemoveStreamScheme(name string) {
+	v.native.RemoveStreamScheme(name)
+}
+
+// serve calls handler, answering with status 500 if it panics before
+// writing a status.
+func serve(name string, handler http.Handler, w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			reportPanic("scheme handler "+name, rec)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}()
+
+	handler.ServeHTTP(w, r)
+}
+
+// schemeRequest converts req to an HTTP request.
+func schemeRequest(req SchemeRequest) (*http.Request, error) {
+	r, err := http.NewRequest(req.Method, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range req.Headers {
+		r.Header.Set(key, value)
+	}
+
+	return r, nil
+}
+
+// responseHeaders joins the values of header, as saucer takes one value per
+// header.
+func responseHeaders(header http.Header) map[string]string {
+	rtn := make(map[string]string, len(header))
+	for key, values := range header {
+		rtn[key] = strings.Join(values, ", ")
+	}
+	return rtn
+}
+
+// schemeWriter is an http.ResponseWriter buffering the response.
+type schemeWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *schemeWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *schemeWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *schemeWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// response converts the buffered response, sniffing the content type like
+// net/http does when the handler did not set one.
+func (w *schemeWriter) response() SchemeResponse {
+	w.WriteHeader(http.StatusOK)
+
+	mime := w.header.Get("Content-Type")
+	if mime == "" && w.body.Len() > 0 {
+		mime = http.DetectContentType(w.body.Bytes())
+	}
+
+	return SchemeResponse{Status: w.status, Mime: mime, Headers: responseHeaders(w.header), Body: w.body.Bytes()}
+}
+
+// streamWriter is an http.ResponseWriter streaming the response.
+type streamWriter struct {
+	stream  SchemeStream
+	header  http.Header
+	status  int
+	started bool
+}
+
+func (w *streamWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *streamWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *streamWriter) Write(data []byte) (int, error) {
+	w.start(data)
+
+	if len(data) > 0 {
+		w.stream.Write(data)
+	}
+	return len(data), nil
+}
+
+// Flush implements http.Flusher, sending the status and headers if they were
+// not sent yet. Written data is never buffered.
+func (w *streamWriter) Flush() {
+	w.start(nil)
+}
+
+// start sends the status and headers once, sniffing the content type from
+// the first chunk like net/http does when the handler did not set one.
+func (w *streamWriter) start(chunk []byte) {
+	if w.started {
+		return
+	}
+
+	w.started = true
+	w.WriteHeader(http.StatusOK)
+
+	mime := w.header.Get("Content-Type")
+	if mime == "" && len(chunk) > 0 {
+		mime = http.DetectContentType(chunk)
+	}
+
+	w.stream.Start(SchemeResponse{Status: w.status, Mime: mime, Headers: responseHeaders(w.header)})
+}
+
+// finish completes the response once the handler returned.
+func (w *streamWriter) finish() {
+	w.start(nil)
+	w.stream.Finish()
+}
@@ -0,0 +1,78 @@
+// Package screens finds the monitors attached to the system and places
+// windows on them, e.g. a kiosk window on a given display of a digital
+// signage setup.
+//
+//	all := screens.List(app)
+//	if len(all) > 1 {
+//		screens.Place(win, all[1])
+//	}
+//	win.SetKiosk(true)
+//
+// The functions call into the application and must be used while its event
+// loop runs. Wayland compositors do not let windows position themselves,
+// Place and Center only resize the window there.
+package screens
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// List returns the screens attached to the system ordered left to right, then
+// top to bottom, so that indices follow their arrangement.
+func List(app *saucerw.Application) []saucerw.Screen {
+	rtn := app.Screens()
+
+	slices.SortStableFunc(rtn, func(a, b saucerw.Screen) int {
+		return cmp.Or(cmp.Compare(a.Position.X, b.Position.X), cmp.Compare(a.Position.Y, b.Position.Y))
+	})
+	return rtn
+}
+
+// Find returns the screen named name.
+func Find(app *saucerw.Application, name string) (saucerw.Screen, bool) {
+	for _, screen := range app.Screens() {
+		if screen.Name == name {
+			return screen, true
+		}
+	}
+	return saucerw.Screen{}, false
+}
+
+// At returns the screen containing pos.
+func At(app *saucerw.Application, pos saucerw.Position) (saucerw.Screen, bool) {
+	for _, screen := range app.Screens() {
+		if screen.Contains(pos) {
+			return screen, true
+		}
+	}
+	return saucerw.Screen{}, false
+}
+
+// Of returns the screen showing the center of w.
+func Of(w *saucerw.Window) (saucerw.Screen, bool) {
+	pos, size := w.Position(), w.Size()
+	return At(w.Parent(), saucerw.Position{X: pos.X + size.W/2, Y: pos.Y + size.H/2})
+}
+
+// Place moves w onto s and resizes it to cover s. A window in fullscreen or
+// kiosk mode has to leave it first; enter it again after placing the window,
+// it then covers s.
+func Place(w *saucerw.Window, s saucerw.Screen) {
+	w.SetPosition(s.Position)
+	w.SetSize(s.Size)
+}
+
+// Center moves w to the middle of s, shrinking it to fit on s.
+func Center(w *saucerw.Window, s saucerw.Screen) {
+	size := w.Size()
+	size.W, size.H = min(size.W, s.Size.W), min(size.H, s.Size.H)
+
+	w.SetSize(size)
+	w.SetPosition(saucerw.Position{
+		X: s.Position.X + (s.Size.W-size.W)/2,
+		Y: s.Position.Y + (s.Size.H-size.H)/2,
+	})
+}
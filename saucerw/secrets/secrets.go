@@ -0,0 +1,167 @@
+// Package secrets keeps small secrets of an application, e.g. the tokens of
+// the accounts it signs in to, in the keychain of the system: the Keychain on
+// macOS, the Credential Manager on Windows and the Secret Service, e.g. GNOME
+// Keyring or KWallet, on Linux and other freedesktop systems.
+//
+// A secret is a string identified by a service, usually the application id,
+// and an account:
+//
+//	err := secrets.Set(ctx, "com.example.app", "alice@example.com", token)
+//	token, err := secrets.Get(ctx, "com.example.app", "alice@example.com")
+//
+// The system may ask the user to unlock the keychain first, which ctx bounds.
+// Expose lets the pages of a webview use the secrets of a service, limited to
+// some accounts. Restrict the pages allowed to call its functions with the
+// Bridge rules of saucerw.SecurityPolicy:
+//
+//	secrets.Expose(view, secrets.ExposeOptions{Service: "com.example.app", Accounts: []string{"sync"}})
+//
+//	const token = await window.saucer.call("secrets.get", ["sync"]);
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+var (
+	// ErrNotFound is returned by Get and Delete for a secret that is not
+	// stored.
+	ErrNotFound = errors.New("secrets: secret not found")
+	// ErrUnsupported is returned when the system has no keychain or the
+	// backend is not compiled in.
+	ErrUnsupported = errors.New("secrets: no keychain available")
+)
+
+// check returns the error of an invalid service or account.
+func check(service, account string) error {
+	switch {
+	case service == "":
+		return errors.New("secrets: service is required")
+	case account == "":
+		return errors.New("secrets: account is required")
+	}
+	return nil
+}
+
+// Set stores secret for account of service, replacing a stored one.
+func Set(ctx context.Context, service, account, secret string) error {
+	if err := check(service, account); err != nil {
+		return err
+	}
+	return set(ctx, service, account, []byte(secret))
+}
+
+// Get returns the secret stored for account of service, ErrNotFound if there
+// is none.
+func Get(ctx context.Context, service, account string) (string, error) {
+	if err := check(service, account); err != nil {
+		return "", err
+	}
+
+	secret, err := get(ctx, service, account)
+	return string(secret), err
+}
+
+// Delete deletes the secret stored for account of service, ErrNotFound if
+// there is none.
+func Delete(ctx context.Context, service, account string) error {
+	if err := check(service, account); err != nil {
+		return err
+	}
+	return remove(ctx, service, account)
+}
+
+// ExposeOptions configures Expose.
+type ExposeOptions struct {
+	// Name is the prefix of the exposed functions, "secrets" if empty.
+	Name string
+	// Service is the service whose secrets the pages may access. It is
+	// required.
+	Service string
+	// Accounts lists the accounts the pages may access. At least one is
+	// required.
+	Accounts []string
+	// ReadOnly keeps the pages from writing.
+	ReadOnly bool
+}
+
+// check returns the error of an access to account the options do not allow.
+func (o *ExposeOptions) check(account string, write bool) error {
+	switch {
+	case !slices.Contains(o.Accounts, account):
+		return &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: fmt.Errorf("secrets: account %q is not exposed", account)}
+	case write && o.ReadOnly:
+		return &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: errors.New("secrets: secrets are read-only")}
+	}
+	return nil
+}
+
+// Expose lets the pages of v access the secrets of the accounts opts allows,
+// through the functions "<name>.get", "<name>.set" and "<name>.delete":
+//
+//	const token = await window.saucer.call("secrets.get", ["sync"]);
+//	await window.saucer.call("secrets.set", ["sync", token]);
+//
+// get resolves to null for secrets that are not stored and delete does not
+// reject for them. Other accounts reject with saucerw.CodePermissionDenied,
+// as do writes if ReadOnly is set. A call aborted by the page stops waiting
+// for the user to unlock the keychain. Which pages may call the functions at
+// all is up to the Bridge rules of the saucerw.SecurityPolicy of v; a page
+// allowed to read a secret can send it anywhere it can reach.
+func Expose(v *saucerw.Webview, opts ExposeOptions) error {
+	if opts.Service == "" {
+		return errors.New("secrets: no service exposed, set ExposeOptions.Service")
+	}
+	if len(opts.Accounts) == 0 {
+		return errors.New("secrets: no accounts exposed, set ExposeOptions.Accounts")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = "secrets"
+	}
+
+	err := v.Expose(name+".get", func(ctx context.Context, account string) (*string, error) {
+		if err := opts.check(account, false); err != nil {
+			return nil, err
+		}
+
+		secret, err := Get(ctx, opts.Service, account)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &secret, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = v.Expose(name+".set", func(ctx context.Context, account, secret string) error {
+		if err := opts.check(account, true); err != nil {
+			return err
+		}
+		return Set(ctx, opts.Service, account, secret)
+	})
+	if err != nil {
+		return err
+	}
+
+	return v.Expose(name+".delete", func(ctx context.Context, account string) error {
+		if err := opts.check(account, true); err != nil {
+			return err
+		}
+
+		if err := Delete(ctx, opts.Service, account); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return nil
+	})
+}
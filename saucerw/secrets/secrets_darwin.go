@@ -0,0 +1,85 @@
+//go:build darwin && cgo && saucer
+
+package secrets
+
+/*
+#cgo CFLAGS: -fobjc-arc
+#cgo LDFLAGS: -framework Security -framework Foundation
+
+#include <stdint.h>
+#include <stdlib.h>
+
+int32_t secrets_set(const char *service, const char *account, const void *data, size_t size);
+int32_t secrets_get(const char *service, const char *account, void **data, size_t *size);
+int32_t secrets_delete(const char *service, const char *account);
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"unsafe"
+)
+
+// Security framework errors, see SecBase.h.
+const (
+	errSecItemNotFound          = -25300
+	errSecInteractionNotAllowed = -25308
+)
+
+// status returns the error of the OSStatus code of a call.
+func status(call string, code C.int32_t) error {
+	switch code {
+	case 0:
+		return nil
+	case errSecItemNotFound:
+		return ErrNotFound
+	case errSecInteractionNotAllowed:
+		return fmt.Errorf("secrets: %s: the keychain is locked", call)
+	}
+	return fmt.Errorf("secrets: %s failed with status %d", call, int32(code))
+}
+
+// cstrings returns service and account as C strings, freed by the returned
+// function.
+func cstrings(service, account string) (*C.char, *C.char, func()) {
+	cservice, caccount := C.CString(service), C.CString(account)
+	return cservice, caccount, func() {
+		C.free(unsafe.Pointer(cservice))
+		C.free(unsafe.Pointer(caccount))
+	}
+}
+
+// The Keychain prompts the user on its own, without a way to cancel; ctx is
+// not used.
+
+func set(_ context.Context, service, account string, secret []byte) error {
+	cservice, caccount, free := cstrings(service, account)
+	defer free()
+
+	data := C.CBytes(secret)
+	defer C.free(data)
+
+	return status("SecItemAdd", C.secrets_set(cservice, caccount, data, C.size_t(len(secret))))
+}
+
+func get(_ context.Context, service, account string) ([]byte, error) {
+	cservice, caccount, free := cstrings(service, account)
+	defer free()
+
+	var data unsafe.Pointer
+	var size C.size_t
+	if err := status("SecItemCopyMatching", C.secrets_get(cservice, caccount, &data, &size)); err != nil {
+		return nil, err
+	}
+	defer C.free(data)
+
+	return C.GoBytes(data, C.int(size)), nil
+}
+
+func remove(_ context.Context, service, account string) error {
+	cservice, caccount, free := cstrings(service, account)
+	defer free()
+
+	return status("SecItemDelete", C.secrets_delete(cservice, caccount))
+}
@@ -0,0 +1,69 @@
+//go:build darwin && cgo && saucer
+
+#import <Foundation/Foundation.h>
+#import <Security/Security.h>
+
+#include <stdlib.h>
+#include <string.h>
+
+// The query of the generic password of account of service.
+static NSMutableDictionary *secrets_query(const char *service, const char *account)
+{
+    return [@{
+        (__bridge id)kSecClass : (__bridge id)kSecClassGenericPassword,
+        (__bridge id)kSecAttrService : [NSString stringWithUTF8String:service],
+        (__bridge id)kSecAttrAccount : [NSString stringWithUTF8String:account],
+    } mutableCopy];
+}
+
+int32_t secrets_set(const char *service, const char *account, const void *data, size_t size)
+{
+    @autoreleasepool
+    {
+        NSMutableDictionary *query = secrets_query(service, account);
+        NSData *value              = [NSData dataWithBytes:data length:size];
+
+        OSStatus status = SecItemUpdate((__bridge CFDictionaryRef)query, (__bridge CFDictionaryRef)@{(__bridge id)kSecValueData : value});
+        if (status != errSecItemNotFound)
+        {
+            return status;
+        }
+
+        query[(__bridge id)kSecValueData] = value;
+        return SecItemAdd((__bridge CFDictionaryRef)query, NULL);
+    }
+}
+
+int32_t secrets_get(const char *service, const char *account, void **data, size_t *size)
+{
+    @autoreleasepool
+    {
+        NSMutableDictionary *query = secrets_query(service, account);
+
+        query[(__bridge id)kSecReturnData] = @YES;
+        query[(__bridge id)kSecMatchLimit] = (__bridge id)kSecMatchLimitOne;
+
+        CFTypeRef result = NULL;
+        OSStatus status  = SecItemCopyMatching((__bridge CFDictionaryRef)query, &result);
+        if (status != errSecSuccess)
+        {
+            return status;
+        }
+
+        NSData *value = (__bridge_transfer NSData *)result;
+
+        *size = value.length;
+        *data = malloc(value.length ? value.length : 1);
+        memcpy(*data, value.bytes, value.length);
+
+        return errSecSuccess;
+    }
+}
+
+int32_t secrets_delete(const char *service, const char *account)
+{
+    @autoreleasepool
+    {
+        return SecItemDelete((__bridge CFDictionaryRef)secrets_query(service, account));
+    }
+}
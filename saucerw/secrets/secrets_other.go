@@ -0,0 +1,19 @@
+//go:build darwin && !(cgo && saucer)
+
+package secrets
+
+import "context"
+
+// The Keychain backend is compiled with the native backend of saucer only.
+
+func set(context.Context, string, string, []byte) error {
+	return ErrUnsupported
+}
+
+func get(context.Context, string, string) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func remove(context.Context, string, string) error {
+	return ErrUnsupported
+}
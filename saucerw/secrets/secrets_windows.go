@@ -0,0 +1,123 @@
+//go:build windows
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32 = syscall.NewLazyDLL("advapi32.dll")
+
+	procCredWrite  = advapi32.NewProc("CredWriteW")
+	procCredRead   = advapi32.NewProc("CredReadW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+
+	// maxBlob is CRED_MAX_CREDENTIAL_BLOB_SIZE.
+	maxBlob = 5 * 512
+
+	errorNotFound syscall.Errno = 1168
+)
+
+// credential is CREDENTIALW.
+type credential struct {
+	flags          uint32
+	typ            uint32
+	targetName     *uint16
+	comment        *uint16
+	lastWritten    syscall.Filetime
+	blobSize       uint32
+	blob           *byte
+	persist        uint32
+	attributeCount uint32
+	attributes     uintptr
+	targetAlias    *uint16
+	userName       *uint16
+}
+
+// target returns the target name of the credential of account of service,
+// which the Credential Manager lists it by.
+func target(service, account string) (*uint16, error) {
+	name, err := syscall.UTF16PtrFromString(service + ":" + account)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	return name, nil
+}
+
+func set(_ context.Context, service, account string, secret []byte) error {
+	if len(secret) > maxBlob {
+		return fmt.Errorf("secrets: secret exceeds %d bytes", maxBlob)
+	}
+
+	name, err := target(service, account)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return fmt.Errorf("secrets: %w", err)
+	}
+
+	cred := credential{
+		typ:        credTypeGeneric,
+		targetName: name,
+		blobSize:   uint32(len(secret)),
+		persist:    credPersistLocalMachine,
+		userName:   user,
+	}
+	if len(secret) > 0 {
+		cred.blob = &secret[0]
+	}
+
+	if r, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0); r == 0 {
+		return fmt.Errorf("secrets: CredWrite: %w", err)
+	}
+	return nil
+}
+
+func get(_ context.Context, service, account string) ([]byte, error) {
+	name, err := target(service, account)
+	if err != nil {
+		return nil, err
+	}
+
+	var cred *credential
+	if r, _, err := procCredRead.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); r == 0 {
+		if errors.Is(err, errorNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("secrets: CredRead: %w", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.blobSize == 0 {
+		return []byte{}, nil
+	}
+	return append([]byte(nil), unsafe.Slice(cred.blob, cred.blobSize)...), nil
+}
+
+func remove(_ context.Context, service, account string) error {
+	name, err := target(service, account)
+	if err != nil {
+		return err
+	}
+
+	if r, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0); r == 0 {
+		if errors.Is(err, errorNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("secrets: CredDelete: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+//go:build !windows && !darwin
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw/internal/dbus"
+)
+
+// The Secret Service API.
+const (
+	serviceName     = "org.freedesktop.secrets"
+	servicePath     = "/org/freedesktop/secrets"
+	serviceIface    = "org.freedesktop.Secret.Service"
+	collectionIface = "org.freedesktop.Secret.Collection"
+	itemIface       = "org.freedesktop.Secret.Item"
+	promptIface     = "org.freedesktop.Secret.Prompt"
+
+	// The attributes of the items, those libsecret tools such as secret-tool
+	// search by.
+	attrService = "service"
+	attrAccount = "username"
+)
+
+// secretService is the session bus connection with its session, which
+// transfers the secrets unencrypted; the bus is private to the user.
+type secretService struct {
+	conn    *dbus.Conn
+	session dbus.ObjectPath
+
+	mu      sync.Mutex
+	waiting map[dbus.ObjectPath]chan []any
+}
+
+var (
+	serviceOnce sync.Once
+	shared      *secretService
+	sharedErr   error
+)
+
+// connect returns the shared connection, connecting on first use.
+func connect() (*secretService, error) {
+	serviceOnce.Do(func() {
+		conn, err := dbus.SessionBus()
+		if err != nil {
+			sharedErr = fmt.Errorf("%w: %w", ErrUnsupported, err)
+			return
+		}
+
+		out, err := conn.Call(serviceName, servicePath, serviceIface, "OpenSession", "sv", "plain", dbus.MakeVariant("s", ""))
+		if err != nil {
+			conn.Close()
+			sharedErr = fmt.Errorf("%w: %w", ErrUnsupported, err)
+			return
+		}
+
+		session, _ := at(out, 1).(dbus.ObjectPath)
+
+		match := fmt.Sprintf("type='signal',interface='%s',member='Completed'", promptIface)
+		if _, err := conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", match); err != nil {
+			conn.Close()
+			sharedErr = fmt.Errorf("secrets: %w", err)
+			return
+		}
+
+		shared = &secretService{conn: conn, session: session, waiting: map[dbus.ObjectPath]chan []any{}}
+		conn.Handle(shared.handle)
+	})
+	return shared, sharedErr
+}
+
+// handle delivers Completed signals. It runs on the goroutine reading the
+// connection.
+func (s *secretService) handle(msg *dbus.Message) {
+	if msg.Type != dbus.TypeSignal || msg.Interface != promptIface || msg.Member != "Completed" {
+		return
+	}
+
+	s.mu.Lock()
+	ch := s.waiting[msg.Path]
+	s.mu.Unlock()
+
+	if ch != nil {
+		select {
+		case ch <- msg.Body:
+		default:
+		}
+	}
+}
+
+// call calls method of the object at path.
+func (s *secretService) call(path dbus.ObjectPath, iface, method, sig string, args ...any) ([]any, error) {
+	out, err := s.conn.Call(serviceName, path, iface, method, sig, args...)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %s: %w", method, err)
+	}
+	return out, nil
+}
+
+// prompt shows the prompt at path, "/" for none, and waits until the user
+// completed it.
+func (s *secretService) prompt(ctx context.Context, path dbus.ObjectPath) error {
+	if path == "/" || path == "" {
+		return nil
+	}
+
+	ch := make(chan []any, 1)
+
+	s.mu.Lock()
+	s.waiting[path] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.waiting, path)
+		s.mu.Unlock()
+	}()
+
+	if _, err := s.call(path, promptIface, "Prompt", "s", ""); err != nil {
+		return err
+	}
+
+	select {
+	case body := <-ch:
+		if dismissed, _ := at(body, 0).(bool); dismissed {
+			return errors.New("secrets: the user dismissed the prompt")
+		}
+		return nil
+	case <-ctx.Done():
+		s.call(path, promptIface, "Dismiss", "")
+		return ctx.Err()
+	case <-s.conn.Done():
+		return fmt.Errorf("secrets: %w", dbus.ErrClosed)
+	}
+}
+
+// unlock unlocks the objects at paths, prompting the user if needed.
+func (s *secretService) unlock(ctx context.Context, paths []dbus.ObjectPath) error {
+	out, err := s.call(servicePath, serviceIface, "Unlock", "ao", paths)
+	if err != nil {
+		return err
+	}
+
+	prompt, _ := at(out, 1).(dbus.ObjectPath)
+	return s.prompt(ctx, prompt)
+}
+
+// search returns the items of account of service, unlocking them.
+func (s *secretService) search(ctx context.Context, service, account string) ([]dbus.ObjectPath, error) {
+	out, err := s.call(servicePath, serviceIface, "SearchItems", "a{ss}", attributes(service, account))
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked, locked := paths(at(out, 0)), paths(at(out, 1))
+	if len(locked) > 0 {
+		if err := s.unlock(ctx, locked); err != nil {
+			return nil, err
+		}
+	}
+
+	items := append(unlocked, locked...)
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return items, nil
+}
+
+// attributes returns the attributes of the item of account of service.
+func attributes(service, account string) map[string]string {
+	return map[string]string{attrService: service, attrAccount: account}
+}
+
+// at returns the i-th value of body, nil if it is shorter.
+func at(body []any, i int) any {
+	if i < len(body) {
+		return body[i]
+	}
+	return nil
+}
+
+// paths returns the object paths of an array decoded from "ao".
+func paths(v any) []dbus.ObjectPath {
+	values, _ := v.([]any)
+
+	rtn := make([]dbus.ObjectPath, 0, len(values))
+	for _, value := range values {
+		if path, ok := value.(dbus.ObjectPath); ok {
+			rtn = append(rtn, path)
+		}
+	}
+	return rtn
+}
+
+func set(ctx context.Context, service, account string, secret []byte) error {
+	s, err := connect()
+	if err != nil {
+		return err
+	}
+
+	out, err := s.call(servicePath, serviceIface, "ReadAlias", "s", "default")
+	if err != nil {
+		return err
+	}
+
+	collection, _ := at(out, 0).(dbus.ObjectPath)
+	if collection == "/" || collection == "" {
+		return fmt.Errorf("%w: no default collection", ErrUnsupported)
+	}
+
+	if err := s.unlock(ctx, []dbus.ObjectPath{collection}); err != nil {
+		return err
+	}
+
+	props := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant("s", service+" ("+account+")"),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant("a{ss}", attributes(service, account)),
+	}
+	value := []any{s.session, []byte{}, secret, "text/plain; charset=utf8"}
+
+	out, err = s.call(collection, collectionIface, "CreateItem", "a{sv}(oayays)b", props, value, true)
+	if err != nil {
+		return err
+	}
+
+	prompt, _ := at(out, 1).(dbus.ObjectPath)
+	return s.prompt(ctx, prompt)
+}
+
+func get(ctx context.Context, service, account string) ([]byte, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.search(ctx, service, account)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.call(items[0], itemIface, "GetSecret", "o", s.session)
+	if err != nil {
+		return nil, err
+	}
+
+	// (session, parameters, value, content type)
+	value, _ := at(out, 0).([]any)
+	if len(value) != 4 {
+		return nil, errors.New("secrets: invalid secret")
+	}
+
+	secret, _ := value[2].([]byte)
+	return secret, nil
+}
+
+func remove(ctx context.Context, service, account string) error {
+	s, err := connect()
+	if err != nil {
+		return err
+	}
+
+	items, err := s.search(ctx, service, account)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		out, err := s.call(item, itemIface, "Delete", "")
+		if err != nil {
+			return err
+		}
+
+		prompt, _ := at(out, 0).(dbus.ObjectPath)
+		if err := s.prompt(ctx, prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
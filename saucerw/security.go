@@ -0,0 +1,103 @@
+package saucerw
+
+import (
+	"net/http"
+	"time"
+)
+
+// StrictContentSecurityPolicy is the Content-Security-Policy of
+// StrictSecurity: pages load scripts, styles and other resources from their
+// own origin only, besides inline styles and data and blob images, and
+// cannot be framed. The bridge scripts are injected by the engine and not
+// restricted by it.
+const StrictContentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: blob:; font-src 'self' data:; media-src 'self' blob:; connect-src 'self' " + stashScheme + ": " + fetchScheme + ":; " +
+	"worker-src 'self' blob:; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+// SecurityPolicy restricts what the pages of a webview may do. The zero value
+// restricts nothing.
+type SecurityPolicy struct {
+	// Bridge, if non-nil, lists the rules allowing the pages of an origin to
+	// call exposed functions, e.g. to keep a third-party page the webview
+	// navigated to away from them. Calls no rule allows reject with
+	// CodePermissionDenied.
+	//
+	// The origin is that of the top-level page, as reported by the browser
+	// engine, or of the child frame for the rules with Frames set. The rules
+	// select origins, not scripts, and do not isolate the bridge: saucer
+	// has no isolated worlds on any backend and runs window.saucer in the
+	// world of the page, so every script of an allowed page, including
+	// third-party scripts it loads and same-origin frames, calls the allowed
+	// functions as the page does. The WebKit backends also let the frames of
+	// the page post to the bridge directly, as if the page did: pages
+	// allowed to call functions should not load scripts or embed frames
+	// they do not trust, and on macOS and with WebKitGTK no frames of other
+	// origins.
+	Bridge []BridgeRule
+	// ContentSecurityPolicy, if non-empty, is sent as the
+	// Content-Security-Policy header of the responses served with
+	// HandleScheme and HandleStreamScheme that do not set one. Files served
+	// with Embed are sent without headers.
+	ContentSecurityPolicy string
+	// BlockMixedContent blocks loading http and ws URLs, except from loopback
+	// hosts such as a local development server, so that pages from custom
+	// schemes or https do not load insecure content.
+	BlockMixedContent bool
+	// OnDenied, if non-nil, is called for every call the Bridge rules denied,
+	// e.g. to keep an audit log. Denied calls are logged as warnings too. It
+	// runs on the event loop thread and must not block.
+	OnDenied func(DeniedCall)
+}
+
+// DeniedCall describes a call of an exposed function a SecurityPolicy denied.
+type DeniedCall struct {
+	// Time is when the page made the call.
+	Time time.Time
+	// URL is the URL of the page.
+	URL string
+	// Origin is the origin of URL the rules were matched against.
+	Origin string
+	// Frame is the ID of the child frame that made the call, see
+	// Webview.Frames. URL and Origin are then those of the frame.
+	Frame string
+	// Function is the name of the function the page called.
+	Function string
+}
+
+// StrictSecurity returns a policy allowing the pages of origins to call all
+// exposed functions and no other page to call any, sending
+// StrictContentSecurityPolicy and blocking mixed content:
+//
+//	view, err := saucerw.NewWebview(saucerw.WebviewOptions{
+//		Window:   win,
+//		Security: saucerw.StrictSecurity("app://localhost"),
+//	})
+func StrictSecurity(origins ...string) SecurityPolicy {
+	rtn := SecurityPolicy{
+		Bridge:                []BridgeRule{},
+		ContentSecurityPolicy: StrictContentSecurityPolicy,
+		BlockMixedContent:     true,
+	}
+
+	for _, origin := range origins {
+		rtn.Bridge = append(rtn.Bridge, BridgeRule{Origin: origin})
+	}
+	return rtn
+}
+
+// validate returns an error for rules that cannot match.
+func (p *SecurityPolicy) validate() error {
+	for _, rule := range p.Bridge {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// header sets the Content-Security-Policy of p on header.
+func (p *SecurityPolicy) header(header http.Header) {
+	if p.ContentSecurityPolicy != "" {
+		header.Set("Content-Security-Policy", p.ContentSecurityPolicy)
+	}
+}
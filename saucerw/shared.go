@@ -0,0 +1,129 @@
+package saucerw
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// sharedScript delivers the shared buffers posted by WebView2 like the
+// payloads of SendBytes.
+const sharedScript = `
+window.chrome?.webview?.addEventListener("sharedbufferreceived", (event) =>
+{
+    const name = event.additionalData?.["saucer:shared"];
+
+    if (typeof name !== "string")
+    {
+        return;
+    }
+
+    const data = event.getBuffer();
+
+    window.saucer.bytes.set(name, data);
+    window.dispatchEvent(new CustomEvent("saucer:bytes", { detail: { name, data, shared: true } }));
+});
+`
+
+// SharedBuffer is a block of memory for large payloads such as video frames,
+// sent to the page without encoding or copying it where the backend can map
+// it into the page. Only the WebView2 backend shares memory; elsewhere Send
+// falls back to SendBytes with a copy of the buffer, which the page fetches
+// from the stash scheme.
+//
+// The page receives a buffer like the payloads of SendBytes, the detail of
+// its "saucer:bytes" event having shared set if the ArrayBuffer maps the
+// memory of the buffer. Writes on either side are then seen by the other,
+// which is why access must be coordinated, e.g. through a Channel. The
+// methods of SharedBuffer are safe to call from any goroutine.
+type SharedBuffer struct {
+	webview *Webview
+
+	mu     sync.Mutex
+	data   []byte
+	native SharedMemory
+	closed bool
+}
+
+// sharing installs sharedScript once.
+func (v *Webview) sharing() {
+	v.shared.Do(func() {
+		v.native.Inject(Script{Code: sharedScript, Time: AtCreation, Permanent: true})
+		v.native.Execute(sharedScript)
+	})
+}
+
+// NewSharedBuffer allocates a buffer of size bytes, shared with the page if
+// the backend supports it.
+func (v *Webview) NewSharedBuffer(size int) (*SharedBuffer, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("saucerw: bad shared buffer size %d", size)
+	}
+
+	native, err := v.native.NewSharedBuffer(size)
+	switch {
+	case errors.Is(err, ErrUnsupported):
+		return &SharedBuffer{webview: v, data: make([]byte, size)}, nil
+	case err != nil:
+		return nil, err
+	}
+
+	v.sharing()
+
+	return &SharedBuffer{webview: v, data: native.Bytes(), native: native}, nil
+}
+
+// Bytes returns the memory of the buffer. It must not be used once the buffer
+// is closed.
+func (b *SharedBuffer) Bytes() []byte {
+	return b.data
+}
+
+// Shared reports whether the memory is mapped into the page rather than
+// copied by Send.
+func (b *SharedBuffer) Shared() bool {
+	return b.native != nil
+}
+
+// Send delivers the buffer to the page under name. The page may write to a
+// shared buffer unless readOnly is set and keeps its ArrayBuffer until the
+// buffer is closed, window.chrome.webview.releaseBuffer releases it earlier.
+func (b *SharedBuffer) Send(name string, readOnly bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.closed:
+		return errors.New("saucerw: shared buffer closed")
+	case b.webview.bridge.ctx.Err() != nil:
+		return ErrReleased
+	case b.native == nil:
+		b.webview.SendBytes(name, slices.Clone(b.data))
+		return nil
+	}
+
+	detail, _ := json.Marshal(map[string]string{"saucer:shared": name})
+	b.native.Post(readOnly, string(detail))
+
+	return nil
+}
+
+// Close frees the buffer, detaching the ArrayBuffers of the page mapping it.
+// It may be called after the webview was released, but not once the
+// application quit.
+func (b *SharedBuffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+
+	if b.native != nil {
+		b.native.Close()
+	}
+}
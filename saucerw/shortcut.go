@@ -0,0 +1,263 @@
+package saucerw
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// ErrShortcutConflict is returned by RegisterShortcut for a shortcut that is
+// already registered or the accelerator of a menu item.
+var ErrShortcutConflict = errors.New("saucerw: shortcut is already in use")
+
+// shortcutScript intercepts the registered shortcuts before the page and the
+// browser engine see them and posts them to Go. It is formatted with the
+// names of the shortcuts in the syntax of Accelerator.String; running it again
+// replaces them.
+const shortcutScript = `
+(() =>
+{
+    const internal = window.saucer.internal;
+    const first    = !internal.shortcuts;
+
+    internal.shortcuts = new Set(%s);
+
+    if (!first)
+    {
+        return;
+    }
+
+    const named = {
+        " ": "Space",
+        "+": "Plus",
+        "-": "Minus",
+        Esc: "Escape",
+        Del: "Delete",
+        ArrowUp: "Up",
+        ArrowDown: "Down",
+        ArrowLeft: "Left",
+        ArrowRight: "Right",
+    };
+
+    // The key as named by ParseAccelerator, letters and digits by position
+    // so that modifiers and layouts do not change them
+    const key = (e) =>
+    {
+        if (/^Key[A-Z]$/.test(e.code))
+        {
+            return e.code.slice(3);
+        }
+
+        if (/^Digit[0-9]$/.test(e.code))
+        {
+            return e.code.slice(5);
+        }
+
+        if (e.key in named)
+        {
+            return named[e.key];
+        }
+
+        return e.key.length === 1 ? e.key.toUpperCase() : e.key;
+    };
+
+    window.addEventListener("keydown", (e) =>
+    {
+        if (!e.key || internal.shortcuts.size === 0)
+        {
+            return;
+        }
+
+        const pressed = key(e);
+
+        // Most layouts need Shift for the plus key
+        const shift     = e.shiftKey && pressed !== "Plus";
+        const modifiers = [[e.ctrlKey, "Ctrl"], [e.altKey, "Alt"], [shift, "Shift"], [e.metaKey, "Super"]];
+        const name      = [...modifiers.filter(([down]) => down).map(([, name]) => name), pressed].join("+");
+
+        if (!internal.shortcuts.has(name))
+        {
+            return;
+        }
+
+        e.preventDefault();
+        e.stopImmediatePropagation();
+
+        if (!e.repeat)
+        {
+            internal.post(JSON.stringify({ ["saucer:shortcut"]: name }));
+        }
+    }, true);
+})();
+`
+
+// shortcuts are the shortcuts registered with Application.RegisterShortcut.
+type shortcuts struct {
+	mu       sync.Mutex
+	handlers map[string]*func()
+}
+
+// shortcutOverride tracks the script injected for the shortcuts.
+type shortcutOverride struct {
+	mu       sync.Mutex
+	script   uint64
+	injected bool
+}
+
+// RegisterShortcut registers fn, called off the event loop thread when
+// accelerator is pressed in a focused window of the application, see
+// ParseAccelerator. Unlike menu accelerators, shortcuts need no menu item.
+// Pressing the shortcut does nothing else: the page does not see the key
+// event and shortcuts of the browser engine, e.g. F5 reloading the page or
+// Ctrl+P printing it, are suppressed. A nil fn only suppresses them.
+//
+// It returns ErrShortcutConflict if the shortcut is already registered or the
+// accelerator of an item of a window menu. Cancel the subscription to
+// unregister it.
+//
+// The shortcuts are seen by the pages, not by the frames they embed. See
+// package hotkey for shortcuts triggered while the application is not
+// focused.
+func (a *Application) RegisterShortcut(accelerator string, fn func()) (*Subscription, error) {
+	parsed, err := ParseAccelerator(accelerator)
+	if err != nil {
+		return nil, err
+	}
+	name := parsed.String()
+
+	for _, w := range a.Windows() {
+		if w.menuUses(parsed) {
+			return nil, fmt.Errorf("%w: %s is the accelerator of a menu item", ErrShortcutConflict, name)
+		}
+	}
+
+	handler := &fn
+
+	a.keys.mu.Lock()
+	if _, ok := a.keys.handlers[name]; ok {
+		a.keys.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s is already registered", ErrShortcutConflict, name)
+	}
+	if a.keys.handlers == nil {
+		a.keys.handlers = map[string]*func(){}
+	}
+	a.keys.handlers[name] = handler
+	a.keys.mu.Unlock()
+
+	a.updateShortcuts()
+
+	return &Subscription{cancel: func() {
+		a.keys.mu.Lock()
+		if a.keys.handlers[name] == handler {
+			delete(a.keys.handlers, name)
+		}
+		a.keys.mu.Unlock()
+
+		a.updateShortcuts()
+	}}, nil
+}
+
+// names returns the names of the registered shortcuts, sorted.
+func (s *shortcuts) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rtn := make([]string, 0, len(s.handlers))
+	for name := range s.handlers {
+		rtn = append(rtn, name)
+	}
+	slices.Sort(rtn)
+	return rtn
+}
+
+// updateShortcuts passes the registered shortcuts to all webviews.
+func (a *Application) updateShortcuts() {
+	names := a.keys.names()
+
+	for _, w := range a.Windows() {
+		for _, v := range w.Webviews() {
+			v.setShortcuts(names)
+		}
+	}
+}
+
+// shortcut handles the shortcut name pressed in a page.
+func (a *Application) shortcut(name string) {
+	a.keys.mu.Lock()
+	handler := a.keys.handlers[name]
+	a.keys.mu.Unlock()
+
+	if handler != nil && *handler != nil {
+		a.pressed.emit(*handler)
+	}
+}
+
+// setShortcuts replaces the shortcuts the pages of the webview intercept.
+func (v *Webview) setShortcuts(names []string) {
+	v.keys.mu.Lock()
+	defer v.keys.mu.Unlock()
+
+	if v.keys.injected {
+		v.native.Uninject(v.keys.script)
+		v.keys.injected = false
+	}
+
+	encoded, _ := json.Marshal(names)
+	code := fmt.Sprintf(shortcutScript, encoded)
+
+	if len(names) > 0 {
+		v.keys.script = v.native.Inject(Script{Code: code, Time: AtCreation, Permanent: true})
+		v.keys.injected = true
+	}
+
+	// The script only runs on the next page load, update the current one
+	v.native.Execute(code)
+}
+
+// menuUses reports whether a is the accelerator of an item of the menu of
+// the window.
+func (w *Window) menuUses(a Accelerator) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state := w.menu
+	if state == nil {
+		return false
+	}
+
+	var walk func(items []MenuItem) bool
+	walk = func(items []MenuItem) bool {
+		for i := range items {
+			item := &items[i]
+			if item.Role.macOnly() && runtime.GOOS != "darwin" {
+				continue
+			}
+
+			accelerator := item.Accelerator
+			if accelerator == "" {
+				accelerator = roleDefaults[item.Role].accelerator
+			}
+
+			if accelerator != "" && !item.Separator {
+				if parsed, err := ParseAccelerator(accelerator); err == nil && parsed == a {
+					return true
+				}
+			}
+
+			if walk(item.Submenu) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, menu := range state.menus {
+		if walk(menu.Items) {
+			return true
+		}
+	}
+	return false
+}
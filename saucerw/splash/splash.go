@@ -0,0 +1,199 @@
+// Package splash shows a splash window while the main window of an
+// application loads, instead of the white flash of a large single-page
+// application starting cold.
+//
+// The splash is a small frameless window with a static page, shown right
+// away. Handoff shows the main window and closes the splash once the page of
+// the main window is ready, at the latest after Options.Timeout:
+//
+//	s, err := splash.Show(app, splash.Options{Image: logo})
+//	if err != nil {
+//		return err
+//	}
+//
+//	win, _ := app.NewWindow(saucerw.WindowOptions{})
+//	view, _ := saucerw.NewWebview(saucerw.WebviewOptions{Window: win})
+//	s.Handoff(view)
+//	view.Navigate("app://localhost/index.html")
+//
+// By default the page signals readiness by calling window.saucer.ready(),
+// e.g. after its first render; Options.Until selects the dom-ready or load
+// events instead. Handoff has to be called before the page is loaded.
+package splash
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/screens"
+)
+
+// Signal selects when the page of the main window counts as ready.
+type Signal uint8
+
+const (
+	// Ready waits for the page to call window.saucer.ready().
+	Ready Signal = iota
+	// DomReady waits for the DOM of the page to be ready.
+	DomReady
+	// Loaded waits for the page to finish loading.
+	Loaded
+)
+
+// Options configures a splash window.
+type Options struct {
+	// HTML is the page shown, a page showing Image centered on Background if
+	// empty. It should not load anything over the network.
+	HTML string
+	// Image is the image data shown by the default page, e.g. a PNG logo.
+	Image []byte
+	// Background is the color of the window and the default page, white if
+	// zero.
+	Background saucerw.Color
+	// Size is the size of the window, 480x320 if zero.
+	Size saucerw.Size
+	// Until selects when the main window is ready.
+	Until Signal
+	// Timeout shows the main window if it did not get ready in time, 15
+	// seconds if zero.
+	Timeout time.Duration
+}
+
+// Splash is a splash window.
+type Splash struct {
+	window *saucerw.Window
+	opts   Options
+
+	once sync.Once
+
+	mu   sync.Mutex
+	stop func()
+}
+
+// Show creates the splash window of app and shows it centered on the first
+// screen. Like Application.NewWindow, it has to be called once the event loop
+// runs.
+func Show(app *saucerw.Application, opts Options) (*Splash, error) {
+	if opts.Background == (saucerw.Color{}) {
+		opts.Background = saucerw.Color{R: 255, G: 255, B: 255, A: 255}
+	}
+	if opts.Size == (saucerw.Size{}) {
+		opts.Size = saucerw.Size{W: 480, H: 320}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 15 * time.Second
+	}
+
+	win, err := app.NewWindow(saucerw.WindowOptions{Frameless: true, MinSize: opts.Size, MaxSize: opts.Size})
+	if err != nil {
+		return nil, fmt.Errorf("splash: %w", err)
+	}
+
+	view, err := saucerw.NewWebview(saucerw.WebviewOptions{Window: win, DisableAttributes: true})
+	if err != nil {
+		_ = win.Destroy()
+		return nil, fmt.Errorf("splash: %w", err)
+	}
+
+	win.SetSize(opts.Size)
+	win.SetResizable(false)
+	win.SetBackground(opts.Background)
+	view.SetBackground(opts.Background)
+	view.SetContextMenu(func(saucerw.ContextInfo) []saucerw.MenuItem { return []saucerw.MenuItem{} })
+	view.SetHTML(opts.page())
+
+	if all := screens.List(app); len(all) > 0 {
+		screens.Center(win, all[0])
+	}
+	win.Show()
+
+	return &Splash{window: win, opts: opts}, nil
+}
+
+// page returns the HTML of the splash page.
+func (o *Options) page() string {
+	if o.HTML != "" {
+		return o.HTML
+	}
+
+	bg := fmt.Sprintf("rgba(%d, %d, %d, %.3f)", o.Background.R, o.Background.G, o.Background.B, float64(o.Background.A)/255)
+
+	img := ""
+	if len(o.Image) > 0 {
+		src := "data:" + http.DetectContentType(o.Image) + ";base64," + base64.StdEncoding.EncodeToString(o.Image)
+		img = `<img src="` + html.EscapeString(src) + `" alt="">`
+	}
+
+	return `<!DOCTYPE html><html><head><meta charset="utf-8"><style>` +
+		`html, body { margin: 0; height: 100%; overflow: hidden; user-select: none; background: ` + bg + `; }` +
+		`body { display: flex; align-items: center; justify-content: center; }` +
+		`img { max-width: 80%; max-height: 80%; }` +
+		`</style></head><body>` + img + `</body></html>`
+}
+
+// Window returns the splash window.
+func (s *Splash) Window() *saucerw.Window {
+	return s.window
+}
+
+// Handoff shows the window of main and closes the splash once the page of
+// main is ready as selected by Options.Until, or when Options.Timeout passed.
+// It has to be called before main loads the page.
+func (s *Splash) Handoff(main *saucerw.Webview) {
+	swap := func() {
+		s.once.Do(func() {
+			main.Parent().Show()
+			main.Parent().Focus()
+			s.close()
+		})
+	}
+
+	var sub *saucerw.Subscription
+	switch s.opts.Until {
+	case DomReady:
+		sub = main.OnDomReady(swap)
+	case Loaded:
+		sub = main.OnLoad(func(state saucerw.LoadState) {
+			if state == saucerw.LoadFinished {
+				swap()
+			}
+		})
+	default:
+		sub = main.OnReady(swap)
+	}
+
+	timer := time.AfterFunc(s.opts.Timeout, swap)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stop = func() {
+		timer.Stop()
+		sub.Cancel()
+	}
+}
+
+// Close closes the splash without showing a main window, e.g. when the
+// application failed to start.
+func (s *Splash) Close() {
+	s.once.Do(s.close)
+}
+
+// close stops waiting for the main window and destroys the splash window.
+func (s *Splash) close() {
+	s.mu.Lock()
+	stop := s.stop
+	s.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	// Fails only once the application quit, which released the window
+	_ = s.window.Destroy()
+}
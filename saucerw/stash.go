@@ -0,0 +1,137 @@
+package saucerw
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// stashScheme is the custom scheme carrying binary payloads between Go and
+// the page. It is registered by every Application.
+const stashScheme = "saucerw"
+
+// stashScript extends the bridge with binary payloads: ArrayBuffer, typed
+// array and Blob arguments of window.saucer.call are uploaded through the
+// stash scheme and replaced by references, payloads sent with SendBytes are
+// fetched from it.
+const stashScript = `
+window.saucer.internal.stashID = 0;
+
+window.saucer.internal.pack = async (params) =>
+{
+    return Promise.all(params.map(async (param) =>
+    {
+        if (!(param instanceof ArrayBuffer || ArrayBuffer.isView(param) || param instanceof Blob))
+        {
+            return param;
+        }
+
+        const id = ` + "`${Date.now()}-${++window.saucer.internal.stashID}`" + `;
+        await fetch(` + "`saucerw://stash/in/${id}`" + `, { method: "POST", body: param });
+
+        return { ["saucer:bytes"]: id };
+    }));
+};
+
+window.saucer.bytes = new Map();
+
+window.saucer.internal.receive = async (name) =>
+{
+    const response = await fetch(` + "`saucerw://stash/out/${encodeURIComponent(name)}`" + `);
+    const data     = await response.arrayBuffer();
+
+    window.saucer.bytes.set(name, data);
+    window.dispatchEvent(new CustomEvent("saucer:bytes", { detail: { name, data } }));
+};
+`
+
+var bytesType = reflect.TypeFor[[]byte]()
+
+// stashRef is the reference replacing an uploaded argument.
+type stashRef struct {
+	ID string `json:"saucer:bytes"`
+}
+
+// stash holds binary payloads in transit, served on the stash scheme.
+type stash struct {
+	mu  sync.Mutex
+	in  map[string][]byte
+	out map[string][]byte
+}
+
+func newStash() *stash {
+	return &stash{in: map[string][]byte{}, out: map[string][]byte{}}
+}
+
+// ServeHTTP stores uploads and serves outgoing payloads, each exactly once.
+func (s *stash) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	kind, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case kind == "in" && r.Method == http.MethodPost:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.in[name] = data
+	case kind == "out" && r.Method == http.MethodGet:
+		data, ok := s.out[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		delete(s.out, name)
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(data)
+	default:
+		http.Error(w, "bad stash request", http.StatusBadRequest)
+	}
+}
+
+// take returns and removes the upload referenced by param, if it is a
+// reference.
+func (s *stash) take(param json.RawMessage) ([]byte, bool) {
+	if !strings.Contains(string(param), `"saucer:bytes"`) {
+		return nil, false
+	}
+
+	var ref stashRef
+	if err := json.Unmarshal(param, &ref); err != nil || ref.ID == "" {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.in[ref.ID]
+	delete(s.in, ref.ID)
+
+	return data, ok
+}
+
+// SendBytes delivers data to the page without encoding it. The page receives
+// it as an ArrayBuffer through a "saucer:bytes" event on window, whose detail
+// carries the name and the data, and in the window.saucer.bytes map.
+// Sending a name again before the page fetched it replaces the payload.
+func (v *Webview) SendBytes(name string, data []byte) {
+	s := v.bridge.stash
+
+	s.mu.Lock()
+	s.out[name] = data
+	s.mu.Unlock()
+
+	encoded, _ := json.Marshal(name)
+	v.bridge.batch.execute(fmt.Sprintf("window.saucer.internal.receive(%s);", encoded))
+}
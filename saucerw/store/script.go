@@ -0,0 +1,109 @@
+package store
+
+// script adds window.saucer.store(name) for the store it is formatted with,
+// by its JSON encoded name and the prefix of its exposed functions. The page
+// keeps a copy of the entries, which the changes pushed by Go and the
+// snapshot loaded on start update if they are newer. Deleted keys are kept
+// with their version, so an older change does not bring them back.
+const script = `
+(() =>
+{
+    if (!window.saucer)
+    {
+        return;
+    }
+
+    const name   = %s;
+    const prefix = %q;
+    const stores = window.saucer.internal.stores ??= new Map();
+
+    if (stores.has(name))
+    {
+        return;
+    }
+
+    const entries   = new Map();
+    const listeners = new Set();
+
+    const notify = (key, value) =>
+    {
+        for (const listener of [...listeners])
+        {
+            if (listener.key !== undefined && listener.key !== key)
+            {
+                continue;
+            }
+
+            try
+            {
+                listener.fn(value, key);
+            } catch (e)
+            {
+                console.error(e);
+            }
+        }
+    };
+
+    const apply = (changes) =>
+    {
+        for (const { key, value, version, deleted } of changes)
+        {
+            if (!version || (entries.get(key)?.version ?? 0) >= version)
+            {
+                continue;
+            }
+
+            entries.set(key, { value: deleted ? undefined : value, version, deleted: !!deleted });
+            notify(key, deleted ? undefined : value);
+        }
+    };
+
+    const ready = window.saucer.call(prefix + "snapshot", []).then((snapshot) =>
+    {
+        apply(Object.entries(snapshot.entries ?? {}).map(([key, entry]) => ({ key, ...entry })));
+
+        // Deleted before the snapshot, while the page missed the change
+        const gone = [...entries].filter(([key, entry]) => !entry.deleted && entry.version <= snapshot.version && !(key in (snapshot.entries ?? {})));
+        apply(gone.map(([key]) => ({ key, version: snapshot.version, deleted: true })));
+    });
+
+    const base = (key) =>
+    {
+        const entry = entries.get(key);
+        return entry && !entry.deleted ? entry.version : 0;
+    };
+
+    const write = async (fn, key, ...args) =>
+    {
+        await ready;
+
+        apply([await window.saucer.call(prefix + fn, [key, ...args, base(key)])]);
+    };
+
+    const store = {
+        name,
+        ready,
+        get: (key) => entries.get(key)?.value,
+        has: (key) => entries.has(key) && !entries.get(key).deleted,
+        keys: () => [...entries].filter(([, entry]) => !entry.deleted).map(([key]) => key),
+        version: (key) => base(key),
+        set: (key, value) => write("set", key, value ?? null),
+        delete: (key) => write("delete", key),
+        subscribe: (key, fn) =>
+        {
+            const listener = typeof key === "function" ? { fn: key } : { key, fn };
+
+            listeners.add(listener);
+            return () => listeners.delete(listener);
+        },
+    };
+
+    stores.set(name, { store, apply });
+
+    window.saucer.store ??= (name = "store") => window.saucer.internal.stores.get(name)?.store;
+})();
+`
+
+// applyScript passes the JSON encoded changes to the copy of the store named
+// by the JSON encoded name.
+const applyScript = "window.saucer.internal.stores?.get(%s)?.apply(%s);"
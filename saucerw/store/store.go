@@ -0,0 +1,422 @@
+// Package store shares a key/value store owned by Go with the pages of
+// webviews, so the settings or documents of an application are kept in sync
+// without exposing getters and setters for each of them.
+//
+// Values are JSON. Every change gets the next version of the store; Go and
+// the pages see the changes in order and both may subscribe to them:
+//
+//	settings := store.New(store.Options{Name: "settings"})
+//	settings.Set("theme", "dark")
+//	settings.OnChange(func(c store.Change) { log.Println(c.Key, "is now", string(c.Value)) })
+//	settings.Attach(view)
+//
+// A page holds a copy of the store, which it reads synchronously:
+//
+//	const settings = window.saucer.store("settings");
+//	await settings.ready;
+//
+//	settings.get("theme");
+//	settings.subscribe("theme", (theme) => applyTheme(theme));
+//	await settings.set("theme", "light");
+//
+// A write of a page is based on the version of the key it has seen. If the
+// key changed meanwhile, Options.Resolve decides; without it the write
+// rejects with an Error whose code is CodeConflict and whose data is the
+// current Entry, which the page has by then as well.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// CodeConflict is the code of the Error a conflicting write of a page rejects
+// with, see saucerw.BridgeError.
+const CodeConflict = "conflict"
+
+var (
+	// ErrConflict is returned by Options.Resolve to reject a write.
+	ErrConflict = errors.New("store: conflicting write")
+	// ErrReadOnly rejects the writes of the pages of a read-only store.
+	ErrReadOnly = errors.New("store: store is read-only")
+)
+
+// Entry is a value with the version of its last change.
+type Entry struct {
+	Value   json.RawMessage `json:"value"`
+	Version uint64          `json:"version"`
+}
+
+// Change is the change of a key.
+type Change struct {
+	Key string `json:"key"`
+	// Value is the new value, nil if the key was deleted.
+	Value   json.RawMessage `json:"value"`
+	Version uint64          `json:"version"`
+	Deleted bool            `json:"deleted,omitempty"`
+	// FromPage is set if a page made the change.
+	FromPage bool `json:"-"`
+}
+
+// Conflict is a write of a page based on a version of the key that is not
+// the current one.
+type Conflict struct {
+	Key string
+	// Base is the version the page has seen, 0 if it had not seen the key.
+	Base uint64
+	// Current is the current entry, with version 0 if the key is not set.
+	Current Entry
+	// Proposed is the value the page wrote, nil if it deleted the key.
+	Proposed json.RawMessage
+}
+
+// Options configures a Store.
+type Options struct {
+	// Name is the name pages open the store by, "store" if empty. The
+	// functions of the store are exposed as "store.<name>.*".
+	Name string
+	// ReadOnly rejects the writes of the pages with ErrReadOnly.
+	ReadOnly bool
+	// Validate, if non-nil, checks the values the pages write; an error
+	// rejects the write.
+	Validate func(key string, value json.RawMessage) error
+	// Resolve, if non-nil, returns the value a conflicting write results in,
+	// e.g. c.Proposed to let the last write win or a merge of both. It may
+	// return nil to delete the key or ErrConflict to reject the write.
+	Resolve func(c Conflict) (json.RawMessage, error)
+}
+
+// Store is a versioned key/value store. Its methods are safe to call from any
+// goroutine.
+type Store struct {
+	opts Options
+
+	mu        sync.Mutex
+	entries   map[string]Entry
+	version   uint64
+	listeners map[int]func(Change)
+	next      int
+	views     map[*saucerw.Webview]*attached
+
+	// pending are the changes not passed to the listeners yet, notifying is
+	// set while a goroutine passes them.
+	pending   []pending
+	notifying bool
+}
+
+// pending is a change with the listeners it is passed to.
+type pending struct {
+	change Change
+	fns    []func(Change)
+}
+
+// attached is a webview whose pages follow the store.
+type attached struct {
+	script uint64
+	sub    *saucerw.Subscription
+}
+
+// New returns an empty store.
+func New(opts Options) *Store {
+	if opts.Name == "" {
+		opts.Name = "store"
+	}
+
+	return &Store{
+		opts:      opts,
+		entries:   map[string]Entry{},
+		listeners: map[int]func(Change){},
+		views:     map[*saucerw.Webview]*attached{},
+	}
+}
+
+// Name returns the name of the store.
+func (s *Store) Name() string {
+	return s.opts.Name
+}
+
+// Version returns the version of the last change.
+func (s *Store) Version() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version
+}
+
+// Get decodes the value of key into out, which may be nil, and returns its
+// version. ok is false if key is not set.
+func (s *Store) Get(key string, out any) (version uint64, ok bool, err error) {
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	s.mu.Unlock()
+
+	if !ok || out == nil {
+		return e.Version, ok, nil
+	}
+	return e.Version, true, json.Unmarshal(e.Value, out)
+}
+
+// Snapshot returns the entries of the store.
+func (s *Store) Snapshot() map[string]Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.entries)
+}
+
+// Set sets key to the JSON encoding of value and returns its version.
+func (s *Store) Set(key string, value any) (uint64, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0, fmt.Errorf("store: %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.change(key, data, false), nil
+}
+
+// Delete deletes key, if it is set.
+func (s *Store) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; ok {
+		s.change(key, nil, false)
+	}
+}
+
+// Update sets key to the JSON encoding of the value fn returns for its
+// current value, nil if it is not set, and returns its version. No other
+// change happens in between; fn must not call the methods of s. An error of
+// fn leaves the key unchanged.
+func (s *Store) Update(key string, fn func(current json.RawMessage) (any, error)) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, err := fn(s.entries[key].Value)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0, fmt.Errorf("store: %s: %w", key, err)
+	}
+
+	return s.change(key, data, false), nil
+}
+
+// OnChange adds a receiver of the changes, called in order on a goroutine of
+// the store. It returns a function removing it.
+func (s *Store) OnChange(fn func(Change)) (remove func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.next
+	s.next++
+	s.listeners[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.listeners, id)
+	}
+}
+
+// change sets key to value, deleting it if value is nil, and reports the
+// change. It returns the new version. s.mu is held.
+func (s *Store) change(key string, value json.RawMessage, page bool) uint64 {
+	s.version++
+
+	c := Change{Key: key, Value: value, Version: s.version, Deleted: value == nil, FromPage: page}
+	if c.Deleted {
+		delete(s.entries, key)
+	} else {
+		s.entries[key] = Entry{Value: value, Version: s.version}
+	}
+
+	changes, _ := json.Marshal([]Change{c})
+	for v := range s.views {
+		v.Execute(fmt.Sprintf(applyScript, s.quotedName(), changes))
+	}
+
+	fns := make([]func(Change), 0, len(s.listeners))
+	for _, fn := range s.listeners {
+		fns = append(fns, fn)
+	}
+
+	s.pending = append(s.pending, pending{change: c, fns: fns})
+	if !s.notifying {
+		s.notifying = true
+		go s.notify()
+	}
+
+	return s.version
+}
+
+// notify passes the pending changes to the listeners until there are none.
+func (s *Store) notify() {
+	s.mu.Lock()
+
+	for len(s.pending) > 0 {
+		p := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+
+		for _, fn := range p.fns {
+			fn(p.change)
+		}
+
+		s.mu.Lock()
+	}
+
+	s.notifying = false
+	s.mu.Unlock()
+}
+
+// write applies the write of a page, based on version base, and returns the
+// resulting change, none if a deleted key was not set. A nil value deletes
+// the key.
+func (s *Store) write(key string, value json.RawMessage, base uint64) (Change, error) {
+	if s.opts.ReadOnly {
+		return Change{}, &saucerw.BridgeError{Code: saucerw.CodePermissionDenied, Err: ErrReadOnly}
+	}
+
+	if value != nil && s.opts.Validate != nil {
+		if err := s.opts.Validate(key, value); err != nil {
+			var be *saucerw.BridgeError
+			if !errors.As(err, &be) {
+				err = &saucerw.BridgeError{Code: saucerw.CodeInvalidArgument, Err: err}
+			}
+			return Change{}, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.entries[key]
+	if current.Version != base {
+		if s.opts.Resolve == nil {
+			return Change{}, conflict(current)
+		}
+
+		// Called with the lock held so the entry cannot change meanwhile
+		resolved, err := s.opts.Resolve(Conflict{Key: key, Base: base, Current: current, Proposed: value})
+		if errors.Is(err, ErrConflict) {
+			return Change{}, conflict(current)
+		}
+		if err != nil {
+			return Change{}, err
+		}
+		value = resolved
+	}
+
+	if _, ok := s.entries[key]; !ok && value == nil {
+		return Change{}, nil
+	}
+
+	version := s.change(key, value, true)
+	return Change{Key: key, Value: value, Version: version, Deleted: value == nil, FromPage: true}, nil
+}
+
+// conflict returns the error of a write conflicting with current.
+func conflict(current Entry) error {
+	return &saucerw.BridgeError{Code: CodeConflict, Err: ErrConflict, Data: current}
+}
+
+// snapshot is the state of the store pages start from.
+type snapshot struct {
+	Version uint64           `json:"version"`
+	Entries map[string]Entry `json:"entries"`
+}
+
+// quotedName returns the JSON encoding of the name.
+func (s *Store) quotedName() []byte {
+	name, _ := json.Marshal(s.opts.Name)
+	return name
+}
+
+// Attach shares the store with the pages of v, see the package
+// documentation, until the window of v is closed or Detach is called. The
+// script is injected into the pages loaded afterwards and the current one.
+//
+// The page-facing functions are exposed as "store.<name>.snapshot",
+// "store.<name>.set" and "store.<name>.delete".
+func (s *Store) Attach(v *saucerw.Webview) error {
+	prefix := "store." + s.opts.Name + "."
+
+	err := v.Expose(prefix+"snapshot", func() snapshot {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return snapshot{Version: s.version, Entries: maps.Clone(s.entries)}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = v.Expose(prefix+"set", func(key string, value json.RawMessage, base uint64) (Change, error) {
+		return s.write(key, value, base)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = v.Expose(prefix+"delete", func(key string, base uint64) (Change, error) {
+		return s.write(key, nil, base)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.views[v]; ok {
+		return nil
+	}
+
+	a := &attached{}
+	a.sub = v.Parent().OnClosed(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.views, v)
+	})
+
+	code := fmt.Sprintf(script, s.quotedName(), prefix)
+	a.script = v.InjectScript(saucerw.Script{Code: code, Time: saucerw.AtCreation, Frames: saucerw.MainFrame, Permanent: true})
+	v.Execute(code)
+	s.views[v] = a
+
+	return nil
+}
+
+// Detach stops sharing the store with the pages of v.
+func (s *Store) Detach(v *saucerw.Webview) {
+	s.mu.Lock()
+	a, ok := s.views[v]
+	delete(s.views, v)
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	a.sub.Cancel()
+	v.Uninject(a.script)
+
+	prefix := "store." + s.opts.Name + "."
+	for _, name := range []string{"snapshot", "set", "delete"} {
+		v.Unexpose(prefix + name)
+	}
+}
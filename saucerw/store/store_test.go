@@ -0,0 +1,245 @@
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+	"github.com/aperturerobotics/saucer/saucerw/store"
+)
+
+// writers is the number of pages writing at once.
+const writers = 16
+
+// attach shares s with a fake page and runs test with the page.
+func attach(t *testing.T, s *store.Store, test func(page *saucertest.Webview)) {
+	t.Helper()
+
+	drv := saucertest.New()
+	app, err := saucerw.NewApplicationWithDriver(drv, saucerw.AppOptions{ID: "com.example.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.Run(func(app *saucerw.Application) {
+		win, err := app.NewWindow(saucerw.WindowOptions{})
+		if err != nil {
+			t.Error(err)
+			app.Quit()
+			return
+		}
+		view, err := saucerw.NewWebview(saucerw.WebviewOptions{Window: win})
+		if err != nil {
+			t.Error(err)
+			app.Quit()
+			return
+		}
+		if err := s.Attach(view); err != nil {
+			t.Error(err)
+			app.Quit()
+			return
+		}
+
+		page := drv.App().Windows()[0].Webviews()[0]
+		go func() {
+			defer app.Quit()
+			test(page)
+		}()
+	})
+}
+
+// writeAll makes the writers set key at once to value with their index, all
+// based on version base, and returns the results of the calls.
+func writeAll(page *saucertest.Webview, key, value string, base uint64) ([]store.Change, []error) {
+	var (
+		wg      sync.WaitGroup
+		changes = make([]store.Change, writers)
+		errs    = make([]error, writers)
+	)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := page.Call(context.Background(), "store.test.set", key, json.RawMessage(fmt.Sprintf(value, i)), base)
+			if err == nil {
+				err = json.Unmarshal(result, &changes[i])
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	return changes, errs
+}
+
+// TestConflict checks that of writes based on the same version one wins and
+// the others reject with the entry it wrote.
+func TestConflict(t *testing.T) {
+	s := store.New(store.Options{Name: "test"})
+	base, err := s.Set("count", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attach(t, s, func(page *saucertest.Webview) {
+		changes, errs := writeAll(page, "count", "%d", base)
+
+		var won []store.Change
+		for i, err := range errs {
+			if err == nil {
+				won = append(won, changes[i])
+			}
+		}
+		if len(won) != 1 {
+			t.Errorf("%d writes won, expected 1", len(won))
+			return
+		}
+
+		for _, err := range errs {
+			if err == nil {
+				continue
+			}
+
+			var ce *saucertest.CallError
+			if !errors.As(err, &ce) || ce.Code != store.CodeConflict {
+				t.Errorf("write failed with %v, expected a conflict", err)
+				continue
+			}
+			var current store.Entry
+			if err := json.Unmarshal(ce.Data, &current); err != nil || current.Version != won[0].Version || string(current.Value) != string(won[0].Value) {
+				t.Errorf("conflict with %s, %v, expected the entry %s of version %d", ce.Data, err, won[0].Value, won[0].Version)
+			}
+		}
+
+		// A write based on the current version succeeds
+		if _, err := page.Call(context.Background(), "store.test.set", "count", json.RawMessage("100"), won[0].Version); err != nil {
+			t.Error(err)
+		}
+		// Deleting based on the stale version conflicts
+		if _, err := page.Call(context.Background(), "store.test.delete", "count", base); callCode(err) != store.CodeConflict {
+			t.Errorf("stale delete returned %v, expected a conflict", err)
+		}
+	})
+
+	var count int
+	if _, ok, err := s.Get("count", &count); !ok || err != nil || count != 100 {
+		t.Errorf("count %d, %v, %v, expected 100", count, ok, err)
+	}
+}
+
+// TestResolve checks that Resolve sees every write based on a stale version
+// and that its results apply in order.
+func TestResolve(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		conflicts []store.Conflict
+	)
+	s := store.New(store.Options{Name: "test", Resolve: func(c store.Conflict) (json.RawMessage, error) {
+		mu.Lock()
+		conflicts = append(conflicts, c)
+		mu.Unlock()
+
+		// Merge the written lists
+		var current, proposed []int
+		if err := json.Unmarshal(c.Current.Value, &current); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(c.Proposed, &proposed); err != nil {
+			return nil, err
+		}
+		return json.Marshal(append(current, proposed...))
+	}})
+	base, err := s.Set("list", []int{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attach(t, s, func(page *saucertest.Webview) {
+		// The first write replaces the list, the others are merged into it
+		_, errs := writeAll(page, "list", "[%d]", base)
+		for _, err := range errs {
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	if len(conflicts) != writers-1 {
+		t.Fatalf("%d conflicts, expected %d", len(conflicts), writers-1)
+	}
+	for _, c := range conflicts {
+		if c.Key != "list" || c.Base != base || c.Current.Version <= base {
+			t.Errorf("conflict of %s based on %d with version %d", c.Key, c.Base, c.Current.Version)
+		}
+	}
+
+	var list []int
+	version, _, err := s.Get("list", &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != base+writers || len(list) != writers {
+		t.Fatalf("list %v of version %d, expected %d numbers of version %d", list, version, writers, base+writers)
+	}
+	slices.Sort(list)
+	for i, n := range list[1:] {
+		if n == list[i] {
+			t.Errorf("list %v holds %d twice", list, n)
+		}
+	}
+}
+
+func TestResolveRejects(t *testing.T) {
+	failure := errors.New("cannot merge")
+	tests := []struct {
+		name    string
+		resolve func(store.Conflict) (json.RawMessage, error)
+		code    string
+		// deleted reports whether the write deleted the key.
+		deleted bool
+	}{
+		{name: "conflict", resolve: func(store.Conflict) (json.RawMessage, error) { return nil, store.ErrConflict }, code: store.CodeConflict},
+		{name: "error", resolve: func(store.Conflict) (json.RawMessage, error) { return nil, failure }, code: string(saucerw.CodeUnknown)},
+		{name: "delete", resolve: func(store.Conflict) (json.RawMessage, error) { return nil, nil }, deleted: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := store.New(store.Options{Name: "test", Resolve: test.resolve})
+			base, _ := s.Set("key", "first")
+			if _, err := s.Set("key", "second"); err != nil {
+				t.Fatal(err)
+			}
+
+			attach(t, s, func(page *saucertest.Webview) {
+				_, err := page.Call(context.Background(), "store.test.set", "key", json.RawMessage(`"stale"`), base)
+				if got := callCode(err); got != test.code {
+					t.Errorf("write returned %v, expected code %q", err, test.code)
+				}
+			})
+
+			var value string
+			_, ok, _ := s.Get("key", &value)
+			if ok == test.deleted || !test.deleted && value != "second" {
+				t.Errorf("key is %q, set %v", value, ok)
+			}
+		})
+	}
+}
+
+// callCode returns the code a call rejected with, "" if it succeeded.
+func callCode(err error) string {
+	var ce *saucertest.CallError
+	if errors.As(err, &ce) {
+		return ce.Code
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,357 @@
+package saucerw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// streamScript turns the streamed results of exposed functions into
+// ReadableStreams, which can be iterated with for await in every engine. Like
+// a Channel, the page grants the items it has room for, 64 of them. Cancelling the stream
+// or leaving the loop early cancels the function.
+const streamScript = `
+window.saucer.internal.streams = new Map();
+
+window.saucer.internal.stream = (id, op, value) =>
+{
+    window.saucer.internal.streams.get(id)?.(op, value);
+};
+
+window.saucer.internal.streamed = (call, id) =>
+{
+    const rpc  = window.saucer.internal.rpc[call];
+    const post = (op, fields) => window.saucer.internal.post(JSON.stringify({ ["saucer:stream"]: id, op, ...fields }));
+
+    delete window.saucer.internal.rpc[call];
+
+    if (!rpc)
+    {
+        post("cancel");
+        return;
+    }
+
+    const streams = window.saucer.internal.streams;
+    const size    = 64;
+
+    let controller  = undefined;
+    let outstanding = 0;
+
+    const stream = new ReadableStream({
+        start: (c) =>
+        {
+            controller = c;
+        },
+        pull: () =>
+        {
+            const credit = controller.desiredSize - outstanding;
+
+            if (credit > 0)
+            {
+                outstanding += credit;
+                post("credit", { credit });
+            }
+        },
+        cancel: () =>
+        {
+            streams.delete(id);
+            post("cancel");
+        },
+    }, new CountQueuingStrategy({ highWaterMark: size }));
+
+    streams.set(id, (op, value) =>
+    {
+        switch (op)
+        {
+        case "data":
+            outstanding--;
+            controller.enqueue(value);
+            break;
+        case "end":
+            streams.delete(id);
+            controller.close();
+            break;
+        case "error":
+            streams.delete(id);
+            controller.error(window.saucer.internal.error(value));
+            break;
+        }
+    });
+
+    if (!stream[Symbol.asyncIterator])
+    {
+        stream[Symbol.asyncIterator] = async function* ()
+        {
+            const reader = stream.getReader();
+            let done     = false;
+
+            try
+            {
+                while (true)
+                {
+                    const next = await reader.read();
+
+                    if (next.done)
+                    {
+                        done = true;
+                        return;
+                    }
+
+                    yield next.value;
+                }
+            } finally
+            {
+                if (!done)
+                {
+                    await reader.cancel().catch(() => {});
+                }
+
+                reader.releaseLock();
+            }
+        };
+    }
+
+    rpc.resolve(stream);
+};
+`
+
+// streamedScript settles the promise of a bridge call with the stream id, see
+// resolveScript.
+const streamedScript = "window.saucer.internal.streamed(%d, %d);"
+
+// ErrStreamCanceled is returned by StreamWriter.Send once the page stopped
+// reading the stream, e.g. by leaving a for await loop, or navigated away.
+var ErrStreamCanceled = errors.New("saucerw: stream canceled")
+
+var streamWriterType = reflect.TypeFor[*StreamWriter]()
+
+// StreamWriter sends the items of a streamed result, see Webview.Expose.
+type StreamWriter struct {
+	s *stream
+}
+
+// Send sends the JSON encoding of value as the next item of the stream. It
+// waits until the page has room for it and fails with ErrStreamCanceled once
+// the page stopped reading.
+func (w *StreamWriter) Send(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("saucerw: stream: %w", err)
+	}
+	return w.s.send(data)
+}
+
+// stream is a streamed result of an exposed function.
+type stream struct {
+	id     uint64
+	bridge *bridge
+	// ctx is the context of the function, canceled once the page stopped
+	// reading.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// run sends the items, started once the page got the stream.
+	run func()
+
+	mu sync.Mutex
+	// credit is the number of items the page has room for.
+	credit int
+	// changed is closed and replaced whenever credit changes.
+	changed chan struct{}
+}
+
+// streamRef is the result of an exposed function whose items are streamed.
+type streamRef struct {
+	ID uint64 `json:"saucer:stream"`
+}
+
+// newStream registers a stream for a call with context ctx. Its context keeps
+// the values of ctx and is canceled with the webview, not with the call.
+func (b *bridge) newStream(ctx context.Context) *stream {
+	s := &stream{bridge: b, changed: make(chan struct{})}
+	s.ctx, s.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	context.AfterFunc(b.ctx, s.cancel)
+
+	b.mu.Lock()
+	b.lastID++
+	s.id = b.lastID
+	b.streams[s.id] = s
+	b.mu.Unlock()
+
+	return s
+}
+
+// ref returns the JSON encoded result of the call returning s.
+func (s *stream) ref() []byte {
+	ref, _ := json.Marshal(streamRef{ID: s.id})
+	return ref
+}
+
+// send posts an item once the page has room for it.
+func (s *stream) send(data []byte) error {
+	s.mu.Lock()
+	for s.credit == 0 && s.ctx.Err() == nil {
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-s.ctx.Done():
+		}
+		s.mu.Lock()
+	}
+
+	if err := s.err(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.credit--
+	s.mu.Unlock()
+
+	s.bridge.batch.execute(fmt.Sprintf("window.saucer.internal.stream(%d, \"data\", %s);", s.id, data))
+	return nil
+}
+
+// err returns why the stream ended early, if it did.
+func (s *stream) err() error {
+	switch {
+	case s.bridge.ctx.Err() != nil:
+		return ErrReleased
+	case s.ctx.Err() != nil:
+		return ErrStreamCanceled
+	}
+	return nil
+}
+
+// end ends the stream, with err unless it is nil. A canceled stream ends
+// silently.
+func (s *stream) end(err error) {
+	if s.ctx.Err() == nil {
+		if err != nil {
+			reason, _ := json.Marshal(newGoError(err))
+			s.bridge.batch.execute(fmt.Sprintf("window.saucer.internal.stream(%d, \"error\", %s);", s.id, reason))
+		} else {
+			s.bridge.batch.execute(fmt.Sprintf("window.saucer.internal.stream(%d, \"end\", null);", s.id))
+		}
+	}
+
+	s.bridge.dropStream(s.id)
+}
+
+// drain returns the run of a stream sending the values received from ch
+// until it is closed or the stream canceled.
+func (s *stream) drain(ch reflect.Value) func() {
+	return func() {
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: ch},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.ctx.Done())},
+		}
+
+		for {
+			chosen, value, ok := reflect.Select(cases)
+			if chosen == 1 || !ok {
+				s.end(nil)
+				return
+			}
+
+			data, err := json.Marshal(value.Interface())
+			if err == nil {
+				err = s.send(data)
+			}
+			if err != nil {
+				s.end(err)
+				return
+			}
+		}
+	}
+}
+
+// streamOf returns the stream whose ref is result, nil if result is not one.
+func (b *bridge) streamOf(result []byte) *stream {
+	if !bytes.HasPrefix(result, []byte(`{"saucer:stream":`)) {
+		return nil
+	}
+
+	var ref streamRef
+	if json.Unmarshal(result, &ref) != nil {
+		return nil
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.streams[ref.ID]
+}
+
+// started sends result of call id to the page and starts its stream, if it is
+// the ref of one that did not start yet.
+func (b *bridge) started(id uint64, result []byte) bool {
+	s := b.streamOf(result)
+	if s == nil || s.run == nil {
+		return false
+	}
+
+	run := s.run
+	s.run = nil
+
+	b.batch.execute(fmt.Sprintf(streamedScript, id, s.id))
+	go run()
+
+	return true
+}
+
+// dropStreamOf cancels the stream whose ref is the result of an aborted call.
+func (b *bridge) dropStreamOf(result []byte) {
+	if s := b.streamOf(result); s != nil {
+		b.dropStream(s.id)
+	}
+}
+
+// onStream handles a stream message posted by the page.
+func (b *bridge) onStream(msg bridgeMessage) {
+	b.mu.RLock()
+	s, ok := b.streams[msg.Stream]
+	b.mu.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	switch msg.Op {
+	case "credit":
+		s.mu.Lock()
+		s.credit += msg.Credit
+		close(s.changed)
+		s.changed = make(chan struct{})
+		s.mu.Unlock()
+	case "cancel":
+		b.dropStream(s.id)
+	}
+}
+
+// dropStream cancels and forgets stream id.
+func (b *bridge) dropStream(id uint64) {
+	b.mu.Lock()
+	s, ok := b.streams[id]
+	delete(b.streams, id)
+	b.mu.Unlock()
+
+	if ok {
+		s.cancel()
+	}
+}
+
+// cancelStreams cancels the streams of a page that navigated away.
+func (b *bridge) cancelStreams() {
+	b.mu.Lock()
+	streams := b.streams
+	b.streams = map[uint64]*stream{}
+	b.mu.Unlock()
+
+	for _, s := range streams {
+		s.cancel()
+	}
+}
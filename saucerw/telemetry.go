@@ -0,0 +1,192 @@
+package saucerw
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// tracerName is the name the webview asks its TracerProvider for a Tracer
+// with, the instrumentation scope of OpenTelemetry.
+const tracerName = "github.com/aperturerobotics/saucer/saucerw"
+
+// TracerProvider provides the Tracer recording spans around the work of a
+// webview: calls from the page to exposed functions ("saucerw.bridge.call",
+// SpanKindServer), Eval and Call ("saucerw.eval", SpanKindClient), page loads
+// ("saucerw.load") and custom scheme requests ("saucerw.scheme",
+// SpanKindServer). Metrics such as call counts and latencies are derived
+// from the spans by the implementation.
+//
+// The bindings have no dependencies, so TracerProvider, Tracer and Span
+// mirror the interfaces of go.opentelemetry.io/otel/trace, with slog.Attr
+// for attribute.KeyValue, and an OpenTelemetry trace.TracerProvider is used
+// through an adapter mapping every method onto its counterpart:
+//
+//	type otelProvider struct{ trace.TracerProvider }
+//
+//	func (p otelProvider) Tracer(name string) saucerw.Tracer {
+//		return otelTracer{p.TracerProvider.Tracer(name)}
+//	}
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, kind saucerw.SpanKind, attrs ...slog.Attr) (context.Context, saucerw.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKind(kind)), trace.WithAttributes(otelAttrs(attrs)...))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ span trace.Span }
+//
+//	func (s otelSpan) SetAttributes(attrs ...slog.Attr)            { s.span.SetAttributes(otelAttrs(attrs)...) }
+//	func (s otelSpan) RecordError(err error)                       { s.span.RecordError(err) }
+//	func (s otelSpan) SetStatus(code saucerw.StatusCode, d string) { s.span.SetStatus(codes.Code(code), d) }
+//	func (s otelSpan) End()                                        { s.span.End() }
+//
+//	func otelAttrs(attrs []slog.Attr) []attribute.KeyValue {
+//		kvs := make([]attribute.KeyValue, len(attrs))
+//		for i, a := range attrs {
+//			if a.Value.Kind() == slog.KindInt64 {
+//				kvs[i] = attribute.Int64(a.Key, a.Value.Int64())
+//			} else {
+//				kvs[i] = attribute.String(a.Key, a.Value.String())
+//			}
+//		}
+//		return kvs
+//	}
+//
+// The values of SpanKind and StatusCode are those of trace.SpanKind and
+// codes.Code, and the attributes follow the semantic conventions.
+type TracerProvider interface {
+	// Tracer returns the Tracer of the instrumentation scope name.
+	Tracer(name string) Tracer
+}
+
+// Tracer starts spans, see TracerProvider.
+type Tracer interface {
+	// Start starts a span of kind named name as a child of the span in ctx
+	// and returns a context carrying it.
+	Start(ctx context.Context, name string, kind SpanKind, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// SpanKind is the role of a span, with the values of trace.SpanKind.
+type SpanKind int
+
+// Span kinds of the spans of a webview.
+const (
+	// SpanKindInternal is an operation of the application, a page load.
+	SpanKindInternal SpanKind = 1
+	// SpanKindServer handles a request of the page, a bridge call or
+	// scheme request.
+	SpanKindServer SpanKind = 2
+	// SpanKindClient is a request to the page, an evaluation.
+	SpanKindClient SpanKind = 3
+)
+
+// StatusCode is the status of a span, with the values of codes.Code.
+type StatusCode uint32
+
+// Status codes of spans.
+const (
+	StatusUnset StatusCode = 0
+	StatusError StatusCode = 1
+	StatusOK    StatusCode = 2
+)
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttributes adds attributes known after the span started.
+	SetAttributes(attrs ...slog.Attr)
+	// RecordError records err as an exception event of the span.
+	RecordError(err error)
+	// SetStatus sets the status of the span, with a description for
+	// StatusError.
+	SetStatus(code StatusCode, description string)
+	// End ends the span.
+	End()
+}
+
+// noopSpan is the Span used without a TracerProvider.
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr)   {}
+func (noopSpan) RecordError(error)            {}
+func (noopSpan) SetStatus(StatusCode, string) {}
+func (noopSpan) End()                         {}
+
+// endSpan ends span, failed with err if non-nil.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(StatusError, err.Error())
+	}
+	span.End()
+}
+
+// startSpan starts a span with the tracer of the webview, if any.
+func (v *Webview) startSpan(ctx context.Context, name string, kind SpanKind, attrs ...slog.Attr) (context.Context, Span) {
+	if v.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return v.tracer.Start(ctx, name, kind, attrs...)
+}
+
+// trace installs the spans that are not started inline: bridge calls, as the
+// outermost middleware so the exposed functions receive the span in their
+// context, and page loads.
+func (v *Webview) trace() {
+	if v.tracer == nil {
+		return
+	}
+
+	v.UseBridge(func(next BridgeHandler) BridgeHandler {
+		return func(ctx context.Context, call *BridgeCall) (json.RawMessage, error) {
+			ctx, span := v.startSpan(ctx, "saucerw.bridge.call", SpanKindServer, slog.String("saucerw.function", call.Name))
+
+			result, err := next(ctx, call)
+			endSpan(span, err)
+
+			return result, err
+		}
+	})
+
+	var (
+		mu   sync.Mutex
+		load Span
+	)
+
+	v.OnLoad(func(state LoadState) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// A load started before the last one finished replaces it.
+		if load != nil {
+			load.End()
+			load = nil
+		}
+
+		if state == LoadStarted {
+			_, load = v.startSpan(context.Background(), "saucerw.load", SpanKindInternal, slog.String("url.full", v.URL()))
+		}
+	})
+}
+
+// traceScheme starts the span of a request r for the custom scheme name. The
+// returned function ends it with the response status.
+func (v *Webview) traceScheme(name string, r *http.Request) (*http.Request, func(status int)) {
+	ctx, span := v.startSpan(r.Context(), "saucerw.scheme", SpanKindServer,
+		slog.String("saucerw.scheme", name),
+		slog.String("http.request.method", r.Method),
+		slog.String("url.full", r.URL.String()),
+	)
+
+	return r.WithContext(ctx), func(status int) {
+		span.SetAttributes(slog.Int("http.response.status_code", status))
+		// Server spans fail with server errors only
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, "")
+		}
+		span.End()
+	}
+}
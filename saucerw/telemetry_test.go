@@ -0,0 +1,102 @@
+package saucerw_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/saucertest"
+)
+
+// recorder is a TracerProvider recording the spans once they ended.
+type recorder struct {
+	mu    sync.Mutex
+	scope string
+	spans []*span
+}
+
+func (r *recorder) Tracer(name string) saucerw.Tracer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.scope = name
+	return r
+}
+
+func (r *recorder) Start(ctx context.Context, name string, kind saucerw.SpanKind, attrs ...slog.Attr) (context.Context, saucerw.Span) {
+	return ctx, &span{r: r, name: name, kind: kind, attrs: attrs}
+}
+
+// ended returns the spans that ended.
+func (r *recorder) ended() []*span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*span(nil), r.spans...)
+}
+
+// span is a span of a recorder.
+type span struct {
+	r      *recorder
+	name   string
+	kind   saucerw.SpanKind
+	attrs  []slog.Attr
+	err    error
+	status saucerw.StatusCode
+}
+
+func (s *span) SetAttributes(attrs ...slog.Attr) { s.attrs = append(s.attrs, attrs...) }
+func (s *span) RecordError(err error)            { s.err = err }
+func (s *span) SetStatus(code saucerw.StatusCode, _ string) {
+	s.status = code
+}
+
+func (s *span) End() {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	s.r.spans = append(s.r.spans, s)
+}
+
+func TestTracerProvider(t *testing.T) {
+	r := &recorder{}
+	failure := errors.New("no")
+
+	runPage(t, saucerw.WebviewOptions{TracerProvider: r}, func(v *saucerw.Webview) {
+		v.Expose("add", func(a, b int) int { return a + b })
+		v.Expose("fail", func() error { return failure })
+	}, func(page *saucertest.Webview) {
+		ctx := context.Background()
+
+		if _, err := page.Call(ctx, "add", 1, 2); err != nil {
+			t.Error(err)
+		}
+		if _, err := page.Call(ctx, "fail"); err == nil {
+			t.Error("fail succeeded")
+		}
+	})
+
+	if r.scope != "github.com/aperturerobotics/saucer/saucerw" {
+		t.Errorf("tracer of scope %q", r.scope)
+	}
+
+	spans := r.ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans, expected 2", len(spans))
+	}
+	for i, function := range []string{"add", "fail"} {
+		s := spans[i]
+		if s.name != "saucerw.bridge.call" || s.kind != saucerw.SpanKindServer || len(s.attrs) != 1 || s.attrs[0].Value.String() != function {
+			t.Errorf("span %s of kind %d with %v, expected the call of %s", s.name, s.kind, s.attrs, function)
+		}
+	}
+	if s := spans[0]; s.err != nil || s.status != saucerw.StatusUnset {
+		t.Errorf("call of add failed with %v, status %d", s.err, s.status)
+	}
+	if s := spans[1]; !errors.Is(s.err, failure) || s.status != saucerw.StatusError {
+		t.Errorf("call of fail recorded %v, status %d", s.err, s.status)
+	}
+}
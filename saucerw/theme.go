@@ -0,0 +1,279 @@
+package saucerw
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// featureScript overrides the prefers-color-scheme, prefers-reduced-motion
+// and prefers-contrast media features for the backends that cannot force
+// them. It merges the features set to a value into those of the earlier runs
+// and removes the empty ones, then rewrites the media queries of the style
+// sheets the page can access and of matchMedia to match the forced values and
+// sets the color-scheme of the root element for the controls drawn by the
+// engine. Queries of features without a forced value are left as is.
+const featureScript = `
+(() =>
+{
+    const forced = %s;
+    const state  = window["saucer:features"];
+
+    if (state)
+    {
+        Object.assign(state.forced, forced);
+        state.scan();
+        return;
+    }
+
+    if (!Object.values(forced).some(Boolean))
+    {
+        return;
+    }
+
+    const features = { forced: { ...forced }, media: new WeakMap() };
+    const query    = /\(\s*(prefers-color-scheme|prefers-reduced-motion|prefers-contrast)\s*(?::\s*([a-z-]+)\s*)?\)/gi;
+
+    // Queries for the forced value always match, the others never do. A
+    // feature without value matches unless it is forced to no-preference
+    const rewrite = (text) => text.replace(query, (match, name, value) =>
+    {
+        const target = features.forced[name.toLowerCase()];
+
+        if (!target)
+        {
+            return match;
+        }
+
+        const matches = value ? value.toLowerCase() === target : target !== "no-preference";
+        return matches ? "(min-width: 0px)" : "(max-width: -1px)";
+    });
+
+    const patch = (list) =>
+    {
+        if (!list)
+        {
+            return;
+        }
+
+        if (!features.media.has(list))
+        {
+            if (!/prefers-(color-scheme|reduced-motion|contrast)/i.test(list.mediaText))
+            {
+                return;
+            }
+
+            features.media.set(list, list.mediaText);
+        }
+
+        const text = rewrite(features.media.get(list));
+
+        if (list.mediaText !== text)
+        {
+            list.mediaText = text;
+        }
+    };
+
+    const visit = (sheet) =>
+    {
+        // Cross-origin style sheets cannot be read
+        try
+        {
+            patch(sheet.media);
+
+            for (const rule of sheet.cssRules)
+            {
+                patch(rule.media);
+
+                if (rule.styleSheet)
+                {
+                    visit(rule.styleSheet);
+                }
+
+                if (rule.cssRules)
+                {
+                    visit(rule);
+                }
+            }
+        } catch (e)
+        {
+        }
+    };
+
+    features.scan = () =>
+    {
+        for (const sheet of document.styleSheets)
+        {
+            visit(sheet);
+        }
+
+        const root = document.documentElement;
+
+        if (!root)
+        {
+            return;
+        }
+
+        const scheme = features.forced["prefers-color-scheme"];
+
+        // The color-scheme of the page is left alone unless the scheme was forced
+        if (scheme)
+        {
+            root.style.setProperty("color-scheme", scheme);
+            features.styled = true;
+        } else if (features.styled)
+        {
+            root.style.removeProperty("color-scheme");
+            features.styled = false;
+        }
+    };
+
+    const matchMedia = window.matchMedia.bind(window);
+    window.matchMedia = (query) => matchMedia(rewrite(String(query)));
+
+    const sheets = ["HTML", "STYLE", "LINK"];
+
+    new MutationObserver((records) =>
+    {
+        let added = false;
+
+        for (const record of records)
+        {
+            for (const node of record.addedNodes)
+            {
+                if (!sheets.includes(node.nodeName))
+                {
+                    continue;
+                }
+
+                if (node.nodeName === "LINK")
+                {
+                    node.addEventListener("load", features.scan, { once: true });
+                }
+
+                added = true;
+            }
+        }
+
+        if (added)
+        {
+            features.scan();
+        }
+    }).observe(document, { childList: true, subtree: true });
+
+    document.addEventListener("DOMContentLoaded", features.scan);
+    window["saucer:features"] = features;
+
+    features.scan();
+})();
+`
+
+// mediaFeatures returns the featureScript forcing features, a map of media
+// feature names to their values, empty values remove the override.
+func mediaFeatures(features map[string]string) string {
+	encoded, _ := json.Marshal(features)
+	return fmt.Sprintf(featureScript, encoded)
+}
+
+// featureOverride tracks a script injected to override media features.
+type featureOverride struct {
+	mu       sync.Mutex
+	script   uint64
+	injected bool
+}
+
+// set replaces the script injected into native with the featureScript
+// forcing features and runs it on the current page. The new script is only
+// kept if it forces a value.
+func (o *featureOverride) set(native WebviewDriver, features map[string]string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.injected {
+		native.Uninject(o.script)
+		o.injected = false
+	}
+
+	code := mediaFeatures(features)
+	for _, value := range features {
+		if value != "" {
+			o.script = native.Inject(Script{Code: code, Time: AtCreation, Frames: AllFrames, Permanent: true})
+			o.injected = true
+			break
+		}
+	}
+
+	// The script only runs on the next page load, update the current one
+	native.Execute(code)
+}
+
+// ColorScheme is the light or dark appearance of the system.
+type ColorScheme uint8
+
+const (
+	// ColorSchemeLight is the light appearance, also reported by systems
+	// without a preference.
+	ColorSchemeLight ColorScheme = iota
+	// ColorSchemeDark is the dark appearance.
+	ColorSchemeDark
+)
+
+// String returns "light" or "dark", the values of the prefers-color-scheme
+// media feature.
+func (s ColorScheme) String() string {
+	switch s {
+	case ColorSchemeLight:
+		return "light"
+	case ColorSchemeDark:
+		return "dark"
+	}
+	return fmt.Sprintf("ColorScheme(%d)", uint8(s))
+}
+
+// ColorScheme returns the color scheme the user selected for the system.
+func (a *Application) ColorScheme() ColorScheme {
+	return a.native.ColorScheme()
+}
+
+// OnColorSchemeChange registers fn, called with the new color scheme when the
+// user switched the appearance of the system. Webviews following the system
+// update their pages themselves.
+func (a *Application) OnColorSchemeChange(fn func(ColorScheme)) *Subscription {
+	return a.scheme.subscribe(fn)
+}
+
+// DarkMode selects the color scheme a webview presents to its pages.
+type DarkMode uint8
+
+const (
+	// DarkModeSystem follows the color scheme of the system.
+	DarkModeSystem DarkMode = iota
+	// DarkModeOff presents the light scheme.
+	DarkModeOff
+	// DarkModeOn presents the dark scheme.
+	DarkModeOn
+)
+
+// ForceDarkMode overrides the color scheme the pages of the webview see
+// through the prefers-color-scheme media feature, DarkModeSystem removes the
+// override.
+//
+// The WebView2 backend applies it to all webviews sharing the storage path.
+// The WebKitGTK and Qt backends cannot force the scheme, they rewrite the
+// media queries of the page instead. Style sheets of other origins and queries
+// evaluated before the override was added are not affected there.
+func (v *Webview) ForceDarkMode(mode DarkMode) {
+	if v.native.SetDarkMode(mode) {
+		return
+	}
+
+	var forced string
+	switch mode {
+	case DarkModeOff:
+		forced = ColorSchemeLight.String()
+	case DarkModeOn:
+		forced = ColorSchemeDark.String()
+	}
+
+	v.scheme.set(v.native, map[string]string{"prefers-color-scheme": forced})
+}
@@ -0,0 +1,78 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <WebKit/WebKit.h>
+
+#include "native.h"
+
+// Implemented in cookies_darwin.m
+WKWebView *saucerw_cocoa_webview(const void *impl);
+
+// SaucerwAppearanceObserver reports changes of the appearance of the application to its handler.
+@interface SaucerwAppearanceObserver : NSObject
+@property(nonatomic) uintptr_t handler;
+@property(nonatomic) int scheme;
+@end
+
+@implementation SaucerwAppearanceObserver
+- (void)observeValueForKeyPath:(NSString *)path
+                      ofObject:(id)object
+                        change:(NSDictionary<NSKeyValueChangeKey, id> *)change
+                       context:(void *)context
+{
+    const int scheme = saucerw_cocoa_color_scheme();
+
+    // The appearance is replaced for other reasons too, e.g. switching to high contrast
+    if (scheme == self.scheme)
+    {
+        return;
+    }
+
+    self.scheme = scheme;
+    saucerwColorScheme(self.handler, scheme);
+}
+@end
+
+int saucerw_cocoa_color_scheme(void)
+{
+    NSAppearanceName name =
+        [NSApp.effectiveAppearance bestMatchFromAppearancesWithNames:@[ NSAppearanceNameAqua, NSAppearanceNameDarkAqua ]];
+
+    return [name isEqualToString:NSAppearanceNameDarkAqua] ? SAUCERW_SCHEME_DARK : SAUCERW_SCHEME_LIGHT;
+}
+
+void *saucerw_cocoa_watch_color_scheme(uintptr_t handler)
+{
+    SaucerwAppearanceObserver *observer = [SaucerwAppearanceObserver new];
+
+    observer.handler = handler;
+    observer.scheme  = saucerw_cocoa_color_scheme();
+
+    [NSApp addObserver:observer forKeyPath:@"effectiveAppearance" options:0 context:nil];
+
+    return (__bridge_retained void *)observer;
+}
+
+void saucerw_cocoa_unwatch_color_scheme(void *watcher)
+{
+    SaucerwAppearanceObserver *observer = (__bridge_transfer SaucerwAppearanceObserver *)watcher;
+    [NSApp removeObserver:observer forKeyPath:@"effectiveAppearance"];
+}
+
+void saucerw_cocoa_set_dark_mode(const void *webview, int mode)
+{
+    WKWebView *native = saucerw_cocoa_webview(webview);
+
+    // The web view evaluates prefers-color-scheme with its own appearance, nil inherits the one of the window
+    switch (mode)
+    {
+    case SAUCERW_DARK_MODE_OFF:
+        native.appearance = [NSAppearance appearanceNamed:NSAppearanceNameAqua];
+        break;
+    case SAUCERW_DARK_MODE_ON:
+        native.appearance = [NSAppearance appearanceNamed:NSAppearanceNameDarkAqua];
+        break;
+    default:
+        native.appearance = nil;
+    }
+}
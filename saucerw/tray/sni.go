@@ -0,0 +1,390 @@
+//go:build !windows && !darwin
+
+package tray
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aperturerobotics/saucer/saucerw/internal/dbus"
+)
+
+// The StatusNotifierItem and dbusmenu specifications.
+const (
+	watcherName  = "org.kde.StatusNotifierWatcher"
+	watcherPath  = "/StatusNotifierWatcher"
+	itemIface    = "org.kde.StatusNotifierItem"
+	itemPath     = "/StatusNotifierItem"
+	menuIface    = "com.canonical.dbusmenu"
+	menuPath     = "/MenuBar"
+	propsIface   = "org.freedesktop.DBus.Properties"
+	introIface   = "org.freedesktop.DBus.Introspectable"
+	unknownError = "org.freedesktop.DBus.Error.UnknownMethod"
+)
+
+const introspection = `<!DOCTYPE node PUBLIC "-//freedesktop//DTD D-BUS Object Introspection 1.0//EN" "http://www.freedesktop.org/standards/dbus/1.0/introspect.dtd">
+<node>
+  <interface name="org.kde.StatusNotifierItem">
+    <property name="Category" type="s" access="read"/>
+    <property name="Id" type="s" access="read"/>
+    <property name="Title" type="s" access="read"/>
+    <property name="Status" type="s" access="read"/>
+    <property name="IconName" type="s" access="read"/>
+    <property name="IconPixmap" type="a(iiay)" access="read"/>
+    <property name="ToolTip" type="(sa(iiay)ss)" access="read"/>
+    <property name="ItemIsMenu" type="b" access="read"/>
+    <property name="Menu" type="o" access="read"/>
+    <method name="Activate"><arg name="x" type="i" direction="in"/><arg name="y" type="i" direction="in"/></method>
+    <method name="SecondaryActivate"><arg name="x" type="i" direction="in"/><arg name="y" type="i" direction="in"/></method>
+    <method name="ContextMenu"><arg name="x" type="i" direction="in"/><arg name="y" type="i" direction="in"/></method>
+    <method name="Scroll"><arg name="delta" type="i" direction="in"/><arg name="orientation" type="s" direction="in"/></method>
+    <signal name="NewIcon"/>
+    <signal name="NewTitle"/>
+    <signal name="NewToolTip"/>
+  </interface>
+  <interface name="com.canonical.dbusmenu">
+    <property name="Version" type="u" access="read"/>
+    <property name="TextDirection" type="s" access="read"/>
+    <property name="Status" type="s" access="read"/>
+    <property name="IconThemePath" type="as" access="read"/>
+    <method name="GetLayout"><arg type="i" direction="in"/><arg type="i" direction="in"/><arg type="as" direction="in"/><arg type="u" direction="out"/><arg type="(ia{sv}av)" direction="out"/></method>
+    <method name="GetGroupProperties"><arg type="ai" direction="in"/><arg type="as" direction="in"/><arg type="a(ia{sv})" direction="out"/></method>
+    <method name="GetProperty"><arg type="i" direction="in"/><arg type="s" direction="in"/><arg type="v" direction="out"/></method>
+    <method name="Event"><arg type="i" direction="in"/><arg type="s" direction="in"/><arg type="v" direction="in"/><arg type="u" direction="in"/></method>
+    <method name="EventGroup"><arg type="a(isvu)" direction="in"/><arg type="ai" direction="out"/></method>
+    <method name="AboutToShow"><arg type="i" direction="in"/><arg type="b" direction="out"/></method>
+    <method name="AboutToShowGroup"><arg type="ai" direction="in"/><arg type="ai" direction="out"/><arg type="ai" direction="out"/></method>
+    <signal name="LayoutUpdated"><arg type="u"/><arg type="i"/></signal>
+  </interface>
+  <interface name="org.freedesktop.DBus.Properties">
+    <method name="Get"><arg type="s" direction="in"/><arg type="s" direction="in"/><arg type="v" direction="out"/></method>
+    <method name="GetAll"><arg type="s" direction="in"/><arg type="a{sv}" direction="out"/></method>
+  </interface>
+</node>`
+
+var items atomic.Uint32
+
+// sni is a StatusNotifierItem exporting its menu with dbusmenu.
+type sni struct {
+	tray *Tray
+	conn *dbus.Conn
+	name string
+	id   string
+
+	mu       sync.Mutex
+	pixmaps  []any
+	tooltip  string
+	root     *node
+	revision uint32
+}
+
+func newBackend(t *Tray, icon []byte) (backend, error) {
+	pixmaps, err := pixmap(icon)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	s := &sni{
+		tray:    t,
+		conn:    conn,
+		name:    fmt.Sprintf("org.kde.StatusNotifierItem-%d-%d", os.Getpid(), items.Add(1)),
+		id:      filepath.Base(os.Args[0]),
+		pixmaps: pixmaps,
+		root:    &node{},
+	}
+
+	conn.Handle(s.handle)
+
+	if _, err := conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "RequestName", "su", s.name, uint32(4)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Register again whenever the tray host restarts.
+	match := "type='signal',sender='org.freedesktop.DBus',interface='org.freedesktop.DBus',member='NameOwnerChanged',arg0='" + watcherName + "'"
+	if _, err := conn.Call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", match); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := s.register(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+
+	return s, nil
+}
+
+// register announces the item to the StatusNotifierWatcher.
+func (s *sni) register() error {
+	_, err := s.conn.Call(watcherName, watcherPath, watcherName, "RegisterStatusNotifierItem", "s", s.name)
+	return err
+}
+
+// pixmap converts icon to the ARGB32 pixmaps of the IconPixmap property.
+func pixmap(icon []byte) ([]any, error) {
+	img, _, err := image.Decode(bytes.NewReader(icon))
+	if err != nil {
+		return nil, fmt.Errorf("tray: decode icon: %w", err)
+	}
+
+	bounds := img.Bounds()
+	data := make([]byte, 0, bounds.Dx()*bounds.Dy()*4)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			data = append(data, c.A, c.R, c.G, c.B)
+		}
+	}
+
+	return []any{[]any{int32(bounds.Dx()), int32(bounds.Dy()), data}}, nil
+}
+
+func (s *sni) setIcon(icon []byte) error {
+	pixmaps, err := pixmap(icon)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.pixmaps = pixmaps
+	s.mu.Unlock()
+
+	return s.conn.Emit(itemPath, itemIface, "NewIcon", "")
+}
+
+func (s *sni) setTooltip(tooltip string) {
+	s.mu.Lock()
+	s.tooltip = tooltip
+	s.mu.Unlock()
+
+	_ = s.conn.Emit(itemPath, itemIface, "NewToolTip", "")
+	_ = s.conn.Emit(itemPath, itemIface, "NewTitle", "")
+}
+
+func (s *sni) setMenu(root *node) {
+	s.mu.Lock()
+	s.root = root
+	s.revision++
+	revision := s.revision
+	s.mu.Unlock()
+
+	_ = s.conn.Emit(menuPath, menuIface, "LayoutUpdated", "ui", revision, int32(0))
+}
+
+func (s *sni) close() {
+	s.conn.Close()
+}
+
+// handle answers calls of the tray host. It runs on the connection goroutine.
+func (s *sni) handle(msg *dbus.Message) {
+	if msg.Type == dbus.TypeSignal {
+		if msg.Member == "NameOwnerChanged" && len(msg.Body) == 3 && msg.Body[0] == watcherName && msg.Body[2] != "" {
+			go s.register()
+		}
+		return
+	}
+
+	sig, body, err := s.call(msg)
+	if err != nil {
+		_ = s.conn.ReplyError(msg, unknownError, err.Error())
+		return
+	}
+	_ = s.conn.Reply(msg, sig, body...)
+}
+
+// call dispatches a method call to the item or the menu.
+func (s *sni) call(msg *dbus.Message) (string, []any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	arg := func(i int) any {
+		if i < len(msg.Body) {
+			return msg.Body[i]
+		}
+		return nil
+	}
+
+	switch msg.Interface + "." + msg.Member {
+	case introIface + ".Introspect":
+		return "s", []any{introspection}, nil
+	case propsIface + ".Get":
+		iface, _ := arg(0).(string)
+		name, _ := arg(1).(string)
+		if value, ok := s.properties(msg.Path, iface)[name]; ok {
+			return "v", []any{value}, nil
+		}
+	case propsIface + ".GetAll":
+		iface, _ := arg(0).(string)
+		return "a{sv}", []any{s.properties(msg.Path, iface)}, nil
+	case itemIface + ".Activate":
+		go s.tray.activate()
+		return "", nil, nil
+	case itemIface + ".SecondaryActivate", itemIface + ".ContextMenu", itemIface + ".Scroll":
+		return "", nil, nil
+	case menuIface + ".GetLayout":
+		parent, _ := arg(0).(int32)
+		depth, _ := arg(1).(int32)
+		if n := s.find(parent); n != nil {
+			return "u(ia{sv}av)", []any{s.revision, layout(n, depth)}, nil
+		}
+	case menuIface + ".GetGroupProperties":
+		ids, _ := arg(0).([]any)
+		var rtn []any
+		for _, id := range ids {
+			id, _ := id.(int32)
+			if n := s.find(id); n != nil {
+				rtn = append(rtn, []any{id, n.properties()})
+			}
+		}
+		return "a(ia{sv})", []any{rtn}, nil
+	case menuIface + ".GetProperty":
+		id, _ := arg(0).(int32)
+		name, _ := arg(1).(string)
+		if n := s.find(id); n != nil {
+			if value, ok := n.properties()[name]; ok {
+				return "v", []any{value}, nil
+			}
+		}
+	case menuIface + ".Event":
+		id, _ := arg(0).(int32)
+		event, _ := arg(1).(string)
+		s.event(id, event)
+		return "", nil, nil
+	case menuIface + ".EventGroup":
+		events, _ := arg(0).([]any)
+		for _, ev := range events {
+			if ev, ok := ev.([]any); ok && len(ev) == 4 {
+				id, _ := ev[0].(int32)
+				event, _ := ev[1].(string)
+				s.event(id, event)
+			}
+		}
+		return "ai", []any{[]int32{}}, nil
+	case menuIface + ".AboutToShow":
+		return "b", []any{false}, nil
+	case menuIface + ".AboutToShowGroup":
+		return "aiai", []any{[]int32{}, []int32{}}, nil
+	}
+
+	return "", nil, fmt.Errorf("unknown method %s.%s on %s", msg.Interface, msg.Member, msg.Path)
+}
+
+// event handles a menu event of the tray host.
+func (s *sni) event(id int32, event string) {
+	if event == "clicked" {
+		go s.tray.click(id)
+	}
+}
+
+// properties returns the D-Bus properties of iface.
+func (s *sni) properties(path dbus.ObjectPath, iface string) map[string]any {
+	switch {
+	case path == itemPath && iface == itemIface:
+		title := s.tooltip
+		if title == "" {
+			title = s.id
+		}
+
+		return map[string]any{
+			"Category":   dbus.MakeVariant("s", "ApplicationStatus"),
+			"Id":         dbus.MakeVariant("s", s.id),
+			"Title":      dbus.MakeVariant("s", title),
+			"Status":     dbus.MakeVariant("s", "Active"),
+			"IconName":   dbus.MakeVariant("s", ""),
+			"IconPixmap": dbus.MakeVariant("a(iiay)", s.pixmaps),
+			"ToolTip":    dbus.MakeVariant("(sa(iiay)ss)", []any{"", []any{}, s.tooltip, ""}),
+			"ItemIsMenu": dbus.MakeVariant("b", false),
+			"Menu":       dbus.MakeVariant("o", dbus.ObjectPath(menuPath)),
+		}
+	case path == menuPath && iface == menuIface:
+		return map[string]any{
+			"Version":       dbus.MakeVariant("u", uint32(3)),
+			"TextDirection": dbus.MakeVariant("s", "ltr"),
+			"Status":        dbus.MakeVariant("s", "normal"),
+			"IconThemePath": dbus.MakeVariant("as", []string{}),
+		}
+	}
+	return map[string]any{}
+}
+
+// find returns the menu node with id.
+func (s *sni) find(id int32) *node {
+	var find func(n *node) *node
+
+	find = func(n *node) *node {
+		if n.id == id {
+			return n
+		}
+		for _, child := range n.children {
+			if found := find(child); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+
+	return find(s.root)
+}
+
+// layout encodes n and its children up to depth levels, all for -1.
+func layout(n *node, depth int32) []any {
+	children := []any{}
+
+	if depth != 0 {
+		for _, child := range n.children {
+			children = append(children, dbus.MakeVariant("(ia{sv}av)", layout(child, depth-1)))
+		}
+	}
+
+	return []any{n.id, n.properties(), children}
+}
+
+// properties returns the dbusmenu properties of the item.
+func (n *node) properties() map[string]any {
+	props := map[string]any{}
+
+	if n.id == 0 {
+		props["children-display"] = dbus.MakeVariant("s", "submenu")
+		return props
+	}
+
+	if n.item.Separator {
+		props["type"] = dbus.MakeVariant("s", "separator")
+		return props
+	}
+
+	// Underscores mark access keys.
+	props["label"] = dbus.MakeVariant("s", strings.ReplaceAll(n.item.Label, "_", "__"))
+	props["enabled"] = dbus.MakeVariant("b", !n.item.Disabled)
+
+	if n.item.Checkable {
+		state := int32(0)
+		if n.item.Checked {
+			state = 1
+		}
+
+		props["toggle-type"] = dbus.MakeVariant("s", "checkmark")
+		props["toggle-state"] = dbus.MakeVariant("i", state)
+	}
+
+	if len(n.children) > 0 {
+		props["children-display"] = dbus.MakeVariant("s", "submenu")
+	}
+
+	return props
+}
@@ -0,0 +1,271 @@
+// Package tray shows an icon with a menu in the system tray, the status area
+// of the taskbar or menu bar, so background applications remain reachable
+// without an open window.
+//
+// The icon is a StatusNotifierItem on Linux and other freedesktop systems, a
+// notification area icon on Windows and a status item on macOS. Handlers run
+// on their own goroutine; use Application.Dispatch to touch windows from
+// them.
+//
+//	t, err := tray.New(icon, []tray.MenuItem{
+//		{Label: "Show", OnClick: func(tray.MenuItem) { app.Post(window.Show) }},
+//		{Label: "Sync", ID: "sync", Checkable: true, Checked: true},
+//		{Separator: true},
+//		{Label: "Quit", OnClick: func(tray.MenuItem) { app.Quit() }},
+//	})
+package tray
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrUnsupported is returned by New when the system has no tray or the
+// backend is not compiled in.
+var ErrUnsupported = errors.New("tray: system tray is not supported")
+
+// MenuItem is an entry of the tray menu.
+type MenuItem struct {
+	// ID identifies the item for the Set methods of Tray. Optional.
+	ID string
+	// Label is the text of the item.
+	Label string
+	// Disabled greys the item out.
+	Disabled bool
+	// Checkable items show a check mark and toggle Checked when clicked.
+	Checkable bool
+	// Checked is the state of a checkable item.
+	Checked bool
+	// Separator turns the item into a separator line; the other fields are
+	// ignored.
+	Separator bool
+	// Submenu, if non-empty, is shown when the item is hovered.
+	Submenu []MenuItem
+	// OnClick is called when the item is clicked, with the state after the
+	// click.
+	OnClick func(MenuItem)
+}
+
+// node is a menu item with the numeric id used by the backends. Nodes are
+// rebuilt on every change and not modified afterwards.
+type node struct {
+	id       int32
+	item     MenuItem
+	children []*node
+}
+
+// backend is the native tray icon. Its methods are called with the lock of
+// the Tray held and must not block on the handlers.
+type backend interface {
+	setIcon(icon []byte) error
+	setTooltip(tooltip string)
+	setMenu(root *node)
+	close()
+}
+
+// Tray is an icon in the system tray. Its methods are safe to call from any
+// goroutine.
+type Tray struct {
+	mu      sync.Mutex
+	native  backend
+	menu    []MenuItem
+	items   map[int32]*MenuItem
+	onClick func()
+	closed  bool
+}
+
+// New shows icon, PNG image data, in the system tray with menu. The menu
+// opens on click, or on right click where the system distinguishes them, see
+// OnClick.
+func New(icon []byte, menu []MenuItem) (*Tray, error) {
+	t := &Tray{menu: cloneMenu(menu)}
+
+	// Events arriving before the backend is stored wait for the lock.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	native, err := newBackend(t, icon)
+	if err != nil {
+		return nil, err
+	}
+
+	t.native = native
+	t.native.setMenu(t.tree())
+
+	return t, nil
+}
+
+// cloneMenu deep copies menu, so items can be updated in place.
+func cloneMenu(menu []MenuItem) []MenuItem {
+	rtn := slices.Clone(menu)
+	for i := range rtn {
+		rtn[i].Submenu = cloneMenu(rtn[i].Submenu)
+	}
+	return rtn
+}
+
+// tree numbers the items of the menu and returns the root node.
+func (t *Tray) tree() *node {
+	t.items = map[int32]*MenuItem{}
+
+	var next int32
+	var build func(menu []MenuItem) []*node
+
+	build = func(menu []MenuItem) []*node {
+		nodes := make([]*node, len(menu))
+		for i := range menu {
+			next++
+			t.items[next] = &menu[i]
+
+			nodes[i] = &node{id: next, item: menu[i]}
+			nodes[i].item.Submenu = nil
+			nodes[i].children = build(menu[i].Submenu)
+		}
+		return nodes
+	}
+
+	return &node{children: build(t.menu)}
+}
+
+// SetIcon replaces the icon.
+func (t *Tray) SetIcon(icon []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil
+	}
+	return t.native.setIcon(icon)
+}
+
+// SetTooltip sets the text shown when hovering the icon.
+func (t *Tray) SetTooltip(tooltip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.closed {
+		t.native.setTooltip(tooltip)
+	}
+}
+
+// SetMenu replaces the menu.
+func (t *Tray) SetMenu(menu []MenuItem) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.menu = cloneMenu(menu)
+	t.update()
+}
+
+// SetLabel sets the label of the items with id.
+func (t *Tray) SetLabel(id, label string) {
+	t.modify(id, func(item *MenuItem) { item.Label = label })
+}
+
+// SetChecked sets the state of the checkable items with id.
+func (t *Tray) SetChecked(id string, checked bool) {
+	t.modify(id, func(item *MenuItem) { item.Checked = checked })
+}
+
+// SetDisabled enables or disables the items with id.
+func (t *Tray) SetDisabled(id string, disabled bool) {
+	t.modify(id, func(item *MenuItem) { item.Disabled = disabled })
+}
+
+// Item returns the current state of the first item with id.
+func (t *Tray) Item(id string) (MenuItem, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, item := range t.sorted() {
+		if item.ID == id {
+			return *item, true
+		}
+	}
+	return MenuItem{}, false
+}
+
+// OnClick sets the function called when the icon itself is clicked. Where
+// the system opens the menu on click, as on macOS, fn is never called.
+func (t *Tray) OnClick(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onClick = fn
+}
+
+// Close removes the icon from the tray.
+func (t *Tray) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.closed {
+		t.closed = true
+		t.native.close()
+	}
+}
+
+// sorted returns the items in menu order.
+func (t *Tray) sorted() []*MenuItem {
+	rtn := make([]*MenuItem, len(t.items))
+	for id, item := range t.items {
+		rtn[id-1] = item
+	}
+	return rtn
+}
+
+// modify applies fn to the items with id and updates the menu.
+func (t *Tray) modify(id string, fn func(*MenuItem)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, item := range t.items {
+		if item.ID == id {
+			fn(item)
+		}
+	}
+	t.update()
+}
+
+// update passes the changed menu to the backend.
+func (t *Tray) update() {
+	if !t.closed {
+		t.native.setMenu(t.tree())
+	}
+}
+
+// activate handles a click on the icon.
+func (t *Tray) activate() {
+	t.mu.Lock()
+	fn := t.onClick
+	t.mu.Unlock()
+
+	if fn != nil {
+		go fn()
+	}
+}
+
+// click handles a click on the item with the numeric id.
+func (t *Tray) click(id int32) {
+	t.mu.Lock()
+
+	item := t.items[id]
+	if item == nil || item.Disabled || item.Separator || t.closed {
+		t.mu.Unlock()
+		return
+	}
+
+	if item.Checkable {
+		item.Checked = !item.Checked
+	}
+
+	state := *item
+	state.Submenu = cloneMenu(state.Submenu)
+	t.update()
+	t.mu.Unlock()
+
+	if state.OnClick != nil {
+		go state.OnClick(state)
+	}
+}
@@ -0,0 +1,128 @@
+//go:build darwin && cgo && saucer
+
+package tray
+
+/*
+#cgo CFLAGS: -fobjc-arc
+#cgo LDFLAGS: -framework AppKit
+
+#include <stdint.h>
+#include <stdlib.h>
+
+void *tray_new(uintptr_t id, const void *icon, size_t size);
+void tray_set_icon(void *item, const void *icon, size_t size);
+void tray_set_tooltip(void *item, const char *tooltip);
+void tray_set_menu(void *item, size_t count, int32_t *ids, int32_t *parents, char **labels, int32_t *flags);
+void tray_free(void *item);
+
+enum
+{
+    TRAY_DISABLED  = 1,
+    TRAY_CHECKABLE = 2,
+    TRAY_CHECKED   = 4,
+    TRAY_SEPARATOR = 8,
+};
+*/
+import "C"
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+var (
+	lastID atomic.Uint64
+	items  sync.Map // id → *statusItem
+)
+
+// statusItem is an NSStatusItem. The calls are queued on the main thread, so
+// they do not block before the application runs.
+type statusItem struct {
+	tray *Tray
+	id   uint64
+	ptr  unsafe.Pointer
+}
+
+func newBackend(t *Tray, icon []byte) (backend, error) {
+	if len(icon) == 0 {
+		return nil, errors.New("tray: icon is empty")
+	}
+
+	s := &statusItem{tray: t, id: lastID.Add(1)}
+	items.Store(s.id, s)
+
+	s.ptr = C.tray_new(C.uintptr_t(s.id), C.CBytes(icon), C.size_t(len(icon)))
+	return s, nil
+}
+
+//export trayClicked
+func trayClicked(id C.uintptr_t, item C.int32_t) {
+	if s, ok := items.Load(uint64(id)); ok {
+		s.(*statusItem).tray.click(int32(item))
+	}
+}
+
+func (s *statusItem) setIcon(icon []byte) error {
+	if len(icon) == 0 {
+		return errors.New("tray: icon is empty")
+	}
+
+	C.tray_set_icon(s.ptr, C.CBytes(icon), C.size_t(len(icon)))
+	return nil
+}
+
+func (s *statusItem) setTooltip(tooltip string) {
+	text := C.CString(tooltip)
+	defer C.free(unsafe.Pointer(text))
+
+	C.tray_set_tooltip(s.ptr, text)
+}
+
+func (s *statusItem) setMenu(root *node) {
+	var ids, parents, flags []C.int32_t
+	var labels []*C.char
+
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, child := range n.children {
+			var flag C.int32_t
+			if child.item.Disabled {
+				flag |= C.TRAY_DISABLED
+			}
+			if child.item.Checkable {
+				flag |= C.TRAY_CHECKABLE
+			}
+			if child.item.Checked {
+				flag |= C.TRAY_CHECKED
+			}
+			if child.item.Separator {
+				flag |= C.TRAY_SEPARATOR
+			}
+
+			label := C.CString(child.item.Label)
+			defer C.free(unsafe.Pointer(label))
+
+			ids = append(ids, C.int32_t(child.id))
+			parents = append(parents, C.int32_t(n.id))
+			labels = append(labels, label)
+			flags = append(flags, flag)
+
+			walk(child)
+		}
+	}
+	walk(root)
+
+	if len(ids) == 0 {
+		C.tray_set_menu(s.ptr, 0, nil, nil, nil, nil)
+		return
+	}
+
+	C.tray_set_menu(s.ptr, C.size_t(len(ids)), &ids[0], &parents[0], &labels[0], &flags[0])
+}
+
+func (s *statusItem) close() {
+	items.Delete(s.id)
+	C.tray_free(s.ptr)
+}
@@ -0,0 +1,139 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+
+#include "_cgo_export.h"
+
+enum
+{
+    TRAY_DISABLED  = 1,
+    TRAY_CHECKABLE = 2,
+    TRAY_CHECKED   = 4,
+    TRAY_SEPARATOR = 8,
+};
+
+@interface TrayItem : NSObject
+@property uintptr_t id;
+@property(strong) NSStatusItem *item;
+- (void)clicked:(NSMenuItem *)sender;
+@end
+
+@implementation TrayItem
+- (void)clicked:(NSMenuItem *)sender
+{
+    trayClicked(self.id, (int32_t)sender.tag);
+}
+@end
+
+// The icon data is allocated by the caller with malloc and freed here.
+static NSImage *tray_image(const void *icon, size_t size)
+{
+    NSData *data  = [NSData dataWithBytesNoCopy:(void *)icon length:size freeWhenDone:YES];
+    NSImage *rtn  = [[NSImage alloc] initWithData:data];
+    CGFloat ratio = rtn.size.height > 0 ? rtn.size.width / rtn.size.height : 1;
+
+    rtn.size = NSMakeSize(18 * ratio, 18);
+
+    return rtn;
+}
+
+void *tray_new(uintptr_t id, const void *icon, size_t size)
+{
+    TrayItem *rtn = [TrayItem new];
+    NSImage *image = tray_image(icon, size);
+
+    rtn.id = id;
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+      rtn.item              = [[NSStatusBar systemStatusBar] statusItemWithLength:NSVariableStatusItemLength];
+      rtn.item.button.image = image;
+    });
+
+    return (__bridge_retained void *)rtn;
+}
+
+void tray_set_icon(void *item, const void *icon, size_t size)
+{
+    TrayItem *tray = (__bridge TrayItem *)item;
+    NSImage *image = tray_image(icon, size);
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+      tray.item.button.image = image;
+    });
+}
+
+void tray_set_tooltip(void *item, const char *tooltip)
+{
+    TrayItem *tray = (__bridge TrayItem *)item;
+    NSString *text = [NSString stringWithUTF8String:tooltip];
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+      tray.item.button.toolTip = text;
+    });
+}
+
+void tray_set_menu(void *item, size_t count, int32_t *ids, int32_t *parents, char **labels, int32_t *flags)
+{
+    TrayItem *tray = (__bridge TrayItem *)item;
+
+    NSMutableDictionary<NSNumber *, NSMenu *> *menus       = [NSMutableDictionary dictionary];
+    NSMutableDictionary<NSNumber *, NSMenuItem *> *entries = [NSMutableDictionary dictionary];
+
+    NSMenu *root          = [NSMenu new];
+    root.autoenablesItems = NO;
+    menus[@0]             = root;
+
+    // Parents precede their children.
+    for (size_t i = 0; count > i; ++i)
+    {
+        NSMenu *parent = menus[@(parents[i])];
+
+        if (flags[i] & TRAY_SEPARATOR)
+        {
+            [parent addItem:[NSMenuItem separatorItem]];
+            continue;
+        }
+
+        NSMenuItem *entry = [[NSMenuItem alloc] initWithTitle:[NSString stringWithUTF8String:labels[i]]
+                                                       action:@selector(clicked:)
+                                                keyEquivalent:@""];
+
+        entry.target  = tray;
+        entry.tag     = ids[i];
+        entry.enabled = !(flags[i] & TRAY_DISABLED);
+
+        if (flags[i] & TRAY_CHECKABLE)
+        {
+            entry.state = (flags[i] & TRAY_CHECKED) ? NSControlStateValueOn : NSControlStateValueOff;
+        }
+
+        NSMenu *submenu          = [NSMenu new];
+        submenu.autoenablesItems = NO;
+
+        menus[@(ids[i])]   = submenu;
+        entries[@(ids[i])] = entry;
+
+        [parent addItem:entry];
+    }
+
+    for (NSNumber *id in entries)
+    {
+        if (menus[id].numberOfItems > 0)
+        {
+            entries[id].submenu = menus[id];
+        }
+    }
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+      tray.item.menu = root;
+    });
+}
+
+void tray_free(void *item)
+{
+    TrayItem *tray = (__bridge_transfer TrayItem *)item;
+
+    dispatch_async(dispatch_get_main_queue(), ^{
+      [[NSStatusBar systemStatusBar] removeStatusItem:tray.item];
+    });
+}
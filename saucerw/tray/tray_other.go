@@ -0,0 +1,8 @@
+//go:build darwin && !(cgo && saucer)
+
+package tray
+
+// newBackend fails, status items need the AppKit event loop of saucer.
+func newBackend(*Tray, []byte) (backend, error) {
+	return nil, ErrUnsupported
+}
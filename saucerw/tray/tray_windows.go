@@ -0,0 +1,366 @@
+package tray
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32  = syscall.NewLazyDLL("user32.dll")
+	shell32 = syscall.NewLazyDLL("shell32.dll")
+
+	procRegisterClassEx        = user32.NewProc("RegisterClassExW")
+	procCreateWindowEx         = user32.NewProc("CreateWindowExW")
+	procDestroyWindow          = user32.NewProc("DestroyWindow")
+	procDefWindowProc          = user32.NewProc("DefWindowProcW")
+	procGetMessage             = user32.NewProc("GetMessageW")
+	procTranslateMessage       = user32.NewProc("TranslateMessage")
+	procDispatchMessage        = user32.NewProc("DispatchMessageW")
+	procPostMessage            = user32.NewProc("PostMessageW")
+	procPostQuitMessage        = user32.NewProc("PostQuitMessage")
+	procRegisterWindowMessage  = user32.NewProc("RegisterWindowMessageW")
+	procCreatePopupMenu        = user32.NewProc("CreatePopupMenu")
+	procAppendMenu             = user32.NewProc("AppendMenuW")
+	procDestroyMenu            = user32.NewProc("DestroyMenu")
+	procTrackPopupMenu         = user32.NewProc("TrackPopupMenu")
+	procSetForegroundWindow    = user32.NewProc("SetForegroundWindow")
+	procGetCursorPos           = user32.NewProc("GetCursorPos")
+	procCreateIconFromResource = user32.NewProc("CreateIconFromResourceEx")
+	procDestroyIcon            = user32.NewProc("DestroyIcon")
+	procShellNotifyIcon        = shell32.NewProc("Shell_NotifyIconW")
+)
+
+const (
+	wmNull        = 0x0000
+	wmClose       = 0x0010
+	wmDestroy     = 0x0002
+	wmLButtonUp   = 0x0202
+	wmRButtonUp   = 0x0205
+	wmApp         = 0x8000
+	wmTrayMessage = wmApp + 1
+
+	nimAdd    = 0
+	nimModify = 1
+	nimDelete = 2
+
+	nifMessage = 0x1
+	nifIcon    = 0x2
+	nifTip     = 0x4
+
+	mfString    = 0x0000
+	mfGrayed    = 0x0001
+	mfChecked   = 0x0008
+	mfPopup     = 0x0010
+	mfSeparator = 0x0800
+
+	tpmRightButton = 0x0002
+	tpmReturnCmd   = 0x0100
+	tpmNoNotify    = 0x0080
+
+	hwndMessage = ^uintptr(2) // HWND_MESSAGE, (HWND)-3
+)
+
+type wndClassEx struct {
+	size       uint32
+	style      uint32
+	wndProc    uintptr
+	clsExtra   int32
+	wndExtra   int32
+	instance   uintptr
+	icon       uintptr
+	cursor     uintptr
+	background uintptr
+	menuName   *uint16
+	className  *uint16
+	iconSm     uintptr
+}
+
+type point struct {
+	x, y int32
+}
+
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      point
+}
+
+type notifyIconData struct {
+	size            uint32
+	wnd             uintptr
+	id              uint32
+	flags           uint32
+	callbackMessage uint32
+	icon            uintptr
+	tip             [128]uint16
+	state           uint32
+	stateMask       uint32
+	info            [256]uint16
+	version         uint32
+	infoTitle       [64]uint16
+	infoFlags       uint32
+	guidItem        syscall.GUID
+	balloonIcon     uintptr
+}
+
+var (
+	registerClass sync.Once
+	className     = syscall.StringToUTF16Ptr("saucerwTray")
+	classErr      error
+
+	// taskbarCreated is broadcast when Explorer restarted and icons have to
+	// be added again.
+	taskbarCreated uintptr
+
+	windows sync.Map // hwnd → *notifyIcon
+)
+
+// notifyIcon is a notification area icon owned by a hidden message window
+// running its own message loop.
+type notifyIcon struct {
+	tray *Tray
+	hwnd uintptr
+
+	mu   sync.Mutex
+	data notifyIconData
+	root *node
+}
+
+func newBackend(t *Tray, icon []byte) (backend, error) {
+	handle, err := loadIcon(icon)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &notifyIcon{tray: t, root: &node{}}
+	n.data.size = uint32(unsafe.Sizeof(n.data))
+	n.data.flags = nifMessage | nifIcon | nifTip
+	n.data.callbackMessage = wmTrayMessage
+	n.data.icon = handle
+
+	ready := make(chan error, 1)
+	go n.run(ready)
+
+	if err := <-ready; err != nil {
+		procDestroyIcon.Call(handle)
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// loadIcon creates an icon from PNG data.
+func loadIcon(icon []byte) (uintptr, error) {
+	if len(icon) == 0 {
+		return 0, errors.New("tray: icon is empty")
+	}
+
+	handle, _, err := procCreateIconFromResource.Call(uintptr(unsafe.Pointer(&icon[0])), uintptr(len(icon)), 1, 0x00030000, 0, 0, 0)
+	if handle == 0 {
+		return 0, fmt.Errorf("tray: load icon: %w", err)
+	}
+	return handle, nil
+}
+
+// run creates the window and the icon and pumps messages until closed.
+func (n *notifyIcon) run(ready chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	registerClass.Do(func() {
+		name := syscall.StringToUTF16Ptr("TaskbarCreated")
+		taskbarCreated, _, _ = procRegisterWindowMessage.Call(uintptr(unsafe.Pointer(name)))
+
+		class := wndClassEx{wndProc: syscall.NewCallback(wndProc), className: className}
+		class.size = uint32(unsafe.Sizeof(class))
+
+		if r, _, err := procRegisterClassEx.Call(uintptr(unsafe.Pointer(&class))); r == 0 {
+			classErr = fmt.Errorf("tray: register window class: %w", err)
+		}
+	})
+
+	if classErr != nil {
+		ready <- classErr
+		return
+	}
+
+	hwnd, _, err := procCreateWindowEx.Call(0, uintptr(unsafe.Pointer(className)), 0, 0, 0, 0, 0, 0, hwndMessage, 0, 0, 0)
+	if hwnd == 0 {
+		ready <- fmt.Errorf("tray: create window: %w", err)
+		return
+	}
+
+	n.hwnd = hwnd
+	n.data.wnd = hwnd
+	windows.Store(hwnd, n)
+
+	if err := n.notify(nimAdd); err != nil {
+		windows.Delete(hwnd)
+		procDestroyWindow.Call(hwnd)
+		ready <- err
+		return
+	}
+
+	ready <- nil
+
+	var m msg
+	for {
+		r, _, _ := procGetMessage.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(r) <= 0 {
+			return
+		}
+
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessage.Call(uintptr(unsafe.Pointer(&m)))
+	}
+}
+
+// notify passes the icon data to the shell.
+func (n *notifyIcon) notify(message uintptr) error {
+	n.mu.Lock()
+	data := n.data
+	n.mu.Unlock()
+
+	if r, _, err := procShellNotifyIcon.Call(message, uintptr(unsafe.Pointer(&data))); r == 0 {
+		return fmt.Errorf("tray: Shell_NotifyIcon: %w", err)
+	}
+	return nil
+}
+
+// wndProc handles the messages of all tray windows.
+func wndProc(hwnd, message, wParam, lParam uintptr) uintptr {
+	value, ok := windows.Load(hwnd)
+	if !ok {
+		r, _, _ := procDefWindowProc.Call(hwnd, message, wParam, lParam)
+		return r
+	}
+
+	n := value.(*notifyIcon)
+
+	switch {
+	case message == wmTrayMessage && lParam == wmLButtonUp:
+		go n.tray.activate()
+		return 0
+	case message == wmTrayMessage && lParam == wmRButtonUp:
+		n.popup()
+		return 0
+	case message == taskbarCreated && taskbarCreated != 0:
+		_ = n.notify(nimAdd)
+		return 0
+	case message == wmClose:
+		_ = n.notify(nimDelete)
+		procDestroyWindow.Call(hwnd)
+		return 0
+	case message == wmDestroy:
+		windows.Delete(hwnd)
+
+		n.mu.Lock()
+		procDestroyIcon.Call(n.data.icon)
+		n.mu.Unlock()
+
+		procPostQuitMessage.Call(0)
+		return 0
+	}
+
+	r, _, _ := procDefWindowProc.Call(hwnd, message, wParam, lParam)
+	return r
+}
+
+// popup shows the menu at the cursor and handles the chosen item.
+func (n *notifyIcon) popup() {
+	n.mu.Lock()
+	root := n.root
+	n.mu.Unlock()
+
+	menu := buildMenu(root)
+	defer procDestroyMenu.Call(menu)
+
+	var pt point
+	procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+
+	// The menu only closes on outside clicks while the window is in the
+	// foreground.
+	procSetForegroundWindow.Call(n.hwnd)
+
+	id, _, _ := procTrackPopupMenu.Call(menu, tpmRightButton|tpmReturnCmd|tpmNoNotify, uintptr(pt.x), uintptr(pt.y), 0, n.hwnd, 0)
+	procPostMessage.Call(n.hwnd, wmNull, 0, 0)
+
+	if id != 0 {
+		go n.tray.click(int32(id))
+	}
+}
+
+// buildMenu creates the popup menu of the children of n.
+func buildMenu(n *node) uintptr {
+	menu, _, _ := procCreatePopupMenu.Call()
+
+	for _, child := range n.children {
+		if child.item.Separator {
+			procAppendMenu.Call(menu, mfSeparator, 0, 0)
+			continue
+		}
+
+		flags, id := uintptr(mfString), uintptr(child.id)
+		if child.item.Disabled {
+			flags |= mfGrayed
+		}
+		if child.item.Checkable && child.item.Checked {
+			flags |= mfChecked
+		}
+		if len(child.children) > 0 {
+			flags |= mfPopup
+			id = buildMenu(child)
+		}
+
+		label, _ := syscall.UTF16PtrFromString(child.item.Label)
+		procAppendMenu.Call(menu, flags, id, uintptr(unsafe.Pointer(label)))
+	}
+
+	return menu
+}
+
+func (n *notifyIcon) setIcon(icon []byte) error {
+	handle, err := loadIcon(icon)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	old := n.data.icon
+	n.data.icon = handle
+	n.mu.Unlock()
+
+	err = n.notify(nimModify)
+	procDestroyIcon.Call(old)
+
+	return err
+}
+
+func (n *notifyIcon) setTooltip(tooltip string) {
+	text, _ := syscall.UTF16FromString(tooltip)
+
+	n.mu.Lock()
+	n.data.tip = [128]uint16{}
+	copy(n.data.tip[:len(n.data.tip)-1], text)
+	n.mu.Unlock()
+
+	_ = n.notify(nimModify)
+}
+
+func (n *notifyIcon) setMenu(root *node) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.root = root
+}
+
+func (n *notifyIcon) close() {
+	procPostMessage.Call(n.hwnd, wmClose, 0, 0)
+}
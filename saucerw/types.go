@@ -0,0 +1,98 @@
+package saucerw
+
+import "math"
+
+// Size is a width and height in logical pixels.
+type Size struct {
+	W int
+	H int
+}
+
+// Position is a point in logical pixels.
+type Position struct {
+	X int
+	Y int
+}
+
+// Insets are the distances from the edges of a rectangle in logical pixels.
+type Insets struct {
+	Top    int
+	Right  int
+	Bottom int
+	Left   int
+}
+
+// ToPhysical returns the size in device pixels at scale, see Window.Scale.
+func (s Size) ToPhysical(scale float64) Size {
+	return Size{W: scaled(s.W, scale), H: scaled(s.H, scale)}
+}
+
+// ToLogical returns the size in device pixels s in logical pixels at scale.
+func (s Size) ToLogical(scale float64) Size {
+	return Size{W: scaled(s.W, 1/scale), H: scaled(s.H, 1/scale)}
+}
+
+// ToPhysical returns the point in device pixels at scale, see Window.Scale.
+func (p Position) ToPhysical(scale float64) Position {
+	return Position{X: scaled(p.X, scale), Y: scaled(p.Y, scale)}
+}
+
+// ToLogical returns the point in device pixels p in logical pixels at
+// scale.
+func (p Position) ToLogical(scale float64) Position {
+	return Position{X: scaled(p.X, 1/scale), Y: scaled(p.Y, 1/scale)}
+}
+
+// scaled returns v times factor, rounded to the nearest pixel.
+func scaled(v int, factor float64) int {
+	return int(math.Round(float64(v) * factor))
+}
+
+// Screen describes a monitor attached to the system.
+type Screen struct {
+	Name     string
+	Size     Size
+	Position Position
+	// Scale is the number of device pixels per logical pixel.
+	Scale float64
+}
+
+// DPI returns the logical resolution of the screen: 96 dots per inch at a
+// Scale of 1, the convention of Windows and X11.
+func (s Screen) DPI() float64 {
+	return 96 * s.Scale
+}
+
+// Contains reports whether pos lies on the screen.
+func (s Screen) Contains(pos Position) bool {
+	return pos.X >= s.Position.X && pos.X < s.Position.X+s.Size.W &&
+		pos.Y >= s.Position.Y && pos.Y < s.Position.Y+s.Size.H
+}
+
+// Edge is a set of window edges, see Window.StartResize.
+type Edge uint8
+
+const (
+	EdgeTop Edge = 1 << iota
+	EdgeBottom
+	EdgeLeft
+	EdgeRight
+)
+
+// Color is an RGBA color. An alpha of 0 is fully transparent.
+type Color struct {
+	R, G, B, A uint8
+}
+
+// Decoration is the kind of window decorations drawn by the system.
+type Decoration uint8
+
+const (
+	// DecorationNone draws no decorations.
+	DecorationNone Decoration = iota
+	// DecorationPartial keeps the resize borders and shadow but hides the
+	// title bar.
+	DecorationPartial
+	// DecorationFull draws the title bar and borders.
+	DecorationFull
+)
@@ -0,0 +1,207 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// deltaMagic starts every delta.
+const deltaMagic = "saucer-delta1\n"
+
+// Operations of a delta.
+const (
+	opCopy = iota
+	opInsert
+	opEnd
+)
+
+// deltaBlock is the size of the blocks of the old binary Diff looks up.
+const deltaBlock = 64
+
+// maxCandidates bounds the offsets remembered per block hash, so repetitive
+// input does not make Diff quadratic.
+const maxCandidates = 8
+
+// errBadDelta is returned by Patch for malformed deltas.
+var errBadDelta = errors.New("update: malformed delta")
+
+// Diff returns a delta turning old into new for Patch: the ranges new shares
+// with old are copied, the rest is inserted, and the result is compressed.
+// The publisher of a release creates one delta per earlier version worth
+// supporting and lists them in the Asset.
+func Diff(old, new []byte) []byte {
+	index := map[uint32][]int{}
+	for off := 0; off+deltaBlock <= len(old); off += deltaBlock {
+		h := weakHash(old[off : off+deltaBlock])
+		if len(index[h]) < maxCandidates {
+			index[h] = append(index[h], off)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(deltaMagic)
+
+	// flate.NewWriter only fails for invalid levels.
+	zw, _ := flate.NewWriter(&buf, flate.BestCompression)
+	w := bufio.NewWriter(zw)
+
+	uvarint := func(v int) {
+		w.Write(binary.AppendUvarint(nil, uint64(v)))
+	}
+
+	literal := 0
+	flush := func(end int) {
+		if end > literal {
+			uvarint(opInsert)
+			uvarint(end - literal)
+			w.Write(new[literal:end])
+		}
+	}
+
+	var r rolling
+	pos := 0
+	if len(new) >= deltaBlock {
+		r.init(new[:deltaBlock])
+	}
+
+	for pos+deltaBlock <= len(new) {
+		from, n := -1, 0
+		for _, off := range index[r.sum()] {
+			if !bytes.Equal(old[off:off+deltaBlock], new[pos:pos+deltaBlock]) {
+				continue
+			}
+
+			m := deltaBlock
+			for off+m < len(old) && pos+m < len(new) && old[off+m] == new[pos+m] {
+				m++
+			}
+			if m > n {
+				from, n = off, m
+			}
+		}
+
+		if from < 0 {
+			if pos+deltaBlock < len(new) {
+				r.roll(new[pos], new[pos+deltaBlock])
+			}
+			pos++
+			continue
+		}
+
+		// Extend the match backwards into the pending literal.
+		for from > 0 && pos > literal && old[from-1] == new[pos-1] {
+			from, pos, n = from-1, pos-1, n+1
+		}
+
+		flush(pos)
+		uvarint(opCopy)
+		uvarint(from)
+		uvarint(n)
+
+		pos += n
+		literal = pos
+		if pos+deltaBlock <= len(new) {
+			r.init(new[pos : pos+deltaBlock])
+		}
+	}
+
+	flush(len(new))
+	uvarint(opEnd)
+
+	w.Flush()
+	zw.Close()
+	return buf.Bytes()
+}
+
+// Patch applies delta, created by Diff, to old and returns the new binary.
+func Patch(old, delta []byte) ([]byte, error) {
+	rest, ok := bytes.CutPrefix(delta, []byte(deltaMagic))
+	if !ok {
+		return nil, errBadDelta
+	}
+
+	r := bufio.NewReader(flate.NewReader(bytes.NewReader(rest)))
+
+	uvarint := func() (int, error) {
+		v, err := binary.ReadUvarint(r)
+		if err != nil || v > 1<<40 {
+			return 0, errBadDelta
+		}
+		return int(v), nil
+	}
+
+	var out []byte
+	for {
+		op, err := uvarint()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case opCopy:
+			from, err := uvarint()
+			if err != nil {
+				return nil, err
+			}
+			n, err := uvarint()
+			if err != nil {
+				return nil, err
+			}
+			if from+n > len(old) {
+				return nil, fmt.Errorf("%w: copy beyond the old binary", errBadDelta)
+			}
+			out = append(out, old[from:from+n]...)
+		case opInsert:
+			n, err := uvarint()
+			if err != nil {
+				return nil, err
+			}
+
+			start := len(out)
+			out = append(out, make([]byte, n)...)
+			if _, err := io.ReadFull(r, out[start:]); err != nil {
+				return nil, errBadDelta
+			}
+		case opEnd:
+			return out, nil
+		default:
+			return nil, errBadDelta
+		}
+	}
+}
+
+// rolling is the rolling checksum of rsync over a window of deltaBlock
+// bytes.
+type rolling struct {
+	a, b uint32
+}
+
+func (r *rolling) init(block []byte) {
+	r.a, r.b = 0, 0
+	for i, c := range block {
+		r.a += uint32(c)
+		r.b += uint32(len(block)-i) * uint32(c)
+	}
+}
+
+// roll moves the window by one byte, dropping out and adding in.
+func (r *rolling) roll(out, in byte) {
+	r.a += uint32(in) - uint32(out)
+	r.b += r.a - deltaBlock*uint32(out)
+}
+
+func (r *rolling) sum() uint32 {
+	return r.a&0xffff | r.b<<16
+}
+
+// weakHash returns the rolling checksum of block.
+func weakHash(block []byte) uint32 {
+	var r rolling
+	r.init(block)
+	return r.sum()
+}
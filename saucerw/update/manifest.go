@@ -0,0 +1,162 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrBadSignature is returned when the signature of a release manifest does
+// not verify with the public key.
+var ErrBadSignature = errors.New("update: manifest signature does not verify")
+
+// Manifest describes the latest release. It is published as JSON next to
+// an Ed25519 signature of its bytes, see SignManifest.
+type Manifest struct {
+	// Version is the version of the release, e.g. "1.2.3".
+	Version string `json:"version"`
+	// Notes are the release notes shown to the user.
+	Notes string `json:"notes,omitempty"`
+	// Published is the release time.
+	Published time.Time `json:"published,omitzero"`
+	// Assets are the binaries of the release by platform.
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is the binary of a release for a platform.
+type Asset struct {
+	GOOS   string `json:"goos"`
+	GOARCH string `json:"goarch"`
+	// URL is the location of the binary, relative to the manifest or
+	// absolute.
+	URL string `json:"url"`
+	// Size is the size of the binary in bytes and SHA256 its hex encoded
+	// hash.
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Deltas are patches from earlier versions to the binary, see Diff.
+	Deltas []Delta `json:"deltas,omitempty"`
+}
+
+// Delta is a patch from the binary of an earlier version.
+type Delta struct {
+	// From is the version the patch applies to.
+	From string `json:"from"`
+	// URL is the location of the patch, relative to the manifest or absolute.
+	URL string `json:"url"`
+	// Size is the size of the patch in bytes and SHA256 its hex encoded hash.
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// asset returns the asset of goos and goarch.
+func (m *Manifest) asset(goos, goarch string) (*Asset, bool) {
+	for i := range m.Assets {
+		if m.Assets[i].GOOS == goos && m.Assets[i].GOARCH == goarch {
+			return &m.Assets[i], true
+		}
+	}
+	return nil, false
+}
+
+// SignManifest encodes m and signs the encoding with key. Publish both, the
+// signature at the manifest URL with ".sig" appended by default.
+func SignManifest(m *Manifest, key ed25519.PrivateKey) (data, sig []byte, err error) {
+	if m.Version == "" {
+		return nil, nil, errors.New("update: manifest without version")
+	}
+
+	data, err = json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	data = append(data, '\n')
+	return data, ed25519.Sign(key, data), nil
+}
+
+// ParseManifest verifies sig of data with key and decodes the manifest.
+func ParseManifest(data, sig []byte, key ed25519.PublicKey) (*Manifest, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("update: invalid public key")
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return nil, ErrBadSignature
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("update: decode manifest: %w", err)
+	}
+	if m.Version == "" {
+		return nil, errors.New("update: manifest without version")
+	}
+	return &m, nil
+}
+
+// CompareVersions compares the semantic versions a and b, with or without
+// the "v" prefix. It returns -1 if a is older than b, 1 if it is newer and 0
+// if they are equal. Pre-releases are older than their release, build
+// metadata is ignored.
+func CompareVersions(a, b string) int {
+	a, _, _ = strings.Cut(strings.TrimPrefix(a, "v"), "+")
+	b, _, _ = strings.Cut(strings.TrimPrefix(b, "v"), "+")
+
+	a, preA, _ := strings.Cut(a, "-")
+	b, preB, _ := strings.Cut(b, "-")
+
+	if c := compareIdentifiers(a, b); c != 0 {
+		return c
+	}
+
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareIdentifiers(preA, preB)
+}
+
+// compareIdentifiers compares dot separated identifiers, numerically where
+// both are numbers. Missing identifiers sort first.
+func compareIdentifiers(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := range max(len(as), len(bs)) {
+		if i >= len(as) {
+			return -1
+		}
+		if i >= len(bs) {
+			return 1
+		}
+
+		na, errA := strconv.ParseUint(as[i], 10, 64)
+		nb, errB := strconv.ParseUint(bs[i], 10, 64)
+
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			// Numeric identifiers have lower precedence.
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
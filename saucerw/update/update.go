@@ -0,0 +1,507 @@
+// Package update keeps an application up to date: it checks a signed release
+// manifest, downloads the binary of the new version, as a delta of the
+// running one where the release offers it, verifies it and stages it next to
+// the executable. The staged binary replaces the executable on the next
+// start, or right away with Restart.
+//
+//	u, err := update.New(update.Options{
+//		ManifestURL: "https://example.com/releases/latest.json",
+//		PublicKey:   publicKey,
+//		Version:     version,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	if _, err := u.Apply(); err != nil {
+//		log.Print(err)
+//	}
+//
+//	app.Run(start)
+//	return u.Relaunch()
+//
+// Apply at the start of main replaces the executable with an update staged in
+// an earlier run; Relaunch after the event loop starts the new version if the
+// user chose to restart. Expose lets the page drive the update and follow its
+// progress.
+//
+// Updating needs write access to the directory of the executable, which is
+// not the case for applications installed system wide or from stores, where
+// the package manager updates them.
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUpToDate is returned by Check if the running version is the latest.
+	ErrUpToDate = errors.New("update: already up to date")
+	// ErrNoAsset is returned by Check if the release has no binary for the
+	// platform.
+	ErrNoAsset = errors.New("update: no binary for this platform")
+)
+
+// Options configures an Updater.
+type Options struct {
+	// ManifestURL is the location of the release manifest. Required.
+	ManifestURL string
+	// SignatureURL is the location of its signature, ManifestURL with ".sig"
+	// appended by default.
+	SignatureURL string
+	// PublicKey verifies the signature of the manifest. Required.
+	PublicKey ed25519.PublicKey
+	// Version is the running version. Required.
+	Version string
+	// Executable is the binary to update, the running executable by default.
+	Executable string
+	// Client downloads the manifest and the binaries, http.DefaultClient by
+	// default.
+	Client *http.Client
+	// Progress receives the progress of downloads, see also OnProgress.
+	Progress func(Progress)
+}
+
+// Phases of a Progress.
+const (
+	PhaseDownload = "download"
+	PhasePatch    = "patch"
+	PhaseStaged   = "staged"
+)
+
+// Progress reports the progress of Download.
+type Progress struct {
+	// Phase is one of the Phase constants.
+	Phase string `json:"phase"`
+	// Version is the version being downloaded.
+	Version string `json:"version"`
+	// Done and Total are the bytes downloaded and to download, Total is 0
+	// if unknown.
+	Done  int64 `json:"done"`
+	Total int64 `json:"total"`
+	// Delta reports whether a delta is downloaded.
+	Delta bool `json:"delta,omitempty"`
+}
+
+// Release is a newer version found by Check.
+type Release struct {
+	Version   string    `json:"version"`
+	Notes     string    `json:"notes,omitempty"`
+	Published time.Time `json:"published,omitzero"`
+	// Size is the size of the download, the delta if one applies.
+	Size int64 `json:"size"`
+
+	asset *Asset
+	base  *url.URL
+}
+
+// progressInterval limits the rate of progress reports during downloads.
+const progressInterval = 100 * time.Millisecond
+
+// Updater checks for and installs updates of an executable.
+type Updater struct {
+	opts Options
+
+	mu        sync.Mutex
+	latest    *Release
+	listeners map[int]func(Progress)
+	next      int
+	restart   bool
+}
+
+// New returns an Updater of opts.
+func New(opts Options) (*Updater, error) {
+	if opts.ManifestURL == "" {
+		return nil, errors.New("update: ManifestURL is required")
+	}
+	if len(opts.PublicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("update: invalid public key")
+	}
+	if opts.Version == "" {
+		return nil, errors.New("update: Version is required")
+	}
+
+	if opts.SignatureURL == "" {
+		opts.SignatureURL = opts.ManifestURL + ".sig"
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	if opts.Executable == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("update: %w", err)
+		}
+		opts.Executable = exe
+	}
+
+	exe, err := filepath.EvalSymlinks(opts.Executable)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+	opts.Executable = exe
+
+	u := &Updater{opts: opts, listeners: map[int]func(Progress){}}
+	if opts.Progress != nil {
+		u.OnProgress(opts.Progress)
+	}
+	return u, nil
+}
+
+// OnProgress adds a receiver of the progress of downloads. It returns a
+// function removing it.
+func (u *Updater) OnProgress(fn func(Progress)) (remove func()) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	id := u.next
+	u.next++
+	u.listeners[id] = fn
+
+	return func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		delete(u.listeners, id)
+	}
+}
+
+// report sends p to the receivers.
+func (u *Updater) report(p Progress) {
+	u.mu.Lock()
+	fns := make([]func(Progress), 0, len(u.listeners))
+	for _, fn := range u.listeners {
+		fns = append(fns, fn)
+	}
+	u.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(p)
+	}
+}
+
+// Check fetches and verifies the manifest and returns the release if it is
+// newer than the running version, ErrUpToDate otherwise.
+func (u *Updater) Check(ctx context.Context) (*Release, error) {
+	base, err := url.Parse(u.opts.ManifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	data, err := u.fetch(ctx, u.opts.ManifestURL)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := u.fetch(ctx, u.opts.SignatureURL)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := ParseManifest(data, sig, u.opts.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if CompareVersions(m.Version, u.opts.Version) <= 0 {
+		return nil, ErrUpToDate
+	}
+
+	asset, ok := m.asset(runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return nil, ErrNoAsset
+	}
+
+	rel := &Release{Version: m.Version, Notes: m.Notes, Published: m.Published, Size: asset.Size, asset: asset, base: base}
+	if delta, ok := u.delta(asset); ok {
+		rel.Size = delta.Size
+	}
+
+	u.mu.Lock()
+	u.latest = rel
+	u.mu.Unlock()
+
+	return rel, nil
+}
+
+// delta returns the delta of asset from the running version.
+func (u *Updater) delta(asset *Asset) (*Delta, bool) {
+	for i := range asset.Deltas {
+		if CompareVersions(asset.Deltas[i].From, u.opts.Version) == 0 {
+			return &asset.Deltas[i], true
+		}
+	}
+	return nil, false
+}
+
+// fetch returns the body of rawURL, which must be small.
+func (u *Updater) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	resp, err := u.get(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("update: %s: %w", rawURL, err)
+	}
+	return data, nil
+}
+
+// get requests rawURL, failing for other statuses than 200.
+func (u *Updater) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	resp, err := u.opts.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("update: %s: %s", rawURL, resp.Status)
+	}
+	return resp, nil
+}
+
+// staged returns the path the downloaded binary is staged at.
+func (u *Updater) staged() string {
+	return u.opts.Executable + ".new"
+}
+
+// Staged reports whether an update is staged, to be applied with Apply.
+func (u *Updater) Staged() bool {
+	_, err := os.Stat(u.staged())
+	return err == nil
+}
+
+// Download downloads the binary of rel, the release returned by Check, and
+// stages it. It uses the delta from the running version if the release has
+// one, falling back to the full binary if the delta fails to apply. The
+// binary is only staged if its hash matches the signed manifest.
+func (u *Updater) Download(ctx context.Context, rel *Release) error {
+	if rel == nil || rel.asset == nil {
+		return errors.New("update: no release, see Check")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if delta, ok := u.delta(rel.asset); ok {
+		data, err = u.patch(ctx, rel, delta)
+	}
+	if data == nil || err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if data, err = u.download(ctx, rel, rel.asset.URL, rel.asset.Size, rel.asset.SHA256, false); err != nil {
+			return err
+		}
+	}
+
+	if err := u.stage(data); err != nil {
+		return err
+	}
+
+	u.report(Progress{Phase: PhaseStaged, Version: rel.Version, Done: int64(len(data)), Total: rel.asset.Size})
+	return nil
+}
+
+// patch downloads delta and applies it to the executable.
+func (u *Updater) patch(ctx context.Context, rel *Release, delta *Delta) ([]byte, error) {
+	patch, err := u.download(ctx, rel, delta.URL, delta.Size, delta.SHA256, true)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := os.ReadFile(u.opts.Executable)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	u.report(Progress{Phase: PhasePatch, Version: rel.Version, Done: delta.Size, Total: delta.Size, Delta: true})
+	data, err := Patch(old, patch)
+	if err != nil {
+		return nil, err
+	}
+	return data, verify(data, rel.asset.Size, rel.asset.SHA256)
+}
+
+// download downloads rawURL, relative to the manifest, reporting progress,
+// and checks its size and hash.
+func (u *Updater) download(ctx context.Context, rel *Release, rawURL string, size int64, sum string, delta bool) ([]byte, error) {
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	resp, err := u.get(ctx, rel.base.ResolveReference(ref).String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	p := Progress{Phase: PhaseDownload, Version: rel.Version, Total: size, Delta: delta}
+	u.report(p)
+
+	var last time.Time
+	w := &progressWriter{fn: func(n int64) {
+		p.Done = n
+		if now := time.Now(); now.Sub(last) >= progressInterval || n == size {
+			last = now
+			u.report(p)
+		}
+	}}
+
+	// Read one byte more than expected to detect oversized downloads.
+	data, err := io.ReadAll(io.TeeReader(io.LimitReader(resp.Body, size+1), w))
+	if err != nil {
+		return nil, fmt.Errorf("update: download %s: %w", rawURL, err)
+	}
+	if err := verify(data, size, sum); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, rawURL)
+	}
+	return data, nil
+}
+
+// verify checks the size and SHA-256 of data.
+func verify(data []byte, size int64, sum string) error {
+	if int64(len(data)) != size {
+		return fmt.Errorf("update: size %d does not match %d", len(data), size)
+	}
+
+	hash := sha256.Sum256(data)
+	if got := hex.EncodeToString(hash[:]); got != sum {
+		return fmt.Errorf("update: sha256 %s does not match %s", got, sum)
+	}
+	return nil
+}
+
+// stage writes data next to the executable, with its permissions.
+func (u *Updater) stage(data []byte) error {
+	info, err := os.Stat(u.opts.Executable)
+	if err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(u.opts.Executable), filepath.Base(u.opts.Executable)+".download-*")
+	if err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("update: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+	if err := os.Chmod(f.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), u.staged()); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+	return nil
+}
+
+// rename renames the binaries in Apply, the tests make it fail.
+var rename = os.Rename
+
+// Apply replaces the executable with the staged update, if any, and reports
+// whether it did. The running process keeps executing the old binary. Call it
+// at the start of main to apply an update staged by an earlier run; it also
+// removes the binary replaced by an earlier update.
+func (u *Updater) Apply() (bool, error) {
+	exe, staged, old := u.opts.Executable, u.staged(), u.opts.Executable+".old"
+
+	// Windows does not remove a running executable, the old binary of the
+	// last update is removed by the next start instead.
+	_ = os.Remove(old)
+
+	if _, err := os.Stat(staged); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	// Renaming works for running executables on every platform, replacing
+	// them in place does not on Windows.
+	if err := rename(exe, old); err != nil {
+		return false, fmt.Errorf("update: %w", err)
+	}
+	if err := rename(staged, exe); err != nil {
+		if rerr := rename(old, exe); rerr != nil {
+			return false, fmt.Errorf("update: %w, restoring the executable: %w", err, rerr)
+		}
+		return false, fmt.Errorf("update: %w", err)
+	}
+
+	_ = os.Remove(old)
+	return true, nil
+}
+
+// RequestRestart applies the staged update and marks the restart for
+// Relaunch, the application still has to quit.
+func (u *Updater) RequestRestart() error {
+	if _, err := u.Apply(); err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	u.restart = true
+	u.mu.Unlock()
+
+	return nil
+}
+
+// Relaunch starts the executable again with the arguments of the process if
+// a restart was requested, see RequestRestart and Expose. Call it once the
+// event loop has quit and single-instance locks are released, e.g. with
+// Instance.Close, so the new process does not forward its launch to this one.
+func (u *Updater) Relaunch() error {
+	u.mu.Lock()
+	restart := u.restart
+	u.mu.Unlock()
+
+	if !restart {
+		return nil
+	}
+
+	cmd := exec.Command(u.opts.Executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("update: relaunch: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// progressWriter counts the bytes written to it.
+type progressWriter struct {
+	n  int64
+	fn func(n int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	w.fn(w.n)
+	return len(p), nil
+}
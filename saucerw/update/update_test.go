@@ -0,0 +1,442 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// testKey returns a fixed key pair.
+func testKey() (ed25519.PublicKey, ed25519.PrivateKey) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	return key.Public().(ed25519.PublicKey), key
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseManifest(t *testing.T) {
+	public, private := testKey()
+	other, _, _ := ed25519.GenerateKey(nil)
+
+	data, sig, err := SignManifest(&Manifest{Version: "1.2.3"}, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unversioned := []byte(`{"assets":[]}`)
+	invalid := []byte(`{"version":`)
+
+	tests := []struct {
+		name      string
+		data, sig []byte
+		key       ed25519.PublicKey
+		err       error
+	}{
+		{name: "valid", data: data, sig: sig, key: public},
+		{name: "tampered", data: bytes.Replace(data, []byte("1.2.3"), []byte("9.9.9"), 1), sig: sig, key: public, err: ErrBadSignature},
+		{name: "other key", data: data, sig: sig, key: other, err: ErrBadSignature},
+		{name: "truncated signature", data: data, sig: sig[:10], key: public, err: ErrBadSignature},
+		{name: "invalid key", data: data, sig: sig, key: public[:10]},
+		{name: "without version", data: unversioned, sig: ed25519.Sign(private, unversioned), key: public},
+		{name: "invalid json", data: invalid, sig: ed25519.Sign(private, invalid), key: public},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := ParseManifest(test.data, test.sig, test.key)
+			switch {
+			case test.name == "valid":
+				if err != nil || m.Version != "1.2.3" {
+					t.Errorf("parsed %+v, %v", m, err)
+				}
+			case test.err != nil && !errors.Is(err, test.err):
+				t.Errorf("error %v, expected %v", err, test.err)
+			case err == nil:
+				t.Errorf("parsed %+v", m)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3+build.1", "1.2.3+build.2", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2", "1.2.0", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+	}
+	for _, test := range tests {
+		if got := CompareVersions(test.a, test.b); got != test.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, expected %d", test.a, test.b, got, test.want)
+		}
+		if got := CompareVersions(test.b, test.a); got != -test.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, expected %d", test.b, test.a, got, -test.want)
+		}
+	}
+}
+
+func TestDiffPatch(t *testing.T) {
+	binary := make([]byte, 64<<10)
+	for i := range binary {
+		binary[i] = byte(i * 7 % 251)
+	}
+	changed := bytes.Clone(binary)
+	copy(changed[1000:], "changed in the middle")
+
+	tests := []struct {
+		name     string
+		old, new []byte
+	}{
+		{"empty", nil, nil},
+		{"from nothing", nil, []byte("new binary")},
+		{"to nothing", binary, nil},
+		{"same", binary, binary},
+		{"changed", binary, changed},
+		{"inserted", binary, append(append(bytes.Clone(binary[:5000]), "inserted"...), binary[5000:]...)},
+		{"moved", binary, append(bytes.Clone(binary[32<<10:]), binary[:32<<10]...)},
+		{"short", []byte("old"), []byte("new")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delta := Diff(test.old, test.new)
+			got, err := Patch(test.old, delta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, test.new) {
+				t.Fatalf("patched %d bytes, expected %d", len(got), len(test.new))
+			}
+			if len(test.old) > 0 && bytes.Equal(test.old, test.new) && len(delta) > 100 {
+				t.Errorf("delta of an unchanged binary is %d bytes", len(delta))
+			}
+		})
+	}
+}
+
+func TestPatchMalformed(t *testing.T) {
+	old := bytes.Repeat([]byte("old binary "), 100)
+	delta := Diff(old, append(bytes.Clone(old), "more"...))
+
+	tests := map[string][]byte{
+		"no magic":  delta[len(deltaMagic):],
+		"truncated": delta[:len(deltaMagic)+4],
+		"not flate": append([]byte(deltaMagic), "garbage"...),
+	}
+	for name, delta := range tests {
+		if _, err := Patch(old, delta); !errors.Is(err, errBadDelta) {
+			t.Errorf("%s: error %v, expected %v", name, err, errBadDelta)
+		}
+	}
+
+	// A delta of a longer binary copies beyond a shorter one
+	if _, err := Patch(old[:10], delta); !errors.Is(err, errBadDelta) {
+		t.Errorf("other binary: error %v, expected %v", err, errBadDelta)
+	}
+}
+
+// release serves a signed manifest of version 2.0.0 for the platform with
+// the binary next and a delta from 1.0.0, and returns an Updater of version
+// 1.0.0 of the executable old.
+func release(t *testing.T, old, next []byte, edit func(m *Manifest, files map[string][]byte)) *Updater {
+	public, private := testKey()
+
+	delta := Diff(old, next)
+	files := map[string][]byte{"/app-2.0.0": next, "/app-1.0.0-2.0.0.delta": delta}
+	m := &Manifest{Version: "2.0.0", Assets: []Asset{{
+		GOOS: runtime.GOOS, GOARCH: runtime.GOARCH,
+		URL: "app-2.0.0", Size: int64(len(next)), SHA256: hash(next),
+		Deltas: []Delta{{From: "1.0.0", URL: "app-1.0.0-2.0.0.delta", Size: int64(len(delta)), SHA256: hash(delta)}},
+	}}}
+	if edit != nil {
+		edit(m, files)
+	}
+
+	data, sig, err := SignManifest(m, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["/latest.json"], files["/latest.json.sig"] = data, sig
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	exe := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(exe, old, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := New(Options{ManifestURL: srv.URL + "/latest.json", PublicKey: public, Version: "1.0.0", Executable: exe})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestDownload(t *testing.T) {
+	old := bytes.Repeat([]byte("version 1.0.0 "), 1000)
+	next := bytes.Replace(old, []byte("1.0.0"), []byte("2.0.0"), 3)
+
+	tests := []struct {
+		name string
+		edit func(m *Manifest, files map[string][]byte)
+		// delta reports whether the delta is expected to be used instead of
+		// the full binary.
+		delta bool
+		err   string
+	}{
+		{name: "delta", delta: true},
+		{
+			name: "full",
+			edit: func(m *Manifest, _ map[string][]byte) { m.Assets[0].Deltas = nil },
+		},
+		{
+			name: "delta of other version",
+			edit: func(m *Manifest, _ map[string][]byte) { m.Assets[0].Deltas[0].From = "0.9.0" },
+		},
+		{
+			name: "corrupted delta",
+			edit: func(m *Manifest, files map[string][]byte) {
+				delta := bytes.Clone(files["/app-1.0.0-2.0.0.delta"])
+				delta[len(deltaMagic)+2] ^= 0xff
+				files["/app-1.0.0-2.0.0.delta"] = delta
+				m.Assets[0].Deltas[0].SHA256 = hash(delta)
+			},
+		},
+		{
+			name: "delta mismatch",
+			edit: func(_ *Manifest, files map[string][]byte) {
+				files["/app-1.0.0-2.0.0.delta"] = Diff(old, append(bytes.Clone(next), "other"...))
+			},
+		},
+		{
+			name: "size mismatch",
+			edit: func(m *Manifest, _ map[string][]byte) { m.Assets[0].Deltas, m.Assets[0].Size = nil, m.Assets[0].Size-1 },
+			err:  "size",
+		},
+		{
+			name: "oversized binary",
+			edit: func(m *Manifest, files map[string][]byte) {
+				m.Assets[0].Deltas = nil
+				files["/app-2.0.0"] = append(bytes.Clone(next), "appended"...)
+			},
+			err: "size",
+		},
+		{
+			name: "sha256 mismatch",
+			edit: func(m *Manifest, _ map[string][]byte) { m.Assets[0].Deltas, m.Assets[0].SHA256 = nil, hash(old) },
+			err:  "sha256",
+		},
+		{
+			name: "patched sha256 mismatch",
+			edit: func(m *Manifest, _ map[string][]byte) { m.Assets[0].SHA256 = hash(old) },
+			err:  "sha256",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := release(t, old, next, test.edit)
+
+			var full bool
+			u.OnProgress(func(p Progress) { full = full || p.Phase == PhaseDownload && !p.Delta })
+
+			rel, err := u.Check(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = u.Download(context.Background(), rel)
+
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error %v, expected a %s mismatch", err, test.err)
+				}
+				if u.Staged() {
+					t.Error("staged a binary that does not match")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			staged, err := os.ReadFile(u.staged())
+			if err != nil || !bytes.Equal(staged, next) {
+				t.Fatalf("staged %d bytes, %v", len(staged), err)
+			}
+			if full == test.delta {
+				t.Errorf("downloaded the full binary %v, expected %v", full, !test.delta)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(m *Manifest, files map[string][]byte)
+		err  error
+	}{
+		{
+			name: "up to date",
+			edit: func(m *Manifest, _ map[string][]byte) { m.Version = "1.0.0" },
+			err:  ErrUpToDate,
+		},
+		{
+			name: "older",
+			edit: func(m *Manifest, _ map[string][]byte) { m.Version = "0.9.0" },
+			err:  ErrUpToDate,
+		},
+		{
+			name: "other platform",
+			edit: func(m *Manifest, _ map[string][]byte) { m.Assets[0].GOOS = "plan9" + m.Assets[0].GOOS },
+			err:  ErrNoAsset,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := release(t, []byte("old"), []byte("new"), test.edit)
+			if _, err := u.Check(context.Background()); !errors.Is(err, test.err) {
+				t.Errorf("error %v, expected %v", err, test.err)
+			}
+		})
+	}
+
+	// A manifest signed by another key is rejected
+	u := release(t, []byte("old"), []byte("new"), nil)
+	u.opts.PublicKey, _, _ = ed25519.GenerateKey(nil)
+	if _, err := u.Check(context.Background()); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("error %v, expected %v", err, ErrBadSignature)
+	}
+}
+
+func TestApply(t *testing.T) {
+	newUpdater := func(t *testing.T) *Updater {
+		exe := filepath.Join(t.TempDir(), "app")
+		if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		public, _ := testKey()
+		u, err := New(Options{ManifestURL: "https://example.com/latest.json", PublicKey: public, Version: "1.0.0", Executable: exe})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	content := func(path string) string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err.Error()
+		}
+		return string(data)
+	}
+	exists := func(path string) bool {
+		_, err := os.Lstat(path)
+		return err == nil
+	}
+
+	t.Run("nothing staged", func(t *testing.T) {
+		u := newUpdater(t)
+		if applied, err := u.Apply(); applied || err != nil {
+			t.Fatalf("applied %v, %v", applied, err)
+		}
+		if got := content(u.opts.Executable); got != "old" {
+			t.Errorf("executable %q", got)
+		}
+	})
+
+	t.Run("staged", func(t *testing.T) {
+		u := newUpdater(t)
+		if err := u.stage([]byte("new")); err != nil {
+			t.Fatal(err)
+		}
+		// The binary replaced by an earlier update is removed
+		if err := os.WriteFile(u.opts.Executable+".old", []byte("older"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if applied, err := u.Apply(); !applied || err != nil {
+			t.Fatalf("applied %v, %v", applied, err)
+		}
+		if got := content(u.opts.Executable); got != "new" {
+			t.Errorf("executable %q", got)
+		}
+		if info, err := os.Stat(u.opts.Executable); err != nil || info.Mode().Perm() != 0o755 {
+			t.Errorf("executable %v, %v", info, err)
+		}
+		if exists(u.staged()) || exists(u.opts.Executable+".old") {
+			t.Error("left the staged or the old binary behind")
+		}
+		if u.Staged() {
+			t.Error("still staged")
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		u := newUpdater(t)
+		if err := u.stage([]byte("new")); err != nil {
+			t.Fatal(err)
+		}
+
+		// Moving the staged binary in place fails after the executable was
+		// moved aside
+		failure := errors.New("rename failed")
+		rename = func(from, to string) error {
+			if from == u.staged() {
+				return failure
+			}
+			return os.Rename(from, to)
+		}
+		defer func() { rename = os.Rename }()
+
+		if applied, err := u.Apply(); applied || !errors.Is(err, failure) {
+			t.Fatalf("applied %v, %v", applied, err)
+		}
+		if got := content(u.opts.Executable); got != "old" {
+			t.Errorf("executable %q after rolling back", got)
+		}
+		if exists(u.opts.Executable + ".old") {
+			t.Error("left the old binary behind")
+		}
+		if !u.Staged() {
+			t.Error("dropped the staged binary")
+		}
+
+		// The next start applies it
+		rename = os.Rename
+		if applied, err := u.Apply(); !applied || err != nil {
+			t.Fatalf("applied %v, %v", applied, err)
+		}
+		if got := content(u.opts.Executable); got != "new" {
+			t.Errorf("executable %q", got)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// progressEvent is the event the page receives the progress of a download
+// started with update.download as.
+const progressEvent = "saucer:update"
+
+// Expose makes u available to the page of v:
+//
+//   - window.saucer.exposed["update.check"]() resolves to the newer release
+//     or null if the application is up to date.
+//   - window.saucer.exposed["update.download"]() downloads and stages the
+//     release found by the last check. Its progress is dispatched on window
+//     as "saucer:update" events with the Progress as detail.
+//   - window.saucer.exposed["update.restart"]() applies the staged update and
+//     quits the application, to be started again by Relaunch.
+func Expose(v *saucerw.Webview, u *Updater) error {
+	err := v.Expose("update.check", func(ctx context.Context) (*Release, error) {
+		rel, err := u.Check(ctx)
+		if errors.Is(err, ErrUpToDate) {
+			return nil, nil
+		}
+		return rel, err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = v.Expose("update.download", func(ctx context.Context) error {
+		u.mu.Lock()
+		rel := u.latest
+		u.mu.Unlock()
+
+		// Only report while the call runs, it is canceled when v is released.
+		remove := u.OnProgress(func(p Progress) {
+			detail, err := json.Marshal(p)
+			if err == nil {
+				v.Execute(fmt.Sprintf("window.dispatchEvent(new CustomEvent(%q, { detail: %s }));", progressEvent, detail))
+			}
+		})
+		defer remove()
+
+		return u.Download(ctx, rel)
+	})
+	if err != nil {
+		return err
+	}
+
+	return v.Expose("update.restart", func() error {
+		if err := u.RequestRestart(); err != nil {
+			return err
+		}
+
+		v.Parent().Parent().Quit()
+		return nil
+	})
+}
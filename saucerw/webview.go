@@ -0,0 +1,325 @@
+package saucerw
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// WebviewOptions configures a new Webview.
+type WebviewOptions struct {
+	// Window is the window the webview is placed in. Required.
+	Window *Window
+	// DisableAttributes turns off the handling of data-webview-* attributes
+	// (drag, resize, minimize, maximize and close regions).
+	DisableAttributes bool
+	// Preferences configures the browser engine.
+	Preferences Preferences
+	// Network configures proxies, certificate handling and the hosts pages
+	// may reach.
+	Network NetworkOptions
+	// Batching tunes how bridge messages are coalesced.
+	Batching BatchOptions
+	// Compression compresses the large messages of the bridge.
+	Compression CompressionOptions
+	// Security restricts the origins calling exposed functions, the content
+	// the pages load and the Content-Security-Policy of custom schemes.
+	Security SecurityPolicy
+	// Mirror selects what the webview mirrors from its page onto the window,
+	// see OnPageChrome.
+	Mirror MirrorOptions
+	// TracerProvider, if non-nil, provides the Tracer recording spans around
+	// bridge calls, evaluations, page loads and custom scheme requests.
+	TracerProvider TracerProvider
+}
+
+// Preferences configures the browser engine of a webview. They are fixed once
+// the webview is created. The zero value matches the saucer defaults with the
+// developer tools disabled.
+type Preferences struct {
+	// DevTools allows opening the developer tools, see Webview.SetDevTools.
+	// The DevToolsEnv environment variable overrides it.
+	DevTools bool
+	// DisableHardwareAcceleration renders the page in software.
+	DisableHardwareAcceleration bool
+	// DisablePersistentCookies keeps cookies for the session only.
+	DisablePersistentCookies bool
+	// Ephemeral keeps all website data in memory instead of StoragePath,
+	// e.g. for a private window or a test that must not see the data of
+	// earlier runs.
+	Ephemeral bool
+	// StoragePath is the directory persistent website data is stored in.
+	// The backend picks a default location if empty.
+	StoragePath string
+	// Profile, if non-empty, stores the website data in the directory of the
+	// named profile below AppOptions.ProfileDir instead, e.g. one per
+	// account: webviews of the same profile share cookies, caches and
+	// storage, those of different profiles do not. Names consist of letters,
+	// digits, '.', '-' and '_'. It cannot be combined with StoragePath or
+	// Ephemeral.
+	//
+	// WebKitGTK keeps only the cookies of a profile apart, caches and storage
+	// are shared by the persistent webviews of the application. WebKit on
+	// macOS needs macOS 14.
+	Profile string
+	// UserAgent overrides the user agent if non-empty.
+	UserAgent string
+	// Locales are the languages the pages prefer as BCP 47 tags in order of
+	// preference, e.g. "de-DE": the Accept-Language header and
+	// navigator.languages. The backend follows the system if empty, see
+	// LocalesEnv. WebKit on macOS sends the languages of the system
+	// regardless, WebKitGTK, Qt and WebView2 may apply them to the other
+	// webviews sharing the browser context or process.
+	Locales []string
+	// SpellCheckLanguages enables spell checking of editable elements in
+	// these languages, the backend default if empty. WebView2 and WebKit on
+	// macOS check the languages of the system, Qt needs the dictionaries in
+	// its qtwebengine_dictionaries directory.
+	SpellCheckLanguages []string
+	// Autoplay selects the media pages may play before the user interacted
+	// with them, enforced the same way on all backends unless it is
+	// AutoplayDefault.
+	Autoplay AutoplayPolicy
+	// SuspendBackgroundMedia pauses the playing media while the window is
+	// minimized or the page hidden and resumes it afterwards.
+	SuspendBackgroundMedia bool
+	// DisableMediaKeys keeps the hardware media keys and the media controls
+	// of the system away from the pages. Only the Qt and WebView2 backends
+	// support it.
+	DisableMediaKeys bool
+	// BrowserFlags are passed to the browser engine. Only the Qt and WebView2
+	// backends support them.
+	BrowserFlags []string
+	// ForceAccessibilityTree builds the accessibility tree of the pages
+	// before an assistive technology asks for it, e.g. for UI automation and
+	// accessibility checkers attaching later. The Qt and WebView2 backends
+	// apply it to all webviews of the browser process, WebKit builds the
+	// tree on request.
+	ForceAccessibilityTree bool
+	// IgnoreAccessibilityPreferences leaves the prefers-reduced-motion and
+	// prefers-contrast media features to the engine. By default they match
+	// the preferences of the system, see Application.Accessibility, also on
+	// the engines that do not follow them.
+	IgnoreAccessibilityPreferences bool
+}
+
+// Webview renders web content inside a Window.
+//
+// All methods are safe to call from any goroutine once the event loop runs.
+type Webview struct {
+	window      *Window
+	native      WebviewDriver
+	bridge      *bridge
+	events      emitter[WebviewEvent]
+	console     emitter[ConsoleMessage]
+	pageErrors  pageErrors
+	chrome      pageChrome
+	ready       emitter[struct{}]
+	drops       emitter[FileDrop]
+	navigate    deciders[NavigationEvent]
+	downloads   chain[DownloadRequest, DownloadDecision]
+	permissions chain[PermissionRequest, PermissionDecision]
+	windows     chain[NewWindowRequest, NewWindowDecision]
+	gone        chain[GoneReason, RecoveryAction]
+	devTools    atomic.Bool
+	quitHooks   atomic.Bool
+	scheme      featureOverride
+	access      featureOverride
+	keys        shortcutOverride
+	menu        contextMenu
+	tracer      Tracer
+	options     WebviewOptions
+	network     atomic.Pointer[NetworkOptions]
+	streams     atomic.Int64
+
+	once     sync.Once
+	suspend  sync.Once
+	dropping sync.Once
+	shared   sync.Once
+}
+
+// NewWebview creates a webview inside opts.Window. It returns ErrNotRunning
+// when called off the event loop thread before the event loop started.
+func NewWebview(opts WebviewOptions) (*Webview, error) {
+	if opts.Window == nil {
+		return nil, errors.New("saucerw: webview window is required")
+	}
+
+	if err := opts.Window.app.checkLoop(); err != nil {
+		return nil, err
+	}
+
+	options := opts
+	if err := opts.prepare(); err != nil {
+		return nil, err
+	}
+
+	raw, err := opts.Window.native.NewWebview(opts)
+	if err != nil {
+		return nil, err
+	}
+	native := newWebviewHandle(raw)
+
+	v := &Webview{window: opts.Window, native: native, options: options}
+	if opts.TracerProvider != nil {
+		v.tracer = opts.TracerProvider.Tracer(tracerName)
+	}
+	v.bridge = newBridge(native, opts.Batching, v.console.emit, v.menu.probed, func() { v.ready.emit(struct{}{}) }, v.hookQuit)
+	v.bridge.policy = opts.Security
+	v.bridge.policy.Bridge = slices.Clone(opts.Security.Bridge)
+	v.bridge.injectFrames()
+	v.bridge.shortcut = opts.Window.app.shortcut
+	v.bridge.pageError = v.pageErrors.report
+	v.bridge.chrome = func(data json.RawMessage) { v.chrome.report(v, data) }
+	v.chrome.opts = opts.Mirror
+	v.bridge.compress(opts.Compression)
+	v.trace()
+	v.setup(raw, &opts)
+
+	native.HandleNavigate(v.decideNavigation)
+
+	// Also checked by the backend, which may install its filter late
+	v.navigate.subscribe(func(ev NavigationEvent) Policy {
+		if network := v.network.Load(); network.filtered() && !network.allowURL(ev.URL) {
+			return Block
+		}
+		return Allow
+	})
+	native.HandlePermission(v.decidePermission)
+	native.HandleDownload(v.decideDownload)
+	native.HandleEvents(func(ev WebviewEvent) {
+		// On the thread handling the messages, so the streams of the next
+		// page are not canceled
+		if ev.Type == WebviewLoad && ev.Load == LoadStarted {
+			v.bridge.cancelStreams()
+			v.bridge.cancelProxied()
+		}
+		v.events.emit(ev)
+	})
+	native.HandleFileDrop(v.drops.emit)
+
+	v.events.subscribe(func(ev WebviewEvent) {
+		if ev.Type == WebviewProcessGone {
+			// Not from the callback of the webview a restart replaces
+			v.window.app.Post(func() { v.processGone(ev.Gone) })
+		}
+	})
+
+	v.HandleScheme(stashScheme, v.bridge.stash)
+
+	opts.Window.adopt(v)
+
+	// After adopt, so that no registration misses the webview
+	if names := opts.Window.app.keys.names(); len(names) > 0 {
+		v.setShortcuts(names)
+	}
+	v.followAccessibility(opts.Window.app.Accessibility(), true)
+
+	return v, nil
+}
+
+// prepare validates the options and completes them for the driver.
+func (o *WebviewOptions) prepare() error {
+	if err := o.Network.validate(); err != nil {
+		return err
+	}
+
+	if err := o.Security.validate(); err != nil {
+		return err
+	}
+	o.Network.blockInsecure = o.Security.BlockMixedContent
+
+	if err := o.Window.app.profile(&o.Preferences); err != nil {
+		return err
+	}
+
+	locales, err := o.Preferences.locales()
+	if err != nil {
+		return err
+	}
+	o.Preferences.Locales = locales
+
+	if flags := o.Window.app.remoteDebuggingFlags(); len(flags) > 0 {
+		o.Preferences.BrowserFlags = append(slices.Clip(o.Preferences.BrowserFlags), flags...)
+	}
+	if o.Preferences.ForceAccessibilityTree && !slices.Contains(o.Preferences.BrowserFlags, forceAccessibilityFlag) {
+		o.Preferences.BrowserFlags = append(slices.Clip(o.Preferences.BrowserFlags), forceAccessibilityFlag)
+	}
+	return nil
+}
+
+// setup applies the prepared options to the native webview, which
+// Webview.Restart does again for the webview replacing it. The scripts are
+// injected into native directly, so that they are not kept for the next one.
+func (v *Webview) setup(native WebviewDriver, opts *WebviewOptions) {
+	if locales := opts.Preferences.Locales; len(locales) > 0 {
+		native.Inject(Script{Code: localesScript(locales), Time: AtCreation, Frames: AllFrames, Permanent: true})
+	}
+	native.Inject(Script{Code: opts.Preferences.mediaScript(), Time: AtCreation, Frames: AllFrames, Permanent: true})
+	if opts.Preferences.SuspendBackgroundMedia {
+		v.suspend.Do(v.suspendMedia)
+	}
+	v.devTools.Store(opts.Preferences.devTools())
+	v.network.Store(&opts.Network)
+}
+
+// Parent returns the window the webview is placed in.
+func (v *Webview) Parent() *Window {
+	return v.window
+}
+
+// URL returns the current URL.
+func (v *Webview) URL() string {
+	return v.native.URL()
+}
+
+// PageTitle returns the title of the current page.
+func (v *Webview) PageTitle() string {
+	return v.native.PageTitle()
+}
+
+// Navigate loads url.
+func (v *Webview) Navigate(url string) {
+	v.native.SetURL(url)
+}
+
+// SetHTML replaces the page with the given HTML document.
+func (v *Webview) SetHTML(html string) {
+	v.native.SetHTML(html)
+}
+
+// Back navigates back in history.
+func (v *Webview) Back() {
+	v.native.Back()
+}
+
+// Forward navigates forward in history.
+func (v *Webview) Forward() {
+	v.native.Forward()
+}
+
+// Reload reloads the current page.
+func (v *Webview) Reload() {
+	v.native.Reload()
+}
+
+// Background returns the color painted behind the page.
+func (v *Webview) Background() Color {
+	return v.native.Background()
+}
+
+// SetBackground sets the color painted behind the page. Combined with a
+// transparent window and page, Color{} lets the desktop show through.
+func (v *Webview) SetBackground(color Color) {
+	v.native.SetBackground(color)
+}
+
+// release frees the native webview exactly once.
+func (v *Webview) release() {
+	v.once.Do(func() {
+		v.bridge.close()
+		v.native.Release()
+	})
+}
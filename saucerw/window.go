@@ -0,0 +1,458 @@
+package saucerw
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// WindowOptions configures a new Window. The zero value creates a decorated,
+// opaque window without size constraints.
+type WindowOptions struct {
+	// Frameless hides the title bar and borders, see SetDecorations.
+	Frameless bool
+	// Transparent clears the window background. Webviews paint a background
+	// of their own, see Webview.SetBackground.
+	Transparent bool
+	// AlwaysOnTop keeps the window above other windows.
+	AlwaysOnTop bool
+	// ClickThrough passes mouse input through the window.
+	ClickThrough bool
+	// MinSize and MaxSize constrain the size of the window, if non-zero.
+	MinSize Size
+	MaxSize Size
+	// Kiosk starts the window in kiosk mode, see SetKiosk.
+	Kiosk bool
+	// Offscreen keeps the window off the screens: Show maps it, so its
+	// webviews load and render, beyond the left edge of the leftmost screen.
+	// It is the default of a Headless application. Wayland compositors place
+	// windows themselves, use a virtual display there, see package display.
+	Offscreen bool
+}
+
+// apply sets the options on a new native window.
+func (o *WindowOptions) apply(native WindowDriver) {
+	if o.Frameless {
+		native.SetDecorations(DecorationNone)
+	}
+
+	if o.Transparent {
+		native.SetBackground(Color{})
+	}
+
+	if o.AlwaysOnTop {
+		native.SetAlwaysOnTop(true)
+	}
+
+	if o.ClickThrough {
+		native.SetClickThrough(true)
+	}
+
+	if o.MinSize != (Size{}) {
+		native.SetMinSize(o.MinSize)
+	}
+
+	if o.MaxSize != (Size{}) {
+		native.SetMaxSize(o.MaxSize)
+	}
+}
+
+// Window is a native top-level window.
+//
+// All methods are safe to call from any goroutine once the event loop runs.
+// Event handlers run on a goroutine of their own, in the order the events
+// were emitted.
+type Window struct {
+	app    *Application
+	native WindowDriver
+	id     uint64
+	events emitter[WindowEvent]
+
+	clicks emitter[menuClick]
+
+	kiosk     atomic.Bool
+	offscreen bool
+
+	closes       chain[context.Context, QuitDecision]
+	intercepting atomic.Bool
+	requesting   atomic.Bool
+
+	mu       sync.Mutex
+	webviews []*Webview
+	menu     *menuState
+	chrome   chrome
+	released bool
+
+	mirror windowMirror
+}
+
+// chrome is the state of a window kiosk mode restores.
+type chrome struct {
+	decorations Decoration
+	resizable   bool
+	alwaysOnTop bool
+	fullscreen  bool
+}
+
+// Parent returns the application owning the window.
+func (w *Window) Parent() *Application {
+	return w.app
+}
+
+// ID returns the identifier of the window, unique within the application,
+// see Application.Window.
+func (w *Window) ID() uint64 {
+	return w.id
+}
+
+// Webviews returns the webviews created inside the window.
+func (w *Window) Webviews() []*Webview {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return slices.Clone(w.webviews)
+}
+
+// Visible reports whether the window is shown.
+func (w *Window) Visible() bool {
+	return w.native.Visible()
+}
+
+// Focused reports whether the window has input focus.
+func (w *Window) Focused() bool {
+	return w.native.Focused()
+}
+
+// Minimized reports whether the window is minimized.
+func (w *Window) Minimized() bool {
+	return w.native.Minimized()
+}
+
+// Maximized reports whether the window is maximized.
+func (w *Window) Maximized() bool {
+	return w.native.Maximized()
+}
+
+// Resizable reports whether the window can be resized by the user.
+func (w *Window) Resizable() bool {
+	return w.native.Resizable()
+}
+
+// Fullscreen reports whether the window covers its screen.
+func (w *Window) Fullscreen() bool {
+	return w.native.Fullscreen()
+}
+
+// AlwaysOnTop reports whether the window is kept above other windows.
+func (w *Window) AlwaysOnTop() bool {
+	return w.native.AlwaysOnTop()
+}
+
+// ClickThrough reports whether mouse input passes through the window.
+func (w *Window) ClickThrough() bool {
+	return w.native.ClickThrough()
+}
+
+// Title returns the window title.
+func (w *Window) Title() string {
+	return w.native.Title()
+}
+
+// Size returns the size of the content of the window, without its
+// decorations.
+func (w *Window) Size() Size {
+	return w.native.Size()
+}
+
+// OuterSize returns the size of the window including its decorations, e.g.
+// to place it next to another window.
+func (w *Window) OuterSize() Size {
+	return w.native.OuterSize()
+}
+
+// Scale returns the number of device pixels per logical pixel of the screen
+// the window is on, e.g. 2 on most HiDPI screens. Sizes and positions of the
+// window are in logical pixels, see Size.ToPhysical to convert them.
+func (w *Window) Scale() float64 {
+	return w.native.Scale()
+}
+
+// SafeArea returns the insets of the content the system draws over, e.g. the
+// title bar of a window on macOS whose decorations are DecorationPartial.
+// Content within them may be covered. It is zero on the other platforms.
+func (w *Window) SafeArea() Insets {
+	return w.native.SafeArea()
+}
+
+// Background returns the window background color.
+func (w *Window) Background() Color {
+	return w.native.Background()
+}
+
+// Decorations returns the decorations drawn by the system.
+func (w *Window) Decorations() Decoration {
+	return w.native.Decorations()
+}
+
+// MinSize returns the minimum window size.
+func (w *Window) MinSize() Size {
+	return w.native.MinSize()
+}
+
+// MaxSize returns the maximum window size.
+func (w *Window) MaxSize() Size {
+	return w.native.MaxSize()
+}
+
+// Position returns the window position.
+func (w *Window) Position() Position {
+	return w.native.Position()
+}
+
+// Show shows the window, off the screens if it was created Offscreen.
+func (w *Window) Show() {
+	if w.offscreen {
+		w.native.SetPosition(offscreenPosition(w.app.Screens(), w.native.Size()))
+	}
+	w.native.Show()
+}
+
+// offscreenPosition returns a position of a window of the given size not
+// overlapping any of the screens.
+func offscreenPosition(screens []Screen, size Size) Position {
+	var left int
+	for _, s := range screens {
+		left = min(left, s.Position.X)
+	}
+	return Position{X: left - size.W - 100, Y: 0}
+}
+
+// Hide hides the window.
+func (w *Window) Hide() {
+	w.native.Hide()
+}
+
+// Close closes the window.
+func (w *Window) Close() {
+	w.native.Close()
+}
+
+// Focus brings the window to the front and focuses it.
+func (w *Window) Focus() {
+	w.native.Focus()
+}
+
+// StartDrag lets the user move the window, as if they pressed the mouse on
+// its title bar. Call it while the primary mouse button is held, e.g. from a
+// function the page calls on mousedown. Elements with the data-webview-drag
+// attribute do this without a round trip to Go.
+func (w *Window) StartDrag() {
+	w.native.StartDrag()
+}
+
+// StartResize lets the user resize the window at edges while the primary
+// mouse button is held, like StartDrag. Elements with the data-webview-resize
+// attribute do this for the edges listed in its value, e.g. "br" for the
+// bottom right corner.
+func (w *Window) StartResize(edges Edge) {
+	w.native.StartResize(edges)
+}
+
+// SetMinimized minimizes or restores the window.
+func (w *Window) SetMinimized(minimized bool) {
+	w.native.SetMinimized(minimized)
+}
+
+// SetMaximized maximizes or restores the window.
+func (w *Window) SetMaximized(maximized bool) {
+	w.native.SetMaximized(maximized)
+}
+
+// SetResizable controls whether the window can be resized by the user.
+func (w *Window) SetResizable(resizable bool) {
+	w.native.SetResizable(resizable)
+}
+
+// SetFullscreen makes the window cover its screen or restores it.
+func (w *Window) SetFullscreen(fullscreen bool) {
+	w.native.SetFullscreen(fullscreen)
+}
+
+// Kiosk reports whether the window is in kiosk mode.
+func (w *Window) Kiosk() bool {
+	return w.kiosk.Load()
+}
+
+// SetKiosk enters or leaves kiosk mode. A kiosk window covers its screen
+// without decorations, stays on top and cannot be closed by the user: the
+// close button, Alt+F4 and the close, quit, minimize, fullscreen and
+// developer tools roles do nothing. The shortcuts leaving fullscreen,
+// opening windows, printing and navigating the history (F11, Ctrl+W, Ctrl+Q,
+// Ctrl+N, Ctrl+T, Ctrl+O, Ctrl+P, Alt+Left, Alt+Right and Alt+Home) are
+// swallowed. Close and Destroy still close the window. Leaving kiosk mode
+// restores the previous state.
+//
+// Shortcuts of the system, like switching applications, stay available
+// except on macOS, where kiosk windows hide the dock and the menu bar and
+// disable process switching while they are fullscreen.
+func (w *Window) SetKiosk(kiosk bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.kiosk.Load() == kiosk {
+		return
+	}
+
+	if kiosk {
+		w.chrome = chrome{
+			decorations: w.native.Decorations(),
+			resizable:   w.native.Resizable(),
+			alwaysOnTop: w.native.AlwaysOnTop(),
+			fullscreen:  w.native.Fullscreen(),
+		}
+
+		w.native.SetKiosk(true)
+		w.native.SetDecorations(DecorationNone)
+		w.native.SetResizable(false)
+		w.native.SetAlwaysOnTop(true)
+		w.native.SetFullscreen(true)
+	} else {
+		w.native.SetKiosk(false)
+		w.native.SetFullscreen(w.chrome.fullscreen)
+		w.native.SetAlwaysOnTop(w.chrome.alwaysOnTop)
+		w.native.SetResizable(w.chrome.resizable)
+		w.native.SetDecorations(w.chrome.decorations)
+	}
+
+	w.kiosk.Store(kiosk)
+}
+
+// SetAlwaysOnTop controls whether the window is kept above other windows.
+func (w *Window) SetAlwaysOnTop(onTop bool) {
+	w.native.SetAlwaysOnTop(onTop)
+}
+
+// SetClickThrough controls whether mouse input passes through the window to
+// the windows below. It applies to the whole window.
+func (w *Window) SetClickThrough(clickThrough bool) {
+	w.native.SetClickThrough(clickThrough)
+}
+
+// SetTitle sets the window title. The title no longer follows the page
+// title then, see FollowPage.
+func (w *Window) SetTitle(title string) {
+	w.mirror.pin(chromeTitle)
+	w.native.SetTitle(title)
+}
+
+// SetIcon sets the icon of the window, shown in the title bar and the task
+// switcher. macOS shows the icon of the application bundle instead. The icon
+// no longer follows the favicon of the page then, see FollowPage.
+func (w *Window) SetIcon(img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("saucerw: encode icon: %w", err)
+	}
+	w.mirror.pin(chromeIcon)
+	return w.native.SetIcon(buf.Bytes())
+}
+
+// SetTitleBarColor colors the title bar drawn by the system, the zero Color
+// restores the default. It returns ErrUnsupported on Linux, Windows before
+// Windows 11 ignores it. WebKit on macOS draws the title bar transparently
+// over the window background. The color no longer follows the theme-color
+// of the page then, see FollowPage.
+func (w *Window) SetTitleBarColor(color Color) error {
+	w.mirror.pin(chromeColor)
+	if !w.native.SetTitleBarColor(color) {
+		return ErrUnsupported
+	}
+	return nil
+}
+
+// SetSize resizes the content of the window to size, the decorations are
+// added to it.
+func (w *Window) SetSize(size Size) {
+	w.native.SetSize(size)
+}
+
+// SetOuterSize resizes the window to size including its decorations. The
+// decorations are measured before, so the window has to be shown for them to
+// be known.
+func (w *Window) SetOuterSize(size Size) {
+	inner, outer := w.native.Size(), w.native.OuterSize()
+	w.native.SetSize(Size{W: size.W - (outer.W - inner.W), H: size.H - (outer.H - inner.H)})
+}
+
+// SetBackground sets the window background color. An alpha below 255 makes
+// the window translucent where nothing is drawn on top of it.
+func (w *Window) SetBackground(color Color) {
+	w.native.SetBackground(color)
+}
+
+// SetDecorations sets the decorations drawn by the system. Frameless windows
+// can be moved and resized through the data-webview-* attributes of their
+// webviews.
+func (w *Window) SetDecorations(decoration Decoration) {
+	w.native.SetDecorations(decoration)
+}
+
+// SetMinSize sets the minimum window size.
+func (w *Window) SetMinSize(size Size) {
+	w.native.SetMinSize(size)
+}
+
+// SetMaxSize sets the maximum window size.
+func (w *Window) SetMaxSize(size Size) {
+	w.native.SetMaxSize(size)
+}
+
+// SetPosition moves the window.
+func (w *Window) SetPosition(pos Position) {
+	w.native.SetPosition(pos)
+}
+
+// Destroy releases the native window and its webviews before the
+// application exits. The window must not be used afterwards.
+//
+// Native resources are freed on the event loop thread. Destroy returns
+// ErrNotRunning when called off that thread while the loop is not running.
+func (w *Window) Destroy() error {
+	if err := w.app.Dispatch(w.release); err != nil {
+		return err
+	}
+
+	w.app.forget(w)
+	return nil
+}
+
+// adopt registers a webview created inside w.
+func (w *Window) adopt(v *Webview) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.webviews = append(w.webviews, v)
+}
+
+// release frees the native window and its webviews exactly once.
+func (w *Window) release() {
+	w.mu.Lock()
+	if w.released {
+		w.mu.Unlock()
+		return
+	}
+
+	webviews := w.webviews
+	w.webviews, w.released = nil, true
+	w.mu.Unlock()
+
+	for _, v := range webviews {
+		v.release()
+	}
+	w.native.Release()
+}
@@ -0,0 +1,204 @@
+//go:build darwin && cgo && saucer
+
+#import <AppKit/AppKit.h>
+#import <objc/runtime.h>
+
+#include "native.h"
+
+// The windows in kiosk mode, held weakly
+static NSHashTable<NSWindow *> *kiosk_windows;
+
+// find_window returns the window created by the saucer::window::impl, which saucer keeps in the "me" ivar of its
+// NSWindow subclass.
+static NSWindow *find_window(const void *impl)
+{
+    for (NSWindow *window in NSApp.windows)
+    {
+        Ivar ivar = class_getInstanceVariable(object_getClass(window), "me");
+
+        if (ivar && *(const void **)((uint8_t *)(__bridge void *)window + ivar_getOffset(ivar)) == impl)
+        {
+            return window;
+        }
+    }
+
+    return nil;
+}
+
+// presentation is added to the window delegates of saucer, the options apply while a window is fullscreen.
+static NSApplicationPresentationOptions presentation(id self, SEL cmd, NSWindow *window,
+                                                     NSApplicationPresentationOptions proposed)
+{
+    if (![kiosk_windows containsObject:window])
+    {
+        return proposed;
+    }
+
+    return NSApplicationPresentationFullScreen | NSApplicationPresentationHideDock |
+           NSApplicationPresentationHideMenuBar | NSApplicationPresentationDisableAppleMenu |
+           NSApplicationPresentationDisableProcessSwitching | NSApplicationPresentationDisableForceQuit |
+           NSApplicationPresentationDisableSessionTermination | NSApplicationPresentationDisableHideApplication;
+}
+
+void saucerw_cocoa_set_kiosk(const void *window, bool kiosk)
+{
+    NSWindow *native = find_window(window);
+
+    if (!native)
+    {
+        return;
+    }
+
+    if (!kiosk_windows)
+    {
+        kiosk_windows = [NSHashTable weakObjectsHashTable];
+    }
+
+    id delegate  = native.delegate;
+    SEL selector = @selector(window:willUseFullScreenPresentationOptions:);
+
+    if (delegate && ![delegate respondsToSelector:selector])
+    {
+        class_addMethod(object_getClass(delegate), selector, (IMP)presentation, "Q@:@Q");
+    }
+
+    if (kiosk)
+    {
+        [kiosk_windows addObject:native];
+    }
+    else
+    {
+        [kiosk_windows removeObject:native];
+    }
+}
+
+// The key of the background color a window had before its title bar was colored
+static const void *const untinted_key = &untinted_key;
+
+// The title bar is colored by drawing the window background below it, which is hidden by the webviews elsewhere
+void saucerw_cocoa_set_titlebar_color(const void *window, saucerw_color color)
+{
+    NSWindow *native = find_window(window);
+
+    if (!native)
+    {
+        return;
+    }
+
+    NSColor *untinted = objc_getAssociatedObject(native, untinted_key);
+
+    if (color.a == 0)
+    {
+        if (untinted)
+        {
+            native.backgroundColor            = untinted;
+            native.titlebarAppearsTransparent = NO;
+            objc_setAssociatedObject(native, untinted_key, nil, OBJC_ASSOCIATION_RETAIN_NONATOMIC);
+        }
+
+        return;
+    }
+
+    if (!untinted)
+    {
+        objc_setAssociatedObject(native, untinted_key, native.backgroundColor, OBJC_ASSOCIATION_RETAIN_NONATOMIC);
+    }
+
+    native.titlebarAppearsTransparent = YES;
+    native.backgroundColor            = [NSColor colorWithSRGBRed:color.r / 255.0
+                                                 green:color.g / 255.0
+                                                  blue:color.b / 255.0
+                                                 alpha:1];
+}
+
+void saucerw_cocoa_screen_scales(double *scales, size_t count)
+{
+    NSArray<NSScreen *> *screens = NSScreen.screens;
+
+    for (size_t i = 0; count > i; i++)
+    {
+        scales[i] = screens.count > i ? screens[i].backingScaleFactor : 1;
+    }
+}
+
+double saucerw_cocoa_window_scale(const void *window)
+{
+    NSWindow *native = find_window(window);
+    return native ? native.backingScaleFactor : NSScreen.mainScreen.backingScaleFactor;
+}
+
+void saucerw_cocoa_outer_size(const void *window, int *w, int *h)
+{
+    NSWindow *native = find_window(window);
+
+    *w = native ? (int)lround(native.frame.size.width) : 0;
+    *h = native ? (int)lround(native.frame.size.height) : 0;
+}
+
+void saucerw_cocoa_safe_area(const void *window, int *top, int *right, int *bottom, int *left)
+{
+    NSWindow *native = find_window(window);
+
+    if (!native)
+    {
+        return;
+    }
+
+    // The layout rect leaves out the title bar drawn over a full size content view
+    NSRect bounds = native.contentView.bounds;
+    NSRect layout = [native.contentView convertRect:native.contentLayoutRect fromView:nil];
+
+    *top    = (int)lround(NSMaxY(bounds) - NSMaxY(layout));
+    *right  = (int)lround(NSMaxX(bounds) - NSMaxX(layout));
+    *bottom = (int)lround(NSMinY(layout) - NSMinY(bounds));
+    *left   = (int)lround(NSMinX(layout) - NSMinX(bounds));
+
+    if (@available(macOS 11.0, *))
+    {
+        // Covers the camera housing of fullscreen windows as well
+        NSEdgeInsets insets = native.contentView.safeAreaInsets;
+
+        *top    = MAX(*top, (int)lround(insets.top));
+        *right  = MAX(*right, (int)lround(insets.right));
+        *bottom = MAX(*bottom, (int)lround(insets.bottom));
+        *left   = MAX(*left, (int)lround(insets.left));
+    }
+}
+
+void *saucerw_cocoa_watch_scale(const void *window, uintptr_t handler)
+{
+    NSWindow *native = find_window(window);
+
+    if (!native)
+    {
+        return NULL;
+    }
+
+    __block CGFloat last = native.backingScaleFactor;
+    __weak NSWindow *weak = native;
+
+    // Also posted for changes of the color space, which leave the scale alone
+    id observer = [NSNotificationCenter.defaultCenter
+        addObserverForName:NSWindowDidChangeBackingPropertiesNotification
+                    object:native
+                     queue:NSOperationQueue.mainQueue
+                usingBlock:^(NSNotification *notification) {
+                  CGFloat scale = weak.backingScaleFactor;
+
+                  if (scale == last)
+                  {
+                      return;
+                  }
+
+                  last = scale;
+                  saucerwWindowEvent(handler, SAUCERW_WINDOW_SCALE, (int)lround(scale * 1000), 0, 0);
+                }];
+
+    return (__bridge_retained void *)observer;
+}
+
+void saucerw_cocoa_unwatch_scale(void *watcher)
+{
+    id observer = (__bridge_transfer id)watcher;
+    [NSNotificationCenter.defaultCenter removeObserver:observer];
+}
@@ -0,0 +1,141 @@
+package windowstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// Store persists the states of windows by name.
+//
+// Its methods are called from the event loop thread and from timers, a Store
+// must be safe for concurrent use.
+type Store interface {
+	// Load returns the state saved under name, ok being false if there is
+	// none.
+	Load(name string) (state State, ok bool, err error)
+	// Save replaces the state saved under name.
+	Save(name string, state State) error
+}
+
+// File is a Store keeping the states of all windows in one JSON file.
+type File struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFile returns a Store writing to the JSON file at path. The file and its
+// directory are created on the first save.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// UserFile returns the File "windowstate.json" in the Data directory of
+// saucerw.DefaultPaths of the application id.
+func UserFile(id string) (*File, error) {
+	if id == "" {
+		return nil, errors.New("windowstate: application id is required")
+	}
+
+	paths, err := saucerw.DefaultPaths(id)
+	if err != nil {
+		return nil, err
+	}
+	return NewFile(filepath.Join(paths.Data, "windowstate.json")), nil
+}
+
+// AppFile returns the File "windowstate.json" in the Data directory of the
+// Paths of app.
+func AppFile(app *saucerw.Application) (*File, error) {
+	dir := app.Paths().Data
+	if dir == "" {
+		return nil, errors.New("windowstate: the application has no data directory")
+	}
+	return NewFile(filepath.Join(dir, "windowstate.json")), nil
+}
+
+// Path returns the path of the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Load returns the state saved under name.
+func (f *File) Load(name string) (State, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	states, err := f.read()
+	if err != nil {
+		return State{}, false, err
+	}
+
+	state, ok := states[name]
+	return state, ok, nil
+}
+
+// Save replaces the state saved under name, keeping those of other windows.
+// The file is replaced atomically, a crash while saving leaves the previous
+// contents.
+func (f *File) Save(name string, state State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	states, err := f.read()
+	if err != nil {
+		return err
+	}
+	states[name] = state
+
+	data, err := json.MarshalIndent(states, "", "\t")
+	if err != nil {
+		return fmt.Errorf("windowstate: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("windowstate: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".windowstate-*")
+	if err != nil {
+		return fmt.Errorf("windowstate: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("windowstate: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("windowstate: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("windowstate: %w", err)
+	}
+	return nil
+}
+
+// read returns the states in the file, none if it does not exist.
+func (f *File) read() (map[string]State, error) {
+	states := make(map[string]State)
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("windowstate: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("windowstate: %s: %w", f.path, err)
+	}
+	return states, nil
+}
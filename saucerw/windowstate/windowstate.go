@@ -0,0 +1,284 @@
+// Package windowstate remembers the size, position and maximized state of
+// windows across runs of the application.
+//
+//	store, err := windowstate.UserFile("com.example.app")
+//	if err != nil {
+//		return err
+//	}
+//	if _, err := windowstate.Track(win, windowstate.Options{Name: "main", Store: store}); err != nil {
+//		return err
+//	}
+//	win.Show()
+//
+// A saved state is only restored where it fits: a window whose screen was
+// disconnected or rearranged is centered on another screen, and a window
+// larger than its screen is shrunk to fit. Wayland compositors do not let
+// windows position themselves, only the size and maximized state apply there.
+package windowstate
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+	"github.com/aperturerobotics/saucer/saucerw/screens"
+)
+
+// State is the saved placement of a window.
+type State struct {
+	// Width and Height are the size of the window while neither maximized
+	// nor minimized.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// X and Y are its position.
+	X int `json:"x"`
+	Y int `json:"y"`
+	// Maximized reports whether the window was maximized.
+	Maximized bool `json:"maximized,omitempty"`
+	// Screen is the name of the screen the window was on.
+	Screen string `json:"screen,omitempty"`
+}
+
+// Size returns the size of the window.
+func (s State) Size() saucerw.Size {
+	return saucerw.Size{W: s.Width, H: s.Height}
+}
+
+// Position returns the position of the window.
+func (s State) Position() saucerw.Position {
+	return saucerw.Position{X: s.X, Y: s.Y}
+}
+
+// bounds returns the rectangle covered by the window.
+func (s State) bounds() image.Rectangle {
+	return image.Rect(s.X, s.Y, s.X+s.Width, s.Y+s.Height)
+}
+
+const (
+	// minSize is the smallest width and height restored, smaller windows
+	// are left at their size.
+	minSize = 64
+	// grip is the part of the top edge of a window that has to be on a
+	// screen for the user to drag it.
+	grip = 64
+	// saveDelay is the time a Tracker waits after the last event before it
+	// saves, so that resizing does not write the file many times a second.
+	saveDelay = time.Second
+)
+
+// bounds returns the rectangle covered by s.
+func bounds(s saucerw.Screen) image.Rectangle {
+	return image.Rect(s.Position.X, s.Position.Y, s.Position.X+s.Size.W, s.Position.Y+s.Size.H)
+}
+
+// reachable returns the screen the top edge of a window with the bounds r can
+// be dragged on.
+func reachable(all []saucerw.Screen, r image.Rectangle) (saucerw.Screen, bool) {
+	top := image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1)
+
+	for _, screen := range all {
+		if visible := top.Intersect(bounds(screen)); visible.Dx() >= min(grip, r.Dx()) {
+			return screen, true
+		}
+	}
+	return saucerw.Screen{}, false
+}
+
+// Restore applies state to w, before it is shown. A state without a size is
+// ignored.
+func Restore(w *saucerw.Window, state State) {
+	if state.Width < minSize || state.Height < minSize {
+		return
+	}
+
+	all := w.Parent().Screens()
+	if len(all) == 0 {
+		w.SetSize(state.Size())
+		w.SetMaximized(state.Maximized)
+		return
+	}
+
+	screen, ok := reachable(all, state.bounds())
+	placed := ok
+
+	if !ok {
+		// The screen was disconnected or moved, fall back to the one of the
+		// same name or the first one
+		if screen, ok = screens.Find(w.Parent(), state.Screen); !ok {
+			screen = all[0]
+		}
+	}
+
+	size := state.Size()
+	size.W, size.H = min(size.W, screen.Size.W), min(size.H, screen.Size.H)
+	w.SetSize(size)
+
+	if placed {
+		w.SetPosition(state.Position())
+	} else {
+		screens.Center(w, screen)
+	}
+
+	w.SetMaximized(state.Maximized)
+}
+
+// Options configures Track.
+type Options struct {
+	// Name identifies the window in the store. Required.
+	Name string
+	// Store persists the state. Required.
+	Store Store
+	// OnError, if non-nil, receives the errors of the saves the window
+	// events started. They are discarded otherwise.
+	OnError func(error)
+}
+
+// Tracker saves the state of a window as it changes.
+type Tracker struct {
+	window *saucerw.Window
+	opts   Options
+	subs   []*saucerw.Subscription
+
+	mu    sync.Mutex
+	state State
+	timer *time.Timer
+}
+
+// Track restores the state saved for the window and saves it whenever the
+// window was resized, maximized, focused or closed. Call it before showing the
+// window. Moving a window emits no event, its position is picked up by the
+// next one, at the latest when it is closed.
+func Track(w *saucerw.Window, opts Options) (*Tracker, error) {
+	if opts.Name == "" {
+		return nil, errors.New("windowstate: name is required")
+	}
+
+	if opts.Store == nil {
+		return nil, errors.New("windowstate: store is required")
+	}
+
+	state, ok, err := opts.Store.Load(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tracker{window: w, opts: opts}
+
+	if ok {
+		Restore(w, state)
+		t.state = state
+	} else {
+		t.update()
+	}
+
+	t.subs = []*saucerw.Subscription{
+		w.OnResize(func(saucerw.Size) { t.changed() }),
+		w.OnMaximize(func(bool) { t.changed() }),
+		w.OnFocus(func(bool) { t.changed() }),
+		w.OnClosed(func() { t.report(t.Save()) }),
+	}
+
+	return t, nil
+}
+
+// State returns the last state recorded.
+func (t *Tracker) State() State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.state
+}
+
+// Save records the current state of the window and saves it right away. It
+// must not be called once the window was destroyed.
+func (t *Tracker) Save() error {
+	t.update()
+
+	t.mu.Lock()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	state := t.state
+	t.mu.Unlock()
+
+	return t.opts.Store.Save(t.opts.Name, state)
+}
+
+// Stop stops tracking the window without saving it again.
+func (t *Tracker) Stop() {
+	for _, sub := range t.subs {
+		sub.Cancel()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// changed records the state and schedules saving it.
+func (t *Tracker) changed() {
+	t.update()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Reset(saveDelay)
+		return
+	}
+
+	t.timer = time.AfterFunc(saveDelay, func() {
+		t.mu.Lock()
+		t.timer = nil
+		state := t.state
+		t.mu.Unlock()
+
+		t.report(t.opts.Store.Save(t.opts.Name, state))
+	})
+}
+
+// update records the state of the window. The bounds are kept while it is
+// maximized, minimized or fullscreen, so that they restore its normal size.
+//
+// The window is read before locking, its getters wait for the event loop
+// which may be delivering an event to the tracker.
+func (t *Tracker) update() {
+	w := t.window
+	maximized, minimized := w.Maximized(), w.Minimized()
+	normal := !maximized && !minimized && !w.Fullscreen()
+
+	size, pos := w.Size(), w.Position()
+	screen, onScreen := screens.Of(w)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !minimized {
+		t.state.Maximized = maximized
+	}
+
+	if !normal || size.W < minSize || size.H < minSize {
+		return
+	}
+
+	t.state.Width, t.state.Height = size.W, size.H
+	t.state.X, t.state.Y = pos.X, pos.Y
+
+	if onScreen {
+		t.state.Screen = screen.Name
+	}
+}
+
+// report passes err to the OnError option.
+func (t *Tracker) report(err error) {
+	if err != nil && t.opts.OnError != nil {
+		t.opts.OnError(err)
+	}
+}
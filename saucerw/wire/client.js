@@ -0,0 +1,303 @@
+// Client of the saucerw wire protocol, see the documentation of package
+// saucerw/wire. It runs in browsers and in Node.
+
+export const VERSIONS = [1];
+
+// error returns the GoError a failed call rejects with, like in a saucerw
+// webview
+export const error = ({ message, code, chain, data }) =>
+{
+    const error = new Error(message);
+
+    error.name  = "GoError";
+    error.code  = code;
+    error.chain = chain;
+    error.data  = data;
+
+    return error;
+};
+
+// Binary arguments are sent as base64 encoded strings
+const encode = (value) =>
+{
+    if (!(value instanceof ArrayBuffer) && !ArrayBuffer.isView(value))
+    {
+        return value;
+    }
+
+    const bytes = value instanceof ArrayBuffer ? new Uint8Array(value) : new Uint8Array(value.buffer, value.byteOffset, value.byteLength);
+    let binary  = "";
+
+    for (let i = 0; i < bytes.length; i += 0x8000)
+    {
+        binary += String.fromCharCode(...bytes.subarray(i, i + 0x8000));
+    }
+
+    return btoa(binary);
+};
+
+export class Client
+{
+    #transport;
+    #pending = new Map();
+    #handlers = new Map();
+    #lastID = 0;
+    #welcome;
+    #closed = null;
+
+    // The version of the protocol and the names of the functions the server
+    // offered
+    version   = 0;
+    functions = [];
+
+    exposed = new Proxy({}, {
+        get: (_, prop) => (...args) => this.call(prop, args),
+    });
+
+    // transport sends a frame with send(text), passes received frames to
+    // receive and reports the end of the connection to closed
+    constructor(transport)
+    {
+        this.#transport = transport;
+
+        this.#welcome = new Promise((resolve, reject) =>
+        {
+            this.#pending.set(0, { resolve, reject });
+        });
+
+        transport.start((text) => this.#receive(text), (reason) => this.#close(reason));
+        transport.send(JSON.stringify({ type: "hello", versions: VERSIONS }));
+    }
+
+    // ready resolves once the server welcomed the client
+    ready()
+    {
+        return this.#welcome.then(() => this);
+    }
+
+    async call(name, params = [], options = {})
+    {
+        if (!Array.isArray(params))
+        {
+            throw 'Bad arguments, expected array';
+        }
+
+        await this.#welcome;
+
+        const signal = options.signal;
+        signal?.throwIfAborted();
+
+        if (this.#closed)
+        {
+            throw this.#closed;
+        }
+
+        const id      = ++this.#lastID;
+        const promise = new Promise((resolve, reject) =>
+        {
+            this.#pending.set(id, { resolve, reject });
+        });
+
+        this.#transport.send(JSON.stringify({ type: "call", id, name, params: params.map(encode) }));
+
+        if (!signal)
+        {
+            return promise;
+        }
+
+        const abort = () =>
+        {
+            this.#pending.get(id)?.reject(signal.reason);
+            this.#pending.delete(id);
+
+            this.#transport.send(JSON.stringify({ type: "abort", id }));
+        };
+
+        signal.addEventListener("abort", abort, { once: true });
+
+        try
+        {
+            return await promise;
+        } finally
+        {
+            signal.removeEventListener("abort", abort);
+        }
+    }
+
+    // on calls handler with the data of the events name and returns a
+    // function removing it
+    on(name, handler)
+    {
+        if (!this.#handlers.has(name))
+        {
+            this.#handlers.set(name, new Set());
+        }
+
+        this.#handlers.get(name).add(handler);
+        return () => this.#handlers.get(name)?.delete(handler);
+    }
+
+    close()
+    {
+        this.#transport.close();
+        this.#close(new Error("connection closed"));
+    }
+
+    // install makes window.saucer.call and window.saucer.exposed use the
+    // client outside of a saucerw webview, for the clients generated by
+    // saucerw/dts
+    install()
+    {
+        globalThis.saucer ??= {};
+        globalThis.saucer.call    = (name, params, options) => this.call(name, params, options);
+        globalThis.saucer.exposed = this.exposed;
+
+        return this;
+    }
+
+    #receive(text)
+    {
+        let frame = undefined;
+
+        try
+        {
+            frame = JSON.parse(text);
+        } catch
+        {
+            return;
+        }
+
+        switch (frame.type)
+        {
+        case "welcome":
+            this.version   = frame.version;
+            this.functions = frame.functions ?? [];
+            this.#settle(0, frame);
+            break;
+        case "result":
+            if (frame.error)
+            {
+                this.#settle(frame.id, undefined, error(frame.error));
+            }
+            else
+            {
+                this.#settle(frame.id, frame.result);
+            }
+            break;
+        case "event":
+            for (const handler of [...(this.#handlers.get(frame.name) ?? [])])
+            {
+                handler(frame.data);
+            }
+            break;
+        case "error":
+            this.#close(error(frame.error));
+            break;
+        }
+    }
+
+    #settle(id, value, reason)
+    {
+        const pending = this.#pending.get(id);
+        this.#pending.delete(id);
+
+        if (reason)
+        {
+            pending?.reject(reason);
+        }
+        else
+        {
+            pending?.resolve(value);
+        }
+    }
+
+    #close(reason)
+    {
+        if (this.#closed)
+        {
+            return;
+        }
+
+        this.#closed = reason;
+
+        for (const { reject } of this.#pending.values())
+        {
+            reject(reason);
+        }
+
+        this.#pending.clear();
+    }
+}
+
+// connectWebSocket connects to the server at url, e.g.
+// "ws://localhost:8080/bridge"
+export const connectWebSocket = (url) =>
+{
+    const socket = new WebSocket(url);
+
+    return new Client({
+        start: (receive, closed) =>
+        {
+            socket.addEventListener("message", (e) => receive(e.data));
+            socket.addEventListener("close", () => closed(new Error(`connection to ${url} closed`)));
+        },
+        send: (text) =>
+        {
+            if (socket.readyState === WebSocket.CONNECTING)
+            {
+                socket.addEventListener("open", () => socket.send(text), { once: true });
+                return;
+            }
+
+            if (socket.readyState === WebSocket.OPEN)
+            {
+                socket.send(text);
+            }
+        },
+        close: () => socket.close(),
+    }).ready();
+};
+
+// connectStdio talks to a server on the standard input and output of a Node
+// child process, or of anything with a readable stdout and a writable stdin
+export const connectStdio = (child) =>
+{
+    let buffer = "";
+
+    return new Client({
+        start: (receive, closed) =>
+        {
+            child.stdout.setEncoding?.("utf8");
+            child.stdout.on("data", (chunk) =>
+            {
+                buffer += chunk;
+
+                let end = buffer.indexOf("\n");
+                for (; end !== -1; end = buffer.indexOf("\n"))
+                {
+                    const line = buffer.slice(0, end).trim();
+                    buffer     = buffer.slice(end + 1);
+
+                    if (line)
+                    {
+                        receive(line);
+                    }
+                }
+            });
+            child.stdout.on("close", () => closed(new Error("server exited")));
+        },
+        send: (text) => child.stdin.write(text + "\n"),
+        close: () => child.stdin.end(),
+    }).ready();
+};
+
+// connect connects to a WebSocket URL or a child process
+export const connect = (target) =>
+{
+    if (typeof target === "string" || target instanceof URL)
+    {
+        return connectWebSocket(String(target));
+    }
+
+    return connectStdio(target);
+};
@@ -0,0 +1,222 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// transport carries the frames of a session.
+type transport interface {
+	// read returns the next frame, io.EOF once the client closed the
+	// connection.
+	read() ([]byte, error)
+	// write sends a frame, it may be called concurrently.
+	write(frame []byte) error
+	close() error
+}
+
+// session is a connected client.
+type session struct {
+	t transport
+
+	mu    sync.Mutex
+	calls map[uint64]context.CancelFunc
+}
+
+// serve runs the session of a client on t.
+func (s *Server) serve(ctx context.Context, t transport) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	defer t.close()
+	stop := context.AfterFunc(ctx, func() { t.close() })
+	defer stop()
+
+	sess := &session{t: t, calls: map[uint64]context.CancelFunc{}}
+
+	hello, err := sess.read()
+	if err != nil {
+		return err
+	}
+	if hello.Type != typeHello {
+		return sess.fail(&saucerw.BridgeError{Code: saucerw.CodeInvalidArgument, Err: fmt.Errorf("expected a hello frame, got %q", hello.Type)})
+	}
+
+	version, err := negotiate(hello.Versions)
+	if err != nil {
+		return sess.fail(err)
+	}
+
+	s.mu.Lock()
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, sess)
+		s.mu.Unlock()
+	}()
+
+	sess.send(&frame{Type: typeWelcome, Version: version, Functions: s.names()})
+
+	for {
+		f, err := sess.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch f.Type {
+		case typeCall:
+			s.call(ctx, sess, f)
+		case typeAbort:
+			sess.abort(f.ID)
+		}
+	}
+}
+
+// call runs the function requested by f through the middleware.
+func (s *Server) call(ctx context.Context, sess *session, f *frame) {
+	ctx, cancel := context.WithCancel(ctx)
+	if !sess.start(f.ID, cancel) {
+		cancel()
+		sess.send(&frame{Type: typeResult, ID: f.ID, Error: saucerw.MarshalBridgeError(&saucerw.BridgeError{
+			Code: saucerw.CodeInvalidArgument,
+			Err:  fmt.Errorf("call %d is already pending", f.ID),
+		})})
+		return
+	}
+
+	handler := s.handler()
+
+	// Functions may block, keep them off the reading goroutine.
+	go func() {
+		defer sess.abort(f.ID)
+
+		result, err := func() (_ json.RawMessage, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = &saucerw.PanicError{Source: "bridge middleware", Value: r}
+				}
+			}()
+			return handler(ctx, &saucerw.BridgeCall{Name: f.Name, Params: f.Params})
+		}()
+
+		// Nobody waits for the result of an aborted call
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			sess.send(&frame{Type: typeResult, ID: f.ID, Error: saucerw.MarshalBridgeError(err)})
+			return
+		}
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		sess.send(&frame{Type: typeResult, ID: f.ID, Result: result})
+	}()
+}
+
+// read returns the next frame. A frame that cannot be decoded fails the
+// session.
+func (sess *session) read() (*frame, error) {
+	data, err := sess.t.read()
+	if err != nil {
+		return nil, err
+	}
+
+	f := new(frame)
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, sess.fail(&saucerw.BridgeError{Code: saucerw.CodeInvalidArgument, Err: fmt.Errorf("malformed frame: %w", err)})
+	}
+	return f, nil
+}
+
+// send writes f, dropping it if the connection failed.
+func (sess *session) send(f *frame) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		data, _ = json.Marshal(&frame{Type: typeResult, ID: f.ID, Error: saucerw.MarshalBridgeError(err)})
+	}
+	sess.t.write(data)
+}
+
+// fail sends err in an error frame and returns it to end the session.
+func (sess *session) fail(err error) error {
+	sess.send(&frame{Type: typeError, Error: saucerw.MarshalBridgeError(err)})
+	return fmt.Errorf("wire: %w", err)
+}
+
+// start registers the pending call id, false if it is already pending.
+func (sess *session) start(id uint64, cancel context.CancelFunc) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if _, ok := sess.calls[id]; ok {
+		return false
+	}
+	sess.calls[id] = cancel
+	return true
+}
+
+// abort cancels the context of call id, if it is still running.
+func (sess *session) abort(id uint64) {
+	sess.mu.Lock()
+	cancel, ok := sess.calls[id]
+	delete(sess.calls, id)
+	sess.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// streamTransport carries a frame per line.
+type streamTransport struct {
+	r  *bufio.Reader
+	rw io.ReadWriter
+
+	mu sync.Mutex
+}
+
+func newStreamTransport(rw io.ReadWriter) *streamTransport {
+	return &streamTransport{r: bufio.NewReader(rw), rw: rw}
+}
+
+func (t *streamTransport) read() ([]byte, error) {
+	for {
+		line, err := t.r.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) != 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *streamTransport) write(frame []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, err := t.rw.Write(append(frame, '\n'))
+	return err
+}
+
+func (t *streamTransport) close() error {
+	if c, ok := t.rw.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
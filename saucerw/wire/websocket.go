@@ -0,0 +1,64 @@
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aperturerobotics/saucer/saucerw/internal/websocket"
+)
+
+// defaultMaxMessageSize is the limit of Options.MaxMessageSize when zero.
+const defaultMaxMessageSize = 64 << 20
+
+// wsTransport is a transport carrying a frame per text message over the
+// server end of a WebSocket.
+type wsTransport struct {
+	ws *websocket.Conn
+}
+
+// upgrade switches r to the WebSocket protocol. It answers the request with
+// an error if it is not a WebSocket handshake.
+func upgrade(w http.ResponseWriter, r *http.Request, max int64) (*wsTransport, error) {
+	if max <= 0 {
+		max = defaultMaxMessageSize
+	}
+
+	ws, err := websocket.Upgrade(w, r, max)
+	if err != nil {
+		return nil, fmt.Errorf("wire: %w", err)
+	}
+	return &wsTransport{ws: ws}, nil
+}
+
+// read returns the next message, answering closes.
+func (t *wsTransport) read() ([]byte, error) {
+	op, message, err := t.ws.ReadMessage()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("wire: %w", err)
+	}
+
+	if op == websocket.OpClose {
+		code := uint16(websocket.CloseNoStatus)
+		if len(message) >= 2 {
+			code = binary.BigEndian.Uint16(message)
+		}
+		t.ws.WriteClose(code, "")
+		return nil, io.EOF
+	}
+	return message, nil
+}
+
+func (t *wsTransport) write(frame []byte) error {
+	return t.ws.WriteFrame(websocket.OpText, frame)
+}
+
+// close sends a normal close frame and closes the connection.
+func (t *wsTransport) close() error {
+	t.ws.WriteClose(websocket.CloseNormal, "")
+	return t.ws.NetConn().Close()
+}
@@ -0,0 +1,372 @@
+// Package wire serves Go functions to JavaScript over a documented protocol of
+// JSON frames, carried by a WebSocket or by standard input and output, for
+// frontends that do not run in a saucerw webview: a page in a browser during
+// development, a webview hosted in another process, or an application written
+// in another language that embeds saucer and reuses the Go side of this
+// module.
+//
+// A Server exposes functions like Webview.Expose and runs bridge middleware
+// like Webview.UseBridge, so the code registering the bridge surface, package
+// dts and package bridgerec work with both:
+//
+//	srv := wire.NewServer(wire.Options{})
+//	srv.Expose("add", func(a, b int) int { return a + b })
+//	http.Handle("/bridge", srv)
+//
+// Client, a JavaScript module, is the client for browsers and Node:
+//
+//	import { connect } from "./wire.js";
+//
+//	const client = await connect("ws://localhost:8080/bridge");
+//	await client.exposed.add(1, 2); // 3
+//
+// Browsers may connect from pages of the server and of the AllowedOrigins.
+// Other clients send no origin and could be any process of the machine, so
+// they present the token of the server, see Server.Token:
+//
+//	const client = await connect(`ws://localhost:8080/bridge?token=${token}`);
+//
+// # Protocol
+//
+// Every frame is a JSON object with a "type". Over a WebSocket each text
+// message is a frame, over a stream each frame is a line. The client starts
+// by offering the versions it speaks, and the server answers with the one it
+// chose, the highest both speak, and the names of the exposed functions:
+//
+//	→ {"type":"hello","versions":[1]}
+//	← {"type":"welcome","version":1,"functions":["add"]}
+//
+// A server speaking none of the versions answers with an error frame and
+// closes the connection, as it does for frames it cannot decode:
+//
+//	← {"type":"error","error":{"message":"...","code":"invalid_argument","chain":["..."]}}
+//
+// The client then calls functions with ids it chooses, unique among its
+// pending calls, and gets their results in any order. A failed call carries
+// the error a bridge call rejects with in a webview, see BridgeError:
+//
+//	→ {"type":"call","id":1,"name":"add","params":[1,2]}
+//	← {"type":"result","id":1,"result":3}
+//	← {"type":"result","id":2,"error":{"message":"No exposed function 'sub'","code":"not_found","chain":["..."]}}
+//
+// Aborting a call cancels its context, its result is not sent:
+//
+//	→ {"type":"abort","id":1}
+//
+// The server sends events passed to Server.Emit at any time after the
+// welcome:
+//
+//	← {"type":"event","name":"progress","data":0.5}
+//
+// Binary arguments are base64 encoded strings, which decode into []byte
+// parameters. Later versions add frame types and fields, both sides ignore
+// those they do not know.
+package wire
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aperturerobotics/saucer/saucerw"
+)
+
+// Version is the highest version of the protocol the Server speaks.
+const Version = 1
+
+// versions are the versions of the protocol the Server speaks.
+var versions = []int{1}
+
+// Client is the JavaScript client of the protocol, an ES module for browsers
+// and Node.
+//
+//go:embed client.js
+var Client string
+
+// Frame types of the protocol.
+const (
+	typeHello   = "hello"
+	typeWelcome = "welcome"
+	typeCall    = "call"
+	typeAbort   = "abort"
+	typeResult  = "result"
+	typeEvent   = "event"
+	typeError   = "error"
+)
+
+// frame is a frame of the protocol, see the package documentation.
+type frame struct {
+	Type string `json:"type"`
+
+	Versions  []int    `json:"versions,omitempty"`
+	Version   int      `json:"version,omitempty"`
+	Functions []string `json:"functions,omitempty"`
+
+	ID     uint64            `json:"id,omitempty"`
+	Name   string            `json:"name,omitempty"`
+	Params []json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage   `json:"result,omitempty"`
+	Error  json.RawMessage   `json:"error,omitempty"`
+	Data   json.RawMessage   `json:"data,omitempty"`
+}
+
+// Options configures a Server.
+type Options struct {
+	// AllowedOrigins lists the origins of pages that may connect over a
+	// WebSocket, e.g. "http://localhost:5173", "*" allowing all. Pages of
+	// the host of the server may always connect.
+	AllowedOrigins []string
+	// AllowedHosts lists the hosts, with or without port, the server is
+	// reached at besides localhost and the loopback addresses, e.g.
+	// "devbox.lan:8080" for a server listening on the network. WebSocket
+	// requests for other hosts are refused, so that pages of other sites
+	// cannot reach the server by resolving their name to its address.
+	AllowedHosts []string
+	// Token is the secret clients sending no origin, which are not
+	// browsers, present in the "token" query parameter of the WebSocket URL.
+	// A random token is generated if empty, see Server.Token.
+	Token string
+	// MaxMessageSize limits the size of a WebSocket message, 64 MiB when
+	// zero.
+	MaxMessageSize int64
+}
+
+// Server serves exposed functions to clients of the protocol. Its methods may
+// be called from any goroutine.
+type Server struct {
+	opts Options
+
+	mu         sync.RWMutex
+	functions  map[string]saucerw.BridgeHandler
+	middleware []func(saucerw.BridgeHandler) saucerw.BridgeHandler
+	sessions   map[*session]struct{}
+}
+
+// NewServer returns a Server without functions.
+func NewServer(opts Options) *Server {
+	if opts.Token == "" {
+		var secret [16]byte
+		rand.Read(secret[:])
+		opts.Token = hex.EncodeToString(secret[:])
+	}
+
+	return &Server{
+		opts:      opts,
+		functions: map[string]saucerw.BridgeHandler{},
+		sessions:  map[*session]struct{}{},
+	}
+}
+
+// Token returns the token of Options.Token, which clients without an origin
+// present to connect over a WebSocket. Hand it to them out of band, e.g. in
+// the environment of a process started with it.
+func (s *Server) Token() string {
+	return s.opts.Token
+}
+
+// Expose makes fn callable under name, see Webview.Expose.
+func (s *Server) Expose(name string, fn any) error {
+	handler, err := saucerw.ExposedHandler(fn)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.functions[name] = handler
+	return nil
+}
+
+// ExposeRaw is like Expose but passes the undecoded JSON arguments to fn, see
+// Webview.ExposeRaw.
+func (s *Server) ExposeRaw(name string, fn func(ctx context.Context, params []json.RawMessage) (any, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.functions[name] = func(ctx context.Context, call *saucerw.BridgeCall) (json.RawMessage, error) {
+		result, err := fn(ctx, call.Params)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(result)
+	}
+}
+
+// Unexpose removes the function name. Clients learn about it from the
+// not_found error of their next call.
+func (s *Server) Unexpose(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.functions, name)
+}
+
+// Use adds bridge middleware wrapping every call, see Webview.UseBridge.
+func (s *Server) Use(middleware ...func(next saucerw.BridgeHandler) saucerw.BridgeHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middleware = append(s.middleware, middleware...)
+}
+
+// Emit sends the event name with data, which has to be JSON encodable, to
+// all connected clients.
+func (s *Server) Emit(name string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("wire: %w", err)
+	}
+
+	s.mu.RLock()
+	sessions := slices.Collect(maps.Keys(s.sessions))
+	s.mu.RUnlock()
+
+	for _, sess := range sessions {
+		sess.send(&frame{Type: typeEvent, Name: name, Data: encoded})
+	}
+	return nil
+}
+
+// ServeConn serves a client on rw, a frame per line, until the client closes
+// it, ctx is canceled or a frame cannot be decoded.
+func (s *Server) ServeConn(ctx context.Context, rw io.ReadWriter) error {
+	return s.serve(ctx, newStreamTransport(rw))
+}
+
+// ServeStdio serves the process that started this one on standard input and
+// output, see ServeConn. Nothing else may write to standard output.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.ServeConn(ctx, stdio{})
+}
+
+// stdio is standard input and output, closing standard input.
+type stdio struct{}
+
+func (stdio) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (stdio) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdio) Close() error                { return os.Stdin.Close() }
+
+// ServeHTTP upgrades the request to a WebSocket and serves the client on it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.allow(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	t, err := upgrade(w, r, s.opts.MaxMessageSize)
+	if err != nil {
+		// upgrade answered the request
+		return
+	}
+
+	// The client learned about protocol errors from the error frame
+	s.serve(r.Context(), t)
+}
+
+// allow returns why the client sending r may not connect, nil if it may.
+func (s *Server) allow(r *http.Request) error {
+	if !s.allowHost(r.Host) {
+		return errors.New("wire: host not allowed")
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		token := r.URL.Query().Get("token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			return errors.New("wire: invalid token")
+		}
+		return nil
+	}
+
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return nil
+	}
+	if slices.Contains(s.opts.AllowedOrigins, "*") || slices.Contains(s.opts.AllowedOrigins, origin) {
+		return nil
+	}
+	return errors.New("wire: origin not allowed")
+}
+
+// allowHost reports whether the server may be reached at host, the Host of a
+// request: localhost, a loopback address or one of the AllowedHosts.
+func (s *Server) allowHost(host string) bool {
+	name, _, err := net.SplitHostPort(host)
+	if err != nil {
+		name = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	}
+
+	if slices.ContainsFunc(s.opts.AllowedHosts, func(allowed string) bool {
+		return strings.EqualFold(allowed, host) || strings.EqualFold(allowed, name)
+	}) {
+		return true
+	}
+
+	name = strings.ToLower(name)
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return true
+	}
+	ip, err := netip.ParseAddr(name)
+	return err == nil && ip.IsLoopback()
+}
+
+// handler returns the handler of calls, the function wrapped in the
+// middleware.
+func (s *Server) handler() saucerw.BridgeHandler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	handler := saucerw.BridgeHandler(s.invoke)
+	for _, mw := range slices.Backward(s.middleware) {
+		handler = mw(handler)
+	}
+	return handler
+}
+
+// invoke is the innermost handler, calling the exposed function.
+func (s *Server) invoke(ctx context.Context, call *saucerw.BridgeCall) (json.RawMessage, error) {
+	s.mu.RLock()
+	fn, ok := s.functions[call.Name]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, &saucerw.BridgeError{Code: saucerw.CodeNotFound, Err: fmt.Errorf("No exposed function '%s'", call.Name)}
+	}
+	return fn(ctx, call)
+}
+
+// names returns the names of the exposed functions.
+func (s *Server) names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(s.functions))
+}
+
+// negotiate returns the highest version of offered the server speaks.
+func negotiate(offered []int) (int, error) {
+	for _, v := range slices.Backward(versions) {
+		if slices.Contains(offered, v) {
+			return v, nil
+		}
+	}
+	return 0, &saucerw.BridgeError{
+		Code: saucerw.CodeInvalidArgument,
+		Err:  fmt.Errorf("no common protocol version, the server speaks %v", versions),
+	}
+}
@@ -0,0 +1,54 @@
+package wire
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllow(t *testing.T) {
+	s := NewServer(Options{
+		AllowedOrigins: []string{"http://localhost:5173"},
+		AllowedHosts:   []string{"devbox.lan:8080", "build.lan"},
+		Token:          "secret",
+	})
+
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		query  string
+		allow  bool
+	}{
+		{name: "page of the server", host: "localhost:8080", origin: "http://localhost:8080", allow: true},
+		{name: "allowed origin", host: "127.0.0.1:8080", origin: "http://localhost:5173", allow: true},
+		{name: "other origin", host: "localhost:8080", origin: "https://example.com"},
+		{name: "rebound name", host: "attacker.example:8080", origin: "http://attacker.example:8080"},
+		{name: "ipv6 loopback", host: "[::1]:8080", origin: "http://[::1]:8080", allow: true},
+		{name: "localhost subdomain", host: "app.localhost:8080", origin: "http://app.localhost:8080", allow: true},
+		{name: "allowed host", host: "devbox.lan:8080", origin: "http://devbox.lan:8080", allow: true},
+		{name: "allowed host on other port", host: "devbox.lan:9090", origin: "http://devbox.lan:9090"},
+		{name: "allowed host without port", host: "BUILD.lan:1234", origin: "http://BUILD.lan:1234", allow: true},
+		{name: "network address", host: "192.168.1.2:8080", origin: "http://192.168.1.2:8080"},
+		{name: "token", host: "localhost:8080", query: "?token=secret", allow: true},
+		{name: "no token", host: "localhost:8080"},
+		{name: "wrong token", host: "localhost:8080", query: "?token=guess"},
+		{name: "token for other host", host: "attacker.example", query: "?token=secret"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/bridge"+test.query, nil)
+			r.Host = test.host
+			if test.origin != "" {
+				r.Header.Set("Origin", test.origin)
+			}
+
+			if err := s.allow(r); (err == nil) != test.allow {
+				t.Errorf("allow returned %v, expected allowed %v", err, test.allow)
+			}
+		})
+	}
+
+	if generated := NewServer(Options{}).Token(); len(generated) != 32 {
+		t.Errorf("generated token %q", generated)
+	}
+}
@@ -0,0 +1,464 @@
+package saucer
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SBOMFormat is the format of a software bill of materials.
+type SBOMFormat string
+
+const (
+	// SBOMSPDX is SPDX 2.3 in its JSON encoding.
+	SBOMSPDX SBOMFormat = "spdx"
+	// SBOMCycloneDX is CycloneDX 1.5 in its JSON encoding.
+	SBOMCycloneDX SBOMFormat = "cyclonedx"
+)
+
+// upstreamRepository is the repository the sources are vendored from.
+const upstreamRepository = "https://github.com/saucer/saucer"
+
+// license is the license of the saucer sources.
+const license = "MIT"
+
+// noAssertion is the SPDX value for unknown information.
+const noAssertion = "NOASSERTION"
+
+// SBOMPackage is a dependency listed in a bill of materials, e.g. a C++
+// library fetched by the build.
+type SBOMPackage struct {
+	Name    string
+	Version string
+	// Repository is the git repository the package was fetched from.
+	Repository string
+	// Commit is the git commit of the fetched sources, if known.
+	Commit string
+	// License is the SPDX license expression of the package, if known.
+	License string
+}
+
+// sbomFile is a source file listed in a bill of materials.
+type sbomFile struct {
+	name    string
+	sha1    string
+	sha256  string
+	license string
+}
+
+// SBOM returns a software bill of materials of SourceFS in format, see SBOMFS.
+func SBOM(format SBOMFormat, deps ...SBOMPackage) ([]byte, error) {
+	return SBOMFS(source, format, deps...)
+}
+
+// SBOMFS returns a software bill of materials in format listing the saucer
+// sources of src, each file with its digests and the license declared by its
+// SPDX-License-Identifier, and the dependencies deps, such as the C++
+// libraries resolved by a build.
+func SBOMFS(src fs.FS, format SBOMFormat, deps ...SBOMPackage) ([]byte, error) {
+	files, err := sbomFiles(src)
+	if err != nil {
+		return nil, err
+	}
+
+	created := time.Now().UTC().Format(time.RFC3339)
+
+	var doc any
+	switch format {
+	case SBOMSPDX:
+		doc = spdxDocument(files, deps, created)
+	case SBOMCycloneDX:
+		doc = cycloneDXDocument(files, deps, created)
+	default:
+		return nil, fmt.Errorf("saucer: unknown SBOM format %q", format)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// sbomFiles digests the files of src in lexical order.
+func sbomFiles(src fs.FS) ([]sbomFile, error) {
+	var rtn []sbomFile
+
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+
+		sum1, sum256 := sha1.Sum(data), sha256.Sum256(data)
+		rtn = append(rtn, sbomFile{
+			name:    name,
+			sha1:    hex.EncodeToString(sum1[:]),
+			sha256:  hex.EncodeToString(sum256[:]),
+			license: declaredLicense(data),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rtn, nil
+}
+
+// declaredLicense returns the SPDX-License-Identifier in the head of a
+// file, empty if it declares none.
+func declaredLicense(data []byte) string {
+	const tag = "SPDX-License-Identifier:"
+
+	head := data[:min(len(data), 4096)]
+
+	i := bytes.Index(head, []byte(tag))
+	if i < 0 {
+		return ""
+	}
+
+	line, _, _ := strings.Cut(string(head[i+len(tag):]), "\n")
+	line = strings.TrimSuffix(strings.TrimSpace(line), "*/")
+	return strings.TrimSpace(line)
+}
+
+// verificationCode returns the SPDX package verification code of files.
+func verificationCode(files []sbomFile) string {
+	sums := make([]string, len(files))
+	for i, file := range files {
+		sums[i] = file.sha1
+	}
+	slices.Sort(sums)
+
+	sum := sha1.Sum([]byte(strings.Join(sums, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// purl returns the package URL of a package fetched from repository, empty
+// for repositories other than GitHub.
+func purl(repository, version string) string {
+	path, ok := strings.CutPrefix(strings.TrimSuffix(repository, ".git"), "https://github.com/")
+	if !ok {
+		return ""
+	}
+
+	rtn := "pkg:github/" + strings.ToLower(path)
+	if version != "" {
+		rtn += "@" + version
+	}
+	return rtn
+}
+
+// spdxID makes s usable in an SPDX identifier.
+func spdxID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, s)
+}
+
+// orNoAssertion returns s, or NOASSERTION if it is empty.
+func orNoAssertion(s string) string {
+	if s == "" {
+		return noAssertion
+	}
+	return s
+}
+
+type (
+	spdxDoc struct {
+		SPDXVersion       string             `json:"spdxVersion"`
+		DataLicense       string             `json:"dataLicense"`
+		SPDXID            string             `json:"SPDXID"`
+		Name              string             `json:"name"`
+		DocumentNamespace string             `json:"documentNamespace"`
+		CreationInfo      spdxCreation       `json:"creationInfo"`
+		Packages          []spdxPackage      `json:"packages"`
+		Files             []spdxFile         `json:"files"`
+		Relationships     []spdxRelationship `json:"relationships"`
+	}
+	spdxCreation struct {
+		Created  string   `json:"created"`
+		Creators []string `json:"creators"`
+	}
+	spdxPackage struct {
+		Name                  string                `json:"name"`
+		SPDXID                string                `json:"SPDXID"`
+		VersionInfo           string                `json:"versionInfo,omitempty"`
+		DownloadLocation      string                `json:"downloadLocation"`
+		FilesAnalyzed         bool                  `json:"filesAnalyzed"`
+		VerificationCode      *spdxVerificationCode `json:"packageVerificationCode,omitempty"`
+		LicenseConcluded      string                `json:"licenseConcluded"`
+		LicenseDeclared       string                `json:"licenseDeclared"`
+		LicenseInfoFromFiles  []string              `json:"licenseInfoFromFiles,omitempty"`
+		CopyrightText         string                `json:"copyrightText"`
+		ExternalRefs          []spdxExternalRef     `json:"externalRefs,omitempty"`
+		PrimaryPackagePurpose string                `json:"primaryPackagePurpose,omitempty"`
+		SourceInfo            string                `json:"sourceInfo,omitempty"`
+	}
+	spdxVerificationCode struct {
+		Value string `json:"packageVerificationCodeValue"`
+	}
+	spdxExternalRef struct {
+		Category string `json:"referenceCategory"`
+		Type     string `json:"referenceType"`
+		Locator  string `json:"referenceLocator"`
+	}
+	spdxFile struct {
+		FileName           string         `json:"fileName"`
+		SPDXID             string         `json:"SPDXID"`
+		Checksums          []spdxChecksum `json:"checksums"`
+		LicenseConcluded   string         `json:"licenseConcluded"`
+		LicenseInfoInFiles []string       `json:"licenseInfoInFiles"`
+		CopyrightText      string         `json:"copyrightText"`
+	}
+	spdxChecksum struct {
+		Algorithm string `json:"algorithm"`
+		Value     string `json:"checksumValue"`
+	}
+	spdxRelationship struct {
+		Element string `json:"spdxElementId"`
+		Type    string `json:"relationshipType"`
+		Related string `json:"relatedSpdxElement"`
+	}
+)
+
+// spdxDocument builds the SPDX document of files and deps.
+func spdxDocument(files []sbomFile, deps []SBOMPackage, created string) spdxDoc {
+	const root = "SPDXRef-Package-saucer"
+
+	code := verificationCode(files)
+
+	doc := spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "saucer-" + version,
+		DocumentNamespace: "https://github.com/aperturerobotics/saucer/spdx/saucer-" + version + "-" + code,
+		CreationInfo:      spdxCreation{Created: created, Creators: []string{"Tool: github.com/aperturerobotics/saucer"}},
+		Relationships:     []spdxRelationship{{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: root}},
+	}
+
+	var fileLicenses []string
+	for i, file := range files {
+		id := fmt.Sprintf("SPDXRef-File-%d", i+1)
+
+		doc.Files = append(doc.Files, spdxFile{
+			FileName: "./" + file.name,
+			SPDXID:   id,
+			Checksums: []spdxChecksum{
+				{Algorithm: "SHA1", Value: file.sha1},
+				{Algorithm: "SHA256", Value: file.sha256},
+			},
+			LicenseConcluded:   noAssertion,
+			LicenseInfoInFiles: []string{orNoAssertion(file.license)},
+			CopyrightText:      noAssertion,
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{Element: root, Type: "CONTAINS", Related: id})
+
+		if file.license != "" && !slices.Contains(fileLicenses, file.license) {
+			fileLicenses = append(fileLicenses, file.license)
+		}
+	}
+	slices.Sort(fileLicenses)
+
+	doc.Packages = append(doc.Packages, spdxPackage{
+		Name:                  "saucer",
+		SPDXID:                root,
+		VersionInfo:           version,
+		DownloadLocation:      "git+" + upstreamRepository + "@" + upstreamTag,
+		FilesAnalyzed:         true,
+		VerificationCode:      &spdxVerificationCode{Value: code},
+		LicenseConcluded:      license,
+		LicenseDeclared:       license,
+		LicenseInfoFromFiles:  fileLicenses,
+		CopyrightText:         noAssertion,
+		ExternalRefs:          spdxPURL(purl(upstreamRepository, upstreamTag)),
+		PrimaryPackagePurpose: "SOURCE",
+	})
+
+	for _, dep := range deps {
+		id := "SPDXRef-Package-" + spdxID(dep.Name)
+
+		location := noAssertion
+		if dep.Repository != "" {
+			location = "git+" + dep.Repository
+			if ref := cmp.Or(dep.Commit, dep.Version); ref != "" {
+				location += "@" + ref
+			}
+		}
+
+		pkg := spdxPackage{
+			Name:             dep.Name,
+			SPDXID:           id,
+			VersionInfo:      dep.Version,
+			DownloadLocation: location,
+			LicenseConcluded: orNoAssertion(dep.License),
+			LicenseDeclared:  orNoAssertion(dep.License),
+			CopyrightText:    noAssertion,
+			ExternalRefs:     spdxPURL(purl(dep.Repository, cmp.Or(dep.Commit, dep.Version))),
+		}
+		if dep.Commit != "" {
+			pkg.SourceInfo = "git commit " + dep.Commit
+		}
+
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{Element: root, Type: "DEPENDS_ON", Related: id})
+	}
+
+	return doc
+}
+
+// spdxPURL returns the external reference of the package URL p, if any.
+func spdxPURL(p string) []spdxExternalRef {
+	if p == "" {
+		return nil
+	}
+	return []spdxExternalRef{{Category: "PACKAGE-MANAGER", Type: "purl", Locator: p}}
+}
+
+type (
+	cdxDoc struct {
+		BOMFormat    string          `json:"bomFormat"`
+		SpecVersion  string          `json:"specVersion"`
+		SerialNumber string          `json:"serialNumber"`
+		Version      int             `json:"version"`
+		Metadata     cdxMetadata     `json:"metadata"`
+		Components   []cdxComponent  `json:"components"`
+		Dependencies []cdxDependency `json:"dependencies"`
+	}
+	cdxMetadata struct {
+		Timestamp string       `json:"timestamp"`
+		Tools     cdxTools     `json:"tools"`
+		Component cdxComponent `json:"component"`
+	}
+	cdxTools struct {
+		Components []cdxComponent `json:"components"`
+	}
+	cdxComponent struct {
+		Type       string         `json:"type"`
+		BOMRef     string         `json:"bom-ref,omitempty"`
+		Name       string         `json:"name"`
+		Version    string         `json:"version,omitempty"`
+		Hashes     []cdxHash      `json:"hashes,omitempty"`
+		Licenses   []cdxLicense   `json:"licenses,omitempty"`
+		PURL       string         `json:"purl,omitempty"`
+		References []cdxExternal  `json:"externalReferences,omitempty"`
+		Components []cdxComponent `json:"components,omitempty"`
+	}
+	cdxHash struct {
+		Alg     string `json:"alg"`
+		Content string `json:"content"`
+	}
+	cdxLicense struct {
+		Expression string `json:"expression"`
+	}
+	cdxExternal struct {
+		Type    string `json:"type"`
+		URL     string `json:"url"`
+		Comment string `json:"comment,omitempty"`
+	}
+	cdxDependency struct {
+		Ref       string   `json:"ref"`
+		DependsOn []string `json:"dependsOn,omitempty"`
+	}
+)
+
+// cycloneDXDocument builds the CycloneDX document of files and deps.
+func cycloneDXDocument(files []sbomFile, deps []SBOMPackage, created string) cdxDoc {
+	const root = "saucer"
+
+	code := verificationCode(files)
+
+	pkg := cdxComponent{
+		Type:       "library",
+		BOMRef:     root,
+		Name:       "saucer",
+		Version:    version,
+		Licenses:   []cdxLicense{{Expression: license}},
+		PURL:       purl(upstreamRepository, upstreamTag),
+		References: []cdxExternal{{Type: "vcs", URL: upstreamRepository}},
+	}
+
+	for _, file := range files {
+		component := cdxComponent{
+			Type:   "file",
+			BOMRef: "file:" + file.name,
+			Name:   file.name,
+			Hashes: []cdxHash{{Alg: "SHA-1", Content: file.sha1}, {Alg: "SHA-256", Content: file.sha256}},
+		}
+		if file.license != "" {
+			component.Licenses = []cdxLicense{{Expression: file.license}}
+		}
+		pkg.Components = append(pkg.Components, component)
+	}
+
+	doc := cdxDoc{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid(code+created),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: created,
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "github.com/aperturerobotics/saucer"}}},
+			Component: pkg,
+		},
+		Components: []cdxComponent{},
+	}
+
+	dependency := cdxDependency{Ref: root}
+	for _, dep := range deps {
+		ref := "pkg:" + dep.Name
+
+		component := cdxComponent{
+			Type:    "library",
+			BOMRef:  ref,
+			Name:    dep.Name,
+			Version: dep.Version,
+			PURL:    purl(dep.Repository, cmp.Or(dep.Commit, dep.Version)),
+		}
+		if dep.License != "" {
+			component.Licenses = []cdxLicense{{Expression: dep.License}}
+		}
+		if dep.Repository != "" {
+			ext := cdxExternal{Type: "vcs", URL: dep.Repository}
+			if dep.Commit != "" {
+				ext.Comment = "commit " + dep.Commit
+			}
+			component.References = []cdxExternal{ext}
+		}
+
+		doc.Components = append(doc.Components, component)
+		dependency.DependsOn = append(dependency.DependsOn, ref)
+	}
+
+	doc.Dependencies = []cdxDependency{dependency}
+	return doc
+}
+
+// uuid derives a version 4 formatted UUID from seed.
+func uuid(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+
+	b := sum[:16]
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
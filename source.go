@@ -0,0 +1,184 @@
+package saucer
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Per-backend and per-serializer source trees. A variable is empty when its
+// part was excluded by build tags, see SourceFS.
+var (
+	// SourceCore holds the backend independent saucer C++ source files.
+	SourceCore fs.FS = sourceCore
+	// SourceQt6 holds the Qt 6 backend sources.
+	SourceQt6 fs.FS = embed.FS{}
+	// SourceWebKitGTK holds the WebKitGTK backend sources.
+	SourceWebKitGTK fs.FS = embed.FS{}
+	// SourceWebView2 holds the Microsoft Edge WebView2 backend sources.
+	SourceWebView2 fs.FS = embed.FS{}
+	// SourceWKWebView holds the macOS WKWebView backend sources.
+	SourceWKWebView fs.FS = embed.FS{}
+	// SourceRflpp holds the sources of the rfl++ serializer. Glaze, the
+	// default serializer, is part of SourceCore.
+	SourceRflpp fs.FS = embed.FS{}
+)
+
+// Source embeds the saucer C++ source files for Go vendoring, all backends
+// and serializers regardless of build tags.
+//
+// Source is uncompressed and unaffected by the build tags and the
+// compression of SourceFS: any binary referring to it carries the full
+// tree, several times the size of the compressed one. It stays an embed.FS
+// for callers relying on its type; use SourceFS, the documented entry point
+// to the sources, everywhere else.
+//
+//go:embed CMakeLists.txt
+//go:embed cmake/*.cmake
+//go:embed cmake/toolchain/*.cmake
+//go:embed cmake/toolchain/*.hpp
+//go:embed include/saucer/*.hpp
+//go:embed include/saucer/*.inl
+//go:embed include/saucer/error/*.hpp
+//go:embed include/saucer/error/*.inl
+//go:embed include/saucer/modules/*.hpp
+//go:embed include/saucer/modules/stable/*.hpp
+//go:embed include/saucer/serializers/*.hpp
+//go:embed include/saucer/serializers/*.inl
+//go:embed include/saucer/serializers/format/*.hpp
+//go:embed include/saucer/serializers/format/*.inl
+//go:embed include/saucer/serializers/glaze/*.hpp
+//go:embed include/saucer/serializers/glaze/*.inl
+//go:embed include/saucer/serializers/rflpp/*.hpp
+//go:embed include/saucer/serializers/rflpp/*.inl
+//go:embed include/saucer/stash/*.hpp
+//go:embed include/saucer/stash/*.inl
+//go:embed include/saucer/traits/*.hpp
+//go:embed include/saucer/traits/*.inl
+//go:embed include/saucer/utils/*.hpp
+//go:embed include/saucer/utils/*.inl
+//go:embed private/saucer/*.hpp
+//go:embed private/saucer/*.inl
+//go:embed src/*.cpp
+//go:embed src/*.mm
+//go:embed src/module/*.cpp
+//go:embed src/module/*.mm
+//go:embed template/*.in
+var Source embed.FS
+
+// source is the tree of SourceFS.
+var source fs.FS = SourceCore
+
+// SourceFS returns the saucer C++ source tree for Go vendoring: SourceCore
+// merged with the sources of every backend compiled into this binary.
+//
+// All backends are embedded by default. Setting one or more of the build tags
+// saucer_qt6, saucer_webkitgtk, saucer_webview2 and saucer_wkwebview restricts
+// the embedded backends to the tagged ones. The saucer_native build tag
+// restricts them to the native backend of the target GOOS instead, dropping
+// the Objective-C++ sources of WKWebView from non-Darwin binaries.
+//
+// The saucer_no_rflpp build tag excludes the rfl++ serializer.
+//
+// SourceManifest and SourceForTarget only report the parts embedded.
+//
+// The trees are embedded as compressed archives, generated by go generate,
+// and inflated file by file when read. The saucer_raw build tag embeds the
+// files from the source tree uncompressed instead.
+func SourceFS() fs.FS {
+	return source
+}
+
+// Canonical backend names accepted by SourceForTarget.
+const (
+	backendQt6       = "qt6"
+	backendWebKitGTK = "webkitgtk"
+	backendWebView2  = "webview2"
+	backendWKWebView = "wkwebview"
+)
+
+// backendAliases maps accepted spellings, including the CMake backend names,
+// to the canonical backend names.
+var backendAliases = map[string]string{
+	"qt":        backendQt6,
+	"qt6":       backendQt6,
+	"webkitgtk": backendWebKitGTK,
+	"gtk":       backendWebKitGTK,
+	"webview2":  backendWebView2,
+	"wkwebview": backendWKWebView,
+	"webkit":    backendWKWebView,
+}
+
+// backendOS lists the only GOOS a backend can target, if restricted.
+var backendOS = map[string]string{
+	backendWebView2:  "windows",
+	backendWKWebView: "darwin",
+}
+
+// backends holds the backend trees compiled into this binary.
+var backends = map[string]fs.FS{}
+
+// registerBackend records an embedded backend tree and adds it to SourceFS.
+func registerBackend(name string, fsys fs.FS) {
+	backends[name] = fsys
+	source = union(source, fsys)
+}
+
+// serializers holds the serializer trees compiled into this binary, beyond
+// the glaze sources of SourceCore.
+var serializers []fs.FS
+
+// registerSerializer records an embedded serializer tree and adds it to
+// SourceFS.
+func registerSerializer(fsys fs.FS) {
+	serializers = append(serializers, fsys)
+	source = union(source, fsys)
+}
+
+// SourceForTarget returns the source tree needed to build saucer for goos
+// with the given backend: SourceCore merged with the embedded serializer and
+// the backend sources.
+//
+// An empty backend or "default" selects the native backend of goos. Backend
+// names are case insensitive and include the CMake spellings ("WebKitGtk",
+// "WebKit", "Qt"). Qt 5 is not supported by this saucer release.
+func SourceForTarget(goos, backend string) (fs.FS, error) {
+	name := strings.ToLower(backend)
+
+	switch name {
+	case "", "default":
+		name = defaultBackend(goos)
+	case "qt5":
+		return nil, fmt.Errorf("saucer: backend %q is not supported by this saucer release, use qt6", backend)
+	}
+
+	canonical, ok := backendAliases[name]
+	if !ok {
+		return nil, fmt.Errorf("saucer: unknown backend %q", backend)
+	}
+
+	if want, ok := backendOS[canonical]; ok && goos != want {
+		return nil, fmt.Errorf("saucer: backend %q cannot target %s", backend, goos)
+	}
+
+	fsys, ok := backends[canonical]
+	if !ok {
+		return nil, fmt.Errorf("saucer: backend %q was excluded from this build, add -tags saucer_%s", backend, canonical)
+	}
+
+	layers := append([]fs.FS{SourceCore}, serializers...)
+	return union(append(layers, fsys)...), nil
+}
+
+// defaultBackend mirrors the default backend selection of the CMake project.
+func defaultBackend(goos string) string {
+	switch goos {
+	case "windows":
+		return backendWebView2
+	case "darwin":
+		return backendWKWebView
+	default:
+		return backendWebKitGTK
+	}
+}
@@ -0,0 +1,17 @@
+//go:build saucer_raw && (saucer_qt6 || !(saucer_webkitgtk || saucer_webview2 || saucer_wkwebview || saucer_native))
+
+package saucer
+
+import "embed"
+
+//go:embed src/qt.*.cpp
+//go:embed src/module/qt.*.cpp
+//go:embed private/saucer/qt.*.hpp
+//go:embed private/saucer/qt.*.inl
+//go:embed include/saucer/modules/stable/qt.hpp
+var sourceQt6 embed.FS
+
+func init() {
+	SourceQt6 = sourceQt6
+	registerBackend(backendQt6, sourceQt6)
+}
@@ -0,0 +1,15 @@
+//go:build !saucer_raw && (saucer_qt6 || !(saucer_webkitgtk || saucer_webview2 || saucer_wkwebview || saucer_native))
+
+package saucer
+
+import _ "embed"
+
+//go:embed zz_source_qt6.zip
+var sourceQt6Zip []byte
+
+func init() {
+	fsys := newCompressedFS(sourceQt6Zip)
+
+	SourceQt6 = fsys
+	registerBackend(backendQt6, fsys)
+}
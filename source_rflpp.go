@@ -0,0 +1,15 @@
+//go:build saucer_raw && !saucer_no_rflpp
+
+package saucer
+
+import "embed"
+
+//go:embed include/saucer/serializers/rflpp/*.hpp
+//go:embed include/saucer/serializers/rflpp/*.inl
+//go:embed src/rfl.*.cpp
+var sourceRflpp embed.FS
+
+func init() {
+	SourceRflpp = sourceRflpp
+	registerSerializer(sourceRflpp)
+}
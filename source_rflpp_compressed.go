@@ -0,0 +1,15 @@
+//go:build !saucer_raw && !saucer_no_rflpp
+
+package saucer
+
+import _ "embed"
+
+//go:embed zz_source_rflpp.zip
+var sourceRflppZip []byte
+
+func init() {
+	fsys := newCompressedFS(sourceRflppZip)
+
+	SourceRflpp = fsys
+	registerSerializer(fsys)
+}
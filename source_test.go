@@ -0,0 +1,66 @@
+package saucer
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"testing"
+)
+
+// Source stays an embed.FS for the callers relying on its type.
+var _ embed.FS = Source
+
+// TestSourceFS checks that the files of SourceFS, selected by build tags and
+// compressed, are those of Source.
+func TestSourceFS(t *testing.T) {
+	fsys := SourceFS()
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		want, err := Source.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from Source", name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// BenchmarkSourceRead reads every file of SourceFS. Run it with and without the
+// saucer_raw tag to compare compressed and uncompressed embedding.
+func BenchmarkSourceRead(b *testing.B) {
+	var size int
+	fsys := SourceFS()
+
+	for b.Loop() {
+		size = 0
+
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			data, err := fs.ReadFile(fsys, name)
+			size += len(data)
+
+			return err
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(size), "source-bytes")
+}
@@ -0,0 +1,19 @@
+//go:build saucer_raw && (saucer_webkitgtk || (!(saucer_qt6 || saucer_webview2 || saucer_wkwebview) && (!saucer_native || (!windows && !darwin))))
+
+package saucer
+
+import "embed"
+
+//go:embed src/gtk.*.cpp
+//go:embed src/wkg.*.cpp
+//go:embed src/module/wkg.*.cpp
+//go:embed private/saucer/gtk.*.hpp
+//go:embed private/saucer/gtk.*.inl
+//go:embed private/saucer/wkg.*.hpp
+//go:embed include/saucer/modules/stable/webkitgtk.hpp
+var sourceWebKitGTK embed.FS
+
+func init() {
+	SourceWebKitGTK = sourceWebKitGTK
+	registerBackend(backendWebKitGTK, sourceWebKitGTK)
+}
@@ -0,0 +1,15 @@
+//go:build !saucer_raw && (saucer_webkitgtk || (!(saucer_qt6 || saucer_webview2 || saucer_wkwebview) && (!saucer_native || (!windows && !darwin))))
+
+package saucer
+
+import _ "embed"
+
+//go:embed zz_source_webkitgtk.zip
+var sourceWebKitGTKZip []byte
+
+func init() {
+	fsys := newCompressedFS(sourceWebKitGTKZip)
+
+	SourceWebKitGTK = fsys
+	registerBackend(backendWebKitGTK, fsys)
+}
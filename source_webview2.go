@@ -0,0 +1,18 @@
+//go:build saucer_raw && (saucer_webview2 || (!(saucer_qt6 || saucer_webkitgtk || saucer_wkwebview) && (!saucer_native || windows)))
+
+package saucer
+
+import "embed"
+
+//go:embed src/win32.*.cpp
+//go:embed src/wv2.*.cpp
+//go:embed src/module/wv2.*.cpp
+//go:embed private/saucer/win32.*.hpp
+//go:embed private/saucer/wv2.*.hpp
+//go:embed include/saucer/modules/stable/webview2.hpp
+var sourceWebView2 embed.FS
+
+func init() {
+	SourceWebView2 = sourceWebView2
+	registerBackend(backendWebView2, sourceWebView2)
+}
@@ -0,0 +1,15 @@
+//go:build !saucer_raw && (saucer_webview2 || (!(saucer_qt6 || saucer_webkitgtk || saucer_wkwebview) && (!saucer_native || windows)))
+
+package saucer
+
+import _ "embed"
+
+//go:embed zz_source_webview2.zip
+var sourceWebView2Zip []byte
+
+func init() {
+	fsys := newCompressedFS(sourceWebView2Zip)
+
+	SourceWebView2 = fsys
+	registerBackend(backendWebView2, fsys)
+}
@@ -0,0 +1,19 @@
+//go:build saucer_raw && (saucer_wkwebview || (!(saucer_qt6 || saucer_webkitgtk || saucer_webview2) && (!saucer_native || darwin)))
+
+package saucer
+
+import "embed"
+
+//go:embed src/cocoa.*.mm
+//go:embed src/wk.*.mm
+//go:embed src/module/wk.*.mm
+//go:embed private/saucer/cocoa.*.hpp
+//go:embed private/saucer/cocoa.*.inl
+//go:embed private/saucer/wk.*.hpp
+//go:embed include/saucer/modules/stable/webkit.hpp
+var sourceWKWebView embed.FS
+
+func init() {
+	SourceWKWebView = sourceWKWebView
+	registerBackend(backendWKWebView, sourceWKWebView)
+}
@@ -0,0 +1,15 @@
+//go:build !saucer_raw && (saucer_wkwebview || (!(saucer_qt6 || saucer_webkitgtk || saucer_webview2) && (!saucer_native || darwin)))
+
+package saucer
+
+import _ "embed"
+
+//go:embed zz_source_wkwebview.zip
+var sourceWKWebViewZip []byte
+
+func init() {
+	fsys := newCompressedFS(sourceWKWebViewZip)
+
+	SourceWKWebView = fsys
+	registerBackend(backendWKWebView, fsys)
+}
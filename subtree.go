@@ -0,0 +1,122 @@
+package saucer
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// Roots of the parts of the source tree, relative to SourceFS.
+const (
+	includeRoot  = "include"
+	privateRoot  = "private"
+	sourceRoot   = "src"
+	templateRoot = "template"
+	cmakeRoot    = "cmake"
+	cmakeLists   = "CMakeLists.txt"
+)
+
+// Includes returns the public headers of SourceFS, rooted like the include
+// path of the build: "saucer/app.hpp" is the header included as
+// <saucer/app.hpp>.
+func Includes() fs.FS {
+	return subtree(includeRoot)
+}
+
+// PrivateIncludes returns the internal headers of SourceFS, rooted like
+// Includes. They are needed to compile Sources but not to use the library.
+func PrivateIncludes() fs.FS {
+	return subtree(privateRoot)
+}
+
+// Sources returns the C++ translation units of SourceFS, e.g. "app.cpp".
+func Sources() fs.FS {
+	return subtree(sourceRoot)
+}
+
+// Templates returns the files the CMake project configures, e.g.
+// "config.hpp.in".
+func Templates() fs.FS {
+	return subtree(templateRoot)
+}
+
+// CMakeFiles returns the CMake project of SourceFS: the top-level
+// CMakeLists.txt and the cmake directory of modules and toolchains, at the
+// same paths as in SourceFS.
+func CMakeFiles() fs.FS {
+	return &selectFS{fsys: source, roots: []string{cmakeLists, cmakeRoot}}
+}
+
+// subtree returns the directory dir of SourceFS.
+func subtree(dir string) fs.FS {
+	sub, err := fs.Sub(source, dir)
+	if err != nil {
+		// fs.Sub only fails for invalid paths
+		panic(err)
+	}
+	return sub
+}
+
+// selectFS exposes the files of fsys at or below roots, which are top-level
+// names of fsys.
+type selectFS struct {
+	fsys  fs.FS
+	roots []string
+}
+
+// selected reports whether name is exposed.
+func (s *selectFS) selected(name string) bool {
+	top, _, _ := strings.Cut(name, "/")
+	return slices.Contains(s.roots, top)
+}
+
+// Open implements fs.FS.
+func (s *selectFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name != "." {
+		if !s.selected(name) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return s.fsys.Open(name)
+	}
+
+	info, err := fs.Stat(s.fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	return &unionDir{info: info, entries: entries}, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (s *selectFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) || !s.selected(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return fs.ReadFile(s.fsys, name)
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (s *selectFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		if !fs.ValidPath(name) || !s.selected(name) {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+		}
+		return fs.ReadDir(s.fsys, name)
+	}
+
+	entries, err := fs.ReadDir(s.fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(entries, func(entry fs.DirEntry) bool { return !s.selected(entry.Name()) }), nil
+}
@@ -0,0 +1,188 @@
+package saucer
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Template is a file of the source tree the CMake project configures with
+// configure_file.
+type Template struct {
+	// Name is the slash-separated path of the template, e.g.
+	// "template/config.hpp.in".
+	Name string
+	// Output is the slash-separated path CMake writes the configured file to,
+	// relative to the tree, e.g. "include/saucer/config.hpp".
+	Output string
+	// AtOnly reports whether configure_file is passed @ONLY, substituting
+	// only @VAR@ references and leaving ${VAR} as is.
+	AtOnly bool
+}
+
+// TemplateVars are the CMake variables substituted into a template by name.
+// Values are strings, bools, which render as ON and OFF like option(),
+// integers or string slices, which render as CMake lists joined by ";".
+// Undefined variables render empty, as in CMake.
+type TemplateVars map[string]any
+
+// configureFile matches the configure_file calls of CMakeLists.txt.
+var configureFile = regexp.MustCompile(`configure_file\(\s*"?([^"\s)]+)"?\s+"?([^"\s)]+)"?([^)]*)\)`)
+
+// sourceDirVars are the variables a configure_file output may start with
+// that refer to the root of the tree.
+var sourceDirVars = []string{"${CMAKE_CURRENT_SOURCE_DIR}/", "${PROJECT_SOURCE_DIR}/", "${CMAKE_SOURCE_DIR}/"}
+
+// ListTemplates returns the templates of SourceFS, sorted by name.
+func ListTemplates() ([]Template, error) {
+	return ListTemplatesFS(source)
+}
+
+// ListTemplatesFS returns the .in files below the template directory of src,
+// sorted by name. Their outputs and options are read from the configure_file
+// calls of CMakeLists.txt, templates it does not configure are written next
+// to themselves without the .in suffix.
+func ListTemplatesFS(src fs.FS) ([]Template, error) {
+	names, err := fs.Glob(src, templateRoot+"/*.in")
+	if err != nil {
+		return nil, err
+	}
+
+	calls := map[string]Template{}
+	if lists, err := fs.ReadFile(src, cmakeLists); err == nil {
+		for _, match := range configureFile.FindAllStringSubmatch(string(lists), -1) {
+			output := match[2]
+			for _, prefix := range sourceDirVars {
+				output = strings.TrimPrefix(output, prefix)
+			}
+			calls[match[1]] = Template{
+				Name:   match[1],
+				Output: output,
+				AtOnly: slices.Contains(strings.Fields(match[3]), "@ONLY"),
+			}
+		}
+	}
+
+	rtn := make([]Template, 0, len(names))
+	for _, name := range names {
+		tmpl, ok := calls[name]
+		if !ok || strings.Contains(tmpl.Output, "$") || path.IsAbs(tmpl.Output) {
+			tmpl = Template{Name: name, Output: strings.TrimSuffix(name, ".in")}
+		}
+		rtn = append(rtn, tmpl)
+	}
+	slices.SortFunc(rtn, func(a, b Template) int { return strings.Compare(a.Name, b.Name) })
+	return rtn, nil
+}
+
+// Render reads the template from src and renders it with vars.
+func (t Template) Render(src fs.FS, vars TemplateVars) ([]byte, error) {
+	data, err := fs.ReadFile(src, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	return render(data, vars, t.AtOnly)
+}
+
+// RenderTemplate renders data like CMake's configure_file: @VAR@ and ${VAR}
+// are replaced by the value of VAR, "#cmakedefine VAR ..." becomes
+// "#define VAR ..." if VAR is set to a true value and "/* #undef VAR */"
+// otherwise, and "#cmakedefine01 VAR" becomes "#define VAR 1" or
+// "#define VAR 0".
+func RenderTemplate(data []byte, vars TemplateVars) ([]byte, error) {
+	return render(data, vars, false)
+}
+
+var (
+	// cmakeDefine matches a #cmakedefine line, see RenderTemplate.
+	cmakeDefine = regexp.MustCompile(`^(\s*#\s*)cmakedefine(01)?[ \t]+([A-Za-z0-9_]+)(.*)$`)
+	// atVar matches an @VAR@ reference.
+	atVar = regexp.MustCompile(`@([A-Za-z0-9_./+-]+)@`)
+	// anyVar matches an @VAR@ or ${VAR} reference.
+	anyVar = regexp.MustCompile(`@([A-Za-z0-9_./+-]+)@|\$\{([A-Za-z0-9_./+-]+)\}`)
+)
+
+// render renders data, substituting only @VAR@ references if atOnly is set.
+func render(data []byte, vars TemplateVars, atOnly bool) ([]byte, error) {
+	values := make(map[string]string, len(vars))
+	for name, value := range vars {
+		str, err := templateValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("saucer: template variable %s: %w", name, err)
+		}
+		values[name] = str
+	}
+
+	refs := anyVar
+	if atOnly {
+		refs = atVar
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for i, line := range lines {
+		body := bytes.TrimRight(line, "\r\n")
+		end := line[len(body):]
+
+		if m := cmakeDefine.FindSubmatch(body); m != nil {
+			name, value := string(m[3]), values[string(m[3])]
+			switch {
+			case len(m[2]) > 0 && isOff(value):
+				body = fmt.Appendf(nil, "%sdefine %s 0", m[1], name)
+			case len(m[2]) > 0:
+				body = fmt.Appendf(nil, "%sdefine %s 1", m[1], name)
+			case isOff(value):
+				body = fmt.Appendf(nil, "/* #undef %s */", name)
+			default:
+				body = fmt.Appendf(nil, "%sdefine %s%s", m[1], name, m[4])
+			}
+		}
+
+		body = refs.ReplaceAllFunc(body, func(ref []byte) []byte {
+			name := strings.Trim(string(ref), "@${}")
+			return []byte(values[name])
+		})
+		lines[i] = append(body, end...)
+	}
+	return bytes.Join(lines, nil), nil
+}
+
+// templateValue returns the CMake string of a template variable value.
+func templateValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "ON", nil
+		}
+		return "OFF", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case []string:
+		return strings.Join(v, ";"), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("unsupported type %T", value)
+}
+
+// isOff reports whether value is false in CMake: empty, 0, OFF, NO, FALSE,
+// N, IGNORE, NOTFOUND or ending in -NOTFOUND, ignoring case.
+func isOff(value string) bool {
+	switch strings.ToUpper(value) {
+	case "", "0", "OFF", "NO", "FALSE", "N", "IGNORE", "NOTFOUND":
+		return true
+	}
+	return strings.HasSuffix(strings.ToUpper(value), "-NOTFOUND")
+}
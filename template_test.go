@@ -0,0 +1,113 @@
+package saucer
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+// semver is a fmt.Stringer template variable.
+type semver struct{ major, minor int }
+
+func (v semver) String() string { return fmt.Sprintf("%d.%d", v.major, v.minor) }
+
+func TestRenderTemplate(t *testing.T) {
+	vars := TemplateVars{
+		"NAME":     "saucer",
+		"ENABLED":  true,
+		"DISABLED": false,
+		"COUNT":    8,
+		"BIG":      int64(1) << 40,
+		"SIZE":     uint(3),
+		"ID":       uint64(7),
+		"LIST":     []string{"a", "b", "c"},
+		"VERSION":  semver{7, 1},
+		"EMPTY":    "",
+		"MISSING":  "Qt6-NOTFOUND",
+		"path/x+y": "dotted",
+	}
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"at", "name @NAME@ @COUNT@\n", "name saucer 8\n"},
+		{"dollar", "name ${NAME} ${LIST}\n", "name saucer a;b;c\n"},
+		{"values", "@ENABLED@ @DISABLED@ @BIG@ @SIZE@ @ID@ @VERSION@\n", "ON OFF 1099511627776 3 7 7.1\n"},
+		{"undefined", "[@UNDEFINED@] [${UNDEFINED}]\n", "[] []\n"},
+		{"name characters", "@path/x+y@\n", "dotted\n"},
+		{"not a reference", "email@example.com $NAME {NAME} @ NAME@\n", "email@example.com $NAME {NAME} @ NAME@\n"},
+		{"define", "#cmakedefine ENABLED\n", "#define ENABLED\n"},
+		{"define value", "#cmakedefine NAME \"@NAME@\"\n", "#define NAME \"saucer\"\n"},
+		{"define dollar value", "#cmakedefine COUNT ${COUNT}\n", "#define COUNT 8\n"},
+		{"define spacing", "  #  cmakedefine ENABLED 1\n", "  #  define ENABLED 1\n"},
+		{"undef", "#cmakedefine DISABLED\n", "/* #undef DISABLED */\n"},
+		{"undef undefined", "#cmakedefine UNDEFINED value\n", "/* #undef UNDEFINED */\n"},
+		{"undef notfound", "  #cmakedefine MISSING\n", "/* #undef MISSING */\n"},
+		{"01 on", "#cmakedefine01 ENABLED\n", "#define ENABLED 1\n"},
+		{"01 off", "#cmakedefine01 DISABLED\n", "#define DISABLED 0\n"},
+		{"01 empty", "# cmakedefine01 EMPTY\n", "# define EMPTY 0\n"},
+		{"01 string", "#cmakedefine01 NAME\n", "#define NAME 1\n"},
+		{"01 number", "#cmakedefine01 COUNT trailing\n", "#define COUNT 1\n"},
+		{"not a define", "// #cmakedefine ENABLED\n#cmakedefineENABLED\n", "// #cmakedefine ENABLED\n#cmakedefineENABLED\n"},
+		{"line endings", "#cmakedefine01 ENABLED\r\n@NAME@\r\nlast @COUNT@", "#define ENABLED 1\r\nsaucer\r\nlast 8"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := RenderTemplate([]byte(test.in), vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("rendered %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateInvalid(t *testing.T) {
+	_, err := RenderTemplate([]byte("@X@\n"), TemplateVars{"X": 1.5})
+	if err == nil || err.Error() != "saucer: template variable X: unsupported type float64" {
+		t.Errorf("error %v", err)
+	}
+}
+
+// TestTemplateRender checks that templates configured with @ONLY leave
+// ${VAR} as is.
+func TestTemplateRender(t *testing.T) {
+	src := fstest.MapFS{"template/config.hpp.in": {Data: []byte("#cmakedefine01 ON\n@NAME@ ${NAME}\n")}}
+	vars := TemplateVars{"ON": true, "NAME": "saucer"}
+
+	for _, test := range []struct {
+		atOnly bool
+		want   string
+	}{
+		{false, "#define ON 1\nsaucer saucer\n"},
+		{true, "#define ON 1\nsaucer ${NAME}\n"},
+	} {
+		tmpl := Template{Name: "template/config.hpp.in", AtOnly: test.atOnly}
+		got, err := tmpl.Render(src, vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("AtOnly %v: rendered %q, expected %q", test.atOnly, got, test.want)
+		}
+	}
+}
+
+func TestIsOff(t *testing.T) {
+	off := []string{"", "0", "OFF", "off", "No", "FALSE", "false", "N", "n", "IGNORE", "NOTFOUND", "notfound", "Qt6-NOTFOUND", "lib-notfound"}
+	on := []string{"1", "ON", "YES", "TRUE", "Y", "2", "00", "saucer", "NOTFOUND-lib", "OFFLINE", " OFF"}
+
+	for _, value := range off {
+		if !isOff(value) {
+			t.Errorf("%q is on, expected off", value)
+		}
+	}
+	for _, value := range on {
+		if isOff(value) {
+			t.Errorf("%q is off, expected on", value)
+		}
+	}
+}
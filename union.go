@@ -0,0 +1,146 @@
+package saucer
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// unionFS merges several file systems. Files are looked up in layer order,
+// directories present in more than one layer are merged.
+type unionFS []fs.FS
+
+// union merges layers into a single file system, flattening nested unions.
+func union(layers ...fs.FS) fs.FS {
+	var rtn unionFS
+
+	for _, layer := range layers {
+		if nested, ok := layer.(unionFS); ok {
+			rtn = append(rtn, nested...)
+			continue
+		}
+		rtn = append(rtn, layer)
+	}
+
+	return rtn
+}
+
+// Open implements fs.FS.
+func (u unionFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	for _, layer := range u {
+		f, err := layer.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := f.Stat()
+		if err != nil || !info.IsDir() {
+			return f, err
+		}
+		_ = f.Close()
+
+		entries, err := u.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &unionDir{info: info, entries: entries}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (u unionFS) ReadFile(name string) ([]byte, error) {
+	for _, layer := range u {
+		data, err := fs.ReadFile(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return data, err
+	}
+
+	return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (u unionFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var (
+		found   bool
+		entries []fs.DirEntry
+		seen    = map[string]bool{}
+	)
+
+	for _, layer := range u {
+		layerEntries, err := fs.ReadDir(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found = true
+
+		for _, entry := range layerEntries {
+			if seen[entry.Name()] {
+				continue
+			}
+
+			seen[entry.Name()] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// unionDir is a merged directory opened from a unionFS.
+type unionDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *unionDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *unionDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+func (d *unionDir) Close() error {
+	return nil
+}
+
+func (d *unionDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	rest = rest[:min(n, len(rest))]
+	d.offset += len(rest)
+
+	return rest, nil
+}
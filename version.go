@@ -0,0 +1,27 @@
+package saucer
+
+import "time"
+
+// Version returns the version of the vendored saucer release, as declared by
+// its CMake project.
+func Version() string {
+	return version
+}
+
+// UpstreamTag returns the upstream git tag the sources were vendored from.
+func UpstreamTag() string {
+	return upstreamTag
+}
+
+// UpstreamCommit returns the upstream git commit the sources were vendored
+// from, or an empty string if it was not recorded.
+func UpstreamCommit() string {
+	return upstreamCommit
+}
+
+// ReleaseDate returns the commit date of the upstream release the sources
+// were vendored from, or the zero time if it was not recorded.
+func ReleaseDate() time.Time {
+	t, _ := time.Parse(time.RFC3339, upstreamDate)
+	return t
+}
@@ -0,0 +1,186 @@
+// Code generated by genmanifest. DO NOT EDIT.
+
+package saucer
+
+var manifestEntries = Manifest{
+	"CMakeLists.txt":                                 {Size: 20588, SHA256: "67072fa4420978b04974dd5a84366a466ce396f02f174402cd7c911427636fb9"},
+	"cmake/cpm.cmake":                                {Size: 961, SHA256: "b617838dd40e15965fef053de006a96c24f5e2a9d8df7b450c1656c71c7f6c92"},
+	"cmake/module.cmake":                             {Size: 1390, SHA256: "757e99be362f656bb627b3fc51d5cd38fa8277281b89efea0f50c8e89c1808b2"},
+	"cmake/nuget.cmake":                              {Size: 3340, SHA256: "f5d552301e921918c879366765f710bae98b69a8f3d30f9fad2d44e71b351e79"},
+	"cmake/toolchain/zig.cmake":                      {Size: 363, SHA256: "fb66086eb4a670a3b5d567da8a187a88e82535a3f3e47b8801ca141d74e846b1"},
+	"cmake/toolchain/zig.fix.hpp":                    {Size: 57, SHA256: "0dab407711f87beb15ad59cc78ccbb29de1648faac2ec6781aa82e7df84bc2dd"},
+	"include/saucer/app.hpp":                         {Size: 3098, SHA256: "7fcd62b4ca39cf84294deb5479c89e7106d21725a548a4731b2932cdfa06d6c7"},
+	"include/saucer/app.inl":                         {Size: 2235, SHA256: "100f76c49a439035f83126ec665318ccab8756a9a304eab6b5a0a751a78b6430"},
+	"include/saucer/error/error.hpp":                 {Size: 1631, SHA256: "099b49d5f6540809e6fdea54a7057bd29bf9f2b810bd5c83d23a4ad946c8c51c"},
+	"include/saucer/error/error.inl":                 {Size: 1608, SHA256: "8253a937cf378dcff16211c666da2b6fd276e55e2e42a4d22008ee12360912a5"},
+	"include/saucer/executor.hpp":                    {Size: 707, SHA256: "16100f076da05538ec403d78a39ce4a36b61f0477690b06d62f87cd17e40cd9b"},
+	"include/saucer/icon.hpp":                        {Size: 1012, SHA256: "76e7e593254a206970952c2a72c43e952167628ad8a4722aa29fbaf1c66c2f1d"},
+	"include/saucer/modules/module.hpp":              {Size: 259, SHA256: "25c0ef3261e5c4ffe1d7e4eb7a864ecb7c39002ed938834e4926576e4612b05e"},
+	"include/saucer/modules/stable/qt.hpp":           {Size: 799, SHA256: "3643fc48e19e0c587e5be67a31842006fa3b575784630e8373a197bc4a7bb92f"},
+	"include/saucer/modules/stable/webkit.hpp":       {Size: 821, SHA256: "f708ba43719585c666ad949768ee3b7e28203505e472ce930aaea6b6fe04efc7"},
+	"include/saucer/modules/stable/webkitgtk.hpp":    {Size: 808, SHA256: "28758816c06c649c9e8adc8db8a9d3c70b70620a387de116a569c5076a13ce3f"},
+	"include/saucer/modules/stable/webview2.hpp":     {Size: 945, SHA256: "29f36d734bc721f2627d28cd5d5461516066bb8fba066ff08e7c3aa006cd5741"},
+	"include/saucer/navigation.hpp":                  {Size: 506, SHA256: "bd1628abd6d6aa963b691e5c9fbc4faf23648ecca055d068db3cbf98cef1f30f"},
+	"include/saucer/permission.hpp":                  {Size: 958, SHA256: "5068d139c580cfaf2090ed355179503884aa40a5da2a363d5ac4ace04acfb8da"},
+	"include/saucer/scheme.hpp":                      {Size: 1883, SHA256: "03ca8c858a13f4415380e9ab12773b18dc71170c46cd5cdbb6fac6461e0457d8"},
+	"include/saucer/script.hpp":                      {Size: 449, SHA256: "c58cbc17b655241a566e6e2c964b2e01eaf2395c79ec2b30a434c617dafb790a"},
+	"include/saucer/serializers/data.hpp":            {Size: 382, SHA256: "4e385420097037bfe31ae537db2fe1996cec01673c3bb304b492f06ba967cafd"},
+	"include/saucer/serializers/format/args.hpp":     {Size: 262, SHA256: "4120b351d1a0ffb3c3c631317d86a4d6f74eda00fa5e9b0d1ffd7452c2c69980"},
+	"include/saucer/serializers/format/args.inl":     {Size: 231, SHA256: "e6173e73fdc49f4499851014c183749fc3a9764ac417e44ffa5bf569bbb13e46"},
+	"include/saucer/serializers/format/unquoted.hpp": {Size: 227, SHA256: "651b378389cdace8266f3d249f5406caaf738a551cc84f085fc37a5ed0de4b05"},
+	"include/saucer/serializers/format/unquoted.inl": {Size: 393, SHA256: "01fbc8580870d1d52cb5ddc2f181fcccbd2c6dc821dd7eaddfd2f38d781d58ba"},
+	"include/saucer/serializers/glaze/glaze.hpp":     {Size: 1307, SHA256: "58f22d0192d5da720c1fc85503d68e1e779c63ff53f24b37828a6e22edd93bd9"},
+	"include/saucer/serializers/glaze/glaze.inl":     {Size: 2566, SHA256: "2198262f5497ec2679344a7b917ca771b4cdf8fb6810470424cbeae53a4dc00e"},
+	"include/saucer/serializers/rflpp/rflpp.hpp":     {Size: 1390, SHA256: "0c1491652024549defb4fa0cd9c246c59d7bce92cdb2f585ec52e966f52714dc"},
+	"include/saucer/serializers/rflpp/rflpp.inl":     {Size: 2000, SHA256: "273d775a3a8b7152f1ad02f274f21b148d84747611d0d55b66e26814d226bbc6"},
+	"include/saucer/serializers/serializer.hpp":      {Size: 2735, SHA256: "93ac60a6563b73ea6f4322822d896160c650bc46de23be1d1caf29f48d15c64f"},
+	"include/saucer/serializers/serializer.inl":      {Size: 7612, SHA256: "d8f3df50c9130757e16047d23e167e7e8319863235918d88e3a4a7922e74eaed"},
+	"include/saucer/smartview.hpp":                   {Size: 1721, SHA256: "5e24437c10fc654adc7d23f63a8169d5fdfbb81966d93ad6841b02cb216a8a2c"},
+	"include/saucer/smartview.inl":                   {Size: 1836, SHA256: "f368e46231114dd4fe2f6d19a564e5dae155033344c75e1a339eaf5cb05f71da"},
+	"include/saucer/stash/stash.hpp":                 {Size: 1670, SHA256: "4eb6d348247d960782648b247c0bb8d0d8791ef030d1d97e7704b9162ceaecda"},
+	"include/saucer/stash/stash.inl":                 {Size: 3281, SHA256: "ef18cf1ecaa8a59c18686048cd2aa6a317215ec02b06c0d7c7052b38789d5b05"},
+	"include/saucer/traits/traits.hpp":               {Size: 344, SHA256: "a0b8c004316313f336bc9587a5b5b48fc896430ef88c9a2625c02483793b2b73"},
+	"include/saucer/traits/traits.inl":               {Size: 9696, SHA256: "e0b210b540e8aaf363fae26a4e5c323e61df3bc4dd98854c1e84c7439a497671"},
+	"include/saucer/url.hpp":                         {Size: 1760, SHA256: "af582191136c93f8f129e5f7123da2aff6d706c7001adb5df249f3e9dc742317"},
+	"include/saucer/utils/cstring.hpp":               {Size: 2302, SHA256: "fe286f0ac1ec82aeec1b52ccb53cc94590051a17eec98ac7494cf9a234341aad"},
+	"include/saucer/utils/cstring.inl":               {Size: 3468, SHA256: "0fd342040f2e2ea97d3a09955593d93454348ea18dac8e54f95d23e354a8c9e7"},
+	"include/saucer/utils/overload.hpp":              {Size: 131, SHA256: "4648c90cfd525823a6a6aead1ab31a6de884973018101abac643268cef9d195e"},
+	"include/saucer/utils/overload.inl":              {Size: 276, SHA256: "82707ed38ce07a4e68c6a0217265f1c4519f31a1c4e9f7ee225f7ab4435abd67"},
+	"include/saucer/utils/required.hpp":              {Size: 478, SHA256: "377eb1615279c73b164585166cb9610ff5f45f4ea188e71e32f6d8d28da2aaad"},
+	"include/saucer/utils/required.inl":              {Size: 693, SHA256: "e0fdfa19a2cdcc0b936f2875459c87a41b141074e8df69a03c4cafd594476509"},
+	"include/saucer/utils/tuple.hpp":                 {Size: 647, SHA256: "778350eac08f467668da06f10054941f3013525a55226bc67dd4ab658c7b1582"},
+	"include/saucer/utils/tuple.inl":                 {Size: 1302, SHA256: "f54b79cd63f4fba8aee4946063d2707869d2b55ddbb9ead2b61305214e9fdc5d"},
+	"include/saucer/webview.hpp":                     {Size: 6598, SHA256: "0c60091f509de1818e564d702a837f543ef1f8ca18437b5a46e60ef0de7c71ad"},
+	"include/saucer/webview.inl":                     {Size: 1003, SHA256: "3bb14df58fe07c59406f8026f36102bf8aa7f9263cb568e67e64eedb75fdf17b"},
+	"include/saucer/window.hpp":                      {Size: 5333, SHA256: "01ec2ebd93ca5d5247c7fca663dc8eb6cd81ef5e0e2271bdee400e33a01448ec"},
+	"include/saucer/window.inl":                      {Size: 673, SHA256: "9688a853c6a3109538111e4549ddd2a591d1263785e38767912d6409527308a6"},
+	"private/saucer/app.impl.hpp":                    {Size: 652, SHA256: "88928b040b5f29cb27fbc528ea8efa74caf121805d4ba130f800dc63d616c997"},
+	"private/saucer/cocoa.app.impl.hpp":              {Size: 534, SHA256: "7fb30246ff4880de4035c781bb176d4e9295d58f876f4e826c6bbdace750baf7"},
+	"private/saucer/cocoa.icon.impl.hpp":             {Size: 210, SHA256: "3bf1db455a779568175011ed905e2ed4ec24c3f8b5591749cc2b439ed93465f3"},
+	"private/saucer/cocoa.utils.hpp":                 {Size: 684, SHA256: "a1f2b5a587268865e194f852e8b42fff83ba16b7a4c13b1394844ea5b33befbb"},
+	"private/saucer/cocoa.utils.inl":                 {Size: 612, SHA256: "e26c45c5227c65f06644b937842e5bc91de1499b653ca8548e71b8d6f776a4ca"},
+	"private/saucer/cocoa.window.impl.hpp":           {Size: 1739, SHA256: "e604be5264fad3f5f804c6f28709f5ac7b142d4e1be210dd33d5901a14030adb"},
+	"private/saucer/error.impl.hpp":                  {Size: 1241, SHA256: "af9d729db9429ef0a2ecda1ec378e7e35c5fe65ffffe3ed266279b18a4e46546"},
+	"private/saucer/gtk.app.impl.hpp":                {Size: 586, SHA256: "d75a4fe4acd1db25a37e0c5f06b9e462485f4b1700d6b176de2709322c389dae"},
+	"private/saucer/gtk.error.hpp":                   {Size: 504, SHA256: "005eacbbb320f30b70fbe73b9a4f09e5fb1a4b8849da0843462b879838621df9"},
+	"private/saucer/gtk.icon.impl.hpp":               {Size: 215, SHA256: "292bf7fe32233695800aa8dbd6e261c80741414d68e1af1bb4f6ffaed25756f1"},
+	"private/saucer/gtk.utils.hpp":                   {Size: 768, SHA256: "2d089f4d48f93b904552dfced7a48694e3884825ed4a3599170a20d50a31e69b"},
+	"private/saucer/gtk.utils.inl":                   {Size: 400, SHA256: "9dfca04e9c0b9737a85fe973d9f9f7d9f20ebf47b5a8d7ce5d1f5cc95ab98aad"},
+	"private/saucer/gtk.window.impl.hpp":             {Size: 1768, SHA256: "4733ef421dec0f2d10975bf7f98d3b40bf44f3b2108894b45e5aabf02300c7c9"},
+	"private/saucer/handle.hpp":                      {Size: 530, SHA256: "f649f08a6851b3de5e707b7f322446a54038f541882cbb824933f2332787ba37"},
+	"private/saucer/handle.inl":                      {Size: 1302, SHA256: "5d8418b797bee8b9e15e483ef6081b1fa59f1f845afa932a0e594c3c35338b88"},
+	"private/saucer/instantiate.hpp":                 {Size: 2131, SHA256: "bf771ffd90b4e0afeb4dcf3f24c62fe006a3242d70f0348dc602631bed5534fa"},
+	"private/saucer/invoke.hpp":                      {Size: 484, SHA256: "45ea3030ae1793bb9b0435e8dd714aa8e5816f128153fe3f1bbbadbcb1d89dd1"},
+	"private/saucer/invoke.inl":                      {Size: 1820, SHA256: "94934dafd2fa2b31c060e414d34837b9bc41ca29544fb834db5cc791ba4f498f"},
+	"private/saucer/lease.hpp":                       {Size: 1393, SHA256: "6eaed1bd1d7548d6da54f94e0daccd260e9ff4da278f773b0901eed6e0168543"},
+	"private/saucer/lease.inl":                       {Size: 2366, SHA256: "1d646027532c54fad9867112bd4d7221e4bfee147fa27c22626a387e697c7318"},
+	"private/saucer/qt.app.impl.hpp":                 {Size: 727, SHA256: "bc51fb48e2468edcaf845d7e8fc715a63134ddae2e40ab2c9050be03476ce730"},
+	"private/saucer/qt.error.hpp":                    {Size: 419, SHA256: "bb5a1615fcbcb1707cc1472ba828579d7aac4e4c81b91a2a6dcb698d9485a070"},
+	"private/saucer/qt.icon.impl.hpp":                {Size: 232, SHA256: "5ae9da291a7073ce3a8a7ed80948068355bf9cd220d8a593ac2def0cff6a5885"},
+	"private/saucer/qt.navigation.impl.hpp":          {Size: 319, SHA256: "7dba366f5d52a4f6babcd15f53bb13352870ff56baeddf4ff1387a9f8ea23774"},
+	"private/saucer/qt.permission.impl.hpp":          {Size: 309, SHA256: "1d122af4aa296a468460cdee195f947aa3433ee44c4bf30c4bf72fd495ae82c2"},
+	"private/saucer/qt.scheme.impl.hpp":              {Size: 1195, SHA256: "2c79da11af4d8ed09804bd93282653bb9320e64bc0cd8577e1118af8e693881b"},
+	"private/saucer/qt.url.impl.hpp":                 {Size: 151, SHA256: "9fed1e232085a709e128de08101927ce64adaeeeeead23f4da329555d212b25e"},
+	"private/saucer/qt.utils.hpp":                    {Size: 385, SHA256: "ef4399a8cc027d3a0d85a8823712035f086655a5390cd3dd34a6b81dfdb86196"},
+	"private/saucer/qt.utils.inl":                    {Size: 511, SHA256: "590b241ad8b7804c9a45271f249898a0ae2f308adbd4e3c641d9a9ce40113bfc"},
+	"private/saucer/qt.webview.impl.hpp":             {Size: 2528, SHA256: "83db2beb1496e522c6ea1cab67f18b3c403fa64ffda92e0d0e6cd923cfcfaa87"},
+	"private/saucer/qt.window.impl.hpp":              {Size: 1578, SHA256: "ab3ad2be5f000948a5951c63092f0c0a77947a34d7166f7093996195b6571e0d"},
+	"private/saucer/ref_obj.hpp":                     {Size: 910, SHA256: "60e632903f4f72805966c34ac14b155201571c7caa49c1d84093ce3375ac0d0d"},
+	"private/saucer/ref_obj.inl":                     {Size: 2301, SHA256: "ed53258a4d779a3d21ed29447528fb1e2b7f9c8029ec7c271049b30e7c938df6"},
+	"private/saucer/request.hpp":                     {Size: 856, SHA256: "a9e711c5ba1e7f4dfe2348abf4585adbd2bc63020c47b79b5f1a51e343c04859"},
+	"private/saucer/request.utils.hpp":               {Size: 852, SHA256: "90b2b7bb15521368335d38cc8110b45af73b0a525ade93329616604e41200606"},
+	"private/saucer/request.utils.inl":               {Size: 1152, SHA256: "20182d6d6fa6254a805dea07b3f12eb41f637039d236645cc682b4dae9c584a8"},
+	"private/saucer/scripts.hpp":                     {Size: 4031, SHA256: "21746cb3dac93c1fcb7e40def86dbcc76da237dbdbf660e3b3941f254eeabc79"},
+	"private/saucer/webview.impl.hpp":                {Size: 2451, SHA256: "9d378ede25a2a5c5e057a9dbf7803468b59bee0e9a717075b0e70fe7d264d1b3"},
+	"private/saucer/win32.app.impl.hpp":              {Size: 1297, SHA256: "e4fa01f5ed2493af2675f664fe6c42febf3a9a8e43f25146ee554a9b7db728c5"},
+	"private/saucer/win32.error.hpp":                 {Size: 680, SHA256: "85d55f0eaca93dd0910b07a1e726897e56894e1e40a4ab59ed7c0fb4a1cfc2ac"},
+	"private/saucer/win32.icon.impl.hpp":             {Size: 210, SHA256: "57b622fb70382df845693215dd5e92731c484a11c6c8fc205ffb0f586155d271"},
+	"private/saucer/win32.utils.hpp":                 {Size: 2152, SHA256: "b7f069e8a52ea751ff52f4a47e7ec725ce8e9dbdbaea2ea873e37b0c34c68e8b"},
+	"private/saucer/win32.window.impl.hpp":           {Size: 1477, SHA256: "d7474a5a649b682aaf9a1653342346fb67f96f08c4a76b46aea8e9ebf74ff288"},
+	"private/saucer/window.impl.hpp":                 {Size: 2237, SHA256: "bbd84259678e46574ae9476528a6ed19bd6daa2c37e2d1d3ccdafeb92d6acef2"},
+	"private/saucer/wk.navigation.impl.hpp":          {Size: 237, SHA256: "42875462362f1135d2355fd28e3ff778f79a79362bf8761db8edc572156a3e4c"},
+	"private/saucer/wk.permission.impl.hpp":          {Size: 397, SHA256: "d49369de8e1f2414344293f58742876b4efc31b44c66284210be717ae04d0383"},
+	"private/saucer/wk.scheme.impl.hpp":              {Size: 1163, SHA256: "feb90c88aa45503ca3858270b5b66d67875f96083e0854fddd327ebba302cbf9"},
+	"private/saucer/wk.url.impl.hpp":                 {Size: 207, SHA256: "7c06d9818d2396231d0f4eb6ff6fc9c5551b27c4d5248df784eadfc863f1e51b"},
+	"private/saucer/wk.webview.impl.hpp":             {Size: 2151, SHA256: "562b615fc4a368f44b858b344667ed627bfb779c8f88d04e42e8484e9057fccb"},
+	"private/saucer/wkg.navigation.impl.hpp":         {Size: 247, SHA256: "1dbb674faeed2e03950a320b015349a87fbee2d978572929d6dd83faf8330d5a"},
+	"private/saucer/wkg.permission.impl.hpp":         {Size: 338, SHA256: "cc0cd60afe91c4473ecbe6fe989707a2e608072f2c8d1135efe9e01dcd58904b"},
+	"private/saucer/wkg.scheme.impl.hpp":             {Size: 1152, SHA256: "ccb73e7f80e9a2f97c7f4e9a5008a2d25a7d5eabd7ef6344c055b92350c721a0"},
+	"private/saucer/wkg.url.impl.hpp":                {Size: 217, SHA256: "0fc2d53cb7c501153cfe6fb1e572df5491cf7f58f3ff71dd0ad456cd26299e7e"},
+	"private/saucer/wkg.webview.impl.hpp":            {Size: 1959, SHA256: "96909afe7429935dbcd39109b8378523248fe90a9578e2669557ce10b0fa34fc"},
+	"private/saucer/wv2.navigation.impl.hpp":         {Size: 291, SHA256: "be9875cef6ef155062ab6dd719db1999c4893535964418470c0a87962378e696"},
+	"private/saucer/wv2.permission.impl.hpp":         {Size: 341, SHA256: "6c958f33571b5b21420abe05cab1c2afb369d14b36498f3684878174a05a7cc7"},
+	"private/saucer/wv2.scheme.impl.hpp":             {Size: 2116, SHA256: "58924225dbc5301c84e20acd072472d42b4411681df51e4e2709a025969c7f6e"},
+	"private/saucer/wv2.url.impl.hpp":                {Size: 221, SHA256: "aac11249c5e50dc735eb9de850f087d4fd25d0dff26a17b4417a9a237aab5161"},
+	"private/saucer/wv2.webview.impl.hpp":            {Size: 4242, SHA256: "3359e241f624e3b4786a0ee1fd53a920defd71bfa2b5ca06f4061fe038d0d073"},
+	"src/app.cpp":                                    {Size: 2555, SHA256: "2b82f4e4077fd4170d8e5a8b5ff03c5696e3773db350903dd2bb2ce1d22576f8"},
+	"src/cocoa.app.impl.mm":                          {Size: 3050, SHA256: "053bf7fb97a814b753c03ae2fccf10c71d7d1f38697948947c9947fb041a6ed9"},
+	"src/cocoa.app.mm":                               {Size: 2050, SHA256: "0c84f71e44f47f9332a65ede91aa3f4a732e8f1558406c73875a51bf11b9b354"},
+	"src/cocoa.icon.mm":                              {Size: 2536, SHA256: "01116342bc0b17b2516275461cec3865ec304426b600d71a4aee9c3c4ee5cf73"},
+	"src/cocoa.utils.mm":                             {Size: 828, SHA256: "3ca327d8a769aa4a0917da020d1ef1c9fa9078d058e3eafc31b7c09591916737"},
+	"src/cocoa.window.impl.mm":                       {Size: 6342, SHA256: "f1107b0dc10865e5b8a943819817380d71fff1d92ce63bf678e03ec9f2b37950"},
+	"src/cocoa.window.mm":                            {Size: 11200, SHA256: "f8fe22c167abe74cca7ac39a447d5a4319d6dd0733af21d703563ccdd57aa741"},
+	"src/error.cpp":                                  {Size: 847, SHA256: "7bc237f7c8b6d98979e2003e18ca3d44895305d3626e77078f0cd1bace450c7e"},
+	"src/error.impl.cpp":                             {Size: 1437, SHA256: "70f32fff6ba92186ad42354053e817253999ac464affc12abe67f49303098ac7"},
+	"src/glaze.request.cpp":                          {Size: 1055, SHA256: "bedc38b8ae4d7d059bff21485b717e73d0c36dcab3f10f7888602a4382ae05df"},
+	"src/glaze.serializer.cpp":                       {Size: 1972, SHA256: "6721bfdb18d67ccd8740cbffe9220b6aa2ce34889f1100010a8d1e47aa5aed42"},
+	"src/gtk.app.cpp":                                {Size: 2914, SHA256: "bc4ec6e971da4069c08ecc0bab4d68a26957d151b184bfcbe5074f6f6c335399"},
+	"src/gtk.app.impl.cpp":                           {Size: 1010, SHA256: "90b6595cd447a8254a1f61f512e62495dd612358d1c41b058c2754b39becd5e5"},
+	"src/gtk.error.cpp":                              {Size: 581, SHA256: "f74c175df53b41e68f88ee2e16c496a9ce50362d610e76aa2e20d90e5cd7bf8d"},
+	"src/gtk.icon.cpp":                               {Size: 2136, SHA256: "ff9c70ee6615ae94bcb7252df30c87a9b4648393f061f3bf929c38ed31b890ed"},
+	"src/gtk.window.cpp":                             {Size: 9394, SHA256: "6b7c97e75c1ec81aa9f85bc14153bf7c89a484ba83db02c5b4729a5742abd137"},
+	"src/gtk.window.impl.cpp":                        {Size: 7923, SHA256: "4a1fe17760589b5144320ad79cbf8496a0fbc604e1a0102da642463227257a55"},
+	"src/module/qt.module.stable.cpp":                {Size: 1096, SHA256: "9fb75132f9f6fa7b70d8660d3746c51bc88a3386e583cb26ba752add09875edd"},
+	"src/module/unstable.cpp":                        {Size: 823, SHA256: "a420929813024586b749a593c74760bc69b1aede61bd2c377c7e2e8adcd29350"},
+	"src/module/wk.module.stable.mm":                 {Size: 1133, SHA256: "7962c1a890c697c7ff8a5703c4b5415d18857de93ad64517ebb5578dde0bed21"},
+	"src/module/wkg.module.stable.cpp":               {Size: 1124, SHA256: "88f3a7f1396d969e24575342daffcc67c0fea3081c735861150b486eb752bdb0"},
+	"src/module/wv2.module.stable.cpp":               {Size: 1200, SHA256: "988744cbf29491a8033da6c4245dfc74a85b8c63bc9ad62e023652a9c8afdf1a"},
+	"src/qt.app.cpp":                                 {Size: 1742, SHA256: "0317537c492e190ecc20cbe04dd954d68e226b7f36108e80a2fd3a089861ffa6"},
+	"src/qt.app.impl.cpp":                            {Size: 696, SHA256: "63a681ac21dd5314f8b625e5050655e86dbc36695c133b755d628957e0c3c753"},
+	"src/qt.error.cpp":                               {Size: 418, SHA256: "46ff622ed2c8d35661d54149b898c37d150f955ff3ddcc24cd15d00acee2a285"},
+	"src/qt.icon.cpp":                                {Size: 1828, SHA256: "424f71c767c1298581009dc21b80a26fa64663dca6c0c45a8c568e5ec2b3a619"},
+	"src/qt.icon.impl.cpp":                           {Size: 533, SHA256: "0de3944a0d850809e1690b8f4155325804192d8fa90570c9e6fa8496db148237"},
+	"src/qt.navigation.cpp":                          {Size: 1541, SHA256: "19df7d8c4c127bf424784baa23ff5e6ab0a2c0579f2081bab98bac33de892516"},
+	"src/qt.permission.cpp":                          {Size: 1613, SHA256: "744c94e900fa09b12459e3900f3759417ba0f4dea7853d4118cb269882a32267"},
+	"src/qt.scheme.cpp":                              {Size: 10213, SHA256: "acbc60d5d1d8b8d99825e917a0baf1271b6d0a30dd6553a5e2de94db0886e15f"},
+	"src/qt.url.cpp":                                 {Size: 3140, SHA256: "bb794d18417ede7eeb0d9319f3506d7f7dd6e7dd5adae749d3ca339da2d5b2ce"},
+	"src/qt.webview.cpp":                             {Size: 15382, SHA256: "e2d2d687b8f62278fe741127cb4f60472cc03a4d9f37524db388b95c860f32b5"},
+	"src/qt.webview.impl.cpp":                        {Size: 6372, SHA256: "2db97601643d96272930c5a5d8316c6614876aca8564da8e718751ac561a47f5"},
+	"src/qt.window.cpp":                              {Size: 8358, SHA256: "99b4b836c00e56192ba54c1c8af074a43a20e97e6c9c9edda9820f913b81de35"},
+	"src/qt.window.impl.cpp":                         {Size: 3696, SHA256: "095651a197754f8d17824ff8bb6f10de6b44b132927c669a53e47e0cfedf91c1"},
+	"src/request.cpp":                                {Size: 1853, SHA256: "195e623e5d21449e01266dcfe8afe4ab33fc6b555f1a8ecf0ee97e3d3b471907"},
+	"src/rfl.request.cpp":                            {Size: 2529, SHA256: "4d0d2c8c1afa5ebf53e1933a10cd8f3ee5aa9f62e5c3e107b6c8174771fba5ac"},
+	"src/rfl.serializer.cpp":                         {Size: 2204, SHA256: "369095586a02664cab3f3b0a8ed7fac73236353af7f081cd352a47f1c689ffd8"},
+	"src/smartview.cpp":                              {Size: 4564, SHA256: "4def476e752d68042a614d4f3f598d84a002d0e97acb664b1780d39ad67fc290"},
+	"src/webview.cpp":                                {Size: 8759, SHA256: "0045eeea5998395c2e7abc1059b9e289cfc13d16af93814070126a28da676ee7"},
+	"src/webview.impl.cpp":                           {Size: 1386, SHA256: "845b9177831399298eee8f67fb69cabb5da92cb9b15926dd99ad0c957879883b"},
+	"src/win32.app.cpp":                              {Size: 3449, SHA256: "4351960b05266f0f14519a92859de905e48aaf11a36ca682ba17ee332d49b4b2"},
+	"src/win32.app.impl.cpp":                         {Size: 1658, SHA256: "4d6bd83d1d19fa19c93893f000bec5d734c507e744fd23e6e8710d8940aa90ec"},
+	"src/win32.error.cpp":                            {Size: 2318, SHA256: "492600cbde026b751b12e651071b14f08f66bc1ec96709a32f6fd97ceceaa142"},
+	"src/win32.icon.cpp":                             {Size: 2610, SHA256: "660a1738b1d77956258c219add244058fd38cc8874638108691344b34683c868"},
+	"src/win32.utils.cpp":                            {Size: 6936, SHA256: "943ea7999f62444a836c4a08ea9e1035574383f26b39a15f509c10d7178cf234"},
+	"src/win32.window.cpp":                           {Size: 12773, SHA256: "c1ec0dcae74244018a5ea42e432c2324262feeea6918c057af42ee48c6e21ef7"},
+	"src/win32.window.impl.cpp":                      {Size: 8548, SHA256: "ce1a30875a0de954f0d7dbf0fd1d82c2818e451ce6af1a7036214d1f45374625"},
+	"src/window.cpp":                                 {Size: 5756, SHA256: "a36738ed4e17b050a6811ecd2f159f16bb8f9b0bf260fa457d31e429cac69910"},
+	"src/wk.navigation.mm":                           {Size: 1220, SHA256: "8d7891fba98876f315c9aadd5c5e22e997196e5f6016fe2ee101ed06bef8c1b7"},
+	"src/wk.permission.mm":                           {Size: 1235, SHA256: "af2fcca6242fc790512d489dac64296f77f7b5418898290b5574e2fdb81f9608"},
+	"src/wk.scheme.impl.mm":                          {Size: 8497, SHA256: "ca48164e837996ca45e1f3fc5c59a912ce48e126c1e32dac1166e0887b01a1a4"},
+	"src/wk.scheme.mm":                               {Size: 1340, SHA256: "7054ca992f76cfec7c8c95fe9aea1ab62dcf85f9f92a8b9dfa1de499e3d6fdb8"},
+	"src/wk.url.mm":                                  {Size: 4066, SHA256: "e4d920ab0807b994a6cc47f891a614d0d2cce8d0ecb1b6e04e03187153d31221"},
+	"src/wk.webview.impl.mm":                         {Size: 12478, SHA256: "c9b81a65a0f9c933cf76577ba567940e9e15e01784aa59ade3d45d71d6154ce8"},
+	"src/wk.webview.mm":                              {Size: 13856, SHA256: "34094077e171d8490fda16f9d44cc1f8e2cc0a6ed9e8002ac2a7272cffbb03ed"},
+	"src/wkg.navigation.cpp":                         {Size: 1114, SHA256: "99c53ab9488185d9bf566defe816b3af57b8a43a67177a577fad3a12ed38cc2c"},
+	"src/wkg.permission.cpp":                         {Size: 679, SHA256: "708ed1b3017aa73739a5b2f0b2fc22fdb8bdd13e488e6d587c417a69182b5154"},
+	"src/wkg.scheme.cpp":                             {Size: 1863, SHA256: "c1fa197d8bef76b1bd8cf225a58564a93cdfaaecb1f8dd3178e6a32e1706139c"},
+	"src/wkg.scheme.impl.cpp":                        {Size: 6905, SHA256: "540db9ce6f0a3719bcc44dd0602384c63635e1e20570af5d35ec8ba79ccabb1d"},
+	"src/wkg.url.cpp":                                {Size: 3967, SHA256: "9c452946e207e21edbd3feecbfb93a6a52270047d1aa8bc41d52c82144b0c579"},
+	"src/wkg.webview.cpp":                            {Size: 14555, SHA256: "861d619c65100ec08706551d239646ed8dca7b90594f0932dc9e56ecc8c2aee7"},
+	"src/wkg.webview.impl.cpp":                       {Size: 11518, SHA256: "a67baeb251043e9fd44ee6041f68c7b1fec31ef6ed886a8c3b55700a6d037d44"},
+	"src/wv2.navigation.cpp":                         {Size: 1682, SHA256: "94e0a266ca7ceda7ef84ece695b061d0d6c9f5edbd2104c250a3d2f4dbdfece0"},
+	"src/wv2.permission.cpp":                         {Size: 1617, SHA256: "6275af81cdc6f65cc6af2984f6f6cb99a845d1cac60cb7387e88ab1ab3a75673"},
+	"src/wv2.scheme.cpp":                             {Size: 7345, SHA256: "cb31db445bf65e3b35f4f9c5a4f60a74dce7edd31b4c6296ca62c6b5444863c9"},
+	"src/wv2.url.cpp":                                {Size: 4621, SHA256: "fc1c489518e92c8e756193075ff8f436cbf7a9ecef1d6a11b70e5f0f1221aa5a"},
+	"src/wv2.webview.cpp":                            {Size: 17888, SHA256: "f3d234f1b5050dc34af2eaad13048f7d5287b639c80867f42a7cec9fed491b9f"},
+	"src/wv2.webview.impl.cpp":                       {Size: 15053, SHA256: "03d0efff7abc409f5522a02c521f677468d39e3f81fd596d27c5c31ab90366da"},
+	"template/config.hpp.in":                         {Size: 110, SHA256: "88706b74ab054b486a0ce61d181cbbd5cbffab28681a2494e4add5541b900a46"},
+}